@@ -0,0 +1,243 @@
+// Package main provides the audit trail tool for the GoEdu Control Testing Platform.
+// It verifies the tamper-evident audit hash chain directly against the database and
+// exports signed checkpoints to a file that can be handed to an external notary.
+//
+// Usage:
+//
+//	audit verify [-org <organization id>]
+//	audit export -org <organization id> [-out <file>]
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/auditchain"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/database"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/logger"
+)
+
+// main is the entry point for the audit tool.
+// It exits with status 2 when a chain fails verification.
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+
+	command := os.Args[1]
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	orgID := flags.String("org", "", "organization ID (hex); verify checks all organizations when empty")
+	out := flags.String("out", "", "output file for export (defaults to stdout)")
+	flags.Parse(os.Args[2:])
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("Failed to load configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Initialize logger
+	log, err := logger.New(&cfg.Logger)
+	if err != nil {
+		fmt.Printf("Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+
+	var signingKey ed25519.PrivateKey
+	if cfg.Audit.CheckpointSigningKey != "" {
+		signingKey, err = auditchain.ParsePrivateKey(cfg.Audit.CheckpointSigningKey)
+		if err != nil {
+			log.Error(ctx, "Invalid audit checkpoint signing key", err)
+			os.Exit(1)
+		}
+	}
+
+	// Connect to database
+	dbClient, err := database.NewClient(&cfg.Database, log)
+	if err != nil {
+		log.Error(ctx, "Failed to connect to database", err)
+		os.Exit(1)
+	}
+	defer dbClient.Close(ctx)
+
+	switch command {
+	case "verify":
+		valid, err := runVerify(ctx, dbClient, *orgID, publicKeyOf(signingKey))
+		if err != nil {
+			log.Error(ctx, "Audit chain verification failed", err)
+			os.Exit(1)
+		}
+		if !valid {
+			os.Exit(2)
+		}
+	case "export":
+		if *orgID == "" || signingKey == nil {
+			fmt.Println("export requires -org and a configured checkpoint signing key")
+			os.Exit(1)
+		}
+		if err := runExport(ctx, dbClient, *orgID, cfg.Audit.CheckpointKeyID, publicKeyOf(signingKey), *out); err != nil {
+			log.Error(ctx, "Audit checkpoint export failed", err)
+			os.Exit(1)
+		}
+	default:
+		usage()
+		os.Exit(1)
+	}
+}
+
+// usage prints the command line help.
+func usage() {
+	fmt.Println("Usage:")
+	fmt.Println("  audit verify [-org <organization id>]")
+	fmt.Println("  audit export -org <organization id> [-out <file>]")
+}
+
+// runVerify verifies the chain of one organization, or of every organization
+// with audit entries, and prints one JSON result per organization.
+//
+// Parameters:
+//   - ctx: Context for database operations
+//   - db: Database client
+//   - orgID: Organization to verify, empty for all
+//   - publicKey: Checkpoint verification key, nil skips signature checks
+//
+// Returns:
+//   - bool: True if all verified chains are intact
+//   - error: Database error
+func runVerify(ctx context.Context, db *database.Client, orgID string, publicKey ed25519.PublicKey) (bool, error) {
+	orgIDs := []string{orgID}
+	if orgID == "" {
+		ids, err := db.Collection("audit_logs").Distinct(ctx, "organization_id", bson.D{{Key: "sequence", Value: bson.D{{Key: "$gt", Value: 0}}}})
+		if err != nil {
+			return false, fmt.Errorf("failed to list organizations: %w", err)
+		}
+		orgIDs = orgIDs[:0]
+		for _, id := range ids {
+			if oid, ok := id.(primitive.ObjectID); ok {
+				orgIDs = append(orgIDs, oid.Hex())
+			}
+		}
+	}
+
+	allValid := true
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	for _, id := range orgIDs {
+		result, err := verifyOrganization(ctx, db, id, publicKey)
+		if err != nil {
+			return false, err
+		}
+		allValid = allValid && result.Valid
+		if err := encoder.Encode(result); err != nil {
+			return false, err
+		}
+	}
+	return allValid, nil
+}
+
+// verifyOrganization streams an organization's chain from the database through the verifier.
+func verifyOrganization(ctx context.Context, db *database.Client, orgID string, publicKey ed25519.PublicKey) (*services.ChainVerificationResult, error) {
+	oid, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid organization ID %q: %w", orgID, err)
+	}
+
+	checkpoints, err := loadCheckpoints(ctx, db, oid)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := db.Collection("audit_logs").Find(ctx,
+		bson.D{{Key: "organization_id", Value: oid}, {Key: "sequence", Value: bson.D{{Key: "$gt", Value: 0}}}},
+		options.Find().SetSort(bson.D{{Key: "sequence", Value: 1}}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit chain: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	verifier := services.NewAuditChainVerifier(orgID, checkpoints, publicKey)
+	for cursor.Next(ctx) {
+		var entry models.AuditLog
+		if err := cursor.Decode(&entry); err != nil {
+			return nil, fmt.Errorf("failed to decode audit entry: %w", err)
+		}
+		if !verifier.Verify(&entry) {
+			break
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit chain: %w", err)
+	}
+
+	return verifier.Result(), nil
+}
+
+// runExport writes the notary export file of an organization.
+func runExport(ctx context.Context, db *database.Client, orgID, keyID string, publicKey ed25519.PublicKey, out string) error {
+	oid, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return fmt.Errorf("invalid organization ID %q: %w", orgID, err)
+	}
+
+	checkpoints, err := loadCheckpoints(ctx, db, oid)
+	if err != nil {
+		return err
+	}
+
+	writer := os.Stdout
+	if out != "" {
+		file, err := os.Create(out)
+		if err != nil {
+			return fmt.Errorf("failed to create export file: %w", err)
+		}
+		defer file.Close()
+		writer = file
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(services.BuildNotaryExport(orgID, keyID, publicKey, checkpoints))
+}
+
+// loadCheckpoints reads all checkpoints of an organization ordered by sequence.
+func loadCheckpoints(ctx context.Context, db *database.Client, orgID primitive.ObjectID) ([]*models.AuditCheckpoint, error) {
+	cursor, err := db.Collection("audit_checkpoints").Find(ctx,
+		bson.D{{Key: "organization_id", Value: orgID}},
+		options.Find().SetSort(bson.D{{Key: "sequence", Value: 1}}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit checkpoints: %w", err)
+	}
+
+	var checkpoints []*models.AuditCheckpoint
+	if err := cursor.All(ctx, &checkpoints); err != nil {
+		return nil, fmt.Errorf("failed to decode audit checkpoints: %w", err)
+	}
+	return checkpoints, nil
+}
+
+// publicKeyOf returns the public half of a signing key, or nil.
+func publicKeyOf(key ed25519.PrivateKey) ed25519.PublicKey {
+	if key == nil {
+		return nil
+	}
+	return key.Public().(ed25519.PublicKey)
+}
@@ -15,6 +15,9 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/jobs"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
 	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/cache"
 	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/database"
 	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/logger"
@@ -28,6 +31,11 @@ type Application struct {
 	database *database.Client
 	cache    *cache.Client
 	server   *http.Server
+
+	// stopScheduler stops the scheduler started by startScheduler, which
+	// closes schedulerDone once its running jobs finished
+	stopScheduler context.CancelFunc
+	schedulerDone chan struct{}
 }
 
 // main is the application entry point.
@@ -144,6 +152,21 @@ func NewApplication(ctx context.Context) (*Application, error) {
 		return nil, fmt.Errorf("failed to setup HTTP server: %w", err)
 	}
 
+	// Scheduled jobs would start here, next to the services doing their work
+	// if cfg.Scheduler.Enabled {
+	// 	scheduler := services.NewSchedulerService(jobRepo, runRepo, services.NewRedisJobLocker(cacheClient), cfg.Scheduler, log)
+	// 	if err := app.startScheduler(scheduler, jobs.ScheduledServices{
+	// 		EvidenceLifecycle: lifecycleService,
+	// 		Retention:         retentionService,
+	// 		Organizations:     orgService,
+	// 		Digests:           digestService,
+	// 		AuditChain:        auditChainService,
+	// 		Directory:         ldapService,
+	// 	}); err != nil {
+	// 		return nil, fmt.Errorf("failed to start scheduler: %w", err)
+	// 	}
+	// }
+
 	log.Info("Application initialized successfully")
 	return app, nil
 }
@@ -165,6 +188,7 @@ func (app *Application) setupServer() error {
 	// Add middleware
 	router.Use(gin.Recovery())
 	router.Use(app.loggingMiddleware())
+	router.Use(middleware.RequestContext())
 	router.Use(app.corsMiddleware())
 
 	// Health check endpoint
@@ -178,10 +202,44 @@ func (app *Application) setupServer() error {
 		// Authentication routes would go here
 		// v1.POST("/auth/login", app.loginHandler)
 		// v1.POST("/auth/logout", app.logoutHandler)
-
-		// GraphQL endpoint would go here
-		// v1.POST("/graphql", app.graphqlHandler)
-		// v1.GET("/graphql", app.graphqlPlaygroundHandler)
+		// passwordChecker, err := services.NewPasswordChecker(app.config.Passwords)
+		// authService := services.NewAuthenticationService(orgRepo, userRepo, sessionRepo, app.cache, passwordResetRepo, notificationService, app.cache, jwtManager, auth.NewPasswordHasher(app.config.Auth.BCryptCost), passwordChecker, app.config.Passwords, app.config.Sessions, app.logger)
+
+		// OpenAPI validation would go here
+		// apiDoc, err := handlers.OpenAPIDocument()
+		// v1.Use(middleware.NewOpenAPIValidator(apiDoc, app.config.OpenAPI, app.logger).Validate())
+
+		// GraphQL server would go here
+		// graphServer, err := graph.NewServer(queryService, app.config.GraphQL)
+
+		// API routes would go here; the OpenAPI spec test registers the same
+		// routes, so every route is checked against openapi.json
+		// handlers.RegisterRoutes(v1, handlers.Services{
+		// 	Auth:  authService,
+		// 	SSO:   services.NewSSOService(orgRepo, userRepo, sessionRepo, app.cache, ssoStateRepo, jwtManager, app.config.Auth, app.config.SSO, app.config.Sessions, app.logger),
+		// 	SAML:  services.NewSAMLService(orgRepo, userRepo, sessionRepo, app.cache, ssoStateRepo, jwtManager, app.config.SSO, app.config.Sessions, app.logger),
+		// 	LDAP:  services.NewLDAPService(orgRepo, userRepo, sessionRepo, app.cache, jwtManager, authService, app.config.LDAP, app.config.Sessions, app.logger),
+		// 	Users: services.NewUserService(orgRepo, userRepo, invitationRepo, orgService, authService, notificationService, auth.NewPasswordHasher(app.config.Auth.BCryptCost), passwordChecker, app.config.Invitations, app.logger),
+		// 	APIKeys: apiKeyService,
+		// 	GraphQL: graphServer,
+		// 	// ...the remaining services
+		// }, app.logger,
+		// 	// Session and API key authentication, before the organization middleware
+		// 	middleware.NewSessionMiddleware(authService, app.logger).Authenticate(),
+		// 	middleware.NewAPIKeyMiddleware(apiKeyService, orgService, app.logger).Authenticate(),
+		// 	orgMiddleware.EnforceOrganizationContext(),
+		// 	// Rate limiting, after the organization middleware
+		// 	middleware.NewRateLimitMiddleware(app.cache, app.config.RateLimit, app.logger).Limit(),
+		// )
+
+		// SCIM would be served at /scim/v2, outside the API version, where
+		// identity providers expect it; only API keys with the scim scope are
+		// accepted there
+		// handlers.RegisterSCIMRoutes(router.Group(""),
+		// 	services.NewSCIMService(orgRepo, userRepo, scimGroupRepo, authService, app.logger), app.logger,
+		// 	middleware.NewAPIKeyMiddleware(apiKeyService, orgService, app.logger).RequireAPIKey(),
+		// 	middleware.NewRateLimitMiddleware(app.cache, app.config.RateLimit, app.logger).Limit(),
+		// )
 
 		// REST API endpoints would go here
 		// controls := v1.Group("/controls")
@@ -230,6 +288,33 @@ func (app *Application) Start(ctx context.Context) error {
 	return nil
 }
 
+// startScheduler registers the platform's periodic work with the scheduler
+// and runs it until Shutdown. Jobs without a schedule under
+// scheduler.schedules are not run.
+//
+// Parameters:
+//   - scheduler: Scheduler running the jobs
+//   - svc: Services doing the jobs' work
+//
+// Returns:
+//   - error: Error if a schedule is invalid
+func (app *Application) startScheduler(scheduler services.SchedulerService, svc jobs.ScheduledServices) error {
+	if err := jobs.RegisterScheduledJobs(scheduler, app.config.Scheduler.Schedules, svc); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	app.stopScheduler = cancel
+	app.schedulerDone = make(chan struct{})
+	go func() {
+		defer close(app.schedulerDone)
+		if err := scheduler.Start(ctx); err != nil {
+			app.logger.Error(ctx, "Scheduler failed", err)
+		}
+	}()
+	return nil
+}
+
 // WaitForShutdown waits for termination signals and begins graceful shutdown.
 // It listens for SIGINT and SIGTERM signals commonly used in containerized environments.
 func (app *Application) WaitForShutdown() {
@@ -262,6 +347,23 @@ func (app *Application) Shutdown(ctx context.Context) error {
 		return fmt.Errorf("HTTP server shutdown failed: %w", err)
 	}
 
+	// Stop the scheduler, waiting for running jobs
+	if app.stopScheduler != nil {
+		app.logger.Info("Stopping scheduler...")
+		app.stopScheduler()
+		select {
+		case <-app.schedulerDone:
+		case <-ctx.Done():
+			app.logger.Error(ctx, "Scheduler did not stop in time", ctx.Err())
+		}
+	}
+
+	// Password reset requests still being handled would be awaited here,
+	// before the cache and database connections close
+	// if err := authService.Close(ctx); err != nil {
+	// 	app.logger.Error(ctx, "Password reset requests did not finish", err)
+	// }
+
 	// Close cache connection
 	app.logger.Info("Closing cache connection...")
 	if err := app.cache.Close(); err != nil {
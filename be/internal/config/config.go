@@ -42,6 +42,48 @@ type Config struct {
 
 	// Monitoring and observability
 	Monitoring MonitoringConfig `mapstructure:"monitoring"`
+
+	// Workflow automation (evidence request lifecycle)
+	Workflow WorkflowConfig `mapstructure:"workflow"`
+
+	// Tamper-evident audit trail
+	Audit AuditConfig `mapstructure:"audit"`
+
+	// Data retention enforcement
+	Retention RetentionConfig `mapstructure:"retention"`
+
+	// Domain event bus (outbox relay and stream consumers)
+	Events EventsConfig `mapstructure:"events"`
+
+	// Scheduled jobs
+	Scheduler SchedulerConfig `mapstructure:"scheduler"`
+
+	// GraphQL API
+	GraphQL GraphQLConfig `mapstructure:"graphql"`
+
+	// OpenAPI request and response validation
+	OpenAPI OpenAPIConfig `mapstructure:"openapi"`
+
+	// API rate limiting
+	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+
+	// Organization API keys
+	APIKeys APIKeyConfig `mapstructure:"api_keys"`
+
+	// Single sign-on
+	SSO SSOConfig `mapstructure:"sso"`
+
+	// LDAP sign-in and directory sync
+	LDAP LDAPConfig `mapstructure:"ldap"`
+
+	// User invitations
+	Invitations InvitationConfig `mapstructure:"invitations"`
+
+	// Password dictionary and breached password checks
+	Passwords PasswordConfig `mapstructure:"passwords"`
+
+	// Session timeouts
+	Sessions SessionConfig `mapstructure:"sessions"`
 }
 
 // AppConfig contains basic application settings.
@@ -106,7 +148,10 @@ type AuthConfig struct {
 	JWTExpiration time.Duration `mapstructure:"jwt_expiration"`
 	BCryptCost    int           `mapstructure:"bcrypt_cost"`
 
-	// OAuth/OIDC settings for enterprise authentication
+	// OAuth/OIDC settings for enterprise authentication. OAuthRedirectURL is
+	// the SSO callback registered with every organization's provider; the
+	// other settings name a platform-wide provider for organizations that do
+	// not configure their own issuer.
 	OAuthProvider     string `mapstructure:"oauth_provider"`
 	OAuthClientID     string `mapstructure:"oauth_client_id"`
 	OAuthClientSecret string `mapstructure:"oauth_client_secret"`
@@ -114,24 +159,49 @@ type AuthConfig struct {
 }
 
 // EmailConfig contains email service settings for notifications.
+// Notification emails are queued in an outbox and delivered by a background
+// dispatcher; failed sends are retried with exponential backoff starting at
+// RetryDelay and capped at MaxRetryDelay until MaxAttempts is reached.
 type EmailConfig struct {
 	Provider string `mapstructure:"provider"`
 	APIKey   string `mapstructure:"api_key"`
 	From     string `mapstructure:"from"`
 
+	// Base URL of the web application used for links in emails
+	AppURL string `mapstructure:"app_url"`
+
 	// SMTP settings (alternative to API)
-	SMTPHost     string `mapstructure:"smtp_host"`
-	SMTPPort     int    `mapstructure:"smtp_port"`
-	SMTPUsername string `mapstructure:"smtp_username"`
-	SMTPPassword string `mapstructure:"smtp_password"`
+	SMTPHost     string        `mapstructure:"smtp_host"`
+	SMTPPort     int           `mapstructure:"smtp_port"`
+	SMTPUsername string        `mapstructure:"smtp_username"`
+	SMTPPassword string        `mapstructure:"smtp_password"`
+	SMTPTimeout  time.Duration `mapstructure:"smtp_timeout"`
+
+	// Outbox delivery settings
+	OutboxPollInterval time.Duration `mapstructure:"outbox_poll_interval"`
+	OutboxBatchSize    int           `mapstructure:"outbox_batch_size"`
+	MaxAttempts        int           `mapstructure:"max_attempts"`
+	RetryDelay         time.Duration `mapstructure:"retry_delay"`
+	MaxRetryDelay      time.Duration `mapstructure:"max_retry_delay"`
+
+	// Digest settings; digests are sent at DigestHour in the recipient's timezone
+	DigestHour int `mapstructure:"digest_hour"`
 }
 
 // WebhookConfig contains webhook settings for external integrations.
+// Secret is the master key from which every subscription's signing secret is
+// derived; webhooks cannot be created without it.
 type WebhookConfig struct {
 	Secret     string        `mapstructure:"secret"`
 	Timeout    time.Duration `mapstructure:"timeout"`
 	RetryCount int           `mapstructure:"retry_count"`
 	RetryDelay time.Duration `mapstructure:"retry_delay"`
+
+	// Delivery settings; retries back off exponentially up to MaxRetryDelay
+	MaxRetryDelay        time.Duration `mapstructure:"max_retry_delay"`
+	PollInterval         time.Duration `mapstructure:"poll_interval"`
+	BatchSize            int           `mapstructure:"batch_size"`
+	DisableAfterFailures int           `mapstructure:"disable_after_failures"`
 }
 
 // MonitoringConfig contains settings for application monitoring and metrics.
@@ -142,6 +212,197 @@ type MonitoringConfig struct {
 	PrometheusEnabled bool   `mapstructure:"prometheus_enabled"`
 }
 
+// WorkflowConfig contains settings for evidence request lifecycle automation.
+// Reminder offsets are measured backwards from the due date, while the grace
+// period is measured forwards from the due date before escalating to a manager.
+type WorkflowConfig struct {
+	ReminderOffsets       []time.Duration `mapstructure:"reminder_offsets"`
+	EscalationGracePeriod time.Duration   `mapstructure:"escalation_grace_period"`
+}
+
+// AuditConfig contains settings for the tamper-evident audit trail and its exports.
+// The checkpoint signing key is a base64 encoded 32 byte Ed25519 seed; when it
+// is empty, chain verification still works but checkpoints and export manifests
+// are not signed. Exports with more rows than ExportSyncRowLimit must be run
+// as background jobs.
+type AuditConfig struct {
+	CheckpointSigningKey string        `mapstructure:"checkpoint_signing_key"`
+	CheckpointKeyID      string        `mapstructure:"checkpoint_key_id"`
+	ExportDirectory      string        `mapstructure:"export_directory"`
+	ExportSyncRowLimit   int64         `mapstructure:"export_sync_row_limit"`
+	ExportLinkTTL        time.Duration `mapstructure:"export_link_ttl"`
+	ExportPollInterval   time.Duration `mapstructure:"export_poll_interval"`
+}
+
+// RetentionConfig contains settings for the data retention engine. Retention
+// periods themselves are configured per organization and purges run on the
+// scheduler's retention schedule; these settings only control whether and in
+// what batches records are purged. When Enabled is false, dry-run
+// retention reports can still be generated but nothing is deleted.
+type RetentionConfig struct {
+	Enabled   bool `mapstructure:"enabled"`
+	BatchSize int  `mapstructure:"batch_size"`
+}
+
+// EventsConfig contains settings for the domain event bus. Events are written
+// to an outbox collection, relayed to a Redis stream and consumed by the
+// asynchronous subscribers, which retry failed events with backoff.
+type EventsConfig struct {
+	Stream          string        `mapstructure:"stream"`
+	StreamMaxLength int64         `mapstructure:"stream_max_length"`
+	RelayInterval   time.Duration `mapstructure:"relay_interval"`
+	RelayBatchSize  int           `mapstructure:"relay_batch_size"`
+	MaxAttempts     int           `mapstructure:"max_attempts"`
+	RetryDelay      time.Duration `mapstructure:"retry_delay"`
+	MaxRetryDelay   time.Duration `mapstructure:"max_retry_delay"`
+	ClaimIdle       time.Duration `mapstructure:"claim_idle"`
+}
+
+// SchedulerConfig contains settings for the scheduled jobs runner. Schedules
+// maps job names to cron expressions evaluated in Timezone. Each run holds a
+// lease in Redis or MongoDB (LockBackend) that is renewed while the job runs,
+// so only one instance runs a job at a time. The admin API is only available
+// to administrators of AdminOrganizationID, the operator's own organization.
+type SchedulerConfig struct {
+	Enabled             bool              `mapstructure:"enabled"`
+	Timezone            string            `mapstructure:"timezone"`
+	LockBackend         string            `mapstructure:"lock_backend"`
+	LeaseTTL            time.Duration     `mapstructure:"lease_ttl"`
+	PollInterval        time.Duration     `mapstructure:"poll_interval"`
+	RunHistoryTTL       time.Duration     `mapstructure:"run_history_ttl"`
+	AdminOrganizationID string            `mapstructure:"admin_organization_id"`
+	Schedules           map[string]string `mapstructure:"schedules"`
+}
+
+// GraphQLConfig contains settings for the GraphQL endpoint. Queries nested
+// deeper than MaxDepth or with an estimated cost above MaxComplexity are
+// rejected before they run; a connection field costs its page size times the
+// cost of its selections. Page sizes default to DefaultPageSize and are capped
+// at MaxPageSize. Queries longer than MaxQueryLength bytes or with more than
+// MaxFragmentSpreads fragment spreads are rejected before they are validated.
+type GraphQLConfig struct {
+	Enabled            bool `mapstructure:"enabled"`
+	MaxDepth           int  `mapstructure:"max_depth"`
+	MaxComplexity      int  `mapstructure:"max_complexity"`
+	MaxQueryLength     int  `mapstructure:"max_query_length"`
+	MaxFragmentSpreads int  `mapstructure:"max_fragment_spreads"`
+	DefaultPageSize    int  `mapstructure:"default_page_size"`
+	MaxPageSize        int  `mapstructure:"max_page_size"`
+	Introspection      bool `mapstructure:"introspection"`
+}
+
+// OpenAPIConfig controls validation against the OpenAPI document. Invalid
+// requests are rejected with 400 before they reach a handler. Response
+// validation buffers JSON responses and replaces non-conforming ones with a
+// 500; it is meant for development and test environments.
+type OpenAPIConfig struct {
+	ValidateRequests  bool `mapstructure:"validate_requests"`
+	ValidateResponses bool `mapstructure:"validate_responses"`
+}
+
+// RateLimitConfig contains the API rate limits. Plans maps subscription plans
+// to the requests allowed per Window; organizations with an unknown plan get
+// the starter limits. Groups overrides the plan limits for the routes below
+// a first path segment, e.g. "audit" for /api/v1/audit/..., which then count
+// against their own buckets. Limits are kept in Redis; while Redis is
+// unreachable each instance falls back to local limits and retries Redis
+// after FallbackRetry.
+type RateLimitConfig struct {
+	Enabled       bool                                `mapstructure:"enabled"`
+	Window        time.Duration                       `mapstructure:"window"`
+	FallbackRetry time.Duration                       `mapstructure:"fallback_retry"`
+	Plans         map[string]RateLimitPlan            `mapstructure:"plans"`
+	Groups        map[string]map[string]RateLimitPlan `mapstructure:"groups"`
+}
+
+// RateLimitPlan holds the requests allowed per window for an organization as
+// a whole and for each of its users and API keys. Zero means unlimited in a
+// plan and inherits the plan's value in a group override.
+type RateLimitPlan struct {
+	Organization int `mapstructure:"organization"`
+	User         int `mapstructure:"user"`
+	APIKey       int `mapstructure:"api_key"`
+}
+
+// APIKeyConfig contains settings for organization API keys. Keys created
+// without an expiry expire after DefaultTTL and none may live longer than
+// MaxTTL. A rotated key stays valid for RotationOverlap, or the overlap
+// requested up to MaxRotationOverlap, so clients can switch over. Last-used
+// time is written at most once per LastUsedInterval per key.
+type APIKeyConfig struct {
+	DefaultTTL         time.Duration `mapstructure:"default_ttl"`
+	MaxTTL             time.Duration `mapstructure:"max_ttl"`
+	RotationOverlap    time.Duration `mapstructure:"rotation_overlap"`
+	MaxRotationOverlap time.Duration `mapstructure:"max_rotation_overlap"`
+	LastUsedInterval   time.Duration `mapstructure:"last_used_interval"`
+}
+
+// SSOConfig contains settings for single sign-on. A login must be completed
+// within StateTTL of being started. Identity provider metadata and signing keys
+// are cached for MetadataCacheTTL, requests to providers time out after
+// HTTPTimeout, and token and assertion timestamps may be off by up to
+// ClockSkew.
+type SSOConfig struct {
+	StateTTL         time.Duration `mapstructure:"state_ttl"`
+	HTTPTimeout      time.Duration `mapstructure:"http_timeout"`
+	MetadataCacheTTL time.Duration `mapstructure:"metadata_cache_ttl"`
+	ClockSkew        time.Duration `mapstructure:"clock_skew"`
+
+	// SAML service provider settings. SAMLBaseURL is the public API URL the
+	// per-organization SAML endpoints are published under, e.g.
+	// https://app.example.com/api/v1; requests are signed with the PEM encoded
+	// key and certificate. SAML logins are unavailable until all are set.
+	SAMLBaseURL         string `mapstructure:"saml_base_url"`
+	SAMLCertificateFile string `mapstructure:"saml_certificate_file"`
+	SAMLKeyFile         string `mapstructure:"saml_key_file"`
+}
+
+// LDAPConfig contains settings for LDAP sign-in and directory sync. Dialing
+// and each directory operation time out after Timeout; syncs read users in
+// pages of PageSize. AllowInsecure permits ldap:// directories without
+// StartTLS, which send passwords in the clear; it is meant for development.
+type LDAPConfig struct {
+	Timeout       time.Duration `mapstructure:"timeout"`
+	PageSize      int           `mapstructure:"page_size"`
+	AllowInsecure bool          `mapstructure:"allow_insecure"`
+}
+
+// InvitationConfig contains settings for user invitations. Invitation links
+// are signed with SigningKey and can be accepted until TTL after they were
+// sent; inviting is unavailable while no key is set.
+type InvitationConfig struct {
+	TTL        time.Duration `mapstructure:"ttl"`
+	SigningKey string        `mapstructure:"signing_key"`
+}
+
+// PasswordConfig contains the word lists new passwords are checked against
+// and the limits of self-service password resets. BreachedHashesPath is a
+// directory of Pwned Passwords range files or a file of SHA-1 hashes; without
+// it, breached password checks are skipped. DictionaryPath adds words to the
+// built-in list of common passwords. Reset links expire after ResetTTL; each
+// email address may request ResetLimit and each client IP ResetIPLimit
+// resets per ResetWindow.
+type PasswordConfig struct {
+	BreachedHashesPath string        `mapstructure:"breached_hashes_path"`
+	DictionaryPath     string        `mapstructure:"dictionary_path"`
+	ResetTTL           time.Duration `mapstructure:"reset_ttl"`
+	ResetLimit         int           `mapstructure:"reset_limit"`
+	ResetIPLimit       int           `mapstructure:"reset_ip_limit"`
+	ResetWindow        time.Duration `mapstructure:"reset_window"`
+}
+
+// SessionConfig contains the platform session timeouts, used for
+// organizations that do not set their own. A session ends after IdleTimeout
+// without requests or AbsoluteTimeout after sign-in, whichever comes first.
+// Activity is written to the session store at most once per ActivityInterval
+// per session; revocations are enforced on every request through the Redis
+// denylist.
+type SessionConfig struct {
+	IdleTimeout      time.Duration `mapstructure:"idle_timeout"`
+	AbsoluteTimeout  time.Duration `mapstructure:"absolute_timeout"`
+	ActivityInterval time.Duration `mapstructure:"activity_interval"`
+}
+
 // Load reads configuration from environment variables, config files, and defaults.
 // It follows the 12-factor app methodology for configuration management.
 //
@@ -278,12 +539,24 @@ func bindEnvironmentVariables() {
 	viper.BindEnv("email.smtp_port", "GOEDU_EMAIL_SMTP_PORT")
 	viper.BindEnv("email.smtp_username", "GOEDU_EMAIL_SMTP_USERNAME")
 	viper.BindEnv("email.smtp_password", "GOEDU_EMAIL_SMTP_PASSWORD")
+	viper.BindEnv("email.smtp_timeout", "GOEDU_EMAIL_SMTP_TIMEOUT")
+	viper.BindEnv("email.app_url", "GOEDU_EMAIL_APP_URL")
+	viper.BindEnv("email.outbox_poll_interval", "GOEDU_EMAIL_OUTBOX_POLL_INTERVAL")
+	viper.BindEnv("email.outbox_batch_size", "GOEDU_EMAIL_OUTBOX_BATCH_SIZE")
+	viper.BindEnv("email.max_attempts", "GOEDU_EMAIL_MAX_ATTEMPTS")
+	viper.BindEnv("email.retry_delay", "GOEDU_EMAIL_RETRY_DELAY")
+	viper.BindEnv("email.max_retry_delay", "GOEDU_EMAIL_MAX_RETRY_DELAY")
+	viper.BindEnv("email.digest_hour", "GOEDU_EMAIL_DIGEST_HOUR")
 
 	// Webhook configuration
 	viper.BindEnv("webhook.secret", "GOEDU_WEBHOOK_SECRET")
 	viper.BindEnv("webhook.timeout", "GOEDU_WEBHOOK_TIMEOUT")
 	viper.BindEnv("webhook.retry_count", "GOEDU_WEBHOOK_RETRY_COUNT")
 	viper.BindEnv("webhook.retry_delay", "GOEDU_WEBHOOK_RETRY_DELAY")
+	viper.BindEnv("webhook.max_retry_delay", "GOEDU_WEBHOOK_MAX_RETRY_DELAY")
+	viper.BindEnv("webhook.poll_interval", "GOEDU_WEBHOOK_POLL_INTERVAL")
+	viper.BindEnv("webhook.batch_size", "GOEDU_WEBHOOK_BATCH_SIZE")
+	viper.BindEnv("webhook.disable_after_failures", "GOEDU_WEBHOOK_DISABLE_AFTER_FAILURES")
 
 	// Monitoring configuration
 	viper.BindEnv("monitoring.enabled", "GOEDU_MONITORING_ENABLED")
@@ -291,6 +564,113 @@ func bindEnvironmentVariables() {
 	viper.BindEnv("monitoring.health_check_path", "GOEDU_MONITORING_HEALTH_CHECK_PATH")
 	viper.BindEnv("monitoring.prometheus_enabled", "GOEDU_MONITORING_PROMETHEUS_ENABLED")
 
+	// Workflow configuration
+	viper.BindEnv("workflow.reminder_offsets", "GOEDU_WORKFLOW_REMINDER_OFFSETS")
+	viper.BindEnv("workflow.escalation_grace_period", "GOEDU_WORKFLOW_ESCALATION_GRACE_PERIOD")
+
+	// Audit configuration
+	viper.BindEnv("audit.checkpoint_signing_key", "GOEDU_AUDIT_CHECKPOINT_SIGNING_KEY")
+	viper.BindEnv("audit.checkpoint_key_id", "GOEDU_AUDIT_CHECKPOINT_KEY_ID")
+	viper.BindEnv("audit.export_directory", "GOEDU_AUDIT_EXPORT_DIRECTORY")
+	viper.BindEnv("audit.export_sync_row_limit", "GOEDU_AUDIT_EXPORT_SYNC_ROW_LIMIT")
+	viper.BindEnv("audit.export_link_ttl", "GOEDU_AUDIT_EXPORT_LINK_TTL")
+	viper.BindEnv("audit.export_poll_interval", "GOEDU_AUDIT_EXPORT_POLL_INTERVAL")
+
+	// Retention configuration
+	viper.BindEnv("retention.enabled", "GOEDU_RETENTION_ENABLED")
+	viper.BindEnv("retention.batch_size", "GOEDU_RETENTION_BATCH_SIZE")
+
+	// Event bus configuration
+	viper.BindEnv("events.stream", "GOEDU_EVENTS_STREAM")
+	viper.BindEnv("events.stream_max_length", "GOEDU_EVENTS_STREAM_MAX_LENGTH")
+	viper.BindEnv("events.relay_interval", "GOEDU_EVENTS_RELAY_INTERVAL")
+	viper.BindEnv("events.relay_batch_size", "GOEDU_EVENTS_RELAY_BATCH_SIZE")
+	viper.BindEnv("events.max_attempts", "GOEDU_EVENTS_MAX_ATTEMPTS")
+	viper.BindEnv("events.retry_delay", "GOEDU_EVENTS_RETRY_DELAY")
+	viper.BindEnv("events.max_retry_delay", "GOEDU_EVENTS_MAX_RETRY_DELAY")
+	viper.BindEnv("events.claim_idle", "GOEDU_EVENTS_CLAIM_IDLE")
+
+	// Scheduler configuration
+	viper.BindEnv("scheduler.enabled", "GOEDU_SCHEDULER_ENABLED")
+	viper.BindEnv("scheduler.timezone", "GOEDU_SCHEDULER_TIMEZONE")
+	viper.BindEnv("scheduler.lock_backend", "GOEDU_SCHEDULER_LOCK_BACKEND")
+	viper.BindEnv("scheduler.lease_ttl", "GOEDU_SCHEDULER_LEASE_TTL")
+	viper.BindEnv("scheduler.poll_interval", "GOEDU_SCHEDULER_POLL_INTERVAL")
+	viper.BindEnv("scheduler.run_history_ttl", "GOEDU_SCHEDULER_RUN_HISTORY_TTL")
+	viper.BindEnv("scheduler.admin_organization_id", "GOEDU_SCHEDULER_ADMIN_ORGANIZATION_ID")
+	viper.BindEnv("scheduler.schedules.evidence_lifecycle", "GOEDU_SCHEDULER_SCHEDULES_EVIDENCE_LIFECYCLE")
+	viper.BindEnv("scheduler.schedules.retention", "GOEDU_SCHEDULER_SCHEDULES_RETENTION")
+	viper.BindEnv("scheduler.schedules.subscription_renewal", "GOEDU_SCHEDULER_SCHEDULES_SUBSCRIPTION_RENEWAL")
+	viper.BindEnv("scheduler.schedules.notification_digest", "GOEDU_SCHEDULER_SCHEDULES_NOTIFICATION_DIGEST")
+	viper.BindEnv("scheduler.schedules.audit_checkpoint", "GOEDU_SCHEDULER_SCHEDULES_AUDIT_CHECKPOINT")
+	viper.BindEnv("scheduler.schedules.ldap_sync", "GOEDU_SCHEDULER_SCHEDULES_LDAP_SYNC")
+
+	// GraphQL configuration
+	viper.BindEnv("graphql.enabled", "GOEDU_GRAPHQL_ENABLED")
+	viper.BindEnv("graphql.max_depth", "GOEDU_GRAPHQL_MAX_DEPTH")
+	viper.BindEnv("graphql.max_complexity", "GOEDU_GRAPHQL_MAX_COMPLEXITY")
+	viper.BindEnv("graphql.max_query_length", "GOEDU_GRAPHQL_MAX_QUERY_LENGTH")
+	viper.BindEnv("graphql.max_fragment_spreads", "GOEDU_GRAPHQL_MAX_FRAGMENT_SPREADS")
+	viper.BindEnv("graphql.default_page_size", "GOEDU_GRAPHQL_DEFAULT_PAGE_SIZE")
+	viper.BindEnv("graphql.max_page_size", "GOEDU_GRAPHQL_MAX_PAGE_SIZE")
+	viper.BindEnv("graphql.introspection", "GOEDU_GRAPHQL_INTROSPECTION")
+
+	// OpenAPI configuration
+	viper.BindEnv("openapi.validate_requests", "GOEDU_OPENAPI_VALIDATE_REQUESTS")
+	viper.BindEnv("openapi.validate_responses", "GOEDU_OPENAPI_VALIDATE_RESPONSES")
+
+	// Rate limit configuration
+	viper.BindEnv("rate_limit.enabled", "GOEDU_RATE_LIMIT_ENABLED")
+	viper.BindEnv("rate_limit.window", "GOEDU_RATE_LIMIT_WINDOW")
+	viper.BindEnv("rate_limit.fallback_retry", "GOEDU_RATE_LIMIT_FALLBACK_RETRY")
+	viper.BindEnv("rate_limit.plans.starter.organization", "GOEDU_RATE_LIMIT_PLANS_STARTER_ORGANIZATION")
+	viper.BindEnv("rate_limit.plans.starter.user", "GOEDU_RATE_LIMIT_PLANS_STARTER_USER")
+	viper.BindEnv("rate_limit.plans.starter.api_key", "GOEDU_RATE_LIMIT_PLANS_STARTER_API_KEY")
+	viper.BindEnv("rate_limit.plans.professional.organization", "GOEDU_RATE_LIMIT_PLANS_PROFESSIONAL_ORGANIZATION")
+	viper.BindEnv("rate_limit.plans.professional.user", "GOEDU_RATE_LIMIT_PLANS_PROFESSIONAL_USER")
+	viper.BindEnv("rate_limit.plans.professional.api_key", "GOEDU_RATE_LIMIT_PLANS_PROFESSIONAL_API_KEY")
+	viper.BindEnv("rate_limit.plans.enterprise.organization", "GOEDU_RATE_LIMIT_PLANS_ENTERPRISE_ORGANIZATION")
+	viper.BindEnv("rate_limit.plans.enterprise.user", "GOEDU_RATE_LIMIT_PLANS_ENTERPRISE_USER")
+	viper.BindEnv("rate_limit.plans.enterprise.api_key", "GOEDU_RATE_LIMIT_PLANS_ENTERPRISE_API_KEY")
+
+	// API key configuration
+	viper.BindEnv("api_keys.default_ttl", "GOEDU_API_KEYS_DEFAULT_TTL")
+	viper.BindEnv("api_keys.max_ttl", "GOEDU_API_KEYS_MAX_TTL")
+	viper.BindEnv("api_keys.rotation_overlap", "GOEDU_API_KEYS_ROTATION_OVERLAP")
+	viper.BindEnv("api_keys.max_rotation_overlap", "GOEDU_API_KEYS_MAX_ROTATION_OVERLAP")
+	viper.BindEnv("api_keys.last_used_interval", "GOEDU_API_KEYS_LAST_USED_INTERVAL")
+
+	// SSO configuration
+	viper.BindEnv("sso.state_ttl", "GOEDU_SSO_STATE_TTL")
+	viper.BindEnv("sso.http_timeout", "GOEDU_SSO_HTTP_TIMEOUT")
+	viper.BindEnv("sso.metadata_cache_ttl", "GOEDU_SSO_METADATA_CACHE_TTL")
+	viper.BindEnv("sso.clock_skew", "GOEDU_SSO_CLOCK_SKEW")
+	viper.BindEnv("sso.saml_base_url", "GOEDU_SSO_SAML_BASE_URL")
+	viper.BindEnv("sso.saml_certificate_file", "GOEDU_SSO_SAML_CERTIFICATE_FILE")
+	viper.BindEnv("sso.saml_key_file", "GOEDU_SSO_SAML_KEY_FILE")
+
+	// LDAP configuration
+	viper.BindEnv("ldap.timeout", "GOEDU_LDAP_TIMEOUT")
+	viper.BindEnv("ldap.page_size", "GOEDU_LDAP_PAGE_SIZE")
+	viper.BindEnv("ldap.allow_insecure", "GOEDU_LDAP_ALLOW_INSECURE")
+
+	// Invitation configuration
+	viper.BindEnv("invitations.ttl", "GOEDU_INVITATIONS_TTL")
+	viper.BindEnv("invitations.signing_key", "GOEDU_INVITATIONS_SIGNING_KEY")
+
+	// Password configuration
+	viper.BindEnv("passwords.breached_hashes_path", "GOEDU_PASSWORDS_BREACHED_HASHES_PATH")
+	viper.BindEnv("passwords.dictionary_path", "GOEDU_PASSWORDS_DICTIONARY_PATH")
+	viper.BindEnv("passwords.reset_ttl", "GOEDU_PASSWORDS_RESET_TTL")
+	viper.BindEnv("passwords.reset_limit", "GOEDU_PASSWORDS_RESET_LIMIT")
+	viper.BindEnv("passwords.reset_ip_limit", "GOEDU_PASSWORDS_RESET_IP_LIMIT")
+	viper.BindEnv("passwords.reset_window", "GOEDU_PASSWORDS_RESET_WINDOW")
+
+	// Session configuration
+	viper.BindEnv("sessions.idle_timeout", "GOEDU_SESSIONS_IDLE_TIMEOUT")
+	viper.BindEnv("sessions.absolute_timeout", "GOEDU_SESSIONS_ABSOLUTE_TIMEOUT")
+	viper.BindEnv("sessions.activity_interval", "GOEDU_SESSIONS_ACTIVITY_INTERVAL")
+
 	// Logger configuration
 	viper.BindEnv("logger.level", "GOEDU_LOGGER_LEVEL")
 	viper.BindEnv("logger.environment", "GOEDU_LOGGER_ENVIRONMENT")
@@ -352,11 +732,23 @@ func setDefaults() {
 	viper.SetDefault("email.from", "noreply@goedu.com")
 	viper.SetDefault("email.smtp_host", "localhost")
 	viper.SetDefault("email.smtp_port", 587)
+	viper.SetDefault("email.smtp_timeout", "30s")
+	viper.SetDefault("email.app_url", "http://localhost:3000")
+	viper.SetDefault("email.outbox_poll_interval", "15s")
+	viper.SetDefault("email.outbox_batch_size", 100)
+	viper.SetDefault("email.max_attempts", 8)
+	viper.SetDefault("email.retry_delay", "1m")
+	viper.SetDefault("email.max_retry_delay", "6h")
+	viper.SetDefault("email.digest_hour", 8)
 
 	// Webhook defaults
 	viper.SetDefault("webhook.timeout", "30s")
 	viper.SetDefault("webhook.retry_count", 3)
 	viper.SetDefault("webhook.retry_delay", "5s")
+	viper.SetDefault("webhook.max_retry_delay", "1h")
+	viper.SetDefault("webhook.poll_interval", "10s")
+	viper.SetDefault("webhook.batch_size", 100)
+	viper.SetDefault("webhook.disable_after_failures", 10)
 
 	// Monitoring defaults
 	viper.SetDefault("monitoring.enabled", true)
@@ -364,6 +756,119 @@ func setDefaults() {
 	viper.SetDefault("monitoring.health_check_path", "/health")
 	viper.SetDefault("monitoring.prometheus_enabled", true)
 
+	// Workflow defaults
+	viper.SetDefault("workflow.reminder_offsets", []string{"72h", "24h"})
+	viper.SetDefault("workflow.escalation_grace_period", "48h")
+
+	// Audit defaults
+	viper.SetDefault("audit.checkpoint_signing_key", "")
+	viper.SetDefault("audit.checkpoint_key_id", "default")
+	viper.SetDefault("audit.export_directory", "./data/exports")
+	viper.SetDefault("audit.export_sync_row_limit", 10000)
+	viper.SetDefault("audit.export_link_ttl", "24h")
+	viper.SetDefault("audit.export_poll_interval", "30s")
+
+	// Retention defaults
+	viper.SetDefault("retention.enabled", false)
+	viper.SetDefault("retention.batch_size", 500)
+
+	// Event bus defaults
+	viper.SetDefault("events.stream", "goedu:events")
+	viper.SetDefault("events.stream_max_length", 100000)
+	viper.SetDefault("events.relay_interval", "1s")
+	viper.SetDefault("events.relay_batch_size", 100)
+	viper.SetDefault("events.max_attempts", 5)
+	viper.SetDefault("events.retry_delay", "1s")
+	viper.SetDefault("events.max_retry_delay", "1m")
+	viper.SetDefault("events.claim_idle", "5m")
+
+	// Scheduler defaults
+	viper.SetDefault("scheduler.enabled", true)
+	viper.SetDefault("scheduler.timezone", "UTC")
+	viper.SetDefault("scheduler.lock_backend", "redis")
+	viper.SetDefault("scheduler.lease_ttl", "2m")
+	viper.SetDefault("scheduler.poll_interval", "30s")
+	viper.SetDefault("scheduler.run_history_ttl", "720h")
+	viper.SetDefault("scheduler.admin_organization_id", "")
+	viper.SetDefault("scheduler.schedules.evidence_lifecycle", "*/15 * * * *")
+	viper.SetDefault("scheduler.schedules.retention", "0 2 * * *")
+	viper.SetDefault("scheduler.schedules.subscription_renewal", "0 1 * * *")
+	viper.SetDefault("scheduler.schedules.notification_digest", "*/5 * * * *")
+	viper.SetDefault("scheduler.schedules.audit_checkpoint", "0 * * * *")
+	viper.SetDefault("scheduler.schedules.ldap_sync", "30 * * * *")
+
+	// GraphQL defaults
+	viper.SetDefault("graphql.enabled", true)
+	viper.SetDefault("graphql.max_depth", 8)
+	viper.SetDefault("graphql.max_complexity", 5000)
+	viper.SetDefault("graphql.max_query_length", 16384)
+	viper.SetDefault("graphql.max_fragment_spreads", 100)
+	viper.SetDefault("graphql.default_page_size", 20)
+	viper.SetDefault("graphql.max_page_size", 100)
+	viper.SetDefault("graphql.introspection", true)
+
+	// OpenAPI defaults
+	viper.SetDefault("openapi.validate_requests", true)
+	viper.SetDefault("openapi.validate_responses", false)
+
+	// Rate limit defaults
+	viper.SetDefault("rate_limit.enabled", true)
+	viper.SetDefault("rate_limit.window", "1m")
+	viper.SetDefault("rate_limit.fallback_retry", "30s")
+	viper.SetDefault("rate_limit.plans.starter.organization", 600)
+	viper.SetDefault("rate_limit.plans.starter.user", 120)
+	viper.SetDefault("rate_limit.plans.starter.api_key", 300)
+	viper.SetDefault("rate_limit.plans.professional.organization", 3000)
+	viper.SetDefault("rate_limit.plans.professional.user", 600)
+	viper.SetDefault("rate_limit.plans.professional.api_key", 1200)
+	viper.SetDefault("rate_limit.plans.enterprise.organization", 12000)
+	viper.SetDefault("rate_limit.plans.enterprise.user", 2400)
+	viper.SetDefault("rate_limit.plans.enterprise.api_key", 4800)
+	viper.SetDefault("rate_limit.groups.audit.starter.organization", 30)
+	viper.SetDefault("rate_limit.groups.audit.starter.user", 10)
+	viper.SetDefault("rate_limit.groups.audit.starter.api_key", 10)
+	viper.SetDefault("rate_limit.groups.audit.professional.organization", 120)
+	viper.SetDefault("rate_limit.groups.audit.professional.user", 30)
+	viper.SetDefault("rate_limit.groups.audit.professional.api_key", 30)
+	viper.SetDefault("rate_limit.groups.audit.enterprise.organization", 600)
+	viper.SetDefault("rate_limit.groups.audit.enterprise.user", 120)
+	viper.SetDefault("rate_limit.groups.audit.enterprise.api_key", 120)
+
+	// API key defaults
+	viper.SetDefault("api_keys.default_ttl", "2160h")
+	viper.SetDefault("api_keys.max_ttl", "8760h")
+	viper.SetDefault("api_keys.rotation_overlap", "24h")
+	viper.SetDefault("api_keys.max_rotation_overlap", "168h")
+	viper.SetDefault("api_keys.last_used_interval", "1m")
+
+	// SSO defaults
+	viper.SetDefault("sso.state_ttl", "10m")
+	viper.SetDefault("sso.http_timeout", "10s")
+	viper.SetDefault("sso.metadata_cache_ttl", "1h")
+	viper.SetDefault("sso.clock_skew", "1m")
+
+	// LDAP defaults
+	viper.SetDefault("ldap.timeout", "10s")
+	viper.SetDefault("ldap.page_size", 500)
+	viper.SetDefault("ldap.allow_insecure", false)
+
+	// Invitation defaults
+	viper.SetDefault("invitations.ttl", "168h")
+	viper.SetDefault("invitations.signing_key", "")
+
+	// Password defaults
+	viper.SetDefault("passwords.breached_hashes_path", "")
+	viper.SetDefault("passwords.dictionary_path", "")
+	viper.SetDefault("passwords.reset_ttl", "1h")
+	viper.SetDefault("passwords.reset_limit", 3)
+	viper.SetDefault("passwords.reset_ip_limit", 20)
+	viper.SetDefault("passwords.reset_window", "1h")
+
+	// Session defaults
+	viper.SetDefault("sessions.idle_timeout", "8h")
+	viper.SetDefault("sessions.absolute_timeout", "24h")
+	viper.SetDefault("sessions.activity_interval", "1m")
+
 	// Logger defaults
 	viper.SetDefault("logger.level", "info")
 	viper.SetDefault("logger.environment", "development")
@@ -398,6 +903,152 @@ func validate(config *Config) error {
 		return fmt.Errorf("database max_pool_size must be >= min_pool_size")
 	}
 
+	// Validate workflow timings
+	for _, offset := range config.Workflow.ReminderOffsets {
+		if offset <= 0 {
+			return fmt.Errorf("workflow reminder offsets must be positive, got %s", offset)
+		}
+	}
+	if config.Workflow.EscalationGracePeriod < 0 {
+		return fmt.Errorf("workflow escalation grace period must not be negative")
+	}
+
+	// Validate audit checkpointing and exports
+	if config.Audit.ExportLinkTTL <= 0 || config.Audit.ExportPollInterval <= 0 {
+		return fmt.Errorf("audit export link TTL and poll interval must be positive")
+	}
+	if config.App.Environment == "production" && config.Audit.CheckpointSigningKey == "" {
+		return fmt.Errorf("audit checkpoint signing key must be configured for production")
+	}
+
+	// Validate retention enforcement
+	if config.Retention.BatchSize <= 0 {
+		return fmt.Errorf("retention batch size must be positive")
+	}
+
+	// Validate event bus
+	if config.Events.Stream == "" || config.Events.StreamMaxLength <= 0 {
+		return fmt.Errorf("events stream name and max length are required")
+	}
+	if config.Events.RelayInterval <= 0 || config.Events.RelayBatchSize <= 0 || config.Events.MaxAttempts <= 0 || config.Events.ClaimIdle <= 0 {
+		return fmt.Errorf("events relay interval, relay batch size, max attempts and claim idle must be positive")
+	}
+	if config.Events.RetryDelay <= 0 || config.Events.MaxRetryDelay < config.Events.RetryDelay {
+		return fmt.Errorf("events retry delay must be positive and not exceed the max retry delay")
+	}
+
+	// Validate scheduler
+	if config.Scheduler.LockBackend != "redis" && config.Scheduler.LockBackend != "mongo" {
+		return fmt.Errorf("scheduler lock backend must be redis or mongo, got %q", config.Scheduler.LockBackend)
+	}
+	if config.Scheduler.LeaseTTL <= 0 || config.Scheduler.PollInterval <= 0 || config.Scheduler.RunHistoryTTL <= 0 {
+		return fmt.Errorf("scheduler lease TTL, poll interval and run history TTL must be positive")
+	}
+	if _, err := time.LoadLocation(config.Scheduler.Timezone); err != nil {
+		return fmt.Errorf("invalid scheduler timezone %q: %w", config.Scheduler.Timezone, err)
+	}
+
+	// Validate rate limits
+	if config.RateLimit.Window <= 0 || config.RateLimit.FallbackRetry <= 0 {
+		return fmt.Errorf("rate limit window and fallback retry must be positive")
+	}
+	if _, ok := config.RateLimit.Plans["starter"]; !ok {
+		return fmt.Errorf("rate limits for the starter plan are required")
+	}
+	for plan, limits := range config.RateLimit.Plans {
+		if limits.Organization < 0 || limits.User < 0 || limits.APIKey < 0 {
+			return fmt.Errorf("rate limits of plan %q must not be negative", plan)
+		}
+	}
+	for group, plans := range config.RateLimit.Groups {
+		for plan, limits := range plans {
+			if limits.Organization < 0 || limits.User < 0 || limits.APIKey < 0 {
+				return fmt.Errorf("rate limits of group %q plan %q must not be negative", group, plan)
+			}
+		}
+	}
+
+	// Validate API keys
+	if config.APIKeys.DefaultTTL <= 0 || config.APIKeys.MaxTTL < config.APIKeys.DefaultTTL {
+		return fmt.Errorf("api key default TTL must be positive and not exceed the max TTL")
+	}
+	if config.APIKeys.RotationOverlap < 0 || config.APIKeys.MaxRotationOverlap < config.APIKeys.RotationOverlap {
+		return fmt.Errorf("api key rotation overlap must not be negative and not exceed the max rotation overlap")
+	}
+	if config.APIKeys.LastUsedInterval < 0 {
+		return fmt.Errorf("api key last used interval must not be negative")
+	}
+
+	// Validate SSO
+	if config.SSO.StateTTL <= 0 || config.SSO.HTTPTimeout <= 0 || config.SSO.MetadataCacheTTL <= 0 {
+		return fmt.Errorf("sso state TTL, HTTP timeout and metadata cache TTL must be positive")
+	}
+	if config.SSO.ClockSkew < 0 {
+		return fmt.Errorf("sso clock skew must not be negative")
+	}
+	if (config.SSO.SAMLCertificateFile == "") != (config.SSO.SAMLKeyFile == "") {
+		return fmt.Errorf("sso SAML certificate and key files must be set together")
+	}
+
+	// Validate LDAP
+	if config.LDAP.Timeout <= 0 || config.LDAP.PageSize <= 0 {
+		return fmt.Errorf("ldap timeout and page size must be positive")
+	}
+
+	// Validate invitations
+	if config.Invitations.TTL <= 0 {
+		return fmt.Errorf("invitation TTL must be positive")
+	}
+	if config.App.Environment == "production" && config.Invitations.SigningKey == "" {
+		return fmt.Errorf("invitation signing key must be configured for production")
+	}
+
+	// Validate password resets
+	if config.Passwords.ResetTTL <= 0 || config.Passwords.ResetWindow <= 0 {
+		return fmt.Errorf("password reset TTL and window must be positive")
+	}
+	if config.Passwords.ResetLimit <= 0 || config.Passwords.ResetIPLimit <= 0 {
+		return fmt.Errorf("password reset limits must be positive")
+	}
+
+	// Validate session timeouts
+	if config.Sessions.IdleTimeout <= 0 || config.Sessions.AbsoluteTimeout <= 0 || config.Sessions.ActivityInterval <= 0 {
+		return fmt.Errorf("session timeouts and activity interval must be positive")
+	}
+	if config.Sessions.ActivityInterval >= config.Sessions.IdleTimeout {
+		return fmt.Errorf("session activity interval must be shorter than the idle timeout")
+	}
+
+	// Validate GraphQL limits
+	if config.GraphQL.MaxDepth <= 0 || config.GraphQL.MaxComplexity <= 0 {
+		return fmt.Errorf("graphql max depth and max complexity must be positive")
+	}
+	if config.GraphQL.MaxQueryLength <= 0 || config.GraphQL.MaxFragmentSpreads <= 0 {
+		return fmt.Errorf("graphql max query length and max fragment spreads must be positive")
+	}
+	if config.GraphQL.DefaultPageSize <= 0 || config.GraphQL.MaxPageSize < config.GraphQL.DefaultPageSize {
+		return fmt.Errorf("graphql default page size must be positive and not exceed the max page size")
+	}
+
+	// Validate notification email delivery
+	if config.Email.OutboxPollInterval <= 0 || config.Email.OutboxBatchSize <= 0 || config.Email.MaxAttempts <= 0 {
+		return fmt.Errorf("email outbox poll interval, batch size and max attempts must be positive")
+	}
+	if config.Email.RetryDelay <= 0 || config.Email.MaxRetryDelay < config.Email.RetryDelay {
+		return fmt.Errorf("email retry delay must be positive and not exceed the max retry delay")
+	}
+	if config.Email.DigestHour < 0 || config.Email.DigestHour > 23 {
+		return fmt.Errorf("email digest hour must be between 0 and 23, got %d", config.Email.DigestHour)
+	}
+
+	// Validate webhook delivery
+	if config.Webhook.RetryCount < 0 || config.Webhook.RetryDelay <= 0 || config.Webhook.MaxRetryDelay < config.Webhook.RetryDelay {
+		return fmt.Errorf("webhook retry count must not be negative and the retry delay must be positive and not exceed the max retry delay")
+	}
+	if config.Webhook.PollInterval <= 0 || config.Webhook.BatchSize <= 0 || config.Webhook.DisableAfterFailures <= 0 {
+		return fmt.Errorf("webhook poll interval, batch size and disable after failures must be positive")
+	}
+
 	// Validate BCrypt cost
 	if config.Auth.BCryptCost < 10 || config.Auth.BCryptCost > 15 {
 		return fmt.Errorf("bcrypt cost must be between 10 and 15, got %d", config.Auth.BCryptCost)
@@ -0,0 +1,432 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/requestctx"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/cache"
+)
+
+// Stream consumption settings.
+const (
+	consumerBatchSize = 10
+	consumerBlock     = 5 * time.Second
+	relayLease        = time.Minute
+)
+
+// Handler reacts to an event. Handlers of asynchronous subscriptions are
+// retried when they return an error, so they must be idempotent.
+type Handler func(ctx context.Context, event *Event) error
+
+// Publisher publishes domain events. Services depend on Publisher rather than
+// on Bus so that they only see the publishing side.
+type Publisher interface {
+	// Publish records an event and runs its synchronous handlers
+	Publish(ctx context.Context, event *Event) error
+}
+
+// subscription is a named handler of some or all event types.
+type subscription struct {
+	name    string
+	types   map[string]bool // nil subscribes to all types
+	handler Handler
+}
+
+// wants reports whether the subscription handles events of the given type.
+func (s *subscription) wants(eventType string) bool {
+	return s.types == nil || s.types[eventType]
+}
+
+// Bus dispatches domain events to synchronous handlers in-process and to
+// asynchronous handlers through the outbox and the event stream.
+type Bus struct {
+	outbox   repositories.DomainEventRepository
+	streams  cache.Streams
+	cfg      config.EventsConfig
+	consumer string
+	logger   *zap.Logger
+
+	mu    sync.RWMutex
+	sync  []*subscription
+	async []*subscription
+}
+
+// NewBus creates a new event bus. Subscriptions are added before the bus is
+// used; every server instance must register the same subscriptions.
+//
+// Parameters:
+//   - outbox: Repository for the transactional outbox
+//   - streams: Redis streams carrying relayed events to asynchronous handlers
+//   - cfg: Event bus configuration
+//   - logger: Logger for bus operations
+//
+// Returns:
+//   - *Bus: Configured event bus
+//
+// Example:
+//
+//	bus := events.NewBus(domainEventRepo, cacheClient, cfg.Events, logger)
+//	services.RegisterEventSubscribers(bus, auditService, cacheRepo, notificationService, webhookService,
+//	    evidenceRepo, commentRepo, userRepo, logger)
+//	go bus.Consume(ctx)
+func NewBus(outbox repositories.DomainEventRepository, streams cache.Streams, cfg config.EventsConfig, logger *zap.Logger) *Bus {
+	hostname, _ := os.Hostname()
+	return &Bus{
+		outbox:   outbox,
+		streams:  streams,
+		cfg:      cfg,
+		consumer: hostname + "-" + strconv.Itoa(os.Getpid()),
+		logger:   logger,
+	}
+}
+
+// Subscribe adds a synchronous handler. It runs while the event is published,
+// with the publisher's context and inside its transaction, if any, so it runs
+// before the change is committed. A handler error fails Publish and with it
+// the publishing transaction; subscribe only side effects that must commit
+// together with the change, such as the audit trail, and use SubscribeAsync
+// for anything that must see the committed change.
+//
+// Parameters:
+//   - name: Subscription name used in logs
+//   - handler: Handler to run
+//   - eventTypes: Event types to handle; none subscribes to all types
+//
+// Example:
+//
+//	bus.Subscribe("audit", auditHandler)
+func (b *Bus) Subscribe(name string, handler Handler, eventTypes ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sync = append(b.sync, newSubscription(name, handler, eventTypes))
+}
+
+// SubscribeAsync adds an asynchronous handler. It receives events from the
+// event stream after the publishing transaction committed, through a consumer
+// group named after the subscription, and is retried with backoff on errors.
+//
+// Parameters:
+//   - name: Subscription name, also the stream consumer group; must be stable
+//   - handler: Idempotent handler to run
+//   - eventTypes: Event types to handle; none subscribes to all types
+//
+// Example:
+//
+//	bus.SubscribeAsync("webhooks", forwardToWebhooks, events.TypeCycleCompleted)
+//	bus.SubscribeAsync("cache", invalidateFlags, events.TypeFeatureFlagUpdated)
+func (b *Bus) SubscribeAsync(name string, handler Handler, eventTypes ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.async = append(b.async, newSubscription(name, handler, eventTypes))
+}
+
+// newSubscription creates a subscription to the given types, or all types.
+func newSubscription(name string, handler Handler, eventTypes []string) *subscription {
+	sub := &subscription{name: name, handler: handler}
+	if len(eventTypes) > 0 {
+		sub.types = make(map[string]bool, len(eventTypes))
+		for _, eventType := range eventTypes {
+			sub.types[eventType] = true
+		}
+	}
+	return sub
+}
+
+// Publish writes an event to the outbox and runs its synchronous handlers.
+// The event ID, time, actor and correlation ID are filled in from ctx when
+// missing. Call Publish inside the transaction of the change the event
+// describes, so the event is only relayed if the change is committed.
+//
+// Parameters:
+//   - ctx: Request context, possibly carrying a transaction
+//   - event: Event to publish
+//
+// Returns:
+//   - error: Error if the event is invalid, cannot be written to the outbox or
+//     a synchronous handler failed
+func (b *Bus) Publish(ctx context.Context, event *Event) error {
+	if event == nil || event.Payload == nil {
+		return errors.New("event payload is required")
+	}
+	event.Type = event.Payload.EventType()
+	if event.ID == "" {
+		event.ID = primitive.NewObjectID().Hex()
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().UTC()
+	}
+	if md, ok := requestctx.FromContext(ctx); ok {
+		if event.ActorID == "" {
+			event.ActorID = md.UserID
+		}
+		if event.CorrelationID == "" {
+			event.CorrelationID = md.CorrelationID
+		}
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	record := &models.DomainEvent{
+		ID:             primitive.NewObjectID(),
+		EventID:        event.ID,
+		Type:           event.Type,
+		OrganizationID: event.OrganizationID,
+		Payload:        string(payload),
+		Status:         models.DomainEventPending,
+		NextAttemptAt:  event.OccurredAt,
+		CreatedAt:      event.OccurredAt,
+	}
+	if err := b.outbox.Create(ctx, record); err != nil {
+		return fmt.Errorf("failed to write event to outbox: %w", err)
+	}
+
+	b.mu.RLock()
+	subscriptions := b.sync
+	b.mu.RUnlock()
+	for _, sub := range subscriptions {
+		if !sub.wants(event.Type) {
+			continue
+		}
+		if err := sub.handler(ctx, event); err != nil {
+			b.logger.Error("Event handler failed",
+				zap.Error(err),
+				zap.String("subscription", sub.name),
+				zap.String("event_id", event.ID),
+				zap.String("type", event.Type),
+			)
+			return fmt.Errorf("event handler %s failed: %w", sub.name, err)
+		}
+	}
+	return nil
+}
+
+// RelayPending leases due outbox events and appends them to the event stream,
+// up to the configured batch size. Events that cannot be appended are retried
+// with backoff; they are never dropped.
+//
+// Parameters:
+//   - ctx: Request context
+//
+// Returns:
+//   - int: Number of events attempted
+//   - error: Error if the outbox cannot be read; append failures are recorded on the events
+func (b *Bus) RelayPending(ctx context.Context) (int, error) {
+	attempted := 0
+	for attempted < b.cfg.RelayBatchSize {
+		if err := ctx.Err(); err != nil {
+			return attempted, err
+		}
+
+		now := time.Now().UTC()
+		record, err := b.outbox.ClaimDue(ctx, now, now.Add(relayLease))
+		if errors.Is(err, repositories.ErrNotFound) {
+			return attempted, nil
+		}
+		if err != nil {
+			return attempted, fmt.Errorf("failed to claim outbox event: %w", err)
+		}
+
+		attempted++
+		b.relay(ctx, record)
+	}
+	return attempted, nil
+}
+
+// relay appends one leased outbox event to the stream and records the outcome.
+func (b *Bus) relay(ctx context.Context, record *models.DomainEvent) {
+	_, err := b.streams.StreamAppend(ctx, b.cfg.Stream, []byte(record.Payload), b.cfg.StreamMaxLength)
+
+	now := time.Now().UTC()
+	record.Attempts++
+	record.LockedUntil = time.Time{}
+	if err == nil {
+		record.Status = models.DomainEventRelayed
+		record.RelayedAt = now
+		record.LastError = ""
+	} else {
+		record.Status = models.DomainEventPending
+		record.NextAttemptAt = now.Add(b.backoff(record.Attempts))
+		record.LastError = err.Error()
+		b.logger.Warn("Failed to relay event, will retry",
+			zap.Error(err),
+			zap.String("event_id", record.EventID),
+			zap.Int("attempts", record.Attempts),
+		)
+	}
+
+	if err := b.outbox.Update(ctx, record); err != nil {
+		// The lease expires and the event is relayed again; consumers must be idempotent
+		b.logger.Error("Failed to record event relay",
+			zap.Error(err),
+			zap.String("event_id", record.EventID),
+			zap.String("status", record.Status),
+		)
+	}
+}
+
+// Consume delivers events from the stream to the asynchronous handlers until
+// ctx is cancelled. Each subscription consumes through its own consumer group;
+// events a stopped instance left unacknowledged are taken over after the
+// configured idle time.
+//
+// Parameters:
+//   - ctx: Context controlling the consumers' lifetime
+//
+// Returns:
+//   - error: Error if a consumer group cannot be created; nil after ctx is cancelled
+func (b *Bus) Consume(ctx context.Context) error {
+	b.mu.RLock()
+	subscriptions := b.async
+	b.mu.RUnlock()
+
+	for _, sub := range subscriptions {
+		if err := b.streams.StreamCreateGroup(ctx, b.cfg.Stream, sub.name); err != nil {
+			return err
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, sub := range subscriptions {
+		wg.Add(1)
+		go func(sub *subscription) {
+			defer wg.Done()
+			b.consume(ctx, sub)
+		}(sub)
+	}
+	wg.Wait()
+	return nil
+}
+
+// consume runs the consumer loop of one subscription.
+func (b *Bus) consume(ctx context.Context, sub *subscription) {
+	var lastClaim time.Time
+	for ctx.Err() == nil {
+		if time.Since(lastClaim) >= b.cfg.ClaimIdle/2 {
+			lastClaim = time.Now()
+			claimed, err := b.streams.StreamClaimIdle(ctx, b.cfg.Stream, sub.name, b.consumer, b.cfg.ClaimIdle, consumerBatchSize)
+			if err != nil {
+				b.logger.Warn("Failed to claim idle events", zap.Error(err), zap.String("subscription", sub.name))
+			}
+			for _, message := range claimed {
+				b.handle(ctx, sub, message)
+			}
+		}
+
+		messages, err := b.streams.StreamReadGroup(ctx, b.cfg.Stream, sub.name, b.consumer, consumerBatchSize, consumerBlock)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			b.logger.Warn("Failed to read event stream", zap.Error(err), zap.String("subscription", sub.name))
+			b.sleep(ctx, b.cfg.RetryDelay)
+			continue
+		}
+		for _, message := range messages {
+			b.handle(ctx, sub, message)
+		}
+	}
+}
+
+// handle runs a subscription's handler on one stream message, retrying it with
+// backoff, and acknowledges the message once it is handled or given up on. A
+// message interrupted by shutdown stays pending and is taken over later.
+func (b *Bus) handle(ctx context.Context, sub *subscription, message cache.StreamMessage) {
+	event, err := Decode(message.Payload)
+	if err != nil {
+		b.logger.Error("Dropping undecodable event",
+			zap.Error(err),
+			zap.String("subscription", sub.name),
+			zap.String("message_id", message.ID),
+		)
+		b.ack(ctx, sub, message)
+		return
+	}
+	if !sub.wants(event.Type) {
+		b.ack(ctx, sub, message)
+		return
+	}
+
+	// Handlers see the metadata of the request that published the event
+	handlerCtx := requestctx.WithMetadata(ctx, &requestctx.Metadata{
+		CorrelationID:  event.CorrelationID,
+		UserID:         event.ActorID,
+		OrganizationID: event.OrganizationID,
+	})
+	for attempt := 1; ; attempt++ {
+		err := sub.handler(handlerCtx, event)
+		if err == nil {
+			break
+		}
+		if attempt >= b.cfg.MaxAttempts {
+			b.logger.Error("Event handler failed permanently, dropping event",
+				zap.Error(err),
+				zap.String("subscription", sub.name),
+				zap.String("event_id", event.ID),
+				zap.String("type", event.Type),
+				zap.Int("attempts", attempt),
+			)
+			break
+		}
+		b.logger.Warn("Event handler failed, will retry",
+			zap.Error(err),
+			zap.String("subscription", sub.name),
+			zap.String("event_id", event.ID),
+			zap.Int("attempts", attempt),
+		)
+		if !b.sleep(ctx, b.backoff(attempt)) {
+			return
+		}
+	}
+	b.ack(ctx, sub, message)
+}
+
+// ack acknowledges a message; an unacknowledged message is handled again.
+func (b *Bus) ack(ctx context.Context, sub *subscription, message cache.StreamMessage) {
+	if err := b.streams.StreamAck(ctx, b.cfg.Stream, sub.name, message.ID); err != nil {
+		b.logger.Warn("Failed to acknowledge event",
+			zap.Error(err),
+			zap.String("subscription", sub.name),
+			zap.String("message_id", message.ID),
+		)
+	}
+}
+
+// sleep waits for d and reports whether ctx is still active.
+func (b *Bus) sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// backoff returns the delay after the given number of failed attempts: the
+// retry delay doubled per attempt, capped at the max delay.
+func (b *Bus) backoff(attempts int) time.Duration {
+	delay := b.cfg.RetryDelay
+	for i := 1; i < attempts && delay < b.cfg.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > b.cfg.MaxRetryDelay {
+		delay = b.cfg.MaxRetryDelay
+	}
+	return delay
+}
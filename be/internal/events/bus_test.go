@@ -0,0 +1,280 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/requestctx"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/cache/cachetest"
+)
+
+// fakeOutbox is an in-memory DomainEventRepository.
+type fakeOutbox struct {
+	mu     sync.Mutex
+	events []*models.DomainEvent
+}
+
+func (o *fakeOutbox) Create(ctx context.Context, event *models.DomainEvent) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	stored := *event
+	o.events = append(o.events, &stored)
+	return nil
+}
+
+func (o *fakeOutbox) Update(ctx context.Context, event *models.DomainEvent) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i, existing := range o.events {
+		if existing.ID == event.ID {
+			stored := *event
+			o.events[i] = &stored
+			return nil
+		}
+	}
+	return repositories.ErrNotFound
+}
+
+func (o *fakeOutbox) ClaimDue(ctx context.Context, now, leaseUntil time.Time) (*models.DomainEvent, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, event := range o.events {
+		if event.Status == models.DomainEventPending && !event.NextAttemptAt.After(now) {
+			event.Status = models.DomainEventRelaying
+			event.LockedUntil = leaseUntil
+			claimed := *event
+			return &claimed, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (o *fakeOutbox) statuses() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	statuses := make([]string, 0, len(o.events))
+	for _, event := range o.events {
+		statuses = append(statuses, event.Status)
+	}
+	return statuses
+}
+
+// failingStreams fails every append.
+type failingStreams struct {
+	*cachetest.Streams
+}
+
+func (failingStreams) StreamAppend(ctx context.Context, name string, payload []byte, maxLen int64) (string, error) {
+	return "", errors.New("redis unavailable")
+}
+
+func testEventsConfig() config.EventsConfig {
+	return config.EventsConfig{
+		Stream:          "test:events",
+		StreamMaxLength: 1000,
+		RelayInterval:   time.Second,
+		RelayBatchSize:  10,
+		MaxAttempts:     3,
+		RetryDelay:      time.Millisecond,
+		MaxRetryDelay:   4 * time.Millisecond,
+		ClaimIdle:       time.Minute,
+	}
+}
+
+func flagEvent(orgID string, enabled bool) *Event {
+	return New(orgID, "organization", orgID, &FeatureFlagUpdated{Flag: "sso", Enabled: enabled})
+}
+
+func TestBus_PublishRunsMatchingSyncHandlersAndWritesOutbox(t *testing.T) {
+	outbox := &fakeOutbox{}
+	bus := NewBus(outbox, cachetest.NewStreams(), testEventsConfig(), zap.NewNop())
+
+	var all, flags, cycles []string
+	bus.Subscribe("all", func(ctx context.Context, event *Event) error {
+		all = append(all, event.Type)
+		return nil
+	})
+	bus.Subscribe("flags", func(ctx context.Context, event *Event) error {
+		flags = append(flags, event.ID)
+		return nil
+	}, TypeFeatureFlagUpdated)
+	bus.Subscribe("cycles", func(ctx context.Context, event *Event) error {
+		cycles = append(cycles, event.ID)
+		return nil
+	}, TypeCycleCompleted)
+
+	ctx := requestctx.WithMetadata(context.Background(), &requestctx.Metadata{CorrelationID: "corr-1", UserID: "user-1"})
+	event := flagEvent("org-1", true)
+	require.NoError(t, bus.Publish(ctx, event))
+
+	assert.NotEmpty(t, event.ID)
+	assert.Equal(t, "user-1", event.ActorID)
+	assert.Equal(t, "corr-1", event.CorrelationID)
+	assert.False(t, event.OccurredAt.IsZero())
+	assert.Equal(t, []string{TypeFeatureFlagUpdated}, all)
+	assert.Equal(t, []string{event.ID}, flags)
+	assert.Empty(t, cycles)
+
+	require.Len(t, outbox.events, 1)
+	record := outbox.events[0]
+	assert.Equal(t, event.ID, record.EventID)
+	assert.Equal(t, models.DomainEventPending, record.Status)
+
+	decoded, err := Decode([]byte(record.Payload))
+	require.NoError(t, err)
+	assert.Equal(t, &FeatureFlagUpdated{Flag: "sso", Enabled: true}, decoded.Payload)
+	assert.Equal(t, "user-1", decoded.ActorID)
+}
+
+func TestBus_PublishFailsWithSyncHandler(t *testing.T) {
+	bus := NewBus(&fakeOutbox{}, cachetest.NewStreams(), testEventsConfig(), zap.NewNop())
+	handlerErr := errors.New("audit store unavailable")
+	var later []string
+	bus.Subscribe("audit", func(ctx context.Context, event *Event) error {
+		return handlerErr
+	})
+	bus.Subscribe("later", func(ctx context.Context, event *Event) error {
+		later = append(later, event.ID)
+		return nil
+	})
+
+	err := bus.Publish(context.Background(), flagEvent("org-1", true))
+	assert.ErrorIs(t, err, handlerErr, "the publishing transaction is aborted")
+	assert.Empty(t, later)
+}
+
+func TestBus_RelayAndConsume(t *testing.T) {
+	outbox := &fakeOutbox{}
+	streams := cachetest.NewStreams()
+	cfg := testEventsConfig()
+	bus := NewBus(outbox, streams, cfg, zap.NewNop())
+
+	received := make(chan *Event, 10)
+	var handlerCtxActor string
+	var attempts int
+	bus.SubscribeAsync("flags", func(ctx context.Context, event *Event) error {
+		attempts++
+		if attempts == 1 {
+			return errors.New("temporary failure")
+		}
+		md, _ := requestctx.FromContext(ctx)
+		handlerCtxActor = md.UserID
+		received <- event
+		return nil
+	}, TypeFeatureFlagUpdated)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, streams.StreamCreateGroup(ctx, cfg.Stream, "flags"))
+	consumed := make(chan error, 1)
+	go func() { consumed <- bus.Consume(ctx) }()
+
+	_, err := streams.StreamAppend(ctx, cfg.Stream, []byte(`{"type":"organization.deleted"}`), 0)
+	require.NoError(t, err)
+
+	pubCtx := requestctx.WithMetadata(context.Background(), &requestctx.Metadata{UserID: "user-1"})
+	require.NoError(t, bus.Publish(pubCtx, New("org-1", "cycle", "c-1", &CycleCompleted{})))
+	require.NoError(t, bus.Publish(pubCtx, flagEvent("org-1", false)))
+
+	relayed, err := bus.RelayPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, relayed)
+	assert.Equal(t, 3, streams.Len(cfg.Stream))
+	assert.Equal(t, []string{models.DomainEventRelayed, models.DomainEventRelayed}, outbox.statuses())
+
+	select {
+	case event := <-received:
+		assert.Equal(t, TypeFeatureFlagUpdated, event.Type)
+		assert.Equal(t, &FeatureFlagUpdated{Flag: "sso", Enabled: false}, event.Payload)
+	case <-time.After(2 * time.Second):
+		t.Fatal("event was not delivered")
+	}
+	assert.Equal(t, "user-1", handlerCtxActor)
+	assert.Equal(t, 2, attempts)
+
+	// Uninteresting and undecodable messages are acknowledged too
+	require.Eventually(t, func() bool {
+		return streams.Pending(cfg.Stream, "flags") == 0
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-consumed)
+}
+
+func TestBus_DropsEventAfterMaxAttempts(t *testing.T) {
+	streams := cachetest.NewStreams()
+	cfg := testEventsConfig()
+	bus := NewBus(&fakeOutbox{}, streams, cfg, zap.NewNop())
+
+	var mu sync.Mutex
+	attempts := 0
+	bus.SubscribeAsync("broken", func(ctx context.Context, event *Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		return errors.New("permanent failure")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, streams.StreamCreateGroup(ctx, cfg.Stream, "broken"))
+	go bus.Consume(ctx)
+
+	require.NoError(t, bus.Publish(ctx, flagEvent("org-1", true)))
+	_, err := bus.RelayPending(ctx)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return attempts == cfg.MaxAttempts && streams.Pending(cfg.Stream, "broken") == 0
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestBus_RelayFailureBacksOff(t *testing.T) {
+	outbox := &fakeOutbox{}
+	cfg := testEventsConfig()
+	cfg.RetryDelay = time.Hour
+	cfg.MaxRetryDelay = 2 * time.Hour
+	bus := NewBus(outbox, failingStreams{cachetest.NewStreams()}, cfg, zap.NewNop())
+
+	ctx := context.Background()
+	require.NoError(t, bus.Publish(ctx, flagEvent("org-1", true)))
+
+	relayed, err := bus.RelayPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, relayed)
+
+	record := outbox.events[0]
+	assert.Equal(t, models.DomainEventPending, record.Status)
+	assert.Equal(t, 1, record.Attempts)
+	assert.Equal(t, "redis unavailable", record.LastError)
+	assert.True(t, record.NextAttemptAt.After(time.Now().Add(59*time.Minute)))
+
+	// Not due again until the backoff has passed
+	relayed, err = bus.RelayPending(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, relayed)
+
+	assert.Equal(t, time.Hour, bus.backoff(1))
+	assert.Equal(t, 2*time.Hour, bus.backoff(2))
+	assert.Equal(t, 2*time.Hour, bus.backoff(10))
+}
+
+func TestDecode_UnknownType(t *testing.T) {
+	_, err := Decode([]byte(`{"id":"1","type":"organization.deleted","payload":{}}`))
+	assert.ErrorIs(t, err, ErrUnknownEventType)
+
+	_, err = Decode([]byte(`not json`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,342 @@
+// Package events is the backend's domain event bus. Services publish typed
+// events describing what changed instead of calling every interested
+// component themselves; audit logging, cache invalidation, notifications and
+// webhooks subscribe to the events they care about.
+//
+// Synchronous handlers run in-process while the event is published, inside the
+// publisher's transaction if there is one. Every event is also written to a
+// transactional outbox, relayed to a Redis stream and delivered to the
+// asynchronous handlers, each of which consumes the stream through its own
+// consumer group and so sees every event once across all server instances.
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+)
+
+// Event types. Types shared with webhooks use the webhook event names.
+const (
+	TypeOrganizationCreated = "organization.created"
+	TypeFeatureFlagUpdated  = "organization.feature_flag_updated"
+	TypeSubscriptionRenewed = "organization.subscription_renewed"
+	TypeControlChanged      = "control.changed"
+	TypeEvidenceUploaded    = "evidence.uploaded"
+	TypeCycleCompleted      = "cycle.completed"
+	TypeFindingApproved     = "finding.approved"
+
+	// Evidence request and comment events keep the names of the audit
+	// actions recorded for them before they were published as events
+	TypeEvidenceReviewersAssigned = "evidence_request.reviewers_assigned"
+	TypeEvidenceSubmitted         = "evidence_request.submitted"
+	TypeEvidenceApproved          = "evidence_request.approved"
+	TypeEvidenceRejected          = "evidence_request.rejected"
+	TypeEvidenceCompleted         = "evidence_request.completed"
+	TypeEvidenceReminderSent      = "evidence_request.reminder_sent"
+	TypeEvidenceOverdue           = "evidence_request.overdue"
+	TypeEvidenceEscalated         = "evidence_request.escalated"
+	TypeCommentCreated            = "comment.created"
+	TypeCommentEdited             = "comment.edited"
+	TypeCommentDeleted            = "comment.deleted"
+)
+
+// ErrUnknownEventType is returned when decoding an event of an unregistered type.
+var ErrUnknownEventType = errors.New("unknown event type")
+
+// Payload is the typed body of an event. Each payload type reports its event
+// type; payloads are always used as pointers.
+type Payload interface {
+	EventType() string
+}
+
+// Event is a domain event: who changed which resource of which organization,
+// and a typed payload describing the change.
+type Event struct {
+	ID             string    `json:"id"`
+	Type           string    `json:"type"`
+	OrganizationID string    `json:"organization_id,omitempty"`
+	ActorID        string    `json:"actor_id,omitempty"`
+	CorrelationID  string    `json:"correlation_id,omitempty"`
+	ResourceType   string    `json:"resource_type"`
+	ResourceID     string    `json:"resource_id"`
+	OccurredAt     time.Time `json:"occurred_at"`
+	Payload        Payload   `json:"payload"`
+}
+
+// New creates an event about a resource of an organization. The ID, time and
+// actor are filled in when the event is published.
+//
+// Parameters:
+//   - orgID: Organization the resource belongs to
+//   - resourceType: Type of the changed resource, e.g. models.ResourceTypeControl
+//   - resourceID: ID of the changed resource
+//   - payload: Typed description of the change
+//
+// Returns:
+//   - *Event: Event ready to publish
+//
+// Example:
+//
+//	event := events.New(orgID, "organization", orgID, &events.FeatureFlagUpdated{Flag: "sso", Enabled: true})
+//	err := bus.Publish(ctx, event)
+func New(orgID, resourceType, resourceID string, payload Payload) *Event {
+	return &Event{
+		Type:           payload.EventType(),
+		OrganizationID: orgID,
+		ResourceType:   resourceType,
+		ResourceID:     resourceID,
+		Payload:        payload,
+	}
+}
+
+// Decode parses an event encoded as JSON into its typed payload.
+//
+// Parameters:
+//   - data: JSON encoded event
+//
+// Returns:
+//   - *Event: Decoded event
+//   - error: ErrUnknownEventType or a JSON error
+func Decode(data []byte) (*Event, error) {
+	var encoded struct {
+		Event
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, fmt.Errorf("failed to decode event: %w", err)
+	}
+
+	newPayload, ok := payloadTypes[encoded.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownEventType, encoded.Type)
+	}
+	event := encoded.Event
+	event.Payload = newPayload()
+	if len(encoded.Payload) > 0 {
+		if err := json.Unmarshal(encoded.Payload, event.Payload); err != nil {
+			return nil, fmt.Errorf("failed to decode %s payload: %w", encoded.Type, err)
+		}
+	}
+	return &event, nil
+}
+
+// payloadTypes creates an empty payload of each event type for decoding.
+var payloadTypes = map[string]func() Payload{
+	TypeOrganizationCreated: func() Payload { return &OrganizationCreated{} },
+	TypeFeatureFlagUpdated:  func() Payload { return &FeatureFlagUpdated{} },
+	TypeSubscriptionRenewed: func() Payload { return &SubscriptionRenewed{} },
+	TypeControlChanged:      func() Payload { return &ControlChanged{} },
+	TypeEvidenceUploaded:    func() Payload { return &EvidenceUploaded{} },
+	TypeCycleCompleted:      func() Payload { return &CycleCompleted{} },
+	TypeFindingApproved:     func() Payload { return &FindingApproved{} },
+
+	TypeEvidenceReviewersAssigned: func() Payload { return &EvidenceReviewersAssigned{} },
+	TypeEvidenceSubmitted:         func() Payload { return &EvidenceSubmitted{} },
+	TypeEvidenceApproved:          func() Payload { return &EvidenceApproved{} },
+	TypeEvidenceRejected:          func() Payload { return &EvidenceRejected{} },
+	TypeEvidenceCompleted:         func() Payload { return &EvidenceCompleted{} },
+	TypeEvidenceReminderSent:      func() Payload { return &EvidenceReminderSent{} },
+	TypeEvidenceOverdue:           func() Payload { return &EvidenceOverdue{} },
+	TypeEvidenceEscalated:         func() Payload { return &EvidenceEscalated{} },
+	TypeCommentCreated:            func() Payload { return &CommentCreated{} },
+	TypeCommentEdited:             func() Payload { return &CommentEdited{} },
+	TypeCommentDeleted:            func() Payload { return &CommentDeleted{} },
+}
+
+// OrganizationCreated is published when an organization is created.
+type OrganizationCreated struct {
+	Name             string `json:"name"`
+	Type             string `json:"type"`
+	Industry         string `json:"industry"`
+	SubscriptionPlan string `json:"subscription_plan"`
+}
+
+// EventType implements Payload.
+func (*OrganizationCreated) EventType() string { return TypeOrganizationCreated }
+
+// FeatureFlagUpdated is published when a feature flag of an organization is
+// switched on or off.
+type FeatureFlagUpdated struct {
+	Flag    string `json:"flag"`
+	Enabled bool   `json:"enabled"`
+}
+
+// EventType implements Payload.
+func (*FeatureFlagUpdated) EventType() string { return TypeFeatureFlagUpdated }
+
+// SubscriptionRenewed is published when a subscription is renewed for a new
+// billing period or ends because its trial or cancellation ran out.
+type SubscriptionRenewed struct {
+	Plan        string    `json:"plan"`
+	Status      string    `json:"status"`
+	PeriodStart time.Time `json:"period_start,omitempty"`
+	PeriodEnd   time.Time `json:"period_end,omitempty"`
+}
+
+// EventType implements Payload.
+func (*SubscriptionRenewed) EventType() string { return TypeSubscriptionRenewed }
+
+// ControlChanged is published when a control is created, updated or retired.
+type ControlChanged struct {
+	ControlID string   `json:"control_id"`
+	Title     string   `json:"title"`
+	Status    string   `json:"status"`
+	Fields    []string `json:"fields,omitempty"` // names of the changed fields
+}
+
+// EventType implements Payload.
+func (*ControlChanged) EventType() string { return TypeControlChanged }
+
+// EvidenceUploaded is published when a file is added to an evidence request.
+type EvidenceUploaded struct {
+	RequestID string `json:"request_id"`
+	FileName  string `json:"file_name"`
+	FileSize  int64  `json:"file_size"`
+}
+
+// EventType implements Payload.
+func (*EvidenceUploaded) EventType() string { return TypeEvidenceUploaded }
+
+// CycleCompleted is published when a testing cycle is completed.
+type CycleCompleted struct {
+	Cycle *models.TestingCycle `json:"cycle"`
+}
+
+// EventType implements Payload.
+func (*CycleCompleted) EventType() string { return TypeCycleCompleted }
+
+// FindingApproved is published when a test finding is approved.
+type FindingApproved struct {
+	FindingID      string `json:"finding_id"`
+	TestingCycleID string `json:"testing_cycle_id"`
+	Title          string `json:"title"`
+	Severity       string `json:"severity"`
+}
+
+// EventType implements Payload.
+func (*FindingApproved) EventType() string { return TypeFindingApproved }
+
+// EvidenceReviewersAssigned is published when the reviewers of an evidence
+// request are replaced. Reviewers of a request under review are asked to
+// review it.
+type EvidenceReviewersAssigned struct {
+	RequestID           string   `json:"request_id"`
+	ReviewerIDs         []string `json:"reviewer_ids"`
+	PreviousReviewerIDs []string `json:"previous_reviewer_ids,omitempty"`
+	UnderReview         bool     `json:"under_review"`
+}
+
+// EventType implements Payload.
+func (*EvidenceReviewersAssigned) EventType() string { return TypeEvidenceReviewersAssigned }
+
+// EvidenceSubmitted is published when evidence is submitted and a review
+// round starts.
+type EvidenceSubmitted struct {
+	RequestID      string `json:"request_id"`
+	ReviewRound    int    `json:"review_round"`
+	PreviousStatus string `json:"previous_status"`
+}
+
+// EventType implements Payload.
+func (*EvidenceSubmitted) EventType() string { return TypeEvidenceSubmitted }
+
+// EvidenceApproved is published when a reviewer approves submitted evidence.
+type EvidenceApproved struct {
+	RequestID   string `json:"request_id"`
+	ReviewRound int    `json:"review_round"`
+	Approvals   int    `json:"approvals"`
+}
+
+// EventType implements Payload.
+func (*EvidenceApproved) EventType() string { return TypeEvidenceApproved }
+
+// EvidenceRejected is published when a reviewer rejects submitted evidence
+// and the request is reopened.
+type EvidenceRejected struct {
+	RequestID   string `json:"request_id"`
+	ReviewRound int    `json:"review_round"`
+}
+
+// EventType implements Payload.
+func (*EvidenceRejected) EventType() string { return TypeEvidenceRejected }
+
+// EvidenceCompleted is published when an evidence request is completed,
+// either on submission or after enough approvals.
+type EvidenceCompleted struct {
+	RequestID        string `json:"request_id"`
+	ApprovalRequired bool   `json:"approval_required"`
+}
+
+// EventType implements Payload.
+func (*EvidenceCompleted) EventType() string { return TypeEvidenceCompleted }
+
+// EvidenceReminderSent is published when a deadline reminder of an evidence
+// request is due; the notification subscriber sends it.
+type EvidenceReminderSent struct {
+	RequestID string    `json:"request_id"`
+	Stage     int       `json:"reminder_stage"`
+	DueDate   time.Time `json:"due_date"`
+}
+
+// EventType implements Payload.
+func (*EvidenceReminderSent) EventType() string { return TypeEvidenceReminderSent }
+
+// EvidenceOverdue is published when an evidence request passes its due date.
+type EvidenceOverdue struct {
+	RequestID      string    `json:"request_id"`
+	PreviousStatus string    `json:"previous_status"`
+	DueDate        time.Time `json:"due_date"`
+}
+
+// EventType implements Payload.
+func (*EvidenceOverdue) EventType() string { return TypeEvidenceOverdue }
+
+// EvidenceEscalated is published when an overdue evidence request is
+// escalated to the assignee's manager.
+type EvidenceEscalated struct {
+	RequestID string    `json:"request_id"`
+	ManagerID string    `json:"manager_id"`
+	DueDate   time.Time `json:"due_date"`
+}
+
+// EventType implements Payload.
+func (*EvidenceEscalated) EventType() string { return TypeEvidenceEscalated }
+
+// CommentCreated is published when a comment is added to a resource.
+// NotifyUserIDs are the mentioned users to notify.
+type CommentCreated struct {
+	ResourceType  string   `json:"resource_type"`
+	ResourceID    string   `json:"resource_id"`
+	ParentID      string   `json:"parent_id,omitempty"`
+	Content       string   `json:"content"`
+	NotifyUserIDs []string `json:"notify_user_ids,omitempty"`
+}
+
+// EventType implements Payload.
+func (*CommentCreated) EventType() string { return TypeCommentCreated }
+
+// CommentEdited is published when the content of a comment is replaced.
+// NotifyUserIDs are the users newly mentioned by the edit.
+type CommentEdited struct {
+	ResourceType    string   `json:"resource_type"`
+	ResourceID      string   `json:"resource_id"`
+	PreviousContent string   `json:"previous_content"`
+	Content         string   `json:"content"`
+	NotifyUserIDs   []string `json:"notify_user_ids,omitempty"`
+}
+
+// EventType implements Payload.
+func (*CommentEdited) EventType() string { return TypeCommentEdited }
+
+// CommentDeleted is published when a comment is soft deleted.
+type CommentDeleted struct {
+	ResourceType string `json:"resource_type"`
+	ResourceID   string `json:"resource_id"`
+}
+
+// EventType implements Payload.
+func (*CommentDeleted) EventType() string { return TypeCommentDeleted }
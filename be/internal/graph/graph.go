@@ -0,0 +1,89 @@
+// Package graph serves the read-only GraphQL API over controls, testing
+// cycles, evidence requests and the caller's organization. The schema is
+// defined in schema.graphql and executed by pkg/graphql; records referenced
+// from lists, such as the control of each evidence request, are fetched
+// through per-request batching loaders so that a page of results costs one
+// repository call per referenced type.
+package graph
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/graphql"
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+// Server executes GraphQL requests against the schema.
+type Server struct {
+	schema  *graphql.Schema
+	queries services.QueryService
+	cfg     config.GraphQLConfig
+}
+
+// NewServer builds the schema and binds it to the query service.
+//
+// Parameters:
+//   - queries: Read-only service the resolvers fetch data from
+//   - cfg: Query limits and page sizes
+//
+// Returns:
+//   - *Server: Server ready to execute requests
+//   - error: Schema construction error
+//
+// Example:
+//
+//	server, err := graph.NewServer(queryService, cfg.GraphQL)
+//	resp := server.Execute(ctx, orgID, &graphql.Request{Query: "{ organization { name } }"})
+func NewServer(queries services.QueryService, cfg config.GraphQLConfig) (*Server, error) {
+	s := &Server{queries: queries, cfg: cfg}
+	schema, err := graphql.NewSchema(schemaSDL, s.resolvers(), graphql.Time)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build GraphQL schema: %w", err)
+	}
+	s.schema = schema
+	return s, nil
+}
+
+// Execute runs a request for a member of an organization. Every request gets
+// its own loaders, so cached records never leak between requests.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization of the caller; all data is scoped to it
+//   - req: GraphQL request
+//
+// Returns:
+//   - *graphql.Response: Result data and errors
+func (s *Server) Execute(ctx context.Context, orgID string, req *graphql.Request) *graphql.Response {
+	ctx = context.WithValue(ctx, requestKey{}, &requestState{
+		orgID:   orgID,
+		loaders: newLoaders(ctx, s.queries, orgID, s.cfg.MaxPageSize),
+	})
+	return s.schema.Execute(ctx, req, graphql.Limits{
+		MaxDepth:             s.cfg.MaxDepth,
+		MaxComplexity:        s.cfg.MaxComplexity,
+		MaxQueryLength:       s.cfg.MaxQueryLength,
+		MaxFragmentSpreads:   s.cfg.MaxFragmentSpreads,
+		DefaultPageSize:      s.cfg.DefaultPageSize,
+		DisableIntrospection: !s.cfg.Introspection,
+	})
+}
+
+// requestKey is the context key of the per-request state.
+type requestKey struct{}
+
+// requestState is the organization and loaders of one request.
+type requestState struct {
+	orgID   string
+	loaders *loaders
+}
+
+func stateFrom(ctx context.Context) *requestState {
+	return ctx.Value(requestKey{}).(*requestState)
+}
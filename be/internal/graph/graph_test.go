@@ -0,0 +1,315 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/graphql"
+)
+
+// fakeQueryService serves fixed records and counts batch lookups.
+type fakeQueryService struct {
+	services.QueryService
+
+	mu             sync.Mutex
+	org            *models.Organization
+	controls       []*models.Control
+	cycles         []*models.TestingCycle
+	requests       []*models.EvidenceRequest
+	users          []*models.User
+	controlBatches [][]string
+	userBatches    [][]string
+	lastFilter     *services.EvidenceRequestFilter
+}
+
+func (f *fakeQueryService) GetOrganization(ctx context.Context, orgID string) (*models.Organization, error) {
+	return f.org, nil
+}
+
+func (f *fakeQueryService) ListControls(ctx context.Context, filter *services.ControlFilter) (*services.ControlConnection, error) {
+	nodes := window(f.controls, filter.Offset, filter.Limit)
+	return &services.ControlConnection{Nodes: nodes, TotalCount: len(f.controls)}, nil
+}
+
+func (f *fakeQueryService) GetControl(ctx context.Context, orgID, id string) (*models.Control, error) {
+	for _, control := range f.controls {
+		if control.ID.Hex() == id {
+			return control, nil
+		}
+	}
+	return nil, services.ErrControlNotFound
+}
+
+func (f *fakeQueryService) GetControlsByIDs(ctx context.Context, orgID string, ids []string) ([]*models.Control, error) {
+	f.mu.Lock()
+	f.controlBatches = append(f.controlBatches, ids)
+	f.mu.Unlock()
+	var found []*models.Control
+	for _, control := range f.controls {
+		for _, id := range ids {
+			if control.ID.Hex() == id {
+				found = append(found, control)
+			}
+		}
+	}
+	return found, nil
+}
+
+func (f *fakeQueryService) GetTestingCyclesByIDs(ctx context.Context, orgID string, ids []string) ([]*models.TestingCycle, error) {
+	var found []*models.TestingCycle
+	for _, cycle := range f.cycles {
+		for _, id := range ids {
+			if cycle.ID.Hex() == id {
+				found = append(found, cycle)
+			}
+		}
+	}
+	return found, nil
+}
+
+func (f *fakeQueryService) GetTestingCycle(ctx context.Context, orgID, id string) (*models.TestingCycle, error) {
+	for _, cycle := range f.cycles {
+		if cycle.ID.Hex() == id {
+			return cycle, nil
+		}
+	}
+	return nil, services.ErrTestingCycleNotFound
+}
+
+func (f *fakeQueryService) ListEvidenceRequests(ctx context.Context, filter *services.EvidenceRequestFilter) (*services.EvidenceRequestConnection, error) {
+	f.lastFilter = filter
+	nodes := window(f.requests, filter.Offset, filter.Limit)
+	return &services.EvidenceRequestConnection{Nodes: nodes, TotalCount: len(f.requests)}, nil
+}
+
+func (f *fakeQueryService) GetUsersByIDs(ctx context.Context, orgID string, ids []string) ([]*models.User, error) {
+	f.mu.Lock()
+	f.userBatches = append(f.userBatches, ids)
+	f.mu.Unlock()
+	var found []*models.User
+	for _, user := range f.users {
+		for _, id := range ids {
+			if user.ID.Hex() == id {
+				found = append(found, user)
+			}
+		}
+	}
+	return found, nil
+}
+
+func window[T any](items []T, offset, limit int) []T {
+	if offset >= len(items) {
+		return nil
+	}
+	end := offset + limit
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[offset:end]
+}
+
+func testConfig() config.GraphQLConfig {
+	return config.GraphQLConfig{
+		Enabled:            true,
+		MaxDepth:           8,
+		MaxComplexity:      5000,
+		MaxQueryLength:     16384,
+		MaxFragmentSpreads: 100,
+		DefaultPageSize:    2,
+		MaxPageSize:        10,
+		Introspection:      true,
+	}
+}
+
+func newFixture(t *testing.T) (*fakeQueryService, *Server) {
+	t.Helper()
+
+	fake := &fakeQueryService{org: &models.Organization{Name: "Acme Bank", Slug: "acme"}}
+	fake.org.ID = primitive.NewObjectID()
+	for _, name := range []string{"Alice", "Bob"} {
+		user := &models.User{Email: name + "@example.com", Profile: models.UserProfile{FirstName: name, LastName: "Tester"}}
+		user.ID = primitive.NewObjectID()
+		fake.users = append(fake.users, user)
+	}
+	for _, id := range []string{"AC-1", "AC-2", "AC-3"} {
+		control := &models.Control{ControlID: id, Title: "Control " + id}
+		control.ID = primitive.NewObjectID()
+		fake.controls = append(fake.controls, control)
+	}
+	cycle := &models.TestingCycle{CycleID: "Q1", Name: "Q1 testing", ControlScope: []primitive.ObjectID{
+		fake.controls[0].ID, primitive.NewObjectID(), fake.controls[2].ID,
+	}}
+	cycle.ID = primitive.NewObjectID()
+	fake.cycles = append(fake.cycles, cycle)
+	for i := 0; i < 4; i++ {
+		request := &models.EvidenceRequest{
+			RequestID:  "ER-" + string(rune('1'+i)),
+			Title:      "Request",
+			Status:     "pending",
+			ControlID:  fake.controls[i%len(fake.controls)].ID,
+			CycleID:    cycle.ID,
+			AssigneeID: fake.users[i%2].ID,
+		}
+		request.ID = primitive.NewObjectID()
+		fake.requests = append(fake.requests, request)
+	}
+
+	server, err := NewServer(fake, testConfig())
+	require.NoError(t, err)
+	return fake, server
+}
+
+func execute(t *testing.T, server *Server, query string, variables map[string]interface{}) map[string]interface{} {
+	t.Helper()
+
+	resp := server.Execute(context.Background(), primitive.NewObjectID().Hex(), &graphql.Request{Query: query, Variables: variables})
+	require.Empty(t, resp.Errors)
+	raw, err := json.Marshal(resp.Data)
+	require.NoError(t, err)
+	var data map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &data))
+	return data
+}
+
+func TestServer_Organization(t *testing.T) {
+	fake, server := newFixture(t)
+
+	data := execute(t, server, `{ organization { id name slug memberCount } }`, nil)
+
+	assert.Equal(t, map[string]interface{}{
+		"id":          fake.org.ID.Hex(),
+		"name":        "Acme Bank",
+		"slug":        "acme",
+		"memberCount": float64(0),
+	}, data["organization"])
+}
+
+func TestServer_EvidenceRequestsBatchReferences(t *testing.T) {
+	fake, server := newFixture(t)
+
+	data := execute(t, server, `{
+		evidenceRequests(first: 4) {
+			edges { node { requestId control { controlId } assignee { fullName } assigner { id } } }
+		}
+	}`, nil)
+
+	edges := data["evidenceRequests"].(map[string]interface{})["edges"].([]interface{})
+	require.Len(t, edges, 4)
+	first := edges[0].(map[string]interface{})["node"].(map[string]interface{})
+	assert.Equal(t, "AC-1", first["control"].(map[string]interface{})["controlId"])
+	assert.Equal(t, "Alice Tester", first["assignee"].(map[string]interface{})["fullName"])
+	assert.Nil(t, first["assigner"])
+
+	assert.Len(t, fake.controlBatches, 1, "controls must be fetched in one batch")
+	assert.ElementsMatch(t, []string{fake.controls[0].ID.Hex(), fake.controls[1].ID.Hex(), fake.controls[2].ID.Hex()}, fake.controlBatches[0])
+	assert.Len(t, fake.userBatches, 1, "users must be fetched in one batch")
+	assert.Len(t, fake.userBatches[0], 2)
+}
+
+func TestServer_CursorPagination(t *testing.T) {
+	fake, server := newFixture(t)
+	query := `query($after: String) {
+		evidenceRequests(after: $after, filter: {status: "pending"}) {
+			edges { cursor node { requestId } }
+			pageInfo { hasNextPage hasPreviousPage endCursor }
+			totalCount
+		}
+	}`
+
+	data := execute(t, server, query, nil)
+	conn := data["evidenceRequests"].(map[string]interface{})
+	assert.Len(t, conn["edges"], 2, "the default page size applies")
+	assert.Equal(t, float64(4), conn["totalCount"])
+	assert.Equal(t, map[string]interface{}{"hasNextPage": true, "hasPreviousPage": false, "endCursor": encodeCursor(1)}, conn["pageInfo"])
+	assert.Equal(t, "pending", fake.lastFilter.Status)
+
+	data = execute(t, server, query, map[string]interface{}{"after": encodeCursor(1)})
+	conn = data["evidenceRequests"].(map[string]interface{})
+	edges := conn["edges"].([]interface{})
+	require.Len(t, edges, 2)
+	assert.Equal(t, "ER-3", edges[0].(map[string]interface{})["node"].(map[string]interface{})["requestId"])
+	assert.Equal(t, map[string]interface{}{"hasNextPage": false, "hasPreviousPage": true, "endCursor": encodeCursor(3)}, conn["pageInfo"])
+}
+
+func TestServer_PaginationArguments(t *testing.T) {
+	_, server := newFixture(t)
+
+	tests := []struct {
+		name    string
+		query   string
+		message string
+	}{
+		{"first above maximum", `{ controls(first: 11) { totalCount } }`, "first must be between 0 and 10"},
+		{"negative first", `{ controls(first: -1) { totalCount } }`, "first must be between 0 and 10"},
+		{"invalid cursor", `{ controls(after: "bogus") { totalCount } }`, "after is not a valid cursor"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := server.Execute(context.Background(), "org", &graphql.Request{Query: tt.query})
+			require.Len(t, resp.Errors, 1)
+			assert.Equal(t, tt.message, resp.Errors[0].Message)
+			var gqlErr *graphql.Error
+			assert.True(t, errors.As(resp.Errors[0].Err, &gqlErr))
+		})
+	}
+
+	t.Run("first zero", func(t *testing.T) {
+		data := execute(t, server, `{ controls(first: 0) { edges { cursor } totalCount pageInfo { hasNextPage } } }`, nil)
+		conn := data["controls"].(map[string]interface{})
+		assert.Empty(t, conn["edges"])
+		assert.Equal(t, float64(3), conn["totalCount"])
+		assert.Equal(t, true, conn["pageInfo"].(map[string]interface{})["hasNextPage"])
+	})
+}
+
+func TestServer_TestingCycleControls(t *testing.T) {
+	fake, server := newFixture(t)
+
+	data := execute(t, server, `query($id: ID!) { testingCycle(id: $id) { name controls { controlId systems } } }`,
+		map[string]interface{}{"id": fake.cycles[0].ID.Hex()})
+
+	cycle := data["testingCycle"].(map[string]interface{})
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"controlId": "AC-1", "systems": []interface{}{}},
+		map[string]interface{}{"controlId": "AC-3", "systems": []interface{}{}},
+	}, cycle["controls"], "controls that no longer exist are left out")
+}
+
+func TestServer_NotFoundIsNull(t *testing.T) {
+	_, server := newFixture(t)
+
+	data := execute(t, server, `{ control(id: "000000000000000000000000") { id } testingCycle(id: "x") { id } }`, nil)
+
+	assert.Nil(t, data["control"])
+	assert.Nil(t, data["testingCycle"])
+}
+
+func TestServer_Limits(t *testing.T) {
+	cfg := testConfig()
+	cfg.MaxDepth = 5
+	cfg.Introspection = false
+	server, err := NewServer(&fakeQueryService{}, cfg)
+	require.NoError(t, err)
+
+	resp := server.Execute(context.Background(), "org", &graphql.Request{
+		Query: `{ evidenceRequests(first: 10) { edges { node { cycle { controls { id } } } } } }`,
+	})
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "Query depth 6 exceeds the maximum depth of 5.", resp.Errors[0].Message)
+	assert.Nil(t, resp.Data)
+
+	resp = server.Execute(context.Background(), "org", &graphql.Request{Query: `{ __schema { queryType { name } } }`})
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "Introspection is disabled.", resp.Errors[0].Message)
+}
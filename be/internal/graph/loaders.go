@@ -0,0 +1,64 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/dataloader"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/graphql"
+)
+
+// loaders batch the lookups of records referenced by other records.
+type loaders struct {
+	controls *dataloader.Loader[string, *models.Control]
+	cycles   *dataloader.Loader[string, *models.TestingCycle]
+	users    *dataloader.Loader[string, *models.User]
+}
+
+// newLoaders creates the loaders of one request. Batches are limited to
+// maxBatch keys, the largest page a query can request.
+func newLoaders(ctx context.Context, queries services.QueryService, orgID string, maxBatch int) *loaders {
+	return &loaders{
+		controls: dataloader.New(ctx, func(ctx context.Context, ids []string) (map[string]*models.Control, error) {
+			controls, err := queries.GetControlsByIDs(ctx, orgID, ids)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[string]*models.Control, len(controls))
+			for _, control := range controls {
+				byID[control.ID.Hex()] = control
+			}
+			return byID, nil
+		}, maxBatch),
+		cycles: dataloader.New(ctx, func(ctx context.Context, ids []string) (map[string]*models.TestingCycle, error) {
+			cycles, err := queries.GetTestingCyclesByIDs(ctx, orgID, ids)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[string]*models.TestingCycle, len(cycles))
+			for _, cycle := range cycles {
+				byID[cycle.ID.Hex()] = cycle
+			}
+			return byID, nil
+		}, maxBatch),
+		users: dataloader.New(ctx, func(ctx context.Context, ids []string) (map[string]*models.User, error) {
+			users, err := queries.GetUsersByIDs(ctx, orgID, ids)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[string]*models.User, len(users))
+			for _, user := range users {
+				byID[user.ID.Hex()] = user
+			}
+			return byID, nil
+		}, maxBatch),
+	}
+}
+
+// thunk adapts a loader thunk to a GraphQL resolver result.
+func thunk[V any](load dataloader.Thunk[V]) graphql.Thunk {
+	return func() (interface{}, error) {
+		return load()
+	}
+}
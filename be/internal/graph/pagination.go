@@ -0,0 +1,108 @@
+package graph
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/graphql"
+)
+
+// cursorPrefix marks offset cursors, so that other strings are rejected.
+const cursorPrefix = "offset:"
+
+// encodeCursor returns the opaque cursor of the item at an offset.
+func encodeCursor(offset int) string {
+	return base64.StdEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+// decodeCursor returns the offset of a cursor.
+func decodeCursor(cursor string) (int, bool) {
+	raw, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(raw), cursorPrefix) {
+		return 0, false
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(raw), cursorPrefix))
+	if err != nil || offset < 0 {
+		return 0, false
+	}
+	return offset, true
+}
+
+// page is the slice of a list selected by the first and after arguments.
+type page struct {
+	limit  int
+	offset int
+}
+
+// fetchLimit is the number of records to fetch for the page. The services
+// treat a zero limit as the default page size, so first: 0 fetches one
+// record that newConnection then drops.
+func (p page) fetchLimit() int {
+	if p.limit == 0 {
+		return 1
+	}
+	return p.limit
+}
+
+// pageFrom reads the first and after arguments. first defaults to the
+// default page size and cannot exceed the maximum page size.
+func (s *Server) pageFrom(args map[string]interface{}) (page, error) {
+	p := page{limit: s.cfg.DefaultPageSize}
+	if first, ok := args["first"].(int); ok {
+		if first < 0 || first > s.cfg.MaxPageSize {
+			return page{}, &graphql.Error{Message: "first must be between 0 and " + strconv.Itoa(s.cfg.MaxPageSize)}
+		}
+		p.limit = first
+	}
+	if after, ok := args["after"].(string); ok {
+		offset, valid := decodeCursor(after)
+		if !valid {
+			return page{}, &graphql.Error{Message: "after is not a valid cursor"}
+		}
+		p.offset = offset + 1
+	}
+	return p, nil
+}
+
+// connection is a Relay connection resolved by field name.
+type connection struct {
+	Edges      []edge
+	PageInfo   pageInfo
+	TotalCount int
+}
+
+type edge struct {
+	Cursor string
+	Node   interface{}
+}
+
+type pageInfo struct {
+	HasNextPage     bool
+	HasPreviousPage bool
+	StartCursor     *string
+	EndCursor       *string
+}
+
+// newConnection builds a connection from a page of nodes.
+func newConnection[T any](p page, nodes []T, totalCount int) *connection {
+	if len(nodes) > p.limit {
+		nodes = nodes[:p.limit]
+	}
+	c := &connection{
+		Edges:      make([]edge, len(nodes)),
+		TotalCount: totalCount,
+		PageInfo: pageInfo{
+			HasNextPage:     p.offset+len(nodes) < totalCount,
+			HasPreviousPage: p.offset > 0,
+		},
+	}
+	for i, node := range nodes {
+		c.Edges[i] = edge{Cursor: encodeCursor(p.offset + i), Node: node}
+	}
+	if len(c.Edges) > 0 {
+		c.PageInfo.StartCursor = &c.Edges[0].Cursor
+		c.PageInfo.EndCursor = &c.Edges[len(c.Edges)-1].Cursor
+	}
+	return c
+}
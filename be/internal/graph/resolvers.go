@@ -0,0 +1,223 @@
+package graph
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/dataloader"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/graphql"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// resolvers binds the schema fields that the default resolver cannot read
+// directly from the models.
+func (s *Server) resolvers() graphql.Resolvers {
+	return graphql.Resolvers{
+		"Query": {
+			"organization":     s.organization,
+			"control":          s.control,
+			"controls":         s.controls,
+			"testingCycle":     s.testingCycle,
+			"testingCycles":    s.testingCycles,
+			"evidenceRequest":  s.evidenceRequest,
+			"evidenceRequests": s.evidenceRequests,
+		},
+		"User": {
+			"firstName":  userProfile(func(p models.UserProfile) string { return p.FirstName }),
+			"lastName":   userProfile(func(p models.UserProfile) string { return p.LastName }),
+			"title":      userProfile(func(p models.UserProfile) string { return p.Title }),
+			"department": userProfile(func(p models.UserProfile) string { return p.Department }),
+			"fullName": userProfile(func(p models.UserProfile) string {
+				return strings.TrimSpace(p.FirstName + " " + p.LastName)
+			}),
+		},
+		"Control": {
+			"systems":       controlList(func(c *models.Control) []string { return c.Systems }),
+			"evidenceTypes": controlList(func(c *models.Control) []string { return c.EvidenceTypes }),
+			"tags":          controlList(func(c *models.Control) []string { return c.Tags }),
+		},
+		"TestingCycle": {
+			"controls": cycleControls,
+		},
+		"EvidenceRequest": {
+			"evidenceTypes": func(p graphql.ResolveParams) (interface{}, error) {
+				return nonNil(p.Source.(*models.EvidenceRequest).EvidenceTypes), nil
+			},
+			"control": func(p graphql.ResolveParams) (interface{}, error) {
+				return load(stateFrom(p.Context).loaders.controls.Load, p.Source.(*models.EvidenceRequest).ControlID), nil
+			},
+			"cycle": func(p graphql.ResolveParams) (interface{}, error) {
+				return load(stateFrom(p.Context).loaders.cycles.Load, p.Source.(*models.EvidenceRequest).CycleID), nil
+			},
+			"assignee": func(p graphql.ResolveParams) (interface{}, error) {
+				return load(stateFrom(p.Context).loaders.users.Load, p.Source.(*models.EvidenceRequest).AssigneeID), nil
+			},
+			"assigner": func(p graphql.ResolveParams) (interface{}, error) {
+				return load(stateFrom(p.Context).loaders.users.Load, p.Source.(*models.EvidenceRequest).AssignerID), nil
+			},
+		},
+	}
+}
+
+func (s *Server) organization(p graphql.ResolveParams) (interface{}, error) {
+	return s.queries.GetOrganization(p.Context, stateFrom(p.Context).orgID)
+}
+
+func (s *Server) control(p graphql.ResolveParams) (interface{}, error) {
+	control, err := s.queries.GetControl(p.Context, stateFrom(p.Context).orgID, p.Args["id"].(string))
+	if errors.Is(err, services.ErrControlNotFound) {
+		return nil, nil
+	}
+	return control, err
+}
+
+func (s *Server) controls(p graphql.ResolveParams) (interface{}, error) {
+	pg, err := s.pageFrom(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	state := stateFrom(p.Context)
+	filter := &services.ControlFilter{
+		OrganizationID: state.orgID,
+		Limit:          pg.fetchLimit(),
+		Offset:         pg.offset,
+	}
+	if f, ok := p.Args["filter"].(map[string]interface{}); ok {
+		filter.Framework, _ = f["framework"].(string)
+		filter.Category, _ = f["category"].(string)
+		filter.RiskLevel, _ = f["riskLevel"].(string)
+		filter.Status, _ = f["status"].(string)
+		filter.Owner, _ = f["owner"].(string)
+		if tags, ok := f["tags"].([]interface{}); ok {
+			for _, tag := range tags {
+				filter.Tags = append(filter.Tags, tag.(string))
+			}
+		}
+	}
+
+	result, err := s.queries.ListControls(p.Context, filter)
+	if err != nil {
+		return nil, err
+	}
+	for _, control := range result.Nodes {
+		state.loaders.controls.Prime(control.ID.Hex(), control)
+	}
+	return newConnection(pg, result.Nodes, result.TotalCount), nil
+}
+
+func (s *Server) testingCycle(p graphql.ResolveParams) (interface{}, error) {
+	cycle, err := s.queries.GetTestingCycle(p.Context, stateFrom(p.Context).orgID, p.Args["id"].(string))
+	if errors.Is(err, services.ErrTestingCycleNotFound) {
+		return nil, nil
+	}
+	return cycle, err
+}
+
+func (s *Server) testingCycles(p graphql.ResolveParams) (interface{}, error) {
+	pg, err := s.pageFrom(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	state := stateFrom(p.Context)
+	result, err := s.queries.ListTestingCycles(p.Context, &services.TestingCycleFilter{
+		OrganizationID: state.orgID,
+		Limit:          pg.fetchLimit(),
+		Offset:         pg.offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, cycle := range result.Nodes {
+		state.loaders.cycles.Prime(cycle.ID.Hex(), cycle)
+	}
+	return newConnection(pg, result.Nodes, result.TotalCount), nil
+}
+
+func (s *Server) evidenceRequest(p graphql.ResolveParams) (interface{}, error) {
+	request, err := s.queries.GetEvidenceRequest(p.Context, stateFrom(p.Context).orgID, p.Args["id"].(string))
+	if errors.Is(err, services.ErrEvidenceRequestNotFound) {
+		return nil, nil
+	}
+	return request, err
+}
+
+func (s *Server) evidenceRequests(p graphql.ResolveParams) (interface{}, error) {
+	pg, err := s.pageFrom(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	filter := &services.EvidenceRequestFilter{
+		OrganizationID: stateFrom(p.Context).orgID,
+		Limit:          pg.fetchLimit(),
+		Offset:         pg.offset,
+	}
+	if f, ok := p.Args["filter"].(map[string]interface{}); ok {
+		filter.Status, _ = f["status"].(string)
+		filter.ControlID, _ = f["controlId"].(string)
+		filter.CycleID, _ = f["cycleId"].(string)
+		filter.AssigneeID, _ = f["assigneeId"].(string)
+	}
+
+	result, err := s.queries.ListEvidenceRequests(p.Context, filter)
+	if err != nil {
+		return nil, err
+	}
+	return newConnection(pg, result.Nodes, result.TotalCount), nil
+}
+
+// cycleControls resolves the controls in the scope of a testing cycle.
+// Controls that no longer exist are left out.
+func cycleControls(p graphql.ResolveParams) (interface{}, error) {
+	cycle := p.Source.(*models.TestingCycle)
+	ids := make([]string, len(cycle.ControlScope))
+	for i, id := range cycle.ControlScope {
+		ids[i] = id.Hex()
+	}
+	loadAll := stateFrom(p.Context).loaders.controls.LoadMany(ids)
+	return graphql.Thunk(func() (interface{}, error) {
+		controls, err := loadAll()
+		if err != nil {
+			return nil, err
+		}
+		found := make([]*models.Control, 0, len(controls))
+		for _, control := range controls {
+			if control != nil {
+				found = append(found, control)
+			}
+		}
+		return found, nil
+	}), nil
+}
+
+// load resolves a reference through a loader; an unset reference is null.
+func load[V any](loadFn func(string) dataloader.Thunk[V], id primitive.ObjectID) interface{} {
+	if id.IsZero() {
+		return nil
+	}
+	return thunk(loadFn(id.Hex()))
+}
+
+// userProfile resolves a User field from the profile.
+func userProfile(get func(models.UserProfile) string) graphql.FieldResolver {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(*models.User).Profile), nil
+	}
+}
+
+// controlList resolves a non-null list field of a Control.
+func controlList(get func(*models.Control) []string) graphql.FieldResolver {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return nonNil(get(p.Source.(*models.Control))), nil
+	}
+}
+
+// nonNil returns an empty list for a nil slice, which would otherwise be
+// resolved as null.
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// AccountHandler exposes the signed-in user's own credentials, and the reset
+// of forgotten passwords, over HTTP.
+type AccountHandler struct {
+	authService services.AuthenticationService
+	logger      *zap.Logger
+}
+
+// NewAccountHandler creates a new account handler.
+//
+// Parameters:
+//   - authService: Service managing passwords and security questions
+//   - logger: Logger for handler operations
+//
+// Returns:
+//   - *AccountHandler: Configured handler instance
+func NewAccountHandler(authService services.AuthenticationService, logger *zap.Logger) *AccountHandler {
+	return &AccountHandler{
+		authService: authService,
+		logger:      logger,
+	}
+}
+
+// RegisterRoutes registers the account routes on the given authenticated router group.
+func (h *AccountHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	account := rg.Group("/account")
+	account.POST("/password", h.ChangePassword)
+	account.PUT("/security-questions", h.SetSecurityQuestions)
+}
+
+// RegisterPasswordResetRoutes registers the public password reset routes on the given router group.
+func (h *AccountHandler) RegisterPasswordResetRoutes(rg *gin.RouterGroup) {
+	rg.POST("/auth/password/forgot", h.ForgotPassword)
+	rg.GET("/auth/password/reset", h.LookupPasswordReset)
+	rg.POST("/auth/password/reset/verify", h.VerifyPasswordResetAnswer)
+	rg.POST("/auth/password/reset", h.ResetPassword)
+}
+
+// changePasswordRequest is the body of POST /account/password.
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// ChangePassword handles POST /account/password. The new password must
+// satisfy the organization's password policy.
+func (h *AccountHandler) ChangePassword(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	var request changePasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBadRequest(c, err)
+		return
+	}
+
+	userID := orgContext.UserID.Hex()
+	if err := h.authService.ChangePassword(c.Request.Context(), userID, request.CurrentPassword, request.NewPassword); err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, userID)
+	c.Status(http.StatusNoContent)
+}
+
+// securityQuestionsRequest is the body of PUT /account/security-questions.
+type securityQuestionsRequest struct {
+	Questions []struct {
+		Question string `json:"question" binding:"required"`
+		Answer   string `json:"answer" binding:"required"`
+	} `json:"questions" binding:"dive"`
+}
+
+// SetSecurityQuestions handles PUT /account/security-questions and replaces
+// the questions asked before a password reset. An empty list removes them.
+func (h *AccountHandler) SetSecurityQuestions(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	var request securityQuestionsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBadRequest(c, err)
+		return
+	}
+
+	// The service hashes the plain answers carried in AnswerHash
+	questions := make([]models.SecurityQuestion, 0, len(request.Questions))
+	for _, question := range request.Questions {
+		questions = append(questions, models.SecurityQuestion{Question: question.Question, AnswerHash: question.Answer})
+	}
+	userID := orgContext.UserID.Hex()
+	if err := h.authService.SetSecurityQuestions(c.Request.Context(), userID, questions); err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, userID)
+	c.Status(http.StatusNoContent)
+}
+
+// forgotPasswordRequest is the body of POST /auth/password/forgot.
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+// ForgotPassword handles POST /auth/password/forgot. It answers 202 whether
+// or not the address belongs to an account.
+func (h *AccountHandler) ForgotPassword(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	var request forgotPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBadRequest(c, err)
+		return
+	}
+
+	if err := h.authService.ResetPassword(c.Request.Context(), request.Email); err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// LookupPasswordReset handles GET /auth/password/reset?token=... and returns
+// the security question to answer before the password can be reset.
+func (h *AccountHandler) LookupPasswordReset(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	details, err := h.authService.LookupPasswordReset(c.Request.Context(), c.Query("token"))
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, details)
+}
+
+// verifyPasswordResetRequest is the body of POST /auth/password/reset/verify.
+type verifyPasswordResetRequest struct {
+	Token  string `json:"token" binding:"required"`
+	Answer string `json:"answer" binding:"required"`
+}
+
+// VerifyPasswordResetAnswer handles POST /auth/password/reset/verify.
+func (h *AccountHandler) VerifyPasswordResetAnswer(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	var request verifyPasswordResetRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBadRequest(c, err)
+		return
+	}
+
+	if err := h.authService.VerifyPasswordResetAnswer(c.Request.Context(), request.Token, request.Answer); err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// resetPasswordRequest is the body of POST /auth/password/reset.
+type resetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// ResetPassword handles POST /auth/password/reset. The new password must
+// satisfy the organization's password policy; every session of the user ends.
+func (h *AccountHandler) ResetPassword(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	var request resetPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBadRequest(c, err)
+		return
+	}
+
+	if err := h.authService.ValidatePasswordReset(c.Request.Context(), request.Token, request.NewPassword); err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
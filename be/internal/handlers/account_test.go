@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// MockAuthenticationService is a mock of the AuthenticationService methods the account handler calls.
+type MockAuthenticationService struct {
+	services.AuthenticationService
+	mock.Mock
+}
+
+func (m *MockAuthenticationService) ChangePassword(ctx context.Context, userID, oldPassword, newPassword string) error {
+	return m.Called(ctx, userID, oldPassword, newPassword).Error(0)
+}
+
+func (m *MockAuthenticationService) SetSecurityQuestions(ctx context.Context, userID string, questions []models.SecurityQuestion) error {
+	return m.Called(ctx, userID, questions).Error(0)
+}
+
+func (m *MockAuthenticationService) ResetPassword(ctx context.Context, email string) error {
+	return m.Called(ctx, email).Error(0)
+}
+
+func (m *MockAuthenticationService) LookupPasswordReset(ctx context.Context, token string) (*services.PasswordResetDetails, error) {
+	args := m.Called(ctx, token)
+	details, _ := args.Get(0).(*services.PasswordResetDetails)
+	return details, args.Error(1)
+}
+
+func (m *MockAuthenticationService) VerifyPasswordResetAnswer(ctx context.Context, token, answer string) error {
+	return m.Called(ctx, token, answer).Error(0)
+}
+
+func (m *MockAuthenticationService) ValidatePasswordReset(ctx context.Context, token, newPassword string) error {
+	return m.Called(ctx, token, newPassword).Error(0)
+}
+
+func TestAccountHandler_ChangePassword(t *testing.T) {
+	doc, err := OpenAPIDocument()
+	require.NoError(t, err)
+	orgContext := &middleware.OrganizationContext{
+		OrganizationID: primitive.NewObjectID(),
+		UserID:         primitive.NewObjectID(),
+	}
+	userID := orgContext.UserID.Hex()
+
+	tests := []struct {
+		name           string
+		body           string
+		serviceErr     error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"changed", `{"current_password":"correct horse battery","new_password":"quiet meadow lantern"}`, nil, http.StatusNoContent, ""},
+		{"wrong current password", `{"current_password":"wrong password!","new_password":"quiet meadow lantern"}`, services.ErrIncorrectPassword, http.StatusUnauthorized, "INCORRECT_PASSWORD"},
+		{"common", `{"current_password":"correct horse battery","new_password":"quiet meadow lantern"}`, services.ErrPasswordCommon, http.StatusBadRequest, "PASSWORD_COMMON"},
+		{"breached", `{"current_password":"correct horse battery","new_password":"quiet meadow lantern"}`, services.ErrPasswordBreached, http.StatusBadRequest, "PASSWORD_BREACHED"},
+		{"reused", `{"current_password":"correct horse battery","new_password":"quiet meadow lantern"}`, services.ErrPasswordReused, http.StatusBadRequest, "PASSWORD_REUSED"},
+		{"missing new password", `{"current_password":"correct horse battery"}`, nil, http.StatusBadRequest, "INVALID_REQUEST_BODY"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authService := new(MockAuthenticationService)
+			if tt.expectedCode != "INVALID_REQUEST_BODY" {
+				authService.On("ChangePassword", mock.Anything, userID, mock.Anything, "quiet meadow lantern").Return(tt.serviceErr)
+			}
+			router := newTestRouter(orgContext, func(rg *gin.RouterGroup) {
+				NewAccountHandler(authService, zap.NewNop()).RegisterRoutes(rg)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/account/password", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedCode)
+			}
+			assert.NoError(t, doc.Operation(http.MethodPost, "/account/password").ValidateResponse(w.Code, w.Header(), w.Body.Bytes()))
+			authService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAccountHandler_SetSecurityQuestions(t *testing.T) {
+	doc, err := OpenAPIDocument()
+	require.NoError(t, err)
+	orgContext := &middleware.OrganizationContext{
+		OrganizationID: primitive.NewObjectID(),
+		UserID:         primitive.NewObjectID(),
+	}
+
+	authService := new(MockAuthenticationService)
+	authService.On("SetSecurityQuestions", mock.Anything, orgContext.UserID.Hex(), []models.SecurityQuestion{
+		{Question: "First pet?", AnswerHash: "Rex"},
+	}).Return(nil)
+	router := newTestRouter(orgContext, func(rg *gin.RouterGroup) {
+		NewAccountHandler(authService, zap.NewNop()).RegisterRoutes(rg)
+	})
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/account/security-questions",
+		strings.NewReader(`{"questions":[{"question":"First pet?","answer":"Rex"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.NoError(t, doc.Operation(http.MethodPut, "/account/security-questions").ValidateResponse(w.Code, w.Header(), w.Body.Bytes()))
+	authService.AssertExpectations(t)
+}
+
+func TestAccountHandler_PasswordReset(t *testing.T) {
+	doc, err := OpenAPIDocument()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		target         string
+		body           string
+		setup          func(*MockAuthenticationService)
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name: "forgot", method: http.MethodPost, path: "/auth/password/forgot", target: "/api/v1/auth/password/forgot",
+			body: `{"email":"nobody@first-bank.com"}`,
+			setup: func(m *MockAuthenticationService) {
+				m.On("ResetPassword", mock.Anything, "nobody@first-bank.com").Return(nil)
+			},
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name: "forgot rate limited", method: http.MethodPost, path: "/auth/password/forgot", target: "/api/v1/auth/password/forgot",
+			body: `{"email":"ada@first-bank.com"}`,
+			setup: func(m *MockAuthenticationService) {
+				m.On("ResetPassword", mock.Anything, "ada@first-bank.com").Return(services.ErrTooManyResetRequests)
+			},
+			expectedStatus: http.StatusTooManyRequests, expectedCode: "TOO_MANY_RESET_REQUESTS",
+		},
+		{
+			name: "lookup", method: http.MethodGet, path: "/auth/password/reset", target: "/api/v1/auth/password/reset?token=abc",
+			setup: func(m *MockAuthenticationService) {
+				m.On("LookupPasswordReset", mock.Anything, "abc").Return(&services.PasswordResetDetails{
+					SecurityQuestion: "First pet?", AnswerRequired: true, ExpiresAt: time.Now().Add(time.Hour),
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "wrong answer", method: http.MethodPost, path: "/auth/password/reset/verify", target: "/api/v1/auth/password/reset/verify",
+			body: `{"token":"abc","answer":"Felix"}`,
+			setup: func(m *MockAuthenticationService) {
+				m.On("VerifyPasswordResetAnswer", mock.Anything, "abc", "Felix").Return(services.ErrIncorrectSecurityAnswer)
+			},
+			expectedStatus: http.StatusUnauthorized, expectedCode: "INCORRECT_SECURITY_ANSWER",
+		},
+		{
+			name: "reset", method: http.MethodPost, path: "/auth/password/reset", target: "/api/v1/auth/password/reset",
+			body: `{"token":"abc","new_password":"quiet meadow lantern"}`,
+			setup: func(m *MockAuthenticationService) {
+				m.On("ValidatePasswordReset", mock.Anything, "abc", "quiet meadow lantern").Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name: "used link", method: http.MethodPost, path: "/auth/password/reset", target: "/api/v1/auth/password/reset",
+			body: `{"token":"abc","new_password":"quiet meadow lantern"}`,
+			setup: func(m *MockAuthenticationService) {
+				m.On("ValidatePasswordReset", mock.Anything, "abc", "quiet meadow lantern").Return(services.ErrInvalidPasswordReset)
+			},
+			expectedStatus: http.StatusGone, expectedCode: "INVALID_PASSWORD_RESET",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authService := new(MockAuthenticationService)
+			tt.setup(authService)
+			router := newTestRouter(&middleware.OrganizationContext{}, func(rg *gin.RouterGroup) {
+				NewAccountHandler(authService, zap.NewNop()).RegisterPasswordResetRoutes(rg)
+			})
+
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+			if tt.expectedCode != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedCode)
+			}
+			assert.NoError(t, doc.Operation(tt.method, tt.path).ValidateResponse(w.Code, w.Header(), w.Body.Bytes()))
+			authService.AssertExpectations(t)
+		})
+	}
+}
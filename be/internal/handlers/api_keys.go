@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// APIKeyHandler exposes an organization's API keys over HTTP. All routes are
+// restricted to administrators and cannot be called with an API key.
+type APIKeyHandler struct {
+	apiKeyService services.APIKeyService
+	logger        *zap.Logger
+}
+
+// NewAPIKeyHandler creates a new API key handler.
+//
+// Parameters:
+//   - apiKeyService: Service managing API keys
+//   - logger: Logger for handler operations
+//
+// Returns:
+//   - *APIKeyHandler: Configured handler instance
+func NewAPIKeyHandler(apiKeyService services.APIKeyService, logger *zap.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+		logger:        logger,
+	}
+}
+
+// RegisterRoutes registers the API key routes on the given router group.
+func (h *APIKeyHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	keys := rg.Group("/api-keys", middleware.RequireRole(models.RoleAdmin))
+	keys.GET("", h.List)
+	keys.POST("", h.Create)
+	keys.GET("/:id", h.Get)
+	keys.POST("/:id/rotate", h.Rotate)
+	keys.POST("/:id/revoke", h.Revoke)
+}
+
+// List handles GET /api-keys.
+func (h *APIKeyHandler) List(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	keys, err := h.apiKeyService.ListAPIKeys(c.Request.Context(), orgContext.OrganizationID.Hex())
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+// Create handles POST /api-keys. The response is the only time the key is
+// shown, apart from rotations.
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	var input services.APIKeyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBadRequest(c, err)
+		return
+	}
+	input.OrganizationID = orgContext.OrganizationID.Hex()
+	input.CreatedBy = orgContext.UserID.Hex()
+
+	created, err := h.apiKeyService.CreateAPIKey(c.Request.Context(), &input)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, created.APIKey.ID.Hex())
+	c.JSON(http.StatusCreated, created)
+}
+
+// Get handles GET /api-keys/:id.
+func (h *APIKeyHandler) Get(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	key, err := h.apiKeyService.GetAPIKey(c.Request.Context(), orgContext.OrganizationID.Hex(), c.Param("id"))
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+// Rotate handles POST /api-keys/:id/rotate with an optional body setting
+// overlap_hours.
+func (h *APIKeyHandler) Rotate(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	var input services.RotateAPIKeyInput
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			respondBadRequest(c, err)
+			return
+		}
+	}
+	input.OrganizationID = orgContext.OrganizationID.Hex()
+	input.APIKeyID = c.Param("id")
+	input.RotatedBy = orgContext.UserID.Hex()
+
+	previous, err := h.apiKeyService.GetAPIKey(c.Request.Context(), input.OrganizationID, input.APIKeyID)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+	before := middleware.AuditSnapshot(previous)
+
+	rotated, err := h.apiKeyService.RotateAPIKey(c.Request.Context(), &input)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	// The rotated key now expires at the end of the overlap and points to its replacement
+	middleware.SetAuditResourceID(c, c.Param("id"))
+	if current, err := h.apiKeyService.GetAPIKey(c.Request.Context(), input.OrganizationID, input.APIKeyID); err == nil {
+		middleware.SetAuditChange(c, before, middleware.AuditSnapshot(current))
+	}
+	c.JSON(http.StatusOK, rotated)
+}
+
+// Revoke handles POST /api-keys/:id/revoke.
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	previous, err := h.apiKeyService.GetAPIKey(c.Request.Context(), orgContext.OrganizationID.Hex(), c.Param("id"))
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+	before := middleware.AuditSnapshot(previous)
+
+	key, err := h.apiKeyService.RevokeAPIKey(c.Request.Context(), orgContext.OrganizationID.Hex(), c.Param("id"), orgContext.UserID.Hex())
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, key.ID.Hex())
+	middleware.SetAuditChange(c, before, middleware.AuditSnapshot(key))
+	c.JSON(http.StatusOK, key)
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// MockAPIKeyService is a mock of the administrative methods of APIKeyService;
+// authentication is not used by handlers.
+type MockAPIKeyService struct {
+	services.APIKeyService
+	mock.Mock
+}
+
+func (m *MockAPIKeyService) CreateAPIKey(ctx context.Context, input *services.APIKeyInput) (*services.APIKeySecret, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.APIKeySecret), args.Error(1)
+}
+
+func (m *MockAPIKeyService) GetAPIKey(ctx context.Context, orgID, keyID string) (*models.APIKey, error) {
+	args := m.Called(ctx, orgID, keyID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyService) ListAPIKeys(ctx context.Context, orgID string) ([]*models.APIKey, error) {
+	args := m.Called(ctx, orgID)
+	return args.Get(0).([]*models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyService) RotateAPIKey(ctx context.Context, input *services.RotateAPIKeyInput) (*services.APIKeySecret, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.APIKeySecret), args.Error(1)
+}
+
+func (m *MockAPIKeyService) RevokeAPIKey(ctx context.Context, orgID, keyID, revokedBy string) (*models.APIKey, error) {
+	args := m.Called(ctx, orgID, keyID, revokedBy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func TestAPIKeyHandler_Routes(t *testing.T) {
+	orgID := primitive.NewObjectID()
+	adminID := primitive.NewObjectID()
+	key := &models.APIKey{ID: primitive.NewObjectID(), OrganizationID: orgID, Name: "Evidence sync", Prefix: "goedu_0a1b2c3d"}
+	keyID := key.ID.Hex()
+	missingID := primitive.NewObjectID().Hex()
+
+	service := new(MockAPIKeyService)
+	service.On("ListAPIKeys", mock.Anything, orgID.Hex()).Return([]*models.APIKey{key}, nil)
+	service.On("CreateAPIKey", mock.Anything, mock.MatchedBy(func(input *services.APIKeyInput) bool {
+		return input.OrganizationID == orgID.Hex() && input.CreatedBy == adminID.Hex() && input.Name == "Evidence sync"
+	})).Return(&services.APIKeySecret{APIKey: key, Key: "goedu_0a1b2c3d_secret"}, nil)
+	service.On("CreateAPIKey", mock.Anything, mock.Anything).Return(nil, services.ErrInvalidAPIKeyScope)
+	service.On("GetAPIKey", mock.Anything, orgID.Hex(), keyID).Return(key, nil)
+	service.On("GetAPIKey", mock.Anything, orgID.Hex(), missingID).Return(nil, services.ErrAPIKeyNotFound)
+	service.On("RotateAPIKey", mock.Anything, mock.MatchedBy(func(input *services.RotateAPIKeyInput) bool {
+		return input.APIKeyID == keyID && input.OverlapHours != nil && *input.OverlapHours == 48
+	})).Return(&services.APIKeySecret{APIKey: key, Key: "goedu_0a1b2c3d_rotated"}, nil)
+	service.On("RotateAPIKey", mock.Anything, mock.MatchedBy(func(input *services.RotateAPIKeyInput) bool {
+		return input.APIKeyID == keyID && input.OverlapHours == nil
+	})).Return(nil, services.ErrAPIKeyRotated)
+	service.On("RevokeAPIKey", mock.Anything, orgID.Hex(), keyID, adminID.Hex()).Return(key, nil)
+
+	tests := []struct {
+		name           string
+		role           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"admin lists keys", models.RoleAdmin, http.MethodGet, "/api-keys", "", http.StatusOK, `"prefix":"goedu_0a1b2c3d"`},
+		{"manager cannot list keys", models.RoleManager, http.MethodGet, "/api-keys", "", http.StatusForbidden, "INSUFFICIENT_ROLE"},
+		{"create returns key", models.RoleAdmin, http.MethodPost, "/api-keys", `{"name":"Evidence sync","role":"viewer","scopes":["controls:read"]}`, http.StatusCreated, "goedu_0a1b2c3d_secret"},
+		{"create with bad scope", models.RoleAdmin, http.MethodPost, "/api-keys", `{"name":"Sync","role":"viewer","scopes":["controls"]}`, http.StatusBadRequest, "INVALID_API_KEY_SCOPE"},
+		{"create with malformed body", models.RoleAdmin, http.MethodPost, "/api-keys", `{"name":`, http.StatusBadRequest, "INVALID_REQUEST_BODY"},
+		{"get missing key", models.RoleAdmin, http.MethodGet, "/api-keys/" + missingID, "", http.StatusNotFound, "API_KEY_NOT_FOUND"},
+		{"rotate with overlap", models.RoleAdmin, http.MethodPost, "/api-keys/" + keyID + "/rotate", `{"overlap_hours":48}`, http.StatusOK, "goedu_0a1b2c3d_rotated"},
+		{"rotate without body", models.RoleAdmin, http.MethodPost, "/api-keys/" + keyID + "/rotate", "", http.StatusConflict, "API_KEY_ROTATED"},
+		{"revoke key", models.RoleAdmin, http.MethodPost, "/api-keys/" + keyID + "/revoke", "", http.StatusOK, keyID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgContext := &middleware.OrganizationContext{OrganizationID: orgID, UserID: adminID, UserRole: tt.role}
+			router := newTestRouter(orgContext, NewAPIKeyHandler(service, zap.NewNop()).RegisterRoutes)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, "/api/v1"+tt.path, strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+			assert.NotContains(t, w.Body.String(), "key_hash")
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// AuditHandler exposes audit log exports, chain verification and checkpoint
+// export over HTTP. Routes are restricted to administrators and auditors of
+// the organization.
+type AuditHandler struct {
+	auditService  services.AuditService
+	chainService  services.AuditChainService
+	exportService services.AuditExportService
+	logger        *zap.Logger
+}
+
+// NewAuditHandler creates a new audit handler.
+//
+// Parameters:
+//   - auditService: Service producing synchronous audit log exports
+//   - chainService: Service verifying the audit chain and exporting checkpoints
+//   - exportService: Service running background audit log exports
+//   - logger: Logger for handler operations
+//
+// Returns:
+//   - *AuditHandler: Configured handler instance
+func NewAuditHandler(
+	auditService services.AuditService,
+	chainService services.AuditChainService,
+	exportService services.AuditExportService,
+	logger *zap.Logger,
+) *AuditHandler {
+	return &AuditHandler{
+		auditService:  auditService,
+		chainService:  chainService,
+		exportService: exportService,
+		logger:        logger,
+	}
+}
+
+// RegisterRoutes registers the audit routes on the given router group.
+func (h *AuditHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	audit := rg.Group("/audit", middleware.RequireRole(models.RoleAdmin, models.RoleAuditor))
+	audit.GET("/export", h.Export)
+	audit.POST("/exports", h.RequestExport)
+	audit.GET("/exports/:id", h.GetExport)
+	audit.GET("/exports/:id/download", h.DownloadExport)
+	audit.GET("/verify", h.Verify)
+	audit.GET("/checkpoints/export", h.ExportCheckpoints)
+}
+
+// Export handles GET /audit/export?format=csv|jsonl|pdf with optional user_id,
+// action, resource_type, resource_id, start and end (RFC 3339) filters. The file
+// is returned directly; its manifest is returned in X-Export-* headers.
+func (h *AuditHandler) Export(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	filter := &services.AuditFilter{
+		OrganizationID: orgContext.OrganizationID.Hex(),
+		UserID:         c.Query("user_id"),
+		Action:         c.Query("action"),
+		ResourceType:   c.Query("resource_type"),
+		ResourceID:     c.Query("resource_id"),
+	}
+	if c.Query("start") != "" || c.Query("end") != "" {
+		filter.TimeRange = &services.TimeRange{Start: c.Query("start"), End: c.Query("end")}
+	}
+
+	doc, err := h.auditService.ExportAuditLog(c.Request.Context(), filter, c.DefaultQuery("format", models.ExportFormatCSV))
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	if manifest, ok := doc.Metadata["manifest"].(*models.ExportManifest); ok {
+		setManifestHeaders(c, manifest)
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", doc.Name))
+	c.Data(http.StatusOK, doc.Type, doc.Content)
+}
+
+// RequestExport handles POST /audit/exports and queues a background export.
+func (h *AuditHandler) RequestExport(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	var input services.AuditExportInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBadRequest(c, err)
+		return
+	}
+	input.OrganizationID = orgContext.OrganizationID.Hex()
+	input.RequestedBy = orgContext.UserID.Hex()
+
+	job, err := h.exportService.RequestExport(c.Request.Context(), &input)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, job.ID.Hex())
+	c.JSON(http.StatusAccepted, exportResponse(c, job))
+}
+
+// GetExport handles GET /audit/exports/:id. Completed exports include their
+// manifest and a download link valid until the export expires.
+func (h *AuditHandler) GetExport(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	job, err := h.exportService.GetExport(c.Request.Context(), orgContext.OrganizationID.Hex(), c.Param("id"))
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, exportResponse(c, job))
+}
+
+// DownloadExport handles GET /audit/exports/:id/download?token=... and streams
+// the export file.
+func (h *AuditHandler) DownloadExport(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	download, err := h.exportService.OpenDownload(c.Request.Context(), orgContext.OrganizationID.Hex(), c.Param("id"), c.Query("token"))
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+	defer download.Content.Close()
+
+	setManifestHeaders(c, download.Manifest)
+	c.DataFromReader(http.StatusOK, download.Manifest.SizeBytes, download.ContentType, download.Content, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", download.FileName),
+	})
+}
+
+// Verify handles GET /audit/verify. It always responds 200 with the
+// verification result; a broken chain is reported in the body.
+func (h *AuditHandler) Verify(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	result, err := h.chainService.VerifyChain(c.Request.Context(), orgContext.OrganizationID.Hex())
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ExportCheckpoints handles GET /audit/checkpoints/export and returns the
+// notary file as a JSON attachment.
+func (h *AuditHandler) ExportCheckpoints(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	export, err := h.chainService.ExportCheckpoints(c.Request.Context(), orgContext.OrganizationID.Hex())
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	filename := fmt.Sprintf("audit-checkpoints-%s-%s.json", export.OrganizationID, export.ExportedAt.Format("20060102T150405Z"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.JSON(http.StatusOK, export)
+}
+
+// exportResponse renders an export job together with its status and download links.
+func exportResponse(c *gin.Context, job *models.ExportJob) gin.H {
+	// Both calling routes live below ".../audit/exports"
+	route := c.FullPath()
+	base := route[:strings.Index(route, "/exports")] + "/exports/" + job.ID.Hex()
+
+	response := gin.H{
+		"export":     job,
+		"status_url": base,
+	}
+	if job.IsDownloadable(time.Now()) {
+		response["download_url"] = base + "/download?token=" + url.QueryEscape(job.DownloadToken)
+	}
+	return response
+}
+
+// setManifestHeaders exposes the essential manifest fields of an export file.
+func setManifestHeaders(c *gin.Context, manifest *models.ExportManifest) {
+	c.Header("X-Export-Row-Count", strconv.FormatInt(manifest.RowCount, 10))
+	c.Header("X-Export-SHA256", manifest.SHA256)
+	if manifest.Signature != "" {
+		c.Header("X-Export-Key-ID", manifest.KeyID)
+		c.Header("X-Export-Signature", manifest.Signature)
+	}
+}
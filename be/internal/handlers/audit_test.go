@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// MockAuditChainService is a mock implementation of AuditChainService for testing
+type MockAuditChainService struct {
+	mock.Mock
+}
+
+func (m *MockAuditChainService) VerifyChain(ctx context.Context, orgID string) (*services.ChainVerificationResult, error) {
+	args := m.Called(ctx, orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.ChainVerificationResult), args.Error(1)
+}
+
+func (m *MockAuditChainService) CreateCheckpoint(ctx context.Context, orgID string) (*models.AuditCheckpoint, error) {
+	args := m.Called(ctx, orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AuditCheckpoint), args.Error(1)
+}
+
+func (m *MockAuditChainService) CheckpointAll(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockAuditChainService) ExportCheckpoints(ctx context.Context, orgID string) (*services.NotaryExport, error) {
+	args := m.Called(ctx, orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.NotaryExport), args.Error(1)
+}
+
+func TestAuditHandler_Verify(t *testing.T) {
+	orgID := primitive.NewObjectID()
+
+	tests := []struct {
+		name           string
+		role           string
+		expectedStatus int
+	}{
+		{"auditor can verify", models.RoleAuditor, http.StatusOK},
+		{"admin with several roles can verify", models.RoleViewer + "," + models.RoleAdmin, http.StatusOK},
+		{"viewer is forbidden", models.RoleViewer, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := new(MockAuditChainService)
+			service.On("VerifyChain", mock.Anything, orgID.Hex()).Return(&services.ChainVerificationResult{
+				OrganizationID: orgID.Hex(),
+				Valid:          false,
+				FirstBreak:     &services.ChainBreak{Sequence: 7, Reason: services.ChainBreakHashMismatch},
+			}, nil)
+
+			orgContext := &middleware.OrganizationContext{OrganizationID: orgID, UserID: primitive.NewObjectID(), UserRole: tt.role}
+			router := newTestRouter(orgContext, NewAuditHandler(nil, service, nil, zap.NewNop()).RegisterRoutes)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/audit/verify", nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus != http.StatusOK {
+				service.AssertNotCalled(t, "VerifyChain", mock.Anything, mock.Anything)
+				return
+			}
+
+			var result services.ChainVerificationResult
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+			assert.False(t, result.Valid)
+			assert.Equal(t, int64(7), result.FirstBreak.Sequence)
+		})
+	}
+}
+
+func TestAuditHandler_ExportCheckpoints(t *testing.T) {
+	orgID := primitive.NewObjectID()
+	orgContext := &middleware.OrganizationContext{OrganizationID: orgID, UserRole: models.RoleAuditor}
+
+	service := new(MockAuditChainService)
+	service.On("ExportCheckpoints", mock.Anything, orgID.Hex()).Return(nil, services.ErrCheckpointSigningDisabled).Once()
+	service.On("ExportCheckpoints", mock.Anything, orgID.Hex()).Return(&services.NotaryExport{
+		Format:         services.NotaryExportFormat,
+		OrganizationID: orgID.Hex(),
+	}, nil)
+	router := newTestRouter(orgContext, NewAuditHandler(nil, service, nil, zap.NewNop()).RegisterRoutes)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/audit/checkpoints/export", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "CHECKPOINT_SIGNING_DISABLED")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/audit/checkpoints/export", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+	assert.Contains(t, w.Body.String(), services.NotaryExportFormat)
+}
+
+// MockAuditExportService is a mock implementation of AuditExportService for testing
+type MockAuditExportService struct {
+	mock.Mock
+}
+
+func (m *MockAuditExportService) RequestExport(ctx context.Context, input *services.AuditExportInput) (*models.ExportJob, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ExportJob), args.Error(1)
+}
+
+func (m *MockAuditExportService) GetExport(ctx context.Context, orgID, exportID string) (*models.ExportJob, error) {
+	args := m.Called(ctx, orgID, exportID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ExportJob), args.Error(1)
+}
+
+func (m *MockAuditExportService) ProcessPending(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockAuditExportService) OpenDownload(ctx context.Context, orgID, exportID, token string) (*services.ExportDownload, error) {
+	args := m.Called(ctx, orgID, exportID, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.ExportDownload), args.Error(1)
+}
+
+func TestAuditHandler_BackgroundExport(t *testing.T) {
+	orgID := primitive.NewObjectID()
+	userID := primitive.NewObjectID()
+	orgContext := &middleware.OrganizationContext{OrganizationID: orgID, UserID: userID, UserRole: models.RoleAdmin}
+
+	job := &models.ExportJob{ID: primitive.NewObjectID(), OrganizationID: orgID, Status: models.ExportStatusPending}
+	service := new(MockAuditExportService)
+	service.On("RequestExport", mock.Anything, mock.MatchedBy(func(input *services.AuditExportInput) bool {
+		return input.OrganizationID == orgID.Hex() && input.RequestedBy == userID.Hex() && input.Format == models.ExportFormatJSONL
+	})).Return(job, nil)
+	router := newTestRouter(orgContext, NewAuditHandler(nil, nil, service, zap.NewNop()).RegisterRoutes)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/audit/exports", strings.NewReader(`{"format":"jsonl"}`)))
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "/api/v1/audit/exports/"+job.ID.Hex(), response["status_url"])
+	assert.NotContains(t, response, "download_url")
+
+	// A completed export links to its download and never serializes the token itself
+	completed := *job
+	completed.Status = models.ExportStatusCompleted
+	completed.DownloadToken = "secret"
+	completed.ExpiresAt = time.Now().Add(time.Hour)
+	completed.Manifest = &models.ExportManifest{RowCount: 2, SizeBytes: 5, SHA256: "abc", KeyID: "key-1", Signature: "sig"}
+	service.On("GetExport", mock.Anything, orgID.Hex(), job.ID.Hex()).Return(&completed, nil)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/audit/exports/"+job.ID.Hex(), nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "/api/v1/audit/exports/"+job.ID.Hex()+"/download?token=secret", response["download_url"])
+	assert.NotContains(t, response["export"], "download_token")
+
+	service.On("OpenDownload", mock.Anything, orgID.Hex(), job.ID.Hex(), "wrong").Return(nil, services.ErrExportLinkInvalid)
+	service.On("OpenDownload", mock.Anything, orgID.Hex(), job.ID.Hex(), "secret").Return(&services.ExportDownload{
+		FileName:    "audit-log.jsonl",
+		ContentType: "application/x-ndjson",
+		Manifest:    completed.Manifest,
+		Content:     io.NopCloser(strings.NewReader("{}\n{}")),
+	}, nil)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/audit/exports/"+job.ID.Hex()+"/download?token=wrong", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "EXPORT_LINK_INVALID")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/audit/exports/"+job.ID.Hex()+"/download?token=secret", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "{}\n{}", w.Body.String())
+	assert.Equal(t, "2", w.Header().Get("X-Export-Row-Count"))
+	assert.Equal(t, "sig", w.Header().Get("X-Export-Signature"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "audit-log.jsonl")
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// CommentHandler exposes threaded comments over HTTP.
+// All routes require the organization context established by
+// OrganizationMiddleware.EnforceOrganizationContext.
+type CommentHandler struct {
+	commentService services.CommentService
+	logger         *zap.Logger
+}
+
+// NewCommentHandler creates a new comment handler.
+//
+// Parameters:
+//   - commentService: Service implementing comment operations
+//   - logger: Logger for handler operations
+//
+// Returns:
+//   - *CommentHandler: Configured handler instance
+func NewCommentHandler(commentService services.CommentService, logger *zap.Logger) *CommentHandler {
+	return &CommentHandler{
+		commentService: commentService,
+		logger:         logger,
+	}
+}
+
+// RegisterRoutes registers the comment routes on the given router group.
+func (h *CommentHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	comments := rg.Group("/comments")
+	comments.GET("", h.ListThread)
+	comments.POST("", h.Create)
+	comments.PUT("/:id", h.Edit)
+	comments.DELETE("/:id", h.Delete)
+}
+
+// ListThread handles GET /comments?resource_type=...&resource_id=...
+func (h *CommentHandler) ListThread(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	resourceType := c.Query("resource_type")
+	resourceID := c.Query("resource_id")
+	if resourceType == "" || resourceID == "" {
+		respondError(c, h.logger, services.ErrInvalidInput)
+		return
+	}
+
+	threads, err := h.commentService.GetThread(c.Request.Context(), orgContext.OrganizationID.Hex(), resourceType, resourceID)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"threads": threads})
+}
+
+// Create handles POST /comments.
+func (h *CommentHandler) Create(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	var input services.CreateCommentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBadRequest(c, err)
+		return
+	}
+	input.OrganizationID = orgContext.OrganizationID.Hex()
+	input.AuthorID = orgContext.UserID.Hex()
+
+	comment, err := h.commentService.AddComment(c.Request.Context(), &input)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// Edit handles PUT /comments/:id.
+func (h *CommentHandler) Edit(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	var input services.EditCommentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBadRequest(c, err)
+		return
+	}
+	input.OrganizationID = orgContext.OrganizationID.Hex()
+	input.CommentID = c.Param("id")
+	input.ActorID = orgContext.UserID.Hex()
+
+	editedAfter := time.Now()
+	comment, err := h.commentService.EditComment(c.Request.Context(), &input)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	// An edit that changed the content appended the replaced content to the
+	// history; unchanged content leaves the history as it was
+	previousContent := comment.Content
+	if revisions := comment.EditHistory; len(revisions) > 0 && !revisions[len(revisions)-1].EditedAt.Before(editedAfter) {
+		previousContent = revisions[len(revisions)-1].Content
+	}
+	middleware.SetAuditChange(c,
+		map[string]interface{}{"content": previousContent},
+		map[string]interface{}{"content": comment.Content},
+	)
+	c.JSON(http.StatusOK, comment)
+}
+
+// Delete handles DELETE /comments/:id.
+func (h *CommentHandler) Delete(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	if err := h.commentService.DeleteComment(c.Request.Context(), orgContext.OrganizationID.Hex(), c.Param("id"), orgContext.UserID.Hex()); err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	middleware.SetAuditChange(c,
+		map[string]interface{}{"status": models.CommentStatusActive},
+		map[string]interface{}{"status": models.CommentStatusDeleted},
+	)
+	c.Status(http.StatusNoContent)
+}
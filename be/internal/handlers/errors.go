@@ -0,0 +1,162 @@
+// Package handlers contains the HTTP handlers of the REST API.
+// Handlers translate HTTP requests into service calls and service errors into
+// the standard JSON error envelope used across the API:
+//
+//	{"error": "human readable message", "code": "MACHINE_READABLE_CODE"}
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// errorMapping associates a service error with its HTTP status and error code.
+type errorMapping struct {
+	err    error
+	status int
+	code   string
+}
+
+// serviceErrors lists the service errors that are safe to expose to API clients.
+// Any error not listed here is reported as an internal error.
+var serviceErrors = []errorMapping{
+	{services.ErrInvalidInput, http.StatusBadRequest, "INVALID_INPUT"},
+	{services.ErrEvidenceRequestNotFound, http.StatusNotFound, "EVIDENCE_REQUEST_NOT_FOUND"},
+	{services.ErrReviewCommentRequired, http.StatusBadRequest, "REVIEW_COMMENT_REQUIRED"},
+	{services.ErrReviewNotAllowed, http.StatusConflict, "REVIEW_NOT_ALLOWED"},
+	{services.ErrNotAssignee, http.StatusForbidden, "NOT_ASSIGNEE"},
+	{services.ErrNotAssignedReviewer, http.StatusForbidden, "NOT_ASSIGNED_REVIEWER"},
+	{services.ErrSelfApproval, http.StatusForbidden, "SELF_APPROVAL_NOT_ALLOWED"},
+	{services.ErrAlreadyReviewed, http.StatusConflict, "ALREADY_REVIEWED"},
+	{services.ErrInvalidReviewer, http.StatusBadRequest, "INVALID_REVIEWER"},
+	{services.ErrInsufficientReviewers, http.StatusUnprocessableEntity, "INSUFFICIENT_REVIEWERS"},
+	{services.ErrReviewerAssignment, http.StatusForbidden, "REVIEWER_ASSIGNMENT_NOT_ALLOWED"},
+	{services.ErrEvidenceRequestConflict, http.StatusConflict, "EVIDENCE_REQUEST_CONFLICT"},
+	{services.ErrCommentNotFound, http.StatusNotFound, "COMMENT_NOT_FOUND"},
+	{services.ErrCommentContentRequired, http.StatusBadRequest, "COMMENT_CONTENT_REQUIRED"},
+	{services.ErrCommentTooLong, http.StatusBadRequest, "COMMENT_TOO_LONG"},
+	{services.ErrUnsupportedResourceType, http.StatusBadRequest, "UNSUPPORTED_RESOURCE_TYPE"},
+	{services.ErrCommentResourceNotFound, http.StatusNotFound, "RESOURCE_NOT_FOUND"},
+	{services.ErrInvalidCommentParent, http.StatusBadRequest, "INVALID_COMMENT_PARENT"},
+	{services.ErrCommentDeleted, http.StatusGone, "COMMENT_DELETED"},
+	{services.ErrCommentForbidden, http.StatusForbidden, "COMMENT_FORBIDDEN"},
+	{services.ErrCheckpointSigningDisabled, http.StatusServiceUnavailable, "CHECKPOINT_SIGNING_DISABLED"},
+	{services.ErrUnsupportedExportFormat, http.StatusBadRequest, "UNSUPPORTED_EXPORT_FORMAT"},
+	{services.ErrInvalidTimeRange, http.StatusBadRequest, "INVALID_TIME_RANGE"},
+	{services.ErrExportTooLarge, http.StatusRequestEntityTooLarge, "EXPORT_TOO_LARGE"},
+	{services.ErrExportNotFound, http.StatusNotFound, "EXPORT_NOT_FOUND"},
+	{services.ErrExportNotReady, http.StatusConflict, "EXPORT_NOT_READY"},
+	{services.ErrExportLinkInvalid, http.StatusForbidden, "EXPORT_LINK_INVALID"},
+	{services.ErrRetentionDisabled, http.StatusServiceUnavailable, "RETENTION_DISABLED"},
+	{services.ErrLegalHoldNotFound, http.StatusNotFound, "LEGAL_HOLD_NOT_FOUND"},
+	{services.ErrLegalHoldReleased, http.StatusConflict, "LEGAL_HOLD_RELEASED"},
+	{services.ErrInvalidLegalHold, http.StatusBadRequest, "INVALID_LEGAL_HOLD"},
+	{services.ErrLegalHoldReasonMissing, http.StatusBadRequest, "LEGAL_HOLD_REASON_REQUIRED"},
+	{services.ErrUnknownNotificationType, http.StatusNotFound, "UNKNOWN_NOTIFICATION_TYPE"},
+	{services.ErrInvalidNotificationTemplate, http.StatusBadRequest, "INVALID_NOTIFICATION_TEMPLATE"},
+	{services.ErrNotificationTemplateNotFound, http.StatusNotFound, "NOTIFICATION_TEMPLATE_NOT_FOUND"},
+	{services.ErrWebhookNotFound, http.StatusNotFound, "WEBHOOK_NOT_FOUND"},
+	{services.ErrWebhookDeliveryNotFound, http.StatusNotFound, "WEBHOOK_DELIVERY_NOT_FOUND"},
+	{services.ErrWebhooksDisabled, http.StatusForbidden, "WEBHOOKS_DISABLED"},
+	{services.ErrWebhookSigningDisabled, http.StatusServiceUnavailable, "WEBHOOK_SIGNING_DISABLED"},
+	{services.ErrInvalidWebhookURL, http.StatusBadRequest, "INVALID_WEBHOOK_URL"},
+	{services.ErrUnknownWebhookEvent, http.StatusBadRequest, "UNKNOWN_WEBHOOK_EVENT"},
+	{services.ErrWebhookInactive, http.StatusConflict, "WEBHOOK_INACTIVE"},
+	{services.ErrScheduledJobNotFound, http.StatusNotFound, "SCHEDULED_JOB_NOT_FOUND"},
+	{services.ErrScheduledJobRunning, http.StatusConflict, "SCHEDULED_JOB_RUNNING"},
+	{services.ErrSchedulerAdminForbidden, http.StatusForbidden, "SCHEDULER_ADMIN_FORBIDDEN"},
+	{services.ErrInvalidSchedule, http.StatusBadRequest, "INVALID_SCHEDULE"},
+	{services.ErrAPIKeyNotFound, http.StatusNotFound, "API_KEY_NOT_FOUND"},
+	{services.ErrInvalidAPIKey, http.StatusUnauthorized, "INVALID_API_KEY"},
+	{services.ErrAPIKeysDisabled, http.StatusForbidden, "API_KEYS_DISABLED"},
+	{services.ErrInvalidAPIKeyScope, http.StatusBadRequest, "INVALID_API_KEY_SCOPE"},
+	{services.ErrInvalidAPIKeyRole, http.StatusBadRequest, "INVALID_API_KEY_ROLE"},
+	{services.ErrInvalidAPIKeyExpiry, http.StatusBadRequest, "INVALID_API_KEY_EXPIRY"},
+	{services.ErrAPIKeyInactive, http.StatusConflict, "API_KEY_INACTIVE"},
+	{services.ErrAPIKeyRotated, http.StatusConflict, "API_KEY_ROTATED"},
+	{services.ErrSSODisabled, http.StatusForbidden, "SSO_DISABLED"},
+	{services.ErrInvalidSSOState, http.StatusBadRequest, "INVALID_SSO_STATE"},
+	{services.ErrSSOAuthenticationFailed, http.StatusUnauthorized, "SSO_AUTHENTICATION_FAILED"},
+	{services.ErrSSOProviderUnavailable, http.StatusBadGateway, "SSO_PROVIDER_UNAVAILABLE"},
+	{services.ErrSSOAccessDenied, http.StatusForbidden, "SSO_ACCESS_DENIED"},
+	{services.ErrSCIMDisabled, http.StatusForbidden, "SCIM_DISABLED"},
+	{services.ErrSCIMUserNotFound, http.StatusNotFound, "SCIM_USER_NOT_FOUND"},
+	{services.ErrSCIMGroupNotFound, http.StatusNotFound, "SCIM_GROUP_NOT_FOUND"},
+	{services.ErrSCIMUserExists, http.StatusConflict, "SCIM_USER_EXISTS"},
+	{services.ErrSCIMGroupExists, http.StatusConflict, "SCIM_GROUP_EXISTS"},
+	{services.ErrLDAPDisabled, http.StatusForbidden, "LDAP_DISABLED"},
+	{services.ErrLDAPInvalidCredentials, http.StatusUnauthorized, "LDAP_INVALID_CREDENTIALS"},
+	{services.ErrLDAPUnavailable, http.StatusBadGateway, "LDAP_UNAVAILABLE"},
+	{services.ErrLDAPAccessDenied, http.StatusForbidden, "LDAP_ACCESS_DENIED"},
+	{services.ErrLDAPNoUsers, http.StatusBadGateway, "LDAP_NO_USERS"},
+	{services.ErrAccountLocked, http.StatusLocked, "ACCOUNT_LOCKED"},
+	{services.ErrUserNotFound, http.StatusNotFound, "USER_NOT_FOUND"},
+	{services.ErrEmailTaken, http.StatusConflict, "EMAIL_TAKEN"},
+	{services.ErrInvalidUserRole, http.StatusBadRequest, "INVALID_USER_ROLE"},
+	{services.ErrInvalidUserStatus, http.StatusBadRequest, "INVALID_USER_STATUS"},
+	{services.ErrPasswordTooWeak, http.StatusBadRequest, "PASSWORD_TOO_WEAK"},
+	{services.ErrPasswordCommon, http.StatusBadRequest, "PASSWORD_COMMON"},
+	{services.ErrPasswordBreached, http.StatusBadRequest, "PASSWORD_BREACHED"},
+	{services.ErrPasswordReused, http.StatusBadRequest, "PASSWORD_REUSED"},
+	{services.ErrPasswordExpired, http.StatusForbidden, "PASSWORD_EXPIRED"},
+	{services.ErrInvalidCredentials, http.StatusUnauthorized, "INVALID_CREDENTIALS"},
+	{services.ErrIncorrectPassword, http.StatusUnauthorized, "INCORRECT_PASSWORD"},
+	{services.ErrInvalidPasswordReset, http.StatusGone, "INVALID_PASSWORD_RESET"},
+	{services.ErrTooManyResetRequests, http.StatusTooManyRequests, "TOO_MANY_RESET_REQUESTS"},
+	{services.ErrSecurityAnswerRequired, http.StatusForbidden, "SECURITY_ANSWER_REQUIRED"},
+	{services.ErrIncorrectSecurityAnswer, http.StatusUnauthorized, "INCORRECT_SECURITY_ANSWER"},
+	{services.ErrInvalidSecurityQuestions, http.StatusBadRequest, "INVALID_SECURITY_QUESTIONS"},
+	{services.ErrSessionNotFound, http.StatusNotFound, "SESSION_NOT_FOUND"},
+	{services.ErrSessionExpired, http.StatusUnauthorized, "SESSION_EXPIRED"},
+	{services.ErrSessionRevoked, http.StatusUnauthorized, "SESSION_REVOKED"},
+	{services.ErrInvalidAccessToken, http.StatusUnauthorized, "INVALID_ACCESS_TOKEN"},
+	{services.ErrPermissionDenied, http.StatusForbidden, "PERMISSION_DENIED"},
+	{services.ErrMemberLimitReached, http.StatusForbidden, "MEMBER_LIMIT_REACHED"},
+	{services.ErrInvitationsDisabled, http.StatusForbidden, "INVITATIONS_DISABLED"},
+	{services.ErrInvitationsUnavailable, http.StatusServiceUnavailable, "INVITATIONS_UNAVAILABLE"},
+	{services.ErrInvitationNotFound, http.StatusNotFound, "INVITATION_NOT_FOUND"},
+	{services.ErrInvitationExists, http.StatusConflict, "INVITATION_EXISTS"},
+	{services.ErrInvitationClosed, http.StatusConflict, "INVITATION_CLOSED"},
+	{services.ErrInvalidInvitation, http.StatusGone, "INVALID_INVITATION"},
+	{services.ErrUserNotActive, http.StatusForbidden, "USER_NOT_ACTIVE"},
+	{services.ErrOrganizationNotFound, http.StatusNotFound, "ORGANIZATION_NOT_FOUND"},
+	{services.ErrControlNotFound, http.StatusNotFound, "CONTROL_NOT_FOUND"},
+	{services.ErrTestingCycleNotFound, http.StatusNotFound, "TESTING_CYCLE_NOT_FOUND"},
+}
+
+// respondError writes the JSON error envelope for err and aborts the request.
+// Unknown errors are logged and hidden behind a generic internal error.
+func respondError(c *gin.Context, logger *zap.Logger, err error) {
+	for _, mapping := range serviceErrors {
+		if errors.Is(err, mapping.err) {
+			c.AbortWithStatusJSON(mapping.status, gin.H{
+				"error": mapping.err.Error(),
+				"code":  mapping.code,
+			})
+			return
+		}
+	}
+
+	logger.Error("Request failed",
+		zap.Error(err),
+		zap.String("path", c.Request.URL.Path),
+		zap.String("method", c.Request.Method),
+	)
+	c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+		"error": "Internal server error",
+		"code":  "INTERNAL_ERROR",
+	})
+}
+
+// respondBadRequest writes the envelope for malformed request bodies.
+func respondBadRequest(c *gin.Context, err error) {
+	c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+		"error": "Invalid request body: " + err.Error(),
+		"code":  "INVALID_REQUEST_BODY",
+	})
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// EvidenceReviewHandler exposes the evidence review workflow over HTTP.
+// All routes require the organization context established by
+// OrganizationMiddleware.EnforceOrganizationContext.
+type EvidenceReviewHandler struct {
+	reviewService services.EvidenceReviewService
+	logger        *zap.Logger
+}
+
+// NewEvidenceReviewHandler creates a new evidence review handler.
+//
+// Parameters:
+//   - reviewService: Service implementing the review workflow
+//   - logger: Logger for handler operations
+//
+// Returns:
+//   - *EvidenceReviewHandler: Configured handler instance
+func NewEvidenceReviewHandler(reviewService services.EvidenceReviewService, logger *zap.Logger) *EvidenceReviewHandler {
+	return &EvidenceReviewHandler{
+		reviewService: reviewService,
+		logger:        logger,
+	}
+}
+
+// RegisterRoutes registers the review routes on the given router group. Only
+// managers and administrators may assign reviewers.
+//
+// Example:
+//
+//	v1 := router.Group("/api/v1", orgMiddleware.EnforceOrganizationContext())
+//	handlers.NewEvidenceReviewHandler(reviewService, logger).RegisterRoutes(v1)
+func (h *EvidenceReviewHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	requests := rg.Group("/evidence-requests/:id")
+	requests.PUT("/reviewers", middleware.RequireRole(models.RoleAdmin, models.RoleManager), h.AssignReviewers)
+	requests.POST("/submit", h.Submit)
+	requests.POST("/approve", h.Approve)
+	requests.POST("/reject", h.Reject)
+}
+
+// AssignReviewers handles PUT /evidence-requests/:id/reviewers.
+func (h *EvidenceReviewHandler) AssignReviewers(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	var input services.AssignReviewersInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBadRequest(c, err)
+		return
+	}
+	input.OrganizationID = orgContext.OrganizationID.Hex()
+	input.RequestID = c.Param("id")
+	input.AssignedBy = orgContext.UserID.Hex()
+
+	request, err := h.reviewService.AssignReviewers(c.Request.Context(), &input)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
+
+// Submit handles POST /evidence-requests/:id/submit.
+func (h *EvidenceReviewHandler) Submit(c *gin.Context) {
+	h.handleAction(c, h.reviewService.SubmitForReview)
+}
+
+// Approve handles POST /evidence-requests/:id/approve.
+func (h *EvidenceReviewHandler) Approve(c *gin.Context) {
+	h.handleAction(c, h.reviewService.ApproveEvidence)
+}
+
+// Reject handles POST /evidence-requests/:id/reject.
+func (h *EvidenceReviewHandler) Reject(c *gin.Context) {
+	h.handleAction(c, h.reviewService.RejectEvidence)
+}
+
+// handleAction binds a ReviewActionInput and invokes the given service action.
+func (h *EvidenceReviewHandler) handleAction(c *gin.Context, action func(ctx context.Context, input *services.ReviewActionInput) (*models.EvidenceRequest, error)) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	var input services.ReviewActionInput
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			respondBadRequest(c, err)
+			return
+		}
+	}
+	input.OrganizationID = orgContext.OrganizationID.Hex()
+	input.RequestID = c.Param("id")
+	input.ActorID = orgContext.UserID.Hex()
+
+	request, err := action(c.Request.Context(), &input)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// MockEvidenceReviewService is a mock implementation of EvidenceReviewService for testing
+type MockEvidenceReviewService struct {
+	mock.Mock
+}
+
+func (m *MockEvidenceReviewService) result(args mock.Arguments) (*models.EvidenceRequest, error) {
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.EvidenceRequest), args.Error(1)
+}
+
+func (m *MockEvidenceReviewService) AssignReviewers(ctx context.Context, input *services.AssignReviewersInput) (*models.EvidenceRequest, error) {
+	return m.result(m.Called(ctx, input))
+}
+
+func (m *MockEvidenceReviewService) SubmitForReview(ctx context.Context, input *services.ReviewActionInput) (*models.EvidenceRequest, error) {
+	return m.result(m.Called(ctx, input))
+}
+
+func (m *MockEvidenceReviewService) ApproveEvidence(ctx context.Context, input *services.ReviewActionInput) (*models.EvidenceRequest, error) {
+	return m.result(m.Called(ctx, input))
+}
+
+func (m *MockEvidenceReviewService) RejectEvidence(ctx context.Context, input *services.ReviewActionInput) (*models.EvidenceRequest, error) {
+	return m.result(m.Called(ctx, input))
+}
+
+// newTestRouter builds a router that injects an organization context the same
+// way OrganizationMiddleware does and registers the given handler routes.
+func newTestRouter(orgContext *middleware.OrganizationContext, register func(rg *gin.RouterGroup)) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	v1 := router.Group("/api/v1", func(c *gin.Context) {
+		c.Set("organization_context", orgContext)
+		c.Set("organization_id", orgContext.OrganizationID.Hex())
+		c.Next()
+	})
+	register(v1)
+	return router
+}
+
+func TestEvidenceReviewHandler_Actions(t *testing.T) {
+	orgContext := &middleware.OrganizationContext{
+		OrganizationID: primitive.NewObjectID(),
+		UserID:         primitive.NewObjectID(),
+	}
+	requestID := primitive.NewObjectID().Hex()
+
+	tests := []struct {
+		name           string
+		path           string
+		body           string
+		method         string
+		serviceErr     error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "approve succeeds",
+			path:           "/approve",
+			body:           `{"comment":"Looks good"}`,
+			method:         "ApproveEvidence",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "reject without comment",
+			path:           "/reject",
+			body:           `{}`,
+			method:         "RejectEvidence",
+			serviceErr:     services.ErrReviewCommentRequired,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "REVIEW_COMMENT_REQUIRED",
+		},
+		{
+			name:           "self approval is forbidden",
+			path:           "/approve",
+			body:           `{"comment":"Mine"}`,
+			method:         "ApproveEvidence",
+			serviceErr:     services.ErrSelfApproval,
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   "SELF_APPROVAL_NOT_ALLOWED",
+		},
+		{
+			name:           "submit without body",
+			path:           "/submit",
+			method:         "SubmitForReview",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(MockEvidenceReviewService)
+			expectedInput := mock.MatchedBy(func(input *services.ReviewActionInput) bool {
+				return input.OrganizationID == orgContext.OrganizationID.Hex() &&
+					input.ActorID == orgContext.UserID.Hex() &&
+					input.RequestID == requestID
+			})
+			if tt.serviceErr != nil {
+				svc.On(tt.method, mock.Anything, expectedInput).Return(nil, tt.serviceErr)
+			} else {
+				svc.On(tt.method, mock.Anything, expectedInput).Return(&models.EvidenceRequest{RequestID: "REQ-1"}, nil)
+			}
+
+			router := newTestRouter(orgContext, NewEvidenceReviewHandler(svc, zap.NewNop()).RegisterRoutes)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/evidence-requests/"+requestID+tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				var body map[string]string
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, tt.expectedCode, body["code"])
+			}
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestEvidenceReviewHandler_AssignReviewers(t *testing.T) {
+	orgContext := &middleware.OrganizationContext{
+		OrganizationID: primitive.NewObjectID(),
+		UserID:         primitive.NewObjectID(),
+		UserRole:       models.RoleManager,
+	}
+	reviewerID := primitive.NewObjectID().Hex()
+
+	svc := new(MockEvidenceReviewService)
+	svc.On("AssignReviewers", mock.Anything, mock.MatchedBy(func(input *services.AssignReviewersInput) bool {
+		return input.AssignedBy == orgContext.UserID.Hex() &&
+			len(input.ReviewerIDs) == 1 && input.ReviewerIDs[0] == reviewerID
+	})).Return(&models.EvidenceRequest{}, nil)
+
+	router := newTestRouter(orgContext, NewEvidenceReviewHandler(svc, zap.NewNop()).RegisterRoutes)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/evidence-requests/abc/reviewers",
+		strings.NewReader(`{"reviewer_ids":["`+reviewerID+`"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	svc.AssertExpectations(t)
+
+	// Other roles cannot assign reviewers
+	orgContext.UserRole = models.RoleAuditor
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/v1/evidence-requests/abc/reviewers",
+		strings.NewReader(`{"reviewer_ids":["`+reviewerID+`"]}`)))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	svc.AssertNumberOfCalls(t, "AssignReviewers", 1)
+}
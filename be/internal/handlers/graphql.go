@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/graphql"
+)
+
+// GraphQLExecutor executes GraphQL requests for an organization.
+// It is implemented by graph.Server.
+type GraphQLExecutor interface {
+	Execute(ctx context.Context, orgID string, req *graphql.Request) *graphql.Response
+}
+
+// GraphQLHandler serves the GraphQL endpoint. Responses follow the GraphQL
+// over HTTP convention: executed requests return 200 with data and errors in
+// the body, only malformed requests are rejected with the JSON error envelope.
+type GraphQLHandler struct {
+	executor GraphQLExecutor
+	logger   *zap.Logger
+}
+
+// NewGraphQLHandler creates a new GraphQL handler.
+//
+// Parameters:
+//   - executor: Executes requests against the schema
+//   - logger: Logger for handler operations
+//
+// Returns:
+//   - *GraphQLHandler: Configured handler instance
+func NewGraphQLHandler(executor GraphQLExecutor, logger *zap.Logger) *GraphQLHandler {
+	return &GraphQLHandler{executor: executor, logger: logger}
+}
+
+// RegisterRoutes registers the GraphQL routes on the given router group.
+func (h *GraphQLHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/graphql", h.Query)
+	rg.GET("/graphql", h.Query)
+}
+
+// Query handles POST /graphql with a JSON body and GET /graphql with the
+// query, operationName and variables URL parameters.
+func (h *GraphQLHandler) Query(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	req := &graphql.Request{}
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if variables := c.Query("variables"); variables != "" {
+			if err := decodeJSON([]byte(variables), &req.Variables); err != nil {
+				respondBadRequest(c, err)
+				return
+			}
+		}
+	} else {
+		body, err := c.GetRawData()
+		if err == nil {
+			err = decodeJSON(body, req)
+		}
+		if err != nil {
+			respondBadRequest(c, err)
+			return
+		}
+	}
+	if req.Query == "" {
+		respondBadRequest(c, errors.New("query is required"))
+		return
+	}
+
+	resp := h.executor.Execute(c.Request.Context(), orgContext.OrganizationID.Hex(), req)
+	for _, gqlErr := range resp.Errors {
+		h.maskError(c, gqlErr)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// maskError hides the details of resolver errors that are not safe to expose.
+// Service errors listed in serviceErrors keep their message and get their
+// code as an extension; errors raised by the engine or returned as GraphQL
+// errors by resolvers are kept as they are.
+func (h *GraphQLHandler) maskError(c *gin.Context, gqlErr *graphql.Error) {
+	if gqlErr.Err == nil {
+		return
+	}
+	for _, mapping := range serviceErrors {
+		if errors.Is(gqlErr.Err, mapping.err) {
+			gqlErr.Message = mapping.err.Error()
+			gqlErr.Extensions = map[string]interface{}{"code": mapping.code}
+			return
+		}
+	}
+	var public *graphql.Error
+	if errors.As(gqlErr.Err, &public) {
+		return
+	}
+
+	h.logger.Error("GraphQL resolver failed",
+		zap.Error(gqlErr.Err),
+		zap.Any("path", gqlErr.Path),
+		zap.String("method", c.Request.Method),
+	)
+	gqlErr.Message = "Internal server error"
+	gqlErr.Extensions = map[string]interface{}{"code": "INTERNAL_ERROR"}
+}
+
+// decodeJSON decodes a JSON document keeping numbers as json.Number, so that
+// integer variables are not turned into floats.
+func decodeJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/graphql"
+)
+
+// MockGraphQLExecutor is a mock implementation of GraphQLExecutor.
+type MockGraphQLExecutor struct {
+	mock.Mock
+}
+
+func (m *MockGraphQLExecutor) Execute(ctx context.Context, orgID string, req *graphql.Request) *graphql.Response {
+	args := m.Called(ctx, orgID, req)
+	return args.Get(0).(*graphql.Response)
+}
+
+func TestGraphQLHandler_Query(t *testing.T) {
+	orgID := primitive.NewObjectID()
+	data := map[string]interface{}{"organization": map[string]interface{}{"name": "Acme Bank"}}
+
+	executor := new(MockGraphQLExecutor)
+	executor.On("Execute", mock.Anything, orgID.Hex(), &graphql.Request{Query: "{ organization { name } }"}).
+		Return(&graphql.Response{Data: data})
+	executor.On("Execute", mock.Anything, orgID.Hex(), &graphql.Request{
+		Query:     "query($first: Int) { controls(first: $first) { totalCount } }",
+		Variables: map[string]interface{}{"first": json.Number("5")},
+	}).Return(&graphql.Response{Data: data})
+	executor.On("Execute", mock.Anything, orgID.Hex(), &graphql.Request{Query: "{ broken }"}).
+		Return(&graphql.Response{Errors: []*graphql.Error{{Message: `Cannot query field "broken" on type "Query".`}}})
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"post query", http.MethodPost, "/graphql", `{"query":"{ organization { name } }"}`, http.StatusOK, `"name":"Acme Bank"`},
+		{"post with variables", http.MethodPost, "/graphql",
+			`{"query":"query($first: Int) { controls(first: $first) { totalCount } }","variables":{"first":5}}`, http.StatusOK, `"data"`},
+		{"get query", http.MethodGet, "/graphql?query=" + url.QueryEscape("{ organization { name } }"), "", http.StatusOK, `"name":"Acme Bank"`},
+		{"get with variables", http.MethodGet, "/graphql?query=" + url.QueryEscape("query($first: Int) { controls(first: $first) { totalCount } }") +
+			"&variables=" + url.QueryEscape(`{"first":5}`), "", http.StatusOK, `"data"`},
+		{"validation errors are returned with 200", http.MethodPost, "/graphql", `{"query":"{ broken }"}`, http.StatusOK, `Cannot query field`},
+		{"malformed body", http.MethodPost, "/graphql", `{"query":`, http.StatusBadRequest, "INVALID_REQUEST_BODY"},
+		{"missing query", http.MethodPost, "/graphql", `{}`, http.StatusBadRequest, "INVALID_REQUEST_BODY"},
+		{"malformed variables", http.MethodGet, "/graphql?query=x&variables=nope", "", http.StatusBadRequest, "INVALID_REQUEST_BODY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgContext := &middleware.OrganizationContext{OrganizationID: orgID, UserID: primitive.NewObjectID(), UserRole: models.RoleViewer}
+			router := newTestRouter(orgContext, NewGraphQLHandler(executor, zap.NewNop()).RegisterRoutes)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, "/api/v1"+tt.path, strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestGraphQLHandler_MasksResolverErrors(t *testing.T) {
+	orgID := primitive.NewObjectID()
+	executor := new(MockGraphQLExecutor)
+	executor.On("Execute", mock.Anything, orgID.Hex(), mock.Anything).Return(&graphql.Response{
+		Data: map[string]interface{}{"a": nil, "b": nil, "c": nil},
+		Errors: []*graphql.Error{
+			{Message: "dial tcp 10.0.0.5:27017: connection refused", Path: []interface{}{"a"}, Err: errors.New("dial tcp 10.0.0.5:27017: connection refused")},
+			{Message: "failed to get control: control not found", Path: []interface{}{"b"}, Err: fmt.Errorf("failed to get control: %w", services.ErrControlNotFound)},
+			{Message: "first must be between 0 and 100", Path: []interface{}{"c"}, Err: &graphql.Error{Message: "first must be between 0 and 100"}},
+		},
+	})
+
+	orgContext := &middleware.OrganizationContext{OrganizationID: orgID, UserID: primitive.NewObjectID(), UserRole: models.RoleViewer}
+	router := newTestRouter(orgContext, NewGraphQLHandler(executor, zap.NewNop()).RegisterRoutes)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/graphql", strings.NewReader(`{"query":"{ a b c }"}`)))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Errors []struct {
+			Message    string                 `json:"message"`
+			Extensions map[string]interface{} `json:"extensions"`
+		} `json:"errors"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Len(t, body.Errors, 3)
+	assert.Equal(t, "Internal server error", body.Errors[0].Message)
+	assert.Equal(t, "INTERNAL_ERROR", body.Errors[0].Extensions["code"])
+	assert.Equal(t, "control not found", body.Errors[1].Message)
+	assert.Equal(t, "CONTROL_NOT_FOUND", body.Errors[1].Extensions["code"])
+	assert.Equal(t, "first must be between 0 and 100", body.Errors[2].Message)
+	assert.Nil(t, body.Errors[2].Extensions)
+	assert.NotContains(t, w.Body.String(), "10.0.0.5")
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// LDAPHandler exposes LDAP sign-in and directory sync over HTTP. Sign-in is
+// called before the user has a token, so RegisterRoutes must be called
+// outside the authenticated route groups; RegisterAdminRoutes belongs inside
+// them.
+type LDAPHandler struct {
+	ldapService services.LDAPService
+	logger      *zap.Logger
+}
+
+// ldapLoginRequest is the body of an LDAP sign-in.
+type ldapLoginRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// NewLDAPHandler creates a new LDAP handler.
+//
+// Parameters:
+//   - ldapService: Service signing users in against and syncing from directories
+//   - logger: Logger for handler operations
+//
+// Returns:
+//   - *LDAPHandler: Configured handler instance
+func NewLDAPHandler(ldapService services.LDAPService, logger *zap.Logger) *LDAPHandler {
+	return &LDAPHandler{
+		ldapService: ldapService,
+		logger:      logger,
+	}
+}
+
+// RegisterRoutes registers the public LDAP sign-in route on the given router group.
+func (h *LDAPHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/auth/ldap/:organization/login", h.Login)
+}
+
+// RegisterAdminRoutes registers the directory administration routes on the
+// given authenticated router group.
+func (h *LDAPHandler) RegisterAdminRoutes(rg *gin.RouterGroup) {
+	rg.POST("/ldap/sync", middleware.RequireRole(models.RoleAdmin), h.Sync)
+}
+
+// Login handles POST /auth/ldap/:organization/login by checking the email and
+// password against the organization's directory and returning the platform's tokens.
+func (h *LDAPHandler) Login(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	var req ldapLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(c, err)
+		return
+	}
+
+	response, err := h.ldapService.Login(c.Request.Context(), &services.LDAPLoginInput{
+		OrganizationSlug: c.Param("organization"),
+		Email:            req.Email,
+		Password:         req.Password,
+		IPAddress:        c.ClientIP(),
+		UserAgent:        c.Request.UserAgent(),
+	})
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Sync handles POST /ldap/sync and syncs the organization's accounts from
+// its directory now.
+func (h *LDAPHandler) Sync(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	result, err := h.ldapService.SyncOrganization(c.Request.Context(), orgContext.OrganizationID.Hex())
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, orgContext.OrganizationID.Hex())
+	c.JSON(http.StatusOK, result)
+}
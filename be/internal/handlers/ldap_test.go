@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// MockLDAPService is a mock of LDAPService.
+type MockLDAPService struct {
+	mock.Mock
+}
+
+func (m *MockLDAPService) Login(ctx context.Context, input *services.LDAPLoginInput) (*models.LoginResponse, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.LoginResponse), args.Error(1)
+}
+
+func (m *MockLDAPService) SyncOrganization(ctx context.Context, orgID string) (*services.LDAPSyncResult, error) {
+	args := m.Called(ctx, orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.LDAPSyncResult), args.Error(1)
+}
+
+func (m *MockLDAPService) SyncAll(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+func TestLDAPHandler_Login(t *testing.T) {
+	doc, err := OpenAPIDocument()
+	require.NoError(t, err)
+
+	now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	service := new(MockLDAPService)
+	service.On("Login", mock.Anything, mock.MatchedBy(func(input *services.LDAPLoginInput) bool {
+		return input.OrganizationSlug == "first-bank" && input.Password == "ada-secret" && input.UserAgent == "browser"
+	})).Return(&models.LoginResponse{
+		Success: true,
+		User: &models.UserProfileResponse{
+			ID:             primitive.NewObjectID(),
+			Email:          "ada@first-bank.com",
+			FirstName:      "Ada",
+			LastName:       "Auditor",
+			OrganizationID: primitive.NewObjectID(),
+			Role:           models.RoleAuditor,
+			Status:         models.UserStatusActive,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		},
+		AccessToken:  "access-token",
+		RefreshToken: "refresh-token",
+		ExpiresAt:    now.Add(time.Hour),
+		SessionID:    "session-1",
+	}, nil)
+	service.On("Login", mock.Anything, mock.MatchedBy(func(input *services.LDAPLoginInput) bool {
+		return input.Password == "wrong"
+	})).Return(nil, services.ErrLDAPInvalidCredentials)
+	service.On("Login", mock.Anything, mock.MatchedBy(func(input *services.LDAPLoginInput) bool {
+		return input.Password == "locked"
+	})).Return(nil, services.ErrAccountLocked)
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"login returns tokens", `{"email":"ada@first-bank.com","password":"ada-secret"}`, http.StatusOK, `"access_token":"access-token"`},
+		{"wrong password", `{"email":"ada@first-bank.com","password":"wrong"}`, http.StatusUnauthorized, "LDAP_INVALID_CREDENTIALS"},
+		{"locked account", `{"email":"ada@first-bank.com","password":"locked"}`, http.StatusLocked, "ACCOUNT_LOCKED"},
+		{"missing password", `{"email":"ada@first-bank.com"}`, http.StatusBadRequest, "INVALID_REQUEST_BODY"},
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewLDAPHandler(service, zap.NewNop()).RegisterRoutes(router.Group("/api/v1"))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/ldap/first-bank/login", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("User-Agent", "browser")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+			op := doc.Operation(http.MethodPost, "/auth/ldap/{organization}/login")
+			require.NotNil(t, op)
+			assert.NoError(t, op.ValidateResponse(w.Code, w.Header(), w.Body.Bytes()))
+		})
+	}
+}
+
+func TestLDAPHandler_Sync(t *testing.T) {
+	doc, err := OpenAPIDocument()
+	require.NoError(t, err)
+
+	orgID := primitive.NewObjectID()
+	service := new(MockLDAPService)
+	service.On("SyncOrganization", mock.Anything, orgID.Hex()).Return(&services.LDAPSyncResult{Created: 2, Deactivated: 1}, nil)
+
+	tests := []struct {
+		name           string
+		role           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"admin syncs", models.RoleAdmin, http.StatusOK, `"created":2`},
+		{"non-admin is rejected", models.RoleManager, http.StatusForbidden, "INSUFFICIENT_ROLE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgContext := &middleware.OrganizationContext{OrganizationID: orgID, UserID: primitive.NewObjectID(), UserRole: tt.role}
+			router := newTestRouter(orgContext, NewLDAPHandler(service, zap.NewNop()).RegisterAdminRoutes)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/ldap/sync", nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			op := doc.Operation(http.MethodPost, "/ldap/sync")
+			require.NotNil(t, op)
+			assert.NoError(t, op.ValidateResponse(w.Code, w.Header(), w.Body.Bytes()))
+		})
+	}
+}
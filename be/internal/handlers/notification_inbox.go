@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// inboxHeartbeatInterval is how often an idle event stream sends a comment so
+// that proxies and load balancers keep the connection open.
+const inboxHeartbeatInterval = 25 * time.Second
+
+// NotificationInboxHandler exposes the current user's in-app notification
+// inbox over HTTP, including a Server-Sent Events stream of live inbox events.
+type NotificationInboxHandler struct {
+	inboxService services.NotificationInboxService
+	heartbeat    time.Duration
+	logger       *zap.Logger
+}
+
+// NewNotificationInboxHandler creates a new notification inbox handler.
+//
+// Parameters:
+//   - inboxService: Service managing in-app notification inboxes
+//   - logger: Logger for handler operations
+//
+// Returns:
+//   - *NotificationInboxHandler: Configured handler instance
+func NewNotificationInboxHandler(inboxService services.NotificationInboxService, logger *zap.Logger) *NotificationInboxHandler {
+	return &NotificationInboxHandler{
+		inboxService: inboxService,
+		heartbeat:    inboxHeartbeatInterval,
+		logger:       logger,
+	}
+}
+
+// RegisterRoutes registers the inbox routes on the given router group.
+func (h *NotificationInboxHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	notifications := rg.Group("/notifications")
+	notifications.GET("", h.List)
+	notifications.GET("/stream", h.Stream)
+	notifications.POST("/read", h.MarkRead)
+	notifications.POST("/read-all", h.MarkAllRead)
+}
+
+// markReadRequest is the body of POST /notifications/read.
+type markReadRequest struct {
+	IDs []string `json:"ids" binding:"required"`
+}
+
+// List handles GET /notifications?unread=true&limit=...&offset=...
+func (h *NotificationInboxHandler) List(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	filter := &services.InboxFilter{UnreadOnly: c.Query("unread") == "true"}
+	for param, target := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		if value := c.Query(param); value != "" {
+			if *target, err = strconv.Atoi(value); err != nil {
+				respondError(c, h.logger, services.ErrInvalidInput)
+				return
+			}
+		}
+	}
+
+	page, err := h.inboxService.ListNotifications(c.Request.Context(), orgContext.UserID.Hex(), filter)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// MarkRead handles POST /notifications/read.
+func (h *NotificationInboxHandler) MarkRead(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	var req markReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBadRequest(c, err)
+		return
+	}
+
+	marked, err := h.inboxService.MarkRead(c.Request.Context(), orgContext.UserID.Hex(), req.IDs)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"marked": marked})
+}
+
+// MarkAllRead handles POST /notifications/read-all.
+func (h *NotificationInboxHandler) MarkAllRead(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	marked, err := h.inboxService.MarkAllRead(c.Request.Context(), orgContext.UserID.Hex())
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"marked": marked})
+}
+
+// Stream handles GET /notifications/stream, a Server-Sent Events stream of the
+// user's live inbox events. Each event is named after its type, e.g.
+// "notification" or "cycle_progress", and carries the event as JSON.
+func (h *NotificationInboxHandler) Stream(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	events, err := h.inboxService.Subscribe(ctx, orgContext.OrganizationID.Hex(), orgContext.UserID.Hex())
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	// The stream stays open far longer than the server's write timeout
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Debug("Event stream keeps the server write timeout", zap.Error(err))
+	}
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			c.SSEvent(event.Type, event)
+		case <-heartbeat.C:
+			if _, err := c.Writer.WriteString(": keepalive\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
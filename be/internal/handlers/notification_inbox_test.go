@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// MockNotificationInboxService is a mock of the user facing methods of
+// NotificationInboxService; delivery and relay are not used by handlers.
+type MockNotificationInboxService struct {
+	services.NotificationInboxService
+	mock.Mock
+}
+
+func (m *MockNotificationInboxService) ListNotifications(ctx context.Context, userID string, filter *services.InboxFilter) (*services.InAppNotificationConnection, error) {
+	args := m.Called(ctx, userID, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.InAppNotificationConnection), args.Error(1)
+}
+
+func (m *MockNotificationInboxService) MarkRead(ctx context.Context, userID string, notificationIDs []string) (int64, error) {
+	args := m.Called(ctx, userID, notificationIDs)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockNotificationInboxService) MarkAllRead(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockNotificationInboxService) Subscribe(ctx context.Context, orgID, userID string) (<-chan *services.InboxEvent, error) {
+	args := m.Called(ctx, orgID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(chan *services.InboxEvent), args.Error(1)
+}
+
+func TestNotificationInboxHandler_Routes(t *testing.T) {
+	orgID := primitive.NewObjectID()
+	userID := primitive.NewObjectID()
+	notificationID := primitive.NewObjectID().Hex()
+
+	inbox := new(MockNotificationInboxService)
+	inbox.On("ListNotifications", mock.Anything, userID.Hex(), &services.InboxFilter{UnreadOnly: true, Limit: 10, Offset: 20}).
+		Return(&services.InAppNotificationConnection{Nodes: []*models.InAppNotification{{Title: "Evidence requested"}}, TotalCount: 21, UnreadCount: 21}, nil)
+	inbox.On("ListNotifications", mock.Anything, userID.Hex(), &services.InboxFilter{Offset: -1}).Return(nil, services.ErrInvalidInput)
+	inbox.On("MarkRead", mock.Anything, userID.Hex(), []string{notificationID}).Return(int64(1), nil)
+	inbox.On("MarkAllRead", mock.Anything, userID.Hex()).Return(int64(4), nil)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"list unread page", http.MethodGet, "/notifications?unread=true&limit=10&offset=20", "", http.StatusOK, `"unread_count":21`},
+		{"non-numeric limit", http.MethodGet, "/notifications?limit=ten", "", http.StatusBadRequest, "INVALID_INPUT"},
+		{"negative offset", http.MethodGet, "/notifications?offset=-1", "", http.StatusBadRequest, "INVALID_INPUT"},
+		{"mark read", http.MethodPost, "/notifications/read", `{"ids":["` + notificationID + `"]}`, http.StatusOK, `"marked":1`},
+		{"mark read without IDs", http.MethodPost, "/notifications/read", `{}`, http.StatusBadRequest, "INVALID_REQUEST_BODY"},
+		{"mark all read", http.MethodPost, "/notifications/read-all", "", http.StatusOK, `"marked":4`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgContext := &middleware.OrganizationContext{OrganizationID: orgID, UserID: userID, UserRole: models.RoleViewer}
+			router := newTestRouter(orgContext, NewNotificationInboxHandler(inbox, zap.NewNop()).RegisterRoutes)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, "/api/v1"+tt.path, strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+}
+
+func TestNotificationInboxHandler_Stream(t *testing.T) {
+	orgID := primitive.NewObjectID()
+	userID := primitive.NewObjectID()
+	events := make(chan *services.InboxEvent, 1)
+
+	inbox := new(MockNotificationInboxService)
+	inbox.On("Subscribe", mock.Anything, orgID.Hex(), userID.Hex()).Return(events, nil)
+
+	handler := NewNotificationInboxHandler(inbox, zap.NewNop())
+	handler.heartbeat = 10 * time.Millisecond
+	orgContext := &middleware.OrganizationContext{OrganizationID: orgID, UserID: userID, UserRole: models.RoleViewer}
+	server := httptest.NewServer(newTestRouter(orgContext, handler.RegisterRoutes))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v1/notifications/stream", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	readLine := func() string {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		return strings.TrimRight(line, "\n")
+	}
+
+	// Idle streams send heartbeats
+	assert.Equal(t, ": keepalive", readLine())
+	assert.Equal(t, "", readLine())
+
+	events <- &services.InboxEvent{
+		Type:         models.InboxEventNotification,
+		Notification: &models.InAppNotification{Title: "Evidence requested"},
+	}
+	var eventLine, dataLine string
+	for eventLine == "" || dataLine == "" {
+		line := readLine()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			eventLine = line
+		case strings.HasPrefix(line, "data:"):
+			dataLine = line
+		}
+	}
+	assert.Equal(t, "event:"+models.InboxEventNotification, eventLine)
+	assert.Contains(t, dataLine, `"title":"Evidence requested"`)
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// NotificationHandler exposes notification preferences and the organization's
+// notification email templates over HTTP. Every user manages their own
+// preferences; only administrators can override templates.
+type NotificationHandler struct {
+	notificationService services.NotificationService
+	templateService     services.NotificationTemplateService
+	logger              *zap.Logger
+}
+
+// NewNotificationHandler creates a new notification handler.
+//
+// Parameters:
+//   - notificationService: Service managing notification preferences
+//   - templateService: Service managing notification template overrides
+//   - logger: Logger for handler operations
+//
+// Returns:
+//   - *NotificationHandler: Configured handler instance
+func NewNotificationHandler(
+	notificationService services.NotificationService,
+	templateService services.NotificationTemplateService,
+	logger *zap.Logger,
+) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+		templateService:     templateService,
+		logger:              logger,
+	}
+}
+
+// RegisterRoutes registers the notification routes on the given router group.
+func (h *NotificationHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	notifications := rg.Group("/notifications")
+	notifications.GET("/preferences", h.GetPreferences)
+	notifications.PUT("/preferences", h.UpdatePreferences)
+
+	templates := notifications.Group("/templates", middleware.RequireRole(models.RoleAdmin))
+	templates.GET("", h.ListTemplates)
+	templates.GET("/:type/preview", h.PreviewTemplate)
+	templates.PUT("/:type", h.SetTemplate)
+	templates.DELETE("/:type", h.ResetTemplate)
+}
+
+// GetPreferences handles GET /notifications/preferences and returns the
+// current user's effective preferences.
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	prefs, err := h.notificationService.GetNotificationPreferences(c.Request.Context(), orgContext.UserID.Hex())
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// UpdatePreferences handles PUT /notifications/preferences.
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	var prefs services.NotificationPreferences
+	if err := c.ShouldBindJSON(&prefs); err != nil {
+		respondBadRequest(c, err)
+		return
+	}
+
+	userID := orgContext.UserID.Hex()
+	if err := h.notificationService.UpdateNotificationPreferences(c.Request.Context(), userID, &prefs); err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, userID)
+	c.JSON(http.StatusOK, prefs)
+}
+
+// ListTemplates handles GET /notifications/templates.
+func (h *NotificationHandler) ListTemplates(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	templates, err := h.templateService.ListTemplates(c.Request.Context(), orgContext.OrganizationID.Hex())
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"templates": templates})
+}
+
+// PreviewTemplate handles GET /notifications/templates/:type/preview and
+// renders the effective template with sample data.
+func (h *NotificationHandler) PreviewTemplate(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	rendered, err := h.templateService.PreviewTemplate(c.Request.Context(), orgContext.OrganizationID.Hex(), c.Param("type"))
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rendered)
+}
+
+// SetTemplate handles PUT /notifications/templates/:type. Templates that do not
+// parse or render are rejected with the template error in the response.
+func (h *NotificationHandler) SetTemplate(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	var input services.NotificationTemplateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBadRequest(c, err)
+		return
+	}
+	input.OrganizationID = orgContext.OrganizationID.Hex()
+	input.Type = c.Param("type")
+	input.UpdatedBy = orgContext.UserID.Hex()
+
+	view, err := h.templateService.SetTemplate(c.Request.Context(), &input)
+	if errors.Is(err, services.ErrInvalidNotificationTemplate) {
+		// The template error is the admin's own input and tells them what to fix
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "INVALID_NOTIFICATION_TEMPLATE",
+		})
+		return
+	}
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, input.Type)
+	c.JSON(http.StatusOK, view)
+}
+
+// ResetTemplate handles DELETE /notifications/templates/:type and restores the
+// built-in template.
+func (h *NotificationHandler) ResetTemplate(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	notificationType := c.Param("type")
+	if err := h.templateService.ResetTemplate(c.Request.Context(), orgContext.OrganizationID.Hex(), notificationType); err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, notificationType)
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// MockNotificationService is a mock of the preference methods of NotificationService;
+// the send methods are not used by handlers and panic if called.
+type MockNotificationService struct {
+	services.NotificationService
+	mock.Mock
+}
+
+func (m *MockNotificationService) GetNotificationPreferences(ctx context.Context, userID string) (*services.NotificationPreferences, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.NotificationPreferences), args.Error(1)
+}
+
+func (m *MockNotificationService) UpdateNotificationPreferences(ctx context.Context, userID string, prefs *services.NotificationPreferences) error {
+	args := m.Called(ctx, userID, prefs)
+	return args.Error(0)
+}
+
+// MockNotificationTemplateService is a mock implementation of NotificationTemplateService for testing
+type MockNotificationTemplateService struct {
+	mock.Mock
+}
+
+func (m *MockNotificationTemplateService) ListTemplates(ctx context.Context, orgID string) ([]*services.NotificationTemplateView, error) {
+	args := m.Called(ctx, orgID)
+	return args.Get(0).([]*services.NotificationTemplateView), args.Error(1)
+}
+
+func (m *MockNotificationTemplateService) SetTemplate(ctx context.Context, input *services.NotificationTemplateInput) (*services.NotificationTemplateView, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.NotificationTemplateView), args.Error(1)
+}
+
+func (m *MockNotificationTemplateService) ResetTemplate(ctx context.Context, orgID, notificationType string) error {
+	args := m.Called(ctx, orgID, notificationType)
+	return args.Error(0)
+}
+
+func (m *MockNotificationTemplateService) PreviewTemplate(ctx context.Context, orgID, notificationType string) (*services.RenderedNotification, error) {
+	args := m.Called(ctx, orgID, notificationType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.RenderedNotification), args.Error(1)
+}
+
+func TestNotificationHandler_Routes(t *testing.T) {
+	orgID := primitive.NewObjectID()
+	userID := primitive.NewObjectID()
+
+	notifications := new(MockNotificationService)
+	notifications.On("GetNotificationPreferences", mock.Anything, userID.Hex()).Return(&services.NotificationPreferences{Email: true}, nil)
+	notifications.On("UpdateNotificationPreferences", mock.Anything, userID.Hex(), mock.MatchedBy(func(prefs *services.NotificationPreferences) bool {
+		return prefs.Email && !prefs.Reminders
+	})).Return(nil)
+	notifications.On("UpdateNotificationPreferences", mock.Anything, userID.Hex(), mock.MatchedBy(func(prefs *services.NotificationPreferences) bool {
+		return prefs.DigestFrequency == "hourly"
+	})).Return(services.ErrInvalidInput)
+
+	templates := new(MockNotificationTemplateService)
+	templates.On("ListTemplates", mock.Anything, orgID.Hex()).Return([]*services.NotificationTemplateView{{Type: models.NotificationTypeReminder}}, nil)
+	templates.On("PreviewTemplate", mock.Anything, orgID.Hex(), "weekly_digest").Return(nil, services.ErrUnknownNotificationType)
+	templates.On("SetTemplate", mock.Anything, mock.MatchedBy(func(input *services.NotificationTemplateInput) bool {
+		return input.Type == models.NotificationTypeReminder && input.Subject == "{{.Nope}}"
+	})).Return(nil, fmt.Errorf("%w: subject: map has no entry for key \"Nope\"", services.ErrInvalidNotificationTemplate))
+	templates.On("SetTemplate", mock.Anything, mock.MatchedBy(func(input *services.NotificationTemplateInput) bool {
+		return input.OrganizationID == orgID.Hex() && input.UpdatedBy == userID.Hex() && input.Subject == "Due {{.DueDate}}"
+	})).Return(&services.NotificationTemplateView{Type: models.NotificationTypeReminder, Overridden: true}, nil)
+	templates.On("ResetTemplate", mock.Anything, orgID.Hex(), models.NotificationTypeReminder).Return(services.ErrNotificationTemplateNotFound)
+
+	tests := []struct {
+		name           string
+		role           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"viewer reads own preferences", models.RoleViewer, http.MethodGet, "/notifications/preferences", "", http.StatusOK, `"email":true`},
+		{"viewer updates own preferences", models.RoleViewer, http.MethodPut, "/notifications/preferences", `{"email":true,"reminders":false}`, http.StatusOK, ""},
+		{"unknown digest frequency", models.RoleViewer, http.MethodPut, "/notifications/preferences", `{"digest_frequency":"hourly"}`, http.StatusBadRequest, "INVALID_INPUT"},
+		{"malformed preferences", models.RoleViewer, http.MethodPut, "/notifications/preferences", `{"email":"yes"}`, http.StatusBadRequest, "INVALID_REQUEST_BODY"},
+		{"auditor cannot list templates", models.RoleAuditor, http.MethodGet, "/notifications/templates", "", http.StatusForbidden, "INSUFFICIENT_ROLE"},
+		{"admin lists templates", models.RoleAdmin, http.MethodGet, "/notifications/templates", "", http.StatusOK, models.NotificationTypeReminder},
+		{"preview unknown type", models.RoleAdmin, http.MethodGet, "/notifications/templates/weekly_digest/preview", "", http.StatusNotFound, "UNKNOWN_NOTIFICATION_TYPE"},
+		{"invalid template shows details", models.RoleAdmin, http.MethodPut, "/notifications/templates/" + models.NotificationTypeReminder, `{"subject":"{{.Nope}}"}`, http.StatusBadRequest, `no entry for key \"Nope\"`},
+		{"admin overrides template", models.RoleAdmin, http.MethodPut, "/notifications/templates/" + models.NotificationTypeReminder, `{"subject":"Due {{.DueDate}}"}`, http.StatusOK, `"overridden":true`},
+		{"reset without override", models.RoleAdmin, http.MethodDelete, "/notifications/templates/" + models.NotificationTypeReminder, "", http.StatusNotFound, "NOTIFICATION_TEMPLATE_NOT_FOUND"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgContext := &middleware.OrganizationContext{OrganizationID: orgID, UserID: userID, UserRole: tt.role}
+			router := newTestRouter(orgContext, NewNotificationHandler(notifications, templates, zap.NewNop()).RegisterRoutes)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, "/api/v1"+tt.path, strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+}
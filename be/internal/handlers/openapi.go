@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/openapi"
+)
+
+// openAPISpec is the OpenAPI 3.1 description of every /api/v1 endpoint.
+// TestOpenAPISpec_CoversRoutes keeps it in sync with the registered routes.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// OpenAPIDocument loads the embedded API description for request and
+// response validation.
+//
+// Returns:
+//   - *openapi.Document: The loaded document; its base path is "/api/v1"
+//   - error: openapi.ErrInvalidDocument if the embedded spec is broken
+//
+// Example:
+//
+//	doc, err := handlers.OpenAPIDocument()
+//	validator := middleware.NewOpenAPIValidator(doc, cfg.OpenAPI, logger)
+//	v1.Use(validator.Validate())
+func OpenAPIDocument() (*openapi.Document, error) {
+	return openapi.Load(openAPISpec)
+}
+
+// OpenAPIHandler serves the API description so clients can generate code
+// from it.
+type OpenAPIHandler struct{}
+
+// NewOpenAPIHandler creates a new OpenAPI handler.
+//
+// Returns:
+//   - *OpenAPIHandler: Configured handler instance
+func NewOpenAPIHandler() *OpenAPIHandler {
+	return &OpenAPIHandler{}
+}
+
+// RegisterRoutes registers the OpenAPI routes on the given router group.
+// The route needs no authentication and should be registered before the
+// organization middleware.
+func (h *OpenAPIHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/openapi.json", h.Spec)
+}
+
+// Spec handles GET /openapi.json.
+func (h *OpenAPIHandler) Spec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", openAPISpec)
+}
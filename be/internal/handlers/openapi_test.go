@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/openapi"
+)
+
+func TestOpenAPISpec_CoversRoutes(t *testing.T) {
+	doc, err := OpenAPIDocument()
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v1", doc.BasePath())
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	RegisterRoutes(router.Group(doc.BasePath()), Services{}, zap.NewNop())
+	RegisterSCIMRoutes(router.Group(""), nil, zap.NewNop())
+
+	registered := make(map[string]bool)
+	var undocumented []string
+	for _, route := range router.Routes() {
+		path := openapi.RoutePath(route.Path)
+		registered[route.Method+" "+path] = true
+		if doc.Route(route.Method, path) == nil {
+			undocumented = append(undocumented, route.Method+" "+path)
+		}
+	}
+	assert.Empty(t, undocumented, "routes missing from openapi.json")
+	assert.True(t, registered[http.MethodPost+" /scim/v2/Users"], "SCIM is served outside the API version")
+
+	var unknown []string
+	for path, item := range doc.Paths {
+		for method := range item.Operations() {
+			if !registered[method+" "+doc.BasePathOf(path)+path] {
+				unknown = append(unknown, method+" "+path)
+			}
+		}
+	}
+	sort.Strings(unknown)
+	assert.Empty(t, unknown, "operations in openapi.json without a route")
+}
+
+func TestOpenAPIHandler_Spec(t *testing.T) {
+	orgContext := &middleware.OrganizationContext{OrganizationID: primitive.NewObjectID(), UserID: primitive.NewObjectID(), UserRole: models.RoleViewer}
+	router := newTestRouter(orgContext, NewOpenAPIHandler().RegisterRoutes)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, openAPISpec, w.Body.Bytes())
+}
+
+func TestOpenAPISpec_MatchesHandlerResponses(t *testing.T) {
+	doc, err := OpenAPIDocument()
+	require.NoError(t, err)
+
+	orgID := primitive.NewObjectID()
+	job := &models.ScheduledJob{ID: primitive.NewObjectID(), Name: "retention", Schedule: "0 2 * * *"}
+	run := &models.JobRun{ID: primitive.NewObjectID(), JobName: "retention", Trigger: models.JobTriggerManual, Status: models.JobRunRunning}
+
+	service := new(MockSchedulerService)
+	service.On("ListJobs", mock.Anything, orgID.Hex()).Return([]*models.ScheduledJob{job}, nil)
+	service.On("GetJob", mock.Anything, orgID.Hex(), "unknown").Return(nil, services.ErrScheduledJobNotFound)
+	service.On("ListRuns", mock.Anything, orgID.Hex(), "retention", 0, 0).Return(&services.JobRunConnection{Nodes: []*models.JobRun{run}, TotalCount: 1}, nil)
+	service.On("TriggerJob", mock.Anything, orgID.Hex(), "retention").Return(run, nil)
+
+	orgContext := &middleware.OrganizationContext{OrganizationID: orgID, UserID: primitive.NewObjectID(), UserRole: models.RoleAdmin}
+	router := newTestRouter(orgContext, NewSchedulerHandler(service, zap.NewNop()).RegisterRoutes)
+
+	tests := []struct {
+		method string
+		path   string
+		route  string
+		status int
+	}{
+		{http.MethodGet, "/scheduler/jobs", "/scheduler/jobs", http.StatusOK},
+		{http.MethodGet, "/scheduler/jobs/unknown", "/scheduler/jobs/{name}", http.StatusNotFound},
+		{http.MethodGet, "/scheduler/jobs/retention/runs", "/scheduler/jobs/{name}/runs", http.StatusOK},
+		{http.MethodPost, "/scheduler/jobs/retention/trigger", "/scheduler/jobs/{name}/trigger", http.StatusAccepted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, "/api/v1"+tt.path, nil))
+
+			require.Equal(t, tt.status, w.Code, w.Body.String())
+			op := doc.Operation(tt.method, tt.route)
+			require.NotNil(t, op)
+			assert.NoError(t, op.ValidateResponse(w.Code, w.Header(), w.Body.Bytes()))
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// RetentionHandler exposes retention reports, purges and legal holds over HTTP.
+// Administrators and auditors can inspect them; only administrators can purge
+// data or place and release holds.
+type RetentionHandler struct {
+	retentionService services.RetentionService
+	logger           *zap.Logger
+}
+
+// NewRetentionHandler creates a new retention handler.
+//
+// Parameters:
+//   - retentionService: Service implementing retention enforcement and legal holds
+//   - logger: Logger for handler operations
+//
+// Returns:
+//   - *RetentionHandler: Configured handler instance
+func NewRetentionHandler(retentionService services.RetentionService, logger *zap.Logger) *RetentionHandler {
+	return &RetentionHandler{
+		retentionService: retentionService,
+		logger:           logger,
+	}
+}
+
+// RegisterRoutes registers the retention routes on the given router group.
+func (h *RetentionHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	retention := rg.Group("/retention", middleware.RequireRole(models.RoleAdmin, models.RoleAuditor))
+	adminOnly := middleware.RequireRole(models.RoleAdmin)
+	retention.GET("/report", h.Report)
+	retention.POST("/purge", adminOnly, h.Purge)
+	retention.GET("/legal-holds", h.ListLegalHolds)
+	retention.POST("/legal-holds", adminOnly, h.CreateLegalHold)
+	retention.POST("/legal-holds/:id/release", adminOnly, h.ReleaseLegalHold)
+}
+
+// Report handles GET /retention/report and returns a dry-run report of what
+// the next purge would remove.
+func (h *RetentionHandler) Report(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	report, err := h.retentionService.Report(c.Request.Context(), orgContext.OrganizationID.Hex())
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Purge handles POST /retention/purge and enforces the retention policy now.
+func (h *RetentionHandler) Purge(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	report, err := h.retentionService.Enforce(c.Request.Context(), orgContext.OrganizationID.Hex())
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, orgContext.OrganizationID.Hex())
+	c.JSON(http.StatusOK, report)
+}
+
+// ListLegalHolds handles GET /retention/legal-holds?include_released=true
+func (h *RetentionHandler) ListLegalHolds(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	holds, err := h.retentionService.ListLegalHolds(c.Request.Context(), orgContext.OrganizationID.Hex(), c.Query("include_released") == "true")
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"legal_holds": holds})
+}
+
+// CreateLegalHold handles POST /retention/legal-holds.
+func (h *RetentionHandler) CreateLegalHold(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	var input services.LegalHoldInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBadRequest(c, err)
+		return
+	}
+	input.OrganizationID = orgContext.OrganizationID.Hex()
+	input.CreatedBy = orgContext.UserID.Hex()
+
+	hold, err := h.retentionService.CreateLegalHold(c.Request.Context(), &input)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, hold.ID.Hex())
+	c.JSON(http.StatusCreated, hold)
+}
+
+// ReleaseLegalHold handles POST /retention/legal-holds/:id/release.
+func (h *RetentionHandler) ReleaseLegalHold(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	hold, err := h.retentionService.ReleaseLegalHold(c.Request.Context(), orgContext.OrganizationID.Hex(), c.Param("id"), orgContext.UserID.Hex())
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, hold)
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// MockRetentionService is a mock implementation of RetentionService for testing
+type MockRetentionService struct {
+	mock.Mock
+}
+
+func (m *MockRetentionService) Report(ctx context.Context, orgID string) (*services.RetentionReport, error) {
+	args := m.Called(ctx, orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.RetentionReport), args.Error(1)
+}
+
+func (m *MockRetentionService) Enforce(ctx context.Context, orgID string) (*services.RetentionReport, error) {
+	args := m.Called(ctx, orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.RetentionReport), args.Error(1)
+}
+
+func (m *MockRetentionService) EnforceAll(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRetentionService) CreateLegalHold(ctx context.Context, input *services.LegalHoldInput) (*models.LegalHold, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.LegalHold), args.Error(1)
+}
+
+func (m *MockRetentionService) ReleaseLegalHold(ctx context.Context, orgID, holdID, userID string) (*models.LegalHold, error) {
+	args := m.Called(ctx, orgID, holdID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.LegalHold), args.Error(1)
+}
+
+func (m *MockRetentionService) ListLegalHolds(ctx context.Context, orgID string, includeReleased bool) ([]*models.LegalHold, error) {
+	args := m.Called(ctx, orgID, includeReleased)
+	return args.Get(0).([]*models.LegalHold), args.Error(1)
+}
+
+func TestRetentionHandler_Routes(t *testing.T) {
+	orgID := primitive.NewObjectID()
+	holdID := primitive.NewObjectID().Hex()
+
+	service := new(MockRetentionService)
+	service.On("Report", mock.Anything, orgID.Hex()).Return(&services.RetentionReport{OrganizationID: orgID.Hex(), DryRun: true}, nil)
+	service.On("Enforce", mock.Anything, orgID.Hex()).Return(nil, services.ErrRetentionDisabled)
+	service.On("CreateLegalHold", mock.Anything, mock.MatchedBy(func(input *services.LegalHoldInput) bool {
+		return input.OrganizationID == orgID.Hex() && input.Scope == models.LegalHoldScopeOrganization
+	})).Return(&models.LegalHold{ID: primitive.NewObjectID(), Scope: models.LegalHoldScopeOrganization}, nil)
+	service.On("ReleaseLegalHold", mock.Anything, orgID.Hex(), holdID, mock.Anything).Return(nil, services.ErrLegalHoldReleased)
+
+	tests := []struct {
+		name           string
+		role           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"auditor reads report", models.RoleAuditor, http.MethodGet, "/retention/report", "", http.StatusOK, ""},
+		{"viewer cannot read report", models.RoleViewer, http.MethodGet, "/retention/report", "", http.StatusForbidden, "INSUFFICIENT_ROLE"},
+		{"auditor cannot purge", models.RoleAuditor, http.MethodPost, "/retention/purge", "", http.StatusForbidden, "INSUFFICIENT_ROLE"},
+		{"purge while disabled", models.RoleAdmin, http.MethodPost, "/retention/purge", "", http.StatusServiceUnavailable, "RETENTION_DISABLED"},
+		{"admin places hold", models.RoleAdmin, http.MethodPost, "/retention/legal-holds", `{"scope":"organization","reason":"Litigation"}`, http.StatusCreated, ""},
+		{"auditor cannot place hold", models.RoleAuditor, http.MethodPost, "/retention/legal-holds", `{"scope":"organization","reason":"Litigation"}`, http.StatusForbidden, "INSUFFICIENT_ROLE"},
+		{"release twice", models.RoleAdmin, http.MethodPost, "/retention/legal-holds/" + holdID + "/release", "", http.StatusConflict, "LEGAL_HOLD_RELEASED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgContext := &middleware.OrganizationContext{OrganizationID: orgID, UserID: primitive.NewObjectID(), UserRole: tt.role}
+			router := newTestRouter(orgContext, NewRetentionHandler(service, zap.NewNop()).RegisterRoutes)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, "/api/v1"+tt.path, strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if tt.expectedCode != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedCode)
+			}
+		})
+	}
+}
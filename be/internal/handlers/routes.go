@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// Services holds the services behind the API's handlers.
+type Services struct {
+	Auth                  services.AuthenticationService
+	APIKeys               services.APIKeyService
+	Audit                 services.AuditService
+	AuditChain            services.AuditChainService
+	AuditExports          services.AuditExportService
+	Comments              services.CommentService
+	EvidenceReview        services.EvidenceReviewService
+	GraphQL               GraphQLExecutor
+	Notifications         services.NotificationService
+	NotificationTemplates services.NotificationTemplateService
+	NotificationInbox     services.NotificationInboxService
+	Retention             services.RetentionService
+	Scheduler             services.SchedulerService
+	LDAP                  services.LDAPService
+	SSO                   services.SSOService
+	SAML                  services.SAMLService
+	Users                 services.UserService
+	Webhooks              services.WebhookService
+}
+
+// RegisterRoutes registers every API route on the given router group. The
+// API description, sign-in, password reset and invitation routes are public;
+// all other routes are registered behind the authenticate middleware, which
+// must establish the organization context of the request.
+//
+// Parameters:
+//   - rg: Router group of the API version, e.g. /api/v1
+//   - svc: Services behind the handlers
+//   - logger: Logger for handler operations
+//   - authenticate: Middleware run before every authenticated route
+//
+// Example:
+//
+//	handlers.RegisterRoutes(router.Group("/api/v1"), svc, logger,
+//		sessionMiddleware.Authenticate(),
+//		apiKeyMiddleware.Authenticate(),
+//		orgMiddleware.EnforceOrganizationContext(),
+//	)
+func RegisterRoutes(rg *gin.RouterGroup, svc Services, logger *zap.Logger, authenticate ...gin.HandlerFunc) {
+	accountHandler := NewAccountHandler(svc.Auth, logger)
+	ldapHandler := NewLDAPHandler(svc.LDAP, logger)
+	userHandler := NewUserHandler(svc.Users, logger)
+	sessionHandler := NewSessionHandler(svc.Auth, logger)
+
+	NewOpenAPIHandler().RegisterRoutes(rg)
+	accountHandler.RegisterPasswordResetRoutes(rg)
+	NewSSOHandler(svc.SSO, logger).RegisterRoutes(rg)
+	NewSAMLHandler(svc.SAML, logger).RegisterRoutes(rg)
+	ldapHandler.RegisterRoutes(rg)
+	userHandler.RegisterRoutes(rg)
+
+	api := rg.Group("", authenticate...)
+	NewAPIKeyHandler(svc.APIKeys, logger).RegisterRoutes(api)
+	ldapHandler.RegisterAdminRoutes(api)
+	userHandler.RegisterAdminRoutes(api)
+	accountHandler.RegisterRoutes(api)
+	sessionHandler.RegisterRoutes(api)
+	sessionHandler.RegisterAdminRoutes(api)
+	NewAuditHandler(svc.Audit, svc.AuditChain, svc.AuditExports, logger).RegisterRoutes(api)
+	NewCommentHandler(svc.Comments, logger).RegisterRoutes(api)
+	NewEvidenceReviewHandler(svc.EvidenceReview, logger).RegisterRoutes(api)
+	NewGraphQLHandler(svc.GraphQL, logger).RegisterRoutes(api)
+	NewNotificationHandler(svc.Notifications, svc.NotificationTemplates, logger).RegisterRoutes(api)
+	NewNotificationInboxHandler(svc.NotificationInbox, logger).RegisterRoutes(api)
+	NewRetentionHandler(svc.Retention, logger).RegisterRoutes(api)
+	NewSchedulerHandler(svc.Scheduler, logger).RegisterRoutes(api)
+	NewWebhookHandler(svc.Webhooks, logger).RegisterRoutes(api)
+}
+
+// RegisterSCIMRoutes registers the SCIM 2.0 API at /scim/v2 on the server's
+// root, outside the API version, where identity providers expect it. SCIM
+// clients authenticate only with an organization API key holding the scim
+// scope, sent as a bearer token, so the authenticate middleware must reject
+// requests without one.
+//
+// Parameters:
+//   - router: Root router group of the server
+//   - scimService: Service provisioning users and groups
+//   - logger: Logger for handler operations
+//   - authenticate: Middleware run before every SCIM route
+//
+// Example:
+//
+//	handlers.RegisterSCIMRoutes(router.Group(""), scimService, logger,
+//		apiKeyMiddleware.RequireAPIKey(),
+//		middleware.NewRateLimitMiddleware(cacheClient, cfg.RateLimit, logger).Limit(),
+//	)
+func RegisterSCIMRoutes(router *gin.RouterGroup, scimService services.SCIMService, logger *zap.Logger, authenticate ...gin.HandlerFunc) {
+	NewSCIMHandler(scimService, logger).RegisterRoutes(router.Group("", authenticate...))
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// samlMetadataContentType is the media type of SAML metadata documents.
+const samlMetadataContentType = "application/samlmetadata+xml"
+
+// SAMLHandler exposes the platform's SAML service provider endpoints. Like
+// the OIDC routes they are called before the user has a token and must be
+// registered outside the authenticated route groups.
+type SAMLHandler struct {
+	samlService services.SAMLService
+	logger      *zap.Logger
+}
+
+// NewSAMLHandler creates a new SAML handler.
+//
+// Parameters:
+//   - samlService: Service running SAML logins
+//   - logger: Logger for handler operations
+//
+// Returns:
+//   - *SAMLHandler: Configured handler instance
+func NewSAMLHandler(samlService services.SAMLService, logger *zap.Logger) *SAMLHandler {
+	return &SAMLHandler{
+		samlService: samlService,
+		logger:      logger,
+	}
+}
+
+// RegisterRoutes registers the SAML routes on the given router group.
+func (h *SAMLHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	samlRoutes := rg.Group("/auth/sso/saml/:organization")
+	samlRoutes.GET("/metadata", h.Metadata)
+	samlRoutes.GET("/login", h.BeginLogin)
+	samlRoutes.POST("/acs", h.AssertionConsumerService)
+}
+
+// Metadata handles GET /auth/sso/saml/:organization/metadata, the service
+// provider metadata an organization registers with its identity provider.
+func (h *SAMLHandler) Metadata(c *gin.Context) {
+	metadata, err := h.samlService.Metadata(c.Request.Context(), c.Param("organization"))
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.Data(http.StatusOK, samlMetadataContentType, metadata)
+}
+
+// BeginLogin handles GET /auth/sso/saml/:organization/login by redirecting
+// the browser to the identity provider with a signed AuthnRequest. The
+// optional return_to query parameter is handed back when the login completes.
+func (h *SAMLHandler) BeginLogin(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	redirectURL, err := h.samlService.BeginLogin(c.Request.Context(), &services.BeginSSOInput{
+		OrganizationSlug: c.Param("organization"),
+		ReturnTo:         c.Query("return_to"),
+	})
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.Redirect(http.StatusFound, redirectURL)
+}
+
+// AssertionConsumerService handles POST /auth/sso/saml/:organization/acs,
+// where the identity provider posts its response form, and returns the
+// platform's tokens.
+func (h *SAMLHandler) AssertionConsumerService(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	result, err := h.samlService.CompleteLogin(c.Request.Context(), &services.CompleteSAMLInput{
+		OrganizationSlug: c.Param("organization"),
+		SAMLResponse:     c.PostForm("SAMLResponse"),
+		RelayState:       c.PostForm("RelayState"),
+		IPAddress:        c.ClientIP(),
+		UserAgent:        c.Request.UserAgent(),
+	})
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// MockSAMLService is a mock of SAMLService.
+type MockSAMLService struct {
+	mock.Mock
+}
+
+func (m *MockSAMLService) Metadata(ctx context.Context, organizationSlug string) ([]byte, error) {
+	args := m.Called(ctx, organizationSlug)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *MockSAMLService) BeginLogin(ctx context.Context, input *services.BeginSSOInput) (string, error) {
+	args := m.Called(ctx, input)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockSAMLService) CompleteLogin(ctx context.Context, input *services.CompleteSAMLInput) (*services.SSOLoginResult, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.SSOLoginResult), args.Error(1)
+}
+
+func TestSAMLHandler_Routes(t *testing.T) {
+	service := new(MockSAMLService)
+	service.On("Metadata", mock.Anything, "first-bank").Return([]byte(`<md:EntityDescriptor/>`), nil)
+	service.On("Metadata", mock.Anything, "unknown").Return(nil, services.ErrOrganizationNotFound)
+	service.On("BeginLogin", mock.Anything, &services.BeginSSOInput{OrganizationSlug: "first-bank", ReturnTo: "/controls"}).
+		Return("https://idp.example.com/sso?SAMLRequest=abc", nil)
+	service.On("BeginLogin", mock.Anything, &services.BeginSSOInput{OrganizationSlug: "no-sso"}).
+		Return("", services.ErrSSODisabled)
+	service.On("CompleteLogin", mock.Anything, &services.CompleteSAMLInput{
+		OrganizationSlug: "first-bank", SAMLResponse: "PHJlc3BvbnNlLz4=", RelayState: "relay-1", IPAddress: "192.0.2.1", UserAgent: "browser",
+	}).Return(&services.SSOLoginResult{
+		LoginResponse: &models.LoginResponse{Success: true, AccessToken: "access-token", SessionID: "session-1"},
+		ReturnTo:      "/controls",
+	}, nil)
+	service.On("CompleteLogin", mock.Anything, mock.MatchedBy(func(input *services.CompleteSAMLInput) bool {
+		return input.SAMLResponse == "forged"
+	})).Return(nil, services.ErrSSOAuthenticationFailed)
+
+	tests := []struct {
+		name             string
+		method           string
+		path             string
+		form             url.Values
+		expectedStatus   int
+		expectedBody     string
+		expectedLocation string
+	}{
+		{"metadata", http.MethodGet, "/auth/sso/saml/first-bank/metadata", nil, http.StatusOK, "<md:EntityDescriptor/>", ""},
+		{"metadata of unknown organization", http.MethodGet, "/auth/sso/saml/unknown/metadata", nil, http.StatusNotFound, "", ""},
+		{"login redirects to provider", http.MethodGet, "/auth/sso/saml/first-bank/login?return_to=/controls", nil, http.StatusFound, "", "https://idp.example.com/sso?SAMLRequest=abc"},
+		{"login without SSO", http.MethodGet, "/auth/sso/saml/no-sso/login", nil, http.StatusForbidden, "SSO_DISABLED", ""},
+		{"assertion consumer returns tokens", http.MethodPost, "/auth/sso/saml/first-bank/acs",
+			url.Values{"SAMLResponse": {"PHJlc3BvbnNlLz4="}, "RelayState": {"relay-1"}}, http.StatusOK, `"access_token":"access-token"`, ""},
+		{"assertion consumer rejects response", http.MethodPost, "/auth/sso/saml/first-bank/acs",
+			url.Values{"SAMLResponse": {"forged"}}, http.StatusUnauthorized, "SSO_AUTHENTICATION_FAILED", ""},
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewSAMLHandler(service, zap.NewNop()).RegisterRoutes(router.Group("/api/v1"))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, "/api/v1"+tt.path, strings.NewReader(tt.form.Encode()))
+			if tt.form != nil {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			req.Header.Set("User-Agent", "browser")
+			req.RemoteAddr = "192.0.2.1:1234"
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			assert.Equal(t, tt.expectedLocation, w.Header().Get("Location"))
+			if tt.name == "metadata" {
+				assert.Equal(t, "application/samlmetadata+xml", w.Header().Get("Content-Type"))
+			} else if !strings.HasSuffix(tt.path, "/metadata") {
+				assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// SchedulerHandler exposes the scheduled jobs over HTTP. Jobs are shared by
+// the whole platform, so only administrators of the operator organization
+// configured in scheduler.admin_organization_id can see or control them.
+type SchedulerHandler struct {
+	schedulerService services.SchedulerService
+	logger           *zap.Logger
+}
+
+// NewSchedulerHandler creates a new scheduler handler.
+//
+// Parameters:
+//   - schedulerService: Service running the scheduled jobs
+//   - logger: Logger for handler operations
+//
+// Returns:
+//   - *SchedulerHandler: Configured handler instance
+func NewSchedulerHandler(schedulerService services.SchedulerService, logger *zap.Logger) *SchedulerHandler {
+	return &SchedulerHandler{
+		schedulerService: schedulerService,
+		logger:           logger,
+	}
+}
+
+// RegisterRoutes registers the scheduler routes on the given router group.
+func (h *SchedulerHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	jobs := rg.Group("/scheduler/jobs", middleware.RequireRole(models.RoleAdmin))
+	jobs.GET("", h.ListJobs)
+	jobs.GET("/:name", h.GetJob)
+	jobs.GET("/:name/runs", h.ListRuns)
+	jobs.POST("/:name/trigger", h.TriggerJob)
+	jobs.POST("/:name/pause", h.PauseJob)
+	jobs.POST("/:name/resume", h.ResumeJob)
+}
+
+// ListJobs handles GET /scheduler/jobs.
+func (h *SchedulerHandler) ListJobs(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	jobs, err := h.schedulerService.ListJobs(c.Request.Context(), orgContext.OrganizationID.Hex())
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"jobs": jobs})
+}
+
+// GetJob handles GET /scheduler/jobs/:name.
+func (h *SchedulerHandler) GetJob(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	job, err := h.schedulerService.GetJob(c.Request.Context(), orgContext.OrganizationID.Hex(), c.Param("name"))
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// ListRuns handles GET /scheduler/jobs/:name/runs?limit=...&offset=...
+func (h *SchedulerHandler) ListRuns(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	var limit, offset int
+	for param, target := range map[string]*int{"limit": &limit, "offset": &offset} {
+		if value := c.Query(param); value != "" {
+			if *target, err = strconv.Atoi(value); err != nil {
+				respondError(c, h.logger, services.ErrInvalidInput)
+				return
+			}
+		}
+	}
+
+	page, err := h.schedulerService.ListRuns(c.Request.Context(), orgContext.OrganizationID.Hex(), c.Param("name"), limit, offset)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// TriggerJob handles POST /scheduler/jobs/:name/trigger and starts a run in
+// the background.
+func (h *SchedulerHandler) TriggerJob(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	run, err := h.schedulerService.TriggerJob(c.Request.Context(), orgContext.OrganizationID.Hex(), c.Param("name"))
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, c.Param("name"))
+	c.JSON(http.StatusAccepted, run)
+}
+
+// PauseJob handles POST /scheduler/jobs/:name/pause.
+func (h *SchedulerHandler) PauseJob(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	job, err := h.schedulerService.PauseJob(c.Request.Context(), orgContext.OrganizationID.Hex(), c.Param("name"))
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, c.Param("name"))
+	c.JSON(http.StatusOK, job)
+}
+
+// ResumeJob handles POST /scheduler/jobs/:name/resume.
+func (h *SchedulerHandler) ResumeJob(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	job, err := h.schedulerService.ResumeJob(c.Request.Context(), orgContext.OrganizationID.Hex(), c.Param("name"))
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, c.Param("name"))
+	c.JSON(http.StatusOK, job)
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// MockSchedulerService is a mock of the administrative methods of
+// SchedulerService; registering and starting jobs is not used by handlers.
+type MockSchedulerService struct {
+	services.SchedulerService
+	mock.Mock
+}
+
+func (m *MockSchedulerService) ListJobs(ctx context.Context, orgID string) ([]*models.ScheduledJob, error) {
+	args := m.Called(ctx, orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.ScheduledJob), args.Error(1)
+}
+
+func (m *MockSchedulerService) GetJob(ctx context.Context, orgID, name string) (*models.ScheduledJob, error) {
+	args := m.Called(ctx, orgID, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ScheduledJob), args.Error(1)
+}
+
+func (m *MockSchedulerService) ListRuns(ctx context.Context, orgID, name string, limit, offset int) (*services.JobRunConnection, error) {
+	args := m.Called(ctx, orgID, name, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.JobRunConnection), args.Error(1)
+}
+
+func (m *MockSchedulerService) TriggerJob(ctx context.Context, orgID, name string) (*models.JobRun, error) {
+	args := m.Called(ctx, orgID, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.JobRun), args.Error(1)
+}
+
+func (m *MockSchedulerService) PauseJob(ctx context.Context, orgID, name string) (*models.ScheduledJob, error) {
+	args := m.Called(ctx, orgID, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ScheduledJob), args.Error(1)
+}
+
+func (m *MockSchedulerService) ResumeJob(ctx context.Context, orgID, name string) (*models.ScheduledJob, error) {
+	args := m.Called(ctx, orgID, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ScheduledJob), args.Error(1)
+}
+
+func TestSchedulerHandler_Routes(t *testing.T) {
+	operatorID := primitive.NewObjectID()
+	customerID := primitive.NewObjectID()
+	job := &models.ScheduledJob{Name: "retention", Schedule: "0 2 * * *"}
+
+	service := new(MockSchedulerService)
+	service.On("ListJobs", mock.Anything, operatorID.Hex()).Return([]*models.ScheduledJob{job}, nil)
+	service.On("ListJobs", mock.Anything, customerID.Hex()).Return(nil, services.ErrSchedulerAdminForbidden)
+	service.On("GetJob", mock.Anything, operatorID.Hex(), "unknown").Return(nil, services.ErrScheduledJobNotFound)
+	service.On("ListRuns", mock.Anything, operatorID.Hex(), "retention", 5, 10).Return(&services.JobRunConnection{TotalCount: 12}, nil)
+	service.On("TriggerJob", mock.Anything, operatorID.Hex(), "retention").Return(&models.JobRun{JobName: "retention", Status: models.JobRunRunning}, nil)
+	service.On("TriggerJob", mock.Anything, operatorID.Hex(), "digest").Return(nil, services.ErrScheduledJobRunning)
+	service.On("PauseJob", mock.Anything, operatorID.Hex(), "retention").Return(&models.ScheduledJob{Name: "retention", Paused: true}, nil)
+	service.On("ResumeJob", mock.Anything, operatorID.Hex(), "retention").Return(job, nil)
+
+	tests := []struct {
+		name           string
+		orgID          primitive.ObjectID
+		role           string
+		method         string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"operator admin lists jobs", operatorID, models.RoleAdmin, http.MethodGet, "/scheduler/jobs", http.StatusOK, `"retention"`},
+		{"manager cannot list jobs", operatorID, models.RoleManager, http.MethodGet, "/scheduler/jobs", http.StatusForbidden, "INSUFFICIENT_ROLE"},
+		{"customer admin cannot list jobs", customerID, models.RoleAdmin, http.MethodGet, "/scheduler/jobs", http.StatusForbidden, "SCHEDULER_ADMIN_FORBIDDEN"},
+		{"get unknown job", operatorID, models.RoleAdmin, http.MethodGet, "/scheduler/jobs/unknown", http.StatusNotFound, "SCHEDULED_JOB_NOT_FOUND"},
+		{"list runs page", operatorID, models.RoleAdmin, http.MethodGet, "/scheduler/jobs/retention/runs?limit=5&offset=10", http.StatusOK, `"total_count":12`},
+		{"non-numeric run offset", operatorID, models.RoleAdmin, http.MethodGet, "/scheduler/jobs/retention/runs?offset=ten", http.StatusBadRequest, "INVALID_INPUT"},
+		{"trigger job", operatorID, models.RoleAdmin, http.MethodPost, "/scheduler/jobs/retention/trigger", http.StatusAccepted, `"status":"running"`},
+		{"trigger running job", operatorID, models.RoleAdmin, http.MethodPost, "/scheduler/jobs/digest/trigger", http.StatusConflict, "SCHEDULED_JOB_RUNNING"},
+		{"pause job", operatorID, models.RoleAdmin, http.MethodPost, "/scheduler/jobs/retention/pause", http.StatusOK, `"paused":true`},
+		{"resume job", operatorID, models.RoleAdmin, http.MethodPost, "/scheduler/jobs/retention/resume", http.StatusOK, `"paused":false`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgContext := &middleware.OrganizationContext{OrganizationID: tt.orgID, UserID: primitive.NewObjectID(), UserRole: tt.role}
+			router := newTestRouter(orgContext, NewSchedulerHandler(service, zap.NewNop()).RegisterRoutes)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, "/api/v1"+tt.path, nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+}
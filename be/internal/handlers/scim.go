@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/scim"
+)
+
+// scimServiceProviderConfig describes the supported SCIM features to
+// identity providers.
+var scimServiceProviderConfig = gin.H{
+	"schemas":        []string{scim.SchemaServiceProviderConfig},
+	"patch":          gin.H{"supported": true},
+	"bulk":           gin.H{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+	"filter":         gin.H{"supported": true, "maxResults": 200},
+	"changePassword": gin.H{"supported": false},
+	"sort":           gin.H{"supported": false},
+	"etag":           gin.H{"supported": false},
+	"authenticationSchemes": []gin.H{{
+		"type":        "oauthbearertoken",
+		"name":        "API key",
+		"description": "Organization API key with the scim scope, sent as a bearer token",
+		"primary":     true,
+	}},
+}
+
+// SCIMHandler exposes the SCIM 2.0 API an organization's identity provider
+// uses to provision users and groups. Requests authenticate with an admin API
+// key holding the scim scope, and errors use the SCIM error format instead
+// of the platform's error envelope.
+type SCIMHandler struct {
+	scimService services.SCIMService
+	logger      *zap.Logger
+}
+
+// NewSCIMHandler creates a new SCIM handler.
+//
+// Parameters:
+//   - scimService: Service provisioning users and groups
+//   - logger: Logger for handler operations
+//
+// Returns:
+//   - *SCIMHandler: Configured handler instance
+func NewSCIMHandler(scimService services.SCIMService, logger *zap.Logger) *SCIMHandler {
+	return &SCIMHandler{
+		scimService: scimService,
+		logger:      logger,
+	}
+}
+
+// RegisterRoutes registers the SCIM routes on the given router group.
+func (h *SCIMHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	scimRoutes := rg.Group("/scim/v2", middleware.RequireRole(models.RoleAdmin))
+	scimRoutes.GET("/ServiceProviderConfig", h.ServiceProviderConfig)
+
+	users := scimRoutes.Group("/Users")
+	users.GET("", h.ListUsers)
+	users.POST("", h.CreateUser)
+	users.GET("/:id", h.GetUser)
+	users.PUT("/:id", h.ReplaceUser)
+	users.PATCH("/:id", h.PatchUser)
+	users.DELETE("/:id", h.DeleteUser)
+
+	groups := scimRoutes.Group("/Groups")
+	groups.GET("", h.ListGroups)
+	groups.POST("", h.CreateGroup)
+	groups.GET("/:id", h.GetGroup)
+	groups.PUT("/:id", h.ReplaceGroup)
+	groups.PATCH("/:id", h.PatchGroup)
+	groups.DELETE("/:id", h.DeleteGroup)
+}
+
+// ServiceProviderConfig handles GET /scim/v2/ServiceProviderConfig.
+func (h *SCIMHandler) ServiceProviderConfig(c *gin.Context) {
+	h.respond(c, http.StatusOK, scimServiceProviderConfig)
+}
+
+// ListUsers handles GET /scim/v2/Users with the optional filter, startIndex
+// and count query parameters.
+func (h *SCIMHandler) ListUsers(c *gin.Context) {
+	h.list(c, h.scimService.ListUsers)
+}
+
+// GetUser handles GET /scim/v2/Users/:id.
+func (h *SCIMHandler) GetUser(c *gin.Context) {
+	orgID, ok := h.organizationID(c)
+	if !ok {
+		return
+	}
+	user, err := h.scimService.GetUser(c.Request.Context(), orgID, c.Param("id"))
+	h.result(c, http.StatusOK, user, err)
+}
+
+// CreateUser handles POST /scim/v2/Users.
+func (h *SCIMHandler) CreateUser(c *gin.Context) {
+	orgID, ok := h.organizationID(c)
+	if !ok {
+		return
+	}
+	var resource scim.User
+	if !h.bind(c, &resource) {
+		return
+	}
+	user, err := h.scimService.CreateUser(c.Request.Context(), orgID, &resource)
+	if err == nil {
+		middleware.SetAuditResourceID(c, user.ID)
+		c.Header("Location", c.Request.URL.Path+"/"+user.ID)
+	}
+	h.result(c, http.StatusCreated, user, err)
+}
+
+// ReplaceUser handles PUT /scim/v2/Users/:id.
+func (h *SCIMHandler) ReplaceUser(c *gin.Context) {
+	orgID, ok := h.organizationID(c)
+	if !ok {
+		return
+	}
+	var resource scim.User
+	if !h.bind(c, &resource) {
+		return
+	}
+	user, err := h.scimService.ReplaceUser(c.Request.Context(), orgID, c.Param("id"), &resource)
+	h.result(c, http.StatusOK, user, err)
+}
+
+// PatchUser handles PATCH /scim/v2/Users/:id. Setting active to false
+// deprovisions the user.
+func (h *SCIMHandler) PatchUser(c *gin.Context) {
+	orgID, ok := h.organizationID(c)
+	if !ok {
+		return
+	}
+	var patch scim.PatchRequest
+	if !h.bind(c, &patch) {
+		return
+	}
+	user, err := h.scimService.PatchUser(c.Request.Context(), orgID, c.Param("id"), &patch)
+	h.result(c, http.StatusOK, user, err)
+}
+
+// DeleteUser handles DELETE /scim/v2/Users/:id.
+func (h *SCIMHandler) DeleteUser(c *gin.Context) {
+	orgID, ok := h.organizationID(c)
+	if !ok {
+		return
+	}
+	err := h.scimService.DeleteUser(c.Request.Context(), orgID, c.Param("id"))
+	h.result(c, http.StatusNoContent, nil, err)
+}
+
+// ListGroups handles GET /scim/v2/Groups with the optional filter,
+// startIndex and count query parameters.
+func (h *SCIMHandler) ListGroups(c *gin.Context) {
+	h.list(c, h.scimService.ListGroups)
+}
+
+// GetGroup handles GET /scim/v2/Groups/:id.
+func (h *SCIMHandler) GetGroup(c *gin.Context) {
+	orgID, ok := h.organizationID(c)
+	if !ok {
+		return
+	}
+	group, err := h.scimService.GetGroup(c.Request.Context(), orgID, c.Param("id"))
+	h.result(c, http.StatusOK, group, err)
+}
+
+// CreateGroup handles POST /scim/v2/Groups.
+func (h *SCIMHandler) CreateGroup(c *gin.Context) {
+	orgID, ok := h.organizationID(c)
+	if !ok {
+		return
+	}
+	var resource scim.Group
+	if !h.bind(c, &resource) {
+		return
+	}
+	group, err := h.scimService.CreateGroup(c.Request.Context(), orgID, &resource)
+	if err == nil {
+		middleware.SetAuditResourceID(c, group.ID)
+		c.Header("Location", c.Request.URL.Path+"/"+group.ID)
+	}
+	h.result(c, http.StatusCreated, group, err)
+}
+
+// ReplaceGroup handles PUT /scim/v2/Groups/:id.
+func (h *SCIMHandler) ReplaceGroup(c *gin.Context) {
+	orgID, ok := h.organizationID(c)
+	if !ok {
+		return
+	}
+	var resource scim.Group
+	if !h.bind(c, &resource) {
+		return
+	}
+	group, err := h.scimService.ReplaceGroup(c.Request.Context(), orgID, c.Param("id"), &resource)
+	h.result(c, http.StatusOK, group, err)
+}
+
+// PatchGroup handles PATCH /scim/v2/Groups/:id, typically to add or remove
+// members.
+func (h *SCIMHandler) PatchGroup(c *gin.Context) {
+	orgID, ok := h.organizationID(c)
+	if !ok {
+		return
+	}
+	var patch scim.PatchRequest
+	if !h.bind(c, &patch) {
+		return
+	}
+	group, err := h.scimService.PatchGroup(c.Request.Context(), orgID, c.Param("id"), &patch)
+	h.result(c, http.StatusOK, group, err)
+}
+
+// DeleteGroup handles DELETE /scim/v2/Groups/:id.
+func (h *SCIMHandler) DeleteGroup(c *gin.Context) {
+	orgID, ok := h.organizationID(c)
+	if !ok {
+		return
+	}
+	err := h.scimService.DeleteGroup(c.Request.Context(), orgID, c.Param("id"))
+	h.result(c, http.StatusNoContent, nil, err)
+}
+
+// list runs a query from the filter, startIndex and count query parameters.
+func (h *SCIMHandler) list(c *gin.Context, query func(ctx context.Context, orgID string, query *services.SCIMQuery) (*scim.ListResponse, error)) {
+	orgID, ok := h.organizationID(c)
+	if !ok {
+		return
+	}
+	input := &services.SCIMQuery{Filter: c.Query("filter"), StartIndex: 1}
+	if value, ok := c.GetQuery("startIndex"); ok {
+		startIndex, err := strconv.Atoi(value)
+		if err != nil {
+			h.respondError(c, scim.NewError(http.StatusBadRequest, scim.ErrorTypeInvalidValue, "startIndex must be an integer"))
+			return
+		}
+		input.StartIndex = startIndex
+	}
+	if value, ok := c.GetQuery("count"); ok {
+		count, err := strconv.Atoi(value)
+		if err != nil {
+			h.respondError(c, scim.NewError(http.StatusBadRequest, scim.ErrorTypeInvalidValue, "count must be an integer"))
+			return
+		}
+		input.Count = &count
+	}
+
+	response, err := query(c.Request.Context(), orgID, input)
+	h.result(c, http.StatusOK, response, err)
+}
+
+// organizationID returns the ID of the caller's organization.
+func (h *SCIMHandler) organizationID(c *gin.Context) (string, bool) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		h.respondError(c, err)
+		return "", false
+	}
+	return orgContext.OrganizationID.Hex(), true
+}
+
+// bind decodes a request body sent as application/scim+json or
+// application/json.
+func (h *SCIMHandler) bind(c *gin.Context, v interface{}) bool {
+	if err := json.NewDecoder(c.Request.Body).Decode(v); err != nil {
+		h.respondError(c, scim.NewError(http.StatusBadRequest, scim.ErrorTypeInvalidSyntax, "invalid request body: "+err.Error()))
+		return false
+	}
+	return true
+}
+
+// result writes a service result, or the error when err is set.
+func (h *SCIMHandler) result(c *gin.Context, status int, v interface{}, err error) {
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	if status == http.StatusNoContent {
+		c.Status(status)
+		return
+	}
+	h.respond(c, status, v)
+}
+
+// respond writes a SCIM response body.
+func (h *SCIMHandler) respond(c *gin.Context, status int, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.Data(status, scim.ContentType, data)
+}
+
+// respondError writes a SCIM error response and aborts the request. Service
+// errors keep the status of the platform's error mapping; conflicts have the
+// uniqueness error type.
+func (h *SCIMHandler) respondError(c *gin.Context, err error) {
+	var scimErr *scim.Error
+	if !errors.As(err, &scimErr) {
+		scimErr = scim.NewError(http.StatusInternalServerError, "", "Internal server error")
+		for _, mapping := range serviceErrors {
+			if errors.Is(err, mapping.err) {
+				scimErr = scim.NewError(mapping.status, "", mapping.err.Error())
+				break
+			}
+		}
+		if scimErr.Status == http.StatusConflict {
+			scimErr.Type = scim.ErrorTypeUniqueness
+		}
+		if scimErr.Status == http.StatusInternalServerError {
+			h.logger.Error("SCIM request failed",
+				zap.Error(err),
+				zap.String("path", c.Request.URL.Path),
+				zap.String("method", c.Request.Method),
+			)
+		}
+	}
+
+	data, _ := json.Marshal(scimErr)
+	c.Abort()
+	c.Data(scimErr.Status, scim.ContentType, data)
+}
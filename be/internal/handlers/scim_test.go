@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/scim"
+)
+
+// MockSCIMService is a mock of SCIMService.
+type MockSCIMService struct {
+	mock.Mock
+}
+
+func (m *MockSCIMService) list(args mock.Arguments) (*scim.ListResponse, error) {
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*scim.ListResponse), args.Error(1)
+}
+
+func (m *MockSCIMService) user(args mock.Arguments) (*scim.User, error) {
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*scim.User), args.Error(1)
+}
+
+func (m *MockSCIMService) group(args mock.Arguments) (*scim.Group, error) {
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*scim.Group), args.Error(1)
+}
+
+func (m *MockSCIMService) ListUsers(ctx context.Context, orgID string, query *services.SCIMQuery) (*scim.ListResponse, error) {
+	return m.list(m.Called(ctx, orgID, query))
+}
+
+func (m *MockSCIMService) GetUser(ctx context.Context, orgID, userID string) (*scim.User, error) {
+	return m.user(m.Called(ctx, orgID, userID))
+}
+
+func (m *MockSCIMService) CreateUser(ctx context.Context, orgID string, user *scim.User) (*scim.User, error) {
+	return m.user(m.Called(ctx, orgID, user))
+}
+
+func (m *MockSCIMService) ReplaceUser(ctx context.Context, orgID, userID string, user *scim.User) (*scim.User, error) {
+	return m.user(m.Called(ctx, orgID, userID, user))
+}
+
+func (m *MockSCIMService) PatchUser(ctx context.Context, orgID, userID string, patch *scim.PatchRequest) (*scim.User, error) {
+	return m.user(m.Called(ctx, orgID, userID, patch))
+}
+
+func (m *MockSCIMService) DeleteUser(ctx context.Context, orgID, userID string) error {
+	return m.Called(ctx, orgID, userID).Error(0)
+}
+
+func (m *MockSCIMService) ListGroups(ctx context.Context, orgID string, query *services.SCIMQuery) (*scim.ListResponse, error) {
+	return m.list(m.Called(ctx, orgID, query))
+}
+
+func (m *MockSCIMService) GetGroup(ctx context.Context, orgID, groupID string) (*scim.Group, error) {
+	return m.group(m.Called(ctx, orgID, groupID))
+}
+
+func (m *MockSCIMService) CreateGroup(ctx context.Context, orgID string, group *scim.Group) (*scim.Group, error) {
+	return m.group(m.Called(ctx, orgID, group))
+}
+
+func (m *MockSCIMService) ReplaceGroup(ctx context.Context, orgID, groupID string, group *scim.Group) (*scim.Group, error) {
+	return m.group(m.Called(ctx, orgID, groupID, group))
+}
+
+func (m *MockSCIMService) PatchGroup(ctx context.Context, orgID, groupID string, patch *scim.PatchRequest) (*scim.Group, error) {
+	return m.group(m.Called(ctx, orgID, groupID, patch))
+}
+
+func (m *MockSCIMService) DeleteGroup(ctx context.Context, orgID, groupID string) error {
+	return m.Called(ctx, orgID, groupID).Error(0)
+}
+
+func TestSCIMHandler_Routes(t *testing.T) {
+	doc, err := OpenAPIDocument()
+	require.NoError(t, err)
+
+	orgID := primitive.NewObjectID()
+	org := orgID.Hex()
+	now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	active := scim.Boolean(true)
+	user := &scim.User{
+		Schemas:  []string{scim.SchemaUser},
+		ID:       primitive.NewObjectID().Hex(),
+		UserName: "ada@first-bank.com",
+		Active:   &active,
+		Meta:     &scim.Meta{ResourceType: "User", Created: now, LastModified: now},
+	}
+	group := &scim.Group{
+		Schemas:     []string{scim.SchemaGroup},
+		ID:          primitive.NewObjectID().Hex(),
+		DisplayName: "GRC-Auditors",
+		Members:     []scim.MultiValued{{Value: user.ID, Display: user.UserName}},
+		Meta:        &scim.Meta{ResourceType: "Group", Created: now, LastModified: now},
+	}
+	missingID := primitive.NewObjectID().Hex()
+
+	service := new(MockSCIMService)
+	service.On("ListUsers", mock.Anything, org, &services.SCIMQuery{Filter: `userName eq "ada@first-bank.com"`, StartIndex: 1}).
+		Return(scim.NewListResponse([]interface{}{user}, 1, 1), nil)
+	service.On("ListUsers", mock.Anything, org, &services.SCIMQuery{Filter: `userName eq`, StartIndex: 1}).
+		Return(nil, scim.NewError(http.StatusBadRequest, scim.ErrorTypeInvalidFilter, "unexpected end of filter"))
+	service.On("GetUser", mock.Anything, org, user.ID).Return(user, nil)
+	service.On("GetUser", mock.Anything, org, missingID).Return(nil, services.ErrSCIMUserNotFound)
+	service.On("CreateUser", mock.Anything, org, mock.MatchedBy(func(input *scim.User) bool {
+		return input.UserName == "ada@first-bank.com"
+	})).Return(user, nil)
+	service.On("CreateUser", mock.Anything, org, mock.Anything).Return(nil, services.ErrSCIMUserExists)
+	service.On("PatchUser", mock.Anything, org, user.ID, mock.MatchedBy(func(patch *scim.PatchRequest) bool {
+		return len(patch.Operations) == 1 && patch.Operations[0].Path == "active"
+	})).Return(user, nil)
+	service.On("DeleteUser", mock.Anything, org, user.ID).Return(nil)
+	service.On("ListGroups", mock.Anything, org, mock.Anything).Return(scim.NewListResponse([]interface{}{group}, 1, 1), nil)
+	service.On("CreateGroup", mock.Anything, org, mock.Anything).Return(group, nil)
+	service.On("ReplaceGroup", mock.Anything, org, group.ID, mock.Anything).Return(nil, services.ErrSCIMDisabled)
+
+	tests := []struct {
+		name           string
+		role           string
+		method         string
+		path           string
+		route          string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"service provider config", models.RoleAdmin, http.MethodGet, "/scim/v2/ServiceProviderConfig", "/scim/v2/ServiceProviderConfig", "", http.StatusOK, `"patch":{"supported":true}`},
+		{"non-admin is rejected", models.RoleManager, http.MethodGet, "/scim/v2/Users", "/scim/v2/Users", "", http.StatusForbidden, "INSUFFICIENT_ROLE"},
+		{"filter users", models.RoleAdmin, http.MethodGet, `/scim/v2/Users?filter=userName+eq+"ada@first-bank.com"`, "/scim/v2/Users", "", http.StatusOK, `"totalResults":1`},
+		{"invalid filter", models.RoleAdmin, http.MethodGet, "/scim/v2/Users?filter=userName+eq", "/scim/v2/Users", "", http.StatusBadRequest, `"scimType":"invalidFilter"`},
+		{"invalid count", models.RoleAdmin, http.MethodGet, "/scim/v2/Users?count=ten", "/scim/v2/Users", "", http.StatusBadRequest, `"scimType":"invalidValue"`},
+		{"get user", models.RoleAdmin, http.MethodGet, "/scim/v2/Users/" + user.ID, "/scim/v2/Users/{id}", "", http.StatusOK, `"userName":"ada@first-bank.com"`},
+		{"get missing user", models.RoleAdmin, http.MethodGet, "/scim/v2/Users/" + missingID, "/scim/v2/Users/{id}", "", http.StatusNotFound, `"status":"404"`},
+		{"create user", models.RoleAdmin, http.MethodPost, "/scim/v2/Users", "/scim/v2/Users", `{"schemas":["` + scim.SchemaUser + `"],"userName":"ada@first-bank.com"}`, http.StatusCreated, user.ID},
+		{"create existing user", models.RoleAdmin, http.MethodPost, "/scim/v2/Users", "/scim/v2/Users", `{"userName":"bob@first-bank.com"}`, http.StatusConflict, `"scimType":"uniqueness"`},
+		{"malformed body", models.RoleAdmin, http.MethodPost, "/scim/v2/Users", "/scim/v2/Users", `{"userName":`, http.StatusBadRequest, `"scimType":"invalidSyntax"`},
+		{"deactivate user", models.RoleAdmin, http.MethodPatch, "/scim/v2/Users/" + user.ID, "/scim/v2/Users/{id}", `{"Operations":[{"op":"replace","path":"active","value":false}]}`, http.StatusOK, user.ID},
+		{"delete user", models.RoleAdmin, http.MethodDelete, "/scim/v2/Users/" + user.ID, "/scim/v2/Users/{id}", "", http.StatusNoContent, ""},
+		{"list groups", models.RoleAdmin, http.MethodGet, "/scim/v2/Groups?startIndex=1&count=10", "/scim/v2/Groups", "", http.StatusOK, `"displayName":"GRC-Auditors"`},
+		{"create group", models.RoleAdmin, http.MethodPost, "/scim/v2/Groups", "/scim/v2/Groups", `{"displayName":"GRC-Auditors"}`, http.StatusCreated, group.ID},
+		{"SCIM disabled", models.RoleAdmin, http.MethodPut, "/scim/v2/Groups/" + group.ID, "/scim/v2/Groups/{id}", `{"displayName":"GRC-Auditors"}`, http.StatusForbidden, `"status":"403"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgContext := &middleware.OrganizationContext{OrganizationID: orgID, UserID: primitive.NewObjectID(), UserRole: tt.role}
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			// SCIM is served from the root, authenticated by the given middleware
+			RegisterSCIMRoutes(router.Group(""), service, zap.NewNop(), func(c *gin.Context) {
+				c.Set("organization_context", orgContext)
+				c.Set("organization_id", orgContext.OrganizationID.Hex())
+				c.Next()
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", scim.ContentType)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+			if w.Code == http.StatusCreated {
+				assert.Equal(t, tt.path+"/"+strings.Trim(tt.expectedBody, `"`), w.Header().Get("Location"))
+			}
+			op := doc.Operation(tt.method, tt.route)
+			require.NotNil(t, op)
+			assert.NoError(t, op.ValidateResponse(w.Code, w.Header(), w.Body.Bytes()))
+		})
+	}
+}
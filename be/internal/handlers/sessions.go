@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// SessionHandler exposes the signed-in user's sessions, and administration
+// of the sessions of an organization's users, over HTTP.
+type SessionHandler struct {
+	authService services.AuthenticationService
+	logger      *zap.Logger
+}
+
+// NewSessionHandler creates a new session handler.
+//
+// Parameters:
+//   - authService: Service managing sessions
+//   - logger: Logger for handler operations
+//
+// Returns:
+//   - *SessionHandler: Configured handler instance
+func NewSessionHandler(authService services.AuthenticationService, logger *zap.Logger) *SessionHandler {
+	return &SessionHandler{
+		authService: authService,
+		logger:      logger,
+	}
+}
+
+// RegisterRoutes registers the routes of the signed-in user's own sessions
+// on the given authenticated router group.
+func (h *SessionHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	sessions := rg.Group("/account/sessions")
+	sessions.GET("", h.ListOwn)
+	sessions.DELETE("", h.RevokeOthers)
+	sessions.DELETE("/:id", h.RevokeOwn)
+}
+
+// RegisterAdminRoutes registers the routes of the sessions of an
+// organization's users on the given authenticated router group.
+func (h *SessionHandler) RegisterAdminRoutes(rg *gin.RouterGroup) {
+	users := rg.Group("/users", middleware.RequireRole(models.RoleAdmin))
+	users.GET("/:id/sessions", h.List)
+	users.DELETE("/:id/sessions", h.RevokeAll)
+	users.DELETE("/:id/sessions/:session_id", h.Revoke)
+}
+
+// ListOwn handles GET /account/sessions, listing the signed-in user's active
+// sessions with the session of the request marked as current.
+func (h *SessionHandler) ListOwn(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+	h.list(c, orgContext, orgContext.UserID.Hex(), middleware.GetSessionID(c))
+}
+
+// RevokeOthers handles DELETE /account/sessions, signing the user out
+// everywhere except the session of the request.
+func (h *SessionHandler) RevokeOthers(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+	h.revokeAll(c, orgContext, orgContext.UserID.Hex(), middleware.GetSessionID(c))
+}
+
+// RevokeOwn handles DELETE /account/sessions/:id. Revoking the session of
+// the request signs the user out.
+func (h *SessionHandler) RevokeOwn(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+	h.revoke(c, orgContext, orgContext.UserID.Hex(), c.Param("id"))
+}
+
+// List handles GET /users/:id/sessions
+func (h *SessionHandler) List(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+	h.list(c, orgContext, c.Param("id"), middleware.GetSessionID(c))
+}
+
+// RevokeAll handles DELETE /users/:id/sessions, signing the user out everywhere.
+func (h *SessionHandler) RevokeAll(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+	h.revokeAll(c, orgContext, c.Param("id"), "")
+}
+
+// Revoke handles DELETE /users/:id/sessions/:session_id
+func (h *SessionHandler) Revoke(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+	h.revoke(c, orgContext, c.Param("id"), c.Param("session_id"))
+}
+
+// list responds with the active sessions of a user.
+func (h *SessionHandler) list(c *gin.Context, orgContext *middleware.OrganizationContext, userID, currentSessionID string) {
+	sessions, err := h.authService.ListSessions(c.Request.Context(), orgContext.OrganizationID.Hex(), userID, currentSessionID)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// revokeAll ends every session of a user except exceptSessionID and
+// responds with how many were ended.
+func (h *SessionHandler) revokeAll(c *gin.Context, orgContext *middleware.OrganizationContext, userID, exceptSessionID string) {
+	revoked, err := h.authService.RevokeSessions(c.Request.Context(), orgContext.OrganizationID.Hex(), userID, exceptSessionID)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+	middleware.SetAuditResourceID(c, userID)
+	c.JSON(http.StatusOK, gin.H{"revoked": revoked})
+}
+
+// revoke ends one session of a user.
+func (h *SessionHandler) revoke(c *gin.Context, orgContext *middleware.OrganizationContext, userID, sessionID string) {
+	if err := h.authService.RevokeSession(c.Request.Context(), orgContext.OrganizationID.Hex(), userID, sessionID); err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+	middleware.SetAuditResourceID(c, sessionID)
+	c.Status(http.StatusNoContent)
+}
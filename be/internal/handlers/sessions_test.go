@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+func (m *MockAuthenticationService) ValidateAccessToken(ctx context.Context, token string) (*models.JWTClaims, error) {
+	args := m.Called(ctx, token)
+	claims, _ := args.Get(0).(*models.JWTClaims)
+	return claims, args.Error(1)
+}
+
+func (m *MockAuthenticationService) ListSessions(ctx context.Context, orgID, userID, currentSessionID string) ([]*services.UserSession, error) {
+	args := m.Called(ctx, orgID, userID, currentSessionID)
+	sessions, _ := args.Get(0).([]*services.UserSession)
+	return sessions, args.Error(1)
+}
+
+func (m *MockAuthenticationService) RevokeSession(ctx context.Context, orgID, userID, sessionID string) error {
+	return m.Called(ctx, orgID, userID, sessionID).Error(0)
+}
+
+func (m *MockAuthenticationService) RevokeSessions(ctx context.Context, orgID, userID, exceptSessionID string) (int64, error) {
+	args := m.Called(ctx, orgID, userID, exceptSessionID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// newSessionTestRouter serves the session routes to requests signed in to
+// the "laptop" session.
+func newSessionTestRouter(orgContext *middleware.OrganizationContext, authService *MockAuthenticationService) *gin.Engine {
+	authService.On("ValidateAccessToken", mock.Anything, "access-token").Return(&models.JWTClaims{
+		UserID:         orgContext.UserID.Hex(),
+		OrganizationID: orgContext.OrganizationID.Hex(),
+		SessionID:      "laptop",
+	}, nil).Maybe()
+	return newTestRouter(orgContext, func(rg *gin.RouterGroup) {
+		rg.Use(middleware.NewSessionMiddleware(authService, zap.NewNop()).Authenticate())
+		handler := NewSessionHandler(authService, zap.NewNop())
+		handler.RegisterRoutes(rg)
+		handler.RegisterAdminRoutes(rg)
+	})
+}
+
+func TestSessionHandler_OwnSessions(t *testing.T) {
+	doc, err := OpenAPIDocument()
+	require.NoError(t, err)
+	orgContext := &middleware.OrganizationContext{
+		OrganizationID: primitive.NewObjectID(),
+		UserID:         primitive.NewObjectID(),
+		UserRole:       models.RoleAuditor,
+	}
+	orgID, userID := orgContext.OrganizationID.Hex(), orgContext.UserID.Hex()
+	now := time.Now().UTC()
+	session := &models.Session{
+		SessionID:      "laptop",
+		UserID:         orgContext.UserID,
+		OrganizationID: orgContext.OrganizationID,
+		IPAddress:      "203.0.113.7",
+		UserAgent:      "Mozilla/5.0",
+		LoginMethod:    "password",
+		LastActivity:   now,
+		ExpiresAt:      now.Add(24 * time.Hour),
+		IsActive:       true,
+	}
+	session.ID = primitive.NewObjectID()
+	session.CreatedAt = now
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		operation      string
+		setup          func(authService *MockAuthenticationService)
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"list", http.MethodGet, "/account/sessions", "/account/sessions", func(authService *MockAuthenticationService) {
+			authService.On("ListSessions", mock.Anything, orgID, userID, "laptop").Return([]*services.UserSession{{Session: session, Current: true}}, nil)
+		}, http.StatusOK, ""},
+		{"revoke others", http.MethodDelete, "/account/sessions", "/account/sessions", func(authService *MockAuthenticationService) {
+			authService.On("RevokeSessions", mock.Anything, orgID, userID, "laptop").Return(int64(2), nil)
+		}, http.StatusOK, ""},
+		{"revoke one", http.MethodDelete, "/account/sessions/phone", "/account/sessions/{id}", func(authService *MockAuthenticationService) {
+			authService.On("RevokeSession", mock.Anything, orgID, userID, "phone").Return(nil)
+		}, http.StatusNoContent, ""},
+		{"revoke unknown", http.MethodDelete, "/account/sessions/tablet", "/account/sessions/{id}", func(authService *MockAuthenticationService) {
+			authService.On("RevokeSession", mock.Anything, orgID, userID, "tablet").Return(services.ErrSessionNotFound)
+		}, http.StatusNotFound, "SESSION_NOT_FOUND"},
+		{"admin routes need the admin role", http.MethodGet, "/users/" + userID + "/sessions", "/users/{id}/sessions", func(authService *MockAuthenticationService) {}, http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authService := new(MockAuthenticationService)
+			tt.setup(authService)
+			router := newSessionTestRouter(orgContext, authService)
+
+			req := httptest.NewRequest(tt.method, "/api/v1"+tt.path, nil)
+			req.Header.Set("Authorization", "Bearer access-token")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if tt.expectedCode != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedCode)
+			}
+			assert.NoError(t, doc.Operation(tt.method, tt.operation).ValidateResponse(w.Code, w.Header(), w.Body.Bytes()))
+			authService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSessionHandler_UserSessions(t *testing.T) {
+	doc, err := OpenAPIDocument()
+	require.NoError(t, err)
+	orgContext := &middleware.OrganizationContext{
+		OrganizationID: primitive.NewObjectID(),
+		UserID:         primitive.NewObjectID(),
+		UserRole:       models.RoleAdmin,
+	}
+	orgID := orgContext.OrganizationID.Hex()
+	userID := primitive.NewObjectID().Hex()
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		operation      string
+		setup          func(authService *MockAuthenticationService)
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"list", http.MethodGet, "/users/" + userID + "/sessions", "/users/{id}/sessions", func(authService *MockAuthenticationService) {
+			authService.On("ListSessions", mock.Anything, orgID, userID, "laptop").Return([]*services.UserSession{}, nil)
+		}, http.StatusOK, ""},
+		{"other organization", http.MethodGet, "/users/" + userID + "/sessions", "/users/{id}/sessions", func(authService *MockAuthenticationService) {
+			authService.On("ListSessions", mock.Anything, orgID, userID, "laptop").Return(nil, services.ErrUserNotFound)
+		}, http.StatusNotFound, "USER_NOT_FOUND"},
+		{"revoke all", http.MethodDelete, "/users/" + userID + "/sessions", "/users/{id}/sessions", func(authService *MockAuthenticationService) {
+			authService.On("RevokeSessions", mock.Anything, orgID, userID, "").Return(int64(3), nil)
+		}, http.StatusOK, ""},
+		{"revoke one", http.MethodDelete, "/users/" + userID + "/sessions/phone", "/users/{id}/sessions/{session_id}", func(authService *MockAuthenticationService) {
+			authService.On("RevokeSession", mock.Anything, orgID, userID, "phone").Return(nil)
+		}, http.StatusNoContent, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authService := new(MockAuthenticationService)
+			tt.setup(authService)
+			router := newSessionTestRouter(orgContext, authService)
+
+			req := httptest.NewRequest(tt.method, "/api/v1"+tt.path, nil)
+			req.Header.Set("Authorization", "Bearer access-token")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if tt.expectedCode != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedCode)
+			}
+			assert.NoError(t, doc.Operation(tt.method, tt.operation).ValidateResponse(w.Code, w.Header(), w.Body.Bytes()))
+			authService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSessionHandler_RevokedSession(t *testing.T) {
+	orgContext := &middleware.OrganizationContext{
+		OrganizationID: primitive.NewObjectID(),
+		UserID:         primitive.NewObjectID(),
+	}
+	authService := new(MockAuthenticationService)
+	authService.On("ValidateAccessToken", mock.Anything, "revoked-token").Return(nil, services.ErrSessionRevoked)
+	router := newSessionTestRouter(orgContext, authService)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/account/sessions", nil)
+	req.Header.Set("Authorization", "Bearer revoked-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "SESSION_REVOKED")
+	authService.AssertNotCalled(t, "ListSessions", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
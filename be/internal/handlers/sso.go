@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// SSOHandler exposes single sign-on over HTTP. Its routes are called before
+// the user has a token, so they must be registered outside the authenticated
+// route groups.
+type SSOHandler struct {
+	ssoService services.SSOService
+	logger     *zap.Logger
+}
+
+// NewSSOHandler creates a new single sign-on handler.
+//
+// Parameters:
+//   - ssoService: Service running single sign-on logins
+//   - logger: Logger for handler operations
+//
+// Returns:
+//   - *SSOHandler: Configured handler instance
+func NewSSOHandler(ssoService services.SSOService, logger *zap.Logger) *SSOHandler {
+	return &SSOHandler{
+		ssoService: ssoService,
+		logger:     logger,
+	}
+}
+
+// RegisterRoutes registers the single sign-on routes on the given router group.
+func (h *SSOHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	oidc := rg.Group("/auth/sso/oidc")
+	oidc.GET("/:organization/login", h.BeginLogin)
+	oidc.GET("/callback", h.Callback)
+}
+
+// BeginLogin handles GET /auth/sso/oidc/:organization/login by redirecting the
+// browser to the organization's identity provider. The optional return_to
+// query parameter is handed back when the login completes.
+func (h *SSOHandler) BeginLogin(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	authURL, err := h.ssoService.BeginLogin(c.Request.Context(), &services.BeginSSOInput{
+		OrganizationSlug: c.Param("organization"),
+		ReturnTo:         c.Query("return_to"),
+	})
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback handles GET /auth/sso/oidc/callback, the redirect URI registered
+// with identity providers, and returns the platform's tokens.
+func (h *SSOHandler) Callback(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	result, err := h.ssoService.CompleteLogin(c.Request.Context(), &services.CompleteSSOInput{
+		State:            c.Query("state"),
+		Code:             c.Query("code"),
+		Error:            c.Query("error"),
+		ErrorDescription: c.Query("error_description"),
+		IPAddress:        c.ClientIP(),
+		UserAgent:        c.Request.UserAgent(),
+	})
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// MockSSOService is a mock of SSOService.
+type MockSSOService struct {
+	mock.Mock
+}
+
+func (m *MockSSOService) BeginLogin(ctx context.Context, input *services.BeginSSOInput) (string, error) {
+	args := m.Called(ctx, input)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockSSOService) CompleteLogin(ctx context.Context, input *services.CompleteSSOInput) (*services.SSOLoginResult, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.SSOLoginResult), args.Error(1)
+}
+
+func TestSSOHandler_Routes(t *testing.T) {
+	service := new(MockSSOService)
+	service.On("BeginLogin", mock.Anything, &services.BeginSSOInput{OrganizationSlug: "first-bank", ReturnTo: "/controls"}).
+		Return("https://idp.example.com/authorize?state=abc", nil)
+	service.On("BeginLogin", mock.Anything, &services.BeginSSOInput{OrganizationSlug: "no-sso"}).
+		Return("", services.ErrSSODisabled)
+	service.On("CompleteLogin", mock.Anything, mock.MatchedBy(func(input *services.CompleteSSOInput) bool {
+		return input.State == "abc" && input.Code == "code-1" && input.UserAgent == "browser"
+	})).Return(&services.SSOLoginResult{
+		LoginResponse: &models.LoginResponse{Success: true, AccessToken: "access-token", SessionID: "session-1"},
+		ReturnTo:      "/controls",
+	}, nil)
+	service.On("CompleteLogin", mock.Anything, mock.MatchedBy(func(input *services.CompleteSSOInput) bool {
+		return input.State == "stale"
+	})).Return(nil, services.ErrInvalidSSOState)
+	service.On("CompleteLogin", mock.Anything, mock.MatchedBy(func(input *services.CompleteSSOInput) bool {
+		return input.Error == "access_denied"
+	})).Return(nil, services.ErrSSOAuthenticationFailed)
+
+	tests := []struct {
+		name             string
+		path             string
+		expectedStatus   int
+		expectedBody     string
+		expectedLocation string
+	}{
+		{"login redirects to provider", "/auth/sso/oidc/first-bank/login?return_to=/controls", http.StatusFound, "", "https://idp.example.com/authorize?state=abc"},
+		{"login without SSO", "/auth/sso/oidc/no-sso/login", http.StatusForbidden, "SSO_DISABLED", ""},
+		{"callback returns tokens", "/auth/sso/oidc/callback?state=abc&code=code-1", http.StatusOK, `"access_token":"access-token"`, ""},
+		{"callback with stale state", "/auth/sso/oidc/callback?state=stale&code=code-1", http.StatusBadRequest, "INVALID_SSO_STATE", ""},
+		{"callback with provider error", "/auth/sso/oidc/callback?state=abc&error=access_denied", http.StatusUnauthorized, "SSO_AUTHENTICATION_FAILED", ""},
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewSSOHandler(service, zap.NewNop()).RegisterRoutes(router.Group("/api/v1"))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1"+tt.path, nil)
+			req.Header.Set("User-Agent", "browser")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			assert.Equal(t, tt.expectedLocation, w.Header().Get("Location"))
+			assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		})
+	}
+}
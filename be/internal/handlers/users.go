@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// UserHandler exposes user management and invitations over HTTP. Looking up
+// and accepting an invitation happen before the invitee has an account, so
+// RegisterRoutes must be called outside the authenticated route groups;
+// RegisterAdminRoutes belongs inside them and is restricted to administrators.
+type UserHandler struct {
+	userService services.UserService
+	logger      *zap.Logger
+}
+
+// userPage is the response of GET /users.
+type userPage struct {
+	Users      []*models.UserProfileResponse `json:"users"`
+	TotalCount int                           `json:"total_count"`
+	HasMore    bool                          `json:"has_more"`
+}
+
+// NewUserHandler creates a new user handler.
+//
+// Parameters:
+//   - userService: Service managing users and invitations
+//   - logger: Logger for handler operations
+//
+// Returns:
+//   - *UserHandler: Configured handler instance
+func NewUserHandler(userService services.UserService, logger *zap.Logger) *UserHandler {
+	return &UserHandler{
+		userService: userService,
+		logger:      logger,
+	}
+}
+
+// RegisterRoutes registers the public invitation routes on the given router group.
+func (h *UserHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/auth/invitations", h.LookupInvitation)
+	rg.POST("/auth/invitations/accept", h.AcceptInvitation)
+}
+
+// RegisterAdminRoutes registers the user and invitation management routes on
+// the given authenticated router group.
+func (h *UserHandler) RegisterAdminRoutes(rg *gin.RouterGroup) {
+	users := rg.Group("/users", middleware.RequireRole(models.RoleAdmin))
+	users.GET("", h.List)
+	users.POST("", h.Create)
+	users.GET("/:id", h.Get)
+	users.PATCH("/:id", h.Update)
+	users.DELETE("/:id", h.Deactivate)
+
+	invitations := rg.Group("/invitations", middleware.RequireRole(models.RoleAdmin))
+	invitations.GET("", h.ListInvitations)
+	invitations.POST("", h.Invite)
+	invitations.POST("/:id/resend", h.ResendInvitation)
+	invitations.DELETE("/:id", h.RevokeInvitation)
+}
+
+// List handles GET /users?role=...&status=...&department=...&limit=...&offset=...
+func (h *UserHandler) List(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	filter := &services.UserFilter{
+		OrganizationID: orgContext.OrganizationID.Hex(),
+		Role:           c.Query("role"),
+		Status:         c.Query("status"),
+		Department:     c.Query("department"),
+	}
+	for param, target := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		if value := c.Query(param); value != "" {
+			if *target, err = strconv.Atoi(value); err != nil {
+				respondError(c, h.logger, services.ErrInvalidInput)
+				return
+			}
+		}
+	}
+
+	connection, err := h.userService.ListUsers(c.Request.Context(), filter)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	page := &userPage{
+		Users:      make([]*models.UserProfileResponse, 0, len(connection.Nodes)),
+		TotalCount: connection.TotalCount,
+		HasMore:    connection.HasMore,
+	}
+	for _, user := range connection.Nodes {
+		page.Users = append(page.Users, user.ToUserProfileResponse())
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// Create handles POST /users and creates an active user with a password.
+func (h *UserHandler) Create(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	var input services.CreateUserInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBadRequest(c, err)
+		return
+	}
+	input.OrganizationID = orgContext.OrganizationID.Hex()
+
+	user, err := h.userService.CreateUser(c.Request.Context(), &input)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, user.ID.Hex())
+	c.JSON(http.StatusCreated, user.ToUserProfileResponse())
+}
+
+// Get handles GET /users/:id.
+func (h *UserHandler) Get(c *gin.Context) {
+	user, ok := h.organizationUser(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, user.ToUserProfileResponse())
+}
+
+// Update handles PATCH /users/:id; fields missing from the body are left unchanged.
+func (h *UserHandler) Update(c *gin.Context) {
+	user, ok := h.organizationUser(c)
+	if !ok {
+		return
+	}
+
+	var input services.UpdateUserInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBadRequest(c, err)
+		return
+	}
+
+	before := middleware.AuditSnapshot(user.ToUserProfileResponse())
+	updated, err := h.userService.UpdateUser(c.Request.Context(), user.ID.Hex(), &input)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, user.ID.Hex())
+	middleware.SetAuditChange(c, before, middleware.AuditSnapshot(updated.ToUserProfileResponse()))
+	c.JSON(http.StatusOK, updated.ToUserProfileResponse())
+}
+
+// Deactivate handles DELETE /users/:id. Users are deactivated rather than
+// deleted so their audit trail keeps resolving.
+func (h *UserHandler) Deactivate(c *gin.Context) {
+	user, ok := h.organizationUser(c)
+	if !ok {
+		return
+	}
+
+	previousStatus := user.Status
+	if err := h.userService.DeactivateUser(c.Request.Context(), user.ID.Hex()); err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, user.ID.Hex())
+	middleware.SetAuditChange(c,
+		map[string]interface{}{"status": previousStatus},
+		map[string]interface{}{"status": models.UserStatusInactive},
+	)
+	c.Status(http.StatusNoContent)
+}
+
+// ListInvitations handles GET /invitations?include_closed=true
+func (h *UserHandler) ListInvitations(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	invitations, err := h.userService.ListInvitations(c.Request.Context(), orgContext.OrganizationID.Hex(), c.Query("include_closed") == "true")
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invitations": invitations})
+}
+
+// Invite handles POST /invitations and emails an invitation link.
+func (h *UserHandler) Invite(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	var input services.InviteUserInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBadRequest(c, err)
+		return
+	}
+	input.OrganizationID = orgContext.OrganizationID.Hex()
+	input.InvitedBy = orgContext.UserID.Hex()
+
+	sent, err := h.userService.InviteUser(c.Request.Context(), &input)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, sent.Invitation.ID.Hex())
+	c.JSON(http.StatusCreated, sent)
+}
+
+// ResendInvitation handles POST /invitations/:id/resend.
+func (h *UserHandler) ResendInvitation(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	sent, err := h.userService.ResendInvitation(c.Request.Context(), orgContext.OrganizationID.Hex(), c.Param("id"), orgContext.UserID.Hex())
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, c.Param("id"))
+	c.JSON(http.StatusOK, sent)
+}
+
+// RevokeInvitation handles DELETE /invitations/:id.
+func (h *UserHandler) RevokeInvitation(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	invitation, err := h.userService.RevokeInvitation(c.Request.Context(), orgContext.OrganizationID.Hex(), c.Param("id"), orgContext.UserID.Hex())
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, c.Param("id"))
+	c.JSON(http.StatusOK, invitation)
+}
+
+// LookupInvitation handles GET /auth/invitations?token=... and describes the
+// invitation so the invitee can see what they are accepting.
+func (h *UserHandler) LookupInvitation(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	details, err := h.userService.LookupInvitation(c.Request.Context(), c.Query("token"))
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, details)
+}
+
+// AcceptInvitation handles POST /auth/invitations/accept and creates the
+// invitee's account.
+func (h *UserHandler) AcceptInvitation(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	var input services.AcceptInvitationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBadRequest(c, err)
+		return
+	}
+
+	user, err := h.userService.AcceptInvitation(c.Request.Context(), &input)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, user.ToUserProfileResponse())
+}
+
+// organizationUser loads the user named by the :id parameter, answering 404
+// for users of other organizations. It writes the error response itself.
+func (h *UserHandler) organizationUser(c *gin.Context) (*models.User, bool) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return nil, false
+	}
+
+	user, err := h.userService.GetUser(c.Request.Context(), c.Param("id"))
+	if err == nil && user.OrganizationID != orgContext.OrganizationID {
+		err = services.ErrUserNotFound
+	}
+	if err != nil {
+		respondError(c, h.logger, err)
+		return nil, false
+	}
+	return user, true
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// MockUserService is a mock of the UserService methods the user handler calls.
+type MockUserService struct {
+	services.UserService
+	mock.Mock
+}
+
+func (m *MockUserService) CreateUser(ctx context.Context, input *services.CreateUserInput) (*models.User, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserService) GetUser(ctx context.Context, id string) (*models.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserService) UpdateUser(ctx context.Context, id string, input *services.UpdateUserInput) (*models.User, error) {
+	args := m.Called(ctx, id, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserService) DeactivateUser(ctx context.Context, id string) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockUserService) ListUsers(ctx context.Context, filter *services.UserFilter) (*services.UserConnection, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.UserConnection), args.Error(1)
+}
+
+func (m *MockUserService) InviteUser(ctx context.Context, input *services.InviteUserInput) (*services.InvitationSecret, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.InvitationSecret), args.Error(1)
+}
+
+func (m *MockUserService) ListInvitations(ctx context.Context, orgID string, includeClosed bool) ([]*models.Invitation, error) {
+	args := m.Called(ctx, orgID, includeClosed)
+	return args.Get(0).([]*models.Invitation), args.Error(1)
+}
+
+func (m *MockUserService) ResendInvitation(ctx context.Context, orgID, invitationID, resentBy string) (*services.InvitationSecret, error) {
+	args := m.Called(ctx, orgID, invitationID, resentBy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.InvitationSecret), args.Error(1)
+}
+
+func (m *MockUserService) RevokeInvitation(ctx context.Context, orgID, invitationID, revokedBy string) (*models.Invitation, error) {
+	args := m.Called(ctx, orgID, invitationID, revokedBy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Invitation), args.Error(1)
+}
+
+func (m *MockUserService) LookupInvitation(ctx context.Context, token string) (*services.InvitationDetails, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.InvitationDetails), args.Error(1)
+}
+
+func (m *MockUserService) AcceptInvitation(ctx context.Context, input *services.AcceptInvitationInput) (*models.User, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func TestUserHandler_AdminRoutes(t *testing.T) {
+	doc, err := OpenAPIDocument()
+	require.NoError(t, err)
+
+	now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	orgID := primitive.NewObjectID()
+	adminID := primitive.NewObjectID()
+	user := &models.User{Email: "ada@first-bank.com", Profile: models.UserProfile{FirstName: "Ada", LastName: "Auditor"},
+		Roles: []string{models.RoleAuditor}, OrganizationID: orgID, IsActive: true, Status: models.UserStatusActive}
+	user.ID = primitive.NewObjectID()
+	user.CreatedAt, user.UpdatedAt = now, now
+	user.Authentication.PasswordHash = "$2a$04$hash"
+	userID := user.ID.Hex()
+	outsider := &models.User{Email: "eve@other-bank.com", OrganizationID: primitive.NewObjectID()}
+	outsider.ID = primitive.NewObjectID()
+	invitation := &models.Invitation{ID: primitive.NewObjectID(), OrganizationID: orgID, Email: "grace@first-bank.com", Role: models.RoleAuditor,
+		Status: models.InvitationStatusPending, ExpiresAt: now.Add(7 * 24 * time.Hour), InvitedBy: adminID, CreatedAt: now, UpdatedAt: now}
+	invitationID := invitation.ID.Hex()
+
+	service := new(MockUserService)
+	service.On("ListUsers", mock.Anything, mock.MatchedBy(func(filter *services.UserFilter) bool {
+		return filter.OrganizationID == orgID.Hex() && filter.Status == models.UserStatusActive && filter.Limit == 10
+	})).Return(&services.UserConnection{Nodes: []*models.User{user}, TotalCount: 1}, nil)
+	service.On("CreateUser", mock.Anything, mock.MatchedBy(func(input *services.CreateUserInput) bool {
+		return input.OrganizationID == orgID.Hex() && input.Email == "ada@first-bank.com"
+	})).Return(user, nil)
+	service.On("CreateUser", mock.Anything, mock.Anything).Return(nil, services.ErrMemberLimitReached)
+	service.On("GetUser", mock.Anything, userID).Return(user, nil)
+	service.On("GetUser", mock.Anything, outsider.ID.Hex()).Return(outsider, nil)
+	service.On("UpdateUser", mock.Anything, userID, mock.MatchedBy(func(input *services.UpdateUserInput) bool {
+		return input.Role != nil && *input.Role == models.RoleManager
+	})).Return(user, nil)
+	service.On("UpdateUser", mock.Anything, userID, mock.Anything).Return(nil, services.ErrInvalidUserStatus)
+	service.On("DeactivateUser", mock.Anything, userID).Return(nil)
+	service.On("ListInvitations", mock.Anything, orgID.Hex(), true).Return([]*models.Invitation{invitation}, nil)
+	service.On("InviteUser", mock.Anything, mock.MatchedBy(func(input *services.InviteUserInput) bool {
+		return input.OrganizationID == orgID.Hex() && input.InvitedBy == adminID.Hex() && input.Email == "grace@first-bank.com"
+	})).Return(&services.InvitationSecret{Invitation: invitation, Token: "signed-token"}, nil)
+	service.On("InviteUser", mock.Anything, mock.Anything).Return(nil, services.ErrInvitationExists)
+	service.On("ResendInvitation", mock.Anything, orgID.Hex(), invitationID, adminID.Hex()).Return(&services.InvitationSecret{Invitation: invitation, Token: "new-token"}, nil)
+	service.On("RevokeInvitation", mock.Anything, orgID.Hex(), invitationID, adminID.Hex()).Return(nil, services.ErrInvitationClosed)
+
+	tests := []struct {
+		name           string
+		role           string
+		method         string
+		path           string
+		route          string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"admin lists users", models.RoleAdmin, http.MethodGet, "/users?status=active&limit=10", "/users", "", http.StatusOK, `"email":"ada@first-bank.com"`},
+		{"manager cannot list users", models.RoleManager, http.MethodGet, "/users", "/users", "", http.StatusForbidden, "INSUFFICIENT_ROLE"},
+		{"bad page size", models.RoleAdmin, http.MethodGet, "/users?limit=ten", "/users", "", http.StatusBadRequest, "INVALID_INPUT"},
+		{"create user", models.RoleAdmin, http.MethodPost, "/users", "/users", `{"first_name":"Ada","last_name":"Auditor","email":"ada@first-bank.com","password":"correct horse battery"}`, http.StatusCreated, userID},
+		{"create beyond member limit", models.RoleAdmin, http.MethodPost, "/users", "/users", `{"first_name":"Bob","last_name":"Banker","email":"bob@first-bank.com","password":"correct horse battery"}`, http.StatusForbidden, "MEMBER_LIMIT_REACHED"},
+		{"get user", models.RoleAdmin, http.MethodGet, "/users/" + userID, "/users/{id}", "", http.StatusOK, `"role":"auditor"`},
+		{"user of another organization", models.RoleAdmin, http.MethodGet, "/users/" + outsider.ID.Hex(), "/users/{id}", "", http.StatusNotFound, "USER_NOT_FOUND"},
+		{"update role", models.RoleAdmin, http.MethodPatch, "/users/" + userID, "/users/{id}", `{"role":"manager"}`, http.StatusOK, userID},
+		{"update with bad status", models.RoleAdmin, http.MethodPatch, "/users/" + userID, "/users/{id}", `{"status":"locked"}`, http.StatusBadRequest, "INVALID_USER_STATUS"},
+		{"deactivate user", models.RoleAdmin, http.MethodDelete, "/users/" + userID, "/users/{id}", "", http.StatusNoContent, ""},
+		{"list invitations", models.RoleAdmin, http.MethodGet, "/invitations?include_closed=true", "/invitations", "", http.StatusOK, invitationID},
+		{"invite", models.RoleAdmin, http.MethodPost, "/invitations", "/invitations", `{"email":"grace@first-bank.com"}`, http.StatusCreated, "signed-token"},
+		{"invite twice", models.RoleAdmin, http.MethodPost, "/invitations", "/invitations", `{"email":"bob@first-bank.com"}`, http.StatusConflict, "INVITATION_EXISTS"},
+		{"resend invitation", models.RoleAdmin, http.MethodPost, "/invitations/" + invitationID + "/resend", "/invitations/{id}/resend", "", http.StatusOK, "new-token"},
+		{"revoke accepted invitation", models.RoleAdmin, http.MethodDelete, "/invitations/" + invitationID, "/invitations/{id}", "", http.StatusConflict, "INVITATION_CLOSED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgContext := &middleware.OrganizationContext{OrganizationID: orgID, UserID: adminID, UserRole: tt.role}
+			router := newTestRouter(orgContext, NewUserHandler(service, zap.NewNop()).RegisterAdminRoutes)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, "/api/v1"+tt.path, strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			assert.NotContains(t, w.Body.String(), "$2a$04$hash")
+			op := doc.Operation(tt.method, tt.route)
+			require.NotNil(t, op)
+			assert.NoError(t, op.ValidateResponse(w.Code, w.Header(), w.Body.Bytes()))
+		})
+	}
+}
+
+// recordingAuditLogger captures the audit entries of requests.
+type recordingAuditLogger struct {
+	inputs []*services.AuditInput
+}
+
+func (r *recordingAuditLogger) LogAction(ctx context.Context, input *services.AuditInput) error {
+	r.inputs = append(r.inputs, input)
+	return nil
+}
+
+func TestUserHandler_UpdateStoresDiff(t *testing.T) {
+	orgID := primitive.NewObjectID()
+	user := &models.User{Email: "ada@first-bank.com", OrganizationID: orgID, Roles: []string{models.RoleAuditor}, Status: models.UserStatusActive}
+	user.ID = primitive.NewObjectID()
+	user.Profile.Department = "Risk"
+	updated := *user
+	updated.Profile.Department = "Internal Audit"
+	userID := user.ID.Hex()
+
+	service := new(MockUserService)
+	service.On("GetUser", mock.Anything, userID).Return(user, nil)
+	service.On("UpdateUser", mock.Anything, userID, mock.Anything).Return(&updated, nil)
+	service.On("DeactivateUser", mock.Anything, userID).Return(nil)
+
+	recorder := &recordingAuditLogger{}
+	orgContext := &middleware.OrganizationContext{OrganizationID: orgID, UserID: primitive.NewObjectID(), UserRole: models.RoleAdmin}
+	router := newTestRouter(orgContext, func(rg *gin.RouterGroup) {
+		rg.Use(middleware.NewAuditMiddleware(recorder, zap.NewNop()).RecordMutations())
+		NewUserHandler(service, zap.NewNop()).RegisterAdminRoutes(rg)
+	})
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/users/"+userID, strings.NewReader(`{"department":"Internal Audit"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/api/v1/users/"+userID, nil))
+
+	require.Len(t, recorder.inputs, 2)
+	assert.Equal(t, userID, recorder.inputs[0].ResourceID)
+	assert.Equal(t, map[string]interface{}{"department": "Risk"}, recorder.inputs[0].OldValues)
+	assert.Equal(t, map[string]interface{}{"department": "Internal Audit"}, recorder.inputs[0].NewValues)
+	assert.Equal(t, map[string]interface{}{"status": models.UserStatusActive}, recorder.inputs[1].OldValues)
+	assert.Equal(t, map[string]interface{}{"status": models.UserStatusInactive}, recorder.inputs[1].NewValues)
+}
+
+func TestUserHandler_InvitationRoutes(t *testing.T) {
+	doc, err := OpenAPIDocument()
+	require.NoError(t, err)
+
+	now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	user := &models.User{Email: "grace@first-bank.com", Profile: models.UserProfile{FirstName: "Grace", LastName: "Hopper"},
+		Roles: []string{models.RoleAuditor}, OrganizationID: primitive.NewObjectID(), IsActive: true, Status: models.UserStatusActive}
+	user.ID = primitive.NewObjectID()
+	user.CreatedAt, user.UpdatedAt = now, now
+
+	service := new(MockUserService)
+	service.On("LookupInvitation", mock.Anything, "signed-token").Return(&services.InvitationDetails{
+		Email: "grace@first-bank.com", Role: models.RoleAuditor, OrganizationName: "First Bank", ExpiresAt: now.Add(time.Hour),
+	}, nil)
+	service.On("LookupInvitation", mock.Anything, mock.Anything).Return(nil, services.ErrInvalidInvitation)
+	service.On("AcceptInvitation", mock.Anything, mock.MatchedBy(func(input *services.AcceptInvitationInput) bool {
+		return input.Token == "signed-token" && input.FirstName == "Grace"
+	})).Return(user, nil)
+	service.On("AcceptInvitation", mock.Anything, mock.Anything).Return(nil, services.ErrPasswordTooWeak)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		route          string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"lookup", http.MethodGet, "/auth/invitations?token=signed-token", "/auth/invitations", "", http.StatusOK, `"organization_name":"First Bank"`},
+		{"lookup expired link", http.MethodGet, "/auth/invitations?token=old-token", "/auth/invitations", "", http.StatusGone, "INVALID_INVITATION"},
+		{"accept", http.MethodPost, "/auth/invitations/accept", "/auth/invitations/accept", `{"token":"signed-token","first_name":"Grace","last_name":"Hopper","password":"correct horse battery"}`, http.StatusCreated, user.ID.Hex()},
+		{"accept with weak password", http.MethodPost, "/auth/invitations/accept", "/auth/invitations/accept", `{"token":"other-token","first_name":"Grace","last_name":"Hopper","password":"short"}`, http.StatusBadRequest, "PASSWORD_TOO_WEAK"},
+		{"accept malformed body", http.MethodPost, "/auth/invitations/accept", "/auth/invitations/accept", `{"token":`, http.StatusBadRequest, "INVALID_REQUEST_BODY"},
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewUserHandler(service, zap.NewNop()).RegisterRoutes(router.Group("/api/v1"))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, "/api/v1"+tt.path, strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+			op := doc.Operation(tt.method, tt.route)
+			require.NotNil(t, op)
+			assert.NoError(t, op.ValidateResponse(w.Code, w.Header(), w.Body.Bytes()))
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// WebhookHandler exposes an organization's webhook subscriptions and their
+// delivery logs over HTTP. All routes are restricted to administrators.
+type WebhookHandler struct {
+	webhookService services.WebhookService
+	logger         *zap.Logger
+}
+
+// NewWebhookHandler creates a new webhook handler.
+//
+// Parameters:
+//   - webhookService: Service managing webhook subscriptions and deliveries
+//   - logger: Logger for handler operations
+//
+// Returns:
+//   - *WebhookHandler: Configured handler instance
+func NewWebhookHandler(webhookService services.WebhookService, logger *zap.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+		logger:         logger,
+	}
+}
+
+// RegisterRoutes registers the webhook routes on the given router group.
+func (h *WebhookHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	webhooks := rg.Group("/webhooks", middleware.RequireRole(models.RoleAdmin))
+	webhooks.GET("", h.List)
+	webhooks.POST("", h.Create)
+	webhooks.GET("/:id", h.Get)
+	webhooks.PUT("/:id", h.Update)
+	webhooks.DELETE("/:id", h.Delete)
+	webhooks.POST("/:id/rotate-secret", h.RotateSecret)
+	webhooks.GET("/:id/deliveries", h.ListDeliveries)
+	webhooks.POST("/:id/deliveries/:deliveryID/redeliver", h.Redeliver)
+}
+
+// List handles GET /webhooks.
+func (h *WebhookHandler) List(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	subscriptions, err := h.webhookService.ListSubscriptions(c.Request.Context(), orgContext.OrganizationID.Hex())
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhooks": subscriptions})
+}
+
+// Create handles POST /webhooks. The response is the only time the signing
+// secret is shown, apart from rotations.
+func (h *WebhookHandler) Create(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	var input services.WebhookSubscriptionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBadRequest(c, err)
+		return
+	}
+	input.OrganizationID = orgContext.OrganizationID.Hex()
+	input.CreatedBy = orgContext.UserID.Hex()
+
+	created, err := h.webhookService.CreateSubscription(c.Request.Context(), &input)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, created.Subscription.ID.Hex())
+	c.JSON(http.StatusCreated, created)
+}
+
+// Get handles GET /webhooks/:id.
+func (h *WebhookHandler) Get(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	subscription, err := h.webhookService.GetSubscription(c.Request.Context(), orgContext.OrganizationID.Hex(), c.Param("id"))
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// Update handles PUT /webhooks/:id.
+func (h *WebhookHandler) Update(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	var input services.UpdateWebhookSubscriptionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		respondBadRequest(c, err)
+		return
+	}
+	input.OrganizationID = orgContext.OrganizationID.Hex()
+	input.SubscriptionID = c.Param("id")
+
+	previous, err := h.webhookService.GetSubscription(c.Request.Context(), input.OrganizationID, input.SubscriptionID)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+	before := middleware.AuditSnapshot(previous)
+
+	subscription, err := h.webhookService.UpdateSubscription(c.Request.Context(), &input)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, subscription.ID.Hex())
+	middleware.SetAuditChange(c, before, middleware.AuditSnapshot(subscription))
+	c.JSON(http.StatusOK, subscription)
+}
+
+// Delete handles DELETE /webhooks/:id.
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	previous, err := h.webhookService.GetSubscription(c.Request.Context(), orgContext.OrganizationID.Hex(), c.Param("id"))
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	if err := h.webhookService.DeleteSubscription(c.Request.Context(), orgContext.OrganizationID.Hex(), c.Param("id")); err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, c.Param("id"))
+	middleware.SetAuditChange(c, middleware.AuditSnapshot(previous), nil)
+	c.Status(http.StatusNoContent)
+}
+
+// RotateSecret handles POST /webhooks/:id/rotate-secret.
+func (h *WebhookHandler) RotateSecret(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	rotated, err := h.webhookService.RotateSecret(c.Request.Context(), orgContext.OrganizationID.Hex(), c.Param("id"))
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	// The secret itself is never audited, only that its version moved on
+	middleware.SetAuditResourceID(c, rotated.Subscription.ID.Hex())
+	middleware.SetAuditChange(c,
+		map[string]interface{}{"secret_version": rotated.Subscription.SecretVersion - 1},
+		map[string]interface{}{"secret_version": rotated.Subscription.SecretVersion},
+	)
+	c.JSON(http.StatusOK, rotated)
+}
+
+// ListDeliveries handles GET /webhooks/:id/deliveries?limit=...&offset=...
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	var limit, offset int
+	for param, target := range map[string]*int{"limit": &limit, "offset": &offset} {
+		if value := c.Query(param); value != "" {
+			if *target, err = strconv.Atoi(value); err != nil {
+				respondError(c, h.logger, services.ErrInvalidInput)
+				return
+			}
+		}
+	}
+
+	page, err := h.webhookService.ListDeliveries(c.Request.Context(), orgContext.OrganizationID.Hex(), c.Param("id"), limit, offset)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// Redeliver handles POST /webhooks/:id/deliveries/:deliveryID/redeliver.
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	orgContext, err := middleware.GetOrganizationContext(c)
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	delivery, err := h.webhookService.Redeliver(c.Request.Context(), orgContext.OrganizationID.Hex(), c.Param("id"), c.Param("deliveryID"))
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	middleware.SetAuditResourceID(c, delivery.ID.Hex())
+	c.JSON(http.StatusAccepted, delivery)
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/middleware"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// MockWebhookService is a mock of the administrative methods of WebhookService;
+// publishing is not used by handlers.
+type MockWebhookService struct {
+	services.WebhookService
+	mock.Mock
+}
+
+func (m *MockWebhookService) CreateSubscription(ctx context.Context, input *services.WebhookSubscriptionInput) (*services.WebhookSubscriptionSecret, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.WebhookSubscriptionSecret), args.Error(1)
+}
+
+func (m *MockWebhookService) GetSubscription(ctx context.Context, orgID, subscriptionID string) (*models.WebhookSubscription, error) {
+	args := m.Called(ctx, orgID, subscriptionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookService) ListSubscriptions(ctx context.Context, orgID string) ([]*models.WebhookSubscription, error) {
+	args := m.Called(ctx, orgID)
+	return args.Get(0).([]*models.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookService) UpdateSubscription(ctx context.Context, input *services.UpdateWebhookSubscriptionInput) (*models.WebhookSubscription, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookService) DeleteSubscription(ctx context.Context, orgID, subscriptionID string) error {
+	return m.Called(ctx, orgID, subscriptionID).Error(0)
+}
+
+func (m *MockWebhookService) RotateSecret(ctx context.Context, orgID, subscriptionID string) (*services.WebhookSubscriptionSecret, error) {
+	args := m.Called(ctx, orgID, subscriptionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.WebhookSubscriptionSecret), args.Error(1)
+}
+
+func (m *MockWebhookService) ListDeliveries(ctx context.Context, orgID, subscriptionID string, limit, offset int) (*services.WebhookDeliveryConnection, error) {
+	args := m.Called(ctx, orgID, subscriptionID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.WebhookDeliveryConnection), args.Error(1)
+}
+
+func (m *MockWebhookService) Redeliver(ctx context.Context, orgID, subscriptionID, deliveryID string) (*models.WebhookDelivery, error) {
+	args := m.Called(ctx, orgID, subscriptionID, deliveryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookDelivery), args.Error(1)
+}
+
+func TestWebhookHandler_Routes(t *testing.T) {
+	orgID := primitive.NewObjectID()
+	subscription := &models.WebhookSubscription{ID: primitive.NewObjectID(), OrganizationID: orgID, IsActive: true}
+	subscriptionID := subscription.ID.Hex()
+	missingID := primitive.NewObjectID().Hex()
+	deliveryID := primitive.NewObjectID().Hex()
+
+	service := new(MockWebhookService)
+	service.On("ListSubscriptions", mock.Anything, orgID.Hex()).Return([]*models.WebhookSubscription{subscription}, nil)
+	service.On("CreateSubscription", mock.Anything, mock.MatchedBy(func(input *services.WebhookSubscriptionInput) bool {
+		return input.OrganizationID == orgID.Hex() && input.URL == "https://hooks.bank.test"
+	})).Return(&services.WebhookSubscriptionSecret{Subscription: subscription, Secret: "whsec_abc"}, nil)
+	service.On("CreateSubscription", mock.Anything, mock.Anything).Return(nil, services.ErrInvalidWebhookURL)
+	service.On("GetSubscription", mock.Anything, orgID.Hex(), subscriptionID).Return(subscription, nil)
+	service.On("GetSubscription", mock.Anything, orgID.Hex(), missingID).Return(nil, services.ErrWebhookNotFound)
+	service.On("UpdateSubscription", mock.Anything, mock.MatchedBy(func(input *services.UpdateWebhookSubscriptionInput) bool {
+		return input.SubscriptionID == subscriptionID && input.IsActive != nil && !*input.IsActive
+	})).Return(subscription, nil)
+	service.On("DeleteSubscription", mock.Anything, orgID.Hex(), subscriptionID).Return(nil)
+	service.On("RotateSecret", mock.Anything, orgID.Hex(), subscriptionID).Return(nil, services.ErrWebhookSigningDisabled)
+	service.On("ListDeliveries", mock.Anything, orgID.Hex(), subscriptionID, 5, 10).Return(&services.WebhookDeliveryConnection{TotalCount: 12}, nil)
+	service.On("Redeliver", mock.Anything, orgID.Hex(), subscriptionID, deliveryID).Return(nil, services.ErrWebhookInactive)
+
+	tests := []struct {
+		name           string
+		role           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"admin lists webhooks", models.RoleAdmin, http.MethodGet, "/webhooks", "", http.StatusOK, subscriptionID},
+		{"auditor cannot list webhooks", models.RoleAuditor, http.MethodGet, "/webhooks", "", http.StatusForbidden, "INSUFFICIENT_ROLE"},
+		{"create returns secret", models.RoleAdmin, http.MethodPost, "/webhooks", `{"url":"https://hooks.bank.test","events":["cycle.completed"]}`, http.StatusCreated, "whsec_abc"},
+		{"create with plain http", models.RoleAdmin, http.MethodPost, "/webhooks", `{"url":"http://hooks.bank.test","events":["cycle.completed"]}`, http.StatusBadRequest, "INVALID_WEBHOOK_URL"},
+		{"create with malformed body", models.RoleAdmin, http.MethodPost, "/webhooks", `{"url":`, http.StatusBadRequest, "INVALID_REQUEST_BODY"},
+		{"get missing webhook", models.RoleAdmin, http.MethodGet, "/webhooks/" + missingID, "", http.StatusNotFound, "WEBHOOK_NOT_FOUND"},
+		{"disable webhook", models.RoleAdmin, http.MethodPut, "/webhooks/" + subscriptionID, `{"is_active":false}`, http.StatusOK, ""},
+		{"delete webhook", models.RoleAdmin, http.MethodDelete, "/webhooks/" + subscriptionID, "", http.StatusNoContent, ""},
+		{"rotate without master secret", models.RoleAdmin, http.MethodPost, "/webhooks/" + subscriptionID + "/rotate-secret", "", http.StatusServiceUnavailable, "WEBHOOK_SIGNING_DISABLED"},
+		{"list deliveries page", models.RoleAdmin, http.MethodGet, "/webhooks/" + subscriptionID + "/deliveries?limit=5&offset=10", "", http.StatusOK, `"total_count":12`},
+		{"non-numeric delivery limit", models.RoleAdmin, http.MethodGet, "/webhooks/" + subscriptionID + "/deliveries?limit=five", "", http.StatusBadRequest, "INVALID_INPUT"},
+		{"redeliver to disabled webhook", models.RoleAdmin, http.MethodPost, "/webhooks/" + subscriptionID + "/deliveries/" + deliveryID + "/redeliver", "", http.StatusConflict, "WEBHOOK_INACTIVE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgContext := &middleware.OrganizationContext{OrganizationID: orgID, UserID: primitive.NewObjectID(), UserRole: tt.role}
+			router := newTestRouter(orgContext, NewWebhookHandler(service, zap.NewNop()).RegisterRoutes)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, "/api/v1"+tt.path, strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// AuditExportJob polls for queued audit exports and runs them in the background.
+type AuditExportJob struct {
+	service  services.AuditExportService
+	interval time.Duration
+	logger   *zap.Logger
+}
+
+// NewAuditExportJob creates a new audit export job.
+//
+// Parameters:
+//   - service: Export service running the queued exports
+//   - interval: Time between polls for queued exports
+//   - logger: Logger for job operations
+//
+// Returns:
+//   - *AuditExportJob: Configured job instance
+//
+// Example:
+//
+//	job := jobs.NewAuditExportJob(auditExportService, cfg.Audit.ExportPollInterval, logger)
+//	go job.Start(ctx)
+func NewAuditExportJob(service services.AuditExportService, interval time.Duration, logger *zap.Logger) *AuditExportJob {
+	return &AuditExportJob{
+		service:  service,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Start processes queued exports immediately and then on every interval tick
+// until the context is cancelled.
+//
+// Parameters:
+//   - ctx: Context controlling the job lifetime
+func (j *AuditExportJob) Start(ctx context.Context) {
+	runEvery(ctx, "Audit export", j.interval, j.logger, func(ctx context.Context) {
+		j.RunOnce(ctx)
+	})
+}
+
+// RunOnce processes all queued exports and logs the outcome.
+//
+// Parameters:
+//   - ctx: Request context
+//
+// Returns:
+//   - int: Number of exports processed
+func (j *AuditExportJob) RunOnce(ctx context.Context) int {
+	started := time.Now()
+
+	processed, err := j.service.ProcessPending(ctx)
+	if err != nil {
+		j.logger.Error("Audit export run failed", zap.Error(err))
+	}
+	if processed > 0 {
+		j.logger.Info("Audit export run completed",
+			zap.Int("exports", processed),
+			zap.Duration("duration", time.Since(started)),
+		)
+	}
+	return processed
+}
@@ -0,0 +1,76 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/events"
+)
+
+// EventRelayJob polls the domain event outbox and relays committed events to
+// the event stream. Events stay in the outbox until they are relayed, so events
+// published before a restart reach the asynchronous handlers once the job runs
+// again.
+type EventRelayJob struct {
+	bus      *events.Bus
+	interval time.Duration
+	logger   *zap.Logger
+}
+
+// NewEventRelayJob creates a new event relay job.
+//
+// Parameters:
+//   - bus: Event bus whose outbox is relayed
+//   - interval: Time between outbox polls
+//   - logger: Logger for job operations
+//
+// Returns:
+//   - *EventRelayJob: Configured job instance
+//
+// Example:
+//
+//	job := jobs.NewEventRelayJob(bus, cfg.Events.RelayInterval, logger)
+//	go job.Start(ctx)
+func NewEventRelayJob(bus *events.Bus, interval time.Duration, logger *zap.Logger) *EventRelayJob {
+	return &EventRelayJob{
+		bus:      bus,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Start relays pending events immediately and then on every interval tick
+// until the context is cancelled.
+//
+// Parameters:
+//   - ctx: Context controlling the job lifetime
+func (j *EventRelayJob) Start(ctx context.Context) {
+	runEvery(ctx, "Event relay", j.interval, j.logger, func(ctx context.Context) {
+		j.RunOnce(ctx)
+	})
+}
+
+// RunOnce relays one batch of pending events and logs the outcome.
+//
+// Parameters:
+//   - ctx: Request context
+//
+// Returns:
+//   - int: Number of events attempted
+func (j *EventRelayJob) RunOnce(ctx context.Context) int {
+	started := time.Now()
+
+	attempted, err := j.bus.RelayPending(ctx)
+	if err != nil {
+		j.logger.Error("Event relay run failed", zap.Error(err))
+	}
+	if attempted > 0 {
+		j.logger.Debug("Event relay run completed",
+			zap.Int("events", attempted),
+			zap.Duration("duration", time.Since(started)),
+		)
+	}
+	return attempted
+}
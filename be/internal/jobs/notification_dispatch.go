@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// NotificationDispatchJob polls the notification outbox and delivers due emails.
+// Because messages are persisted before sending, emails queued before a restart
+// are delivered once the job runs again.
+type NotificationDispatchJob struct {
+	dispatcher services.NotificationDispatcher
+	interval   time.Duration
+	logger     *zap.Logger
+}
+
+// NewNotificationDispatchJob creates a new notification dispatch job.
+//
+// Parameters:
+//   - dispatcher: Dispatcher delivering outbox messages
+//   - interval: Time between outbox polls
+//   - logger: Logger for job operations
+//
+// Returns:
+//   - *NotificationDispatchJob: Configured job instance
+//
+// Example:
+//
+//	job := jobs.NewNotificationDispatchJob(dispatcher, cfg.Email.OutboxPollInterval, logger)
+//	go job.Start(ctx)
+func NewNotificationDispatchJob(dispatcher services.NotificationDispatcher, interval time.Duration, logger *zap.Logger) *NotificationDispatchJob {
+	return &NotificationDispatchJob{
+		dispatcher: dispatcher,
+		interval:   interval,
+		logger:     logger,
+	}
+}
+
+// Start dispatches due messages immediately and then on every interval tick
+// until the context is cancelled.
+//
+// Parameters:
+//   - ctx: Context controlling the job lifetime
+func (j *NotificationDispatchJob) Start(ctx context.Context) {
+	runEvery(ctx, "Notification dispatch", j.interval, j.logger, func(ctx context.Context) {
+		j.RunOnce(ctx)
+	})
+}
+
+// RunOnce dispatches one batch of due messages and logs the outcome.
+//
+// Parameters:
+//   - ctx: Request context
+//
+// Returns:
+//   - int: Number of messages attempted
+func (j *NotificationDispatchJob) RunOnce(ctx context.Context) int {
+	started := time.Now()
+
+	attempted, err := j.dispatcher.DispatchPending(ctx)
+	if err != nil {
+		j.logger.Error("Notification dispatch run failed", zap.Error(err))
+	}
+	if attempted > 0 {
+		j.logger.Info("Notification dispatch run completed",
+			zap.Int("messages", attempted),
+			zap.Duration("duration", time.Since(started)),
+		)
+	}
+	return attempted
+}
@@ -0,0 +1,28 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// runEvery calls run immediately and then on every interval tick until the
+// context is cancelled.
+func runEvery(ctx context.Context, name string, interval time.Duration, logger *zap.Logger, run func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	logger.Info(name+" job started", zap.Duration("interval", interval))
+
+	for {
+		run(ctx)
+
+		select {
+		case <-ctx.Done():
+			logger.Info(name + " job stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
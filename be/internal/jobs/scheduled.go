@@ -0,0 +1,125 @@
+// Package jobs contains background jobs that run periodically alongside the API server.
+// Jobs delegate all business logic to the service layer and only handle scheduling,
+// logging and graceful shutdown.
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// Names of the jobs run by the scheduler. They are the keys of the
+// scheduler.schedules configuration.
+const (
+	ScheduledEvidenceLifecycle   = "evidence_lifecycle"
+	ScheduledRetention           = "retention"
+	ScheduledSubscriptionRenewal = "subscription_renewal"
+	ScheduledNotificationDigest  = "notification_digest"
+	ScheduledAuditCheckpoint     = "audit_checkpoint"
+	ScheduledLDAPSync            = "ldap_sync"
+)
+
+// ScheduledServices contains the services whose periodic work is run by the
+// scheduler.
+type ScheduledServices struct {
+	EvidenceLifecycle services.EvidenceLifecycleService
+	Retention         services.RetentionService
+	Organizations     services.OrganizationService
+	Digests           services.NotificationDigester
+	AuditChain        services.AuditChainService
+	Directory         services.LDAPService
+}
+
+// RegisterScheduledJobs registers the platform's periodic work with the
+// scheduler. Each job runs on the schedule configured under its name; jobs
+// without a schedule are not registered. The scheduler leases each run, so a
+// job runs on one replica at a time.
+//
+// Parameters:
+//   - scheduler: Scheduler to register the jobs with
+//   - schedules: Cron expressions by job name
+//   - svc: Services doing the work
+//
+// Returns:
+//   - error: Error if a schedule is invalid
+//
+// Example:
+//
+//	err := jobs.RegisterScheduledJobs(scheduler, cfg.Scheduler.Schedules, jobs.ScheduledServices{
+//		EvidenceLifecycle: lifecycleService,
+//		Retention:         retentionService,
+//		Organizations:     organizationService,
+//		Digests:           digestService,
+//		AuditChain:        auditChainService,
+//		Directory:         ldapService,
+//	})
+func RegisterScheduledJobs(scheduler services.SchedulerService, schedules map[string]string, svc ScheduledServices) error {
+	jobs := []struct {
+		name        string
+		description string
+		run         services.ScheduledJobFunc
+	}{
+		{
+			name:        ScheduledEvidenceLifecycle,
+			description: "Sends evidence reminders and marks overdue requests",
+			run: func(ctx context.Context) error {
+				_, err := svc.EvidenceLifecycle.RunLifecycle(ctx, time.Now())
+				return err
+			},
+		},
+		{
+			name:        ScheduledRetention,
+			description: "Purges data past each organization's retention period",
+			run: func(ctx context.Context) error {
+				_, err := svc.Retention.EnforceAll(ctx)
+				return err
+			},
+		},
+		{
+			name:        ScheduledSubscriptionRenewal,
+			description: "Renews or expires subscriptions whose period has ended",
+			run: func(ctx context.Context) error {
+				_, err := svc.Organizations.RenewDueSubscriptions(ctx, time.Now())
+				return err
+			},
+		},
+		{
+			name:        ScheduledNotificationDigest,
+			description: "Sends due notification digests",
+			run: func(ctx context.Context) error {
+				_, err := svc.Digests.SendDueDigests(ctx)
+				return err
+			},
+		},
+		{
+			name:        ScheduledAuditCheckpoint,
+			description: "Signs the audit chain head of every active organization",
+			run: func(ctx context.Context) error {
+				_, err := svc.AuditChain.CheckpointAll(ctx)
+				return err
+			},
+		},
+		{
+			name:        ScheduledLDAPSync,
+			description: "Syncs accounts from the directories of organizations with LDAP sync enabled",
+			run: func(ctx context.Context) error {
+				_, err := svc.Directory.SyncAll(ctx)
+				return err
+			},
+		},
+	}
+
+	for _, job := range jobs {
+		schedule, ok := schedules[job.name]
+		if !ok || schedule == "" {
+			continue
+		}
+		if err := scheduler.Register(job.name, job.description, schedule, job.run); err != nil {
+			return fmt.Errorf("failed to register job %s: %w", job.name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// MockSchedulerService is a mock implementation of SchedulerService for testing
+type MockSchedulerService struct {
+	services.SchedulerService
+	mock.Mock
+}
+
+func (m *MockSchedulerService) Register(name, description, schedule string, run services.ScheduledJobFunc) error {
+	args := m.Called(name, description, schedule, run)
+	return args.Error(0)
+}
+
+// registered returns the names of the jobs registered with the scheduler, by schedule.
+func (m *MockSchedulerService) registered() map[string]string {
+	names := make(map[string]string)
+	for _, call := range m.Calls {
+		if call.Method == "Register" {
+			names[call.Arguments.String(0)] = call.Arguments.String(2)
+		}
+	}
+	return names
+}
+
+// MockLDAPService is a mock implementation of LDAPService for testing
+type MockLDAPService struct {
+	services.LDAPService
+	mock.Mock
+}
+
+func (m *MockLDAPService) SyncAll(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+// TestRegisterScheduledJobs tests which jobs are registered for a configuration
+func TestRegisterScheduledJobs(t *testing.T) {
+	tests := []struct {
+		name      string
+		schedules map[string]string
+		expected  map[string]string
+	}{
+		{
+			name:      "no_schedules",
+			schedules: nil,
+			expected:  map[string]string{},
+		},
+		{
+			name: "all_schedules",
+			schedules: map[string]string{
+				ScheduledEvidenceLifecycle:   "0 * * * *",
+				ScheduledRetention:           "0 3 * * *",
+				ScheduledSubscriptionRenewal: "30 0 * * *",
+				ScheduledNotificationDigest:  "*/15 * * * *",
+				ScheduledAuditCheckpoint:     "0 0 * * *",
+				ScheduledLDAPSync:            "0 */6 * * *",
+			},
+			expected: map[string]string{
+				ScheduledEvidenceLifecycle:   "0 * * * *",
+				ScheduledRetention:           "0 3 * * *",
+				ScheduledSubscriptionRenewal: "30 0 * * *",
+				ScheduledNotificationDigest:  "*/15 * * * *",
+				ScheduledAuditCheckpoint:     "0 0 * * *",
+				ScheduledLDAPSync:            "0 */6 * * *",
+			},
+		},
+		{
+			name: "skips_jobs_without_schedule",
+			schedules: map[string]string{
+				ScheduledRetention:          "0 3 * * *",
+				ScheduledAuditCheckpoint:    "",
+				ScheduledLDAPSync:           "0 */6 * * *",
+				"unknown_job":               "* * * * *",
+				ScheduledNotificationDigest: "",
+			},
+			expected: map[string]string{
+				ScheduledRetention: "0 3 * * *",
+				ScheduledLDAPSync:  "0 */6 * * *",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scheduler := &MockSchedulerService{}
+			scheduler.On("Register", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+			err := RegisterScheduledJobs(scheduler, test.schedules, ScheduledServices{})
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, scheduler.registered())
+		})
+	}
+}
+
+// TestRegisterScheduledJobs_InvalidSchedule tests that a rejected schedule fails registration
+func TestRegisterScheduledJobs_InvalidSchedule(t *testing.T) {
+	invalid := errors.New("invalid schedule")
+	scheduler := &MockSchedulerService{}
+	scheduler.On("Register", ScheduledRetention, mock.Anything, "not a cron", mock.Anything).Return(invalid)
+
+	err := RegisterScheduledJobs(scheduler, map[string]string{ScheduledRetention: "not a cron"}, ScheduledServices{})
+
+	assert.ErrorIs(t, err, invalid)
+	assert.Contains(t, err.Error(), ScheduledRetention)
+}
+
+// TestRegisterScheduledJobs_RunsService tests that a registered job runs its service
+func TestRegisterScheduledJobs_RunsService(t *testing.T) {
+	var run services.ScheduledJobFunc
+	scheduler := &MockSchedulerService{}
+	scheduler.On("Register", ScheduledLDAPSync, mock.Anything, "0 */6 * * *", mock.Anything).
+		Run(func(args mock.Arguments) { run = args.Get(3).(services.ScheduledJobFunc) }).
+		Return(nil)
+	directory := &MockLDAPService{}
+	directory.On("SyncAll", mock.Anything).Return(2, nil).Once()
+
+	err := RegisterScheduledJobs(scheduler, map[string]string{ScheduledLDAPSync: "0 */6 * * *"}, ScheduledServices{Directory: directory})
+	require.NoError(t, err)
+	require.NotNil(t, run)
+
+	require.NoError(t, run(context.Background()))
+	directory.AssertExpectations(t)
+}
@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// WebhookDispatchJob polls the queued webhook deliveries and sends due ones.
+// Deliveries are persisted before sending, so events queued before a restart
+// are delivered once the job runs again.
+type WebhookDispatchJob struct {
+	dispatcher services.WebhookDispatcher
+	interval   time.Duration
+	logger     *zap.Logger
+}
+
+// NewWebhookDispatchJob creates a new webhook dispatch job.
+//
+// Parameters:
+//   - dispatcher: Dispatcher sending webhook deliveries
+//   - interval: Time between polls
+//   - logger: Logger for job operations
+//
+// Returns:
+//   - *WebhookDispatchJob: Configured job instance
+//
+// Example:
+//
+//	job := jobs.NewWebhookDispatchJob(dispatcher, cfg.Webhook.PollInterval, logger)
+//	go job.Start(ctx)
+func NewWebhookDispatchJob(dispatcher services.WebhookDispatcher, interval time.Duration, logger *zap.Logger) *WebhookDispatchJob {
+	return &WebhookDispatchJob{
+		dispatcher: dispatcher,
+		interval:   interval,
+		logger:     logger,
+	}
+}
+
+// Start dispatches due deliveries immediately and then on every interval tick
+// until the context is cancelled.
+//
+// Parameters:
+//   - ctx: Context controlling the job lifetime
+func (j *WebhookDispatchJob) Start(ctx context.Context) {
+	runEvery(ctx, "Webhook dispatch", j.interval, j.logger, func(ctx context.Context) {
+		j.RunOnce(ctx)
+	})
+}
+
+// RunOnce dispatches one batch of due deliveries and logs the outcome.
+//
+// Parameters:
+//   - ctx: Request context
+//
+// Returns:
+//   - int: Number of deliveries attempted
+func (j *WebhookDispatchJob) RunOnce(ctx context.Context) int {
+	started := time.Now()
+
+	attempted, err := j.dispatcher.DispatchPending(ctx)
+	if err != nil {
+		j.logger.Error("Webhook dispatch run failed", zap.Error(err))
+	}
+	if attempted > 0 {
+		j.logger.Info("Webhook dispatch run completed",
+			zap.Int("deliveries", attempted),
+			zap.Duration("duration", time.Since(started)),
+		)
+	}
+	return attempted
+}
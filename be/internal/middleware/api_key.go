@@ -0,0 +1,227 @@
+// Package middleware provides HTTP middleware functions for the GoEdu Control Testing Platform.
+// This file contains authentication of machine-to-machine requests with organization API keys.
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// Authorization schemes carrying API keys. Bearer credentials are API keys
+// only when they have the API key prefix; other bearer tokens are JWTs.
+const (
+	apiKeyScheme = "ApiKey"
+	bearerScheme = "Bearer"
+)
+
+// apiKeyManagementGroup is the route group managing API keys, which API keys
+// cannot call themselves.
+const apiKeyManagementGroup = "api-keys"
+
+// readOnlyRouteGroups lists the route groups whose POST requests only read
+// data; the GraphQL API serves queries only.
+var readOnlyRouteGroups = map[string]bool{
+	"graphql": true,
+}
+
+// APIKeyAuthenticator interface for middleware dependencies
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, rawKey, ip string) (*models.APIKey, error)
+}
+
+// APIKeyMiddleware authenticates requests made with organization API keys.
+type APIKeyMiddleware struct {
+	authenticator APIKeyAuthenticator
+	orgService    OrganizationService
+	logger        *zap.Logger
+}
+
+// NewAPIKeyMiddleware creates a new API key middleware.
+//
+// Parameters:
+//   - authenticator: Service resolving presented keys, usually the API key service
+//   - orgService: Service for organization operations
+//   - logger: Logger for middleware operations
+//
+// Returns:
+//   - *APIKeyMiddleware: Configured middleware instance
+func NewAPIKeyMiddleware(authenticator APIKeyAuthenticator, orgService OrganizationService, logger *zap.Logger) *APIKeyMiddleware {
+	return &APIKeyMiddleware{
+		authenticator: authenticator,
+		orgService:    orgService,
+		logger:        logger,
+	}
+}
+
+// Authenticate handles requests carrying "Authorization: ApiKey <key>", or a
+// key sent as a bearer token as SCIM clients do, and passes all others
+// through. The key must be active and its scopes must allow the route: GET,
+// HEAD and OPTIONS requests need read access to the route's first path
+// segment, all others write access. On success it builds the same
+// organization context as EnforceOrganizationContext, with the key standing
+// in for the user, so it must run before the organization middleware.
+//
+// Usage:
+//
+//	api.Use(apiKeyMiddleware.Authenticate())
+//	api.Use(orgMiddleware.EnforceOrganizationContext())
+func (m *APIKeyMiddleware) Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rawKey, ok := apiKeyCredential(c.GetHeader("Authorization"))
+		if !ok {
+			c.Next()
+			return
+		}
+		ctx := c.Request.Context()
+
+		key, err := m.authenticator.Authenticate(ctx, rawKey, c.ClientIP())
+		switch {
+		case errors.Is(err, services.ErrInvalidAPIKey):
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid or expired API key",
+				"code":  "INVALID_API_KEY",
+			})
+			return
+		case errors.Is(err, services.ErrAPIKeysDisabled):
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "API keys are disabled for this organization",
+				"code":  "API_KEYS_DISABLED",
+			})
+			return
+		case err != nil:
+			m.logger.Error("Failed to authenticate API key", zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to authenticate API key",
+				"code":  "API_KEY_AUTH_ERROR",
+			})
+			return
+		}
+
+		orgID := key.OrganizationID
+		if header := c.GetHeader("X-Organization-ID"); header != "" && header != orgID.Hex() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "Access denied to organization",
+				"code":  "ORGANIZATION_ACCESS_DENIED",
+			})
+			return
+		}
+
+		group := firstRouteSegment(c.FullPath())
+		if group == apiKeyManagementGroup || !key.Allows(group, requiredAccess(c.Request.Method, group)) {
+			m.logger.Warn("API key scope denied",
+				zap.String("api_key_id", key.ID.Hex()),
+				zap.String("organization_id", orgID.Hex()),
+				zap.String("method", c.Request.Method),
+				zap.String("route", c.FullPath()),
+			)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "API key scopes do not allow this operation",
+				"code":  "INSUFFICIENT_SCOPE",
+			})
+			return
+		}
+
+		org, err := m.orgService.GetOrganization(ctx, orgID.Hex())
+		if err != nil {
+			m.logger.Error("Failed to load organization",
+				zap.Error(err),
+				zap.String("organization_id", orgID.Hex()),
+			)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to load organization context",
+				"code":  "ORGANIZATION_LOAD_ERROR",
+			})
+			return
+		}
+		if !org.IsActive || org.Status != models.OrganizationStatusActive {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "Organization is not active",
+				"code":  "ORGANIZATION_INACTIVE",
+			})
+			return
+		}
+
+		featureFlags, err := m.orgService.GetFeatureFlags(ctx, orgID.Hex())
+		if err != nil {
+			m.logger.Warn("Failed to load feature flags, using defaults",
+				zap.Error(err),
+				zap.String("organization_id", orgID.Hex()),
+			)
+			featureFlags = make(map[string]bool)
+		}
+
+		setOrganizationContext(c, &OrganizationContext{
+			OrganizationID:   orgID,
+			Organization:     org,
+			UserID:           key.ID,
+			UserRole:         key.Role,
+			UserPermissions:  key.Scopes,
+			FeatureFlags:     featureFlags,
+			Settings:         &org.Settings,
+			SubscriptionTier: org.Subscription.Plan,
+			IsActive:         org.IsActive,
+		})
+		SetAPIKeyID(c, key.ID.Hex())
+
+		c.Next()
+	}
+}
+
+// RequireAPIKey authenticates requests like Authenticate but rejects those
+// without an API key, for routes only machine clients call, such as the SCIM
+// API identity providers provision users through. Session tokens are not
+// accepted there, so a signed-in administrator's token cannot be replayed by
+// a provisioning client or the other way round.
+//
+// Usage:
+//
+//	scim := router.Group("", apiKeyMiddleware.RequireAPIKey())
+func (m *APIKeyMiddleware) RequireAPIKey() gin.HandlerFunc {
+	authenticate := m.Authenticate()
+	return func(c *gin.Context) {
+		if _, ok := apiKeyCredential(c.GetHeader("Authorization")); !ok {
+			c.Header("WWW-Authenticate", bearerScheme)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "An API key is required",
+				"code":  "API_KEY_REQUIRED",
+			})
+			return
+		}
+		authenticate(c)
+	}
+}
+
+// apiKeyCredential returns the API key of an Authorization header: the
+// credentials of the ApiKey scheme, or a bearer token with the API key
+// prefix.
+func apiKeyCredential(header string) (string, bool) {
+	scheme, rawKey, ok := strings.Cut(header, " ")
+	rawKey = strings.TrimSpace(rawKey)
+	if !ok {
+		return "", false
+	}
+	if strings.EqualFold(scheme, apiKeyScheme) || (strings.EqualFold(scheme, bearerScheme) && services.IsAPIKey(rawKey)) {
+		return rawKey, true
+	}
+	return "", false
+}
+
+// requiredAccess returns the scope access a request needs.
+func requiredAccess(method, group string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return models.APIKeyAccessRead
+	}
+	if readOnlyRouteGroups[group] {
+		return models.APIKeyAccessRead
+	}
+	return models.APIKeyAccessWrite
+}
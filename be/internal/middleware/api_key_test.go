@@ -0,0 +1,181 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/requestctx"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// fakeAPIKeyAuthenticator resolves raw keys from a fixed table.
+type fakeAPIKeyAuthenticator map[string]*models.APIKey
+
+func (f fakeAPIKeyAuthenticator) Authenticate(ctx context.Context, rawKey, ip string) (*models.APIKey, error) {
+	if rawKey == "goedu_disabled" {
+		return nil, services.ErrAPIKeysDisabled
+	}
+	key, ok := f[rawKey]
+	if !ok {
+		return nil, services.ErrInvalidAPIKey
+	}
+	return key, nil
+}
+
+func TestAPIKeyMiddleware_Authenticate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	org := &models.Organization{Name: "First Bank", Status: models.OrganizationStatusActive, IsActive: true}
+	org.ID = primitive.NewObjectID()
+	org.Subscription.Plan = models.SubscriptionPlanProfessional
+	key := &models.APIKey{
+		ID:             primitive.NewObjectID(),
+		OrganizationID: org.ID,
+		Role:           models.RoleAdmin,
+		Scopes:         []string{"controls:read", "comments:write", "graphql:read"},
+		ExpiresAt:      time.Now().Add(time.Hour),
+	}
+
+	orgService := &MockOrganizationService{}
+	orgService.On("GetOrganization", mock.Anything, org.ID.Hex()).Return(org, nil)
+	orgService.On("GetFeatureFlags", mock.Anything, org.ID.Hex()).Return(map[string]bool{"api_access": true}, nil)
+
+	apiKeys := NewAPIKeyMiddleware(fakeAPIKeyAuthenticator{"goedu_valid": key}, orgService, zap.NewNop())
+	orgs := NewOrganizationMiddleware(orgService, &MockUserService{}, zap.NewNop())
+
+	var seen *OrganizationContext
+	var actor string
+	handler := func(c *gin.Context) {
+		seen, _ = GetOrganizationContext(c)
+		meta, _ := requestctx.FromContext(c.Request.Context())
+		actor = meta.UserID
+		c.Status(http.StatusOK)
+	}
+	router := gin.New()
+	api := router.Group("/api/v1", RequestContext(), apiKeys.Authenticate(), orgs.EnforceOrganizationContext())
+	api.GET("/controls", handler)
+	api.POST("/controls", handler)
+	api.POST("/comments", handler)
+	api.POST("/graphql", handler)
+	api.GET("/api-keys", RequireRole(models.RoleAdmin), handler)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		authorization  string
+		orgHeader      string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"read scope allows GET", http.MethodGet, "/controls", "ApiKey goedu_valid", "", http.StatusOK, ""},
+		{"scheme is case-insensitive", http.MethodGet, "/controls", "apikey goedu_valid", org.ID.Hex(), http.StatusOK, ""},
+		{"read scope denies POST", http.MethodPost, "/controls", "ApiKey goedu_valid", "", http.StatusForbidden, "INSUFFICIENT_SCOPE"},
+		{"write scope allows POST", http.MethodPost, "/comments", "ApiKey goedu_valid", "", http.StatusOK, ""},
+		{"GraphQL queries need read scope", http.MethodPost, "/graphql", "ApiKey goedu_valid", "", http.StatusOK, ""},
+		{"keys cannot manage keys", http.MethodGet, "/api-keys", "ApiKey goedu_valid", "", http.StatusForbidden, "INSUFFICIENT_SCOPE"},
+		{"unknown key", http.MethodGet, "/controls", "ApiKey goedu_unknown", "", http.StatusUnauthorized, "INVALID_API_KEY"},
+		{"disabled organization", http.MethodGet, "/controls", "ApiKey goedu_disabled", "", http.StatusForbidden, "API_KEYS_DISABLED"},
+		{"other organization", http.MethodGet, "/controls", "ApiKey goedu_valid", primitive.NewObjectID().Hex(), http.StatusForbidden, "ORGANIZATION_ACCESS_DENIED"},
+		{"keys are accepted as bearer tokens", http.MethodGet, "/controls", "Bearer goedu_valid", "", http.StatusOK, ""},
+		{"unknown bearer key", http.MethodGet, "/controls", "Bearer goedu_unknown", "", http.StatusUnauthorized, "INVALID_API_KEY"},
+		{"bearer tokens take the JWT path", http.MethodGet, "/controls", "Bearer token", "", http.StatusBadRequest, "INVALID_ORGANIZATION_CONTEXT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen, actor = nil, ""
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, "/api/v1"+tt.path, nil)
+			req.Header.Set("Authorization", tt.authorization)
+			if tt.orgHeader != "" {
+				req.Header.Set("X-Organization-ID", tt.orgHeader)
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if tt.expectedCode != "" {
+				assert.Contains(t, w.Body.String(), `"code":"`+tt.expectedCode+`"`)
+				assert.Nil(t, seen)
+				return
+			}
+			if assert.NotNil(t, seen) {
+				assert.Equal(t, org.ID, seen.OrganizationID)
+				assert.Equal(t, key.ID, seen.UserID)
+				assert.Equal(t, models.RoleAdmin, seen.UserRole)
+				assert.Equal(t, key.Scopes, seen.UserPermissions)
+				assert.Equal(t, models.SubscriptionPlanProfessional, seen.SubscriptionTier)
+				assert.True(t, seen.FeatureFlags["api_access"])
+				assert.Equal(t, key.ID.Hex(), actor)
+			}
+		})
+	}
+}
+
+func TestAPIKeyMiddleware_RequireAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	org := &models.Organization{Name: "First Bank", Status: models.OrganizationStatusActive, IsActive: true}
+	org.ID = primitive.NewObjectID()
+	scimKey := &models.APIKey{ID: primitive.NewObjectID(), OrganizationID: org.ID, Role: models.RoleAdmin, Scopes: []string{"scim:write"}}
+	controlsKey := &models.APIKey{ID: primitive.NewObjectID(), OrganizationID: org.ID, Role: models.RoleAdmin, Scopes: []string{"controls:write"}}
+
+	orgService := &MockOrganizationService{}
+	orgService.On("GetOrganization", mock.Anything, org.ID.Hex()).Return(org, nil)
+	orgService.On("GetFeatureFlags", mock.Anything, org.ID.Hex()).Return(map[string]bool{}, nil)
+	apiKeys := NewAPIKeyMiddleware(fakeAPIKeyAuthenticator{"goedu_scim": scimKey, "goedu_controls": controlsKey}, orgService, zap.NewNop())
+
+	var seen *OrganizationContext
+	router := gin.New()
+	router.Group("/scim/v2", RequestContext(), apiKeys.RequireAPIKey()).POST("/Users", func(c *gin.Context) {
+		seen, _ = GetOrganizationContext(c)
+		c.Status(http.StatusCreated)
+	})
+
+	tests := []struct {
+		name           string
+		authorization  string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"SCIM key as bearer token", "Bearer goedu_scim", http.StatusCreated, ""},
+		{"SCIM key with the ApiKey scheme", "ApiKey goedu_scim", http.StatusCreated, ""},
+		{"missing credentials", "", http.StatusUnauthorized, "API_KEY_REQUIRED"},
+		{"session token", "Bearer eyJhbGciOiJIUzI1NiJ9.e30.c2lnbmF0dXJl", http.StatusUnauthorized, "API_KEY_REQUIRED"},
+		{"unknown key", "Bearer goedu_unknown", http.StatusUnauthorized, "INVALID_API_KEY"},
+		{"key without the scim scope", "Bearer goedu_controls", http.StatusForbidden, "INSUFFICIENT_SCOPE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen = nil
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/scim/v2/Users", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if tt.expectedCode != "" {
+				assert.Contains(t, w.Body.String(), `"code":"`+tt.expectedCode+`"`)
+				assert.Nil(t, seen)
+				return
+			}
+			if assert.NotNil(t, seen) {
+				assert.Equal(t, org.ID, seen.OrganizationID)
+				assert.Equal(t, scimKey.ID, seen.UserID)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+// Package middleware provides HTTP middleware functions for the GoEdu Control Testing Platform.
+// This file contains the audit middleware that records every mutating API call.
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// maxAuditBodyBytes bounds how much of a request body is captured for auditing.
+const maxAuditBodyBytes = 64 * 1024
+
+// Gin context keys handlers use to refine the audit entry of the current request
+const (
+	auditOldValuesKey  = "audit_old_values"
+	auditNewValuesKey  = "audit_new_values"
+	auditResourceIDKey = "audit_resource_id"
+)
+
+// AuditLogger interface for middleware dependencies
+type AuditLogger interface {
+	LogAction(ctx context.Context, input *services.AuditInput) error
+}
+
+// AuditMiddleware writes an audit entry for every mutating API call.
+type AuditMiddleware struct {
+	auditLogger AuditLogger
+	logger      *zap.Logger
+}
+
+// NewAuditMiddleware creates a new audit middleware.
+//
+// Parameters:
+//   - auditLogger: Service used to persist audit entries
+//   - logger: Logger for middleware operations
+//
+// Returns:
+//   - *AuditMiddleware: Configured middleware instance
+func NewAuditMiddleware(auditLogger AuditLogger, logger *zap.Logger) *AuditMiddleware {
+	return &AuditMiddleware{
+		auditLogger: auditLogger,
+		logger:      logger,
+	}
+}
+
+// RecordMutations records POST, PUT, PATCH and DELETE requests after they have
+// been handled. By default the redacted JSON request body is stored as the new
+// values; handlers that know the previous state call SetAuditChange so only the
+// changed fields are stored. Correlation ID, actor, IP and user agent come from
+// the request metadata, so RequestContext must run earlier in the chain.
+//
+// Usage:
+//
+//	api.Use(orgMiddleware.EnforceOrganizationContext())
+//	api.Use(auditMiddleware.RecordMutations())
+func (m *AuditMiddleware) RecordMutations() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		action, mutating := auditActions[c.Request.Method]
+		if !mutating {
+			c.Next()
+			return
+		}
+
+		body := captureJSONBody(c)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		input := &services.AuditInput{
+			Action:       action,
+			ResourceType: resourceTypeFromRoute(c.FullPath()),
+			ResourceID:   c.GetString(auditResourceIDKey),
+			NewValues:    services.RedactValues(body),
+			Metadata: map[string]interface{}{
+				"method": c.Request.Method,
+				"route":  c.FullPath(),
+				"path":   c.Request.URL.Path,
+				"status": status,
+			},
+			Success: status < http.StatusBadRequest,
+		}
+		if input.ResourceID == "" {
+			input.ResourceID = c.Param("id")
+		}
+		if oldValues, ok := c.Get(auditOldValuesKey); ok {
+			newValues, _ := c.Get(auditNewValuesKey)
+			input.OldValues, input.NewValues = services.DiffValues(
+				oldValues.(map[string]interface{}),
+				newValues.(map[string]interface{}),
+			)
+		}
+		if !input.Success {
+			input.ErrorMessage = http.StatusText(status)
+			if len(c.Errors) > 0 {
+				input.ErrorMessage = c.Errors.String()
+			}
+		}
+
+		if err := m.auditLogger.LogAction(c.Request.Context(), input); err != nil {
+			m.logger.Warn("Failed to record audit entry for request",
+				zap.Error(err),
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path),
+			)
+		}
+	}
+}
+
+// SetAuditChange provides the state of the affected resource before and after
+// the request. The audit entry then stores only the fields that changed.
+//
+// Parameters:
+//   - c: Gin context of the current request
+//   - oldValues: Resource snapshot before the change
+//   - newValues: Resource snapshot after the change
+func SetAuditChange(c *gin.Context, oldValues, newValues map[string]interface{}) {
+	if oldValues == nil {
+		oldValues = map[string]interface{}{}
+	}
+	if newValues == nil {
+		newValues = map[string]interface{}{}
+	}
+	c.Set(auditOldValuesKey, oldValues)
+	c.Set(auditNewValuesKey, newValues)
+}
+
+// AuditSnapshot converts a resource to the field map SetAuditChange expects,
+// using the resource's JSON representation so fields hidden from API
+// responses stay out of the audit trail.
+//
+// Parameters:
+//   - resource: Resource to snapshot, usually the API response type
+//
+// Returns:
+//   - map[string]interface{}: Fields of the resource, nil if it cannot be encoded
+func AuditSnapshot(resource interface{}) map[string]interface{} {
+	encoded, err := json.Marshal(resource)
+	if err != nil {
+		return nil
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(encoded, &values); err != nil {
+		return nil
+	}
+	return values
+}
+
+// SetAuditResourceID sets the ID of the affected resource when it is not the
+// ":id" route parameter, e.g. for resources created by a POST request.
+func SetAuditResourceID(c *gin.Context, resourceID string) {
+	c.Set(auditResourceIDKey, resourceID)
+}
+
+// auditActions maps mutating HTTP methods to audit actions.
+var auditActions = map[string]string{
+	http.MethodPost:   "api.create",
+	http.MethodPut:    "api.update",
+	http.MethodPatch:  "api.update",
+	http.MethodDelete: "api.delete",
+}
+
+// captureJSONBody reads up to maxAuditBodyBytes of a JSON request body and
+// restores the body for the handler. Non-JSON or non-object bodies yield nil.
+func captureJSONBody(c *gin.Context) map[string]interface{} {
+	if c.Request.Body == nil || !strings.Contains(c.ContentType(), "json") {
+		return nil
+	}
+
+	prefix, err := io.ReadAll(io.LimitReader(c.Request.Body, maxAuditBodyBytes+1))
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(prefix), c.Request.Body))
+	if err != nil || len(prefix) > maxAuditBodyBytes {
+		return nil
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal(prefix, &values); err != nil {
+		return nil
+	}
+	return values
+}
+
+// resourceTypeFromRoute derives the resource type from the matched route,
+// e.g. "/api/v1/evidence-requests/:id/approve" becomes "evidence_request".
+func resourceTypeFromRoute(route string) string {
+	segment := firstRouteSegment(route)
+	if segment == "" {
+		return "unknown"
+	}
+	return strings.TrimSuffix(strings.ReplaceAll(segment, "-", "_"), "s")
+}
+
+// firstRouteSegment returns the first static path segment of a route below
+// the API version, e.g. "evidence-requests" for
+// "/api/v1/evidence-requests/:id/approve", or "" if there is none.
+func firstRouteSegment(route string) string {
+	for _, segment := range strings.Split(route, "/") {
+		if segment == "" || segment == "api" || (len(segment) > 1 && segment[0] == 'v' && segment[1] >= '0' && segment[1] <= '9') {
+			continue
+		}
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			break
+		}
+		return segment
+	}
+	return ""
+}
@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/requestctx"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// recordingAuditLogger captures audit inputs together with the request metadata
+type recordingAuditLogger struct {
+	inputs   []*services.AuditInput
+	metadata []requestctx.Metadata
+}
+
+func (r *recordingAuditLogger) LogAction(ctx context.Context, input *services.AuditInput) error {
+	md, _ := requestctx.FromContext(ctx)
+	r.inputs = append(r.inputs, input)
+	r.metadata = append(r.metadata, *md)
+	return nil
+}
+
+func newAuditTestRouter(recorder *recordingAuditLogger, register func(r *gin.RouterGroup)) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("correlation_id", "corr-42")
+		c.Next()
+	})
+	router.Use(RequestContext())
+	api := router.Group("/api/v1", func(c *gin.Context) {
+		// Stand-in for the organization middleware
+		requestctx.SetActor(c.Request.Context(), "user-1", "org-1")
+		c.Next()
+	})
+	api.Use(NewAuditMiddleware(recorder, zap.NewNop()).RecordMutations())
+	register(api)
+	return router
+}
+
+func TestAuditMiddleware_RecordsMutatingRequests(t *testing.T) {
+	recorder := &recordingAuditLogger{}
+	var handlerBody string
+	router := newAuditTestRouter(recorder, func(api *gin.RouterGroup) {
+		api.POST("/evidence-requests/:id/approve", func(c *gin.Context) {
+			body, _ := io.ReadAll(c.Request.Body)
+			handlerBody = string(body)
+			c.Status(http.StatusOK)
+		})
+		api.GET("/evidence-requests/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	})
+
+	body := `{"comment":"ok","token":"secret"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/evidence-requests/abc/approve", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "audit-test")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/evidence-requests/abc", nil))
+
+	assert.Equal(t, body, handlerBody, "handler must still see the full body")
+	require.Len(t, recorder.inputs, 1, "GET requests are not audited")
+
+	input := recorder.inputs[0]
+	assert.Equal(t, "api.create", input.Action)
+	assert.Equal(t, "evidence_request", input.ResourceType)
+	assert.Equal(t, "abc", input.ResourceID)
+	assert.Equal(t, "ok", input.NewValues["comment"])
+	assert.True(t, input.Success)
+
+	md := recorder.metadata[0]
+	assert.Equal(t, "corr-42", md.CorrelationID)
+	assert.Equal(t, "user-1", md.UserID)
+	assert.Equal(t, "org-1", md.OrganizationID)
+	assert.Equal(t, "audit-test", md.UserAgent)
+}
+
+func TestAuditMiddleware_RedactsSecurityAnswers(t *testing.T) {
+	recorder := &recordingAuditLogger{}
+	router := newAuditTestRouter(recorder, func(api *gin.RouterGroup) {
+		api.PUT("/account/security-questions", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+		api.POST("/auth/password/reset/verify", func(c *gin.Context) { c.Status(http.StatusOK) })
+	})
+
+	requests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodPut, "/api/v1/account/security-questions", `{"questions":[{"question":"First pet?","answer":"Rex"},{"question":"Birth city?","answer":"Brno"}]}`},
+		{http.MethodPost, "/api/v1/auth/password/reset/verify", `{"token":"reset-token","answer":"Rex"}`},
+	}
+	for _, r := range requests {
+		req := httptest.NewRequest(r.method, r.path, strings.NewReader(r.body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	require.Len(t, recorder.inputs, 2)
+	for _, input := range recorder.inputs {
+		assert.NotContains(t, fmt.Sprint(input.NewValues), "Rex")
+		assert.NotContains(t, fmt.Sprint(input.NewValues), "Brno")
+		assert.NotContains(t, fmt.Sprint(input.NewValues), "reset-token")
+	}
+	questions, ok := recorder.inputs[0].NewValues["questions"].([]interface{})
+	require.True(t, ok)
+	require.Len(t, questions, 2)
+	assert.Equal(t, map[string]interface{}{"question": "First pet?", "answer": "[REDACTED]"}, questions[0])
+}
+
+func TestAuditMiddleware_UsesHandlerProvidedDiff(t *testing.T) {
+	recorder := &recordingAuditLogger{}
+	router := newAuditTestRouter(recorder, func(api *gin.RouterGroup) {
+		api.PUT("/comments/:id", func(c *gin.Context) {
+			SetAuditChange(c,
+				map[string]interface{}{"content": "old", "status": "active"},
+				map[string]interface{}{"content": "new", "status": "active"},
+			)
+			c.Status(http.StatusOK)
+		})
+		api.DELETE("/comments/:id", func(c *gin.Context) {
+			c.AbortWithStatus(http.StatusForbidden)
+		})
+	})
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/comments/c-1", strings.NewReader(`{"content":"new"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/api/v1/comments/c-1", nil))
+
+	require.Len(t, recorder.inputs, 2)
+	assert.Equal(t, map[string]interface{}{"content": "old"}, recorder.inputs[0].OldValues)
+	assert.Equal(t, map[string]interface{}{"content": "new"}, recorder.inputs[0].NewValues)
+
+	assert.Equal(t, "api.delete", recorder.inputs[1].Action)
+	assert.False(t, recorder.inputs[1].Success)
+	assert.Equal(t, "Forbidden", recorder.inputs[1].ErrorMessage)
+}
+
+func TestResourceTypeFromRoute(t *testing.T) {
+	assert.Equal(t, "evidence_request", resourceTypeFromRoute("/api/v1/evidence-requests/:id/approve"))
+	assert.Equal(t, "comment", resourceTypeFromRoute("/api/v1/comments"))
+	assert.Equal(t, "unknown", resourceTypeFromRoute("/api/v1/:id"))
+}
@@ -0,0 +1,189 @@
+// Package middleware provides HTTP middleware functions for the GoEdu Control Testing Platform.
+// This file contains the middleware that validates API traffic against the OpenAPI document.
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/openapi"
+)
+
+// maxValidatedBodyBytes bounds the request bodies read for validation.
+const maxValidatedBodyBytes = 1 << 20
+
+// OpenAPIValidator checks requests, and optionally responses, against the
+// operations of an OpenAPI document.
+type OpenAPIValidator struct {
+	doc    *openapi.Document
+	config config.OpenAPIConfig
+	logger *zap.Logger
+}
+
+// NewOpenAPIValidator creates a new OpenAPI validation middleware.
+//
+// Parameters:
+//   - doc: The API description; its base path must match the router group
+//   - cfg: Which directions are validated
+//   - logger: Logger for middleware operations
+//
+// Returns:
+//   - *OpenAPIValidator: Configured middleware instance
+func NewOpenAPIValidator(doc *openapi.Document, cfg config.OpenAPIConfig, logger *zap.Logger) *OpenAPIValidator {
+	return &OpenAPIValidator{
+		doc:    doc,
+		config: cfg,
+		logger: logger,
+	}
+}
+
+// Validate looks up the operation of the matched route. Requests whose
+// parameters or JSON body do not conform are rejected with 400 and code
+// REQUEST_VALIDATION_FAILED. When response validation is enabled, JSON
+// responses are buffered and a non-conforming one is logged and replaced by a
+// 500 with code RESPONSE_VALIDATION_FAILED; streams and downloads pass
+// through. Routes the document does not describe are not checked.
+//
+// Usage:
+//
+//	v1 := router.Group("/api/v1")
+//	v1.Use(openAPIValidator.Validate())
+func (m *OpenAPIValidator) Validate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		op := m.doc.Route(c.Request.Method, openapi.RoutePath(c.FullPath()))
+		if op == nil {
+			c.Next()
+			return
+		}
+
+		if m.config.ValidateRequests && !m.validateRequest(c, op) {
+			return
+		}
+		if !m.config.ValidateResponses || !op.JSONResponses() {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		buffered := &bufferedWriter{ResponseWriter: original, status: http.StatusOK}
+		c.Writer = buffered
+		c.Next()
+		c.Writer = original
+
+		if err := op.ValidateResponse(buffered.status, original.Header(), buffered.body.Bytes()); err != nil {
+			m.logger.Error("Response does not match the OpenAPI document",
+				zap.Error(err),
+				zap.String("method", c.Request.Method),
+				zap.String("route", c.FullPath()),
+				zap.Int("status", buffered.status),
+			)
+			original.Header().Del("Content-Length")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Internal server error",
+				"code":  "RESPONSE_VALIDATION_FAILED",
+			})
+			return
+		}
+		original.WriteHeader(buffered.status)
+		if buffered.body.Len() > 0 {
+			_, _ = original.Write(buffered.body.Bytes())
+		} else {
+			original.WriteHeaderNow()
+		}
+	}
+}
+
+// validateRequest checks the request and aborts it when it does not conform.
+// The body is restored for the handler.
+func (m *OpenAPIValidator) validateRequest(c *gin.Context, op *openapi.Operation) bool {
+	var body []byte
+	if op.RequestBody != nil && c.Request.Body != nil {
+		var err error
+		body, err = io.ReadAll(io.LimitReader(c.Request.Body, maxValidatedBodyBytes+1))
+		c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error": "Failed to read request body",
+				"code":  "INVALID_REQUEST_BODY",
+			})
+			return false
+		}
+		if len(body) > maxValidatedBodyBytes {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": "Request body is too large",
+				"code":  "REQUEST_BODY_TOO_LARGE",
+			})
+			return false
+		}
+	}
+
+	pathParams := make(map[string]string, len(c.Params))
+	for _, param := range c.Params {
+		pathParams[param.Key] = param.Value
+	}
+	if err := op.ValidateRequest(c.Request, pathParams, body); err != nil {
+		var validationErr *openapi.ValidationError
+		if !errors.As(err, &validationErr) {
+			m.logger.Warn("OpenAPI request validation failed unexpectedly", zap.Error(err))
+		}
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error": "Request validation failed: " + err.Error(),
+			"code":  "REQUEST_VALIDATION_FAILED",
+		})
+		return false
+	}
+	return true
+}
+
+// bufferedWriter holds back the response of a handler so it can be validated
+// before anything is sent. Headers go straight to the wrapped writer.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status  int
+	written bool
+	body    bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.written
+}
+
+// Flush is a no-op; the response is sent once it has been validated.
+func (w *bufferedWriter) Flush() {}
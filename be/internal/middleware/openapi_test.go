@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/openapi"
+)
+
+const widgetSpec = `{
+  "openapi": "3.1.0",
+  "servers": [{"url": "/api/v1"}],
+  "paths": {
+    "/widgets/{id}": {
+      "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string", "pattern": "^[0-9]+$"}}],
+      "put": {
+        "parameters": [{"name": "notify", "in": "query", "schema": {"type": "boolean"}}],
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/WidgetInput"}}}},
+        "responses": {
+          "200": {"description": "OK", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Widget"}}}},
+          "204": {"description": "Unchanged"},
+          "default": {"description": "Error", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+        }
+      }
+    },
+    "/widgets/{id}/export": {
+      "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}],
+      "get": {"responses": {"200": {"description": "File", "content": {"text/csv": {"schema": {"type": "string"}}}}}}
+    }
+  },
+  "components": {
+    "schemas": {
+      "WidgetInput": {"type": "object", "properties": {"name": {"type": "string", "minLength": 1}}, "required": ["name"], "additionalProperties": false},
+      "Widget": {"type": "object", "properties": {"id": {"type": "string"}, "name": {"type": "string"}}, "required": ["id", "name"]},
+      "Error": {"type": "object", "properties": {"error": {"type": "string"}, "code": {"type": "string"}}, "required": ["error", "code"]}
+    }
+  }
+}`
+
+func newOpenAPITestRouter(t *testing.T, cfg config.OpenAPIConfig, register func(r *gin.RouterGroup)) *gin.Engine {
+	doc, err := openapi.Load([]byte(widgetSpec))
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api := router.Group("/api/v1")
+	api.Use(NewOpenAPIValidator(doc, cfg, zap.NewNop()).Validate())
+	register(api)
+	return router
+}
+
+func TestOpenAPIValidator_Requests(t *testing.T) {
+	var handlerBody string
+	router := newOpenAPITestRouter(t, config.OpenAPIConfig{ValidateRequests: true}, func(api *gin.RouterGroup) {
+		api.PUT("/widgets/:id", func(c *gin.Context) {
+			body, _ := io.ReadAll(c.Request.Body)
+			handlerBody = string(body)
+			c.Status(http.StatusNoContent)
+		})
+		api.POST("/undocumented", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	})
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedError  string
+	}{
+		{"valid request", http.MethodPut, "/widgets/42?notify=true", `{"name":"gear"}`, http.StatusNoContent, ""},
+		{"invalid path parameter", http.MethodPut, "/widgets/abc", `{"name":"gear"}`, http.StatusBadRequest,
+			`Request validation failed: path parameter \"id\" must match the pattern ^[0-9]+$`},
+		{"invalid query parameter", http.MethodPut, "/widgets/42?notify=yes", `{"name":"gear"}`, http.StatusBadRequest,
+			`query parameter \"notify\" must be true or false`},
+		{"missing body", http.MethodPut, "/widgets/42", ``, http.StatusBadRequest, "body is required"},
+		{"malformed body", http.MethodPut, "/widgets/42", `{"name":`, http.StatusBadRequest, "body is not valid JSON"},
+		{"missing field", http.MethodPut, "/widgets/42", `{}`, http.StatusBadRequest, "body field /name is required"},
+		{"unknown field", http.MethodPut, "/widgets/42", `{"name":"gear","color":"red"}`, http.StatusBadRequest,
+			"body field /color is not a known field"},
+		{"undocumented route passes through", http.MethodPost, "/undocumented", `not json`, http.StatusCreated, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handlerBody = ""
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, "/api/v1"+tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if tt.expectedError != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedError)
+				assert.Contains(t, w.Body.String(), `"code":"REQUEST_VALIDATION_FAILED"`)
+				assert.Empty(t, handlerBody, "handler must not run")
+			} else if tt.path == "/widgets/42?notify=true" {
+				assert.Equal(t, tt.body, handlerBody, "body must be restored for the handler")
+			}
+		})
+	}
+}
+
+func TestOpenAPIValidator_Responses(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		handler        gin.HandlerFunc
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"conforming response is sent", "/widgets/1", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"id": "1", "name": "gear"})
+		}, http.StatusOK, `{"id":"1","name":"gear"}`},
+		{"empty response is sent", "/widgets/1", func(c *gin.Context) {
+			c.Status(http.StatusNoContent)
+		}, http.StatusNoContent, ""},
+		{"error envelope matches default", "/widgets/1", func(c *gin.Context) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "widget not found", "code": "WIDGET_NOT_FOUND"})
+		}, http.StatusNotFound, "WIDGET_NOT_FOUND"},
+		{"missing field is replaced", "/widgets/1", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"id": "1"})
+		}, http.StatusInternalServerError, `"code":"RESPONSE_VALIDATION_FAILED"`},
+		{"undocumented content type is replaced", "/widgets/1", func(c *gin.Context) {
+			c.String(http.StatusOK, "gear")
+		}, http.StatusInternalServerError, `"code":"RESPONSE_VALIDATION_FAILED"`},
+		{"downloads are not buffered", "/widgets/1/export", func(c *gin.Context) {
+			c.Data(http.StatusOK, "text/csv", []byte("id\n1\n"))
+		}, http.StatusOK, "id\n1\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newOpenAPITestRouter(t, config.OpenAPIConfig{ValidateRequests: true, ValidateResponses: true}, func(api *gin.RouterGroup) {
+				api.PUT("/widgets/:id", tt.handler)
+				api.GET("/widgets/:id/export", tt.handler)
+			})
+
+			method := http.MethodPut
+			if strings.HasSuffix(tt.path, "/export") {
+				method = http.MethodGet
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(method, "/api/v1"+tt.path, strings.NewReader(`{"name":"gear"}`)))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody == "" {
+				assert.Empty(t, w.Body.String())
+			} else {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/requestctx"
 )
 
 // OrganizationContextKey is the key used to store organization context in the request context
@@ -94,12 +95,20 @@ func NewOrganizationMiddleware(
 // 4. Injects organization context into the request context
 // 5. Enforces data isolation boundaries
 //
+// Requests authenticated with an API key already carry their organization
+// context and are passed through.
+//
 // Usage:
 //   router.Use(orgMiddleware.EnforceOrganizationContext())
 func (m *OrganizationMiddleware) EnforceOrganizationContext() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx := c.Request.Context()
 		
+		if _, err := GetOrganizationContext(c); err == nil {
+			c.Next()
+			return
+		}
+		
 		// Extract organization ID from various sources
 		orgID, err := m.extractOrganizationID(c)
 		if err != nil {
@@ -208,16 +217,7 @@ func (m *OrganizationMiddleware) EnforceOrganizationContext() gin.HandlerFunc {
 			IsActive:         org.IsActive,
 		}
 
-		// Inject organization context into request context
-		ctx = context.WithValue(ctx, OrganizationIDKey, orgID)
-		ctx = context.WithValue(ctx, OrganizationKey, org)
-		ctx = context.WithValue(ctx, UserOrganizationKey, orgContext)
-		c.Request = c.Request.WithContext(ctx)
-
-		// Set organization context in Gin context for easy access
-		c.Set("organization_context", orgContext)
-		c.Set("organization_id", orgID.Hex())
-		c.Set("organization", org)
+		setOrganizationContext(c, orgContext)
 
 		// Log successful organization context establishment
 		m.logger.Debug("Organization context established",
@@ -232,6 +232,28 @@ func (m *OrganizationMiddleware) EnforceOrganizationContext() gin.HandlerFunc {
 	}
 }
 
+// setOrganizationContext attributes the request to the authenticated
+// principal for audit logging and makes the organization context available
+// through both the request context and the Gin context.
+func setOrganizationContext(c *gin.Context, orgContext *OrganizationContext) {
+	ctx := c.Request.Context()
+	orgID := orgContext.OrganizationID
+	
+	// Attribute the request to the authenticated principal for audit logging
+	requestctx.SetActor(ctx, orgContext.UserID.Hex(), orgID.Hex())
+
+	// Inject organization context into request context
+	ctx = context.WithValue(ctx, OrganizationIDKey, orgID)
+	ctx = context.WithValue(ctx, OrganizationKey, orgContext.Organization)
+	ctx = context.WithValue(ctx, UserOrganizationKey, orgContext)
+	c.Request = c.Request.WithContext(ctx)
+
+	// Set organization context in Gin context for easy access
+	c.Set("organization_context", orgContext)
+	c.Set("organization_id", orgID.Hex())
+	c.Set("organization", orgContext.Organization)
+}
+
 // RequireFeature creates middleware that checks if a specific feature is enabled
 // for the organization. This enforces feature flag-based access control.
 //
@@ -282,6 +304,44 @@ func (m *OrganizationMiddleware) RequireFeature(feature string) gin.HandlerFunc
 	}
 }
 
+// RequireRole creates middleware that restricts a route to users holding at least
+// one of the given roles in the current organization.
+//
+// Parameters:
+//   - roles: Roles allowed to access the route
+//
+// Returns:
+//   - gin.HandlerFunc: Middleware function that validates the user's roles
+//
+// Usage:
+//   auditRoutes.Use(middleware.RequireRole(models.RoleAdmin, models.RoleAuditor))
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgContext, err := GetOrganizationContext(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error": "Organization context not found",
+				"code":  "MISSING_ORGANIZATION_CONTEXT",
+			})
+			return
+		}
+
+		for _, userRole := range strings.Split(orgContext.UserRole, ",") {
+			for _, role := range roles {
+				if strings.TrimSpace(userRole) == role {
+					c.Next()
+					return
+				}
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "Insufficient role for this operation",
+			"code":  "INSUFFICIENT_ROLE",
+		})
+	}
+}
+
 // extractOrganizationID extracts the organization ID from various request sources.
 // It checks in the following order:
 // 1. X-Organization-ID header
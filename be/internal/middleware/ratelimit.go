@@ -0,0 +1,215 @@
+// Package middleware provides HTTP middleware functions for the GoEdu Control Testing Platform.
+// This file contains the per-organization, per-user and per-API-key rate limiter.
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/cache"
+)
+
+// rateLimitKeyPrefix prefixes the Redis keys of rate limit buckets.
+const rateLimitKeyPrefix = "goedu:ratelimit:"
+
+// defaultRateLimitGroup is the bucket group of routes without a group
+// override.
+const defaultRateLimitGroup = "api"
+
+// apiKeyIDKey is the gin context key holding the ID of the API key that
+// authenticated the request.
+const apiKeyIDKey = "api_key_id"
+
+// RateLimitMiddleware enforces the API rate limits of the subscription plans.
+type RateLimitMiddleware struct {
+	limits cache.RateLimits
+	local  *cache.LocalRateLimits
+	config config.RateLimitConfig
+	logger *zap.Logger
+
+	mu            sync.Mutex
+	fallbackUntil time.Time
+	now           func() time.Time
+}
+
+// NewRateLimitMiddleware creates a new rate limit middleware.
+//
+// Parameters:
+//   - limits: Shared rate limit store, usually the Redis cache client
+//   - cfg: Limits per plan and route group
+//   - logger: Logger for middleware operations
+//
+// Returns:
+//   - *RateLimitMiddleware: Configured middleware instance
+func NewRateLimitMiddleware(limits cache.RateLimits, cfg config.RateLimitConfig, logger *zap.Logger) *RateLimitMiddleware {
+	return &RateLimitMiddleware{
+		limits: limits,
+		local:  cache.NewLocalRateLimits(),
+		config: cfg,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// SetAPIKeyID records the API key that authenticated the request, so rate
+// limits apply per key instead of per user.
+//
+// Parameters:
+//   - c: Gin context of the current request
+//   - apiKeyID: ID of the authenticating API key
+func SetAPIKeyID(c *gin.Context, apiKeyID string) {
+	c.Set(apiKeyIDKey, apiKeyID)
+}
+
+// bucket is a rate limit that applies to a request.
+type bucket struct {
+	key   string
+	limit int
+}
+
+// Limit rejects requests over the limits of the caller's plan with 429 and
+// code RATE_LIMIT_EXCEEDED. Each request counts against its organization and
+// against its API key, or its user when it was not made with an API key.
+// Requests without organization context count against their client IP with
+// the starter user limit. Every response carries RateLimit-Limit,
+// RateLimit-Remaining and RateLimit-Reset for the most restrictive bucket;
+// rejected ones also carry Retry-After. It must run after the organization
+// middleware.
+//
+// Usage:
+//
+//	api.Use(orgMiddleware.EnforceOrganizationContext())
+//	api.Use(rateLimitMiddleware.Limit())
+func (m *RateLimitMiddleware) Limit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !m.config.Enabled {
+			c.Next()
+			return
+		}
+
+		var tightest *cache.RateLimitResult
+		for _, b := range m.buckets(c) {
+			result := m.allow(c.Request.Context(), b)
+			if tightest == nil || !result.Allowed || result.Remaining < tightest.Remaining {
+				tightest = result
+			}
+			if !result.Allowed {
+				break
+			}
+		}
+		if tightest == nil {
+			c.Next()
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(tightest.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(tightest.Remaining))
+		header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(tightest.ResetAfter)))
+		if !tightest.Allowed {
+			header.Set("Retry-After", strconv.Itoa(ceilSeconds(tightest.RetryAfter)))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error": "Rate limit exceeded",
+				"code":  "RATE_LIMIT_EXCEEDED",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// buckets returns the rate limits that apply to a request, the most specific
+// first.
+func (m *RateLimitMiddleware) buckets(c *gin.Context) []bucket {
+	group := firstRouteSegment(c.FullPath())
+	if _, ok := m.config.Groups[group]; !ok {
+		group = defaultRateLimitGroup
+	}
+	prefix := rateLimitKeyPrefix + group + ":"
+
+	orgContext, err := GetOrganizationContext(c)
+	if err != nil {
+		limits := m.planLimits(group, models.SubscriptionPlanStarter)
+		return nonZero(bucket{key: prefix + "ip:" + c.ClientIP(), limit: limits.User})
+	}
+
+	limits := m.planLimits(group, orgContext.SubscriptionTier)
+	principal := bucket{key: prefix + "user:" + orgContext.UserID.Hex(), limit: limits.User}
+	if apiKeyID := c.GetString(apiKeyIDKey); apiKeyID != "" {
+		principal = bucket{key: prefix + "key:" + apiKeyID, limit: limits.APIKey}
+	}
+	return nonZero(principal, bucket{key: prefix + "org:" + orgContext.OrganizationID.Hex(), limit: limits.Organization})
+}
+
+// planLimits returns the limits of a plan for a route group. Group overrides
+// replace the plan limits field by field.
+func (m *RateLimitMiddleware) planLimits(group, plan string) config.RateLimitPlan {
+	limits, ok := m.config.Plans[plan]
+	if !ok {
+		plan = models.SubscriptionPlanStarter
+		limits = m.config.Plans[plan]
+	}
+	if override, ok := m.config.Groups[group][plan]; ok {
+		if override.Organization > 0 {
+			limits.Organization = override.Organization
+		}
+		if override.User > 0 {
+			limits.User = override.User
+		}
+		if override.APIKey > 0 {
+			limits.APIKey = override.APIKey
+		}
+	}
+	return limits
+}
+
+// allow checks a bucket in the shared store, or in the local one while the
+// shared store is failing. After a failure the shared store is retried once
+// FallbackRetry has passed.
+func (m *RateLimitMiddleware) allow(ctx context.Context, b bucket) *cache.RateLimitResult {
+	m.mu.Lock()
+	useLocal := m.now().Before(m.fallbackUntil)
+	m.mu.Unlock()
+
+	if !useLocal {
+		result, err := m.limits.AllowRate(ctx, b.key, b.limit, m.config.Window)
+		if err == nil {
+			return result
+		}
+		m.mu.Lock()
+		m.fallbackUntil = m.now().Add(m.config.FallbackRetry)
+		m.mu.Unlock()
+		m.logger.Warn("Rate limit store unavailable, using local limits",
+			zap.Error(err),
+			zap.Duration("retry_after", m.config.FallbackRetry),
+		)
+	}
+	// The local store never fails
+	result, _ := m.local.AllowRate(ctx, b.key, b.limit, m.config.Window)
+	return result
+}
+
+// nonZero drops unlimited buckets.
+func nonZero(buckets ...bucket) []bucket {
+	limited := buckets[:0]
+	for _, b := range buckets {
+		if b.limit > 0 {
+			limited = append(limited, b)
+		}
+	}
+	return limited
+}
+
+// ceilSeconds rounds a duration up to whole seconds.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/cache"
+)
+
+// flakyRateLimits fails while down is set and otherwise delegates to an
+// in-memory store standing in for Redis.
+type flakyRateLimits struct {
+	store *cache.LocalRateLimits
+	down  bool
+	calls int
+}
+
+func (f *flakyRateLimits) AllowRate(ctx context.Context, key string, limit int, window time.Duration) (*cache.RateLimitResult, error) {
+	f.calls++
+	if f.down {
+		return nil, errors.New("dial tcp: connection refused")
+	}
+	return f.store.AllowRate(ctx, key, limit, window)
+}
+
+func testRateLimitConfig() config.RateLimitConfig {
+	return config.RateLimitConfig{
+		Enabled:       true,
+		Window:        time.Minute,
+		FallbackRetry: 30 * time.Second,
+		Plans: map[string]config.RateLimitPlan{
+			models.SubscriptionPlanStarter:    {Organization: 3, User: 2, APIKey: 1},
+			models.SubscriptionPlanEnterprise: {Organization: 100, User: 50, APIKey: 50},
+		},
+		Groups: map[string]map[string]config.RateLimitPlan{
+			"audit": {models.SubscriptionPlanStarter: {User: 1}},
+		},
+	}
+}
+
+// rateLimitRequest sends a request as the given caller; a nil orgContext
+// sends it unauthenticated.
+func rateLimitRequest(m *RateLimitMiddleware, orgContext *OrganizationContext, apiKeyID, path string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api := router.Group("/api/v1", func(c *gin.Context) {
+		if orgContext != nil {
+			c.Set("organization_context", orgContext)
+		}
+		if apiKeyID != "" {
+			SetAPIKeyID(c, apiKeyID)
+		}
+		c.Next()
+	})
+	api.Use(m.Limit())
+	api.GET("/comments", func(c *gin.Context) { c.Status(http.StatusOK) })
+	api.GET("/audit/verify", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1"+path, nil))
+	return w
+}
+
+func TestRateLimitMiddleware_Limits(t *testing.T) {
+	m := NewRateLimitMiddleware(&flakyRateLimits{store: cache.NewLocalRateLimits()}, testRateLimitConfig(), zap.NewNop())
+	orgID := primitive.NewObjectID()
+	alice := &OrganizationContext{OrganizationID: orgID, UserID: primitive.NewObjectID(), SubscriptionTier: models.SubscriptionPlanStarter}
+	bob := &OrganizationContext{OrganizationID: orgID, UserID: primitive.NewObjectID(), SubscriptionTier: models.SubscriptionPlanStarter}
+
+	// The user bucket (2 of 3 org requests) is the tighter one
+	w := rateLimitRequest(m, alice, "", "/comments")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", w.Header().Get("RateLimit-Reset"))
+	assert.Empty(t, w.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusOK, rateLimitRequest(m, alice, "", "/comments").Code)
+	w = rateLimitRequest(m, alice, "", "/comments")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"RATE_LIMIT_EXCEEDED"`)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+
+	// Bob gets a separate user bucket but shares the organization one
+	w = rateLimitRequest(m, bob, "", "/comments")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "3", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	w = rateLimitRequest(m, bob, "", "/comments")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "3", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "20", w.Header().Get("Retry-After"))
+
+	// Group overrides count against their own buckets
+	carol := &OrganizationContext{OrganizationID: primitive.NewObjectID(), UserID: primitive.NewObjectID(), SubscriptionTier: models.SubscriptionPlanStarter}
+	w = rateLimitRequest(m, carol, "", "/audit/verify")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, http.StatusTooManyRequests, rateLimitRequest(m, carol, "", "/audit/verify").Code)
+	assert.Equal(t, http.StatusOK, rateLimitRequest(m, carol, "", "/comments").Code)
+
+	// API keys have their own limit
+	dave := &OrganizationContext{OrganizationID: primitive.NewObjectID(), UserID: primitive.NewObjectID(), SubscriptionTier: models.SubscriptionPlanStarter}
+	assert.Equal(t, http.StatusOK, rateLimitRequest(m, dave, "key-1", "/comments").Code)
+	assert.Equal(t, http.StatusTooManyRequests, rateLimitRequest(m, dave, "key-1", "/comments").Code)
+	assert.Equal(t, http.StatusOK, rateLimitRequest(m, dave, "key-2", "/comments").Code)
+
+	// Plans carry different quotas; unknown plans get the starter limits
+	erin := &OrganizationContext{OrganizationID: primitive.NewObjectID(), UserID: primitive.NewObjectID(), SubscriptionTier: models.SubscriptionPlanEnterprise}
+	assert.Equal(t, "50", rateLimitRequest(m, erin, "", "/comments").Header().Get("RateLimit-Limit"))
+	frank := &OrganizationContext{OrganizationID: primitive.NewObjectID(), UserID: primitive.NewObjectID(), SubscriptionTier: "legacy"}
+	assert.Equal(t, "2", rateLimitRequest(m, frank, "", "/comments").Header().Get("RateLimit-Limit"))
+
+	// Anonymous requests are limited per client IP
+	assert.Equal(t, http.StatusOK, rateLimitRequest(m, nil, "", "/comments").Code)
+	assert.Equal(t, http.StatusOK, rateLimitRequest(m, nil, "", "/comments").Code)
+	assert.Equal(t, http.StatusTooManyRequests, rateLimitRequest(m, nil, "", "/comments").Code)
+}
+
+func TestRateLimitMiddleware_FallsBackToLocalLimits(t *testing.T) {
+	store := &flakyRateLimits{store: cache.NewLocalRateLimits(), down: true}
+	m := NewRateLimitMiddleware(store, testRateLimitConfig(), zap.NewNop())
+	now := time.Now()
+	m.now = func() time.Time { return now }
+	user := &OrganizationContext{OrganizationID: primitive.NewObjectID(), UserID: primitive.NewObjectID(), SubscriptionTier: models.SubscriptionPlanStarter}
+
+	// Limits are still enforced while the store is down
+	assert.Equal(t, http.StatusOK, rateLimitRequest(m, user, "", "/comments").Code)
+	assert.Equal(t, http.StatusOK, rateLimitRequest(m, user, "", "/comments").Code)
+	assert.Equal(t, http.StatusTooManyRequests, rateLimitRequest(m, user, "", "/comments").Code)
+	assert.Equal(t, 1, store.calls, "store is not retried before FallbackRetry")
+
+	// The store is used again once FallbackRetry has passed
+	store.down = false
+	now = now.Add(31 * time.Second)
+	w := rateLimitRequest(m, user, "", "/comments")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, 3, store.calls)
+}
+
+func TestRateLimitMiddleware_Disabled(t *testing.T) {
+	cfg := testRateLimitConfig()
+	cfg.Enabled = false
+	store := &flakyRateLimits{store: cache.NewLocalRateLimits()}
+	m := NewRateLimitMiddleware(store, cfg, zap.NewNop())
+
+	w := rateLimitRequest(m, nil, "", "/comments")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+	assert.Zero(t, store.calls)
+}
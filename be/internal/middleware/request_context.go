@@ -0,0 +1,37 @@
+// Package middleware provides HTTP middleware functions for the GoEdu Control Testing Platform.
+// This file contains the middleware that propagates request metadata to the service layer.
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/requestctx"
+)
+
+// RequestContext stores the correlation ID, client IP and user agent of the
+// request in the request context, where services such as the audit service
+// read them. It must run after the logging middleware that assigns the
+// correlation ID; the authenticated actor is added later by
+// OrganizationMiddleware.EnforceOrganizationContext.
+//
+// Usage:
+//
+//	router.Use(app.loggingMiddleware())
+//	router.Use(middleware.RequestContext())
+func RequestContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		correlationID := c.GetString("correlation_id")
+		if correlationID == "" {
+			correlationID = c.GetHeader("X-Correlation-ID")
+		}
+
+		md := &requestctx.Metadata{
+			CorrelationID: correlationID,
+			IPAddress:     c.ClientIP(),
+			UserAgent:     c.Request.UserAgent(),
+		}
+		c.Request = c.Request.WithContext(requestctx.WithMetadata(c.Request.Context(), md))
+
+		c.Next()
+	}
+}
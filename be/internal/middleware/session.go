@@ -0,0 +1,116 @@
+// Package middleware provides HTTP middleware functions for the GoEdu Control Testing Platform.
+// This file contains authentication of signed-in users with access tokens,
+// checking on every request that the token's session is still in force.
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// sessionIDKey is the gin context key of the session that authenticated the request.
+const sessionIDKey = "session_id"
+
+// AccessTokenValidator interface for middleware dependencies
+type AccessTokenValidator interface {
+	ValidateAccessToken(ctx context.Context, token string) (*models.JWTClaims, error)
+}
+
+// SessionMiddleware authenticates requests made with access tokens.
+type SessionMiddleware struct {
+	validator AccessTokenValidator
+	logger    *zap.Logger
+}
+
+// NewSessionMiddleware creates a new session middleware.
+//
+// Parameters:
+//   - validator: Service validating access tokens, usually the authentication service
+//   - logger: Logger for middleware operations
+//
+// Returns:
+//   - *SessionMiddleware: Configured middleware instance
+func NewSessionMiddleware(validator AccessTokenValidator, logger *zap.Logger) *SessionMiddleware {
+	return &SessionMiddleware{
+		validator: validator,
+		logger:    logger,
+	}
+}
+
+// Authenticate handles requests carrying "Authorization: Bearer <token>"
+// with an access token and passes all others, including API keys sent as
+// bearer tokens, through. The token's session must not have been revoked,
+// been idle for longer than the organization's idle timeout or expired;
+// revoked sessions are rejected on their next request. On success it records
+// the user, organization and session for the organization middleware, so it
+// must run before it.
+//
+// Usage:
+//
+//	api.Use(sessionMiddleware.Authenticate())
+//	api.Use(orgMiddleware.EnforceOrganizationContext())
+func (m *SessionMiddleware) Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+		token = strings.TrimSpace(token)
+		if !ok || !strings.EqualFold(scheme, bearerScheme) || token == "" || services.IsAPIKey(token) {
+			c.Next()
+			return
+		}
+
+		claims, err := m.validator.ValidateAccessToken(c.Request.Context(), token)
+		switch {
+		case errors.Is(err, services.ErrInvalidAccessToken):
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid or expired access token",
+				"code":  "INVALID_ACCESS_TOKEN",
+			})
+			return
+		case errors.Is(err, services.ErrSessionExpired):
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Session has expired",
+				"code":  "SESSION_EXPIRED",
+			})
+			return
+		case errors.Is(err, services.ErrSessionRevoked):
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Session has been revoked",
+				"code":  "SESSION_REVOKED",
+			})
+			return
+		case err != nil:
+			m.logger.Error("Failed to validate session", zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to validate session",
+				"code":  "SESSION_VALIDATION_ERROR",
+			})
+			return
+		}
+
+		c.Set("user_id", claims.UserID)
+		c.Set("user_organization_id", claims.OrganizationID)
+		c.Set(sessionIDKey, claims.SessionID)
+
+		c.Next()
+	}
+}
+
+// GetSessionID returns the session that authenticated the request, or an
+// empty string for requests not made with an access token.
+//
+// Parameters:
+//   - c: Gin context of the current request
+//
+// Returns:
+//   - string: Session ID
+func GetSessionID(c *gin.Context) string {
+	return c.GetString(sessionIDKey)
+}
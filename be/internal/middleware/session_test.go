@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/services"
+)
+
+// fakeAccessTokenValidator resolves access tokens from a fixed table.
+type fakeAccessTokenValidator map[string]*models.JWTClaims
+
+func (f fakeAccessTokenValidator) ValidateAccessToken(ctx context.Context, token string) (*models.JWTClaims, error) {
+	switch token {
+	case "expired":
+		return nil, services.ErrSessionExpired
+	case "revoked":
+		return nil, services.ErrSessionRevoked
+	case "broken":
+		return nil, errors.New("session store unavailable")
+	}
+	claims, ok := f[token]
+	if !ok {
+		return nil, services.ErrInvalidAccessToken
+	}
+	return claims, nil
+}
+
+func TestSessionMiddleware_Authenticate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	claims := &models.JWTClaims{
+		UserID:         primitive.NewObjectID().Hex(),
+		OrganizationID: primitive.NewObjectID().Hex(),
+		SessionID:      "laptop",
+	}
+	sessions := NewSessionMiddleware(fakeAccessTokenValidator{"valid": claims}, zap.NewNop())
+
+	var userID, orgID, sessionID string
+	router := gin.New()
+	router.GET("/controls", sessions.Authenticate(), func(c *gin.Context) {
+		userID, orgID, sessionID = c.GetString("user_id"), c.GetString("user_organization_id"), GetSessionID(c)
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name           string
+		authorization  string
+		expectedStatus int
+		expectedCode   string
+		expectedUser   string
+	}{
+		{"valid token", "Bearer valid", http.StatusOK, "", claims.UserID},
+		{"scheme is case-insensitive", "bearer valid", http.StatusOK, "", claims.UserID},
+		{"unknown token", "Bearer forged", http.StatusUnauthorized, "INVALID_ACCESS_TOKEN", ""},
+		{"expired session", "Bearer expired", http.StatusUnauthorized, "SESSION_EXPIRED", ""},
+		{"revoked session", "Bearer revoked", http.StatusUnauthorized, "SESSION_REVOKED", ""},
+		{"validation failure", "Bearer broken", http.StatusInternalServerError, "SESSION_VALIDATION_ERROR", ""},
+		{"API keys pass through", "Bearer goedu_key", http.StatusOK, "", ""},
+		{"other schemes pass through", "ApiKey valid", http.StatusOK, "", ""},
+		{"anonymous requests pass through", "", http.StatusOK, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID, orgID, sessionID = "", "", ""
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/controls", nil)
+			req.Header.Set("Authorization", tt.authorization)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			if tt.expectedCode != "" {
+				assert.Contains(t, w.Body.String(), `"code":"`+tt.expectedCode+`"`)
+				return
+			}
+			assert.Equal(t, tt.expectedUser, userID)
+			if tt.expectedUser != "" {
+				assert.Equal(t, claims.OrganizationID, orgID)
+				assert.Equal(t, "laptop", sessionID)
+			}
+		})
+	}
+}
@@ -5,6 +5,11 @@ package migrations
 import (
 	"context"
 
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
 	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/database"
 )
 
@@ -24,6 +29,23 @@ func getAllMigrations() []Migration {
 		migration001InitialIndexes(),
 		migration002AuditIndexes(),
 		migration003OptimizeQueries(),
+		migration004CommentIndexes(),
+		migration005AuditChainIndexes(),
+		migration006ExportJobIndexes(),
+		migration007RetentionIndexes(),
+		migration008NotificationIndexes(),
+		migration009NotificationDigestIndexes(),
+		migration010InAppNotificationIndexes(),
+		migration011WebhookIndexes(),
+		migration012DomainEventIndexes(),
+		migration013SchedulerIndexes(),
+		migration014APIKeyIndexes(),
+		migration015SSOLoginStateIndexes(),
+		migration016SCIMGroupIndexes(),
+		migration017InvitationIndexes(),
+		migration018PasswordResetIndexes(),
+		migration019SessionIndexes(),
+		migration020EvidenceCommentsToCollection(),
 		// Add new migrations here...
 	}
 }
@@ -110,11 +132,698 @@ func migration003OptimizeQueries() Migration {
 	}
 }
 
+// migration004CommentIndexes creates indexes for the generic comments collection.
+// Comments are always read per resource in creation order, and looked up by ID when edited.
+func migration004CommentIndexes() Migration {
+	return Migration{
+		Version:     4,
+		Description: "Create indexes for threaded comments",
+		Up: func(ctx context.Context, db *database.Client) error {
+			_, err := db.Collection("comments").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "id", Value: 1}},
+					Options: options.Index().SetUnique(true).SetName("comments_id"),
+				},
+				{
+					Keys: bson.D{
+						{Key: "organization_id", Value: 1},
+						{Key: "resource_type", Value: 1},
+						{Key: "resource_id", Value: 1},
+						{Key: "created_at", Value: 1},
+					},
+					Options: options.Index().SetName("comments_resource"),
+				},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *database.Client) error {
+			indexes := db.Collection("comments").Indexes()
+			for _, name := range []string{"comments_id", "comments_resource"} {
+				if _, err := indexes.DropOne(ctx, name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// migration005AuditChainIndexes creates the indexes backing the audit hash chain.
+// The unique sequence index makes concurrent appends to the same chain fail instead
+// of forking it; entries written before chaining have no sequence and are excluded.
+func migration005AuditChainIndexes() Migration {
+	return Migration{
+		Version:     5,
+		Description: "Create indexes for the audit hash chain and checkpoints",
+		Up: func(ctx context.Context, db *database.Client) error {
+			_, err := db.Collection("audit_logs").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "organization_id", Value: 1},
+					{Key: "sequence", Value: 1},
+				},
+				Options: options.Index().
+					SetUnique(true).
+					SetName("audit_logs_chain").
+					SetPartialFilterExpression(bson.D{{Key: "sequence", Value: bson.D{{Key: "$gt", Value: 0}}}}),
+			})
+			if err != nil {
+				return err
+			}
+
+			_, err = db.Collection("audit_checkpoints").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "organization_id", Value: 1},
+					{Key: "sequence", Value: 1},
+				},
+				Options: options.Index().SetUnique(true).SetName("audit_checkpoints_chain"),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *database.Client) error {
+			if _, err := db.Collection("audit_logs").Indexes().DropOne(ctx, "audit_logs_chain"); err != nil {
+				return err
+			}
+			_, err := db.Collection("audit_checkpoints").Indexes().DropOne(ctx, "audit_checkpoints_chain")
+			return err
+		},
+	}
+}
+
+// migration006ExportJobIndexes creates indexes for background export jobs.
+// Workers claim the oldest pending job, and users list their organization's exports.
+func migration006ExportJobIndexes() Migration {
+	return Migration{
+		Version:     6,
+		Description: "Create indexes for background export jobs",
+		Up: func(ctx context.Context, db *database.Client) error {
+			_, err := db.Collection("export_jobs").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys: bson.D{
+						{Key: "status", Value: 1},
+						{Key: "created_at", Value: 1},
+					},
+					Options: options.Index().SetName("export_jobs_queue"),
+				},
+				{
+					Keys: bson.D{
+						{Key: "organization_id", Value: 1},
+						{Key: "created_at", Value: -1},
+					},
+					Options: options.Index().SetName("export_jobs_organization"),
+				},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *database.Client) error {
+			indexes := db.Collection("export_jobs").Indexes()
+			for _, name := range []string{"export_jobs_queue", "export_jobs_organization"} {
+				if _, err := indexes.DropOne(ctx, name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// retentionIndexes lists the indexes used by the retention engine per collection.
+var retentionIndexes = []struct {
+	collection string
+	name       string
+	keys       bson.D
+}{
+	{"legal_holds", "legal_holds_organization", bson.D{{Key: "organization_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	{"comments", "comments_retention", bson.D{{Key: "organization_id", Value: 1}, {Key: "status", Value: 1}, {Key: "deleted_at", Value: 1}}},
+	{"sessions", "sessions_retention", bson.D{{Key: "organization_id", Value: 1}, {Key: "expires_at", Value: 1}}},
+	{"evidence_requests", "evidence_requests_retention", bson.D{{Key: "organization_id", Value: 1}, {Key: "status", Value: 1}, {Key: "updated_at", Value: 1}}},
+}
+
+// migration007RetentionIndexes creates indexes for retention enforcement and legal holds.
+// The retention engine scans each tenant's expired records by status and age.
+func migration007RetentionIndexes() Migration {
+	return Migration{
+		Version:     7,
+		Description: "Create indexes for retention enforcement and legal holds",
+		Up: func(ctx context.Context, db *database.Client) error {
+			for _, index := range retentionIndexes {
+				_, err := db.Collection(index.collection).Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys:    index.keys,
+					Options: options.Index().SetName(index.name),
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(ctx context.Context, db *database.Client) error {
+			for _, index := range retentionIndexes {
+				if _, err := db.Collection(index.collection).Indexes().DropOne(ctx, index.name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// migration008NotificationIndexes creates indexes for the notification outbox and
+// template overrides. The dispatcher claims due messages by status and next attempt
+// time; each organization has at most one override per notification type.
+func migration008NotificationIndexes() Migration {
+	return Migration{
+		Version:     8,
+		Description: "Create indexes for the notification outbox and template overrides",
+		Up: func(ctx context.Context, db *database.Client) error {
+			_, err := db.Collection("notification_outbox").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys: bson.D{
+						{Key: "status", Value: 1},
+						{Key: "next_attempt_at", Value: 1},
+					},
+					Options: options.Index().SetName("notification_outbox_queue"),
+				},
+				{
+					Keys: bson.D{
+						{Key: "status", Value: 1},
+						{Key: "locked_until", Value: 1},
+					},
+					Options: options.Index().SetName("notification_outbox_leases"),
+				},
+			})
+			if err != nil {
+				return err
+			}
+
+			_, err = db.Collection("notification_templates").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "organization_id", Value: 1},
+					{Key: "type", Value: 1},
+				},
+				Options: options.Index().SetUnique(true).SetName("notification_templates_type"),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *database.Client) error {
+			indexes := db.Collection("notification_outbox").Indexes()
+			for _, name := range []string{"notification_outbox_queue", "notification_outbox_leases"} {
+				if _, err := indexes.DropOne(ctx, name); err != nil {
+					return err
+				}
+			}
+			_, err := db.Collection("notification_templates").Indexes().DropOne(ctx, "notification_templates_type")
+			return err
+		},
+	}
+}
+
+// migration009NotificationDigestIndexes creates indexes for notifications held back
+// for digests. The digester claims due items by due time and lease, then collects
+// the remaining due items of the same user.
+func migration009NotificationDigestIndexes() Migration {
+	return Migration{
+		Version:     9,
+		Description: "Create indexes for notification digest items",
+		Up: func(ctx context.Context, db *database.Client) error {
+			_, err := db.Collection("notification_digest_items").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys: bson.D{
+						{Key: "due_at", Value: 1},
+						{Key: "locked_until", Value: 1},
+					},
+					Options: options.Index().SetName("notification_digest_items_due"),
+				},
+				{
+					Keys: bson.D{
+						{Key: "user_id", Value: 1},
+						{Key: "due_at", Value: 1},
+					},
+					Options: options.Index().SetName("notification_digest_items_user"),
+				},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *database.Client) error {
+			indexes := db.Collection("notification_digest_items").Indexes()
+			for _, name := range []string{"notification_digest_items_due", "notification_digest_items_user"} {
+				if _, err := indexes.DropOne(ctx, name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// migration010InAppNotificationIndexes creates indexes for users' in-app inboxes,
+// which are listed newest first and counted by read state.
+func migration010InAppNotificationIndexes() Migration {
+	return Migration{
+		Version:     10,
+		Description: "Create indexes for in-app notifications",
+		Up: func(ctx context.Context, db *database.Client) error {
+			_, err := db.Collection("in_app_notifications").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys: bson.D{
+						{Key: "user_id", Value: 1},
+						{Key: "created_at", Value: -1},
+					},
+					Options: options.Index().SetName("in_app_notifications_user_created"),
+				},
+				{
+					Keys: bson.D{
+						{Key: "user_id", Value: 1},
+						{Key: "read_at", Value: 1},
+					},
+					Options: options.Index().SetName("in_app_notifications_user_read"),
+				},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *database.Client) error {
+			indexes := db.Collection("in_app_notifications").Indexes()
+			for _, name := range []string{"in_app_notifications_user_created", "in_app_notifications_user_read"} {
+				if _, err := indexes.DropOne(ctx, name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// migration011WebhookIndexes creates indexes for webhook subscriptions, which
+// are looked up per organization, and for deliveries, which are claimed by due
+// time and listed per subscription.
+func migration011WebhookIndexes() Migration {
+	return Migration{
+		Version:     11,
+		Description: "Create indexes for webhook subscriptions and deliveries",
+		Up: func(ctx context.Context, db *database.Client) error {
+			_, err := db.Collection("webhook_subscriptions").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "organization_id", Value: 1},
+					{Key: "is_active", Value: 1},
+				},
+				Options: options.Index().SetName("webhook_subscriptions_org_active"),
+			})
+			if err != nil {
+				return err
+			}
+
+			_, err = db.Collection("webhook_deliveries").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys: bson.D{
+						{Key: "status", Value: 1},
+						{Key: "next_attempt_at", Value: 1},
+					},
+					Options: options.Index().SetName("webhook_deliveries_status_due"),
+				},
+				{
+					Keys: bson.D{
+						{Key: "subscription_id", Value: 1},
+						{Key: "created_at", Value: -1},
+					},
+					Options: options.Index().SetName("webhook_deliveries_subscription_created"),
+				},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *database.Client) error {
+			if _, err := db.Collection("webhook_subscriptions").Indexes().DropOne(ctx, "webhook_subscriptions_org_active"); err != nil {
+				return err
+			}
+			indexes := db.Collection("webhook_deliveries").Indexes()
+			for _, name := range []string{"webhook_deliveries_status_due", "webhook_deliveries_subscription_created"} {
+				if _, err := indexes.DropOne(ctx, name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// migration012DomainEventIndexes creates indexes for the domain event outbox. The
+// relay claims due events by status and next attempt time; relayed events expire
+// after a week, which leaves time to investigate consumers.
+func migration012DomainEventIndexes() Migration {
+	return Migration{
+		Version:     12,
+		Description: "Create indexes for the domain event outbox",
+		Up: func(ctx context.Context, db *database.Client) error {
+			_, err := db.Collection("domain_events").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys: bson.D{
+						{Key: "status", Value: 1},
+						{Key: "next_attempt_at", Value: 1},
+					},
+					Options: options.Index().SetName("domain_events_status_due"),
+				},
+				{
+					Keys:    bson.D{{Key: "relayed_at", Value: 1}},
+					Options: options.Index().SetExpireAfterSeconds(7 * 24 * 60 * 60).SetName("domain_events_relayed_ttl"),
+				},
+				{
+					Keys:    bson.D{{Key: "event_id", Value: 1}},
+					Options: options.Index().SetUnique(true).SetName("domain_events_event_id"),
+				},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *database.Client) error {
+			indexes := db.Collection("domain_events").Indexes()
+			for _, name := range []string{"domain_events_status_due", "domain_events_relayed_ttl", "domain_events_event_id"} {
+				if _, err := indexes.DropOne(ctx, name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// migration013SchedulerIndexes creates indexes for scheduled jobs and their run
+// history. Job names are unique so that replicas starting at the same time
+// store each job once; runs expire at the time set by the scheduler.
+func migration013SchedulerIndexes() Migration {
+	return Migration{
+		Version:     13,
+		Description: "Create indexes for scheduled jobs and job runs",
+		Up: func(ctx context.Context, db *database.Client) error {
+			_, err := db.Collection("scheduled_jobs").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "name", Value: 1}},
+				Options: options.Index().SetUnique(true).SetName("scheduled_jobs_name"),
+			})
+			if err != nil {
+				return err
+			}
+
+			_, err = db.Collection("job_runs").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys: bson.D{
+						{Key: "job_name", Value: 1},
+						{Key: "started_at", Value: -1},
+					},
+					Options: options.Index().SetName("job_runs_job_started"),
+				},
+				{
+					Keys:    bson.D{{Key: "expires_at", Value: 1}},
+					Options: options.Index().SetExpireAfterSeconds(0).SetName("job_runs_expires_ttl"),
+				},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *database.Client) error {
+			if _, err := db.Collection("scheduled_jobs").Indexes().DropOne(ctx, "scheduled_jobs_name"); err != nil {
+				return err
+			}
+			indexes := db.Collection("job_runs").Indexes()
+			for _, name := range []string{"job_runs_job_started", "job_runs_expires_ttl"} {
+				if _, err := indexes.DropOne(ctx, name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// migration014APIKeyIndexes creates indexes for organization API keys. Keys are
+// looked up by their prefix on every authenticated request, so prefixes are
+// unique; listings are per organization, newest first.
+func migration014APIKeyIndexes() Migration {
+	return Migration{
+		Version:     14,
+		Description: "Create indexes for organization API keys",
+		Up: func(ctx context.Context, db *database.Client) error {
+			_, err := db.Collection("api_keys").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "prefix", Value: 1}},
+					Options: options.Index().SetUnique(true).SetName("api_keys_prefix"),
+				},
+				{
+					Keys: bson.D{
+						{Key: "organization_id", Value: 1},
+						{Key: "created_at", Value: -1},
+					},
+					Options: options.Index().SetName("api_keys_org_created"),
+				},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *database.Client) error {
+			indexes := db.Collection("api_keys").Indexes()
+			for _, name := range []string{"api_keys_prefix", "api_keys_org_created"} {
+				if _, err := indexes.DropOne(ctx, name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// migration015SSOLoginStateIndexes creates indexes for single sign-on logins in
+// progress. States are looked up by their unique value and removed by MongoDB
+// once they expire.
+func migration015SSOLoginStateIndexes() Migration {
+	return Migration{
+		Version:     15,
+		Description: "Create indexes for single sign-on login states",
+		Up: func(ctx context.Context, db *database.Client) error {
+			_, err := db.Collection("sso_login_states").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "state", Value: 1}},
+					Options: options.Index().SetUnique(true).SetName("sso_login_states_state"),
+				},
+				{
+					Keys:    bson.D{{Key: "expires_at", Value: 1}},
+					Options: options.Index().SetExpireAfterSeconds(0).SetName("sso_login_states_expires_ttl"),
+				},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *database.Client) error {
+			indexes := db.Collection("sso_login_states").Indexes()
+			for _, name := range []string{"sso_login_states_state", "sso_login_states_expires_ttl"} {
+				if _, err := indexes.DropOne(ctx, name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// migration016SCIMGroupIndexes creates indexes for groups provisioned through
+// SCIM. Display names are unique per organization regardless of case, and
+// groups are looked up by member when a user's roles are computed.
+func migration016SCIMGroupIndexes() Migration {
+	return Migration{
+		Version:     16,
+		Description: "Create indexes for SCIM groups",
+		Up: func(ctx context.Context, db *database.Client) error {
+			_, err := db.Collection("scim_groups").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys: bson.D{
+						{Key: "organization_id", Value: 1},
+						{Key: "display_name", Value: 1},
+					},
+					Options: options.Index().SetUnique(true).SetName("scim_groups_org_display_name").
+						SetCollation(&options.Collation{Locale: "en", Strength: 2}),
+				},
+				{
+					Keys: bson.D{
+						{Key: "organization_id", Value: 1},
+						{Key: "members", Value: 1},
+					},
+					Options: options.Index().SetName("scim_groups_org_members"),
+				},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *database.Client) error {
+			indexes := db.Collection("scim_groups").Indexes()
+			for _, name := range []string{"scim_groups_org_display_name", "scim_groups_org_members"} {
+				if _, err := indexes.DropOne(ctx, name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// migration017InvitationIndexes creates indexes for invitations. An
+// organization may have only one pending invitation per email, and
+// invitations are listed per organization, newest first.
+func migration017InvitationIndexes() Migration {
+	return Migration{
+		Version:     17,
+		Description: "Create indexes for invitations",
+		Up: func(ctx context.Context, db *database.Client) error {
+			_, err := db.Collection("invitations").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys: bson.D{
+						{Key: "organization_id", Value: 1},
+						{Key: "email", Value: 1},
+					},
+					Options: options.Index().SetUnique(true).SetName("invitations_org_email_pending").
+						SetPartialFilterExpression(bson.D{{Key: "status", Value: "pending"}}),
+				},
+				{
+					Keys: bson.D{
+						{Key: "organization_id", Value: 1},
+						{Key: "created_at", Value: -1},
+					},
+					Options: options.Index().SetName("invitations_org_created"),
+				},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *database.Client) error {
+			indexes := db.Collection("invitations").Indexes()
+			for _, name := range []string{"invitations_org_email_pending", "invitations_org_created"} {
+				if _, err := indexes.DropOne(ctx, name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// migration018PasswordResetIndexes creates indexes for password resets.
+// Resets are looked up by the hash of their token and ended per user, and
+// are removed a day after they expire.
+func migration018PasswordResetIndexes() Migration {
+	return Migration{
+		Version:     18,
+		Description: "Create indexes for password resets",
+		Up: func(ctx context.Context, db *database.Client) error {
+			_, err := db.Collection("password_resets").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "token_hash", Value: 1}},
+					Options: options.Index().SetUnique(true).SetName("password_resets_token_hash"),
+				},
+				{
+					Keys:    bson.D{{Key: "user_id", Value: 1}},
+					Options: options.Index().SetName("password_resets_user"),
+				},
+				{
+					Keys:    bson.D{{Key: "expires_at", Value: 1}},
+					Options: options.Index().SetExpireAfterSeconds(24 * 60 * 60).SetName("password_resets_expires_ttl"),
+				},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *database.Client) error {
+			indexes := db.Collection("password_resets").Indexes()
+			for _, name := range []string{"password_resets_token_hash", "password_resets_user", "password_resets_expires_ttl"} {
+				if _, err := indexes.DropOne(ctx, name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// migration019SessionIndexes creates indexes for sessions. Sessions are
+// looked up by their session ID on every request that records activity, and
+// a user's active sessions are listed to show their devices and enforce the
+// organization's cap on concurrent sessions.
+func migration019SessionIndexes() Migration {
+	return Migration{
+		Version:     19,
+		Description: "Create indexes for sessions",
+		Up: func(ctx context.Context, db *database.Client) error {
+			_, err := db.Collection("sessions").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "session_id", Value: 1}},
+					Options: options.Index().SetUnique(true).SetName("sessions_session_id"),
+				},
+				{
+					Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "is_active", Value: 1}, {Key: "last_activity", Value: -1}},
+					Options: options.Index().SetName("sessions_user_active"),
+				},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *database.Client) error {
+			indexes := db.Collection("sessions").Indexes()
+			for _, name := range []string{"sessions_session_id", "sessions_user_active"} {
+				if _, err := indexes.DropOne(ctx, name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// migration020EvidenceCommentsToCollection moves the comments embedded in
+// evidence requests into the comments collection, where every comment on a
+// request is now written and read. Comments are upserted by ID so an
+// interrupted run can be repeated. The embedded comments are not restored on
+// rollback; they remain readable from the comments collection.
+func migration020EvidenceCommentsToCollection() Migration {
+	return Migration{
+		Version:     20,
+		Description: "Move evidence request comments into the comments collection",
+		Up: func(ctx context.Context, db *database.Client) error {
+			requests := db.Collection("evidence_requests")
+			cursor, err := requests.Find(ctx,
+				bson.M{"comments.0": bson.M{"$exists": true}},
+				options.Find().SetProjection(bson.M{"_id": 1, "organization_id": 1, "comments": 1}),
+			)
+			if err != nil {
+				return err
+			}
+			defer cursor.Close(ctx)
+
+			comments := db.Collection("comments")
+			for cursor.Next(ctx) {
+				var request struct {
+					ID             primitive.ObjectID `bson:"_id"`
+					OrganizationID primitive.ObjectID `bson:"organization_id"`
+					Comments       []bson.M           `bson:"comments"`
+				}
+				if err := cursor.Decode(&request); err != nil {
+					return err
+				}
+
+				writes := make([]mongo.WriteModel, 0, len(request.Comments))
+				for _, comment := range request.Comments {
+					comment["organization_id"] = request.OrganizationID
+					comment["resource_type"] = "evidence_request"
+					comment["resource_id"] = request.ID.Hex()
+					writes = append(writes, mongo.NewUpdateOneModel().
+						SetFilter(bson.M{"id": comment["id"]}).
+						SetUpdate(bson.M{"$setOnInsert": comment}).
+						SetUpsert(true))
+				}
+				if _, err := comments.BulkWrite(ctx, writes); err != nil {
+					return err
+				}
+				if _, err := requests.UpdateByID(ctx, request.ID, bson.M{"$unset": bson.M{"comments": ""}}); err != nil {
+					return err
+				}
+			}
+			return cursor.Err()
+		},
+		Down: func(ctx context.Context, db *database.Client) error {
+			return nil
+		},
+	}
+}
+
 // Future migration templates:
 //
-// func migration004ExampleMigration() Migration {
+// func migration021ExampleMigration() Migration {
 //     return Migration{
-//         Version:     4,
+//         Version:     21,
 //         Description: "Example migration description",
 //         Up: func(ctx context.Context, db *database.Client) error {
 //             // Forward migration logic
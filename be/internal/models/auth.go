@@ -137,21 +137,78 @@ type Session struct {
 	SessionID string             `bson:"session_id" json:"session_id" validate:"required"`
 	UserID    primitive.ObjectID `bson:"user_id" json:"user_id" validate:"required"`
 	
+	// Organization of the user, used to apply the organization's retention policy
+	OrganizationID primitive.ObjectID `bson:"organization_id,omitempty" json:"organization_id,omitempty"`
+	
 	// Session security
 	IPAddress     string    `bson:"ip_address" json:"ip_address"`
 	UserAgent     string    `bson:"user_agent" json:"user_agent"`
 	RefreshToken  string    `bson:"refresh_token" json:"-"` // Hashed refresh token
 	LastActivity  time.Time `bson:"last_activity" json:"last_activity"`
 	
-	// Session management
+	// Session management: ExpiresAt is the absolute timeout; sessions also end
+	// after the organization's idle timeout without activity
 	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
 	IsActive  bool      `bson:"is_active" json:"is_active"`
+	EndedAt   time.Time `bson:"ended_at,omitempty" json:"ended_at,omitempty"`
+	EndReason string    `bson:"end_reason,omitempty" json:"end_reason,omitempty"`
 	
 	// Tracking
 	LoginMethod string                 `bson:"login_method" json:"login_method"` // "password", "sso", "mfa"
 	DeviceInfo  map[string]interface{} `bson:"device_info,omitempty" json:"device_info,omitempty"`
 }
 
+// SSOLoginState is a single sign-on login in progress, kept from the redirect
+// to the identity provider until the user comes back. It is looked up by the
+// random State value sent through the provider and can be used only once.
+// Assertions of IdP-initiated SAML logins are recorded under their assertion
+// ID until they expire, so that a replayed assertion is rejected.
+type SSOLoginState struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	State          string             `bson:"state" json:"-"`
+	OrganizationID primitive.ObjectID `bson:"organization_id" json:"organization_id"`
+	Provider       string             `bson:"provider" json:"provider"`
+	
+	// Values bound to the provider's response: the OIDC nonce and PKCE code verifier
+	Nonce        string `bson:"nonce,omitempty" json:"-"`
+	CodeVerifier string `bson:"code_verifier,omitempty" json:"-"`
+	
+	// RequestID is the SAML AuthnRequest the identity provider's response must answer
+	RequestID string `bson:"request_id,omitempty" json:"-"`
+	
+	// ReturnTo is the application path the user is sent to after signing in
+	ReturnTo string `bson:"return_to,omitempty" json:"return_to,omitempty"`
+	
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
+}
+
+// PasswordReset is a request to reset a forgotten password. Only the SHA-256
+// hash of the token emailed to the user is stored; a reset can be used once,
+// until it expires. Users with security questions must answer
+// SecurityQuestion before the reset can set a new password.
+type PasswordReset struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID         primitive.ObjectID `bson:"user_id" json:"user_id"`
+	OrganizationID primitive.ObjectID `bson:"organization_id" json:"organization_id"`
+	TokenHash      string             `bson:"token_hash" json:"-"`
+	
+	// Second factor: the question asked and when it was answered correctly
+	SecurityQuestion string    `bson:"security_question,omitempty" json:"security_question,omitempty"`
+	AnswerVerifiedAt time.Time `bson:"answer_verified_at,omitempty" json:"answer_verified_at,omitempty"`
+	FailedAnswers    int       `bson:"failed_answers" json:"failed_answers"`
+	
+	IPAddress string    `bson:"ip_address,omitempty" json:"ip_address,omitempty"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
+	UsedAt    time.Time `bson:"used_at,omitempty" json:"used_at,omitempty"`
+}
+
+// IsPending reports whether the reset can still be used at the given time.
+func (r *PasswordReset) IsPending(now time.Time) bool {
+	return r.UsedAt.IsZero() && now.Before(r.ExpiresAt)
+}
+
 // AuditEvent represents security and authentication audit events.
 type AuditEvent struct {
 	BaseModel `bson:",inline"`
@@ -241,9 +298,18 @@ const (
 	TokenTypeAccess  = "access"
 	TokenTypeRefresh = "refresh"
 	
+	// Reasons a session ended
+	SessionEndLogout        = "logout"
+	SessionEndRevoked       = "revoked"
+	SessionEndIdleTimeout   = "idle_timeout"
+	SessionEndExpired       = "expired"
+	SessionEndSessionLimit  = "session_limit"
+	SessionEndPasswordReset = "password_reset"
+	
 	// Login methods
 	LoginMethodPassword = "password"
 	LoginMethodSSO      = "sso"
+	LoginMethodLDAP     = "ldap"
 	LoginMethodMFA      = "mfa"
 	
 	// Event types for audit logging
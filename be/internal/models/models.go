@@ -5,6 +5,7 @@ package models
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -175,7 +176,16 @@ type OrganizationSettings struct {
 	// Security and access control settings
 	RequireMFA            bool `bson:"require_mfa" json:"require_mfa"`
 	AllowInvitations      bool `bson:"allow_invitations" json:"allow_invitations"`
-	SessionTimeoutMinutes int  `bson:"session_timeout_minutes" json:"session_timeout_minutes"`
+	SessionTimeoutMinutes int  `bson:"session_timeout_minutes" json:"session_timeout_minutes"` // Idle timeout
+	
+	// Session limits: time after sign-in at which sessions end, and sessions
+	// a user may have at once; zero uses the platform default and no cap
+	AbsoluteSessionTimeoutMinutes int `bson:"absolute_session_timeout_minutes,omitempty" json:"absolute_session_timeout_minutes,omitempty"`
+	MaxConcurrentSessions         int `bson:"max_concurrent_sessions,omitempty" json:"max_concurrent_sessions,omitempty"`
+	
+	// Password rules for accounts signing in with a platform password; the
+	// platform defaults apply when unset
+	PasswordPolicy *PasswordPolicy `bson:"password_policy,omitempty" json:"password_policy,omitempty"`
 	
 	// Data and audit settings
 	EnableAuditLog       bool `bson:"enable_audit_log" json:"enable_audit_log"`
@@ -210,6 +220,28 @@ type OrganizationSettings struct {
 	CustomSettings map[string]interface{} `bson:"custom_settings,omitempty" json:"custom_settings,omitempty"`
 }
 
+// PasswordPolicy configures the passwords an organization's users may choose.
+// MinLength below the platform minimum of 12 characters is raised to it.
+type PasswordPolicy struct {
+	MinLength        int  `bson:"min_length" json:"min_length"`
+	RequireUppercase bool `bson:"require_uppercase" json:"require_uppercase"`
+	RequireLowercase bool `bson:"require_lowercase" json:"require_lowercase"`
+	RequireDigit     bool `bson:"require_digit" json:"require_digit"`
+	RequireSymbol    bool `bson:"require_symbol" json:"require_symbol"`
+	
+	// HistoryCount previous passwords cannot be reused; 0 only rejects the current one
+	HistoryCount int `bson:"history_count" json:"history_count"`
+	
+	// MaxAgeDays after a change a password expires and must be changed; 0 never expires
+	MaxAgeDays int `bson:"max_age_days" json:"max_age_days"`
+	
+	// RejectCommon rejects common passwords and passwords containing the user's name or email
+	RejectCommon bool `bson:"reject_common" json:"reject_common"`
+	
+	// RejectBreached rejects passwords found in the breached password list
+	RejectBreached bool `bson:"reject_breached" json:"reject_breached"`
+}
+
 // OrganizationIntegrations manages external system integration settings.
 type OrganizationIntegrations struct {
 	SSO  bool `bson:"sso" json:"sso"`
@@ -217,12 +249,163 @@ type OrganizationIntegrations struct {
 	API  bool `bson:"api" json:"api"`
 	
 	// Specific integration configurations
-	SSOProvider    string `bson:"sso_provider,omitempty" json:"sso_provider,omitempty"`
+	SSOProvider    string `bson:"sso_provider,omitempty" json:"sso_provider,omitempty"` // SSOProviderOIDC, SSOProviderSAML
 	LDAPServer     string `bson:"ldap_server,omitempty" json:"ldap_server,omitempty"`
 	APIKeyEnabled  bool   `bson:"api_key_enabled" json:"api_key_enabled"`
 	WebhooksEnabled bool  `bson:"webhooks_enabled" json:"webhooks_enabled"`
+	
+	// Identity provider settings of the selected SSO provider
+	OIDC *OIDCSettings `bson:"oidc,omitempty" json:"oidc,omitempty"`
+	SAML *SAMLSettings `bson:"saml,omitempty" json:"saml,omitempty"`
+	
+	// User provisioning through the SCIM API
+	SCIM *SCIMSettings `bson:"scim,omitempty" json:"scim,omitempty"`
+	
+	// Directory used for LDAP sign-in and sync when LDAP is enabled; LDAPServer holds its URL
+	LDAPSettings *LDAPSettings `bson:"ldap_settings,omitempty" json:"ldap_settings,omitempty"`
+}
+
+// OIDCSettings configures an organization's OpenID Connect identity provider.
+// When Issuer is empty the platform-wide provider from the auth configuration
+// is used with the organization's role mappings.
+type OIDCSettings struct {
+	Issuer       string   `bson:"issuer,omitempty" json:"issuer,omitempty"`
+	ClientID     string   `bson:"client_id,omitempty" json:"client_id,omitempty"`
+	ClientSecret string   `bson:"client_secret,omitempty" json:"-"`
+	Scopes       []string `bson:"scopes,omitempty" json:"scopes,omitempty"`
+	
+	// GroupsClaim names the ID token claim listing the user's groups, "groups" by default
+	GroupsClaim  string             `bson:"groups_claim,omitempty" json:"groups_claim,omitempty"`
+	RoleMappings []GroupRoleMapping `bson:"role_mappings,omitempty" json:"role_mappings,omitempty"`
+	
+	// DefaultRole is given to users in none of the mapped groups; when empty they cannot sign in
+	DefaultRole string `bson:"default_role,omitempty" json:"default_role,omitempty"`
+	
+	// AutoProvision creates accounts for unknown users on their first sign-in
+	AutoProvision bool `bson:"auto_provision" json:"auto_provision"`
+}
+
+// SAMLSettings configures an organization's SAML 2.0 identity provider. The
+// platform acts as the service provider and signs its AuthnRequests; the
+// identity provider must sign its assertions with one of Certificates.
+type SAMLSettings struct {
+	IdPEntityID string `bson:"idp_entity_id" json:"idp_entity_id"`
+	// IdPSSOURL is the identity provider's HTTP-Redirect binding endpoint
+	IdPSSOURL string `bson:"idp_sso_url" json:"idp_sso_url"`
+	// Certificates holds the PEM encoded signing certificates; several are
+	// accepted while the identity provider rolls its key
+	Certificates []string `bson:"certificates" json:"certificates"`
+	
+	// AttributeMapping names the assertion attributes copied onto the user's profile
+	AttributeMapping SAMLAttributeMapping `bson:"attribute_mapping" json:"attribute_mapping"`
+	
+	// GroupsAttribute names the attribute listing the user's groups, "groups" by default
+	GroupsAttribute string             `bson:"groups_attribute,omitempty" json:"groups_attribute,omitempty"`
+	RoleMappings    []GroupRoleMapping `bson:"role_mappings,omitempty" json:"role_mappings,omitempty"`
+	
+	// DefaultRole is given to users in none of the mapped groups; when empty they cannot sign in
+	DefaultRole string `bson:"default_role,omitempty" json:"default_role,omitempty"`
+	
+	// AutoProvision creates accounts for unknown users on their first sign-in
+	AutoProvision bool `bson:"auto_provision" json:"auto_provision"`
+	
+	// AllowIdPInitiated accepts unsolicited responses started at the identity provider
+	AllowIdPInitiated bool `bson:"allow_idp_initiated" json:"allow_idp_initiated"`
+}
+
+// SAMLAttributeMapping names the SAML attributes holding profile fields.
+// Email falls back to the NameID when the attribute is not set or missing.
+type SAMLAttributeMapping struct {
+	Email       string `bson:"email,omitempty" json:"email,omitempty"`
+	FirstName   string `bson:"first_name,omitempty" json:"first_name,omitempty"`
+	LastName    string `bson:"last_name,omitempty" json:"last_name,omitempty"`
+	Title       string `bson:"title,omitempty" json:"title,omitempty"`
+	Department  string `bson:"department,omitempty" json:"department,omitempty"`
+	PhoneNumber string `bson:"phone_number,omitempty" json:"phone_number,omitempty"`
+}
+
+// SCIMSettings configures user and group provisioning by an organization's
+// identity provider through the SCIM 2.0 API. The identity provider
+// authenticates with an organization API key holding the scim scope.
+type SCIMSettings struct {
+	Enabled bool `bson:"enabled" json:"enabled"`
+	
+	// RoleMappings grant roles to members of SCIM groups, matched by display name
+	RoleMappings []GroupRoleMapping `bson:"role_mappings,omitempty" json:"role_mappings,omitempty"`
+	
+	// DefaultRole is given to users in none of the mapped groups, viewer when empty
+	DefaultRole string `bson:"default_role,omitempty" json:"default_role,omitempty"`
+}
+
+// LDAPSettings configures an organization's LDAP or Active Directory server.
+// The service account (BindDN) finds users below BaseDN; users sign in by
+// binding with their own DN and password. Connections to ldap:// servers must
+// use StartTLS unless the platform allows insecure directories.
+type LDAPSettings struct {
+	StartTLS bool `bson:"start_tls" json:"start_tls"`
+	// CACertificate is a PEM encoded CA certificate for directories with a private CA
+	CACertificate string `bson:"ca_certificate,omitempty" json:"ca_certificate,omitempty"`
+	
+	BindDN       string `bson:"bind_dn" json:"bind_dn"`
+	BindPassword string `bson:"bind_password,omitempty" json:"-"`
+	BaseDN       string `bson:"base_dn" json:"base_dn"`
+	
+	// UserFilter selects user entries, "(objectClass=person)" by default
+	UserFilter string `bson:"user_filter,omitempty" json:"user_filter,omitempty"`
+	
+	// AttributeMapping names the directory attributes read into accounts
+	AttributeMapping LDAPAttributeMapping `bson:"attribute_mapping" json:"attribute_mapping"`
+	
+	// RoleMappings grant roles to members of directory groups, matched by DN or common name
+	RoleMappings []GroupRoleMapping `bson:"role_mappings,omitempty" json:"role_mappings,omitempty"`
+	
+	// DefaultRole is given to users in none of the mapped groups; when empty they get no account
+	DefaultRole string `bson:"default_role,omitempty" json:"default_role,omitempty"`
+	
+	// SyncEnabled runs the scheduled directory sync for the organization
+	SyncEnabled bool `bson:"sync_enabled" json:"sync_enabled"`
+}
+
+// LDAPAttributeMapping names the directory attributes holding account fields.
+// Empty names use the Active Directory attribute given in the field comment.
+type LDAPAttributeMapping struct {
+	Email       string `bson:"email,omitempty" json:"email,omitempty"`               // mail
+	FirstName   string `bson:"first_name,omitempty" json:"first_name,omitempty"`     // givenName
+	LastName    string `bson:"last_name,omitempty" json:"last_name,omitempty"`       // sn
+	Title       string `bson:"title,omitempty" json:"title,omitempty"`               // title
+	Department  string `bson:"department,omitempty" json:"department,omitempty"`     // department
+	PhoneNumber string `bson:"phone_number,omitempty" json:"phone_number,omitempty"` // telephoneNumber
+	EmployeeID  string `bson:"employee_id,omitempty" json:"employee_id,omitempty"`   // employeeID
+	Manager     string `bson:"manager,omitempty" json:"manager,omitempty"`           // manager
+	Groups      string `bson:"groups,omitempty" json:"groups,omitempty"`             // memberOf
+}
+
+// GroupRoleMapping grants a platform role to members of an identity provider group.
+type GroupRoleMapping struct {
+	Group string `bson:"group" json:"group"`
+	Role  string `bson:"role" json:"role"`
 }
 
+// MapGroupRoles returns the roles granted to members of the given groups, in
+// mapping order and without duplicates. Groups are compared case-insensitively.
+func MapGroupRoles(mappings []GroupRoleMapping, groups []string) []string {
+	var roles []string
+	for _, mapping := range mappings {
+		for _, group := range groups {
+			if strings.EqualFold(mapping.Group, group) && !slices.Contains(roles, mapping.Role) {
+				roles = append(roles, mapping.Role)
+			}
+		}
+	}
+	return roles
+}
+
+// SSO providers an organization can select
+const (
+	SSOProviderOIDC = "oidc"
+	SSOProviderSAML = "saml"
+)
+
 // OrganizationNotifications manages notification preferences and settings.
 type OrganizationNotifications struct {
 	EmailEnabled     bool   `bson:"email_enabled" json:"email_enabled"`
@@ -230,8 +413,8 @@ type OrganizationNotifications struct {
 	WebEnabled       bool   `bson:"web_enabled" json:"web_enabled"`
 	
 	// Notification frequency and timing
-	DigestFrequency  string `bson:"digest_frequency,omitempty" json:"digest_frequency,omitempty"` // daily, weekly, monthly
-	QuietHoursStart  string `bson:"quiet_hours_start,omitempty" json:"quiet_hours_start,omitempty"`
+	DigestFrequency  string `bson:"digest_frequency,omitempty" json:"digest_frequency,omitempty"` // immediate, daily, weekly, monthly
+	QuietHoursStart  string `bson:"quiet_hours_start,omitempty" json:"quiet_hours_start,omitempty"` // HH:MM in the recipient's timezone
 	QuietHoursEnd    string `bson:"quiet_hours_end,omitempty" json:"quiet_hours_end,omitempty"`
 	
 	// Specific notification types
@@ -280,6 +463,11 @@ type User struct {
 	// Status and lifecycle
 	IsActive bool   `bson:"is_active" json:"is_active"`
 	Status   string `bson:"status" json:"status"` // active, inactive, suspended, locked
+
+	// DeactivatedBy is UserDeactivatedByDirectory when directory sync
+	// deactivated the account; only such accounts are reactivated from the
+	// directory. Deactivation by an administrator or SCIM clears it.
+	DeactivatedBy string `bson:"deactivated_by,omitempty" json:"deactivated_by,omitempty"`
 	
 	// Metadata and preferences - matches DATA_ARCHITECTURE.md metadata section
 	Metadata UserMetadata `bson:"metadata" json:"metadata"`
@@ -316,6 +504,7 @@ type AuthenticationDetails struct {
 	LastPasswordChange   time.Time `bson:"last_password_change,omitempty" json:"last_password_change,omitempty"`
 	PasswordExpiresAt    time.Time `bson:"password_expires_at,omitempty" json:"password_expires_at,omitempty"`
 	RequirePasswordReset bool      `bson:"require_password_reset" json:"require_password_reset"`
+	PasswordHistory      []string  `bson:"password_history,omitempty" json:"-"` // Hashes of previous passwords, newest first
 	
 	// Session tracking
 	LastLoginAt     time.Time `bson:"last_login_at,omitempty" json:"last_login_at,omitempty"`
@@ -334,6 +523,14 @@ type AuthenticationDetails struct {
 	AccountLockedAt     time.Time `bson:"account_locked_at,omitempty" json:"account_locked_at,omitempty"`
 	SecurityQuestions   []SecurityQuestion `bson:"security_questions,omitempty" json:"-"`
 	
+	// Single sign-on identity the account is linked to on its first SSO login,
+	// "<issuer>#<subject>"; later logins must present the same identity
+	SSOSubject string `bson:"sso_subject,omitempty" json:"-"`
+	
+	// Directory entry the account is synced from; such accounts sign in with
+	// their directory password
+	LDAPDN string `bson:"ldap_dn,omitempty" json:"-"`
+	
 	// Compliance and audit
 	LastSecurityReview  time.Time `bson:"last_security_review,omitempty" json:"last_security_review,omitempty"`
 	ComplianceFlags     []string  `bson:"compliance_flags,omitempty" json:"compliance_flags,omitempty"`
@@ -396,6 +593,9 @@ type UserMetadata struct {
 	ManagerID       primitive.ObjectID `bson:"manager_id,omitempty" json:"manager_id,omitempty"`
 	CostCenter      string    `bson:"cost_center,omitempty" json:"cost_center,omitempty"`
 	
+	// ExternalID is the identity provider's identifier of a user provisioned through SCIM
+	ExternalID string `bson:"external_id,omitempty" json:"external_id,omitempty"`
+	
 	// Certification and training
 	Certifications  []UserCertification `bson:"certifications,omitempty" json:"certifications,omitempty"`
 	TrainingRecords []TrainingRecord    `bson:"training_records,omitempty" json:"training_records,omitempty"`
@@ -420,6 +620,25 @@ type UserPreferences struct {
 	DefaultAssignmentView string `bson:"default_assignment_view,omitempty" json:"default_assignment_view,omitempty"`
 	AutoSaveDrafts        bool   `bson:"auto_save_drafts" json:"auto_save_drafts"`
 	ShowAdvancedFeatures  bool   `bson:"show_advanced_features" json:"show_advanced_features"`
+	
+	// Per-user overrides of the organization's notification defaults
+	Notifications NotificationOverrides `bson:"notifications,omitempty" json:"notifications,omitempty"`
+}
+
+// NotificationOverrides holds a user's notification choices. A nil field means
+// the user has not chosen and the organization default applies.
+type NotificationOverrides struct {
+	Email           *bool `bson:"email,omitempty" json:"email,omitempty"`
+	SMS             *bool `bson:"sms,omitempty" json:"sms,omitempty"`
+	InApp           *bool `bson:"in_app,omitempty" json:"in_app,omitempty"`
+	EvidenceRequest *bool `bson:"evidence_request,omitempty" json:"evidence_request,omitempty"`
+	Reminders       *bool `bson:"reminders,omitempty" json:"reminders,omitempty"`
+	SystemAlerts    *bool `bson:"system_alerts,omitempty" json:"system_alerts,omitempty"`
+	
+	// Delivery timing; quiet hours are only overridden as a pair
+	DigestFrequency *string `bson:"digest_frequency,omitempty" json:"digest_frequency,omitempty"`
+	QuietHoursStart *string `bson:"quiet_hours_start,omitempty" json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd   *string `bson:"quiet_hours_end,omitempty" json:"quiet_hours_end,omitempty"`
 }
 
 // UserCertification represents professional certifications held by the user.
@@ -505,6 +724,45 @@ func (u *User) GetPermissionsList() []string {
 	return permissions
 }
 
+// PermissionsFromList converts a list of permission names, as returned by
+// GetPermissionsList, back to UserPermissions. Names without a matching
+// permission flag become custom permissions.
+func PermissionsFromList(permissions []string) UserPermissions {
+	var result UserPermissions
+	for _, permission := range permissions {
+		switch permission {
+		case "controls:read":
+			result.CanViewControls = true
+		case "controls:write":
+			result.CanEditControls = true
+		case "assignments:create":
+			result.CanAssignTests = true
+		case "findings:approve":
+			result.CanApproveFindings = true
+		case "reports:read":
+			result.CanViewReports = true
+		case "users:manage":
+			result.CanManageUsers = true
+		default:
+			if result.CustomPermissions == nil {
+				result.CustomPermissions = make(map[string]bool)
+			}
+			result.CustomPermissions[permission] = true
+		}
+	}
+	return result
+}
+
+// HasRole checks if the user has been granted the given role.
+func (u *User) HasRole(role string) bool {
+	for _, r := range u.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
 // IsLocked checks if the user account is currently locked.
 func (u *User) IsLocked() bool {
 	return !u.Authentication.LockoutUntil.IsZero() && u.Authentication.LockoutUntil.After(time.Now())
@@ -675,8 +933,77 @@ type EvidenceRequest struct {
 	Response string     `bson:"response,omitempty" json:"response,omitempty"`
 	Evidence []Evidence `bson:"evidence,omitempty" json:"evidence,omitempty"`
 	
-	// Communication
-	Comments []Comment `bson:"comments,omitempty" json:"comments,omitempty"`
+	// Lifecycle tracking (reminders, overdue transition and escalation)
+	RemindersSent  int                `bson:"reminders_sent" json:"reminders_sent"`
+	LastReminderAt time.Time          `bson:"last_reminder_at,omitempty" json:"last_reminder_at,omitempty"`
+	OverdueAt      time.Time          `bson:"overdue_at,omitempty" json:"overdue_at,omitempty"`
+	EscalatedAt    time.Time          `bson:"escalated_at,omitempty" json:"escalated_at,omitempty"`
+	EscalatedTo    primitive.ObjectID `bson:"escalated_to,omitempty" json:"escalated_to,omitempty"`
+	
+	// Review and approval
+	ReviewerIDs []primitive.ObjectID `bson:"reviewer_ids,omitempty" json:"reviewer_ids,omitempty"`
+	ReviewRound int                  `bson:"review_round" json:"review_round"`
+	SubmittedBy primitive.ObjectID   `bson:"submitted_by,omitempty" json:"submitted_by,omitempty"`
+	SubmittedAt time.Time            `bson:"submitted_at,omitempty" json:"submitted_at,omitempty"`
+	Reviews     []EvidenceReview     `bson:"reviews,omitempty" json:"reviews,omitempty"`
+	
+	// Concurrency control; incremented on every update so stale updates are refused
+	Version int `bson:"version" json:"version"`
+}
+
+// EvidenceReview records a single reviewer decision on submitted evidence.
+// Reviews are grouped by round; a rejection reopens the request and starts a new round
+// on the next submission, so only approvals of the current round count towards completion.
+type EvidenceReview struct {
+	ID         string             `bson:"id" json:"id"`
+	ReviewerID primitive.ObjectID `bson:"reviewer_id" json:"reviewer_id"`
+	Decision   string             `bson:"decision" json:"decision"` // approved, rejected
+	Comment    string             `bson:"comment" json:"comment"`
+	Round      int                `bson:"round" json:"round"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// IsReviewer reports whether the user is assigned as a reviewer of the request.
+func (r *EvidenceRequest) IsReviewer(userID primitive.ObjectID) bool {
+	for _, id := range r.ReviewerIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// ApprovalsInRound returns the number of distinct reviewers who approved the
+// evidence in the given review round and are still assigned as reviewers.
+func (r *EvidenceRequest) ApprovalsInRound(round int) int {
+	approvers := make(map[primitive.ObjectID]struct{})
+	for _, review := range r.Reviews {
+		if review.Round == round && review.Decision == ReviewDecisionApproved && r.IsReviewer(review.ReviewerID) {
+			approvers[review.ReviewerID] = struct{}{}
+		}
+	}
+	return len(approvers)
+}
+
+// HasReviewedInRound reports whether the user already recorded a decision in the given round.
+func (r *EvidenceRequest) HasReviewedInRound(userID primitive.ObjectID, round int) bool {
+	for _, review := range r.Reviews {
+		if review.Round == round && review.ReviewerID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOpen reports whether the evidence request still awaits a response.
+// Completed and cancelled requests are closed and excluded from lifecycle processing.
+func (r *EvidenceRequest) IsOpen() bool {
+	switch r.Status {
+	case EvidenceRequestStatusCompleted, EvidenceRequestStatusCancelled:
+		return false
+	default:
+		return true
+	}
 }
 
 // Evidence represents uploaded evidence files and metadata.
@@ -697,9 +1024,14 @@ type Evidence struct {
 	// Processing status
 	ProcessingStatus string `bson:"processing_status" json:"processing_status"`
 	TextExtracted    string `bson:"text_extracted,omitempty" json:"text_extracted,omitempty"`
+	
+	// Set when the file was removed under the retention policy; the metadata is kept
+	PurgedAt time.Time `bson:"purged_at,omitempty" json:"purged_at,omitempty"`
 }
 
 // Comment represents a comment on an evidence request or other entity.
+// Comments are stored in the comments collection and identify their
+// organization and target resource.
 type Comment struct {
 	ID        string    `bson:"id" json:"id"`
 	AuthorID  string    `bson:"author_id" json:"author_id"`
@@ -707,11 +1039,36 @@ type Comment struct {
 	CreatedAt time.Time `bson:"created_at" json:"created_at"`
 	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
 	
+	// Target resource
+	OrganizationID primitive.ObjectID `bson:"organization_id,omitempty" json:"organization_id,omitempty"`
+	ResourceType   string             `bson:"resource_type,omitempty" json:"resource_type,omitempty"`
+	ResourceID     string             `bson:"resource_id,omitempty" json:"resource_id,omitempty"`
+	
 	// Threading
 	ParentID string `bson:"parent_id,omitempty" json:"parent_id,omitempty"`
 	
-	// Status
-	Status string `bson:"status" json:"status"`
+	// Mentioned user IDs parsed from the content
+	Mentions []string `bson:"mentions,omitempty" json:"mentions,omitempty"`
+	
+	// Edit history, oldest revision first
+	EditHistory []CommentRevision `bson:"edit_history,omitempty" json:"edit_history,omitempty"`
+	
+	// Status and soft delete
+	Status    string    `bson:"status" json:"status"`
+	DeletedAt time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	DeletedBy string    `bson:"deleted_by,omitempty" json:"deleted_by,omitempty"`
+}
+
+// CommentRevision preserves the content of a comment before an edit.
+type CommentRevision struct {
+	Content  string    `bson:"content" json:"content"`
+	EditedAt time.Time `bson:"edited_at" json:"edited_at"`
+	EditedBy string    `bson:"edited_by" json:"edited_by"`
+}
+
+// IsDeleted reports whether the comment has been soft deleted.
+func (c *Comment) IsDeleted() bool {
+	return c.Status == CommentStatusDeleted
 }
 
 // AuditLog represents an audit trail entry for compliance tracking.
@@ -741,6 +1098,461 @@ type AuditLog struct {
 	// Result
 	Success     bool   `bson:"success" json:"success"`
 	ErrorMessage string `bson:"error_message,omitempty" json:"error_message,omitempty"`
+	
+	// Hash chain, one chain per organization starting at sequence 1
+	Sequence     int64  `bson:"sequence,omitempty" json:"sequence,omitempty"`
+	PreviousHash string `bson:"previous_hash,omitempty" json:"previous_hash,omitempty"`
+	Hash         string `bson:"hash,omitempty" json:"hash,omitempty"`
+}
+
+// ChainFields returns the entry fields covered by its chain hash. The sequence
+// and previous hash are hashed separately as the chain link.
+func (a *AuditLog) ChainFields() map[string]interface{} {
+	return map[string]interface{}{
+		"id":              a.ID,
+		"timestamp":       a.Timestamp,
+		"correlation_id":  a.CorrelationID,
+		"organization_id": a.OrganizationID,
+		"user_id":         a.UserID,
+		"action":          a.Action,
+		"resource_type":   a.ResourceType,
+		"resource_id":     a.ResourceID,
+		"old_values":      a.OldValues,
+		"new_values":      a.NewValues,
+		"ip_address":      a.IPAddress,
+		"user_agent":      a.UserAgent,
+		"metadata":        a.Metadata,
+		"success":         a.Success,
+		"error_message":   a.ErrorMessage,
+	}
+}
+
+// AuditCheckpoint is a signed snapshot of an organization's audit chain head.
+// Checkpoints are exported to an external notary so that truncating or
+// rewriting the chain after a checkpoint can be detected.
+type AuditCheckpoint struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OrganizationID primitive.ObjectID `bson:"organization_id" json:"organization_id"`
+	Sequence       int64              `bson:"sequence" json:"sequence"`
+	Hash           string             `bson:"hash" json:"hash"`
+	KeyID          string             `bson:"key_id" json:"key_id"`
+	Signature      string             `bson:"signature" json:"signature"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// ExportManifest describes a generated export file. The digest covers the exact
+// bytes of the file, and the signature covers the digest, so a recipient can
+// prove the file was produced by the platform and has not been altered.
+type ExportManifest struct {
+	Format         string    `bson:"format" json:"format"`
+	OrganizationID string    `bson:"organization_id" json:"organization_id"`
+	RowCount       int64     `bson:"row_count" json:"row_count"`
+	RangeStart     time.Time `bson:"range_start,omitempty" json:"range_start,omitempty"`
+	RangeEnd       time.Time `bson:"range_end,omitempty" json:"range_end,omitempty"`
+	FirstEntryAt   time.Time `bson:"first_entry_at,omitempty" json:"first_entry_at,omitempty"`
+	LastEntryAt    time.Time `bson:"last_entry_at,omitempty" json:"last_entry_at,omitempty"`
+	SizeBytes      int64     `bson:"size_bytes" json:"size_bytes"`
+	SHA256         string    `bson:"sha256" json:"sha256"`
+	GeneratedAt    time.Time `bson:"generated_at" json:"generated_at"`
+	GeneratedBy    string    `bson:"generated_by" json:"generated_by"`
+	KeyID          string    `bson:"key_id,omitempty" json:"key_id,omitempty"`
+	Signature      string    `bson:"signature,omitempty" json:"signature,omitempty"`
+}
+
+// ExportJob tracks a background export. Once completed, the file can be
+// downloaded with the job's download token until the link expires.
+type ExportJob struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OrganizationID primitive.ObjectID `bson:"organization_id" json:"organization_id"`
+	RequestedBy    primitive.ObjectID `bson:"requested_by" json:"requested_by"`
+	Kind           string             `bson:"kind" json:"kind"`
+	Format         string             `bson:"format" json:"format"`
+	
+	// Export parameters, e.g. the audit filter fields
+	Parameters map[string]string `bson:"parameters,omitempty" json:"parameters,omitempty"`
+	
+	// Processing state
+	Status      string    `bson:"status" json:"status"`
+	Error       string    `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+	StartedAt   time.Time `bson:"started_at,omitempty" json:"started_at,omitempty"`
+	CompletedAt time.Time `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	
+	// Result
+	FileKey       string          `bson:"file_key,omitempty" json:"-"`
+	Manifest      *ExportManifest `bson:"manifest,omitempty" json:"manifest,omitempty"`
+	DownloadToken string          `bson:"download_token,omitempty" json:"-"`
+	ExpiresAt     time.Time       `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+}
+
+// IsDownloadable reports whether the export file can be downloaded at the given time.
+func (j *ExportJob) IsDownloadable(now time.Time) bool {
+	return j.Status == ExportStatusCompleted && now.Before(j.ExpiresAt)
+}
+
+// LegalHold suspends retention purging. A hold covers the whole organization,
+// a testing cycle (including its evidence requests) or a single resource, and
+// stays in force until it is released.
+type LegalHold struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OrganizationID primitive.ObjectID `bson:"organization_id" json:"organization_id"`
+	
+	// Scope of the hold; ResourceType is only set for resource holds
+	Scope        string `bson:"scope" json:"scope"` // organization, testing_cycle, resource
+	ResourceType string `bson:"resource_type,omitempty" json:"resource_type,omitempty"`
+	ResourceID   string `bson:"resource_id,omitempty" json:"resource_id,omitempty"`
+	
+	// Justification, e.g. the litigation or examination reference
+	Reason string `bson:"reason" json:"reason"`
+	
+	CreatedBy  primitive.ObjectID `bson:"created_by" json:"created_by"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	ReleasedBy primitive.ObjectID `bson:"released_by,omitempty" json:"released_by,omitempty"`
+	ReleasedAt time.Time          `bson:"released_at,omitempty" json:"released_at,omitempty"`
+}
+
+// IsActive reports whether the hold is still in force.
+func (h *LegalHold) IsActive() bool {
+	return h.ReleasedAt.IsZero()
+}
+
+// Covers reports whether the hold applies to a resource. The cycle ID is the
+// testing cycle the resource belongs to, if any.
+func (h *LegalHold) Covers(resourceType, resourceID, cycleID string) bool {
+	switch h.Scope {
+	case LegalHoldScopeOrganization:
+		return true
+	case LegalHoldScopeTestingCycle:
+		return h.ResourceID == cycleID || (resourceType == ResourceTypeTestingCycle && h.ResourceID == resourceID)
+	case LegalHoldScopeResource:
+		return h.ResourceType == resourceType && h.ResourceID == resourceID
+	default:
+		return false
+	}
+}
+
+// NotificationTemplate overrides the built-in email template of a notification
+// type for one organization. Empty parts fall back to the built-in template.
+type NotificationTemplate struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OrganizationID primitive.ObjectID `bson:"organization_id" json:"organization_id"`
+	Type           string             `bson:"type" json:"type"`
+	
+	// Go templates; the HTML part is rendered with html/template escaping
+	Subject string `bson:"subject,omitempty" json:"subject,omitempty"`
+	Text    string `bson:"text,omitempty" json:"text,omitempty"`
+	HTML    string `bson:"html,omitempty" json:"html,omitempty"`
+	
+	UpdatedBy primitive.ObjectID `bson:"updated_by" json:"updated_by"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// OutboxMessage is a rendered notification email waiting for delivery. Messages
+// are written to the outbox before sending so that they survive restarts; the
+// dispatcher leases due messages, sends them and retries failures with backoff.
+type OutboxMessage struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OrganizationID primitive.ObjectID `bson:"organization_id,omitempty" json:"organization_id,omitempty"`
+	UserID         primitive.ObjectID `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	Type           string             `bson:"type" json:"type"`
+	
+	// Rendered message
+	Recipient string `bson:"recipient" json:"recipient"`
+	Subject   string `bson:"subject" json:"subject"`
+	Text      string `bson:"text" json:"text"`
+	HTML      string `bson:"html,omitempty" json:"html,omitempty"`
+	
+	// Delivery state
+	Status        string    `bson:"status" json:"status"` // pending, sending, sent, failed
+	Attempts      int       `bson:"attempts" json:"attempts"`
+	NextAttemptAt time.Time `bson:"next_attempt_at" json:"next_attempt_at"`
+	LockedUntil   time.Time `bson:"locked_until,omitempty" json:"locked_until,omitempty"`
+	LastError     string    `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	SentAt    time.Time `bson:"sent_at,omitempty" json:"sent_at,omitempty"`
+}
+
+// NotificationDigestItem is a non-urgent notification held back for a user's
+// next digest email. Items are collected per user once they are due and
+// replaced by a single digest message in the outbox.
+type NotificationDigestItem struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OrganizationID primitive.ObjectID `bson:"organization_id" json:"organization_id"`
+	UserID         primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Type           string             `bson:"type" json:"type"`
+	
+	// Summary line and link shown in the digest
+	Subject string `bson:"subject" json:"subject"`
+	Link    string `bson:"link" json:"link"`
+	
+	DueAt       time.Time `bson:"due_at" json:"due_at"`
+	LockedUntil time.Time `bson:"locked_until,omitempty" json:"locked_until,omitempty"`
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+}
+
+// InAppNotification is a notification in a user's in-app inbox. Inbox entries
+// are written alongside notification emails for users who receive in-app
+// notifications and are pushed live to the user's open sessions.
+type InAppNotification struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OrganizationID primitive.ObjectID `bson:"organization_id" json:"organization_id"`
+	UserID         primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Type           string             `bson:"type" json:"type"`
+	
+	// Summary line and link to the resource the notification is about
+	Title string `bson:"title" json:"title"`
+	Link  string `bson:"link,omitempty" json:"link,omitempty"`
+	
+	// ReadAt is zero while the notification is unread
+	ReadAt    time.Time `bson:"read_at,omitempty" json:"read_at,omitempty"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+// IsRead reports whether the user has read the notification.
+func (n *InAppNotification) IsRead() bool {
+	return !n.ReadAt.IsZero()
+}
+
+// WebhookSubscription sends an organization's domain events of the subscribed
+// types to an external HTTPS endpoint. The signing secret is derived from the
+// server's webhook secret and SecretVersion, so it is never stored.
+type WebhookSubscription struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OrganizationID primitive.ObjectID `bson:"organization_id" json:"organization_id"`
+	
+	URL         string   `bson:"url" json:"url"`
+	Description string   `bson:"description,omitempty" json:"description,omitempty"`
+	Events      []string `bson:"events" json:"events"`
+	
+	// Subscriptions are disabled by admins or after too many failed deliveries
+	IsActive            bool      `bson:"is_active" json:"is_active"`
+	ConsecutiveFailures int       `bson:"consecutive_failures" json:"consecutive_failures"`
+	DisabledAt          time.Time `bson:"disabled_at,omitempty" json:"disabled_at,omitempty"`
+	DisabledReason      string    `bson:"disabled_reason,omitempty" json:"disabled_reason,omitempty"`
+	
+	SecretVersion int                `bson:"secret_version" json:"secret_version"`
+	CreatedBy     primitive.ObjectID `bson:"created_by" json:"created_by"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// Subscribes reports whether the subscription receives events of the given type.
+func (w *WebhookSubscription) Subscribes(eventType string) bool {
+	for _, event := range w.Events {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event sent to one subscription. Deliveries are written
+// before sending so that they survive restarts; the dispatcher leases due
+// deliveries, sends them and retries failures with backoff. Every attempt is
+// kept as the delivery log.
+type WebhookDelivery struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OrganizationID primitive.ObjectID `bson:"organization_id" json:"organization_id"`
+	SubscriptionID primitive.ObjectID `bson:"subscription_id" json:"subscription_id"`
+	
+	// EventID is shared by all deliveries and redeliveries of one event, so
+	// receivers can drop duplicates
+	EventID   string `bson:"event_id" json:"event_id"`
+	EventType string `bson:"event_type" json:"event_type"`
+	Payload   string `bson:"payload" json:"payload"`
+	
+	// RedeliveryOf is set for deliveries created by a manual redelivery
+	RedeliveryOf primitive.ObjectID `bson:"redelivery_of,omitempty" json:"redelivery_of,omitempty"`
+	
+	// Delivery state
+	Status        string           `bson:"status" json:"status"` // pending, delivering, succeeded, failed
+	Attempts      []WebhookAttempt `bson:"attempts" json:"attempts"`
+	NextAttemptAt time.Time        `bson:"next_attempt_at" json:"next_attempt_at"`
+	LockedUntil   time.Time        `bson:"locked_until,omitempty" json:"locked_until,omitempty"`
+	LastError     string           `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+	DeliveredAt time.Time `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`
+}
+
+// WebhookAttempt records one attempt to send a webhook delivery.
+type WebhookAttempt struct {
+	AttemptedAt  time.Time `bson:"attempted_at" json:"attempted_at"`
+	StatusCode   int       `bson:"status_code,omitempty" json:"status_code,omitempty"`
+	ResponseBody string    `bson:"response_body,omitempty" json:"response_body,omitempty"`
+	Error        string    `bson:"error,omitempty" json:"error,omitempty"`
+	DurationMS   int64     `bson:"duration_ms" json:"duration_ms"`
+}
+
+// DomainEvent is a domain event in the transactional outbox. It is written in
+// the same transaction as the change that raised it, then relayed to the event
+// stream, so an event is published if and only if its change was committed.
+type DomainEvent struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	EventID        string             `bson:"event_id" json:"event_id"`
+	Type           string             `bson:"type" json:"type"`
+	OrganizationID string             `bson:"organization_id,omitempty" json:"organization_id,omitempty"`
+	
+	// Payload is the JSON encoded event as published to the stream
+	Payload string `bson:"payload" json:"payload"`
+	
+	// Relay state
+	Status        string    `bson:"status" json:"status"` // pending, relaying, relayed
+	Attempts      int       `bson:"attempts" json:"attempts"`
+	NextAttemptAt time.Time `bson:"next_attempt_at" json:"next_attempt_at"`
+	LockedUntil   time.Time `bson:"locked_until,omitempty" json:"locked_until,omitempty"`
+	LastError     string    `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	RelayedAt time.Time `bson:"relayed_at,omitempty" json:"relayed_at,omitempty"`
+}
+
+// ScheduledJob is the shared state of a job run by the scheduler: its
+// schedule, whether it is paused and the outcome of its last run. NextRunAt is
+// advanced by the instance that claims a scheduled run, so every instance
+// agrees on when the job is due. The lease fields are only used by the MongoDB
+// lock backend.
+type ScheduledJob struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name        string             `bson:"name" json:"name"`
+	Description string             `bson:"description" json:"description"`
+	Schedule    string             `bson:"schedule" json:"schedule"`
+	
+	// Pausing stops scheduled runs; manual triggers still run
+	Paused   bool      `bson:"paused" json:"paused"`
+	PausedBy string    `bson:"paused_by,omitempty" json:"paused_by,omitempty"`
+	PausedAt time.Time `bson:"paused_at,omitempty" json:"paused_at,omitempty"`
+	
+	NextRunAt time.Time `bson:"next_run_at" json:"next_run_at"`
+	
+	// Last run
+	LastRunAt      time.Time `bson:"last_run_at,omitempty" json:"last_run_at,omitempty"`
+	LastStatus     string    `bson:"last_status,omitempty" json:"last_status,omitempty"` // running, succeeded, failed
+	LastError      string    `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	LastDurationMS int64     `bson:"last_duration_ms,omitempty" json:"last_duration_ms,omitempty"`
+	
+	// MongoDB lease
+	LeaseOwner string    `bson:"lease_owner,omitempty" json:"-"`
+	LeaseUntil time.Time `bson:"lease_until,omitempty" json:"-"`
+	
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// JobRun is one run of a scheduled job, kept as the job's run history.
+// ExpiresAt drives a TTL index that removes old runs.
+type JobRun struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	JobName     string             `bson:"job_name" json:"job_name"`
+	Trigger     string             `bson:"trigger" json:"trigger"` // schedule, manual
+	TriggeredBy string             `bson:"triggered_by,omitempty" json:"triggered_by,omitempty"`
+	Instance    string             `bson:"instance" json:"instance"`
+	
+	Status     string    `bson:"status" json:"status"` // running, succeeded, failed
+	Error      string    `bson:"error,omitempty" json:"error,omitempty"`
+	StartedAt  time.Time `bson:"started_at" json:"started_at"`
+	FinishedAt time.Time `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+	DurationMS int64     `bson:"duration_ms" json:"duration_ms"`
+	
+	ExpiresAt time.Time `bson:"expires_at" json:"-"`
+}
+
+// APIKey is a credential an organization issues for machine-to-machine access.
+// Only the SHA-256 hash of the key is stored; the key itself is shown once when
+// it is created or rotated. Prefix is the public part of the key and is used to
+// look it up and to recognize it in listings.
+type APIKey struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OrganizationID primitive.ObjectID `bson:"organization_id" json:"organization_id"`
+	
+	Name    string `bson:"name" json:"name"`
+	Prefix  string `bson:"prefix" json:"prefix"`
+	KeyHash string `bson:"key_hash" json:"-"`
+	
+	// Role and scopes limit what the key may do; scopes are
+	// "<resource>:<read|write>" with "*" matching every resource
+	Role   string   `bson:"role" json:"role"`
+	Scopes []string `bson:"scopes" json:"scopes"`
+	
+	ExpiresAt  time.Time `bson:"expires_at" json:"expires_at"`
+	LastUsedAt time.Time `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+	LastUsedIP string    `bson:"last_used_ip,omitempty" json:"last_used_ip,omitempty"`
+	
+	// A rotated key stays valid until ExpiresAt, shortened to the overlap
+	// period, and points to the key that replaced it
+	RotatedTo primitive.ObjectID `bson:"rotated_to,omitempty" json:"rotated_to,omitempty"`
+	RevokedAt time.Time          `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	RevokedBy primitive.ObjectID `bson:"revoked_by,omitempty" json:"revoked_by,omitempty"`
+	
+	CreatedBy primitive.ObjectID `bson:"created_by" json:"created_by"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// IsActive reports whether the key can authenticate requests at the given time.
+func (k *APIKey) IsActive(now time.Time) bool {
+	return k.RevokedAt.IsZero() && now.Before(k.ExpiresAt)
+}
+
+// Allows reports whether the key's scopes permit access to a resource. Read
+// access is implied by write access.
+func (k *APIKey) Allows(resource, access string) bool {
+	for _, scope := range k.Scopes {
+		scopeResource, scopeAccess, ok := strings.Cut(scope, ":")
+		if !ok || (scopeResource != APIKeyScopeAll && scopeResource != resource) {
+			continue
+		}
+		if scopeAccess == APIKeyAccessWrite || scopeAccess == access {
+			return true
+		}
+	}
+	return false
+}
+
+// SCIMGroup is a group provisioned by an organization's identity provider
+// through SCIM. Membership grants roles through the organization's SCIM role
+// mappings; display names are unique per organization, ignoring case.
+type SCIMGroup struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OrganizationID primitive.ObjectID `bson:"organization_id" json:"organization_id"`
+	
+	DisplayName string `bson:"display_name" json:"display_name"`
+	ExternalID  string `bson:"external_id,omitempty" json:"external_id,omitempty"`
+	
+	// Members holds the IDs of the member users
+	Members []primitive.ObjectID `bson:"members" json:"members"`
+	
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// Invitation invites someone to join an organization by email. The link sent
+// carries a token signed over the invitation's ID and expiry, so extending the
+// expiry on resend invalidates earlier links. Only one invitation per email
+// may be pending in an organization.
+type Invitation struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OrganizationID primitive.ObjectID `bson:"organization_id" json:"organization_id"`
+	
+	Email string `bson:"email" json:"email"`
+	Role  string `bson:"role" json:"role"`
+	
+	Status    string    `bson:"status" json:"status"`
+	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
+	
+	AcceptedAt time.Time          `bson:"accepted_at,omitempty" json:"accepted_at,omitempty"`
+	AcceptedBy primitive.ObjectID `bson:"accepted_by,omitempty" json:"accepted_by,omitempty"`
+	RevokedAt  time.Time          `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	RevokedBy  primitive.ObjectID `bson:"revoked_by,omitempty" json:"revoked_by,omitempty"`
+	
+	InvitedBy primitive.ObjectID `bson:"invited_by" json:"invited_by"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// IsPending reports whether the invitation can still be accepted at the given time.
+func (i *Invitation) IsPending(now time.Time) bool {
+	return i.Status == InvitationStatusPending && now.Before(i.ExpiresAt)
 }
 
 // Common status constants
@@ -749,6 +1561,9 @@ const (
 	UserStatusActive    = "active"
 	UserStatusInactive  = "inactive"
 	UserStatusSuspended = "suspended"
+
+	// UserDeactivatedByDirectory marks accounts deactivated by directory sync
+	UserDeactivatedByDirectory = "directory"
 	
 	// Organization statuses
 	OrganizationStatusActive    = "active"
@@ -783,10 +1598,116 @@ const (
 	// Evidence request statuses
 	EvidenceRequestStatusPending    = "pending"
 	EvidenceRequestStatusInProgress = "in_progress"
+	EvidenceRequestStatusUnderReview = "under_review"
 	EvidenceRequestStatusCompleted  = "completed"
 	EvidenceRequestStatusOverdue    = "overdue"
 	EvidenceRequestStatusCancelled  = "cancelled"
 	
+	// Evidence review decisions
+	ReviewDecisionApproved = "approved"
+	ReviewDecisionRejected = "rejected"
+	
+	// Comment statuses
+	CommentStatusActive  = "active"
+	CommentStatusDeleted = "deleted"
+	
+	// Commentable resource types
+	ResourceTypeEvidenceRequest = "evidence_request"
+	ResourceTypeControl         = "control"
+	ResourceTypeFinding         = "finding"
+	ResourceTypeTestingCycle    = "testing_cycle"
+	
+	// SystemActorID identifies comments and audit entries created by background jobs
+	SystemActorID = "system"
+	
+	// Export job statuses
+	ExportStatusPending   = "pending"
+	ExportStatusRunning   = "running"
+	ExportStatusCompleted = "completed"
+	ExportStatusFailed    = "failed"
+	
+	// Export formats
+	ExportFormatCSV   = "csv"
+	ExportFormatJSONL = "jsonl"
+	ExportFormatPDF   = "pdf"
+	
+	// Export kinds
+	ExportKindAuditLog = "audit_log"
+	
+	// Legal hold scopes
+	LegalHoldScopeOrganization = "organization"
+	LegalHoldScopeTestingCycle = "testing_cycle"
+	LegalHoldScopeResource     = "resource"
+	
+	// Notification types, each with its own email template
+	NotificationTypeEvidenceRequest = "evidence_request"
+	NotificationTypeReminder        = "evidence_reminder"
+	NotificationTypeEscalation      = "evidence_escalation"
+	NotificationTypeReviewRequested = "review_requested"
+	NotificationTypeReviewApproved  = "review_approved"
+	NotificationTypeReviewRejected  = "review_rejected"
+	NotificationTypeMention         = "comment_mention"
+	NotificationTypeTestingCycle    = "testing_cycle"
+	NotificationTypeSystemAlert     = "system_alert"
+	NotificationTypeDigest          = "notification_digest"
+	NotificationTypeInvitation      = "user_invitation"
+	NotificationTypePasswordReset   = "password_reset"
+	
+	// Notification digest frequencies
+	DigestFrequencyImmediate = "immediate"
+	DigestFrequencyDaily     = "daily"
+	DigestFrequencyWeekly    = "weekly"
+	DigestFrequencyMonthly   = "monthly"
+	
+	// System alert severities
+	AlertSeverityInfo     = "info"
+	AlertSeverityWarning  = "warning"
+	AlertSeverityCritical = "critical"
+	
+	// Webhook event types
+	WebhookEventControlChanged   = "control.changed"
+	WebhookEventEvidenceUploaded = "evidence.uploaded"
+	WebhookEventCycleCompleted   = "cycle.completed"
+	WebhookEventFindingApproved  = "finding.approved"
+	
+	// Webhook delivery statuses
+	WebhookDeliveryPending    = "pending"
+	WebhookDeliveryDelivering = "delivering"
+	WebhookDeliverySucceeded  = "succeeded"
+	WebhookDeliveryFailed     = "failed"
+	
+	// Scheduled job run triggers and statuses
+	JobTriggerSchedule = "schedule"
+	JobTriggerManual   = "manual"
+	JobRunRunning      = "running"
+	JobRunSucceeded    = "succeeded"
+	JobRunFailed       = "failed"
+	
+	// Domain event outbox statuses
+	DomainEventPending  = "pending"
+	DomainEventRelaying = "relaying"
+	DomainEventRelayed  = "relayed"
+	
+	// Live inbox event types
+	InboxEventNotification  = "notification"
+	InboxEventCycleProgress = "cycle_progress"
+	
+	// Outbox message statuses
+	OutboxStatusPending = "pending"
+	OutboxStatusSending = "sending"
+	OutboxStatusSent    = "sent"
+	OutboxStatusFailed  = "failed"
+	
+	// Invitation statuses; a pending invitation past its expiry is expired
+	InvitationStatusPending  = "pending"
+	InvitationStatusAccepted = "accepted"
+	InvitationStatusRevoked  = "revoked"
+	
+	// API key scope access levels; APIKeyScopeAll matches every resource
+	APIKeyAccessRead  = "read"
+	APIKeyAccessWrite = "write"
+	APIKeyScopeAll    = "*"
+	
 	// Common roles
 	RoleAdmin     = "admin"
 	RoleManager   = "manager"
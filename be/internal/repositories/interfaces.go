@@ -19,6 +19,10 @@ type Repository[T any] interface {
 	// GetByID retrieves an entity by its ID
 	GetByID(ctx context.Context, id string) (*T, error)
 	
+	// GetByIDs retrieves the entities with the given IDs in a single query.
+	// IDs that do not exist are skipped; the result order is unspecified.
+	GetByIDs(ctx context.Context, ids []string) ([]*T, error)
+	
 	// Update updates an existing entity in the database
 	Update(ctx context.Context, entity *T) error
 	
@@ -51,6 +55,14 @@ type OrganizationRepository interface {
 	
 	// UpdateFeatureFlag updates a specific feature flag
 	UpdateFeatureFlag(ctx context.Context, orgID, flag string, enabled bool) error
+	
+	// AdjustMemberCount atomically adds delta to the member count, never going
+	// below zero. An increase that would exceed a non-zero MaxMembers is not
+	// applied and reports false.
+	AdjustMemberCount(ctx context.Context, orgID string, delta int) (bool, error)
+	
+	// SetMemberCount overwrites the member count, e.g. after recounting members
+	SetMemberCount(ctx context.Context, orgID string, count int) error
 }
 
 // UserRepository handles data access for user accounts.
@@ -84,6 +96,9 @@ type UserRepository interface {
 	
 	// UpdatePreferences updates user preferences
 	UpdatePreferences(ctx context.Context, userID string, preferences map[string]interface{}) error
+	
+	// UpdateSessionCount records the number of active sessions of a user
+	UpdateSessionCount(ctx context.Context, userID string, count int) error
 }
 
 // ControlRepository handles data access for compliance controls.
@@ -97,6 +112,10 @@ type ControlRepository interface {
 	// GetByOrganization retrieves controls for a specific organization
 	GetByOrganization(ctx context.Context, orgID string, filter *ControlFilter) ([]*models.Control, error)
 	
+	// CountByOrganization returns the number of controls of an organization
+	// matching the filter, ignoring its pagination
+	CountByOrganization(ctx context.Context, orgID string, filter *ControlFilter) (int64, error)
+	
 	// GetByFramework retrieves controls for a specific compliance framework
 	GetByFramework(ctx context.Context, orgID, framework string) ([]*models.Control, error)
 	
@@ -130,6 +149,9 @@ type TestingCycleRepository interface {
 	// GetByOrganization retrieves testing cycles for an organization
 	GetByOrganization(ctx context.Context, orgID string, limit, offset int) ([]*models.TestingCycle, error)
 	
+	// CountByOrganization returns the number of testing cycles of an organization
+	CountByOrganization(ctx context.Context, orgID string) (int64, error)
+	
 	// GetActiveByOrganization retrieves active testing cycles
 	GetActiveByOrganization(ctx context.Context, orgID string) ([]*models.TestingCycle, error)
 	
@@ -151,6 +173,14 @@ type EvidenceRequestRepository interface {
 	// GetByRequestID retrieves an evidence request by its business request ID
 	GetByRequestID(ctx context.Context, orgID, requestID string) (*models.EvidenceRequest, error)
 	
+	// GetByOrganization retrieves evidence requests of an organization matching the
+	// filter, ordered by due date
+	GetByOrganization(ctx context.Context, orgID string, filter *EvidenceRequestFilter) ([]*models.EvidenceRequest, error)
+	
+	// CountByOrganization returns the number of evidence requests of an organization
+	// matching the filter, ignoring its pagination
+	CountByOrganization(ctx context.Context, orgID string, filter *EvidenceRequestFilter) (int64, error)
+	
 	// GetByAssignee retrieves evidence requests assigned to a user
 	GetByAssignee(ctx context.Context, assigneeID string, status string) ([]*models.EvidenceRequest, error)
 	
@@ -163,6 +193,15 @@ type EvidenceRequestRepository interface {
 	// GetOverdueRequests retrieves overdue evidence requests
 	GetOverdueRequests(ctx context.Context, orgID string) ([]*models.EvidenceRequest, error)
 	
+	// UpdateIfVersion replaces the stored request if its version still equals
+	// version and reports whether it did. Callers increment request.Version
+	// before calling it.
+	UpdateIfVersion(ctx context.Context, request *models.EvidenceRequest, version int) (bool, error)
+	
+	// GetOpenDueBefore retrieves open (not completed or cancelled) evidence requests
+	// of an organization whose due date is before the given time
+	GetOpenDueBefore(ctx context.Context, orgID string, before time.Time) ([]*models.EvidenceRequest, error)
+	
 	// GetPendingRequests retrieves pending evidence requests for an organization
 	GetPendingRequests(ctx context.Context, orgID string, limit, offset int) ([]*models.EvidenceRequest, error)
 	
@@ -172,19 +211,62 @@ type EvidenceRequestRepository interface {
 	// AddEvidence adds evidence to an evidence request
 	AddEvidence(ctx context.Context, requestID string, evidence *models.Evidence) error
 	
-	// AddComment adds a comment to an evidence request
-	AddComment(ctx context.Context, requestID string, comment *models.Comment) error
-	
 	// GetRequestStats returns statistics about evidence requests
 	GetRequestStats(ctx context.Context, orgID string) (*EvidenceRequestStats, error)
+	
+	// GetClosedWithEvidence retrieves completed or cancelled requests last updated before
+	// the given time that still hold unpurged evidence files, ordered by ID and starting
+	// after afterID (empty for the first page)
+	GetClosedWithEvidence(ctx context.Context, orgID string, before time.Time, afterID string, limit int) ([]*models.EvidenceRequest, error)
+	
+	// MarkEvidencePurged records that the files of the given evidence items were removed
+	MarkEvidencePurged(ctx context.Context, requestID string, evidenceIDs []string, purgedAt time.Time) error
+}
+
+// CommentRepository handles data access for the comments collection.
+// Comments are soft deleted, so there is no Delete method; deletion is an Update.
+type CommentRepository interface {
+	// Create inserts a new comment
+	Create(ctx context.Context, comment *models.Comment) error
+	
+	// GetByID retrieves a comment by its ID
+	GetByID(ctx context.Context, id string) (*models.Comment, error)
+	
+	// Update replaces an existing comment
+	Update(ctx context.Context, comment *models.Comment) error
+	
+	// GetByResource retrieves all comments, including deleted ones, on a resource ordered by creation time
+	GetByResource(ctx context.Context, orgID, resourceType, resourceID string) ([]*models.Comment, error)
+	
+	// GetDeletedBefore retrieves comments soft deleted before the given time, ordered by
+	// ID and starting after afterID (empty for the first page)
+	GetDeletedBefore(ctx context.Context, orgID string, before time.Time, afterID string, limit int) ([]*models.Comment, error)
+	
+	// DeleteMany permanently removes comments and returns how many were removed
+	DeleteMany(ctx context.Context, ids []string) (int64, error)
 }
 
 // AuditLogRepository handles data access for audit trail entries.
 // It provides audit logging and compliance tracking capabilities.
 type AuditLogRepository interface {
-	// Create inserts a new audit log entry
+	// Create inserts a new audit log entry. It returns ErrDuplicate when another
+	// entry already holds the same organization and chain sequence.
 	Create(ctx context.Context, entry *models.AuditLog) error
 	
+	// GetChainHead retrieves the entry with the highest sequence of an organization,
+	// or ErrNotFound when the organization has no chained entries
+	GetChainHead(ctx context.Context, orgID string) (*models.AuditLog, error)
+	
+	// IterateChain calls fn for every chained entry of an organization with a sequence
+	// of at least fromSequence, in ascending sequence order. Iteration stops at the
+	// first error returned by fn.
+	IterateChain(ctx context.Context, orgID string, fromSequence int64, fn func(*models.AuditLog) error) error
+	
+	// Stream calls fn for every entry of an organization matching the filter, ignoring
+	// the filter's pagination, ordered by the filter's sort settings. Iteration stops at
+	// the first error returned by fn.
+	Stream(ctx context.Context, orgID string, filter *AuditFilter, fn func(*models.AuditLog) error) error
+	
 	// GetByUser retrieves audit logs for a specific user
 	GetByUser(ctx context.Context, userID string, limit, offset int) ([]*models.AuditLog, error)
 	
@@ -209,8 +291,377 @@ type AuditLogRepository interface {
 	// GetAuditStats returns audit statistics for an organization
 	GetAuditStats(ctx context.Context, orgID string, timeRange *TimeRange) (*AuditStats, error)
 	
-	// Purge removes old audit logs based on retention policy
-	Purge(ctx context.Context, retentionDays int) (int64, error)
+	// Purge permanently removes an organization's chained entries with a sequence up to
+	// and including throughSequence. Callers must only purge up to a checkpointed
+	// sequence so that the remaining chain stays verifiable.
+	Purge(ctx context.Context, orgID string, throughSequence int64) (int64, error)
+}
+
+// AuditCheckpointRepository handles data access for signed audit chain checkpoints.
+// Checkpoints are append-only.
+type AuditCheckpointRepository interface {
+	// Create inserts a new checkpoint
+	Create(ctx context.Context, checkpoint *models.AuditCheckpoint) error
+	
+	// GetLatest retrieves the checkpoint with the highest sequence, or ErrNotFound
+	GetLatest(ctx context.Context, orgID string) (*models.AuditCheckpoint, error)
+	
+	// GetByOrganization retrieves all checkpoints of an organization ordered by sequence
+	GetByOrganization(ctx context.Context, orgID string) ([]*models.AuditCheckpoint, error)
+}
+
+// ExportJobRepository handles data access for background export jobs.
+type ExportJobRepository interface {
+	// Create inserts a new export job
+	Create(ctx context.Context, job *models.ExportJob) error
+	
+	// GetByID retrieves an export job by its ID
+	GetByID(ctx context.Context, id string) (*models.ExportJob, error)
+	
+	// Update replaces an existing export job
+	Update(ctx context.Context, job *models.ExportJob) error
+	
+	// ClaimPending atomically moves the oldest pending job to running and returns it,
+	// or returns ErrNotFound when no job is pending
+	ClaimPending(ctx context.Context) (*models.ExportJob, error)
+}
+
+// SessionRepository handles data access for user sessions.
+type SessionRepository interface {
+	// CountEndedBefore counts an organization's sessions that expired or were
+	// terminated before the given time
+	CountEndedBefore(ctx context.Context, orgID string, before time.Time) (int64, error)
+	
+	// DeleteEndedBefore removes up to limit ended sessions and returns how many were removed
+	DeleteEndedBefore(ctx context.Context, orgID string, before time.Time, limit int) (int64, error)
+	
+	// Create inserts a new session
+	Create(ctx context.Context, session *models.Session) error
+	
+	// GetBySessionID retrieves a session by its session ID
+	GetBySessionID(ctx context.Context, sessionID string) (*models.Session, error)
+	
+	// GetActiveByUser retrieves a user's active sessions that have not
+	// expired by now, most recently active first
+	GetActiveByUser(ctx context.Context, userID string, now time.Time) ([]*models.Session, error)
+	
+	// RecordActivity sets the last activity of an active session to now, or
+	// returns ErrNotFound when the session ended, expired by now or was last
+	// active before idleSince
+	RecordActivity(ctx context.Context, sessionID string, now, idleSince time.Time) error
+	
+	// Deactivate ends an active session with the given reason, or returns
+	// ErrNotFound when it is not active
+	Deactivate(ctx context.Context, sessionID, reason string, endedAt time.Time) error
+	
+	// DeactivateByUser ends every active session of a user with the given
+	// reason and returns how many were ended
+	DeactivateByUser(ctx context.Context, userID, reason string, endedAt time.Time) (int64, error)
+}
+
+// PasswordResetRepository handles data access for password reset requests.
+type PasswordResetRepository interface {
+	// Create inserts a new password reset
+	Create(ctx context.Context, reset *models.PasswordReset) error
+	
+	// GetByTokenHash retrieves a password reset by the hash of its token
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.PasswordReset, error)
+	
+	// TakeAnswerAttempt atomically counts an answer to the security question
+	// of a pending reset with fewer than maxAttempts counted answers and
+	// returns the new count, or returns ErrNotFound when the reset was used,
+	// expired before now or has no attempts left
+	TakeAnswerAttempt(ctx context.Context, id string, maxAttempts int, now time.Time) (int, error)
+	
+	// VerifyAnswer atomically records at now that the security question of a
+	// pending reset was answered correctly and gives back the attempt the
+	// answer took, or returns ErrNotFound when it was used or expired before now
+	VerifyAnswer(ctx context.Context, id string, now time.Time) error
+	
+	// Consume atomically marks a reset used at now, or returns ErrNotFound when
+	// it was already used or expired before now
+	Consume(ctx context.Context, id string, now time.Time) error
+	
+	// ConsumeByUser marks every pending reset of a user used at now
+	ConsumeByUser(ctx context.Context, userID string, now time.Time) error
+}
+
+// SSOLoginStateRepository handles data access for single sign-on logins in progress.
+type SSOLoginStateRepository interface {
+	// Create inserts a new login state, or returns ErrDuplicate when its state value is taken
+	Create(ctx context.Context, state *models.SSOLoginState) error
+	
+	// Consume atomically removes and returns the login state with the given
+	// state value, or returns ErrNotFound when there is none or it expired before now
+	Consume(ctx context.Context, state string, now time.Time) (*models.SSOLoginState, error)
+}
+
+// LegalHoldRepository handles data access for legal holds.
+// Holds are never deleted; releasing a hold is an Update.
+type LegalHoldRepository interface {
+	// Create inserts a new legal hold
+	Create(ctx context.Context, hold *models.LegalHold) error
+	
+	// GetByID retrieves a legal hold by its ID
+	GetByID(ctx context.Context, id string) (*models.LegalHold, error)
+	
+	// Update replaces an existing legal hold
+	Update(ctx context.Context, hold *models.LegalHold) error
+	
+	// GetByOrganization retrieves the holds of an organization, newest first,
+	// optionally including released ones
+	GetByOrganization(ctx context.Context, orgID string, includeReleased bool) ([]*models.LegalHold, error)
+}
+
+// NotificationTemplateRepository handles data access for per-organization
+// notification template overrides. There is at most one override per
+// organization and notification type.
+type NotificationTemplateRepository interface {
+	// Get retrieves the override of a notification type, or ErrNotFound
+	Get(ctx context.Context, orgID, notificationType string) (*models.NotificationTemplate, error)
+	
+	// Upsert creates or replaces the override identified by organization and type
+	Upsert(ctx context.Context, template *models.NotificationTemplate) error
+	
+	// Delete removes an override; deleting a missing override returns ErrNotFound
+	Delete(ctx context.Context, orgID, notificationType string) error
+	
+	// GetByOrganization retrieves all overrides of an organization
+	GetByOrganization(ctx context.Context, orgID string) ([]*models.NotificationTemplate, error)
+}
+
+// NotificationOutboxRepository handles data access for queued notification emails.
+type NotificationOutboxRepository interface {
+	// Create inserts a new outbox message
+	Create(ctx context.Context, message *models.OutboxMessage) error
+	
+	// Update replaces an existing outbox message
+	Update(ctx context.Context, message *models.OutboxMessage) error
+	
+	// ClaimDue atomically leases the oldest message that is pending and due at now,
+	// or whose sending lease expired, by moving it to sending with LockedUntil set
+	// to leaseUntil. Returns ErrNotFound when no message is due.
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time) (*models.OutboxMessage, error)
+}
+
+// NotificationDigestRepository handles data access for notifications held back for digests.
+type NotificationDigestRepository interface {
+	// Create inserts a new digest item
+	Create(ctx context.Context, item *models.NotificationDigestItem) error
+	
+	// ClaimDue atomically leases the due items of one user: the user owning the
+	// oldest item that is due at now and not leased, with all of that user's due
+	// and unleased items leased until leaseUntil. Returns ErrNotFound when no
+	// item is due.
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time) ([]*models.NotificationDigestItem, error)
+	
+	// Delete removes digest items by ID
+	Delete(ctx context.Context, ids []string) error
+}
+
+// InAppNotificationRepository handles data access for users' in-app notification inboxes.
+type InAppNotificationRepository interface {
+	// Create inserts a new inbox notification
+	Create(ctx context.Context, notification *models.InAppNotification) error
+	
+	// GetByUser retrieves a page of a user's notifications, newest first
+	GetByUser(ctx context.Context, userID string, unreadOnly bool, limit, offset int) ([]*models.InAppNotification, error)
+	
+	// CountByUser counts a user's notifications, or only the unread ones
+	CountByUser(ctx context.Context, userID string, unreadOnly bool) (int64, error)
+	
+	// MarkRead marks the given unread notifications of a user as read at readAt
+	// and returns how many were updated. Without IDs, all of the user's unread
+	// notifications are marked.
+	MarkRead(ctx context.Context, userID string, ids []string, readAt time.Time) (int64, error)
+}
+
+// WebhookSubscriptionRepository handles data access for webhook subscriptions.
+type WebhookSubscriptionRepository interface {
+	// Create inserts a new subscription
+	Create(ctx context.Context, subscription *models.WebhookSubscription) error
+	
+	// GetByID retrieves a subscription by ID
+	GetByID(ctx context.Context, id string) (*models.WebhookSubscription, error)
+	
+	// Update replaces an existing subscription
+	Update(ctx context.Context, subscription *models.WebhookSubscription) error
+	
+	// Delete removes a subscription; deleting a missing subscription returns ErrNotFound
+	Delete(ctx context.Context, id string) error
+	
+	// GetByOrganization retrieves all subscriptions of an organization
+	GetByOrganization(ctx context.Context, orgID string) ([]*models.WebhookSubscription, error)
+	
+	// GetActiveByEvent retrieves the active subscriptions of an organization
+	// that subscribe to the given event type
+	GetActiveByEvent(ctx context.Context, orgID, eventType string) ([]*models.WebhookSubscription, error)
+}
+
+// WebhookDeliveryRepository handles data access for webhook deliveries and their attempt log.
+type WebhookDeliveryRepository interface {
+	// Create inserts a new delivery
+	Create(ctx context.Context, delivery *models.WebhookDelivery) error
+	
+	// GetByID retrieves a delivery by ID
+	GetByID(ctx context.Context, id string) (*models.WebhookDelivery, error)
+	
+	// Update replaces an existing delivery
+	Update(ctx context.Context, delivery *models.WebhookDelivery) error
+	
+	// GetBySubscription retrieves a page of a subscription's deliveries, newest first
+	GetBySubscription(ctx context.Context, subscriptionID string, limit, offset int) ([]*models.WebhookDelivery, error)
+	
+	// CountBySubscription counts a subscription's deliveries
+	CountBySubscription(ctx context.Context, subscriptionID string) (int64, error)
+	
+	// ClaimDue atomically leases the oldest delivery that is pending and due at
+	// now, or whose delivering lease expired, by moving it to delivering with
+	// LockedUntil set to leaseUntil. Returns ErrNotFound when no delivery is due.
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time) (*models.WebhookDelivery, error)
+}
+
+// DomainEventRepository handles data access for the domain event outbox.
+// Implementations must write with the MongoDB session carried by ctx, if any,
+// so that events created inside a transaction commit or roll back with it.
+type DomainEventRepository interface {
+	// Create inserts a new outbox event
+	Create(ctx context.Context, event *models.DomainEvent) error
+	
+	// Update replaces an existing outbox event
+	Update(ctx context.Context, event *models.DomainEvent) error
+	
+	// ClaimDue atomically leases the oldest event that is pending and due at
+	// now, or whose relaying lease expired, by moving it to relaying with
+	// LockedUntil set to leaseUntil. Returns ErrNotFound when no event is due.
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time) (*models.DomainEvent, error)
+}
+
+// ScheduledJobRepository handles data access for the shared state of scheduled
+// jobs. Jobs are identified by their unique name. Updates only touch the fields
+// they name, so that pausing a job and recording its runs never overwrite
+// each other.
+type ScheduledJobRepository interface {
+	// Create inserts a new job; returns ErrDuplicate if the name is taken
+	Create(ctx context.Context, job *models.ScheduledJob) error
+	
+	// GetByName retrieves a job by name
+	GetByName(ctx context.Context, name string) (*models.ScheduledJob, error)
+	
+	// List retrieves all jobs ordered by name
+	List(ctx context.Context) ([]*models.ScheduledJob, error)
+	
+	// UpdateDefinition changes a job's description, schedule and next run time
+	UpdateDefinition(ctx context.Context, name, description, schedule string, nextRunAt time.Time) error
+	
+	// SetPaused pauses or resumes a job and sets its next run time
+	SetPaused(ctx context.Context, name string, paused bool, by string, at, nextRunAt time.Time) error
+	
+	// SetNextRun sets a job's next run time
+	SetNextRun(ctx context.Context, name string, nextRunAt time.Time) error
+	
+	// RecordRun copies the start time, status, error and duration of a run
+	// into the job's last run fields
+	RecordRun(ctx context.Context, name string, run *models.JobRun) error
+	
+	// AcquireLease atomically sets the job's lease to owner until the given
+	// time if the lease is free or expired at now, and reports whether it did
+	AcquireLease(ctx context.Context, name, owner string, now, until time.Time) (bool, error)
+	
+	// RenewLease extends the lease while owner still holds it and reports
+	// whether it did
+	RenewLease(ctx context.Context, name, owner string, until time.Time) (bool, error)
+	
+	// ReleaseLease clears the lease if owner holds it
+	ReleaseLease(ctx context.Context, name, owner string) error
+}
+
+// JobRunRepository handles data access for the run history of scheduled jobs.
+type JobRunRepository interface {
+	// Create inserts a new run
+	Create(ctx context.Context, run *models.JobRun) error
+	
+	// Update replaces an existing run
+	Update(ctx context.Context, run *models.JobRun) error
+	
+	// GetByJob retrieves a page of a job's runs, newest first
+	GetByJob(ctx context.Context, jobName string, limit, offset int) ([]*models.JobRun, error)
+	
+	// CountByJob counts a job's runs
+	CountByJob(ctx context.Context, jobName string) (int64, error)
+}
+
+// APIKeyRepository handles data access for organization API keys.
+type APIKeyRepository interface {
+	// Create inserts a new API key
+	Create(ctx context.Context, key *models.APIKey) error
+	
+	// GetByID retrieves an API key by ID
+	GetByID(ctx context.Context, id string) (*models.APIKey, error)
+	
+	// GetByPrefix retrieves an API key by its unique prefix
+	GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error)
+	
+	// Update replaces an existing API key
+	Update(ctx context.Context, key *models.APIKey) error
+	
+	// GetByOrganization retrieves all API keys of an organization, newest first
+	GetByOrganization(ctx context.Context, orgID string) ([]*models.APIKey, error)
+	
+	// TouchLastUsed records when and from where a key was last used
+	TouchLastUsed(ctx context.Context, id string, usedAt time.Time, ip string) error
+}
+
+// InvitationRepository handles data access for invitations to join an organization.
+type InvitationRepository interface {
+	// Create inserts a new invitation, or returns ErrDuplicate when the
+	// organization already has a pending invitation for its email
+	Create(ctx context.Context, invitation *models.Invitation) error
+	
+	// GetByID retrieves an invitation by ID
+	GetByID(ctx context.Context, id string) (*models.Invitation, error)
+	
+	// Update replaces an existing invitation
+	Update(ctx context.Context, invitation *models.Invitation) error
+	
+	// GetPendingByEmail retrieves the organization's pending invitation for an
+	// email, including an expired one
+	GetPendingByEmail(ctx context.Context, orgID, email string) (*models.Invitation, error)
+	
+	// GetByOrganization retrieves an organization's invitations, newest first;
+	// accepted and revoked ones only when includeClosed is set
+	GetByOrganization(ctx context.Context, orgID string, includeClosed bool) ([]*models.Invitation, error)
+}
+
+// SCIMGroupRepository handles data access for groups provisioned through SCIM.
+type SCIMGroupRepository interface {
+	// Create inserts a new group, or returns ErrDuplicate when the organization
+	// already has a group with its display name
+	Create(ctx context.Context, group *models.SCIMGroup) error
+	
+	// GetByID retrieves a group by ID
+	GetByID(ctx context.Context, id string) (*models.SCIMGroup, error)
+	
+	// Update replaces an existing group, or returns ErrDuplicate when its new
+	// display name is taken
+	Update(ctx context.Context, group *models.SCIMGroup) error
+	
+	// Delete removes a group
+	Delete(ctx context.Context, id string) error
+	
+	// GetByOrganization retrieves all groups of an organization
+	GetByOrganization(ctx context.Context, orgID string) ([]*models.SCIMGroup, error)
+	
+	// GetByMember retrieves the groups of an organization a user belongs to
+	GetByMember(ctx context.Context, orgID, userID string) ([]*models.SCIMGroup, error)
+}
+
+// Transactor runs functions in a database transaction. Repository calls made
+// with the context passed to fn take part in the transaction.
+type Transactor interface {
+	// WithTransaction runs fn in a transaction, committing it if fn returns nil
+	// and aborting it otherwise
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 // Filter and Stats structures
@@ -251,6 +702,18 @@ type ControlUpdate struct {
 	Fields map[string]interface{} `json:"fields"`
 }
 
+// EvidenceRequestFilter defines filtering options for evidence request queries
+type EvidenceRequestFilter struct {
+	Status     string `json:"status,omitempty"`
+	ControlID  string `json:"control_id,omitempty"`
+	CycleID    string `json:"cycle_id,omitempty"`
+	AssigneeID string `json:"assignee_id,omitempty"`
+	
+	// Pagination
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
 // EvidenceRequestStats represents evidence request statistics
 type EvidenceRequestStats struct {
 	TotalRequests   int            `json:"total_requests"`
@@ -0,0 +1,80 @@
+// Package requestctx carries request-scoped metadata (correlation ID, acting user,
+// organization, client IP and user agent) through context.Context so that the
+// service layer can attribute its actions without depending on HTTP types.
+//
+// The metadata is stored as a pointer: the request context middleware creates it
+// at the start of the chain and later middlewares, such as the organization
+// middleware, fill in the actor once it has been authenticated.
+package requestctx
+
+import (
+	"context"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/logger"
+)
+
+// contextKey is the private type for keys defined in this package.
+type contextKey struct{}
+
+// metadataKey is the context key under which *Metadata is stored.
+var metadataKey = contextKey{}
+
+// Metadata describes who performed a request and from where.
+type Metadata struct {
+	CorrelationID  string
+	UserID         string
+	OrganizationID string
+	IPAddress      string
+	UserAgent      string
+}
+
+// WithMetadata returns a copy of ctx carrying md. The correlation ID is also
+// stored under logger.CorrelationIDKey so the structured logger picks it up.
+//
+// Parameters:
+//   - ctx: Parent context
+//   - md: Request metadata
+//
+// Returns:
+//   - context.Context: Context carrying the metadata
+//
+// Example:
+//
+//	ctx = requestctx.WithMetadata(ctx, &requestctx.Metadata{CorrelationID: id, IPAddress: ip})
+func WithMetadata(ctx context.Context, md *Metadata) context.Context {
+	ctx = context.WithValue(ctx, metadataKey, md)
+	if md.CorrelationID != "" {
+		ctx = context.WithValue(ctx, logger.CorrelationIDKey, md.CorrelationID)
+	}
+	return ctx
+}
+
+// FromContext returns the request metadata stored in ctx.
+//
+// Parameters:
+//   - ctx: Context to inspect
+//
+// Returns:
+//   - *Metadata: Stored metadata, or an empty value if none is present
+//   - bool: Whether metadata was present
+func FromContext(ctx context.Context) (*Metadata, bool) {
+	md, ok := ctx.Value(metadataKey).(*Metadata)
+	if !ok || md == nil {
+		return &Metadata{}, false
+	}
+	return md, true
+}
+
+// SetActor records the authenticated user and organization on the metadata
+// stored in ctx. It is a no-op when ctx carries no metadata.
+//
+// Parameters:
+//   - ctx: Context carrying request metadata
+//   - userID: Authenticated user ID
+//   - orgID: Organization the request operates on
+func SetActor(ctx context.Context, userID, orgID string) {
+	if md, ok := FromContext(ctx); ok {
+		md.UserID = userID
+		md.OrganizationID = orgID
+	}
+}
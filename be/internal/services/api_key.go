@@ -0,0 +1,437 @@
+// Package services provides service layer implementations for the GoEdu Control Testing Platform.
+// This file contains the API key service which issues, rotates and revokes an
+// organization's API keys and authenticates requests made with them.
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+)
+
+// API key errors
+var (
+	ErrAPIKeyNotFound      = errors.New("API key not found")
+	ErrInvalidAPIKey       = errors.New("invalid or expired API key")
+	ErrAPIKeysDisabled     = errors.New("API keys are disabled for this organization")
+	ErrInvalidAPIKeyScope  = errors.New("API key scopes must be <resource>:<read|write> with resource a route group or *")
+	ErrInvalidAPIKeyRole   = errors.New("API key role must be admin, manager, auditor or viewer")
+	ErrInvalidAPIKeyExpiry = errors.New("API key expiry must be in the future and within the maximum lifetime")
+	ErrAPIKeyInactive      = errors.New("API key is revoked or expired")
+	ErrAPIKeyRotated       = errors.New("API key has already been rotated")
+)
+
+// apiKeyPrefix starts every API key, so leaked keys are easy to recognize.
+const apiKeyPrefix = "goedu_"
+
+// Random bytes in the public prefix and in the secret part of a key.
+const (
+	apiKeyPrefixBytes = 4
+	apiKeySecretBytes = 32
+)
+
+// apiKeyRoles lists the roles a key may carry. Keys cannot be owners.
+var apiKeyRoles = []string{models.RoleAdmin, models.RoleManager, models.RoleAuditor, models.RoleViewer}
+
+// apiKeyResourcePattern matches the resource part of a scope, the first path
+// segment of the routes it grants access to.
+var apiKeyResourcePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// apiKeyService implements the APIKeyService interface.
+type apiKeyService struct {
+	orgRepo repositories.OrganizationRepository
+	keyRepo repositories.APIKeyRepository
+	config  config.APIKeyConfig
+	logger  *zap.Logger
+}
+
+// NewAPIKeyService creates a new API key service.
+//
+// Parameters:
+//   - orgRepo: Repository for organization data, used to check that API keys are enabled
+//   - keyRepo: Repository for API keys
+//   - cfg: Key lifetime, rotation overlap and last-used tracking settings
+//   - logger: Logger for service operations
+//
+// Returns:
+//   - APIKeyService: Configured API key service instance
+func NewAPIKeyService(
+	orgRepo repositories.OrganizationRepository,
+	keyRepo repositories.APIKeyRepository,
+	cfg config.APIKeyConfig,
+	logger *zap.Logger,
+) APIKeyService {
+	return &apiKeyService{
+		orgRepo: orgRepo,
+		keyRepo: keyRepo,
+		config:  cfg,
+		logger:  logger,
+	}
+}
+
+// CreateAPIKey issues a key. The key is only returned here and by RotateAPIKey;
+// only its hash is stored.
+//
+// Parameters:
+//   - ctx: Request context
+//   - input: Name, role, scopes and optional expiry of the key
+//
+// Returns:
+//   - *APIKeySecret: Created key and its secret value
+//   - error: ErrAPIKeysDisabled, validation or persistence error
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, input *APIKeyInput) (*APIKeySecret, error) {
+	if input == nil || strings.TrimSpace(input.Name) == "" {
+		return nil, ErrInvalidInput
+	}
+	orgID, err := primitive.ObjectIDFromHex(input.OrganizationID)
+	if err != nil {
+		return nil, ErrInvalidInput
+	}
+	createdBy, err := primitive.ObjectIDFromHex(input.CreatedBy)
+	if err != nil {
+		return nil, ErrInvalidInput
+	}
+	if !slices.Contains(apiKeyRoles, input.Role) {
+		return nil, ErrInvalidAPIKeyRole
+	}
+	scopes, err := normalizeAPIKeyScopes(input.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(s.config.DefaultTTL)
+	if input.ExpiresAt != nil {
+		expiresAt = input.ExpiresAt.UTC()
+	}
+	if !expiresAt.After(now) || expiresAt.After(now.Add(s.config.MaxTTL)) {
+		return nil, ErrInvalidAPIKeyExpiry
+	}
+
+	if err := s.checkEnabled(ctx, input.OrganizationID); err != nil {
+		return nil, err
+	}
+
+	key := &models.APIKey{
+		ID:             primitive.NewObjectID(),
+		OrganizationID: orgID,
+		Name:           strings.TrimSpace(input.Name),
+		Role:           input.Role,
+		Scopes:         scopes,
+		ExpiresAt:      expiresAt,
+		CreatedBy:      createdBy,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	secret, err := s.issue(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("API key created",
+		zap.String("organization_id", input.OrganizationID),
+		zap.String("api_key_id", key.ID.Hex()),
+		zap.String("prefix", key.Prefix),
+		zap.Strings("scopes", scopes),
+	)
+	return &APIKeySecret{APIKey: key, Key: secret}, nil
+}
+
+// GetAPIKey retrieves a key of an organization.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization the key must belong to
+//   - keyID: Key ID
+//
+// Returns:
+//   - *models.APIKey: Key without its secret
+//   - error: ErrAPIKeyNotFound or persistence error
+func (s *apiKeyService) GetAPIKey(ctx context.Context, orgID, keyID string) (*models.APIKey, error) {
+	key, err := s.keyRepo.GetByID(ctx, keyID)
+	if errors.Is(err, repositories.ErrNotFound) || (err == nil && key.OrganizationID.Hex() != orgID) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	return key, nil
+}
+
+// ListAPIKeys retrieves all keys of an organization, including revoked and
+// expired ones.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID
+//
+// Returns:
+//   - []*models.APIKey: Keys, newest first
+//   - error: Persistence error
+func (s *apiKeyService) ListAPIKeys(ctx context.Context, orgID string) ([]*models.APIKey, error) {
+	keys, err := s.keyRepo.GetByOrganization(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RotateAPIKey replaces a key with a new one carrying the same name, role,
+// scopes and lifetime. The old key keeps working for the overlap period, or
+// until it expires if that is sooner, so clients can switch over.
+//
+// Parameters:
+//   - ctx: Request context
+//   - input: Key to rotate and optional overlap
+//
+// Returns:
+//   - *APIKeySecret: Replacement key and its secret value
+//   - error: ErrAPIKeyNotFound, ErrAPIKeyInactive, ErrAPIKeyRotated, ErrAPIKeysDisabled, ErrInvalidInput or persistence error
+func (s *apiKeyService) RotateAPIKey(ctx context.Context, input *RotateAPIKeyInput) (*APIKeySecret, error) {
+	if input == nil {
+		return nil, ErrInvalidInput
+	}
+	overlap := s.config.RotationOverlap
+	if input.OverlapHours != nil {
+		overlap = time.Duration(*input.OverlapHours) * time.Hour
+	}
+	if overlap < 0 || overlap > s.config.MaxRotationOverlap {
+		return nil, ErrInvalidInput
+	}
+	rotatedBy, err := primitive.ObjectIDFromHex(input.RotatedBy)
+	if err != nil {
+		return nil, ErrInvalidInput
+	}
+
+	old, err := s.GetAPIKey(ctx, input.OrganizationID, input.APIKeyID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if !old.RotatedTo.IsZero() {
+		return nil, ErrAPIKeyRotated
+	}
+	if !old.IsActive(now) {
+		return nil, ErrAPIKeyInactive
+	}
+	if err := s.checkEnabled(ctx, input.OrganizationID); err != nil {
+		return nil, err
+	}
+
+	replacement := &models.APIKey{
+		ID:             primitive.NewObjectID(),
+		OrganizationID: old.OrganizationID,
+		Name:           old.Name,
+		Role:           old.Role,
+		Scopes:         old.Scopes,
+		ExpiresAt:      now.Add(old.ExpiresAt.Sub(old.CreatedAt)),
+		CreatedBy:      rotatedBy,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	secret, err := s.issue(ctx, replacement)
+	if err != nil {
+		return nil, err
+	}
+
+	old.RotatedTo = replacement.ID
+	if overlapEnd := now.Add(overlap); overlapEnd.Before(old.ExpiresAt) {
+		old.ExpiresAt = overlapEnd
+	}
+	old.UpdatedAt = now
+	if err := s.keyRepo.Update(ctx, old); err != nil {
+		return nil, fmt.Errorf("failed to update rotated API key: %w", err)
+	}
+
+	s.logger.Info("API key rotated",
+		zap.String("organization_id", input.OrganizationID),
+		zap.String("api_key_id", old.ID.Hex()),
+		zap.String("replacement_id", replacement.ID.Hex()),
+		zap.Time("old_key_expires_at", old.ExpiresAt),
+	)
+	return &APIKeySecret{APIKey: replacement, Key: secret}, nil
+}
+
+// RevokeAPIKey disables a key immediately. Revoking a revoked key changes
+// nothing.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization the key must belong to
+//   - keyID: Key ID
+//   - revokedBy: ID of the user revoking the key
+//
+// Returns:
+//   - *models.APIKey: Revoked key
+//   - error: ErrAPIKeyNotFound, ErrInvalidInput or persistence error
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, orgID, keyID, revokedBy string) (*models.APIKey, error) {
+	revokerID, err := primitive.ObjectIDFromHex(revokedBy)
+	if err != nil {
+		return nil, ErrInvalidInput
+	}
+	key, err := s.GetAPIKey(ctx, orgID, keyID)
+	if err != nil {
+		return nil, err
+	}
+	if !key.RevokedAt.IsZero() {
+		return key, nil
+	}
+
+	now := time.Now().UTC()
+	key.RevokedAt = now
+	key.RevokedBy = revokerID
+	key.UpdatedAt = now
+	if err := s.keyRepo.Update(ctx, key); err != nil {
+		return nil, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	s.logger.Info("API key revoked",
+		zap.String("organization_id", orgID),
+		zap.String("api_key_id", keyID),
+		zap.String("revoked_by", revokedBy),
+	)
+	return key, nil
+}
+
+// Authenticate resolves the key presented by a request. The key must be
+// active and its organization must still allow API keys. Last use is recorded
+// at most once per LastUsedInterval; failing to record it does not fail the
+// request.
+//
+// Parameters:
+//   - ctx: Request context
+//   - rawKey: Key as presented by the client
+//   - ip: Client IP address
+//
+// Returns:
+//   - *models.APIKey: Authenticated key
+//   - error: ErrInvalidAPIKey, ErrAPIKeysDisabled or persistence error
+func (s *apiKeyService) Authenticate(ctx context.Context, rawKey, ip string) (*models.APIKey, error) {
+	prefix, ok := parseAPIKey(rawKey)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+	key, err := s.keyRepo.GetByPrefix(ctx, prefix)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(hashAPIKey(rawKey)), []byte(key.KeyHash)) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+	now := time.Now().UTC()
+	if !key.IsActive(now) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err := s.checkEnabled(ctx, key.OrganizationID.Hex()); err != nil {
+		return nil, err
+	}
+
+	if now.Sub(key.LastUsedAt) >= s.config.LastUsedInterval {
+		if err := s.keyRepo.TouchLastUsed(ctx, key.ID.Hex(), now, ip); err != nil {
+			s.logger.Warn("Failed to record API key use",
+				zap.Error(err),
+				zap.String("api_key_id", key.ID.Hex()),
+			)
+		} else {
+			key.LastUsedAt = now
+			key.LastUsedIP = ip
+		}
+	}
+	return key, nil
+}
+
+// checkEnabled returns ErrAPIKeysDisabled unless the organization's plan
+// includes API access and its admins enabled API keys.
+func (s *apiKeyService) checkEnabled(ctx context.Context, orgID string) error {
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return ErrAPIKeysDisabled
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get organization: %w", err)
+	}
+	if !org.FeatureFlags["api_access"] || !org.Settings.Integrations.APIKeyEnabled {
+		return ErrAPIKeysDisabled
+	}
+	return nil
+}
+
+// issue generates a key for key, stores it with the key's prefix and hash and
+// returns the secret value.
+func (s *apiKeyService) issue(ctx context.Context, key *models.APIKey) (string, error) {
+	raw := make([]byte, apiKeyPrefixBytes+apiKeySecretBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	key.Prefix = apiKeyPrefix + hex.EncodeToString(raw[:apiKeyPrefixBytes])
+	secret := key.Prefix + "_" + hex.EncodeToString(raw[apiKeyPrefixBytes:])
+	key.KeyHash = hashAPIKey(secret)
+
+	if err := s.keyRepo.Create(ctx, key); err != nil {
+		return "", fmt.Errorf("failed to create API key: %w", err)
+	}
+	return secret, nil
+}
+
+// parseAPIKey returns the prefix of a well-formed key.
+func parseAPIKey(rawKey string) (string, bool) {
+	if !strings.HasPrefix(rawKey, apiKeyPrefix) {
+		return "", false
+	}
+	random, secret, ok := strings.Cut(strings.TrimPrefix(rawKey, apiKeyPrefix), "_")
+	if !ok || len(random) != 2*apiKeyPrefixBytes || len(secret) != 2*apiKeySecretBytes {
+		return "", false
+	}
+	return apiKeyPrefix + random, true
+}
+
+// IsAPIKey reports whether a credential looks like an API key rather than a
+// JWT, so that API keys can also be presented as bearer tokens.
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, apiKeyPrefix)
+}
+
+// hashAPIKey returns the hex SHA-256 hash stored for a key. Keys carry 256
+// random bits, so a fast unsalted hash is sufficient.
+func hashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeAPIKeyScopes validates scopes and drops duplicates.
+func normalizeAPIKeyScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, ErrInvalidAPIKeyScope
+	}
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		resource, access, ok := strings.Cut(scope, ":")
+		if !ok || (resource != models.APIKeyScopeAll && !apiKeyResourcePattern.MatchString(resource)) ||
+			(access != models.APIKeyAccessRead && access != models.APIKeyAccessWrite) {
+			return nil, ErrInvalidAPIKeyScope
+		}
+		if !slices.Contains(normalized, scope) {
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized, nil
+}
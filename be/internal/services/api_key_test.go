@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+)
+
+type fakeAPIKeyRepository struct {
+	repositories.APIKeyRepository
+	mu      sync.Mutex
+	keys    []*models.APIKey
+	touches int
+}
+
+func (r *fakeAPIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys = append(r.keys, key)
+	return nil
+}
+
+func (r *fakeAPIKeyRepository) GetByID(ctx context.Context, id string) (*models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, key := range r.keys {
+		if key.ID.Hex() == id {
+			return key, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r *fakeAPIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, key := range r.keys {
+		if key.Prefix == prefix {
+			return key, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r *fakeAPIKeyRepository) Update(ctx context.Context, key *models.APIKey) error {
+	return nil
+}
+
+func (r *fakeAPIKeyRepository) GetByOrganization(ctx context.Context, orgID string) ([]*models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var matching []*models.APIKey
+	for i := len(r.keys) - 1; i >= 0; i-- {
+		if r.keys[i].OrganizationID.Hex() == orgID {
+			matching = append(matching, r.keys[i])
+		}
+	}
+	return matching, nil
+}
+
+func (r *fakeAPIKeyRepository) TouchLastUsed(ctx context.Context, id string, usedAt time.Time, ip string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.touches++
+	return nil
+}
+
+type apiKeyFixture struct {
+	org     *models.Organization
+	adminID string
+	keys    *fakeAPIKeyRepository
+	service APIKeyService
+}
+
+func newAPIKeyFixture(t *testing.T) *apiKeyFixture {
+	t.Helper()
+
+	org := &models.Organization{Name: "First Bank", Status: models.OrganizationStatusActive}
+	org.ID = primitive.NewObjectID()
+	org.FeatureFlags = map[string]bool{"api_access": true}
+	org.Settings.Integrations.APIKeyEnabled = true
+
+	f := &apiKeyFixture{
+		org:     org,
+		adminID: primitive.NewObjectID().Hex(),
+		keys:    &fakeAPIKeyRepository{},
+	}
+	f.service = NewAPIKeyService(newFakeOrganizationRepository(org), f.keys, config.APIKeyConfig{
+		DefaultTTL:         90 * 24 * time.Hour,
+		MaxTTL:             365 * 24 * time.Hour,
+		RotationOverlap:    24 * time.Hour,
+		MaxRotationOverlap: 7 * 24 * time.Hour,
+		LastUsedInterval:   time.Minute,
+	}, zap.NewNop())
+	return f
+}
+
+// create issues a key with the given scopes.
+func (f *apiKeyFixture) create(t *testing.T, scopes ...string) *APIKeySecret {
+	t.Helper()
+	created, err := f.service.CreateAPIKey(context.Background(), &APIKeyInput{
+		OrganizationID: f.org.ID.Hex(),
+		CreatedBy:      f.adminID,
+		Name:           "Evidence sync",
+		Role:           models.RoleViewer,
+		Scopes:         scopes,
+	})
+	require.NoError(t, err)
+	return created
+}
+
+func TestAPIKeyService_CreateAndAuthenticate(t *testing.T) {
+	f := newAPIKeyFixture(t)
+	ctx := context.Background()
+
+	created := f.create(t, "Controls:read", "controls:read", "*:write")
+	key := created.APIKey
+	assert.True(t, strings.HasPrefix(created.Key, key.Prefix+"_"))
+	assert.Regexp(t, `^goedu_[0-9a-f]{8}$`, key.Prefix)
+	assert.NotContains(t, key.KeyHash, created.Key)
+	assert.Equal(t, hashAPIKey(created.Key), key.KeyHash)
+	assert.Equal(t, []string{"controls:read", "*:write"}, key.Scopes, "scopes are normalized and deduplicated")
+	assert.WithinDuration(t, time.Now().Add(90*24*time.Hour), key.ExpiresAt, time.Minute)
+
+	authenticated, err := f.service.Authenticate(ctx, created.Key, "10.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, authenticated.ID)
+	assert.Equal(t, "10.0.0.1", authenticated.LastUsedIP)
+	assert.Equal(t, 1, f.keys.touches)
+
+	// Last use is written at most once per interval
+	_, err = f.service.Authenticate(ctx, created.Key, "10.0.0.2")
+	require.NoError(t, err)
+	assert.Equal(t, 1, f.keys.touches)
+
+	// Tampered, malformed and unknown keys are rejected alike
+	for _, raw := range []string{
+		created.Key[:len(created.Key)-1] + "x",
+		"goedu_short",
+		"goedu_00000000_" + strings.Repeat("a", 64),
+	} {
+		_, err := f.service.Authenticate(ctx, raw, "10.0.0.1")
+		assert.ErrorIs(t, err, ErrInvalidAPIKey, raw)
+	}
+
+	// Keys stop working when the organization disables them
+	f.org.Settings.Integrations.APIKeyEnabled = false
+	_, err = f.service.Authenticate(ctx, created.Key, "10.0.0.1")
+	assert.ErrorIs(t, err, ErrAPIKeysDisabled)
+}
+
+func TestAPIKeyService_CreateValidation(t *testing.T) {
+	f := newAPIKeyFixture(t)
+	past := time.Now().Add(-time.Hour)
+	tooLate := time.Now().Add(2 * 365 * 24 * time.Hour)
+
+	tests := []struct {
+		name        string
+		modify      func(input *APIKeyInput)
+		expectedErr error
+	}{
+		{"missing name", func(input *APIKeyInput) { input.Name = " " }, ErrInvalidInput},
+		{"owner role", func(input *APIKeyInput) { input.Role = models.RoleOwner }, ErrInvalidAPIKeyRole},
+		{"no scopes", func(input *APIKeyInput) { input.Scopes = nil }, ErrInvalidAPIKeyScope},
+		{"unknown access", func(input *APIKeyInput) { input.Scopes = []string{"controls:admin"} }, ErrInvalidAPIKeyScope},
+		{"malformed resource", func(input *APIKeyInput) { input.Scopes = []string{"/controls:read"} }, ErrInvalidAPIKeyScope},
+		{"expiry in the past", func(input *APIKeyInput) { input.ExpiresAt = &past }, ErrInvalidAPIKeyExpiry},
+		{"expiry beyond max TTL", func(input *APIKeyInput) { input.ExpiresAt = &tooLate }, ErrInvalidAPIKeyExpiry},
+		{"feature not in plan", func(input *APIKeyInput) { f.org.FeatureFlags["api_access"] = false }, ErrAPIKeysDisabled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f.org.FeatureFlags["api_access"] = true
+			input := &APIKeyInput{
+				OrganizationID: f.org.ID.Hex(),
+				CreatedBy:      f.adminID,
+				Name:           "Evidence sync",
+				Role:           models.RoleViewer,
+				Scopes:         []string{"controls:read"},
+			}
+			tt.modify(input)
+			_, err := f.service.CreateAPIKey(context.Background(), input)
+			assert.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+	assert.Empty(t, f.keys.keys)
+}
+
+func TestAPIKeyService_Rotate(t *testing.T) {
+	f := newAPIKeyFixture(t)
+	ctx := context.Background()
+	old := f.create(t, "controls:read")
+
+	overlap := 2
+	rotated, err := f.service.RotateAPIKey(ctx, &RotateAPIKeyInput{
+		OrganizationID: f.org.ID.Hex(),
+		APIKeyID:       old.APIKey.ID.Hex(),
+		RotatedBy:      f.adminID,
+		OverlapHours:   &overlap,
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, old.Key, rotated.Key)
+	assert.Equal(t, old.APIKey.Scopes, rotated.APIKey.Scopes)
+	assert.Equal(t, rotated.APIKey.ID, old.APIKey.RotatedTo)
+	assert.WithinDuration(t, time.Now().Add(2*time.Hour), old.APIKey.ExpiresAt, time.Minute)
+	assert.WithinDuration(t, time.Now().Add(90*24*time.Hour), rotated.APIKey.ExpiresAt, time.Minute)
+
+	// Both keys work during the overlap
+	_, err = f.service.Authenticate(ctx, old.Key, "10.0.0.1")
+	assert.NoError(t, err)
+	_, err = f.service.Authenticate(ctx, rotated.Key, "10.0.0.1")
+	assert.NoError(t, err)
+
+	_, err = f.service.RotateAPIKey(ctx, &RotateAPIKeyInput{OrganizationID: f.org.ID.Hex(), APIKeyID: old.APIKey.ID.Hex(), RotatedBy: f.adminID})
+	assert.ErrorIs(t, err, ErrAPIKeyRotated)
+
+	tooLong := 24 * 30
+	_, err = f.service.RotateAPIKey(ctx, &RotateAPIKeyInput{OrganizationID: f.org.ID.Hex(), APIKeyID: rotated.APIKey.ID.Hex(), RotatedBy: f.adminID, OverlapHours: &tooLong})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	// The old key stops working once the overlap has passed
+	old.APIKey.ExpiresAt = time.Now().Add(-time.Second)
+	_, err = f.service.Authenticate(ctx, old.Key, "10.0.0.1")
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+}
+
+func TestAPIKeyService_Revoke(t *testing.T) {
+	f := newAPIKeyFixture(t)
+	ctx := context.Background()
+	created := f.create(t, "*:read")
+
+	_, err := f.service.RevokeAPIKey(ctx, primitive.NewObjectID().Hex(), created.APIKey.ID.Hex(), f.adminID)
+	assert.ErrorIs(t, err, ErrAPIKeyNotFound, "keys of other organizations are hidden")
+
+	revoked, err := f.service.RevokeAPIKey(ctx, f.org.ID.Hex(), created.APIKey.ID.Hex(), f.adminID)
+	require.NoError(t, err)
+	assert.False(t, revoked.RevokedAt.IsZero())
+	assert.Equal(t, f.adminID, revoked.RevokedBy.Hex())
+
+	_, err = f.service.Authenticate(ctx, created.Key, "10.0.0.1")
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	_, err = f.service.RotateAPIKey(ctx, &RotateAPIKeyInput{OrganizationID: f.org.ID.Hex(), APIKeyID: created.APIKey.ID.Hex(), RotatedBy: f.adminID})
+	assert.ErrorIs(t, err, ErrAPIKeyInactive)
+
+	keys, err := f.service.ListAPIKeys(ctx, f.org.ID.Hex())
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+}
@@ -0,0 +1,432 @@
+// Package services provides service layer implementations for the GoEdu Control Testing Platform.
+// This file contains the audit service which records and queries the compliance audit trail.
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/requestctx"
+)
+
+// Audit query limits
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// redactedValue replaces sensitive values in audit records.
+const redactedValue = "[REDACTED]"
+
+// sensitiveKeyFragments marks audit value keys whose content must never be stored.
+var sensitiveKeyFragments = []string{"password", "secret", "token", "api_key", "apikey", "authorization", "mfa", "private_key", "answer"}
+
+// Audit service errors
+var (
+	ErrAuditOrganizationRequired = errors.New("audit entries require an organization")
+	ErrAuditActionRequired       = errors.New("audit action and resource type are required")
+	ErrInvalidTimeRange          = errors.New("time range must use RFC 3339 timestamps with start before end")
+	ErrUnsupportedExportFormat   = errors.New("unsupported audit export format")
+)
+
+// auditService implements the AuditService interface.
+type auditService struct {
+	auditRepo repositories.AuditLogRepository
+	exporter  *AuditExporter
+	logger    *zap.Logger
+}
+
+// NewAuditService creates a new audit service.
+//
+// Parameters:
+//   - auditRepo: Repository for audit log persistence
+//   - exporter: Exporter for synchronous exports; nil disables ExportAuditLog
+//   - logger: Logger for service operations
+//
+// Returns:
+//   - AuditService: Configured audit service instance
+func NewAuditService(auditRepo repositories.AuditLogRepository, exporter *AuditExporter, logger *zap.Logger) AuditService {
+	return &auditService{
+		auditRepo: auditRepo,
+		exporter:  exporter,
+		logger:    logger,
+	}
+}
+
+// LogAction records an audit trail entry. Correlation ID, user, organization,
+// IP address and user agent missing from the input are taken from the request
+// metadata in ctx. Sensitive values are redacted before storage.
+//
+// Parameters:
+//   - ctx: Request context carrying request metadata
+//   - input: Audit entry data
+//
+// Returns:
+//   - error: Validation or persistence error
+//
+// Example:
+//
+//	err := auditService.LogAction(ctx, &AuditInput{
+//	    Action:       "control.updated",
+//	    ResourceType: "control",
+//	    ResourceID:   control.ID.Hex(),
+//	    OldValues:    oldValues,
+//	    NewValues:    newValues,
+//	    Success:      true,
+//	})
+func (s *auditService) LogAction(ctx context.Context, input *AuditInput) error {
+	if input == nil {
+		return ErrInvalidInput
+	}
+
+	md, _ := requestctx.FromContext(ctx)
+	entry := &models.AuditLog{
+		ID:            primitive.NewObjectID(),
+		Timestamp:     time.Now().UTC(),
+		CorrelationID: firstNonEmpty(input.CorrelationID, md.CorrelationID),
+		Action:        input.Action,
+		ResourceType:  input.ResourceType,
+		ResourceID:    input.ResourceID,
+		OldValues:     RedactValues(input.OldValues),
+		NewValues:     RedactValues(input.NewValues),
+		IPAddress:     firstNonEmpty(input.IPAddress, md.IPAddress),
+		UserAgent:     firstNonEmpty(input.UserAgent, md.UserAgent),
+		Metadata:      RedactValues(input.Metadata),
+		Success:       input.Success,
+		ErrorMessage:  input.ErrorMessage,
+	}
+
+	if entry.Action == "" || entry.ResourceType == "" {
+		return ErrAuditActionRequired
+	}
+
+	orgID, err := primitive.ObjectIDFromHex(firstNonEmpty(input.OrganizationID, md.OrganizationID))
+	if err != nil {
+		return ErrAuditOrganizationRequired
+	}
+	entry.OrganizationID = orgID
+
+	// Non-user actors (e.g. background jobs) are kept in metadata
+	if actor := firstNonEmpty(input.UserID, md.UserID); actor != "" {
+		if userID, err := primitive.ObjectIDFromHex(actor); err == nil {
+			entry.UserID = userID
+		} else {
+			if entry.Metadata == nil {
+				entry.Metadata = make(map[string]interface{})
+			}
+			entry.Metadata["actor"] = actor
+		}
+	}
+
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		s.logger.Error("Failed to write audit entry",
+			zap.Error(err),
+			zap.String("action", entry.Action),
+			zap.String("organization_id", orgID.Hex()),
+			zap.String("correlation_id", entry.CorrelationID),
+		)
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// GetAuditTrail retrieves audit entries of an organization matching the filter.
+//
+// Parameters:
+//   - ctx: Request context
+//   - filter: Filter with mandatory organization ID
+//
+// Returns:
+//   - []*models.AuditLog: Matching audit entries
+//   - error: Validation or retrieval error
+func (s *auditService) GetAuditTrail(ctx context.Context, filter *AuditFilter) ([]*models.AuditLog, error) {
+	if filter == nil || filter.OrganizationID == "" {
+		return nil, ErrAuditOrganizationRequired
+	}
+
+	repoFilter, err := toRepositoryAuditFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.auditRepo.GetByOrganization(ctx, filter.OrganizationID, repoFilter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit trail: %w", err)
+	}
+	return entries, nil
+}
+
+// GetUserActivity retrieves the most recent activity of a user, optionally
+// restricted to a time range. Results are limited to the caller's organization
+// when the request metadata identifies one.
+//
+// Parameters:
+//   - ctx: Request context
+//   - userID: User ID
+//   - timeRange: Optional time range
+//
+// Returns:
+//   - []*models.AuditLog: Audit entries of the user
+//   - error: Validation or retrieval error
+func (s *auditService) GetUserActivity(ctx context.Context, userID string, timeRange *TimeRange) ([]*models.AuditLog, error) {
+	window, err := parseTimeRange(timeRange)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.auditRepo.GetByUser(ctx, userID, maxAuditLimit, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user activity: %w", err)
+	}
+
+	return filterAuditEntries(ctx, entries, window), nil
+}
+
+// GetResourceActivity retrieves the audit history of a resource. Results are
+// limited to the caller's organization when the request metadata identifies one.
+//
+// Parameters:
+//   - ctx: Request context
+//   - resourceType: Resource type
+//   - resourceID: Resource ID
+//
+// Returns:
+//   - []*models.AuditLog: Audit entries of the resource
+//   - error: Retrieval error
+func (s *auditService) GetResourceActivity(ctx context.Context, resourceType, resourceID string) ([]*models.AuditLog, error) {
+	entries, err := s.auditRepo.GetByResource(ctx, resourceType, resourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource activity: %w", err)
+	}
+
+	return filterAuditEntries(ctx, entries, nil), nil
+}
+
+// ExportAuditLog exports the audit entries matching the filter in memory and
+// records the export in the audit trail. Exports with more rows than the
+// exporter's synchronous limit fail with ErrExportTooLarge and must be run as
+// background jobs through AuditExportService.
+//
+// Parameters:
+//   - ctx: Request context carrying the requesting user
+//   - filter: Audit filter with mandatory organization ID; pagination is ignored
+//   - format: "csv", "jsonl" or "pdf"
+//
+// Returns:
+//   - *Document: Export file with the manifest in Metadata["manifest"]
+//   - error: Validation, size or export error
+//
+// Example:
+//
+//	doc, err := auditService.ExportAuditLog(ctx, &AuditFilter{OrganizationID: orgID}, "csv")
+//	manifest := doc.Metadata["manifest"].(*models.ExportManifest)
+func (s *auditService) ExportAuditLog(ctx context.Context, filter *AuditFilter, format string) (*Document, error) {
+	if s.exporter == nil {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedExportFormat, format)
+	}
+
+	md, _ := requestctx.FromContext(ctx)
+	var buf bytes.Buffer
+	manifest, err := s.exporter.Export(ctx, filter, format, &buf, firstNonEmpty(md.UserID, models.SystemActorID), s.exporter.syncRowLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	exportID := primitive.NewObjectID().Hex()
+	if err := s.LogAction(ctx, exportAuditInput(manifest, exportID, filter)); err != nil {
+		// An export that cannot be audited must not leave the platform
+		return nil, err
+	}
+
+	return &Document{
+		ID:       exportID,
+		Name:     exportFileName(manifest),
+		Type:     exportContentTypes[format],
+		Content:  buf.Bytes(),
+		Metadata: map[string]interface{}{"manifest": manifest},
+	}, nil
+}
+
+// DiffValues reduces two snapshots of a resource to the fields that changed.
+// Fields only present in one snapshot are reported on that side.
+//
+// Parameters:
+//   - oldValues: Snapshot before the change
+//   - newValues: Snapshot after the change
+//
+// Returns:
+//   - map[string]interface{}: Previous values of changed fields
+//   - map[string]interface{}: New values of changed fields
+//
+// Example:
+//
+//	oldDiff, newDiff := DiffValues(
+//	    map[string]interface{}{"status": "active", "name": "A"},
+//	    map[string]interface{}{"status": "inactive", "name": "A"},
+//	) // {"status": "active"}, {"status": "inactive"}
+func DiffValues(oldValues, newValues map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	oldDiff := make(map[string]interface{})
+	newDiff := make(map[string]interface{})
+
+	for key, oldValue := range oldValues {
+		newValue, exists := newValues[key]
+		if !exists {
+			oldDiff[key] = oldValue
+			continue
+		}
+		if !reflect.DeepEqual(oldValue, newValue) {
+			oldDiff[key] = oldValue
+			newDiff[key] = newValue
+		}
+	}
+	for key, newValue := range newValues {
+		if _, exists := oldValues[key]; !exists {
+			newDiff[key] = newValue
+		}
+	}
+
+	return oldDiff, newDiff
+}
+
+// RedactValues returns a copy of values with sensitive fields such as passwords
+// and tokens replaced, descending into nested objects and arrays.
+//
+// Parameters:
+//   - values: Values to redact
+//
+// Returns:
+//   - map[string]interface{}: Redacted copy, nil if values is nil
+func RedactValues(values map[string]interface{}) map[string]interface{} {
+	if values == nil {
+		return nil
+	}
+
+	redacted := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		if isSensitiveKey(key) {
+			redacted[key] = redactedValue
+			continue
+		}
+		redacted[key] = redactValue(value)
+	}
+	return redacted
+}
+
+// redactValue redacts the objects within a value, e.g. the elements of
+// {"accounts": [{"login": "...", "password": "..."}]}.
+func redactValue(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		return RedactValues(typed)
+	case []interface{}:
+		redacted := make([]interface{}, len(typed))
+		for i, element := range typed {
+			redacted[i] = redactValue(element)
+		}
+		return redacted
+	case []map[string]interface{}:
+		redacted := make([]interface{}, len(typed))
+		for i, element := range typed {
+			redacted[i] = RedactValues(element)
+		}
+		return redacted
+	default:
+		return value
+	}
+}
+
+// isSensitiveKey reports whether a value key names a secret.
+func isSensitiveKey(key string) bool {
+	lower := strings.ToLower(key)
+	for _, fragment := range sensitiveKeyFragments {
+		if strings.Contains(lower, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// toRepositoryAuditFilter converts the service filter to the repository filter.
+func toRepositoryAuditFilter(filter *AuditFilter) (*repositories.AuditFilter, error) {
+	window, err := parseTimeRange(filter.TimeRange)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultAuditLimit
+	}
+	if limit > maxAuditLimit {
+		limit = maxAuditLimit
+	}
+
+	return &repositories.AuditFilter{
+		UserID:       filter.UserID,
+		Action:       filter.Action,
+		ResourceType: filter.ResourceType,
+		ResourceID:   filter.ResourceID,
+		TimeRange:    window,
+		Limit:        limit,
+		Offset:       filter.Offset,
+		SortBy:       "timestamp",
+		SortOrder:    "desc",
+	}, nil
+}
+
+// parseTimeRange converts an RFC 3339 time range; a nil range yields nil.
+func parseTimeRange(timeRange *TimeRange) (*repositories.TimeRange, error) {
+	if timeRange == nil {
+		return nil, nil
+	}
+
+	start, err := time.Parse(time.RFC3339, timeRange.Start)
+	if err != nil {
+		return nil, ErrInvalidTimeRange
+	}
+	end, err := time.Parse(time.RFC3339, timeRange.End)
+	if err != nil || end.Before(start) {
+		return nil, ErrInvalidTimeRange
+	}
+
+	return &repositories.TimeRange{Start: start, End: end}, nil
+}
+
+// filterAuditEntries restricts entries to the caller's organization (if known)
+// and to the given time window (if any).
+func filterAuditEntries(ctx context.Context, entries []*models.AuditLog, window *repositories.TimeRange) []*models.AuditLog {
+	md, _ := requestctx.FromContext(ctx)
+
+	filtered := make([]*models.AuditLog, 0, len(entries))
+	for _, entry := range entries {
+		if md.OrganizationID != "" && entry.OrganizationID.Hex() != md.OrganizationID {
+			continue
+		}
+		if window != nil && (entry.Timestamp.Before(window.Start) || entry.Timestamp.After(window.End)) {
+			continue
+		}
+		filtered = append(filtered, entry)
+	}
+	return filtered
+}
+
+// firstNonEmpty returns the first non-empty string.
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
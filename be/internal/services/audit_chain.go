@@ -0,0 +1,552 @@
+// Package services provides service layer implementations for the GoEdu Control Testing Platform.
+// This file contains the tamper-evident audit chain: the repository decorator that
+// links every audit entry to its predecessor, chain verification and signed checkpoints.
+package services
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/auditchain"
+)
+
+// NotaryExportFormat identifies the layout of notary export files.
+const NotaryExportFormat = "goedu-audit-checkpoints/v1"
+
+// maxChainAppendAttempts bounds retries when another instance appends to the
+// same chain concurrently and the sequence number is already taken.
+const maxChainAppendAttempts = 5
+
+// Audit chain errors
+var (
+	ErrAuditChainContention      = errors.New("audit chain is being appended concurrently, try again")
+	ErrCheckpointSigningDisabled = errors.New("audit checkpoint signing key is not configured")
+)
+
+// errChainBroken stops chain iteration once the first break has been found.
+var errChainBroken = errors.New("audit chain broken")
+
+// AuditEntryHash computes the chain hash of an audit entry from its sequence,
+// previous hash and content.
+//
+// Parameters:
+//   - entry: Audit entry with Sequence and PreviousHash set
+//
+// Returns:
+//   - string: Hex encoded entry hash
+//   - error: Error if the entry cannot be encoded
+func AuditEntryHash(entry *models.AuditLog) (string, error) {
+	return auditchain.ComputeHash(entry.PreviousHash, entry.Sequence, entry.ChainFields())
+}
+
+// hashChainedAuditLogRepository decorates an AuditLogRepository so that every
+// created entry is appended to its organization's hash chain. All other
+// methods are served by the wrapped repository.
+type hashChainedAuditLogRepository struct {
+	repositories.AuditLogRepository
+	locks  sync.Map // organization ID -> *sync.Mutex
+	logger *zap.Logger
+}
+
+// NewHashChainedAuditLogRepository wraps an audit log repository with hash chaining.
+// Every service that writes audit entries should receive the wrapped repository so
+// no entry bypasses the chain.
+//
+// Parameters:
+//   - inner: Repository persisting the entries
+//   - logger: Logger for chain operations
+//
+// Returns:
+//   - repositories.AuditLogRepository: Chaining repository
+//
+// Example:
+//
+//	auditRepo := services.NewHashChainedAuditLogRepository(mongoAuditRepo, logger)
+//	auditService := services.NewAuditService(auditRepo, exporter, logger)
+func NewHashChainedAuditLogRepository(inner repositories.AuditLogRepository, logger *zap.Logger) repositories.AuditLogRepository {
+	return &hashChainedAuditLogRepository{
+		AuditLogRepository: inner,
+		logger:             logger,
+	}
+}
+
+// Create assigns the entry its sequence number, previous hash and hash, then
+// persists it. Appends within this process are serialized per organization;
+// appends from other instances are detected through the unique sequence index
+// and retried against the new chain head.
+func (r *hashChainedAuditLogRepository) Create(ctx context.Context, entry *models.AuditLog) error {
+	if entry.OrganizationID.IsZero() {
+		return ErrAuditOrganizationRequired
+	}
+	orgID := entry.OrganizationID.Hex()
+
+	lock, _ := r.locks.LoadOrStore(orgID, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	// The ID and timestamp are covered by the hash, so they must be final and
+	// stored at the precision the database keeps.
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	entry.Timestamp = entry.Timestamp.UTC().Truncate(time.Millisecond)
+
+	for attempt := 0; attempt < maxChainAppendAttempts; attempt++ {
+		entry.Sequence = 1
+		entry.PreviousHash = auditchain.GenesisHash
+
+		head, err := r.AuditLogRepository.GetChainHead(ctx, orgID)
+		switch {
+		case err == nil:
+			entry.Sequence = head.Sequence + 1
+			entry.PreviousHash = head.Hash
+		case !errors.Is(err, repositories.ErrNotFound):
+			return fmt.Errorf("failed to load audit chain head: %w", err)
+		}
+
+		hash, err := AuditEntryHash(entry)
+		if err != nil {
+			return err
+		}
+		entry.Hash = hash
+
+		err = r.AuditLogRepository.Create(ctx, entry)
+		if errors.Is(err, repositories.ErrDuplicate) {
+			r.logger.Debug("Audit chain sequence taken by another writer, retrying",
+				zap.String("organization_id", orgID),
+				zap.Int64("sequence", entry.Sequence),
+			)
+			continue
+		}
+		return err
+	}
+
+	return ErrAuditChainContention
+}
+
+// AuditChainVerifier checks audit entries of one organization fed to it in
+// ascending sequence order. It is shared by the verification endpoint and the
+// offline verification command.
+type AuditChainVerifier struct {
+	publicKey   ed25519.PublicKey
+	checkpoints map[int64]*models.AuditCheckpoint
+	result      *ChainVerificationResult
+	last        *models.AuditLog
+}
+
+// NewAuditChainVerifier creates a verifier for an organization's chain.
+//
+// Parameters:
+//   - orgID: Organization whose chain is verified
+//   - checkpoints: Known checkpoints of the organization
+//   - publicKey: Key for checkpoint signatures; nil skips signature checks
+//
+// Returns:
+//   - *AuditChainVerifier: Verifier ready to receive entries
+//
+// Example:
+//
+//	verifier := services.NewAuditChainVerifier(orgID, checkpoints, publicKey)
+//	for _, entry := range entries {
+//	    if !verifier.Verify(entry) {
+//	        break
+//	    }
+//	}
+//	result := verifier.Result()
+func NewAuditChainVerifier(orgID string, checkpoints []*models.AuditCheckpoint, publicKey ed25519.PublicKey) *AuditChainVerifier {
+	bySequence := make(map[int64]*models.AuditCheckpoint, len(checkpoints))
+	for _, checkpoint := range checkpoints {
+		bySequence[checkpoint.Sequence] = checkpoint
+	}
+
+	return &AuditChainVerifier{
+		publicKey:   publicKey,
+		checkpoints: bySequence,
+		result: &ChainVerificationResult{
+			OrganizationID: orgID,
+			Valid:          true,
+		},
+	}
+}
+
+// Verify checks the next entry of the chain.
+//
+// Parameters:
+//   - entry: Next entry in ascending sequence order
+//
+// Returns:
+//   - bool: False once a break has been found; further entries are ignored
+func (v *AuditChainVerifier) Verify(entry *models.AuditLog) bool {
+	if v.result.FirstBreak != nil {
+		return false
+	}
+
+	if v.last == nil {
+		if !v.verifyStart(entry) {
+			return false
+		}
+	} else {
+		if entry.Sequence != v.last.Sequence+1 {
+			return v.fail(entry, ChainBreakSequenceGap, fmt.Sprint(v.last.Sequence+1), fmt.Sprint(entry.Sequence))
+		}
+		if entry.PreviousHash != v.last.Hash {
+			return v.fail(entry, ChainBreakPreviousHash, v.last.Hash, entry.PreviousHash)
+		}
+	}
+
+	hash, err := AuditEntryHash(entry)
+	if err != nil || hash != entry.Hash {
+		return v.fail(entry, ChainBreakHashMismatch, hash, entry.Hash)
+	}
+
+	if checkpoint, ok := v.checkpoints[entry.Sequence]; ok {
+		if !v.verifyCheckpoint(entry, checkpoint) {
+			return false
+		}
+	}
+
+	v.last = entry
+	v.result.EntriesVerified++
+	v.result.HeadSequence = entry.Sequence
+	v.result.HeadHash = entry.Hash
+	return true
+}
+
+// Result completes the verification and returns its outcome. Checkpoints beyond
+// the last verified entry mean entries were removed from the end of the chain.
+func (v *AuditChainVerifier) Result() *ChainVerificationResult {
+	if v.result.FirstBreak == nil {
+		var truncated *models.AuditCheckpoint
+		for sequence, checkpoint := range v.checkpoints {
+			if sequence > v.result.HeadSequence && (truncated == nil || sequence < truncated.Sequence) {
+				truncated = checkpoint
+			}
+		}
+		if truncated != nil {
+			v.result.Valid = false
+			v.result.FirstBreak = &ChainBreak{
+				Sequence: v.result.HeadSequence + 1,
+				Reason:   ChainBreakTruncated,
+				Expected: truncated.Hash,
+			}
+		}
+	}
+
+	v.result.VerifiedAt = time.Now()
+	return v.result
+}
+
+// verifyStart checks the first entry: it either starts the chain or continues
+// from a checkpointed entry that has been purged under the retention policy.
+func (v *AuditChainVerifier) verifyStart(entry *models.AuditLog) bool {
+	v.result.FirstSequence = entry.Sequence
+
+	if entry.Sequence == 1 {
+		if entry.PreviousHash != auditchain.GenesisHash {
+			return v.fail(entry, ChainBreakPreviousHash, auditchain.GenesisHash, entry.PreviousHash)
+		}
+		return true
+	}
+
+	anchor, ok := v.checkpoints[entry.Sequence-1]
+	if !ok {
+		return v.fail(entry, ChainBreakUnanchoredStart, "", entry.PreviousHash)
+	}
+	if anchor.Hash != entry.PreviousHash {
+		return v.fail(entry, ChainBreakPreviousHash, anchor.Hash, entry.PreviousHash)
+	}
+	if !v.signatureValid(anchor) {
+		return v.fail(entry, ChainBreakCheckpointSignature, "", anchor.Signature)
+	}
+	v.result.CheckpointsVerified++
+	return true
+}
+
+// verifyCheckpoint compares a checkpoint with the entry at its sequence.
+func (v *AuditChainVerifier) verifyCheckpoint(entry *models.AuditLog, checkpoint *models.AuditCheckpoint) bool {
+	if checkpoint.Hash != entry.Hash {
+		return v.fail(entry, ChainBreakCheckpointMismatch, checkpoint.Hash, entry.Hash)
+	}
+	if !v.signatureValid(checkpoint) {
+		return v.fail(entry, ChainBreakCheckpointSignature, "", checkpoint.Signature)
+	}
+	v.result.CheckpointsVerified++
+	return true
+}
+
+// signatureValid checks a checkpoint signature, if a public key is configured.
+func (v *AuditChainVerifier) signatureValid(checkpoint *models.AuditCheckpoint) bool {
+	if v.publicKey == nil {
+		return true
+	}
+	return auditchain.VerifySignature(v.publicKey, checkpointMessage(checkpoint), checkpoint.Signature)
+}
+
+// fail records the first break and stops verification.
+func (v *AuditChainVerifier) fail(entry *models.AuditLog, reason, expected, actual string) bool {
+	v.result.Valid = false
+	v.result.FirstBreak = &ChainBreak{
+		Sequence: entry.Sequence,
+		EntryID:  entry.ID.Hex(),
+		Reason:   reason,
+		Expected: expected,
+		Actual:   actual,
+	}
+	return false
+}
+
+// checkpointMessage converts a stored checkpoint into its signed form.
+func checkpointMessage(checkpoint *models.AuditCheckpoint) auditchain.Checkpoint {
+	return auditchain.Checkpoint{
+		OrganizationID: checkpoint.OrganizationID.Hex(),
+		Sequence:       checkpoint.Sequence,
+		Hash:           checkpoint.Hash,
+		CreatedAt:      checkpoint.CreatedAt,
+	}
+}
+
+// BuildNotaryExport assembles the notary export file for a set of checkpoints.
+//
+// Parameters:
+//   - orgID: Organization the checkpoints belong to
+//   - keyID: Identifier of the signing key
+//   - publicKey: Public half of the signing key
+//   - checkpoints: Checkpoints ordered by sequence
+//
+// Returns:
+//   - *NotaryExport: Export file content
+func BuildNotaryExport(orgID, keyID string, publicKey ed25519.PublicKey, checkpoints []*models.AuditCheckpoint) *NotaryExport {
+	export := &NotaryExport{
+		Format:         NotaryExportFormat,
+		Algorithm:      auditchain.Algorithm,
+		KeyID:          keyID,
+		PublicKey:      auditchain.EncodePublicKey(publicKey),
+		OrganizationID: orgID,
+		ExportedAt:     time.Now().UTC(),
+		Checkpoints:    make([]NotaryCheckpoint, 0, len(checkpoints)),
+	}
+	for _, checkpoint := range checkpoints {
+		export.Checkpoints = append(export.Checkpoints, NotaryCheckpoint{
+			Sequence:  checkpoint.Sequence,
+			Hash:      checkpoint.Hash,
+			CreatedAt: checkpoint.CreatedAt.UTC(),
+			KeyID:     checkpoint.KeyID,
+			Signature: checkpoint.Signature,
+		})
+	}
+	return export
+}
+
+// auditChainService implements the AuditChainService interface.
+type auditChainService struct {
+	orgRepo        repositories.OrganizationRepository
+	auditRepo      repositories.AuditLogRepository
+	checkpointRepo repositories.AuditCheckpointRepository
+	signingKey     ed25519.PrivateKey
+	keyID          string
+	logger         *zap.Logger
+}
+
+// NewAuditChainService creates a new audit chain service.
+//
+// Parameters:
+//   - orgRepo: Repository for listing active organizations
+//   - auditRepo: Repository for reading audit entries
+//   - checkpointRepo: Repository for checkpoint persistence
+//   - signingKey: Checkpoint signing key; nil disables checkpoint creation and export
+//   - keyID: Identifier of the signing key recorded on every checkpoint
+//   - logger: Logger for service operations
+//
+// Returns:
+//   - AuditChainService: Configured audit chain service instance
+func NewAuditChainService(
+	orgRepo repositories.OrganizationRepository,
+	auditRepo repositories.AuditLogRepository,
+	checkpointRepo repositories.AuditCheckpointRepository,
+	signingKey ed25519.PrivateKey,
+	keyID string,
+	logger *zap.Logger,
+) AuditChainService {
+	return &auditChainService{
+		orgRepo:        orgRepo,
+		auditRepo:      auditRepo,
+		checkpointRepo: checkpointRepo,
+		signingKey:     signingKey,
+		keyID:          keyID,
+		logger:         logger,
+	}
+}
+
+// VerifyChain walks an organization's chain from its first stored entry to its
+// head and reports the first break. A broken chain is a valid result, not an error.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID
+//
+// Returns:
+//   - *ChainVerificationResult: Verification outcome
+//   - error: Error if entries or checkpoints cannot be read
+func (s *auditChainService) VerifyChain(ctx context.Context, orgID string) (*ChainVerificationResult, error) {
+	checkpoints, err := s.checkpointRepo.GetByOrganization(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load audit checkpoints: %w", err)
+	}
+
+	verifier := NewAuditChainVerifier(orgID, checkpoints, s.publicKey())
+	err = s.auditRepo.IterateChain(ctx, orgID, 1, func(entry *models.AuditLog) error {
+		if !verifier.Verify(entry) {
+			return errChainBroken
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errChainBroken) {
+		return nil, fmt.Errorf("failed to read audit chain: %w", err)
+	}
+
+	result := verifier.Result()
+	if !result.Valid {
+		s.logger.Error("Audit chain verification failed",
+			zap.String("organization_id", orgID),
+			zap.Int64("sequence", result.FirstBreak.Sequence),
+			zap.String("reason", result.FirstBreak.Reason),
+		)
+	}
+	return result, nil
+}
+
+// CreateCheckpoint signs the current head of an organization's chain.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID
+//
+// Returns:
+//   - *models.AuditCheckpoint: New checkpoint, nil if the chain is empty or the head is already checkpointed
+//   - error: ErrCheckpointSigningDisabled or persistence error
+func (s *auditChainService) CreateCheckpoint(ctx context.Context, orgID string) (*models.AuditCheckpoint, error) {
+	if s.signingKey == nil {
+		return nil, ErrCheckpointSigningDisabled
+	}
+
+	head, err := s.auditRepo.GetChainHead(ctx, orgID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load audit chain head: %w", err)
+	}
+
+	latest, err := s.checkpointRepo.GetLatest(ctx, orgID)
+	switch {
+	case err == nil && latest.Sequence >= head.Sequence:
+		return nil, nil
+	case err != nil && !errors.Is(err, repositories.ErrNotFound):
+		return nil, fmt.Errorf("failed to load latest audit checkpoint: %w", err)
+	}
+
+	checkpoint := &models.AuditCheckpoint{
+		ID:             primitive.NewObjectID(),
+		OrganizationID: head.OrganizationID,
+		Sequence:       head.Sequence,
+		Hash:           head.Hash,
+		KeyID:          s.keyID,
+		CreatedAt:      time.Now().UTC().Truncate(time.Millisecond),
+	}
+	checkpoint.Signature = auditchain.Sign(s.signingKey, checkpointMessage(checkpoint))
+
+	if err := s.checkpointRepo.Create(ctx, checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to store audit checkpoint: %w", err)
+	}
+
+	s.logger.Info("Audit checkpoint created",
+		zap.String("organization_id", orgID),
+		zap.Int64("sequence", checkpoint.Sequence),
+	)
+	return checkpoint, nil
+}
+
+// CheckpointAll creates checkpoints for every active organization. Failures for
+// a single organization are logged and do not abort the run.
+//
+// Parameters:
+//   - ctx: Request context
+//
+// Returns:
+//   - int: Number of checkpoints created
+//   - error: Error if organizations cannot be listed or signing is disabled
+func (s *auditChainService) CheckpointAll(ctx context.Context) (int, error) {
+	if s.signingKey == nil {
+		return 0, ErrCheckpointSigningDisabled
+	}
+
+	created := 0
+	for offset := 0; ; offset += lifecycleOrganizationPageSize {
+		orgs, err := s.orgRepo.GetActiveOrganizations(ctx, lifecycleOrganizationPageSize, offset)
+		if err != nil {
+			return created, fmt.Errorf("failed to list active organizations: %w", err)
+		}
+
+		for _, org := range orgs {
+			checkpoint, err := s.CreateCheckpoint(ctx, org.ID.Hex())
+			if err != nil {
+				s.logger.Error("Audit checkpoint failed for organization",
+					zap.Error(err),
+					zap.String("organization_id", org.ID.Hex()),
+				)
+				continue
+			}
+			if checkpoint != nil {
+				created++
+			}
+		}
+
+		if len(orgs) < lifecycleOrganizationPageSize {
+			break
+		}
+	}
+
+	return created, nil
+}
+
+// ExportCheckpoints builds the notary export file of an organization.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID
+//
+// Returns:
+//   - *NotaryExport: Export file content
+//   - error: ErrCheckpointSigningDisabled or repository error
+func (s *auditChainService) ExportCheckpoints(ctx context.Context, orgID string) (*NotaryExport, error) {
+	if s.signingKey == nil {
+		return nil, ErrCheckpointSigningDisabled
+	}
+
+	checkpoints, err := s.checkpointRepo.GetByOrganization(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load audit checkpoints: %w", err)
+	}
+
+	return BuildNotaryExport(orgID, s.keyID, s.publicKey(), checkpoints), nil
+}
+
+// publicKey returns the public half of the signing key, or nil.
+func (s *auditChainService) publicKey() ed25519.PublicKey {
+	if s.signingKey == nil {
+		return nil
+	}
+	return s.signingKey.Public().(ed25519.PublicKey)
+}
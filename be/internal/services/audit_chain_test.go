@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"crypto/ed25519"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/auditchain"
+)
+
+func newTestSigningKey() ed25519.PrivateKey {
+	seed := make([]byte, ed25519.SeedSize)
+	for i := range seed {
+		seed[i] = byte(i + 1)
+	}
+	return ed25519.NewKeyFromSeed(seed)
+}
+
+// appendEntries writes n audit entries for an organization through the chaining repository
+func appendEntries(t *testing.T, repo *fakeAuditLogRepository, orgID primitive.ObjectID, n int) {
+	t.Helper()
+	chained := NewHashChainedAuditLogRepository(repo, zap.NewNop())
+	for i := 0; i < n; i++ {
+		require.NoError(t, chained.Create(context.Background(), &models.AuditLog{
+			OrganizationID: orgID,
+			Action:         "control.updated",
+			ResourceType:   "control",
+			ResourceID:     "C-1",
+			NewValues:      map[string]interface{}{"revision": i, "tags": []interface{}{"a"}},
+			Success:        true,
+		}))
+	}
+}
+
+func TestHashChainedAuditLogRepository_LinksEntries(t *testing.T) {
+	repo := &fakeAuditLogRepository{}
+	orgID := primitive.NewObjectID()
+	otherOrg := primitive.NewObjectID()
+
+	appendEntries(t, repo, orgID, 3)
+	appendEntries(t, repo, otherOrg, 1)
+
+	chain := repo.chain(orgID.Hex())
+	require.Len(t, chain, 3)
+	assert.Equal(t, auditchain.GenesisHash, chain[0].PreviousHash)
+	for i, entry := range chain {
+		assert.Equal(t, int64(i+1), entry.Sequence)
+		assert.False(t, entry.ID.IsZero())
+		if i > 0 {
+			assert.Equal(t, chain[i-1].Hash, entry.PreviousHash)
+		}
+	}
+	assert.Equal(t, int64(1), repo.chain(otherOrg.Hex())[0].Sequence, "chains are per organization")
+}
+
+func TestHashChainedAuditLogRepository_ConcurrentWritersKeepChainIntact(t *testing.T) {
+	repo := &fakeAuditLogRepository{}
+	orgID := primitive.NewObjectID()
+
+	// Two wrappers over the same store simulate two API instances
+	instances := []interface {
+		Create(context.Context, *models.AuditLog) error
+	}{
+		NewHashChainedAuditLogRepository(repo, zap.NewNop()),
+		NewHashChainedAuditLogRepository(repo, zap.NewNop()),
+	}
+
+	var wg sync.WaitGroup
+	var failures sync.Map
+	for i := 0; i < 20; i++ {
+		for _, instance := range instances {
+			wg.Add(1)
+			go func(instance interface {
+				Create(context.Context, *models.AuditLog) error
+			}) {
+				defer wg.Done()
+				err := instance.Create(context.Background(), &models.AuditLog{OrganizationID: orgID, Action: "x", ResourceType: "y"})
+				if err != nil {
+					failures.Store(err.Error(), true)
+				}
+			}(instance)
+		}
+	}
+	wg.Wait()
+
+	service := NewAuditChainService(nil, repo, &fakeAuditCheckpointRepository{}, nil, "", zap.NewNop())
+	result, err := service.VerifyChain(context.Background(), orgID.Hex())
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, int64(len(repo.chain(orgID.Hex()))), result.EntriesVerified)
+
+	failures.Range(func(key, _ interface{}) bool {
+		assert.Equal(t, ErrAuditChainContention.Error(), key, "only contention may fail an append")
+		return true
+	})
+}
+
+func TestAuditChainService_VerifyChainReportsFirstBreak(t *testing.T) {
+	orgID := primitive.NewObjectID()
+
+	tests := []struct {
+		name           string
+		tamper         func(chain []*models.AuditLog, repo *fakeAuditLogRepository)
+		expectedSeq    int64
+		expectedReason string
+	}{
+		{
+			name:           "intact chain",
+			tamper:         func([]*models.AuditLog, *fakeAuditLogRepository) {},
+			expectedReason: "",
+		},
+		{
+			name: "modified content",
+			tamper: func(chain []*models.AuditLog, _ *fakeAuditLogRepository) {
+				chain[1].NewValues["revision"] = 99
+			},
+			expectedSeq:    2,
+			expectedReason: ChainBreakHashMismatch,
+		},
+		{
+			name: "recomputed hash still breaks the next link",
+			tamper: func(chain []*models.AuditLog, _ *fakeAuditLogRepository) {
+				chain[1].Action = "control.deleted"
+				chain[1].Hash, _ = AuditEntryHash(chain[1])
+			},
+			expectedSeq:    3,
+			expectedReason: ChainBreakPreviousHash,
+		},
+		{
+			name: "deleted entry",
+			tamper: func(chain []*models.AuditLog, repo *fakeAuditLogRepository) {
+				repo.entries = append(repo.entries[:1], repo.entries[2:]...)
+			},
+			expectedSeq:    3,
+			expectedReason: ChainBreakSequenceGap,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeAuditLogRepository{}
+			appendEntries(t, repo, orgID, 4)
+			tt.tamper(repo.chain(orgID.Hex()), repo)
+
+			service := NewAuditChainService(nil, repo, &fakeAuditCheckpointRepository{}, nil, "", zap.NewNop())
+			result, err := service.VerifyChain(context.Background(), orgID.Hex())
+			require.NoError(t, err)
+
+			if tt.expectedReason == "" {
+				assert.True(t, result.Valid)
+				assert.Equal(t, int64(4), result.EntriesVerified)
+				assert.Nil(t, result.FirstBreak)
+				return
+			}
+			assert.False(t, result.Valid)
+			require.NotNil(t, result.FirstBreak)
+			assert.Equal(t, tt.expectedSeq, result.FirstBreak.Sequence)
+			assert.Equal(t, tt.expectedReason, result.FirstBreak.Reason)
+		})
+	}
+}
+
+func TestAuditChainService_Checkpoints(t *testing.T) {
+	ctx := context.Background()
+	orgID := primitive.NewObjectID()
+	org := &models.Organization{Status: models.OrganizationStatusActive}
+	org.ID = orgID
+	repo := &fakeAuditLogRepository{}
+	checkpoints := &fakeAuditCheckpointRepository{}
+	key := newTestSigningKey()
+	service := NewAuditChainService(newFakeOrganizationRepository(org), repo, checkpoints, key, "key-1", zap.NewNop())
+
+	// Empty chain: nothing to checkpoint
+	created, err := service.CheckpointAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, created)
+
+	appendEntries(t, repo, orgID, 3)
+	checkpoint, err := service.CreateCheckpoint(ctx, orgID.Hex())
+	require.NoError(t, err)
+	require.NotNil(t, checkpoint)
+	assert.Equal(t, int64(3), checkpoint.Sequence)
+	assert.Equal(t, "key-1", checkpoint.KeyID)
+
+	// Head already checkpointed
+	again, err := service.CreateCheckpoint(ctx, orgID.Hex())
+	require.NoError(t, err)
+	assert.Nil(t, again)
+
+	export, err := service.ExportCheckpoints(ctx, orgID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, NotaryExportFormat, export.Format)
+	require.Len(t, export.Checkpoints, 1)
+	assert.True(t, auditchain.VerifySignature(key.Public().(ed25519.PublicKey), auditchain.Checkpoint{
+		OrganizationID: export.OrganizationID,
+		Sequence:       export.Checkpoints[0].Sequence,
+		Hash:           export.Checkpoints[0].Hash,
+		CreatedAt:      export.Checkpoints[0].CreatedAt,
+	}, export.Checkpoints[0].Signature), "notary must be able to verify the export on its own")
+
+	result, err := service.VerifyChain(ctx, orgID.Hex())
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, 1, result.CheckpointsVerified)
+
+	// Removing checkpointed entries from the end is detected
+	repo.entries = repo.entries[:1]
+	result, err = service.VerifyChain(ctx, orgID.Hex())
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, ChainBreakTruncated, result.FirstBreak.Reason)
+	assert.Equal(t, int64(2), result.FirstBreak.Sequence)
+}
+
+func TestAuditChainService_ForgedCheckpointIsDetected(t *testing.T) {
+	ctx := context.Background()
+	orgID := primitive.NewObjectID()
+	repo := &fakeAuditLogRepository{}
+	appendEntries(t, repo, orgID, 2)
+	head := repo.chain(orgID.Hex())[1]
+
+	checkpoints := &fakeAuditCheckpointRepository{}
+	require.NoError(t, checkpoints.Create(ctx, &models.AuditCheckpoint{
+		OrganizationID: orgID,
+		Sequence:       head.Sequence,
+		Hash:           head.Hash,
+		Signature:      "Zm9yZ2Vk",
+	}))
+
+	service := NewAuditChainService(nil, repo, checkpoints, newTestSigningKey(), "key-1", zap.NewNop())
+	result, err := service.VerifyChain(ctx, orgID.Hex())
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, ChainBreakCheckpointSignature, result.FirstBreak.Reason)
+
+	_, err = NewAuditChainService(nil, repo, checkpoints, nil, "", zap.NewNop()).CreateCheckpoint(ctx, orgID.Hex())
+	assert.ErrorIs(t, err, ErrCheckpointSigningDisabled)
+}
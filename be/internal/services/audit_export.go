@@ -0,0 +1,692 @@
+// Package services provides service layer implementations for the GoEdu Control Testing Platform.
+// This file contains audit log exports: streaming CSV, JSON Lines and PDF writers,
+// signed export manifests and background export jobs with download links.
+package services
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/auditchain"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/pdf"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/storage"
+)
+
+// AuditActionLogExported is recorded whenever audit entries leave the platform.
+const AuditActionLogExported = "audit_log.exported"
+
+// Audit export errors
+var (
+	ErrExportTooLarge    = errors.New("export exceeds the synchronous row limit, request a background export instead")
+	ErrExportNotFound    = errors.New("export not found")
+	ErrExportNotReady    = errors.New("export is not ready for download")
+	ErrExportLinkInvalid = errors.New("export download link is invalid or has expired")
+)
+
+// errExportRowLimit stops streaming once the row limit is exceeded.
+var errExportRowLimit = errors.New("export row limit reached")
+
+// exportContentTypes lists the supported export formats and their MIME types.
+var exportContentTypes = map[string]string{
+	models.ExportFormatCSV:   "text/csv",
+	models.ExportFormatJSONL: "application/x-ndjson",
+	models.ExportFormatPDF:   "application/pdf",
+}
+
+// auditCSVHeader lists the CSV columns in order.
+var auditCSVHeader = []string{
+	"sequence", "id", "timestamp", "organization_id", "user_id", "actor", "action",
+	"resource_type", "resource_id", "success", "error_message", "ip_address",
+	"user_agent", "correlation_id", "old_values", "new_values", "metadata", "hash",
+}
+
+// AuditExporter streams audit entries matching a filter into an export file
+// and produces its manifest. It is shared by synchronous exports and
+// background export jobs.
+type AuditExporter struct {
+	auditRepo    repositories.AuditLogRepository
+	signingKey   ed25519.PrivateKey
+	keyID        string
+	syncRowLimit int64
+}
+
+// NewAuditExporter creates a new audit exporter.
+//
+// Parameters:
+//   - auditRepo: Repository streaming the audit entries
+//   - signingKey: Key signing export manifests; nil leaves manifests unsigned
+//   - keyID: Identifier of the signing key
+//   - syncRowLimit: Maximum rows of a synchronous export; zero means unlimited
+//
+// Returns:
+//   - *AuditExporter: Configured exporter
+func NewAuditExporter(auditRepo repositories.AuditLogRepository, signingKey ed25519.PrivateKey, keyID string, syncRowLimit int64) *AuditExporter {
+	return &AuditExporter{
+		auditRepo:    auditRepo,
+		signingKey:   signingKey,
+		keyID:        keyID,
+		syncRowLimit: syncRowLimit,
+	}
+}
+
+// Export streams the entries matching the filter to w in the given format.
+//
+// Parameters:
+//   - ctx: Request context
+//   - filter: Audit filter with mandatory organization ID; pagination is ignored
+//   - format: One of the ExportFormat constants
+//   - w: Destination of the export file
+//   - generatedBy: Actor recorded in the manifest
+//   - maxRows: Row limit, ErrExportTooLarge is returned when exceeded; zero means unlimited
+//
+// Returns:
+//   - *models.ExportManifest: Manifest of the written file
+//   - error: Validation, streaming or write error
+func (e *AuditExporter) Export(ctx context.Context, filter *AuditFilter, format string, w io.Writer, generatedBy string, maxRows int64) (*models.ExportManifest, error) {
+	if filter == nil || filter.OrganizationID == "" {
+		return nil, ErrAuditOrganizationRequired
+	}
+	if _, ok := exportContentTypes[format]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedExportFormat, format)
+	}
+	window, err := parseTimeRange(filter.TimeRange)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &models.ExportManifest{
+		Format:         format,
+		OrganizationID: filter.OrganizationID,
+		GeneratedAt:    time.Now().UTC().Truncate(time.Millisecond),
+		GeneratedBy:    generatedBy,
+	}
+	if window != nil {
+		manifest.RangeStart = window.Start.UTC()
+		manifest.RangeEnd = window.End.UTC()
+	}
+
+	digest := sha256.New()
+	counter := &byteCounter{}
+	out := io.MultiWriter(w, digest, counter)
+
+	writer, err := newAuditEntryWriter(format, out, filter, manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	repoFilter := &repositories.AuditFilter{
+		UserID:       filter.UserID,
+		Action:       filter.Action,
+		ResourceType: filter.ResourceType,
+		ResourceID:   filter.ResourceID,
+		TimeRange:    window,
+		SortBy:       "timestamp",
+		SortOrder:    "asc",
+	}
+	err = e.auditRepo.Stream(ctx, filter.OrganizationID, repoFilter, func(entry *models.AuditLog) error {
+		if maxRows > 0 && manifest.RowCount >= maxRows {
+			return errExportRowLimit
+		}
+		if manifest.RowCount == 0 {
+			manifest.FirstEntryAt = entry.Timestamp.UTC()
+		}
+		manifest.LastEntryAt = entry.Timestamp.UTC()
+		manifest.RowCount++
+		return writer.Write(entry)
+	})
+	if errors.Is(err, errExportRowLimit) {
+		return nil, ErrExportTooLarge
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stream audit entries: %w", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish export: %w", err)
+	}
+
+	manifest.SizeBytes = counter.n
+	manifest.SHA256 = hex.EncodeToString(digest.Sum(nil))
+	if e.signingKey != nil {
+		manifest.KeyID = e.keyID
+		manifest.Signature = auditchain.Sign(e.signingKey, ExportStatement(manifest))
+	}
+	return manifest, nil
+}
+
+// ExportStatement returns the statement covered by a manifest signature.
+//
+// Parameters:
+//   - manifest: Export manifest
+//
+// Returns:
+//   - auditchain.ExportStatement: Signed statement
+func ExportStatement(manifest *models.ExportManifest) auditchain.ExportStatement {
+	return auditchain.ExportStatement{
+		OrganizationID: manifest.OrganizationID,
+		Format:         manifest.Format,
+		RowCount:       manifest.RowCount,
+		SHA256:         manifest.SHA256,
+		GeneratedAt:    manifest.GeneratedAt,
+	}
+}
+
+// auditEntryWriter writes audit entries in one export format.
+type auditEntryWriter interface {
+	Write(entry *models.AuditLog) error
+	Close() error
+}
+
+// newAuditEntryWriter creates the writer for a format.
+func newAuditEntryWriter(format string, w io.Writer, filter *AuditFilter, manifest *models.ExportManifest) (auditEntryWriter, error) {
+	switch format {
+	case models.ExportFormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(auditCSVHeader); err != nil {
+			return nil, err
+		}
+		return &csvAuditWriter{w: cw}, nil
+	case models.ExportFormatJSONL:
+		return &jsonlAuditWriter{enc: json.NewEncoder(w)}, nil
+	case models.ExportFormatPDF:
+		return newPDFAuditWriter(w, filter, manifest)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedExportFormat, format)
+	}
+}
+
+// csvAuditWriter writes one CSV row per entry; structured values are JSON encoded.
+// Cells that spreadsheet applications would evaluate as formulas are escaped.
+type csvAuditWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvAuditWriter) Write(entry *models.AuditLog) error {
+	oldValues, err := jsonCell(entry.OldValues)
+	if err != nil {
+		return err
+	}
+	newValues, err := jsonCell(entry.NewValues)
+	if err != nil {
+		return err
+	}
+	metadata, err := jsonCell(entry.Metadata)
+	if err != nil {
+		return err
+	}
+
+	row := []string{
+		strconv.FormatInt(entry.Sequence, 10),
+		entry.ID.Hex(),
+		entry.Timestamp.UTC().Format(time.RFC3339Nano),
+		entry.OrganizationID.Hex(),
+		objectIDCell(entry.UserID),
+		auditActor(entry),
+		entry.Action,
+		entry.ResourceType,
+		entry.ResourceID,
+		strconv.FormatBool(entry.Success),
+		entry.ErrorMessage,
+		entry.IPAddress,
+		entry.UserAgent,
+		entry.CorrelationID,
+		oldValues,
+		newValues,
+		metadata,
+		entry.Hash,
+	}
+	for i, cell := range row {
+		row[i] = csvSafeCell(cell)
+	}
+	return c.w.Write(row)
+}
+
+func (c *csvAuditWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonlAuditWriter writes one JSON document per line.
+type jsonlAuditWriter struct {
+	enc *json.Encoder
+}
+
+func (j *jsonlAuditWriter) Write(entry *models.AuditLog) error {
+	return j.enc.Encode(entry)
+}
+
+func (j *jsonlAuditWriter) Close() error {
+	return nil
+}
+
+// pdfAuditWriter renders a paginated report with one line per entry.
+type pdfAuditWriter struct {
+	doc      *pdf.Writer
+	manifest *models.ExportManifest
+}
+
+// pdfAuditLineFormat lays out the columns of the PDF report.
+const pdfAuditLineFormat = "%-24s %7s %-24s %-32s %-18s %-24s %s"
+
+func newPDFAuditWriter(w io.Writer, filter *AuditFilter, manifest *models.ExportManifest) (*pdfAuditWriter, error) {
+	doc := pdf.NewWriter(w, pdf.Options{Title: "GoEdu Audit Log Export", Landscape: true})
+
+	header := []string{
+		"Organization: " + manifest.OrganizationID,
+		"Generated:    " + manifest.GeneratedAt.Format(time.RFC3339) + " by " + manifest.GeneratedBy,
+		fmt.Sprintf("Filter:       user=%s action=%s resource=%s/%s",
+			valueOrAny(filter.UserID), valueOrAny(filter.Action), valueOrAny(filter.ResourceType), valueOrAny(filter.ResourceID)),
+	}
+	if !manifest.RangeStart.IsZero() {
+		header = append(header, "Time range:   "+manifest.RangeStart.Format(time.RFC3339)+" - "+manifest.RangeEnd.Format(time.RFC3339))
+	}
+	header = append(header, "", fmt.Sprintf(pdfAuditLineFormat, "TIMESTAMP", "SEQ", "ACTOR", "ACTION", "RESOURCE TYPE", "RESOURCE ID", "RESULT"))
+
+	for _, line := range header {
+		if err := doc.WriteLine(line); err != nil {
+			return nil, err
+		}
+	}
+	return &pdfAuditWriter{doc: doc, manifest: manifest}, nil
+}
+
+func (p *pdfAuditWriter) Write(entry *models.AuditLog) error {
+	result := "ok"
+	if !entry.Success {
+		result = "failed: " + entry.ErrorMessage
+	}
+	return p.doc.WriteLine(fmt.Sprintf(pdfAuditLineFormat,
+		entry.Timestamp.UTC().Format(time.RFC3339),
+		strconv.FormatInt(entry.Sequence, 10),
+		auditActor(entry),
+		entry.Action,
+		entry.ResourceType,
+		entry.ResourceID,
+		result,
+	))
+}
+
+func (p *pdfAuditWriter) Close() error {
+	summary := []string{
+		"",
+		fmt.Sprintf("Rows exported: %d", p.manifest.RowCount),
+	}
+	if p.manifest.RowCount > 0 {
+		summary = append(summary, "Entries from "+p.manifest.FirstEntryAt.Format(time.RFC3339)+" to "+p.manifest.LastEntryAt.Format(time.RFC3339))
+	}
+	summary = append(summary, "The SHA-256 digest and signature of this file are recorded in the export manifest.")
+
+	for _, line := range summary {
+		if err := p.doc.WriteLine(line); err != nil {
+			return err
+		}
+	}
+	return p.doc.Close()
+}
+
+// auditActor returns the user ID of an entry, or the non-user actor kept in metadata.
+func auditActor(entry *models.AuditLog) string {
+	if !entry.UserID.IsZero() {
+		return entry.UserID.Hex()
+	}
+	if actor, ok := entry.Metadata["actor"].(string); ok {
+		return actor
+	}
+	return ""
+}
+
+// objectIDCell renders an optional object ID.
+func objectIDCell(id primitive.ObjectID) string {
+	if id.IsZero() {
+		return ""
+	}
+	return id.Hex()
+}
+
+// csvSafeCell prefixes a cell starting with a formula trigger with an
+// apostrophe, so spreadsheet applications show it as text instead of
+// evaluating it.
+func csvSafeCell(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// jsonCell renders structured values as compact JSON, empty values as an empty cell.
+func jsonCell(values map[string]interface{}) (string, error) {
+	if len(values) == 0 {
+		return "", nil
+	}
+	raw, err := json.Marshal(values)
+	return string(raw), err
+}
+
+// valueOrAny renders an optional filter value.
+func valueOrAny(value string) string {
+	if value == "" {
+		return "*"
+	}
+	return value
+}
+
+// byteCounter counts the bytes written through it.
+type byteCounter struct {
+	n int64
+}
+
+func (b *byteCounter) Write(p []byte) (int, error) {
+	b.n += int64(len(p))
+	return len(p), nil
+}
+
+// exportFileName builds the download file name of an export.
+func exportFileName(manifest *models.ExportManifest) string {
+	return fmt.Sprintf("audit-log-%s-%s.%s", manifest.OrganizationID, manifest.GeneratedAt.Format("20060102T150405Z"), manifest.Format)
+}
+
+// exportAuditInput builds the audit entry recording an export.
+func exportAuditInput(manifest *models.ExportManifest, exportID string, filter *AuditFilter) *AuditInput {
+	return &AuditInput{
+		UserID:         manifest.GeneratedBy,
+		OrganizationID: manifest.OrganizationID,
+		Action:         AuditActionLogExported,
+		ResourceType:   "audit_log",
+		ResourceID:     exportID,
+		NewValues: map[string]interface{}{
+			"format":    manifest.Format,
+			"row_count": manifest.RowCount,
+			"sha256":    manifest.SHA256,
+			"filter":    auditFilterParameters(filter),
+		},
+		Success: true,
+	}
+}
+
+// auditFilterParameters flattens an audit filter for storage on export jobs.
+func auditFilterParameters(filter *AuditFilter) map[string]string {
+	params := map[string]string{}
+	for key, value := range map[string]string{
+		"user_id":       filter.UserID,
+		"action":        filter.Action,
+		"resource_type": filter.ResourceType,
+		"resource_id":   filter.ResourceID,
+	} {
+		if value != "" {
+			params[key] = value
+		}
+	}
+	if filter.TimeRange != nil {
+		params["start"] = filter.TimeRange.Start
+		params["end"] = filter.TimeRange.End
+	}
+	return params
+}
+
+// auditFilterFromParameters restores an audit filter stored on an export job.
+func auditFilterFromParameters(orgID string, params map[string]string) *AuditFilter {
+	filter := &AuditFilter{
+		OrganizationID: orgID,
+		UserID:         params["user_id"],
+		Action:         params["action"],
+		ResourceType:   params["resource_type"],
+		ResourceID:     params["resource_id"],
+	}
+	if params["start"] != "" || params["end"] != "" {
+		filter.TimeRange = &TimeRange{Start: params["start"], End: params["end"]}
+	}
+	return filter
+}
+
+// auditExportService implements the AuditExportService interface.
+type auditExportService struct {
+	exportRepo repositories.ExportJobRepository
+	exporter   *AuditExporter
+	store      storage.Store
+	auditSvc   AuditService
+	linkTTL    time.Duration
+	logger     *zap.Logger
+}
+
+// NewAuditExportService creates a new audit export service.
+//
+// Parameters:
+//   - exportRepo: Repository for export job persistence
+//   - exporter: Exporter writing the export files
+//   - store: File store holding completed exports
+//   - auditSvc: Audit service recording the exports themselves
+//   - linkTTL: How long download links stay valid after completion
+//   - logger: Logger for service operations
+//
+// Returns:
+//   - AuditExportService: Configured export service instance
+func NewAuditExportService(
+	exportRepo repositories.ExportJobRepository,
+	exporter *AuditExporter,
+	store storage.Store,
+	auditSvc AuditService,
+	linkTTL time.Duration,
+	logger *zap.Logger,
+) AuditExportService {
+	return &auditExportService{
+		exportRepo: exportRepo,
+		exporter:   exporter,
+		store:      store,
+		auditSvc:   auditSvc,
+		linkTTL:    linkTTL,
+		logger:     logger,
+	}
+}
+
+// RequestExport validates the request and queues a background export job.
+//
+// Parameters:
+//   - ctx: Request context
+//   - input: Export format and audit filter
+//
+// Returns:
+//   - *models.ExportJob: Queued job
+//   - error: Validation or persistence error
+func (s *auditExportService) RequestExport(ctx context.Context, input *AuditExportInput) (*models.ExportJob, error) {
+	if input == nil {
+		return nil, ErrInvalidInput
+	}
+	if _, ok := exportContentTypes[input.Format]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedExportFormat, input.Format)
+	}
+	if _, err := parseTimeRange(input.Filter.TimeRange); err != nil {
+		return nil, err
+	}
+
+	orgID, err := primitive.ObjectIDFromHex(input.OrganizationID)
+	if err != nil {
+		return nil, ErrAuditOrganizationRequired
+	}
+	requestedBy, err := primitive.ObjectIDFromHex(input.RequestedBy)
+	if err != nil {
+		return nil, ErrInvalidInput
+	}
+
+	job := &models.ExportJob{
+		ID:             primitive.NewObjectID(),
+		OrganizationID: orgID,
+		RequestedBy:    requestedBy,
+		Kind:           models.ExportKindAuditLog,
+		Format:         input.Format,
+		Parameters:     auditFilterParameters(&input.Filter),
+		Status:         models.ExportStatusPending,
+		CreatedAt:      time.Now().UTC(),
+	}
+	if err := s.exportRepo.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to queue export: %w", err)
+	}
+	return job, nil
+}
+
+// GetExport retrieves an export job of an organization.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID of the caller
+//   - exportID: Export job ID
+//
+// Returns:
+//   - *models.ExportJob: Export job
+//   - error: ErrExportNotFound if the job does not exist in the organization
+func (s *auditExportService) GetExport(ctx context.Context, orgID, exportID string) (*models.ExportJob, error) {
+	job, err := s.exportRepo.GetByID(ctx, exportID)
+	if errors.Is(err, repositories.ErrNotFound) || (err == nil && job.OrganizationID.Hex() != orgID) {
+		return nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get export: %w", err)
+	}
+	return job, nil
+}
+
+// ProcessPending runs queued export jobs until none are left.
+//
+// Parameters:
+//   - ctx: Request context
+//
+// Returns:
+//   - int: Number of jobs processed, successful or not
+//   - error: Error if jobs cannot be claimed
+func (s *auditExportService) ProcessPending(ctx context.Context) (int, error) {
+	processed := 0
+	for {
+		job, err := s.exportRepo.ClaimPending(ctx)
+		if errors.Is(err, repositories.ErrNotFound) {
+			return processed, nil
+		}
+		if err != nil {
+			return processed, fmt.Errorf("failed to claim export job: %w", err)
+		}
+
+		s.run(ctx, job)
+		processed++
+	}
+}
+
+// run executes a claimed job and persists its outcome.
+func (s *auditExportService) run(ctx context.Context, job *models.ExportJob) {
+	job.StartedAt = time.Now().UTC()
+	filter := auditFilterFromParameters(job.OrganizationID.Hex(), job.Parameters)
+
+	manifest, err := s.write(ctx, job, filter)
+	if err != nil {
+		s.logger.Error("Audit export failed",
+			zap.Error(err),
+			zap.String("export_id", job.ID.Hex()),
+			zap.String("organization_id", job.OrganizationID.Hex()),
+		)
+		job.Status = models.ExportStatusFailed
+		job.Error = err.Error()
+	} else {
+		token, tokenErr := newDownloadToken()
+		if tokenErr != nil {
+			job.Status = models.ExportStatusFailed
+			job.Error = tokenErr.Error()
+		} else {
+			job.Status = models.ExportStatusCompleted
+			job.Manifest = manifest
+			job.DownloadToken = token
+			job.CompletedAt = time.Now().UTC()
+			job.ExpiresAt = job.CompletedAt.Add(s.linkTTL)
+		}
+	}
+
+	if err := s.exportRepo.Update(ctx, job); err != nil {
+		s.logger.Error("Failed to update export job", zap.Error(err), zap.String("export_id", job.ID.Hex()))
+		return
+	}
+
+	if job.Status == models.ExportStatusCompleted {
+		if err := s.auditSvc.LogAction(ctx, exportAuditInput(manifest, job.ID.Hex(), filter)); err != nil {
+			s.logger.Warn("Failed to record audit export", zap.Error(err), zap.String("export_id", job.ID.Hex()))
+		}
+	}
+}
+
+// write streams the export of a job into the file store.
+func (s *auditExportService) write(ctx context.Context, job *models.ExportJob, filter *AuditFilter) (*models.ExportManifest, error) {
+	job.FileKey = fmt.Sprintf("exports/%s/%s.%s", job.OrganizationID.Hex(), job.ID.Hex(), job.Format)
+
+	file, err := s.store.Create(ctx, job.FileKey)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := s.exporter.Export(ctx, filter, job.Format, file, job.RequestedBy.Hex(), 0)
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		s.store.Delete(ctx, job.FileKey)
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// OpenDownload opens the file of a completed export for download.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID of the caller
+//   - exportID: Export job ID
+//   - token: Download token from the download link
+//
+// Returns:
+//   - *ExportDownload: File content and metadata; the caller must close Content
+//   - error: ErrExportNotFound, ErrExportNotReady or ErrExportLinkInvalid
+func (s *auditExportService) OpenDownload(ctx context.Context, orgID, exportID, token string) (*ExportDownload, error) {
+	job, err := s.GetExport(ctx, orgID, exportID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != models.ExportStatusCompleted {
+		return nil, ErrExportNotReady
+	}
+	if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(job.DownloadToken)) != 1 || !job.IsDownloadable(time.Now()) {
+		return nil, ErrExportLinkInvalid
+	}
+
+	content, err := s.store.Open(ctx, job.FileKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export file: %w", err)
+	}
+
+	return &ExportDownload{
+		FileName:    exportFileName(job.Manifest),
+		ContentType: exportContentTypes[job.Format],
+		Manifest:    job.Manifest,
+		Content:     content,
+	}, nil
+}
+
+// newDownloadToken generates an unguessable download token.
+func newDownloadToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate download token: %w", err)
+	}
+	return hex.EncodeToString(raw), nil
+}
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/requestctx"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/auditchain"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/storage"
+)
+
+type fakeExportJobRepository struct {
+	repositories.ExportJobRepository
+	jobs []*models.ExportJob
+}
+
+func (r *fakeExportJobRepository) Create(ctx context.Context, job *models.ExportJob) error {
+	r.jobs = append(r.jobs, job)
+	return nil
+}
+
+func (r *fakeExportJobRepository) GetByID(ctx context.Context, id string) (*models.ExportJob, error) {
+	for _, job := range r.jobs {
+		if job.ID.Hex() == id {
+			return job, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r *fakeExportJobRepository) Update(ctx context.Context, job *models.ExportJob) error {
+	return nil
+}
+
+func (r *fakeExportJobRepository) ClaimPending(ctx context.Context) (*models.ExportJob, error) {
+	for _, job := range r.jobs {
+		if job.Status == models.ExportStatusPending {
+			job.Status = models.ExportStatusRunning
+			return job, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+// seedAuditEntries stores entries one hour apart, starting at base
+func seedAuditEntries(repo *fakeAuditLogRepository, orgID primitive.ObjectID, base time.Time, actions ...string) {
+	for i, action := range actions {
+		repo.entries = append(repo.entries, &models.AuditLog{
+			ID:             primitive.NewObjectID(),
+			Timestamp:      base.Add(time.Duration(i) * time.Hour),
+			OrganizationID: orgID,
+			UserID:         primitive.NewObjectID(),
+			Action:         action,
+			ResourceType:   "control",
+			ResourceID:     "C-1",
+			NewValues:      map[string]interface{}{"title": "Line, with \"quotes\""},
+			Success:        true,
+		})
+	}
+}
+
+func exportRequestContext(orgID, userID primitive.ObjectID) context.Context {
+	ctx := requestctx.WithMetadata(context.Background(), &requestctx.Metadata{})
+	requestctx.SetActor(ctx, userID.Hex(), orgID.Hex())
+	return ctx
+}
+
+func TestAuditService_ExportAuditLogFormats(t *testing.T) {
+	orgID := primitive.NewObjectID()
+	userID := primitive.NewObjectID()
+	base := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	key := newTestSigningKey()
+
+	for _, format := range []string{models.ExportFormatCSV, models.ExportFormatJSONL, models.ExportFormatPDF} {
+		t.Run(format, func(t *testing.T) {
+			repo := &fakeAuditLogRepository{}
+			seedAuditEntries(repo, orgID, base, "control.created", "control.updated", "control.updated")
+			seedAuditEntries(repo, primitive.NewObjectID(), base, "control.created")
+			service := NewAuditService(repo, NewAuditExporter(repo, key, "key-1", 100), zap.NewNop())
+
+			doc, err := service.ExportAuditLog(exportRequestContext(orgID, userID), &AuditFilter{
+				OrganizationID: orgID.Hex(),
+				Action:         "control.updated",
+			}, format)
+			require.NoError(t, err)
+
+			manifest := doc.Metadata["manifest"].(*models.ExportManifest)
+			digest := sha256.Sum256(doc.Content)
+			assert.Equal(t, hex.EncodeToString(digest[:]), manifest.SHA256)
+			assert.Equal(t, int64(len(doc.Content)), manifest.SizeBytes)
+			assert.Equal(t, int64(2), manifest.RowCount)
+			assert.Equal(t, base.Add(time.Hour), manifest.FirstEntryAt)
+			assert.Equal(t, base.Add(2*time.Hour), manifest.LastEntryAt)
+			assert.Equal(t, userID.Hex(), manifest.GeneratedBy)
+			assert.True(t, auditchain.VerifySignature(key.Public().(ed25519.PublicKey), ExportStatement(manifest), manifest.Signature))
+
+			switch format {
+			case models.ExportFormatCSV:
+				rows, err := csv.NewReader(bytes.NewReader(doc.Content)).ReadAll()
+				require.NoError(t, err)
+				require.Len(t, rows, 3)
+				assert.Equal(t, auditCSVHeader, rows[0])
+				assert.Equal(t, `{"title":"Line, with \"quotes\""}`, rows[1][15])
+			case models.ExportFormatJSONL:
+				assert.Equal(t, 2, strings.Count(string(doc.Content), "\n"))
+			case models.ExportFormatPDF:
+				assert.True(t, bytes.HasPrefix(doc.Content, []byte("%PDF-")))
+				assert.Contains(t, string(doc.Content), "Rows exported: 2")
+			}
+
+			// The export itself is audited
+			last := repo.entries[len(repo.entries)-1]
+			assert.Equal(t, AuditActionLogExported, last.Action)
+			assert.Equal(t, manifest.SHA256, last.NewValues["sha256"])
+			assert.Equal(t, userID, last.UserID)
+		})
+	}
+}
+
+func TestAuditService_ExportAuditLogEscapesFormulas(t *testing.T) {
+	orgID := primitive.NewObjectID()
+	repo := &fakeAuditLogRepository{}
+	seedAuditEntries(repo, orgID, time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC), "user.login")
+	entry := repo.entries[0]
+	entry.ResourceID = "+1-555-0100"
+	entry.ErrorMessage = "-2+3"
+	entry.IPAddress = "@SUM(A1:A9)"
+	entry.UserAgent = `=HYPERLINK("https://evil.example","click")`
+	entry.CorrelationID = "\tcmd"
+	entry.Hash = "\rcmd"
+	service := NewAuditService(repo, NewAuditExporter(repo, nil, "", 100), zap.NewNop())
+
+	doc, err := service.ExportAuditLog(exportRequestContext(orgID, primitive.NewObjectID()), &AuditFilter{
+		OrganizationID: orgID.Hex(),
+		Action:         "user.login",
+	}, models.ExportFormatCSV)
+	require.NoError(t, err)
+
+	rows, err := csv.NewReader(bytes.NewReader(doc.Content)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	row := rows[1]
+	assert.Equal(t, "'+1-555-0100", row[8])
+	assert.Equal(t, "'-2+3", row[10])
+	assert.Equal(t, "'@SUM(A1:A9)", row[11])
+	assert.Equal(t, `'=HYPERLINK("https://evil.example","click")`, row[12])
+	assert.Equal(t, "'\tcmd", row[13])
+	assert.Equal(t, "'\rcmd", row[17])
+	assert.Equal(t, "user.login", row[6], "ordinary cells are unchanged")
+}
+
+func TestAuditService_ExportAuditLogLimits(t *testing.T) {
+	orgID := primitive.NewObjectID()
+	repo := &fakeAuditLogRepository{}
+	seedAuditEntries(repo, orgID, time.Now(), "a", "b", "c")
+	service := NewAuditService(repo, NewAuditExporter(repo, nil, "", 2), zap.NewNop())
+	ctx := exportRequestContext(orgID, primitive.NewObjectID())
+
+	_, err := service.ExportAuditLog(ctx, &AuditFilter{OrganizationID: orgID.Hex()}, models.ExportFormatCSV)
+	assert.ErrorIs(t, err, ErrExportTooLarge)
+
+	_, err = service.ExportAuditLog(ctx, &AuditFilter{OrganizationID: orgID.Hex()}, "xlsx")
+	assert.ErrorIs(t, err, ErrUnsupportedExportFormat)
+
+	doc, err := service.ExportAuditLog(ctx, &AuditFilter{OrganizationID: orgID.Hex(), Action: "a"}, models.ExportFormatJSONL)
+	require.NoError(t, err)
+	assert.Empty(t, doc.Metadata["manifest"].(*models.ExportManifest).Signature, "no key, no signature")
+}
+
+func TestAuditExportService_BackgroundExport(t *testing.T) {
+	ctx := context.Background()
+	orgID := primitive.NewObjectID()
+	userID := primitive.NewObjectID()
+	repo := &fakeAuditLogRepository{}
+	seedAuditEntries(repo, orgID, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "a", "b", "c")
+
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	exporter := NewAuditExporter(repo, newTestSigningKey(), "key-1", 1)
+	exportRepo := &fakeExportJobRepository{}
+	service := NewAuditExportService(exportRepo, exporter, store, NewAuditService(repo, exporter, zap.NewNop()), time.Hour, zap.NewNop())
+
+	_, err = service.RequestExport(ctx, &AuditExportInput{OrganizationID: orgID.Hex(), RequestedBy: userID.Hex(), Format: "xml"})
+	assert.ErrorIs(t, err, ErrUnsupportedExportFormat)
+
+	job, err := service.RequestExport(ctx, &AuditExportInput{
+		OrganizationID: orgID.Hex(),
+		RequestedBy:    userID.Hex(),
+		Format:         models.ExportFormatCSV,
+		Filter: AuditFilter{TimeRange: &TimeRange{
+			Start: "2024-01-01T00:00:00Z",
+			End:   "2024-01-02T00:00:00Z",
+		}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.ExportStatusPending, job.Status)
+
+	_, err = service.OpenDownload(ctx, orgID.Hex(), job.ID.Hex(), "")
+	assert.ErrorIs(t, err, ErrExportNotReady)
+
+	processed, err := service.ProcessPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+
+	job, err = service.GetExport(ctx, orgID.Hex(), job.ID.Hex())
+	require.NoError(t, err)
+	require.Equal(t, models.ExportStatusCompleted, job.Status, job.Error)
+	assert.Equal(t, int64(3), job.Manifest.RowCount, "background exports are not subject to the synchronous limit")
+	assert.NotEmpty(t, job.DownloadToken)
+
+	_, err = service.GetExport(ctx, primitive.NewObjectID().Hex(), job.ID.Hex())
+	assert.ErrorIs(t, err, ErrExportNotFound)
+	_, err = service.OpenDownload(ctx, orgID.Hex(), job.ID.Hex(), "wrong")
+	assert.ErrorIs(t, err, ErrExportLinkInvalid)
+
+	download, err := service.OpenDownload(ctx, orgID.Hex(), job.ID.Hex(), job.DownloadToken)
+	require.NoError(t, err)
+	content, _ := io.ReadAll(download.Content)
+	download.Content.Close()
+	digest := sha256.Sum256(content)
+	assert.Equal(t, job.Manifest.SHA256, hex.EncodeToString(digest[:]))
+	assert.Equal(t, "text/csv", download.ContentType)
+
+	assert.Equal(t, AuditActionLogExported, repo.entries[len(repo.entries)-1].Action)
+
+	// Expired links are refused
+	job.ExpiresAt = time.Now().Add(-time.Minute)
+	_, err = service.OpenDownload(ctx, orgID.Hex(), job.ID.Hex(), job.DownloadToken)
+	assert.ErrorIs(t, err, ErrExportLinkInvalid)
+}
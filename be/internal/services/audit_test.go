@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/requestctx"
+)
+
+func TestAuditService_LogActionUsesRequestMetadata(t *testing.T) {
+	repo := &fakeAuditLogRepository{}
+	service := NewAuditService(repo, nil, zap.NewNop())
+
+	orgID := primitive.NewObjectID()
+	userID := primitive.NewObjectID()
+	ctx := requestctx.WithMetadata(context.Background(), &requestctx.Metadata{
+		CorrelationID: "corr-1",
+		IPAddress:     "10.0.0.1",
+		UserAgent:     "test-agent",
+	})
+	requestctx.SetActor(ctx, userID.Hex(), orgID.Hex())
+
+	err := service.LogAction(ctx, &AuditInput{
+		Action:       "control.updated",
+		ResourceType: "control",
+		ResourceID:   "C-1",
+		NewValues: map[string]interface{}{
+			"title":    "New title",
+			"password": "hunter2",
+			"nested":   map[string]interface{}{"api_key": "abc", "name": "x"},
+			"accounts": []interface{}{
+				map[string]interface{}{"login": "ada", "password": "hunter3"},
+				"plain",
+			},
+		},
+		Success: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, repo.entries, 1)
+
+	entry := repo.entries[0]
+	assert.Equal(t, "corr-1", entry.CorrelationID)
+	assert.Equal(t, orgID, entry.OrganizationID)
+	assert.Equal(t, userID, entry.UserID)
+	assert.Equal(t, "10.0.0.1", entry.IPAddress)
+	assert.Equal(t, "test-agent", entry.UserAgent)
+	assert.Equal(t, redactedValue, entry.NewValues["password"])
+	assert.Equal(t, redactedValue, entry.NewValues["nested"].(map[string]interface{})["api_key"])
+	assert.Equal(t, "x", entry.NewValues["nested"].(map[string]interface{})["name"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"login": "ada", "password": redactedValue},
+		"plain",
+	}, entry.NewValues["accounts"], "objects in arrays are redacted")
+}
+
+func TestAuditService_LogActionValidation(t *testing.T) {
+	repo := &fakeAuditLogRepository{}
+	service := NewAuditService(repo, nil, zap.NewNop())
+	orgID := primitive.NewObjectID().Hex()
+
+	err := service.LogAction(context.Background(), &AuditInput{Action: "x", ResourceType: "y"})
+	assert.ErrorIs(t, err, ErrAuditOrganizationRequired)
+
+	err = service.LogAction(context.Background(), &AuditInput{OrganizationID: orgID})
+	assert.ErrorIs(t, err, ErrAuditActionRequired)
+
+	// Non-user actors are kept in metadata
+	err = service.LogAction(context.Background(), &AuditInput{
+		OrganizationID: orgID,
+		UserID:         "system",
+		Action:         "job.run",
+		ResourceType:   "job",
+	})
+	require.NoError(t, err)
+	assert.True(t, repo.entries[0].UserID.IsZero())
+	assert.Equal(t, "system", repo.entries[0].Metadata["actor"])
+}
+
+func TestAuditService_GetResourceActivityIsScopedToOrganization(t *testing.T) {
+	repo := &fakeAuditLogRepository{}
+	service := NewAuditService(repo, nil, zap.NewNop())
+	ownOrg := primitive.NewObjectID().Hex()
+	otherOrg := primitive.NewObjectID().Hex()
+
+	for _, orgID := range []string{ownOrg, otherOrg} {
+		require.NoError(t, service.LogAction(context.Background(), &AuditInput{
+			OrganizationID: orgID, Action: "comment.created", ResourceType: "comment", ResourceID: "c-1",
+		}))
+	}
+
+	ctx := requestctx.WithMetadata(context.Background(), &requestctx.Metadata{OrganizationID: ownOrg})
+	entries, err := service.GetResourceActivity(ctx, "comment", "c-1")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, ownOrg, entries[0].OrganizationID.Hex())
+}
+
+func TestDiffValues(t *testing.T) {
+	oldDiff, newDiff := DiffValues(
+		map[string]interface{}{"status": "active", "name": "A", "removed": true},
+		map[string]interface{}{"status": "inactive", "name": "A", "added": 1},
+	)
+
+	assert.Equal(t, map[string]interface{}{"status": "active", "removed": true}, oldDiff)
+	assert.Equal(t, map[string]interface{}{"status": "inactive", "added": 1}, newDiff)
+}
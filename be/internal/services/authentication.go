@@ -0,0 +1,413 @@
+// Package services provides service layer implementations for the GoEdu Control Testing Platform.
+// This file contains the authentication service, which manages the
+// credentials and sessions of users. Self-service password resets are in
+// password_reset.go and session storage and timeouts in sessions.go.
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/auth"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/cache"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/password"
+)
+
+// authenticationService implements the AuthenticationService interface.
+type authenticationService struct {
+	orgRepo       repositories.OrganizationRepository
+	userRepo      repositories.UserRepository
+	resetRepo     repositories.PasswordResetRepository
+	notifications NotificationService
+	limits        cache.RateLimits
+	localLimits   *cache.LocalRateLimits
+	jwtManager    *auth.JWTManager
+	hasher        *auth.PasswordHasher
+	passwords     *passwordManager
+	sessions      *sessionManager
+	config        config.PasswordConfig
+	logger        *zap.Logger
+
+	// resetSlots bounds, and resetRequests tracks, the password reset
+	// requests still being handled; once resetsClosed is set by Close new
+	// requests are handled before ResetPassword returns
+	resetSlots    chan struct{}
+	resetRequests sync.WaitGroup
+	resetMu       sync.Mutex
+	resetsClosed  bool
+}
+
+// NewAuthenticationService creates a new authentication service.
+//
+// Parameters:
+//   - orgRepo: Repository for the organizations' password policies and session settings
+//   - userRepo: Repository for user accounts
+//   - sessionRepo: Repository for sessions
+//   - denylist: Denylist of ended sessions checked on every request, usually the Redis cache client
+//   - resetRepo: Repository for password resets
+//   - notifications: Service sending password reset emails
+//   - limits: Shared rate limit store for password reset requests, usually the Redis cache client
+//   - jwtManager: Validator of the platform's access tokens
+//   - hasher: Password hasher for changed passwords and security answers
+//   - checker: Dictionary and breached password checks of the password policy
+//   - cfg: Password reset lifetime and rate limits
+//   - sessionCfg: Platform session timeouts
+//   - logger: Logger for service operations
+//
+// Returns:
+//   - AuthenticationService: Configured authentication service instance
+func NewAuthenticationService(
+	orgRepo repositories.OrganizationRepository,
+	userRepo repositories.UserRepository,
+	sessionRepo repositories.SessionRepository,
+	denylist cache.Denylist,
+	resetRepo repositories.PasswordResetRepository,
+	notifications NotificationService,
+	limits cache.RateLimits,
+	jwtManager *auth.JWTManager,
+	hasher *auth.PasswordHasher,
+	checker *password.Checker,
+	cfg config.PasswordConfig,
+	sessionCfg config.SessionConfig,
+	logger *zap.Logger,
+) AuthenticationService {
+	return &authenticationService{
+		orgRepo:       orgRepo,
+		userRepo:      userRepo,
+		resetRepo:     resetRepo,
+		notifications: notifications,
+		limits:        limits,
+		localLimits:   cache.NewLocalRateLimits(),
+		jwtManager:    jwtManager,
+		hasher:        hasher,
+		passwords: &passwordManager{
+			orgRepo:  orgRepo,
+			userRepo: userRepo,
+			hasher:   hasher,
+			checker:  checker,
+			logger:   logger,
+		},
+		sessions:   newSessionManager(orgRepo, userRepo, sessionRepo, denylist, sessionCfg, logger),
+		config:     cfg,
+		logger:     logger,
+		resetSlots: make(chan struct{}, maxPendingResets),
+	}
+}
+
+// ChangePassword changes a signed-in user's password after verifying the
+// current one. The new password must satisfy the organization's password
+// policy, including its history and breached password checks.
+//
+// Parameters:
+//   - ctx: Request context
+//   - userID: User ID
+//   - oldPassword: The user's current password
+//   - newPassword: The new password
+//
+// Returns:
+//   - error: ErrUserNotFound, ErrIncorrectPassword, ErrPasswordTooWeak,
+//     ErrPasswordCommon, ErrPasswordBreached, ErrPasswordReused or update error
+func (s *authenticationService) ChangePassword(ctx context.Context, userID, oldPassword, newPassword string) error {
+	return s.passwords.change(ctx, userID, oldPassword, newPassword)
+}
+
+// TerminateAllSessions ends every session of a user, e.g. after their
+// account was deactivated. Requests with the sessions' access tokens are
+// rejected from then on.
+//
+// Parameters:
+//   - ctx: Request context
+//   - userID: User ID
+//
+// Returns:
+//   - error: Update error
+func (s *authenticationService) TerminateAllSessions(ctx context.Context, userID string) error {
+	ended, err := s.sessions.endAll(ctx, userID, "", models.SessionEndRevoked)
+	if err != nil {
+		return fmt.Errorf("failed to terminate sessions: %w", err)
+	}
+	s.logger.Info("Sessions terminated",
+		zap.String("user_id", userID),
+		zap.Int64("sessions", ended),
+	)
+	return nil
+}
+
+// Logout ends the session a user signed out of.
+//
+// Parameters:
+//   - ctx: Request context
+//   - sessionID: Session ID
+//
+// Returns:
+//   - error: ErrSessionNotFound or update error
+func (s *authenticationService) Logout(ctx context.Context, sessionID string) error {
+	return s.endSession(ctx, sessionID, models.SessionEndLogout)
+}
+
+// TerminateSession ends a session.
+//
+// Parameters:
+//   - ctx: Request context
+//   - sessionID: Session ID
+//
+// Returns:
+//   - error: ErrSessionNotFound or update error
+func (s *authenticationService) TerminateSession(ctx context.Context, sessionID string) error {
+	return s.endSession(ctx, sessionID, models.SessionEndRevoked)
+}
+
+// GetSession retrieves a session, active or ended.
+//
+// Parameters:
+//   - ctx: Request context
+//   - sessionID: Session ID
+//
+// Returns:
+//   - *models.Session: The session
+//   - error: ErrSessionNotFound or retrieval error
+func (s *authenticationService) GetSession(ctx context.Context, sessionID string) (*models.Session, error) {
+	session, err := s.sessions.sessionRepo.GetBySessionID(ctx, sessionID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return session, nil
+}
+
+// UpdateSessionActivity records activity of a session now, ending it if it
+// has been idle for longer than the organization's idle timeout.
+//
+// Parameters:
+//   - ctx: Request context
+//   - sessionID: Session ID
+//
+// Returns:
+//   - error: ErrSessionNotFound, ErrSessionExpired, ErrSessionRevoked or update error
+func (s *authenticationService) UpdateSessionActivity(ctx context.Context, sessionID string) error {
+	session, err := s.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	return s.sessions.recordActivity(ctx, sessionID, session.OrganizationID.Hex(), time.Now().UTC())
+}
+
+// ValidateAccessToken validates an access token and checks that its session
+// has not been revoked, been idle for too long or expired. It is called on
+// every authenticated request.
+//
+// Parameters:
+//   - ctx: Request context
+//   - token: Access token
+//
+// Returns:
+//   - *models.JWTClaims: The token's claims
+//   - error: ErrInvalidAccessToken, ErrSessionExpired, ErrSessionRevoked or retrieval error
+func (s *authenticationService) ValidateAccessToken(ctx context.Context, token string) (*models.JWTClaims, error) {
+	claims, err := s.jwtManager.ValidateToken(token)
+	if err != nil || claims.TokenType != models.TokenTypeAccess || claims.SessionID == "" {
+		return nil, ErrInvalidAccessToken
+	}
+	if err := s.sessions.validate(ctx, claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// ListSessions lists the active sessions of a user of an organization, most
+// recently active first.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID
+//   - userID: User ID
+//   - currentSessionID: Session of the request, marked as current
+//
+// Returns:
+//   - []*UserSession: The user's sessions
+//   - error: ErrUserNotFound or retrieval error
+func (s *authenticationService) ListSessions(ctx context.Context, orgID, userID, currentSessionID string) ([]*UserSession, error) {
+	if _, err := s.member(ctx, orgID, userID); err != nil {
+		return nil, err
+	}
+	active, err := s.sessions.sessionRepo.GetActiveByUser(ctx, userID, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+	sessions := make([]*UserSession, 0, len(active))
+	for _, session := range active {
+		sessions = append(sessions, &UserSession{Session: session, Current: session.SessionID == currentSessionID})
+	}
+	return sessions, nil
+}
+
+// RevokeSession ends one session of a user of an organization.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID
+//   - userID: User ID
+//   - sessionID: Session ID
+//
+// Returns:
+//   - error: ErrUserNotFound, ErrSessionNotFound or update error
+func (s *authenticationService) RevokeSession(ctx context.Context, orgID, userID, sessionID string) error {
+	user, err := s.member(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+	session, err := s.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session.UserID != user.ID || !session.IsActive {
+		return ErrSessionNotFound
+	}
+	now := time.Now().UTC()
+	s.sessions.end(ctx, session, models.SessionEndRevoked, now)
+	s.sessions.recount(ctx, userID, now)
+	return nil
+}
+
+// RevokeSessions ends every session of a user of an organization except
+// exceptSessionID, which may be empty.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID
+//   - userID: User ID
+//   - exceptSessionID: Session to keep, usually the session of the request
+//
+// Returns:
+//   - int64: Number of sessions ended
+//   - error: ErrUserNotFound or update error
+func (s *authenticationService) RevokeSessions(ctx context.Context, orgID, userID, exceptSessionID string) (int64, error) {
+	if _, err := s.member(ctx, orgID, userID); err != nil {
+		return 0, err
+	}
+	ended, err := s.sessions.endAll(ctx, userID, exceptSessionID, models.SessionEndRevoked)
+	if err != nil {
+		return 0, err
+	}
+	s.logger.Info("Sessions revoked",
+		zap.String("user_id", userID),
+		zap.Int64("sessions", ended),
+	)
+	return ended, nil
+}
+
+// endSession ends an active session with the given reason.
+func (s *authenticationService) endSession(ctx context.Context, sessionID, reason string) error {
+	session, err := s.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if !session.IsActive {
+		return nil
+	}
+	now := time.Now().UTC()
+	s.sessions.end(ctx, session, reason, now)
+	s.sessions.recount(ctx, session.UserID.Hex(), now)
+	return nil
+}
+
+// member loads a user of an organization, answering ErrUserNotFound for
+// users of other organizations.
+func (s *authenticationService) member(ctx context.Context, orgID, userID string) (*models.User, error) {
+	user, err := s.user(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.OrganizationID.Hex() != orgID {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// Placeholder implementations for remaining interface methods
+// These would be implemented based on specific business requirements
+
+func (s *authenticationService) Login(ctx context.Context, request *models.LoginRequest) (*models.LoginResponse, error) {
+	// Implementation would authenticate the user and start a session
+	return nil, errors.New("not implemented")
+}
+
+func (s *authenticationService) RefreshToken(ctx context.Context, refreshToken string) (*models.LoginResponse, error) {
+	// Implementation would rotate the session's refresh token
+	return nil, errors.New("not implemented")
+}
+
+func (s *authenticationService) EnableMFA(ctx context.Context, userID string) (*MFASetupResponse, error) {
+	// Implementation would generate a TOTP secret and backup codes
+	return nil, errors.New("not implemented")
+}
+
+func (s *authenticationService) DisableMFA(ctx context.Context, userID, mfaCode string) error {
+	// Implementation would verify the code and disable MFA
+	return errors.New("not implemented")
+}
+
+func (s *authenticationService) ValidateMFA(ctx context.Context, userID, mfaCode string) error {
+	// Implementation would verify a TOTP or backup code
+	return errors.New("not implemented")
+}
+
+func (s *authenticationService) GenerateBackupCodes(ctx context.Context, userID string) ([]string, error) {
+	// Implementation would replace the user's backup codes
+	return nil, errors.New("not implemented")
+}
+
+func (s *authenticationService) CreateSession(ctx context.Context, userID, ipAddress, userAgent string) (*models.Session, error) {
+	// Implementation would persist a new session
+	return nil, errors.New("not implemented")
+}
+
+func (s *authenticationService) GenerateAccessToken(ctx context.Context, user *models.User, sessionID, ipAddress string) (string, time.Time, error) {
+	// Implementation would issue an access token for the session
+	return "", time.Time{}, errors.New("not implemented")
+}
+
+func (s *authenticationService) GenerateRefreshToken(ctx context.Context, userID, sessionID string) (string, time.Time, error) {
+	// Implementation would issue a refresh token for the session
+	return "", time.Time{}, errors.New("not implemented")
+}
+
+func (s *authenticationService) LockAccount(ctx context.Context, userID string, reason string) error {
+	// Implementation would lock the account until an administrator unlocks it
+	return errors.New("not implemented")
+}
+
+func (s *authenticationService) UnlockAccount(ctx context.Context, userID string) error {
+	// Implementation would clear the lockout and failed sign-in count
+	return errors.New("not implemented")
+}
+
+func (s *authenticationService) RecordFailedLogin(ctx context.Context, userID, ipAddress string) error {
+	// Implementation would count the failed sign-in
+	return errors.New("not implemented")
+}
+
+func (s *authenticationService) RecordSuccessfulLogin(ctx context.Context, userID, ipAddress string) error {
+	// Implementation would record the sign-in
+	return errors.New("not implemented")
+}
+
+func (s *authenticationService) LogSecurityEvent(ctx context.Context, event *models.AuditEvent) error {
+	// Implementation would record the event in the audit log
+	return errors.New("not implemented")
+}
+
+func (s *authenticationService) GetSecurityEvents(ctx context.Context, userID string, timeRange *TimeRange) ([]*models.AuditEvent, error) {
+	// Implementation would list the user's security events
+	return nil, errors.New("not implemented")
+}
@@ -0,0 +1,491 @@
+// Package services provides service layer implementations for the GoEdu Control Testing Platform.
+// This file contains the comment service providing threaded discussions with @mentions
+// on any commentable resource. Audit entries and mention notifications come
+// from the subscribers of the comment events the service publishes.
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/events"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+)
+
+// maxCommentLength bounds the size of a single comment.
+const maxCommentLength = 10000
+
+// Comment audit actions, recorded from the events of the same type
+const (
+	AuditActionCommentCreated = events.TypeCommentCreated
+	AuditActionCommentEdited  = events.TypeCommentEdited
+	AuditActionCommentDeleted = events.TypeCommentDeleted
+)
+
+// mentionPattern matches @mentions written as "@" followed by the user's email
+// address, e.g. "please check @jane.doe@bank.com". The mention must start the
+// content or follow a non-word character so plain email addresses are not mentions.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@.])@([A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,})`)
+
+// Comment errors
+var (
+	ErrCommentNotFound         = errors.New("comment not found")
+	ErrCommentContentRequired  = errors.New("comment content is required")
+	ErrCommentTooLong          = errors.New("comment content is too long")
+	ErrUnsupportedResourceType = errors.New("resource type does not support comments")
+	ErrCommentResourceNotFound = errors.New("commented resource not found")
+	ErrInvalidCommentParent    = errors.New("parent comment does not belong to the same resource")
+	ErrCommentDeleted          = errors.New("comment has been deleted")
+	ErrCommentForbidden        = errors.New("only the author or an administrator can modify this comment")
+)
+
+// commentService implements the CommentService interface.
+type commentService struct {
+	commentRepo  repositories.CommentRepository
+	evidenceRepo repositories.EvidenceRequestRepository
+	controlRepo  repositories.ControlRepository
+	cycleRepo    repositories.TestingCycleRepository
+	userRepo     repositories.UserRepository
+	transactor   repositories.Transactor
+	events       events.Publisher
+	logger       *zap.Logger
+}
+
+// NewCommentService creates a new comment service.
+//
+// Parameters:
+//   - commentRepo: Repository for comment data operations
+//   - evidenceRepo: Repository used to verify commented evidence requests
+//   - controlRepo: Repository used to verify commented controls
+//   - cycleRepo: Repository used to verify commented testing cycles
+//   - userRepo: Repository used to resolve mentions and moderators
+//   - transactor: Runs a comment change and its event in one transaction
+//   - publisher: Publisher of comment events
+//   - logger: Logger for service operations
+//
+// Returns:
+//   - CommentService: Configured comment service instance
+func NewCommentService(
+	commentRepo repositories.CommentRepository,
+	evidenceRepo repositories.EvidenceRequestRepository,
+	controlRepo repositories.ControlRepository,
+	cycleRepo repositories.TestingCycleRepository,
+	userRepo repositories.UserRepository,
+	transactor repositories.Transactor,
+	publisher events.Publisher,
+	logger *zap.Logger,
+) CommentService {
+	return &commentService{
+		commentRepo:  commentRepo,
+		evidenceRepo: evidenceRepo,
+		controlRepo:  controlRepo,
+		cycleRepo:    cycleRepo,
+		userRepo:     userRepo,
+		transactor:   transactor,
+		events:       publisher,
+		logger:       logger,
+	}
+}
+
+// AddComment creates a comment on a resource. Replies must target a live comment
+// on the same resource. Mentioned users are notified after the comment is stored.
+//
+// Parameters:
+//   - ctx: Request context
+//   - input: Comment data including the target resource and optional parent
+//
+// Returns:
+//   - *models.Comment: Created comment
+//   - error: Validation or persistence error
+func (s *commentService) AddComment(ctx context.Context, input *CreateCommentInput) (*models.Comment, error) {
+	if input == nil {
+		return nil, ErrInvalidInput
+	}
+	content, err := normalizeCommentContent(input.Content)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verifyResource(ctx, input.OrganizationID, input.ResourceType, input.ResourceID); err != nil {
+		return nil, err
+	}
+
+	if input.ParentID != "" {
+		parent, err := s.loadComment(ctx, input.OrganizationID, input.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.ResourceType != input.ResourceType || parent.ResourceID != input.ResourceID {
+			return nil, ErrInvalidCommentParent
+		}
+		if parent.IsDeleted() {
+			return nil, ErrCommentDeleted
+		}
+	}
+
+	orgID, err := primitive.ObjectIDFromHex(input.OrganizationID)
+	if err != nil {
+		return nil, ErrInvalidInput
+	}
+
+	mentioned := s.resolveMentions(ctx, orgID, content)
+
+	now := time.Now()
+	comment := &models.Comment{
+		ID:             models.NewID(),
+		AuthorID:       input.AuthorID,
+		Content:        content,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		OrganizationID: orgID,
+		ResourceType:   input.ResourceType,
+		ResourceID:     input.ResourceID,
+		ParentID:       input.ParentID,
+		Mentions:       userIDs(mentioned),
+		Status:         models.CommentStatusActive,
+	}
+
+	err = s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.commentRepo.Create(ctx, comment); err != nil {
+			return fmt.Errorf("failed to create comment: %w", err)
+		}
+		return s.publish(ctx, comment, input.AuthorID, &events.CommentCreated{
+			ResourceType:  comment.ResourceType,
+			ResourceID:    comment.ResourceID,
+			ParentID:      comment.ParentID,
+			Content:       comment.Content,
+			NotifyUserIDs: mentionsToNotify(comment, nil),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// EditComment replaces the content of a comment. The previous content is kept
+// in the edit history and only users newly mentioned by the edit are notified.
+//
+// Parameters:
+//   - ctx: Request context
+//   - input: Comment ID, acting user and new content
+//
+// Returns:
+//   - *models.Comment: Updated comment
+//   - error: Validation, permission or persistence error
+func (s *commentService) EditComment(ctx context.Context, input *EditCommentInput) (*models.Comment, error) {
+	if input == nil {
+		return nil, ErrInvalidInput
+	}
+	content, err := normalizeCommentContent(input.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	comment, err := s.loadComment(ctx, input.OrganizationID, input.CommentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.IsDeleted() {
+		return nil, ErrCommentDeleted
+	}
+	// Only the author may put words in their own mouth
+	if comment.AuthorID != input.ActorID {
+		return nil, ErrCommentForbidden
+	}
+	if comment.Content == content {
+		return comment, nil
+	}
+
+	mentioned := s.resolveMentions(ctx, comment.OrganizationID, content)
+	previousMentions := comment.Mentions
+	previousContent := comment.Content
+
+	now := time.Now()
+	comment.EditHistory = append(comment.EditHistory, models.CommentRevision{
+		Content:  comment.Content,
+		EditedAt: now,
+		EditedBy: input.ActorID,
+	})
+	comment.Content = content
+	comment.Mentions = userIDs(mentioned)
+	comment.UpdatedAt = now
+
+	err = s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.commentRepo.Update(ctx, comment); err != nil {
+			return fmt.Errorf("failed to update comment: %w", err)
+		}
+		return s.publish(ctx, comment, input.ActorID, &events.CommentEdited{
+			ResourceType:    comment.ResourceType,
+			ResourceID:      comment.ResourceID,
+			PreviousContent: previousContent,
+			Content:         comment.Content,
+			NotifyUserIDs:   mentionsToNotify(comment, previousMentions),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// DeleteComment soft deletes a comment. The comment stays in place so replies
+// keep their thread position, but its content is no longer returned.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID of the caller
+//   - commentID: Comment ID
+//   - actorID: ID of the user deleting the comment (author or administrator)
+//
+// Returns:
+//   - error: Permission or persistence error
+func (s *commentService) DeleteComment(ctx context.Context, orgID, commentID, actorID string) error {
+	comment, err := s.loadComment(ctx, orgID, commentID)
+	if err != nil {
+		return err
+	}
+	if comment.IsDeleted() {
+		return nil
+	}
+
+	if comment.AuthorID != actorID {
+		actor, err := s.userRepo.GetByID(ctx, actorID)
+		if err != nil || !actor.HasRole(models.RoleAdmin) || actor.OrganizationID != comment.OrganizationID {
+			return ErrCommentForbidden
+		}
+	}
+
+	now := time.Now()
+	comment.Status = models.CommentStatusDeleted
+	comment.DeletedAt = now
+	comment.DeletedBy = actorID
+	comment.UpdatedAt = now
+
+	return s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.commentRepo.Update(ctx, comment); err != nil {
+			return fmt.Errorf("failed to delete comment: %w", err)
+		}
+		return s.publish(ctx, comment, actorID, &events.CommentDeleted{
+			ResourceType: comment.ResourceType,
+			ResourceID:   comment.ResourceID,
+		})
+	})
+}
+
+// GetThread returns the comments on a resource as a forest of threads ordered
+// by creation time. Deleted comments appear with their content removed.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID of the caller
+//   - resourceType: Type of the commented resource
+//   - resourceID: ID of the commented resource
+//
+// Returns:
+//   - []*CommentThread: Top-level comments with nested replies
+//   - error: Validation or retrieval error
+func (s *commentService) GetThread(ctx context.Context, orgID, resourceType, resourceID string) ([]*CommentThread, error) {
+	if !isCommentableResource(resourceType) {
+		return nil, ErrUnsupportedResourceType
+	}
+
+	comments, err := s.commentRepo.GetByResource(ctx, orgID, resourceType, resourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comments: %w", err)
+	}
+
+	nodes := make(map[string]*CommentThread, len(comments))
+	for _, comment := range comments {
+		nodes[comment.ID] = &CommentThread{Comment: redactDeletedComment(comment), Replies: []*CommentThread{}}
+	}
+
+	roots := []*CommentThread{}
+	for _, comment := range comments {
+		node := nodes[comment.ID]
+		if parent, ok := nodes[comment.ParentID]; ok && comment.ParentID != "" {
+			parent.Replies = append(parent.Replies, node)
+			continue
+		}
+		roots = append(roots, node)
+	}
+
+	return roots, nil
+}
+
+// verifyResource ensures the resource type supports comments and, where a store
+// exists for it, that the resource belongs to the caller's organization.
+func (s *commentService) verifyResource(ctx context.Context, orgID, resourceType, resourceID string) error {
+	if !isCommentableResource(resourceType) {
+		return ErrUnsupportedResourceType
+	}
+	if strings.TrimSpace(resourceID) == "" {
+		return ErrInvalidInput
+	}
+
+	var owner primitive.ObjectID
+	var err error
+	switch resourceType {
+	case models.ResourceTypeEvidenceRequest:
+		var request *models.EvidenceRequest
+		if request, err = s.evidenceRepo.GetByID(ctx, resourceID); err == nil {
+			owner = request.OrganizationID
+		}
+	case models.ResourceTypeControl:
+		var control *models.Control
+		if control, err = s.controlRepo.GetByID(ctx, resourceID); err == nil {
+			owner = control.OrganizationID
+		}
+	case models.ResourceTypeTestingCycle:
+		var cycle *models.TestingCycle
+		if cycle, err = s.cycleRepo.GetByID(ctx, resourceID); err == nil {
+			owner = cycle.OrganizationID
+		}
+	default:
+		// Findings have no dedicated store yet; comments are still isolated by organization
+		return nil
+	}
+
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return ErrCommentResourceNotFound
+		}
+		return fmt.Errorf("failed to get commented resource: %w", err)
+	}
+	if owner.Hex() != orgID {
+		return ErrCommentResourceNotFound
+	}
+	return nil
+}
+
+// loadComment retrieves a comment and enforces tenant isolation.
+func (s *commentService) loadComment(ctx context.Context, orgID, commentID string) (*models.Comment, error) {
+	comment, err := s.commentRepo.GetByID(ctx, commentID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrCommentNotFound
+		}
+		return nil, fmt.Errorf("failed to get comment: %w", err)
+	}
+	if comment.OrganizationID.Hex() != orgID {
+		return nil, ErrCommentNotFound
+	}
+	return comment, nil
+}
+
+// resolveMentions maps @email mentions in the content to active members of the
+// organization. Unknown addresses and users of other organizations are ignored.
+func (s *commentService) resolveMentions(ctx context.Context, orgID primitive.ObjectID, content string) []*models.User {
+	var users []*models.User
+	for _, email := range parseMentions(content) {
+		user, err := s.userRepo.GetByEmail(ctx, email)
+		if err != nil {
+			if !errors.Is(err, repositories.ErrNotFound) {
+				s.logger.Warn("Failed to resolve mention", zap.Error(err), zap.String("email", email))
+			}
+			continue
+		}
+		if user.OrganizationID != orgID || !user.IsActive {
+			continue
+		}
+		users = append(users, user)
+	}
+	return users
+}
+
+// publish publishes a comment event on behalf of the acting user.
+func (s *commentService) publish(ctx context.Context, comment *models.Comment, actorID string, payload events.Payload) error {
+	event := events.New(comment.OrganizationID.Hex(), "comment", comment.ID, payload)
+	event.ActorID = actorID
+	return s.events.Publish(ctx, event)
+}
+
+// mentionsToNotify returns the users mentioned in a comment that should be
+// notified, skipping the author and anyone who was already mentioned before
+// an edit.
+func mentionsToNotify(comment *models.Comment, alreadyNotified []string) []string {
+	skip := make(map[string]struct{}, len(alreadyNotified)+1)
+	skip[comment.AuthorID] = struct{}{}
+	for _, id := range alreadyNotified {
+		skip[id] = struct{}{}
+	}
+
+	var ids []string
+	for _, id := range comment.Mentions {
+		if _, ok := skip[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// parseMentions extracts the distinct, lower-cased email addresses mentioned in content.
+func parseMentions(content string) []string {
+	var emails []string
+	seen := make(map[string]struct{})
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		email := strings.ToLower(strings.TrimRight(match[1], "."))
+		if _, ok := seen[email]; ok {
+			continue
+		}
+		seen[email] = struct{}{}
+		emails = append(emails, email)
+	}
+	return emails
+}
+
+// normalizeCommentContent trims and validates comment content.
+func normalizeCommentContent(content string) (string, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", ErrCommentContentRequired
+	}
+	if len(content) > maxCommentLength {
+		return "", ErrCommentTooLong
+	}
+	return content, nil
+}
+
+// isCommentableResource reports whether comments can be attached to the resource type.
+func isCommentableResource(resourceType string) bool {
+	switch resourceType {
+	case models.ResourceTypeEvidenceRequest, models.ResourceTypeControl,
+		models.ResourceTypeFinding, models.ResourceTypeTestingCycle:
+		return true
+	default:
+		return false
+	}
+}
+
+// redactDeletedComment returns a copy of a deleted comment without its content.
+func redactDeletedComment(comment *models.Comment) *models.Comment {
+	if !comment.IsDeleted() {
+		return comment
+	}
+	redacted := *comment
+	redacted.Content = ""
+	redacted.Mentions = nil
+	redacted.EditHistory = nil
+	return &redacted
+}
+
+// userIDs returns the hex IDs of the given users.
+func userIDs(users []*models.User) []string {
+	if len(users) == 0 {
+		return nil
+	}
+	ids := make([]string, len(users))
+	for i, user := range users {
+		ids[i] = user.ID.Hex()
+	}
+	return ids
+}
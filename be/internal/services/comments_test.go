@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+)
+
+type commentFixture struct {
+	orgID       primitive.ObjectID
+	author      *models.User
+	colleague   *models.User
+	admin       *models.User
+	outsider    *models.User
+	request     *models.EvidenceRequest
+	commentRepo *fakeCommentRepository
+	notifier    *fakeNotificationService
+	service     CommentService
+}
+
+func newCommentFixture(t *testing.T) *commentFixture {
+	t.Helper()
+
+	orgID := primitive.NewObjectID()
+	newUser := func(email string, org primitive.ObjectID, roles ...string) *models.User {
+		user := &models.User{Email: email, OrganizationID: org, IsActive: true, Roles: roles}
+		user.ID = primitive.NewObjectID()
+		return user
+	}
+
+	f := &commentFixture{
+		orgID:       orgID,
+		author:      newUser("author@bank.com", orgID, models.RoleAuditor),
+		colleague:   newUser("colleague@bank.com", orgID, models.RoleOwner),
+		admin:       newUser("admin@bank.com", orgID, models.RoleAdmin),
+		outsider:    newUser("outsider@other.com", primitive.NewObjectID(), models.RoleAdmin),
+		commentRepo: &fakeCommentRepository{},
+		notifier:    newFakeNotificationService(),
+	}
+	f.request = &models.EvidenceRequest{OrganizationID: orgID, RequestID: "REQ-7"}
+	f.request.ID = primitive.NewObjectID()
+
+	evidenceRepo := newFakeEvidenceRequestRepository(f.request)
+	userRepo := newFakeUserRepository(f.author, f.colleague, f.admin, f.outsider)
+	f.service = NewCommentService(
+		f.commentRepo,
+		evidenceRepo,
+		nil,
+		nil,
+		userRepo,
+		&fakeTransactor{},
+		newFakeSubscribedPublisher(&fakeAuditLogRepository{}, f.notifier, evidenceRepo, f.commentRepo, userRepo),
+		zap.NewNop(),
+	)
+	return f
+}
+
+func (f *commentFixture) create(t *testing.T, author *models.User, parentID, content string) *models.Comment {
+	t.Helper()
+	comment, err := f.service.AddComment(context.Background(), &CreateCommentInput{
+		OrganizationID: f.orgID.Hex(),
+		AuthorID:       author.ID.Hex(),
+		ResourceType:   models.ResourceTypeEvidenceRequest,
+		ResourceID:     f.request.ID.Hex(),
+		ParentID:       parentID,
+		Content:        content,
+	})
+	require.NoError(t, err)
+	return comment
+}
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected []string
+	}{
+		{"@jane@bank.com please review", []string{"jane@bank.com"}},
+		{"cc @Jane@Bank.com, @bob.smith@bank.com.", []string{"jane@bank.com", "bob.smith@bank.com"}},
+		{"send to jane@bank.com", nil},
+		{"@jane@bank.com and again @jane@bank.com", []string{"jane@bank.com"}},
+		{"(@ops@bank.com)", []string{"ops@bank.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseMentions(tt.content))
+		})
+	}
+}
+
+func TestCommentService_ThreadingAndMentions(t *testing.T) {
+	f := newCommentFixture(t)
+
+	root := f.create(t, f.author, "", "Please upload the Q3 sample, @colleague@bank.com and @outsider@other.com")
+	assert.Equal(t, []string{f.colleague.ID.Hex()}, root.Mentions)
+	assert.Equal(t, []string{"colleague@bank.com"}, f.notifier.mentions)
+
+	reply := f.create(t, f.colleague, root.ID, "Uploaded, @author@bank.com")
+	f.create(t, f.author, reply.ID, "Thanks")
+	f.create(t, f.admin, "", "Separate topic")
+
+	threads, err := f.service.GetThread(context.Background(), f.orgID.Hex(), models.ResourceTypeEvidenceRequest, f.request.ID.Hex())
+	require.NoError(t, err)
+	require.Len(t, threads, 2)
+	assert.Equal(t, root.ID, threads[0].Comment.ID)
+	require.Len(t, threads[0].Replies, 1)
+	assert.Equal(t, reply.ID, threads[0].Replies[0].Comment.ID)
+	assert.Len(t, threads[0].Replies[0].Replies, 1)
+	assert.Empty(t, threads[1].Replies)
+}
+
+func TestCommentService_EditKeepsHistoryAndNotifiesNewMentionsOnly(t *testing.T) {
+	f := newCommentFixture(t)
+	comment := f.create(t, f.author, "", "Ping @colleague@bank.com")
+
+	edited, err := f.service.EditComment(context.Background(), &EditCommentInput{
+		OrganizationID: f.orgID.Hex(),
+		CommentID:      comment.ID,
+		ActorID:        f.author.ID.Hex(),
+		Content:        "Ping @colleague@bank.com and @admin@bank.com",
+	})
+	require.NoError(t, err)
+	require.Len(t, edited.EditHistory, 1)
+	assert.Equal(t, "Ping @colleague@bank.com", edited.EditHistory[0].Content)
+	assert.Equal(t, []string{"colleague@bank.com", "admin@bank.com"}, f.notifier.mentions)
+
+	_, err = f.service.EditComment(context.Background(), &EditCommentInput{
+		OrganizationID: f.orgID.Hex(),
+		CommentID:      comment.ID,
+		ActorID:        f.admin.ID.Hex(),
+		Content:        "Rewritten by someone else",
+	})
+	assert.ErrorIs(t, err, ErrCommentForbidden)
+}
+
+func TestCommentService_SoftDelete(t *testing.T) {
+	f := newCommentFixture(t)
+	ctx := context.Background()
+	root := f.create(t, f.author, "", "Original")
+	f.create(t, f.colleague, root.ID, "Reply")
+
+	err := f.service.DeleteComment(ctx, f.orgID.Hex(), root.ID, f.colleague.ID.Hex())
+	assert.ErrorIs(t, err, ErrCommentForbidden)
+
+	err = f.service.DeleteComment(ctx, f.orgID.Hex(), root.ID, f.outsider.ID.Hex())
+	assert.ErrorIs(t, err, ErrCommentForbidden)
+
+	require.NoError(t, f.service.DeleteComment(ctx, f.orgID.Hex(), root.ID, f.admin.ID.Hex()))
+
+	threads, err := f.service.GetThread(ctx, f.orgID.Hex(), models.ResourceTypeEvidenceRequest, f.request.ID.Hex())
+	require.NoError(t, err)
+	require.Len(t, threads, 1)
+	assert.True(t, threads[0].Comment.IsDeleted())
+	assert.Empty(t, threads[0].Comment.Content)
+	assert.Len(t, threads[0].Replies, 1)
+
+	// The stored comment keeps its content for audit purposes
+	stored, err := f.commentRepo.GetByID(ctx, root.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Original", stored.Content)
+
+	_, err = f.service.AddComment(ctx, &CreateCommentInput{
+		OrganizationID: f.orgID.Hex(),
+		AuthorID:       f.author.ID.Hex(),
+		ResourceType:   models.ResourceTypeEvidenceRequest,
+		ResourceID:     f.request.ID.Hex(),
+		ParentID:       root.ID,
+		Content:        "Reply to deleted",
+	})
+	assert.ErrorIs(t, err, ErrCommentDeleted)
+}
+
+func TestCommentService_Validation(t *testing.T) {
+	f := newCommentFixture(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		input    CreateCommentInput
+		expected error
+	}{
+		{
+			name:     "empty content",
+			input:    CreateCommentInput{ResourceType: models.ResourceTypeEvidenceRequest, ResourceID: f.request.ID.Hex(), Content: "  "},
+			expected: ErrCommentContentRequired,
+		},
+		{
+			name:     "unsupported resource type",
+			input:    CreateCommentInput{ResourceType: "organization", ResourceID: "x", Content: "hi"},
+			expected: ErrUnsupportedResourceType,
+		},
+		{
+			name:     "unknown evidence request",
+			input:    CreateCommentInput{ResourceType: models.ResourceTypeEvidenceRequest, ResourceID: primitive.NewObjectID().Hex(), Content: "hi"},
+			expected: ErrCommentResourceNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := tt.input
+			input.OrganizationID = f.orgID.Hex()
+			input.AuthorID = f.author.ID.Hex()
+			_, err := f.service.AddComment(ctx, &input)
+			assert.ErrorIs(t, err, tt.expected)
+		})
+	}
+
+	t.Run("evidence request of another organization", func(t *testing.T) {
+		_, err := f.service.AddComment(ctx, &CreateCommentInput{
+			OrganizationID: primitive.NewObjectID().Hex(),
+			AuthorID:       f.outsider.ID.Hex(),
+			ResourceType:   models.ResourceTypeEvidenceRequest,
+			ResourceID:     f.request.ID.Hex(),
+			Content:        "hi",
+		})
+		assert.ErrorIs(t, err, ErrCommentResourceNotFound)
+	})
+}
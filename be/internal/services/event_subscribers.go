@@ -0,0 +1,284 @@
+// Package services provides service layer implementations for the GoEdu Control Testing Platform.
+// This file subscribes the audit, cache, notification and webhook side effects
+// to the domain events published by the services.
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/events"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+)
+
+// Event subscription names. Asynchronous subscription names are also the
+// stream consumer groups and must not change once deployed.
+const (
+	eventSubscriptionAudit         = "audit"
+	eventSubscriptionCache         = "cache"
+	eventSubscriptionNotifications = "notifications"
+	eventSubscriptionWebhooks      = "webhooks"
+)
+
+// notifiedEventTypes are the event types the notification subscriber handles.
+var notifiedEventTypes = []string{
+	events.TypeCycleCompleted,
+	events.TypeFeatureFlagUpdated,
+	events.TypeEvidenceReviewersAssigned,
+	events.TypeEvidenceSubmitted,
+	events.TypeEvidenceRejected,
+	events.TypeEvidenceCompleted,
+	events.TypeEvidenceReminderSent,
+	events.TypeEvidenceEscalated,
+	events.TypeCommentCreated,
+	events.TypeCommentEdited,
+}
+
+// eventCacheKeys returns the cache keys made stale by an event, by event type.
+var eventCacheKeys = map[string]func(event *events.Event) []string{
+	events.TypeFeatureFlagUpdated: func(event *events.Event) []string {
+		return []string{
+			fmt.Sprintf("org:flags:%s", event.OrganizationID),
+			fmt.Sprintf("org:%s", event.OrganizationID),
+		}
+	},
+}
+
+// eventSubscribers holds the side effects triggered by domain events.
+type eventSubscribers struct {
+	auditSvc        AuditService
+	cacheRepo       repositories.CacheRepository
+	notificationSvc NotificationService
+	webhookSvc      WebhookService
+	evidenceRepo    repositories.EvidenceRequestRepository
+	commentRepo     repositories.CommentRepository
+	userRepo        repositories.UserRepository
+	logger          *zap.Logger
+}
+
+// RegisterEventSubscribers subscribes the platform's side effects to the event
+// bus. Audit logging runs synchronously inside the publishing transaction, so
+// a change whose audit entry cannot be written is not made. Cache
+// invalidation, notifications and webhooks run asynchronously from the event
+// stream, after the change committed; invalidating earlier would let a
+// concurrent read cache the data the transaction is replacing.
+//
+// Parameters:
+//   - bus: Event bus to subscribe to
+//   - auditSvc: Service recording an audit entry for every event
+//   - cacheRepo: Cache invalidated by events that change cached data
+//   - notificationSvc: Service sending notifications about events
+//   - webhookSvc: Service forwarding events to webhook subscriptions
+//   - evidenceRepo: Repository loading the evidence requests notified about
+//   - commentRepo: Repository loading the comments notified about
+//   - userRepo: Repository loading the users to notify
+//   - logger: Logger for subscriber operations
+//
+// Example:
+//
+//	bus := events.NewBus(domainEventRepo, cacheClient, cfg.Events, logger)
+//	services.RegisterEventSubscribers(bus, auditService, cacheRepo, notificationService, webhookService,
+//	    evidenceRepo, commentRepo, userRepo, logger)
+func RegisterEventSubscribers(
+	bus *events.Bus,
+	auditSvc AuditService,
+	cacheRepo repositories.CacheRepository,
+	notificationSvc NotificationService,
+	webhookSvc WebhookService,
+	evidenceRepo repositories.EvidenceRequestRepository,
+	commentRepo repositories.CommentRepository,
+	userRepo repositories.UserRepository,
+	logger *zap.Logger,
+) {
+	s := &eventSubscribers{
+		auditSvc:        auditSvc,
+		cacheRepo:       cacheRepo,
+		notificationSvc: notificationSvc,
+		webhookSvc:      webhookSvc,
+		evidenceRepo:    evidenceRepo,
+		commentRepo:     commentRepo,
+		userRepo:        userRepo,
+		logger:          logger,
+	}
+
+	bus.Subscribe(eventSubscriptionAudit, s.audit)
+
+	cacheTypes := make([]string, 0, len(eventCacheKeys))
+	for eventType := range eventCacheKeys {
+		cacheTypes = append(cacheTypes, eventType)
+	}
+	bus.SubscribeAsync(eventSubscriptionCache, s.invalidateCache, cacheTypes...)
+
+	bus.SubscribeAsync(eventSubscriptionNotifications, s.notify, notifiedEventTypes...)
+	bus.SubscribeAsync(eventSubscriptionWebhooks, s.forwardToWebhooks, WebhookEventTypes...)
+}
+
+// audit records an audit entry for an event, with its payload as metadata.
+func (s *eventSubscribers) audit(ctx context.Context, event *events.Event) error {
+	metadata, err := eventMetadata(event)
+	if err != nil {
+		return err
+	}
+	metadata["event_id"] = event.ID
+
+	return s.auditSvc.LogAction(ctx, &AuditInput{
+		UserID:         event.ActorID,
+		OrganizationID: event.OrganizationID,
+		Action:         event.Type,
+		ResourceType:   event.ResourceType,
+		ResourceID:     event.ResourceID,
+		CorrelationID:  event.CorrelationID,
+		Metadata:       metadata,
+		Success:        true,
+	})
+}
+
+// invalidateCache deletes the cache entries made stale by an event.
+func (s *eventSubscribers) invalidateCache(ctx context.Context, event *events.Event) error {
+	keys, ok := eventCacheKeys[event.Type]
+	if !ok {
+		return nil
+	}
+	if err := s.cacheRepo.Delete(ctx, keys(event)...); err != nil {
+		return fmt.Errorf("failed to invalidate cache: %w", err)
+	}
+	return nil
+}
+
+// notify sends the notifications about an event.
+func (s *eventSubscribers) notify(ctx context.Context, event *events.Event) error {
+	switch payload := event.Payload.(type) {
+	case *events.CycleCompleted:
+		if payload.Cycle == nil {
+			return nil
+		}
+		return s.notificationSvc.SendTestingCycleNotification(ctx, payload.Cycle, "completed")
+	case *events.FeatureFlagUpdated:
+		state := "disabled"
+		if payload.Enabled {
+			state = "enabled"
+		}
+		return s.notificationSvc.SendSystemAlert(ctx, &SystemAlert{
+			Type:     event.Type,
+			Severity: models.AlertSeverityInfo,
+			Title:    "Feature flag updated",
+			Message:  fmt.Sprintf("Feature %q was %s.", payload.Flag, state),
+			Metadata: map[string]interface{}{"organization_id": event.OrganizationID},
+		})
+	case *events.EvidenceReviewersAssigned:
+		if !payload.UnderReview {
+			return nil
+		}
+		return s.notifyReview(ctx, event, ReviewEventRequested)
+	case *events.EvidenceSubmitted:
+		return s.notifyReview(ctx, event, ReviewEventRequested)
+	case *events.EvidenceRejected:
+		return s.notifyReview(ctx, event, ReviewEventRejected)
+	case *events.EvidenceCompleted:
+		if !payload.ApprovalRequired {
+			return nil
+		}
+		return s.notifyReview(ctx, event, ReviewEventApproved)
+	case *events.EvidenceReminderSent:
+		request, err := s.evidenceRequest(ctx, event)
+		if err != nil || request == nil {
+			return err
+		}
+		return s.notificationSvc.SendReminderNotification(ctx, request)
+	case *events.EvidenceEscalated:
+		request, err := s.evidenceRequest(ctx, event)
+		if err != nil || request == nil {
+			return err
+		}
+		manager, err := s.userRepo.GetByID(ctx, payload.ManagerID)
+		if err != nil {
+			return fmt.Errorf("failed to get manager: %w", err)
+		}
+		return s.notificationSvc.SendEscalationNotification(ctx, request, manager)
+	case *events.CommentCreated:
+		return s.notifyMentions(ctx, event, payload.NotifyUserIDs)
+	case *events.CommentEdited:
+		return s.notifyMentions(ctx, event, payload.NotifyUserIDs)
+	}
+	return nil
+}
+
+// notifyReview sends a review notification about the evidence request of an event.
+func (s *eventSubscribers) notifyReview(ctx context.Context, event *events.Event, reviewEvent string) error {
+	request, err := s.evidenceRequest(ctx, event)
+	if err != nil || request == nil {
+		return err
+	}
+	return s.notificationSvc.SendReviewNotification(ctx, request, reviewEvent)
+}
+
+// evidenceRequest loads the evidence request of an event, or nil if it no
+// longer exists.
+func (s *eventSubscribers) evidenceRequest(ctx context.Context, event *events.Event) (*models.EvidenceRequest, error) {
+	request, err := s.evidenceRepo.GetByID(ctx, event.ResourceID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get evidence request: %w", err)
+	}
+	return request, nil
+}
+
+// notifyMentions notifies the users mentioned in the comment of an event.
+// Deleted comments and users that no longer exist are skipped. Everything is
+// loaded before the first notification so that a retry never notifies a user
+// twice; failed notifications are logged only.
+func (s *eventSubscribers) notifyMentions(ctx context.Context, event *events.Event, userIDs []string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	comment, err := s.commentRepo.GetByID(ctx, event.ResourceID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get comment: %w", err)
+	}
+	if comment.IsDeleted() {
+		return nil
+	}
+	users, err := s.userRepo.GetByIDs(ctx, userIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get mentioned users: %w", err)
+	}
+
+	for _, user := range users {
+		if err := s.notificationSvc.SendMentionNotification(ctx, comment, user); err != nil {
+			s.logger.Warn("Failed to send mention notification",
+				zap.Error(err),
+				zap.String("comment_id", comment.ID),
+				zap.String("user_id", user.ID.Hex()),
+			)
+		}
+	}
+	return nil
+}
+
+// forwardToWebhooks queues webhook deliveries of an event.
+func (s *eventSubscribers) forwardToWebhooks(ctx context.Context, event *events.Event) error {
+	return s.webhookSvc.Publish(ctx, event.OrganizationID, event.Type, event.Payload)
+}
+
+// eventMetadata converts an event payload into audit metadata.
+func eventMetadata(event *events.Event) (map[string]interface{}, error) {
+	data, err := json.Marshal(event.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event payload: %w", err)
+	}
+	metadata := make(map[string]interface{})
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to decode event payload: %w", err)
+	}
+	return metadata, nil
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/events"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/requestctx"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/cache/cachetest"
+)
+
+// fakeDomainEventRepository is an in-memory event outbox; createErr makes
+// writes fail.
+type fakeDomainEventRepository struct {
+	mu        sync.Mutex
+	events    []*models.DomainEvent
+	createErr error
+}
+
+func (r *fakeDomainEventRepository) Create(ctx context.Context, event *models.DomainEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.createErr != nil {
+		return r.createErr
+	}
+	stored := *event
+	r.events = append(r.events, &stored)
+	return nil
+}
+
+func (r *fakeDomainEventRepository) Update(ctx context.Context, event *models.DomainEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.events {
+		if existing.ID == event.ID {
+			stored := *event
+			r.events[i] = &stored
+			return nil
+		}
+	}
+	return repositories.ErrNotFound
+}
+
+func (r *fakeDomainEventRepository) ClaimDue(ctx context.Context, now, leaseUntil time.Time) (*models.DomainEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, event := range r.events {
+		if event.Status == models.DomainEventPending && !event.NextAttemptAt.After(now) {
+			event.Status = models.DomainEventRelaying
+			event.LockedUntil = leaseUntil
+			claimed := *event
+			return &claimed, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+// fakeWebhookPublisher records published webhook event types.
+type fakeWebhookPublisher struct {
+	WebhookService
+	mu        sync.Mutex
+	published []string
+}
+
+func (w *fakeWebhookPublisher) Publish(ctx context.Context, orgID, eventType string, data interface{}) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.published = append(w.published, eventType)
+	return nil
+}
+
+type eventFixture struct {
+	org           *models.Organization
+	auditRepo     *fakeAuditLogRepository
+	cache         *fakeCacheRepository
+	outbox        *fakeDomainEventRepository
+	transactor    *fakeTransactor
+	notifications *fakeNotificationService
+	webhooks      *fakeWebhookPublisher
+	evidenceRepo  *fakeEvidenceRequestRepository
+	users         *fakeUserRepository
+	streams       *cachetest.Streams
+	bus           *events.Bus
+	orgService    OrganizationService
+}
+
+func newEventFixture(t *testing.T) *eventFixture {
+	t.Helper()
+
+	org := &models.Organization{Name: "First Bank", Status: models.OrganizationStatusActive}
+	org.ID = primitive.NewObjectID()
+
+	f := &eventFixture{
+		org:           org,
+		auditRepo:     &fakeAuditLogRepository{},
+		cache:         &fakeCacheRepository{},
+		outbox:        &fakeDomainEventRepository{},
+		transactor:    &fakeTransactor{},
+		notifications: newFakeNotificationService(),
+		webhooks:      &fakeWebhookPublisher{},
+		evidenceRepo:  newFakeEvidenceRequestRepository(),
+		users:         newFakeUserRepository(),
+		streams:       cachetest.NewStreams(),
+	}
+	f.bus = events.NewBus(f.outbox, f.streams, config.EventsConfig{
+		Stream:         "test:events",
+		RelayBatchSize: 10,
+		MaxAttempts:    3,
+		RetryDelay:     time.Millisecond,
+		MaxRetryDelay:  time.Millisecond,
+		ClaimIdle:      time.Minute,
+	}, zap.NewNop())
+	RegisterEventSubscribers(f.bus, NewAuditService(f.auditRepo, nil, zap.NewNop()), f.cache, f.notifications, f.webhooks,
+		f.evidenceRepo, &fakeCommentRepository{}, f.users, zap.NewNop())
+	f.orgService = NewOrganizationService(newFakeOrganizationRepository(org), nil, f.cache, f.transactor, f.bus, zap.NewNop())
+	return f
+}
+
+// consume relays the outbox and runs the asynchronous subscribers until cond holds.
+func (f *eventFixture) consume(t *testing.T, cond func() bool) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Consumer groups only see events relayed after they were created
+	for _, group := range []string{eventSubscriptionCache, eventSubscriptionNotifications, eventSubscriptionWebhooks} {
+		require.NoError(t, f.streams.StreamCreateGroup(ctx, "test:events", group))
+	}
+	done := make(chan error, 1)
+	go func() { done <- f.bus.Consume(ctx) }()
+	defer func() {
+		cancel()
+		require.NoError(t, <-done)
+	}()
+
+	_, err := f.bus.RelayPending(ctx)
+	require.NoError(t, err)
+	require.Eventually(t, cond, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestOrganizationService_UpdateFeatureFlagPublishesEvent(t *testing.T) {
+	f := newEventFixture(t)
+	orgID := f.org.ID.Hex()
+	adminID := primitive.NewObjectID().Hex()
+	ctx := requestctx.WithMetadata(context.Background(), &requestctx.Metadata{CorrelationID: "corr-1", UserID: adminID})
+
+	require.NoError(t, f.orgService.UpdateFeatureFlag(ctx, orgID, "sso", true))
+	assert.True(t, f.org.FeatureFlags["sso"])
+
+	// Synchronous subscribers are done when the call returns
+	require.Len(t, f.auditRepo.entries, 1)
+	entry := f.auditRepo.entries[0]
+	assert.Equal(t, events.TypeFeatureFlagUpdated, entry.Action)
+	assert.Equal(t, adminID, entry.UserID.Hex())
+	assert.Equal(t, "corr-1", entry.CorrelationID)
+	assert.Equal(t, "sso", entry.Metadata["flag"])
+	assert.Equal(t, true, entry.Metadata["enabled"])
+	assert.Empty(t, f.cache.deleted, "the cache is invalidated after the change committed")
+
+	require.Len(t, f.outbox.events, 1)
+	assert.Equal(t, models.DomainEventPending, f.outbox.events[0].Status)
+
+	f.consume(t, func() bool {
+		f.notifications.mu.Lock()
+		defer f.notifications.mu.Unlock()
+		return len(f.notifications.alerts) == 1
+	})
+	require.Eventually(t, func() bool { return len(f.cache.keys()) == 2 }, 2*time.Second, 10*time.Millisecond)
+	assert.ElementsMatch(t, []string{"org:flags:" + orgID, "org:" + orgID}, f.cache.keys())
+	assert.Equal(t, models.DomainEventRelayed, f.outbox.events[0].Status)
+	assert.Empty(t, f.webhooks.published)
+}
+
+func TestOrganizationService_UpdateFeatureFlagFailsWithoutOutbox(t *testing.T) {
+	f := newEventFixture(t)
+	f.outbox.createErr = errors.New("write conflict")
+
+	err := f.orgService.UpdateFeatureFlag(context.Background(), f.org.ID.Hex(), "sso", true)
+	assert.Error(t, err)
+	assert.Equal(t, 1, f.transactor.aborted)
+	assert.Empty(t, f.auditRepo.entries)
+	assert.Empty(t, f.cache.deleted)
+}
+
+func TestOrganizationService_UpdateFeatureFlagFailsWithoutAuditEntry(t *testing.T) {
+	f := newEventFixture(t)
+	f.auditRepo.createErr = errors.New("audit store unavailable")
+
+	err := f.orgService.UpdateFeatureFlag(context.Background(), f.org.ID.Hex(), "sso", true)
+	assert.ErrorIs(t, err, f.auditRepo.createErr, "changes are not made without their audit entry")
+	assert.Equal(t, 1, f.transactor.aborted)
+	assert.Empty(t, f.cache.deleted)
+}
+
+func TestEventSubscribers_CycleCompletedNotifiesAndForwardsToWebhooks(t *testing.T) {
+	f := newEventFixture(t)
+	orgID := f.org.ID.Hex()
+	cycle := &models.TestingCycle{Name: "Q3 SOX testing"}
+	cycle.ID = primitive.NewObjectID()
+
+	event := events.New(orgID, models.ResourceTypeTestingCycle, cycle.ID.Hex(), &events.CycleCompleted{Cycle: cycle})
+	require.NoError(t, f.bus.Publish(context.Background(), event))
+	assert.Equal(t, []string{events.TypeCycleCompleted}, f.auditRepo.actions())
+
+	f.consume(t, func() bool {
+		f.notifications.mu.Lock()
+		defer f.notifications.mu.Unlock()
+		f.webhooks.mu.Lock()
+		defer f.webhooks.mu.Unlock()
+		return len(f.notifications.cycleEvents) == 1 && len(f.webhooks.published) == 1
+	})
+	assert.Equal(t, []string{"completed"}, f.notifications.cycleEvents)
+	assert.Equal(t, []string{models.WebhookEventCycleCompleted}, f.webhooks.published)
+}
+
+func TestEvidenceLifecycleService_RemindersGoThroughOutbox(t *testing.T) {
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	f := newEventFixture(t)
+	f.org.Settings.EnableWorkflowReminders = true
+	f.org.Settings.Notifications.DeadlineReminders = true
+	request := &models.EvidenceRequest{
+		OrganizationID: f.org.ID,
+		RequestID:      "REQ-9",
+		Status:         models.EvidenceRequestStatusPending,
+		DueDate:        now.Add(12 * time.Hour),
+	}
+	request.ID = primitive.NewObjectID()
+	f.evidenceRepo.requests[request.ID.Hex()] = request
+	lifecycle := NewEvidenceLifecycleService(newFakeOrganizationRepository(f.org), f.evidenceRepo, &fakeCommentRepository{}, f.users, f.transactor, f.bus,
+		config.WorkflowConfig{ReminderOffsets: []time.Duration{24 * time.Hour}}, zap.NewNop())
+
+	result, err := lifecycle.ProcessOrganization(context.Background(), f.org.ID.Hex(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.RemindersSent)
+	require.Len(t, f.outbox.events, 1)
+	assert.Equal(t, []string{events.TypeEvidenceReminderSent}, f.auditRepo.actions())
+	assert.Empty(t, f.notifications.reminders, "reminders are sent by the notification subscriber")
+
+	f.consume(t, func() bool {
+		f.notifications.mu.Lock()
+		defer f.notifications.mu.Unlock()
+		return len(f.notifications.reminders) == 1
+	})
+	assert.Equal(t, []string{"REQ-9"}, f.notifications.reminders)
+}
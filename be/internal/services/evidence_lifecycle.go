@@ -0,0 +1,346 @@
+// Package services provides service layer implementations for the GoEdu Control Testing Platform.
+// This file contains the evidence request lifecycle service which handles deadline
+// reminders, overdue transitions and manager escalation.
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/events"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+)
+
+// lifecycleOrganizationPageSize bounds how many organizations are loaded per page
+// while iterating over all active tenants.
+const lifecycleOrganizationPageSize = 100
+
+// Evidence lifecycle audit actions, recorded from the events of the same type
+const (
+	AuditActionEvidenceReminderSent = events.TypeEvidenceReminderSent
+	AuditActionEvidenceOverdue      = events.TypeEvidenceOverdue
+	AuditActionEvidenceEscalated    = events.TypeEvidenceEscalated
+)
+
+// evidenceLifecycleService implements the EvidenceLifecycleService interface.
+// Every transition it performs is persisted on the request together with an
+// event, from which the audit trail and the reminder and escalation
+// notifications are produced, and a system comment on the request.
+type evidenceLifecycleService struct {
+	orgRepo         repositories.OrganizationRepository
+	evidenceRepo    repositories.EvidenceRequestRepository
+	commentRepo     repositories.CommentRepository
+	userRepo        repositories.UserRepository
+	transactor      repositories.Transactor
+	events          events.Publisher
+	reminderOffsets []time.Duration
+	gracePeriod     time.Duration
+	logger          *zap.Logger
+}
+
+// NewEvidenceLifecycleService creates a new evidence lifecycle service.
+//
+// Parameters:
+//   - orgRepo: Repository for organization data operations
+//   - evidenceRepo: Repository for evidence request data operations
+//   - commentRepo: Repository storing the system comments on transitions
+//   - userRepo: Repository for resolving assignees and their managers
+//   - transactor: Runs a transition and its event in one transaction
+//   - publisher: Publisher of lifecycle events
+//   - cfg: Workflow configuration with reminder offsets and escalation grace period
+//   - logger: Logger for service operations
+//
+// Returns:
+//   - EvidenceLifecycleService: Configured lifecycle service instance
+func NewEvidenceLifecycleService(
+	orgRepo repositories.OrganizationRepository,
+	evidenceRepo repositories.EvidenceRequestRepository,
+	commentRepo repositories.CommentRepository,
+	userRepo repositories.UserRepository,
+	transactor repositories.Transactor,
+	publisher events.Publisher,
+	cfg config.WorkflowConfig,
+	logger *zap.Logger,
+) EvidenceLifecycleService {
+	// Sort offsets from the earliest reminder (largest offset) to the latest
+	offsets := append([]time.Duration(nil), cfg.ReminderOffsets...)
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] > offsets[j] })
+
+	return &evidenceLifecycleService{
+		orgRepo:         orgRepo,
+		evidenceRepo:    evidenceRepo,
+		commentRepo:     commentRepo,
+		userRepo:        userRepo,
+		transactor:      transactor,
+		events:          publisher,
+		reminderOffsets: offsets,
+		gracePeriod:     cfg.EscalationGracePeriod,
+		logger:          logger,
+	}
+}
+
+// RunLifecycle processes evidence requests of all active organizations.
+// Failures for a single organization are logged and counted but do not
+// abort the run for the remaining organizations.
+//
+// Parameters:
+//   - ctx: Request context
+//   - now: Reference time used for all deadline calculations
+//
+// Returns:
+//   - *LifecycleRunResult: Aggregated counters for the run
+//   - error: Error if organizations cannot be listed
+func (s *evidenceLifecycleService) RunLifecycle(ctx context.Context, now time.Time) (*LifecycleRunResult, error) {
+	total := &LifecycleRunResult{}
+
+	for offset := 0; ; offset += lifecycleOrganizationPageSize {
+		orgs, err := s.orgRepo.GetActiveOrganizations(ctx, lifecycleOrganizationPageSize, offset)
+		if err != nil {
+			return total, fmt.Errorf("failed to list active organizations: %w", err)
+		}
+
+		for _, org := range orgs {
+			result, err := s.processOrganization(ctx, org, now)
+			if err != nil {
+				s.logger.Error("Evidence lifecycle failed for organization",
+					zap.Error(err),
+					zap.String("organization_id", org.ID.Hex()),
+				)
+				total.Failures++
+				continue
+			}
+			total.add(result)
+		}
+
+		if len(orgs) < lifecycleOrganizationPageSize {
+			break
+		}
+	}
+
+	return total, nil
+}
+
+// ProcessOrganization processes evidence requests of a single organization.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID
+//   - now: Reference time used for all deadline calculations
+//
+// Returns:
+//   - *LifecycleRunResult: Counters for the organization
+//   - error: Error if the organization or its requests cannot be loaded
+func (s *evidenceLifecycleService) ProcessOrganization(ctx context.Context, orgID string, now time.Time) (*LifecycleRunResult, error) {
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+
+	return s.processOrganization(ctx, org, now)
+}
+
+// processOrganization applies reminder, overdue and escalation rules to every
+// open request of the organization that is due within the reminder horizon.
+func (s *evidenceLifecycleService) processOrganization(ctx context.Context, org *models.Organization, now time.Time) (*LifecycleRunResult, error) {
+	result := &LifecycleRunResult{OrganizationsProcessed: 1}
+
+	requests, err := s.evidenceRepo.GetOpenDueBefore(ctx, org.ID.Hex(), now.Add(s.reminderHorizon()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get evidence requests: %w", err)
+	}
+
+	remindersEnabled := org.Settings.EnableWorkflowReminders && org.Settings.Notifications.DeadlineReminders
+
+	for _, request := range requests {
+		// Evidence awaiting review has been delivered; deadlines no longer apply
+		if !request.IsOpen() || request.Status == models.EvidenceRequestStatusUnderReview {
+			continue
+		}
+		result.RequestsExamined++
+
+		err := s.processRequest(ctx, request, now, remindersEnabled, result)
+		if errors.Is(err, ErrEvidenceRequestConflict) {
+			// Another run or a user changed the request first; the next run picks it up
+			s.logger.Debug("Skipping evidence request changed during lifecycle run",
+				zap.String("organization_id", org.ID.Hex()),
+				zap.String("request_id", request.RequestID),
+			)
+			continue
+		}
+		if err != nil {
+			s.logger.Warn("Failed to process evidence request lifecycle",
+				zap.Error(err),
+				zap.String("organization_id", org.ID.Hex()),
+				zap.String("request_id", request.RequestID),
+			)
+			result.Failures++
+		}
+	}
+
+	return result, nil
+}
+
+// processRequest moves a single request through its lifecycle stages.
+func (s *evidenceLifecycleService) processRequest(ctx context.Context, request *models.EvidenceRequest, now time.Time, remindersEnabled bool, result *LifecycleRunResult) error {
+	if now.Before(request.DueDate) {
+		if !remindersEnabled {
+			return nil
+		}
+		sent, err := s.sendReminder(ctx, request, now)
+		if sent {
+			result.RemindersSent++
+		}
+		return err
+	}
+
+	if request.Status != models.EvidenceRequestStatusOverdue {
+		if err := s.markOverdue(ctx, request, now); err != nil {
+			return err
+		}
+		result.MarkedOverdue++
+	}
+
+	escalated, err := s.escalate(ctx, request, now)
+	if escalated {
+		result.Escalated++
+	}
+	return err
+}
+
+// sendReminder sends the next due reminder stage, if any. Reminders are counted
+// by stage so that a missed run never produces duplicate notifications. The
+// stage is claimed by saving it before the reminder is queued, and the save
+// fails if another run saved the request first, so concurrent runs cannot both
+// send it.
+func (s *evidenceLifecycleService) sendReminder(ctx context.Context, request *models.EvidenceRequest, now time.Time) (bool, error) {
+	stage := 0
+	for _, offset := range s.reminderOffsets {
+		if !now.Before(request.DueDate.Add(-offset)) {
+			stage++
+		}
+	}
+	if stage <= request.RemindersSent {
+		return false, nil
+	}
+
+	request.RemindersSent = stage
+	request.LastReminderAt = now
+	message := fmt.Sprintf("Reminder sent: evidence is due on %s.", request.DueDate.UTC().Format(time.RFC1123))
+	if err := s.save(ctx, request, now, message, &events.EvidenceReminderSent{
+		RequestID: request.RequestID,
+		Stage:     stage,
+		DueDate:   request.DueDate,
+	}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// markOverdue transitions a past-due request to the overdue status.
+func (s *evidenceLifecycleService) markOverdue(ctx context.Context, request *models.EvidenceRequest, now time.Time) error {
+	previousStatus := request.Status
+	request.Status = models.EvidenceRequestStatusOverdue
+	request.OverdueAt = now
+	return s.save(ctx, request, now, "Request marked overdue: the due date has passed without a response.", &events.EvidenceOverdue{
+		RequestID:      request.RequestID,
+		PreviousStatus: previousStatus,
+		DueDate:        request.DueDate,
+	})
+}
+
+// escalate escalates the request to the assignee's manager once the grace period has elapsed.
+// Requests are escalated at most once; assignees without a manager are skipped.
+func (s *evidenceLifecycleService) escalate(ctx context.Context, request *models.EvidenceRequest, now time.Time) (bool, error) {
+	if !request.EscalatedAt.IsZero() || now.Before(request.DueDate.Add(s.gracePeriod)) {
+		return false, nil
+	}
+
+	assignee, err := s.userRepo.GetByID(ctx, request.AssigneeID.Hex())
+	if err != nil {
+		return false, fmt.Errorf("failed to get assignee: %w", err)
+	}
+	if assignee.Metadata.ManagerID.IsZero() {
+		s.logger.Debug("Skipping escalation, assignee has no manager",
+			zap.String("request_id", request.RequestID),
+			zap.String("assignee_id", request.AssigneeID.Hex()),
+		)
+		return false, nil
+	}
+
+	manager, err := s.userRepo.GetByID(ctx, assignee.Metadata.ManagerID.Hex())
+	if err != nil {
+		return false, fmt.Errorf("failed to get manager: %w", err)
+	}
+
+	request.EscalatedAt = now
+	request.EscalatedTo = manager.ID
+	message := fmt.Sprintf("Escalated to %s: request is still overdue after the grace period.", manager.Email)
+	if err := s.save(ctx, request, now, message, &events.EvidenceEscalated{
+		RequestID: request.RequestID,
+		ManagerID: manager.ID.Hex(),
+		DueDate:   request.DueDate,
+	}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// save persists lifecycle changes on the request together with a system
+// comment describing the transition and its event. It returns
+// ErrEvidenceRequestConflict if the request was saved since it was loaded.
+func (s *evidenceLifecycleService) save(ctx context.Context, request *models.EvidenceRequest, now time.Time, message string, payload events.Payload) error {
+	request.UpdatedAt = now
+	request.UpdatedBy = models.SystemActorID
+	loadedVersion := request.Version
+	request.Version++
+	comment := &models.Comment{
+		ID:             models.NewID(),
+		AuthorID:       models.SystemActorID,
+		Content:        message,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		OrganizationID: request.OrganizationID,
+		ResourceType:   models.ResourceTypeEvidenceRequest,
+		ResourceID:     request.ID.Hex(),
+		Status:         models.CommentStatusActive,
+	}
+	return s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		updated, err := s.evidenceRepo.UpdateIfVersion(ctx, request, loadedVersion)
+		if err != nil {
+			return fmt.Errorf("failed to update evidence request: %w", err)
+		}
+		if !updated {
+			return ErrEvidenceRequestConflict
+		}
+		if err := s.commentRepo.Create(ctx, comment); err != nil {
+			return fmt.Errorf("failed to create comment: %w", err)
+		}
+		return s.events.Publish(ctx, events.New(request.OrganizationID.Hex(), models.ResourceTypeEvidenceRequest, request.ID.Hex(), payload))
+	})
+}
+
+// reminderHorizon returns how far ahead of now requests must be loaded so that
+// the earliest reminder stage can be evaluated.
+func (s *evidenceLifecycleService) reminderHorizon() time.Duration {
+	if len(s.reminderOffsets) == 0 {
+		return 0
+	}
+	return s.reminderOffsets[0]
+}
+
+// add accumulates counters of another result into r.
+func (r *LifecycleRunResult) add(other *LifecycleRunResult) {
+	r.OrganizationsProcessed += other.OrganizationsProcessed
+	r.RequestsExamined += other.RequestsExamined
+	r.RemindersSent += other.RemindersSent
+	r.MarkedOverdue += other.MarkedOverdue
+	r.Escalated += other.Escalated
+	r.Failures += other.Failures
+}
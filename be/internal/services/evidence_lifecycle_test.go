@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+)
+
+type lifecycleFixture struct {
+	org          *models.Organization
+	assignee     *models.User
+	manager      *models.User
+	evidenceRepo *fakeEvidenceRequestRepository
+	auditRepo    *fakeAuditLogRepository
+	commentRepo  *fakeCommentRepository
+	notifier     *fakeNotificationService
+	service      EvidenceLifecycleService
+}
+
+func newLifecycleFixture(t *testing.T, remindersEnabled bool, requests ...*models.EvidenceRequest) *lifecycleFixture {
+	t.Helper()
+
+	org := &models.Organization{Status: models.OrganizationStatusActive}
+	org.ID = primitive.NewObjectID()
+	org.Settings.EnableWorkflowReminders = remindersEnabled
+	org.Settings.Notifications.DeadlineReminders = remindersEnabled
+
+	manager := &models.User{Email: "manager@example.com"}
+	manager.ID = primitive.NewObjectID()
+	assignee := &models.User{Email: "owner@example.com"}
+	assignee.ID = primitive.NewObjectID()
+	assignee.Metadata.ManagerID = manager.ID
+
+	for _, request := range requests {
+		request.OrganizationID = org.ID
+		if request.AssigneeID.IsZero() {
+			request.AssigneeID = assignee.ID
+		}
+	}
+
+	f := &lifecycleFixture{
+		org:          org,
+		assignee:     assignee,
+		manager:      manager,
+		evidenceRepo: newFakeEvidenceRequestRepository(requests...),
+		auditRepo:    &fakeAuditLogRepository{},
+		commentRepo:  &fakeCommentRepository{},
+		notifier:     newFakeNotificationService(),
+	}
+	userRepo := newFakeUserRepository(assignee, manager)
+	f.service = NewEvidenceLifecycleService(
+		newFakeOrganizationRepository(org),
+		f.evidenceRepo,
+		f.commentRepo,
+		userRepo,
+		&fakeTransactor{},
+		newFakeSubscribedPublisher(f.auditRepo, f.notifier, f.evidenceRepo, f.commentRepo, userRepo),
+		config.WorkflowConfig{
+			ReminderOffsets:       []time.Duration{24 * time.Hour, 72 * time.Hour},
+			EscalationGracePeriod: 48 * time.Hour,
+		},
+		zap.NewNop(),
+	)
+	return f
+}
+
+func newLifecycleRequest(requestID, status string, due time.Time) *models.EvidenceRequest {
+	request := &models.EvidenceRequest{RequestID: requestID, Status: status, DueDate: due}
+	request.ID = primitive.NewObjectID()
+	return request
+}
+
+func TestEvidenceLifecycle_Reminders(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("sends one reminder per stage", func(t *testing.T) {
+		request := newLifecycleRequest("REQ-1", models.EvidenceRequestStatusPending, now.Add(48*time.Hour))
+		f := newLifecycleFixture(t, true, request)
+
+		result, err := f.service.RunLifecycle(context.Background(), now)
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.RemindersSent)
+		assert.Equal(t, 1, request.RemindersSent)
+		assert.Equal(t, now, request.LastReminderAt)
+
+		// Running again within the same stage must not resend
+		result, err = f.service.RunLifecycle(context.Background(), now.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.RemindersSent)
+
+		// Entering the 24h window triggers the second stage
+		result, err = f.service.RunLifecycle(context.Background(), now.Add(30*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.RemindersSent)
+		assert.Equal(t, 2, request.RemindersSent)
+
+		assert.Equal(t, []string{"REQ-1", "REQ-1"}, f.notifier.reminders)
+		require.Len(t, f.commentRepo.comments, 2)
+		comment := f.commentRepo.comments[0]
+		assert.Equal(t, models.SystemActorID, comment.AuthorID)
+		assert.Equal(t, models.ResourceTypeEvidenceRequest, comment.ResourceType)
+		assert.Equal(t, request.ID.Hex(), comment.ResourceID)
+		assert.Equal(t, []string{AuditActionEvidenceReminderSent, AuditActionEvidenceReminderSent}, f.auditRepo.actions())
+	})
+
+	t.Run("missed stages collapse into a single reminder", func(t *testing.T) {
+		request := newLifecycleRequest("REQ-2", models.EvidenceRequestStatusInProgress, now.Add(time.Hour))
+		f := newLifecycleFixture(t, true, request)
+
+		result, err := f.service.RunLifecycle(context.Background(), now)
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.RemindersSent)
+		assert.Equal(t, 2, request.RemindersSent)
+	})
+
+	t.Run("a concurrent run claims the stage first", func(t *testing.T) {
+		request := newLifecycleRequest("REQ-4", models.EvidenceRequestStatusPending, now.Add(time.Hour))
+		f := newLifecycleFixture(t, true, request)
+		// Another run saved the request after this one loaded it
+		f.evidenceRepo.changeConcurrently(request.ID.Hex())
+
+		result, err := f.service.RunLifecycle(context.Background(), now)
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.RemindersSent)
+		assert.Equal(t, 0, result.Failures)
+		assert.Empty(t, f.notifier.reminders)
+		assert.Empty(t, f.auditRepo.actions())
+		assert.Empty(t, f.commentRepo.comments)
+	})
+
+	t.Run("skipped when organization disables reminders", func(t *testing.T) {
+		request := newLifecycleRequest("REQ-3", models.EvidenceRequestStatusPending, now.Add(time.Hour))
+		f := newLifecycleFixture(t, false, request)
+
+		result, err := f.service.RunLifecycle(context.Background(), now)
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.RemindersSent)
+		assert.Empty(t, f.notifier.reminders)
+	})
+}
+
+func TestEvidenceLifecycle_OverdueAndEscalation(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("marks past-due request overdue without escalating", func(t *testing.T) {
+		request := newLifecycleRequest("REQ-10", models.EvidenceRequestStatusInProgress, now.Add(-time.Hour))
+		f := newLifecycleFixture(t, true, request)
+
+		result, err := f.service.ProcessOrganization(context.Background(), f.org.ID.Hex(), now)
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.MarkedOverdue)
+		assert.Equal(t, 0, result.Escalated)
+		assert.Equal(t, models.EvidenceRequestStatusOverdue, request.Status)
+		assert.Equal(t, now, request.OverdueAt)
+		assert.Equal(t, []string{AuditActionEvidenceOverdue}, f.auditRepo.actions())
+	})
+
+	t.Run("escalates to manager once after grace period", func(t *testing.T) {
+		request := newLifecycleRequest("REQ-11", models.EvidenceRequestStatusPending, now.Add(-72*time.Hour))
+		f := newLifecycleFixture(t, true, request)
+
+		result, err := f.service.RunLifecycle(context.Background(), now)
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.MarkedOverdue)
+		assert.Equal(t, 1, result.Escalated)
+		assert.Equal(t, f.manager.ID, request.EscalatedTo)
+		assert.Equal(t, "manager@example.com", f.notifier.escalations["REQ-11"])
+
+		result, err = f.service.RunLifecycle(context.Background(), now.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.MarkedOverdue)
+		assert.Equal(t, 0, result.Escalated)
+		assert.Equal(t, []string{AuditActionEvidenceOverdue, AuditActionEvidenceEscalated}, f.auditRepo.actions())
+	})
+
+	t.Run("assignee without manager is not escalated", func(t *testing.T) {
+		request := newLifecycleRequest("REQ-12", models.EvidenceRequestStatusOverdue, now.Add(-72*time.Hour))
+		f := newLifecycleFixture(t, true, request)
+		f.assignee.Metadata.ManagerID = primitive.NilObjectID
+
+		result, err := f.service.RunLifecycle(context.Background(), now)
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.Escalated)
+		assert.Equal(t, 0, result.Failures)
+		assert.True(t, request.EscalatedAt.IsZero())
+	})
+
+	t.Run("closed requests are ignored", func(t *testing.T) {
+		request := newLifecycleRequest("REQ-13", models.EvidenceRequestStatusCompleted, now.Add(-72*time.Hour))
+		f := newLifecycleFixture(t, true, request)
+
+		result, err := f.service.RunLifecycle(context.Background(), now)
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.RequestsExamined)
+		assert.Equal(t, models.EvidenceRequestStatusCompleted, request.Status)
+	})
+}
@@ -0,0 +1,478 @@
+// Package services provides service layer implementations for the GoEdu Control Testing Platform.
+// This file contains the evidence review service which enforces the organization's
+// evidence approval requirements. Audit entries and notifications of review
+// actions come from the subscribers of the events the service publishes.
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/events"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+)
+
+// Evidence review audit actions, recorded from the events of the same type
+const (
+	AuditActionEvidenceReviewersAssigned = events.TypeEvidenceReviewersAssigned
+	AuditActionEvidenceSubmitted         = events.TypeEvidenceSubmitted
+	AuditActionEvidenceApproved          = events.TypeEvidenceApproved
+	AuditActionEvidenceRejected          = events.TypeEvidenceRejected
+	AuditActionEvidenceCompleted         = events.TypeEvidenceCompleted
+)
+
+// Review notification event types passed to NotificationService.SendReviewNotification
+const (
+	ReviewEventRequested = "review_requested"
+	ReviewEventApproved  = "approved"
+	ReviewEventRejected  = "rejected"
+)
+
+// Evidence review errors
+var (
+	ErrEvidenceRequestNotFound = errors.New("evidence request not found")
+	ErrReviewCommentRequired   = errors.New("a review comment is required")
+	ErrReviewNotAllowed        = errors.New("evidence request is not in a reviewable state")
+	ErrNotAssignee             = errors.New("only the assignee can submit evidence for review")
+	ErrNotAssignedReviewer     = errors.New("user is not an assigned reviewer of this request")
+	ErrSelfApproval            = errors.New("submitters cannot review their own evidence")
+	ErrAlreadyReviewed         = errors.New("reviewer already recorded a decision in this round")
+	ErrInvalidReviewer         = errors.New("reviewer must be an active member of the organization other than the assignee")
+	ErrInsufficientReviewers   = errors.New("not enough reviewers assigned to satisfy the approval policy")
+	ErrEvidenceRequestConflict = errors.New("evidence request was changed by someone else, reload it and try again")
+	ErrReviewerAssignment      = errors.New("only managers and administrators other than the assignee can assign reviewers")
+)
+
+// evidenceReviewService implements the EvidenceReviewService interface.
+type evidenceReviewService struct {
+	orgRepo      repositories.OrganizationRepository
+	evidenceRepo repositories.EvidenceRequestRepository
+	commentRepo  repositories.CommentRepository
+	userRepo     repositories.UserRepository
+	transactor   repositories.Transactor
+	events       events.Publisher
+	logger       *zap.Logger
+}
+
+// NewEvidenceReviewService creates a new evidence review service.
+//
+// Parameters:
+//   - orgRepo: Repository used to read the organization's approval policy
+//   - evidenceRepo: Repository for evidence request data operations
+//   - commentRepo: Repository storing the comments made with review actions
+//   - userRepo: Repository used to validate reviewers
+//   - transactor: Runs a change and its events in one transaction
+//   - publisher: Publisher of review events
+//   - logger: Logger for service operations
+//
+// Returns:
+//   - EvidenceReviewService: Configured review service instance
+func NewEvidenceReviewService(
+	orgRepo repositories.OrganizationRepository,
+	evidenceRepo repositories.EvidenceRequestRepository,
+	commentRepo repositories.CommentRepository,
+	userRepo repositories.UserRepository,
+	transactor repositories.Transactor,
+	publisher events.Publisher,
+	logger *zap.Logger,
+) EvidenceReviewService {
+	return &evidenceReviewService{
+		orgRepo:      orgRepo,
+		evidenceRepo: evidenceRepo,
+		commentRepo:  commentRepo,
+		userRepo:     userRepo,
+		transactor:   transactor,
+		events:       publisher,
+		logger:       logger,
+	}
+}
+
+// AssignReviewers replaces the reviewers of an evidence request. Only managers
+// and administrators of the request's organization other than its assignee
+// may assign them, so assignees cannot pick their own reviewers. Reviewers
+// must be active members of the organization and cannot be the assignee.
+// While the request is under review the new reviewers must still be able to
+// give the approvals the organization requires.
+//
+// Parameters:
+//   - ctx: Request context
+//   - input: Organization, request, acting user and reviewer IDs
+//
+// Returns:
+//   - *models.EvidenceRequest: Updated evidence request
+//   - error: ErrReviewerAssignment, ErrInsufficientReviewers, validation or persistence error
+func (s *evidenceReviewService) AssignReviewers(ctx context.Context, input *AssignReviewersInput) (*models.EvidenceRequest, error) {
+	if input == nil || len(input.ReviewerIDs) == 0 {
+		return nil, ErrInvalidInput
+	}
+
+	request, err := s.loadRequest(ctx, input.OrganizationID, input.RequestID)
+	if err != nil {
+		return nil, err
+	}
+	if !request.IsOpen() {
+		return nil, ErrReviewNotAllowed
+	}
+	if err := s.checkAssigner(ctx, request, input.AssignedBy); err != nil {
+		return nil, err
+	}
+
+	reviewerIDs := make([]primitive.ObjectID, 0, len(input.ReviewerIDs))
+	seen := make(map[primitive.ObjectID]struct{}, len(input.ReviewerIDs))
+	for _, id := range input.ReviewerIDs {
+		reviewer, err := s.userRepo.GetByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, repositories.ErrNotFound) {
+				return nil, ErrInvalidReviewer
+			}
+			return nil, fmt.Errorf("failed to get reviewer: %w", err)
+		}
+		if reviewer.OrganizationID != request.OrganizationID || !reviewer.IsActive || reviewer.ID == request.AssigneeID {
+			return nil, ErrInvalidReviewer
+		}
+		if _, dup := seen[reviewer.ID]; dup {
+			continue
+		}
+		seen[reviewer.ID] = struct{}{}
+		reviewerIDs = append(reviewerIDs, reviewer.ID)
+	}
+	if request.Status == models.EvidenceRequestStatusUnderReview {
+		compliance, err := s.getCompliance(ctx, input.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+		if len(reviewerIDs) < requiredApprovals(compliance) {
+			return nil, ErrInsufficientReviewers
+		}
+	}
+
+	now := time.Now().UTC()
+	previous := objectIDsToHex(request.ReviewerIDs)
+	request.ReviewerIDs = reviewerIDs
+	if err := s.save(ctx, request, input.AssignedBy, now, nil, &events.EvidenceReviewersAssigned{
+		RequestID:           request.RequestID,
+		ReviewerIDs:         objectIDsToHex(reviewerIDs),
+		PreviousReviewerIDs: previous,
+		UnderReview:         request.Status == models.EvidenceRequestStatusUnderReview,
+	}); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// SubmitForReview submits the collected evidence. When the organization does not
+// require approval the request completes immediately; otherwise a new review round
+// starts and the assigned reviewers are notified.
+//
+// Parameters:
+//   - ctx: Request context
+//   - input: Organization, request, submitting user and optional comment
+//
+// Returns:
+//   - *models.EvidenceRequest: Updated evidence request
+//   - error: Validation or persistence error
+func (s *evidenceReviewService) SubmitForReview(ctx context.Context, input *ReviewActionInput) (*models.EvidenceRequest, error) {
+	if input == nil {
+		return nil, ErrInvalidInput
+	}
+
+	request, err := s.loadRequest(ctx, input.OrganizationID, input.RequestID)
+	if err != nil {
+		return nil, err
+	}
+	if !request.IsOpen() || request.Status == models.EvidenceRequestStatusUnderReview {
+		return nil, ErrReviewNotAllowed
+	}
+
+	submitterID, err := primitive.ObjectIDFromHex(input.ActorID)
+	if err != nil || submitterID != request.AssigneeID {
+		return nil, ErrNotAssignee
+	}
+
+	compliance, err := s.getCompliance(ctx, input.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	request.SubmittedBy = submitterID
+	request.SubmittedAt = now
+	comment := s.newComment(request, input.ActorID, input.Comment, now)
+
+	if !compliance.RequireEvidenceApproval {
+		request.Status = models.EvidenceRequestStatusCompleted
+		request.CompletedAt = now
+		if err := s.save(ctx, request, input.ActorID, now, comment, &events.EvidenceCompleted{
+			RequestID: request.RequestID,
+		}); err != nil {
+			return nil, err
+		}
+		return request, nil
+	}
+
+	eligible := 0
+	for _, id := range request.ReviewerIDs {
+		if id != submitterID {
+			eligible++
+		}
+	}
+	if eligible < requiredApprovals(compliance) {
+		return nil, ErrInsufficientReviewers
+	}
+
+	previousStatus := request.Status
+	request.Status = models.EvidenceRequestStatusUnderReview
+	request.ReviewRound++
+	if err := s.save(ctx, request, input.ActorID, now, comment, &events.EvidenceSubmitted{
+		RequestID:      request.RequestID,
+		ReviewRound:    request.ReviewRound,
+		PreviousStatus: previousStatus,
+	}); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// ApproveEvidence records an approval from an assigned reviewer. The request is
+// completed once the number of distinct approvers in the current round reaches
+// the organization's MinimumReviewers.
+//
+// Parameters:
+//   - ctx: Request context
+//   - input: Organization, request, reviewing user and mandatory comment
+//
+// Returns:
+//   - *models.EvidenceRequest: Updated evidence request
+//   - error: Validation or persistence error
+func (s *evidenceReviewService) ApproveEvidence(ctx context.Context, input *ReviewActionInput) (*models.EvidenceRequest, error) {
+	request, reviewerID, err := s.prepareDecision(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	compliance, err := s.getCompliance(ctx, input.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	s.appendReview(request, reviewerID, models.ReviewDecisionApproved, input.Comment, now)
+	comment := s.newComment(request, input.ActorID, input.Comment, now)
+
+	approvals := request.ApprovalsInRound(request.ReviewRound)
+	payloads := []events.Payload{&events.EvidenceApproved{
+		RequestID:   request.RequestID,
+		ReviewRound: request.ReviewRound,
+		Approvals:   approvals,
+	}}
+	if approvals >= requiredApprovals(compliance) {
+		request.Status = models.EvidenceRequestStatusCompleted
+		request.CompletedAt = now
+		payloads = append(payloads, &events.EvidenceCompleted{RequestID: request.RequestID, ApprovalRequired: true})
+	}
+	if err := s.save(ctx, request, input.ActorID, now, comment, payloads...); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// RejectEvidence records a rejection from an assigned reviewer and reopens the
+// request to the assignee. Approvals of the rejected round no longer count.
+//
+// Parameters:
+//   - ctx: Request context
+//   - input: Organization, request, reviewing user and mandatory comment
+//
+// Returns:
+//   - *models.EvidenceRequest: Updated evidence request
+//   - error: Validation or persistence error
+func (s *evidenceReviewService) RejectEvidence(ctx context.Context, input *ReviewActionInput) (*models.EvidenceRequest, error) {
+	request, reviewerID, err := s.prepareDecision(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	s.appendReview(request, reviewerID, models.ReviewDecisionRejected, input.Comment, now)
+	comment := s.newComment(request, input.ActorID, input.Comment, now)
+	request.Status = models.EvidenceRequestStatusInProgress
+	if err := s.save(ctx, request, input.ActorID, now, comment, &events.EvidenceRejected{
+		RequestID:   request.RequestID,
+		ReviewRound: request.ReviewRound,
+	}); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// checkAssigner ensures the user assigning reviewers is an active manager or
+// administrator of the request's organization and not its assignee.
+func (s *evidenceReviewService) checkAssigner(ctx context.Context, request *models.EvidenceRequest, assignedBy string) error {
+	assignerID, err := primitive.ObjectIDFromHex(assignedBy)
+	if err != nil || assignerID == request.AssigneeID {
+		return ErrReviewerAssignment
+	}
+	assigner, err := s.userRepo.GetByID(ctx, assignedBy)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return ErrReviewerAssignment
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get assigning user: %w", err)
+	}
+	if assigner.OrganizationID != request.OrganizationID || !assigner.IsActive ||
+		!(assigner.HasRole(models.RoleAdmin) || assigner.HasRole(models.RoleManager)) {
+		return ErrReviewerAssignment
+	}
+	return nil
+}
+
+// prepareDecision performs the checks shared by approve and reject.
+func (s *evidenceReviewService) prepareDecision(ctx context.Context, input *ReviewActionInput) (*models.EvidenceRequest, primitive.ObjectID, error) {
+	if input == nil {
+		return nil, primitive.NilObjectID, ErrInvalidInput
+	}
+	if strings.TrimSpace(input.Comment) == "" {
+		return nil, primitive.NilObjectID, ErrReviewCommentRequired
+	}
+
+	request, err := s.loadRequest(ctx, input.OrganizationID, input.RequestID)
+	if err != nil {
+		return nil, primitive.NilObjectID, err
+	}
+	if request.Status != models.EvidenceRequestStatusUnderReview {
+		return nil, primitive.NilObjectID, ErrReviewNotAllowed
+	}
+
+	reviewerID, err := primitive.ObjectIDFromHex(input.ActorID)
+	if err != nil {
+		return nil, primitive.NilObjectID, ErrNotAssignedReviewer
+	}
+	if reviewerID == request.SubmittedBy || reviewerID == request.AssigneeID {
+		return nil, primitive.NilObjectID, ErrSelfApproval
+	}
+	if !request.IsReviewer(reviewerID) {
+		return nil, primitive.NilObjectID, ErrNotAssignedReviewer
+	}
+	if request.HasReviewedInRound(reviewerID, request.ReviewRound) {
+		return nil, primitive.NilObjectID, ErrAlreadyReviewed
+	}
+
+	return request, reviewerID, nil
+}
+
+// loadRequest retrieves an evidence request and enforces tenant isolation.
+func (s *evidenceReviewService) loadRequest(ctx context.Context, orgID, requestID string) (*models.EvidenceRequest, error) {
+	request, err := s.evidenceRepo.GetByID(ctx, requestID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrEvidenceRequestNotFound
+		}
+		return nil, fmt.Errorf("failed to get evidence request: %w", err)
+	}
+	if request.OrganizationID.Hex() != orgID {
+		return nil, ErrEvidenceRequestNotFound
+	}
+	return request, nil
+}
+
+// getCompliance loads the organization's compliance settings.
+func (s *evidenceReviewService) getCompliance(ctx context.Context, orgID string) (*models.OrganizationCompliance, error) {
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	return &org.Settings.ComplianceSettings, nil
+}
+
+// appendReview adds a reviewer decision for the current round.
+func (s *evidenceReviewService) appendReview(request *models.EvidenceRequest, reviewerID primitive.ObjectID, decision, comment string, now time.Time) {
+	request.Reviews = append(request.Reviews, models.EvidenceReview{
+		ID:         models.NewID(),
+		ReviewerID: reviewerID,
+		Decision:   decision,
+		Comment:    comment,
+		Round:      request.ReviewRound,
+		CreatedAt:  now,
+	})
+}
+
+// newComment returns the actor's comment on the request conversation, or nil
+// if the actor left none.
+func (s *evidenceReviewService) newComment(request *models.EvidenceRequest, authorID, content string, now time.Time) *models.Comment {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil
+	}
+	return &models.Comment{
+		ID:             models.NewID(),
+		AuthorID:       authorID,
+		Content:        content,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		OrganizationID: request.OrganizationID,
+		ResourceType:   models.ResourceTypeEvidenceRequest,
+		ResourceID:     request.ID.Hex(),
+		Status:         models.CommentStatusActive,
+	}
+}
+
+// save persists the request with updated audit fields together with the
+// actor's comment, if any, and the events describing the change. The request
+// is only saved if nobody else saved it since it was loaded, so concurrent
+// decisions cannot overwrite each other.
+func (s *evidenceReviewService) save(ctx context.Context, request *models.EvidenceRequest, actorID string, now time.Time, comment *models.Comment, payloads ...events.Payload) error {
+	request.UpdatedAt = now
+	request.UpdatedBy = actorID
+	loadedVersion := request.Version
+	request.Version++
+	return s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		updated, err := s.evidenceRepo.UpdateIfVersion(ctx, request, loadedVersion)
+		if err != nil {
+			return fmt.Errorf("failed to update evidence request: %w", err)
+		}
+		if !updated {
+			return ErrEvidenceRequestConflict
+		}
+		if comment != nil {
+			if err := s.commentRepo.Create(ctx, comment); err != nil {
+				return fmt.Errorf("failed to create comment: %w", err)
+			}
+		}
+		for _, payload := range payloads {
+			event := events.New(request.OrganizationID.Hex(), models.ResourceTypeEvidenceRequest, request.ID.Hex(), payload)
+			event.ActorID = actorID
+			if err := s.events.Publish(ctx, event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// requiredApprovals returns the number of distinct approvals needed to complete a request.
+func requiredApprovals(compliance *models.OrganizationCompliance) int {
+	if compliance.MinimumReviewers < 1 {
+		return 1
+	}
+	return compliance.MinimumReviewers
+}
+
+// objectIDsToHex converts object IDs to their hex representation for audit records.
+func objectIDsToHex(ids []primitive.ObjectID) []string {
+	hexIDs := make([]string, len(ids))
+	for i, id := range ids {
+		hexIDs[i] = id.Hex()
+	}
+	return hexIDs
+}
@@ -0,0 +1,358 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+)
+
+type reviewFixture struct {
+	org          *models.Organization
+	assignee     *models.User
+	manager      *models.User
+	reviewers    []*models.User
+	outsider     *models.User
+	request      *models.EvidenceRequest
+	auditRepo    *fakeAuditLogRepository
+	evidenceRepo *fakeEvidenceRequestRepository
+	commentRepo  *fakeCommentRepository
+	notifier     *fakeNotificationService
+	service      EvidenceReviewService
+}
+
+func newReviewFixture(t *testing.T, requireApproval bool, minimumReviewers int) *reviewFixture {
+	t.Helper()
+
+	org := &models.Organization{Status: models.OrganizationStatusActive}
+	org.ID = primitive.NewObjectID()
+	org.Settings.ComplianceSettings.RequireEvidenceApproval = requireApproval
+	org.Settings.ComplianceSettings.MinimumReviewers = minimumReviewers
+
+	newMember := func(orgID primitive.ObjectID) *models.User {
+		user := &models.User{OrganizationID: orgID, IsActive: true}
+		user.ID = primitive.NewObjectID()
+		return user
+	}
+
+	assignee := newMember(org.ID)
+	manager := newMember(org.ID)
+	manager.Roles = []string{models.RoleManager}
+	reviewers := []*models.User{newMember(org.ID), newMember(org.ID), newMember(org.ID)}
+	outsider := newMember(primitive.NewObjectID())
+
+	request := &models.EvidenceRequest{
+		OrganizationID: org.ID,
+		AssigneeID:     assignee.ID,
+		RequestID:      "REQ-100",
+		Status:         models.EvidenceRequestStatusInProgress,
+	}
+	request.ID = primitive.NewObjectID()
+
+	f := &reviewFixture{
+		org:         org,
+		assignee:    assignee,
+		manager:     manager,
+		reviewers:   reviewers,
+		outsider:    outsider,
+		request:     request,
+		auditRepo:   &fakeAuditLogRepository{},
+		commentRepo: &fakeCommentRepository{},
+		notifier:    newFakeNotificationService(),
+	}
+	f.evidenceRepo = newFakeEvidenceRequestRepository(request)
+	userRepo := newFakeUserRepository(append([]*models.User{assignee, manager, outsider}, reviewers...)...)
+	f.service = NewEvidenceReviewService(
+		newFakeOrganizationRepository(org),
+		f.evidenceRepo,
+		f.commentRepo,
+		userRepo,
+		&fakeTransactor{},
+		newFakeSubscribedPublisher(f.auditRepo, f.notifier, f.evidenceRepo, f.commentRepo, userRepo),
+		zap.NewNop(),
+	)
+	return f
+}
+
+func (f *reviewFixture) action(actor *models.User, comment string) *ReviewActionInput {
+	return &ReviewActionInput{
+		OrganizationID: f.org.ID.Hex(),
+		RequestID:      f.request.ID.Hex(),
+		ActorID:        actor.ID.Hex(),
+		Comment:        comment,
+	}
+}
+
+func (f *reviewFixture) assign(t *testing.T, reviewers ...*models.User) {
+	t.Helper()
+	ids := make([]string, len(reviewers))
+	for i, reviewer := range reviewers {
+		ids[i] = reviewer.ID.Hex()
+	}
+	_, err := f.service.AssignReviewers(context.Background(), &AssignReviewersInput{
+		OrganizationID: f.org.ID.Hex(),
+		RequestID:      f.request.ID.Hex(),
+		AssignedBy:     f.manager.ID.Hex(),
+		ReviewerIDs:    ids,
+	})
+	require.NoError(t, err)
+}
+
+func TestEvidenceReview_CompletesAfterMinimumDistinctApprovals(t *testing.T) {
+	f := newReviewFixture(t, true, 2)
+	ctx := context.Background()
+	f.assign(t, f.reviewers...)
+
+	request, err := f.service.SubmitForReview(ctx, f.action(f.assignee, "Evidence attached"))
+	require.NoError(t, err)
+	assert.Equal(t, models.EvidenceRequestStatusUnderReview, request.Status)
+	assert.Equal(t, 1, request.ReviewRound)
+
+	request, err = f.service.ApproveEvidence(ctx, f.action(f.reviewers[0], "Looks complete"))
+	require.NoError(t, err)
+	assert.Equal(t, models.EvidenceRequestStatusUnderReview, request.Status)
+
+	_, err = f.service.ApproveEvidence(ctx, f.action(f.reviewers[0], "Approving again"))
+	assert.ErrorIs(t, err, ErrAlreadyReviewed)
+
+	request, err = f.service.ApproveEvidence(ctx, f.action(f.reviewers[1], "Agreed"))
+	require.NoError(t, err)
+	assert.Equal(t, models.EvidenceRequestStatusCompleted, request.Status)
+	assert.False(t, request.CompletedAt.IsZero())
+	assert.Equal(t, 2, request.ApprovalsInRound(1))
+
+	assert.Contains(t, f.auditRepo.actions(), AuditActionEvidenceCompleted)
+	assert.Equal(t, []string{ReviewEventRequested, ReviewEventApproved}, f.notifier.reviewEvents)
+}
+
+func TestEvidenceReview_RejectionReopensAndResetsApprovals(t *testing.T) {
+	f := newReviewFixture(t, true, 2)
+	ctx := context.Background()
+	f.assign(t, f.reviewers...)
+
+	_, err := f.service.SubmitForReview(ctx, f.action(f.assignee, ""))
+	require.NoError(t, err)
+	_, err = f.service.ApproveEvidence(ctx, f.action(f.reviewers[0], "Fine"))
+	require.NoError(t, err)
+
+	request, err := f.service.RejectEvidence(ctx, f.action(f.reviewers[1], "Sample size too small"))
+	require.NoError(t, err)
+	assert.Equal(t, models.EvidenceRequestStatusInProgress, request.Status)
+
+	// A new round requires fresh approvals from two distinct reviewers
+	request, err = f.service.SubmitForReview(ctx, f.action(f.assignee, "Added samples"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, request.ReviewRound)
+
+	request, err = f.service.ApproveEvidence(ctx, f.action(f.reviewers[0], "Fine"))
+	require.NoError(t, err)
+	assert.Equal(t, models.EvidenceRequestStatusUnderReview, request.Status)
+
+	request, err = f.service.ApproveEvidence(ctx, f.action(f.reviewers[1], "Now complete"))
+	require.NoError(t, err)
+	assert.Equal(t, models.EvidenceRequestStatusCompleted, request.Status)
+}
+
+func TestEvidenceReview_RefusesDecisionsOnStaleRequests(t *testing.T) {
+	f := newReviewFixture(t, true, 1)
+	ctx := context.Background()
+	f.assign(t, f.reviewers...)
+	_, err := f.service.SubmitForReview(ctx, f.action(f.assignee, ""))
+	require.NoError(t, err)
+
+	// Another reviewer's decision is saved between loading and saving the request
+	f.evidenceRepo.changeConcurrently(f.request.ID.Hex())
+
+	_, err = f.service.ApproveEvidence(ctx, f.action(f.reviewers[0], "Looks complete"))
+	assert.ErrorIs(t, err, ErrEvidenceRequestConflict)
+	assert.NotContains(t, f.auditRepo.actions(), AuditActionEvidenceApproved)
+	assert.Empty(t, f.commentRepo.comments)
+}
+
+func TestEvidenceReview_OnlyAssignedReviewersApprovalsCount(t *testing.T) {
+	f := newReviewFixture(t, true, 2)
+	ctx := context.Background()
+	f.assign(t, f.reviewers...)
+	_, err := f.service.SubmitForReview(ctx, f.action(f.assignee, ""))
+	require.NoError(t, err)
+
+	_, err = f.service.ApproveEvidence(ctx, f.action(f.reviewers[0], "Looks complete"))
+	require.NoError(t, err)
+
+	// Unassigning a reviewer withdraws their approval
+	f.assign(t, f.reviewers[1], f.reviewers[2])
+	request, err := f.service.ApproveEvidence(ctx, f.action(f.reviewers[1], "Agreed"))
+	require.NoError(t, err)
+	assert.Equal(t, models.EvidenceRequestStatusUnderReview, request.Status)
+	assert.Equal(t, 1, request.ApprovalsInRound(1))
+
+	request, err = f.service.ApproveEvidence(ctx, f.action(f.reviewers[2], "Agreed"))
+	require.NoError(t, err)
+	assert.Equal(t, models.EvidenceRequestStatusCompleted, request.Status)
+	assert.Equal(t, time.UTC, request.CompletedAt.Location())
+}
+
+func TestEvidenceReview_CommentsJoinTheRequestThread(t *testing.T) {
+	f := newReviewFixture(t, true, 1)
+	ctx := context.Background()
+	f.assign(t, f.reviewers[0])
+
+	_, err := f.service.SubmitForReview(ctx, f.action(f.assignee, "Evidence attached"))
+	require.NoError(t, err)
+	_, err = f.service.RejectEvidence(ctx, f.action(f.reviewers[0], "Sample size too small"))
+	require.NoError(t, err)
+	_, err = f.service.SubmitForReview(ctx, f.action(f.assignee, ""))
+	require.NoError(t, err)
+
+	comments := NewCommentService(f.commentRepo, nil, nil, nil, nil, nil, nil, zap.NewNop())
+	thread, err := comments.GetThread(ctx, f.org.ID.Hex(), models.ResourceTypeEvidenceRequest, f.request.ID.Hex())
+	require.NoError(t, err)
+	require.Len(t, thread, 2)
+	assert.Equal(t, "Evidence attached", thread[0].Comment.Content)
+	assert.Equal(t, f.assignee.ID.Hex(), thread[0].Comment.AuthorID)
+	assert.Equal(t, "Sample size too small", thread[1].Comment.Content)
+	assert.Equal(t, f.reviewers[0].ID.Hex(), thread[1].Comment.AuthorID)
+}
+
+func TestEvidenceReview_Validation(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		run      func(f *reviewFixture) error
+		expected error
+	}{
+		{
+			name: "approval requires a comment",
+			run: func(f *reviewFixture) error {
+				_, err := f.service.ApproveEvidence(ctx, f.action(f.reviewers[0], "   "))
+				return err
+			},
+			expected: ErrReviewCommentRequired,
+		},
+		{
+			name: "submitter cannot approve own evidence",
+			run: func(f *reviewFixture) error {
+				_, err := f.service.ApproveEvidence(ctx, f.action(f.assignee, "I approve"))
+				return err
+			},
+			expected: ErrSelfApproval,
+		},
+		{
+			name: "unassigned user cannot approve",
+			run: func(f *reviewFixture) error {
+				_, err := f.service.ApproveEvidence(ctx, f.action(f.reviewers[2], "I approve"))
+				return err
+			},
+			expected: ErrNotAssignedReviewer,
+		},
+		{
+			name: "only assignee can submit",
+			run: func(f *reviewFixture) error {
+				f.request.Status = models.EvidenceRequestStatusInProgress
+				_, err := f.service.SubmitForReview(ctx, f.action(f.reviewers[0], ""))
+				return err
+			},
+			expected: ErrNotAssignee,
+		},
+		{
+			name: "reviewers from another organization are rejected",
+			run: func(f *reviewFixture) error {
+				_, err := f.service.AssignReviewers(ctx, &AssignReviewersInput{
+					OrganizationID: f.org.ID.Hex(),
+					RequestID:      f.request.ID.Hex(),
+					AssignedBy:     f.manager.ID.Hex(),
+					ReviewerIDs:    []string{f.outsider.ID.Hex()},
+				})
+				return err
+			},
+			expected: ErrInvalidReviewer,
+		},
+		{
+			name: "members without the manager role cannot assign reviewers",
+			run: func(f *reviewFixture) error {
+				_, err := f.service.AssignReviewers(ctx, &AssignReviewersInput{
+					OrganizationID: f.org.ID.Hex(),
+					RequestID:      f.request.ID.Hex(),
+					AssignedBy:     f.reviewers[0].ID.Hex(),
+					ReviewerIDs:    []string{f.reviewers[0].ID.Hex(), f.reviewers[2].ID.Hex()},
+				})
+				return err
+			},
+			expected: ErrReviewerAssignment,
+		},
+		{
+			name: "assignee cannot pick their reviewers",
+			run: func(f *reviewFixture) error {
+				f.assignee.Roles = []string{models.RoleManager}
+				_, err := f.service.AssignReviewers(ctx, &AssignReviewersInput{
+					OrganizationID: f.org.ID.Hex(),
+					RequestID:      f.request.ID.Hex(),
+					AssignedBy:     f.assignee.ID.Hex(),
+					ReviewerIDs:    []string{f.reviewers[2].ID.Hex()},
+				})
+				return err
+			},
+			expected: ErrReviewerAssignment,
+		},
+		{
+			name: "reviewers under review must cover the required approvals",
+			run: func(f *reviewFixture) error {
+				f.org.Settings.ComplianceSettings.MinimumReviewers = 2
+				_, err := f.service.AssignReviewers(ctx, &AssignReviewersInput{
+					OrganizationID: f.org.ID.Hex(),
+					RequestID:      f.request.ID.Hex(),
+					AssignedBy:     f.manager.ID.Hex(),
+					ReviewerIDs:    []string{f.reviewers[2].ID.Hex()},
+				})
+				return err
+			},
+			expected: ErrInsufficientReviewers,
+		},
+		{
+			name: "request from another organization is not visible",
+			run: func(f *reviewFixture) error {
+				input := f.action(f.reviewers[0], "ok")
+				input.OrganizationID = primitive.NewObjectID().Hex()
+				_, err := f.service.ApproveEvidence(ctx, input)
+				return err
+			},
+			expected: ErrEvidenceRequestNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newReviewFixture(t, true, 1)
+			f.assign(t, f.reviewers[0], f.reviewers[1])
+			_, err := f.service.SubmitForReview(ctx, f.action(f.assignee, ""))
+			require.NoError(t, err)
+
+			assert.ErrorIs(t, tt.run(f), tt.expected)
+		})
+	}
+}
+
+func TestEvidenceReview_SubmitRequiresEnoughReviewers(t *testing.T) {
+	f := newReviewFixture(t, true, 2)
+	f.assign(t, f.reviewers[0])
+
+	_, err := f.service.SubmitForReview(context.Background(), f.action(f.assignee, ""))
+	assert.ErrorIs(t, err, ErrInsufficientReviewers)
+	assert.Equal(t, models.EvidenceRequestStatusInProgress, f.request.Status)
+}
+
+func TestEvidenceReview_CompletesOnSubmitWhenApprovalNotRequired(t *testing.T) {
+	f := newReviewFixture(t, false, 0)
+
+	request, err := f.service.SubmitForReview(context.Background(), f.action(f.assignee, "Done"))
+	require.NoError(t, err)
+	assert.Equal(t, models.EvidenceRequestStatusCompleted, request.Status)
+	assert.Empty(t, f.notifier.reviewEvents)
+}
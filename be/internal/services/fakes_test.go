@@ -0,0 +1,1188 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/events"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+)
+
+// In-memory fakes shared by several service tests; a fake used by a single
+// test lives in that test's file. Each fake embeds the repository
+// interface so that only the methods exercised by the tests need implementing;
+// calling anything else panics and points at a missing fake method.
+
+type fakeOrganizationRepository struct {
+	repositories.OrganizationRepository
+	mu   sync.Mutex
+	orgs map[string]*models.Organization
+}
+
+func newFakeOrganizationRepository(orgs ...*models.Organization) *fakeOrganizationRepository {
+	repo := &fakeOrganizationRepository{orgs: make(map[string]*models.Organization)}
+	for _, org := range orgs {
+		repo.orgs[org.ID.Hex()] = org
+	}
+	return repo
+}
+
+func (r *fakeOrganizationRepository) GetByID(ctx context.Context, id string) (*models.Organization, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	org, ok := r.orgs[id]
+	if !ok {
+		return nil, repositories.ErrNotFound
+	}
+	return org, nil
+}
+
+func (r *fakeOrganizationRepository) GetActiveOrganizations(ctx context.Context, limit, offset int) ([]*models.Organization, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var active []*models.Organization
+	for _, org := range r.orgs {
+		if org.Status == models.OrganizationStatusActive {
+			active = append(active, org)
+		}
+	}
+	if offset >= len(active) {
+		return nil, nil
+	}
+	end := offset + limit
+	if end > len(active) {
+		end = len(active)
+	}
+	return active[offset:end], nil
+}
+
+func (r *fakeOrganizationRepository) UpdateFeatureFlag(ctx context.Context, orgID, flag string, enabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	org, ok := r.orgs[orgID]
+	if !ok {
+		return repositories.ErrNotFound
+	}
+	if org.FeatureFlags == nil {
+		org.FeatureFlags = make(map[string]bool)
+	}
+	org.FeatureFlags[flag] = enabled
+	return nil
+}
+
+func (r *fakeOrganizationRepository) Update(ctx context.Context, org *models.Organization) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.orgs[org.ID.Hex()]; !ok {
+		return repositories.ErrNotFound
+	}
+	r.orgs[org.ID.Hex()] = org
+	return nil
+}
+
+func (r *fakeOrganizationRepository) GetBySlug(ctx context.Context, slug string) (*models.Organization, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, org := range r.orgs {
+		if org.Slug == slug {
+			return org, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+// AdjustMemberCount mirrors the repository's atomic update: the count never
+// drops below zero and an increase beyond a non-zero MaxMembers is refused.
+func (r *fakeOrganizationRepository) AdjustMemberCount(ctx context.Context, orgID string, delta int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	org, ok := r.orgs[orgID]
+	if !ok {
+		return false, repositories.ErrNotFound
+	}
+	if delta > 0 && org.MaxMembers > 0 && org.MemberCount+delta > org.MaxMembers {
+		return false, nil
+	}
+	org.MemberCount = max(org.MemberCount+delta, 0)
+	return true, nil
+}
+
+func (r *fakeOrganizationRepository) SetMemberCount(ctx context.Context, orgID string, count int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	org, ok := r.orgs[orgID]
+	if !ok {
+		return repositories.ErrNotFound
+	}
+	org.MemberCount = count
+	return nil
+}
+
+type fakeUserRepository struct {
+	repositories.UserRepository
+	mu    sync.Mutex
+	users map[string]*models.User
+}
+
+func newFakeUserRepository(users ...*models.User) *fakeUserRepository {
+	repo := &fakeUserRepository{users: make(map[string]*models.User)}
+	for _, user := range users {
+		repo.users[user.ID.Hex()] = user
+	}
+	return repo
+}
+
+func (r *fakeUserRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return nil, repositories.ErrNotFound
+	}
+	return user, nil
+}
+
+func (r *fakeUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) {
+			return user, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r *fakeUserRepository) GetByRole(ctx context.Context, orgID, role string) ([]*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var users []*models.User
+	for _, user := range r.users {
+		if user.OrganizationID.Hex() == orgID && user.HasRole(role) {
+			users = append(users, user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Email < users[j].Email })
+	return users, nil
+}
+
+func (r *fakeUserRepository) UpdatePreferences(ctx context.Context, userID string, preferences map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[userID]
+	if !ok {
+		return repositories.ErrNotFound
+	}
+	if overrides, ok := preferences["notifications"].(models.NotificationOverrides); ok {
+		user.Metadata.Preferences.Notifications = overrides
+	}
+	return nil
+}
+
+func (r *fakeUserRepository) GetByIDs(ctx context.Context, ids []string) ([]*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var users []*models.User
+	for _, id := range ids {
+		if user, ok := r.users[id]; ok {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (r *fakeUserRepository) Create(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	r.users[user.ID.Hex()] = user
+	return nil
+}
+
+func (r *fakeUserRepository) Update(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[user.ID.Hex()]; !ok {
+		return repositories.ErrNotFound
+	}
+	r.users[user.ID.Hex()] = user
+	return nil
+}
+
+func (r *fakeUserRepository) UpdateLastLogin(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user, ok := r.users[userID]; ok {
+		user.Authentication.LastLoginAt = time.Now()
+	}
+	return nil
+}
+
+func (r *fakeUserRepository) GetByOrganization(ctx context.Context, orgID string, limit, offset int) ([]*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var users []*models.User
+	for _, user := range r.users {
+		if user.OrganizationID.Hex() == orgID {
+			users = append(users, user)
+		}
+	}
+	slices.SortFunc(users, func(a, b *models.User) int { return strings.Compare(a.ID.Hex(), b.ID.Hex()) })
+	if offset >= len(users) {
+		return nil, nil
+	}
+	return users[offset:min(offset+limit, len(users))], nil
+}
+
+// Delete removes the user, as soft-deleted users are not returned by lookups.
+func (r *fakeUserRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[id]; !ok {
+		return repositories.ErrNotFound
+	}
+	delete(r.users, id)
+	return nil
+}
+
+func (r *fakeUserRepository) IncrementFailedLogins(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user, ok := r.users[userID]; ok {
+		user.Authentication.FailedLoginAttempts++
+	}
+	return nil
+}
+
+func (r *fakeUserRepository) LockUser(ctx context.Context, userID string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user, ok := r.users[userID]; ok {
+		user.Authentication.LockoutUntil = until
+	}
+	return nil
+}
+
+func (r *fakeUserRepository) ResetFailedLogins(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user, ok := r.users[userID]; ok {
+		user.Authentication.FailedLoginAttempts = 0
+	}
+	return nil
+}
+
+func (r *fakeUserRepository) UpdatePassword(ctx context.Context, userID, passwordHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[userID]
+	if !ok {
+		return repositories.ErrNotFound
+	}
+	user.Authentication.PasswordHash = passwordHash
+	return nil
+}
+
+func (r *fakeUserRepository) UpdateSessionCount(ctx context.Context, userID string, count int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user, ok := r.users[userID]; ok {
+		user.Authentication.CurrentSessionCount = count
+	}
+	return nil
+}
+
+// fakeEvidenceRequestRepository hands out the stored requests themselves, so
+// it keeps the stored version of each request apart to check versioned updates.
+type fakeEvidenceRequestRepository struct {
+	repositories.EvidenceRequestRepository
+	mu       sync.Mutex
+	requests map[string]*models.EvidenceRequest
+	versions map[string]int
+}
+
+func newFakeEvidenceRequestRepository(requests ...*models.EvidenceRequest) *fakeEvidenceRequestRepository {
+	repo := &fakeEvidenceRequestRepository{
+		requests: make(map[string]*models.EvidenceRequest),
+		versions: make(map[string]int),
+	}
+	for _, request := range requests {
+		repo.requests[request.ID.Hex()] = request
+		repo.versions[request.ID.Hex()] = request.Version
+	}
+	return repo
+}
+
+func (r *fakeEvidenceRequestRepository) UpdateIfVersion(ctx context.Context, request *models.EvidenceRequest, version int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := request.ID.Hex()
+	if _, ok := r.requests[id]; !ok || r.versions[id] != version {
+		return false, nil
+	}
+	r.requests[id] = request
+	r.versions[id] = request.Version
+	return true, nil
+}
+
+// changeConcurrently bumps the stored version of a request as if another
+// writer had saved it.
+func (r *fakeEvidenceRequestRepository) changeConcurrently(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.versions[id]++
+}
+
+func (r *fakeEvidenceRequestRepository) GetByID(ctx context.Context, id string) (*models.EvidenceRequest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	request, ok := r.requests[id]
+	if !ok {
+		return nil, repositories.ErrNotFound
+	}
+	return request, nil
+}
+
+func (r *fakeEvidenceRequestRepository) GetOpenDueBefore(ctx context.Context, orgID string, before time.Time) ([]*models.EvidenceRequest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []*models.EvidenceRequest
+	for _, request := range r.requests {
+		if request.OrganizationID.Hex() == orgID && request.IsOpen() && request.DueDate.Before(before) {
+			result = append(result, request)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeEvidenceRequestRepository) GetClosedWithEvidence(ctx context.Context, orgID string, before time.Time, afterID string, limit int) ([]*models.EvidenceRequest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []*models.EvidenceRequest
+	for _, request := range r.requests {
+		if request.OrganizationID.Hex() != orgID || request.IsOpen() || !request.UpdatedAt.Before(before) || request.ID.Hex() <= afterID {
+			continue
+		}
+		for _, evidence := range request.Evidence {
+			if evidence.PurgedAt.IsZero() {
+				result = append(result, request)
+				break
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID.Hex() < result[j].ID.Hex() })
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (r *fakeEvidenceRequestRepository) MarkEvidencePurged(ctx context.Context, requestID string, evidenceIDs []string, purgedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	request, ok := r.requests[requestID]
+	if !ok {
+		return repositories.ErrNotFound
+	}
+	for i := range request.Evidence {
+		for _, id := range evidenceIDs {
+			if request.Evidence[i].ID == id {
+				request.Evidence[i].PurgedAt = purgedAt
+			}
+		}
+	}
+	return nil
+}
+
+func (r *fakeEvidenceRequestRepository) GetByOrganization(ctx context.Context, orgID string, filter *repositories.EvidenceRequestFilter) ([]*models.EvidenceRequest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var requests []*models.EvidenceRequest
+	for _, request := range r.requests {
+		if request.OrganizationID.Hex() != orgID {
+			continue
+		}
+		if filter.Status != "" && request.Status != filter.Status {
+			continue
+		}
+		if filter.ControlID != "" && request.ControlID.Hex() != filter.ControlID {
+			continue
+		}
+		requests = append(requests, request)
+	}
+	sort.Slice(requests, func(i, j int) bool { return requests[i].DueDate.Before(requests[j].DueDate) })
+	return paginate(requests, filter.Limit, filter.Offset), nil
+}
+
+func (r *fakeEvidenceRequestRepository) CountByOrganization(ctx context.Context, orgID string, filter *repositories.EvidenceRequestFilter) (int64, error) {
+	all := *filter
+	all.Limit, all.Offset = 0, 0
+	requests, _ := r.GetByOrganization(ctx, orgID, &all)
+	return int64(len(requests)), nil
+}
+
+type fakeAuditLogRepository struct {
+	repositories.AuditLogRepository
+	mu        sync.Mutex
+	entries   []*models.AuditLog
+	createErr error
+}
+
+func (r *fakeAuditLogRepository) Create(ctx context.Context, entry *models.AuditLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.createErr != nil {
+		return r.createErr
+	}
+	// Mirrors the unique (organization_id, sequence) index
+	for _, existing := range r.entries {
+		if entry.Sequence > 0 && existing.Sequence == entry.Sequence && existing.OrganizationID == entry.OrganizationID {
+			return repositories.ErrDuplicate
+		}
+	}
+	stored := *entry
+	r.entries = append(r.entries, &stored)
+	return nil
+}
+
+func (r *fakeAuditLogRepository) actions() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	actions := make([]string, 0, len(r.entries))
+	for _, entry := range r.entries {
+		actions = append(actions, entry.Action)
+	}
+	return actions
+}
+
+func (r *fakeAuditLogRepository) GetByResource(ctx context.Context, resourceType, resourceID string) ([]*models.AuditLog, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []*models.AuditLog
+	for _, entry := range r.entries {
+		if entry.ResourceType == resourceType && entry.ResourceID == resourceID {
+			result = append(result, entry)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeAuditLogRepository) chain(orgID string) []*models.AuditLog {
+	var chain []*models.AuditLog
+	for _, entry := range r.entries {
+		if entry.Sequence > 0 && entry.OrganizationID.Hex() == orgID {
+			chain = append(chain, entry)
+		}
+	}
+	sort.Slice(chain, func(i, j int) bool { return chain[i].Sequence < chain[j].Sequence })
+	return chain
+}
+
+func (r *fakeAuditLogRepository) GetChainHead(ctx context.Context, orgID string) (*models.AuditLog, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	chain := r.chain(orgID)
+	if len(chain) == 0 {
+		return nil, repositories.ErrNotFound
+	}
+	head := *chain[len(chain)-1]
+	return &head, nil
+}
+
+func (r *fakeAuditLogRepository) IterateChain(ctx context.Context, orgID string, fromSequence int64, fn func(*models.AuditLog) error) error {
+	r.mu.Lock()
+	chain := r.chain(orgID)
+	r.mu.Unlock()
+	for _, entry := range chain {
+		if entry.Sequence < fromSequence {
+			continue
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *fakeAuditLogRepository) Stream(ctx context.Context, orgID string, filter *repositories.AuditFilter, fn func(*models.AuditLog) error) error {
+	r.mu.Lock()
+	var matches []*models.AuditLog
+	for _, entry := range r.entries {
+		if entry.OrganizationID.Hex() != orgID ||
+			(filter.Action != "" && entry.Action != filter.Action) ||
+			(filter.ResourceType != "" && entry.ResourceType != filter.ResourceType) ||
+			(filter.TimeRange != nil && (entry.Timestamp.Before(filter.TimeRange.Start) || entry.Timestamp.After(filter.TimeRange.End))) {
+			continue
+		}
+		matches = append(matches, entry)
+	}
+	r.mu.Unlock()
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Timestamp.Before(matches[j].Timestamp) })
+	for _, entry := range matches {
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *fakeAuditLogRepository) Purge(ctx context.Context, orgID string, throughSequence int64) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.entries[:0]
+	var purged int64
+	for _, entry := range r.entries {
+		if entry.OrganizationID.Hex() == orgID && entry.Sequence > 0 && entry.Sequence <= throughSequence {
+			purged++
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	r.entries = kept
+	return purged, nil
+}
+
+type fakeNotificationService struct {
+	NotificationService
+	mu             sync.Mutex
+	reminders      []string
+	escalations    map[string]string
+	reviewEvents   []string
+	mentions       []string
+	cycleEvents    []string
+	alerts         []string
+	invitations    []string
+	passwordResets []string
+}
+
+func newFakeNotificationService() *fakeNotificationService {
+	return &fakeNotificationService{escalations: make(map[string]string)}
+}
+
+func (n *fakeNotificationService) SendReminderNotification(ctx context.Context, request *models.EvidenceRequest) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.reminders = append(n.reminders, request.RequestID)
+	return nil
+}
+
+func (n *fakeNotificationService) SendEscalationNotification(ctx context.Context, request *models.EvidenceRequest, manager *models.User) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.escalations[request.RequestID] = manager.Email
+	return nil
+}
+
+func (n *fakeNotificationService) SendReviewNotification(ctx context.Context, request *models.EvidenceRequest, eventType string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.reviewEvents = append(n.reviewEvents, eventType)
+	return nil
+}
+
+func (n *fakeNotificationService) SendMentionNotification(ctx context.Context, comment *models.Comment, mentioned *models.User) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.mentions = append(n.mentions, mentioned.Email)
+	return nil
+}
+
+func (n *fakeNotificationService) SendTestingCycleNotification(ctx context.Context, cycle *models.TestingCycle, eventType string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.cycleEvents = append(n.cycleEvents, eventType)
+	return nil
+}
+
+func (n *fakeNotificationService) SendSystemAlert(ctx context.Context, alert *SystemAlert) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.alerts = append(n.alerts, alert.Title)
+	return nil
+}
+
+// SendInvitation records the token of each invitation link sent.
+func (n *fakeNotificationService) SendInvitation(ctx context.Context, invitation *models.Invitation, token string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.invitations = append(n.invitations, token)
+	return nil
+}
+
+// SendPasswordReset records the token of each password reset link sent.
+func (n *fakeNotificationService) SendPasswordReset(ctx context.Context, user *models.User, token string, expiresAt time.Time) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.passwordResets = append(n.passwordResets, token)
+	return nil
+}
+
+type fakeCommentRepository struct {
+	repositories.CommentRepository
+	mu       sync.Mutex
+	comments []*models.Comment
+}
+
+func (r *fakeCommentRepository) Create(ctx context.Context, comment *models.Comment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.comments = append(r.comments, comment)
+	return nil
+}
+
+func (r *fakeCommentRepository) GetByID(ctx context.Context, id string) (*models.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, comment := range r.comments {
+		if comment.ID == id {
+			return comment, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r *fakeCommentRepository) Update(ctx context.Context, comment *models.Comment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.comments {
+		if existing.ID == comment.ID {
+			r.comments[i] = comment
+			return nil
+		}
+	}
+	return repositories.ErrNotFound
+}
+
+func (r *fakeCommentRepository) GetByResource(ctx context.Context, orgID, resourceType, resourceID string) ([]*models.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []*models.Comment
+	for _, comment := range r.comments {
+		if comment.OrganizationID.Hex() == orgID && comment.ResourceType == resourceType && comment.ResourceID == resourceID {
+			result = append(result, comment)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeCommentRepository) GetDeletedBefore(ctx context.Context, orgID string, before time.Time, afterID string, limit int) ([]*models.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []*models.Comment
+	for _, comment := range r.comments {
+		if comment.OrganizationID.Hex() == orgID && comment.IsDeleted() && comment.DeletedAt.Before(before) && comment.ID > afterID {
+			result = append(result, comment)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (r *fakeCommentRepository) DeleteMany(ctx context.Context, ids []string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	remove := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		remove[id] = true
+	}
+	kept := r.comments[:0]
+	for _, comment := range r.comments {
+		if !remove[comment.ID] {
+			kept = append(kept, comment)
+		}
+	}
+	deleted := int64(len(r.comments) - len(kept))
+	r.comments = kept
+	return deleted, nil
+}
+
+type fakeAuditCheckpointRepository struct {
+	repositories.AuditCheckpointRepository
+	checkpoints []*models.AuditCheckpoint
+}
+
+func (r *fakeAuditCheckpointRepository) Create(ctx context.Context, checkpoint *models.AuditCheckpoint) error {
+	r.checkpoints = append(r.checkpoints, checkpoint)
+	return nil
+}
+
+func (r *fakeAuditCheckpointRepository) GetLatest(ctx context.Context, orgID string) (*models.AuditCheckpoint, error) {
+	checkpoints, _ := r.GetByOrganization(ctx, orgID)
+	if len(checkpoints) == 0 {
+		return nil, repositories.ErrNotFound
+	}
+	return checkpoints[len(checkpoints)-1], nil
+}
+
+func (r *fakeAuditCheckpointRepository) GetByOrganization(ctx context.Context, orgID string) ([]*models.AuditCheckpoint, error) {
+	var result []*models.AuditCheckpoint
+	for _, checkpoint := range r.checkpoints {
+		if checkpoint.OrganizationID.Hex() == orgID {
+			result = append(result, checkpoint)
+		}
+	}
+	return result, nil
+}
+
+type fakeSessionRepository struct {
+	repositories.SessionRepository
+	sessions []*models.Session
+}
+
+func (r *fakeSessionRepository) ended(orgID string, before time.Time) []*models.Session {
+	var result []*models.Session
+	for _, session := range r.sessions {
+		if session.OrganizationID.Hex() == orgID && session.ExpiresAt.Before(before) {
+			result = append(result, session)
+		}
+	}
+	return result
+}
+
+func (r *fakeSessionRepository) CountEndedBefore(ctx context.Context, orgID string, before time.Time) (int64, error) {
+	return int64(len(r.ended(orgID, before))), nil
+}
+
+func (r *fakeSessionRepository) DeleteEndedBefore(ctx context.Context, orgID string, before time.Time, limit int) (int64, error) {
+	ended := r.ended(orgID, before)
+	if len(ended) > limit {
+		ended = ended[:limit]
+	}
+	kept := r.sessions[:0]
+	for _, session := range r.sessions {
+		remove := false
+		for _, e := range ended {
+			remove = remove || e == session
+		}
+		if !remove {
+			kept = append(kept, session)
+		}
+	}
+	r.sessions = kept
+	return int64(len(ended)), nil
+}
+
+func (r *fakeSessionRepository) Create(ctx context.Context, session *models.Session) error {
+	r.sessions = append(r.sessions, session)
+	return nil
+}
+
+func (r *fakeSessionRepository) GetBySessionID(ctx context.Context, sessionID string) (*models.Session, error) {
+	for _, session := range r.sessions {
+		if session.SessionID == sessionID {
+			return session, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r *fakeSessionRepository) GetActiveByUser(ctx context.Context, userID string, now time.Time) ([]*models.Session, error) {
+	var active []*models.Session
+	for _, session := range r.sessions {
+		if session.UserID.Hex() == userID && session.IsActive && now.Before(session.ExpiresAt) {
+			active = append(active, session)
+		}
+	}
+	sort.SliceStable(active, func(i, j int) bool { return active[i].LastActivity.After(active[j].LastActivity) })
+	return active, nil
+}
+
+func (r *fakeSessionRepository) RecordActivity(ctx context.Context, sessionID string, now, idleSince time.Time) error {
+	session, err := r.GetBySessionID(ctx, sessionID)
+	if err != nil || !session.IsActive || !now.Before(session.ExpiresAt) || session.LastActivity.Before(idleSince) {
+		return repositories.ErrNotFound
+	}
+	session.LastActivity = now
+	return nil
+}
+
+func (r *fakeSessionRepository) Deactivate(ctx context.Context, sessionID, reason string, endedAt time.Time) error {
+	session, err := r.GetBySessionID(ctx, sessionID)
+	if err != nil || !session.IsActive {
+		return repositories.ErrNotFound
+	}
+	session.IsActive, session.EndReason, session.EndedAt = false, reason, endedAt
+	return nil
+}
+
+func (r *fakeSessionRepository) DeactivateByUser(ctx context.Context, userID, reason string, endedAt time.Time) (int64, error) {
+	var ended int64
+	for _, session := range r.sessions {
+		if session.UserID.Hex() == userID && session.IsActive {
+			session.IsActive, session.EndReason, session.EndedAt = false, reason, endedAt
+			ended++
+		}
+	}
+	return ended, nil
+}
+
+type fakeInAppNotificationRepository struct {
+	repositories.InAppNotificationRepository
+	mu            sync.Mutex
+	notifications []*models.InAppNotification
+}
+
+func (r *fakeInAppNotificationRepository) Create(ctx context.Context, notification *models.InAppNotification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notifications = append(r.notifications, notification)
+	return nil
+}
+
+// matching returns a user's notifications, newest first.
+func (r *fakeInAppNotificationRepository) matching(userID string, unreadOnly bool) []*models.InAppNotification {
+	var result []*models.InAppNotification
+	for i := len(r.notifications) - 1; i >= 0; i-- {
+		notification := r.notifications[i]
+		if notification.UserID.Hex() == userID && (!unreadOnly || !notification.IsRead()) {
+			result = append(result, notification)
+		}
+	}
+	return result
+}
+
+func (r *fakeInAppNotificationRepository) GetByUser(ctx context.Context, userID string, unreadOnly bool, limit, offset int) ([]*models.InAppNotification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := r.matching(userID, unreadOnly)
+	if offset >= len(result) {
+		return nil, nil
+	}
+	result = result[offset:]
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (r *fakeInAppNotificationRepository) CountByUser(ctx context.Context, userID string, unreadOnly bool) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return int64(len(r.matching(userID, unreadOnly))), nil
+}
+
+func (r *fakeInAppNotificationRepository) MarkRead(ctx context.Context, userID string, ids []string, readAt time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	selected := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		selected[id] = true
+	}
+	var marked int64
+	for _, notification := range r.matching(userID, true) {
+		if len(ids) == 0 || selected[notification.ID.Hex()] {
+			notification.ReadAt = readAt
+			marked++
+		}
+	}
+	return marked, nil
+}
+
+// fakePubSub is an in-process cache.PubSub; every subscriber receives every message.
+type fakePubSub struct {
+	mu          sync.Mutex
+	subscribers map[chan []byte]bool
+	published   int
+}
+
+func (p *fakePubSub) Publish(ctx context.Context, channel string, payload []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.published++
+	for subscriber := range p.subscribers {
+		subscriber <- payload
+	}
+	return nil
+}
+
+func (p *fakePubSub) Subscribe(ctx context.Context, channel string) (<-chan []byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.subscribers == nil {
+		p.subscribers = make(map[chan []byte]bool)
+	}
+	messages := make(chan []byte, 16)
+	p.subscribers[messages] = true
+	go func() {
+		<-ctx.Done()
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		delete(p.subscribers, messages)
+		close(messages)
+	}()
+	return messages, nil
+}
+
+// fakeCacheRepository records deleted keys.
+type fakeCacheRepository struct {
+	repositories.CacheRepository
+	mu      sync.Mutex
+	deleted []string
+}
+
+func (c *fakeCacheRepository) Delete(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deleted = append(c.deleted, keys...)
+	return nil
+}
+
+func (c *fakeCacheRepository) keys() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.deleted...)
+}
+
+// fakeTransactor runs functions directly and counts aborted transactions.
+type fakeTransactor struct {
+	mu      sync.Mutex
+	aborted int
+}
+
+func (t *fakeTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	err := fn(ctx)
+	if err != nil {
+		t.mu.Lock()
+		t.aborted++
+		t.mu.Unlock()
+	}
+	return err
+}
+
+// fakeSubscribedPublisher records published domain events and hands each to
+// the audit and notification subscribers right away, as if the relay had run.
+// Subscriber errors are ignored, as they are by the bus.
+type fakeSubscribedPublisher struct {
+	fakeEventPublisher
+	subscribers *eventSubscribers
+}
+
+func newFakeSubscribedPublisher(auditRepo *fakeAuditLogRepository, notifier *fakeNotificationService, evidenceRepo *fakeEvidenceRequestRepository, commentRepo *fakeCommentRepository, userRepo *fakeUserRepository) *fakeSubscribedPublisher {
+	return &fakeSubscribedPublisher{subscribers: &eventSubscribers{
+		auditSvc:        NewAuditService(auditRepo, nil, zap.NewNop()),
+		notificationSvc: notifier,
+		evidenceRepo:    evidenceRepo,
+		commentRepo:     commentRepo,
+		userRepo:        userRepo,
+		logger:          zap.NewNop(),
+	}}
+}
+
+func (p *fakeSubscribedPublisher) Publish(ctx context.Context, event *events.Event) error {
+	_ = p.fakeEventPublisher.Publish(ctx, event)
+	_ = p.subscribers.audit(ctx, event)
+	_ = p.subscribers.notify(ctx, event)
+	return nil
+}
+
+// paginate returns a page of items; a zero limit returns all items from offset.
+func paginate[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return nil
+	}
+	items = items[offset:]
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
+
+type fakeSSOLoginStateRepository struct {
+	mu     sync.Mutex
+	states map[string]*models.SSOLoginState
+}
+
+func (r *fakeSSOLoginStateRepository) Create(ctx context.Context, state *models.SSOLoginState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.states == nil {
+		r.states = make(map[string]*models.SSOLoginState)
+	}
+	if _, ok := r.states[state.State]; ok {
+		return repositories.ErrDuplicate
+	}
+	r.states[state.State] = state
+	return nil
+}
+
+func (r *fakeSSOLoginStateRepository) Consume(ctx context.Context, state string, now time.Time) (*models.SSOLoginState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	found, ok := r.states[state]
+	delete(r.states, state)
+	if !ok || !now.Before(found.ExpiresAt) {
+		return nil, repositories.ErrNotFound
+	}
+	return found, nil
+}
+
+// fakeAuthenticationService records the users whose sessions were terminated.
+type fakeAuthenticationService struct {
+	AuthenticationService
+	mu         sync.Mutex
+	terminated []string
+}
+
+func (a *fakeAuthenticationService) TerminateAllSessions(ctx context.Context, userID string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.terminated = append(a.terminated, userID)
+	return nil
+}
+
+type fakeInvitationRepository struct {
+	repositories.InvitationRepository
+	mu          sync.Mutex
+	invitations map[string]*models.Invitation
+}
+
+func newFakeInvitationRepository() *fakeInvitationRepository {
+	return &fakeInvitationRepository{invitations: make(map[string]*models.Invitation)}
+}
+
+func (r *fakeInvitationRepository) Create(ctx context.Context, invitation *models.Invitation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.invitations {
+		if existing.OrganizationID == invitation.OrganizationID && existing.Email == invitation.Email &&
+			existing.Status == models.InvitationStatusPending {
+			return repositories.ErrDuplicate
+		}
+	}
+	invitation.ID = primitive.NewObjectID()
+	r.invitations[invitation.ID.Hex()] = invitation
+	return nil
+}
+
+func (r *fakeInvitationRepository) GetByID(ctx context.Context, id string) (*models.Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	invitation, ok := r.invitations[id]
+	if !ok {
+		return nil, repositories.ErrNotFound
+	}
+	return invitation, nil
+}
+
+func (r *fakeInvitationRepository) Update(ctx context.Context, invitation *models.Invitation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.invitations[invitation.ID.Hex()]; !ok {
+		return repositories.ErrNotFound
+	}
+	r.invitations[invitation.ID.Hex()] = invitation
+	return nil
+}
+
+func (r *fakeInvitationRepository) GetPendingByEmail(ctx context.Context, orgID, email string) (*models.Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, invitation := range r.invitations {
+		if invitation.OrganizationID.Hex() == orgID && invitation.Email == email &&
+			invitation.Status == models.InvitationStatusPending {
+			return invitation, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r *fakeInvitationRepository) GetByOrganization(ctx context.Context, orgID string, includeClosed bool) ([]*models.Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var invitations []*models.Invitation
+	for _, invitation := range r.invitations {
+		if invitation.OrganizationID.Hex() == orgID && (includeClosed || invitation.Status == models.InvitationStatusPending) {
+			invitations = append(invitations, invitation)
+		}
+	}
+	sort.Slice(invitations, func(i, j int) bool { return invitations[i].CreatedAt.After(invitations[j].CreatedAt) })
+	return invitations, nil
+}
+
+type fakePasswordResetRepository struct {
+	repositories.PasswordResetRepository
+	mu     sync.Mutex
+	resets []*models.PasswordReset
+}
+
+func (r *fakePasswordResetRepository) Create(ctx context.Context, reset *models.PasswordReset) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reset.ID = primitive.NewObjectID()
+	r.resets = append(r.resets, reset)
+	return nil
+}
+
+func (r *fakePasswordResetRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.PasswordReset, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, reset := range r.resets {
+		if reset.TokenHash == tokenHash {
+			loaded := *reset
+			return &loaded, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r *fakePasswordResetRepository) TakeAnswerAttempt(ctx context.Context, id string, maxAttempts int, now time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, reset := range r.resets {
+		if reset.ID.Hex() == id && reset.IsPending(now) && reset.FailedAnswers < maxAttempts {
+			reset.FailedAnswers++
+			return reset.FailedAnswers, nil
+		}
+	}
+	return 0, repositories.ErrNotFound
+}
+
+func (r *fakePasswordResetRepository) VerifyAnswer(ctx context.Context, id string, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, reset := range r.resets {
+		if reset.ID.Hex() == id && reset.IsPending(now) {
+			reset.AnswerVerifiedAt = now
+			reset.FailedAnswers--
+			return nil
+		}
+	}
+	return repositories.ErrNotFound
+}
+
+func (r *fakePasswordResetRepository) Consume(ctx context.Context, id string, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, reset := range r.resets {
+		if reset.ID.Hex() == id && reset.IsPending(now) {
+			reset.UsedAt = now
+			return nil
+		}
+	}
+	return repositories.ErrNotFound
+}
+
+func (r *fakePasswordResetRepository) ConsumeByUser(ctx context.Context, userID string, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, reset := range r.resets {
+		if reset.UserID.Hex() == userID && reset.IsPending(now) {
+			reset.UsedAt = now
+		}
+	}
+	return nil
+}
@@ -4,9 +4,11 @@ package services
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/scim"
 )
 
 // ControlService handles all business logic related to compliance controls.
@@ -90,6 +92,86 @@ type EvidenceService interface {
 	GetPendingRequests(ctx context.Context, userID string) ([]*models.EvidenceRequest, error)
 }
 
+// EvidenceLifecycleService drives time-based transitions of evidence requests.
+// It sends deadline reminders, marks past-due requests overdue and escalates
+// long-overdue requests to the assignee's manager.
+type EvidenceLifecycleService interface {
+	// RunLifecycle processes all active organizations at the given point in time
+	RunLifecycle(ctx context.Context, now time.Time) (*LifecycleRunResult, error)
+	
+	// ProcessOrganization processes evidence requests of a single organization
+	ProcessOrganization(ctx context.Context, orgID string, now time.Time) (*LifecycleRunResult, error)
+}
+
+// EvidenceReviewService handles the review and approval workflow for submitted evidence.
+// It enforces the organization's approval requirements, including the minimum number
+// of distinct approvers and the separation between submitter and reviewer.
+type EvidenceReviewService interface {
+	// AssignReviewers replaces the set of reviewers for an evidence request
+	AssignReviewers(ctx context.Context, input *AssignReviewersInput) (*models.EvidenceRequest, error)
+	
+	// SubmitForReview submits collected evidence, starting a new review round
+	SubmitForReview(ctx context.Context, input *ReviewActionInput) (*models.EvidenceRequest, error)
+	
+	// ApproveEvidence records an approval and completes the request once enough reviewers approved
+	ApproveEvidence(ctx context.Context, input *ReviewActionInput) (*models.EvidenceRequest, error)
+	
+	// RejectEvidence records a rejection and reopens the request to the assignee
+	RejectEvidence(ctx context.Context, input *ReviewActionInput) (*models.EvidenceRequest, error)
+}
+
+// CommentService handles threaded discussions on evidence requests, controls,
+// findings and testing cycles. Comments support editing with history, soft
+// deletion and @mentions that notify the mentioned users.
+type CommentService interface {
+	// AddComment creates a top-level comment or a reply to an existing comment
+	AddComment(ctx context.Context, input *CreateCommentInput) (*models.Comment, error)
+	
+	// EditComment changes the content of a comment, preserving the previous revision
+	EditComment(ctx context.Context, input *EditCommentInput) (*models.Comment, error)
+	
+	// DeleteComment soft deletes a comment
+	DeleteComment(ctx context.Context, orgID, commentID, actorID string) error
+	
+	// GetThread returns the comments on a resource arranged as threads
+	GetThread(ctx context.Context, orgID, resourceType, resourceID string) ([]*CommentThread, error)
+}
+
+// QueryService provides read-only, organization-scoped access to controls,
+// testing cycles and evidence requests for the GraphQL API. The batch methods
+// let resolvers load the records referenced by a page of results at once.
+type QueryService interface {
+	// GetOrganization retrieves an organization
+	GetOrganization(ctx context.Context, orgID string) (*models.Organization, error)
+	
+	// ListControls retrieves a page of an organization's controls
+	ListControls(ctx context.Context, filter *ControlFilter) (*ControlConnection, error)
+	
+	// GetControl retrieves a control of an organization
+	GetControl(ctx context.Context, orgID, id string) (*models.Control, error)
+	
+	// GetControlsByIDs retrieves several controls of an organization in one query
+	GetControlsByIDs(ctx context.Context, orgID string, ids []string) ([]*models.Control, error)
+	
+	// ListTestingCycles retrieves a page of an organization's testing cycles
+	ListTestingCycles(ctx context.Context, filter *TestingCycleFilter) (*TestingCycleConnection, error)
+	
+	// GetTestingCycle retrieves a testing cycle of an organization
+	GetTestingCycle(ctx context.Context, orgID, id string) (*models.TestingCycle, error)
+	
+	// GetTestingCyclesByIDs retrieves several testing cycles of an organization in one query
+	GetTestingCyclesByIDs(ctx context.Context, orgID string, ids []string) ([]*models.TestingCycle, error)
+	
+	// ListEvidenceRequests retrieves a page of an organization's evidence requests
+	ListEvidenceRequests(ctx context.Context, filter *EvidenceRequestFilter) (*EvidenceRequestConnection, error)
+	
+	// GetEvidenceRequest retrieves an evidence request of an organization
+	GetEvidenceRequest(ctx context.Context, orgID, id string) (*models.EvidenceRequest, error)
+	
+	// GetUsersByIDs retrieves several members of an organization in one query
+	GetUsersByIDs(ctx context.Context, orgID string, ids []string) ([]*models.User, error)
+}
+
 // AuthenticationService handles user authentication, authorization, and security operations.
 // It provides comprehensive authentication features including JWT token management,
 // password hashing, session management, and role-based access control.
@@ -102,8 +184,13 @@ type AuthenticationService interface {
 	// Password management
 	ChangePassword(ctx context.Context, userID, oldPassword, newPassword string) error
 	ResetPassword(ctx context.Context, email string) error
+	LookupPasswordReset(ctx context.Context, token string) (*PasswordResetDetails, error)
+	VerifyPasswordResetAnswer(ctx context.Context, token, answer string) error
 	ValidatePasswordReset(ctx context.Context, token, newPassword string) error
 	
+	// Close waits for the password reset requests still being handled, on shutdown
+	Close(ctx context.Context) error
+	
 	// Multi-factor authentication
 	EnableMFA(ctx context.Context, userID string) (*MFASetupResponse, error)
 	DisableMFA(ctx context.Context, userID, mfaCode string) error
@@ -116,6 +203,9 @@ type AuthenticationService interface {
 	UpdateSessionActivity(ctx context.Context, sessionID string) error
 	TerminateSession(ctx context.Context, sessionID string) error
 	TerminateAllSessions(ctx context.Context, userID string) error
+	ListSessions(ctx context.Context, orgID, userID, currentSessionID string) ([]*UserSession, error)
+	RevokeSession(ctx context.Context, orgID, userID, sessionID string) error
+	RevokeSessions(ctx context.Context, orgID, userID, exceptSessionID string) (int64, error)
 	
 	// Token operations
 	ValidateAccessToken(ctx context.Context, token string) (*models.JWTClaims, error)
@@ -197,6 +287,7 @@ type OrganizationService interface {
 	DowngradeSubscription(ctx context.Context, orgID, newPlan string) error
 	CancelSubscription(ctx context.Context, orgID string) error
 	RenewSubscription(ctx context.Context, orgID string) error
+	RenewDueSubscriptions(ctx context.Context, now time.Time) (int, error)
 	
 	// Feature flag management
 	GetFeatureFlags(ctx context.Context, orgID string) (map[string]bool, error)
@@ -212,7 +303,7 @@ type OrganizationService interface {
 	// Member management
 	GetMemberCount(ctx context.Context, orgID string) (int, error)
 	UpdateMemberCount(ctx context.Context, orgID string, count int) error
-	GetMemberLimits(ctx context.Context, orgID string) (current, max int, error)
+	GetMemberLimits(ctx context.Context, orgID string) (current, max int, err error)
 	CanAddMember(ctx context.Context, orgID string) (bool, error)
 	
 	// Organization validation and compliance
@@ -231,7 +322,8 @@ type OrganizationService interface {
 }
 
 // UserService manages user accounts, authentication, and authorization.
-// It handles user lifecycle and security operations.
+// It handles user lifecycle and security operations, including inviting
+// users to join an organization.
 type UserService interface {
 	// CreateUser creates a new user account with proper validation
 	CreateUser(ctx context.Context, input *CreateUserInput) (*models.User, error)
@@ -259,6 +351,24 @@ type UserService interface {
 	
 	// ValidatePermissions checks if user has required permissions
 	ValidatePermissions(ctx context.Context, userID string, permissions []string) error
+	
+	// InviteUser invites an email address to join an organization
+	InviteUser(ctx context.Context, input *InviteUserInput) (*InvitationSecret, error)
+	
+	// ListInvitations retrieves an organization's invitations
+	ListInvitations(ctx context.Context, orgID string, includeClosed bool) ([]*models.Invitation, error)
+	
+	// ResendInvitation extends a pending invitation and sends a new link, invalidating earlier ones
+	ResendInvitation(ctx context.Context, orgID, invitationID, resentBy string) (*InvitationSecret, error)
+	
+	// RevokeInvitation withdraws a pending invitation
+	RevokeInvitation(ctx context.Context, orgID, invitationID, revokedBy string) (*models.Invitation, error)
+	
+	// LookupInvitation describes the invitation a link was sent for
+	LookupInvitation(ctx context.Context, token string) (*InvitationDetails, error)
+	
+	// AcceptInvitation creates the invitee's account with the password they chose
+	AcceptInvitation(ctx context.Context, input *AcceptInvitationInput) (*models.User, error)
 }
 
 // AuditService handles audit logging and compliance tracking.
@@ -280,6 +390,61 @@ type AuditService interface {
 	ExportAuditLog(ctx context.Context, filter *AuditFilter, format string) (*Document, error)
 }
 
+// AuditChainService verifies the per-organization audit hash chain and manages
+// signed checkpoints of the chain head that can be handed to an external notary.
+type AuditChainService interface {
+	// VerifyChain walks an organization's chain and reports the first break
+	VerifyChain(ctx context.Context, orgID string) (*ChainVerificationResult, error)
+	
+	// CreateCheckpoint signs the current chain head; it returns nil when the head is already checkpointed
+	CreateCheckpoint(ctx context.Context, orgID string) (*models.AuditCheckpoint, error)
+	
+	// CheckpointAll creates checkpoints for all active organizations and returns how many were created
+	CheckpointAll(ctx context.Context) (int, error)
+	
+	// ExportCheckpoints builds the notary export file of an organization's checkpoints
+	ExportCheckpoints(ctx context.Context, orgID string) (*NotaryExport, error)
+}
+
+// AuditExportService runs large audit log exports as background jobs and serves
+// the finished files through expiring download links.
+type AuditExportService interface {
+	// RequestExport queues a background export
+	RequestExport(ctx context.Context, input *AuditExportInput) (*models.ExportJob, error)
+	
+	// GetExport retrieves an export job of an organization
+	GetExport(ctx context.Context, orgID, exportID string) (*models.ExportJob, error)
+	
+	// ProcessPending runs queued export jobs and returns how many were processed
+	ProcessPending(ctx context.Context) (int, error)
+	
+	// OpenDownload opens the file of a completed export using its download token
+	OpenDownload(ctx context.Context, orgID, exportID, token string) (*ExportDownload, error)
+}
+
+// RetentionService applies each organization's retention policy to audit logs,
+// evidence files, sessions and soft-deleted records. Legal holds exempt the data
+// they cover from purging until they are released.
+type RetentionService interface {
+	// Report computes what a purge would remove without changing any data
+	Report(ctx context.Context, orgID string) (*RetentionReport, error)
+	
+	// Enforce purges an organization's data past its retention period
+	Enforce(ctx context.Context, orgID string) (*RetentionReport, error)
+	
+	// EnforceAll enforces retention for all active organizations and returns how many records were purged
+	EnforceAll(ctx context.Context) (int64, error)
+	
+	// CreateLegalHold places a legal hold
+	CreateLegalHold(ctx context.Context, input *LegalHoldInput) (*models.LegalHold, error)
+	
+	// ReleaseLegalHold releases an active legal hold
+	ReleaseLegalHold(ctx context.Context, orgID, holdID, userID string) (*models.LegalHold, error)
+	
+	// ListLegalHolds retrieves the legal holds of an organization
+	ListLegalHolds(ctx context.Context, orgID string, includeReleased bool) ([]*models.LegalHold, error)
+}
+
 // NotificationService handles system notifications and communications.
 // It manages email notifications and system alerts.
 type NotificationService interface {
@@ -289,12 +454,27 @@ type NotificationService interface {
 	// SendReminderNotification sends reminder for overdue evidence
 	SendReminderNotification(ctx context.Context, request *models.EvidenceRequest) error
 	
+	// SendEscalationNotification notifies the assignee's manager about an overdue request
+	SendEscalationNotification(ctx context.Context, request *models.EvidenceRequest, manager *models.User) error
+	
+	// SendReviewNotification notifies participants about review workflow events
+	SendReviewNotification(ctx context.Context, request *models.EvidenceRequest, eventType string) error
+	
+	// SendMentionNotification notifies a user mentioned in a comment
+	SendMentionNotification(ctx context.Context, comment *models.Comment, mentioned *models.User) error
+	
 	// SendTestingCycleNotification notifies about testing cycle updates
 	SendTestingCycleNotification(ctx context.Context, cycle *models.TestingCycle, eventType string) error
 	
 	// SendSystemAlert sends system alerts to administrators
 	SendSystemAlert(ctx context.Context, alert *SystemAlert) error
 	
+	// SendInvitation emails an invitation link to the invited address
+	SendInvitation(ctx context.Context, invitation *models.Invitation, token string) error
+	
+	// SendPasswordReset emails a password reset link to a user
+	SendPasswordReset(ctx context.Context, user *models.User, token string, expiresAt time.Time) error
+	
 	// GetNotificationPreferences retrieves user notification preferences
 	GetNotificationPreferences(ctx context.Context, userID string) (*NotificationPreferences, error)
 	
@@ -302,6 +482,246 @@ type NotificationService interface {
 	UpdateNotificationPreferences(ctx context.Context, userID string, prefs *NotificationPreferences) error
 }
 
+// NotificationTemplateService manages per-organization overrides of the
+// built-in notification email templates.
+type NotificationTemplateService interface {
+	// ListTemplates retrieves the effective template of every notification type
+	ListTemplates(ctx context.Context, orgID string) ([]*NotificationTemplateView, error)
+	
+	// SetTemplate validates and stores an organization override
+	SetTemplate(ctx context.Context, input *NotificationTemplateInput) (*NotificationTemplateView, error)
+	
+	// ResetTemplate removes an organization override, restoring the built-in template
+	ResetTemplate(ctx context.Context, orgID, notificationType string) error
+	
+	// PreviewTemplate renders the effective template with sample data
+	PreviewTemplate(ctx context.Context, orgID, notificationType string) (*RenderedNotification, error)
+}
+
+// NotificationDispatcher delivers queued notification emails from the outbox.
+type NotificationDispatcher interface {
+	// DispatchPending sends due outbox messages and returns how many were attempted
+	DispatchPending(ctx context.Context) (int, error)
+}
+
+// NotificationDigester turns held back non-urgent notifications into digest emails.
+type NotificationDigester interface {
+	// SendDueDigests queues a digest email for every user with due digest items
+	// and returns how many digests were queued
+	SendDueDigests(ctx context.Context) (int, error)
+}
+
+// NotificationInboxService manages users' in-app notification inboxes and
+// pushes inbox events live to the users' open sessions on every server instance.
+type NotificationInboxService interface {
+	// ListNotifications retrieves a page of a user's inbox, newest first
+	ListNotifications(ctx context.Context, userID string, filter *InboxFilter) (*InAppNotificationConnection, error)
+	
+	// MarkRead marks the given notifications of a user as read
+	MarkRead(ctx context.Context, userID string, notificationIDs []string) (int64, error)
+	
+	// MarkAllRead marks all unread notifications of a user as read
+	MarkAllRead(ctx context.Context, userID string) (int64, error)
+	
+	// Deliver stores a notification in its user's inbox and pushes it to the
+	// user's sessions; browser asks clients to also show a browser notification
+	Deliver(ctx context.Context, notification *models.InAppNotification, browser bool) error
+	
+	// PublishCycleProgress pushes a testing cycle's progress to the sessions of
+	// the cycle's organization
+	PublishCycleProgress(ctx context.Context, cycle *models.TestingCycle) error
+	
+	// Subscribe returns the live inbox events of a user's session until ctx is cancelled
+	Subscribe(ctx context.Context, orgID, userID string) (<-chan *InboxEvent, error)
+	
+	// Listen relays inbox events published by any server instance to the
+	// sessions subscribed on this instance until ctx is cancelled
+	Listen(ctx context.Context) error
+}
+
+// WebhookService manages an organization's webhook subscriptions and queues
+// deliveries of the organization's domain events to them.
+type WebhookService interface {
+	// CreateSubscription creates a subscription and returns it with its signing secret
+	CreateSubscription(ctx context.Context, input *WebhookSubscriptionInput) (*WebhookSubscriptionSecret, error)
+	
+	// GetSubscription retrieves a subscription of an organization
+	GetSubscription(ctx context.Context, orgID, subscriptionID string) (*models.WebhookSubscription, error)
+	
+	// ListSubscriptions retrieves all subscriptions of an organization
+	ListSubscriptions(ctx context.Context, orgID string) ([]*models.WebhookSubscription, error)
+	
+	// UpdateSubscription changes a subscription; re-enabling resets its failures
+	UpdateSubscription(ctx context.Context, input *UpdateWebhookSubscriptionInput) (*models.WebhookSubscription, error)
+	
+	// DeleteSubscription removes a subscription
+	DeleteSubscription(ctx context.Context, orgID, subscriptionID string) error
+	
+	// RotateSecret replaces a subscription's signing secret and returns the new one
+	RotateSecret(ctx context.Context, orgID, subscriptionID string) (*WebhookSubscriptionSecret, error)
+	
+	// ListDeliveries retrieves a page of a subscription's delivery log, newest first
+	ListDeliveries(ctx context.Context, orgID, subscriptionID string, limit, offset int) (*WebhookDeliveryConnection, error)
+	
+	// Redeliver queues a new delivery of a past delivery's event
+	Redeliver(ctx context.Context, orgID, subscriptionID, deliveryID string) (*models.WebhookDelivery, error)
+	
+	// Publish queues a delivery of an event to every active subscription of the
+	// organization subscribed to the event type
+	Publish(ctx context.Context, orgID, eventType string, data interface{}) error
+}
+
+// APIKeyService issues, rotates and revokes an organization's API keys and
+// authenticates requests made with them.
+type APIKeyService interface {
+	// CreateAPIKey issues a key and returns it with its secret value
+	CreateAPIKey(ctx context.Context, input *APIKeyInput) (*APIKeySecret, error)
+	
+	// GetAPIKey retrieves a key of an organization
+	GetAPIKey(ctx context.Context, orgID, keyID string) (*models.APIKey, error)
+	
+	// ListAPIKeys retrieves all keys of an organization
+	ListAPIKeys(ctx context.Context, orgID string) ([]*models.APIKey, error)
+	
+	// RotateAPIKey replaces a key; the old key keeps working for an overlap period
+	RotateAPIKey(ctx context.Context, input *RotateAPIKeyInput) (*APIKeySecret, error)
+	
+	// RevokeAPIKey disables a key immediately
+	RevokeAPIKey(ctx context.Context, orgID, keyID, revokedBy string) (*models.APIKey, error)
+	
+	// Authenticate resolves the key presented by a request and records its use
+	Authenticate(ctx context.Context, rawKey, ip string) (*models.APIKey, error)
+}
+
+// SSOService signs users in through their organization's identity provider
+// and starts platform sessions for them.
+type SSOService interface {
+	// BeginLogin starts a login and returns the identity provider URL to send the user to
+	BeginLogin(ctx context.Context, input *BeginSSOInput) (string, error)
+	
+	// CompleteLogin finishes a login with the identity provider's response
+	CompleteLogin(ctx context.Context, input *CompleteSSOInput) (*SSOLoginResult, error)
+}
+
+// SAMLService signs users in through their organization's SAML identity
+// provider, with the platform acting as the service provider.
+type SAMLService interface {
+	// Metadata returns the service provider metadata of an organization
+	Metadata(ctx context.Context, organizationSlug string) ([]byte, error)
+	
+	// BeginLogin starts a login and returns the identity provider URL carrying a signed AuthnRequest
+	BeginLogin(ctx context.Context, input *BeginSSOInput) (string, error)
+	
+	// CompleteLogin validates a response posted to the assertion consumer service
+	CompleteLogin(ctx context.Context, input *CompleteSAMLInput) (*SSOLoginResult, error)
+}
+
+// SCIMService lets an organization's identity provider provision users and
+// groups through SCIM 2.0. Group membership decides the users' roles, and
+// deactivating or deleting a user terminates their sessions.
+type SCIMService interface {
+	// ListUsers returns a page of the users matching a query
+	ListUsers(ctx context.Context, orgID string, query *SCIMQuery) (*scim.ListResponse, error)
+	
+	// GetUser retrieves a user of the organization
+	GetUser(ctx context.Context, orgID, userID string) (*scim.User, error)
+	
+	// CreateUser provisions a user
+	CreateUser(ctx context.Context, orgID string, user *scim.User) (*scim.User, error)
+	
+	// ReplaceUser replaces a user's attributes
+	ReplaceUser(ctx context.Context, orgID, userID string, user *scim.User) (*scim.User, error)
+	
+	// PatchUser applies PATCH operations to a user
+	PatchUser(ctx context.Context, orgID, userID string, patch *scim.PatchRequest) (*scim.User, error)
+	
+	// DeleteUser deprovisions and deletes a user
+	DeleteUser(ctx context.Context, orgID, userID string) error
+	
+	// ListGroups returns a page of the groups matching a query
+	ListGroups(ctx context.Context, orgID string, query *SCIMQuery) (*scim.ListResponse, error)
+	
+	// GetGroup retrieves a group of the organization
+	GetGroup(ctx context.Context, orgID, groupID string) (*scim.Group, error)
+	
+	// CreateGroup creates a group
+	CreateGroup(ctx context.Context, orgID string, group *scim.Group) (*scim.Group, error)
+	
+	// ReplaceGroup replaces a group's name and members
+	ReplaceGroup(ctx context.Context, orgID, groupID string, group *scim.Group) (*scim.Group, error)
+	
+	// PatchGroup applies PATCH operations to a group
+	PatchGroup(ctx context.Context, orgID, groupID string, patch *scim.PatchRequest) (*scim.Group, error)
+	
+	// DeleteGroup deletes a group
+	DeleteGroup(ctx context.Context, orgID, groupID string) error
+}
+
+// LDAPService signs users in with their directory password and keeps accounts
+// in sync with their organization's LDAP or Active Directory server. Directory
+// groups decide the users' roles.
+type LDAPService interface {
+	// Login authenticates a user with a bind to the directory and starts a session
+	Login(ctx context.Context, input *LDAPLoginInput) (*models.LoginResponse, error)
+	
+	// SyncOrganization creates, updates and deactivates an organization's accounts from its directory
+	SyncOrganization(ctx context.Context, orgID string) (*LDAPSyncResult, error)
+	
+	// SyncAll syncs every organization with directory sync enabled and returns how many were synced
+	SyncAll(ctx context.Context) (int, error)
+}
+
+// WebhookDispatcher sends queued webhook deliveries.
+type WebhookDispatcher interface {
+	// DispatchPending sends due deliveries and returns how many were attempted
+	DispatchPending(ctx context.Context) (int, error)
+}
+
+// ScheduledJobFunc is the work of a scheduled job. The context is cancelled
+// when the scheduler stops or loses the job's lease.
+type ScheduledJobFunc func(ctx context.Context) error
+
+// SchedulerService runs registered jobs on cron schedules, on one instance at a
+// time, and lets the operator's administrators inspect and control them.
+type SchedulerService interface {
+	// Register adds a job; all jobs are registered before Start
+	Register(name, description, schedule string, run ScheduledJobFunc) error
+	
+	// Start runs due jobs until ctx is cancelled, then waits for running jobs
+	Start(ctx context.Context) error
+	
+	// ListJobs retrieves the state of all registered jobs
+	ListJobs(ctx context.Context, orgID string) ([]*models.ScheduledJob, error)
+	
+	// GetJob retrieves the state of a job
+	GetJob(ctx context.Context, orgID, name string) (*models.ScheduledJob, error)
+	
+	// ListRuns retrieves a page of a job's run history, newest first
+	ListRuns(ctx context.Context, orgID, name string, limit, offset int) (*JobRunConnection, error)
+	
+	// TriggerJob starts a run of a job now, even if it is paused
+	TriggerJob(ctx context.Context, orgID, name string) (*models.JobRun, error)
+	
+	// PauseJob stops scheduled runs of a job
+	PauseJob(ctx context.Context, orgID, name string) (*models.ScheduledJob, error)
+	
+	// ResumeJob restarts scheduled runs of a job from its next scheduled time
+	ResumeJob(ctx context.Context, orgID, name string) (*models.ScheduledJob, error)
+}
+
+// JobLocker grants the expiring leases that keep a scheduled job from running
+// on two instances at once. Owners identify a single run.
+type JobLocker interface {
+	// Acquire takes the job's lease if it is free and reports whether it did
+	Acquire(ctx context.Context, job, owner string, ttl time.Duration) (bool, error)
+	
+	// Renew extends a lease still held by owner and reports whether it did
+	Renew(ctx context.Context, job, owner string, ttl time.Duration) (bool, error)
+	
+	// Release frees a lease held by owner
+	Release(ctx context.Context, job, owner string) error
+}
+
 // Input/Output structures for service operations
 
 // CreateControlInput contains the data needed to create a new control
@@ -371,6 +791,42 @@ type ControlConnection struct {
 	HasMore    bool              `json:"has_more"`
 }
 
+// TestingCycleFilter defines pagination options for testing cycle queries
+type TestingCycleFilter struct {
+	OrganizationID string `json:"organization_id"`
+	
+	// Pagination
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// TestingCycleConnection represents a paginated list of testing cycles
+type TestingCycleConnection struct {
+	Nodes      []*models.TestingCycle `json:"nodes"`
+	TotalCount int                    `json:"total_count"`
+	HasMore    bool                   `json:"has_more"`
+}
+
+// EvidenceRequestFilter defines filtering options for evidence request queries
+type EvidenceRequestFilter struct {
+	OrganizationID string `json:"organization_id"`
+	Status         string `json:"status,omitempty"`
+	ControlID      string `json:"control_id,omitempty"`
+	CycleID        string `json:"cycle_id,omitempty"`
+	AssigneeID     string `json:"assignee_id,omitempty"`
+	
+	// Pagination
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// EvidenceRequestConnection represents a paginated list of evidence requests
+type EvidenceRequestConnection struct {
+	Nodes      []*models.EvidenceRequest `json:"nodes"`
+	TotalCount int                       `json:"total_count"`
+	HasMore    bool                      `json:"has_more"`
+}
+
 // Additional service input/output structures...
 
 // CreateCycleInput contains data for creating a testing cycle
@@ -453,6 +909,174 @@ type UpdateEvidenceRequestInput struct {
 	CompletedAt  *string `json:"completed_at,omitempty"`
 }
 
+// AssignReviewersInput contains data for assigning reviewers to an evidence request
+type AssignReviewersInput struct {
+	OrganizationID string   `json:"-"`
+	RequestID      string   `json:"-"`
+	AssignedBy     string   `json:"-"`
+	ReviewerIDs    []string `json:"reviewer_ids" validate:"required,min=1"`
+}
+
+// ReviewActionInput contains data for submitting, approving or rejecting evidence
+type ReviewActionInput struct {
+	OrganizationID string `json:"-"`
+	RequestID      string `json:"-"`
+	ActorID        string `json:"-"`
+	Comment        string `json:"comment"`
+}
+
+// CreateCommentInput contains data for creating comments
+type CreateCommentInput struct {
+	OrganizationID string `json:"-"`
+	AuthorID       string `json:"-"`
+	ResourceType   string `json:"resource_type" validate:"required"`
+	ResourceID     string `json:"resource_id" validate:"required"`
+	ParentID       string `json:"parent_id,omitempty"`
+	Content        string `json:"content" validate:"required,max=10000"`
+}
+
+// EditCommentInput contains data for editing comments
+type EditCommentInput struct {
+	OrganizationID string `json:"-"`
+	CommentID      string `json:"-"`
+	ActorID        string `json:"-"`
+	Content        string `json:"content" validate:"required,max=10000"`
+}
+
+// CommentThread represents a comment together with its nested replies
+type CommentThread struct {
+	Comment *models.Comment  `json:"comment"`
+	Replies []*CommentThread `json:"replies"`
+}
+
+// LifecycleRunResult summarizes the outcome of an evidence lifecycle run
+type LifecycleRunResult struct {
+	OrganizationsProcessed int `json:"organizations_processed"`
+	RequestsExamined       int `json:"requests_examined"`
+	RemindersSent          int `json:"reminders_sent"`
+	MarkedOverdue          int `json:"marked_overdue"`
+	Escalated              int `json:"escalated"`
+	Failures               int `json:"failures"`
+}
+
+// AuditExportInput contains data for requesting a background audit export
+type AuditExportInput struct {
+	OrganizationID string      `json:"-"`
+	RequestedBy    string      `json:"-"`
+	Format         string      `json:"format" validate:"required,oneof=csv jsonl pdf"`
+	Filter         AuditFilter `json:"filter"`
+}
+
+// ExportDownload is an opened export file ready to be streamed to the client
+type ExportDownload struct {
+	FileName    string
+	ContentType string
+	Manifest    *models.ExportManifest
+	Content     io.ReadCloser
+}
+
+// Retention data categories
+const (
+	RetentionCategoryAuditLogs       = "audit_logs"
+	RetentionCategoryEvidenceFiles   = "evidence_files"
+	RetentionCategorySessions        = "sessions"
+	RetentionCategoryDeletedComments = "deleted_comments"
+)
+
+// LegalHoldInput contains data for placing a legal hold. Testing cycle holds
+// identify the cycle in ResourceID; resource holds also need ResourceType.
+type LegalHoldInput struct {
+	OrganizationID string `json:"-"`
+	CreatedBy      string `json:"-"`
+	Scope          string `json:"scope" validate:"required,oneof=organization testing_cycle resource"`
+	ResourceType   string `json:"resource_type,omitempty"`
+	ResourceID     string `json:"resource_id,omitempty"`
+	Reason         string `json:"reason" validate:"required"`
+}
+
+// RetentionPolicy is the effective retention policy of an organization: the longer
+// of the regulatory retention period and the organization's own setting
+type RetentionPolicy struct {
+	RetentionDays     int `json:"retention_days"`
+	RegulatoryYears   int `json:"regulatory_years"`
+	DataRetentionDays int `json:"data_retention_days"`
+}
+
+// RetentionReport describes the outcome of a retention run, or of a dry run
+type RetentionReport struct {
+	OrganizationID string                     `json:"organization_id"`
+	DryRun         bool                       `json:"dry_run"`
+	Policy         RetentionPolicy            `json:"policy"`
+	Cutoff         time.Time                  `json:"cutoff,omitempty"`
+	LegalHolds     []*models.LegalHold        `json:"legal_holds"`
+	Categories     []*RetentionCategoryReport `json:"categories"`
+	GeneratedAt    time.Time                  `json:"generated_at"`
+}
+
+// RetentionCategoryReport counts the records of one data category past the cutoff
+type RetentionCategoryReport struct {
+	Category string `json:"category"`
+	Eligible int64  `json:"eligible"`
+	Held     int64  `json:"held"`
+	Purged   int64  `json:"purged"`
+	Batches  int    `json:"batches"`
+	Note     string `json:"note,omitempty"`
+}
+
+// Audit chain break reasons
+const (
+	ChainBreakSequenceGap         = "sequence_gap"
+	ChainBreakPreviousHash        = "previous_hash_mismatch"
+	ChainBreakHashMismatch        = "hash_mismatch"
+	ChainBreakUnanchoredStart     = "unanchored_start"
+	ChainBreakCheckpointMismatch  = "checkpoint_mismatch"
+	ChainBreakCheckpointSignature = "invalid_checkpoint_signature"
+	ChainBreakTruncated           = "truncated"
+)
+
+// ChainVerificationResult reports the outcome of an audit chain verification
+type ChainVerificationResult struct {
+	OrganizationID      string      `json:"organization_id"`
+	Valid               bool        `json:"valid"`
+	EntriesVerified     int64       `json:"entries_verified"`
+	FirstSequence       int64       `json:"first_sequence,omitempty"`
+	HeadSequence        int64       `json:"head_sequence,omitempty"`
+	HeadHash            string      `json:"head_hash,omitempty"`
+	CheckpointsVerified int         `json:"checkpoints_verified"`
+	FirstBreak          *ChainBreak `json:"first_break,omitempty"`
+	VerifiedAt          time.Time   `json:"verified_at"`
+}
+
+// ChainBreak describes the first point at which an audit chain fails verification
+type ChainBreak struct {
+	Sequence int64  `json:"sequence"`
+	EntryID  string `json:"entry_id,omitempty"`
+	Reason   string `json:"reason"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+// NotaryExport is the file handed to an external notary. It contains everything
+// needed to check the checkpoint signatures independently of the platform.
+type NotaryExport struct {
+	Format         string             `json:"format"`
+	Algorithm      string             `json:"algorithm"`
+	KeyID          string             `json:"key_id"`
+	PublicKey      string             `json:"public_key"`
+	OrganizationID string             `json:"organization_id"`
+	ExportedAt     time.Time          `json:"exported_at"`
+	Checkpoints    []NotaryCheckpoint `json:"checkpoints"`
+}
+
+// NotaryCheckpoint is a single signed checkpoint in a notary export
+type NotaryCheckpoint struct {
+	Sequence  int64     `json:"sequence"`
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"created_at"`
+	KeyID     string    `json:"key_id"`
+	Signature string    `json:"signature"`
+}
+
 // FileUpload represents an uploaded file
 type FileUpload struct {
 	FileName    string `json:"file_name"`
@@ -475,6 +1099,55 @@ type CreateUserInput struct {
 	Phone          string `json:"phone,omitempty"`
 }
 
+// InviteUserInput contains the data needed to invite someone; without a Role
+// the organization's default user role applies
+type InviteUserInput struct {
+	OrganizationID string `json:"-"`
+	InvitedBy      string `json:"-"`
+	Email          string `json:"email" validate:"required,email"`
+	Role           string `json:"role,omitempty"`
+}
+
+// InvitationSecret is an invitation together with the token of its link,
+// returned only when the invitation is sent or resent
+type InvitationSecret struct {
+	Invitation *models.Invitation `json:"invitation"`
+	Token      string             `json:"token"`
+}
+
+// InvitationDetails describes a pending invitation to the invitee before they accept it
+type InvitationDetails struct {
+	Email            string    `json:"email"`
+	Role             string    `json:"role"`
+	OrganizationName string    `json:"organization_name"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+// AcceptInvitationInput contains the token of an invitation link and the
+// profile and password of the account to create
+type AcceptInvitationInput struct {
+	Token     string `json:"token" validate:"required"`
+	FirstName string `json:"first_name" validate:"required"`
+	LastName  string `json:"last_name" validate:"required"`
+	Password  string `json:"password" validate:"required"`
+}
+
+// UserSession is an active session, or device, of a user
+type UserSession struct {
+	*models.Session
+	
+	// Current marks the session the request was made with
+	Current bool `json:"current"`
+}
+
+// PasswordResetDetails describes a pending password reset to the holder of its link
+type PasswordResetDetails struct {
+	// SecurityQuestion must be answered before the new password can be set
+	SecurityQuestion string    `json:"security_question,omitempty"`
+	AnswerRequired   bool      `json:"answer_required"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
 // UpdateUserInput contains data for updating users
 type UpdateUserInput struct {
 	FirstName  *string  `json:"first_name,omitempty"`
@@ -517,6 +1190,8 @@ type AuditInput struct {
 	NewValues      map[string]interface{} `json:"new_values,omitempty"`
 	IPAddress      string                 `json:"ip_address,omitempty"`
 	UserAgent      string                 `json:"user_agent,omitempty"`
+	CorrelationID  string                 `json:"correlation_id,omitempty"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
 	Success        bool                   `json:"success"`
 	ErrorMessage   string                 `json:"error_message,omitempty"`
 }
@@ -559,6 +1234,218 @@ type NotificationPreferences struct {
 	EvidenceRequest bool `json:"evidence_request"`
 	Reminders       bool `json:"reminders"`
 	SystemAlerts    bool `json:"system_alerts"`
+	
+	// DigestFrequency is immediate, daily, weekly or monthly; quiet hours are
+	// HH:MM in the user's timezone, with equal or empty values meaning none
+	DigestFrequency string `json:"digest_frequency"`
+	QuietHoursStart string `json:"quiet_hours_start"`
+	QuietHoursEnd   string `json:"quiet_hours_end"`
+}
+
+// InboxFilter defines filtering options for inbox queries
+type InboxFilter struct {
+	UnreadOnly bool `json:"unread_only,omitempty"`
+	
+	// Pagination
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// InAppNotificationConnection represents a paginated list of inbox notifications
+type InAppNotificationConnection struct {
+	Nodes       []*models.InAppNotification `json:"nodes"`
+	TotalCount  int                         `json:"total_count"`
+	UnreadCount int                         `json:"unread_count"`
+	HasMore     bool                        `json:"has_more"`
+}
+
+// InboxEvent is pushed live to a user's sessions. Type is one of the
+// models.InboxEvent* constants and selects the populated field.
+type InboxEvent struct {
+	Type          string                    `json:"type"`
+	Notification  *models.InAppNotification `json:"notification,omitempty"`
+	CycleProgress *CycleProgressUpdate      `json:"cycle_progress,omitempty"`
+	
+	// Browser asks the client to also show a browser notification
+	Browser bool `json:"browser,omitempty"`
+}
+
+// CycleProgressUpdate reports the progress of a testing cycle
+type CycleProgressUpdate struct {
+	CycleID  string          `json:"cycle_id"`
+	Name     string          `json:"name"`
+	Status   string          `json:"status"`
+	Progress models.Progress `json:"progress"`
+}
+
+// WebhookSubscriptionInput contains the data needed to create a webhook subscription
+type WebhookSubscriptionInput struct {
+	OrganizationID string   `json:"-"`
+	CreatedBy      string   `json:"-"`
+	URL            string   `json:"url" validate:"required,url"`
+	Description    string   `json:"description,omitempty"`
+	Events         []string `json:"events" validate:"required,min=1"`
+}
+
+// UpdateWebhookSubscriptionInput contains changes to a webhook subscription;
+// nil fields are left unchanged
+type UpdateWebhookSubscriptionInput struct {
+	OrganizationID string    `json:"-"`
+	SubscriptionID string    `json:"-"`
+	URL            *string   `json:"url,omitempty"`
+	Description    *string   `json:"description,omitempty"`
+	Events         *[]string `json:"events,omitempty"`
+	IsActive       *bool     `json:"is_active,omitempty"`
+}
+
+// WebhookSubscriptionSecret is a subscription together with its signing
+// secret, returned only when the secret is created or rotated
+type WebhookSubscriptionSecret struct {
+	Subscription *models.WebhookSubscription `json:"subscription"`
+	Secret       string                      `json:"secret"`
+}
+
+// APIKeyInput contains the data needed to create an API key
+type APIKeyInput struct {
+	OrganizationID string     `json:"-"`
+	CreatedBy      string     `json:"-"`
+	Name           string     `json:"name" validate:"required"`
+	Role           string     `json:"role" validate:"required"`
+	Scopes         []string   `json:"scopes" validate:"required,min=1"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+}
+
+// RotateAPIKeyInput identifies a key to rotate; without OverlapHours the
+// configured rotation overlap applies
+type RotateAPIKeyInput struct {
+	OrganizationID string `json:"-"`
+	APIKeyID       string `json:"-"`
+	RotatedBy      string `json:"-"`
+	OverlapHours   *int   `json:"overlap_hours,omitempty"`
+}
+
+// APIKeySecret is an API key together with its secret value, returned only
+// when the key is created or rotated
+type APIKeySecret struct {
+	APIKey *models.APIKey `json:"api_key"`
+	Key    string         `json:"key"`
+}
+
+// BeginSSOInput identifies the organization to sign in to by slug and the
+// application path to return to afterwards
+type BeginSSOInput struct {
+	OrganizationSlug string
+	ReturnTo         string
+}
+
+// CompleteSSOInput carries the parameters of an identity provider's redirect
+// back to the platform and the client's security context
+type CompleteSSOInput struct {
+	State            string
+	Code             string
+	Error            string
+	ErrorDescription string
+	IPAddress        string
+	UserAgent        string
+}
+
+// CompleteSAMLInput carries a response posted by a SAML identity provider and
+// the client's security context
+type CompleteSAMLInput struct {
+	OrganizationSlug string
+	SAMLResponse     string
+	RelayState       string
+	IPAddress        string
+	UserAgent        string
+}
+
+// SCIMQuery selects a page of SCIM resources
+type SCIMQuery struct {
+	// Filter is a SCIM filter expression; empty matches all resources
+	Filter string
+	
+	// StartIndex is the 1-based index of the first resource returned
+	StartIndex int
+	
+	// Count limits the page size; nil returns a page of the maximum size
+	Count *int
+}
+
+// LDAPLoginInput carries a directory sign-in and the client's security context
+type LDAPLoginInput struct {
+	OrganizationSlug string
+	Email            string
+	Password         string
+	IPAddress        string
+	UserAgent        string
+}
+
+// LDAPSyncResult counts the accounts changed by a directory sync
+type LDAPSyncResult struct {
+	Created     int `json:"created"`
+	Updated     int `json:"updated"`
+	Deactivated int `json:"deactivated"`
+	
+	// Skipped counts directory entries without an email address or whose
+	// email belongs to another organization's account
+	Skipped int `json:"skipped"`
+}
+
+// SSOLoginResult is a completed single sign-on login
+type SSOLoginResult struct {
+	*models.LoginResponse
+	ReturnTo string `json:"return_to,omitempty"`
+}
+
+// WebhookDeliveryConnection represents a paginated delivery log
+type WebhookDeliveryConnection struct {
+	Nodes      []*models.WebhookDelivery `json:"nodes"`
+	TotalCount int                       `json:"total_count"`
+	HasMore    bool                      `json:"has_more"`
+}
+
+// JobRunConnection represents a paginated run history of a scheduled job
+type JobRunConnection struct {
+	Nodes      []*models.JobRun `json:"nodes"`
+	TotalCount int              `json:"total_count"`
+	HasMore    bool             `json:"has_more"`
+}
+
+// WebhookEvent is the JSON body of every webhook request
+type WebhookEvent struct {
+	ID             string      `json:"id"`
+	Type           string      `json:"type"`
+	OrganizationID string      `json:"organization_id"`
+	CreatedAt      time.Time   `json:"created_at"`
+	Data           interface{} `json:"data"`
+}
+
+// NotificationTemplateInput contains an organization override of a notification
+// template. Empty parts keep the built-in template.
+type NotificationTemplateInput struct {
+	OrganizationID string `json:"-"`
+	Type           string `json:"-"`
+	UpdatedBy      string `json:"-"`
+	Subject        string `json:"subject"`
+	Text           string `json:"text"`
+	HTML           string `json:"html"`
+}
+
+// NotificationTemplateView is the effective template of a notification type.
+type NotificationTemplateView struct {
+	Type       string    `json:"type"`
+	Subject    string    `json:"subject"`
+	Text       string    `json:"text"`
+	HTML       string    `json:"html"`
+	Overridden bool      `json:"overridden"`
+	UpdatedAt  time.Time `json:"updated_at,omitempty"`
+}
+
+// RenderedNotification is a notification email ready to be sent.
+type RenderedNotification struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
 }
 
 // Authentication service input/output structures
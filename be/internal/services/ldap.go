@@ -0,0 +1,670 @@
+// Package services provides service layer implementations for the GoEdu Control Testing Platform.
+// This file contains the LDAP service, which signs users in by binding to
+// their organization's LDAP or Active Directory server and syncs accounts,
+// roles and managers from the directory.
+package services
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/auth"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/cache"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/ldap"
+)
+
+// LDAP errors
+var (
+	ErrLDAPDisabled           = errors.New("LDAP sign-in is not enabled for this organization")
+	ErrLDAPInvalidCredentials = errors.New("invalid email or password")
+	ErrLDAPUnavailable        = errors.New("directory server is unavailable")
+	ErrLDAPAccessDenied       = errors.New("directory account may not sign in to this organization")
+	ErrLDAPNoUsers            = errors.New("directory search returned no users; accounts were left unchanged")
+	ErrAccountLocked          = errors.New("account is temporarily locked after repeated failed sign-ins")
+)
+
+const (
+	// defaultLDAPUserFilter selects user entries when an organization sets no filter
+	defaultLDAPUserFilter = "(objectClass=person)"
+
+	// adUserAccountControl holds Active Directory's account flags;
+	// adAccountDisabled marks disabled accounts
+	adUserAccountControl = "userAccountControl"
+	adAccountDisabled    = 0x2
+)
+
+// ldapService implements the LDAPService interface.
+type ldapService struct {
+	orgRepo     repositories.OrganizationRepository
+	userRepo    repositories.UserRepository
+	authService AuthenticationService
+	login       *loginIssuer
+	seats       *memberSeats
+	config      config.LDAPConfig
+	logger      *zap.Logger
+}
+
+// NewLDAPService creates a new LDAP service.
+//
+// Parameters:
+//   - orgRepo: Repository for organization data and directory settings
+//   - userRepo: Repository for the accounts signed in and synced
+//   - sessionRepo: Repository the sessions of signed-in users are stored in
+//   - denylist: Denylist of ended sessions, usually the Redis cache client
+//   - jwtManager: Issuer of the platform's access and refresh tokens
+//   - authService: Service terminating the sessions of deactivated users
+//   - cfg: Directory timeouts, page size and TLS policy
+//   - sessionCfg: Platform session timeouts
+//   - logger: Logger for service operations
+//
+// Returns:
+//   - LDAPService: Configured LDAP service instance
+func NewLDAPService(
+	orgRepo repositories.OrganizationRepository,
+	userRepo repositories.UserRepository,
+	sessionRepo repositories.SessionRepository,
+	denylist cache.Denylist,
+	jwtManager *auth.JWTManager,
+	authService AuthenticationService,
+	cfg config.LDAPConfig,
+	sessionCfg config.SessionConfig,
+	logger *zap.Logger,
+) LDAPService {
+	return &ldapService{
+		orgRepo:     orgRepo,
+		userRepo:    userRepo,
+		authService: authService,
+		login: &loginIssuer{
+			userRepo:   userRepo,
+			sessions:   newSessionManager(orgRepo, userRepo, sessionRepo, denylist, sessionCfg, logger),
+			jwtManager: jwtManager,
+			logger:     logger,
+		},
+		seats:  &memberSeats{orgRepo: orgRepo, logger: logger},
+		config: cfg,
+		logger: logger,
+	}
+}
+
+// Login finds the user's entry by email with the organization's service
+// account and binds as that entry with the given password. Repeated failures
+// lock the account. On success the account is created or updated from the
+// entry as a sync would, and a session with login method LDAP is started.
+// Unknown users and wrong passwords get the same error.
+func (s *ldapService) Login(ctx context.Context, input *LDAPLoginInput) (*models.LoginResponse, error) {
+	email := strings.ToLower(strings.TrimSpace(input.Email))
+	if email == "" || input.Password == "" {
+		return nil, ErrLDAPInvalidCredentials
+	}
+	org, err := s.orgRepo.GetBySlug(ctx, input.OrganizationSlug)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	settings, err := s.settings(org)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	switch {
+	case errors.Is(err, repositories.ErrNotFound):
+		user = nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	case user.OrganizationID != org.ID:
+		return nil, ErrLDAPInvalidCredentials
+	case user.IsLocked():
+		return nil, ErrAccountLocked
+	}
+
+	directory, err := s.connect(ctx, org, settings)
+	if err != nil {
+		return nil, err
+	}
+	defer directory.Close()
+
+	entries, err := directory.searchUsers(ctx, "("+directory.attributes.Email+"="+ldap.EscapeFilter(email)+")", 0)
+	if err != nil {
+		return nil, s.unavailable(org, "user search failed", err)
+	}
+	if len(entries) != 1 {
+		if len(entries) > 1 {
+			s.logger.Warn("Several directory entries share an email",
+				zap.String("organization_id", org.ID.Hex()),
+				zap.Int("entries", len(entries)),
+			)
+		}
+		recordFailedLogin(ctx, s.userRepo, s.logger, user, input.IPAddress)
+		return nil, ErrLDAPInvalidCredentials
+	}
+	entry := entries[0]
+	if err := directory.conn.Bind(ctx, entry.DN, input.Password); err != nil {
+		if errors.Is(err, ldap.ErrInvalidCredentials) {
+			recordFailedLogin(ctx, s.userRepo, s.logger, user, input.IPAddress)
+			return nil, ErrLDAPInvalidCredentials
+		}
+		return nil, s.unavailable(org, "user bind failed", err)
+	}
+
+	account := directory.account(entry)
+	deny := func(reason string) error {
+		s.logger.Warn("LDAP sign-in denied",
+			zap.String("organization_id", org.ID.Hex()),
+			zap.String("dn", entry.DN),
+			zap.String("reason", reason),
+		)
+		return ErrLDAPAccessDenied
+	}
+	switch {
+	case account.Email != email:
+		return nil, deny("entry email does not match")
+	case account.Disabled:
+		return nil, deny("directory account is disabled")
+	case len(account.Roles) == 0:
+		return nil, deny("no mapped group")
+	}
+
+	if user == nil {
+		if user, err = s.createUser(ctx, org, account); err != nil {
+			return nil, err
+		}
+	} else {
+		if !user.IsActive && !deactivatedByDirectory(user) {
+			return nil, deny("account is " + user.Status)
+		}
+		reactivated := !user.IsActive
+		if reactivated {
+			if err := s.seats.take(ctx, org.ID.Hex()); err != nil {
+				return nil, err
+			}
+		}
+		changed := applyLDAPAccount(user, account)
+		if changed || user.Authentication.FailedLoginAttempts > 0 {
+			user.Authentication.FailedLoginAttempts = 0
+			user.UpdatedAt = time.Now().UTC()
+			if err := s.userRepo.Update(ctx, user); err != nil {
+				if reactivated {
+					s.seats.release(ctx, org.ID.Hex())
+				}
+				return nil, fmt.Errorf("failed to update user: %w", err)
+			}
+		}
+	}
+	return s.login.issue(ctx, user, models.LoginMethodLDAP, input.IPAddress, input.UserAgent)
+}
+
+// SyncOrganization syncs an organization's accounts from its directory. Sync
+// need not be scheduled for the organization; LDAP must be enabled.
+func (s *ldapService) SyncOrganization(ctx context.Context, orgID string) (*LDAPSyncResult, error) {
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	settings, err := s.settings(org)
+	if err != nil {
+		return nil, err
+	}
+	return s.sync(ctx, org, settings)
+}
+
+// SyncAll syncs every active organization that has LDAP and directory sync
+// enabled. A failing organization is logged and does not stop the others.
+func (s *ldapService) SyncAll(ctx context.Context) (int, error) {
+	synced := 0
+	for offset := 0; ; offset += lifecycleOrganizationPageSize {
+		orgs, err := s.orgRepo.GetActiveOrganizations(ctx, lifecycleOrganizationPageSize, offset)
+		if err != nil {
+			return synced, fmt.Errorf("failed to list active organizations: %w", err)
+		}
+
+		for _, org := range orgs {
+			settings, err := s.settings(org)
+			if err != nil || !settings.SyncEnabled {
+				continue
+			}
+			if _, err := s.sync(ctx, org, settings); err != nil {
+				s.logger.Error("Directory sync failed for organization",
+					zap.Error(err),
+					zap.String("organization_id", org.ID.Hex()),
+				)
+				continue
+			}
+			synced++
+		}
+
+		if len(orgs) < lifecycleOrganizationPageSize {
+			break
+		}
+	}
+	return synced, nil
+}
+
+// sync reads all user entries and brings the organization's accounts in line
+// with them. Entitled entries (enabled and with a role) get an account with
+// their profile, roles and manager; accounts of other entries, and synced
+// accounts whose entry is gone, are deactivated. A search without results
+// changes nothing, so a broken filter cannot deactivate everyone.
+func (s *ldapService) sync(ctx context.Context, org *models.Organization, settings *models.LDAPSettings) (*LDAPSyncResult, error) {
+	directory, err := s.connect(ctx, org, settings)
+	if err != nil {
+		return nil, err
+	}
+	defer directory.Close()
+
+	entries, err := directory.searchUsers(ctx, "", s.config.PageSize)
+	if err != nil {
+		return nil, s.unavailable(org, "user search failed", err)
+	}
+	if len(entries) == 0 {
+		return nil, ErrLDAPNoUsers
+	}
+	users, err := organizationUsers(ctx, s.userRepo, org.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	byEmail := make(map[string]*models.User, len(users))
+	byDN := make(map[string]*models.User, len(users))
+	for _, user := range users {
+		byEmail[strings.ToLower(user.Email)] = user
+		if user.Authentication.LDAPDN != "" {
+			byDN[ldap.NormalizeDN(user.Authentication.LDAPDN)] = user
+		}
+	}
+
+	type synced struct {
+		user    *models.User
+		account *ldapAccount
+	}
+	result := &LDAPSyncResult{}
+	seen := make(map[primitive.ObjectID]bool)
+	changed := make(map[primitive.ObjectID]bool)
+	entitled := make(map[string]*models.User)
+	var accounts []synced
+	for _, entry := range entries {
+		account := directory.account(entry)
+		if account.Email == "" {
+			result.Skipped++
+			continue
+		}
+		user := byDN[ldap.NormalizeDN(account.DN)]
+		if user == nil {
+			user = byEmail[account.Email]
+		}
+		isEntitled := !account.Disabled && len(account.Roles) > 0
+
+		switch {
+		case user == nil && !isEntitled:
+			continue
+		case user == nil:
+			created, err := s.createUser(ctx, org, account)
+			if errors.Is(err, repositories.ErrDuplicate) {
+				s.logger.Warn("Directory user's email belongs to another organization",
+					zap.String("organization_id", org.ID.Hex()),
+					zap.String("dn", account.DN),
+				)
+				result.Skipped++
+				continue
+			}
+			if errors.Is(err, ErrMemberLimitReached) {
+				result.Skipped++
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			user = created
+			result.Created++
+			changed[user.ID] = true
+		case !isEntitled:
+			seen[user.ID] = true
+			if user.IsActive {
+				if err := s.deactivate(ctx, user); err != nil {
+					return nil, err
+				}
+				result.Deactivated++
+			}
+			continue
+		case !user.IsActive && deactivatedByDirectory(user):
+			// Reactivated accounts need a free member seat
+			if err := s.seats.take(ctx, org.ID.Hex()); errors.Is(err, ErrMemberLimitReached) {
+				seen[user.ID] = true
+				result.Skipped++
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			applyLDAPAccount(user, account)
+			if err := s.updateUser(ctx, user); err != nil {
+				s.seats.release(ctx, org.ID.Hex())
+				return nil, err
+			}
+			result.Updated++
+			changed[user.ID] = true
+		case applyLDAPAccount(user, account):
+			if err := s.updateUser(ctx, user); err != nil {
+				return nil, err
+			}
+			result.Updated++
+			changed[user.ID] = true
+		}
+		seen[user.ID] = true
+		entitled[ldap.NormalizeDN(account.DN)] = user
+		accounts = append(accounts, synced{user: user, account: account})
+	}
+
+	for _, a := range accounts {
+		var managerID primitive.ObjectID
+		if manager := entitled[ldap.NormalizeDN(a.account.ManagerDN)]; manager != nil && a.account.ManagerDN != "" {
+			managerID = manager.ID
+		}
+		if a.user.Metadata.ManagerID == managerID {
+			continue
+		}
+		a.user.Metadata.ManagerID = managerID
+		if err := s.updateUser(ctx, a.user); err != nil {
+			return nil, err
+		}
+		if !changed[a.user.ID] {
+			result.Updated++
+			changed[a.user.ID] = true
+		}
+	}
+
+	for _, user := range users {
+		if user.Authentication.LDAPDN != "" && user.IsActive && !seen[user.ID] {
+			if err := s.deactivate(ctx, user); err != nil {
+				return nil, err
+			}
+			result.Deactivated++
+		}
+	}
+
+	s.logger.Info("Directory sync completed",
+		zap.String("organization_id", org.ID.Hex()),
+		zap.Int("entries", len(entries)),
+		zap.Int("created", result.Created),
+		zap.Int("updated", result.Updated),
+		zap.Int("deactivated", result.Deactivated),
+		zap.Int("skipped", result.Skipped),
+	)
+	return result, nil
+}
+
+// createUser creates the account of an entitled directory entry, taking one
+// of the organization's member seats.
+func (s *ldapService) createUser(ctx context.Context, org *models.Organization, account *ldapAccount) (*models.User, error) {
+	if err := s.seats.take(ctx, org.ID.Hex()); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	user := &models.User{
+		Email:          account.Email,
+		OrganizationID: org.ID,
+		IsActive:       true,
+		Status:         models.UserStatusActive,
+	}
+	applyLDAPAccount(user, account)
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		s.seats.release(ctx, org.ID.Hex())
+		if errors.Is(err, repositories.ErrDuplicate) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	s.logger.Info("Provisioned user from directory",
+		zap.String("organization_id", org.ID.Hex()),
+		zap.String("user_id", user.ID.Hex()),
+	)
+	return user, nil
+}
+
+// updateUser stores a changed account.
+func (s *ldapService) updateUser(ctx context.Context, user *models.User) error {
+	user.UpdatedAt = time.Now().UTC()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return nil
+}
+
+// deactivate marks an account inactive by the directory, releasing its
+// member seat, and terminates its sessions. A later sync or sign-in
+// reactivates it once its entry is entitled again.
+func (s *ldapService) deactivate(ctx context.Context, user *models.User) error {
+	user.IsActive = false
+	user.Status = models.UserStatusInactive
+	user.DeactivatedBy = models.UserDeactivatedByDirectory
+	if err := s.updateUser(ctx, user); err != nil {
+		return err
+	}
+	s.seats.release(ctx, user.OrganizationID.Hex())
+	if err := s.authService.TerminateAllSessions(ctx, user.ID.Hex()); err != nil {
+		return fmt.Errorf("failed to terminate sessions: %w", err)
+	}
+	s.logger.Info("Deactivated user from directory",
+		zap.String("organization_id", user.OrganizationID.Hex()),
+		zap.String("user_id", user.ID.Hex()),
+	)
+	return nil
+}
+
+// unavailable logs a directory failure and returns ErrLDAPUnavailable.
+func (s *ldapService) unavailable(org *models.Organization, message string, err error) error {
+	s.logger.Error("Directory "+message,
+		zap.Error(err),
+		zap.String("organization_id", org.ID.Hex()),
+		zap.String("server", org.Settings.Integrations.LDAPServer),
+	)
+	return ErrLDAPUnavailable
+}
+
+// settings returns an organization's directory settings. LDAP must be in the
+// organization's plan, which it shares with single sign-on, and enabled with
+// a server and base DN.
+func (s *ldapService) settings(org *models.Organization) (*models.LDAPSettings, error) {
+	integrations := org.Settings.Integrations
+	settings := integrations.LDAPSettings
+	if !org.IsActive || org.Status != models.OrganizationStatusActive || !org.FeatureFlags[ssoFeatureFlag] ||
+		!integrations.LDAP || integrations.LDAPServer == "" || settings == nil || settings.BaseDN == "" {
+		return nil, ErrLDAPDisabled
+	}
+	return settings, nil
+}
+
+// connect opens a connection to an organization's directory and binds as its
+// service account. Plain ldap:// connections must use StartTLS unless the
+// platform allows insecure directories.
+func (s *ldapService) connect(ctx context.Context, org *models.Organization, settings *models.LDAPSettings) (*ldapDirectory, error) {
+	server := org.Settings.Integrations.LDAPServer
+	if u, err := url.Parse(server); err == nil && strings.EqualFold(u.Scheme, "ldap") && !settings.StartTLS && !s.config.AllowInsecure {
+		s.logger.Warn("LDAP directory without TLS refused", zap.String("organization_id", org.ID.Hex()))
+		return nil, ErrLDAPDisabled
+	}
+	cfg := ldap.Config{URL: server, StartTLS: settings.StartTLS, Timeout: s.config.Timeout}
+	if settings.CACertificate != "" {
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM([]byte(settings.CACertificate)) {
+			s.logger.Warn("Invalid LDAP CA certificate", zap.String("organization_id", org.ID.Hex()))
+			return nil, ErrLDAPDisabled
+		}
+	}
+
+	conn, err := ldap.Dial(ctx, cfg)
+	if err != nil {
+		return nil, s.unavailable(org, "connection failed", err)
+	}
+	if err := conn.Bind(ctx, settings.BindDN, settings.BindPassword); err != nil {
+		conn.Close()
+		return nil, s.unavailable(org, "service account bind failed", err)
+	}
+	return &ldapDirectory{conn: conn, settings: settings, attributes: ldapAttributeNames(settings.AttributeMapping)}, nil
+}
+
+// ldapDirectory is a connection to an organization's directory.
+type ldapDirectory struct {
+	conn       *ldap.Conn
+	settings   *models.LDAPSettings
+	attributes models.LDAPAttributeMapping
+}
+
+// Close closes the connection.
+func (d *ldapDirectory) Close() {
+	d.conn.Close()
+}
+
+// searchUsers returns the user entries below the base DN that also match
+// filter, if one is given.
+func (d *ldapDirectory) searchUsers(ctx context.Context, filter string, pageSize int) ([]*ldap.Entry, error) {
+	userFilter := strings.TrimSpace(d.settings.UserFilter)
+	if userFilter == "" {
+		userFilter = defaultLDAPUserFilter
+	}
+	if !strings.HasPrefix(userFilter, "(") {
+		userFilter = "(" + userFilter + ")"
+	}
+	if filter != "" {
+		userFilter = "(&" + userFilter + filter + ")"
+	}
+
+	a := d.attributes
+	return d.conn.Search(ctx, &ldap.SearchRequest{
+		BaseDN: d.settings.BaseDN,
+		Scope:  ldap.ScopeWholeSubtree,
+		Filter: userFilter,
+		Attributes: []string{
+			a.Email, a.FirstName, a.LastName, a.Title, a.Department, a.PhoneNumber,
+			a.EmployeeID, a.Manager, a.Groups, adUserAccountControl,
+		},
+		PageSize: pageSize,
+	})
+}
+
+// ldapAccount is a user as described by their directory entry.
+type ldapAccount struct {
+	DN         string
+	Email      string
+	Profile    models.UserProfile
+	EmployeeID string
+	ManagerDN  string
+	// Roles are mapped from the entry's groups; empty when the user is not entitled
+	Roles    []string
+	Disabled bool
+}
+
+// account reads a directory entry.
+func (d *ldapDirectory) account(entry *ldap.Entry) *ldapAccount {
+	a := d.attributes
+	account := &ldapAccount{
+		DN:    entry.DN,
+		Email: strings.ToLower(strings.TrimSpace(entry.Value(a.Email))),
+		Profile: models.UserProfile{
+			FirstName:   entry.Value(a.FirstName),
+			LastName:    entry.Value(a.LastName),
+			Title:       entry.Value(a.Title),
+			Department:  entry.Value(a.Department),
+			PhoneNumber: entry.Value(a.PhoneNumber),
+		},
+		EmployeeID: entry.Value(a.EmployeeID),
+		ManagerDN:  entry.Value(a.Manager),
+	}
+	if flags, err := strconv.ParseInt(entry.Value(adUserAccountControl), 10, 64); err == nil {
+		account.Disabled = flags&adAccountDisabled != 0
+	}
+
+	var groups []string
+	for _, group := range entry.Values(a.Groups) {
+		groups = append(groups, ldap.NormalizeDN(group), ldap.RDNValue(group))
+	}
+	mappings := slices.Clone(d.settings.RoleMappings)
+	for i, mapping := range mappings {
+		if strings.Contains(mapping.Group, "=") {
+			mappings[i].Group = ldap.NormalizeDN(mapping.Group)
+		}
+	}
+	account.Roles = models.MapGroupRoles(mappings, groups)
+	if len(account.Roles) == 0 && d.settings.DefaultRole != "" {
+		account.Roles = []string{d.settings.DefaultRole}
+	}
+	return account
+}
+
+// applyLDAPAccount copies an entitled directory account onto a platform
+// account, reactivating it if the directory deactivated it, and reports
+// whether anything changed. Profile fields the directory leaves empty are kept.
+func applyLDAPAccount(user *models.User, account *ldapAccount) bool {
+	profile := mergeProfile(user.Profile, account.Profile)
+	employeeID := user.Metadata.EmployeeID
+	if account.EmployeeID != "" {
+		employeeID = account.EmployeeID
+	}
+	reactivate := !user.IsActive && deactivatedByDirectory(user)
+	if profile == user.Profile && employeeID == user.Metadata.EmployeeID && slices.Equal(user.Roles, account.Roles) &&
+		user.Authentication.LDAPDN == account.DN && user.Email == account.Email && !reactivate {
+		return false
+	}
+
+	user.Email = account.Email
+	user.Profile = profile
+	user.Metadata.EmployeeID = employeeID
+	user.Roles = account.Roles
+	user.Authentication.LDAPDN = account.DN
+	if reactivate {
+		user.IsActive = true
+		user.Status = models.UserStatusActive
+		user.DeactivatedBy = ""
+	}
+	return true
+}
+
+// deactivatedByDirectory reports whether directory sync, rather than an
+// administrator or SCIM, deactivated an account.
+func deactivatedByDirectory(user *models.User) bool {
+	return user.Status == models.UserStatusInactive && user.DeactivatedBy == models.UserDeactivatedByDirectory
+}
+
+// ldapAttributeNames fills in the Active Directory attribute names for the
+// fields an organization did not map.
+func ldapAttributeNames(mapping models.LDAPAttributeMapping) models.LDAPAttributeMapping {
+	for _, field := range []struct {
+		name     *string
+		fallback string
+	}{
+		{&mapping.Email, "mail"},
+		{&mapping.FirstName, "givenName"},
+		{&mapping.LastName, "sn"},
+		{&mapping.Title, "title"},
+		{&mapping.Department, "department"},
+		{&mapping.PhoneNumber, "telephoneNumber"},
+		{&mapping.EmployeeID, "employeeID"},
+		{&mapping.Manager, "manager"},
+		{&mapping.Groups, "memberOf"},
+	} {
+		if *field.name == "" {
+			*field.name = field.fallback
+		}
+	}
+	return mapping
+}
@@ -0,0 +1,293 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/auth"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/cache/cachetest"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/ldap/ldaptest"
+)
+
+const (
+	ldapBaseDN    = "ou=people,dc=first-bank,dc=com"
+	ldapAuditors  = "CN=GRC-Auditors,OU=Groups,DC=first-bank,DC=com"
+	ldapAdmins    = "CN=GRC-Admins,OU=Groups,DC=first-bank,DC=com"
+	ldapAllStaff  = "CN=All-Staff,OU=Groups,DC=first-bank,DC=com"
+	ldapAdaDN     = "cn=Ada Auditor," + ldapBaseDN
+	ldapManagerDN = "cn=Grace Admin," + ldapBaseDN
+)
+
+type ldapFixture struct {
+	directory *ldaptest.Server
+	org       *models.Organization
+	orgs      *fakeOrganizationRepository
+	users     *fakeUserRepository
+	sessions  *fakeSessionRepository
+	auth      *fakeAuthenticationService
+	service   LDAPService
+}
+
+func newLDAPFixture(t *testing.T) *ldapFixture {
+	t.Helper()
+
+	directory, err := ldaptest.NewServer()
+	require.NoError(t, err)
+	t.Cleanup(func() { directory.Close() })
+	directory.AddEntry("cn=svc-goedu,dc=first-bank,dc=com", "service-secret", nil)
+	directory.AddEntry(ldapAdaDN, "ada-secret", map[string][]string{
+		"objectClass":        {"person"},
+		"mail":               {"Ada.Auditor@First-Bank.com"},
+		"givenName":          {"Ada"},
+		"sn":                 {"Auditor"},
+		"department":         {"Internal Audit"},
+		"employeeID":         {"1017"},
+		"manager":            {ldapManagerDN},
+		"memberOf":           {ldapAllStaff, ldapAuditors},
+		"userAccountControl": {"512"},
+	})
+	directory.AddEntry(ldapManagerDN, "grace-secret", map[string][]string{
+		"objectClass": {"person"},
+		"mail":        {"grace.admin@first-bank.com"},
+		"givenName":   {"Grace"},
+		"sn":          {"Admin"},
+		"memberOf":    {ldapAdmins},
+	})
+	directory.AddEntry("cn=Eve Outsider,"+ldapBaseDN, "eve-secret", map[string][]string{
+		"objectClass": {"person"},
+		"mail":        {"eve@first-bank.com"},
+		"memberOf":    {ldapAllStaff},
+	})
+
+	org := &models.Organization{Name: "First Bank", Slug: "first-bank", Status: models.OrganizationStatusActive, IsActive: true}
+	org.ID = primitive.NewObjectID()
+	org.FeatureFlags = map[string]bool{"sso_integration": true}
+	org.Settings.Integrations.LDAP = true
+	org.Settings.Integrations.LDAPServer = directory.URL()
+	org.Settings.Integrations.LDAPSettings = &models.LDAPSettings{
+		StartTLS:      true,
+		CACertificate: directory.CertificatePEM(),
+		BindDN:        "cn=svc-goedu,dc=first-bank,dc=com",
+		BindPassword:  "service-secret",
+		BaseDN:        ldapBaseDN,
+		RoleMappings: []models.GroupRoleMapping{
+			{Group: ldapAdmins, Role: models.RoleAdmin},
+			{Group: "grc-auditors", Role: models.RoleAuditor},
+		},
+		SyncEnabled: true,
+	}
+
+	f := &ldapFixture{
+		directory: directory,
+		org:       org,
+		orgs:      newFakeOrganizationRepository(org),
+		users:     newFakeUserRepository(),
+		sessions:  &fakeSessionRepository{},
+		auth:      &fakeAuthenticationService{},
+	}
+	f.service = NewLDAPService(
+		f.orgs, f.users, f.sessions, cachetest.NewDenylist(),
+		auth.NewJWTManager([]byte("test-secret"), "goedu-platform", "goedu-api"), f.auth,
+		config.LDAPConfig{Timeout: 5 * time.Second, PageSize: 2}, testSessionConfig, zap.NewNop(),
+	)
+	return f
+}
+
+func (f *ldapFixture) login(email, password string) (*models.LoginResponse, error) {
+	return f.service.Login(context.Background(), &LDAPLoginInput{
+		OrganizationSlug: "first-bank",
+		Email:            email,
+		Password:         password,
+		IPAddress:        "10.0.0.1",
+		UserAgent:        "test",
+	})
+}
+
+func TestLDAPService_Login(t *testing.T) {
+	f := newLDAPFixture(t)
+
+	response, err := f.login("ada.auditor@first-bank.com", "ada-secret")
+	require.NoError(t, err)
+	assert.NotEmpty(t, response.AccessToken)
+
+	// The account is created from the entry with the roles of the mapped groups
+	user, err := f.users.GetByEmail(context.Background(), "ada.auditor@first-bank.com")
+	require.NoError(t, err)
+	assert.Equal(t, "ada.auditor@first-bank.com", user.Email)
+	assert.Equal(t, f.org.ID, user.OrganizationID)
+	assert.Equal(t, []string{models.RoleAuditor}, user.Roles)
+	assert.Equal(t, "Internal Audit", user.Profile.Department)
+	assert.Equal(t, ldapAdaDN, user.Authentication.LDAPDN)
+	require.Len(t, f.sessions.sessions, 1)
+	assert.Equal(t, models.LoginMethodLDAP, f.sessions.sessions[0].LoginMethod)
+
+	// The user's own entry was bound to check the password
+	assert.Contains(t, f.directory.Binds(), ldapAdaDN)
+
+	// Users outside the mapped groups may not sign in
+	_, err = f.login("eve@first-bank.com", "eve-secret")
+	assert.ErrorIs(t, err, ErrLDAPAccessDenied)
+
+	// Unknown users and wrong passwords are indistinguishable
+	_, err = f.login("nobody@first-bank.com", "secret")
+	assert.ErrorIs(t, err, ErrLDAPInvalidCredentials)
+	_, err = f.login("ada.auditor@first-bank.com", "wrong")
+	assert.ErrorIs(t, err, ErrLDAPInvalidCredentials)
+
+	// Filter characters in the email cannot widen the search
+	_, err = f.login("*", "ada-secret")
+	assert.ErrorIs(t, err, ErrLDAPInvalidCredentials)
+}
+
+func TestLDAPService_LoginLockout(t *testing.T) {
+	f := newLDAPFixture(t)
+	_, err := f.login("ada.auditor@first-bank.com", "ada-secret")
+	require.NoError(t, err)
+
+	for i := 0; i < maxFailedLogins; i++ {
+		_, err = f.login("ada.auditor@first-bank.com", "wrong")
+		assert.ErrorIs(t, err, ErrLDAPInvalidCredentials)
+	}
+	_, err = f.login("ada.auditor@first-bank.com", "ada-secret")
+	assert.ErrorIs(t, err, ErrAccountLocked)
+}
+
+func TestLDAPService_Disabled(t *testing.T) {
+	f := newLDAPFixture(t)
+
+	f.org.Settings.Integrations.LDAP = false
+	_, err := f.login("ada.auditor@first-bank.com", "ada-secret")
+	assert.ErrorIs(t, err, ErrLDAPDisabled)
+
+	// Plain connections are refused unless insecure directories are allowed
+	f.org.Settings.Integrations.LDAP = true
+	f.org.Settings.Integrations.LDAPSettings.StartTLS = false
+	_, err = f.login("ada.auditor@first-bank.com", "ada-secret")
+	assert.ErrorIs(t, err, ErrLDAPDisabled)
+
+	f.org.Settings.Integrations.LDAPSettings.StartTLS = true
+	f.org.Settings.Integrations.LDAPSettings.BindPassword = "wrong"
+	_, err = f.login("ada.auditor@first-bank.com", "ada-secret")
+	assert.ErrorIs(t, err, ErrLDAPUnavailable)
+}
+
+func TestLDAPService_Sync(t *testing.T) {
+	f := newLDAPFixture(t)
+	ctx := context.Background()
+	orgID := f.org.ID.Hex()
+
+	result, err := f.service.SyncOrganization(ctx, orgID)
+	require.NoError(t, err)
+	assert.Equal(t, &LDAPSyncResult{Created: 2}, result)
+
+	ada, err := f.users.GetByEmail(ctx, "ada.auditor@first-bank.com")
+	require.NoError(t, err)
+	grace, err := f.users.GetByEmail(ctx, "grace.admin@first-bank.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{models.RoleAdmin}, grace.Roles)
+	assert.Equal(t, grace.ID, ada.Metadata.ManagerID)
+	assert.Equal(t, "1017", ada.Metadata.EmployeeID)
+	_, err = f.users.GetByEmail(ctx, "eve@first-bank.com")
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+
+	// A second run finds nothing to change
+	result, err = f.service.SyncOrganization(ctx, orgID)
+	require.NoError(t, err)
+	assert.Equal(t, &LDAPSyncResult{}, result)
+
+	// Disabled accounts and removed entries are deactivated with their sessions
+	f.directory.AddEntry(ldapAdaDN, "ada-secret", map[string][]string{
+		"objectClass":        {"person"},
+		"mail":               {"ada.auditor@first-bank.com"},
+		"memberOf":           {ldapAuditors},
+		"userAccountControl": {"514"},
+	})
+	f.directory.RemoveEntry(ldapManagerDN)
+	result, err = f.service.SyncOrganization(ctx, orgID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Deactivated)
+	assert.False(t, ada.IsActive)
+	assert.Equal(t, models.UserStatusInactive, grace.Status)
+	assert.ElementsMatch(t, []string{ada.ID.Hex(), grace.ID.Hex()}, f.auth.terminated)
+
+	// Accounts the directory deactivated are reactivated once entitled again
+	f.directory.AddEntry(ldapManagerDN, "grace-secret", map[string][]string{
+		"objectClass": {"person"},
+		"mail":        {"grace.admin@first-bank.com"},
+		"memberOf":    {ldapAdmins},
+	})
+	_, err = f.service.SyncOrganization(ctx, orgID)
+	require.NoError(t, err)
+	assert.True(t, grace.IsActive)
+	assert.Empty(t, grace.DeactivatedBy)
+
+	// An empty result leaves accounts alone
+	f.org.Settings.Integrations.LDAPSettings.UserFilter = "(objectClass=nothing)"
+	_, err = f.service.SyncOrganization(ctx, orgID)
+	assert.ErrorIs(t, err, ErrLDAPNoUsers)
+}
+
+func TestLDAPService_KeepsAdministratorDeactivations(t *testing.T) {
+	f := newLDAPFixture(t)
+	ctx := context.Background()
+	orgID := f.org.ID.Hex()
+	_, err := f.service.SyncOrganization(ctx, orgID)
+	require.NoError(t, err)
+	ada, err := f.users.GetByEmail(ctx, "ada.auditor@first-bank.com")
+	require.NoError(t, err)
+	grace, err := f.users.GetByEmail(ctx, "grace.admin@first-bank.com")
+	require.NoError(t, err)
+
+	users := NewUserService(f.orgs, f.users, newFakeInvitationRepository(), NewOrganizationService(f.orgs, nil, nil, nil, nil, zap.NewNop()),
+		f.auth, newFakeNotificationService(), auth.NewPasswordHasher(4), nil, config.InvitationConfig{}, zap.NewNop())
+	require.NoError(t, users.DeactivateUser(ctx, ada.ID.Hex()))
+
+	// Sync and sign-in leave an administrator's deactivation in place
+	result, err := f.service.SyncOrganization(ctx, orgID)
+	require.NoError(t, err)
+	assert.Zero(t, result.Updated)
+	_, err = f.login("ada.auditor@first-bank.com", "ada-secret")
+	assert.ErrorIs(t, err, ErrLDAPAccessDenied)
+	assert.False(t, ada.IsActive)
+	assert.Equal(t, models.UserStatusInactive, ada.Status)
+
+	// Accounts the directory deactivated come back with their entry
+	f.directory.RemoveEntry(ldapManagerDN)
+	_, err = f.service.SyncOrganization(ctx, orgID)
+	require.NoError(t, err)
+	assert.Equal(t, models.UserDeactivatedByDirectory, grace.DeactivatedBy)
+
+	// An administrator deactivating them takes over
+	require.NoError(t, users.DeactivateUser(ctx, grace.ID.Hex()))
+	assert.Empty(t, grace.DeactivatedBy)
+	f.directory.AddEntry(ldapManagerDN, "grace-secret", map[string][]string{
+		"objectClass": {"person"},
+		"mail":        {"grace.admin@first-bank.com"},
+		"memberOf":    {ldapAdmins},
+	})
+	_, err = f.login("grace.admin@first-bank.com", "grace-secret")
+	assert.ErrorIs(t, err, ErrLDAPAccessDenied)
+	assert.False(t, grace.IsActive)
+}
+
+func TestLDAPService_SyncAll(t *testing.T) {
+	f := newLDAPFixture(t)
+
+	synced, err := f.service.SyncAll(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, synced)
+
+	f.org.Settings.Integrations.LDAPSettings.SyncEnabled = false
+	synced, err = f.service.SyncAll(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, synced)
+}
@@ -0,0 +1,125 @@
+// Package services provides service layer implementations for the GoEdu Control Testing Platform.
+// This file contains the steps shared by every sign-in method: starting a
+// session for the authenticated user and issuing the platform's tokens, and
+// locking accounts after repeated failed sign-ins.
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/auth"
+)
+
+// sessionIDBytes is the number of random bytes in a session ID.
+const sessionIDBytes = 16
+
+// maxFailedLogins consecutive failed sign-ins lock an account for loginLockoutDuration
+const (
+	maxFailedLogins      = 5
+	loginLockoutDuration = 15 * time.Minute
+)
+
+// loginIssuer starts sessions and issues access and refresh tokens.
+type loginIssuer struct {
+	userRepo   repositories.UserRepository
+	sessions   *sessionManager
+	jwtManager *auth.JWTManager
+	logger     *zap.Logger
+}
+
+// issue starts a session for a user who has been authenticated with the given
+// login method and returns the tokens for it. Only the hash of the refresh
+// token is stored with the session, which is subject to the organization's
+// session timeouts and cap on concurrent sessions.
+func (l *loginIssuer) issue(ctx context.Context, user *models.User, loginMethod, ipAddress, userAgent string) (*models.LoginResponse, error) {
+	raw := make([]byte, sessionIDBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate session ID: %w", err)
+	}
+	sessionID := hex.EncodeToString(raw)
+
+	profile := user.ToUserProfileResponse()
+	accessToken, expiresAt, err := l.jwtManager.GenerateAccessToken(profile, sessionID, ipAddress)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, _, err := l.jwtManager.GenerateRefreshToken(user.ID.Hex(), sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	refreshHash := sha256.Sum256([]byte(refreshToken))
+	session := &models.Session{
+		SessionID:      sessionID,
+		UserID:         user.ID,
+		OrganizationID: user.OrganizationID,
+		IPAddress:      ipAddress,
+		UserAgent:      userAgent,
+		RefreshToken:   hex.EncodeToString(refreshHash[:]),
+		LastActivity:   now,
+		IsActive:       true,
+		LoginMethod:    loginMethod,
+	}
+	session.CreatedAt = now
+	session.UpdatedAt = now
+	if err := l.sessions.start(ctx, session); err != nil {
+		return nil, err
+	}
+
+	if err := l.userRepo.UpdateLastLogin(ctx, user.ID.Hex()); err != nil {
+		l.logger.Warn("Failed to record last login",
+			zap.Error(err),
+			zap.String("user_id", user.ID.Hex()),
+		)
+	}
+
+	l.logger.Info("User signed in",
+		zap.String("user_id", user.ID.Hex()),
+		zap.String("organization_id", user.OrganizationID.Hex()),
+		zap.String("login_method", loginMethod),
+		zap.String("session_id", sessionID),
+	)
+	return &models.LoginResponse{
+		Success:      true,
+		User:         profile,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresAt:    expiresAt,
+		SessionID:    sessionID,
+	}, nil
+}
+
+// recordFailedLogin counts a failed sign-in of a known account and locks it
+// after maxFailedLogins consecutive failures.
+func recordFailedLogin(ctx context.Context, userRepo repositories.UserRepository, logger *zap.Logger, user *models.User, ipAddress string) {
+	if user == nil {
+		return
+	}
+	userID := user.ID.Hex()
+	attempts := user.Authentication.FailedLoginAttempts + 1
+	if err := userRepo.IncrementFailedLogins(ctx, userID); err != nil {
+		logger.Warn("Failed to record failed sign-in", zap.Error(err), zap.String("user_id", userID))
+		return
+	}
+	if attempts < maxFailedLogins {
+		return
+	}
+	if err := userRepo.LockUser(ctx, userID, time.Now().UTC().Add(loginLockoutDuration)); err != nil {
+		logger.Warn("Failed to lock account", zap.Error(err), zap.String("user_id", userID))
+		return
+	}
+	logger.Warn("Account locked after failed sign-ins",
+		zap.String("user_id", userID),
+		zap.String("ip_address", ipAddress),
+	)
+}
@@ -0,0 +1,52 @@
+// Package services provides service layer implementations for the GoEdu Control Testing Platform.
+// This file contains the member seat accounting shared by every path that
+// creates, deactivates or reactivates users, which keeps an organization's
+// member count equal to its number of active users and enforces its limit.
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+)
+
+// ErrMemberLimitReached is returned when an organization has as many active
+// members as its subscription allows.
+var ErrMemberLimitReached = errors.New("organization has reached its member limit")
+
+// memberSeats takes a seat before a user becomes active and releases it when
+// the user is deactivated. Taking a seat is atomic, so concurrent sign-ups
+// cannot exceed the organization's MaxMembers.
+type memberSeats struct {
+	orgRepo repositories.OrganizationRepository
+	logger  *zap.Logger
+}
+
+// take takes a seat for a user about to become active.
+func (m *memberSeats) take(ctx context.Context, orgID string) error {
+	ok, err := m.orgRepo.AdjustMemberCount(ctx, orgID, 1)
+	if err != nil {
+		return fmt.Errorf("failed to update member count: %w", err)
+	}
+	if !ok {
+		m.logger.Info("Member limit reached", zap.String("organization_id", orgID))
+		return ErrMemberLimitReached
+	}
+	return nil
+}
+
+// release frees the seat of a deactivated user, or of a user whose creation
+// failed after the seat was taken. Failures are logged; an organization can
+// have its count corrected with UpdateMemberCount.
+func (m *memberSeats) release(ctx context.Context, orgID string) {
+	if _, err := m.orgRepo.AdjustMemberCount(ctx, orgID, -1); err != nil {
+		m.logger.Error("Failed to release member seat",
+			zap.Error(err),
+			zap.String("organization_id", orgID),
+		)
+	}
+}
@@ -0,0 +1,921 @@
+// Package services provides service layer implementations for the GoEdu Control Testing Platform.
+// This file contains the notification service which resolves recipients and their
+// preferences, renders notification emails and queues them in the outbox for
+// delivery, or holds non-urgent ones back for the recipient's digest. Users who
+// receive in-app notifications also get them in their inbox.
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+)
+
+// ErrNoNotificationRecipients is returned for system alerts without recipients or organization.
+var ErrNoNotificationRecipients = errors.New("notification has no recipients")
+
+// mentionExcerptLength is the maximum number of characters of a comment quoted in mention emails.
+const mentionExcerptLength = 280
+
+// notificationDateLayout formats dates in notification emails.
+const notificationDateLayout = "2 January 2006"
+
+// notificationService implements the NotificationService interface.
+type notificationService struct {
+	orgRepo    repositories.OrganizationRepository
+	userRepo   repositories.UserRepository
+	outboxRepo repositories.NotificationOutboxRepository
+	digestRepo repositories.NotificationDigestRepository
+	inbox      NotificationInboxService
+	renderer   *notificationRenderer
+	appURL     string
+	digestHour int
+	batchSize  int
+	logger     *zap.Logger
+}
+
+// NewNotificationService creates a new notification service. Urgent
+// notifications are rendered immediately and written to the outbox, deferred
+// past the recipient's quiet hours; a NotificationDispatcher delivers them.
+// Non-urgent notifications are held back for the recipient's digest unless the
+// recipient receives notifications immediately. Recipients with in-app
+// notifications enabled also get every notification in their inbox right away.
+//
+// Parameters:
+//   - orgRepo: Repository for organization data operations
+//   - userRepo: Repository for recipients and their preferences
+//   - templateRepo: Repository for per-organization template overrides
+//   - outboxRepo: Repository for queued notification emails
+//   - digestRepo: Repository for notifications held back for digests
+//   - inbox: Inbox service for in-app notifications
+//   - cfg: Email configuration providing the application URL and digest hour
+//   - logger: Logger for service operations
+//
+// Returns:
+//   - NotificationService: Configured notification service instance
+//
+// Example:
+//
+//	notificationService := services.NewNotificationService(orgRepo, userRepo, templateRepo, outboxRepo, digestRepo, inboxService, cfg.Email, logger)
+func NewNotificationService(
+	orgRepo repositories.OrganizationRepository,
+	userRepo repositories.UserRepository,
+	templateRepo repositories.NotificationTemplateRepository,
+	outboxRepo repositories.NotificationOutboxRepository,
+	digestRepo repositories.NotificationDigestRepository,
+	inbox NotificationInboxService,
+	cfg config.EmailConfig,
+	logger *zap.Logger,
+) NotificationService {
+	service := newNotificationService(orgRepo, userRepo, templateRepo, outboxRepo, digestRepo, cfg, logger)
+	service.inbox = inbox
+	return service
+}
+
+// newNotificationService creates the service behind both NotificationService
+// and NotificationDigester. The inbox is only set for NotificationService, as
+// digests are sent by email only.
+func newNotificationService(
+	orgRepo repositories.OrganizationRepository,
+	userRepo repositories.UserRepository,
+	templateRepo repositories.NotificationTemplateRepository,
+	outboxRepo repositories.NotificationOutboxRepository,
+	digestRepo repositories.NotificationDigestRepository,
+	cfg config.EmailConfig,
+	logger *zap.Logger,
+) *notificationService {
+	batchSize := cfg.OutboxBatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	return &notificationService{
+		orgRepo:    orgRepo,
+		userRepo:   userRepo,
+		outboxRepo: outboxRepo,
+		digestRepo: digestRepo,
+		renderer:   &notificationRenderer{templateRepo: templateRepo, logger: logger},
+		appURL:     strings.TrimRight(cfg.AppURL, "/"),
+		digestHour: cfg.DigestHour,
+		batchSize:  batchSize,
+		logger:     logger,
+	}
+}
+
+// notificationRecipient is a resolved email recipient. User is nil for plain
+// addresses, which are not subject to user preferences.
+type notificationRecipient struct {
+	User    *models.User
+	Address string
+	Name    string
+}
+
+// notification describes one notification to render for a set of recipients.
+type notification struct {
+	Type string
+	Org  *models.Organization
+	Link string
+	Data map[string]interface{}
+
+	// Urgent notifications skip digests and quiet hours
+	Urgent bool
+
+	// Wants reports whether the recipient's preferences allow this notification type
+	Wants func(prefs *NotificationPreferences) bool
+}
+
+// SendEvidenceRequest notifies the assignee of a new evidence request.
+//
+// Parameters:
+//   - ctx: Request context
+//   - request: Newly created evidence request
+//
+// Returns:
+//   - error: Error if the recipient cannot be resolved or the email cannot be queued
+func (s *notificationService) SendEvidenceRequest(ctx context.Context, request *models.EvidenceRequest) error {
+	return s.sendToUser(ctx, request.OrganizationID, request.AssigneeID, &notification{
+		Type: models.NotificationTypeEvidenceRequest,
+		Link: s.resourceLink(models.ResourceTypeEvidenceRequest, request.ID.Hex()),
+		Data: map[string]interface{}{
+			"RequestID":    request.RequestID,
+			"Title":        request.Title,
+			"Instructions": request.Instructions,
+			"DueDate":      formatNotificationDate(request.DueDate),
+		},
+		Wants: func(prefs *NotificationPreferences) bool { return prefs.EvidenceRequest },
+	})
+}
+
+// SendReminderNotification reminds the assignee of an upcoming or overdue evidence request.
+//
+// Parameters:
+//   - ctx: Request context
+//   - request: Open evidence request
+//
+// Returns:
+//   - error: Error if the recipient cannot be resolved or the email cannot be queued
+func (s *notificationService) SendReminderNotification(ctx context.Context, request *models.EvidenceRequest) error {
+	return s.sendToUser(ctx, request.OrganizationID, request.AssigneeID, &notification{
+		Type: models.NotificationTypeReminder,
+		Link: s.resourceLink(models.ResourceTypeEvidenceRequest, request.ID.Hex()),
+		Data: map[string]interface{}{
+			"RequestID": request.RequestID,
+			"Title":     request.Title,
+			"DueDate":   formatNotificationDate(request.DueDate),
+			"Overdue":   request.Status == models.EvidenceRequestStatusOverdue || time.Now().After(request.DueDate),
+		},
+		Wants: func(prefs *NotificationPreferences) bool { return prefs.Reminders },
+	})
+}
+
+// SendEscalationNotification notifies the assignee's manager about an overdue request.
+//
+// Parameters:
+//   - ctx: Request context
+//   - request: Overdue evidence request
+//   - manager: Manager of the assignee
+//
+// Returns:
+//   - error: Error if the email cannot be queued
+func (s *notificationService) SendEscalationNotification(ctx context.Context, request *models.EvidenceRequest, manager *models.User) error {
+	assigneeName := "the assignee"
+	if assignee, err := s.userRepo.GetByID(ctx, request.AssigneeID.Hex()); err == nil {
+		assigneeName = assignee.Profile.GetFullName()
+	}
+
+	org, err := s.loadOrganization(ctx, request.OrganizationID)
+	if err != nil {
+		return err
+	}
+	return s.send(ctx, org, []*notificationRecipient{userRecipient(manager)}, &notification{
+		Type: models.NotificationTypeEscalation,
+		Link: s.resourceLink(models.ResourceTypeEvidenceRequest, request.ID.Hex()),
+		Data: map[string]interface{}{
+			"RequestID":    request.RequestID,
+			"Title":        request.Title,
+			"DueDate":      formatNotificationDate(request.DueDate),
+			"AssigneeName": assigneeName,
+		},
+		Wants: func(prefs *NotificationPreferences) bool { return prefs.Reminders },
+	})
+}
+
+// SendReviewNotification notifies participants about review workflow events.
+// Review requests go to the assigned reviewers; approvals and rejections go to
+// the assignee.
+//
+// Parameters:
+//   - ctx: Request context
+//   - request: Evidence request under review
+//   - eventType: One of ReviewEventRequested, ReviewEventApproved or ReviewEventRejected
+//
+// Returns:
+//   - error: ErrInvalidInput for unknown events, or an error if emails cannot be queued
+func (s *notificationService) SendReviewNotification(ctx context.Context, request *models.EvidenceRequest, eventType string) error {
+	n := &notification{
+		Link: s.resourceLink(models.ResourceTypeEvidenceRequest, request.ID.Hex()),
+		Data: map[string]interface{}{
+			"RequestID": request.RequestID,
+			"Title":     request.Title,
+		},
+		Wants: func(prefs *NotificationPreferences) bool { return prefs.EvidenceRequest },
+	}
+	recipientIDs := []primitive.ObjectID{request.AssigneeID}
+
+	switch eventType {
+	case ReviewEventRequested:
+		n.Type = models.NotificationTypeReviewRequested
+		n.Data["Round"] = request.ReviewRound
+		recipientIDs = request.ReviewerIDs
+	case ReviewEventApproved:
+		n.Type = models.NotificationTypeReviewApproved
+	case ReviewEventRejected:
+		n.Type = models.NotificationTypeReviewRejected
+		n.Data["Comment"] = latestRejectionComment(request)
+	default:
+		return fmt.Errorf("%w: unknown review event %q", ErrInvalidInput, eventType)
+	}
+
+	org, err := s.loadOrganization(ctx, request.OrganizationID)
+	if err != nil {
+		return err
+	}
+	recipients, err := s.userRecipients(ctx, recipientIDs)
+	if err != nil {
+		return err
+	}
+	return s.send(ctx, org, recipients, n)
+}
+
+// SendMentionNotification notifies a user mentioned in a comment.
+//
+// Parameters:
+//   - ctx: Request context
+//   - comment: Comment containing the mention
+//   - mentioned: Mentioned user
+//
+// Returns:
+//   - error: Error if the email cannot be queued
+func (s *notificationService) SendMentionNotification(ctx context.Context, comment *models.Comment, mentioned *models.User) error {
+	authorName := "Someone"
+	if comment.AuthorID == models.SystemActorID {
+		authorName = "GoEdu"
+	} else if author, err := s.userRepo.GetByID(ctx, comment.AuthorID); err == nil {
+		authorName = author.Profile.GetFullName()
+	}
+
+	orgID := comment.OrganizationID
+	if orgID.IsZero() {
+		orgID = mentioned.OrganizationID
+	}
+	org, err := s.loadOrganization(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	return s.send(ctx, org, []*notificationRecipient{userRecipient(mentioned)}, &notification{
+		Type: models.NotificationTypeMention,
+		Link: s.resourceLink(comment.ResourceType, comment.ResourceID),
+		Data: map[string]interface{}{
+			"AuthorName":   authorName,
+			"Excerpt":      truncateRunes(comment.Content, mentionExcerptLength),
+			"ResourceType": comment.ResourceType,
+		},
+		Wants: func(prefs *NotificationPreferences) bool { return prefs.EvidenceRequest },
+	})
+}
+
+// SendTestingCycleNotification notifies the organization's administrators and
+// managers about a testing cycle event, e.g. "started" or "completed".
+//
+// Parameters:
+//   - ctx: Request context
+//   - cycle: Testing cycle
+//   - eventType: Event name shown in the email
+//
+// Returns:
+//   - error: Error if recipients cannot be resolved or emails cannot be queued
+func (s *notificationService) SendTestingCycleNotification(ctx context.Context, cycle *models.TestingCycle, eventType string) error {
+	org, err := s.loadOrganization(ctx, cycle.OrganizationID)
+	if err != nil {
+		return err
+	}
+	recipients, err := s.roleRecipients(ctx, org.ID.Hex(), models.RoleAdmin, models.RoleManager)
+	if err != nil {
+		return err
+	}
+	return s.send(ctx, org, recipients, &notification{
+		Type: models.NotificationTypeTestingCycle,
+		Link: s.resourceLink(models.ResourceTypeTestingCycle, cycle.ID.Hex()),
+		Data: map[string]interface{}{
+			"CycleName":       cycle.Name,
+			"Event":           strings.ReplaceAll(eventType, "_", " "),
+			"StartDate":       formatNotificationDate(cycle.StartDate),
+			"EndDate":         formatNotificationDate(cycle.EndDate),
+			"PercentComplete": cycle.Progress.PercentComplete,
+		},
+		Wants: func(prefs *NotificationPreferences) bool { return true },
+	})
+}
+
+// SendSystemAlert sends a system alert. Recipients are user IDs or email
+// addresses; without recipients, the alert goes to the administrators of the
+// organization named in Metadata["organization_id"]. Critical alerts are sent
+// immediately, even during the recipients' quiet hours.
+//
+// Parameters:
+//   - ctx: Request context
+//   - alert: Alert to send
+//
+// Returns:
+//   - error: ErrNoNotificationRecipients, or an error if emails cannot be queued
+func (s *notificationService) SendSystemAlert(ctx context.Context, alert *SystemAlert) error {
+	var org *models.Organization
+	if orgID, _ := alert.Metadata["organization_id"].(string); orgID != "" {
+		id, err := primitive.ObjectIDFromHex(orgID)
+		if err != nil {
+			return ErrInvalidInput
+		}
+		if org, err = s.loadOrganization(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	var recipients []*notificationRecipient
+	var userIDs []primitive.ObjectID
+	for _, recipient := range alert.Recipients {
+		if strings.Contains(recipient, "@") {
+			recipients = append(recipients, &notificationRecipient{Address: recipient, Name: recipient})
+			continue
+		}
+		id, err := primitive.ObjectIDFromHex(recipient)
+		if err != nil {
+			return ErrInvalidInput
+		}
+		userIDs = append(userIDs, id)
+	}
+	users, err := s.userRecipients(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+	recipients = append(recipients, users...)
+
+	if len(alert.Recipients) == 0 {
+		if org == nil {
+			return ErrNoNotificationRecipients
+		}
+		if recipients, err = s.roleRecipients(ctx, org.ID.Hex(), models.RoleAdmin); err != nil {
+			return err
+		}
+	}
+
+	n := &notification{
+		Type: models.NotificationTypeSystemAlert,
+		Link: s.appURL + "/admin/alerts",
+		Data: map[string]interface{}{
+			"Severity": alert.Severity,
+			"Title":    alert.Title,
+			"Message":  alert.Message,
+		},
+		Urgent: alert.Severity == models.AlertSeverityCritical,
+		Wants:  func(prefs *NotificationPreferences) bool { return prefs.SystemAlerts },
+	}
+	if org != nil {
+		return s.send(ctx, org, recipients, n)
+	}
+
+	// Without an organization context each user recipient gets the alert in the
+	// name of their own organization
+	var errs []error
+	var orgIDs []primitive.ObjectID
+	byOrg := make(map[primitive.ObjectID][]*notificationRecipient)
+	for _, recipient := range recipients {
+		var orgID primitive.ObjectID
+		if recipient.User != nil {
+			orgID = recipient.User.OrganizationID
+		}
+		if _, ok := byOrg[orgID]; !ok {
+			orgIDs = append(orgIDs, orgID)
+		}
+		byOrg[orgID] = append(byOrg[orgID], recipient)
+	}
+	for _, orgID := range orgIDs {
+		recipientOrg := &models.Organization{Name: "GoEdu"}
+		if !orgID.IsZero() {
+			if recipientOrg, err = s.loadOrganization(ctx, orgID); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		errs = append(errs, s.send(ctx, recipientOrg, byOrg[orgID], n))
+	}
+	return errors.Join(errs...)
+}
+
+// SendInvitation emails an invitation link to the invited address. The
+// address has no account yet, so the email is queued immediately if the
+// organization sends email at all.
+//
+// Parameters:
+//   - ctx: Request context
+//   - invitation: Invitation to send
+//   - token: Signed token the link carries
+//
+// Returns:
+//   - error: Error if the email cannot be queued
+func (s *notificationService) SendInvitation(ctx context.Context, invitation *models.Invitation, token string) error {
+	inviterName := "An administrator"
+	if inviter, err := s.userRepo.GetByID(ctx, invitation.InvitedBy.Hex()); err == nil {
+		inviterName = inviter.Profile.GetFullName()
+	}
+	org, err := s.loadOrganization(ctx, invitation.OrganizationID)
+	if err != nil {
+		return err
+	}
+	recipient := &notificationRecipient{Address: invitation.Email, Name: invitation.Email}
+	return s.send(ctx, org, []*notificationRecipient{recipient}, &notification{
+		Type: models.NotificationTypeInvitation,
+		Link: s.appURL + "/invitations/accept?token=" + url.QueryEscape(token),
+		Data: map[string]interface{}{
+			"InviterName": inviterName,
+			"Role":        invitation.Role,
+			"ExpiresAt":   formatNotificationDate(invitation.ExpiresAt),
+		},
+		Urgent: true,
+	})
+}
+
+// SendPasswordReset emails a password reset link to a user. The email is
+// queued immediately, bypassing the user's notification preferences, if the
+// organization sends email at all; the link never appears in the in-app inbox.
+//
+// Parameters:
+//   - ctx: Request context
+//   - user: User who asked to reset their password
+//   - token: Reset token the link carries
+//   - expiresAt: Time the link stops working
+//
+// Returns:
+//   - error: Error if the email cannot be queued
+func (s *notificationService) SendPasswordReset(ctx context.Context, user *models.User, token string, expiresAt time.Time) error {
+	org, err := s.loadOrganization(ctx, user.OrganizationID)
+	if err != nil {
+		return err
+	}
+	minutes := int(math.Ceil(time.Until(expiresAt).Minutes()))
+	recipient := &notificationRecipient{Address: user.Email, Name: user.Profile.GetFullName()}
+	return s.send(ctx, org, []*notificationRecipient{recipient}, &notification{
+		Type: models.NotificationTypePasswordReset,
+		Link: s.appURL + "/password-reset?token=" + url.QueryEscape(token),
+		Data: map[string]interface{}{
+			"ExpiresIn": fmt.Sprintf("%d minutes", max(minutes, 1)),
+		},
+		Urgent: true,
+	})
+}
+
+// GetNotificationPreferences returns a user's effective notification
+// preferences: the user's own choices, falling back to the organization defaults.
+//
+// Parameters:
+//   - ctx: Request context
+//   - userID: User ID
+//
+// Returns:
+//   - *NotificationPreferences: Effective preferences
+//   - error: ErrInvalidInput if the user does not exist
+func (s *notificationService) GetNotificationPreferences(ctx context.Context, userID string) (*NotificationPreferences, error) {
+	user, org, err := s.loadUserWithOrganization(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return EffectiveNotificationPreferences(org, user), nil
+}
+
+// UpdateNotificationPreferences stores a user's notification preferences.
+// Settings equal to the organization default are stored as "not chosen", so the
+// user keeps following the organization for them. An empty digest frequency or
+// empty quiet hours also follow the organization.
+//
+// Parameters:
+//   - ctx: Request context
+//   - userID: User ID
+//   - prefs: Desired preferences
+//
+// Returns:
+//   - error: ErrInvalidInput if the user does not exist or the timing settings
+//     are invalid, or a storage error
+func (s *notificationService) UpdateNotificationPreferences(ctx context.Context, userID string, prefs *NotificationPreferences) error {
+	if prefs == nil {
+		return ErrInvalidInput
+	}
+	if err := validateNotificationTiming(prefs); err != nil {
+		return err
+	}
+	_, org, err := s.loadUserWithOrganization(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	defaults := organizationNotificationDefaults(org)
+	override := func(value, orgDefault bool) *bool {
+		if value == orgDefault {
+			return nil
+		}
+		return &value
+	}
+	overrides := models.NotificationOverrides{
+		Email:           override(prefs.Email, defaults.Email),
+		SMS:             override(prefs.SMS, defaults.SMS),
+		InApp:           override(prefs.InApp, defaults.InApp),
+		EvidenceRequest: override(prefs.EvidenceRequest, defaults.EvidenceRequest),
+		Reminders:       override(prefs.Reminders, defaults.Reminders),
+		SystemAlerts:    override(prefs.SystemAlerts, defaults.SystemAlerts),
+	}
+	if prefs.DigestFrequency != "" && prefs.DigestFrequency != defaults.DigestFrequency {
+		overrides.DigestFrequency = &prefs.DigestFrequency
+	}
+	if prefs.QuietHoursStart != "" &&
+		(prefs.QuietHoursStart != defaults.QuietHoursStart || prefs.QuietHoursEnd != defaults.QuietHoursEnd) {
+		overrides.QuietHoursStart = &prefs.QuietHoursStart
+		overrides.QuietHoursEnd = &prefs.QuietHoursEnd
+	}
+
+	if err := s.userRepo.UpdatePreferences(ctx, userID, map[string]interface{}{"notifications": overrides}); err != nil {
+		return fmt.Errorf("failed to update notification preferences: %w", err)
+	}
+	return nil
+}
+
+// EffectiveNotificationPreferences combines the organization's notification
+// defaults with a user's overrides.
+//
+// Parameters:
+//   - org: User's organization
+//   - user: User whose preferences are computed
+//
+// Returns:
+//   - *NotificationPreferences: Effective preferences
+func EffectiveNotificationPreferences(org *models.Organization, user *models.User) *NotificationPreferences {
+	prefs := organizationNotificationDefaults(org)
+	overrides := user.Metadata.Preferences.Notifications
+	for _, field := range []struct {
+		override *bool
+		target   *bool
+	}{
+		{overrides.Email, &prefs.Email},
+		{overrides.SMS, &prefs.SMS},
+		{overrides.InApp, &prefs.InApp},
+		{overrides.EvidenceRequest, &prefs.EvidenceRequest},
+		{overrides.Reminders, &prefs.Reminders},
+		{overrides.SystemAlerts, &prefs.SystemAlerts},
+	} {
+		if field.override != nil {
+			*field.target = *field.override
+		}
+	}
+	if overrides.DigestFrequency != nil && validDigestFrequencies[*overrides.DigestFrequency] {
+		prefs.DigestFrequency = *overrides.DigestFrequency
+	}
+	if overrides.QuietHoursStart != nil && overrides.QuietHoursEnd != nil {
+		prefs.QuietHoursStart = *overrides.QuietHoursStart
+		prefs.QuietHoursEnd = *overrides.QuietHoursEnd
+	}
+	return prefs
+}
+
+// organizationNotificationDefaults returns the notification defaults of an
+// organization. Unknown digest frequencies deliver immediately and malformed
+// quiet hours are ignored.
+func organizationNotificationDefaults(org *models.Organization) *NotificationPreferences {
+	settings := org.Settings.Notifications
+	prefs := &NotificationPreferences{
+		Email:           settings.EmailEnabled,
+		SMS:             settings.SMSEnabled,
+		InApp:           settings.WebEnabled,
+		EvidenceRequest: settings.EvidenceRequests,
+		Reminders:       settings.DeadlineReminders,
+		SystemAlerts:    settings.SystemAlerts,
+		DigestFrequency: models.DigestFrequencyImmediate,
+	}
+	if validDigestFrequencies[settings.DigestFrequency] {
+		prefs.DigestFrequency = settings.DigestFrequency
+	}
+	if _, ok := parseClock(settings.QuietHoursStart); ok {
+		if _, ok := parseClock(settings.QuietHoursEnd); ok {
+			prefs.QuietHoursStart = settings.QuietHoursStart
+			prefs.QuietHoursEnd = settings.QuietHoursEnd
+		}
+	}
+	return prefs
+}
+
+// sendToUser sends a notification to a single user of an organization.
+func (s *notificationService) sendToUser(ctx context.Context, orgID, userID primitive.ObjectID, n *notification) error {
+	org, err := s.loadOrganization(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	recipients, err := s.userRecipients(ctx, []primitive.ObjectID{userID})
+	if err != nil {
+		return err
+	}
+	return s.send(ctx, org, recipients, n)
+}
+
+// send renders the notification for every recipient whose preferences allow it,
+// adds it to the inboxes of users with in-app notifications and queues the
+// emails or holds them back for digests. Recipients are
+// processed independently; the returned error joins all failures.
+func (s *notificationService) send(ctx context.Context, org *models.Organization, recipients []*notificationRecipient, n *notification) error {
+	n.Org = org
+	var errs []error
+	for _, recipient := range recipients {
+		if recipient.Address == "" {
+			continue
+		}
+		var prefs *NotificationPreferences
+		if recipient.User != nil {
+			if !recipient.User.IsActive {
+				continue
+			}
+			prefs = EffectiveNotificationPreferences(org, recipient.User)
+			if !n.Wants(prefs) || (!prefs.Email && !prefs.InApp) {
+				s.logger.Debug("Notification suppressed by preferences",
+					zap.String("type", n.Type),
+					zap.String("user_id", recipient.User.ID.Hex()),
+				)
+				continue
+			}
+			if prefs.InApp && !org.ID.IsZero() {
+				if err := s.notifyInApp(ctx, recipient, n); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			if !prefs.Email {
+				continue
+			}
+		} else if !org.ID.IsZero() && !org.Settings.Notifications.EmailEnabled {
+			continue
+		}
+
+		if err := s.deliver(ctx, recipient, prefs, n); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deliver holds a non-urgent notification back for the recipient's digest, or
+// queues it for sending once the recipient's quiet hours are over. Urgent
+// notifications and plain addresses, which have no preferences, are queued
+// for immediate sending.
+func (s *notificationService) deliver(ctx context.Context, recipient *notificationRecipient, prefs *NotificationPreferences, n *notification) error {
+	now := time.Now().UTC()
+	if n.Urgent || prefs == nil {
+		return s.enqueue(ctx, recipient, n, now)
+	}
+
+	location := notificationLocation(n.Org, recipient.User)
+	if digestNotificationTypes[n.Type] && prefs.DigestFrequency != models.DigestFrequencyImmediate {
+		return s.holdForDigest(ctx, recipient, n, nextDigestAt(now, prefs.DigestFrequency, s.digestHour, location))
+	}
+	return s.enqueue(ctx, recipient, n, afterQuietHours(now, prefs.QuietHoursStart, prefs.QuietHoursEnd, location))
+}
+
+// render renders a notification for one recipient.
+func (s *notificationService) render(ctx context.Context, recipient *notificationRecipient, n *notification) (*RenderedNotification, error) {
+	data := notificationBaseData(s.appURL, n.Org.Name, recipient.Name, n.Link)
+	for key, value := range n.Data {
+		data[key] = value
+	}
+	return s.renderer.Render(ctx, notificationOrgID(n.Org), n.Type, data)
+}
+
+// enqueue renders a notification for one recipient and writes it to the
+// outbox, to be sent no earlier than sendAt.
+func (s *notificationService) enqueue(ctx context.Context, recipient *notificationRecipient, n *notification, sendAt time.Time) error {
+	rendered, err := s.render(ctx, recipient, n)
+	if err != nil {
+		return err
+	}
+
+	orgID := notificationOrgID(n.Org)
+	now := time.Now().UTC()
+	message := &models.OutboxMessage{
+		ID:             primitive.NewObjectID(),
+		OrganizationID: n.Org.ID,
+		Type:           n.Type,
+		Recipient:      recipient.Address,
+		Subject:        rendered.Subject,
+		Text:           rendered.Text,
+		HTML:           rendered.HTML,
+		Status:         models.OutboxStatusPending,
+		NextAttemptAt:  sendAt,
+		CreatedAt:      now,
+	}
+	if recipient.User != nil {
+		message.UserID = recipient.User.ID
+	}
+	if err := s.outboxRepo.Create(ctx, message); err != nil {
+		return fmt.Errorf("failed to queue %s notification: %w", n.Type, err)
+	}
+
+	s.logger.Debug("Notification queued",
+		zap.String("type", n.Type),
+		zap.String("outbox_id", message.ID.Hex()),
+		zap.String("organization_id", orgID),
+		zap.Time("send_at", sendAt),
+	)
+	return nil
+}
+
+// holdForDigest renders a notification for a user and stores its summary for
+// the user's digest due at dueAt.
+func (s *notificationService) holdForDigest(ctx context.Context, recipient *notificationRecipient, n *notification, dueAt time.Time) error {
+	rendered, err := s.render(ctx, recipient, n)
+	if err != nil {
+		return err
+	}
+
+	item := &models.NotificationDigestItem{
+		ID:             primitive.NewObjectID(),
+		OrganizationID: n.Org.ID,
+		UserID:         recipient.User.ID,
+		Type:           n.Type,
+		Subject:        notificationSummary(rendered, n.Org),
+		Link:           n.Link,
+		DueAt:          dueAt,
+		CreatedAt:      time.Now().UTC(),
+	}
+	if err := s.digestRepo.Create(ctx, item); err != nil {
+		return fmt.Errorf("failed to hold %s notification for digest: %w", n.Type, err)
+	}
+
+	s.logger.Debug("Notification held for digest",
+		zap.String("type", n.Type),
+		zap.String("user_id", recipient.User.ID.Hex()),
+		zap.Time("due_at", dueAt),
+	)
+	return nil
+}
+
+// notifyInApp renders a notification for a user and adds its summary to the
+// user's inbox. In-app notifications are silent, so they are neither digested
+// nor deferred by quiet hours.
+func (s *notificationService) notifyInApp(ctx context.Context, recipient *notificationRecipient, n *notification) error {
+	rendered, err := s.render(ctx, recipient, n)
+	if err != nil {
+		return err
+	}
+
+	notification := &models.InAppNotification{
+		OrganizationID: n.Org.ID,
+		UserID:         recipient.User.ID,
+		Type:           n.Type,
+		Title:          notificationSummary(rendered, n.Org),
+		Link:           n.Link,
+	}
+	browser := recipient.User.Metadata.Preferences.BrowserNotifications
+	if err := s.inbox.Deliver(ctx, notification, browser); err != nil {
+		return fmt.Errorf("failed to deliver in-app %s notification: %w", n.Type, err)
+	}
+	return nil
+}
+
+// userRecipients loads users by ID; users that no longer exist are skipped.
+func (s *notificationService) userRecipients(ctx context.Context, ids []primitive.ObjectID) ([]*notificationRecipient, error) {
+	recipients := make([]*notificationRecipient, 0, len(ids))
+	for _, id := range ids {
+		user, err := s.userRepo.GetByID(ctx, id.Hex())
+		if errors.Is(err, repositories.ErrNotFound) {
+			s.logger.Warn("Notification recipient not found", zap.String("user_id", id.Hex()))
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get notification recipient: %w", err)
+		}
+		recipients = append(recipients, userRecipient(user))
+	}
+	return recipients, nil
+}
+
+// roleRecipients loads the users holding any of the roles, each user once.
+func (s *notificationService) roleRecipients(ctx context.Context, orgID string, roles ...string) ([]*notificationRecipient, error) {
+	seen := make(map[primitive.ObjectID]bool)
+	var recipients []*notificationRecipient
+	for _, role := range roles {
+		users, err := s.userRepo.GetByRole(ctx, orgID, role)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s users: %w", role, err)
+		}
+		for _, user := range users {
+			if !seen[user.ID] {
+				seen[user.ID] = true
+				recipients = append(recipients, userRecipient(user))
+			}
+		}
+	}
+	return recipients, nil
+}
+
+// loadOrganization loads the organization a notification is sent for.
+func (s *notificationService) loadOrganization(ctx context.Context, orgID primitive.ObjectID) (*models.Organization, error) {
+	org, err := s.orgRepo.GetByID(ctx, orgID.Hex())
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrInvalidInput
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	return org, nil
+}
+
+// loadUserWithOrganization loads a user and the user's organization.
+func (s *notificationService) loadUserWithOrganization(ctx context.Context, userID string) (*models.User, *models.Organization, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, nil, ErrInvalidInput
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	org, err := s.loadOrganization(ctx, user.OrganizationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, org, nil
+}
+
+// resourceLink returns the web application URL of a resource.
+func (s *notificationService) resourceLink(resourceType, resourceID string) string {
+	paths := map[string]string{
+		models.ResourceTypeEvidenceRequest: "/evidence-requests/",
+		models.ResourceTypeControl:         "/controls/",
+		models.ResourceTypeFinding:         "/findings/",
+		models.ResourceTypeTestingCycle:    "/testing-cycles/",
+	}
+	path, ok := paths[resourceType]
+	if !ok {
+		return s.appURL
+	}
+	return s.appURL + path + resourceID
+}
+
+// notificationOrgID returns the organization ID used for template overrides,
+// empty for notifications outside an organization.
+func notificationOrgID(org *models.Organization) string {
+	if org.ID.IsZero() {
+		return ""
+	}
+	return org.ID.Hex()
+}
+
+// notificationSummary returns the subject of a rendered notification without
+// the organization prefix, for digests and inboxes that already name it.
+func notificationSummary(rendered *RenderedNotification, org *models.Organization) string {
+	return strings.TrimPrefix(rendered.Subject, "["+org.Name+"] ")
+}
+
+// userRecipient addresses a user by email and full name.
+func userRecipient(user *models.User) *notificationRecipient {
+	return &notificationRecipient{
+		User:    user,
+		Address: user.Email,
+		Name:    user.Profile.GetFullName(),
+	}
+}
+
+// latestRejectionComment returns the comment of the most recent rejection.
+func latestRejectionComment(request *models.EvidenceRequest) string {
+	for i := len(request.Reviews) - 1; i >= 0; i-- {
+		if request.Reviews[i].Decision == models.ReviewDecisionRejected {
+			return request.Reviews[i].Comment
+		}
+	}
+	return ""
+}
+
+// formatNotificationDate formats a date for notification emails.
+func formatNotificationDate(t time.Time) string {
+	return t.UTC().Format(notificationDateLayout)
+}
+
+// truncateRunes shortens s to at most max characters, marking the cut with an ellipsis.
+func truncateRunes(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:max-1])) + "…"
+}
@@ -0,0 +1,277 @@
+// Package services provides service layer implementations for the GoEdu Control Testing Platform.
+// This file contains notification digests and quiet hours: scheduling of held back
+// notifications, the digest emails that replace them and the deferral of emails
+// during a recipient's quiet hours.
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+)
+
+// digestLease is how long claimed digest items stay reserved for one digester.
+const digestLease = 5 * time.Minute
+
+// digestNotificationTypes are the non-urgent notification types that are
+// collected into digests. All other types are sent individually.
+var digestNotificationTypes = map[string]bool{
+	models.NotificationTypeReminder:       true,
+	models.NotificationTypeReviewApproved: true,
+	models.NotificationTypeMention:        true,
+	models.NotificationTypeTestingCycle:   true,
+}
+
+// validDigestFrequencies lists the accepted digest frequencies.
+var validDigestFrequencies = map[string]bool{
+	models.DigestFrequencyImmediate: true,
+	models.DigestFrequencyDaily:     true,
+	models.DigestFrequencyWeekly:    true,
+	models.DigestFrequencyMonthly:   true,
+}
+
+// NewNotificationDigester creates the digester sending held back notifications
+// as one digest email per user. It shares the notification service's
+// dependencies so that digests honour the same preferences and templates.
+//
+// Parameters:
+//   - orgRepo: Repository for organization data operations
+//   - userRepo: Repository for recipients and their preferences
+//   - templateRepo: Repository for per-organization template overrides
+//   - outboxRepo: Repository for queued notification emails
+//   - digestRepo: Repository for notifications held back for digests
+//   - cfg: Email configuration providing the application URL and batch size
+//   - logger: Logger for digester operations
+//
+// Returns:
+//   - NotificationDigester: Configured digester instance
+//
+// Example:
+//
+//	digester := services.NewNotificationDigester(orgRepo, userRepo, templateRepo, outboxRepo, digestRepo, cfg.Email, logger)
+//	jobs.RegisterScheduledJobs(scheduler, cfg.Scheduler.Schedules, jobs.ScheduledServices{Digests: digester})
+func NewNotificationDigester(
+	orgRepo repositories.OrganizationRepository,
+	userRepo repositories.UserRepository,
+	templateRepo repositories.NotificationTemplateRepository,
+	outboxRepo repositories.NotificationOutboxRepository,
+	digestRepo repositories.NotificationDigestRepository,
+	cfg config.EmailConfig,
+	logger *zap.Logger,
+) NotificationDigester {
+	return newNotificationService(orgRepo, userRepo, templateRepo, outboxRepo, digestRepo, cfg, logger)
+}
+
+// SendDueDigests claims the due digest items one user at a time and queues a
+// digest email for each user, up to the outbox batch size per run. Users whose
+// digest fails keep their items, which are claimed again once the lease expires.
+//
+// Parameters:
+//   - ctx: Request context
+//
+// Returns:
+//   - int: Number of digests queued
+//   - error: Error if due items cannot be claimed
+func (s *notificationService) SendDueDigests(ctx context.Context) (int, error) {
+	queued := 0
+	for claimed := 0; claimed < s.batchSize; claimed++ {
+		now := time.Now().UTC()
+		items, err := s.digestRepo.ClaimDue(ctx, now, now.Add(digestLease))
+		if errors.Is(err, repositories.ErrNotFound) {
+			return queued, nil
+		}
+		if err != nil {
+			return queued, fmt.Errorf("failed to claim digest items: %w", err)
+		}
+		if len(items) == 0 {
+			return queued, nil
+		}
+
+		sent, err := s.sendDigest(ctx, items, now)
+		if err != nil {
+			s.logger.Error("Failed to send notification digest",
+				zap.Error(err),
+				zap.String("user_id", items[0].UserID.Hex()),
+				zap.Int("items", len(items)),
+			)
+			continue
+		}
+		if sent {
+			queued++
+		}
+	}
+	return queued, nil
+}
+
+// sendDigest queues one digest email summarizing a user's items and removes
+// the items. Items of users who left or opted out of email are dropped.
+func (s *notificationService) sendDigest(ctx context.Context, items []*models.NotificationDigestItem, now time.Time) (bool, error) {
+	sort.SliceStable(items, func(i, j int) bool { return items[i].CreatedAt.Before(items[j].CreatedAt) })
+	ids := make([]string, len(items))
+	entries := make([]map[string]interface{}, len(items))
+	for i, item := range items {
+		ids[i] = item.ID.Hex()
+		entries[i] = map[string]interface{}{"Subject": item.Subject, "Link": item.Link}
+	}
+
+	user, err := s.userRepo.GetByID(ctx, items[0].UserID.Hex())
+	if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+		return false, fmt.Errorf("failed to get digest recipient: %w", err)
+	}
+	var org *models.Organization
+	if err == nil {
+		if org, err = s.loadOrganization(ctx, items[0].OrganizationID); err != nil && !errors.Is(err, ErrInvalidInput) {
+			return false, err
+		}
+	}
+
+	sent := false
+	if user != nil && org != nil && user.IsActive {
+		prefs := EffectiveNotificationPreferences(org, user)
+		if prefs.Email {
+			frequency := prefs.DigestFrequency
+			if frequency == models.DigestFrequencyImmediate {
+				// The user switched to immediate delivery after these were held back
+				frequency = "latest"
+			}
+			n := &notification{
+				Type: models.NotificationTypeDigest,
+				Org:  org,
+				Link: s.appURL + "/notifications",
+				Data: map[string]interface{}{
+					"Frequency": frequency,
+					"Items":     entries,
+				},
+			}
+			location := notificationLocation(org, user)
+			sendAt := afterQuietHours(now, prefs.QuietHoursStart, prefs.QuietHoursEnd, location)
+			if err := s.enqueue(ctx, userRecipient(user), n, sendAt); err != nil {
+				return false, err
+			}
+			sent = true
+		}
+	}
+
+	if err := s.digestRepo.Delete(ctx, ids); err != nil {
+		return sent, fmt.Errorf("failed to delete digest items: %w", err)
+	}
+	return sent, nil
+}
+
+// validateNotificationTiming checks the digest frequency and quiet hours of
+// preferences. Empty values are valid and mean "follow the organization".
+func validateNotificationTiming(prefs *NotificationPreferences) error {
+	if prefs.DigestFrequency != "" && !validDigestFrequencies[prefs.DigestFrequency] {
+		return fmt.Errorf("%w: unknown digest frequency %q", ErrInvalidInput, prefs.DigestFrequency)
+	}
+	if (prefs.QuietHoursStart == "") != (prefs.QuietHoursEnd == "") {
+		return fmt.Errorf("%w: quiet hours need both a start and an end", ErrInvalidInput)
+	}
+	if prefs.QuietHoursStart == "" {
+		return nil
+	}
+	for _, value := range []string{prefs.QuietHoursStart, prefs.QuietHoursEnd} {
+		if _, ok := parseClock(value); !ok {
+			return fmt.Errorf("%w: quiet hours must be HH:MM, got %q", ErrInvalidInput, value)
+		}
+	}
+	return nil
+}
+
+// parseClock parses an HH:MM time of day into minutes after midnight.
+func parseClock(value string) (int, bool) {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, false
+	}
+	return parsed.Hour()*60 + parsed.Minute(), true
+}
+
+// notificationLocation returns the timezone of a recipient: the user's own
+// timezone, then the organization's, then UTC.
+func notificationLocation(org *models.Organization, user *models.User) *time.Location {
+	var candidates []string
+	if user != nil {
+		candidates = append(candidates, user.Metadata.Timezone)
+	}
+	if org != nil {
+		candidates = append(candidates, org.Timezone)
+	}
+	for _, name := range candidates {
+		if name == "" {
+			continue
+		}
+		if location, err := time.LoadLocation(name); err == nil {
+			return location
+		}
+	}
+	return time.UTC
+}
+
+// nextDigestAt returns when the next digest of the given frequency is due after
+// now: at hour o'clock local time on the next day, the next Monday or the first
+// of the next month.
+func nextDigestAt(now time.Time, frequency string, hour int, location *time.Location) time.Time {
+	local := now.In(location)
+	year, month, day := local.Date()
+
+	var due time.Time
+	switch frequency {
+	case models.DigestFrequencyWeekly:
+		daysUntilMonday := (int(time.Monday) - int(local.Weekday()) + 7) % 7
+		due = time.Date(year, month, day+daysUntilMonday, hour, 0, 0, 0, location)
+		if !due.After(local) {
+			due = time.Date(year, month, day+daysUntilMonday+7, hour, 0, 0, 0, location)
+		}
+	case models.DigestFrequencyMonthly:
+		due = time.Date(year, month, 1, hour, 0, 0, 0, location)
+		if !due.After(local) {
+			due = time.Date(year, month+1, 1, hour, 0, 0, 0, location)
+		}
+	default:
+		due = time.Date(year, month, day, hour, 0, 0, 0, location)
+		if !due.After(local) {
+			due = time.Date(year, month, day+1, hour, 0, 0, 0, location)
+		}
+	}
+	return due.UTC()
+}
+
+// afterQuietHours returns the end of the quiet hours if now falls within them,
+// and now otherwise. Quiet hours are HH:MM in the given location and may span
+// midnight, e.g. 22:00 to 06:00. Missing, malformed or empty windows never defer.
+func afterQuietHours(now time.Time, start, end string, location *time.Location) time.Time {
+	startMinute, ok := parseClock(start)
+	if !ok {
+		return now
+	}
+	endMinute, ok := parseClock(end)
+	if !ok || startMinute == endMinute {
+		return now
+	}
+
+	local := now.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	quiet := minute >= startMinute && minute < endMinute
+	if startMinute > endMinute {
+		quiet = minute >= startMinute || minute < endMinute
+	}
+	if !quiet {
+		return now
+	}
+
+	year, month, day := local.Date()
+	resume := time.Date(year, month, day, endMinute/60, endMinute%60, 0, 0, location)
+	if !resume.After(local) {
+		resume = time.Date(year, month, day+1, endMinute/60, endMinute%60, 0, 0, location)
+	}
+	return resume.UTC()
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+)
+
+func TestNextDigestAt(t *testing.T) {
+	prague, err := time.LoadLocation("Europe/Prague")
+	require.NoError(t, err)
+
+	// Wednesday 12 March 2025, 09:30 in Prague
+	now := time.Date(2025, 3, 12, 9, 30, 0, 0, prague)
+
+	tests := []struct {
+		name      string
+		now       time.Time
+		frequency string
+		expected  time.Time
+	}{
+		{"daily after the digest hour", now, models.DigestFrequencyDaily, time.Date(2025, 3, 13, 8, 0, 0, 0, prague)},
+		{"daily before the digest hour", time.Date(2025, 3, 12, 7, 0, 0, 0, prague), models.DigestFrequencyDaily, time.Date(2025, 3, 12, 8, 0, 0, 0, prague)},
+		{"weekly", now, models.DigestFrequencyWeekly, time.Date(2025, 3, 17, 8, 0, 0, 0, prague)},
+		{"weekly on Monday after the digest hour", time.Date(2025, 3, 17, 8, 0, 0, 0, prague), models.DigestFrequencyWeekly, time.Date(2025, 3, 24, 8, 0, 0, 0, prague)},
+		{"monthly", now, models.DigestFrequencyMonthly, time.Date(2025, 4, 1, 8, 0, 0, 0, prague)},
+		{"monthly across the year end", time.Date(2025, 12, 31, 23, 0, 0, 0, prague), models.DigestFrequencyMonthly, time.Date(2026, 1, 1, 8, 0, 0, 0, prague)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, tt.expected.Equal(nextDigestAt(tt.now, tt.frequency, 8, prague)), nextDigestAt(tt.now, tt.frequency, 8, prague))
+		})
+	}
+}
+
+func TestAfterQuietHours(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2025, 3, day, hour, minute, 0, 0, newYork)
+	}
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		start    string
+		end      string
+		expected time.Time
+	}{
+		{"before overnight quiet hours", at(12, 21, 59), "22:00", "06:00", at(12, 21, 59)},
+		{"evening in overnight quiet hours", at(12, 23, 15), "22:00", "06:00", at(13, 6, 0)},
+		{"morning in overnight quiet hours", at(13, 5, 0), "22:00", "06:00", at(13, 6, 0)},
+		{"end of quiet hours is not quiet", at(13, 6, 0), "22:00", "06:00", at(13, 6, 0)},
+		{"daytime quiet hours", at(12, 12, 30), "12:00", "13:00", at(12, 13, 0)},
+		{"equal start and end", at(12, 12, 30), "12:00", "12:00", at(12, 12, 30)},
+		{"no quiet hours", at(12, 23, 15), "", "", at(12, 23, 15)},
+		{"malformed quiet hours", at(12, 23, 15), "10pm", "06:00", at(12, 23, 15)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := afterQuietHours(tt.now.UTC(), tt.start, tt.end, newYork)
+			assert.True(t, tt.expected.Equal(actual), actual)
+		})
+	}
+}
+
+func TestNotificationService_HoldsNonUrgentNotificationsForDigests(t *testing.T) {
+	f := newNotificationFixture(t)
+	ctx := context.Background()
+	f.org.Settings.Notifications.DigestFrequency = models.DigestFrequencyWeekly
+	f.assignee.Metadata.Timezone = "Asia/Tokyo"
+
+	request := f.request("Access review")
+	require.NoError(t, f.service.SendReminderNotification(ctx, request))
+	require.NoError(t, f.service.SendReviewNotification(ctx, request, ReviewEventApproved))
+	require.NoError(t, f.service.SendEvidenceRequest(ctx, request))
+
+	// Only the new request is urgent enough to be sent on its own
+	require.Len(t, f.outbox.messages, 1)
+	assert.Equal(t, models.NotificationTypeEvidenceRequest, f.outbox.messages[0].Type)
+	require.Len(t, f.digests.items, 2)
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	dueAt := f.digests.items[0].DueAt.In(tokyo)
+	assert.Equal(t, time.Monday, dueAt.Weekday())
+	assert.Equal(t, 8, dueAt.Hour())
+	assert.NotContains(t, f.digests.items[0].Subject, "[First Bank]")
+
+	// Nothing is due until the digest time
+	queued, err := f.digester.SendDueDigests(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, queued)
+
+	for _, item := range f.digests.items {
+		item.DueAt = time.Now().Add(-time.Minute)
+	}
+	queued, err = f.digester.SendDueDigests(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, queued)
+	assert.Empty(t, f.digests.items)
+
+	require.Len(t, f.outbox.messages, 2)
+	digest := f.outbox.messages[1]
+	assert.Equal(t, models.NotificationTypeDigest, digest.Type)
+	assert.Equal(t, "alice@bank.com", digest.Recipient)
+	assert.Equal(t, "[First Bank] Your weekly notification digest (2)", digest.Subject)
+	assert.Contains(t, digest.Text, "evidence for REQ-9 is due 15 March 2025\n  https://app.goedu.test/evidence-requests/")
+	assert.Contains(t, digest.Text, "https://app.goedu.test/evidence-requests/"+request.ID.Hex())
+	assert.Contains(t, digest.HTML, "<li><a href=")
+
+	// A user choosing immediate delivery overrides the organization's digest
+	immediate := models.DigestFrequencyImmediate
+	f.assignee.Metadata.Preferences.Notifications.DigestFrequency = &immediate
+	require.NoError(t, f.service.SendReminderNotification(ctx, request))
+	assert.Len(t, f.outbox.messages, 3)
+	assert.Empty(t, f.digests.items)
+}
+
+func TestNotificationService_DefersDuringQuietHours(t *testing.T) {
+	f := newNotificationFixture(t)
+	ctx := context.Background()
+	f.admin.Metadata.Timezone = "America/New_York"
+	newYork, _ := time.LoadLocation("America/New_York")
+
+	// Quiet hours around the current time, so every email is deferred to their end
+	local := time.Now().In(newYork)
+	start := local.Add(-time.Hour).Format("15:04")
+	end := local.Add(time.Hour).Format("15:04")
+	f.admin.Metadata.Preferences.Notifications.QuietHoursStart = &start
+	f.admin.Metadata.Preferences.Notifications.QuietHoursEnd = &end
+
+	alert := &SystemAlert{
+		Severity:   models.AlertSeverityWarning,
+		Title:      "Storage nearly full",
+		Recipients: []string{f.admin.ID.Hex()},
+	}
+	require.NoError(t, f.service.SendSystemAlert(ctx, alert))
+	alert.Severity = models.AlertSeverityCritical
+	require.NoError(t, f.service.SendSystemAlert(ctx, alert))
+
+	require.Len(t, f.outbox.messages, 2)
+	deferred := f.outbox.messages[0].NextAttemptAt
+	assert.WithinDuration(t, local.Add(time.Hour).Truncate(time.Minute), deferred, time.Minute)
+	assert.Equal(t, end, deferred.In(newYork).Format("15:04"))
+
+	// Critical alerts go out immediately despite quiet hours
+	assert.WithinDuration(t, time.Now(), f.outbox.messages[1].NextAttemptAt, 5*time.Second)
+
+	// Deferred messages are not dispatched before the quiet hours end
+	attempted, err := f.dispatcher.DispatchPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempted)
+	assert.Equal(t, models.OutboxStatusPending, f.outbox.messages[0].Status)
+}
+
+func TestNotificationService_TimingPreferences(t *testing.T) {
+	f := newNotificationFixture(t)
+	ctx := context.Background()
+	f.org.Settings.Notifications.DigestFrequency = models.DigestFrequencyDaily
+	f.org.Settings.Notifications.QuietHoursStart = "22:00"
+	f.org.Settings.Notifications.QuietHoursEnd = "06:00"
+	userID := f.assignee.ID.Hex()
+
+	prefs, err := f.service.GetNotificationPreferences(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, models.DigestFrequencyDaily, prefs.DigestFrequency)
+	assert.Equal(t, "22:00", prefs.QuietHoursStart)
+	assert.Equal(t, "06:00", prefs.QuietHoursEnd)
+
+	invalid := []*NotificationPreferences{
+		{Email: true, DigestFrequency: "hourly"},
+		{Email: true, QuietHoursStart: "22:00"},
+		{Email: true, QuietHoursStart: "22:00", QuietHoursEnd: "6am"},
+	}
+	for _, prefs := range invalid {
+		assert.ErrorIs(t, f.service.UpdateNotificationPreferences(ctx, userID, prefs), ErrInvalidInput)
+	}
+
+	// Changing one end of the quiet hours overrides both; the unchanged digest
+	// frequency keeps following the organization
+	prefs.QuietHoursEnd = "07:30"
+	require.NoError(t, f.service.UpdateNotificationPreferences(ctx, userID, prefs))
+	overrides := f.assignee.Metadata.Preferences.Notifications
+	assert.Nil(t, overrides.DigestFrequency)
+	require.NotNil(t, overrides.QuietHoursStart)
+	require.NotNil(t, overrides.QuietHoursEnd)
+	assert.Equal(t, "22:00", *overrides.QuietHoursStart)
+	assert.Equal(t, "07:30", *overrides.QuietHoursEnd)
+
+	f.org.Settings.Notifications.DigestFrequency = models.DigestFrequencyMonthly
+	prefs, err = f.service.GetNotificationPreferences(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, models.DigestFrequencyMonthly, prefs.DigestFrequency)
+	assert.Equal(t, "07:30", prefs.QuietHoursEnd)
+}
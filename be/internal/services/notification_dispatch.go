@@ -0,0 +1,180 @@
+// Package services provides service layer implementations for the GoEdu Control Testing Platform.
+// This file contains the notification dispatcher which delivers queued emails from
+// the outbox and retries failed sends with exponential backoff.
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+	pkgmail "github.com/radek-zitek-cloud/goedu-omicron/be/pkg/mail"
+)
+
+// notificationDispatcher implements the NotificationDispatcher interface.
+type notificationDispatcher struct {
+	outboxRepo    repositories.NotificationOutboxRepository
+	sender        pkgmail.Sender
+	from          string
+	messageDomain string
+	batchSize     int
+	maxAttempts   int
+	retryDelay    time.Duration
+	maxRetryDelay time.Duration
+	lease         time.Duration
+	logger        *zap.Logger
+}
+
+// NewNotificationDispatcher creates a new notification dispatcher.
+//
+// Parameters:
+//   - outboxRepo: Repository for queued notification emails
+//   - sender: Mail sender, typically an SMTP sender
+//   - cfg: Email configuration with the sender address and retry settings
+//   - logger: Logger for dispatcher operations
+//
+// Returns:
+//   - NotificationDispatcher: Configured dispatcher instance
+//
+// Example:
+//
+//	sender := mail.NewSMTPSender(mail.SMTPConfig{Host: cfg.Email.SMTPHost, Port: cfg.Email.SMTPPort})
+//	dispatcher := services.NewNotificationDispatcher(outboxRepo, sender, cfg.Email, logger)
+func NewNotificationDispatcher(
+	outboxRepo repositories.NotificationOutboxRepository,
+	sender pkgmail.Sender,
+	cfg config.EmailConfig,
+	logger *zap.Logger,
+) NotificationDispatcher {
+	domain := "localhost"
+	if addr, err := mail.ParseAddress(cfg.From); err == nil {
+		domain = addr.Address[strings.LastIndex(addr.Address, "@")+1:]
+	}
+
+	// A lease outlives the SMTP session, so a message is only reclaimed when its
+	// dispatcher died mid-send
+	lease := 2 * cfg.SMTPTimeout
+	if lease < time.Minute {
+		lease = time.Minute
+	}
+
+	return &notificationDispatcher{
+		outboxRepo:    outboxRepo,
+		sender:        sender,
+		from:          cfg.From,
+		messageDomain: domain,
+		batchSize:     cfg.OutboxBatchSize,
+		maxAttempts:   cfg.MaxAttempts,
+		retryDelay:    cfg.RetryDelay,
+		maxRetryDelay: cfg.MaxRetryDelay,
+		lease:         lease,
+		logger:        logger,
+	}
+}
+
+// DispatchPending leases and sends due outbox messages, up to the configured
+// batch size. Failed sends are rescheduled with exponential backoff until they
+// fail permanently or run out of attempts.
+//
+// Parameters:
+//   - ctx: Request context
+//
+// Returns:
+//   - int: Number of messages attempted
+//   - error: Error if the outbox cannot be read; send failures are recorded on the messages
+func (d *notificationDispatcher) DispatchPending(ctx context.Context) (int, error) {
+	attempted := 0
+	for attempted < d.batchSize {
+		if err := ctx.Err(); err != nil {
+			return attempted, err
+		}
+
+		now := time.Now().UTC()
+		message, err := d.outboxRepo.ClaimDue(ctx, now, now.Add(d.lease))
+		if errors.Is(err, repositories.ErrNotFound) {
+			return attempted, nil
+		}
+		if err != nil {
+			return attempted, fmt.Errorf("failed to claim outbox message: %w", err)
+		}
+
+		attempted++
+		d.deliver(ctx, message)
+	}
+	return attempted, nil
+}
+
+// deliver sends one leased message and records the outcome.
+func (d *notificationDispatcher) deliver(ctx context.Context, message *models.OutboxMessage) {
+	err := d.sender.Send(ctx, &pkgmail.Message{
+		From:    d.from,
+		To:      []string{message.Recipient},
+		Subject: message.Subject,
+		Text:    message.Text,
+		HTML:    message.HTML,
+		Headers: map[string]string{
+			// Stable across retries so receiving systems can drop duplicates
+			"Message-ID":           fmt.Sprintf("<%s@%s>", message.ID.Hex(), d.messageDomain),
+			"X-GoEdu-Notification": message.Type,
+		},
+	})
+
+	now := time.Now().UTC()
+	message.Attempts++
+	message.LockedUntil = time.Time{}
+	switch {
+	case err == nil:
+		message.Status = models.OutboxStatusSent
+		message.SentAt = now
+		message.LastError = ""
+	case pkgmail.IsPermanent(err) || message.Attempts >= d.maxAttempts:
+		message.Status = models.OutboxStatusFailed
+		message.LastError = err.Error()
+		d.logger.Error("Notification delivery failed permanently",
+			zap.Error(err),
+			zap.String("outbox_id", message.ID.Hex()),
+			zap.String("type", message.Type),
+			zap.Int("attempts", message.Attempts),
+		)
+	default:
+		message.Status = models.OutboxStatusPending
+		message.NextAttemptAt = now.Add(d.backoff(message.Attempts))
+		message.LastError = err.Error()
+		d.logger.Warn("Notification delivery failed, will retry",
+			zap.Error(err),
+			zap.String("outbox_id", message.ID.Hex()),
+			zap.Int("attempts", message.Attempts),
+			zap.Time("next_attempt_at", message.NextAttemptAt),
+		)
+	}
+
+	if err := d.outboxRepo.Update(ctx, message); err != nil {
+		// The lease expires and the message is retried; a sent message may be sent again
+		d.logger.Error("Failed to record notification delivery",
+			zap.Error(err),
+			zap.String("outbox_id", message.ID.Hex()),
+			zap.String("status", message.Status),
+		)
+	}
+}
+
+// backoff returns the delay before the next attempt after the given number of
+// failed attempts: the retry delay doubled per attempt, capped at the max delay.
+func (d *notificationDispatcher) backoff(attempts int) time.Duration {
+	delay := d.retryDelay
+	for i := 1; i < attempts && delay < d.maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > d.maxRetryDelay {
+		delay = d.maxRetryDelay
+	}
+	return delay
+}
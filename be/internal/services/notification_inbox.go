@@ -0,0 +1,364 @@
+// Package services provides service layer implementations for the GoEdu Control Testing Platform.
+// This file contains the in-app notification inbox and its live push of inbox
+// events, which are relayed between server instances over pub/sub.
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/cache"
+)
+
+// inboxChannel is the pub/sub channel relaying inbox events between server instances.
+const inboxChannel = "goedu:notifications:inbox"
+
+// inboxSubscriberBuffer is the number of events buffered per session. Events
+// for sessions that fall further behind are dropped; the inbox itself stays
+// complete and clients catch up by listing it.
+const inboxSubscriberBuffer = 32
+
+// Page sizes of inbox listings.
+const (
+	defaultInboxPageSize = 20
+	maxInboxPageSize     = 100
+)
+
+// inboxEnvelope routes an inbox event to its sessions on every instance: the
+// sessions of one user, or without a user, of every member of an organization.
+type inboxEnvelope struct {
+	OrganizationID string      `json:"organization_id"`
+	UserID         string      `json:"user_id,omitempty"`
+	Event          *InboxEvent `json:"event"`
+}
+
+// inboxSubscriber is one live session subscribed on this instance.
+type inboxSubscriber struct {
+	orgID  string
+	userID string
+	events chan *InboxEvent
+}
+
+// notificationInboxService implements the NotificationInboxService interface.
+type notificationInboxService struct {
+	inboxRepo repositories.InAppNotificationRepository
+	broker    cache.PubSub
+	logger    *zap.Logger
+
+	mu          sync.RWMutex
+	subscribers map[*inboxSubscriber]struct{}
+}
+
+// NewNotificationInboxService creates a new notification inbox service. Inbox
+// events are published to the broker and only reach sessions through Listen,
+// so every server instance serving sessions must run Listen.
+//
+// Parameters:
+//   - inboxRepo: Repository for inbox notifications
+//   - broker: Pub/sub relaying events between server instances, typically Redis
+//   - logger: Logger for service operations
+//
+// Returns:
+//   - NotificationInboxService: Configured inbox service instance
+//
+// Example:
+//
+//	inboxService := services.NewNotificationInboxService(inboxRepo, cacheClient, logger)
+//	go inboxService.Listen(ctx)
+func NewNotificationInboxService(
+	inboxRepo repositories.InAppNotificationRepository,
+	broker cache.PubSub,
+	logger *zap.Logger,
+) NotificationInboxService {
+	return &notificationInboxService{
+		inboxRepo:   inboxRepo,
+		broker:      broker,
+		logger:      logger,
+		subscribers: make(map[*inboxSubscriber]struct{}),
+	}
+}
+
+// ListNotifications retrieves a page of a user's inbox, newest first, together
+// with the number of unread notifications.
+//
+// Parameters:
+//   - ctx: Request context
+//   - userID: User whose inbox is listed
+//   - filter: Unread filter and pagination; nil lists the first page
+//
+// Returns:
+//   - *InAppNotificationConnection: Page of notifications
+//   - error: ErrInvalidInput for malformed IDs or pagination, or a storage error
+func (s *notificationInboxService) ListNotifications(ctx context.Context, userID string, filter *InboxFilter) (*InAppNotificationConnection, error) {
+	if _, err := primitive.ObjectIDFromHex(userID); err != nil {
+		return nil, ErrInvalidInput
+	}
+	if filter == nil {
+		filter = &InboxFilter{}
+	}
+	if filter.Limit < 0 || filter.Offset < 0 {
+		return nil, ErrInvalidInput
+	}
+	limit := filter.Limit
+	if limit == 0 {
+		limit = defaultInboxPageSize
+	}
+	if limit > maxInboxPageSize {
+		limit = maxInboxPageSize
+	}
+
+	nodes, err := s.inboxRepo.GetByUser(ctx, userID, filter.UnreadOnly, limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	total, err := s.inboxRepo.CountByUser(ctx, userID, filter.UnreadOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count notifications: %w", err)
+	}
+	unread := total
+	if !filter.UnreadOnly {
+		if unread, err = s.inboxRepo.CountByUser(ctx, userID, true); err != nil {
+			return nil, fmt.Errorf("failed to count unread notifications: %w", err)
+		}
+	}
+
+	if nodes == nil {
+		nodes = []*models.InAppNotification{}
+	}
+	return &InAppNotificationConnection{
+		Nodes:       nodes,
+		TotalCount:  int(total),
+		UnreadCount: int(unread),
+		HasMore:     int64(filter.Offset+len(nodes)) < total,
+	}, nil
+}
+
+// MarkRead marks the given notifications of a user as read. Notifications of
+// other users and notifications that are already read are left unchanged.
+//
+// Parameters:
+//   - ctx: Request context
+//   - userID: User owning the notifications
+//   - notificationIDs: Notifications to mark
+//
+// Returns:
+//   - int64: Number of notifications marked as read
+//   - error: ErrInvalidInput without IDs or for malformed IDs, or a storage error
+func (s *notificationInboxService) MarkRead(ctx context.Context, userID string, notificationIDs []string) (int64, error) {
+	if _, err := primitive.ObjectIDFromHex(userID); err != nil || len(notificationIDs) == 0 {
+		return 0, ErrInvalidInput
+	}
+	for _, id := range notificationIDs {
+		if _, err := primitive.ObjectIDFromHex(id); err != nil {
+			return 0, fmt.Errorf("%w: invalid notification ID %q", ErrInvalidInput, id)
+		}
+	}
+
+	marked, err := s.inboxRepo.MarkRead(ctx, userID, notificationIDs, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications as read: %w", err)
+	}
+	return marked, nil
+}
+
+// MarkAllRead marks all unread notifications of a user as read.
+//
+// Parameters:
+//   - ctx: Request context
+//   - userID: User owning the notifications
+//
+// Returns:
+//   - int64: Number of notifications marked as read
+//   - error: ErrInvalidInput for a malformed user ID, or a storage error
+func (s *notificationInboxService) MarkAllRead(ctx context.Context, userID string) (int64, error) {
+	if _, err := primitive.ObjectIDFromHex(userID); err != nil {
+		return 0, ErrInvalidInput
+	}
+
+	marked, err := s.inboxRepo.MarkRead(ctx, userID, nil, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications as read: %w", err)
+	}
+	return marked, nil
+}
+
+// Deliver stores a notification in its user's inbox and pushes it to the
+// user's sessions. A failed push is logged but not returned: the notification
+// is stored and clients see it the next time they list the inbox.
+//
+// Parameters:
+//   - ctx: Request context
+//   - notification: Notification with organization, user, type and title set
+//   - browser: Whether the user wants browser notifications for pushed events
+//
+// Returns:
+//   - error: ErrInvalidInput for incomplete notifications, or a storage error
+func (s *notificationInboxService) Deliver(ctx context.Context, notification *models.InAppNotification, browser bool) error {
+	if notification == nil || notification.UserID.IsZero() || notification.OrganizationID.IsZero() || notification.Title == "" {
+		return ErrInvalidInput
+	}
+	if notification.ID.IsZero() {
+		notification.ID = primitive.NewObjectID()
+	}
+	notification.CreatedAt = time.Now().UTC()
+
+	if err := s.inboxRepo.Create(ctx, notification); err != nil {
+		return fmt.Errorf("failed to store %s notification: %w", notification.Type, err)
+	}
+
+	s.publish(ctx, &inboxEnvelope{
+		OrganizationID: notification.OrganizationID.Hex(),
+		UserID:         notification.UserID.Hex(),
+		Event: &InboxEvent{
+			Type:         models.InboxEventNotification,
+			Notification: notification,
+			Browser:      browser,
+		},
+	})
+	return nil
+}
+
+// PublishCycleProgress pushes a testing cycle's progress to the sessions of
+// every member of the cycle's organization. Progress is not stored in inboxes.
+//
+// Parameters:
+//   - ctx: Request context
+//   - cycle: Testing cycle with its current progress
+//
+// Returns:
+//   - error: ErrInvalidInput for cycles without organization, or a publish error
+func (s *notificationInboxService) PublishCycleProgress(ctx context.Context, cycle *models.TestingCycle) error {
+	if cycle == nil || cycle.OrganizationID.IsZero() {
+		return ErrInvalidInput
+	}
+
+	envelope := &inboxEnvelope{
+		OrganizationID: cycle.OrganizationID.Hex(),
+		Event: &InboxEvent{
+			Type: models.InboxEventCycleProgress,
+			CycleProgress: &CycleProgressUpdate{
+				CycleID:  cycle.ID.Hex(),
+				Name:     cycle.Name,
+				Status:   cycle.Status,
+				Progress: cycle.Progress,
+			},
+		},
+	}
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("failed to encode cycle progress: %w", err)
+	}
+	if err := s.broker.Publish(ctx, inboxChannel, payload); err != nil {
+		return fmt.Errorf("failed to publish cycle progress: %w", err)
+	}
+	return nil
+}
+
+// Subscribe registers a live session of a user. Events are delivered once
+// Listen relays them; the returned channel is closed when ctx is cancelled.
+//
+// Parameters:
+//   - ctx: Context of the session, typically the streaming request's context
+//   - orgID: Organization of the user, receiving organization-wide events
+//   - userID: User receiving their own notifications
+//
+// Returns:
+//   - <-chan *InboxEvent: Live inbox events
+//   - error: ErrInvalidInput for malformed IDs
+func (s *notificationInboxService) Subscribe(ctx context.Context, orgID, userID string) (<-chan *InboxEvent, error) {
+	if _, err := primitive.ObjectIDFromHex(orgID); err != nil {
+		return nil, ErrInvalidInput
+	}
+	if _, err := primitive.ObjectIDFromHex(userID); err != nil {
+		return nil, ErrInvalidInput
+	}
+
+	subscriber := &inboxSubscriber{
+		orgID:  orgID,
+		userID: userID,
+		events: make(chan *InboxEvent, inboxSubscriberBuffer),
+	}
+	s.mu.Lock()
+	s.subscribers[subscriber] = struct{}{}
+	s.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		delete(s.subscribers, subscriber)
+		s.mu.Unlock()
+		close(subscriber.events)
+	}()
+	return subscriber.events, nil
+}
+
+// Listen subscribes to the inbox channel and fans out the events published by
+// any server instance to the sessions subscribed on this instance.
+//
+// Parameters:
+//   - ctx: Context controlling the relay lifetime
+//
+// Returns:
+//   - error: Subscription error; nil once ctx is cancelled
+func (s *notificationInboxService) Listen(ctx context.Context) error {
+	messages, err := s.broker.Subscribe(ctx, inboxChannel)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to inbox events: %w", err)
+	}
+
+	for payload := range messages {
+		var envelope inboxEnvelope
+		if err := json.Unmarshal(payload, &envelope); err != nil || envelope.Event == nil {
+			s.logger.Warn("Discarding malformed inbox event", zap.Error(err))
+			continue
+		}
+		s.fanOut(&envelope)
+	}
+	return nil
+}
+
+// publish sends an inbox event to all server instances, logging failures.
+func (s *notificationInboxService) publish(ctx context.Context, envelope *inboxEnvelope) {
+	payload, err := json.Marshal(envelope)
+	if err == nil {
+		err = s.broker.Publish(ctx, inboxChannel, payload)
+	}
+	if err != nil {
+		s.logger.Warn("Failed to push inbox event",
+			zap.Error(err),
+			zap.String("type", envelope.Event.Type),
+			zap.String("user_id", envelope.UserID),
+		)
+	}
+}
+
+// fanOut delivers an event to the matching sessions on this instance without
+// blocking: sessions whose buffer is full miss the event.
+func (s *notificationInboxService) fanOut(envelope *inboxEnvelope) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for subscriber := range s.subscribers {
+		if envelope.UserID != "" && subscriber.userID != envelope.UserID {
+			continue
+		}
+		if envelope.UserID == "" && subscriber.orgID != envelope.OrganizationID {
+			continue
+		}
+		select {
+		case subscriber.events <- envelope.Event:
+		default:
+			s.logger.Warn("Dropping inbox event for slow session",
+				zap.String("type", envelope.Event.Type),
+				zap.String("user_id", subscriber.userID),
+			)
+		}
+	}
+}
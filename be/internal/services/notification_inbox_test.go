@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+)
+
+// listen runs the inbox relay until the test ends and waits for its subscription.
+func listen(t *testing.T, inbox NotificationInboxService, pubsub *fakePubSub) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(t, inbox.Listen(ctx))
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	require.Eventually(t, func() bool {
+		pubsub.mu.Lock()
+		defer pubsub.mu.Unlock()
+		return len(pubsub.subscribers) == 1
+	}, time.Second, time.Millisecond)
+}
+
+// subscribe opens a live session that ends with the test.
+func subscribe(t *testing.T, inbox NotificationInboxService, orgID, userID primitive.ObjectID) <-chan *InboxEvent {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	events, err := inbox.Subscribe(ctx, orgID.Hex(), userID.Hex())
+	require.NoError(t, err)
+	return events
+}
+
+// nextEvent waits for the next event of a session, or returns nil.
+func nextEvent(events <-chan *InboxEvent, wait time.Duration) *InboxEvent {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(wait):
+		return nil
+	}
+}
+
+func TestNotificationService_DeliversInAppNotifications(t *testing.T) {
+	f := newNotificationFixture(t)
+	ctx := context.Background()
+	f.org.Settings.Notifications.WebEnabled = true
+	f.assignee.Metadata.Preferences.BrowserNotifications = true
+	listen(t, f.inboxService, f.pubsub)
+
+	assigneeEvents := subscribe(t, f.inboxService, f.org.ID, f.assignee.ID)
+	reviewerEvents := subscribe(t, f.inboxService, f.org.ID, f.reviewer.ID)
+
+	request := f.request("Access review")
+	require.NoError(t, f.service.SendEvidenceRequest(ctx, request))
+
+	// The notification is both emailed and added to the inbox
+	assert.Equal(t, []string{"alice@bank.com"}, f.recipients())
+	require.Len(t, f.inbox.notifications, 1)
+	stored := f.inbox.notifications[0]
+	assert.Equal(t, f.assignee.ID, stored.UserID)
+	assert.Equal(t, models.NotificationTypeEvidenceRequest, stored.Type)
+	assert.NotContains(t, stored.Title, "[First Bank]")
+	assert.Contains(t, stored.Title, "Access review")
+	assert.Equal(t, "https://app.goedu.test/evidence-requests/"+request.ID.Hex(), stored.Link)
+	assert.False(t, stored.IsRead())
+
+	event := nextEvent(assigneeEvents, time.Second)
+	require.NotNil(t, event)
+	assert.Equal(t, models.InboxEventNotification, event.Type)
+	assert.Equal(t, stored.ID, event.Notification.ID)
+	assert.True(t, event.Browser)
+	assert.Nil(t, nextEvent(reviewerEvents, 50*time.Millisecond))
+
+	// Opting out of in-app notifications keeps the inbox empty but still sends email
+	disabled := false
+	f.assignee.Metadata.Preferences.Notifications.InApp = &disabled
+	require.NoError(t, f.service.SendEvidenceRequest(ctx, request))
+	assert.Len(t, f.outbox.messages, 2)
+	assert.Len(t, f.inbox.notifications, 1)
+
+	// In-app only users are not emailed
+	f.assignee.Metadata.Preferences.Notifications = models.NotificationOverrides{Email: &disabled}
+	require.NoError(t, f.service.SendEvidenceRequest(ctx, request))
+	assert.Len(t, f.outbox.messages, 2)
+	assert.Len(t, f.inbox.notifications, 2)
+}
+
+func TestNotificationInboxService_ListAndMarkRead(t *testing.T) {
+	repo := &fakeInAppNotificationRepository{}
+	inbox := NewNotificationInboxService(repo, &fakePubSub{}, zap.NewNop())
+	ctx := context.Background()
+	orgID := primitive.NewObjectID()
+	userID := primitive.NewObjectID()
+
+	for _, title := range []string{"First", "Second", "Third"} {
+		require.NoError(t, inbox.Deliver(ctx, &models.InAppNotification{
+			OrganizationID: orgID,
+			UserID:         userID,
+			Type:           models.NotificationTypeReminder,
+			Title:          title,
+		}, false))
+	}
+	require.NoError(t, inbox.Deliver(ctx, &models.InAppNotification{
+		OrganizationID: orgID,
+		UserID:         primitive.NewObjectID(),
+		Type:           models.NotificationTypeReminder,
+		Title:          "Someone else's",
+	}, false))
+
+	page, err := inbox.ListNotifications(ctx, userID.Hex(), &InboxFilter{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, page.Nodes, 2)
+	assert.Equal(t, "Third", page.Nodes[0].Title)
+	assert.Equal(t, 3, page.TotalCount)
+	assert.Equal(t, 3, page.UnreadCount)
+	assert.True(t, page.HasMore)
+
+	marked, err := inbox.MarkRead(ctx, userID.Hex(), []string{page.Nodes[0].ID.Hex(), repo.notifications[3].ID.Hex()})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), marked, "other users' notifications are not marked")
+
+	unread, err := inbox.ListNotifications(ctx, userID.Hex(), &InboxFilter{UnreadOnly: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, unread.TotalCount)
+	assert.Equal(t, 2, unread.UnreadCount)
+	assert.False(t, unread.HasMore)
+
+	marked, err = inbox.MarkAllRead(ctx, userID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), marked)
+
+	page, err = inbox.ListNotifications(ctx, userID.Hex(), nil)
+	require.NoError(t, err)
+	assert.Len(t, page.Nodes, 3)
+	assert.Equal(t, 0, page.UnreadCount)
+
+	_, err = inbox.MarkRead(ctx, userID.Hex(), []string{"not-an-id"})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	_, err = inbox.MarkRead(ctx, userID.Hex(), nil)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	_, err = inbox.ListNotifications(ctx, userID.Hex(), &InboxFilter{Offset: -1})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
+
+func TestNotificationInboxService_PublishCycleProgress(t *testing.T) {
+	pubsub := &fakePubSub{}
+	inbox := NewNotificationInboxService(&fakeInAppNotificationRepository{}, pubsub, zap.NewNop())
+	listen(t, inbox, pubsub)
+
+	orgID := primitive.NewObjectID()
+	member := subscribe(t, inbox, orgID, primitive.NewObjectID())
+	otherMember := subscribe(t, inbox, orgID, primitive.NewObjectID())
+	outsider := subscribe(t, inbox, primitive.NewObjectID(), primitive.NewObjectID())
+
+	cycle := &models.TestingCycle{
+		OrganizationID: orgID,
+		Name:           "Q1 2025 SOX",
+		Status:         "active",
+		Progress:       models.Progress{TotalControls: 40, CompletedControls: 10, PercentComplete: 25},
+	}
+	cycle.ID = primitive.NewObjectID()
+	require.NoError(t, inbox.PublishCycleProgress(context.Background(), cycle))
+
+	for _, events := range []<-chan *InboxEvent{member, otherMember} {
+		event := nextEvent(events, time.Second)
+		require.NotNil(t, event)
+		assert.Equal(t, models.InboxEventCycleProgress, event.Type)
+		assert.Equal(t, cycle.ID.Hex(), event.CycleProgress.CycleID)
+		assert.Equal(t, 25, event.CycleProgress.Progress.PercentComplete)
+	}
+	assert.Nil(t, nextEvent(outsider, 50*time.Millisecond))
+}
@@ -0,0 +1,500 @@
+// Package services provides service layer implementations for the GoEdu Control Testing Platform.
+// This file contains the notification email templates: the built-in HTML and text
+// templates of every notification type, per-organization overrides and rendering.
+package services
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+)
+
+// Notification template errors
+var (
+	ErrUnknownNotificationType      = errors.New("unknown notification type")
+	ErrInvalidNotificationTemplate  = errors.New("invalid notification template")
+	ErrNotificationTemplateNotFound = errors.New("notification template override not found")
+)
+
+//go:embed templates/notifications/*.tmpl
+var notificationTemplateFiles embed.FS
+
+// notificationTypes lists the notification types in the order they are presented to admins.
+var notificationTypes = []string{
+	models.NotificationTypeEvidenceRequest,
+	models.NotificationTypeReminder,
+	models.NotificationTypeEscalation,
+	models.NotificationTypeReviewRequested,
+	models.NotificationTypeReviewApproved,
+	models.NotificationTypeReviewRejected,
+	models.NotificationTypeMention,
+	models.NotificationTypeTestingCycle,
+	models.NotificationTypeSystemAlert,
+	models.NotificationTypeDigest,
+	models.NotificationTypeInvitation,
+	models.NotificationTypePasswordReset,
+}
+
+// notificationSampleData holds the type specific template fields with sample
+// values. Every key here is also set when the notification is sent, so
+// overrides validated against the samples render for real notifications too.
+var notificationSampleData = map[string]map[string]interface{}{
+	models.NotificationTypeEvidenceRequest: {
+		"RequestID":    "REQ-1042",
+		"Title":        "Quarterly user access review",
+		"Instructions": "Upload the signed access review report for all privileged accounts.",
+		"DueDate":      "15 March 2025",
+	},
+	models.NotificationTypeReminder: {
+		"RequestID": "REQ-1042",
+		"Title":     "Quarterly user access review",
+		"DueDate":   "15 March 2025",
+		"Overdue":   false,
+	},
+	models.NotificationTypeEscalation: {
+		"RequestID":    "REQ-1042",
+		"Title":        "Quarterly user access review",
+		"DueDate":      "15 March 2025",
+		"AssigneeName": "Jordan Lee",
+	},
+	models.NotificationTypeReviewRequested: {
+		"RequestID": "REQ-1042",
+		"Title":     "Quarterly user access review",
+		"Round":     1,
+	},
+	models.NotificationTypeReviewApproved: {
+		"RequestID": "REQ-1042",
+		"Title":     "Quarterly user access review",
+	},
+	models.NotificationTypeReviewRejected: {
+		"RequestID": "REQ-1042",
+		"Title":     "Quarterly user access review",
+		"Comment":   "The report is missing the approval signatures.",
+	},
+	models.NotificationTypeMention: {
+		"AuthorName":   "Jordan Lee",
+		"Excerpt":      "@alex could you confirm the sample selection?",
+		"ResourceType": models.ResourceTypeEvidenceRequest,
+	},
+	models.NotificationTypeTestingCycle: {
+		"CycleName":       "Q1 2025 SOX testing",
+		"Event":           "started",
+		"StartDate":       "1 January 2025",
+		"EndDate":         "31 March 2025",
+		"PercentComplete": 0,
+	},
+	models.NotificationTypeSystemAlert: {
+		"Severity": "warning",
+		"Title":    "Evidence storage nearly full",
+		"Message":  "Evidence storage is at 90% of the subscription quota.",
+	},
+	models.NotificationTypeDigest: {
+		"Frequency": models.DigestFrequencyDaily,
+		"Items": []map[string]interface{}{
+			{"Subject": "Reminder: evidence for REQ-1042 is due 15 March 2025", "Link": "https://app.goedu.com/evidence-requests/1042"},
+			{"Subject": "Jordan Lee mentioned you in a comment", "Link": "https://app.goedu.com/evidence-requests/1042"},
+		},
+	},
+	models.NotificationTypeInvitation: {
+		"InviterName": "Jordan Lee",
+		"Role":        models.RoleAuditor,
+		"ExpiresAt":   "22 March 2025",
+	},
+	models.NotificationTypePasswordReset: {
+		"ExpiresIn": "60 minutes",
+	},
+}
+
+// Shared layouts wrapping the "content" template of every text and HTML body.
+var (
+	notificationTextLayout = mustReadNotificationTemplate("layout.txt.tmpl")
+	notificationHTMLLayout = mustReadNotificationTemplate("layout.html.tmpl")
+)
+
+// builtinNotificationTemplates holds the embedded template of every notification type.
+var builtinNotificationTemplates = loadBuiltinNotificationTemplates()
+
+func loadBuiltinNotificationTemplates() map[string]*models.NotificationTemplate {
+	templates := make(map[string]*models.NotificationTemplate, len(notificationTypes))
+	for _, notificationType := range notificationTypes {
+		templates[notificationType] = &models.NotificationTemplate{
+			Type:    notificationType,
+			Subject: mustReadNotificationTemplate(notificationType + ".subject.tmpl"),
+			Text:    mustReadNotificationTemplate(notificationType + ".txt.tmpl"),
+			HTML:    mustReadNotificationTemplate(notificationType + ".html.tmpl"),
+		}
+	}
+	return templates
+}
+
+func mustReadNotificationTemplate(name string) string {
+	content, err := notificationTemplateFiles.ReadFile("templates/notifications/" + name)
+	if err != nil {
+		panic(fmt.Sprintf("missing built-in notification template %s: %v", name, err))
+	}
+	return string(content)
+}
+
+// renderNotification executes the subject, text and HTML templates of a
+// notification. Missing data fields are errors rather than "<no value>".
+//
+// Parameters:
+//   - source: Templates to render
+//   - data: Template data
+//
+// Returns:
+//   - *RenderedNotification: Rendered subject and bodies
+//   - error: Error naming the part that failed to parse or execute
+func renderNotification(source *models.NotificationTemplate, data map[string]interface{}) (*RenderedNotification, error) {
+	subject, err := executeTextTemplate("", source.Subject, data)
+	if err != nil {
+		return nil, fmt.Errorf("subject: %w", err)
+	}
+	text, err := executeTextTemplate(notificationTextLayout, source.Text, data)
+	if err != nil {
+		return nil, fmt.Errorf("text: %w", err)
+	}
+	html, err := executeHTMLTemplate(notificationHTMLLayout, source.HTML, data)
+	if err != nil {
+		return nil, fmt.Errorf("html: %w", err)
+	}
+
+	return &RenderedNotification{
+		// Subjects are single header lines
+		Subject: strings.Join(strings.Fields(subject), " "),
+		Text:    strings.TrimSpace(text) + "\n",
+		HTML:    html,
+	}, nil
+}
+
+// executeTextTemplate renders content inside layout; an empty layout renders content alone.
+func executeTextTemplate(layout, content string, data map[string]interface{}) (string, error) {
+	root := texttemplate.New("content").Option("missingkey=error")
+	if layout != "" {
+		root = texttemplate.New("layout").Option("missingkey=error")
+		if _, err := root.Parse(layout); err != nil {
+			return "", err
+		}
+		if _, err := root.New("content").Parse(content); err != nil {
+			return "", err
+		}
+	} else if _, err := root.Parse(content); err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	if err := root.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// executeHTMLTemplate renders content inside layout with contextual HTML escaping.
+func executeHTMLTemplate(layout, content string, data map[string]interface{}) (string, error) {
+	root, err := htmltemplate.New("layout").Option("missingkey=error").Parse(layout)
+	if err != nil {
+		return "", err
+	}
+	if _, err := root.New("content").Parse(content); err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	if err := root.ExecuteTemplate(&out, "layout", data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// mergeNotificationTemplate returns the built-in template with the non-empty
+// parts of an override applied.
+func mergeNotificationTemplate(builtin, override *models.NotificationTemplate) *models.NotificationTemplate {
+	merged := *builtin
+	if override == nil {
+		return &merged
+	}
+	if override.Subject != "" {
+		merged.Subject = override.Subject
+	}
+	if override.Text != "" {
+		merged.Text = override.Text
+	}
+	if override.HTML != "" {
+		merged.HTML = override.HTML
+	}
+	return &merged
+}
+
+// notificationRenderer renders notifications with the organization's effective templates.
+type notificationRenderer struct {
+	templateRepo repositories.NotificationTemplateRepository
+	logger       *zap.Logger
+}
+
+// override loads an organization's override of a notification type, if any.
+func (r *notificationRenderer) override(ctx context.Context, orgID, notificationType string) (*models.NotificationTemplate, error) {
+	if orgID == "" || orgID == primitive.NilObjectID.Hex() {
+		return nil, nil
+	}
+	override, err := r.templateRepo.Get(ctx, orgID, notificationType)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load notification template: %w", err)
+	}
+	return override, nil
+}
+
+// Render renders a notification with the organization's override of the type,
+// falling back to the built-in template if the override fails to render.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID, empty for notifications outside an organization
+//   - notificationType: Notification type
+//   - data: Template data
+//
+// Returns:
+//   - *RenderedNotification: Rendered notification
+//   - error: Error if the type is unknown or no template renders
+func (r *notificationRenderer) Render(ctx context.Context, orgID, notificationType string, data map[string]interface{}) (*RenderedNotification, error) {
+	builtin, ok := builtinNotificationTemplates[notificationType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownNotificationType, notificationType)
+	}
+
+	override, err := r.override(ctx, orgID, notificationType)
+	if err != nil {
+		return nil, err
+	}
+	if override != nil {
+		rendered, err := renderNotification(mergeNotificationTemplate(builtin, override), data)
+		if err == nil {
+			return rendered, nil
+		}
+		r.logger.Warn("Notification template override failed to render, using built-in template",
+			zap.Error(err),
+			zap.String("organization_id", orgID),
+			zap.String("type", notificationType),
+		)
+	}
+
+	rendered, err := renderNotification(builtin, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s notification: %w", notificationType, err)
+	}
+	return rendered, nil
+}
+
+// notificationTemplateService implements the NotificationTemplateService interface.
+type notificationTemplateService struct {
+	orgRepo      repositories.OrganizationRepository
+	templateRepo repositories.NotificationTemplateRepository
+	appURL       string
+	logger       *zap.Logger
+}
+
+// NewNotificationTemplateService creates a new notification template service.
+//
+// Parameters:
+//   - orgRepo: Repository for organization data operations
+//   - templateRepo: Repository for per-organization template overrides
+//   - cfg: Email configuration providing the application URL used in previews
+//   - logger: Logger for service operations
+//
+// Returns:
+//   - NotificationTemplateService: Configured template service instance
+func NewNotificationTemplateService(
+	orgRepo repositories.OrganizationRepository,
+	templateRepo repositories.NotificationTemplateRepository,
+	cfg config.EmailConfig,
+	logger *zap.Logger,
+) NotificationTemplateService {
+	return &notificationTemplateService{
+		orgRepo:      orgRepo,
+		templateRepo: templateRepo,
+		appURL:       strings.TrimRight(cfg.AppURL, "/"),
+		logger:       logger,
+	}
+}
+
+// ListTemplates retrieves the effective template of every notification type.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID
+//
+// Returns:
+//   - []*NotificationTemplateView: One view per notification type
+//   - error: Error if the overrides cannot be loaded
+func (s *notificationTemplateService) ListTemplates(ctx context.Context, orgID string) ([]*NotificationTemplateView, error) {
+	overrides, err := s.templateRepo.GetByOrganization(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load notification templates: %w", err)
+	}
+	byType := make(map[string]*models.NotificationTemplate, len(overrides))
+	for _, override := range overrides {
+		byType[override.Type] = override
+	}
+
+	views := make([]*NotificationTemplateView, 0, len(notificationTypes))
+	for _, notificationType := range notificationTypes {
+		views = append(views, notificationTemplateView(builtinNotificationTemplates[notificationType], byType[notificationType]))
+	}
+	return views, nil
+}
+
+// SetTemplate validates and stores an organization override. The merged
+// template must render the type's sample data without errors.
+//
+// Parameters:
+//   - ctx: Request context
+//   - input: Override to store
+//
+// Returns:
+//   - *NotificationTemplateView: Effective template after the change
+//   - error: ErrUnknownNotificationType or ErrInvalidNotificationTemplate with details
+func (s *notificationTemplateService) SetTemplate(ctx context.Context, input *NotificationTemplateInput) (*NotificationTemplateView, error) {
+	builtin, ok := builtinNotificationTemplates[input.Type]
+	if !ok {
+		return nil, ErrUnknownNotificationType
+	}
+	if strings.TrimSpace(input.Subject+input.Text+input.HTML) == "" {
+		return nil, fmt.Errorf("%w: at least one of subject, text or html is required", ErrInvalidNotificationTemplate)
+	}
+	orgID, err := primitive.ObjectIDFromHex(input.OrganizationID)
+	if err != nil {
+		return nil, ErrInvalidInput
+	}
+	updatedBy, _ := primitive.ObjectIDFromHex(input.UpdatedBy)
+
+	override := &models.NotificationTemplate{
+		OrganizationID: orgID,
+		Type:           input.Type,
+		Subject:        input.Subject,
+		Text:           input.Text,
+		HTML:           input.HTML,
+		UpdatedBy:      updatedBy,
+		UpdatedAt:      time.Now().UTC(),
+	}
+	data, err := s.sampleData(ctx, input.OrganizationID, input.Type)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := renderNotification(mergeNotificationTemplate(builtin, override), data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNotificationTemplate, err)
+	}
+
+	if err := s.templateRepo.Upsert(ctx, override); err != nil {
+		return nil, fmt.Errorf("failed to store notification template: %w", err)
+	}
+
+	s.logger.Info("Notification template overridden",
+		zap.String("organization_id", input.OrganizationID),
+		zap.String("type", input.Type),
+		zap.String("updated_by", input.UpdatedBy),
+	)
+	return notificationTemplateView(builtin, override), nil
+}
+
+// ResetTemplate removes an organization override.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID
+//   - notificationType: Notification type
+//
+// Returns:
+//   - error: ErrUnknownNotificationType or ErrNotificationTemplateNotFound
+func (s *notificationTemplateService) ResetTemplate(ctx context.Context, orgID, notificationType string) error {
+	if _, ok := builtinNotificationTemplates[notificationType]; !ok {
+		return ErrUnknownNotificationType
+	}
+	err := s.templateRepo.Delete(ctx, orgID, notificationType)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return ErrNotificationTemplateNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete notification template: %w", err)
+	}
+	return nil
+}
+
+// PreviewTemplate renders the effective template of a notification type with sample data.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID
+//   - notificationType: Notification type
+//
+// Returns:
+//   - *RenderedNotification: Rendered sample notification
+//   - error: ErrUnknownNotificationType or a rendering error
+func (s *notificationTemplateService) PreviewTemplate(ctx context.Context, orgID, notificationType string) (*RenderedNotification, error) {
+	if _, ok := builtinNotificationTemplates[notificationType]; !ok {
+		return nil, ErrUnknownNotificationType
+	}
+	data, err := s.sampleData(ctx, orgID, notificationType)
+	if err != nil {
+		return nil, err
+	}
+	renderer := &notificationRenderer{templateRepo: s.templateRepo, logger: s.logger}
+	return renderer.Render(ctx, orgID, notificationType, data)
+}
+
+// sampleData builds preview data for a notification type in the organization's name.
+func (s *notificationTemplateService) sampleData(ctx context.Context, orgID, notificationType string) (map[string]interface{}, error) {
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrInvalidInput
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+
+	data := notificationBaseData(s.appURL, org.Name, "Alex Morgan", s.appURL+"/notifications/preview")
+	for key, value := range notificationSampleData[notificationType] {
+		data[key] = value
+	}
+	return data, nil
+}
+
+// notificationBaseData returns the template fields shared by all notification types.
+func notificationBaseData(appURL, organizationName, recipientName, link string) map[string]interface{} {
+	return map[string]interface{}{
+		"AppURL":           appURL,
+		"OrganizationName": organizationName,
+		"RecipientName":    recipientName,
+		"Link":             link,
+	}
+}
+
+// notificationTemplateView describes the effective template of a type.
+func notificationTemplateView(builtin, override *models.NotificationTemplate) *NotificationTemplateView {
+	merged := mergeNotificationTemplate(builtin, override)
+	view := &NotificationTemplateView{
+		Type:    builtin.Type,
+		Subject: merged.Subject,
+		Text:    merged.Text,
+		HTML:    merged.HTML,
+	}
+	if override != nil {
+		view.Overridden = true
+		view.UpdatedAt = override.UpdatedAt
+	}
+	return view
+}
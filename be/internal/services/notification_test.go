@@ -0,0 +1,541 @@
+package services
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/mail"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/mail/mailtest"
+)
+
+type fakeNotificationTemplateRepository struct {
+	repositories.NotificationTemplateRepository
+	templates map[string]*models.NotificationTemplate
+}
+
+func newFakeNotificationTemplateRepository() *fakeNotificationTemplateRepository {
+	return &fakeNotificationTemplateRepository{templates: make(map[string]*models.NotificationTemplate)}
+}
+
+func (r *fakeNotificationTemplateRepository) Get(ctx context.Context, orgID, notificationType string) (*models.NotificationTemplate, error) {
+	template, ok := r.templates[orgID+"/"+notificationType]
+	if !ok {
+		return nil, repositories.ErrNotFound
+	}
+	return template, nil
+}
+
+func (r *fakeNotificationTemplateRepository) Upsert(ctx context.Context, template *models.NotificationTemplate) error {
+	r.templates[template.OrganizationID.Hex()+"/"+template.Type] = template
+	return nil
+}
+
+func (r *fakeNotificationTemplateRepository) Delete(ctx context.Context, orgID, notificationType string) error {
+	if _, ok := r.templates[orgID+"/"+notificationType]; !ok {
+		return repositories.ErrNotFound
+	}
+	delete(r.templates, orgID+"/"+notificationType)
+	return nil
+}
+
+func (r *fakeNotificationTemplateRepository) GetByOrganization(ctx context.Context, orgID string) ([]*models.NotificationTemplate, error) {
+	var templates []*models.NotificationTemplate
+	for _, template := range r.templates {
+		if template.OrganizationID.Hex() == orgID {
+			templates = append(templates, template)
+		}
+	}
+	return templates, nil
+}
+
+type fakeNotificationOutboxRepository struct {
+	repositories.NotificationOutboxRepository
+	mu       sync.Mutex
+	messages []*models.OutboxMessage
+}
+
+func (r *fakeNotificationOutboxRepository) Create(ctx context.Context, message *models.OutboxMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, message)
+	return nil
+}
+
+func (r *fakeNotificationOutboxRepository) Update(ctx context.Context, message *models.OutboxMessage) error {
+	return nil
+}
+
+func (r *fakeNotificationOutboxRepository) ClaimDue(ctx context.Context, now, leaseUntil time.Time) (*models.OutboxMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, message := range r.messages {
+		due := message.Status == models.OutboxStatusPending && !message.NextAttemptAt.After(now)
+		abandoned := message.Status == models.OutboxStatusSending && !message.LockedUntil.After(now)
+		if due || abandoned {
+			message.Status = models.OutboxStatusSending
+			message.LockedUntil = leaseUntil
+			return message, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+type fakeNotificationDigestRepository struct {
+	repositories.NotificationDigestRepository
+	mu    sync.Mutex
+	items []*models.NotificationDigestItem
+}
+
+func (r *fakeNotificationDigestRepository) Create(ctx context.Context, item *models.NotificationDigestItem) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.items = append(r.items, item)
+	return nil
+}
+
+func (r *fakeNotificationDigestRepository) ClaimDue(ctx context.Context, now, leaseUntil time.Time) ([]*models.NotificationDigestItem, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	claimable := func(item *models.NotificationDigestItem) bool {
+		return !item.DueAt.After(now) && !item.LockedUntil.After(now)
+	}
+	var oldest *models.NotificationDigestItem
+	for _, item := range r.items {
+		if claimable(item) && (oldest == nil || item.DueAt.Before(oldest.DueAt)) {
+			oldest = item
+		}
+	}
+	if oldest == nil {
+		return nil, repositories.ErrNotFound
+	}
+	var claimed []*models.NotificationDigestItem
+	for _, item := range r.items {
+		if item.UserID == oldest.UserID && claimable(item) {
+			item.LockedUntil = leaseUntil
+			claimed = append(claimed, item)
+		}
+	}
+	return claimed, nil
+}
+
+func (r *fakeNotificationDigestRepository) Delete(ctx context.Context, ids []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	remove := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		remove[id] = true
+	}
+	kept := r.items[:0]
+	for _, item := range r.items {
+		if !remove[item.ID.Hex()] {
+			kept = append(kept, item)
+		}
+	}
+	r.items = kept
+	return nil
+}
+
+type notificationFixture struct {
+	org          *models.Organization
+	assignee     *models.User
+	reviewer     *models.User
+	admin        *models.User
+	userRepo     *fakeUserRepository
+	templateRepo *fakeNotificationTemplateRepository
+	outbox       *fakeNotificationOutboxRepository
+	digests      *fakeNotificationDigestRepository
+	inbox        *fakeInAppNotificationRepository
+	pubsub       *fakePubSub
+	server       *mailtest.Server
+	service      NotificationService
+	templates    NotificationTemplateService
+	dispatcher   NotificationDispatcher
+	digester     NotificationDigester
+	inboxService NotificationInboxService
+}
+
+func newNotificationFixture(t *testing.T) *notificationFixture {
+	t.Helper()
+
+	server, err := mailtest.NewServer()
+	require.NoError(t, err)
+	t.Cleanup(func() { server.Close() })
+
+	org := &models.Organization{Name: "First Bank", Status: models.OrganizationStatusActive}
+	org.ID = primitive.NewObjectID()
+	org.Settings.Notifications = models.OrganizationNotifications{
+		EmailEnabled:      true,
+		EvidenceRequests:  true,
+		DeadlineReminders: true,
+		SystemAlerts:      true,
+	}
+	newUser := func(email, first string, roles ...string) *models.User {
+		user := &models.User{Email: email, OrganizationID: org.ID, IsActive: true, Roles: roles}
+		user.ID = primitive.NewObjectID()
+		user.Profile.FirstName = first
+		user.Profile.LastName = "Tester"
+		return user
+	}
+
+	cfg := config.EmailConfig{
+		From:            "GoEdu <noreply@goedu.com>",
+		AppURL:          "https://app.goedu.test/",
+		SMTPHost:        server.Host(),
+		SMTPPort:        server.Port(),
+		SMTPTimeout:     5 * time.Second,
+		OutboxBatchSize: 10,
+		MaxAttempts:     3,
+		RetryDelay:      time.Minute,
+		MaxRetryDelay:   time.Hour,
+		DigestHour:      8,
+	}
+
+	f := &notificationFixture{
+		org:          org,
+		assignee:     newUser("alice@bank.com", "Alice", models.RoleOwner),
+		reviewer:     newUser("rita@bank.com", "Rita", models.RoleAuditor),
+		admin:        newUser("adam@bank.com", "Adam", models.RoleAdmin),
+		templateRepo: newFakeNotificationTemplateRepository(),
+		outbox:       &fakeNotificationOutboxRepository{},
+		digests:      &fakeNotificationDigestRepository{},
+		inbox:        &fakeInAppNotificationRepository{},
+		pubsub:       &fakePubSub{},
+		server:       server,
+	}
+	f.userRepo = newFakeUserRepository(f.assignee, f.reviewer, f.admin)
+	orgRepo := newFakeOrganizationRepository(org)
+	f.inboxService = NewNotificationInboxService(f.inbox, f.pubsub, zap.NewNop())
+	f.service = NewNotificationService(orgRepo, f.userRepo, f.templateRepo, f.outbox, f.digests, f.inboxService, cfg, zap.NewNop())
+	f.digester = NewNotificationDigester(orgRepo, f.userRepo, f.templateRepo, f.outbox, f.digests, cfg, zap.NewNop())
+	f.templates = NewNotificationTemplateService(orgRepo, f.templateRepo, cfg, zap.NewNop())
+	sender := mail.NewSMTPSender(mail.SMTPConfig{Host: cfg.SMTPHost, Port: cfg.SMTPPort, Timeout: cfg.SMTPTimeout})
+	f.dispatcher = NewNotificationDispatcher(f.outbox, sender, cfg, zap.NewNop())
+	return f
+}
+
+func (f *notificationFixture) request(title string) *models.EvidenceRequest {
+	request := &models.EvidenceRequest{
+		OrganizationID: f.org.ID,
+		RequestID:      "REQ-9",
+		Title:          title,
+		AssigneeID:     f.assignee.ID,
+		DueDate:        time.Date(2025, 3, 15, 17, 0, 0, 0, time.UTC),
+		ReviewerIDs:    []primitive.ObjectID{f.reviewer.ID},
+		ReviewRound:    2,
+	}
+	request.ID = primitive.NewObjectID()
+	return request
+}
+
+// recipients returns the recipients of the queued outbox messages.
+func (f *notificationFixture) recipients() []string {
+	var recipients []string
+	for _, message := range f.outbox.messages {
+		recipients = append(recipients, message.Recipient)
+	}
+	return recipients
+}
+
+// bodies decodes the text and HTML parts of a received message.
+func bodies(t *testing.T, received mailtest.Received) (string, string) {
+	t.Helper()
+	parsed, err := received.Parse()
+	require.NoError(t, err)
+	_, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	require.NoError(t, err)
+
+	parts := map[string]string{}
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(part)
+		require.NoError(t, err)
+		mediaType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parts[mediaType] = string(content)
+	}
+	return parts["text/plain"], parts["text/html"]
+}
+
+func TestNotificationService_QueuesAndDeliversThroughSMTP(t *testing.T) {
+	f := newNotificationFixture(t)
+	ctx := context.Background()
+
+	request := f.request("Access review <Q1>")
+	require.NoError(t, f.service.SendEvidenceRequest(ctx, request))
+	require.Len(t, f.outbox.messages, 1)
+	queued := f.outbox.messages[0]
+	assert.Equal(t, models.OutboxStatusPending, queued.Status)
+	assert.Equal(t, models.NotificationTypeEvidenceRequest, queued.Type)
+	assert.Empty(t, f.server.Messages(), "nothing is sent before the dispatcher runs")
+
+	attempted, err := f.dispatcher.DispatchPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempted)
+	assert.Equal(t, models.OutboxStatusSent, queued.Status)
+	assert.Equal(t, 1, queued.Attempts)
+
+	messages := f.server.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, []string{"alice@bank.com"}, messages[0].To)
+	parsed, err := messages[0].Parse()
+	require.NoError(t, err)
+	assert.Equal(t, "[First Bank] Evidence requested: Access review <Q1> (REQ-9)", parsed.Header.Get("Subject"))
+	assert.Equal(t, "<"+queued.ID.Hex()+"@goedu.com>", parsed.Header.Get("Message-ID"))
+
+	text, html := bodies(t, messages[0])
+	assert.Contains(t, text, "Hello Alice Tester,")
+	assert.Contains(t, text, "15 March 2025")
+	assert.Contains(t, text, "https://app.goedu.test/evidence-requests/"+request.ID.Hex())
+	assert.Contains(t, html, "Access review &lt;Q1&gt;", "HTML bodies are escaped")
+	assert.NotContains(t, html, "<Q1>")
+
+	attempted, err = f.dispatcher.DispatchPending(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, attempted, "sent messages are not sent again")
+}
+
+func TestNotificationService_Recipients(t *testing.T) {
+	f := newNotificationFixture(t)
+	ctx := context.Background()
+	request := f.request("Access review")
+
+	require.NoError(t, f.service.SendReviewNotification(ctx, request, ReviewEventRequested))
+	require.NoError(t, f.service.SendReviewNotification(ctx, request, ReviewEventRejected))
+	require.NoError(t, f.service.SendEscalationNotification(ctx, request, f.admin))
+	assert.Equal(t, []string{"rita@bank.com", "alice@bank.com", "adam@bank.com"}, f.recipients())
+	assert.ErrorIs(t, f.service.SendReviewNotification(ctx, request, "reopened"), ErrInvalidInput)
+
+	f.outbox.messages = nil
+	cycle := &models.TestingCycle{OrganizationID: f.org.ID, Name: "Q1 SOX"}
+	cycle.ID = primitive.NewObjectID()
+	require.NoError(t, f.service.SendTestingCycleNotification(ctx, cycle, "completed"))
+	assert.Equal(t, []string{"adam@bank.com"}, f.recipients(), "cycle updates go to admins and managers")
+	assert.Equal(t, "[First Bank] Testing cycle Q1 SOX: completed", f.outbox.messages[0].Subject)
+
+	f.outbox.messages = nil
+	require.NoError(t, f.service.SendSystemAlert(ctx, &SystemAlert{
+		Severity: "critical",
+		Title:    "Storage full",
+		Message:  "Uploads are failing",
+		Metadata: map[string]interface{}{"organization_id": f.org.ID.Hex()},
+	}))
+	require.NoError(t, f.service.SendSystemAlert(ctx, &SystemAlert{
+		Severity:   "info",
+		Title:      "Maintenance",
+		Recipients: []string{"ops@goedu.com", f.reviewer.ID.Hex()},
+	}))
+	assert.Equal(t, []string{"adam@bank.com", "ops@goedu.com", "rita@bank.com"}, f.recipients())
+	assert.ErrorIs(t, f.service.SendSystemAlert(ctx, &SystemAlert{Title: "Nobody"}), ErrNoNotificationRecipients)
+}
+
+func TestNotificationService_Preferences(t *testing.T) {
+	f := newNotificationFixture(t)
+	ctx := context.Background()
+
+	prefs, err := f.service.GetNotificationPreferences(ctx, f.assignee.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, &NotificationPreferences{
+		Email:           true,
+		EvidenceRequest: true,
+		Reminders:       true,
+		SystemAlerts:    true,
+		DigestFrequency: models.DigestFrequencyImmediate,
+	}, prefs)
+
+	// Opting out of reminders only stores the difference from the organization defaults
+	prefs.Reminders = false
+	require.NoError(t, f.service.UpdateNotificationPreferences(ctx, f.assignee.ID.Hex(), prefs))
+	overrides := f.assignee.Metadata.Preferences.Notifications
+	require.NotNil(t, overrides.Reminders)
+	assert.False(t, *overrides.Reminders)
+	assert.Nil(t, overrides.Email)
+
+	request := f.request("Access review")
+	require.NoError(t, f.service.SendReminderNotification(ctx, request))
+	require.NoError(t, f.service.SendEvidenceRequest(ctx, request))
+	require.Len(t, f.outbox.messages, 1)
+	assert.Equal(t, models.NotificationTypeEvidenceRequest, f.outbox.messages[0].Type)
+
+	// Organization-wide email opt-out suppresses everything, including plain addresses
+	f.outbox.messages = nil
+	f.org.Settings.Notifications.EmailEnabled = false
+	require.NoError(t, f.service.SendEvidenceRequest(ctx, request))
+	require.NoError(t, f.service.SendSystemAlert(ctx, &SystemAlert{
+		Title:      "Maintenance",
+		Recipients: []string{"ops@goedu.com"},
+		Metadata:   map[string]interface{}{"organization_id": f.org.ID.Hex()},
+	}))
+	assert.Empty(t, f.outbox.messages)
+}
+
+func TestNotificationService_UsesOrganizationTemplateOverrides(t *testing.T) {
+	f := newNotificationFixture(t)
+	ctx := context.Background()
+
+	view, err := f.templates.SetTemplate(ctx, &NotificationTemplateInput{
+		OrganizationID: f.org.ID.Hex(),
+		Type:           models.NotificationTypeEvidenceRequest,
+		UpdatedBy:      f.admin.ID.Hex(),
+		Subject:        "Action needed: {{.RequestID}} by {{.DueDate}}",
+		HTML:           "<p>Dear {{.RecipientName}}, please see <a href=\"{{.Link}}\">{{.Title}}</a>.</p>",
+	})
+	require.NoError(t, err)
+	assert.True(t, view.Overridden)
+	assert.Contains(t, view.Text, "You have been asked", "parts without override keep the built-in template")
+
+	require.NoError(t, f.service.SendEvidenceRequest(ctx, f.request("Access review")))
+	message := f.outbox.messages[0]
+	assert.Equal(t, "Action needed: REQ-9 by 15 March 2025", message.Subject)
+	assert.Contains(t, message.HTML, "Dear Alice Tester")
+	assert.Contains(t, message.HTML, "Manage your notifications", "overrides are wrapped in the shared layout")
+	assert.Contains(t, message.Text, "You have been asked to provide evidence for REQ-9")
+
+	preview, err := f.templates.PreviewTemplate(ctx, f.org.ID.Hex(), models.NotificationTypeEvidenceRequest)
+	require.NoError(t, err)
+	assert.Equal(t, "Action needed: REQ-1042 by 15 March 2025", preview.Subject)
+
+	require.NoError(t, f.templates.ResetTemplate(ctx, f.org.ID.Hex(), models.NotificationTypeEvidenceRequest))
+	assert.ErrorIs(t, f.templates.ResetTemplate(ctx, f.org.ID.Hex(), models.NotificationTypeEvidenceRequest), ErrNotificationTemplateNotFound)
+
+	views, err := f.templates.ListTemplates(ctx, f.org.ID.Hex())
+	require.NoError(t, err)
+	assert.Len(t, views, len(notificationTypes))
+	for _, view := range views {
+		assert.False(t, view.Overridden, view.Type)
+	}
+}
+
+func TestNotificationTemplateService_RejectsInvalidTemplates(t *testing.T) {
+	f := newNotificationFixture(t)
+	ctx := context.Background()
+	input := func(notificationType, subject, html string) *NotificationTemplateInput {
+		return &NotificationTemplateInput{OrganizationID: f.org.ID.Hex(), Type: notificationType, Subject: subject, HTML: html}
+	}
+
+	_, err := f.templates.SetTemplate(ctx, input("weekly_digest", "Hi", ""))
+	assert.ErrorIs(t, err, ErrUnknownNotificationType)
+
+	for name, in := range map[string]*NotificationTemplateInput{
+		"empty":         input(models.NotificationTypeReminder, " ", ""),
+		"syntax error":  input(models.NotificationTypeReminder, "Due {{.DueDate", ""),
+		"unknown field": input(models.NotificationTypeReminder, "", "<p>{{.AssigneeName}}</p>"),
+	} {
+		_, err := f.templates.SetTemplate(ctx, in)
+		assert.ErrorIs(t, err, ErrInvalidNotificationTemplate, name)
+	}
+	assert.Empty(t, f.templateRepo.templates)
+}
+
+func TestNotificationTemplates_BuiltinsRenderSampleData(t *testing.T) {
+	for _, notificationType := range notificationTypes {
+		data := notificationBaseData("https://app.goedu.test", "First Bank", "Alex", "https://app.goedu.test/x")
+		for key, value := range notificationSampleData[notificationType] {
+			data[key] = value
+		}
+		rendered, err := renderNotification(builtinNotificationTemplates[notificationType], data)
+		require.NoError(t, err, notificationType)
+		assert.NotEmpty(t, rendered.Subject, notificationType)
+		assert.NotContains(t, rendered.Subject, "\n", notificationType)
+		assert.Contains(t, rendered.Text, "Alex", notificationType)
+		assert.True(t, strings.HasPrefix(rendered.HTML, "<!DOCTYPE html>"), notificationType)
+	}
+}
+
+func TestNotificationDispatcher_RetriesWithBackoff(t *testing.T) {
+	f := newNotificationFixture(t)
+	ctx := context.Background()
+	require.NoError(t, f.service.SendEvidenceRequest(ctx, f.request("Access review")))
+	message := f.outbox.messages[0]
+
+	f.server.Reject(1, "451 4.3.0 try again later")
+	attempted, err := f.dispatcher.DispatchPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempted)
+	assert.Equal(t, models.OutboxStatusPending, message.Status)
+	assert.Equal(t, 1, message.Attempts)
+	assert.Contains(t, message.LastError, "try again later")
+	assert.WithinDuration(t, time.Now().Add(time.Minute), message.NextAttemptAt, 5*time.Second)
+
+	attempted, err = f.dispatcher.DispatchPending(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, attempted, "the message is not due before its backoff elapses")
+
+	f.server.Reject(1, "451 4.3.0 try again later")
+	message.NextAttemptAt = time.Now().Add(-time.Second)
+	_, err = f.dispatcher.DispatchPending(ctx)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(2*time.Minute), message.NextAttemptAt, 5*time.Second, "backoff doubles")
+
+	message.NextAttemptAt = time.Now().Add(-time.Second)
+	_, err = f.dispatcher.DispatchPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, models.OutboxStatusSent, message.Status)
+	assert.Equal(t, 3, message.Attempts)
+	assert.Empty(t, message.LastError)
+	assert.Len(t, f.server.Messages(), 1)
+}
+
+func TestNotificationDispatcher_GivesUp(t *testing.T) {
+	f := newNotificationFixture(t)
+	ctx := context.Background()
+	require.NoError(t, f.service.SendEvidenceRequest(ctx, f.request("Access review")))
+	require.NoError(t, f.service.SendReminderNotification(ctx, f.request("Access review")))
+	rejected, exhausted := f.outbox.messages[0], f.outbox.messages[1]
+
+	// Permanent rejection of the first message, transient failure of the second
+	f.server.Reject(1, "550 5.1.1 mailbox unavailable")
+	f.server.Reject(1, "421 4.7.0 too many connections")
+	_, err := f.dispatcher.DispatchPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, models.OutboxStatusFailed, rejected.Status)
+	assert.Equal(t, 1, rejected.Attempts)
+	assert.Equal(t, models.OutboxStatusPending, exhausted.Status)
+
+	for exhausted.Status == models.OutboxStatusPending {
+		f.server.Reject(1, "421 4.7.0 too many connections")
+		exhausted.NextAttemptAt = time.Now().Add(-time.Second)
+		_, err := f.dispatcher.DispatchPending(ctx)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, models.OutboxStatusFailed, exhausted.Status)
+	assert.Equal(t, 3, exhausted.Attempts, "max attempts")
+	assert.Empty(t, f.server.Messages())
+}
+
+func TestNotificationDispatcher_ReclaimsAbandonedSends(t *testing.T) {
+	f := newNotificationFixture(t)
+	ctx := context.Background()
+	require.NoError(t, f.service.SendEvidenceRequest(ctx, f.request("Access review")))
+	message := f.outbox.messages[0]
+
+	// A dispatcher that crashed mid-send leaves the message leased
+	message.Status = models.OutboxStatusSending
+	message.LockedUntil = time.Now().Add(time.Minute)
+	attempted, err := f.dispatcher.DispatchPending(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, attempted)
+
+	message.LockedUntil = time.Now().Add(-time.Second)
+	attempted, err = f.dispatcher.DispatchPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempted)
+	assert.Equal(t, models.OutboxStatusSent, message.Status)
+}
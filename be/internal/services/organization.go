@@ -12,6 +12,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
 
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/events"
 	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
 	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
 )
@@ -22,18 +23,22 @@ import (
 type organizationService struct {
 	orgRepo     repositories.OrganizationRepository
 	userRepo    repositories.UserRepository
-	auditRepo   repositories.AuditLogRepository
 	cacheRepo   repositories.CacheRepository
+	transactor  repositories.Transactor
+	events      events.Publisher
 	logger      *zap.Logger
 }
 
 // NewOrganizationService creates a new organization service with required dependencies.
+// Audit logging and cache invalidation of changes happen in the subscribers of
+// the events the service publishes.
 //
 // Parameters:
 //   - orgRepo: Repository for organization data operations
 //   - userRepo: Repository for user data operations
-//   - auditRepo: Repository for audit logging
 //   - cacheRepo: Repository for caching operations
+//   - transactor: Runs a change and its events in one transaction
+//   - publisher: Publisher of organization events
 //   - logger: Logger for service operations
 //
 // Returns:
@@ -41,16 +46,18 @@ type organizationService struct {
 func NewOrganizationService(
 	orgRepo repositories.OrganizationRepository,
 	userRepo repositories.UserRepository,
-	auditRepo repositories.AuditLogRepository,
 	cacheRepo repositories.CacheRepository,
+	transactor repositories.Transactor,
+	publisher events.Publisher,
 	logger *zap.Logger,
 ) OrganizationService {
 	return &organizationService{
-		orgRepo:   orgRepo,
-		userRepo:  userRepo,
-		auditRepo: auditRepo,
-		cacheRepo: cacheRepo,
-		logger:    logger,
+		orgRepo:    orgRepo,
+		userRepo:   userRepo,
+		cacheRepo:  cacheRepo,
+		transactor: transactor,
+		events:     publisher,
+		logger:     logger,
 	}
 }
 
@@ -139,8 +146,19 @@ func (s *organizationService) CreateOrganization(ctx context.Context, input *Cre
 	// Initialize default feature flags
 	org.FeatureFlags = s.createDefaultFeatureFlags(input.SubscriptionPlan)
 
-	// Create organization in database
-	if err := s.orgRepo.Create(ctx, org); err != nil {
+	// Create organization in database together with its event
+	err := s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.orgRepo.Create(ctx, org); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, events.New(org.ID.Hex(), "organization", org.ID.Hex(), &events.OrganizationCreated{
+			Name:             org.Name,
+			Type:             org.Type,
+			Industry:         org.Industry,
+			SubscriptionPlan: org.Subscription.Plan,
+		}))
+	})
+	if err != nil {
 		s.logger.Error("Failed to create organization in database",
 			zap.Error(err),
 			zap.String("name", org.Name),
@@ -149,14 +167,6 @@ func (s *organizationService) CreateOrganization(ctx context.Context, input *Cre
 		return nil, fmt.Errorf("failed to create organization: %w", err)
 	}
 
-	// Log organization creation
-	s.logOrganizationEvent(ctx, org.ID, "organization_created", map[string]interface{}{
-		"name":              org.Name,
-		"type":              org.Type,
-		"industry":          org.Industry,
-		"subscription_plan": org.Subscription.Plan,
-	})
-
 	s.logger.Info("Organization created successfully",
 		zap.String("organization_id", org.ID.Hex()),
 		zap.String("name", org.Name),
@@ -291,8 +301,18 @@ func (s *organizationService) GetFeatureFlags(ctx context.Context, orgID string)
 // Returns:
 //   - error: Error if update fails
 func (s *organizationService) UpdateFeatureFlag(ctx context.Context, orgID, flag string, enabled bool) error {
-	// Update in database
-	if err := s.orgRepo.UpdateFeatureFlag(ctx, orgID, flag, enabled); err != nil {
+	// Update in database together with its event; subscribers invalidate the
+	// cache once the transaction committed
+	err := s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.orgRepo.UpdateFeatureFlag(ctx, orgID, flag, enabled); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, events.New(orgID, "organization", orgID, &events.FeatureFlagUpdated{
+			Flag:    flag,
+			Enabled: enabled,
+		}))
+	})
+	if err != nil {
 		s.logger.Error("Failed to update feature flag",
 			zap.Error(err),
 			zap.String("organization_id", orgID),
@@ -302,22 +322,6 @@ func (s *organizationService) UpdateFeatureFlag(ctx context.Context, orgID, flag
 		return fmt.Errorf("failed to update feature flag: %w", err)
 	}
 
-	// Invalidate cache
-	cacheKey := fmt.Sprintf("org:flags:%s", orgID)
-	if err := s.cacheRepo.Delete(ctx, cacheKey); err != nil {
-		s.logger.Warn("Failed to invalidate feature flags cache",
-			zap.Error(err),
-			zap.String("organization_id", orgID),
-		)
-	}
-
-	// Log feature flag change
-	s.logOrganizationEvent(ctx, primitive.ObjectID{}, "feature_flag_updated", map[string]interface{}{
-		"organization_id": orgID,
-		"flag":           flag,
-		"enabled":        enabled,
-	})
-
 	s.logger.Info("Feature flag updated",
 		zap.String("organization_id", orgID),
 		zap.String("flag", flag),
@@ -378,6 +382,114 @@ func (s *organizationService) IsFeatureEnabled(ctx context.Context, orgID, featu
 
 // Helper methods for organization service
 
+// RenewSubscription brings an organization's subscription up to date: an active
+// subscription whose billing period ended is renewed for the following periods,
+// while an ended trial or an ended cancelled subscription expires.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID
+//
+// Returns:
+//   - error: Error if the organization cannot be loaded or saved
+func (s *organizationService) RenewSubscription(ctx context.Context, orgID string) error {
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to get organization: %w", err)
+	}
+	_, err = s.renewSubscription(ctx, org, time.Now().UTC())
+	return err
+}
+
+// RenewDueSubscriptions renews or expires the subscriptions of all active
+// organizations whose billing period or trial has ended. It is run by the
+// subscription renewal job.
+//
+// Parameters:
+//   - ctx: Request context
+//   - now: Time to renew subscriptions at
+//
+// Returns:
+//   - int: Number of subscriptions changed
+//   - error: Error if organizations cannot be listed; failures of single
+//     organizations are logged and skipped
+func (s *organizationService) RenewDueSubscriptions(ctx context.Context, now time.Time) (int, error) {
+	const pageSize = 100
+	renewed := 0
+	for offset := 0; ; offset += pageSize {
+		orgs, err := s.orgRepo.GetActiveOrganizations(ctx, pageSize, offset)
+		if err != nil {
+			return renewed, fmt.Errorf("failed to list organizations: %w", err)
+		}
+		for _, org := range orgs {
+			changed, err := s.renewSubscription(ctx, org, now)
+			if err != nil {
+				s.logger.Error("Failed to renew subscription",
+					zap.Error(err),
+					zap.String("organization_id", org.ID.Hex()),
+				)
+				continue
+			}
+			if changed {
+				renewed++
+			}
+		}
+		if len(orgs) < pageSize {
+			return renewed, nil
+		}
+	}
+}
+
+// renewSubscription applies due renewals and expiries to an organization's
+// subscription and saves it together with a SubscriptionRenewed event.
+func (s *organizationService) renewSubscription(ctx context.Context, org *models.Organization, now time.Time) (bool, error) {
+	sub := &org.Subscription
+	switch {
+	case sub.IsInTrial && !sub.TrialEnd.IsZero() && !sub.TrialEnd.After(now):
+		sub.IsInTrial = false
+		sub.Status = models.SubscriptionStatusExpired
+	case sub.Status == models.SubscriptionStatusCancelled && !sub.CurrentPeriodEnd.IsZero() && !sub.CurrentPeriodEnd.After(now):
+		sub.Status = models.SubscriptionStatusExpired
+	case sub.Status == models.SubscriptionStatusActive && !sub.CurrentPeriodEnd.IsZero() && !sub.CurrentPeriodEnd.After(now):
+		for !sub.CurrentPeriodEnd.After(now) {
+			sub.CurrentPeriodStart = sub.CurrentPeriodEnd
+			if sub.BillingPeriod == "yearly" {
+				sub.CurrentPeriodEnd = sub.CurrentPeriodEnd.AddDate(1, 0, 0)
+			} else {
+				sub.CurrentPeriodEnd = sub.CurrentPeriodEnd.AddDate(0, 1, 0)
+			}
+		}
+		sub.NextPaymentDate = sub.CurrentPeriodEnd
+	default:
+		return false, nil
+	}
+	sub.UpdatedAt = now
+	org.UpdatedAt = now
+
+	orgID := org.ID.Hex()
+	err := s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.orgRepo.Update(ctx, org); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, events.New(orgID, "organization", orgID, &events.SubscriptionRenewed{
+			Plan:        sub.Plan,
+			Status:      sub.Status,
+			PeriodStart: sub.CurrentPeriodStart,
+			PeriodEnd:   sub.CurrentPeriodEnd,
+		}))
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to save subscription: %w", err)
+	}
+
+	s.logger.Info("Subscription renewed",
+		zap.String("organization_id", orgID),
+		zap.String("status", sub.Status),
+		zap.Time("period_end", sub.CurrentPeriodEnd),
+	)
+	return true, nil
+}
+
 // validateCreateOrganizationInput validates the input for creating an organization.
 func (s *organizationService) validateCreateOrganizationInput(input *CreateOrganizationInput) error {
 	if input == nil {
@@ -584,27 +696,6 @@ func (s *organizationService) getDefaultStorageLimit(plan string) int64 {
 	}
 }
 
-// logOrganizationEvent logs an organization-related event for audit purposes.
-func (s *organizationService) logOrganizationEvent(ctx context.Context, orgID primitive.ObjectID, action string, metadata map[string]interface{}) {
-	auditEntry := &models.AuditLog{
-		ID:             primitive.NewObjectID(),
-		Timestamp:      time.Now(),
-		OrganizationID: orgID,
-		Action:         action,
-		ResourceType:   "organization",
-		Success:        true,
-		Metadata:       metadata,
-	}
-
-	if err := s.auditRepo.Create(ctx, auditEntry); err != nil {
-		s.logger.Warn("Failed to log organization event",
-			zap.Error(err),
-			zap.String("action", action),
-			zap.String("organization_id", orgID.Hex()),
-		)
-	}
-}
-
 // Service errors
 var (
 	ErrInvalidInput              = errors.New("invalid input provided")
@@ -664,11 +755,6 @@ func (s *organizationService) CancelSubscription(ctx context.Context, orgID stri
 	return errors.New("not implemented")
 }
 
-func (s *organizationService) RenewSubscription(ctx context.Context, orgID string) error {
-	// Implementation would renew subscription
-	return errors.New("not implemented")
-}
-
 func (s *organizationService) BulkUpdateFeatureFlags(ctx context.Context, orgID string, flags map[string]bool) error {
 	// Implementation would bulk update feature flags
 	return errors.New("not implemented")
@@ -689,24 +775,91 @@ func (s *organizationService) UpdatePartialSettings(ctx context.Context, orgID s
 	return errors.New("not implemented")
 }
 
+// GetMemberCount returns the number of active members of an organization.
+// It is read from the database rather than the cache, as it changes with
+// every user created or deactivated.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID
+//
+// Returns:
+//   - int: Number of active members
+//   - error: ErrOrganizationNotFound or retrieval error
 func (s *organizationService) GetMemberCount(ctx context.Context, orgID string) (int, error) {
-	// Implementation would get member count
-	return 0, errors.New("not implemented")
+	current, _, err := s.GetMemberLimits(ctx, orgID)
+	return current, err
 }
 
+// UpdateMemberCount overwrites the member count of an organization, e.g. to
+// correct it after recounting its active users. Users joining and leaving
+// adjust the count themselves.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID
+//   - count: Number of active members
+//
+// Returns:
+//   - error: ErrInvalidInput for a negative count, ErrOrganizationNotFound or update error
 func (s *organizationService) UpdateMemberCount(ctx context.Context, orgID string, count int) error {
-	// Implementation would update member count
-	return errors.New("not implemented")
+	if count < 0 {
+		return ErrInvalidInput
+	}
+	err := s.orgRepo.SetMemberCount(ctx, orgID, count)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return ErrOrganizationNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update member count: %w", err)
+	}
+
+	s.logger.Info("Member count updated",
+		zap.String("organization_id", orgID),
+		zap.Int("member_count", count),
+	)
+	return nil
 }
 
-func (s *organizationService) GetMemberLimits(ctx context.Context, orgID string) (current, max int, error) {
-	// Implementation would get member limits
-	return 0, 0, errors.New("not implemented")
+// GetMemberLimits returns the number of active members of an organization and
+// the most it may have; a maximum of zero means unlimited.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID
+//
+// Returns:
+//   - current: Number of active members
+//   - max: Member limit, zero for unlimited
+//   - err: ErrOrganizationNotFound or retrieval error
+func (s *organizationService) GetMemberLimits(ctx context.Context, orgID string) (current, max int, err error) {
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return 0, 0, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get organization: %w", err)
+	}
+	return org.MemberCount, org.MaxMembers, nil
 }
 
+// CanAddMember reports whether an organization has a free seat for another
+// active member. The answer may be stale by the time a member is added; the
+// seat itself is taken atomically when the user is created.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID
+//
+// Returns:
+//   - bool: Whether another member fits within the limit
+//   - error: ErrOrganizationNotFound or retrieval error
 func (s *organizationService) CanAddMember(ctx context.Context, orgID string) (bool, error) {
-	// Implementation would check if can add member
-	return false, errors.New("not implemented")
+	current, max, err := s.GetMemberLimits(ctx, orgID)
+	if err != nil {
+		return false, err
+	}
+	return max == 0 || current < max, nil
 }
 
 func (s *organizationService) ValidateOrganization(ctx context.Context, org *models.Organization) error {
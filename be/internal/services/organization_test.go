@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/events"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+)
+
+// fakeEventPublisher records published domain events.
+type fakeEventPublisher struct {
+	mu     sync.Mutex
+	events []*events.Event
+}
+
+func (p *fakeEventPublisher) Publish(ctx context.Context, event *events.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
+
+func newSubscribedOrganization(sub models.OrganizationSubscription) *models.Organization {
+	org := &models.Organization{Name: "First Bank", Status: models.OrganizationStatusActive, Subscription: sub}
+	org.ID = primitive.NewObjectID()
+	return org
+}
+
+func TestOrganizationService_RenewDueSubscriptions(t *testing.T) {
+	now := time.Date(2026, time.October, 15, 1, 0, 0, 0, time.UTC)
+	periodEnd := time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC)
+
+	monthly := newSubscribedOrganization(models.OrganizationSubscription{
+		Status: models.SubscriptionStatusActive, BillingPeriod: "monthly",
+		CurrentPeriodStart: periodEnd.AddDate(0, -1, 0), CurrentPeriodEnd: periodEnd,
+	})
+	yearly := newSubscribedOrganization(models.OrganizationSubscription{
+		Status: models.SubscriptionStatusActive, BillingPeriod: "yearly",
+		CurrentPeriodStart: periodEnd.AddDate(-1, 0, 0), CurrentPeriodEnd: periodEnd,
+	})
+	trial := newSubscribedOrganization(models.OrganizationSubscription{
+		Status: models.SubscriptionStatusTrial, IsInTrial: true, TrialEnd: now.Add(-time.Hour),
+	})
+	cancelled := newSubscribedOrganization(models.OrganizationSubscription{
+		Status: models.SubscriptionStatusCancelled, CurrentPeriodEnd: periodEnd,
+	})
+	current := newSubscribedOrganization(models.OrganizationSubscription{
+		Status: models.SubscriptionStatusActive, BillingPeriod: "monthly", CurrentPeriodEnd: now.AddDate(0, 0, 10),
+	})
+
+	publisher := &fakeEventPublisher{}
+	service := NewOrganizationService(
+		newFakeOrganizationRepository(monthly, yearly, trial, cancelled, current),
+		nil, &fakeCacheRepository{}, &fakeTransactor{}, publisher, zap.NewNop(),
+	)
+
+	renewed, err := service.RenewDueSubscriptions(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 4, renewed)
+
+	assert.Equal(t, time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC), monthly.Subscription.CurrentPeriodEnd)
+	assert.Equal(t, periodEnd, monthly.Subscription.CurrentPeriodStart)
+	assert.Equal(t, monthly.Subscription.CurrentPeriodEnd, monthly.Subscription.NextPaymentDate)
+	assert.Equal(t, time.Date(2027, time.October, 1, 0, 0, 0, 0, time.UTC), yearly.Subscription.CurrentPeriodEnd)
+	assert.Equal(t, models.SubscriptionStatusExpired, trial.Subscription.Status)
+	assert.False(t, trial.Subscription.IsInTrial)
+	assert.Equal(t, models.SubscriptionStatusExpired, cancelled.Subscription.Status)
+	assert.Equal(t, now.AddDate(0, 0, 10), current.Subscription.CurrentPeriodEnd)
+
+	require.Len(t, publisher.events, 4)
+	for _, event := range publisher.events {
+		assert.Equal(t, events.TypeSubscriptionRenewed, event.Type)
+	}
+}
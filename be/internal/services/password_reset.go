@@ -0,0 +1,481 @@
+// Package services provides service layer implementations for the GoEdu Control Testing Platform.
+// This file contains self-service password resets and the security questions
+// that can guard them. A reset emails a single-use link whose token is stored
+// only as a hash; requesting a reset answers the same way whether or not the
+// email belongs to an account, and requests are rate limited per email
+// address and per client IP.
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	mathrand "math/rand/v2"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/requestctx"
+)
+
+// Password reset errors
+var (
+	ErrInvalidPasswordReset     = errors.New("password reset link is invalid or has expired")
+	ErrTooManyResetRequests     = errors.New("too many password reset requests; try again later")
+	ErrSecurityAnswerRequired   = errors.New("the security question must be answered before the password can be reset")
+	ErrIncorrectSecurityAnswer  = errors.New("security answer is incorrect")
+	ErrInvalidSecurityQuestions = errors.New("security questions must be distinct, at most five, and each needs an answer of at least three characters")
+)
+
+const (
+	// resetTokenBytes is the number of random bytes in a password reset token
+	resetTokenBytes = 32
+
+	// maxPendingResets bounds the password reset requests handled at once
+	// after ResetPassword returned
+	maxPendingResets = 32
+
+	// maxSecurityAnswerAttempts wrong answers end a password reset
+	maxSecurityAnswerAttempts = 3
+
+	// maxSecurityQuestions and minSecurityAnswerLength bound SetSecurityQuestions
+	maxSecurityQuestions    = 5
+	minSecurityAnswerLength = 3
+
+	// resetRateLimitKeyPrefix prefixes the rate limit buckets of reset requests
+	resetRateLimitKeyPrefix = "goedu:ratelimit:password_reset:"
+)
+
+// ResetPassword emails a password reset link to the account with the given
+// email address. Unknown addresses and accounts without a platform password,
+// such as single sign-on or directory accounts, get no email but the same
+// answer. The account is looked up, and the reset stored and emailed, after
+// ResetPassword returns, so neither the answer nor its timing reveals whether
+// an account exists. A new reset ends the user's earlier pending ones.
+//
+// Parameters:
+//   - ctx: Request context carrying the client IP
+//   - email: Email address of the account
+//
+// Returns:
+//   - error: ErrInvalidInput or ErrTooManyResetRequests, also returned while
+//     maxPendingResets requests are still being handled
+func (s *authenticationService) ResetPassword(ctx context.Context, email string) error {
+	email, err := normalizeUserEmail(email)
+	if err != nil {
+		return ErrInvalidInput
+	}
+	var ipAddress string
+	if md, ok := requestctx.FromContext(ctx); ok {
+		ipAddress = md.IPAddress
+	}
+	if ipAddress != "" && !s.allowReset(ctx, "ip:"+ipAddress, s.config.ResetIPLimit) {
+		s.logger.Warn("Password reset requests rate limited", zap.String("ip_address", ipAddress))
+		return ErrTooManyResetRequests
+	}
+	emailHash := sha256.Sum256([]byte(email))
+	if !s.allowReset(ctx, "email:"+hex.EncodeToString(emailHash[:]), s.config.ResetLimit) {
+		s.logger.Warn("Password reset requests rate limited", zap.String("ip_address", ipAddress))
+		return ErrTooManyResetRequests
+	}
+
+	// The reset outlives the request
+	resetCtx := context.WithoutCancel(ctx)
+	handle := func() {
+		if err := s.requestReset(resetCtx, email, ipAddress); err != nil {
+			s.logger.Error("Failed to request password reset", zap.Error(err), zap.String("ip_address", ipAddress))
+		}
+	}
+	s.resetMu.Lock()
+	if s.resetsClosed {
+		s.resetMu.Unlock()
+		handle()
+		return nil
+	}
+	select {
+	case s.resetSlots <- struct{}{}:
+	default:
+		s.resetMu.Unlock()
+		s.logger.Warn("Too many password reset requests in progress", zap.String("ip_address", ipAddress))
+		return ErrTooManyResetRequests
+	}
+	s.resetRequests.Add(1)
+	s.resetMu.Unlock()
+	go func() {
+		defer s.resetRequests.Done()
+		defer func() { <-s.resetSlots }()
+		handle()
+	}()
+	return nil
+}
+
+// Close waits for the password reset requests still being handled after
+// ResetPassword returned. Requests made after Close are handled before
+// ResetPassword returns.
+//
+// Parameters:
+//   - ctx: Context bounding the wait, usually the shutdown context
+//
+// Returns:
+//   - error: Error if ctx ends before the requests are handled
+func (s *authenticationService) Close(ctx context.Context) error {
+	s.resetMu.Lock()
+	s.resetsClosed = true
+	s.resetMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.resetRequests.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("password reset requests still in progress: %w", ctx.Err())
+	}
+}
+
+// requestReset stores a password reset for the account with the given email
+// address, ending its earlier pending ones, and emails the reset link.
+// Unknown addresses and accounts without a platform password are skipped.
+func (s *authenticationService) requestReset(ctx context.Context, email, ipAddress string) error {
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if errors.Is(err, repositories.ErrNotFound) {
+		s.logger.Info("Password reset requested for unknown email", zap.String("ip_address", ipAddress))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	userID := user.ID.Hex()
+	if !user.IsActive || user.Status != models.UserStatusActive || user.Authentication.PasswordHash == "" || user.Authentication.LDAPDN != "" {
+		s.logger.Info("Password reset requested for account without a platform password",
+			zap.String("user_id", userID),
+			zap.String("ip_address", ipAddress),
+		)
+		return nil
+	}
+
+	raw := make([]byte, resetTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return fmt.Errorf("failed to generate reset token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	now := time.Now().UTC()
+	if err := s.resetRepo.ConsumeByUser(ctx, userID, now); err != nil {
+		return fmt.Errorf("failed to end earlier password resets: %w", err)
+	}
+	reset := &models.PasswordReset{
+		UserID:         user.ID,
+		OrganizationID: user.OrganizationID,
+		TokenHash:      resetTokenHash(token),
+		IPAddress:      ipAddress,
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(s.config.ResetTTL),
+	}
+	if questions := user.Authentication.SecurityQuestions; len(questions) > 0 {
+		reset.SecurityQuestion = questions[mathrand.IntN(len(questions))].Question
+	}
+	if err := s.resetRepo.Create(ctx, reset); err != nil {
+		return fmt.Errorf("failed to create password reset: %w", err)
+	}
+
+	if err := s.notifications.SendPasswordReset(ctx, user, token, reset.ExpiresAt); err != nil {
+		s.logger.Error("Failed to send password reset email", zap.Error(err), zap.String("user_id", userID))
+	}
+	s.logger.Info("Password reset requested",
+		zap.String("user_id", userID),
+		zap.String("reset_id", reset.ID.Hex()),
+		zap.String("ip_address", ipAddress),
+	)
+	return nil
+}
+
+// LookupPasswordReset describes the pending reset of a link, including the
+// security question that must be answered before the password can be set.
+//
+// Parameters:
+//   - ctx: Request context
+//   - token: Token of the reset link
+//
+// Returns:
+//   - *PasswordResetDetails: The reset's security question and expiry
+//   - error: ErrInvalidPasswordReset or retrieval error
+func (s *authenticationService) LookupPasswordReset(ctx context.Context, token string) (*PasswordResetDetails, error) {
+	reset, err := s.pendingReset(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return &PasswordResetDetails{
+		SecurityQuestion: reset.SecurityQuestion,
+		AnswerRequired:   reset.SecurityQuestion != "" && reset.AnswerVerifiedAt.IsZero(),
+		ExpiresAt:        reset.ExpiresAt,
+	}, nil
+}
+
+// VerifyPasswordResetAnswer checks the answer to a reset's security question.
+// Each answer takes one of maxSecurityAnswerAttempts attempts before it is
+// checked, so parallel guesses cannot exceed them; after the last wrong
+// answer the reset ends and the user has to request a new one.
+//
+// Parameters:
+//   - ctx: Request context
+//   - token: Token of the reset link
+//   - answer: Answer to the reset's security question
+//
+// Returns:
+//   - error: ErrInvalidPasswordReset, ErrIncorrectSecurityAnswer or update error
+func (s *authenticationService) VerifyPasswordResetAnswer(ctx context.Context, token, answer string) error {
+	reset, err := s.pendingReset(ctx, token)
+	if err != nil {
+		return err
+	}
+	if reset.SecurityQuestion == "" || !reset.AnswerVerifiedAt.IsZero() {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	resetID := reset.ID.Hex()
+	attempts, err := s.resetRepo.TakeAnswerAttempt(ctx, resetID, maxSecurityAnswerAttempts, now)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return ErrInvalidPasswordReset
+	}
+	if err != nil {
+		return fmt.Errorf("failed to count security answer: %w", err)
+	}
+
+	err = s.ValidateSecurityAnswer(ctx, reset.UserID.Hex(), reset.SecurityQuestion, answer)
+	switch {
+	case errors.Is(err, ErrIncorrectSecurityAnswer):
+		s.logger.Warn("Incorrect security answer for password reset",
+			zap.String("user_id", reset.UserID.Hex()),
+			zap.String("reset_id", resetID),
+			zap.Int("failed_answers", attempts),
+		)
+		if attempts >= maxSecurityAnswerAttempts {
+			if err := s.resetRepo.Consume(ctx, resetID, now); err != nil && !errors.Is(err, repositories.ErrNotFound) {
+				return fmt.Errorf("failed to end password reset: %w", err)
+			}
+		}
+		return ErrIncorrectSecurityAnswer
+	case errors.Is(err, ErrUserNotFound):
+		return ErrInvalidPasswordReset
+	case err != nil:
+		return err
+	}
+
+	if err := s.resetRepo.VerifyAnswer(ctx, resetID, now); errors.Is(err, repositories.ErrNotFound) {
+		return ErrInvalidPasswordReset
+	} else if err != nil {
+		return fmt.Errorf("failed to update password reset: %w", err)
+	}
+	return nil
+}
+
+// ValidatePasswordReset sets a new password with a reset link. The password
+// must satisfy the organization's password policy; a rejected password
+// leaves the link usable. The link then stops working, the account is
+// unlocked and every session of the user ends.
+//
+// Parameters:
+//   - ctx: Request context
+//   - token: Token of the reset link
+//   - newPassword: The new password
+//
+// Returns:
+//   - error: ErrInvalidPasswordReset, ErrSecurityAnswerRequired,
+//     ErrPasswordTooWeak, ErrPasswordCommon, ErrPasswordBreached,
+//     ErrPasswordReused or update error
+func (s *authenticationService) ValidatePasswordReset(ctx context.Context, token, newPassword string) error {
+	reset, err := s.pendingReset(ctx, token)
+	if err != nil {
+		return err
+	}
+	if reset.SecurityQuestion != "" && reset.AnswerVerifiedAt.IsZero() {
+		return ErrSecurityAnswerRequired
+	}
+	userID := reset.UserID.Hex()
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return ErrInvalidPasswordReset
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	org, err := s.orgRepo.GetByID(ctx, user.OrganizationID.Hex())
+	if errors.Is(err, repositories.ErrNotFound) {
+		return ErrOrganizationNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get organization: %w", err)
+	}
+	if err := s.passwords.set(ctx, org, user, newPassword); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	if err := s.resetRepo.Consume(ctx, reset.ID.Hex(), now); errors.Is(err, repositories.ErrNotFound) {
+		return ErrInvalidPasswordReset
+	} else if err != nil {
+		return fmt.Errorf("failed to use password reset: %w", err)
+	}
+	user.Authentication.FailedLoginAttempts = 0
+	user.Authentication.LockoutUntil = time.Time{}
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if err := s.resetRepo.ConsumeByUser(ctx, userID, now); err != nil {
+		s.logger.Warn("Failed to end other password resets", zap.Error(err), zap.String("user_id", userID))
+	}
+	if _, err := s.sessions.endAll(ctx, userID, "", models.SessionEndPasswordReset); err != nil {
+		s.logger.Error("Failed to terminate sessions after password reset", zap.Error(err), zap.String("user_id", userID))
+	}
+	s.logger.Info("Password reset",
+		zap.String("user_id", userID),
+		zap.String("reset_id", reset.ID.Hex()),
+	)
+	return nil
+}
+
+// SetSecurityQuestions replaces a user's security questions. The questions
+// carry the plain answers in AnswerHash; answers are compared ignoring case
+// and extra spaces and only their hashes are stored. An empty list removes
+// the security questions.
+//
+// Parameters:
+//   - ctx: Request context
+//   - userID: User ID
+//   - questions: Questions with their plain answers
+//
+// Returns:
+//   - error: ErrUserNotFound, ErrInvalidSecurityQuestions or update error
+func (s *authenticationService) SetSecurityQuestions(ctx context.Context, userID string, questions []models.SecurityQuestion) error {
+	if len(questions) > maxSecurityQuestions {
+		return ErrInvalidSecurityQuestions
+	}
+	user, err := s.user(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	seen := make(map[string]bool, len(questions))
+	hashed := make([]models.SecurityQuestion, 0, len(questions))
+	for _, question := range questions {
+		text := strings.TrimSpace(question.Question)
+		answer := normalizeSecurityAnswer(question.AnswerHash)
+		key := strings.ToLower(text)
+		if text == "" || seen[key] || utf8.RuneCountInString(answer) < minSecurityAnswerLength {
+			return ErrInvalidSecurityQuestions
+		}
+		seen[key] = true
+		hash, err := s.hasher.HashSecret(answer)
+		if err != nil {
+			return ErrInvalidSecurityQuestions
+		}
+		hashed = append(hashed, models.SecurityQuestion{Question: text, AnswerHash: hash, CreatedAt: now})
+	}
+
+	user.Authentication.SecurityQuestions = hashed
+	user.UpdatedAt = now
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update security questions: %w", err)
+	}
+	s.logger.Info("Security questions set", zap.String("user_id", userID), zap.Int("questions", len(hashed)))
+	return nil
+}
+
+// ValidateSecurityAnswer checks the answer to one of a user's security questions.
+//
+// Parameters:
+//   - ctx: Request context
+//   - userID: User ID
+//   - question: The question answered
+//   - answer: The answer given
+//
+// Returns:
+//   - error: ErrUserNotFound, ErrIncorrectSecurityAnswer or verification error
+func (s *authenticationService) ValidateSecurityAnswer(ctx context.Context, userID, question, answer string) error {
+	user, err := s.user(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, candidate := range user.Authentication.SecurityQuestions {
+		if candidate.Question != question {
+			continue
+		}
+		ok, err := s.hasher.VerifyPassword(normalizeSecurityAnswer(answer), candidate.AnswerHash)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		break
+	}
+	return ErrIncorrectSecurityAnswer
+}
+
+// user loads a user, mapping a missing one to ErrUserNotFound.
+func (s *authenticationService) user(ctx context.Context, userID string) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, repositories.ErrNotFound) || errors.Is(err, repositories.ErrInvalidInput) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// pendingReset loads the reset of a token, reporting used, expired and
+// unknown tokens alike as ErrInvalidPasswordReset.
+func (s *authenticationService) pendingReset(ctx context.Context, token string) (*models.PasswordReset, error) {
+	if token == "" {
+		return nil, ErrInvalidPasswordReset
+	}
+	reset, err := s.resetRepo.GetByTokenHash(ctx, resetTokenHash(token))
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrInvalidPasswordReset
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get password reset: %w", err)
+	}
+	if !reset.IsPending(time.Now().UTC()) {
+		return nil, ErrInvalidPasswordReset
+	}
+	return reset, nil
+}
+
+// allowReset takes a token from a password reset rate limit bucket. While
+// the shared store is unavailable, each instance limits on its own.
+func (s *authenticationService) allowReset(ctx context.Context, key string, limit int) bool {
+	result, err := s.limits.AllowRate(ctx, resetRateLimitKeyPrefix+key, limit, s.config.ResetWindow)
+	if err != nil {
+		s.logger.Warn("Password reset rate limit unavailable, limiting locally", zap.Error(err))
+		result, _ = s.localLimits.AllowRate(ctx, resetRateLimitKeyPrefix+key, limit, s.config.ResetWindow)
+	}
+	return result.Allowed
+}
+
+// resetTokenHash returns the hex SHA-256 hash under which a reset token is stored.
+func resetTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeSecurityAnswer lowercases an answer and collapses its spaces.
+func normalizeSecurityAnswer(answer string) string {
+	return strings.Join(strings.Fields(strings.ToLower(answer)), " ")
+}
@@ -0,0 +1,292 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/requestctx"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/auth"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/cache"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/cache/cachetest"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/password"
+)
+
+type passwordResetFixture struct {
+	*userFixture
+	user     *models.User
+	sessions *fakeSessionRepository
+	resets   *fakePasswordResetRepository
+	service  AuthenticationService
+}
+
+func newPasswordResetFixture(t *testing.T) *passwordResetFixture {
+	t.Helper()
+	f := &passwordResetFixture{
+		userFixture: newUserFixture(t),
+		sessions:    &fakeSessionRepository{},
+		resets:      &fakePasswordResetRepository{},
+	}
+	f.user = f.createUser(t, "ada@first-bank.com")
+	f.service = f.newService(f.notifications)
+	return f
+}
+
+// newService creates an authentication service over the fixture's
+// repositories that sends password reset emails with notifications.
+func (f *passwordResetFixture) newService(notifications NotificationService) AuthenticationService {
+	return NewAuthenticationService(f.orgs, f.users, f.sessions, cachetest.NewDenylist(), f.resets, notifications,
+		cache.NewLocalRateLimits(), testJWTManager, auth.NewPasswordHasher(4), password.NewChecker(password.DefaultDictionary(), nil),
+		testPasswordConfig, testSessionConfig, zap.NewNop())
+}
+
+// waitForResets waits for the reset requests still being handled after
+// ResetPassword returned.
+func (f *passwordResetFixture) waitForResets() {
+	f.service.(*authenticationService).resetRequests.Wait()
+}
+
+// requestReset requests a reset for the fixture user and returns the emailed token.
+func (f *passwordResetFixture) requestReset(t *testing.T) string {
+	t.Helper()
+	require.NoError(t, f.service.ResetPassword(context.Background(), " Ada@First-Bank.com "))
+	f.waitForResets()
+	require.NotEmpty(t, f.notifications.passwordResets)
+	return f.notifications.passwordResets[len(f.notifications.passwordResets)-1]
+}
+
+func TestAuthenticationService_ResetPassword(t *testing.T) {
+	f := newPasswordResetFixture(t)
+	ctx := context.Background()
+	f.sessions.sessions = []*models.Session{
+		{SessionID: "laptop", UserID: f.user.ID, IsActive: true},
+		{SessionID: "phone", UserID: f.user.ID, IsActive: true},
+	}
+	f.user.Authentication.FailedLoginAttempts = 4
+	f.user.Authentication.LockoutUntil = time.Now().Add(time.Hour)
+
+	first := f.requestReset(t)
+	token := f.requestReset(t)
+	require.Len(t, f.resets.resets, 2)
+	assert.NotEqual(t, token, f.resets.resets[1].TokenHash, "only the token's hash is stored")
+	assert.WithinDuration(t, time.Now().Add(time.Hour), f.resets.resets[1].ExpiresAt, time.Minute)
+	_, err := f.service.LookupPasswordReset(ctx, first)
+	assert.ErrorIs(t, err, ErrInvalidPasswordReset, "a new reset ends the earlier ones")
+
+	details, err := f.service.LookupPasswordReset(ctx, token)
+	require.NoError(t, err)
+	assert.False(t, details.AnswerRequired)
+
+	err = f.service.ValidatePasswordReset(ctx, token, "Password123!")
+	assert.ErrorIs(t, err, ErrPasswordCommon)
+	require.NoError(t, f.service.ValidatePasswordReset(ctx, token, "quiet meadow lantern"),
+		"a rejected password leaves the link usable")
+
+	_, err = f.userFixture.service.AuthenticateUser(ctx, f.user.Email, "quiet meadow lantern")
+	require.NoError(t, err)
+	assert.Zero(t, f.user.Authentication.FailedLoginAttempts)
+	for _, session := range f.sessions.sessions {
+		assert.False(t, session.IsActive, session.SessionID)
+		assert.Equal(t, models.SessionEndPasswordReset, session.EndReason)
+	}
+
+	err = f.service.ValidatePasswordReset(ctx, token, "silver harbor compass")
+	assert.ErrorIs(t, err, ErrInvalidPasswordReset, "a link can be used once")
+	assert.ErrorIs(t, f.service.ValidatePasswordReset(ctx, "not-a-token", "silver harbor compass"), ErrInvalidPasswordReset)
+}
+
+func TestAuthenticationService_ResetPasswordExpired(t *testing.T) {
+	f := newPasswordResetFixture(t)
+	token := f.requestReset(t)
+	f.resets.resets[0].ExpiresAt = time.Now().Add(-time.Second)
+
+	err := f.service.ValidatePasswordReset(context.Background(), token, "quiet meadow lantern")
+	assert.ErrorIs(t, err, ErrInvalidPasswordReset)
+}
+
+func TestAuthenticationService_ResetPasswordDoesNotRevealAccounts(t *testing.T) {
+	f := newPasswordResetFixture(t)
+	ctx := context.Background()
+
+	require.NoError(t, f.service.ResetPassword(ctx, "nobody@first-bank.com"))
+	f.user.Authentication.LDAPDN = "cn=ada,dc=first-bank,dc=com"
+	require.NoError(t, f.service.ResetPassword(ctx, f.user.Email), "directory accounts have no platform password")
+	f.waitForResets()
+	assert.Empty(t, f.notifications.passwordResets)
+	assert.Empty(t, f.resets.resets)
+
+	assert.ErrorIs(t, f.service.ResetPassword(ctx, "not an email"), ErrInvalidInput)
+}
+
+// blockingResetSender holds password reset emails until released.
+type blockingResetSender struct {
+	*fakeNotificationService
+	release chan struct{}
+}
+
+func (n *blockingResetSender) SendPasswordReset(ctx context.Context, user *models.User, token string, expiresAt time.Time) error {
+	<-n.release
+	return n.fakeNotificationService.SendPasswordReset(ctx, user, token, expiresAt)
+}
+
+func TestAuthenticationService_ResetPasswordAnswersBeforeEmailing(t *testing.T) {
+	f := newPasswordResetFixture(t)
+	sender := &blockingResetSender{fakeNotificationService: f.notifications, release: make(chan struct{})}
+	f.service = f.newService(sender)
+
+	answered := make(chan error, 1)
+	go func() { answered <- f.service.ResetPassword(context.Background(), f.user.Email) }()
+	select {
+	case err := <-answered:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		close(sender.release)
+		t.Fatal("the answer waited for the email, so its timing reveals that the account exists")
+	}
+	assert.Empty(t, f.notifications.passwordResets)
+
+	close(sender.release)
+	f.waitForResets()
+	assert.Len(t, f.notifications.passwordResets, 1)
+	assert.Len(t, f.resets.resets, 1)
+}
+
+func TestAuthenticationService_ResetPasswordClose(t *testing.T) {
+	f := newPasswordResetFixture(t)
+	sender := &blockingResetSender{fakeNotificationService: f.notifications, release: make(chan struct{})}
+	f.service = f.newService(sender)
+	f.service.(*authenticationService).resetSlots = make(chan struct{}, 1)
+	ctx := context.Background()
+
+	require.NoError(t, f.service.ResetPassword(ctx, f.user.Email))
+	assert.ErrorIs(t, f.service.ResetPassword(ctx, "nobody@first-bank.com"), ErrTooManyResetRequests,
+		"requests in progress are bounded")
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, f.service.Close(timeout), context.DeadlineExceeded)
+
+	close(sender.release)
+	require.NoError(t, f.service.Close(ctx))
+	assert.Len(t, f.notifications.passwordResets, 1, "Close waits for requests in progress")
+
+	// Requests after Close are handled before ResetPassword returns
+	require.NoError(t, f.service.ResetPassword(ctx, f.user.Email))
+	assert.Len(t, f.notifications.passwordResets, 2)
+}
+
+func TestAuthenticationService_ResetPasswordRateLimit(t *testing.T) {
+	f := newPasswordResetFixture(t)
+	ctx := context.Background()
+
+	for i := 0; i < testPasswordConfig.ResetLimit; i++ {
+		require.NoError(t, f.service.ResetPassword(ctx, "nobody@first-bank.com"))
+	}
+	assert.ErrorIs(t, f.service.ResetPassword(ctx, "NOBODY@first-bank.com"), ErrTooManyResetRequests,
+		"unknown addresses are limited like known ones")
+	f.requestReset(t)
+
+	// Every address counts against the client IP
+	ipCtx := requestctx.WithMetadata(ctx, &requestctx.Metadata{IPAddress: "203.0.113.7"})
+	for i := 0; i < testPasswordConfig.ResetIPLimit; i++ {
+		email := primitive.NewObjectID().Hex() + "@first-bank.com"
+		require.NoError(t, f.service.ResetPassword(ipCtx, email))
+	}
+	assert.ErrorIs(t, f.service.ResetPassword(ipCtx, "someone@first-bank.com"), ErrTooManyResetRequests)
+}
+
+func TestAuthenticationService_ResetPasswordSecurityQuestion(t *testing.T) {
+	f := newPasswordResetFixture(t)
+	ctx := context.Background()
+	userID := f.user.ID.Hex()
+
+	require.NoError(t, f.service.SetSecurityQuestions(ctx, userID, []models.SecurityQuestion{
+		{Question: "First pet?", AnswerHash: "  Rex  the Dog "},
+	}))
+	question := f.user.Authentication.SecurityQuestions[0]
+	assert.NotContains(t, question.AnswerHash, "rex", "only the answer's hash is stored")
+	require.NoError(t, f.service.ValidateSecurityAnswer(ctx, userID, "First pet?", "rex the dog"))
+
+	token := f.requestReset(t)
+	details, err := f.service.LookupPasswordReset(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, "First pet?", details.SecurityQuestion)
+	assert.True(t, details.AnswerRequired)
+
+	err = f.service.ValidatePasswordReset(ctx, token, "quiet meadow lantern")
+	assert.ErrorIs(t, err, ErrSecurityAnswerRequired)
+	assert.ErrorIs(t, f.service.VerifyPasswordResetAnswer(ctx, token, "Felix"), ErrIncorrectSecurityAnswer)
+	require.NoError(t, f.service.VerifyPasswordResetAnswer(ctx, token, "REX THE DOG"))
+	require.NoError(t, f.service.ValidatePasswordReset(ctx, token, "quiet meadow lantern"))
+
+	// Too many wrong answers end the reset
+	token = f.requestReset(t)
+	for i := 0; i < maxSecurityAnswerAttempts; i++ {
+		assert.ErrorIs(t, f.service.VerifyPasswordResetAnswer(ctx, token, "Felix"), ErrIncorrectSecurityAnswer)
+	}
+	assert.ErrorIs(t, f.service.VerifyPasswordResetAnswer(ctx, token, "rex the dog"), ErrInvalidPasswordReset)
+}
+
+func TestAuthenticationService_ResetPasswordParallelWrongAnswers(t *testing.T) {
+	f := newPasswordResetFixture(t)
+	ctx := context.Background()
+	require.NoError(t, f.service.SetSecurityQuestions(ctx, f.user.ID.Hex(), []models.SecurityQuestion{
+		{Question: "First pet?", AnswerHash: "Rex the dog"},
+	}))
+	token := f.requestReset(t)
+
+	const guesses = 4 * maxSecurityAnswerAttempts
+	results := make(chan error, guesses)
+	var wg sync.WaitGroup
+	for i := 0; i < guesses; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results <- f.service.VerifyPasswordResetAnswer(ctx, token, "Felix")
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	checked := 0
+	for err := range results {
+		if errors.Is(err, ErrIncorrectSecurityAnswer) {
+			checked++
+			continue
+		}
+		assert.ErrorIs(t, err, ErrInvalidPasswordReset)
+	}
+	assert.Equal(t, maxSecurityAnswerAttempts, checked, "only the allowed attempts are checked")
+	assert.ErrorIs(t, f.service.VerifyPasswordResetAnswer(ctx, token, "rex the dog"), ErrInvalidPasswordReset)
+	_, err := f.service.LookupPasswordReset(ctx, token)
+	assert.ErrorIs(t, err, ErrInvalidPasswordReset, "the last wrong answer ends the reset")
+}
+
+func TestAuthenticationService_SetSecurityQuestions(t *testing.T) {
+	f := newPasswordResetFixture(t)
+	ctx := context.Background()
+	userID := f.user.ID.Hex()
+
+	for name, questions := range map[string][]models.SecurityQuestion{
+		"short answer":       {{Question: "First pet?", AnswerHash: "ox"}},
+		"blank question":     {{Question: " ", AnswerHash: "Rex the dog"}},
+		"duplicate question": {{Question: "First pet?", AnswerHash: "Rex"}, {Question: "first PET?", AnswerHash: "Felix"}},
+		"too many":           make([]models.SecurityQuestion, maxSecurityQuestions+1),
+	} {
+		err := f.service.SetSecurityQuestions(ctx, userID, questions)
+		assert.ErrorIs(t, err, ErrInvalidSecurityQuestions, name)
+	}
+
+	require.NoError(t, f.service.SetSecurityQuestions(ctx, userID, []models.SecurityQuestion{{Question: "First pet?", AnswerHash: "Rex"}}))
+	require.NoError(t, f.service.SetSecurityQuestions(ctx, userID, nil))
+	assert.Empty(t, f.user.Authentication.SecurityQuestions)
+	assert.ErrorIs(t, f.service.SetSecurityQuestions(ctx, primitive.NewObjectID().Hex(), nil), ErrUserNotFound)
+}
@@ -0,0 +1,239 @@
+// Package services provides service layer implementations for the GoEdu Control Testing Platform.
+// This file contains the password policy shared by every path that sets a
+// platform password: the organization's length, character class, history and
+// dictionary rules, the offline breached password check, and password expiry.
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/auth"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/password"
+)
+
+// Password policy errors
+var (
+	ErrPasswordReused   = errors.New("password matches the current or a recently used password")
+	ErrPasswordCommon   = errors.New("password is too common or contains the user's name or email")
+	ErrPasswordBreached = errors.New("password has appeared in a data breach")
+	ErrPasswordExpired  = errors.New("password has expired and must be reset")
+)
+
+// maxPasswordHistory bounds the number of previous password hashes kept per user
+const maxPasswordHistory = 24
+
+// defaultPasswordPolicy applies to organizations without a password policy.
+var defaultPasswordPolicy = models.PasswordPolicy{
+	MinLength:      auth.MinPasswordLength,
+	RejectCommon:   true,
+	RejectBreached: true,
+}
+
+// NewPasswordChecker creates the checker enforcing the dictionary and breached
+// password rules of password policies. The built-in dictionary is extended
+// with the configured word list; without a breached hashes path, breached
+// password checks are skipped.
+//
+// Parameters:
+//   - cfg: Paths of the extra dictionary and the breached password hashes
+//
+// Returns:
+//   - *password.Checker: Configured checker
+//   - error: Error opening either list
+func NewPasswordChecker(cfg config.PasswordConfig) (*password.Checker, error) {
+	dictionary := password.DefaultDictionary()
+	if cfg.DictionaryPath != "" {
+		var err error
+		if dictionary, err = password.LoadDictionary(cfg.DictionaryPath); err != nil {
+			return nil, err
+		}
+	}
+	var breaches *password.BreachList
+	if cfg.BreachedHashesPath != "" {
+		var err error
+		if breaches, err = password.OpenBreachList(cfg.BreachedHashesPath); err != nil {
+			return nil, err
+		}
+	}
+	return password.NewChecker(dictionary, breaches), nil
+}
+
+// passwordManager checks new passwords against the organization's password
+// policy and records them on the user.
+type passwordManager struct {
+	orgRepo  repositories.OrganizationRepository
+	userRepo repositories.UserRepository
+	hasher   *auth.PasswordHasher
+	checker  *password.Checker
+	logger   *zap.Logger
+}
+
+// change verifies a user's current password and replaces it with a new one.
+func (m *passwordManager) change(ctx context.Context, userID, oldPassword, newPassword string) error {
+	user, err := m.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, repositories.ErrNotFound) || errors.Is(err, repositories.ErrInvalidInput) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if ok, err := m.verify(user, oldPassword); err != nil {
+		return err
+	} else if !ok {
+		return ErrIncorrectPassword
+	}
+	org, err := m.orgRepo.GetByID(ctx, user.OrganizationID.Hex())
+	if errors.Is(err, repositories.ErrNotFound) {
+		return ErrOrganizationNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get organization: %w", err)
+	}
+	if err := m.set(ctx, org, user, newPassword); err != nil {
+		return err
+	}
+	if err := m.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	m.logger.Info("Password changed", zap.String("user_id", userID))
+	return nil
+}
+
+// set checks a new password against the organization's policy and the
+// user's previous passwords and sets it on the user, who still has to be
+// saved. The replaced hash moves into the user's password history.
+func (m *passwordManager) set(ctx context.Context, org *models.Organization, user *models.User, plain string) error {
+	policy := passwordPolicy(org)
+	if err := m.check(ctx, policy, org, user, plain); err != nil {
+		return err
+	}
+
+	details := &user.Authentication
+	if details.PasswordHash != "" {
+		previous := append([]string{details.PasswordHash}, details.PasswordHistory...)
+		for _, hash := range previous[:min(len(previous), policy.HistoryCount+1)] {
+			if reused, err := m.hasher.VerifyPassword(plain, hash); err != nil {
+				return err
+			} else if reused {
+				return ErrPasswordReused
+			}
+		}
+	}
+
+	hash, err := m.hasher.HashPassword(plain)
+	if errors.Is(err, auth.ErrPasswordTooWeak) {
+		return fmt.Errorf("%w: %v", ErrPasswordTooWeak, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	var history []string
+	if policy.HistoryCount > 0 {
+		if details.PasswordHash != "" {
+			history = append(history, details.PasswordHash)
+		}
+		history = append(history, details.PasswordHistory...)
+		history = history[:min(len(history), policy.HistoryCount)]
+	}
+
+	now := time.Now().UTC()
+	details.PasswordHash = hash
+	details.PasswordHistory = history
+	details.LastPasswordChange = now
+	details.PasswordExpiresAt = time.Time{}
+	if policy.MaxAgeDays > 0 {
+		details.PasswordExpiresAt = now.AddDate(0, 0, policy.MaxAgeDays)
+	}
+	details.RequirePasswordReset = false
+	user.UpdatedAt = now
+	return nil
+}
+
+// check applies the policy's rules. A failing breach list lookup is logged
+// and the password is accepted, so a broken list cannot block every
+// password change.
+func (m *passwordManager) check(ctx context.Context, policy models.PasswordPolicy, org *models.Organization, user *models.User, plain string) error {
+	rules := password.Rules{
+		MinLength:        policy.MinLength,
+		MaxLength:        auth.MaxPasswordLength,
+		RequireUppercase: policy.RequireUppercase,
+		RequireLowercase: policy.RequireLowercase,
+		RequireDigit:     policy.RequireDigit,
+		RequireSymbol:    policy.RequireSymbol,
+		RejectCommon:     policy.RejectCommon,
+		RejectBreached:   policy.RejectBreached,
+	}
+	personal := []string{user.Email, user.Profile.FirstName, user.Profile.LastName, org.Name}
+	violations, err := m.checker.Check(ctx, plain, rules, personal...)
+	if err != nil {
+		m.logger.Error("Breached password check failed", zap.Error(err), zap.String("user_id", user.ID.Hex()))
+		rules.RejectBreached = false
+		if violations, err = m.checker.Check(ctx, plain, rules, personal...); err != nil {
+			return err
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(violations))
+	for i, violation := range violations {
+		names[i] = string(violation)
+	}
+	cause := ErrPasswordTooWeak
+	switch violations[0] {
+	case password.ViolationCommon, password.ViolationPersonal:
+		cause = ErrPasswordCommon
+	case password.ViolationBreached:
+		cause = ErrPasswordBreached
+	}
+	return fmt.Errorf("%w: %s", cause, strings.Join(names, ", "))
+}
+
+// expired reports whether a user must set a new password before signing in:
+// an administrator required a reset, or the password is older than the
+// policy's maximum age.
+func (m *passwordManager) expired(org *models.Organization, user *models.User, now time.Time) bool {
+	details := user.Authentication
+	if details.RequirePasswordReset {
+		return true
+	}
+	if !details.PasswordExpiresAt.IsZero() && now.After(details.PasswordExpiresAt) {
+		return true
+	}
+	maxAge := passwordPolicy(org).MaxAgeDays
+	return maxAge > 0 && !details.LastPasswordChange.IsZero() && now.After(details.LastPasswordChange.AddDate(0, 0, maxAge))
+}
+
+// verify checks a password against the user's hash; accounts that sign in
+// only through single sign-on or a directory have no hash and match no
+// password.
+func (m *passwordManager) verify(user *models.User, plain string) (bool, error) {
+	if user.Authentication.PasswordHash == "" {
+		return false, nil
+	}
+	return m.hasher.VerifyPassword(plain, user.Authentication.PasswordHash)
+}
+
+// passwordPolicy returns an organization's password policy with the
+// platform's bounds applied.
+func passwordPolicy(org *models.Organization) models.PasswordPolicy {
+	policy := defaultPasswordPolicy
+	if org != nil && org.Settings.PasswordPolicy != nil {
+		policy = *org.Settings.PasswordPolicy
+	}
+	policy.MinLength = max(policy.MinLength, auth.MinPasswordLength)
+	policy.HistoryCount = min(max(policy.HistoryCount, 0), maxPasswordHistory)
+	policy.MaxAgeDays = max(policy.MaxAgeDays, 0)
+	return policy
+}
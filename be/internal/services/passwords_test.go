@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/auth"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/cache"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/cache/cachetest"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/password"
+)
+
+// breachedPassword is listed in the test breach list by its SHA-1 hash.
+const breachedPassword = "correct horse battery staple"
+
+// failingRangeSource is a breach list source that is unavailable.
+type failingRangeSource struct{}
+
+func (failingRangeSource) Range(ctx context.Context, prefix string) ([]string, error) {
+	return nil, errors.New("range file unreadable")
+}
+
+// testPasswordConfig allows three password reset requests per email address an hour.
+var testPasswordConfig = config.PasswordConfig{ResetTTL: time.Hour, ResetLimit: 3, ResetIPLimit: 20, ResetWindow: time.Hour}
+
+func newPasswordAuthService(t *testing.T, f *userFixture, source password.RangeSource) AuthenticationService {
+	t.Helper()
+	return NewAuthenticationService(f.orgs, f.users, &fakeSessionRepository{}, cachetest.NewDenylist(), &fakePasswordResetRepository{}, f.notifications,
+		cache.NewLocalRateLimits(), testJWTManager, auth.NewPasswordHasher(4),
+		password.NewChecker(password.DefaultDictionary(), password.NewBreachList(source)), testPasswordConfig, testSessionConfig, zap.NewNop())
+}
+
+func newBreachedHashSet(t *testing.T) *password.HashSet {
+	t.Helper()
+	set, err := password.ReadHashSet(strings.NewReader("ABF7AAD6438836DBE526AA231ABDE2D0EEF74D42:3645804\n"))
+	require.NoError(t, err)
+	return set
+}
+
+func TestAuthenticationService_ChangePasswordPolicy(t *testing.T) {
+	f := newUserFixture(t)
+	ctx := context.Background()
+	user := f.createUser(t, "ada@first-bank.com")
+	service := newPasswordAuthService(t, f, newBreachedHashSet(t))
+	userID := user.ID.Hex()
+
+	// The default policy rejects common, personal and breached passwords
+	for candidate, expected := range map[string]error{
+		"Password123!":       ErrPasswordCommon,
+		"ada-auditor-rocks":  ErrPasswordCommon,
+		"First Bank forever": ErrPasswordCommon,
+		breachedPassword:     ErrPasswordBreached,
+		"tiny":               ErrPasswordTooWeak,
+	} {
+		err := service.ChangePassword(ctx, userID, testUserPassword, candidate)
+		assert.ErrorIs(t, err, expected, candidate)
+	}
+
+	f.org.Settings.PasswordPolicy = &models.PasswordPolicy{MinLength: 8, RequireUppercase: true, RequireDigit: true, RequireSymbol: true}
+	err := service.ChangePassword(ctx, userID, testUserPassword, "Ab1!")
+	assert.ErrorIs(t, err, ErrPasswordTooWeak, "the platform minimum length applies")
+	err = service.ChangePassword(ctx, userID, testUserPassword, "quiet meadow lantern")
+	assert.ErrorIs(t, err, ErrPasswordTooWeak)
+	assert.ErrorContains(t, err, "missing_uppercase, missing_digit, missing_symbol")
+
+	require.NoError(t, service.ChangePassword(ctx, userID, testUserPassword, breachedPassword+" 1!A"),
+		"breach and dictionary checks are off when the policy does not enable them")
+	require.NoError(t, service.ChangePassword(ctx, userID, breachedPassword+" 1!A", "Password1234!"))
+	assert.Nil(t, user.Authentication.PasswordHistory, "no history is kept without HistoryCount")
+	assert.True(t, user.Authentication.PasswordExpiresAt.IsZero())
+
+	err = service.ChangePassword(ctx, userID, "Password1234!", "Password1234!")
+	assert.ErrorIs(t, err, ErrPasswordReused, "the current password is never reused")
+}
+
+func TestAuthenticationService_PasswordHistory(t *testing.T) {
+	f := newUserFixture(t)
+	ctx := context.Background()
+	user := f.createUser(t, "ada@first-bank.com")
+	service := newPasswordAuthService(t, f, newBreachedHashSet(t))
+	userID := user.ID.Hex()
+	f.org.Settings.PasswordPolicy = &models.PasswordPolicy{HistoryCount: 2, RejectCommon: true}
+
+	passwords := []string{testUserPassword, "quiet meadow lantern", "silver harbor compass", "amber orchard signal"}
+	for i := 1; i < len(passwords); i++ {
+		require.NoError(t, service.ChangePassword(ctx, userID, passwords[i-1], passwords[i]))
+	}
+	assert.Len(t, user.Authentication.PasswordHistory, 2)
+
+	for _, reused := range passwords[1:] {
+		err := service.ChangePassword(ctx, userID, passwords[3], reused)
+		assert.ErrorIs(t, err, ErrPasswordReused, reused)
+	}
+	require.NoError(t, service.ChangePassword(ctx, userID, passwords[3], passwords[0]),
+		"passwords older than the history can be used again")
+}
+
+func TestUserService_PasswordExpiry(t *testing.T) {
+	f := newUserFixture(t)
+	ctx := context.Background()
+	f.org.Settings.PasswordPolicy = &models.PasswordPolicy{MaxAgeDays: 30}
+	user := f.createUser(t, "ada@first-bank.com")
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, 30), user.Authentication.PasswordExpiresAt, time.Minute)
+
+	_, err := f.service.AuthenticateUser(ctx, user.Email, testUserPassword)
+	require.NoError(t, err)
+
+	user.Authentication.PasswordExpiresAt = time.Now().Add(-time.Minute)
+	_, err = f.service.AuthenticateUser(ctx, user.Email, testUserPassword)
+	assert.ErrorIs(t, err, ErrPasswordExpired)
+
+	// Passwords set before the policy had a maximum age expire too
+	user.Authentication.PasswordExpiresAt = time.Time{}
+	user.Authentication.LastPasswordChange = time.Now().AddDate(0, 0, -31)
+	_, err = f.service.AuthenticateUser(ctx, user.Email, testUserPassword)
+	assert.ErrorIs(t, err, ErrPasswordExpired)
+
+	f.org.Settings.PasswordPolicy = nil
+	user.Authentication.RequirePasswordReset = true
+	_, err = f.service.AuthenticateUser(ctx, user.Email, testUserPassword)
+	assert.ErrorIs(t, err, ErrPasswordExpired)
+
+	require.NoError(t, f.service.UpdatePassword(ctx, user.ID.Hex(), testUserPassword, "quiet meadow lantern"))
+	assert.False(t, user.Authentication.RequirePasswordReset)
+	_, err = f.service.AuthenticateUser(ctx, user.Email, "quiet meadow lantern")
+	require.NoError(t, err)
+}
+
+func TestAuthenticationService_BreachListUnavailable(t *testing.T) {
+	f := newUserFixture(t)
+	ctx := context.Background()
+	user := f.createUser(t, "ada@first-bank.com")
+	service := newPasswordAuthService(t, f, failingRangeSource{})
+
+	require.NoError(t, service.ChangePassword(ctx, user.ID.Hex(), testUserPassword, "quiet meadow lantern"),
+		"an unavailable breach list does not block password changes")
+	err := service.ChangePassword(ctx, user.ID.Hex(), "quiet meadow lantern", "Password2024!")
+	assert.ErrorIs(t, err, ErrPasswordCommon, "the other rules still apply")
+}
+
+func TestNewPasswordChecker(t *testing.T) {
+	dir := t.TempDir()
+	dictionary := filepath.Join(dir, "words.txt")
+	require.NoError(t, os.WriteFile(dictionary, []byte("firstbank\n"), 0o600))
+	hashes := filepath.Join(dir, "hashes.txt")
+	require.NoError(t, os.WriteFile(hashes, []byte("ABF7AAD6438836DBE526AA231ABDE2D0EEF74D42\n"), 0o600))
+
+	checker, err := NewPasswordChecker(config.PasswordConfig{DictionaryPath: dictionary, BreachedHashesPath: hashes})
+	require.NoError(t, err)
+	rules := password.Rules{MinLength: auth.MinPasswordLength, RejectCommon: true, RejectBreached: true}
+	for candidate, expected := range map[string]password.Violation{
+		"FirstBank2026!": password.ViolationCommon,
+		breachedPassword: password.ViolationBreached,
+	} {
+		violations, err := checker.Check(context.Background(), candidate, rules)
+		require.NoError(t, err)
+		assert.Equal(t, []password.Violation{expected}, violations, candidate)
+	}
+
+	_, err = NewPasswordChecker(config.PasswordConfig{BreachedHashesPath: filepath.Join(dir, "missing")})
+	assert.Error(t, err)
+}
@@ -0,0 +1,377 @@
+// Package services provides service layer implementations for the GoEdu Control Testing Platform.
+// This file contains the query service, a read-only view of an organization's
+// controls, testing cycles and evidence requests used by the GraphQL API.
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+)
+
+// Page size bounds applied by the query service
+const (
+	defaultQueryLimit = 20
+	maxQueryLimit     = 500
+)
+
+// Query errors
+var (
+	ErrOrganizationNotFound = errors.New("organization not found")
+	ErrControlNotFound      = errors.New("control not found")
+	ErrTestingCycleNotFound = errors.New("testing cycle not found")
+)
+
+// queryService implements the QueryService interface.
+type queryService struct {
+	orgRepo      repositories.OrganizationRepository
+	controlRepo  repositories.ControlRepository
+	cycleRepo    repositories.TestingCycleRepository
+	evidenceRepo repositories.EvidenceRequestRepository
+	userRepo     repositories.UserRepository
+	logger       *zap.Logger
+}
+
+// NewQueryService creates a new query service.
+//
+// Parameters:
+//   - orgRepo: Repository for organization data
+//   - controlRepo: Repository for control data
+//   - cycleRepo: Repository for testing cycle data
+//   - evidenceRepo: Repository for evidence request data
+//   - userRepo: Repository for user data
+//   - logger: Logger for service operations
+//
+// Returns:
+//   - QueryService: Configured query service instance
+func NewQueryService(
+	orgRepo repositories.OrganizationRepository,
+	controlRepo repositories.ControlRepository,
+	cycleRepo repositories.TestingCycleRepository,
+	evidenceRepo repositories.EvidenceRequestRepository,
+	userRepo repositories.UserRepository,
+	logger *zap.Logger,
+) QueryService {
+	return &queryService{
+		orgRepo:      orgRepo,
+		controlRepo:  controlRepo,
+		cycleRepo:    cycleRepo,
+		evidenceRepo: evidenceRepo,
+		userRepo:     userRepo,
+		logger:       logger,
+	}
+}
+
+// GetOrganization retrieves an organization.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID
+//
+// Returns:
+//   - *models.Organization: The organization
+//   - error: ErrOrganizationNotFound or a repository error
+func (s *queryService) GetOrganization(ctx context.Context, orgID string) (*models.Organization, error) {
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrOrganizationNotFound
+		}
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	return org, nil
+}
+
+// ListControls retrieves a page of an organization's controls.
+//
+// Parameters:
+//   - ctx: Request context
+//   - filter: Organization, filters and pagination; a non-positive limit selects the default page size
+//
+// Returns:
+//   - *ControlConnection: Page of controls with the total count
+//   - error: ErrInvalidInput or a repository error
+func (s *queryService) ListControls(ctx context.Context, filter *ControlFilter) (*ControlConnection, error) {
+	if filter == nil || filter.OrganizationID == "" || filter.Offset < 0 {
+		return nil, ErrInvalidInput
+	}
+	repoFilter := &repositories.ControlFilter{
+		Framework: filter.Framework,
+		Category:  filter.Category,
+		RiskLevel: filter.RiskLevel,
+		Status:    filter.Status,
+		Owner:     filter.Owner,
+		Tags:      filter.Tags,
+		Limit:     pageLimit(filter.Limit),
+		Offset:    filter.Offset,
+		SortBy:    filter.SortBy,
+		SortOrder: filter.SortOrder,
+	}
+
+	controls, err := s.controlRepo.GetByOrganization(ctx, filter.OrganizationID, repoFilter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list controls: %w", err)
+	}
+	total, err := s.controlRepo.CountByOrganization(ctx, filter.OrganizationID, repoFilter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count controls: %w", err)
+	}
+	return &ControlConnection{
+		Nodes:      controls,
+		TotalCount: int(total),
+		HasMore:    filter.Offset+len(controls) < int(total),
+	}, nil
+}
+
+// GetControl retrieves a control of an organization.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization the control must belong to
+//   - id: Control ID
+//
+// Returns:
+//   - *models.Control: The control
+//   - error: ErrControlNotFound or a repository error
+func (s *queryService) GetControl(ctx context.Context, orgID, id string) (*models.Control, error) {
+	control, err := s.controlRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrControlNotFound
+		}
+		return nil, fmt.Errorf("failed to get control: %w", err)
+	}
+	if control.OrganizationID.Hex() != orgID {
+		return nil, ErrControlNotFound
+	}
+	return control, nil
+}
+
+// GetControlsByIDs retrieves several controls of an organization in one query.
+// Unknown IDs and controls of other organizations are left out.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization the controls must belong to
+//   - ids: Control IDs
+//
+// Returns:
+//   - []*models.Control: The controls found, in no particular order
+//   - error: Repository error
+func (s *queryService) GetControlsByIDs(ctx context.Context, orgID string, ids []string) ([]*models.Control, error) {
+	ids = validObjectIDs(ids)
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	controls, err := s.controlRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get controls: %w", err)
+	}
+	scoped := controls[:0]
+	for _, control := range controls {
+		if control.OrganizationID.Hex() == orgID {
+			scoped = append(scoped, control)
+		}
+	}
+	return scoped, nil
+}
+
+// ListTestingCycles retrieves a page of an organization's testing cycles.
+//
+// Parameters:
+//   - ctx: Request context
+//   - filter: Organization and pagination; a non-positive limit selects the default page size
+//
+// Returns:
+//   - *TestingCycleConnection: Page of testing cycles with the total count
+//   - error: ErrInvalidInput or a repository error
+func (s *queryService) ListTestingCycles(ctx context.Context, filter *TestingCycleFilter) (*TestingCycleConnection, error) {
+	if filter == nil || filter.OrganizationID == "" || filter.Offset < 0 {
+		return nil, ErrInvalidInput
+	}
+	cycles, err := s.cycleRepo.GetByOrganization(ctx, filter.OrganizationID, pageLimit(filter.Limit), filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list testing cycles: %w", err)
+	}
+	total, err := s.cycleRepo.CountByOrganization(ctx, filter.OrganizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count testing cycles: %w", err)
+	}
+	return &TestingCycleConnection{
+		Nodes:      cycles,
+		TotalCount: int(total),
+		HasMore:    filter.Offset+len(cycles) < int(total),
+	}, nil
+}
+
+// GetTestingCycle retrieves a testing cycle of an organization.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization the cycle must belong to
+//   - id: Testing cycle ID
+//
+// Returns:
+//   - *models.TestingCycle: The testing cycle
+//   - error: ErrTestingCycleNotFound or a repository error
+func (s *queryService) GetTestingCycle(ctx context.Context, orgID, id string) (*models.TestingCycle, error) {
+	cycle, err := s.cycleRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrTestingCycleNotFound
+		}
+		return nil, fmt.Errorf("failed to get testing cycle: %w", err)
+	}
+	if cycle.OrganizationID.Hex() != orgID {
+		return nil, ErrTestingCycleNotFound
+	}
+	return cycle, nil
+}
+
+// GetTestingCyclesByIDs retrieves several testing cycles of an organization in
+// one query. Unknown IDs and cycles of other organizations are left out.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization the cycles must belong to
+//   - ids: Testing cycle IDs
+//
+// Returns:
+//   - []*models.TestingCycle: The testing cycles found, in no particular order
+//   - error: Repository error
+func (s *queryService) GetTestingCyclesByIDs(ctx context.Context, orgID string, ids []string) ([]*models.TestingCycle, error) {
+	ids = validObjectIDs(ids)
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	cycles, err := s.cycleRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get testing cycles: %w", err)
+	}
+	scoped := cycles[:0]
+	for _, cycle := range cycles {
+		if cycle.OrganizationID.Hex() == orgID {
+			scoped = append(scoped, cycle)
+		}
+	}
+	return scoped, nil
+}
+
+// ListEvidenceRequests retrieves a page of an organization's evidence requests.
+//
+// Parameters:
+//   - ctx: Request context
+//   - filter: Organization, filters and pagination; a non-positive limit selects the default page size
+//
+// Returns:
+//   - *EvidenceRequestConnection: Page of evidence requests with the total count
+//   - error: ErrInvalidInput or a repository error
+func (s *queryService) ListEvidenceRequests(ctx context.Context, filter *EvidenceRequestFilter) (*EvidenceRequestConnection, error) {
+	if filter == nil || filter.OrganizationID == "" || filter.Offset < 0 {
+		return nil, ErrInvalidInput
+	}
+	repoFilter := &repositories.EvidenceRequestFilter{
+		Status:     filter.Status,
+		ControlID:  filter.ControlID,
+		CycleID:    filter.CycleID,
+		AssigneeID: filter.AssigneeID,
+		Limit:      pageLimit(filter.Limit),
+		Offset:     filter.Offset,
+	}
+
+	requests, err := s.evidenceRepo.GetByOrganization(ctx, filter.OrganizationID, repoFilter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list evidence requests: %w", err)
+	}
+	total, err := s.evidenceRepo.CountByOrganization(ctx, filter.OrganizationID, repoFilter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count evidence requests: %w", err)
+	}
+	return &EvidenceRequestConnection{
+		Nodes:      requests,
+		TotalCount: int(total),
+		HasMore:    filter.Offset+len(requests) < int(total),
+	}, nil
+}
+
+// GetEvidenceRequest retrieves an evidence request of an organization.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization the request must belong to
+//   - id: Evidence request ID
+//
+// Returns:
+//   - *models.EvidenceRequest: The evidence request
+//   - error: ErrEvidenceRequestNotFound or a repository error
+func (s *queryService) GetEvidenceRequest(ctx context.Context, orgID, id string) (*models.EvidenceRequest, error) {
+	request, err := s.evidenceRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrEvidenceRequestNotFound
+		}
+		return nil, fmt.Errorf("failed to get evidence request: %w", err)
+	}
+	if request.OrganizationID.Hex() != orgID {
+		return nil, ErrEvidenceRequestNotFound
+	}
+	return request, nil
+}
+
+// GetUsersByIDs retrieves several members of an organization in one query.
+// Unknown IDs and users of other organizations are left out.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization the users must belong to
+//   - ids: User IDs
+//
+// Returns:
+//   - []*models.User: The users found, in no particular order
+//   - error: Repository error
+func (s *queryService) GetUsersByIDs(ctx context.Context, orgID string, ids []string) ([]*models.User, error) {
+	ids = validObjectIDs(ids)
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	users, err := s.userRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	scoped := users[:0]
+	for _, user := range users {
+		if user.OrganizationID.Hex() == orgID {
+			scoped = append(scoped, user)
+		}
+	}
+	return scoped, nil
+}
+
+// pageLimit applies the default and maximum page size.
+func pageLimit(limit int) int {
+	switch {
+	case limit <= 0:
+		return defaultQueryLimit
+	case limit > maxQueryLimit:
+		return maxQueryLimit
+	}
+	return limit
+}
+
+// validObjectIDs drops IDs that are not object IDs, which cannot match any
+// document, so that repositories only see well-formed IDs.
+func validObjectIDs(ids []string) []string {
+	valid := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if _, err := primitive.ObjectIDFromHex(id); err == nil {
+			valid = append(valid, id)
+		}
+	}
+	return valid
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+)
+
+// fakeControlRepository keeps controls in insertion order and counts batch lookups.
+type fakeControlRepository struct {
+	repositories.ControlRepository
+	mu           sync.Mutex
+	controls     []*models.Control
+	batchLookups int
+}
+
+func (r *fakeControlRepository) GetByID(ctx context.Context, id string) (*models.Control, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, control := range r.controls {
+		if control.ID.Hex() == id {
+			return control, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r *fakeControlRepository) GetByIDs(ctx context.Context, ids []string) ([]*models.Control, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batchLookups++
+	var controls []*models.Control
+	for _, control := range r.controls {
+		for _, id := range ids {
+			if control.ID.Hex() == id {
+				controls = append(controls, control)
+			}
+		}
+	}
+	return controls, nil
+}
+
+func (r *fakeControlRepository) matching(orgID string, filter *repositories.ControlFilter) []*models.Control {
+	var controls []*models.Control
+	for _, control := range r.controls {
+		if control.OrganizationID.Hex() != orgID {
+			continue
+		}
+		if filter.Framework != "" && control.Framework != filter.Framework {
+			continue
+		}
+		controls = append(controls, control)
+	}
+	return controls
+}
+
+func (r *fakeControlRepository) GetByOrganization(ctx context.Context, orgID string, filter *repositories.ControlFilter) ([]*models.Control, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return paginate(r.matching(orgID, filter), filter.Limit, filter.Offset), nil
+}
+
+func (r *fakeControlRepository) CountByOrganization(ctx context.Context, orgID string, filter *repositories.ControlFilter) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return int64(len(r.matching(orgID, filter))), nil
+}
+
+type fakeTestingCycleRepository struct {
+	repositories.TestingCycleRepository
+	mu     sync.Mutex
+	cycles []*models.TestingCycle
+}
+
+func (r *fakeTestingCycleRepository) GetByID(ctx context.Context, id string) (*models.TestingCycle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, cycle := range r.cycles {
+		if cycle.ID.Hex() == id {
+			return cycle, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r *fakeTestingCycleRepository) GetByIDs(ctx context.Context, ids []string) ([]*models.TestingCycle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var cycles []*models.TestingCycle
+	for _, cycle := range r.cycles {
+		for _, id := range ids {
+			if cycle.ID.Hex() == id {
+				cycles = append(cycles, cycle)
+			}
+		}
+	}
+	return cycles, nil
+}
+
+func (r *fakeTestingCycleRepository) GetByOrganization(ctx context.Context, orgID string, limit, offset int) ([]*models.TestingCycle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var cycles []*models.TestingCycle
+	for _, cycle := range r.cycles {
+		if cycle.OrganizationID.Hex() == orgID {
+			cycles = append(cycles, cycle)
+		}
+	}
+	return paginate(cycles, limit, offset), nil
+}
+
+func (r *fakeTestingCycleRepository) CountByOrganization(ctx context.Context, orgID string) (int64, error) {
+	cycles, _ := r.GetByOrganization(ctx, orgID, 0, 0)
+	return int64(len(cycles)), nil
+}
+
+type queryFixture struct {
+	orgID    primitive.ObjectID
+	otherOrg primitive.ObjectID
+	controls *fakeControlRepository
+	cycles   *fakeTestingCycleRepository
+	requests *fakeEvidenceRequestRepository
+	users    *fakeUserRepository
+	service  QueryService
+}
+
+func newQueryFixture(t *testing.T) *queryFixture {
+	t.Helper()
+
+	f := &queryFixture{
+		orgID:    primitive.NewObjectID(),
+		otherOrg: primitive.NewObjectID(),
+		controls: &fakeControlRepository{},
+		cycles:   &fakeTestingCycleRepository{},
+		requests: newFakeEvidenceRequestRepository(),
+		users:    newFakeUserRepository(),
+	}
+	org := &models.Organization{Name: "Acme Bank"}
+	org.ID = f.orgID
+
+	f.service = NewQueryService(newFakeOrganizationRepository(org), f.controls, f.cycles, f.requests, f.users, zap.NewNop())
+	return f
+}
+
+func (f *queryFixture) addControl(orgID primitive.ObjectID, framework string) *models.Control {
+	control := &models.Control{OrganizationID: orgID, Framework: framework}
+	control.ID = primitive.NewObjectID()
+	f.controls.controls = append(f.controls.controls, control)
+	return control
+}
+
+func TestQueryService_ListControls(t *testing.T) {
+	f := newQueryFixture(t)
+	for i := 0; i < 3; i++ {
+		f.addControl(f.orgID, "SOX")
+	}
+	f.addControl(f.orgID, "PCI")
+	f.addControl(f.otherOrg, "SOX")
+
+	page, err := f.service.ListControls(context.Background(), &ControlFilter{
+		OrganizationID: f.orgID.Hex(),
+		Framework:      "SOX",
+		Limit:          2,
+	})
+	require.NoError(t, err)
+	assert.Len(t, page.Nodes, 2)
+	assert.Equal(t, 3, page.TotalCount)
+	assert.True(t, page.HasMore)
+
+	page, err = f.service.ListControls(context.Background(), &ControlFilter{
+		OrganizationID: f.orgID.Hex(),
+		Framework:      "SOX",
+		Limit:          2,
+		Offset:         2,
+	})
+	require.NoError(t, err)
+	assert.Len(t, page.Nodes, 1)
+	assert.False(t, page.HasMore)
+
+	_, err = f.service.ListControls(context.Background(), &ControlFilter{})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
+
+func TestQueryService_GetScopesToOrganization(t *testing.T) {
+	f := newQueryFixture(t)
+	own := f.addControl(f.orgID, "SOX")
+	foreign := f.addControl(f.otherOrg, "SOX")
+
+	control, err := f.service.GetControl(context.Background(), f.orgID.Hex(), own.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, own, control)
+
+	_, err = f.service.GetControl(context.Background(), f.orgID.Hex(), foreign.ID.Hex())
+	assert.ErrorIs(t, err, ErrControlNotFound)
+
+	_, err = f.service.GetTestingCycle(context.Background(), f.orgID.Hex(), primitive.NewObjectID().Hex())
+	assert.ErrorIs(t, err, ErrTestingCycleNotFound)
+
+	_, err = f.service.GetEvidenceRequest(context.Background(), f.orgID.Hex(), primitive.NewObjectID().Hex())
+	assert.ErrorIs(t, err, ErrEvidenceRequestNotFound)
+
+	_, err = f.service.GetOrganization(context.Background(), f.otherOrg.Hex())
+	assert.ErrorIs(t, err, ErrOrganizationNotFound)
+}
+
+func TestQueryService_GetControlsByIDs(t *testing.T) {
+	f := newQueryFixture(t)
+	a := f.addControl(f.orgID, "SOX")
+	b := f.addControl(f.orgID, "SOX")
+	foreign := f.addControl(f.otherOrg, "SOX")
+
+	controls, err := f.service.GetControlsByIDs(context.Background(), f.orgID.Hex(),
+		[]string{a.ID.Hex(), b.ID.Hex(), foreign.ID.Hex(), "not-an-id"})
+
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []*models.Control{a, b}, controls)
+	assert.Equal(t, 1, f.controls.batchLookups)
+
+	// IDs that cannot exist do not reach the repository
+	controls, err = f.service.GetControlsByIDs(context.Background(), f.orgID.Hex(), []string{"not-an-id"})
+	require.NoError(t, err)
+	assert.Empty(t, controls)
+	assert.Equal(t, 1, f.controls.batchLookups)
+}
+
+func TestQueryService_ListEvidenceRequests(t *testing.T) {
+	f := newQueryFixture(t)
+	now := time.Now()
+	for i, status := range []string{models.EvidenceRequestStatusPending, models.EvidenceRequestStatusPending, models.EvidenceRequestStatusInProgress} {
+		request := &models.EvidenceRequest{OrganizationID: f.orgID, Status: status, DueDate: now.Add(time.Duration(3-i) * time.Hour)}
+		request.ID = primitive.NewObjectID()
+		f.requests.requests[request.ID.Hex()] = request
+	}
+
+	page, err := f.service.ListEvidenceRequests(context.Background(), &EvidenceRequestFilter{
+		OrganizationID: f.orgID.Hex(),
+		Status:         models.EvidenceRequestStatusPending,
+	})
+
+	require.NoError(t, err)
+	require.Len(t, page.Nodes, 2)
+	assert.True(t, page.Nodes[0].DueDate.Before(page.Nodes[1].DueDate))
+	assert.Equal(t, 2, page.TotalCount)
+	assert.False(t, page.HasMore)
+}
+
+func TestQueryService_ListTestingCycles(t *testing.T) {
+	f := newQueryFixture(t)
+	for _, orgID := range []primitive.ObjectID{f.orgID, f.orgID, f.otherOrg} {
+		cycle := &models.TestingCycle{OrganizationID: orgID}
+		cycle.ID = primitive.NewObjectID()
+		f.cycles.cycles = append(f.cycles.cycles, cycle)
+	}
+
+	page, err := f.service.ListTestingCycles(context.Background(), &TestingCycleFilter{OrganizationID: f.orgID.Hex(), Limit: 1})
+
+	require.NoError(t, err)
+	assert.Len(t, page.Nodes, 1)
+	assert.Equal(t, 2, page.TotalCount)
+	assert.True(t, page.HasMore)
+}
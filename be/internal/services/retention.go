@@ -0,0 +1,783 @@
+// Package services provides service layer implementations for the GoEdu Control Testing Platform.
+// This file contains the retention engine which purges audit logs, evidence files,
+// sessions and soft-deleted comments once they exceed the organization's retention
+// period, unless a legal hold covers them.
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/storage"
+)
+
+// AuditActionRetentionPurged is recorded for every purge batch.
+const AuditActionRetentionPurged = "retention.purged"
+
+// Retention errors
+var (
+	ErrRetentionDisabled      = errors.New("retention enforcement is disabled")
+	ErrLegalHoldNotFound      = errors.New("legal hold not found")
+	ErrLegalHoldReleased      = errors.New("legal hold has already been released")
+	ErrInvalidLegalHold       = errors.New("legal hold scope requires a valid resource")
+	ErrLegalHoldReasonMissing = errors.New("legal hold reason is required")
+)
+
+// errStopIteration ends a chain iteration early without reporting an error.
+var errStopIteration = errors.New("stop iteration")
+
+// retentionService implements the RetentionService interface.
+type retentionService struct {
+	orgRepo        repositories.OrganizationRepository
+	auditRepo      repositories.AuditLogRepository
+	checkpointRepo repositories.AuditCheckpointRepository
+	evidenceRepo   repositories.EvidenceRequestRepository
+	commentRepo    repositories.CommentRepository
+	sessionRepo    repositories.SessionRepository
+	holdRepo       repositories.LegalHoldRepository
+	files          storage.Store
+	enabled        bool
+	batchSize      int
+	logger         *zap.Logger
+}
+
+// NewRetentionService creates a new retention service.
+//
+// Parameters:
+//   - orgRepo: Repository for organization data operations
+//   - auditRepo: Repository for audit logs, used both as purge target and to record purges
+//   - checkpointRepo: Repository for audit chain checkpoints bounding audit log purges
+//   - evidenceRepo: Repository for evidence requests and their files
+//   - commentRepo: Repository for soft-deleted comments
+//   - sessionRepo: Repository for user sessions
+//   - holdRepo: Repository for legal holds
+//   - files: Object store holding evidence files
+//   - cfg: Retention configuration
+//   - logger: Logger for service operations
+//
+// Returns:
+//   - RetentionService: Configured retention service instance
+func NewRetentionService(
+	orgRepo repositories.OrganizationRepository,
+	auditRepo repositories.AuditLogRepository,
+	checkpointRepo repositories.AuditCheckpointRepository,
+	evidenceRepo repositories.EvidenceRequestRepository,
+	commentRepo repositories.CommentRepository,
+	sessionRepo repositories.SessionRepository,
+	holdRepo repositories.LegalHoldRepository,
+	files storage.Store,
+	cfg config.RetentionConfig,
+	logger *zap.Logger,
+) RetentionService {
+	return &retentionService{
+		orgRepo:        orgRepo,
+		auditRepo:      auditRepo,
+		checkpointRepo: checkpointRepo,
+		evidenceRepo:   evidenceRepo,
+		commentRepo:    commentRepo,
+		sessionRepo:    sessionRepo,
+		holdRepo:       holdRepo,
+		files:          files,
+		enabled:        cfg.Enabled,
+		batchSize:      cfg.BatchSize,
+		logger:         logger,
+	}
+}
+
+// EffectiveRetentionPolicy returns the retention policy of an organization. The
+// regulatory retention period and the organization's own setting are both
+// minimums, so the longer one applies. A zero RetentionDays means no policy is
+// configured and nothing is purged.
+//
+// Parameters:
+//   - org: Organization whose policy is computed
+//
+// Returns:
+//   - RetentionPolicy: Effective policy
+func EffectiveRetentionPolicy(org *models.Organization) RetentionPolicy {
+	policy := RetentionPolicy{
+		RegulatoryYears:   org.RegulatoryProfile.RetentionPeriod,
+		DataRetentionDays: org.Settings.DataRetentionDays,
+	}
+	policy.RetentionDays = policy.RegulatoryYears * 365
+	if policy.DataRetentionDays > policy.RetentionDays {
+		policy.RetentionDays = policy.DataRetentionDays
+	}
+	return policy
+}
+
+// Report computes what a purge would remove without changing any data.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID
+//
+// Returns:
+//   - *RetentionReport: Dry run report
+//   - error: Error if the organization or its data cannot be read
+func (s *retentionService) Report(ctx context.Context, orgID string) (*RetentionReport, error) {
+	org, err := s.loadOrganization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return s.run(ctx, org, true)
+}
+
+// Enforce purges an organization's data past its retention period. Every purged
+// batch is recorded in the audit log; the run stops if a batch cannot be recorded.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID
+//
+// Returns:
+//   - *RetentionReport: Report of the purge, including partial results on failure
+//   - error: ErrRetentionDisabled or an error that stopped the purge
+func (s *retentionService) Enforce(ctx context.Context, orgID string) (*RetentionReport, error) {
+	if !s.enabled {
+		return nil, ErrRetentionDisabled
+	}
+	org, err := s.loadOrganization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return s.run(ctx, org, false)
+}
+
+// EnforceAll enforces retention for all active organizations. Failures for a
+// single organization are logged and do not abort the run for the others.
+//
+// Parameters:
+//   - ctx: Request context
+//
+// Returns:
+//   - int64: Number of records purged
+//   - error: ErrRetentionDisabled or an error if organizations cannot be listed
+func (s *retentionService) EnforceAll(ctx context.Context) (int64, error) {
+	if !s.enabled {
+		return 0, ErrRetentionDisabled
+	}
+
+	var purged int64
+	for offset := 0; ; offset += lifecycleOrganizationPageSize {
+		orgs, err := s.orgRepo.GetActiveOrganizations(ctx, lifecycleOrganizationPageSize, offset)
+		if err != nil {
+			return purged, fmt.Errorf("failed to list active organizations: %w", err)
+		}
+
+		for _, org := range orgs {
+			report, err := s.run(ctx, org, false)
+			if report != nil {
+				for _, category := range report.Categories {
+					purged += category.Purged
+				}
+			}
+			if err != nil {
+				s.logger.Error("Retention enforcement failed for organization",
+					zap.Error(err),
+					zap.String("organization_id", org.ID.Hex()),
+				)
+			}
+		}
+
+		if len(orgs) < lifecycleOrganizationPageSize {
+			break
+		}
+	}
+
+	return purged, nil
+}
+
+// CreateLegalHold places a legal hold.
+//
+// Parameters:
+//   - ctx: Request context
+//   - input: Hold scope, target and justification
+//
+// Returns:
+//   - *models.LegalHold: Created hold
+//   - error: Validation or persistence error
+func (s *retentionService) CreateLegalHold(ctx context.Context, input *LegalHoldInput) (*models.LegalHold, error) {
+	if input == nil {
+		return nil, ErrInvalidInput
+	}
+	orgID, err := primitive.ObjectIDFromHex(input.OrganizationID)
+	if err != nil {
+		return nil, ErrInvalidInput
+	}
+	createdBy, err := primitive.ObjectIDFromHex(input.CreatedBy)
+	if err != nil {
+		return nil, ErrInvalidInput
+	}
+
+	hold := &models.LegalHold{
+		ID:             primitive.NewObjectID(),
+		OrganizationID: orgID,
+		Scope:          input.Scope,
+		Reason:         strings.TrimSpace(input.Reason),
+		CreatedBy:      createdBy,
+		CreatedAt:      time.Now(),
+	}
+	if hold.Reason == "" {
+		return nil, ErrLegalHoldReasonMissing
+	}
+
+	switch input.Scope {
+	case models.LegalHoldScopeOrganization:
+	case models.LegalHoldScopeTestingCycle:
+		if !primitive.IsValidObjectID(input.ResourceID) {
+			return nil, ErrInvalidLegalHold
+		}
+		hold.ResourceID = input.ResourceID
+	case models.LegalHoldScopeResource:
+		if input.ResourceType == "" || input.ResourceID == "" {
+			return nil, ErrInvalidLegalHold
+		}
+		hold.ResourceType = input.ResourceType
+		hold.ResourceID = input.ResourceID
+	default:
+		return nil, ErrInvalidLegalHold
+	}
+
+	if err := s.holdRepo.Create(ctx, hold); err != nil {
+		return nil, fmt.Errorf("failed to create legal hold: %w", err)
+	}
+
+	s.logger.Info("Legal hold placed",
+		zap.String("organization_id", input.OrganizationID),
+		zap.String("hold_id", hold.ID.Hex()),
+		zap.String("scope", hold.Scope),
+	)
+	return hold, nil
+}
+
+// ReleaseLegalHold releases an active legal hold. Released holds are kept as a record.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization the hold must belong to
+//   - holdID: Hold ID
+//   - userID: User releasing the hold
+//
+// Returns:
+//   - *models.LegalHold: Released hold
+//   - error: ErrLegalHoldNotFound, ErrLegalHoldReleased or persistence error
+func (s *retentionService) ReleaseLegalHold(ctx context.Context, orgID, holdID, userID string) (*models.LegalHold, error) {
+	releasedBy, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidInput
+	}
+
+	hold, err := s.holdRepo.GetByID(ctx, holdID)
+	if errors.Is(err, repositories.ErrNotFound) || (err == nil && hold.OrganizationID.Hex() != orgID) {
+		return nil, ErrLegalHoldNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get legal hold: %w", err)
+	}
+	if !hold.IsActive() {
+		return nil, ErrLegalHoldReleased
+	}
+
+	hold.ReleasedAt = time.Now()
+	hold.ReleasedBy = releasedBy
+	if err := s.holdRepo.Update(ctx, hold); err != nil {
+		return nil, fmt.Errorf("failed to update legal hold: %w", err)
+	}
+
+	s.logger.Info("Legal hold released",
+		zap.String("organization_id", orgID),
+		zap.String("hold_id", holdID),
+	)
+	return hold, nil
+}
+
+// ListLegalHolds retrieves the legal holds of an organization.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID
+//   - includeReleased: Whether released holds are included
+//
+// Returns:
+//   - []*models.LegalHold: Holds, newest first
+//   - error: Persistence error
+func (s *retentionService) ListLegalHolds(ctx context.Context, orgID string, includeReleased bool) ([]*models.LegalHold, error) {
+	holds, err := s.holdRepo.GetByOrganization(ctx, orgID, includeReleased)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list legal holds: %w", err)
+	}
+	return holds, nil
+}
+
+// loadOrganization retrieves an organization, mapping a missing one to ErrInvalidInput.
+func (s *retentionService) loadOrganization(ctx context.Context, orgID string) (*models.Organization, error) {
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrInvalidInput
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	return org, nil
+}
+
+// run evaluates, and unless dryRun is set purges, every data category of an organization.
+func (s *retentionService) run(ctx context.Context, org *models.Organization, dryRun bool) (*RetentionReport, error) {
+	now := time.Now()
+	report := &RetentionReport{
+		OrganizationID: org.ID.Hex(),
+		DryRun:         dryRun,
+		Policy:         EffectiveRetentionPolicy(org),
+		Categories:     []*RetentionCategoryReport{},
+		GeneratedAt:    now,
+	}
+
+	holds, err := s.holdRepo.GetByOrganization(ctx, org.ID.Hex(), false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list legal holds: %w", err)
+	}
+	report.LegalHolds = holds
+
+	if report.Policy.RetentionDays <= 0 {
+		return report, nil
+	}
+	report.Cutoff = now.AddDate(0, 0, -report.Policy.RetentionDays)
+
+	p := &purge{
+		service: s,
+		org:     org,
+		cutoff:  report.Cutoff,
+		dryRun:  dryRun,
+		holds:   newHoldSet(holds, s.evidenceRepo),
+	}
+	steps := []struct {
+		category string
+		fn       func(context.Context, *RetentionCategoryReport) error
+	}{
+		{RetentionCategoryAuditLogs, p.auditLogs},
+		{RetentionCategoryEvidenceFiles, p.evidenceFiles},
+		{RetentionCategorySessions, p.sessions},
+		{RetentionCategoryDeletedComments, p.deletedComments},
+	}
+	for _, step := range steps {
+		category := &RetentionCategoryReport{Category: step.category}
+		report.Categories = append(report.Categories, category)
+		if err := step.fn(ctx, category); err != nil {
+			return report, fmt.Errorf("failed to apply retention to %s: %w", step.category, err)
+		}
+	}
+
+	if !dryRun {
+		s.logger.Info("Retention enforced",
+			zap.String("organization_id", report.OrganizationID),
+			zap.Time("cutoff", report.Cutoff),
+			zap.Int("active_legal_holds", len(holds)),
+		)
+	}
+	return report, nil
+}
+
+// purge carries the state of one retention run for an organization.
+type purge struct {
+	service *retentionService
+	org     *models.Organization
+	cutoff  time.Time
+	dryRun  bool
+	holds   *holdSet
+}
+
+// auditLogs purges the oldest part of the audit chain. Only a contiguous prefix
+// ending at a signed checkpoint can be removed, so the remaining chain stays
+// verifiable from that checkpoint; the newest entry is always kept so the chain
+// can continue. A held entry ends the purgeable prefix.
+func (p *purge) auditLogs(ctx context.Context, result *RetentionCategoryReport) error {
+	s := p.service
+	orgID := p.org.ID.Hex()
+
+	head, err := s.auditRepo.GetChainHead(ctx, orgID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get chain head: %w", err)
+	}
+
+	var first, limit, expired int64
+	blocked := ""
+	err = s.auditRepo.IterateChain(ctx, orgID, 0, func(entry *models.AuditLog) error {
+		if !entry.Timestamp.Before(p.cutoff) {
+			return errStopIteration
+		}
+		expired++
+		if first == 0 {
+			first = entry.Sequence
+		}
+		if blocked != "" {
+			return nil
+		}
+		if entry.Sequence >= head.Sequence {
+			blocked = "the newest entry is kept to continue the chain"
+			return nil
+		}
+		held, err := p.holds.covers(ctx, entry.ResourceType, entry.ResourceID)
+		if err != nil {
+			return err
+		}
+		if held {
+			blocked = "a legal hold covers an entry in the chain"
+			return nil
+		}
+		limit = entry.Sequence
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopIteration) {
+		return fmt.Errorf("failed to read audit chain: %w", err)
+	}
+	if expired == 0 {
+		return nil
+	}
+
+	checkpoints, err := s.checkpointRepo.GetByOrganization(ctx, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to get checkpoints: %w", err)
+	}
+	batches := auditPurgeBatches(checkpoints, first, limit, int64(s.batchSize))
+
+	var through int64
+	if len(batches) > 0 {
+		through = batches[len(batches)-1]
+		result.Eligible = through - first + 1
+	}
+	result.Held = expired - result.Eligible
+	switch {
+	case blocked != "":
+		result.Note = blocked
+	case through < limit:
+		result.Note = "entries after the last checkpoint are kept until the next checkpoint"
+	}
+
+	if p.dryRun {
+		return nil
+	}
+	for _, end := range batches {
+		purged, err := s.auditRepo.Purge(ctx, orgID, end)
+		if err != nil {
+			return fmt.Errorf("failed to purge audit logs: %w", err)
+		}
+		if err := p.recordBatch(ctx, result, purged, map[string]interface{}{"through_sequence": end}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// auditPurgeBatches splits the chain prefix [first, limit] into batches that each
+// end at a checkpoint. Batches hold up to batchSize entries, unless checkpoints are
+// further apart. It returns the last sequence of each batch.
+func auditPurgeBatches(checkpoints []*models.AuditCheckpoint, first, limit, batchSize int64) []int64 {
+	var ends []int64
+	start, candidate := first, int64(0)
+	for _, checkpoint := range checkpoints {
+		if checkpoint.Sequence < first || checkpoint.Sequence > limit {
+			continue
+		}
+		if checkpoint.Sequence-start+1 > batchSize && candidate >= start {
+			ends = append(ends, candidate)
+			start = candidate + 1
+		}
+		candidate = checkpoint.Sequence
+	}
+	if candidate >= start {
+		ends = append(ends, candidate)
+	}
+	return ends
+}
+
+// evidenceFiles removes the files of closed evidence requests. The evidence
+// metadata is kept and marked as purged.
+func (p *purge) evidenceFiles(ctx context.Context, result *RetentionCategoryReport) error {
+	s := p.service
+	afterID := ""
+	for {
+		requests, err := s.evidenceRepo.GetClosedWithEvidence(ctx, p.org.ID.Hex(), p.cutoff, afterID, s.batchSize)
+		if err != nil {
+			return fmt.Errorf("failed to get evidence requests: %w", err)
+		}
+
+		var purged int64
+		var requestIDs []string
+		var purgeErr error
+		for _, request := range requests {
+			var evidenceIDs []string
+			for _, evidence := range request.Evidence {
+				if evidence.PurgedAt.IsZero() {
+					evidenceIDs = append(evidenceIDs, evidence.ID)
+				}
+			}
+			if len(evidenceIDs) == 0 {
+				continue
+			}
+			if p.holds.coversRequest(request) {
+				result.Held += int64(len(evidenceIDs))
+				continue
+			}
+			result.Eligible += int64(len(evidenceIDs))
+			if p.dryRun {
+				continue
+			}
+
+			removed, err := p.removeEvidenceFiles(ctx, request, evidenceIDs)
+			purged += int64(len(removed))
+			if len(removed) > 0 {
+				requestIDs = append(requestIDs, request.ID.Hex())
+			}
+			if err != nil {
+				purgeErr = err
+				break
+			}
+		}
+
+		if purged > 0 {
+			if err := p.recordBatch(ctx, result, purged, map[string]interface{}{"evidence_request_ids": requestIDs}); err != nil {
+				return err
+			}
+		}
+		if purgeErr != nil {
+			return purgeErr
+		}
+		if len(requests) < s.batchSize {
+			return nil
+		}
+		afterID = requests[len(requests)-1].ID.Hex()
+	}
+}
+
+// removeEvidenceFiles deletes evidence files from storage and marks them purged.
+// It returns the IDs that were removed, also when a later file fails.
+func (p *purge) removeEvidenceFiles(ctx context.Context, request *models.EvidenceRequest, evidenceIDs []string) ([]string, error) {
+	paths := make(map[string]string, len(request.Evidence))
+	for _, evidence := range request.Evidence {
+		paths[evidence.ID] = evidence.StoragePath
+	}
+
+	var removed []string
+	var deleteErr error
+	for _, id := range evidenceIDs {
+		if path := paths[id]; path != "" {
+			if err := p.service.files.Delete(ctx, path); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				deleteErr = fmt.Errorf("failed to delete evidence file: %w", err)
+				break
+			}
+		}
+		removed = append(removed, id)
+	}
+
+	if len(removed) > 0 {
+		if err := p.service.evidenceRepo.MarkEvidencePurged(ctx, request.ID.Hex(), removed, time.Now()); err != nil {
+			return nil, fmt.Errorf("failed to mark evidence purged: %w", err)
+		}
+	}
+	return removed, deleteErr
+}
+
+// sessions removes sessions that ended before the cutoff. Sessions are only
+// covered by organization-wide holds.
+func (p *purge) sessions(ctx context.Context, result *RetentionCategoryReport) error {
+	s := p.service
+	orgID := p.org.ID.Hex()
+
+	count, err := s.sessionRepo.CountEndedBefore(ctx, orgID, p.cutoff)
+	if err != nil {
+		return fmt.Errorf("failed to count sessions: %w", err)
+	}
+	if p.holds.organizationWide() {
+		result.Held = count
+		return nil
+	}
+	result.Eligible = count
+	if p.dryRun {
+		return nil
+	}
+
+	for {
+		purged, err := s.sessionRepo.DeleteEndedBefore(ctx, orgID, p.cutoff, s.batchSize)
+		if err != nil {
+			return fmt.Errorf("failed to delete sessions: %w", err)
+		}
+		if purged > 0 {
+			if err := p.recordBatch(ctx, result, purged, nil); err != nil {
+				return err
+			}
+		}
+		if purged < int64(s.batchSize) {
+			return nil
+		}
+	}
+}
+
+// deletedComments permanently removes comments soft deleted before the cutoff.
+func (p *purge) deletedComments(ctx context.Context, result *RetentionCategoryReport) error {
+	s := p.service
+	afterID := ""
+	for {
+		comments, err := s.commentRepo.GetDeletedBefore(ctx, p.org.ID.Hex(), p.cutoff, afterID, s.batchSize)
+		if err != nil {
+			return fmt.Errorf("failed to get deleted comments: %w", err)
+		}
+
+		var ids []string
+		for _, comment := range comments {
+			held, err := p.holds.covers(ctx, comment.ResourceType, comment.ResourceID)
+			if err != nil {
+				return err
+			}
+			if held || p.holds.coversResource("comment", comment.ID) {
+				result.Held++
+				continue
+			}
+			ids = append(ids, comment.ID)
+		}
+		result.Eligible += int64(len(ids))
+
+		if !p.dryRun && len(ids) > 0 {
+			purged, err := s.commentRepo.DeleteMany(ctx, ids)
+			if err != nil {
+				return fmt.Errorf("failed to delete comments: %w", err)
+			}
+			if err := p.recordBatch(ctx, result, purged, map[string]interface{}{"comment_ids": ids}); err != nil {
+				return err
+			}
+		}
+
+		if len(comments) < s.batchSize {
+			return nil
+		}
+		afterID = comments[len(comments)-1].ID
+	}
+}
+
+// recordBatch counts a purged batch and writes its audit entry. Purging stops
+// when the entry cannot be written, so no batch goes unrecorded.
+func (p *purge) recordBatch(ctx context.Context, result *RetentionCategoryReport, purged int64, details map[string]interface{}) error {
+	result.Purged += purged
+	result.Batches++
+
+	metadata := map[string]interface{}{
+		"actor":  models.SystemActorID,
+		"cutoff": p.cutoff,
+		"batch":  result.Batches,
+	}
+	for key, value := range details {
+		metadata[key] = value
+	}
+
+	entry := &models.AuditLog{
+		ID:             primitive.NewObjectID(),
+		Timestamp:      time.Now(),
+		OrganizationID: p.org.ID,
+		Action:         AuditActionRetentionPurged,
+		ResourceType:   "retention",
+		ResourceID:     result.Category,
+		NewValues:      map[string]interface{}{"category": result.Category, "purged": purged},
+		Metadata:       metadata,
+		Success:        true,
+	}
+	if err := p.service.auditRepo.Create(ctx, entry); err != nil {
+		return fmt.Errorf("failed to record purge batch: %w", err)
+	}
+	return nil
+}
+
+// holdSet evaluates the active legal holds of an organization. Testing cycle
+// holds also cover evidence requests of the cycle, which are looked up once.
+type holdSet struct {
+	holds        []*models.LegalHold
+	evidenceRepo repositories.EvidenceRequestRepository
+	cycleHolds   bool
+	requestCycle map[string]string
+}
+
+// newHoldSet creates a hold set from active holds.
+func newHoldSet(holds []*models.LegalHold, evidenceRepo repositories.EvidenceRequestRepository) *holdSet {
+	set := &holdSet{
+		holds:        holds,
+		evidenceRepo: evidenceRepo,
+		requestCycle: make(map[string]string),
+	}
+	for _, hold := range holds {
+		if hold.Scope == models.LegalHoldScopeTestingCycle {
+			set.cycleHolds = true
+		}
+	}
+	return set
+}
+
+// organizationWide reports whether a hold covers the whole organization.
+func (h *holdSet) organizationWide() bool {
+	for _, hold := range h.holds {
+		if hold.Scope == models.LegalHoldScopeOrganization {
+			return true
+		}
+	}
+	return false
+}
+
+// coversResource reports whether a hold covers a resource outside any testing cycle.
+func (h *holdSet) coversResource(resourceType, resourceID string) bool {
+	return h.coversInCycle(resourceType, resourceID, "")
+}
+
+// coversRequest reports whether a hold covers an evidence request.
+func (h *holdSet) coversRequest(request *models.EvidenceRequest) bool {
+	return h.coversInCycle(models.ResourceTypeEvidenceRequest, request.ID.Hex(), request.CycleID.Hex())
+}
+
+// covers reports whether a hold covers a resource, resolving the testing cycle
+// of evidence requests when cycle holds are active.
+func (h *holdSet) covers(ctx context.Context, resourceType, resourceID string) (bool, error) {
+	cycleID := ""
+	if h.cycleHolds && resourceType == models.ResourceTypeEvidenceRequest && resourceID != "" {
+		var err error
+		if cycleID, err = h.cycleOf(ctx, resourceID); err != nil {
+			return false, err
+		}
+	}
+	return h.coversInCycle(resourceType, resourceID, cycleID), nil
+}
+
+// coversInCycle checks all holds against a resource and its testing cycle.
+func (h *holdSet) coversInCycle(resourceType, resourceID, cycleID string) bool {
+	for _, hold := range h.holds {
+		if hold.Covers(resourceType, resourceID, cycleID) {
+			return true
+		}
+	}
+	return false
+}
+
+// cycleOf returns the testing cycle of an evidence request, or "" if the request no longer exists.
+func (h *holdSet) cycleOf(ctx context.Context, requestID string) (string, error) {
+	if cycleID, ok := h.requestCycle[requestID]; ok {
+		return cycleID, nil
+	}
+	request, err := h.evidenceRepo.GetByID(ctx, requestID)
+	if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+		return "", fmt.Errorf("failed to get evidence request: %w", err)
+	}
+	cycleID := ""
+	if request != nil {
+		cycleID = request.CycleID.Hex()
+	}
+	h.requestCycle[requestID] = cycleID
+	return cycleID, nil
+}
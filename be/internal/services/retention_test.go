@@ -0,0 +1,381 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/storage"
+)
+
+type fakeLegalHoldRepository struct {
+	repositories.LegalHoldRepository
+	holds []*models.LegalHold
+}
+
+func (r *fakeLegalHoldRepository) Create(ctx context.Context, hold *models.LegalHold) error {
+	r.holds = append(r.holds, hold)
+	return nil
+}
+
+func (r *fakeLegalHoldRepository) GetByID(ctx context.Context, id string) (*models.LegalHold, error) {
+	for _, hold := range r.holds {
+		if hold.ID.Hex() == id {
+			return hold, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r *fakeLegalHoldRepository) Update(ctx context.Context, hold *models.LegalHold) error {
+	return nil
+}
+
+func (r *fakeLegalHoldRepository) GetByOrganization(ctx context.Context, orgID string, includeReleased bool) ([]*models.LegalHold, error) {
+	var result []*models.LegalHold
+	for _, hold := range r.holds {
+		if hold.OrganizationID.Hex() == orgID && (includeReleased || hold.IsActive()) {
+			result = append(result, hold)
+		}
+	}
+	return result, nil
+}
+
+// retentionFixture is an organization with data on both sides of a one year retention cutoff
+type retentionFixture struct {
+	org         *models.Organization
+	cycleID     primitive.ObjectID
+	auditRepo   *fakeAuditLogRepository
+	checkpoints *fakeAuditCheckpointRepository
+	evidence    *fakeEvidenceRequestRepository
+	comments    *fakeCommentRepository
+	sessions    *fakeSessionRepository
+	holds       *fakeLegalHoldRepository
+	files       *storage.LocalStore
+	heldRequest *models.EvidenceRequest
+	service     RetentionService
+}
+
+func newRetentionFixture(t *testing.T, enabled bool) *retentionFixture {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Now()
+	expired := now.AddDate(-1, 0, -30)
+
+	org := &models.Organization{Status: models.OrganizationStatusActive}
+	org.ID = primitive.NewObjectID()
+	org.RegulatoryProfile.RetentionPeriod = 1
+	org.Settings.DataRetentionDays = 30
+
+	f := &retentionFixture{
+		org:         org,
+		cycleID:     primitive.NewObjectID(),
+		auditRepo:   &fakeAuditLogRepository{},
+		checkpoints: &fakeAuditCheckpointRepository{},
+		comments:    &fakeCommentRepository{},
+		holds:       &fakeLegalHoldRepository{},
+	}
+	files, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	f.files = files
+
+	// Closed request with two expired files, one recently closed and one open request
+	f.heldRequest = &models.EvidenceRequest{OrganizationID: org.ID, CycleID: f.cycleID, Status: models.EvidenceRequestStatusCompleted}
+	f.heldRequest.ID = primitive.NewObjectID()
+	f.heldRequest.UpdatedAt = expired
+	recent := &models.EvidenceRequest{OrganizationID: org.ID, Status: models.EvidenceRequestStatusCompleted}
+	recent.ID = primitive.NewObjectID()
+	recent.UpdatedAt = now
+	open := &models.EvidenceRequest{OrganizationID: org.ID, Status: models.EvidenceRequestStatusPending}
+	open.ID = primitive.NewObjectID()
+	open.UpdatedAt = expired
+	for _, request := range []*models.EvidenceRequest{f.heldRequest, recent, open} {
+		for i := 0; i < 2; i++ {
+			path := fmt.Sprintf("evidence/%s/%d.pdf", request.ID.Hex(), i)
+			writer, err := files.Create(ctx, path)
+			require.NoError(t, err)
+			_, _ = io.WriteString(writer, "evidence")
+			require.NoError(t, writer.Close())
+			request.Evidence = append(request.Evidence, models.Evidence{ID: models.NewID(), StoragePath: path})
+		}
+	}
+	f.evidence = newFakeEvidenceRequestRepository(f.heldRequest, recent, open)
+
+	// Four expired chain entries checkpointed at 2 and 4, then two recent ones
+	chained := NewHashChainedAuditLogRepository(f.auditRepo, zap.NewNop())
+	chainService := NewAuditChainService(nil, f.auditRepo, f.checkpoints, newTestSigningKey(), "key-1", zap.NewNop())
+	for i := 1; i <= 6; i++ {
+		entry := &models.AuditLog{OrganizationID: org.ID, Action: "control.updated", ResourceType: "control", ResourceID: "C-1", Timestamp: expired}
+		if i == 2 {
+			entry.ResourceType = models.ResourceTypeEvidenceRequest
+			entry.ResourceID = f.heldRequest.ID.Hex()
+		}
+		if i > 4 {
+			entry.Timestamp = now
+		}
+		require.NoError(t, chained.Create(ctx, entry))
+		if i == 2 || i == 4 {
+			_, err := chainService.CreateCheckpoint(ctx, org.ID.Hex())
+			require.NoError(t, err)
+		}
+	}
+
+	// Three ended sessions, one active
+	f.sessions = &fakeSessionRepository{}
+	for _, expiresAt := range []time.Time{expired, expired, expired, now.Add(time.Hour)} {
+		f.sessions.sessions = append(f.sessions.sessions, &models.Session{OrganizationID: org.ID, ExpiresAt: expiresAt})
+	}
+
+	// Two expired deleted comments, one recently deleted and one active
+	for i, comment := range []*models.Comment{
+		{Status: models.CommentStatusDeleted, DeletedAt: expired, ResourceType: models.ResourceTypeControl, ResourceID: "C-1"},
+		{Status: models.CommentStatusDeleted, DeletedAt: expired, ResourceType: models.ResourceTypeEvidenceRequest, ResourceID: f.heldRequest.ID.Hex()},
+		{Status: models.CommentStatusDeleted, DeletedAt: now},
+		{Status: models.CommentStatusActive, CreatedAt: expired},
+	} {
+		comment.ID = fmt.Sprintf("comment-%d", i)
+		comment.OrganizationID = org.ID
+		f.comments.comments = append(f.comments.comments, comment)
+	}
+
+	f.service = NewRetentionService(newFakeOrganizationRepository(org), chained, f.checkpoints, f.evidence, f.comments,
+		f.sessions, f.holds, files, config.RetentionConfig{Enabled: enabled, BatchSize: 2}, zap.NewNop())
+	return f
+}
+
+// categories indexes a report by category
+func categories(report *RetentionReport) map[string]*RetentionCategoryReport {
+	result := make(map[string]*RetentionCategoryReport)
+	for _, category := range report.Categories {
+		result[category.Category] = category
+	}
+	return result
+}
+
+func TestEffectiveRetentionPolicy(t *testing.T) {
+	org := &models.Organization{}
+	assert.Equal(t, 0, EffectiveRetentionPolicy(org).RetentionDays, "no policy configured")
+
+	org.RegulatoryProfile.RetentionPeriod = 7
+	org.Settings.DataRetentionDays = 2000
+	assert.Equal(t, 2555, EffectiveRetentionPolicy(org).RetentionDays, "regulatory period is longer")
+
+	org.Settings.DataRetentionDays = 3000
+	assert.Equal(t, 3000, EffectiveRetentionPolicy(org).RetentionDays, "organization setting is longer")
+}
+
+func TestRetentionService_ReportAndEnforce(t *testing.T) {
+	ctx := context.Background()
+	f := newRetentionFixture(t, true)
+	orgID := f.org.ID.Hex()
+
+	report, err := f.service.Report(ctx, orgID)
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 365, report.Policy.RetentionDays)
+
+	expected := map[string]int64{
+		RetentionCategoryAuditLogs:       4,
+		RetentionCategoryEvidenceFiles:   2,
+		RetentionCategorySessions:        3,
+		RetentionCategoryDeletedComments: 2,
+	}
+	for category, eligible := range expected {
+		assert.Equal(t, eligible, categories(report)[category].Eligible, category)
+		assert.Zero(t, categories(report)[category].Purged, "dry run must not purge %s", category)
+	}
+	assert.Len(t, f.auditRepo.chain(orgID), 6)
+	assert.Len(t, f.sessions.sessions, 4)
+
+	report, err = f.service.Enforce(ctx, orgID)
+	require.NoError(t, err)
+	byCategory := categories(report)
+	for category, eligible := range expected {
+		assert.Equal(t, eligible, byCategory[category].Purged, category)
+	}
+	assert.Equal(t, 2, byCategory[RetentionCategoryAuditLogs].Batches, "audit batches end at checkpoints 2 and 4")
+	assert.Equal(t, 2, byCategory[RetentionCategorySessions].Batches)
+
+	// Every batch is audited
+	var batches int
+	for _, category := range report.Categories {
+		batches += category.Batches
+	}
+	var purgeEntries int
+	for _, action := range f.auditRepo.actions() {
+		if action == AuditActionRetentionPurged {
+			purgeEntries++
+		}
+	}
+	assert.Equal(t, batches, purgeEntries)
+
+	// Files are gone but their metadata is kept
+	_, err = f.files.Open(ctx, f.heldRequest.Evidence[0].StoragePath)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	assert.False(t, f.heldRequest.Evidence[0].PurgedAt.IsZero())
+	assert.Len(t, f.sessions.sessions, 1)
+	assert.Len(t, f.comments.comments, 2)
+
+	// The remaining chain is anchored at the last purged checkpoint
+	result, err := NewAuditChainService(nil, f.auditRepo, f.checkpoints, newTestSigningKey(), "key-1", zap.NewNop()).VerifyChain(ctx, orgID)
+	require.NoError(t, err)
+	assert.True(t, result.Valid, "%+v", result.FirstBreak)
+	assert.Equal(t, int64(5), result.FirstSequence)
+
+	// Nothing left to purge
+	report, err = f.service.Report(ctx, orgID)
+	require.NoError(t, err)
+	for _, category := range report.Categories {
+		assert.Zero(t, category.Eligible, category.Category)
+	}
+}
+
+func TestRetentionService_LegalHolds(t *testing.T) {
+	ctx := context.Background()
+	f := newRetentionFixture(t, true)
+	orgID := f.org.ID.Hex()
+	adminID := primitive.NewObjectID().Hex()
+
+	cycleHold, err := f.service.CreateLegalHold(ctx, &LegalHoldInput{
+		OrganizationID: orgID,
+		CreatedBy:      adminID,
+		Scope:          models.LegalHoldScopeTestingCycle,
+		ResourceID:     f.cycleID.Hex(),
+		Reason:         "Regulatory examination 2024-17",
+	})
+	require.NoError(t, err)
+
+	report, err := f.service.Enforce(ctx, orgID)
+	require.NoError(t, err)
+	byCategory := categories(report)
+
+	// The held entry at sequence 2 ends the purgeable prefix before any checkpoint
+	assert.Zero(t, byCategory[RetentionCategoryAuditLogs].Purged)
+	assert.Equal(t, int64(4), byCategory[RetentionCategoryAuditLogs].Held)
+	assert.NotEmpty(t, byCategory[RetentionCategoryAuditLogs].Note)
+	assert.Equal(t, int64(2), byCategory[RetentionCategoryEvidenceFiles].Held)
+	assert.Zero(t, byCategory[RetentionCategoryEvidenceFiles].Purged)
+	assert.Equal(t, int64(1), byCategory[RetentionCategoryDeletedComments].Held, "comment on a request in the held cycle")
+	assert.Equal(t, int64(1), byCategory[RetentionCategoryDeletedComments].Purged)
+	assert.Equal(t, int64(3), byCategory[RetentionCategorySessions].Purged, "sessions are only held organization wide")
+
+	_, err = f.files.Open(ctx, f.heldRequest.Evidence[0].StoragePath)
+	assert.NoError(t, err, "held evidence file must survive")
+
+	// An organization-wide hold blocks everything
+	_, err = f.service.CreateLegalHold(ctx, &LegalHoldInput{OrganizationID: orgID, CreatedBy: adminID, Scope: models.LegalHoldScopeOrganization, Reason: "Litigation"})
+	require.NoError(t, err)
+	f.sessions.sessions = append(f.sessions.sessions, &models.Session{OrganizationID: f.org.ID, ExpiresAt: time.Now().AddDate(-2, 0, 0)})
+	report, err = f.service.Report(ctx, orgID)
+	require.NoError(t, err)
+	assert.Len(t, report.LegalHolds, 2)
+	for _, category := range report.Categories {
+		assert.Zero(t, category.Eligible, category.Category)
+	}
+	assert.Equal(t, int64(1), categories(report)[RetentionCategorySessions].Held)
+
+	// Releasing
+	_, err = f.service.ReleaseLegalHold(ctx, primitive.NewObjectID().Hex(), cycleHold.ID.Hex(), adminID)
+	assert.ErrorIs(t, err, ErrLegalHoldNotFound, "holds of other organizations are invisible")
+	released, err := f.service.ReleaseLegalHold(ctx, orgID, cycleHold.ID.Hex(), adminID)
+	require.NoError(t, err)
+	assert.False(t, released.IsActive())
+	_, err = f.service.ReleaseLegalHold(ctx, orgID, cycleHold.ID.Hex(), adminID)
+	assert.ErrorIs(t, err, ErrLegalHoldReleased)
+
+	holds, err := f.service.ListLegalHolds(ctx, orgID, false)
+	require.NoError(t, err)
+	assert.Len(t, holds, 1)
+	holds, err = f.service.ListLegalHolds(ctx, orgID, true)
+	require.NoError(t, err)
+	assert.Len(t, holds, 2)
+}
+
+func TestRetentionService_CreateLegalHoldValidation(t *testing.T) {
+	f := newRetentionFixture(t, true)
+	base := LegalHoldInput{OrganizationID: f.org.ID.Hex(), CreatedBy: primitive.NewObjectID().Hex(), Reason: "Litigation"}
+
+	tests := []struct {
+		name        string
+		modify      func(input *LegalHoldInput)
+		expectedErr error
+	}{
+		{"unknown scope", func(in *LegalHoldInput) { in.Scope = "everything" }, ErrInvalidLegalHold},
+		{"cycle without valid ID", func(in *LegalHoldInput) { in.Scope = models.LegalHoldScopeTestingCycle; in.ResourceID = "Q1" }, ErrInvalidLegalHold},
+		{"resource without type", func(in *LegalHoldInput) { in.Scope = models.LegalHoldScopeResource; in.ResourceID = "C-1" }, ErrInvalidLegalHold},
+		{"missing reason", func(in *LegalHoldInput) { in.Scope = models.LegalHoldScopeOrganization; in.Reason = "  " }, ErrLegalHoldReasonMissing},
+		{"valid resource hold", func(in *LegalHoldInput) {
+			in.Scope = models.LegalHoldScopeResource
+			in.ResourceType = models.ResourceTypeControl
+			in.ResourceID = "C-1"
+		}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := base
+			tt.modify(&input)
+			_, err := f.service.CreateLegalHold(context.Background(), &input)
+			if tt.expectedErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestRetentionService_Disabled(t *testing.T) {
+	f := newRetentionFixture(t, false)
+
+	_, err := f.service.Enforce(context.Background(), f.org.ID.Hex())
+	assert.ErrorIs(t, err, ErrRetentionDisabled)
+	_, err = f.service.EnforceAll(context.Background())
+	assert.ErrorIs(t, err, ErrRetentionDisabled)
+
+	report, err := f.service.Report(context.Background(), f.org.ID.Hex())
+	require.NoError(t, err, "dry runs work while enforcement is disabled")
+	assert.Equal(t, int64(4), categories(report)[RetentionCategoryAuditLogs].Eligible)
+}
+
+func TestAuditPurgeBatches(t *testing.T) {
+	checkpoints := func(sequences ...int64) []*models.AuditCheckpoint {
+		var result []*models.AuditCheckpoint
+		for _, sequence := range sequences {
+			result = append(result, &models.AuditCheckpoint{Sequence: sequence})
+		}
+		return result
+	}
+
+	tests := []struct {
+		name        string
+		checkpoints []*models.AuditCheckpoint
+		first       int64
+		limit       int64
+		batchSize   int64
+		expected    []int64
+	}{
+		{"no checkpoint", nil, 1, 10, 5, nil},
+		{"limit before first checkpoint", checkpoints(5), 1, 4, 5, nil},
+		{"single batch", checkpoints(3, 7), 1, 8, 10, []int64{7}},
+		{"split at checkpoints", checkpoints(2, 4, 6, 8), 1, 8, 3, []int64{2, 4, 6, 8}},
+		{"checkpoints further apart than the batch size", checkpoints(10, 20), 1, 25, 3, []int64{10, 20}},
+		{"already purged prefix", checkpoints(2, 4, 6), 5, 6, 10, []int64{6}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, auditPurgeBatches(tt.checkpoints, tt.first, tt.limit, tt.batchSize))
+		})
+	}
+}
@@ -0,0 +1,340 @@
+// Package services provides service layer implementations for the GoEdu Control Testing Platform.
+// This file contains the SAML single sign-on service. The platform acts as a
+// SAML 2.0 service provider for each organization: it publishes metadata,
+// sends signed AuthnRequests and accepts signed assertions posted to the
+// organization's assertion consumer service.
+package services
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/auth"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/cache"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/oidc"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/saml"
+)
+
+// defaultGroupsAttribute is the assertion attribute read for group memberships.
+const defaultGroupsAttribute = "groups"
+
+// samlAssertionStatePrefix marks the login states recording consumed
+// IdP-initiated assertions. Relay states with this prefix are never consumed,
+// so a replay marker cannot be removed by posting it as a relay state.
+const samlAssertionStatePrefix = "saml-assertion:"
+
+// samlService implements the SAMLService interface.
+type samlService struct {
+	orgRepo     repositories.OrganizationRepository
+	stateRepo   repositories.SSOLoginStateRepository
+	accounts    *ssoAccounts
+	login       *loginIssuer
+	key         *rsa.PrivateKey
+	certificate *x509.Certificate
+	config      config.SSOConfig
+	logger      *zap.Logger
+}
+
+// NewSAMLService creates a new SAML single sign-on service. The service
+// provider key pair is read from the files named in the configuration; when
+// it is missing or unreadable SAML logins are unavailable.
+//
+// Parameters:
+//   - orgRepo: Repository for organization data and SAML settings
+//   - userRepo: Repository for user accounts matched or created on sign-in
+//   - sessionRepo: Repository the sessions of signed-in users are stored in
+//   - denylist: Denylist of ended sessions, usually the Redis cache client
+//   - stateRepo: Repository for logins in progress and consumed assertions
+//   - jwtManager: Issuer of the platform's access and refresh tokens
+//   - cfg: Public URL, key pair, login timeout and clock skew settings
+//   - sessionCfg: Platform session timeouts
+//   - logger: Logger for service operations
+//
+// Returns:
+//   - SAMLService: Configured SAML service instance
+func NewSAMLService(
+	orgRepo repositories.OrganizationRepository,
+	userRepo repositories.UserRepository,
+	sessionRepo repositories.SessionRepository,
+	denylist cache.Denylist,
+	stateRepo repositories.SSOLoginStateRepository,
+	jwtManager *auth.JWTManager,
+	cfg config.SSOConfig,
+	sessionCfg config.SessionConfig,
+	logger *zap.Logger,
+) SAMLService {
+	s := &samlService{
+		orgRepo:   orgRepo,
+		stateRepo: stateRepo,
+		accounts: &ssoAccounts{
+			userRepo: userRepo,
+			seats:    &memberSeats{orgRepo: orgRepo, logger: logger},
+			logger:   logger,
+		},
+		login: &loginIssuer{
+			userRepo:   userRepo,
+			sessions:   newSessionManager(orgRepo, userRepo, sessionRepo, denylist, sessionCfg, logger),
+			jwtManager: jwtManager,
+			logger:     logger,
+		},
+		config: cfg,
+		logger: logger,
+	}
+
+	if cfg.SAMLCertificateFile != "" {
+		pair, err := tls.LoadX509KeyPair(cfg.SAMLCertificateFile, cfg.SAMLKeyFile)
+		if err != nil {
+			logger.Error("Failed to load SAML service provider key pair", zap.Error(err))
+			return s
+		}
+		key, ok := pair.PrivateKey.(*rsa.PrivateKey)
+		if !ok {
+			logger.Error("SAML service provider key must be an RSA key")
+			return s
+		}
+		s.key, s.certificate = key, pair.Leaf
+	}
+	return s
+}
+
+// Metadata returns the service provider metadata of an organization whose
+// plan includes single sign-on, so that it can be registered with the
+// identity provider before SAML is switched on.
+func (s *samlService) Metadata(ctx context.Context, organizationSlug string) ([]byte, error) {
+	org, err := s.organizationBySlug(ctx, organizationSlug)
+	if err != nil {
+		return nil, err
+	}
+	if !org.IsActive || org.Status != models.OrganizationStatusActive || !org.FeatureFlags[ssoFeatureFlag] {
+		return nil, ErrSSODisabled
+	}
+	sp, err := s.serviceProvider(org)
+	if err != nil {
+		return nil, err
+	}
+	return sp.Metadata(), nil
+}
+
+// BeginLogin starts an SP-initiated login. It stores a fresh relay state bound
+// to the AuthnRequest ID and returns the identity provider URL carrying the
+// signed request.
+func (s *samlService) BeginLogin(ctx context.Context, input *BeginSSOInput) (string, error) {
+	if !isLocalPath(input.ReturnTo) {
+		return "", fmt.Errorf("%w: return_to must be an application path", ErrInvalidInput)
+	}
+	org, err := s.organizationBySlug(ctx, input.OrganizationSlug)
+	if err != nil {
+		return "", err
+	}
+	sp, idp, _, err := s.providerConfig(org)
+	if err != nil {
+		return "", err
+	}
+
+	relayState, err := oidc.GenerateVerifier()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now().UTC()
+	redirectURL, requestID, err := sp.AuthnRequestURL(idp, relayState, now)
+	if err != nil {
+		return "", err
+	}
+	state := &models.SSOLoginState{
+		State:          relayState,
+		OrganizationID: org.ID,
+		Provider:       models.SSOProviderSAML,
+		RequestID:      requestID,
+		ReturnTo:       input.ReturnTo,
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(s.config.StateTTL),
+	}
+	if err := s.stateRepo.Create(ctx, state); err != nil {
+		return "", fmt.Errorf("failed to store login state: %w", err)
+	}
+	return redirectURL, nil
+}
+
+// CompleteLogin validates a response posted to the organization's assertion
+// consumer service. A response with a known relay state must answer that
+// login's AuthnRequest; any other response is an IdP-initiated login, which
+// the organization must allow and whose assertion can be used only once. The
+// user is matched or provisioned by email, gets the roles mapped from the
+// assertion's groups and the mapped profile attributes, and a session with
+// login method SSO is started.
+func (s *samlService) CompleteLogin(ctx context.Context, input *CompleteSAMLInput) (*SSOLoginResult, error) {
+	org, err := s.organizationBySlug(ctx, input.OrganizationSlug)
+	if err != nil {
+		return nil, err
+	}
+	sp, idp, settings, err := s.providerConfig(org)
+	if err != nil {
+		return nil, err
+	}
+	if input.SAMLResponse == "" {
+		return nil, fmt.Errorf("%w: SAMLResponse is required", ErrInvalidInput)
+	}
+
+	now := time.Now().UTC()
+	var requestID, returnTo string
+	if input.RelayState != "" && !strings.HasPrefix(input.RelayState, samlAssertionStatePrefix) {
+		state, err := s.stateRepo.Consume(ctx, input.RelayState, now)
+		switch {
+		case err == nil:
+			if state.Provider != models.SSOProviderSAML || state.OrganizationID != org.ID || state.RequestID == "" {
+				return nil, ErrInvalidSSOState
+			}
+			requestID, returnTo = state.RequestID, state.ReturnTo
+		case !errors.Is(err, repositories.ErrNotFound):
+			return nil, fmt.Errorf("failed to load login state: %w", err)
+		}
+	}
+	if requestID == "" && !settings.AllowIdPInitiated {
+		return nil, ErrInvalidSSOState
+	}
+
+	assertion, err := sp.ParseResponse(input.SAMLResponse, idp, requestID, now)
+	if err != nil {
+		s.logger.Warn("SAML response rejected", zap.Error(err), zap.String("organization_id", org.ID.Hex()))
+		return nil, ErrSSOAuthenticationFailed
+	}
+	if requestID == "" {
+		if err := s.recordAssertion(ctx, org, assertion, now); err != nil {
+			return nil, err
+		}
+	}
+
+	user, err := s.accounts.resolve(ctx, org, ssoAccountPolicy{
+		RoleMappings:  settings.RoleMappings,
+		DefaultRole:   settings.DefaultRole,
+		AutoProvision: settings.AutoProvision,
+	}, samlIdentity(settings, assertion))
+	if err != nil {
+		return nil, err
+	}
+	response, err := s.login.issue(ctx, user, models.LoginMethodSSO, input.IPAddress, input.UserAgent)
+	if err != nil {
+		return nil, err
+	}
+	return &SSOLoginResult{LoginResponse: response, ReturnTo: returnTo}, nil
+}
+
+// recordAssertion remembers an IdP-initiated assertion until it expires and
+// rejects it when it has been used before.
+func (s *samlService) recordAssertion(ctx context.Context, org *models.Organization, assertion *saml.Assertion, now time.Time) error {
+	err := s.stateRepo.Create(ctx, &models.SSOLoginState{
+		State:          samlAssertionStatePrefix + assertion.Issuer + "#" + assertion.ID,
+		OrganizationID: org.ID,
+		Provider:       models.SSOProviderSAML,
+		CreatedAt:      now,
+		ExpiresAt:      assertion.NotOnOrAfter.Add(s.config.ClockSkew),
+	})
+	if errors.Is(err, repositories.ErrDuplicate) {
+		s.logger.Warn("Replayed SAML assertion", zap.String("organization_id", org.ID.Hex()), zap.String("assertion_id", assertion.ID))
+		return ErrInvalidSSOState
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record assertion: %w", err)
+	}
+	return nil
+}
+
+// samlIdentity returns the user asserted by a SAML assertion. The email comes
+// from the mapped attribute or else the NameID; the identity provider vouches
+// for it.
+func samlIdentity(settings *models.SAMLSettings, assertion *saml.Assertion) *ssoIdentity {
+	mapping := settings.AttributeMapping
+	attribute := func(name string) string {
+		if name == "" {
+			return ""
+		}
+		return strings.TrimSpace(assertion.Attribute(name))
+	}
+
+	email := attribute(mapping.Email)
+	if email == "" && strings.Contains(assertion.NameID, "@") {
+		email = assertion.NameID
+	}
+	groupsAttribute := settings.GroupsAttribute
+	if groupsAttribute == "" {
+		groupsAttribute = defaultGroupsAttribute
+	}
+	return &ssoIdentity{
+		Subject:       settings.IdPEntityID + "#" + assertion.NameID,
+		Email:         email,
+		EmailVerified: true,
+		Profile: models.UserProfile{
+			FirstName:   attribute(mapping.FirstName),
+			LastName:    attribute(mapping.LastName),
+			Title:       attribute(mapping.Title),
+			Department:  attribute(mapping.Department),
+			PhoneNumber: attribute(mapping.PhoneNumber),
+		},
+		Groups: assertion.Attributes[groupsAttribute],
+	}
+}
+
+// providerConfig returns the service and identity provider of an
+// organization. SSO must be in the organization's plan, enabled and set to
+// SAML with a complete identity provider configuration.
+func (s *samlService) providerConfig(org *models.Organization) (*saml.ServiceProvider, *saml.IdentityProvider, *models.SAMLSettings, error) {
+	settings := org.Settings.Integrations.SAML
+	if !ssoAvailable(org, models.SSOProviderSAML) || settings == nil {
+		return nil, nil, nil, ErrSSODisabled
+	}
+	sp, err := s.serviceProvider(org)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	certificates, err := saml.ParseCertificates(settings.Certificates)
+	if err != nil || settings.IdPEntityID == "" || settings.IdPSSOURL == "" {
+		s.logger.Warn("Incomplete SAML configuration", zap.Error(err), zap.String("organization_id", org.ID.Hex()))
+		return nil, nil, nil, ErrSSODisabled
+	}
+	return sp, &saml.IdentityProvider{
+		EntityID:     settings.IdPEntityID,
+		SSOURL:       settings.IdPSSOURL,
+		Certificates: certificates,
+	}, settings, nil
+}
+
+// serviceProvider returns the platform's service provider for an
+// organization. Each organization has its own entity ID, the URL of its
+// metadata, and its own assertion consumer service.
+func (s *samlService) serviceProvider(org *models.Organization) (*saml.ServiceProvider, error) {
+	if s.key == nil || s.config.SAMLBaseURL == "" {
+		s.logger.Warn("SAML service provider is not configured", zap.String("organization_id", org.ID.Hex()))
+		return nil, ErrSSODisabled
+	}
+	base := strings.TrimSuffix(s.config.SAMLBaseURL, "/") + "/auth/sso/saml/" + org.Slug
+	return &saml.ServiceProvider{
+		EntityID:    base + "/metadata",
+		ACSURL:      base + "/acs",
+		Key:         s.key,
+		Certificate: s.certificate,
+		ClockSkew:   s.config.ClockSkew,
+	}, nil
+}
+
+// organizationBySlug looks up an organization by its slug.
+func (s *samlService) organizationBySlug(ctx context.Context, slug string) (*models.Organization, error) {
+	org, err := s.orgRepo.GetBySlug(ctx, slug)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	return org, nil
+}
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/auth"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/cache/cachetest"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/saml/samltest"
+)
+
+const (
+	samlEntityID = "https://app.example.com/api/v1/auth/sso/saml/first-bank/metadata"
+	samlACSURL   = "https://app.example.com/api/v1/auth/sso/saml/first-bank/acs"
+)
+
+type samlFixture struct {
+	idp           *samltest.IdentityProvider
+	spCertificate *x509.Certificate
+	org           *models.Organization
+	users         *fakeUserRepository
+	sessions      *fakeSessionRepository
+	states        *fakeSSOLoginStateRepository
+	service       SAMLService
+}
+
+func newSAMLFixture(t *testing.T) *samlFixture {
+	t.Helper()
+
+	idp, err := samltest.NewIdentityProvider("https://idp.first-bank.com")
+	require.NoError(t, err)
+	key, certificate, err := samltest.NewCertificate("goedu")
+	require.NoError(t, err)
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "sp.crt"), filepath.Join(dir, "sp.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0o600))
+
+	org := &models.Organization{Name: "First Bank", Slug: "first-bank", Status: models.OrganizationStatusActive, IsActive: true}
+	org.ID = primitive.NewObjectID()
+	org.FeatureFlags = map[string]bool{"sso_integration": true}
+	org.Settings.Integrations.SSO = true
+	org.Settings.Integrations.SSOProvider = models.SSOProviderSAML
+	org.Settings.Integrations.SAML = &models.SAMLSettings{
+		IdPEntityID:  idp.EntityID,
+		IdPSSOURL:    idp.SSOURL,
+		Certificates: []string{idp.CertificatePEM()},
+		AttributeMapping: models.SAMLAttributeMapping{
+			FirstName:  "givenName",
+			LastName:   "sn",
+			Department: "department",
+		},
+		GroupsAttribute: "memberOf",
+		RoleMappings: []models.GroupRoleMapping{
+			{Group: "GRC-Admins", Role: models.RoleAdmin},
+			{Group: "GRC-Auditors", Role: models.RoleAuditor},
+		},
+		AutoProvision: true,
+	}
+
+	f := &samlFixture{
+		idp:           idp,
+		spCertificate: certificate,
+		org:           org,
+		users:         newFakeUserRepository(),
+		sessions:      &fakeSessionRepository{},
+		states:        &fakeSSOLoginStateRepository{},
+	}
+	f.service = NewSAMLService(newFakeOrganizationRepository(org), f.users, f.sessions, cachetest.NewDenylist(), f.states,
+		auth.NewJWTManager([]byte("test-secret"), "goedu-platform", "goedu-api"),
+		config.SSOConfig{
+			StateTTL:            10 * time.Minute,
+			ClockSkew:           time.Minute,
+			SAMLBaseURL:         "https://app.example.com/api/v1/",
+			SAMLCertificateFile: certFile,
+			SAMLKeyFile:         keyFile,
+		}, testSessionConfig, zap.NewNop())
+	return f
+}
+
+// login returns a response for the given request ID; empty for an IdP-initiated login.
+func (f *samlFixture) login(t *testing.T, requestID string, attributes map[string][]string) string {
+	t.Helper()
+	if attributes == nil {
+		attributes = map[string][]string{
+			"givenName":  {"Ada"},
+			"sn":         {"Auditor"},
+			"department": {"Internal Audit"},
+			"memberOf":   {"All-Staff", "GRC-Auditors"},
+		}
+	}
+	response, err := f.idp.Response(samltest.Login{
+		ACSURL:       samlACSURL,
+		Audience:     samlEntityID,
+		InResponseTo: requestID,
+		NameID:       "Auditor@First-Bank.com",
+		Attributes:   attributes,
+	})
+	require.NoError(t, err)
+	return response
+}
+
+// begin starts an SP-initiated login and returns the request the identity provider received.
+func (f *samlFixture) begin(t *testing.T, returnTo string) *samltest.AuthnRequest {
+	t.Helper()
+	redirectURL, err := f.service.BeginLogin(context.Background(), &BeginSSOInput{OrganizationSlug: "first-bank", ReturnTo: returnTo})
+	require.NoError(t, err)
+	request, err := f.idp.ReadAuthnRequest(redirectURL, f.spCertificate)
+	require.NoError(t, err)
+	return request
+}
+
+func (f *samlFixture) complete(response, relayState string) (*SSOLoginResult, error) {
+	return f.service.CompleteLogin(context.Background(), &CompleteSAMLInput{
+		OrganizationSlug: "first-bank",
+		SAMLResponse:     response,
+		RelayState:       relayState,
+		IPAddress:        "10.0.0.1",
+		UserAgent:        "test",
+	})
+}
+
+func TestSAMLService_SPInitiatedLogin(t *testing.T) {
+	f := newSAMLFixture(t)
+	request := f.begin(t, "/controls")
+	assert.Equal(t, samlEntityID, request.Issuer)
+	assert.Equal(t, samlACSURL, request.ACSURL)
+
+	response := f.login(t, request.ID, nil)
+	result, err := f.complete(response, request.RelayState)
+	require.NoError(t, err)
+	assert.Equal(t, "/controls", result.ReturnTo)
+
+	// The account is created from the mapped attributes and groups
+	user, err := f.users.GetByEmail(context.Background(), "auditor@first-bank.com")
+	require.NoError(t, err)
+	assert.Equal(t, f.org.ID, user.OrganizationID)
+	assert.Equal(t, []string{models.RoleAuditor}, user.Roles)
+	assert.Equal(t, models.UserProfile{FirstName: "Ada", LastName: "Auditor", Department: "Internal Audit"}, user.Profile)
+	assert.Equal(t, "https://idp.first-bank.com#Auditor@First-Bank.com", user.Authentication.SSOSubject)
+
+	require.Len(t, f.sessions.sessions, 1)
+	assert.Equal(t, models.LoginMethodSSO, f.sessions.sessions[0].LoginMethod)
+	assert.Equal(t, result.SessionID, f.sessions.sessions[0].SessionID)
+
+	// The relay state is single use and the response cannot be replayed
+	_, err = f.complete(response, request.RelayState)
+	assert.ErrorIs(t, err, ErrInvalidSSOState)
+}
+
+func TestSAMLService_LoginSyncsProfile(t *testing.T) {
+	f := newSAMLFixture(t)
+	request := f.begin(t, "")
+	_, err := f.complete(f.login(t, request.ID, nil), request.RelayState)
+	require.NoError(t, err)
+
+	request = f.begin(t, "")
+	_, err = f.complete(f.login(t, request.ID, map[string][]string{
+		"givenName":  {"Ada"},
+		"department": {"Risk"},
+		"memberOf":   {"GRC-Admins"},
+	}), request.RelayState)
+	require.NoError(t, err)
+
+	user, err := f.users.GetByEmail(context.Background(), "auditor@first-bank.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{models.RoleAdmin}, user.Roles)
+	assert.Equal(t, models.UserProfile{FirstName: "Ada", LastName: "Auditor", Department: "Risk"}, user.Profile)
+}
+
+func TestSAMLService_IdPInitiatedLogin(t *testing.T) {
+	f := newSAMLFixture(t)
+	response := f.login(t, "", nil)
+
+	// Unsolicited responses are refused unless the organization allows them
+	_, err := f.complete(response, "")
+	assert.ErrorIs(t, err, ErrInvalidSSOState)
+
+	f.org.Settings.Integrations.SAML.AllowIdPInitiated = true
+	result, err := f.complete(response, "https://app.example.com/ignored")
+	require.NoError(t, err)
+	assert.Empty(t, result.ReturnTo)
+
+	// The assertion is recorded and cannot be used again, nor its record removed
+	for marker := range f.states.states {
+		_, err = f.complete(response, marker)
+		assert.ErrorIs(t, err, ErrInvalidSSOState)
+	}
+	_, err = f.complete(response, "")
+	assert.ErrorIs(t, err, ErrInvalidSSOState)
+	assert.Len(t, f.sessions.sessions, 1)
+}
+
+func TestSAMLService_CompleteLoginFailures(t *testing.T) {
+	t.Run("response to another request", func(t *testing.T) {
+		f := newSAMLFixture(t)
+		first := f.begin(t, "")
+		second := f.begin(t, "")
+		_, err := f.complete(f.login(t, first.ID, nil), second.RelayState)
+		assert.ErrorIs(t, err, ErrSSOAuthenticationFailed)
+	})
+
+	t.Run("assertion for another audience", func(t *testing.T) {
+		f := newSAMLFixture(t)
+		f.org.Settings.Integrations.SAML.AllowIdPInitiated = true
+		response, err := f.idp.Response(samltest.Login{ACSURL: samlACSURL, Audience: "https://other.example.com", NameID: "auditor@first-bank.com"})
+		require.NoError(t, err)
+		_, err = f.complete(response, "")
+		assert.ErrorIs(t, err, ErrSSOAuthenticationFailed)
+	})
+
+	t.Run("rotated identity provider key", func(t *testing.T) {
+		f := newSAMLFixture(t)
+		other, err := samltest.NewIdentityProvider(f.idp.EntityID)
+		require.NoError(t, err)
+		f.org.Settings.Integrations.SAML.Certificates = []string{other.CertificatePEM()}
+		request := f.begin(t, "")
+		_, err = f.complete(f.login(t, request.ID, nil), request.RelayState)
+		assert.ErrorIs(t, err, ErrSSOAuthenticationFailed)
+	})
+
+	t.Run("no mapped group", func(t *testing.T) {
+		f := newSAMLFixture(t)
+		request := f.begin(t, "")
+		_, err := f.complete(f.login(t, request.ID, map[string][]string{"memberOf": {"All-Staff"}}), request.RelayState)
+		assert.ErrorIs(t, err, ErrSSOAccessDenied)
+		assert.Empty(t, f.sessions.sessions)
+	})
+}
+
+func TestSAMLService_Gating(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(f *samlFixture)
+	}{
+		{"feature not in plan", func(f *samlFixture) { f.org.FeatureFlags["sso_integration"] = false }},
+		{"SSO switched off", func(f *samlFixture) { f.org.Settings.Integrations.SSO = false }},
+		{"other provider type", func(f *samlFixture) { f.org.Settings.Integrations.SSOProvider = models.SSOProviderOIDC }},
+		{"no identity provider certificate", func(f *samlFixture) { f.org.Settings.Integrations.SAML.Certificates = nil }},
+		{"service provider key not configured", func(f *samlFixture) {
+			f.service = NewSAMLService(newFakeOrganizationRepository(f.org), f.users, f.sessions, cachetest.NewDenylist(), f.states, nil,
+				config.SSOConfig{SAMLBaseURL: "https://app.example.com/api/v1"}, testSessionConfig, zap.NewNop())
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newSAMLFixture(t)
+			tt.modify(f)
+			_, err := f.service.BeginLogin(context.Background(), &BeginSSOInput{OrganizationSlug: "first-bank"})
+			assert.ErrorIs(t, err, ErrSSODisabled)
+			_, err = f.complete(f.login(t, "", nil), "")
+			assert.ErrorIs(t, err, ErrSSODisabled)
+			assert.Empty(t, f.states.states)
+		})
+	}
+}
+
+func TestSAMLService_Metadata(t *testing.T) {
+	f := newSAMLFixture(t)
+	metadata, err := f.service.Metadata(context.Background(), "first-bank")
+	require.NoError(t, err)
+	assert.Contains(t, string(metadata), `entityID="`+samlEntityID+`"`)
+	assert.Contains(t, string(metadata), `Location="`+samlACSURL+`"`)
+
+	// Metadata is available before SSO is switched on, but only in plans with SSO
+	f.org.Settings.Integrations.SSO = false
+	_, err = f.service.Metadata(context.Background(), "first-bank")
+	assert.NoError(t, err)
+	f.org.FeatureFlags["sso_integration"] = false
+	_, err = f.service.Metadata(context.Background(), "first-bank")
+	assert.ErrorIs(t, err, ErrSSODisabled)
+
+	_, err = f.service.Metadata(context.Background(), "unknown")
+	assert.ErrorIs(t, err, ErrOrganizationNotFound)
+}
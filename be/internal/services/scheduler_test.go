@@ -0,0 +1,421 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/requestctx"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/cache/cachetest"
+)
+
+// fakeScheduledJobRepository is an in-memory store of scheduled jobs with
+// atomic leases.
+type fakeScheduledJobRepository struct {
+	mu   sync.Mutex
+	jobs map[string]*models.ScheduledJob
+}
+
+func newFakeScheduledJobRepository() *fakeScheduledJobRepository {
+	return &fakeScheduledJobRepository{jobs: make(map[string]*models.ScheduledJob)}
+}
+
+// update applies fn to a stored job under the lock.
+func (r *fakeScheduledJobRepository) update(name string, fn func(job *models.ScheduledJob)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[name]
+	if !ok {
+		return repositories.ErrNotFound
+	}
+	fn(job)
+	return nil
+}
+
+func (r *fakeScheduledJobRepository) Create(ctx context.Context, job *models.ScheduledJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.jobs[job.Name]; ok {
+		return repositories.ErrDuplicate
+	}
+	stored := *job
+	r.jobs[job.Name] = &stored
+	return nil
+}
+
+func (r *fakeScheduledJobRepository) GetByName(ctx context.Context, name string) (*models.ScheduledJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[name]
+	if !ok {
+		return nil, repositories.ErrNotFound
+	}
+	stored := *job
+	return &stored, nil
+}
+
+func (r *fakeScheduledJobRepository) List(ctx context.Context) ([]*models.ScheduledJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	jobs := make([]*models.ScheduledJob, 0, len(r.jobs))
+	for _, job := range r.jobs {
+		stored := *job
+		jobs = append(jobs, &stored)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+	return jobs, nil
+}
+
+func (r *fakeScheduledJobRepository) UpdateDefinition(ctx context.Context, name, description, schedule string, nextRunAt time.Time) error {
+	return r.update(name, func(job *models.ScheduledJob) {
+		job.Description, job.Schedule, job.NextRunAt = description, schedule, nextRunAt
+	})
+}
+
+func (r *fakeScheduledJobRepository) SetPaused(ctx context.Context, name string, paused bool, by string, at, nextRunAt time.Time) error {
+	return r.update(name, func(job *models.ScheduledJob) {
+		job.Paused, job.PausedBy, job.PausedAt, job.NextRunAt = paused, by, at, nextRunAt
+	})
+}
+
+func (r *fakeScheduledJobRepository) SetNextRun(ctx context.Context, name string, nextRunAt time.Time) error {
+	return r.update(name, func(job *models.ScheduledJob) { job.NextRunAt = nextRunAt })
+}
+
+func (r *fakeScheduledJobRepository) RecordRun(ctx context.Context, name string, run *models.JobRun) error {
+	return r.update(name, func(job *models.ScheduledJob) {
+		job.LastRunAt, job.LastStatus, job.LastError, job.LastDurationMS = run.StartedAt, run.Status, run.Error, run.DurationMS
+	})
+}
+
+func (r *fakeScheduledJobRepository) AcquireLease(ctx context.Context, name, owner string, now, until time.Time) (bool, error) {
+	acquired := false
+	err := r.update(name, func(job *models.ScheduledJob) {
+		if job.LeaseOwner == "" || !job.LeaseUntil.After(now) {
+			job.LeaseOwner, job.LeaseUntil = owner, until
+			acquired = true
+		}
+	})
+	return acquired, err
+}
+
+func (r *fakeScheduledJobRepository) RenewLease(ctx context.Context, name, owner string, until time.Time) (bool, error) {
+	renewed := false
+	err := r.update(name, func(job *models.ScheduledJob) {
+		if job.LeaseOwner == owner {
+			job.LeaseUntil = until
+			renewed = true
+		}
+	})
+	return renewed, err
+}
+
+func (r *fakeScheduledJobRepository) ReleaseLease(ctx context.Context, name, owner string) error {
+	return r.update(name, func(job *models.ScheduledJob) {
+		if job.LeaseOwner == owner {
+			job.LeaseOwner, job.LeaseUntil = "", time.Time{}
+		}
+	})
+}
+
+type fakeJobRunRepository struct {
+	mu   sync.Mutex
+	runs []*models.JobRun
+}
+
+func (r *fakeJobRunRepository) Create(ctx context.Context, run *models.JobRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *run
+	r.runs = append(r.runs, &stored)
+	return nil
+}
+
+func (r *fakeJobRunRepository) Update(ctx context.Context, run *models.JobRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.runs {
+		if existing.ID == run.ID {
+			stored := *run
+			r.runs[i] = &stored
+			return nil
+		}
+	}
+	return repositories.ErrNotFound
+}
+
+func (r *fakeJobRunRepository) GetByJob(ctx context.Context, jobName string, limit, offset int) ([]*models.JobRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var runs []*models.JobRun
+	for i := len(r.runs) - 1; i >= 0; i-- {
+		if r.runs[i].JobName == jobName {
+			stored := *r.runs[i]
+			runs = append(runs, &stored)
+		}
+	}
+	if offset >= len(runs) {
+		return nil, nil
+	}
+	runs = runs[offset:]
+	if len(runs) > limit {
+		runs = runs[:limit]
+	}
+	return runs, nil
+}
+
+func (r *fakeJobRunRepository) CountByJob(ctx context.Context, jobName string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, run := range r.runs {
+		if run.JobName == jobName {
+			count++
+		}
+	}
+	return count, nil
+}
+
+const schedulerAdminOrg = "operator-org"
+
+func newTestScheduler(jobRepo *fakeScheduledJobRepository, runRepo *fakeJobRunRepository, locker JobLocker) *schedulerService {
+	return NewSchedulerService(jobRepo, runRepo, locker, config.SchedulerConfig{
+		Timezone:            "UTC",
+		LeaseTTL:            time.Minute,
+		PollInterval:        time.Second,
+		RunHistoryTTL:       time.Hour,
+		AdminOrganizationID: schedulerAdminOrg,
+	}, zap.NewNop()).(*schedulerService)
+}
+
+// makeJobDue moves a job's next run time into the past.
+func makeJobDue(t *testing.T, jobRepo *fakeScheduledJobRepository, name string) {
+	t.Helper()
+	require.NoError(t, jobRepo.SetNextRun(context.Background(), name, time.Now().Add(-time.Minute)))
+}
+
+func TestScheduler_DueJobRunsOnceAcrossReplicas(t *testing.T) {
+	ctx := context.Background()
+	jobRepo, runRepo, locks := newFakeScheduledJobRepository(), &fakeJobRunRepository{}, cachetest.NewLocks()
+
+	var runs atomic.Int32
+	replicas := make([]*schedulerService, 3)
+	for i := range replicas {
+		replicas[i] = newTestScheduler(jobRepo, runRepo, NewRedisJobLocker(locks))
+		require.NoError(t, replicas[i].Register("retention", "Purges old data", "0 2 * * *", func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		}))
+		require.NoError(t, replicas[i].syncJobs(ctx))
+	}
+	makeJobDue(t, jobRepo, "retention")
+
+	for _, replica := range replicas {
+		replica.runDue(ctx, time.Now())
+	}
+	for _, replica := range replicas {
+		replica.running.Wait()
+	}
+
+	assert.Equal(t, int32(1), runs.Load())
+	require.Len(t, runRepo.runs, 1)
+	run := runRepo.runs[0]
+	assert.Equal(t, models.JobRunSucceeded, run.Status)
+	assert.Equal(t, models.JobTriggerSchedule, run.Trigger)
+	assert.False(t, run.FinishedAt.IsZero())
+	assert.WithinDuration(t, time.Now().Add(time.Hour), run.ExpiresAt, time.Minute)
+
+	job, err := jobRepo.GetByName(ctx, "retention")
+	require.NoError(t, err)
+	assert.Equal(t, models.JobRunSucceeded, job.LastStatus)
+	assert.True(t, job.NextRunAt.After(time.Now()))
+	assert.Equal(t, 2, job.NextRunAt.Hour())
+	assert.Empty(t, locks.Owner(schedulerLockPrefix+"retention"), "lease must be released")
+}
+
+func TestScheduler_SyncJobsKeepsStateAndUpdatesSchedule(t *testing.T) {
+	ctx := context.Background()
+	jobRepo, runRepo := newFakeScheduledJobRepository(), &fakeJobRunRepository{}
+	noop := func(ctx context.Context) error { return nil }
+
+	first := newTestScheduler(jobRepo, runRepo, NewMongoJobLocker(jobRepo))
+	require.NoError(t, first.Register("digest", "Sends digests", "0 * * * *", noop))
+	require.NoError(t, first.syncJobs(ctx))
+	_, err := first.PauseJob(ctx, schedulerAdminOrg, "digest")
+	require.NoError(t, err)
+
+	second := newTestScheduler(jobRepo, runRepo, NewMongoJobLocker(jobRepo))
+	require.NoError(t, second.Register("digest", "Sends due digests", "*/5 * * * *", noop))
+	require.NoError(t, second.syncJobs(ctx))
+
+	job, err := jobRepo.GetByName(ctx, "digest")
+	require.NoError(t, err)
+	assert.Equal(t, "*/5 * * * *", job.Schedule)
+	assert.Equal(t, "Sends due digests", job.Description)
+	assert.True(t, job.Paused, "pausing survives a redeploy")
+	assert.Zero(t, job.NextRunAt.Minute()%5)
+}
+
+func TestScheduler_PausedJobIsSkipped(t *testing.T) {
+	ctx := context.Background()
+	jobRepo, runRepo := newFakeScheduledJobRepository(), &fakeJobRunRepository{}
+	scheduler := newTestScheduler(jobRepo, runRepo, NewMongoJobLocker(jobRepo))
+
+	var runs atomic.Int32
+	require.NoError(t, scheduler.Register("retention", "", "@daily", func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}))
+	require.NoError(t, scheduler.syncJobs(ctx))
+
+	ctx = requestctx.WithMetadata(ctx, &requestctx.Metadata{UserID: "admin-1"})
+	job, err := scheduler.PauseJob(ctx, schedulerAdminOrg, "retention")
+	require.NoError(t, err)
+	assert.True(t, job.Paused)
+	assert.Equal(t, "admin-1", job.PausedBy)
+
+	makeJobDue(t, jobRepo, "retention")
+	scheduler.runDue(ctx, time.Now())
+	scheduler.running.Wait()
+	assert.Zero(t, runs.Load())
+
+	job, err = scheduler.ResumeJob(ctx, schedulerAdminOrg, "retention")
+	require.NoError(t, err)
+	assert.False(t, job.Paused)
+	assert.True(t, job.NextRunAt.After(time.Now()), "missed runs are skipped")
+}
+
+func TestScheduler_FailuresAreRecorded(t *testing.T) {
+	ctx := context.Background()
+	jobRepo, runRepo := newFakeScheduledJobRepository(), &fakeJobRunRepository{}
+	scheduler := newTestScheduler(jobRepo, runRepo, NewMongoJobLocker(jobRepo))
+
+	require.NoError(t, scheduler.Register("failing", "", "@hourly", func(ctx context.Context) error {
+		return errors.New("smtp unavailable")
+	}))
+	require.NoError(t, scheduler.Register("panicking", "", "@hourly", func(ctx context.Context) error {
+		panic("nil map")
+	}))
+	require.NoError(t, scheduler.syncJobs(ctx))
+	makeJobDue(t, jobRepo, "failing")
+	makeJobDue(t, jobRepo, "panicking")
+
+	scheduler.runDue(ctx, time.Now())
+	scheduler.running.Wait()
+
+	failing, err := scheduler.ListRuns(ctx, schedulerAdminOrg, "failing", 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, failing.TotalCount)
+	assert.Equal(t, models.JobRunFailed, failing.Nodes[0].Status)
+	assert.Equal(t, "smtp unavailable", failing.Nodes[0].Error)
+
+	job, err := scheduler.GetJob(ctx, schedulerAdminOrg, "panicking")
+	require.NoError(t, err)
+	assert.Equal(t, models.JobRunFailed, job.LastStatus)
+	assert.Contains(t, job.LastError, "nil map")
+	assert.Empty(t, job.LeaseOwner, "lease must be released")
+}
+
+func TestScheduler_TriggerJob(t *testing.T) {
+	ctx := requestctx.WithMetadata(context.Background(), &requestctx.Metadata{UserID: "admin-1"})
+	jobRepo, runRepo, locks := newFakeScheduledJobRepository(), &fakeJobRunRepository{}, cachetest.NewLocks()
+	scheduler := newTestScheduler(jobRepo, runRepo, NewRedisJobLocker(locks))
+
+	release := make(chan struct{})
+	var actor atomic.Value
+	require.NoError(t, scheduler.Register("checkpoint", "", "@hourly", func(ctx context.Context) error {
+		md, _ := requestctx.FromContext(ctx)
+		actor.Store(md.UserID)
+		<-release
+		return nil
+	}))
+	require.NoError(t, scheduler.syncJobs(ctx))
+
+	run, err := scheduler.TriggerJob(ctx, schedulerAdminOrg, "checkpoint")
+	require.NoError(t, err)
+	assert.Equal(t, models.JobTriggerManual, run.Trigger)
+	assert.Equal(t, "admin-1", run.TriggeredBy)
+	assert.Equal(t, models.JobRunRunning, run.Status)
+
+	_, err = scheduler.TriggerJob(ctx, schedulerAdminOrg, "checkpoint")
+	assert.ErrorIs(t, err, ErrScheduledJobRunning)
+
+	close(release)
+	scheduler.running.Wait()
+	assert.Equal(t, "admin-1", actor.Load())
+
+	page, err := scheduler.ListRuns(ctx, schedulerAdminOrg, "checkpoint", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, page.Nodes, 1)
+	assert.Equal(t, models.JobRunSucceeded, page.Nodes[0].Status)
+	assert.False(t, page.HasMore)
+
+	_, err = scheduler.TriggerJob(ctx, schedulerAdminOrg, "unknown")
+	assert.ErrorIs(t, err, ErrScheduledJobNotFound)
+}
+
+func TestScheduler_AdminAccess(t *testing.T) {
+	ctx := context.Background()
+	jobRepo, runRepo := newFakeScheduledJobRepository(), &fakeJobRunRepository{}
+	scheduler := newTestScheduler(jobRepo, runRepo, NewMongoJobLocker(jobRepo))
+
+	_, err := scheduler.ListJobs(ctx, "customer-org")
+	assert.ErrorIs(t, err, ErrSchedulerAdminForbidden)
+	_, err = scheduler.TriggerJob(ctx, "customer-org", "retention")
+	assert.ErrorIs(t, err, ErrSchedulerAdminForbidden)
+
+	scheduler.cfg.AdminOrganizationID = ""
+	_, err = scheduler.ListJobs(ctx, "")
+	assert.ErrorIs(t, err, ErrSchedulerAdminForbidden, "the API is closed until an operator organization is configured")
+}
+
+func TestScheduler_Register(t *testing.T) {
+	scheduler := newTestScheduler(newFakeScheduledJobRepository(), &fakeJobRunRepository{}, nil)
+	noop := func(ctx context.Context) error { return nil }
+
+	assert.ErrorIs(t, scheduler.Register("retention", "", "every day", noop), ErrInvalidSchedule)
+	require.NoError(t, scheduler.Register("retention", "", "@daily", noop))
+	assert.ErrorIs(t, scheduler.Register("retention", "", "@daily", noop), ErrScheduledJobExists)
+}
+
+func TestMongoJobLocker(t *testing.T) {
+	ctx := context.Background()
+	jobRepo := newFakeScheduledJobRepository()
+	require.NoError(t, jobRepo.Create(ctx, &models.ScheduledJob{Name: "retention"}))
+	locker := NewMongoJobLocker(jobRepo)
+
+	acquired, err := locker.Acquire(ctx, "retention", "a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	acquired, err = locker.Acquire(ctx, "retention", "b", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	renewed, err := locker.Renew(ctx, "retention", "b", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, renewed)
+
+	require.NoError(t, locker.Release(ctx, "retention", "b"))
+	require.NoError(t, locker.Release(ctx, "retention", "a"))
+	acquired, err = locker.Acquire(ctx, "retention", "b", time.Nanosecond)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	// Expired leases are taken over
+	time.Sleep(time.Millisecond)
+	acquired, err = locker.Acquire(ctx, "retention", "c", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+}
@@ -0,0 +1,883 @@
+// Package services provides service layer implementations for the GoEdu Control Testing Platform.
+// This file contains the SCIM service, through which an organization's
+// identity provider creates, updates and deprovisions users and maintains
+// groups whose membership decides the users' roles.
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/scim"
+)
+
+// SCIM provisioning errors
+var (
+	ErrSCIMDisabled      = errors.New("SCIM provisioning is not enabled for this organization")
+	ErrSCIMUserNotFound  = errors.New("SCIM user not found")
+	ErrSCIMGroupNotFound = errors.New("SCIM group not found")
+	ErrSCIMUserExists    = errors.New("a user with this userName already exists")
+	ErrSCIMGroupExists   = errors.New("a group with this displayName already exists")
+)
+
+const (
+	// scimMaxResults is the largest page a query returns
+	scimMaxResults = 200
+
+	// organizationUserBatch is the number of users loaded per repository
+	// call when all of an organization's users are read
+	organizationUserBatch = 500
+)
+
+// scimService implements the SCIMService interface.
+type scimService struct {
+	orgRepo     repositories.OrganizationRepository
+	userRepo    repositories.UserRepository
+	groupRepo   repositories.SCIMGroupRepository
+	authService AuthenticationService
+	seats       *memberSeats
+	logger      *zap.Logger
+}
+
+// NewSCIMService creates a new SCIM service.
+//
+// Parameters:
+//   - orgRepo: Repository for organization data and SCIM settings
+//   - userRepo: Repository for the provisioned user accounts
+//   - groupRepo: Repository for the provisioned groups
+//   - authService: Service whose sessions are terminated when a user is deprovisioned
+//   - logger: Logger for service operations
+//
+// Returns:
+//   - SCIMService: Configured SCIM service instance
+func NewSCIMService(
+	orgRepo repositories.OrganizationRepository,
+	userRepo repositories.UserRepository,
+	groupRepo repositories.SCIMGroupRepository,
+	authService AuthenticationService,
+	logger *zap.Logger,
+) SCIMService {
+	return &scimService{
+		orgRepo:     orgRepo,
+		userRepo:    userRepo,
+		groupRepo:   groupRepo,
+		authService: authService,
+		seats:       &memberSeats{orgRepo: orgRepo, logger: logger},
+		logger:      logger,
+	}
+}
+
+// ListUsers returns a page of the organization's users matching the query,
+// ordered by creation.
+func (s *scimService) ListUsers(ctx context.Context, orgID string, query *SCIMQuery) (*scim.ListResponse, error) {
+	org, _, err := s.organization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := parseSCIMFilter(query.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := organizationUsers(ctx, s.userRepo, org.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	groups, err := s.groupRepo.GetByOrganization(ctx, org.ID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get groups: %w", err)
+	}
+	memberships := make(map[primitive.ObjectID][]*models.SCIMGroup)
+	for _, group := range groups {
+		for _, member := range group.Members {
+			memberships[member] = append(memberships[member], group)
+		}
+	}
+
+	var matches []interface{}
+	for _, user := range users {
+		resource := scimUser(user, memberships[user.ID])
+		ok, err := scimMatches(filter, resource)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matches = append(matches, resource)
+		}
+	}
+	return scimPage(matches, query), nil
+}
+
+// GetUser returns a user of the organization.
+func (s *scimService) GetUser(ctx context.Context, orgID, userID string) (*scim.User, error) {
+	org, _, err := s.organization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.user(ctx, org, userID)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := s.groupRepo.GetByMember(ctx, org.ID.Hex(), user.ID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get groups: %w", err)
+	}
+	return scimUser(user, groups), nil
+}
+
+// CreateUser provisions a user. The user name must be the user's email
+// address, which is unique across the platform. New users have the default
+// role until they are added to a mapped group, and sign in through SSO. An
+// active user takes one of the organization's member seats.
+func (s *scimService) CreateUser(ctx context.Context, orgID string, resource *scim.User) (*scim.User, error) {
+	org, settings, err := s.organization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Roles:          scimRoles(settings, nil),
+		OrganizationID: org.ID,
+		IsActive:       true,
+		Status:         models.UserStatusActive,
+	}
+	if err := applySCIMUser(user, resource); err != nil {
+		return nil, err
+	}
+	if err := s.checkEmailAvailable(ctx, user.Email, primitive.NilObjectID); err != nil {
+		return nil, err
+	}
+	if user.IsActive {
+		if err := s.seats.take(ctx, org.ID.Hex()); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now().UTC()
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		if user.IsActive {
+			s.seats.release(ctx, org.ID.Hex())
+		}
+		if errors.Is(err, repositories.ErrDuplicate) {
+			return nil, ErrSCIMUserExists
+		}
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	s.logger.Info("Provisioned user through SCIM",
+		zap.String("organization_id", org.ID.Hex()),
+		zap.String("user_id", user.ID.Hex()),
+	)
+	return scimUser(user, nil), nil
+}
+
+// ReplaceUser replaces a user's attributes. Attributes left out are cleared,
+// except active, which keeps its value.
+func (s *scimService) ReplaceUser(ctx context.Context, orgID, userID string, resource *scim.User) (*scim.User, error) {
+	org, _, err := s.organization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.user(ctx, org, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.updateUser(ctx, user, resource)
+}
+
+// PatchUser applies PATCH operations to a user. Setting active to false
+// deactivates the user and terminates their sessions.
+func (s *scimService) PatchUser(ctx context.Context, orgID, userID string, patch *scim.PatchRequest) (*scim.User, error) {
+	org, _, err := s.organization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.user(ctx, org, userID)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := s.groupRepo.GetByMember(ctx, org.ID.Hex(), user.ID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get groups: %w", err)
+	}
+
+	resource, err := scim.ToResource(scimUser(user, groups))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode user: %w", err)
+	}
+	if err := patch.Apply(resource); err != nil {
+		return nil, err
+	}
+	var patched scim.User
+	if err := resource.Decode(&patched); err != nil {
+		return nil, err
+	}
+	return s.updateUser(ctx, user, &patched)
+}
+
+// DeleteUser deprovisions a user: it leaves its groups, is deactivated, its
+// sessions are terminated and the account is deleted.
+func (s *scimService) DeleteUser(ctx context.Context, orgID, userID string) error {
+	org, _, err := s.organization(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	user, err := s.user(ctx, org, userID)
+	if err != nil {
+		return err
+	}
+
+	groups, err := s.groupRepo.GetByMember(ctx, org.ID.Hex(), user.ID.Hex())
+	if err != nil {
+		return fmt.Errorf("failed to get groups: %w", err)
+	}
+	now := time.Now().UTC()
+	for _, group := range groups {
+		group.Members = slices.DeleteFunc(group.Members, func(id primitive.ObjectID) bool { return id == user.ID })
+		group.UpdatedAt = now
+		if err := s.groupRepo.Update(ctx, group); err != nil {
+			return fmt.Errorf("failed to update group: %w", err)
+		}
+	}
+
+	wasActive := user.IsActive
+	setSCIMActive(user, false)
+	user.UpdatedAt = now
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	if wasActive {
+		s.seats.release(ctx, org.ID.Hex())
+	}
+	if err := s.deprovision(ctx, user); err != nil {
+		return err
+	}
+	if err := s.userRepo.Delete(ctx, user.ID.Hex()); err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return nil
+}
+
+// ListGroups returns a page of the organization's groups matching the query,
+// ordered by creation.
+func (s *scimService) ListGroups(ctx context.Context, orgID string, query *SCIMQuery) (*scim.ListResponse, error) {
+	org, _, err := s.organization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := parseSCIMFilter(query.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := s.groupRepo.GetByOrganization(ctx, org.ID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get groups: %w", err)
+	}
+	slices.SortFunc(groups, func(a, b *models.SCIMGroup) int { return bytes.Compare(a.ID[:], b.ID[:]) })
+	var memberIDs []primitive.ObjectID
+	for _, group := range groups {
+		memberIDs = append(memberIDs, group.Members...)
+	}
+	users, err := s.usersByID(ctx, memberIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []interface{}
+	for _, group := range groups {
+		resource := scimGroup(group, users)
+		ok, err := scimMatches(filter, resource)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matches = append(matches, resource)
+		}
+	}
+	return scimPage(matches, query), nil
+}
+
+// GetGroup returns a group of the organization.
+func (s *scimService) GetGroup(ctx context.Context, orgID, groupID string) (*scim.Group, error) {
+	org, _, err := s.organization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	group, err := s.group(ctx, org, groupID)
+	if err != nil {
+		return nil, err
+	}
+	users, err := s.usersByID(ctx, group.Members)
+	if err != nil {
+		return nil, err
+	}
+	return scimGroup(group, users), nil
+}
+
+// CreateGroup creates a group and grants its members the roles mapped to it.
+// Members must be users of the organization.
+func (s *scimService) CreateGroup(ctx context.Context, orgID string, resource *scim.Group) (*scim.Group, error) {
+	org, settings, err := s.organization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	displayName := strings.TrimSpace(resource.DisplayName)
+	if displayName == "" {
+		return nil, scim.NewError(http.StatusBadRequest, scim.ErrorTypeInvalidValue, "displayName is required")
+	}
+	members, users, err := s.members(ctx, org, resource.Members)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	group := &models.SCIMGroup{
+		OrganizationID: org.ID,
+		DisplayName:    displayName,
+		ExternalID:     resource.ExternalID,
+		Members:        members,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.groupRepo.Create(ctx, group); err != nil {
+		if errors.Is(err, repositories.ErrDuplicate) {
+			return nil, ErrSCIMGroupExists
+		}
+		return nil, fmt.Errorf("failed to create group: %w", err)
+	}
+	if err := s.syncRoles(ctx, org, settings, members); err != nil {
+		return nil, err
+	}
+	return scimGroup(group, users), nil
+}
+
+// ReplaceGroup replaces a group's name and members and updates the roles of
+// the users whose groups changed.
+func (s *scimService) ReplaceGroup(ctx context.Context, orgID, groupID string, resource *scim.Group) (*scim.Group, error) {
+	org, settings, err := s.organization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	group, err := s.group(ctx, org, groupID)
+	if err != nil {
+		return nil, err
+	}
+	return s.updateGroup(ctx, org, settings, group, resource)
+}
+
+// PatchGroup applies PATCH operations to a group, typically adding or
+// removing members, and updates the roles of the users whose groups changed.
+func (s *scimService) PatchGroup(ctx context.Context, orgID, groupID string, patch *scim.PatchRequest) (*scim.Group, error) {
+	org, settings, err := s.organization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	group, err := s.group(ctx, org, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	resource, err := scim.ToResource(scimGroup(group, nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode group: %w", err)
+	}
+	if err := patch.Apply(resource); err != nil {
+		return nil, err
+	}
+	var patched scim.Group
+	if err := resource.Decode(&patched); err != nil {
+		return nil, err
+	}
+	return s.updateGroup(ctx, org, settings, group, &patched)
+}
+
+// DeleteGroup deletes a group and revokes the roles it granted its members.
+func (s *scimService) DeleteGroup(ctx context.Context, orgID, groupID string) error {
+	org, settings, err := s.organization(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	group, err := s.group(ctx, org, groupID)
+	if err != nil {
+		return err
+	}
+	if err := s.groupRepo.Delete(ctx, group.ID.Hex()); err != nil {
+		return fmt.Errorf("failed to delete group: %w", err)
+	}
+	return s.syncRoles(ctx, org, settings, group.Members)
+}
+
+// organization returns an organization and its SCIM settings, or
+// ErrSCIMDisabled when it has not enabled provisioning.
+func (s *scimService) organization(ctx context.Context, orgID string) (*models.Organization, *models.SCIMSettings, error) {
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	settings := org.Settings.Integrations.SCIM
+	if settings == nil || !settings.Enabled {
+		return nil, nil, ErrSCIMDisabled
+	}
+	return org, settings, nil
+}
+
+// user returns a user of the organization.
+func (s *scimService) user(ctx context.Context, org *models.Organization, userID string) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, repositories.ErrNotFound) || errors.Is(err, repositories.ErrInvalidInput) {
+		return nil, ErrSCIMUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.OrganizationID != org.ID {
+		return nil, ErrSCIMUserNotFound
+	}
+	return user, nil
+}
+
+// group returns a group of the organization.
+func (s *scimService) group(ctx context.Context, org *models.Organization, groupID string) (*models.SCIMGroup, error) {
+	group, err := s.groupRepo.GetByID(ctx, groupID)
+	if errors.Is(err, repositories.ErrNotFound) || errors.Is(err, repositories.ErrInvalidInput) {
+		return nil, ErrSCIMGroupNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+	if group.OrganizationID != org.ID {
+		return nil, ErrSCIMGroupNotFound
+	}
+	return group, nil
+}
+
+// organizationUsers returns all users of an organization ordered by creation.
+func organizationUsers(ctx context.Context, userRepo repositories.UserRepository, orgID string) ([]*models.User, error) {
+	var users []*models.User
+	for offset := 0; ; offset += organizationUserBatch {
+		batch, err := userRepo.GetByOrganization(ctx, orgID, organizationUserBatch, offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get users: %w", err)
+		}
+		users = append(users, batch...)
+		if len(batch) < organizationUserBatch {
+			break
+		}
+	}
+	slices.SortFunc(users, func(a, b *models.User) int { return bytes.Compare(a.ID[:], b.ID[:]) })
+	return users, nil
+}
+
+// usersByID loads the given users, keyed by ID.
+func (s *scimService) usersByID(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]*models.User, error) {
+	users := make(map[primitive.ObjectID]*models.User)
+	if len(ids) == 0 {
+		return users, nil
+	}
+	hexIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		hexIDs = append(hexIDs, id.Hex())
+	}
+	loaded, err := s.userRepo.GetByIDs(ctx, hexIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	for _, user := range loaded {
+		users[user.ID] = user
+	}
+	return users, nil
+}
+
+// members resolves the members of a group resource, which must be users of
+// the organization, and returns their IDs without duplicates.
+func (s *scimService) members(ctx context.Context, org *models.Organization, values []scim.MultiValued) ([]primitive.ObjectID, map[primitive.ObjectID]*models.User, error) {
+	ids := make([]primitive.ObjectID, 0, len(values))
+	for _, value := range values {
+		id, err := primitive.ObjectIDFromHex(value.Value)
+		if err != nil {
+			return nil, nil, scimInvalidMember(value.Value)
+		}
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	users, err := s.usersByID(ctx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, id := range ids {
+		if user, ok := users[id]; !ok || user.OrganizationID != org.ID {
+			return nil, nil, scimInvalidMember(id.Hex())
+		}
+	}
+	return ids, users, nil
+}
+
+// scimInvalidMember is the error for a group member that is not a user of
+// the organization; nested groups are not supported.
+func scimInvalidMember(value string) error {
+	return scim.NewError(http.StatusBadRequest, scim.ErrorTypeInvalidValue,
+		fmt.Sprintf("member %q is not a user of this organization", value))
+}
+
+// checkEmailAvailable fails with ErrSCIMUserExists when a user other than
+// the given one has the email address.
+func (s *scimService) checkEmailAvailable(ctx context.Context, email string, userID primitive.ObjectID) error {
+	existing, err := s.userRepo.GetByEmail(ctx, email)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if existing.ID != userID {
+		return ErrSCIMUserExists
+	}
+	return nil
+}
+
+// updateUser applies a user resource to an account and terminates the
+// account's sessions when the resource deactivates it.
+func (s *scimService) updateUser(ctx context.Context, user *models.User, resource *scim.User) (*scim.User, error) {
+	wasActive := user.IsActive
+	if email := strings.ToLower(strings.TrimSpace(resource.UserName)); email != user.Email {
+		if err := s.checkEmailAvailable(ctx, email, user.ID); err != nil {
+			return nil, err
+		}
+	}
+	if err := applySCIMUser(user, resource); err != nil {
+		return nil, err
+	}
+	orgID := user.OrganizationID.Hex()
+	reactivated := !wasActive && user.IsActive
+	if reactivated {
+		if err := s.seats.take(ctx, orgID); err != nil {
+			return nil, err
+		}
+	}
+
+	user.UpdatedAt = time.Now().UTC()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		if reactivated {
+			s.seats.release(ctx, orgID)
+		}
+		if errors.Is(err, repositories.ErrDuplicate) {
+			return nil, ErrSCIMUserExists
+		}
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	if wasActive && !user.IsActive {
+		s.seats.release(ctx, orgID)
+		if err := s.deprovision(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	groups, err := s.groupRepo.GetByMember(ctx, user.OrganizationID.Hex(), user.ID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get groups: %w", err)
+	}
+	return scimUser(user, groups), nil
+}
+
+// deprovision terminates the sessions of a deactivated or deleted user.
+func (s *scimService) deprovision(ctx context.Context, user *models.User) error {
+	if err := s.authService.TerminateAllSessions(ctx, user.ID.Hex()); err != nil {
+		return fmt.Errorf("failed to terminate sessions: %w", err)
+	}
+	s.logger.Info("Deprovisioned user through SCIM",
+		zap.String("organization_id", user.OrganizationID.Hex()),
+		zap.String("user_id", user.ID.Hex()),
+	)
+	return nil
+}
+
+// updateGroup applies a group resource to a group and updates the roles of
+// the users whose groups changed: all members when the group was renamed,
+// otherwise those added or removed.
+func (s *scimService) updateGroup(ctx context.Context, org *models.Organization, settings *models.SCIMSettings, group *models.SCIMGroup, resource *scim.Group) (*scim.Group, error) {
+	displayName := strings.TrimSpace(resource.DisplayName)
+	if displayName == "" {
+		return nil, scim.NewError(http.StatusBadRequest, scim.ErrorTypeInvalidValue, "displayName is required")
+	}
+	members, users, err := s.members(ctx, org, resource.Members)
+	if err != nil {
+		return nil, err
+	}
+
+	var affected []primitive.ObjectID
+	if strings.EqualFold(displayName, group.DisplayName) {
+		affected = changedMembers(group.Members, members)
+	} else {
+		affected = changedMembers(group.Members, nil)
+		affected = append(affected, changedMembers(nil, members)...)
+	}
+
+	group.DisplayName = displayName
+	group.ExternalID = resource.ExternalID
+	group.Members = members
+	group.UpdatedAt = time.Now().UTC()
+	if err := s.groupRepo.Update(ctx, group); err != nil {
+		if errors.Is(err, repositories.ErrDuplicate) {
+			return nil, ErrSCIMGroupExists
+		}
+		return nil, fmt.Errorf("failed to update group: %w", err)
+	}
+	if err := s.syncRoles(ctx, org, settings, affected); err != nil {
+		return nil, err
+	}
+	return scimGroup(group, users), nil
+}
+
+// syncRoles sets the roles of the given users to those mapped from their
+// groups.
+func (s *scimService) syncRoles(ctx context.Context, org *models.Organization, settings *models.SCIMSettings, userIDs []primitive.ObjectID) error {
+	for _, id := range userIDs {
+		user, err := s.userRepo.GetByID(ctx, id.Hex())
+		if errors.Is(err, repositories.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		groups, err := s.groupRepo.GetByMember(ctx, org.ID.Hex(), id.Hex())
+		if err != nil {
+			return fmt.Errorf("failed to get groups: %w", err)
+		}
+
+		roles := scimRoles(settings, groups)
+		if slices.Equal(roles, user.Roles) {
+			continue
+		}
+		user.Roles = roles
+		user.UpdatedAt = time.Now().UTC()
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return fmt.Errorf("failed to update user: %w", err)
+		}
+		s.logger.Info("Updated user roles from SCIM groups",
+			zap.String("organization_id", org.ID.Hex()),
+			zap.String("user_id", user.ID.Hex()),
+			zap.Strings("roles", roles),
+		)
+	}
+	return nil
+}
+
+// changedMembers returns the users in exactly one of two member lists.
+func changedMembers(before, after []primitive.ObjectID) []primitive.ObjectID {
+	var changed []primitive.ObjectID
+	for _, id := range before {
+		if !slices.Contains(after, id) {
+			changed = append(changed, id)
+		}
+	}
+	for _, id := range after {
+		if !slices.Contains(before, id) {
+			changed = append(changed, id)
+		}
+	}
+	return changed
+}
+
+// scimRoles returns the roles mapped from a user's groups, or the default
+// role when no group is mapped.
+func scimRoles(settings *models.SCIMSettings, groups []*models.SCIMGroup) []string {
+	names := make([]string, 0, len(groups))
+	for _, group := range groups {
+		names = append(names, group.DisplayName)
+	}
+	if roles := models.MapGroupRoles(settings.RoleMappings, names); len(roles) > 0 {
+		return roles
+	}
+	if settings.DefaultRole != "" {
+		return []string{settings.DefaultRole}
+	}
+	return []string{models.RoleViewer}
+}
+
+// scimUser returns the SCIM representation of a user.
+func scimUser(user *models.User, groups []*models.SCIMGroup) *scim.User {
+	active := scim.Boolean(user.IsActive)
+	fullName := strings.TrimSpace(user.Profile.GetFullName())
+	resource := &scim.User{
+		Schemas:    []string{scim.SchemaUser, scim.SchemaEnterpriseUser},
+		ID:         user.ID.Hex(),
+		ExternalID: user.Metadata.ExternalID,
+		UserName:   user.Email,
+		Name: &scim.Name{
+			Formatted:  fullName,
+			GivenName:  user.Profile.FirstName,
+			FamilyName: user.Profile.LastName,
+		},
+		DisplayName: fullName,
+		Title:       user.Profile.Title,
+		Active:      &active,
+		Emails:      []scim.MultiValued{{Value: user.Email, Type: "work", Primary: true}},
+		Meta: &scim.Meta{
+			ResourceType: "User",
+			Created:      user.CreatedAt,
+			LastModified: user.UpdatedAt,
+		},
+	}
+	if user.Profile.PhoneNumber != "" {
+		resource.PhoneNumbers = []scim.MultiValued{{Value: user.Profile.PhoneNumber, Type: "work"}}
+	}
+	enterprise := scim.EnterpriseUser{
+		EmployeeNumber: user.Metadata.EmployeeID,
+		CostCenter:     user.Metadata.CostCenter,
+		Department:     user.Profile.Department,
+	}
+	if enterprise != (scim.EnterpriseUser{}) {
+		resource.Enterprise = &enterprise
+	}
+	for _, group := range groups {
+		resource.Groups = append(resource.Groups, scim.MultiValued{Value: group.ID.Hex(), Display: group.DisplayName})
+	}
+	return resource
+}
+
+// applySCIMUser copies the attributes of a user resource onto an account.
+// The user name must be an email address; the name falls back to the
+// formatted or display name when it has no parts.
+func applySCIMUser(user *models.User, resource *scim.User) error {
+	email := strings.ToLower(strings.TrimSpace(resource.UserName))
+	if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+		return scim.NewError(http.StatusBadRequest, scim.ErrorTypeInvalidValue, "userName must be the user's email address")
+	}
+
+	name := resource.Name
+	if name == nil {
+		name = &scim.Name{}
+	}
+	firstName, lastName := name.GivenName, name.FamilyName
+	if firstName == "" && lastName == "" {
+		fullName := name.Formatted
+		if fullName == "" {
+			fullName = resource.DisplayName
+		}
+		firstName, lastName, _ = strings.Cut(strings.TrimSpace(fullName), " ")
+	}
+	enterprise := resource.Enterprise
+	if enterprise == nil {
+		enterprise = &scim.EnterpriseUser{}
+	}
+
+	user.Email = email
+	user.Profile.FirstName = firstName
+	user.Profile.LastName = lastName
+	user.Profile.Title = resource.Title
+	user.Profile.Department = enterprise.Department
+	user.Profile.PhoneNumber = primaryValue(resource.PhoneNumbers)
+	user.Metadata.EmployeeID = enterprise.EmployeeNumber
+	user.Metadata.CostCenter = enterprise.CostCenter
+	user.Metadata.ExternalID = resource.ExternalID
+	if resource.Active != nil {
+		setSCIMActive(user, bool(*resource.Active))
+	}
+	return nil
+}
+
+// setSCIMActive activates or deactivates an account. Reactivation leaves
+// suspended and locked accounts in their status.
+func setSCIMActive(user *models.User, active bool) {
+	user.DeactivatedBy = ""
+	if !active {
+		user.IsActive = false
+		user.Status = models.UserStatusInactive
+		return
+	}
+	user.IsActive = true
+	if user.Status == "" || user.Status == models.UserStatusInactive {
+		user.Status = models.UserStatusActive
+	}
+}
+
+// primaryValue returns the primary value of a multi-valued attribute, or its
+// first value when none is marked primary.
+func primaryValue(values []scim.MultiValued) string {
+	for _, value := range values {
+		if value.Primary {
+			return value.Value
+		}
+	}
+	if len(values) > 0 {
+		return values[0].Value
+	}
+	return ""
+}
+
+// scimGroup returns the SCIM representation of a group; members missing
+// from users have no display name.
+func scimGroup(group *models.SCIMGroup, users map[primitive.ObjectID]*models.User) *scim.Group {
+	resource := &scim.Group{
+		Schemas:     []string{scim.SchemaGroup},
+		ID:          group.ID.Hex(),
+		ExternalID:  group.ExternalID,
+		DisplayName: group.DisplayName,
+		Meta: &scim.Meta{
+			ResourceType: "Group",
+			Created:      group.CreatedAt,
+			LastModified: group.UpdatedAt,
+		},
+	}
+	for _, member := range group.Members {
+		value := scim.MultiValued{Value: member.Hex(), Type: "User"}
+		if user, ok := users[member]; ok {
+			value.Display = user.Email
+		}
+		resource.Members = append(resource.Members, value)
+	}
+	return resource
+}
+
+// parseSCIMFilter parses a query filter; an empty filter matches everything
+// and parses to nil.
+func parseSCIMFilter(expression string) (*scim.Filter, error) {
+	if strings.TrimSpace(expression) == "" {
+		return nil, nil
+	}
+	return scim.ParseFilter(expression)
+}
+
+// scimMatches reports whether a resource matches a filter.
+func scimMatches(filter *scim.Filter, resource interface{}) (bool, error) {
+	if filter == nil {
+		return true, nil
+	}
+	encoded, err := scim.ToResource(resource)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode resource: %w", err)
+	}
+	return filter.Matches(encoded), nil
+}
+
+// scimPage returns the page of matches the query selects. Counts are capped
+// at scimMaxResults and negative counts return no resources.
+func scimPage(matches []interface{}, query *SCIMQuery) *scim.ListResponse {
+	startIndex := max(query.StartIndex, 1)
+	count := scimMaxResults
+	if query.Count != nil {
+		count = min(max(*query.Count, 0), scimMaxResults)
+	}
+	from := min(startIndex-1, len(matches))
+	to := min(from+count, len(matches))
+	return scim.NewListResponse(matches[from:to], len(matches), startIndex)
+}
@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/scim"
+)
+
+type fakeSCIMGroupRepository struct {
+	mu     sync.Mutex
+	groups map[string]*models.SCIMGroup
+}
+
+func newFakeSCIMGroupRepository() *fakeSCIMGroupRepository {
+	return &fakeSCIMGroupRepository{groups: make(map[string]*models.SCIMGroup)}
+}
+
+func (r *fakeSCIMGroupRepository) nameTaken(group *models.SCIMGroup) bool {
+	for _, existing := range r.groups {
+		if existing.ID != group.ID && existing.OrganizationID == group.OrganizationID &&
+			strings.EqualFold(existing.DisplayName, group.DisplayName) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *fakeSCIMGroupRepository) Create(ctx context.Context, group *models.SCIMGroup) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.nameTaken(group) {
+		return repositories.ErrDuplicate
+	}
+	group.ID = primitive.NewObjectID()
+	r.groups[group.ID.Hex()] = group
+	return nil
+}
+
+func (r *fakeSCIMGroupRepository) GetByID(ctx context.Context, id string) (*models.SCIMGroup, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	group, ok := r.groups[id]
+	if !ok {
+		return nil, repositories.ErrNotFound
+	}
+	return group, nil
+}
+
+func (r *fakeSCIMGroupRepository) Update(ctx context.Context, group *models.SCIMGroup) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.groups[group.ID.Hex()]; !ok {
+		return repositories.ErrNotFound
+	}
+	if r.nameTaken(group) {
+		return repositories.ErrDuplicate
+	}
+	r.groups[group.ID.Hex()] = group
+	return nil
+}
+
+func (r *fakeSCIMGroupRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.groups, id)
+	return nil
+}
+
+func (r *fakeSCIMGroupRepository) GetByOrganization(ctx context.Context, orgID string) ([]*models.SCIMGroup, error) {
+	return r.GetByMember(ctx, orgID, "")
+}
+
+// GetByMember returns the organization's groups with the member, or all of
+// them when userID is empty.
+func (r *fakeSCIMGroupRepository) GetByMember(ctx context.Context, orgID, userID string) ([]*models.SCIMGroup, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var groups []*models.SCIMGroup
+	for _, group := range r.groups {
+		if group.OrganizationID.Hex() != orgID {
+			continue
+		}
+		if userID == "" || slices.ContainsFunc(group.Members, func(id primitive.ObjectID) bool { return id.Hex() == userID }) {
+			groups = append(groups, group)
+		}
+	}
+	return groups, nil
+}
+
+type scimFixture struct {
+	org     *models.Organization
+	users   *fakeUserRepository
+	groups  *fakeSCIMGroupRepository
+	auth    *fakeAuthenticationService
+	service SCIMService
+}
+
+func newSCIMFixture(t *testing.T) *scimFixture {
+	t.Helper()
+	org := &models.Organization{Name: "First Bank", Status: models.OrganizationStatusActive, IsActive: true}
+	org.ID = primitive.NewObjectID()
+	org.Settings.Integrations.SCIM = &models.SCIMSettings{
+		Enabled: true,
+		RoleMappings: []models.GroupRoleMapping{
+			{Group: "GRC-Admins", Role: models.RoleAdmin},
+			{Group: "GRC-Auditors", Role: models.RoleAuditor},
+		},
+	}
+
+	f := &scimFixture{
+		org:    org,
+		users:  newFakeUserRepository(),
+		groups: newFakeSCIMGroupRepository(),
+		auth:   &fakeAuthenticationService{},
+	}
+	f.service = NewSCIMService(newFakeOrganizationRepository(org), f.users, f.groups, f.auth, zap.NewNop())
+	return f
+}
+
+func (f *scimFixture) createUser(t *testing.T, userName string) *scim.User {
+	t.Helper()
+	user, err := f.service.CreateUser(context.Background(), f.org.ID.Hex(), &scim.User{
+		UserName: userName,
+		Name:     &scim.Name{GivenName: "Ada", FamilyName: "Auditor"},
+	})
+	require.NoError(t, err)
+	return user
+}
+
+func patchRequest(t *testing.T, operations string) *scim.PatchRequest {
+	t.Helper()
+	var patch scim.PatchRequest
+	require.NoError(t, json.Unmarshal([]byte(`{"Operations": `+operations+`}`), &patch))
+	return &patch
+}
+
+func TestSCIMService_Users(t *testing.T) {
+	f := newSCIMFixture(t)
+	ctx := context.Background()
+	orgID := f.org.ID.Hex()
+
+	active := scim.Boolean(true)
+	created, err := f.service.CreateUser(ctx, orgID, &scim.User{
+		UserName:     "Ada.Auditor@First-Bank.com",
+		ExternalID:   "idp-17",
+		Name:         &scim.Name{GivenName: "Ada", FamilyName: "Auditor"},
+		Title:        "Auditor",
+		Active:       &active,
+		PhoneNumbers: []scim.MultiValued{{Value: "+420 555 0100", Type: "mobile"}},
+		Enterprise:   &scim.EnterpriseUser{Department: "Internal Audit", EmployeeNumber: "1017"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ada.auditor@first-bank.com", created.UserName)
+	assert.True(t, bool(*created.Active))
+
+	stored := f.users.users[created.ID]
+	require.NotNil(t, stored)
+	assert.Equal(t, []string{models.RoleViewer}, stored.Roles)
+	assert.Equal(t, models.UserStatusActive, stored.Status)
+	assert.Equal(t, "Internal Audit", stored.Profile.Department)
+	assert.Equal(t, "+420 555 0100", stored.Profile.PhoneNumber)
+	assert.Equal(t, "idp-17", stored.Metadata.ExternalID)
+
+	_, err = f.service.CreateUser(ctx, orgID, &scim.User{UserName: "ada.auditor@first-bank.com"})
+	assert.ErrorIs(t, err, ErrSCIMUserExists)
+
+	_, err = f.service.CreateUser(ctx, orgID, &scim.User{UserName: "ada"})
+	var scimErr *scim.Error
+	require.True(t, errors.As(err, &scimErr))
+	assert.Equal(t, scim.ErrorTypeInvalidValue, scimErr.Type)
+
+	f.createUser(t, "bob@first-bank.com")
+	list, err := f.service.ListUsers(ctx, orgID, &SCIMQuery{Filter: `userName eq "ADA.AUDITOR@first-bank.com"`})
+	require.NoError(t, err)
+	assert.Equal(t, 1, list.TotalResults)
+	require.Len(t, list.Resources, 1)
+	assert.Equal(t, created.ID, list.Resources[0].(*scim.User).ID)
+
+	one := 1
+	list, err = f.service.ListUsers(ctx, orgID, &SCIMQuery{StartIndex: 2, Count: &one})
+	require.NoError(t, err)
+	assert.Equal(t, 2, list.TotalResults)
+	assert.Equal(t, 2, list.StartIndex)
+	assert.Equal(t, 1, list.ItemsPerPage)
+
+	_, err = f.service.ListUsers(ctx, orgID, &SCIMQuery{Filter: `userName eq`})
+	require.True(t, errors.As(err, &scimErr))
+	assert.Equal(t, scim.ErrorTypeInvalidFilter, scimErr.Type)
+
+	patched, err := f.service.PatchUser(ctx, orgID, created.ID, patchRequest(t, `[
+		{"op": "Replace", "path": "title", "value": "Lead Auditor"},
+		{"op": "Replace", "path": "active", "value": "False"}
+	]`))
+	require.NoError(t, err)
+	assert.Equal(t, "Lead Auditor", patched.Title)
+	assert.False(t, bool(*patched.Active))
+	assert.Equal(t, "Internal Audit", patched.Enterprise.Department)
+	assert.False(t, stored.IsActive)
+	assert.Equal(t, models.UserStatusInactive, stored.Status)
+	assert.Equal(t, []string{created.ID}, f.auth.terminated)
+
+	replaced, err := f.service.ReplaceUser(ctx, orgID, created.ID, &scim.User{
+		UserName: "ada.auditor@first-bank.com",
+		Name:     &scim.Name{Formatted: "Ada Lovelace"},
+		Active:   &active,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Lovelace", replaced.Name.FamilyName)
+	assert.Empty(t, replaced.Title)
+	assert.Nil(t, replaced.Enterprise)
+	assert.True(t, stored.IsActive)
+	assert.Len(t, f.auth.terminated, 1)
+
+	_, err = f.service.ReplaceUser(ctx, orgID, created.ID, &scim.User{UserName: "bob@first-bank.com"})
+	assert.ErrorIs(t, err, ErrSCIMUserExists)
+
+	require.NoError(t, f.service.DeleteUser(ctx, orgID, created.ID))
+	assert.Equal(t, []string{created.ID, created.ID}, f.auth.terminated)
+	_, err = f.service.GetUser(ctx, orgID, created.ID)
+	assert.ErrorIs(t, err, ErrSCIMUserNotFound)
+}
+
+func TestSCIMService_GroupsMapToRoles(t *testing.T) {
+	f := newSCIMFixture(t)
+	ctx := context.Background()
+	orgID := f.org.ID.Hex()
+	ada := f.createUser(t, "ada@first-bank.com")
+	bob := f.createUser(t, "bob@first-bank.com")
+	roles := func(user *scim.User) []string { return f.users.users[user.ID].Roles }
+
+	group, err := f.service.CreateGroup(ctx, orgID, &scim.Group{
+		DisplayName: "grc-auditors",
+		Members:     []scim.MultiValued{{Value: ada.ID}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ada@first-bank.com", group.Members[0].Display)
+	assert.Equal(t, []string{models.RoleAuditor}, roles(ada))
+
+	_, err = f.service.CreateGroup(ctx, orgID, &scim.Group{DisplayName: "GRC-Auditors"})
+	assert.ErrorIs(t, err, ErrSCIMGroupExists)
+
+	_, err = f.service.PatchGroup(ctx, orgID, group.ID, patchRequest(t,
+		`[{"op": "add", "path": "members", "value": [{"value": "`+bob.ID+`"}]}]`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{models.RoleAuditor}, roles(bob))
+
+	user, err := f.service.GetUser(ctx, orgID, bob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []scim.MultiValued{{Value: group.ID, Display: "grc-auditors"}}, user.Groups)
+
+	_, err = f.service.PatchGroup(ctx, orgID, group.ID, patchRequest(t,
+		`[{"op": "remove", "path": "members[value eq \"`+ada.ID+`\"]"}]`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{models.RoleViewer}, roles(ada))
+
+	_, err = f.service.ReplaceGroup(ctx, orgID, group.ID, &scim.Group{
+		DisplayName: "GRC-Admins",
+		Members:     []scim.MultiValued{{Value: bob.ID}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{models.RoleAdmin}, roles(bob))
+
+	list, err := f.service.ListGroups(ctx, orgID, &SCIMQuery{Filter: `displayName eq "grc-admins"`})
+	require.NoError(t, err)
+	assert.Equal(t, 1, list.TotalResults)
+
+	_, err = f.service.PatchGroup(ctx, orgID, group.ID, patchRequest(t,
+		`[{"op": "add", "path": "members", "value": [{"value": "`+primitive.NewObjectID().Hex()+`"}]}]`))
+	var scimErr *scim.Error
+	require.True(t, errors.As(err, &scimErr))
+	assert.Equal(t, scim.ErrorTypeInvalidValue, scimErr.Type)
+
+	require.NoError(t, f.service.DeleteGroup(ctx, orgID, group.ID))
+	assert.Equal(t, []string{models.RoleViewer}, roles(bob))
+	_, err = f.service.GetGroup(ctx, orgID, group.ID)
+	assert.ErrorIs(t, err, ErrSCIMGroupNotFound)
+}
+
+func TestSCIMService_Isolation(t *testing.T) {
+	f := newSCIMFixture(t)
+	ctx := context.Background()
+	ada := f.createUser(t, "ada@first-bank.com")
+
+	other := newSCIMFixture(t)
+	other.users = f.users
+	other.service = NewSCIMService(newFakeOrganizationRepository(other.org), f.users, f.groups, other.auth, zap.NewNop())
+
+	_, err := other.service.GetUser(ctx, other.org.ID.Hex(), ada.ID)
+	assert.ErrorIs(t, err, ErrSCIMUserNotFound)
+	_, err = other.service.CreateGroup(ctx, other.org.ID.Hex(), &scim.Group{
+		DisplayName: "grc-auditors",
+		Members:     []scim.MultiValued{{Value: ada.ID}},
+	})
+	var scimErr *scim.Error
+	assert.True(t, errors.As(err, &scimErr))
+
+	f.org.Settings.Integrations.SCIM.Enabled = false
+	_, err = f.service.GetUser(ctx, f.org.ID.Hex(), ada.ID)
+	assert.ErrorIs(t, err, ErrSCIMDisabled)
+}
@@ -0,0 +1,293 @@
+// Package services provides service layer implementations for the GoEdu Control Testing Platform.
+// This file contains the session store shared by sign-in and request
+// authentication. Sessions end after the organization's idle timeout without
+// requests or its absolute timeout after sign-in, and an organization may cap
+// the sessions a user has at once. Ended sessions are put on a Redis denylist
+// that is checked on every request, so a revocation takes effect on all
+// server instances immediately; activity is written to the session store at
+// most once per activity interval.
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/cache"
+)
+
+// Session errors
+var (
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrSessionExpired     = errors.New("session has expired")
+	ErrSessionRevoked     = errors.New("session has been revoked")
+	ErrInvalidAccessToken = errors.New("invalid or expired access token")
+)
+
+const (
+	// sessionRevokedKeyPrefix prefixes the denylist entries of ended sessions
+	sessionRevokedKeyPrefix = "goedu:session:revoked:"
+
+	// maxRecordedSessions bounds the sessions whose last activity write is
+	// remembered before old entries are swept
+	maxRecordedSessions = 10000
+)
+
+// sessionManager stores sessions and enforces their timeouts, the
+// organization's cap on concurrent sessions and revocations.
+type sessionManager struct {
+	orgRepo     repositories.OrganizationRepository
+	userRepo    repositories.UserRepository
+	sessionRepo repositories.SessionRepository
+	denylist    cache.Denylist
+	config      config.SessionConfig
+	logger      *zap.Logger
+
+	// recorded holds when activity was last written per session ID
+	mu       sync.Mutex
+	recorded map[string]time.Time
+}
+
+// newSessionManager creates a session manager.
+func newSessionManager(
+	orgRepo repositories.OrganizationRepository,
+	userRepo repositories.UserRepository,
+	sessionRepo repositories.SessionRepository,
+	denylist cache.Denylist,
+	cfg config.SessionConfig,
+	logger *zap.Logger,
+) *sessionManager {
+	return &sessionManager{
+		orgRepo:     orgRepo,
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		denylist:    denylist,
+		config:      cfg,
+		logger:      logger,
+		recorded:    make(map[string]time.Time),
+	}
+}
+
+// sessionLimits returns an organization's idle and absolute session timeouts
+// and its cap on concurrent sessions, zero meaning no cap.
+func (m *sessionManager) sessionLimits(org *models.Organization) (idle, absolute time.Duration, maxSessions int) {
+	idle, absolute = m.config.IdleTimeout, m.config.AbsoluteTimeout
+	if minutes := org.Settings.SessionTimeoutMinutes; minutes > 0 {
+		idle = time.Duration(minutes) * time.Minute
+	}
+	if minutes := org.Settings.AbsoluteSessionTimeoutMinutes; minutes > 0 {
+		absolute = time.Duration(minutes) * time.Minute
+	}
+	return idle, absolute, org.Settings.MaxConcurrentSessions
+}
+
+// organization loads the organization whose settings apply to a session.
+func (m *sessionManager) organization(ctx context.Context, orgID string) (*models.Organization, error) {
+	org, err := m.orgRepo.GetByID(ctx, orgID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	return org, nil
+}
+
+// start stores a new session, which expires after the organization's
+// absolute timeout. When the user already has as many sessions as the
+// organization allows, the least recently active ones end to make room.
+func (m *sessionManager) start(ctx context.Context, session *models.Session) error {
+	org, err := m.organization(ctx, session.OrganizationID.Hex())
+	if err != nil {
+		return err
+	}
+	_, absolute, maxSessions := m.sessionLimits(org)
+	now := session.CreatedAt
+	session.ExpiresAt = now.Add(absolute)
+
+	userID := session.UserID.Hex()
+	if maxSessions > 0 {
+		active, err := m.sessionRepo.GetActiveByUser(ctx, userID, now)
+		if err != nil {
+			return fmt.Errorf("failed to get active sessions: %w", err)
+		}
+		for i := maxSessions - 1; i < len(active); i++ {
+			m.end(ctx, active[i], models.SessionEndSessionLimit, now)
+		}
+	}
+	if err := m.sessionRepo.Create(ctx, session); err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	m.recount(ctx, userID, now)
+	return nil
+}
+
+// validate checks that the session of an access token is still in force.
+// The denylist is checked on every request; activity is written, and the
+// idle and absolute timeouts checked, once per activity interval, or on
+// every request while the denylist is unavailable.
+func (m *sessionManager) validate(ctx context.Context, claims *models.JWTClaims) error {
+	sessionID := claims.SessionID
+	denied, err := m.denylist.IsDenied(ctx, sessionRevokedKeyPrefix+sessionID)
+	if err != nil {
+		m.logger.Warn("Session denylist unavailable, checking the session store", zap.Error(err))
+	}
+	if denied {
+		return ErrSessionRevoked
+	}
+
+	now := time.Now().UTC()
+	m.mu.Lock()
+	recordedAt, recent := m.recorded[sessionID]
+	m.mu.Unlock()
+	if err == nil && recent && now.Sub(recordedAt) < m.config.ActivityInterval {
+		return nil
+	}
+	return m.recordActivity(ctx, sessionID, claims.OrganizationID, now)
+}
+
+// recordActivity writes a session's activity, ending it when it has been
+// idle for longer than the organization's idle timeout or has expired.
+func (m *sessionManager) recordActivity(ctx context.Context, sessionID, orgID string, now time.Time) error {
+	org, err := m.organization(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	idle, _, _ := m.sessionLimits(org)
+	err = m.sessionRepo.RecordActivity(ctx, sessionID, now, now.Add(-idle))
+	if err == nil {
+		m.remember(sessionID, now)
+		return nil
+	}
+	if !errors.Is(err, repositories.ErrNotFound) {
+		return fmt.Errorf("failed to record session activity: %w", err)
+	}
+
+	m.forget(sessionID)
+	session, err := m.sessionRepo.GetBySessionID(ctx, sessionID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return ErrSessionRevoked
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+	if session.IsActive {
+		reason := models.SessionEndIdleTimeout
+		if !now.Before(session.ExpiresAt) {
+			reason = models.SessionEndExpired
+		}
+		m.end(ctx, session, reason, now)
+		m.recount(ctx, session.UserID.Hex(), now)
+		return ErrSessionExpired
+	}
+
+	// The session ended but its denylist entry is missing
+	m.deny(ctx, session, now)
+	if session.EndReason == models.SessionEndIdleTimeout || session.EndReason == models.SessionEndExpired {
+		return ErrSessionExpired
+	}
+	return ErrSessionRevoked
+}
+
+// end ends a session with the given reason and puts it on the denylist.
+// Failures are logged: a session that could not be ended is still checked
+// against the denylist, and one missing from the denylist is caught at its
+// next activity write.
+func (m *sessionManager) end(ctx context.Context, session *models.Session, reason string, now time.Time) {
+	if err := m.sessionRepo.Deactivate(ctx, session.SessionID, reason, now); err != nil && !errors.Is(err, repositories.ErrNotFound) {
+		m.logger.Error("Failed to end session", zap.Error(err), zap.String("session_id", session.SessionID))
+	}
+	session.IsActive = false
+	session.EndedAt = now
+	session.EndReason = reason
+	m.deny(ctx, session, now)
+	m.logger.Info("Session ended",
+		zap.String("user_id", session.UserID.Hex()),
+		zap.String("session_id", session.SessionID),
+		zap.String("reason", reason),
+	)
+}
+
+// endAll ends every active session of a user except exceptSessionID and
+// returns how many were ended.
+func (m *sessionManager) endAll(ctx context.Context, userID, exceptSessionID, reason string) (int64, error) {
+	now := time.Now().UTC()
+	active, err := m.sessionRepo.GetActiveByUser(ctx, userID, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get active sessions: %w", err)
+	}
+
+	var ended int64
+	if exceptSessionID == "" {
+		// Also ends sessions that are active but already expired
+		ended, err = m.sessionRepo.DeactivateByUser(ctx, userID, reason, now)
+		if err != nil {
+			return 0, fmt.Errorf("failed to end sessions: %w", err)
+		}
+		for _, session := range active {
+			m.deny(ctx, session, now)
+		}
+	} else {
+		for _, session := range active {
+			if session.SessionID != exceptSessionID {
+				m.end(ctx, session, reason, now)
+				ended++
+			}
+		}
+	}
+	m.recount(ctx, userID, now)
+	return ended, nil
+}
+
+// deny puts a session on the denylist for as long as access tokens issued
+// for it can be valid.
+func (m *sessionManager) deny(ctx context.Context, session *models.Session, now time.Time) {
+	m.forget(session.SessionID)
+	ttl := session.ExpiresAt.Sub(now) + models.AccessTokenDuration
+	if ttl < models.AccessTokenDuration {
+		ttl = models.AccessTokenDuration
+	}
+	if err := m.denylist.Deny(ctx, sessionRevokedKeyPrefix+session.SessionID, ttl); err != nil {
+		m.logger.Warn("Failed to add session to the denylist", zap.Error(err), zap.String("session_id", session.SessionID))
+	}
+}
+
+// recount records the number of active sessions of a user.
+func (m *sessionManager) recount(ctx context.Context, userID string, now time.Time) {
+	active, err := m.sessionRepo.GetActiveByUser(ctx, userID, now)
+	if err == nil {
+		err = m.userRepo.UpdateSessionCount(ctx, userID, len(active))
+	}
+	if err != nil {
+		m.logger.Warn("Failed to update session count", zap.Error(err), zap.String("user_id", userID))
+	}
+}
+
+// remember notes that a session's activity was written at the given time.
+func (m *sessionManager) remember(sessionID string, at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.recorded) >= maxRecordedSessions {
+		for id, recordedAt := range m.recorded {
+			if at.Sub(recordedAt) >= m.config.ActivityInterval {
+				delete(m.recorded, id)
+			}
+		}
+	}
+	m.recorded[sessionID] = at
+}
+
+// forget drops a session's last activity write, so that its next request
+// checks the session store.
+func (m *sessionManager) forget(sessionID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.recorded, sessionID)
+}
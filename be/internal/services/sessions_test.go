@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/auth"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/cache"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/cache/cachetest"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/password"
+)
+
+// testSessionConfig ends sessions after 30 idle minutes or a day.
+var testSessionConfig = config.SessionConfig{IdleTimeout: 30 * time.Minute, AbsoluteTimeout: 24 * time.Hour, ActivityInterval: time.Minute}
+
+var testJWTManager = auth.NewJWTManager([]byte("test-secret"), "goedu-platform", "goedu-api")
+
+type sessionFixture struct {
+	*userFixture
+	user     *models.User
+	sessions *fakeSessionRepository
+	denylist *cachetest.Denylist
+	service  *authenticationService
+}
+
+func newSessionFixture(t *testing.T) *sessionFixture {
+	t.Helper()
+	f := &sessionFixture{
+		userFixture: newUserFixture(t),
+		sessions:    &fakeSessionRepository{},
+		denylist:    cachetest.NewDenylist(),
+	}
+	f.user = f.createUser(t, "ada@first-bank.com")
+	f.newService()
+	return f
+}
+
+// newService rebuilds the service, which forgets when activity was last written.
+func (f *sessionFixture) newService() {
+	f.service = NewAuthenticationService(f.orgs, f.users, f.sessions, f.denylist, &fakePasswordResetRepository{}, f.notifications,
+		cache.NewLocalRateLimits(), testJWTManager, auth.NewPasswordHasher(4), password.NewChecker(password.DefaultDictionary(), nil),
+		testPasswordConfig, testSessionConfig, zap.NewNop()).(*authenticationService)
+}
+
+// signIn starts a session of the fixture user last active at lastActivity
+// and returns an access token for it.
+func (f *sessionFixture) signIn(t *testing.T, sessionID string, lastActivity time.Time) string {
+	t.Helper()
+	now := time.Now().UTC()
+	session := &models.Session{
+		SessionID:      sessionID,
+		UserID:         f.user.ID,
+		OrganizationID: f.org.ID,
+		LastActivity:   lastActivity,
+		IsActive:       true,
+		LoginMethod:    "password",
+	}
+	session.ID = primitive.NewObjectID()
+	session.CreatedAt = now
+	require.NoError(t, f.service.sessions.start(context.Background(), session))
+
+	token, _, err := testJWTManager.GenerateAccessToken(&models.UserProfileResponse{
+		ID:             f.user.ID,
+		Email:          f.user.Email,
+		OrganizationID: f.org.ID,
+		Role:           models.RoleAuditor,
+	}, sessionID, "203.0.113.7")
+	require.NoError(t, err)
+	return token
+}
+
+func TestAuthenticationService_SessionLimit(t *testing.T) {
+	f := newSessionFixture(t)
+	ctx := context.Background()
+	f.org.Settings.MaxConcurrentSessions = 2
+	now := time.Now().UTC()
+
+	laptop := f.signIn(t, "laptop", now.Add(-2*time.Minute))
+	f.signIn(t, "phone", now.Add(-time.Minute))
+	assert.Equal(t, 2, f.user.Authentication.CurrentSessionCount)
+	assert.WithinDuration(t, now.Add(testSessionConfig.AbsoluteTimeout), f.sessions.sessions[0].ExpiresAt, time.Minute)
+
+	tablet := f.signIn(t, "tablet", now)
+	assert.Equal(t, 2, f.user.Authentication.CurrentSessionCount)
+	assert.False(t, f.sessions.sessions[0].IsActive, "the least recently active session ends")
+	assert.Equal(t, models.SessionEndSessionLimit, f.sessions.sessions[0].EndReason)
+
+	_, err := f.service.ValidateAccessToken(ctx, laptop)
+	assert.ErrorIs(t, err, ErrSessionRevoked)
+	claims, err := f.service.ValidateAccessToken(ctx, tablet)
+	require.NoError(t, err)
+	assert.Equal(t, "tablet", claims.SessionID)
+}
+
+func TestAuthenticationService_SessionTimeouts(t *testing.T) {
+	f := newSessionFixture(t)
+	ctx := context.Background()
+	f.org.Settings.SessionTimeoutMinutes = 15
+	f.org.Settings.AbsoluteSessionTimeoutMinutes = 60
+	now := time.Now().UTC()
+
+	token := f.signIn(t, "laptop", now)
+	session := f.sessions.sessions[0]
+	assert.WithinDuration(t, now.Add(time.Hour), session.ExpiresAt, time.Minute, "the organization's absolute timeout applies")
+	_, err := f.service.ValidateAccessToken(ctx, token)
+	require.NoError(t, err)
+
+	// Activity is checked once per activity interval
+	session.LastActivity = now.Add(-20 * time.Minute)
+	_, err = f.service.ValidateAccessToken(ctx, token)
+	require.NoError(t, err)
+	f.newService()
+	_, err = f.service.ValidateAccessToken(ctx, token)
+	assert.ErrorIs(t, err, ErrSessionExpired)
+	assert.False(t, session.IsActive)
+	assert.Equal(t, models.SessionEndIdleTimeout, session.EndReason)
+	_, err = f.service.ValidateAccessToken(ctx, token)
+	assert.ErrorIs(t, err, ErrSessionRevoked, "ended sessions are on the denylist")
+
+	token = f.signIn(t, "phone", now)
+	f.sessions.sessions[1].ExpiresAt = now.Add(-time.Second)
+	_, err = f.service.ValidateAccessToken(ctx, token)
+	assert.ErrorIs(t, err, ErrSessionExpired)
+	assert.Equal(t, models.SessionEndExpired, f.sessions.sessions[1].EndReason)
+	assert.Zero(t, f.user.Authentication.CurrentSessionCount)
+
+	_, err = f.service.ValidateAccessToken(ctx, "not-a-token")
+	assert.ErrorIs(t, err, ErrInvalidAccessToken)
+	refresh, _, err := testJWTManager.GenerateRefreshToken(f.user.ID.Hex(), "phone")
+	require.NoError(t, err)
+	_, err = f.service.ValidateAccessToken(ctx, refresh)
+	assert.ErrorIs(t, err, ErrInvalidAccessToken, "refresh tokens do not authenticate requests")
+}
+
+func TestAuthenticationService_RevokeSessions(t *testing.T) {
+	f := newSessionFixture(t)
+	ctx := context.Background()
+	orgID, userID := f.org.ID.Hex(), f.user.ID.Hex()
+	now := time.Now().UTC()
+
+	laptop := f.signIn(t, "laptop", now)
+	phone := f.signIn(t, "phone", now.Add(-time.Minute))
+	tablet := f.signIn(t, "tablet", now.Add(-2*time.Minute))
+	for _, token := range []string{tablet, phone, laptop} {
+		_, err := f.service.ValidateAccessToken(ctx, token)
+		require.NoError(t, err)
+	}
+
+	sessions, err := f.service.ListSessions(ctx, orgID, userID, "laptop")
+	require.NoError(t, err)
+	require.Len(t, sessions, 3)
+	assert.Equal(t, "laptop", sessions[0].SessionID)
+	assert.True(t, sessions[0].Current)
+	assert.False(t, sessions[1].Current)
+
+	require.NoError(t, f.service.RevokeSession(ctx, orgID, userID, "phone"))
+	_, err = f.service.ValidateAccessToken(ctx, phone)
+	assert.ErrorIs(t, err, ErrSessionRevoked, "revocation takes effect on the next request")
+	assert.ErrorIs(t, f.service.RevokeSession(ctx, orgID, userID, "phone"), ErrSessionNotFound)
+	assert.ErrorIs(t, f.service.RevokeSession(ctx, orgID, f.admin.ID.Hex(), "laptop"), ErrSessionNotFound,
+		"sessions of other users cannot be revoked")
+	assert.ErrorIs(t, f.service.RevokeSession(ctx, primitive.NewObjectID().Hex(), userID, "laptop"), ErrUserNotFound)
+	_, err = f.service.ListSessions(ctx, primitive.NewObjectID().Hex(), userID, "")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	ended, err := f.service.RevokeSessions(ctx, orgID, userID, "laptop")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), ended)
+	assert.Equal(t, 1, f.user.Authentication.CurrentSessionCount)
+	_, err = f.service.ValidateAccessToken(ctx, laptop)
+	require.NoError(t, err)
+	_, err = f.service.ValidateAccessToken(ctx, tablet)
+	assert.ErrorIs(t, err, ErrSessionRevoked)
+}
+
+func TestAuthenticationService_RevokeSessionDenylistUnavailable(t *testing.T) {
+	f := newSessionFixture(t)
+	ctx := context.Background()
+	token := f.signIn(t, "laptop", time.Now().UTC())
+	_, err := f.service.ValidateAccessToken(ctx, token)
+	require.NoError(t, err)
+
+	f.denylist.SetUnavailable(true)
+	require.NoError(t, f.service.RevokeSession(ctx, f.org.ID.Hex(), f.user.ID.Hex(), "laptop"))
+	_, err = f.service.ValidateAccessToken(ctx, token)
+	assert.ErrorIs(t, err, ErrSessionRevoked, "the session store is checked while the denylist is unavailable")
+
+	f.denylist.SetUnavailable(false)
+	_, err = f.service.ValidateAccessToken(ctx, token)
+	assert.ErrorIs(t, err, ErrSessionRevoked)
+}
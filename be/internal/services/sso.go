@@ -0,0 +1,431 @@
+// Package services provides service layer implementations for the GoEdu Control Testing Platform.
+// This file contains the single sign-on service, which signs users in through
+// their organization's OpenID Connect provider with the authorization code
+// flow and PKCE, maps the provider's groups to roles and starts a session.
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/auth"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/cache"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/oidc"
+)
+
+// Single sign-on errors
+var (
+	ErrSSODisabled             = errors.New("single sign-on is not enabled for this organization")
+	ErrInvalidSSOState         = errors.New("single sign-on login is unknown or has expired")
+	ErrSSOAuthenticationFailed = errors.New("identity provider authentication failed")
+	ErrSSOProviderUnavailable  = errors.New("identity provider is unavailable")
+	ErrSSOAccessDenied         = errors.New("identity provider account may not sign in to this organization")
+)
+
+// ssoFeatureFlag is the plan feature that allows single sign-on.
+const ssoFeatureFlag = "sso_integration"
+
+// defaultGroupsClaim is the ID token claim read for group memberships.
+const defaultGroupsClaim = "groups"
+
+// ssoService implements the SSOService interface.
+type ssoService struct {
+	orgRepo   repositories.OrganizationRepository
+	stateRepo repositories.SSOLoginStateRepository
+	accounts  *ssoAccounts
+	login     *loginIssuer
+	oidc      *oidc.Client
+	auth      config.AuthConfig
+	config    config.SSOConfig
+	logger    *zap.Logger
+}
+
+// NewSSOService creates a new single sign-on service.
+//
+// Parameters:
+//   - orgRepo: Repository for organization data and SSO settings
+//   - userRepo: Repository for user accounts matched or created on sign-in
+//   - sessionRepo: Repository the sessions of signed-in users are stored in
+//   - denylist: Denylist of ended sessions, usually the Redis cache client
+//   - stateRepo: Repository for logins in progress
+//   - jwtManager: Issuer of the platform's access and refresh tokens
+//   - authCfg: Callback URL and platform-wide identity provider
+//   - cfg: Login timeout, provider request and caching settings
+//   - sessionCfg: Platform session timeouts
+//   - logger: Logger for service operations
+//
+// Returns:
+//   - SSOService: Configured SSO service instance
+func NewSSOService(
+	orgRepo repositories.OrganizationRepository,
+	userRepo repositories.UserRepository,
+	sessionRepo repositories.SessionRepository,
+	denylist cache.Denylist,
+	stateRepo repositories.SSOLoginStateRepository,
+	jwtManager *auth.JWTManager,
+	authCfg config.AuthConfig,
+	cfg config.SSOConfig,
+	sessionCfg config.SessionConfig,
+	logger *zap.Logger,
+) SSOService {
+	return &ssoService{
+		orgRepo:   orgRepo,
+		stateRepo: stateRepo,
+		accounts: &ssoAccounts{
+			userRepo: userRepo,
+			seats:    &memberSeats{orgRepo: orgRepo, logger: logger},
+			logger:   logger,
+		},
+		login: &loginIssuer{
+			userRepo:   userRepo,
+			sessions:   newSessionManager(orgRepo, userRepo, sessionRepo, denylist, sessionCfg, logger),
+			jwtManager: jwtManager,
+			logger:     logger,
+		},
+		oidc:   oidc.NewClient(&http.Client{Timeout: cfg.HTTPTimeout}, cfg.MetadataCacheTTL, cfg.ClockSkew),
+		auth:   authCfg,
+		config: cfg,
+		logger: logger,
+	}
+}
+
+// BeginLogin starts an OIDC login. It stores a fresh state, nonce and PKCE
+// verifier and returns the provider's authorization URL carrying the state,
+// nonce and code challenge.
+func (s *ssoService) BeginLogin(ctx context.Context, input *BeginSSOInput) (string, error) {
+	if !isLocalPath(input.ReturnTo) {
+		return "", fmt.Errorf("%w: return_to must be an application path", ErrInvalidInput)
+	}
+	org, err := s.orgRepo.GetBySlug(ctx, input.OrganizationSlug)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return "", ErrOrganizationNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get organization: %w", err)
+	}
+	cfg, _, err := s.providerConfig(org)
+	if err != nil {
+		return "", err
+	}
+	metadata, err := s.discover(ctx, org, cfg)
+	if err != nil {
+		return "", err
+	}
+
+	var values [3]string
+	for i := range values {
+		if values[i], err = oidc.GenerateVerifier(); err != nil {
+			return "", err
+		}
+	}
+	now := time.Now().UTC()
+	state := &models.SSOLoginState{
+		State:          values[0],
+		OrganizationID: org.ID,
+		Provider:       models.SSOProviderOIDC,
+		Nonce:          values[1],
+		CodeVerifier:   values[2],
+		ReturnTo:       input.ReturnTo,
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(s.config.StateTTL),
+	}
+	if err := s.stateRepo.Create(ctx, state); err != nil {
+		return "", fmt.Errorf("failed to store login state: %w", err)
+	}
+
+	return s.oidc.AuthCodeURL(cfg, metadata, state.State, state.Nonce, state.CodeVerifier), nil
+}
+
+// CompleteLogin consumes the login state, redeems the authorization code with
+// the stored PKCE verifier and validates the ID token. The user is matched by
+// email within the organization, or created when the organization allows it,
+// and gets the roles mapped from the provider's groups before a session with
+// login method SSO is started.
+func (s *ssoService) CompleteLogin(ctx context.Context, input *CompleteSSOInput) (*SSOLoginResult, error) {
+	if input.State == "" {
+		return nil, ErrInvalidSSOState
+	}
+	state, err := s.stateRepo.Consume(ctx, input.State, time.Now().UTC())
+	if errors.Is(err, repositories.ErrNotFound) || (err == nil && state.Provider != models.SSOProviderOIDC) {
+		return nil, ErrInvalidSSOState
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load login state: %w", err)
+	}
+
+	org, err := s.orgRepo.GetByID(ctx, state.OrganizationID.Hex())
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	cfg, settings, err := s.providerConfig(org)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Error != "" || input.Code == "" {
+		s.logger.Warn("Identity provider returned an error",
+			zap.String("organization_id", org.ID.Hex()),
+			zap.String("error", input.Error),
+			zap.String("error_description", input.ErrorDescription),
+		)
+		return nil, ErrSSOAuthenticationFailed
+	}
+	metadata, err := s.discover(ctx, org, cfg)
+	if err != nil {
+		return nil, err
+	}
+	token, err := s.oidc.Exchange(ctx, cfg, metadata, input.Code, state.CodeVerifier)
+	if err != nil {
+		s.logger.Warn("Authorization code exchange failed", zap.Error(err), zap.String("organization_id", org.ID.Hex()))
+		return nil, ErrSSOAuthenticationFailed
+	}
+	idToken, err := s.oidc.VerifyIDToken(ctx, cfg, metadata, token.IDToken, state.Nonce)
+	if err != nil {
+		s.logger.Warn("ID token rejected", zap.Error(err), zap.String("organization_id", org.ID.Hex()))
+		return nil, ErrSSOAuthenticationFailed
+	}
+
+	user, err := s.accounts.resolve(ctx, org, ssoAccountPolicy{
+		RoleMappings:  settings.RoleMappings,
+		DefaultRole:   settings.DefaultRole,
+		AutoProvision: settings.AutoProvision,
+	}, oidcIdentity(settings, idToken))
+	if err != nil {
+		return nil, err
+	}
+	response, err := s.login.issue(ctx, user, models.LoginMethodSSO, input.IPAddress, input.UserAgent)
+	if err != nil {
+		return nil, err
+	}
+	return &SSOLoginResult{LoginResponse: response, ReturnTo: state.ReturnTo}, nil
+}
+
+// ssoIdentity is a user as asserted by an identity provider.
+type ssoIdentity struct {
+	// Subject identifies the user at the provider as "<issuer>#<subject>"
+	Subject       string
+	Email         string
+	EmailVerified bool
+	// Profile holds the profile fields the provider sent
+	Profile models.UserProfile
+	Groups  []string
+}
+
+// ssoAccountPolicy is an organization's rules for identity provider users.
+type ssoAccountPolicy struct {
+	RoleMappings  []models.GroupRoleMapping
+	DefaultRole   string
+	AutoProvision bool
+}
+
+// ssoAccounts matches identity provider users to platform accounts. It is
+// shared by the OIDC and SAML logins.
+type ssoAccounts struct {
+	userRepo repositories.UserRepository
+	seats    *memberSeats
+	logger   *zap.Logger
+}
+
+// resolve finds or creates the account of the provider's user, applies the
+// roles mapped from the user's groups and copies the profile fields the
+// provider sent.
+func (a *ssoAccounts) resolve(ctx context.Context, org *models.Organization, policy ssoAccountPolicy, identity *ssoIdentity) (*models.User, error) {
+	deny := func(reason string) error {
+		a.logger.Warn("Single sign-on denied",
+			zap.String("organization_id", org.ID.Hex()),
+			zap.String("subject", identity.Subject),
+			zap.String("reason", reason),
+		)
+		return ErrSSOAccessDenied
+	}
+
+	email := strings.ToLower(strings.TrimSpace(identity.Email))
+	if email == "" {
+		return nil, deny("identity has no email")
+	}
+	if !identity.EmailVerified {
+		return nil, deny("email is not verified")
+	}
+
+	roles := models.MapGroupRoles(policy.RoleMappings, identity.Groups)
+	if len(roles) == 0 && policy.DefaultRole != "" {
+		roles = []string{policy.DefaultRole}
+	}
+	if len(roles) == 0 {
+		return nil, deny("no mapped group")
+	}
+
+	user, err := a.userRepo.GetByEmail(ctx, email)
+	if errors.Is(err, repositories.ErrNotFound) {
+		if !policy.AutoProvision {
+			return nil, deny("no account and provisioning is off")
+		}
+		return a.provision(ctx, org, identity, email, roles)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	switch {
+	case user.OrganizationID != org.ID:
+		return nil, deny("account belongs to another organization")
+	case !user.IsActive || user.Status != models.UserStatusActive:
+		return nil, deny("account is not active")
+	case user.Authentication.SSOSubject != "" && user.Authentication.SSOSubject != identity.Subject:
+		return nil, deny("account is linked to another identity")
+	}
+
+	profile := mergeProfile(user.Profile, identity.Profile)
+	if user.Authentication.SSOSubject == "" || !slices.Equal(user.Roles, roles) || profile != user.Profile {
+		user.Authentication.SSOSubject = identity.Subject
+		user.Roles = roles
+		user.Profile = profile
+		user.UpdatedAt = time.Now().UTC()
+		if err := a.userRepo.Update(ctx, user); err != nil {
+			return nil, fmt.Errorf("failed to update user: %w", err)
+		}
+	}
+	return user, nil
+}
+
+// provision creates the account of a provider user signing in for the first
+// time, taking one of the organization's member seats.
+func (a *ssoAccounts) provision(ctx context.Context, org *models.Organization, identity *ssoIdentity, email string, roles []string) (*models.User, error) {
+	if err := a.seats.take(ctx, org.ID.Hex()); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	user := &models.User{
+		Email:          email,
+		Profile:        identity.Profile,
+		Roles:          roles,
+		OrganizationID: org.ID,
+		IsActive:       true,
+		Status:         models.UserStatusActive,
+	}
+	user.Authentication.SSOSubject = identity.Subject
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	if err := a.userRepo.Create(ctx, user); err != nil {
+		a.seats.release(ctx, org.ID.Hex())
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	a.logger.Info("Provisioned user from identity provider",
+		zap.String("organization_id", org.ID.Hex()),
+		zap.String("user_id", user.ID.Hex()),
+	)
+	return user, nil
+}
+
+// mergeProfile returns the profile with the fields the provider sent.
+func mergeProfile(profile, asserted models.UserProfile) models.UserProfile {
+	for _, field := range []struct {
+		target *string
+		value  string
+	}{
+		{&profile.FirstName, asserted.FirstName},
+		{&profile.LastName, asserted.LastName},
+		{&profile.Title, asserted.Title},
+		{&profile.Department, asserted.Department},
+		{&profile.PhoneNumber, asserted.PhoneNumber},
+	} {
+		if field.value != "" {
+			*field.target = field.value
+		}
+	}
+	return profile
+}
+
+// oidcIdentity returns the user asserted by an ID token.
+func oidcIdentity(settings *models.OIDCSettings, idToken *oidc.IDToken) *ssoIdentity {
+	firstName, lastName := idToken.GivenName, idToken.FamilyName
+	if firstName == "" && lastName == "" {
+		firstName, lastName, _ = strings.Cut(strings.TrimSpace(idToken.Name), " ")
+	}
+	groupsClaim := settings.GroupsClaim
+	if groupsClaim == "" {
+		groupsClaim = defaultGroupsClaim
+	}
+	verified, ok := idToken.Claims["email_verified"].(bool)
+	return &ssoIdentity{
+		Subject:       idToken.Issuer + "#" + idToken.Subject,
+		Email:         idToken.Email,
+		EmailVerified: verified || !ok,
+		Profile:       models.UserProfile{FirstName: firstName, LastName: lastName},
+		Groups:        idToken.StringsClaim(groupsClaim),
+	}
+}
+
+// ssoAvailable reports whether an organization may sign in with the given
+// single sign-on provider: SSO must be in its plan and enabled with that provider.
+func ssoAvailable(org *models.Organization, provider string) bool {
+	integrations := org.Settings.Integrations
+	return org.IsActive && org.Status == models.OrganizationStatusActive && org.FeatureFlags[ssoFeatureFlag] &&
+		integrations.SSO && integrations.SSOProvider == provider
+}
+
+// providerConfig returns the OIDC client configuration of an organization. SSO
+// must be in the organization's plan, enabled and set to OIDC; organizations
+// without their own issuer use the platform-wide provider.
+func (s *ssoService) providerConfig(org *models.Organization) (oidc.Config, *models.OIDCSettings, error) {
+	settings := org.Settings.Integrations.OIDC
+	if !ssoAvailable(org, models.SSOProviderOIDC) || settings == nil {
+		return oidc.Config{}, nil, ErrSSODisabled
+	}
+
+	cfg := oidc.Config{
+		Issuer:       settings.Issuer,
+		ClientID:     settings.ClientID,
+		ClientSecret: settings.ClientSecret,
+		RedirectURL:  s.auth.OAuthRedirectURL,
+		Scopes:       settings.Scopes,
+	}
+	if cfg.Issuer == "" {
+		cfg.Issuer = s.auth.OAuthProvider
+		cfg.ClientID = s.auth.OAuthClientID
+		cfg.ClientSecret = s.auth.OAuthClientSecret
+	}
+	if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		s.logger.Warn("Incomplete OIDC configuration", zap.String("organization_id", org.ID.Hex()))
+		return oidc.Config{}, nil, ErrSSODisabled
+	}
+	return cfg, settings, nil
+}
+
+// discover fetches the provider's metadata.
+func (s *ssoService) discover(ctx context.Context, org *models.Organization, cfg oidc.Config) (*oidc.ProviderMetadata, error) {
+	metadata, err := s.oidc.Discover(ctx, cfg.Issuer)
+	if err != nil {
+		s.logger.Error("Identity provider discovery failed",
+			zap.Error(err),
+			zap.String("organization_id", org.ID.Hex()),
+			zap.String("issuer", cfg.Issuer),
+		)
+		return nil, ErrSSOProviderUnavailable
+	}
+	return metadata, nil
+}
+
+// isLocalPath reports whether a return path stays within the application. An
+// empty path is allowed.
+func isLocalPath(path string) bool {
+	if path == "" {
+		return true
+	}
+	return strings.HasPrefix(path, "/") && !strings.HasPrefix(path, "//") && !strings.ContainsAny(path, "\\\r\n")
+}
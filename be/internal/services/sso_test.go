@@ -0,0 +1,306 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/auth"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/cache/cachetest"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/oidc/oidctest"
+)
+
+type ssoFixture struct {
+	provider *oidctest.Provider
+	org      *models.Organization
+	users    *fakeUserRepository
+	sessions *fakeSessionRepository
+	states   *fakeSSOLoginStateRepository
+	jwt      *auth.JWTManager
+	authCfg  config.AuthConfig
+	service  SSOService
+}
+
+func newSSOFixture(t *testing.T) *ssoFixture {
+	t.Helper()
+
+	provider, err := oidctest.NewProvider("goedu", "client-secret")
+	require.NoError(t, err)
+	t.Cleanup(provider.Close)
+	provider.SetUser(map[string]interface{}{
+		"sub":         "idp-user-1",
+		"email":       "Auditor@First-Bank.com",
+		"given_name":  "Ada",
+		"family_name": "Auditor",
+		"groups":      []string{"All-Staff", "grc-auditors"},
+	})
+
+	org := &models.Organization{Name: "First Bank", Slug: "first-bank", Status: models.OrganizationStatusActive, IsActive: true}
+	org.ID = primitive.NewObjectID()
+	org.FeatureFlags = map[string]bool{"sso_integration": true}
+	org.Settings.Integrations.SSO = true
+	org.Settings.Integrations.SSOProvider = models.SSOProviderOIDC
+	org.Settings.Integrations.OIDC = &models.OIDCSettings{
+		Issuer:       provider.Issuer(),
+		ClientID:     "goedu",
+		ClientSecret: "client-secret",
+		RoleMappings: []models.GroupRoleMapping{
+			{Group: "GRC-Admins", Role: models.RoleAdmin},
+			{Group: "GRC-Auditors", Role: models.RoleAuditor},
+		},
+		AutoProvision: true,
+	}
+
+	f := &ssoFixture{
+		provider: provider,
+		org:      org,
+		users:    newFakeUserRepository(),
+		sessions: &fakeSessionRepository{},
+		states:   &fakeSSOLoginStateRepository{},
+		jwt:      auth.NewJWTManager([]byte("test-secret"), "goedu-platform", "goedu-api"),
+		authCfg:  config.AuthConfig{OAuthRedirectURL: "https://app.example.com/api/v1/auth/sso/oidc/callback"},
+	}
+	f.newService()
+	return f
+}
+
+// newService rebuilds the service, e.g. after changing authCfg.
+func (f *ssoFixture) newService() {
+	f.service = NewSSOService(newFakeOrganizationRepository(f.org), f.users, f.sessions, cachetest.NewDenylist(), f.states, f.jwt, f.authCfg, config.SSOConfig{
+		StateTTL:         10 * time.Minute,
+		HTTPTimeout:      5 * time.Second,
+		MetadataCacheTTL: time.Hour,
+		ClockSkew:        time.Minute,
+	}, testSessionConfig, zap.NewNop())
+}
+
+// signIn runs a login through the provider and returns the provider's
+// redirect back to the platform.
+func (f *ssoFixture) signIn(t *testing.T, returnTo string) *url.URL {
+	t.Helper()
+	authURL, err := f.service.BeginLogin(context.Background(), &BeginSSOInput{OrganizationSlug: "first-bank", ReturnTo: returnTo})
+	require.NoError(t, err)
+	redirect, err := f.provider.Login(authURL)
+	require.NoError(t, err)
+	return redirect
+}
+
+// complete finishes a login with the provider's redirect.
+func (f *ssoFixture) complete(redirect *url.URL) (*SSOLoginResult, error) {
+	query := redirect.Query()
+	return f.service.CompleteLogin(context.Background(), &CompleteSSOInput{
+		State:     query.Get("state"),
+		Code:      query.Get("code"),
+		Error:     query.Get("error"),
+		IPAddress: "10.0.0.1",
+		UserAgent: "test",
+	})
+}
+
+func TestSSOService_LoginProvisionsUser(t *testing.T) {
+	f := newSSOFixture(t)
+
+	authURL, err := f.service.BeginLogin(context.Background(), &BeginSSOInput{OrganizationSlug: "first-bank"})
+	require.NoError(t, err)
+	query, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, f.authCfg.OAuthRedirectURL, query.Query().Get("redirect_uri"))
+	assert.Equal(t, "S256", query.Query().Get("code_challenge_method"))
+	assert.NotEmpty(t, query.Query().Get("nonce"))
+
+	redirect := f.signIn(t, "/controls?tab=open")
+	result, err := f.complete(redirect)
+	require.NoError(t, err)
+	assert.Equal(t, "/controls?tab=open", result.ReturnTo)
+
+	// The account is created with the roles of the mapped groups
+	user, err := f.users.GetByEmail(context.Background(), "auditor@first-bank.com")
+	require.NoError(t, err)
+	assert.Equal(t, "auditor@first-bank.com", user.Email)
+	assert.Equal(t, f.org.ID, user.OrganizationID)
+	assert.Equal(t, []string{models.RoleAuditor}, user.Roles)
+	assert.Equal(t, "Ada", user.Profile.FirstName)
+	assert.Equal(t, f.provider.Issuer()+"#idp-user-1", user.Authentication.SSOSubject)
+
+	// A session is started and our own tokens are issued for it
+	require.Len(t, f.sessions.sessions, 1)
+	session := f.sessions.sessions[0]
+	assert.Equal(t, models.LoginMethodSSO, session.LoginMethod)
+	assert.Equal(t, result.SessionID, session.SessionID)
+	assert.Equal(t, user.ID, session.UserID)
+	refreshHash := sha256.Sum256([]byte(result.RefreshToken))
+	assert.Equal(t, hex.EncodeToString(refreshHash[:]), session.RefreshToken)
+
+	claims, err := f.jwt.ValidateToken(result.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID.Hex(), claims.UserID)
+	assert.Equal(t, result.SessionID, claims.SessionID)
+	assert.Equal(t, []string{models.RoleAuditor}, claims.Roles)
+
+	// The login state is single use
+	_, err = f.complete(redirect)
+	assert.ErrorIs(t, err, ErrInvalidSSOState)
+}
+
+func TestSSOService_LoginSyncsRoles(t *testing.T) {
+	f := newSSOFixture(t)
+	_, err := f.complete(f.signIn(t, ""))
+	require.NoError(t, err)
+
+	f.provider.SetUser(map[string]interface{}{
+		"sub":    "idp-user-1",
+		"email":  "auditor@first-bank.com",
+		"groups": []string{"GRC-Auditors", "GRC-Admins"},
+	})
+	result, err := f.complete(f.signIn(t, ""))
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin+","+models.RoleAuditor, result.User.Role)
+
+	user, err := f.users.GetByEmail(context.Background(), "auditor@first-bank.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{models.RoleAdmin, models.RoleAuditor}, user.Roles)
+}
+
+func TestSSOService_LoginDenied(t *testing.T) {
+	otherOrg := primitive.NewObjectID()
+
+	tests := []struct {
+		name   string
+		setup  func(f *ssoFixture)
+		claims map[string]interface{}
+	}{
+		{"no mapped group", nil, map[string]interface{}{"sub": "idp-user-1", "email": "auditor@first-bank.com", "groups": []string{"All-Staff"}}},
+		{"no email", nil, map[string]interface{}{"sub": "idp-user-1", "groups": []string{"GRC-Auditors"}}},
+		{"unverified email", nil, map[string]interface{}{"sub": "idp-user-1", "email": "auditor@first-bank.com", "email_verified": false, "groups": []string{"GRC-Auditors"}}},
+		{"provisioning off", func(f *ssoFixture) { f.org.Settings.Integrations.OIDC.AutoProvision = false }, nil},
+		{"account of another organization", func(f *ssoFixture) {
+			f.users.Create(context.Background(), &models.User{Email: "auditor@first-bank.com", OrganizationID: otherOrg, IsActive: true, Status: models.UserStatusActive})
+		}, nil},
+		{"inactive account", func(f *ssoFixture) {
+			f.users.Create(context.Background(), &models.User{Email: "auditor@first-bank.com", OrganizationID: f.org.ID, Status: models.UserStatusSuspended})
+		}, nil},
+		{"account linked to another identity", func(f *ssoFixture) {
+			user := &models.User{Email: "auditor@first-bank.com", OrganizationID: f.org.ID, IsActive: true, Status: models.UserStatusActive}
+			user.Authentication.SSOSubject = f.provider.Issuer() + "#someone-else"
+			f.users.Create(context.Background(), user)
+		}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newSSOFixture(t)
+			if tt.setup != nil {
+				tt.setup(f)
+			}
+			if tt.claims != nil {
+				f.provider.SetUser(tt.claims)
+			}
+			_, err := f.complete(f.signIn(t, ""))
+			assert.ErrorIs(t, err, ErrSSOAccessDenied)
+			assert.Empty(t, f.sessions.sessions)
+		})
+	}
+}
+
+func TestSSOService_DefaultRole(t *testing.T) {
+	f := newSSOFixture(t)
+	f.org.Settings.Integrations.OIDC.DefaultRole = models.RoleViewer
+	f.provider.SetUser(map[string]interface{}{"sub": "idp-user-2", "email": "teller@first-bank.com", "name": "Tom Teller"})
+
+	result, err := f.complete(f.signIn(t, ""))
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleViewer, result.User.Role)
+	assert.Equal(t, "Tom", result.User.FirstName)
+	assert.Equal(t, "Teller", result.User.LastName)
+}
+
+func TestSSOService_BeginLoginValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(f *ssoFixture, input *BeginSSOInput)
+		expectedErr error
+	}{
+		{"unknown organization", func(f *ssoFixture, input *BeginSSOInput) { input.OrganizationSlug = "nope" }, ErrOrganizationNotFound},
+		{"absolute return URL", func(f *ssoFixture, input *BeginSSOInput) { input.ReturnTo = "https://evil.example.com/" }, ErrInvalidInput},
+		{"protocol-relative return URL", func(f *ssoFixture, input *BeginSSOInput) { input.ReturnTo = "//evil.example.com/" }, ErrInvalidInput},
+		{"feature not in plan", func(f *ssoFixture, input *BeginSSOInput) { f.org.FeatureFlags["sso_integration"] = false }, ErrSSODisabled},
+		{"SSO switched off", func(f *ssoFixture, input *BeginSSOInput) { f.org.Settings.Integrations.SSO = false }, ErrSSODisabled},
+		{"other provider type", func(f *ssoFixture, input *BeginSSOInput) { f.org.Settings.Integrations.SSOProvider = "saml" }, ErrSSODisabled},
+		{"suspended organization", func(f *ssoFixture, input *BeginSSOInput) { f.org.Status = models.OrganizationStatusSuspended }, ErrSSODisabled},
+		{"unreachable provider", func(f *ssoFixture, input *BeginSSOInput) {
+			f.org.Settings.Integrations.OIDC.Issuer = f.provider.Issuer() + "/missing"
+		}, ErrSSOProviderUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newSSOFixture(t)
+			input := &BeginSSOInput{OrganizationSlug: "first-bank"}
+			tt.modify(f, input)
+			_, err := f.service.BeginLogin(context.Background(), input)
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.Empty(t, f.states.states)
+		})
+	}
+}
+
+func TestSSOService_CompleteLoginFailures(t *testing.T) {
+	t.Run("provider error", func(t *testing.T) {
+		f := newSSOFixture(t)
+		f.provider.SetUser(nil)
+		_, err := f.complete(f.signIn(t, ""))
+		assert.ErrorIs(t, err, ErrSSOAuthenticationFailed)
+	})
+
+	t.Run("ID token with another nonce", func(t *testing.T) {
+		f := newSSOFixture(t)
+		f.provider.Tamper(func(claims jwt.MapClaims) { claims["nonce"] = "replayed" })
+		_, err := f.complete(f.signIn(t, ""))
+		assert.ErrorIs(t, err, ErrSSOAuthenticationFailed)
+	})
+
+	t.Run("code of another login", func(t *testing.T) {
+		f := newSSOFixture(t)
+		first := f.signIn(t, "")
+		second := f.signIn(t, "")
+		// The code is bound to the first login's PKCE verifier
+		query := second.Query()
+		query.Set("code", first.Query().Get("code"))
+		second.RawQuery = query.Encode()
+		_, err := f.complete(second)
+		assert.ErrorIs(t, err, ErrSSOAuthenticationFailed)
+	})
+
+	t.Run("expired login", func(t *testing.T) {
+		f := newSSOFixture(t)
+		redirect := f.signIn(t, "")
+		f.states.states[redirect.Query().Get("state")].ExpiresAt = time.Now().Add(-time.Second)
+		_, err := f.complete(redirect)
+		assert.ErrorIs(t, err, ErrInvalidSSOState)
+	})
+}
+
+func TestSSOService_PlatformProvider(t *testing.T) {
+	f := newSSOFixture(t)
+	f.org.Settings.Integrations.OIDC.Issuer = ""
+	f.org.Settings.Integrations.OIDC.ClientID = ""
+	f.org.Settings.Integrations.OIDC.ClientSecret = ""
+	f.authCfg.OAuthProvider = f.provider.Issuer()
+	f.authCfg.OAuthClientID = "goedu"
+	f.authCfg.OAuthClientSecret = "client-secret"
+	f.newService()
+
+	_, err := f.complete(f.signIn(t, ""))
+	require.NoError(t, err)
+}
@@ -0,0 +1,950 @@
+// Package services provides service layer implementations for the GoEdu Control Testing Platform.
+// This file contains the user service, through which administrators manage
+// an organization's accounts and invite people by email. Invitation links
+// carry a signed token, so a link can only be used for the invitation it was
+// sent for and stops working once the invitation expires, is resent or is
+// revoked.
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/mail"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/auth"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/password"
+)
+
+// User management errors
+var (
+	ErrUserNotFound           = errors.New("user not found")
+	ErrEmailTaken             = errors.New("a user with this email address already exists")
+	ErrInvalidUserRole        = errors.New("role must be admin, manager, auditor or viewer")
+	ErrInvalidUserStatus      = errors.New("status must be active, inactive or suspended")
+	ErrPasswordTooWeak        = errors.New("password does not meet the password requirements")
+	ErrInvalidCredentials     = errors.New("invalid email or password")
+	ErrIncorrectPassword      = errors.New("current password is incorrect")
+	ErrPermissionDenied       = errors.New("user lacks a required permission")
+	ErrInvitationsDisabled    = errors.New("invitations are not enabled for this organization")
+	ErrInvitationsUnavailable = errors.New("invitations are unavailable until a signing key is configured")
+	ErrInvitationNotFound     = errors.New("invitation not found")
+	ErrInvitationExists       = errors.New("a pending invitation for this email already exists")
+	ErrInvitationClosed       = errors.New("invitation has already been accepted or revoked")
+	ErrInvalidInvitation      = errors.New("invitation link is invalid or has expired")
+)
+
+const (
+	// defaultUserPageSize and maxUserPageSize bound ListUsers pages
+	defaultUserPageSize = 50
+	maxUserPageSize     = 200
+
+	// invitationTokenContext separates invitation signatures from other
+	// uses of the signing key
+	invitationTokenContext = "invitation:"
+)
+
+// assignableUserRoles are the roles administrators can give users; the
+// owner role is only assigned when an organization is created.
+var assignableUserRoles = []string{
+	models.RoleAdmin,
+	models.RoleManager,
+	models.RoleAuditor,
+	models.RoleViewer,
+}
+
+// userService implements the UserService interface.
+type userService struct {
+	orgRepo        repositories.OrganizationRepository
+	userRepo       repositories.UserRepository
+	invitationRepo repositories.InvitationRepository
+	organizations  OrganizationService
+	authService    AuthenticationService
+	notifications  NotificationService
+	passwords      *passwordManager
+	seats          *memberSeats
+	config         config.InvitationConfig
+	logger         *zap.Logger
+}
+
+// NewUserService creates a new user service.
+//
+// Parameters:
+//   - orgRepo: Repository for organization settings and member counts
+//   - userRepo: Repository for user accounts
+//   - invitationRepo: Repository for invitations
+//   - organizations: Service deciding whether an organization has room for another member
+//   - authService: Service whose sessions are terminated when a user is deactivated
+//   - notifications: Service sending invitation emails
+//   - hasher: Password hasher for new and changed passwords
+//   - checker: Dictionary and breached password checks of the password policy
+//   - cfg: Invitation lifetime and link signing key
+//   - logger: Logger for service operations
+//
+// Returns:
+//   - UserService: Configured user service instance
+func NewUserService(
+	orgRepo repositories.OrganizationRepository,
+	userRepo repositories.UserRepository,
+	invitationRepo repositories.InvitationRepository,
+	organizations OrganizationService,
+	authService AuthenticationService,
+	notifications NotificationService,
+	hasher *auth.PasswordHasher,
+	checker *password.Checker,
+	cfg config.InvitationConfig,
+	logger *zap.Logger,
+) UserService {
+	return &userService{
+		orgRepo:        orgRepo,
+		userRepo:       userRepo,
+		invitationRepo: invitationRepo,
+		organizations:  organizations,
+		authService:    authService,
+		notifications:  notifications,
+		passwords: &passwordManager{
+			orgRepo:  orgRepo,
+			userRepo: userRepo,
+			hasher:   hasher,
+			checker:  checker,
+			logger:   logger,
+		},
+		seats:  &memberSeats{orgRepo: orgRepo, logger: logger},
+		config: cfg,
+		logger: logger,
+	}
+}
+
+// CreateUser creates an active user with a password. Without a role the
+// organization's default user role applies; the user receives the
+// organization's default permissions.
+//
+// Parameters:
+//   - ctx: Request context
+//   - input: Profile, email, password and role of the new user
+//
+// Returns:
+//   - *models.User: The created user
+//   - error: ErrInvalidInput, ErrOrganizationNotFound, ErrInvalidUserRole,
+//     ErrEmailTaken, ErrPasswordTooWeak, ErrPasswordCommon, ErrPasswordBreached,
+//     ErrMemberLimitReached or creation error
+func (s *userService) CreateUser(ctx context.Context, input *CreateUserInput) (*models.User, error) {
+	if input == nil {
+		return nil, ErrInvalidInput
+	}
+	firstName := strings.TrimSpace(input.FirstName)
+	lastName := strings.TrimSpace(input.LastName)
+	email, err := normalizeUserEmail(input.Email)
+	if err != nil || firstName == "" || lastName == "" {
+		return nil, ErrInvalidInput
+	}
+	org, err := s.organization(ctx, input.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	role, err := userRole(org, input.Role)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkEmailAvailable(ctx, email); err != nil {
+		return nil, err
+	}
+
+	user := newOrganizationUser(org, email, firstName, lastName, role)
+	user.Profile.Title = strings.TrimSpace(input.Title)
+	user.Profile.Department = strings.TrimSpace(input.Department)
+	user.Profile.PhoneNumber = strings.TrimSpace(input.Phone)
+	if err := s.passwords.set(ctx, org, user, input.Password); err != nil {
+		return nil, err
+	}
+	if err := s.create(ctx, user); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("User created",
+		zap.String("organization_id", org.ID.Hex()),
+		zap.String("user_id", user.ID.Hex()),
+		zap.String("role", role),
+	)
+	return user, nil
+}
+
+// GetUser retrieves a user by ID.
+//
+// Parameters:
+//   - ctx: Request context
+//   - id: User ID
+//
+// Returns:
+//   - *models.User: The user
+//   - error: ErrUserNotFound or retrieval error
+func (s *userService) GetUser(ctx context.Context, id string) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if errors.Is(err, repositories.ErrNotFound) || errors.Is(err, repositories.ErrInvalidInput) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// GetUserByEmail retrieves a user by email address.
+//
+// Parameters:
+//   - ctx: Request context
+//   - email: Email address, compared case-insensitively
+//
+// Returns:
+//   - *models.User: The user
+//   - error: ErrUserNotFound or retrieval error
+func (s *userService) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	user, err := s.userRepo.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// UpdateUser applies the set fields of the input to a user. Reactivating a
+// user takes a member seat; deactivating or suspending one releases the seat
+// and terminates the user's sessions.
+//
+// Parameters:
+//   - ctx: Request context
+//   - id: User ID
+//   - input: Fields to change; nil fields are left unchanged
+//
+// Returns:
+//   - *models.User: The updated user
+//   - error: ErrUserNotFound, ErrInvalidInput, ErrInvalidUserRole,
+//     ErrInvalidUserStatus, ErrMemberLimitReached or update error
+func (s *userService) UpdateUser(ctx context.Context, id string, input *UpdateUserInput) (*models.User, error) {
+	if input == nil {
+		return nil, ErrInvalidInput
+	}
+	user, err := s.GetUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range []struct {
+		value    *string
+		target   *string
+		required bool
+	}{
+		{input.FirstName, &user.Profile.FirstName, true},
+		{input.LastName, &user.Profile.LastName, true},
+		{input.Title, &user.Profile.Title, false},
+		{input.Department, &user.Profile.Department, false},
+		{input.Phone, &user.Profile.PhoneNumber, false},
+	} {
+		if field.value == nil {
+			continue
+		}
+		value := strings.TrimSpace(*field.value)
+		if field.required && value == "" {
+			return nil, ErrInvalidInput
+		}
+		*field.target = value
+	}
+	if input.Role != nil {
+		if !slices.Contains(assignableUserRoles, *input.Role) {
+			return nil, ErrInvalidUserRole
+		}
+		user.Roles = []string{*input.Role}
+	}
+	if input.Permissions != nil {
+		user.Permissions = models.PermissionsFromList(input.Permissions)
+	}
+
+	wasActive := user.IsActive
+	if input.Status != nil {
+		switch *input.Status {
+		case models.UserStatusActive:
+			user.IsActive = true
+		case models.UserStatusInactive, models.UserStatusSuspended:
+			user.IsActive = false
+		default:
+			return nil, ErrInvalidUserStatus
+		}
+		user.Status = *input.Status
+		user.DeactivatedBy = ""
+	}
+
+	orgID := user.OrganizationID.Hex()
+	reactivated := user.IsActive && !wasActive
+	if reactivated {
+		if err := s.seats.take(ctx, orgID); err != nil {
+			return nil, err
+		}
+	}
+	user.UpdatedAt = time.Now().UTC()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		if reactivated {
+			s.seats.release(ctx, orgID)
+		}
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	if wasActive && !user.IsActive {
+		s.deactivated(ctx, user)
+	}
+	return user, nil
+}
+
+// UpdatePassword changes a user's password after verifying the current one.
+// The new password must satisfy the organization's password policy.
+//
+// Parameters:
+//   - ctx: Request context
+//   - userID: User ID
+//   - oldPassword: The user's current password
+//   - newPassword: The new password
+//
+// Returns:
+//   - error: ErrUserNotFound, ErrIncorrectPassword, ErrPasswordTooWeak,
+//     ErrPasswordCommon, ErrPasswordBreached, ErrPasswordReused or update error
+func (s *userService) UpdatePassword(ctx context.Context, userID string, oldPassword, newPassword string) error {
+	return s.passwords.change(ctx, userID, oldPassword, newPassword)
+}
+
+// AuthenticateUser verifies a user's email and password. Failed attempts are
+// counted and lock the account once too many accumulate. Users whose password
+// has expired must reset it before they can sign in.
+//
+// Parameters:
+//   - ctx: Request context
+//   - email: Email address
+//   - password: Password
+//
+// Returns:
+//   - *models.User: The authenticated user
+//   - error: ErrInvalidCredentials, ErrAccountLocked, ErrUserNotActive,
+//     ErrPasswordExpired or retrieval error
+func (s *userService) AuthenticateUser(ctx context.Context, email, password string) (*models.User, error) {
+	user, err := s.userRepo.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.IsLocked() {
+		return nil, ErrAccountLocked
+	}
+	ok, err := s.passwords.verify(user, password)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		recordFailedLogin(ctx, s.userRepo, s.logger, user, "")
+		return nil, ErrInvalidCredentials
+	}
+	if !user.IsActive || user.Status != models.UserStatusActive {
+		return nil, ErrUserNotActive
+	}
+	org, err := s.organization(ctx, user.OrganizationID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	if s.passwords.expired(org, user, time.Now().UTC()) {
+		return nil, ErrPasswordExpired
+	}
+	if user.Authentication.FailedLoginAttempts > 0 {
+		if err := s.userRepo.ResetFailedLogins(ctx, user.ID.Hex()); err != nil {
+			s.logger.Warn("Failed to reset failed sign-ins", zap.Error(err), zap.String("user_id", user.ID.Hex()))
+		}
+	}
+	return user, nil
+}
+
+// DeactivateUser deactivates a user, releasing their member seat and
+// terminating their sessions. Deactivating a user the directory deactivated
+// keeps the directory from reactivating them; deactivating any other
+// inactive user does nothing.
+//
+// Parameters:
+//   - ctx: Request context
+//   - id: User ID
+//
+// Returns:
+//   - error: ErrUserNotFound or update error
+func (s *userService) DeactivateUser(ctx context.Context, id string) error {
+	user, err := s.GetUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !user.IsActive && user.DeactivatedBy == "" {
+		return nil
+	}
+	wasActive := user.IsActive
+	user.IsActive = false
+	user.Status = models.UserStatusInactive
+	user.DeactivatedBy = ""
+	user.UpdatedAt = time.Now().UTC()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	if wasActive {
+		s.deactivated(ctx, user)
+	}
+	return nil
+}
+
+// ListUsers retrieves a page of an organization's users matching the filter.
+//
+// Parameters:
+//   - ctx: Request context
+//   - filter: Organization, optional role, status and department, and page
+//
+// Returns:
+//   - *UserConnection: The page and the number of matching users
+//   - error: ErrInvalidInput or retrieval error
+func (s *userService) ListUsers(ctx context.Context, filter *UserFilter) (*UserConnection, error) {
+	if filter == nil || filter.OrganizationID == "" || filter.Offset < 0 || filter.Limit < 0 {
+		return nil, ErrInvalidInput
+	}
+	users, err := organizationUsers(ctx, s.userRepo, filter.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	users = slices.DeleteFunc(users, func(user *models.User) bool {
+		return (filter.Role != "" && !user.HasRole(filter.Role)) ||
+			(filter.Status != "" && user.Status != filter.Status) ||
+			(filter.Department != "" && !strings.EqualFold(user.Profile.Department, filter.Department))
+	})
+
+	limit := filter.Limit
+	if limit == 0 {
+		limit = defaultUserPageSize
+	}
+	limit = min(limit, maxUserPageSize)
+	start := min(filter.Offset, len(users))
+	end := min(start+limit, len(users))
+	return &UserConnection{
+		Nodes:      users[start:end],
+		TotalCount: len(users),
+		HasMore:    end < len(users),
+	}, nil
+}
+
+// ValidatePermissions checks that an active user holds every listed
+// permission. Administrators and owners hold all permissions.
+//
+// Parameters:
+//   - ctx: Request context
+//   - userID: User ID
+//   - permissions: Required permission names
+//
+// Returns:
+//   - error: ErrUserNotFound, ErrUserNotActive, ErrPermissionDenied or retrieval error
+func (s *userService) ValidatePermissions(ctx context.Context, userID string, permissions []string) error {
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.IsActive {
+		return ErrUserNotActive
+	}
+	if user.HasRole(models.RoleOwner) || user.HasRole(models.RoleAdmin) {
+		return nil
+	}
+	granted := user.GetPermissionsList()
+	for _, permission := range permissions {
+		if !slices.Contains(granted, permission) {
+			return ErrPermissionDenied
+		}
+	}
+	return nil
+}
+
+// InviteUser invites an email address to join an organization and emails
+// the invitation link. An expired invitation for the same email is renewed
+// instead of a new one being created.
+//
+// Parameters:
+//   - ctx: Request context
+//   - input: Organization, inviting user, email and optional role
+//
+// Returns:
+//   - *InvitationSecret: The invitation and the token of its link
+//   - error: ErrInvitationsUnavailable, ErrInvalidInput, ErrOrganizationNotFound,
+//     ErrInvitationsDisabled, ErrInvalidUserRole, ErrEmailTaken,
+//     ErrMemberLimitReached, ErrInvitationExists or persistence error
+func (s *userService) InviteUser(ctx context.Context, input *InviteUserInput) (*InvitationSecret, error) {
+	if s.config.SigningKey == "" {
+		return nil, ErrInvitationsUnavailable
+	}
+	if input == nil {
+		return nil, ErrInvalidInput
+	}
+	invitedBy, err := primitive.ObjectIDFromHex(input.InvitedBy)
+	if err != nil {
+		return nil, ErrInvalidInput
+	}
+	email, err := normalizeUserEmail(input.Email)
+	if err != nil {
+		return nil, ErrInvalidInput
+	}
+	org, err := s.organization(ctx, input.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	if !org.Settings.AllowInvitations {
+		return nil, ErrInvitationsDisabled
+	}
+	role, err := userRole(org, input.Role)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkEmailAvailable(ctx, email); err != nil {
+		return nil, err
+	}
+	if ok, err := s.organizations.CanAddMember(ctx, org.ID.Hex()); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, ErrMemberLimitReached
+	}
+
+	now := time.Now().UTC()
+	invitation, err := s.invitationRepo.GetPendingByEmail(ctx, org.ID.Hex(), email)
+	switch {
+	case err == nil && invitation.IsPending(now):
+		return nil, ErrInvitationExists
+	case err == nil:
+		invitation.Role = role
+		invitation.InvitedBy = invitedBy
+		invitation.ExpiresAt = now.Add(s.config.TTL)
+		invitation.UpdatedAt = now
+		if err := s.invitationRepo.Update(ctx, invitation); err != nil {
+			return nil, fmt.Errorf("failed to update invitation: %w", err)
+		}
+	case errors.Is(err, repositories.ErrNotFound):
+		invitation = &models.Invitation{
+			OrganizationID: org.ID,
+			Email:          email,
+			Role:           role,
+			Status:         models.InvitationStatusPending,
+			ExpiresAt:      now.Add(s.config.TTL),
+			InvitedBy:      invitedBy,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}
+		if err := s.invitationRepo.Create(ctx, invitation); err != nil {
+			if errors.Is(err, repositories.ErrDuplicate) {
+				return nil, ErrInvitationExists
+			}
+			return nil, fmt.Errorf("failed to create invitation: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+
+	s.logger.Info("User invited",
+		zap.String("organization_id", org.ID.Hex()),
+		zap.String("invitation_id", invitation.ID.Hex()),
+		zap.String("invited_by", input.InvitedBy),
+	)
+	return s.sendInvitation(ctx, invitation), nil
+}
+
+// ListInvitations retrieves an organization's invitations, newest first.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID
+//   - includeClosed: Whether accepted and revoked invitations are included
+//
+// Returns:
+//   - []*models.Invitation: The invitations
+//   - error: Retrieval error
+func (s *userService) ListInvitations(ctx context.Context, orgID string, includeClosed bool) ([]*models.Invitation, error) {
+	invitations, err := s.invitationRepo.GetByOrganization(ctx, orgID, includeClosed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitations: %w", err)
+	}
+	return invitations, nil
+}
+
+// ResendInvitation extends a pending invitation by the invitation lifetime
+// and emails a new link. Links sent earlier stop working.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID
+//   - invitationID: Invitation ID
+//   - resentBy: ID of the user resending the invitation
+//
+// Returns:
+//   - *InvitationSecret: The invitation and the token of its new link
+//   - error: ErrInvitationsUnavailable, ErrInvitationNotFound, ErrInvitationClosed,
+//     ErrInvitationsDisabled or update error
+func (s *userService) ResendInvitation(ctx context.Context, orgID, invitationID, resentBy string) (*InvitationSecret, error) {
+	if s.config.SigningKey == "" {
+		return nil, ErrInvitationsUnavailable
+	}
+	invitation, err := s.invitation(ctx, orgID, invitationID)
+	if err != nil {
+		return nil, err
+	}
+	if invitation.Status != models.InvitationStatusPending {
+		return nil, ErrInvitationClosed
+	}
+	org, err := s.organization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if !org.Settings.AllowInvitations {
+		return nil, ErrInvitationsDisabled
+	}
+
+	now := time.Now().UTC()
+	invitation.ExpiresAt = now.Add(s.config.TTL)
+	invitation.UpdatedAt = now
+	if err := s.invitationRepo.Update(ctx, invitation); err != nil {
+		return nil, fmt.Errorf("failed to update invitation: %w", err)
+	}
+
+	s.logger.Info("Invitation resent",
+		zap.String("organization_id", orgID),
+		zap.String("invitation_id", invitationID),
+		zap.String("resent_by", resentBy),
+	)
+	return s.sendInvitation(ctx, invitation), nil
+}
+
+// RevokeInvitation withdraws a pending invitation so its link stops working.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID
+//   - invitationID: Invitation ID
+//   - revokedBy: ID of the user revoking the invitation
+//
+// Returns:
+//   - *models.Invitation: The revoked invitation
+//   - error: ErrInvalidInput, ErrInvitationNotFound, ErrInvitationClosed or update error
+func (s *userService) RevokeInvitation(ctx context.Context, orgID, invitationID, revokedBy string) (*models.Invitation, error) {
+	revoker, err := primitive.ObjectIDFromHex(revokedBy)
+	if err != nil {
+		return nil, ErrInvalidInput
+	}
+	invitation, err := s.invitation(ctx, orgID, invitationID)
+	if err != nil {
+		return nil, err
+	}
+	if invitation.Status != models.InvitationStatusPending {
+		return nil, ErrInvitationClosed
+	}
+
+	now := time.Now().UTC()
+	invitation.Status = models.InvitationStatusRevoked
+	invitation.RevokedAt = now
+	invitation.RevokedBy = revoker
+	invitation.UpdatedAt = now
+	if err := s.invitationRepo.Update(ctx, invitation); err != nil {
+		return nil, fmt.Errorf("failed to update invitation: %w", err)
+	}
+
+	s.logger.Info("Invitation revoked",
+		zap.String("organization_id", orgID),
+		zap.String("invitation_id", invitationID),
+		zap.String("revoked_by", revokedBy),
+	)
+	return invitation, nil
+}
+
+// LookupInvitation describes the invitation an invitation link was sent for,
+// so the invitee can see which organization they are joining.
+//
+// Parameters:
+//   - ctx: Request context
+//   - token: Token of the invitation link
+//
+// Returns:
+//   - *InvitationDetails: Email, role, organization and expiry of the invitation
+//   - error: ErrInvitationsUnavailable, ErrInvalidInvitation or retrieval error
+func (s *userService) LookupInvitation(ctx context.Context, token string) (*InvitationDetails, error) {
+	invitation, err := s.pendingInvitation(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	org, err := s.organization(ctx, invitation.OrganizationID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	return &InvitationDetails{
+		Email:            invitation.Email,
+		Role:             invitation.Role,
+		OrganizationName: org.Name,
+		ExpiresAt:        invitation.ExpiresAt,
+	}, nil
+}
+
+// AcceptInvitation creates the invitee's account with the password they
+// chose and closes the invitation.
+//
+// Parameters:
+//   - ctx: Request context
+//   - input: Token of the invitation link, the invitee's name and password
+//
+// Returns:
+//   - *models.User: The created user
+//   - error: ErrInvalidInput, ErrInvitationsUnavailable, ErrInvalidInvitation,
+//     ErrInvitationsDisabled, ErrEmailTaken, ErrPasswordTooWeak,
+//     ErrPasswordCommon, ErrPasswordBreached,
+//     ErrMemberLimitReached or creation error
+func (s *userService) AcceptInvitation(ctx context.Context, input *AcceptInvitationInput) (*models.User, error) {
+	if input == nil {
+		return nil, ErrInvalidInput
+	}
+	firstName := strings.TrimSpace(input.FirstName)
+	lastName := strings.TrimSpace(input.LastName)
+	if firstName == "" || lastName == "" {
+		return nil, ErrInvalidInput
+	}
+	invitation, err := s.pendingInvitation(ctx, input.Token)
+	if err != nil {
+		return nil, err
+	}
+	org, err := s.organization(ctx, invitation.OrganizationID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	if !org.IsActive || !org.Settings.AllowInvitations {
+		return nil, ErrInvitationsDisabled
+	}
+	if err := s.checkEmailAvailable(ctx, invitation.Email); err != nil {
+		return nil, err
+	}
+
+	user := newOrganizationUser(org, invitation.Email, firstName, lastName, invitation.Role)
+	if err := s.passwords.set(ctx, org, user, input.Password); err != nil {
+		return nil, err
+	}
+	if err := s.create(ctx, user); err != nil {
+		return nil, err
+	}
+
+	invitation.Status = models.InvitationStatusAccepted
+	invitation.AcceptedAt = user.CreatedAt
+	invitation.AcceptedBy = user.ID
+	invitation.UpdatedAt = user.CreatedAt
+	if err := s.invitationRepo.Update(ctx, invitation); err != nil {
+		s.logger.Error("Failed to close accepted invitation",
+			zap.Error(err),
+			zap.String("invitation_id", invitation.ID.Hex()),
+		)
+	}
+
+	s.logger.Info("Invitation accepted",
+		zap.String("organization_id", org.ID.Hex()),
+		zap.String("invitation_id", invitation.ID.Hex()),
+		zap.String("user_id", user.ID.Hex()),
+	)
+	return user, nil
+}
+
+// organization loads an organization, mapping a missing one to ErrOrganizationNotFound.
+func (s *userService) organization(ctx context.Context, orgID string) (*models.Organization, error) {
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if errors.Is(err, repositories.ErrNotFound) || errors.Is(err, repositories.ErrInvalidInput) {
+		return nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	return org, nil
+}
+
+// invitation loads an invitation of an organization; invitations of other
+// organizations are reported as not found.
+func (s *userService) invitation(ctx context.Context, orgID, invitationID string) (*models.Invitation, error) {
+	invitation, err := s.invitationRepo.GetByID(ctx, invitationID)
+	if errors.Is(err, repositories.ErrNotFound) || errors.Is(err, repositories.ErrInvalidInput) {
+		return nil, ErrInvitationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	if invitation.OrganizationID.Hex() != orgID {
+		return nil, ErrInvitationNotFound
+	}
+	return invitation, nil
+}
+
+// checkEmailAvailable returns ErrEmailTaken when any account uses the email.
+func (s *userService) checkEmailAvailable(ctx context.Context, email string) error {
+	_, err := s.userRepo.GetByEmail(ctx, email)
+	switch {
+	case err == nil:
+		return ErrEmailTaken
+	case errors.Is(err, repositories.ErrNotFound):
+		return nil
+	default:
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+}
+
+// create takes a member seat and inserts a new active user, releasing the
+// seat when the insert fails.
+func (s *userService) create(ctx context.Context, user *models.User) error {
+	orgID := user.OrganizationID.Hex()
+	if err := s.seats.take(ctx, orgID); err != nil {
+		return err
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		s.seats.release(ctx, orgID)
+		if errors.Is(err, repositories.ErrDuplicate) {
+			return ErrEmailTaken
+		}
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+	return nil
+}
+
+// deactivated releases the member seat of a user who was just deactivated
+// and terminates their sessions.
+func (s *userService) deactivated(ctx context.Context, user *models.User) {
+	s.seats.release(ctx, user.OrganizationID.Hex())
+	if err := s.authService.TerminateAllSessions(ctx, user.ID.Hex()); err != nil {
+		s.logger.Error("Failed to terminate sessions of deactivated user",
+			zap.Error(err),
+			zap.String("user_id", user.ID.Hex()),
+		)
+	}
+	s.logger.Info("User deactivated",
+		zap.String("organization_id", user.OrganizationID.Hex()),
+		zap.String("user_id", user.ID.Hex()),
+		zap.String("status", user.Status),
+	)
+}
+
+// sendInvitation emails an invitation's link. A failed email is logged
+// rather than returned; the returned token lets an administrator share the
+// link another way.
+func (s *userService) sendInvitation(ctx context.Context, invitation *models.Invitation) *InvitationSecret {
+	token := s.invitationToken(invitation)
+	if err := s.notifications.SendInvitation(ctx, invitation, token); err != nil {
+		s.logger.Warn("Failed to send invitation email",
+			zap.Error(err),
+			zap.String("invitation_id", invitation.ID.Hex()),
+		)
+	}
+	return &InvitationSecret{Invitation: invitation, Token: token}
+}
+
+// invitationToken returns the token of an invitation's link: the invitation
+// ID and expiry, and an HMAC-SHA256 over both, each base64url encoded.
+func (s *userService) invitationToken(invitation *models.Invitation) string {
+	payload := invitation.ID.Hex() + "." + strconv.FormatInt(invitation.ExpiresAt.Unix(), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(s.invitationMAC(payload))
+}
+
+// invitationMAC signs an invitation token payload.
+func (s *userService) invitationMAC(payload string) []byte {
+	mac := hmac.New(sha256.New, []byte(s.config.SigningKey))
+	mac.Write([]byte(invitationTokenContext + payload))
+	return mac.Sum(nil)
+}
+
+// pendingInvitation returns the invitation a token was issued for, provided
+// the token carries the invitation's current expiry and the invitation can
+// still be accepted.
+func (s *userService) pendingInvitation(ctx context.Context, token string) (*models.Invitation, error) {
+	if s.config.SigningKey == "" {
+		return nil, ErrInvitationsUnavailable
+	}
+	encodedPayload, encodedMAC, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidInvitation
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, ErrInvalidInvitation
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil || !hmac.Equal(mac, s.invitationMAC(string(payload))) {
+		return nil, ErrInvalidInvitation
+	}
+	invitationID, expiry, ok := strings.Cut(string(payload), ".")
+	if !ok {
+		return nil, ErrInvalidInvitation
+	}
+
+	invitation, err := s.invitationRepo.GetByID(ctx, invitationID)
+	if errors.Is(err, repositories.ErrNotFound) || errors.Is(err, repositories.ErrInvalidInput) {
+		return nil, ErrInvalidInvitation
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	if strconv.FormatInt(invitation.ExpiresAt.Unix(), 10) != expiry || !invitation.IsPending(time.Now()) {
+		return nil, ErrInvalidInvitation
+	}
+	return invitation, nil
+}
+
+// newOrganizationUser builds an active user of an organization with the
+// given role and the organization's default permissions.
+func newOrganizationUser(org *models.Organization, email, firstName, lastName, role string) *models.User {
+	now := time.Now().UTC()
+	user := &models.User{
+		Email: email,
+		Profile: models.UserProfile{
+			FirstName: firstName,
+			LastName:  lastName,
+		},
+		Roles:          []string{role},
+		Permissions:    models.PermissionsFromList(org.Settings.DefaultUserPermissions),
+		OrganizationID: org.ID,
+		IsActive:       true,
+		Status:         models.UserStatusActive,
+	}
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	return user
+}
+
+// userRole validates a requested role, falling back to the organization's
+// default user role and then to viewer.
+func userRole(org *models.Organization, role string) (string, error) {
+	if role == "" {
+		role = org.Settings.DefaultUserRole
+	}
+	if role == "" {
+		role = models.RoleViewer
+	}
+	if !slices.Contains(assignableUserRoles, role) {
+		return "", ErrInvalidUserRole
+	}
+	return role, nil
+}
+
+// normalizeUserEmail lowercases an email address and checks that it is a
+// bare address.
+func normalizeUserEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return "", ErrInvalidInput
+	}
+	return email, nil
+}
@@ -0,0 +1,334 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/auth"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/password"
+)
+
+const testUserPassword = "correct horse battery"
+
+type userFixture struct {
+	org           *models.Organization
+	orgs          *fakeOrganizationRepository
+	admin         *models.User
+	users         *fakeUserRepository
+	invitations   *fakeInvitationRepository
+	notifications *fakeNotificationService
+	auth          *fakeAuthenticationService
+	service       UserService
+}
+
+func newUserFixture(t *testing.T) *userFixture {
+	t.Helper()
+	org := &models.Organization{Name: "First Bank", Status: models.OrganizationStatusActive, IsActive: true, MemberCount: 1, MaxMembers: 3}
+	org.ID = primitive.NewObjectID()
+	org.Settings.AllowInvitations = true
+	org.Settings.DefaultUserRole = models.RoleAuditor
+	org.Settings.DefaultUserPermissions = []string{"controls:read", "reports:read"}
+
+	admin := &models.User{Email: "admin@first-bank.com", Roles: []string{models.RoleAdmin}, OrganizationID: org.ID, IsActive: true, Status: models.UserStatusActive}
+	admin.ID = primitive.NewObjectID()
+
+	orgRepo := newFakeOrganizationRepository(org)
+	f := &userFixture{
+		org:           org,
+		orgs:          orgRepo,
+		admin:         admin,
+		users:         newFakeUserRepository(admin),
+		invitations:   newFakeInvitationRepository(),
+		notifications: newFakeNotificationService(),
+		auth:          &fakeAuthenticationService{},
+	}
+	organizations := NewOrganizationService(orgRepo, nil, nil, nil, nil, zap.NewNop())
+	f.service = NewUserService(orgRepo, f.users, f.invitations, organizations, f.auth, f.notifications,
+		auth.NewPasswordHasher(4), password.NewChecker(password.DefaultDictionary(), nil), config.InvitationConfig{TTL: 24 * time.Hour, SigningKey: "test-invitation-key"}, zap.NewNop())
+	return f
+}
+
+func (f *userFixture) createUser(t *testing.T, email string) *models.User {
+	t.Helper()
+	user, err := f.service.CreateUser(context.Background(), &CreateUserInput{
+		FirstName:      "Ada",
+		LastName:       "Auditor",
+		Email:          email,
+		Password:       testUserPassword,
+		OrganizationID: f.org.ID.Hex(),
+	})
+	require.NoError(t, err)
+	return user
+}
+
+func TestUserService_CreateAndUpdate(t *testing.T) {
+	f := newUserFixture(t)
+	ctx := context.Background()
+
+	user := f.createUser(t, " Ada@First-Bank.com ")
+	assert.Equal(t, "ada@first-bank.com", user.Email)
+	assert.Equal(t, []string{models.RoleAuditor}, user.Roles)
+	assert.True(t, user.Permissions.CanViewControls)
+	assert.True(t, user.Permissions.CanViewReports)
+	assert.False(t, user.Permissions.CanEditControls)
+	assert.NotEqual(t, testUserPassword, user.Authentication.PasswordHash)
+	assert.Equal(t, 2, f.org.MemberCount)
+
+	input := &CreateUserInput{FirstName: "Bob", LastName: "Banker", Email: "ADA@first-bank.com", Password: testUserPassword, OrganizationID: f.org.ID.Hex()}
+	_, err := f.service.CreateUser(ctx, input)
+	assert.ErrorIs(t, err, ErrEmailTaken)
+
+	input.Email = "bob@first-bank.com"
+	input.Password = "short"
+	_, err = f.service.CreateUser(ctx, input)
+	assert.ErrorIs(t, err, ErrPasswordTooWeak)
+
+	input.Password = testUserPassword
+	input.Role = models.RoleOwner
+	_, err = f.service.CreateUser(ctx, input)
+	assert.ErrorIs(t, err, ErrInvalidUserRole)
+
+	input.Role = models.RoleManager
+	_, err = f.service.CreateUser(ctx, input)
+	require.NoError(t, err)
+	input.Email = "carol@first-bank.com"
+	_, err = f.service.CreateUser(ctx, input)
+	assert.ErrorIs(t, err, ErrMemberLimitReached)
+	assert.Equal(t, 3, f.org.MemberCount)
+
+	suspended := models.UserStatusSuspended
+	title := " Lead Auditor "
+	updated, err := f.service.UpdateUser(ctx, user.ID.Hex(), &UpdateUserInput{Status: &suspended, Title: &title})
+	require.NoError(t, err)
+	assert.False(t, updated.IsActive)
+	assert.Equal(t, "Lead Auditor", updated.Profile.Title)
+	assert.Equal(t, 2, f.org.MemberCount)
+	assert.Equal(t, []string{user.ID.Hex()}, f.auth.terminated)
+
+	_, err = f.service.CreateUser(ctx, input)
+	require.NoError(t, err)
+	active := models.UserStatusActive
+	_, err = f.service.UpdateUser(ctx, user.ID.Hex(), &UpdateUserInput{Status: &active})
+	assert.ErrorIs(t, err, ErrMemberLimitReached)
+
+	empty := " "
+	_, err = f.service.UpdateUser(ctx, user.ID.Hex(), &UpdateUserInput{FirstName: &empty})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	locked := "locked"
+	_, err = f.service.UpdateUser(ctx, user.ID.Hex(), &UpdateUserInput{Status: &locked})
+	assert.ErrorIs(t, err, ErrInvalidUserStatus)
+
+	carol, err := f.service.GetUserByEmail(ctx, "Carol@First-Bank.com")
+	require.NoError(t, err)
+	require.NoError(t, f.service.DeactivateUser(ctx, carol.ID.Hex()))
+	require.NoError(t, f.service.DeactivateUser(ctx, carol.ID.Hex()))
+	assert.Equal(t, 2, f.org.MemberCount)
+	assert.Equal(t, models.UserStatusInactive, carol.Status)
+}
+
+func TestUserService_DeactivateUnknownUser(t *testing.T) {
+	f := newUserFixture(t)
+	err := f.service.DeactivateUser(context.Background(), primitive.NewObjectID().Hex())
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestUserService_Passwords(t *testing.T) {
+	f := newUserFixture(t)
+	ctx := context.Background()
+	user := f.createUser(t, "ada@first-bank.com")
+
+	authenticated, err := f.service.AuthenticateUser(ctx, "ADA@first-bank.com", testUserPassword)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, authenticated.ID)
+
+	_, err = f.service.AuthenticateUser(ctx, "nobody@first-bank.com", testUserPassword)
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = f.service.AuthenticateUser(ctx, "ada@first-bank.com", "wrong password!")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	assert.Equal(t, 1, user.Authentication.FailedLoginAttempts)
+
+	_, err = f.service.AuthenticateUser(ctx, "ada@first-bank.com", testUserPassword)
+	require.NoError(t, err)
+	assert.Zero(t, user.Authentication.FailedLoginAttempts)
+
+	for range maxFailedLogins {
+		_, err = f.service.AuthenticateUser(ctx, "ada@first-bank.com", "wrong password!")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+	_, err = f.service.AuthenticateUser(ctx, "ada@first-bank.com", testUserPassword)
+	assert.ErrorIs(t, err, ErrAccountLocked)
+
+	err = f.service.UpdatePassword(ctx, user.ID.Hex(), "wrong password!", "a brand new passphrase")
+	assert.ErrorIs(t, err, ErrIncorrectPassword)
+	err = f.service.UpdatePassword(ctx, user.ID.Hex(), testUserPassword, "short")
+	assert.ErrorIs(t, err, ErrPasswordTooWeak)
+	require.NoError(t, f.service.UpdatePassword(ctx, user.ID.Hex(), testUserPassword, "a brand new passphrase"))
+
+	user.Authentication.LockoutUntil = time.Time{}
+	_, err = f.service.AuthenticateUser(ctx, "ada@first-bank.com", "a brand new passphrase")
+	require.NoError(t, err)
+
+	// Accounts that sign in through an identity provider have no password
+	f.admin.Authentication.PasswordHash = ""
+	_, err = f.service.AuthenticateUser(ctx, f.admin.Email, "")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestUserService_ListUsersAndPermissions(t *testing.T) {
+	f := newUserFixture(t)
+	ctx := context.Background()
+	f.org.MaxMembers = 0
+	ada := f.createUser(t, "ada@first-bank.com")
+	f.createUser(t, "bob@first-bank.com")
+	require.NoError(t, f.service.DeactivateUser(ctx, ada.ID.Hex()))
+
+	page, err := f.service.ListUsers(ctx, &UserFilter{OrganizationID: f.org.ID.Hex(), Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 3, page.TotalCount)
+	assert.Len(t, page.Nodes, 2)
+	assert.True(t, page.HasMore)
+
+	page, err = f.service.ListUsers(ctx, &UserFilter{OrganizationID: f.org.ID.Hex(), Role: models.RoleAuditor, Status: models.UserStatusActive})
+	require.NoError(t, err)
+	require.Len(t, page.Nodes, 1)
+	assert.Equal(t, "bob@first-bank.com", page.Nodes[0].Email)
+	assert.False(t, page.HasMore)
+
+	_, err = f.service.ListUsers(ctx, &UserFilter{})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	bob := page.Nodes[0]
+	assert.NoError(t, f.service.ValidatePermissions(ctx, bob.ID.Hex(), []string{"controls:read"}))
+	assert.ErrorIs(t, f.service.ValidatePermissions(ctx, bob.ID.Hex(), []string{"controls:read", "controls:write"}), ErrPermissionDenied)
+	assert.NoError(t, f.service.ValidatePermissions(ctx, f.admin.ID.Hex(), []string{"controls:write"}))
+	assert.ErrorIs(t, f.service.ValidatePermissions(ctx, ada.ID.Hex(), []string{"controls:read"}), ErrUserNotActive)
+}
+
+func TestUserService_Invitations(t *testing.T) {
+	f := newUserFixture(t)
+	ctx := context.Background()
+	orgID := f.org.ID.Hex()
+
+	sent, err := f.service.InviteUser(ctx, &InviteUserInput{OrganizationID: orgID, InvitedBy: f.admin.ID.Hex(), Email: "Grace@First-Bank.com"})
+	require.NoError(t, err)
+	invitation := sent.Invitation
+	assert.Equal(t, "grace@first-bank.com", invitation.Email)
+	assert.Equal(t, models.RoleAuditor, invitation.Role)
+	assert.Equal(t, []string{sent.Token}, f.notifications.invitations)
+
+	_, err = f.service.InviteUser(ctx, &InviteUserInput{OrganizationID: orgID, InvitedBy: f.admin.ID.Hex(), Email: "grace@first-bank.com"})
+	assert.ErrorIs(t, err, ErrInvitationExists)
+	_, err = f.service.InviteUser(ctx, &InviteUserInput{OrganizationID: orgID, InvitedBy: f.admin.ID.Hex(), Email: f.admin.Email})
+	assert.ErrorIs(t, err, ErrEmailTaken)
+
+	details, err := f.service.LookupInvitation(ctx, sent.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "First Bank", details.OrganizationName)
+	assert.Equal(t, "grace@first-bank.com", details.Email)
+
+	// Resending extends the invitation, so earlier links stop working
+	invitation.ExpiresAt = invitation.ExpiresAt.Add(-time.Hour)
+	earlier := f.service.(*userService).invitationToken(invitation)
+	resent, err := f.service.ResendInvitation(ctx, orgID, invitation.ID.Hex(), f.admin.ID.Hex())
+	require.NoError(t, err)
+	assert.NotEqual(t, earlier, resent.Token)
+	_, err = f.service.LookupInvitation(ctx, earlier)
+	assert.ErrorIs(t, err, ErrInvalidInvitation)
+
+	payload, signature, _ := strings.Cut(resent.Token, ".")
+	_, err = f.service.LookupInvitation(ctx, payload+"."+signature[1:])
+	assert.ErrorIs(t, err, ErrInvalidInvitation)
+	_, err = f.service.LookupInvitation(ctx, "not-a-token")
+	assert.ErrorIs(t, err, ErrInvalidInvitation)
+
+	_, err = f.service.AcceptInvitation(ctx, &AcceptInvitationInput{Token: resent.Token, FirstName: "Grace", LastName: "Hopper", Password: "short"})
+	assert.ErrorIs(t, err, ErrPasswordTooWeak)
+	user, err := f.service.AcceptInvitation(ctx, &AcceptInvitationInput{Token: resent.Token, FirstName: "Grace", LastName: "Hopper", Password: testUserPassword})
+	require.NoError(t, err)
+	assert.Equal(t, "grace@first-bank.com", user.Email)
+	assert.Equal(t, []string{models.RoleAuditor}, user.Roles)
+	assert.True(t, user.Permissions.CanViewControls)
+	assert.Equal(t, 2, f.org.MemberCount)
+	assert.Equal(t, models.InvitationStatusAccepted, invitation.Status)
+	assert.Equal(t, user.ID, invitation.AcceptedBy)
+
+	_, err = f.service.AcceptInvitation(ctx, &AcceptInvitationInput{Token: resent.Token, FirstName: "Grace", LastName: "Hopper", Password: testUserPassword})
+	assert.ErrorIs(t, err, ErrInvalidInvitation)
+	_, err = f.service.RevokeInvitation(ctx, orgID, invitation.ID.Hex(), f.admin.ID.Hex())
+	assert.ErrorIs(t, err, ErrInvitationClosed)
+
+	pending, err := f.service.ListInvitations(ctx, orgID, false)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+	all, err := f.service.ListInvitations(ctx, orgID, true)
+	require.NoError(t, err)
+	assert.Len(t, all, 1)
+}
+
+func TestUserService_InvitationLifecycle(t *testing.T) {
+	f := newUserFixture(t)
+	ctx := context.Background()
+	orgID := f.org.ID.Hex()
+	invite := func(email, role string) (*InvitationSecret, error) {
+		return f.service.InviteUser(ctx, &InviteUserInput{OrganizationID: orgID, InvitedBy: f.admin.ID.Hex(), Email: email, Role: role})
+	}
+
+	sent, err := invite("bob@first-bank.com", models.RoleManager)
+	require.NoError(t, err)
+	revoked, err := f.service.RevokeInvitation(ctx, orgID, sent.Invitation.ID.Hex(), f.admin.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, models.InvitationStatusRevoked, revoked.Status)
+	assert.Equal(t, f.admin.ID, revoked.RevokedBy)
+	_, err = f.service.LookupInvitation(ctx, sent.Token)
+	assert.ErrorIs(t, err, ErrInvalidInvitation)
+	_, err = f.service.ResendInvitation(ctx, orgID, sent.Invitation.ID.Hex(), f.admin.ID.Hex())
+	assert.ErrorIs(t, err, ErrInvitationClosed)
+	_, err = f.service.RevokeInvitation(ctx, primitive.NewObjectID().Hex(), sent.Invitation.ID.Hex(), f.admin.ID.Hex())
+	assert.ErrorIs(t, err, ErrInvitationNotFound)
+
+	// An expired invitation is renewed rather than blocking a new one
+	sent, err = invite("carol@first-bank.com", "")
+	require.NoError(t, err)
+	sent.Invitation.ExpiresAt = time.Now().Add(-time.Minute)
+	_, err = f.service.LookupInvitation(ctx, sent.Token)
+	assert.ErrorIs(t, err, ErrInvalidInvitation)
+	renewed, err := invite("carol@first-bank.com", models.RoleViewer)
+	require.NoError(t, err)
+	assert.Equal(t, sent.Invitation.ID, renewed.Invitation.ID)
+	assert.Equal(t, models.RoleViewer, renewed.Invitation.Role)
+	assert.True(t, renewed.Invitation.IsPending(time.Now()))
+
+	_, err = invite("dave@first-bank.com", models.RoleOwner)
+	assert.ErrorIs(t, err, ErrInvalidUserRole)
+
+	f.org.MemberCount = f.org.MaxMembers
+	_, err = invite("dave@first-bank.com", "")
+	assert.ErrorIs(t, err, ErrMemberLimitReached)
+	_, err = f.service.AcceptInvitation(ctx, &AcceptInvitationInput{Token: renewed.Token, FirstName: "Carol", LastName: "Clerk", Password: testUserPassword})
+	assert.ErrorIs(t, err, ErrMemberLimitReached)
+
+	f.org.Settings.AllowInvitations = false
+	_, err = invite("dave@first-bank.com", "")
+	assert.ErrorIs(t, err, ErrInvitationsDisabled)
+	_, err = f.service.AcceptInvitation(ctx, &AcceptInvitationInput{Token: renewed.Token, FirstName: "Carol", LastName: "Clerk", Password: testUserPassword})
+	assert.ErrorIs(t, err, ErrInvitationsDisabled)
+}
+
+func TestUserService_InvitationsRequireSigningKey(t *testing.T) {
+	f := newUserFixture(t)
+	f.service.(*userService).config.SigningKey = ""
+
+	_, err := f.service.InviteUser(context.Background(), &InviteUserInput{OrganizationID: f.org.ID.Hex(), InvitedBy: f.admin.ID.Hex(), Email: "grace@first-bank.com"})
+	assert.ErrorIs(t, err, ErrInvitationsUnavailable)
+	_, err = f.service.LookupInvitation(context.Background(), "payload.signature")
+	assert.ErrorIs(t, err, ErrInvitationsUnavailable)
+}
@@ -0,0 +1,533 @@
+// Package services provides service layer implementations for the GoEdu Control Testing Platform.
+// This file contains the webhook service which manages an organization's webhook
+// subscriptions and queues deliveries of its domain events to them.
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/webhook"
+)
+
+// Webhook errors
+var (
+	ErrWebhookNotFound         = errors.New("webhook subscription not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrWebhooksDisabled        = errors.New("webhooks are disabled for this organization")
+	ErrWebhookSigningDisabled  = errors.New("webhook signing secret is not configured")
+	ErrInvalidWebhookURL       = errors.New("webhook URL must be an absolute https URL of a public host")
+	ErrUnknownWebhookEvent     = errors.New("unknown webhook event type")
+	ErrWebhookInactive         = errors.New("webhook subscription is disabled")
+)
+
+// WebhookEventTypes lists the event types subscriptions can subscribe to.
+var WebhookEventTypes = []string{
+	models.WebhookEventControlChanged,
+	models.WebhookEventEvidenceUploaded,
+	models.WebhookEventCycleCompleted,
+	models.WebhookEventFindingApproved,
+}
+
+// Page sizes of delivery log listings.
+const (
+	defaultWebhookDeliveryPageSize = 20
+	maxWebhookDeliveryPageSize     = 100
+)
+
+// webhookService implements the WebhookService interface.
+type webhookService struct {
+	orgRepo          repositories.OrganizationRepository
+	subscriptionRepo repositories.WebhookSubscriptionRepository
+	deliveryRepo     repositories.WebhookDeliveryRepository
+	masterSecret     string
+	logger           *zap.Logger
+}
+
+// NewWebhookService creates a new webhook service.
+//
+// Parameters:
+//   - orgRepo: Repository for organization data, used to check that webhooks are enabled
+//   - subscriptionRepo: Repository for webhook subscriptions
+//   - deliveryRepo: Repository for queued deliveries and their attempt log
+//   - cfg: Webhook configuration with the master signing secret
+//   - logger: Logger for service operations
+//
+// Returns:
+//   - WebhookService: Configured webhook service instance
+func NewWebhookService(
+	orgRepo repositories.OrganizationRepository,
+	subscriptionRepo repositories.WebhookSubscriptionRepository,
+	deliveryRepo repositories.WebhookDeliveryRepository,
+	cfg config.WebhookConfig,
+	logger *zap.Logger,
+) WebhookService {
+	return &webhookService{
+		orgRepo:          orgRepo,
+		subscriptionRepo: subscriptionRepo,
+		deliveryRepo:     deliveryRepo,
+		masterSecret:     cfg.Secret,
+		logger:           logger,
+	}
+}
+
+// CreateSubscription creates an active subscription. The signing secret is
+// only returned here and by RotateSecret.
+//
+// Parameters:
+//   - ctx: Request context
+//   - input: Endpoint URL, description and event types
+//
+// Returns:
+//   - *WebhookSubscriptionSecret: Created subscription and its signing secret
+//   - error: ErrWebhooksDisabled, ErrWebhookSigningDisabled, validation or persistence error
+func (s *webhookService) CreateSubscription(ctx context.Context, input *WebhookSubscriptionInput) (*WebhookSubscriptionSecret, error) {
+	if input == nil {
+		return nil, ErrInvalidInput
+	}
+	if s.masterSecret == "" {
+		return nil, ErrWebhookSigningDisabled
+	}
+	orgID, err := primitive.ObjectIDFromHex(input.OrganizationID)
+	if err != nil {
+		return nil, ErrInvalidInput
+	}
+	createdBy, err := primitive.ObjectIDFromHex(input.CreatedBy)
+	if err != nil {
+		return nil, ErrInvalidInput
+	}
+	if err := validateWebhookURL(input.URL); err != nil {
+		return nil, err
+	}
+	events, err := normalizeWebhookEvents(input.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	org, err := s.orgRepo.GetByID(ctx, input.OrganizationID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrInvalidInput
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	if !org.Settings.Integrations.WebhooksEnabled {
+		return nil, ErrWebhooksDisabled
+	}
+
+	now := time.Now().UTC()
+	subscription := &models.WebhookSubscription{
+		ID:             primitive.NewObjectID(),
+		OrganizationID: orgID,
+		URL:            input.URL,
+		Description:    strings.TrimSpace(input.Description),
+		Events:         events,
+		IsActive:       true,
+		SecretVersion:  1,
+		CreatedBy:      createdBy,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.subscriptionRepo.Create(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+
+	s.logger.Info("Webhook subscription created",
+		zap.String("organization_id", input.OrganizationID),
+		zap.String("subscription_id", subscription.ID.Hex()),
+		zap.Strings("events", events),
+	)
+	return &WebhookSubscriptionSecret{Subscription: subscription, Secret: s.secret(subscription)}, nil
+}
+
+// GetSubscription retrieves a subscription of an organization.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization the subscription must belong to
+//   - subscriptionID: Subscription ID
+//
+// Returns:
+//   - *models.WebhookSubscription: Subscription
+//   - error: ErrWebhookNotFound or persistence error
+func (s *webhookService) GetSubscription(ctx context.Context, orgID, subscriptionID string) (*models.WebhookSubscription, error) {
+	subscription, err := s.subscriptionRepo.GetByID(ctx, subscriptionID)
+	if errors.Is(err, repositories.ErrNotFound) || (err == nil && subscription.OrganizationID.Hex() != orgID) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+	return subscription, nil
+}
+
+// ListSubscriptions retrieves all subscriptions of an organization.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization ID
+//
+// Returns:
+//   - []*models.WebhookSubscription: Subscriptions
+//   - error: Persistence error
+func (s *webhookService) ListSubscriptions(ctx context.Context, orgID string) ([]*models.WebhookSubscription, error) {
+	subscriptions, err := s.subscriptionRepo.GetByOrganization(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+	return subscriptions, nil
+}
+
+// UpdateSubscription changes a subscription. Re-enabling a subscription resets
+// its failure count, including after it was disabled automatically.
+//
+// Parameters:
+//   - ctx: Request context
+//   - input: Changes to apply; nil fields are left unchanged
+//
+// Returns:
+//   - *models.WebhookSubscription: Updated subscription
+//   - error: ErrWebhookNotFound, validation or persistence error
+func (s *webhookService) UpdateSubscription(ctx context.Context, input *UpdateWebhookSubscriptionInput) (*models.WebhookSubscription, error) {
+	if input == nil {
+		return nil, ErrInvalidInput
+	}
+	subscription, err := s.GetSubscription(ctx, input.OrganizationID, input.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.URL != nil {
+		if err := validateWebhookURL(*input.URL); err != nil {
+			return nil, err
+		}
+		subscription.URL = *input.URL
+	}
+	if input.Description != nil {
+		subscription.Description = strings.TrimSpace(*input.Description)
+	}
+	if input.Events != nil {
+		events, err := normalizeWebhookEvents(*input.Events)
+		if err != nil {
+			return nil, err
+		}
+		subscription.Events = events
+	}
+	if input.IsActive != nil {
+		switch {
+		case *input.IsActive && !subscription.IsActive:
+			subscription.IsActive = true
+			subscription.ConsecutiveFailures = 0
+			subscription.DisabledAt = time.Time{}
+			subscription.DisabledReason = ""
+		case !*input.IsActive && subscription.IsActive:
+			subscription.IsActive = false
+			subscription.DisabledAt = time.Now().UTC()
+			subscription.DisabledReason = "disabled by an administrator"
+		}
+	}
+
+	subscription.UpdatedAt = time.Now().UTC()
+	if err := s.subscriptionRepo.Update(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("failed to update webhook subscription: %w", err)
+	}
+	return subscription, nil
+}
+
+// DeleteSubscription removes a subscription. Queued deliveries of a deleted
+// subscription are dropped by the dispatcher.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization the subscription must belong to
+//   - subscriptionID: Subscription ID
+//
+// Returns:
+//   - error: ErrWebhookNotFound or persistence error
+func (s *webhookService) DeleteSubscription(ctx context.Context, orgID, subscriptionID string) error {
+	if _, err := s.GetSubscription(ctx, orgID, subscriptionID); err != nil {
+		return err
+	}
+	if err := s.subscriptionRepo.Delete(ctx, subscriptionID); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return ErrWebhookNotFound
+		}
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+
+	s.logger.Info("Webhook subscription deleted",
+		zap.String("organization_id", orgID),
+		zap.String("subscription_id", subscriptionID),
+	)
+	return nil
+}
+
+// RotateSecret replaces a subscription's signing secret. The old secret stops
+// working immediately, including for retries of queued deliveries.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization the subscription must belong to
+//   - subscriptionID: Subscription ID
+//
+// Returns:
+//   - *WebhookSubscriptionSecret: Subscription and its new signing secret
+//   - error: ErrWebhookSigningDisabled, ErrWebhookNotFound or persistence error
+func (s *webhookService) RotateSecret(ctx context.Context, orgID, subscriptionID string) (*WebhookSubscriptionSecret, error) {
+	if s.masterSecret == "" {
+		return nil, ErrWebhookSigningDisabled
+	}
+	subscription, err := s.GetSubscription(ctx, orgID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription.SecretVersion++
+	subscription.UpdatedAt = time.Now().UTC()
+	if err := s.subscriptionRepo.Update(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("failed to rotate webhook secret: %w", err)
+	}
+
+	s.logger.Info("Webhook secret rotated",
+		zap.String("organization_id", orgID),
+		zap.String("subscription_id", subscriptionID),
+		zap.Int("secret_version", subscription.SecretVersion),
+	)
+	return &WebhookSubscriptionSecret{Subscription: subscription, Secret: s.secret(subscription)}, nil
+}
+
+// ListDeliveries retrieves a page of a subscription's delivery log, newest first.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization the subscription must belong to
+//   - subscriptionID: Subscription ID
+//   - limit: Page size; zero uses the default, larger values are capped
+//   - offset: Number of deliveries to skip
+//
+// Returns:
+//   - *WebhookDeliveryConnection: Page of deliveries with their attempts
+//   - error: ErrWebhookNotFound, ErrInvalidInput or persistence error
+func (s *webhookService) ListDeliveries(ctx context.Context, orgID, subscriptionID string, limit, offset int) (*WebhookDeliveryConnection, error) {
+	if limit < 0 || offset < 0 {
+		return nil, ErrInvalidInput
+	}
+	if limit == 0 {
+		limit = defaultWebhookDeliveryPageSize
+	}
+	if limit > maxWebhookDeliveryPageSize {
+		limit = maxWebhookDeliveryPageSize
+	}
+	if _, err := s.GetSubscription(ctx, orgID, subscriptionID); err != nil {
+		return nil, err
+	}
+
+	nodes, err := s.deliveryRepo.GetBySubscription(ctx, subscriptionID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	total, err := s.deliveryRepo.CountBySubscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+
+	return &WebhookDeliveryConnection{
+		Nodes:      nodes,
+		TotalCount: int(total),
+		HasMore:    int64(offset+len(nodes)) < total,
+	}, nil
+}
+
+// Redeliver queues a new delivery with the payload of a past delivery. The
+// event ID is kept, so receivers that already processed the event can drop it.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization the subscription must belong to
+//   - subscriptionID: Subscription the delivery belongs to
+//   - deliveryID: Delivery to send again
+//
+// Returns:
+//   - *models.WebhookDelivery: Queued delivery
+//   - error: ErrWebhookNotFound, ErrWebhookDeliveryNotFound, ErrWebhookInactive or persistence error
+func (s *webhookService) Redeliver(ctx context.Context, orgID, subscriptionID, deliveryID string) (*models.WebhookDelivery, error) {
+	subscription, err := s.GetSubscription(ctx, orgID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if !subscription.IsActive {
+		return nil, ErrWebhookInactive
+	}
+
+	original, err := s.deliveryRepo.GetByID(ctx, deliveryID)
+	if errors.Is(err, repositories.ErrNotFound) || (err == nil && original.SubscriptionID != subscription.ID) {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	delivery := newWebhookDelivery(subscription, original.EventID, original.EventType, original.Payload)
+	delivery.RedeliveryOf = original.ID
+	if err := s.deliveryRepo.Create(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("failed to queue webhook redelivery: %w", err)
+	}
+
+	s.logger.Info("Webhook redelivery queued",
+		zap.String("subscription_id", subscriptionID),
+		zap.String("delivery_id", delivery.ID.Hex()),
+		zap.String("redelivery_of", deliveryID),
+	)
+	return delivery, nil
+}
+
+// Publish queues a delivery of an event to every active subscription of the
+// organization subscribed to its type. Organizations with webhooks disabled
+// are skipped without error, so callers can publish unconditionally.
+//
+// Parameters:
+//   - ctx: Request context
+//   - orgID: Organization the event belongs to
+//   - eventType: Event type, one of WebhookEventTypes
+//   - data: Event data, serialized as the "data" field of the payload
+//
+// Returns:
+//   - error: ErrUnknownWebhookEvent or persistence error
+//
+// Example:
+//
+//	err := webhookService.Publish(ctx, orgID, models.WebhookEventCycleCompleted, cycle)
+func (s *webhookService) Publish(ctx context.Context, orgID, eventType string, data interface{}) error {
+	if !isWebhookEventType(eventType) {
+		return ErrUnknownWebhookEvent
+	}
+
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return ErrInvalidInput
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get organization: %w", err)
+	}
+	if !org.Settings.Integrations.WebhooksEnabled {
+		return nil
+	}
+
+	subscriptions, err := s.subscriptionRepo.GetActiveByEvent(ctx, orgID, eventType)
+	if err != nil {
+		return fmt.Errorf("failed to get webhook subscriptions: %w", err)
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	event := &WebhookEvent{
+		ID:             "evt_" + primitive.NewObjectID().Hex(),
+		Type:           eventType,
+		OrganizationID: orgID,
+		CreatedAt:      time.Now().UTC(),
+		Data:           data,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+
+	for _, subscription := range subscriptions {
+		delivery := newWebhookDelivery(subscription, event.ID, eventType, string(payload))
+		if err := s.deliveryRepo.Create(ctx, delivery); err != nil {
+			return fmt.Errorf("failed to queue webhook delivery: %w", err)
+		}
+	}
+
+	s.logger.Debug("Webhook event queued",
+		zap.String("organization_id", orgID),
+		zap.String("event_id", event.ID),
+		zap.String("type", eventType),
+		zap.Int("subscriptions", len(subscriptions)),
+	)
+	return nil
+}
+
+// secret returns the current signing secret of a subscription.
+func (s *webhookService) secret(subscription *models.WebhookSubscription) string {
+	return webhook.DeriveSecret(s.masterSecret, subscription.ID.Hex(), subscription.SecretVersion)
+}
+
+// newWebhookDelivery creates a pending delivery of an event to a subscription, due now.
+func newWebhookDelivery(subscription *models.WebhookSubscription, eventID, eventType, payload string) *models.WebhookDelivery {
+	now := time.Now().UTC()
+	return &models.WebhookDelivery{
+		ID:             primitive.NewObjectID(),
+		OrganizationID: subscription.OrganizationID,
+		SubscriptionID: subscription.ID,
+		EventID:        eventID,
+		EventType:      eventType,
+		Payload:        payload,
+		Status:         models.WebhookDeliveryPending,
+		Attempts:       []models.WebhookAttempt{},
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+	}
+}
+
+// validateWebhookURL accepts absolute https URLs with a host. Plain http is
+// rejected because payloads carry organization data. Hosts that are
+// obviously not public, such as localhost or a private IP address, are
+// rejected here; host names are checked again after DNS resolution whenever
+// the sender connects.
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Host == "" || u.User != nil {
+		return ErrInvalidWebhookURL
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "" || host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrInvalidWebhookURL
+	}
+	if ip := net.ParseIP(host); ip != nil && !webhook.IsPublicIP(ip) {
+		return ErrInvalidWebhookURL
+	}
+	return nil
+}
+
+// normalizeWebhookEvents validates event types and removes duplicates.
+func normalizeWebhookEvents(events []string) ([]string, error) {
+	if len(events) == 0 {
+		return nil, ErrUnknownWebhookEvent
+	}
+	normalized := make([]string, 0, len(events))
+	seen := make(map[string]bool, len(events))
+	for _, event := range events {
+		if !isWebhookEventType(event) {
+			return nil, ErrUnknownWebhookEvent
+		}
+		if !seen[event] {
+			seen[event] = true
+			normalized = append(normalized, event)
+		}
+	}
+	return normalized, nil
+}
+
+// isWebhookEventType reports whether eventType is a known event type.
+func isWebhookEventType(eventType string) bool {
+	for _, known := range WebhookEventTypes {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
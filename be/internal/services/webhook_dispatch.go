@@ -0,0 +1,260 @@
+// Package services provides service layer implementations for the GoEdu Control Testing Platform.
+// This file contains the webhook dispatcher which sends queued webhook deliveries,
+// retries failures with exponential backoff and disables failing subscriptions.
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/webhook"
+)
+
+// webhookDispatcher implements the WebhookDispatcher interface.
+type webhookDispatcher struct {
+	subscriptionRepo     repositories.WebhookSubscriptionRepository
+	deliveryRepo         repositories.WebhookDeliveryRepository
+	sender               webhook.Sender
+	masterSecret         string
+	batchSize            int
+	maxAttempts          int
+	retryDelay           time.Duration
+	maxRetryDelay        time.Duration
+	disableAfterFailures int
+	lease                time.Duration
+	logger               *zap.Logger
+}
+
+// NewWebhookDispatcher creates a new webhook dispatcher.
+//
+// Parameters:
+//   - subscriptionRepo: Repository for webhook subscriptions
+//   - deliveryRepo: Repository for queued deliveries and their attempt log
+//   - sender: Webhook sender, typically an HTTP sender
+//   - cfg: Webhook configuration with the master secret and retry settings
+//   - logger: Logger for dispatcher operations
+//
+// Returns:
+//   - WebhookDispatcher: Configured dispatcher instance
+//
+// Example:
+//
+//	sender := webhook.NewHTTPSender(cfg.Webhook.Timeout)
+//	dispatcher := services.NewWebhookDispatcher(subscriptionRepo, deliveryRepo, sender, cfg.Webhook, logger)
+func NewWebhookDispatcher(
+	subscriptionRepo repositories.WebhookSubscriptionRepository,
+	deliveryRepo repositories.WebhookDeliveryRepository,
+	sender webhook.Sender,
+	cfg config.WebhookConfig,
+	logger *zap.Logger,
+) WebhookDispatcher {
+	// A lease outlives the request, so a delivery is only reclaimed when its
+	// dispatcher died mid-send
+	lease := 2 * cfg.Timeout
+	if lease < time.Minute {
+		lease = time.Minute
+	}
+
+	return &webhookDispatcher{
+		subscriptionRepo:     subscriptionRepo,
+		deliveryRepo:         deliveryRepo,
+		sender:               sender,
+		masterSecret:         cfg.Secret,
+		batchSize:            cfg.BatchSize,
+		maxAttempts:          cfg.RetryCount + 1,
+		retryDelay:           cfg.RetryDelay,
+		maxRetryDelay:        cfg.MaxRetryDelay,
+		disableAfterFailures: cfg.DisableAfterFailures,
+		lease:                lease,
+		logger:               logger,
+	}
+}
+
+// DispatchPending leases and sends due deliveries, up to the configured batch
+// size. Failed sends are rescheduled with exponential backoff until they run
+// out of attempts.
+//
+// Parameters:
+//   - ctx: Request context
+//
+// Returns:
+//   - int: Number of deliveries attempted
+//   - error: Error if the deliveries cannot be read; send failures are recorded on the deliveries
+func (d *webhookDispatcher) DispatchPending(ctx context.Context) (int, error) {
+	attempted := 0
+	for attempted < d.batchSize {
+		if err := ctx.Err(); err != nil {
+			return attempted, err
+		}
+
+		now := time.Now().UTC()
+		delivery, err := d.deliveryRepo.ClaimDue(ctx, now, now.Add(d.lease))
+		if errors.Is(err, repositories.ErrNotFound) {
+			return attempted, nil
+		}
+		if err != nil {
+			return attempted, fmt.Errorf("failed to claim webhook delivery: %w", err)
+		}
+
+		attempted++
+		d.deliver(ctx, delivery)
+	}
+	return attempted, nil
+}
+
+// deliver sends one leased delivery and records the outcome on the delivery
+// and its subscription.
+func (d *webhookDispatcher) deliver(ctx context.Context, delivery *models.WebhookDelivery) {
+	subscription, err := d.subscriptionRepo.GetByID(ctx, delivery.SubscriptionID.Hex())
+	switch {
+	case errors.Is(err, repositories.ErrNotFound):
+		d.drop(ctx, delivery, "subscription was deleted")
+		return
+	case err != nil:
+		// The lease expires and the delivery is claimed again
+		d.logger.Error("Failed to get webhook subscription",
+			zap.Error(err),
+			zap.String("delivery_id", delivery.ID.Hex()),
+		)
+		return
+	case !subscription.IsActive:
+		d.drop(ctx, delivery, "subscription is disabled")
+		return
+	}
+
+	attempt := models.WebhookAttempt{AttemptedAt: time.Now().UTC()}
+	var sendErr error
+	if d.masterSecret == "" {
+		sendErr = ErrWebhookSigningDisabled
+	} else {
+		var resp *webhook.Response
+		resp, sendErr = d.sender.Send(ctx, &webhook.Request{
+			URL:        subscription.URL,
+			Event:      delivery.EventType,
+			DeliveryID: delivery.ID.Hex(),
+			Secret:     webhook.DeriveSecret(d.masterSecret, subscription.ID.Hex(), subscription.SecretVersion),
+			Body:       []byte(delivery.Payload),
+		})
+		if resp != nil {
+			attempt.StatusCode = resp.StatusCode
+			attempt.ResponseBody = resp.Body
+			attempt.DurationMS = resp.Duration.Milliseconds()
+			if sendErr == nil && !resp.Succeeded() {
+				sendErr = fmt.Errorf("receiver responded with status %d", resp.StatusCode)
+			}
+		}
+	}
+	if errors.Is(sendErr, webhook.ErrForbiddenDestination) {
+		// The delivery log is visible to the tenant; do not reveal what
+		// their host name resolved to
+		sendErr = webhook.ErrForbiddenDestination
+	}
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+	}
+
+	now := time.Now().UTC()
+	delivery.Attempts = append(delivery.Attempts, attempt)
+	delivery.LockedUntil = time.Time{}
+	switch {
+	case sendErr == nil:
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.DeliveredAt = now
+		delivery.LastError = ""
+	case len(delivery.Attempts) >= d.maxAttempts:
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.LastError = sendErr.Error()
+		d.logger.Warn("Webhook delivery failed permanently",
+			zap.Error(sendErr),
+			zap.String("delivery_id", delivery.ID.Hex()),
+			zap.String("subscription_id", subscription.ID.Hex()),
+			zap.Int("attempts", len(delivery.Attempts)),
+		)
+	default:
+		delivery.Status = models.WebhookDeliveryPending
+		delivery.NextAttemptAt = now.Add(d.backoff(len(delivery.Attempts)))
+		delivery.LastError = sendErr.Error()
+		d.logger.Debug("Webhook delivery failed, will retry",
+			zap.Error(sendErr),
+			zap.String("delivery_id", delivery.ID.Hex()),
+			zap.Int("attempts", len(delivery.Attempts)),
+			zap.Time("next_attempt_at", delivery.NextAttemptAt),
+		)
+	}
+
+	if err := d.deliveryRepo.Update(ctx, delivery); err != nil {
+		// The lease expires and the delivery is retried; a delivered webhook may be sent again
+		d.logger.Error("Failed to record webhook delivery",
+			zap.Error(err),
+			zap.String("delivery_id", delivery.ID.Hex()),
+			zap.String("status", delivery.Status),
+		)
+		return
+	}
+
+	switch delivery.Status {
+	case models.WebhookDeliverySucceeded:
+		if subscription.ConsecutiveFailures > 0 {
+			subscription.ConsecutiveFailures = 0
+			d.updateSubscription(ctx, subscription)
+		}
+	case models.WebhookDeliveryFailed:
+		subscription.ConsecutiveFailures++
+		if subscription.ConsecutiveFailures >= d.disableAfterFailures {
+			subscription.IsActive = false
+			subscription.DisabledAt = now
+			subscription.DisabledReason = fmt.Sprintf("disabled after %d consecutive failed deliveries", subscription.ConsecutiveFailures)
+			d.logger.Warn("Webhook subscription disabled after repeated failures",
+				zap.String("organization_id", subscription.OrganizationID.Hex()),
+				zap.String("subscription_id", subscription.ID.Hex()),
+				zap.Int("consecutive_failures", subscription.ConsecutiveFailures),
+			)
+		}
+		d.updateSubscription(ctx, subscription)
+	}
+}
+
+// drop fails a delivery without sending it, because its subscription can no
+// longer receive webhooks.
+func (d *webhookDispatcher) drop(ctx context.Context, delivery *models.WebhookDelivery, reason string) {
+	delivery.Status = models.WebhookDeliveryFailed
+	delivery.LockedUntil = time.Time{}
+	delivery.LastError = reason
+	if err := d.deliveryRepo.Update(ctx, delivery); err != nil {
+		d.logger.Error("Failed to record dropped webhook delivery",
+			zap.Error(err),
+			zap.String("delivery_id", delivery.ID.Hex()),
+		)
+	}
+}
+
+// updateSubscription saves a subscription's failure state.
+func (d *webhookDispatcher) updateSubscription(ctx context.Context, subscription *models.WebhookSubscription) {
+	subscription.UpdatedAt = time.Now().UTC()
+	if err := d.subscriptionRepo.Update(ctx, subscription); err != nil {
+		d.logger.Error("Failed to update webhook subscription",
+			zap.Error(err),
+			zap.String("subscription_id", subscription.ID.Hex()),
+		)
+	}
+}
+
+// backoff returns the delay before the next attempt after the given number of
+// failed attempts: the retry delay doubled per attempt, capped at the max delay.
+func (d *webhookDispatcher) backoff(attempts int) time.Duration {
+	delay := d.retryDelay
+	for i := 1; i < attempts && delay < d.maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > d.maxRetryDelay {
+		delay = d.maxRetryDelay
+	}
+	return delay
+}
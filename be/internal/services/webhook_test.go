@@ -0,0 +1,495 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/config"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/models"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/internal/repositories"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/webhook"
+)
+
+type fakeWebhookSubscriptionRepository struct {
+	repositories.WebhookSubscriptionRepository
+	mu            sync.Mutex
+	subscriptions []*models.WebhookSubscription
+}
+
+func (r *fakeWebhookSubscriptionRepository) Create(ctx context.Context, subscription *models.WebhookSubscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscriptions = append(r.subscriptions, subscription)
+	return nil
+}
+
+func (r *fakeWebhookSubscriptionRepository) GetByID(ctx context.Context, id string) (*models.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, subscription := range r.subscriptions {
+		if subscription.ID.Hex() == id {
+			return subscription, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r *fakeWebhookSubscriptionRepository) Update(ctx context.Context, subscription *models.WebhookSubscription) error {
+	return nil
+}
+
+func (r *fakeWebhookSubscriptionRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, subscription := range r.subscriptions {
+		if subscription.ID.Hex() == id {
+			r.subscriptions = append(r.subscriptions[:i], r.subscriptions[i+1:]...)
+			return nil
+		}
+	}
+	return repositories.ErrNotFound
+}
+
+func (r *fakeWebhookSubscriptionRepository) GetByOrganization(ctx context.Context, orgID string) ([]*models.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var matching []*models.WebhookSubscription
+	for _, subscription := range r.subscriptions {
+		if subscription.OrganizationID.Hex() == orgID {
+			matching = append(matching, subscription)
+		}
+	}
+	return matching, nil
+}
+
+func (r *fakeWebhookSubscriptionRepository) GetActiveByEvent(ctx context.Context, orgID, eventType string) ([]*models.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var matching []*models.WebhookSubscription
+	for _, subscription := range r.subscriptions {
+		if subscription.OrganizationID.Hex() == orgID && subscription.IsActive && subscription.Subscribes(eventType) {
+			matching = append(matching, subscription)
+		}
+	}
+	return matching, nil
+}
+
+type fakeWebhookDeliveryRepository struct {
+	repositories.WebhookDeliveryRepository
+	mu         sync.Mutex
+	deliveries []*models.WebhookDelivery
+}
+
+func (r *fakeWebhookDeliveryRepository) Create(ctx context.Context, delivery *models.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deliveries = append(r.deliveries, delivery)
+	return nil
+}
+
+func (r *fakeWebhookDeliveryRepository) GetByID(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, delivery := range r.deliveries {
+		if delivery.ID.Hex() == id {
+			return delivery, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r *fakeWebhookDeliveryRepository) Update(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return nil
+}
+
+// bySubscription returns a subscription's deliveries, newest first.
+func (r *fakeWebhookDeliveryRepository) bySubscription(subscriptionID string) []*models.WebhookDelivery {
+	var matching []*models.WebhookDelivery
+	for i := len(r.deliveries) - 1; i >= 0; i-- {
+		if r.deliveries[i].SubscriptionID.Hex() == subscriptionID {
+			matching = append(matching, r.deliveries[i])
+		}
+	}
+	return matching
+}
+
+func (r *fakeWebhookDeliveryRepository) GetBySubscription(ctx context.Context, subscriptionID string, limit, offset int) ([]*models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	matching := r.bySubscription(subscriptionID)
+	if offset >= len(matching) {
+		return nil, nil
+	}
+	matching = matching[offset:]
+	if len(matching) > limit {
+		matching = matching[:limit]
+	}
+	return matching, nil
+}
+
+func (r *fakeWebhookDeliveryRepository) CountBySubscription(ctx context.Context, subscriptionID string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return int64(len(r.bySubscription(subscriptionID))), nil
+}
+
+func (r *fakeWebhookDeliveryRepository) ClaimDue(ctx context.Context, now, leaseUntil time.Time) (*models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, delivery := range r.deliveries {
+		due := delivery.Status == models.WebhookDeliveryPending && !delivery.NextAttemptAt.After(now)
+		abandoned := delivery.Status == models.WebhookDeliveryDelivering && !delivery.LockedUntil.After(now)
+		if due || abandoned {
+			delivery.Status = models.WebhookDeliveryDelivering
+			delivery.LockedUntil = leaseUntil
+			return delivery, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+// fakeWebhookSender records webhook requests and answers them with the status
+// returned by respond, or 200 when respond is nil.
+type fakeWebhookSender struct {
+	mu       sync.Mutex
+	requests []*webhook.Request
+	respond  func(req *webhook.Request) (*webhook.Response, error)
+}
+
+func (s *fakeWebhookSender) Send(ctx context.Context, req *webhook.Request) (*webhook.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+	if s.respond != nil {
+		return s.respond(req)
+	}
+	return &webhook.Response{StatusCode: 200, Body: "ok", Duration: time.Millisecond}, nil
+}
+
+type webhookFixture struct {
+	org           *models.Organization
+	adminID       string
+	subscriptions *fakeWebhookSubscriptionRepository
+	deliveries    *fakeWebhookDeliveryRepository
+	sender        *fakeWebhookSender
+	cfg           config.WebhookConfig
+	service       WebhookService
+	dispatcher    WebhookDispatcher
+}
+
+func newWebhookFixture(t *testing.T) *webhookFixture {
+	t.Helper()
+
+	org := &models.Organization{Name: "First Bank", Status: models.OrganizationStatusActive}
+	org.ID = primitive.NewObjectID()
+	org.Settings.Integrations.WebhooksEnabled = true
+
+	f := &webhookFixture{
+		org:           org,
+		adminID:       primitive.NewObjectID().Hex(),
+		subscriptions: &fakeWebhookSubscriptionRepository{},
+		deliveries:    &fakeWebhookDeliveryRepository{},
+		sender:        &fakeWebhookSender{},
+		cfg: config.WebhookConfig{
+			Secret:               "master-secret",
+			Timeout:              5 * time.Second,
+			RetryCount:           2,
+			RetryDelay:           time.Minute,
+			MaxRetryDelay:        time.Hour,
+			BatchSize:            10,
+			DisableAfterFailures: 2,
+		},
+	}
+	f.service = NewWebhookService(newFakeOrganizationRepository(org), f.subscriptions, f.deliveries, f.cfg, zap.NewNop())
+	f.dispatcher = NewWebhookDispatcher(f.subscriptions, f.deliveries, f.sender, f.cfg, zap.NewNop())
+	return f
+}
+
+// subscribe creates a subscription to the given events.
+func (f *webhookFixture) subscribe(t *testing.T, events ...string) *WebhookSubscriptionSecret {
+	t.Helper()
+	created, err := f.service.CreateSubscription(context.Background(), &WebhookSubscriptionInput{
+		OrganizationID: f.org.ID.Hex(),
+		CreatedBy:      f.adminID,
+		URL:            "https://hooks.bank.test/goedu",
+		Events:         events,
+	})
+	require.NoError(t, err)
+	return created
+}
+
+// makeDue lets the dispatcher retry a delivery without waiting for its backoff.
+func makeDue(delivery *models.WebhookDelivery) {
+	delivery.NextAttemptAt = time.Now().Add(-time.Second)
+}
+
+func TestWebhookService_PublishAndDispatch(t *testing.T) {
+	f := newWebhookFixture(t)
+	ctx := context.Background()
+	cycles := f.subscribe(t, models.WebhookEventCycleCompleted, models.WebhookEventCycleCompleted)
+	f.subscribe(t, models.WebhookEventEvidenceUploaded)
+
+	assert.Equal(t, []string{models.WebhookEventCycleCompleted}, cycles.Subscription.Events, "duplicates are removed")
+	assert.Equal(t, webhook.DeriveSecret("master-secret", cycles.Subscription.ID.Hex(), 1), cycles.Secret)
+
+	data := map[string]string{"cycle_id": "c1"}
+	require.NoError(t, f.service.Publish(ctx, f.org.ID.Hex(), models.WebhookEventCycleCompleted, data))
+	require.Len(t, f.deliveries.deliveries, 1, "only subscribers of the event get a delivery")
+
+	attempted, err := f.dispatcher.DispatchPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempted)
+
+	require.Len(t, f.sender.requests, 1)
+	request := f.sender.requests[0]
+	delivery := f.deliveries.deliveries[0]
+	assert.Equal(t, "https://hooks.bank.test/goedu", request.URL)
+	assert.Equal(t, models.WebhookEventCycleCompleted, request.Event)
+	assert.Equal(t, delivery.ID.Hex(), request.DeliveryID)
+	assert.Equal(t, cycles.Secret, request.Secret)
+
+	var event WebhookEvent
+	require.NoError(t, json.Unmarshal(request.Body, &event))
+	assert.Equal(t, delivery.EventID, event.ID)
+	assert.Equal(t, models.WebhookEventCycleCompleted, event.Type)
+	assert.Equal(t, f.org.ID.Hex(), event.OrganizationID)
+	assert.Equal(t, map[string]interface{}{"cycle_id": "c1"}, event.Data)
+
+	assert.Equal(t, models.WebhookDeliverySucceeded, delivery.Status)
+	assert.False(t, delivery.DeliveredAt.IsZero())
+	require.Len(t, delivery.Attempts, 1)
+	assert.Equal(t, 200, delivery.Attempts[0].StatusCode)
+
+	// Organizations with webhooks disabled publish nothing
+	f.org.Settings.Integrations.WebhooksEnabled = false
+	require.NoError(t, f.service.Publish(ctx, f.org.ID.Hex(), models.WebhookEventCycleCompleted, data))
+	assert.Len(t, f.deliveries.deliveries, 1)
+	_, err = f.service.CreateSubscription(ctx, &WebhookSubscriptionInput{
+		OrganizationID: f.org.ID.Hex(),
+		CreatedBy:      f.adminID,
+		URL:            "https://hooks.bank.test/other",
+		Events:         []string{models.WebhookEventControlChanged},
+	})
+	assert.ErrorIs(t, err, ErrWebhooksDisabled)
+
+	assert.ErrorIs(t, f.service.Publish(ctx, f.org.ID.Hex(), "control.deleted", data), ErrUnknownWebhookEvent)
+}
+
+func TestWebhookService_ValidatesSubscriptions(t *testing.T) {
+	f := newWebhookFixture(t)
+	ctx := context.Background()
+	input := func(url string, events ...string) *WebhookSubscriptionInput {
+		return &WebhookSubscriptionInput{OrganizationID: f.org.ID.Hex(), CreatedBy: f.adminID, URL: url, Events: events}
+	}
+
+	_, err := f.service.CreateSubscription(ctx, input("http://hooks.bank.test", models.WebhookEventControlChanged))
+	assert.ErrorIs(t, err, ErrInvalidWebhookURL)
+	_, err = f.service.CreateSubscription(ctx, input("https://", models.WebhookEventControlChanged))
+	assert.ErrorIs(t, err, ErrInvalidWebhookURL)
+	for _, url := range []string{"https://localhost/hooks", "https://127.0.0.1/hooks", "https://10.0.0.5/hooks",
+		"https://169.254.169.254/latest/meta-data", "https://[::1]/hooks", "https://[fd00:ec2::254]/hooks"} {
+		_, err = f.service.CreateSubscription(ctx, input(url, models.WebhookEventControlChanged))
+		assert.ErrorIs(t, err, ErrInvalidWebhookURL, url)
+	}
+	_, err = f.service.CreateSubscription(ctx, input("https://hooks.bank.test", "control.deleted"))
+	assert.ErrorIs(t, err, ErrUnknownWebhookEvent)
+	_, err = f.service.CreateSubscription(ctx, input("https://hooks.bank.test"))
+	assert.ErrorIs(t, err, ErrUnknownWebhookEvent)
+
+	// Subscriptions of other organizations are not visible
+	created := f.subscribe(t, models.WebhookEventControlChanged)
+	_, err = f.service.GetSubscription(ctx, primitive.NewObjectID().Hex(), created.Subscription.ID.Hex())
+	assert.ErrorIs(t, err, ErrWebhookNotFound)
+	assert.ErrorIs(t, f.service.DeleteSubscription(ctx, primitive.NewObjectID().Hex(), created.Subscription.ID.Hex()), ErrWebhookNotFound)
+
+	// Without a master secret nothing can be signed
+	f.cfg.Secret = ""
+	unsigned := NewWebhookService(newFakeOrganizationRepository(f.org), f.subscriptions, f.deliveries, f.cfg, zap.NewNop())
+	_, err = unsigned.CreateSubscription(ctx, input("https://hooks.bank.test", models.WebhookEventControlChanged))
+	assert.ErrorIs(t, err, ErrWebhookSigningDisabled)
+	_, err = unsigned.RotateSecret(ctx, f.org.ID.Hex(), created.Subscription.ID.Hex())
+	assert.ErrorIs(t, err, ErrWebhookSigningDisabled)
+}
+
+func TestWebhookService_RotateSecret(t *testing.T) {
+	f := newWebhookFixture(t)
+	ctx := context.Background()
+	created := f.subscribe(t, models.WebhookEventFindingApproved)
+
+	rotated, err := f.service.RotateSecret(ctx, f.org.ID.Hex(), created.Subscription.ID.Hex())
+	require.NoError(t, err)
+	assert.NotEqual(t, created.Secret, rotated.Secret)
+	assert.Equal(t, 2, rotated.Subscription.SecretVersion)
+
+	// Queued deliveries are signed with the current secret
+	require.NoError(t, f.service.Publish(ctx, f.org.ID.Hex(), models.WebhookEventFindingApproved, nil))
+	_, err = f.dispatcher.DispatchPending(ctx)
+	require.NoError(t, err)
+	require.Len(t, f.sender.requests, 1)
+	assert.Equal(t, rotated.Secret, f.sender.requests[0].Secret)
+}
+
+func TestWebhookDispatcher_RetriesAndDisables(t *testing.T) {
+	f := newWebhookFixture(t)
+	ctx := context.Background()
+	created := f.subscribe(t, models.WebhookEventControlChanged)
+	subscription := created.Subscription
+	f.sender.respond = func(req *webhook.Request) (*webhook.Response, error) {
+		return &webhook.Response{StatusCode: 503, Body: "maintenance"}, nil
+	}
+
+	require.NoError(t, f.service.Publish(ctx, f.org.ID.Hex(), models.WebhookEventControlChanged, nil))
+	delivery := f.deliveries.deliveries[0]
+
+	_, err := f.dispatcher.DispatchPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, models.WebhookDeliveryPending, delivery.Status)
+	assert.Contains(t, delivery.LastError, "503")
+	assert.Equal(t, "maintenance", delivery.Attempts[0].ResponseBody)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), delivery.NextAttemptAt, 5*time.Second)
+
+	attempted, err := f.dispatcher.DispatchPending(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, attempted, "the delivery is not due before its backoff elapses")
+
+	makeDue(delivery)
+	_, err = f.dispatcher.DispatchPending(ctx)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(2*time.Minute), delivery.NextAttemptAt, 5*time.Second, "backoff doubles")
+
+	// Connection errors are retried like error responses until attempts run out
+	f.sender.respond = func(req *webhook.Request) (*webhook.Response, error) {
+		return nil, errors.New("connection refused")
+	}
+	makeDue(delivery)
+	_, err = f.dispatcher.DispatchPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, models.WebhookDeliveryFailed, delivery.Status)
+	assert.Len(t, delivery.Attempts, 3)
+	assert.Equal(t, "connection refused", delivery.Attempts[2].Error)
+	assert.Equal(t, 1, subscription.ConsecutiveFailures)
+	assert.True(t, subscription.IsActive)
+
+	// A success resets the failure count
+	f.sender.respond = nil
+	require.NoError(t, f.service.Publish(ctx, f.org.ID.Hex(), models.WebhookEventControlChanged, nil))
+	_, err = f.dispatcher.DispatchPending(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, subscription.ConsecutiveFailures)
+
+	// Consecutive failed deliveries disable the subscription
+	f.sender.respond = func(req *webhook.Request) (*webhook.Response, error) {
+		return &webhook.Response{StatusCode: 500}, nil
+	}
+	for i := 0; i < f.cfg.DisableAfterFailures; i++ {
+		require.NoError(t, f.service.Publish(ctx, f.org.ID.Hex(), models.WebhookEventControlChanged, nil))
+		for _, d := range f.deliveries.deliveries {
+			for d.Status == models.WebhookDeliveryPending {
+				makeDue(d)
+				_, err = f.dispatcher.DispatchPending(ctx)
+				require.NoError(t, err)
+			}
+		}
+	}
+	assert.False(t, subscription.IsActive)
+	assert.False(t, subscription.DisabledAt.IsZero())
+	assert.Contains(t, subscription.DisabledReason, "consecutive failed deliveries")
+
+	// Disabled subscriptions get no new deliveries and refuse redeliveries
+	sent := len(f.sender.requests)
+	require.NoError(t, f.service.Publish(ctx, f.org.ID.Hex(), models.WebhookEventControlChanged, nil))
+	_, err = f.dispatcher.DispatchPending(ctx)
+	require.NoError(t, err)
+	assert.Len(t, f.sender.requests, sent)
+	_, err = f.service.Redeliver(ctx, f.org.ID.Hex(), subscription.ID.Hex(), delivery.ID.Hex())
+	assert.ErrorIs(t, err, ErrWebhookInactive)
+
+	// Re-enabling resets the failures
+	enabled := true
+	updated, err := f.service.UpdateSubscription(ctx, &UpdateWebhookSubscriptionInput{
+		OrganizationID: f.org.ID.Hex(),
+		SubscriptionID: subscription.ID.Hex(),
+		IsActive:       &enabled,
+	})
+	require.NoError(t, err)
+	assert.True(t, updated.IsActive)
+	assert.Zero(t, updated.ConsecutiveFailures)
+	assert.Empty(t, updated.DisabledReason)
+}
+
+func TestWebhookDispatcher_HidesForbiddenDestinations(t *testing.T) {
+	f := newWebhookFixture(t)
+	ctx := context.Background()
+	f.subscribe(t, models.WebhookEventControlChanged)
+	f.sender.respond = func(req *webhook.Request) (*webhook.Response, error) {
+		return nil, fmt.Errorf("webhook: request failed: dial tcp 10.0.0.5:443: %w", webhook.ErrForbiddenDestination)
+	}
+
+	require.NoError(t, f.service.Publish(ctx, f.org.ID.Hex(), models.WebhookEventControlChanged, nil))
+	_, err := f.dispatcher.DispatchPending(ctx)
+	require.NoError(t, err)
+
+	delivery := f.deliveries.deliveries[0]
+	require.Len(t, delivery.Attempts, 1)
+	assert.Equal(t, webhook.ErrForbiddenDestination.Error(), delivery.Attempts[0].Error, "the resolved address is not recorded")
+	assert.Empty(t, delivery.Attempts[0].ResponseBody)
+	assert.Zero(t, delivery.Attempts[0].StatusCode)
+	assert.NotContains(t, delivery.LastError, "10.0.0.5")
+}
+
+func TestWebhookService_RedeliverAndListDeliveries(t *testing.T) {
+	f := newWebhookFixture(t)
+	ctx := context.Background()
+	created := f.subscribe(t, models.WebhookEventEvidenceUploaded)
+	subscriptionID := created.Subscription.ID.Hex()
+
+	require.NoError(t, f.service.Publish(ctx, f.org.ID.Hex(), models.WebhookEventEvidenceUploaded, map[string]string{"file": "policy.pdf"}))
+	_, err := f.dispatcher.DispatchPending(ctx)
+	require.NoError(t, err)
+	original := f.deliveries.deliveries[0]
+
+	redelivery, err := f.service.Redeliver(ctx, f.org.ID.Hex(), subscriptionID, original.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, original.ID, redelivery.RedeliveryOf)
+	assert.Equal(t, original.EventID, redelivery.EventID)
+	assert.Equal(t, original.Payload, redelivery.Payload)
+	assert.Equal(t, models.WebhookDeliveryPending, redelivery.Status)
+
+	_, err = f.dispatcher.DispatchPending(ctx)
+	require.NoError(t, err)
+	require.Len(t, f.sender.requests, 2)
+	assert.Equal(t, f.sender.requests[0].Body, f.sender.requests[1].Body)
+	assert.NotEqual(t, f.sender.requests[0].DeliveryID, f.sender.requests[1].DeliveryID)
+
+	page, err := f.service.ListDeliveries(ctx, f.org.ID.Hex(), subscriptionID, 1, 0)
+	require.NoError(t, err)
+	require.Len(t, page.Nodes, 1)
+	assert.Equal(t, redelivery.ID, page.Nodes[0].ID, "newest first")
+	assert.Equal(t, 2, page.TotalCount)
+	assert.True(t, page.HasMore)
+
+	_, err = f.service.Redeliver(ctx, f.org.ID.Hex(), subscriptionID, primitive.NewObjectID().Hex())
+	assert.ErrorIs(t, err, ErrWebhookDeliveryNotFound)
+	_, err = f.service.ListDeliveries(ctx, f.org.ID.Hex(), subscriptionID, -1, 0)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	// Deliveries of deleted subscriptions are dropped without sending
+	require.NoError(t, f.service.Publish(ctx, f.org.ID.Hex(), models.WebhookEventEvidenceUploaded, nil))
+	require.NoError(t, f.service.DeleteSubscription(ctx, f.org.ID.Hex(), subscriptionID))
+	_, err = f.dispatcher.DispatchPending(ctx)
+	require.NoError(t, err)
+	assert.Len(t, f.sender.requests, 2)
+	dropped := f.deliveries.deliveries[len(f.deliveries.deliveries)-1]
+	assert.Equal(t, models.WebhookDeliveryFailed, dropped.Status)
+	assert.Equal(t, "subscription was deleted", dropped.LastError)
+}
@@ -0,0 +1,86 @@
+package auditchain_test
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/auditchain"
+)
+
+func TestComputeHash_StableAcrossBSONRoundTrip(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 123456789, time.UTC)
+	id := primitive.NewObjectID()
+
+	original := map[string]interface{}{
+		"id":        id,
+		"timestamp": now,
+		"new_values": map[string]interface{}{
+			"tags":   []interface{}{"a", "b"},
+			"nested": map[string]interface{}{"count": 3},
+		},
+	}
+	// Shape of the same entry as decoded from MongoDB
+	decoded := map[string]interface{}{
+		"id":        id.Hex(),
+		"timestamp": primitive.NewDateTimeFromTime(now),
+		"new_values": primitive.D{
+			{Key: "tags", Value: primitive.A{"a", "b"}},
+			{Key: "nested", Value: primitive.D{{Key: "count", Value: int32(3)}}},
+		},
+	}
+
+	h1, err := auditchain.ComputeHash(auditchain.GenesisHash, 1, original)
+	require.NoError(t, err)
+	h2, err := auditchain.ComputeHash(auditchain.GenesisHash, 1, decoded)
+	require.NoError(t, err)
+	assert.Equal(t, h1, h2)
+	assert.Len(t, h1, 64)
+}
+
+func TestComputeHash_CoversLinkAndSequence(t *testing.T) {
+	fields := map[string]interface{}{"action": "x"}
+
+	base, err := auditchain.ComputeHash(auditchain.GenesisHash, 1, fields)
+	require.NoError(t, err)
+
+	otherSequence, _ := auditchain.ComputeHash(auditchain.GenesisHash, 2, fields)
+	otherPrevious, _ := auditchain.ComputeHash(base, 1, fields)
+	otherFields, _ := auditchain.ComputeHash(auditchain.GenesisHash, 1, map[string]interface{}{"action": "y"})
+
+	assert.NotEqual(t, base, otherSequence)
+	assert.NotEqual(t, base, otherPrevious)
+	assert.NotEqual(t, base, otherFields)
+}
+
+func TestCheckpointSignature(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	key, err := auditchain.ParsePrivateKey(base64.StdEncoding.EncodeToString(seed))
+	require.NoError(t, err)
+	public := key.Public().(ed25519.PublicKey)
+
+	checkpoint := auditchain.Checkpoint{
+		OrganizationID: "org-1",
+		Sequence:       42,
+		Hash:           auditchain.GenesisHash,
+		CreatedAt:      time.Now(),
+	}
+	signature := auditchain.Sign(key, checkpoint)
+	assert.True(t, auditchain.VerifySignature(public, checkpoint, signature))
+
+	tampered := checkpoint
+	tampered.Sequence = 41
+	assert.False(t, auditchain.VerifySignature(public, tampered, signature))
+	assert.False(t, auditchain.VerifySignature(public, checkpoint, "not-base64"))
+
+	_, err = auditchain.ParsePrivateKey("c2hvcnQ=")
+	assert.ErrorIs(t, err, auditchain.ErrInvalidKey)
+}
@@ -94,6 +94,24 @@ func (ph *PasswordHasher) HashPassword(password string) (string, error) {
 	return string(hash), nil
 }
 
+// HashSecret creates a bcrypt hash of a short secret that is not a password,
+// such as a security question answer, without applying the password length
+// rules. Verify it with VerifyPassword.
+//
+// Parameters:
+//   - secret: plaintext secret to hash (max 72 bytes, the bcrypt limit)
+//
+// Returns:
+//   - Bcrypt hash string safe for database storage
+//   - Error if the secret is too long or hashing fails
+func (ph *PasswordHasher) HashSecret(secret string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), ph.cost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash secret: %w", err)
+	}
+	return string(hash), nil
+}
+
 // VerifyPassword compares a plaintext password against a bcrypt hash.
 // Implements constant-time comparison to prevent timing attacks.
 //
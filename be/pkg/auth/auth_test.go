@@ -66,6 +66,15 @@ func TestPasswordHasher(t *testing.T) {
 		assert.Contains(t, err.Error(), "maximum length")
 	})
 
+	t.Run("hash short secret", func(t *testing.T) {
+		hash, err := hasher.HashSecret("rex")
+		require.NoError(t, err)
+		
+		valid, err := hasher.VerifyPassword("rex", hash)
+		require.NoError(t, err)
+		assert.True(t, valid)
+	})
+
 	t.Run("get hash cost", func(t *testing.T) {
 		password := "SecurePassword123!"
 		
@@ -0,0 +1,52 @@
+package cachetest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrUnavailable is returned by a Denylist that was made unavailable.
+var ErrUnavailable = errors.New("denylist unavailable")
+
+// Denylist is an in-memory cache.Denylist.
+type Denylist struct {
+	mu          sync.Mutex
+	entries     map[string]time.Time
+	unavailable bool
+}
+
+// NewDenylist creates an empty denylist.
+func NewDenylist() *Denylist {
+	return &Denylist{entries: make(map[string]time.Time)}
+}
+
+// SetUnavailable makes every call fail with ErrUnavailable, as while Redis is unreachable.
+func (d *Denylist) SetUnavailable(unavailable bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.unavailable = unavailable
+}
+
+// Deny adds a key until ttl runs out.
+func (d *Denylist) Deny(ctx context.Context, key string, ttl time.Duration) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.unavailable {
+		return ErrUnavailable
+	}
+	d.entries[key] = time.Now().Add(ttl)
+	return nil
+}
+
+// IsDenied reports whether a key was denied and has not expired.
+func (d *Denylist) IsDenied(ctx context.Context, key string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.unavailable {
+		return false, ErrUnavailable
+	}
+	expiresAt, ok := d.entries[key]
+	return ok && time.Now().Before(expiresAt), nil
+}
@@ -0,0 +1,76 @@
+package cachetest
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// lock is a held lock.
+type lock struct {
+	owner     string
+	expiresAt time.Time
+}
+
+// Locks is an in-memory cache.Locks.
+type Locks struct {
+	mu    sync.Mutex
+	locks map[string]*lock
+}
+
+// NewLocks creates a set of locks that are all free.
+func NewLocks() *Locks {
+	return &Locks{locks: make(map[string]*lock)}
+}
+
+// Owner returns the current owner of a lock, or "" if it is free.
+func (l *Locks) Owner(key string) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if held := l.held(key); held != nil {
+		return held.owner
+	}
+	return ""
+}
+
+// held returns a lock that has not expired. The caller holds l.mu.
+func (l *Locks) held(key string) *lock {
+	held, ok := l.locks[key]
+	if !ok || !time.Now().Before(held.expiresAt) {
+		return nil
+	}
+	return held
+}
+
+// AcquireLock takes a lock if it is free or expired.
+func (l *Locks) AcquireLock(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held(key) != nil {
+		return false, nil
+	}
+	l.locks[key] = &lock{owner: owner, expiresAt: time.Now().Add(ttl)}
+	return true, nil
+}
+
+// RenewLock extends a lock still held by owner.
+func (l *Locks) RenewLock(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	held := l.held(key)
+	if held == nil || held.owner != owner {
+		return false, nil
+	}
+	held.expiresAt = time.Now().Add(ttl)
+	return true, nil
+}
+
+// ReleaseLock frees a lock held by owner.
+func (l *Locks) ReleaseLock(ctx context.Context, key, owner string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if held := l.held(key); held != nil && held.owner == owner {
+		delete(l.locks, key)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+// Package cachetest provides in-memory implementations of the cache package
+// interfaces for tests. Streams follows Redis consumer group semantics closely
+// enough to exercise acknowledgement and takeover of pending messages.
+package cachetest
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/cache"
+)
+
+// pollInterval is how often a blocking read checks for new messages.
+const pollInterval = 5 * time.Millisecond
+
+// pendingMessage is a message delivered to a consumer and not yet acknowledged.
+type pendingMessage struct {
+	consumer    string
+	deliveredAt time.Time
+}
+
+// group is a consumer group of a stream.
+type group struct {
+	next    int // index of the next undelivered message
+	pending map[string]*pendingMessage
+}
+
+// stream is an append-only list of messages with its consumer groups.
+type stream struct {
+	messages []cache.StreamMessage
+	groups   map[string]*group
+}
+
+// Streams is an in-memory cache.Streams. Streams are never trimmed.
+type Streams struct {
+	mu      sync.Mutex
+	streams map[string]*stream
+	lastID  int
+}
+
+// NewStreams creates an empty set of streams.
+func NewStreams() *Streams {
+	return &Streams{streams: make(map[string]*stream)}
+}
+
+// Len returns the number of messages appended to a stream.
+func (s *Streams) Len(name string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.stream(name).messages)
+}
+
+// Pending returns the number of unacknowledged messages of a group.
+func (s *Streams) Pending(name, groupName string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if g, ok := s.stream(name).groups[groupName]; ok {
+		return len(g.pending)
+	}
+	return 0
+}
+
+// stream returns a stream, creating it if needed. The caller holds s.mu.
+func (s *Streams) stream(name string) *stream {
+	st, ok := s.streams[name]
+	if !ok {
+		st = &stream{groups: make(map[string]*group)}
+		s.streams[name] = st
+	}
+	return st
+}
+
+// StreamAppend adds a message to a stream; maxLen is ignored.
+func (s *Streams) StreamAppend(ctx context.Context, name string, payload []byte, maxLen int64) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastID++
+	id := strconv.Itoa(s.lastID) + "-0"
+	st := s.stream(name)
+	st.messages = append(st.messages, cache.StreamMessage{ID: id, Payload: append([]byte(nil), payload...)})
+	return id, nil
+}
+
+// StreamCreateGroup creates a group that receives messages appended from now on.
+func (s *Streams) StreamCreateGroup(ctx context.Context, name, groupName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.stream(name)
+	if _, ok := st.groups[groupName]; !ok {
+		st.groups[groupName] = &group{next: len(st.messages), pending: make(map[string]*pendingMessage)}
+	}
+	return nil
+}
+
+// StreamReadGroup delivers new messages to a consumer, polling until block elapses.
+func (s *Streams) StreamReadGroup(ctx context.Context, name, groupName, consumer string, count int64, block time.Duration) ([]cache.StreamMessage, error) {
+	deadline := time.Now().Add(block)
+	for {
+		if messages := s.read(name, groupName, consumer, count); len(messages) > 0 {
+			return messages, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// read delivers up to count new messages without waiting.
+func (s *Streams) read(name, groupName, consumer string, count int64) []cache.StreamMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.stream(name)
+	g, ok := st.groups[groupName]
+	if !ok {
+		return nil
+	}
+	var messages []cache.StreamMessage
+	for g.next < len(st.messages) && int64(len(messages)) < count {
+		message := st.messages[g.next]
+		g.next++
+		g.pending[message.ID] = &pendingMessage{consumer: consumer, deliveredAt: time.Now()}
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+// StreamClaimIdle takes over messages pending for at least minIdle.
+func (s *Streams) StreamClaimIdle(ctx context.Context, name, groupName, consumer string, minIdle time.Duration, count int64) ([]cache.StreamMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.stream(name)
+	g, ok := st.groups[groupName]
+	if !ok {
+		return nil, nil
+	}
+	var claimed []cache.StreamMessage
+	for _, message := range st.messages {
+		p, ok := g.pending[message.ID]
+		if !ok || time.Since(p.deliveredAt) < minIdle || int64(len(claimed)) >= count {
+			continue
+		}
+		p.consumer = consumer
+		p.deliveredAt = time.Now()
+		claimed = append(claimed, message)
+	}
+	return claimed, nil
+}
+
+// StreamAck acknowledges messages of a group.
+func (s *Streams) StreamAck(ctx context.Context, name, groupName string, ids ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if g, ok := s.stream(name).groups[groupName]; ok {
+		for _, id := range ids {
+			delete(g.pending, id)
+		}
+	}
+	return nil
+}
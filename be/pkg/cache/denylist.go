@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Denylist records revoked identifiers, such as the IDs of ended sessions,
+// until the credentials that carry them can no longer be used. With Redis,
+// an entry added by one server instance is seen by all of them on the next
+// request.
+type Denylist interface {
+	// Deny adds key to the denylist for ttl
+	Deny(ctx context.Context, key string, ttl time.Duration) error
+
+	// IsDenied reports whether key is on the denylist
+	IsDenied(ctx context.Context, key string) (bool, error)
+}
+
+// Deny adds a key to the Redis denylist.
+//
+// Parameters:
+//   - ctx: Context for the operation with timeout
+//   - key: Denylist key
+//   - ttl: Time after which the entry is dropped; must be positive
+//
+// Returns:
+//   - error: Redis error
+//
+// Example:
+//
+//	err := client.Deny(ctx, "goedu:session:revoked:"+sessionID, time.Hour)
+func (c *Client) Deny(ctx context.Context, key string, ttl time.Duration) error {
+	if err := c.client.Set(ctx, key, 1, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to deny %s: %w", key, err)
+	}
+	return nil
+}
+
+// IsDenied checks the Redis denylist.
+//
+// Parameters:
+//   - ctx: Context for the operation with timeout
+//   - key: Denylist key
+//
+// Returns:
+//   - bool: Whether the key is denied
+//   - error: Redis error
+func (c *Client) IsDenied(ctx context.Context, key string) (bool, error) {
+	count, err := c.client.Exists(ctx, key).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check denylist %s: %w", key, err)
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/logger"
+)
+
+// subscriptionBuffer is the number of messages buffered per subscription
+// before the Redis client starts dropping them.
+const subscriptionBuffer = 100
+
+// PubSub publishes messages to every subscriber of a channel. With Redis,
+// subscribers on all server instances receive every message, which lets
+// instances fan out events to the clients connected to them.
+type PubSub interface {
+	// Publish sends a message to all current subscribers of a channel
+	Publish(ctx context.Context, channel string, payload []byte) error
+
+	// Subscribe delivers the messages published on a channel until ctx is
+	// cancelled, after which the returned channel is closed
+	Subscribe(ctx context.Context, channel string) (<-chan []byte, error)
+}
+
+// Publish sends a message to all subscribers of a Redis channel.
+//
+// Parameters:
+//   - ctx: Context for the publish operation with timeout
+//   - channel: Redis channel name
+//   - payload: Message body
+//
+// Returns:
+//   - error: Publish error
+//
+// Example:
+//
+//	err := client.Publish(ctx, "notifications", payload)
+func (c *Client) Publish(ctx context.Context, channel string, payload []byte) error {
+	if err := c.client.Publish(ctx, channel, payload).Err(); err != nil {
+		c.logger.Error(ctx, "Failed to publish message", err,
+			logger.String("channel", channel),
+		)
+		return fmt.Errorf("failed to publish to channel %s: %w", channel, err)
+	}
+	return nil
+}
+
+// Subscribe subscribes to a Redis channel. The subscription survives
+// reconnects; messages published while disconnected are lost, as Redis does
+// not store pub/sub messages.
+//
+// Parameters:
+//   - ctx: Context controlling the subscription lifetime
+//   - channel: Redis channel name
+//
+// Returns:
+//   - <-chan []byte: Message payloads, closed when ctx is cancelled
+//   - error: Subscription error
+//
+// Example:
+//
+//	messages, err := client.Subscribe(ctx, "notifications")
+//	if err != nil {
+//	    return err
+//	}
+//	for payload := range messages {
+//	    handle(payload)
+//	}
+func (c *Client) Subscribe(ctx context.Context, channel string) (<-chan []byte, error) {
+	pubsub := c.client.Subscribe(ctx, channel)
+	// Wait for the subscription to be confirmed so that no message published
+	// after Subscribe returns is missed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("failed to subscribe to channel %s: %w", channel, err)
+	}
+
+	messages := make(chan []byte)
+	go func() {
+		defer close(messages)
+		defer pubsub.Close()
+
+		incoming := pubsub.Channel(redis.WithChannelSize(subscriptionBuffer))
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-incoming:
+				if !ok {
+					return
+				}
+				select {
+				case messages <- []byte(msg.Payload):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	c.logger.Info("Subscribed to channel",
+		logger.String("channel", channel),
+	)
+	return messages, nil
+}
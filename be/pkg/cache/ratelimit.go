@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// rateLimitScript implements GCRA, a token bucket that stores a single
+// theoretical arrival time (TAT) per key. Times are microseconds from the
+// Redis clock so all instances agree. It returns allowed, remaining, reset
+// after and retry after.
+var rateLimitScript = redis.NewScript(`
+local now = redis.call("TIME")
+now = tonumber(now[1]) * 1000000 + tonumber(now[2])
+local interval = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local tat = tonumber(redis.call("GET", KEYS[1]))
+if not tat or tat < now then
+	tat = now
+end
+local allow_at = tat + interval - window
+if now < allow_at then
+	return {0, 0, tat - now, allow_at - now}
+end
+tat = tat + interval
+redis.call("SET", KEYS[1], string.format("%.0f", tat), "PX", math.max(1, math.ceil((tat - now) / 1000)))
+return {1, math.floor((window - (tat - now)) / interval), tat - now, 0}`)
+
+// RateLimitResult is the outcome of a rate limit check.
+type RateLimitResult struct {
+	// Allowed reports whether the request may proceed
+	Allowed bool
+
+	// Limit is the number of requests allowed per window
+	Limit int
+
+	// Remaining is the number of requests that can be made right now
+	Remaining int
+
+	// ResetAfter is the time until the full limit is available again
+	ResetAfter time.Duration
+
+	// RetryAfter is the time until the next request is allowed; zero when
+	// the request was allowed
+	RetryAfter time.Duration
+}
+
+// RateLimits counts requests against per-key limits. Limits behave like a
+// token bucket holding limit tokens that refills over window, so a client
+// may burst up to the limit and then continues at limit per window.
+type RateLimits interface {
+	// AllowRate takes a token for key if one is available
+	AllowRate(ctx context.Context, key string, limit int, window time.Duration) (*RateLimitResult, error)
+}
+
+// AllowRate checks a Redis rate limit. Denied requests do not use a token.
+//
+// Parameters:
+//   - ctx: Context for the operation with timeout
+//   - key: Rate limit key
+//   - limit: Requests allowed per window; must be positive
+//   - window: Time over which limit requests are allowed
+//
+// Returns:
+//   - *RateLimitResult: Whether the request is allowed and the remaining quota
+//   - error: Redis error
+//
+// Example:
+//
+//	result, err := client.AllowRate(ctx, "goedu:ratelimit:api:user:"+userID, 120, time.Minute)
+//	if err == nil && !result.Allowed {
+//	    // reject, retry after result.RetryAfter
+//	}
+func (c *Client) AllowRate(ctx context.Context, key string, limit int, window time.Duration) (*RateLimitResult, error) {
+	interval := emissionInterval(limit, window)
+	values, err := rateLimitScript.Run(ctx, c.client, []string{key}, interval.Microseconds(), window.Microseconds()).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check rate limit %s: %w", key, err)
+	}
+	if len(values) != 4 {
+		return nil, fmt.Errorf("failed to check rate limit %s: unexpected reply %v", key, values)
+	}
+	return &RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      limit,
+		Remaining:  int(values[1]),
+		ResetAfter: time.Duration(values[2]) * time.Microsecond,
+		RetryAfter: time.Duration(values[3]) * time.Microsecond,
+	}, nil
+}
+
+// LocalRateLimits is an in-memory RateLimits for a single instance. It is
+// the fallback while Redis is unreachable; each instance then enforces the
+// limits on its own.
+type LocalRateLimits struct {
+	mu        sync.Mutex
+	tats      map[string]time.Time
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewLocalRateLimits creates an in-memory rate limiter with empty buckets.
+func NewLocalRateLimits() *LocalRateLimits {
+	return &LocalRateLimits{tats: make(map[string]time.Time), now: time.Now}
+}
+
+// AllowRate checks an in-memory rate limit with the same semantics as the
+// Redis implementation. It never returns an error.
+func (l *LocalRateLimits) AllowRate(ctx context.Context, key string, limit int, window time.Duration) (*RateLimitResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now, window)
+
+	interval := emissionInterval(limit, window)
+	tat := l.tats[key]
+	if tat.Before(now) {
+		tat = now
+	}
+	if allowAt := tat.Add(interval - window); now.Before(allowAt) {
+		return &RateLimitResult{Limit: limit, ResetAfter: tat.Sub(now), RetryAfter: allowAt.Sub(now)}, nil
+	}
+	tat = tat.Add(interval)
+	l.tats[key] = tat
+	return &RateLimitResult{
+		Allowed:    true,
+		Limit:      limit,
+		Remaining:  int((window - tat.Sub(now)) / interval),
+		ResetAfter: tat.Sub(now),
+	}, nil
+}
+
+// sweep drops buckets that have refilled completely, at most once per window.
+// The caller holds l.mu.
+func (l *LocalRateLimits) sweep(now time.Time, window time.Duration) {
+	if now.Sub(l.lastSweep) < window {
+		return
+	}
+	l.lastSweep = now
+	for key, tat := range l.tats {
+		if !tat.After(now) {
+			delete(l.tats, key)
+		}
+	}
+}
+
+// emissionInterval is the time it takes to refill one token.
+func emissionInterval(limit int, window time.Duration) time.Duration {
+	interval := window / time.Duration(limit)
+	if interval < time.Microsecond {
+		interval = time.Microsecond
+	}
+	return interval
+}
@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalRateLimits_AllowRate(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	limits := NewLocalRateLimits()
+	limits.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// A full bucket allows a burst of the whole limit
+	for i := 1; i <= 3; i++ {
+		result, err := limits.AllowRate(ctx, "user:1", 3, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 3-i, result.Remaining)
+		assert.Equal(t, time.Duration(i)*20*time.Second, result.ResetAfter)
+	}
+
+	result, err := limits.AllowRate(ctx, "user:1", 3, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, 20*time.Second, result.RetryAfter)
+
+	// Keys are independent
+	result, _ = limits.AllowRate(ctx, "user:2", 3, time.Minute)
+	assert.True(t, result.Allowed)
+
+	// One token refills per window/limit
+	now = now.Add(20 * time.Second)
+	result, _ = limits.AllowRate(ctx, "user:1", 3, time.Minute)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	result, _ = limits.AllowRate(ctx, "user:1", 3, time.Minute)
+	assert.False(t, result.Allowed)
+
+	// Refilled buckets are swept
+	now = now.Add(2 * time.Minute)
+	result, _ = limits.AllowRate(ctx, "user:3", 3, time.Minute)
+	assert.True(t, result.Allowed)
+	assert.Len(t, limits.tats, 1)
+}
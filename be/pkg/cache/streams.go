@@ -0,0 +1,190 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/logger"
+)
+
+// streamPayloadField is the stream entry field holding the message payload.
+const streamPayloadField = "payload"
+
+// StreamMessage is an entry read from a stream.
+type StreamMessage struct {
+	ID      string
+	Payload []byte
+}
+
+// Streams appends messages to durable Redis streams and reads them through
+// consumer groups. Every group receives every message once; within a group,
+// each message goes to one consumer and stays pending until it is acknowledged,
+// so messages of a consumer that stopped can be claimed by another.
+type Streams interface {
+	// StreamAppend adds a message to a stream, trimming it to about maxLen entries
+	StreamAppend(ctx context.Context, stream string, payload []byte, maxLen int64) (string, error)
+
+	// StreamCreateGroup creates a consumer group that receives messages added
+	// from now on; creating an existing group is not an error
+	StreamCreateGroup(ctx context.Context, stream, group string) error
+
+	// StreamReadGroup reads up to count new messages for a consumer, waiting up
+	// to block for the first one; it returns no messages when none arrived
+	StreamReadGroup(ctx context.Context, stream, group, consumer string, count int64, block time.Duration) ([]StreamMessage, error)
+
+	// StreamClaimIdle takes over up to count messages that were delivered to
+	// any consumer of the group and not acknowledged for at least minIdle
+	StreamClaimIdle(ctx context.Context, stream, group, consumer string, minIdle time.Duration, count int64) ([]StreamMessage, error)
+
+	// StreamAck acknowledges processed messages
+	StreamAck(ctx context.Context, stream, group string, ids ...string) error
+}
+
+// StreamAppend adds a message to a Redis stream. The stream is trimmed
+// approximately, which is much cheaper than exact trimming.
+//
+// Parameters:
+//   - ctx: Context for the operation with timeout
+//   - stream: Stream key
+//   - payload: Message body
+//   - maxLen: Approximate number of entries kept in the stream
+//
+// Returns:
+//   - string: ID of the new entry
+//   - error: Append error
+//
+// Example:
+//
+//	id, err := client.StreamAppend(ctx, "goedu:events", payload, 100000)
+func (c *Client) StreamAppend(ctx context.Context, stream string, payload []byte, maxLen int64) (string, error) {
+	id, err := c.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: stream,
+		MaxLen: maxLen,
+		Approx: true,
+		Values: map[string]interface{}{streamPayloadField: payload},
+	}).Result()
+	if err != nil {
+		c.logger.Error(ctx, "Failed to append to stream", err,
+			logger.String("stream", stream),
+		)
+		return "", fmt.Errorf("failed to append to stream %s: %w", stream, err)
+	}
+	return id, nil
+}
+
+// StreamCreateGroup creates a consumer group, creating the stream if needed.
+//
+// Parameters:
+//   - ctx: Context for the operation with timeout
+//   - stream: Stream key
+//   - group: Consumer group name
+//
+// Returns:
+//   - error: Error other than the group already existing
+func (c *Client) StreamCreateGroup(ctx context.Context, stream, group string) error {
+	err := c.client.XGroupCreateMkStream(ctx, stream, group, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group %s on stream %s: %w", group, stream, err)
+	}
+	return nil
+}
+
+// StreamReadGroup reads new messages of a consumer group.
+//
+// Parameters:
+//   - ctx: Context for the operation; cancelling it stops waiting
+//   - stream: Stream key
+//   - group: Consumer group name
+//   - consumer: Name of the reading consumer, unique per process
+//   - count: Maximum number of messages
+//   - block: Maximum time to wait for a message
+//
+// Returns:
+//   - []StreamMessage: Messages, empty when none arrived in time
+//   - error: Read error
+//
+// Example:
+//
+//	messages, err := client.StreamReadGroup(ctx, "goedu:events", "webhooks", consumer, 10, 5*time.Second)
+func (c *Client) StreamReadGroup(ctx context.Context, stream, group, consumer string, count int64, block time.Duration) ([]StreamMessage, error) {
+	streams, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  []string{stream, ">"},
+		Count:    count,
+		Block:    block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stream %s as group %s: %w", stream, group, err)
+	}
+
+	var messages []StreamMessage
+	for _, s := range streams {
+		messages = append(messages, toStreamMessages(s.Messages)...)
+	}
+	return messages, nil
+}
+
+// StreamClaimIdle claims messages left pending by other consumers.
+//
+// Parameters:
+//   - ctx: Context for the operation with timeout
+//   - stream: Stream key
+//   - group: Consumer group name
+//   - consumer: Consumer taking over the messages
+//   - minIdle: Minimum time since a message was last delivered
+//   - count: Maximum number of messages
+//
+// Returns:
+//   - []StreamMessage: Claimed messages
+//   - error: Claim error
+func (c *Client) StreamClaimIdle(ctx context.Context, stream, group, consumer string, minIdle time.Duration, count int64) ([]StreamMessage, error) {
+	messages, _, err := c.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   stream,
+		Group:    group,
+		Consumer: consumer,
+		MinIdle:  minIdle,
+		Start:    "0-0",
+		Count:    count,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim idle messages of stream %s: %w", stream, err)
+	}
+	return toStreamMessages(messages), nil
+}
+
+// StreamAck acknowledges messages of a consumer group.
+//
+// Parameters:
+//   - ctx: Context for the operation with timeout
+//   - stream: Stream key
+//   - group: Consumer group name
+//   - ids: IDs of the processed messages
+//
+// Returns:
+//   - error: Acknowledgement error
+func (c *Client) StreamAck(ctx context.Context, stream, group string, ids ...string) error {
+	if err := c.client.XAck(ctx, stream, group, ids...).Err(); err != nil {
+		return fmt.Errorf("failed to acknowledge messages of stream %s: %w", stream, err)
+	}
+	return nil
+}
+
+// toStreamMessages extracts the payloads of stream entries. Entries without a
+// payload field are returned with an empty payload so they can be acknowledged.
+func toStreamMessages(entries []redis.XMessage) []StreamMessage {
+	messages := make([]StreamMessage, 0, len(entries))
+	for _, entry := range entries {
+		payload, _ := entry.Values[streamPayloadField].(string)
+		messages = append(messages, StreamMessage{ID: entry.ID, Payload: []byte(payload)})
+	}
+	return messages
+}
@@ -0,0 +1,225 @@
+// Package cron parses standard five-field cron expressions and computes when
+// they next fire. Fields are minute, hour, day of month, month and day of week;
+// each accepts "*", single values, ranges ("1-5"), lists ("1,15") and steps
+// ("*/15", "8-18/2"). Months and weekdays also accept three-letter names.
+// The shortcuts @yearly, @annually, @monthly, @weekly, @daily, @midnight and
+// @hourly are supported.
+//
+// As in Vixie cron, when both the day of month and the day of week are
+// restricted (do not start with "*"), a time matches if either of them matches.
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidExpression is returned for expressions that cannot be parsed.
+var ErrInvalidExpression = errors.New("invalid cron expression")
+
+// maxSearchYears bounds the search for the next matching time, so that
+// expressions that never fire, such as "0 0 30 2 *", do not loop forever.
+const maxSearchYears = 5
+
+// shortcuts maps the supported @ shortcuts to their expressions.
+var shortcuts = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// field describes the range and names of one expression field.
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var fields = []field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}},
+	// 7 is accepted as an alias of Sunday
+	{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}},
+}
+
+// Schedule is a parsed cron expression. Each field is a bit set of the values
+// it matches.
+type Schedule struct {
+	expr                          string
+	minute, hour, dom, month, dow uint64
+	domRestricted, dowRestricted  bool
+}
+
+// Parse parses a cron expression.
+//
+// Parameters:
+//   - expr: Five-field expression or @ shortcut
+//
+// Returns:
+//   - *Schedule: Parsed schedule
+//   - error: ErrInvalidExpression describing the offending field
+//
+// Example:
+//
+//	schedule, err := cron.Parse("*/15 8-18 * * mon-fri")
+//	next := schedule.Next(time.Now())
+func Parse(expr string) (*Schedule, error) {
+	spec := strings.TrimSpace(expr)
+	if shortcut, ok := shortcuts[strings.ToLower(spec)]; ok {
+		spec = shortcut
+	}
+
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("%w: %q must have %d fields", ErrInvalidExpression, expr, len(fields))
+	}
+
+	sets := make([]uint64, len(fields))
+	for i, part := range parts {
+		set, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", ErrInvalidExpression, expr, err)
+		}
+		sets[i] = set
+	}
+
+	// Fold Sunday as 7 into Sunday as 0
+	if sets[4]&(1<<7) != 0 {
+		sets[4] = sets[4]&^(1<<7) | 1
+	}
+
+	return &Schedule{
+		expr:          strings.TrimSpace(expr),
+		minute:        sets[0],
+		hour:          sets[1],
+		dom:           sets[2],
+		month:         sets[3],
+		dow:           sets[4],
+		domRestricted: !strings.HasPrefix(parts[2], "*"),
+		dowRestricted: !strings.HasPrefix(parts[4], "*"),
+	}, nil
+}
+
+// parseField parses one comma-separated field into a bit set.
+func parseField(spec string, f field) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(spec, ",") {
+		rangeSpec, step := item, 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			var err error
+			rangeSpec = item[:i]
+			if step, err = strconv.Atoi(item[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %s field %q", f.name, item)
+			}
+		}
+
+		low, high := f.min, f.max
+		switch {
+		case rangeSpec == "*":
+		case strings.Contains(rangeSpec, "-"):
+			bounds := strings.SplitN(rangeSpec, "-", 2)
+			var err error
+			if low, err = parseValue(bounds[0], f); err != nil {
+				return 0, err
+			}
+			if high, err = parseValue(bounds[1], f); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid range in %s field %q", f.name, item)
+			}
+		default:
+			value, err := parseValue(rangeSpec, f)
+			if err != nil {
+				return 0, err
+			}
+			low = value
+			// "5/10" means every 10 starting at 5
+			if step == 1 {
+				high = value
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+// parseValue parses a number or name within a field's range.
+func parseValue(spec string, f field) (int, error) {
+	if value, ok := f.names[strings.ToLower(spec)]; ok {
+		return value, nil
+	}
+	value, err := strconv.Atoi(spec)
+	if err != nil || value < f.min || value > f.max {
+		return 0, fmt.Errorf("%s value %q must be between %d and %d", f.name, spec, f.min, f.max)
+	}
+	return value, nil
+}
+
+// String returns the expression the schedule was parsed from.
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Next returns the first time after t that matches the schedule, in t's
+// location and truncated to the minute. It returns the zero time if the
+// schedule does not fire within the next five years.
+//
+// Parameters:
+//   - t: Time to search from; the result is strictly later
+//
+// Returns:
+//   - time.Time: Next matching time, or the zero time
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxSearchYears, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches applies the day of month and day of week fields to t's date.
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domRestricted && s.dowRestricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
@@ -0,0 +1,71 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	// Wednesday
+	from := time.Date(2026, time.October, 14, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected time.Time
+	}{
+		{"every minute", "* * * * *", time.Date(2026, time.October, 14, 10, 8, 0, 0, time.UTC)},
+		{"every fifteen minutes", "*/15 * * * *", time.Date(2026, time.October, 14, 10, 15, 0, 0, time.UTC)},
+		{"hourly shortcut", "@hourly", time.Date(2026, time.October, 14, 11, 0, 0, 0, time.UTC)},
+		{"daily at two", "0 2 * * *", time.Date(2026, time.October, 15, 2, 0, 0, 0, time.UTC)},
+		{"weekdays in business hours", "30 8-18/2 * * mon-fri", time.Date(2026, time.October, 14, 10, 30, 0, 0, time.UTC)},
+		{"next saturday", "0 6 * * sat", time.Date(2026, time.October, 17, 6, 0, 0, 0, time.UTC)},
+		{"sunday as seven", "0 0 * * 7", time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)},
+		{"first of next month", "@monthly", time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC)},
+		{"list of months", "0 0 1 jan,jul *", time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"day of month or weekday", "0 0 1 * fri", time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC)},
+		{"never fires", "0 0 30 2 *", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, schedule.Next(from))
+		})
+	}
+}
+
+func TestSchedule_NextUsesLocation(t *testing.T) {
+	prague, err := time.LoadLocation("Europe/Prague")
+	require.NoError(t, err)
+
+	schedule, err := Parse("0 2 * * *")
+	require.NoError(t, err)
+
+	next := schedule.Next(time.Date(2026, time.October, 14, 23, 0, 0, 0, time.UTC).In(prague))
+	assert.Equal(t, time.Date(2026, time.October, 15, 2, 0, 0, 0, prague), next)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+		"@reboot",
+	} {
+		_, err := Parse(expr)
+		assert.ErrorIs(t, err, ErrInvalidExpression, expr)
+	}
+}
@@ -327,6 +327,40 @@ func (c *Client) Close(ctx context.Context) error {
 	return nil
 }
 
+// WithTransaction runs fn in a multi-document transaction. Operations that use
+// the context passed to fn take part in the transaction, which is committed
+// when fn returns nil and aborted otherwise. Transient errors are retried by
+// the driver, so fn may run more than once. Transactions require MongoDB to
+// run as a replica set.
+//
+// Parameters:
+//   - ctx: Context for the transaction with timeout
+//   - fn: Function performing the transactional operations
+//
+// Returns:
+//   - error: Error returned by fn, or a session or commit error
+//
+// Example:
+//   err := client.WithTransaction(ctx, func(ctx context.Context) error {
+//       if _, err := orders.InsertOne(ctx, order); err != nil {
+//           return err
+//       }
+//       _, err := outbox.InsertOne(ctx, event)
+//       return err
+//   })
+func (c *Client) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := c.client.StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start MongoDB session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessionCtx)
+	})
+	return err
+}
+
 // Stats returns connection statistics for monitoring and debugging.
 // This information is useful for performance monitoring and capacity planning.
 //
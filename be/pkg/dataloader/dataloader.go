@@ -0,0 +1,172 @@
+// Package dataloader batches and caches lookups by key. It is used by the
+// GraphQL resolvers to fetch the objects referenced by a list of results with
+// one repository call instead of one call per result.
+//
+// Load queues a key and returns a thunk. Keys are collected until the first
+// thunk of the batch is called, at which point all queued keys are fetched
+// together. Results are cached for the lifetime of the loader, which is
+// normally a single request.
+package dataloader
+
+import (
+	"context"
+	"sync"
+)
+
+// BatchFunc fetches the values of a batch of distinct keys. Keys without a
+// value are left out of the result and load as the zero value of V.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// Thunk returns the loaded value, fetching its batch if necessary.
+type Thunk[V any] func() (V, error)
+
+// Loader batches and caches lookups of values of type V by keys of type K.
+// It is safe for concurrent use.
+type Loader[K comparable, V any] struct {
+	ctx      context.Context
+	fetch    BatchFunc[K, V]
+	maxBatch int
+
+	mu    sync.Mutex
+	cache map[K]*entry[V]
+	batch *batch[K, V]
+}
+
+type entry[V any] struct {
+	batch interface{ dispatch() }
+	value V
+	err   error
+}
+
+type batch[K comparable, V any] struct {
+	loader  *Loader[K, V]
+	keys    []K
+	entries []*entry[V]
+	once    sync.Once
+}
+
+// New creates a loader.
+//
+// Parameters:
+//   - ctx: Context passed to fetch, normally the request context
+//   - fetch: Function fetching a batch of keys
+//   - maxBatch: Largest number of keys fetched at once; 0 means no limit
+//
+// Returns:
+//   - *Loader[K, V]: Loader with an empty cache
+//
+// Example:
+//
+//	users := dataloader.New(ctx, func(ctx context.Context, ids []string) (map[string]*models.User, error) {
+//		return fetchUsers(ctx, ids)
+//	}, 100)
+//	thunk := users.Load(id)
+//	user, err := thunk()
+func New[K comparable, V any](ctx context.Context, fetch BatchFunc[K, V], maxBatch int) *Loader[K, V] {
+	return &Loader[K, V]{
+		ctx:      ctx,
+		fetch:    fetch,
+		maxBatch: maxBatch,
+		cache:    make(map[K]*entry[V]),
+	}
+}
+
+// Load queues a key and returns a thunk producing its value. Keys loaded
+// before are served from the cache.
+//
+// Parameters:
+//   - key: Key to load
+//
+// Returns:
+//   - Thunk[V]: Function returning the value once its batch is fetched
+func (l *Loader[K, V]) Load(key K) Thunk[V] {
+	l.mu.Lock()
+	e, ok := l.cache[key]
+	if !ok {
+		if l.batch == nil || (l.maxBatch > 0 && len(l.batch.keys) >= l.maxBatch) {
+			l.batch = &batch[K, V]{loader: l}
+		}
+		e = &entry[V]{batch: l.batch}
+		l.batch.keys = append(l.batch.keys, key)
+		l.batch.entries = append(l.batch.entries, e)
+		l.cache[key] = e
+	}
+	l.mu.Unlock()
+
+	return func() (V, error) {
+		e.batch.dispatch()
+		return e.value, e.err
+	}
+}
+
+// LoadMany queues several keys and returns a thunk producing their values in
+// the order of keys. The first error of any key is returned.
+//
+// Parameters:
+//   - keys: Keys to load
+//
+// Returns:
+//   - Thunk[[]V]: Function returning the values once their batches are fetched
+func (l *Loader[K, V]) LoadMany(keys []K) Thunk[[]V] {
+	thunks := make([]Thunk[V], len(keys))
+	for i, key := range keys {
+		thunks[i] = l.Load(key)
+	}
+	return func() ([]V, error) {
+		values := make([]V, len(thunks))
+		for i, thunk := range thunks {
+			v, err := thunk()
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		return values, nil
+	}
+}
+
+// Prime adds a value to the cache, so that loading its key does not fetch it.
+// Keys already cached are left unchanged.
+//
+// Parameters:
+//   - key: Key of the value
+//   - value: Value already fetched by other means
+func (l *Loader[K, V]) Prime(key K, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.cache[key]; !ok {
+		l.cache[key] = &entry[V]{batch: doneBatch{}, value: value}
+	}
+}
+
+// dispatch fetches the batch once. Later calls wait for the first to finish.
+func (b *batch[K, V]) dispatch() {
+	b.once.Do(func() {
+		l := b.loader
+		l.mu.Lock()
+		if l.batch == b {
+			// Keys loaded from now on start a new batch
+			l.batch = nil
+		}
+		keys, entries := b.keys, b.entries
+		l.mu.Unlock()
+
+		values, err := l.fetch(l.ctx, keys)
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		for i, e := range entries {
+			if err != nil {
+				e.err = err
+				// Failed keys are retried by later loads
+				delete(l.cache, keys[i])
+			} else {
+				e.value = values[keys[i]]
+			}
+		}
+	})
+}
+
+// doneBatch is the batch of primed entries.
+type doneBatch struct{}
+
+func (doneBatch) dispatch() {}
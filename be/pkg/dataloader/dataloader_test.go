@@ -0,0 +1,99 @@
+package dataloader
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder is a batch function that doubles keys and records its calls.
+type recorder struct {
+	calls [][]int
+	err   error
+}
+
+func (r *recorder) fetch(ctx context.Context, keys []int) (map[int]int, error) {
+	sorted := append([]int(nil), keys...)
+	sort.Ints(sorted)
+	r.calls = append(r.calls, sorted)
+	if r.err != nil {
+		return nil, r.err
+	}
+	values := make(map[int]int, len(keys))
+	for _, k := range keys {
+		if k >= 0 {
+			values[k] = k * 2
+		}
+	}
+	return values, nil
+}
+
+func TestLoader_BatchesQueuedKeys(t *testing.T) {
+	r := &recorder{}
+	loader := New(context.Background(), r.fetch, 0)
+
+	a, b, again := loader.Load(1), loader.Load(2), loader.Load(1)
+	missing := loader.Load(-1)
+
+	v, err := b()
+	require.NoError(t, err)
+	assert.Equal(t, 4, v)
+	v, _ = a()
+	assert.Equal(t, 2, v)
+	v, _ = again()
+	assert.Equal(t, 2, v)
+	v, err = missing()
+	require.NoError(t, err)
+	assert.Equal(t, 0, v)
+	assert.Equal(t, [][]int{{-1, 1, 2}}, r.calls)
+
+	// Cached keys are not fetched again; new keys start a new batch
+	c := loader.Load(3)
+	cached := loader.Load(2)
+	v, _ = c()
+	assert.Equal(t, 6, v)
+	v, _ = cached()
+	assert.Equal(t, 4, v)
+	assert.Equal(t, [][]int{{-1, 1, 2}, {3}}, r.calls)
+}
+
+func TestLoader_MaxBatch(t *testing.T) {
+	r := &recorder{}
+	loader := New(context.Background(), r.fetch, 2)
+
+	values, err := loader.LoadMany([]int{1, 2, 3, 4, 5})()
+
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 4, 6, 8, 10}, values)
+	assert.Equal(t, [][]int{{1, 2}, {3, 4}, {5}}, r.calls)
+}
+
+func TestLoader_Prime(t *testing.T) {
+	r := &recorder{}
+	loader := New(context.Background(), r.fetch, 0)
+
+	loader.Prime(7, 100)
+	v, err := loader.Load(7)()
+
+	require.NoError(t, err)
+	assert.Equal(t, 100, v)
+	assert.Empty(t, r.calls)
+}
+
+func TestLoader_ErrorsAreNotCached(t *testing.T) {
+	r := &recorder{err: errors.New("database down")}
+	loader := New(context.Background(), r.fetch, 0)
+
+	_, err := loader.Load(1)()
+	assert.EqualError(t, err, "database down")
+
+	r.err = nil
+	v, err := loader.Load(1)()
+	require.NoError(t, err)
+	assert.Equal(t, 2, v)
+	assert.Len(t, r.calls, 2)
+}
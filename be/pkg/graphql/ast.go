@@ -0,0 +1,180 @@
+package graphql
+
+// Syntax tree of executable documents (queries) and of schema definitions.
+
+// valueKind identifies the kind of a literal value.
+type valueKind int
+
+const (
+	variableValue valueKind = iota
+	intValue
+	floatValue
+	stringValue
+	booleanValue
+	nullValue
+	enumValue
+	listValue
+	objectValue
+)
+
+// value is a literal or variable reference in a document.
+type value struct {
+	kind   valueKind
+	raw    string // variable or enum name, number or string contents
+	list   []*value
+	fields []*objectField
+	loc    Location
+}
+
+type objectField struct {
+	name  string
+	value *value
+	loc   Location
+}
+
+// typeNode is a type reference such as [String!]!.
+type typeNode struct {
+	name    string
+	elem    *typeNode // set for list types
+	nonNull bool
+	loc     Location
+}
+
+type directive struct {
+	name string
+	args []*argument
+	loc  Location
+}
+
+type argument struct {
+	name  string
+	value *value
+	loc   Location
+}
+
+// selection is a field, fragment spread or inline fragment.
+type selection interface {
+	location() Location
+}
+
+type field struct {
+	alias        string
+	name         string
+	args         []*argument
+	directives   []*directive
+	selectionSet []selection
+	loc          Location
+}
+
+// responseKey returns the key of the field in the response.
+func (f *field) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+func (f *field) location() Location { return f.loc }
+
+type fragmentSpread struct {
+	name       string
+	directives []*directive
+	loc        Location
+}
+
+func (f *fragmentSpread) location() Location { return f.loc }
+
+type inlineFragment struct {
+	typeCondition string
+	directives    []*directive
+	selectionSet  []selection
+	loc           Location
+}
+
+func (f *inlineFragment) location() Location { return f.loc }
+
+type operationDefinition struct {
+	operation    string // query, mutation or subscription
+	name         string
+	variables    []*variableDefinition
+	directives   []*directive
+	selectionSet []selection
+	loc          Location
+}
+
+type variableDefinition struct {
+	name         string
+	typ          *typeNode
+	defaultValue *value
+	loc          Location
+}
+
+type fragmentDefinition struct {
+	name          string
+	typeCondition string
+	directives    []*directive
+	selectionSet  []selection
+	loc           Location
+}
+
+// queryDocument is a parsed executable document.
+type queryDocument struct {
+	operations []*operationDefinition
+	fragments  []*fragmentDefinition
+}
+
+// Schema definition language
+
+// definitionKind identifies the kind of a type definition.
+type definitionKind int
+
+const (
+	objectDefinition definitionKind = iota
+	inputDefinition
+	enumDefinition
+	scalarDefinition
+)
+
+type typeDefinition struct {
+	kind        definitionKind
+	name        string
+	description string
+	fields      []*fieldDefinition      // object types
+	inputFields []*inputValueDefinition // input types
+	values      []*enumValueDefinition  // enum types
+	directives  []*directive
+	loc         Location
+}
+
+type fieldDefinition struct {
+	name        string
+	description string
+	args        []*inputValueDefinition
+	typ         *typeNode
+	directives  []*directive
+	loc         Location
+}
+
+type inputValueDefinition struct {
+	name         string
+	description  string
+	typ          *typeNode
+	defaultValue *value
+	directives   []*directive
+	loc          Location
+}
+
+type enumValueDefinition struct {
+	name        string
+	description string
+	directives  []*directive
+	loc         Location
+}
+
+// schemaDocument is a parsed schema definition.
+type schemaDocument struct {
+	description string
+	types       []*typeDefinition
+	// Root operation types by operation, from a schema definition
+	roots map[string]string
+}
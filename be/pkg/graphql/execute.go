@@ -0,0 +1,507 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Execute parses, validates and runs a request.
+//
+// Parameters:
+//   - ctx: Context passed to resolvers
+//   - req: Query, operation name and variables
+//   - limits: Depth and complexity limits for the operation
+//
+// Returns:
+//   - *Response: Result data and errors. Requests that fail to parse or
+//     validate, or exceed the limits, have errors and no data.
+//
+// Example:
+//
+//	resp := schema.Execute(ctx, &graphql.Request{Query: "{ now }"}, graphql.Limits{MaxDepth: 10})
+//	json.NewEncoder(w).Encode(resp)
+func (s *Schema) Execute(ctx context.Context, req *Request, limits Limits) *Response {
+	if limits.MaxQueryLength > 0 && len(req.Query) > limits.MaxQueryLength {
+		return &Response{Errors: []*Error{{Message: fmt.Sprintf("Query length %d exceeds the maximum length of %d.", len(req.Query), limits.MaxQueryLength)}}}
+	}
+	doc, err := parseQuery(req.Query)
+	if err != nil {
+		return &Response{Errors: []*Error{asError(err)}}
+	}
+	if err := checkDocumentLimits(doc, limits); err != nil {
+		return &Response{Errors: []*Error{err}}
+	}
+	op, errs := s.validate(doc, req.OperationName)
+	if len(errs) > 0 {
+		return &Response{Errors: errs}
+	}
+	vars, errs := s.coerceVariables(op, req.Variables)
+	if len(errs) > 0 {
+		return &Response{Errors: errs}
+	}
+	fragments := make(map[string]*fragmentDefinition, len(doc.fragments))
+	for _, fragment := range doc.fragments {
+		fragments[fragment.name] = fragment
+	}
+	if err := s.checkLimits(op, fragments, vars, limits); err != nil {
+		return &Response{Errors: []*Error{err}}
+	}
+
+	e := &executor{s: s, ctx: ctx, vars: vars, fragments: fragments}
+	return e.run(op)
+}
+
+func asError(err error) *Error {
+	var gqlErr *Error
+	if errors.As(err, &gqlErr) {
+		return gqlErr
+	}
+	return &Error{Message: err.Error(), Err: err}
+}
+
+// executor runs one operation. Fields are resolved depth first; thunks
+// returned by resolvers are queued and called in rounds once no other work is
+// left, so that batching loaders see the keys of all sibling fields.
+type executor struct {
+	s         *Schema
+	ctx       context.Context
+	vars      map[string]interface{}
+	fragments map[string]*fragmentDefinition
+	errs      []*Error
+	pending   []func()
+	data      interface{}
+}
+
+// slot is the place a field value is written to. A null in a non-null slot
+// propagates to the parent slot.
+type slot struct {
+	set     func(v interface{})
+	nonNull bool
+	parent  *slot
+}
+
+func (e *executor) run(op *operationDefinition) *Response {
+	root := e.s.rootType(op)
+	data := newOrderedMap()
+	e.data = data
+	rootSlot := &slot{set: func(v interface{}) {
+		// data is null rather than omitted when a root field nulls it
+		e.data = json.RawMessage("null")
+	}}
+
+	fields := e.collectFields(root, [][]selection{op.selectionSet})
+	for _, key := range fields.keys {
+		data.set(key, nil)
+	}
+	for _, key := range fields.keys {
+		e.resolveField(root, nil, key, fields.groups[key], data, nil, rootSlot)
+		if op.operation == "mutation" {
+			// Mutation fields run one after another
+			e.drain()
+		}
+	}
+	e.drain()
+	return &Response{Data: e.data, Errors: e.errs}
+}
+
+// drain calls queued thunks until none are left.
+func (e *executor) drain() {
+	for len(e.pending) > 0 {
+		round := e.pending
+		e.pending = nil
+		for _, resume := range round {
+			resume()
+		}
+	}
+}
+
+// fieldGroups are fields grouped by response key, in document order.
+type fieldGroups struct {
+	keys   []string
+	groups map[string][]*field
+}
+
+// collectFields gathers the fields selected on an object, expanding fragments
+// and applying @skip and @include. Each fragment is expanded at most once per
+// object, so fragments spreading each other repeatedly add no work.
+func (e *executor) collectFields(t *namedType, selectionSets [][]selection) *fieldGroups {
+	fg := &fieldGroups{groups: make(map[string][]*field)}
+	visited := make(map[string]bool)
+	var collect func(selections []selection)
+	collect = func(selections []selection) {
+		for _, sel := range selections {
+			switch sel := sel.(type) {
+			case *field:
+				if !e.included(sel.directives) {
+					continue
+				}
+				key := sel.responseKey()
+				if _, ok := fg.groups[key]; !ok {
+					fg.keys = append(fg.keys, key)
+				}
+				fg.groups[key] = append(fg.groups[key], sel)
+			case *fragmentSpread:
+				if visited[sel.name] || !e.included(sel.directives) {
+					continue
+				}
+				visited[sel.name] = true
+				if fragment := e.fragments[sel.name]; fragment.typeCondition == t.name {
+					collect(fragment.selectionSet)
+				}
+			case *inlineFragment:
+				if !e.included(sel.directives) || (sel.typeCondition != "" && sel.typeCondition != t.name) {
+					continue
+				}
+				collect(sel.selectionSet)
+			}
+		}
+	}
+	for _, selections := range selectionSets {
+		collect(selections)
+	}
+	return fg
+}
+
+// included evaluates @skip and @include.
+func (e *executor) included(directives []*directive) bool {
+	for _, d := range directives {
+		if d.name != "skip" && d.name != "include" {
+			continue
+		}
+		for _, arg := range d.args {
+			if arg.name != "if" {
+				continue
+			}
+			v, _ := coerceLiteral(arg.value, &typeRef{kind: KindScalar, named: e.s.types["Boolean"]}, e.vars)
+			if b, _ := v.(bool); b == (d.name == "skip") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// resolveField resolves one response key of an object and writes the
+// completed value into obj.
+func (e *executor) resolveField(t *namedType, source interface{}, key string, fields []*field, obj *orderedMap, path []interface{}, parent *slot) {
+	f := fields[0]
+	def := e.s.lookupField(t, f.name)
+	fieldPath := appendPath(path, key)
+	s := &slot{set: func(v interface{}) { obj.set(key, v) }, nonNull: def.typ.isNonNull(), parent: parent}
+
+	args, argErr := coerceArgs(def.args, f.args, e.vars, f.loc)
+	if argErr != nil {
+		argErr.Path = fieldPath
+		e.fail(argErr, s)
+		return
+	}
+
+	resolve := def.resolve
+	if resolve == nil {
+		resolve = defaultResolver
+	}
+	result, err := e.call(func() (interface{}, error) {
+		return resolve(ResolveParams{
+			Context:    e.ctx,
+			Source:     source,
+			Args:       args,
+			FieldName:  f.name,
+			ParentType: t.name,
+			Path:       fieldPath,
+		})
+	})
+	if err != nil {
+		e.fail(e.fieldError(err, f, fieldPath), s)
+		return
+	}
+	e.complete(def.typ, fields, result, fieldPath, s)
+}
+
+// call runs a resolver or thunk, turning panics into errors.
+func (e *executor) call(fn func() (interface{}, error)) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("resolver panic: %v", r)
+		}
+	}()
+	return fn()
+}
+
+// complete converts a resolved value to its response value for type t and
+// writes it to s.
+func (e *executor) complete(t *typeRef, fields []*field, result interface{}, path []interface{}, s *slot) {
+	if thunk := asThunk(result); thunk != nil {
+		e.pending = append(e.pending, func() {
+			v, err := e.call(thunk)
+			if err != nil {
+				e.fail(e.fieldError(err, fields[0], path), s)
+				return
+			}
+			e.complete(t, fields, v, path, s)
+		})
+		return
+	}
+
+	nullable := t.nullable()
+	if nullable.kind == KindList && result != nil {
+		// A nil slice is an empty list rather than null
+		if rv := reflect.ValueOf(result); rv.Kind() == reflect.Slice && rv.IsNil() {
+			result = []interface{}{}
+		}
+	}
+	if isNil(result) {
+		if t.isNonNull() {
+			e.fail(nullError(fields[0], path), s)
+			return
+		}
+		s.set(nil)
+		return
+	}
+
+	switch nullable.kind {
+	case KindList:
+		rv := reflect.ValueOf(result)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			e.fail(e.fieldError(fmt.Errorf("expected a list, got %T", result), fields[0], path), s)
+			return
+		}
+		list := make([]interface{}, rv.Len())
+		s.set(list)
+		elem := nullable.ofType
+		for i := range list {
+			i := i
+			item := &slot{set: func(v interface{}) { list[i] = v }, nonNull: elem.isNonNull(), parent: s}
+			e.complete(elem, fields, rv.Index(i).Interface(), appendPath(path, i), item)
+		}
+	case KindScalar, KindEnum:
+		v, err := e.serialize(nullable.named, result)
+		if err != nil {
+			e.fail(e.fieldError(err, fields[0], path), s)
+			return
+		}
+		if v == nil && t.isNonNull() {
+			e.fail(nullError(fields[0], path), s)
+			return
+		}
+		s.set(v)
+	case KindObject:
+		obj := newOrderedMap()
+		selectionSets := make([][]selection, len(fields))
+		for i, f := range fields {
+			selectionSets[i] = f.selectionSet
+		}
+		subfields := e.collectFields(nullable.named, selectionSets)
+		for _, key := range subfields.keys {
+			obj.set(key, nil)
+		}
+		s.set(obj)
+		for _, key := range subfields.keys {
+			e.resolveField(nullable.named, result, key, subfields.groups[key], obj, path, s)
+		}
+	}
+}
+
+// serialize converts a leaf value for the response.
+func (e *executor) serialize(t *namedType, v interface{}) (interface{}, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		v = rv.Elem().Interface()
+		rv = rv.Elem()
+	}
+	if t.kind == KindScalar {
+		return t.scalar.Serialize(v)
+	}
+	if rv.Kind() != reflect.String {
+		return nil, fmt.Errorf("enum %s cannot represent %v", t.name, v)
+	}
+	name := rv.String()
+	if _, ok := t.valueMap[name]; !ok {
+		return nil, fmt.Errorf("enum %s cannot represent value %q", t.name, name)
+	}
+	return name, nil
+}
+
+// fieldError creates the error reported for a failed field.
+func (e *executor) fieldError(err error, f *field, path []interface{}) *Error {
+	gqlErr := &Error{Message: err.Error(), Locations: []Location{f.loc}, Path: path, Err: err}
+	var resolverErr *Error
+	if errors.As(err, &resolverErr) {
+		gqlErr.Message = resolverErr.Message
+	}
+	return gqlErr
+}
+
+// nullError is reported when a non-null field resolves to null.
+func nullError(f *field, path []interface{}) *Error {
+	err := newError(f.loc, "Cannot return null for non-nullable field %s.", fieldName(path))
+	err.Path = path
+	return err
+}
+
+// fail reports an error and writes null to the nearest nullable slot.
+func (e *executor) fail(err *Error, s *slot) {
+	e.errs = append(e.errs, err)
+	for s != nil && s.nonNull {
+		s = s.parent
+	}
+	if s != nil {
+		s.set(nil)
+	}
+}
+
+func asThunk(v interface{}) func() (interface{}, error) {
+	switch fn := v.(type) {
+	case Thunk:
+		return fn
+	case func() (interface{}, error):
+		return fn
+	}
+	return nil
+}
+
+// appendPath returns a copy of path with elem appended, so that paths of
+// sibling fields do not share memory.
+func appendPath(path []interface{}, elem interface{}) []interface{} {
+	p := make([]interface{}, len(path)+1)
+	copy(p, path)
+	p[len(path)] = elem
+	return p
+}
+
+// fieldName formats a response path for error messages.
+func fieldName(path []interface{}) string {
+	parts := make([]string, len(path))
+	for i, p := range path {
+		parts[i] = fmt.Sprint(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+// defaultResolver reads the field from a map key or struct field of the
+// source.
+func defaultResolver(p ResolveParams) (interface{}, error) {
+	rv := reflect.ValueOf(p.Source)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, nil
+		}
+		v := rv.MapIndex(reflect.ValueOf(p.FieldName).Convert(rv.Type().Key()))
+		if !v.IsValid() {
+			return nil, nil
+		}
+		return v.Interface(), nil
+	case reflect.Struct:
+		index := structFieldIndex(rv.Type(), p.FieldName)
+		if index == nil {
+			return nil, nil
+		}
+		v, err := rv.FieldByIndexErr(index)
+		if err != nil {
+			// Nil embedded pointer
+			return nil, nil
+		}
+		return v.Interface(), nil
+	}
+	return nil, nil
+}
+
+// fieldIndexes caches struct field lookups by type and field name.
+var fieldIndexes sync.Map
+
+type fieldIndexKey struct {
+	t    reflect.Type
+	name string
+}
+
+// structFieldIndex finds the exported struct field for a GraphQL field: the
+// field tagged graphql:"name", else the one with json name "name", else the
+// one whose name matches ignoring case, so that OrganizationID serves
+// organizationId. Fields of embedded structs are included.
+func structFieldIndex(t reflect.Type, name string) []int {
+	key := fieldIndexKey{t, name}
+	if index, ok := fieldIndexes.Load(key); ok {
+		return index.([]int)
+	}
+
+	var byTag, byJSON, byName []int
+	for _, sf := range reflect.VisibleFields(t) {
+		if !sf.IsExported() || sf.Anonymous {
+			continue
+		}
+		if tag, ok := sf.Tag.Lookup("graphql"); ok {
+			if tag == name && byTag == nil {
+				byTag = sf.Index
+			}
+			continue
+		}
+		if jsonName := strings.Split(sf.Tag.Get("json"), ",")[0]; jsonName == name && byJSON == nil {
+			byJSON = sf.Index
+		}
+		if strings.EqualFold(sf.Name, name) && byName == nil {
+			byName = sf.Index
+		}
+	}
+	index := byTag
+	if index == nil {
+		index = byJSON
+	}
+	if index == nil {
+		index = byName
+	}
+	fieldIndexes.Store(key, index)
+	return index
+}
+
+// orderedMap is a JSON object that keeps its keys in insertion order.
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newOrderedMap() *orderedMap {
+	return &orderedMap{values: make(map[string]interface{})}
+}
+
+func (m *orderedMap) set(key string, v interface{}) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = v
+}
+
+// MarshalJSON writes the keys in order.
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
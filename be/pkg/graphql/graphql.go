@@ -0,0 +1,138 @@
+// Package graphql is a schema-first GraphQL engine. A schema is written in the
+// GraphQL schema definition language and bound to Go resolvers by type and
+// field name; fields without a resolver read the matching map key or struct
+// field of their parent value.
+//
+// Queries and mutations with variables, aliases, fragments, the @skip and
+// @include directives and introspection are supported. Object, input object,
+// enum and scalar types are supported; interfaces, unions and subscriptions
+// are not.
+//
+// Resolvers may return a Thunk instead of a value. The executor resolves all
+// fields it can before calling any thunks, so thunks created by a batching
+// loader for sibling objects are fetched together, avoiding N+1 lookups.
+//
+// Operations are validated before they run, and rejected if they are nested
+// deeper than MaxDepth or their estimated cost exceeds MaxComplexity. Queries
+// longer than MaxQueryLength or with more than MaxFragmentSpreads spreads are
+// rejected before they are validated.
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Location is a line and column in a GraphQL document, both starting at 1.
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error is a GraphQL error as returned in the errors list of a response.
+type Error struct {
+	Message   string        `json:"message"`
+	Locations []Location    `json:"locations,omitempty"`
+	Path      []interface{} `json:"path,omitempty"`
+
+	// Extensions carries additional information such as an error code
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+
+	// Err is the resolver error this error was created from, if any
+	Err error `json:"-"`
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the underlying resolver error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ErrInvalidSchema is returned when a schema cannot be built.
+var ErrInvalidSchema = errors.New("invalid GraphQL schema")
+
+// syntaxError returns an error for a malformed document.
+func syntaxError(loc Location, format string, args ...interface{}) *Error {
+	return &Error{Message: "Syntax Error: " + fmt.Sprintf(format, args...), Locations: []Location{loc}}
+}
+
+// newError returns a document error at the given locations.
+func newError(loc Location, format string, args ...interface{}) *Error {
+	return &Error{Message: fmt.Sprintf(format, args...), Locations: []Location{loc}}
+}
+
+// Request is a GraphQL request as sent by clients over HTTP.
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Response is the result of executing a request. Data is omitted when the
+// request could not be executed at all.
+type Response struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+// Thunk is a deferred resolver result. The executor calls it after resolving
+// all other pending fields.
+type Thunk func() (interface{}, error)
+
+// ResolveParams are passed to a field resolver.
+type ResolveParams struct {
+	Context context.Context
+
+	// Source is the value of the parent object
+	Source interface{}
+
+	// Args are the field arguments, coerced to Go values: int, float64,
+	// string, bool, nil, []interface{} and map[string]interface{}, or the
+	// result of a custom scalar's ParseValue. Defaults are applied.
+	Args map[string]interface{}
+
+	// FieldName and ParentType identify the field being resolved
+	FieldName  string
+	ParentType string
+
+	// Path is the response path of the field
+	Path []interface{}
+}
+
+// FieldResolver resolves the value of a field. It returns the value, a
+// Thunk producing the value, or an error.
+type FieldResolver func(p ResolveParams) (interface{}, error)
+
+// Resolvers binds resolvers to fields by type name and field name.
+type Resolvers map[string]map[string]FieldResolver
+
+// Limits bounds the cost of operations.
+type Limits struct {
+	// MaxDepth is the deepest allowed field nesting; 0 means no limit
+	MaxDepth int
+
+	// MaxComplexity is the highest allowed estimated cost; 0 means no limit.
+	// Every field, including __typename, and every fragment spread costs 1,
+	// and the cost of the selections below a field with a "first" or "last"
+	// argument is multiplied by that argument.
+	MaxComplexity int
+
+	// MaxQueryLength is the longest allowed query in bytes; 0 means no limit
+	MaxQueryLength int
+
+	// MaxFragmentSpreads is the most fragment spreads a query may contain;
+	// 0 means no limit
+	MaxFragmentSpreads int
+
+	// DefaultPageSize is the multiplier used for fields that accept "first"
+	// or "last" when neither argument is given
+	DefaultPageSize int
+
+	// DisableIntrospection rejects operations that query __schema or __type
+	DisableIntrospection bool
+}
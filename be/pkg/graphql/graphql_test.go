@@ -0,0 +1,480 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSDL = `
+scalar Time
+
+"A book in the library"
+type Book {
+	id: ID!
+	title: String!
+	rating: Float
+	genre: Genre
+	publishedAt: Time
+	author: Author
+	isbn: String @deprecated(reason: "Use id")
+}
+
+type Author {
+	name: String!
+	books(first: Int = 10): [Book!]!
+}
+
+enum Genre {
+	FICTION
+	HISTORY
+}
+
+input BookInput {
+	title: String!
+	genre: Genre = FICTION
+}
+
+type Query {
+	book(id: ID!): Book
+	books(first: Int = 2, genre: Genre): [Book!]!
+	strictBook: Book!
+	failing: String
+	echo(input: BookInput!): String!
+}
+
+type Mutation {
+	append(value: String!): [String!]!
+}
+`
+
+type testBook struct {
+	ID          string
+	Title       string  `json:"title"`
+	Rating      float64 `graphql:"rating"`
+	Genre       string
+	PublishedAt time.Time
+	Author      *testAuthor
+}
+
+type testAuthor struct {
+	Name string
+}
+
+// authorBatch collects the keys of author lookups until one of them is
+// fetched, like a batching loader.
+type authorBatch struct {
+	keys   []string
+	loaded map[string]*testAuthor
+}
+
+// newTestSchema builds the test schema. The author resolver returns thunks
+// and records how many times they were fetched.
+func newTestSchema(t *testing.T) (*Schema, *int) {
+	t.Helper()
+	books := []*testBook{
+		{ID: "1", Title: "Dune", Rating: 4.5, Genre: "FICTION", PublishedAt: time.Date(1965, 8, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: "2", Title: "SPQR", Genre: "HISTORY"},
+		{ID: "3", Title: "Emma", Genre: "FICTION"},
+	}
+	batches := 0
+	batch := &authorBatch{}
+	var appended []string
+
+	schema, err := NewSchema(testSDL, Resolvers{
+		"Query": {
+			"book": func(p ResolveParams) (interface{}, error) {
+				for _, b := range books {
+					if b.ID == p.Args["id"] {
+						return b, nil
+					}
+				}
+				return nil, nil
+			},
+			"books": func(p ResolveParams) (interface{}, error) {
+				var result []*testBook
+				for _, b := range books {
+					if genre, ok := p.Args["genre"].(string); ok && b.Genre != genre {
+						continue
+					}
+					result = append(result, b)
+				}
+				if first := p.Args["first"].(int); first < len(result) {
+					result = result[:first]
+				}
+				return result, nil
+			},
+			"strictBook": func(p ResolveParams) (interface{}, error) {
+				return nil, nil
+			},
+			"failing": func(p ResolveParams) (interface{}, error) {
+				return nil, errors.New("boom")
+			},
+			"echo": func(p ResolveParams) (interface{}, error) {
+				input := p.Args["input"].(map[string]interface{})
+				return input["title"].(string) + "/" + input["genre"].(string), nil
+			},
+		},
+		"Book": {
+			"author": func(p ResolveParams) (interface{}, error) {
+				id := p.Source.(*testBook).ID
+				b := batch
+				b.keys = append(b.keys, id)
+				return Thunk(func() (interface{}, error) {
+					if b.loaded == nil {
+						batches++
+						batch = &authorBatch{}
+						b.loaded = make(map[string]*testAuthor)
+						for _, key := range b.keys {
+							b.loaded[key] = &testAuthor{Name: "author " + key}
+						}
+					}
+					return b.loaded[id], nil
+				}), nil
+			},
+		},
+		"Author": {
+			"books": func(p ResolveParams) (interface{}, error) {
+				if first := p.Args["first"].(int); first < len(books) {
+					return books[:first], nil
+				}
+				return books, nil
+			},
+		},
+		"Mutation": {
+			"append": func(p ResolveParams) (interface{}, error) {
+				appended = append(appended, p.Args["value"].(string))
+				return append([]string(nil), appended...), nil
+			},
+		},
+	}, Time)
+	require.NoError(t, err)
+	return schema, &batches
+}
+
+func execute(t *testing.T, schema *Schema, req *Request, limits Limits) (string, *Response) {
+	t.Helper()
+	resp := schema.Execute(context.Background(), req, limits)
+	data, err := json.Marshal(resp.Data)
+	require.NoError(t, err)
+	return string(data), resp
+}
+
+func TestExecute_Fields(t *testing.T) {
+	schema, _ := newTestSchema(t)
+
+	data, resp := execute(t, schema, &Request{Query: `{
+		book(id: "1") { id title rating genre publishedAt isbn }
+		second: book(id: 2) { title rating publishedAt }
+		missing: book(id: "9") { title }
+	}`}, Limits{})
+
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{
+		"book": {"id": "1", "title": "Dune", "rating": 4.5, "genre": "FICTION", "publishedAt": "1965-08-01T00:00:00Z", "isbn": null},
+		"second": {"title": "SPQR", "rating": 0, "publishedAt": null},
+		"missing": null
+	}`, data)
+}
+
+func TestExecute_PreservesFieldOrder(t *testing.T) {
+	schema, _ := newTestSchema(t)
+
+	data, _ := execute(t, schema, &Request{Query: `{ book(id: "1") { title id genre } }`}, Limits{})
+
+	assert.Equal(t, `{"book":{"title":"Dune","id":"1","genre":"FICTION"}}`, data)
+}
+
+func TestExecute_VariablesAndDefaults(t *testing.T) {
+	schema, _ := newTestSchema(t)
+
+	data, resp := execute(t, schema, &Request{
+		Query: `query List($first: Int, $genre: Genre) {
+			books(first: $first, genre: $genre) { id }
+			all: books { id }
+			echo(input: {title: "x"})
+		}`,
+		Variables: map[string]interface{}{"genre": "FICTION", "first": json.Number("5")},
+	}, Limits{})
+
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{
+		"books": [{"id": "1"}, {"id": "3"}],
+		"all": [{"id": "1"}, {"id": "2"}],
+		"echo": "x/FICTION"
+	}`, data)
+}
+
+func TestExecute_FragmentsAndDirectives(t *testing.T) {
+	schema, _ := newTestSchema(t)
+
+	data, resp := execute(t, schema, &Request{
+		Query: `query($withTitle: Boolean!) {
+			book(id: "1") {
+				...Details
+				... on Book { genre @skip(if: true) }
+				title @include(if: $withTitle)
+				__typename
+			}
+		}
+		fragment Details on Book { id }`,
+		Variables: map[string]interface{}{"withTitle": false},
+	}, Limits{})
+
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"book": {"id": "1", "__typename": "Book"}}`, data)
+}
+
+func TestExecute_BatchesThunks(t *testing.T) {
+	schema, batches := newTestSchema(t)
+
+	data, resp := execute(t, schema, &Request{Query: `{
+		books(first: 3) { id author { name } }
+	}`}, Limits{})
+
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"books": [
+		{"id": "1", "author": {"name": "author 1"}},
+		{"id": "2", "author": {"name": "author 2"}},
+		{"id": "3", "author": {"name": "author 3"}}
+	]}`, data)
+	assert.Equal(t, 1, *batches)
+}
+
+func TestExecute_ErrorsAndNullPropagation(t *testing.T) {
+	schema, _ := newTestSchema(t)
+
+	t.Run("nullable field error", func(t *testing.T) {
+		data, resp := execute(t, schema, &Request{Query: `{ failing book(id: "1") { id } }`}, Limits{})
+
+		assert.JSONEq(t, `{"failing": null, "book": {"id": "1"}}`, data)
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "boom", resp.Errors[0].Message)
+		assert.Equal(t, []interface{}{"failing"}, resp.Errors[0].Path)
+		assert.Equal(t, []Location{{Line: 1, Column: 3}}, resp.Errors[0].Locations)
+	})
+
+	t.Run("non-null field nulls the root", func(t *testing.T) {
+		data, resp := execute(t, schema, &Request{Query: `{ strictBook { id } }`}, Limits{})
+
+		assert.Equal(t, "null", data)
+		require.Len(t, resp.Errors, 1)
+		assert.Contains(t, resp.Errors[0].Message, "Cannot return null for non-nullable field strictBook")
+	})
+}
+
+func TestExecute_MutationsRunSerially(t *testing.T) {
+	schema, _ := newTestSchema(t)
+
+	data, resp := execute(t, schema, &Request{Query: `mutation {
+		first: append(value: "a")
+		second: append(value: "b")
+	}`}, Limits{})
+
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"first": ["a"], "second": ["a", "b"]}`, data)
+}
+
+func TestExecute_ValidationErrors(t *testing.T) {
+	schema, _ := newTestSchema(t)
+
+	tests := []struct {
+		name    string
+		req     Request
+		message string
+	}{
+		{"syntax", Request{Query: `{ book(id: "1") { id }`}, "Syntax Error"},
+		{"unknown field", Request{Query: `{ book(id: "1") { pages } }`}, `Cannot query field "pages" on type "Book".`},
+		{"missing subselection", Request{Query: `{ book(id: "1") }`}, `must have a selection of subfields`},
+		{"leaf subselection", Request{Query: `{ book(id: "1") { id { x } } }`}, `must not have a selection`},
+		{"missing argument", Request{Query: `{ book { id } }`}, `Argument "id" of type "ID!" is required`},
+		{"unknown argument", Request{Query: `{ book(id: "1", x: 1) { id } }`}, `Unknown argument "x"`},
+		{"invalid literal", Request{Query: `{ books(genre: POETRY) { id } }`}, `does not exist in enum Genre`},
+		{"unknown fragment", Request{Query: `{ book(id: "1") { ...Nope } }`}, `Unknown fragment "Nope".`},
+		{"fragment cycle", Request{Query: `{ book(id: "1") { ...A } } fragment A on Book { ...B } fragment B on Book { ...A }`}, `within itself`},
+		{"undefined variable", Request{Query: `{ book(id: $id) { id } }`}, `Variable "$id" is not defined`},
+		{"conflicting fields", Request{Query: `{ book(id: "1") { title: id title } }`}, `are different fields`},
+		{"missing variable", Request{Query: `query($id: ID!) { book(id: $id) { id } }`}, `was not provided`},
+		{"invalid variable", Request{Query: `query($n: Int) { books(first: $n) { id } }`, Variables: map[string]interface{}{"n": "x"}}, `got invalid value "x"`},
+		{"ambiguous operation", Request{Query: `query A { failing } query B { failing }`}, `Must provide operation name`},
+		{"unknown operation", Request{Query: `query A { failing }`, OperationName: "B"}, `Unknown operation named "B".`},
+		{"unsupported subscription", Request{Query: `subscription { failing }`}, `not configured for subscription operations`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			_, resp := execute(t, schema, &req, Limits{})
+
+			assert.Nil(t, resp.Data)
+			require.NotEmpty(t, resp.Errors)
+			assert.Contains(t, resp.Errors[0].Message, tt.message)
+		})
+	}
+}
+
+func TestExecute_Limits(t *testing.T) {
+	schema, _ := newTestSchema(t)
+	query := `{ books(first: 2) { author { books(first: 5) { id title } } } }`
+
+	t.Run("within limits", func(t *testing.T) {
+		_, resp := execute(t, schema, &Request{Query: query}, Limits{MaxDepth: 4, MaxComplexity: 100})
+		assert.Empty(t, resp.Errors)
+	})
+
+	t.Run("too deep", func(t *testing.T) {
+		_, resp := execute(t, schema, &Request{Query: query}, Limits{MaxDepth: 3})
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "Query depth 4 exceeds the maximum depth of 3.", resp.Errors[0].Message)
+	})
+
+	t.Run("too complex", func(t *testing.T) {
+		// books(1) + 2 * (author(1) + 1 * (books(1) + 5 * 2))
+		_, resp := execute(t, schema, &Request{Query: query}, Limits{MaxComplexity: 24})
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "Query complexity 25 exceeds the maximum complexity of 24.", resp.Errors[0].Message)
+	})
+
+	t.Run("uses variables and defaults", func(t *testing.T) {
+		_, resp := execute(t, schema, &Request{
+			Query:     `query($n: Int) { books(first: $n) { id } author: books { id } }`,
+			Variables: map[string]interface{}{"n": 50},
+		}, Limits{MaxComplexity: 50})
+		require.Len(t, resp.Errors, 1)
+		// 1 + 50 * 1, plus 1 + 2 * 1 for the default page size
+		assert.Equal(t, "Query complexity 54 exceeds the maximum complexity of 50.", resp.Errors[0].Message)
+	})
+
+	t.Run("default page size for omitted arguments", func(t *testing.T) {
+		sdl := `type Item { id: ID } type Query { items(first: Int): [Item!]! }`
+		s, err := NewSchema(sdl, nil)
+		require.NoError(t, err)
+		_, resp := execute(t, s, &Request{Query: `{ items { id } }`}, Limits{MaxComplexity: 20, DefaultPageSize: 25})
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "Query complexity 26 exceeds the maximum complexity of 20.", resp.Errors[0].Message)
+	})
+
+	t.Run("__typename and spreads cost", func(t *testing.T) {
+		// book(1) + 1 * (__typename(1) + spread(1) + id(1))
+		_, resp := execute(t, schema, &Request{Query: `{ book(id: "1") { __typename ...F } } fragment F on Book { id }`}, Limits{MaxComplexity: 3})
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "Query complexity 4 exceeds the maximum complexity of 3.", resp.Errors[0].Message)
+	})
+
+	t.Run("fragments are measured once", func(t *testing.T) {
+		// Each fragment spreads the next twice; expanding every spread, when
+		// measuring or collecting fields, would take 2^40 steps
+		data, resp := execute(t, schema, &Request{Query: chainedFragments(40)}, Limits{})
+		require.Empty(t, resp.Errors)
+		assert.JSONEq(t, `{"book":{"id":"1"}}`, data)
+
+		_, resp = execute(t, schema, &Request{Query: chainedFragments(40)}, Limits{MaxComplexity: 5000})
+		require.Len(t, resp.Errors, 1)
+		assert.Contains(t, resp.Errors[0].Message, "exceeds the maximum complexity of 5000")
+	})
+
+	t.Run("too many fragment spreads", func(t *testing.T) {
+		_, resp := execute(t, schema, &Request{Query: chainedFragments(24)}, Limits{MaxFragmentSpreads: 40})
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "Query has 49 fragment spreads, more than the maximum of 40.", resp.Errors[0].Message)
+	})
+
+	t.Run("too long", func(t *testing.T) {
+		_, resp := execute(t, schema, &Request{Query: query}, Limits{MaxQueryLength: 32})
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, fmt.Sprintf("Query length %d exceeds the maximum length of 32.", len(query)), resp.Errors[0].Message)
+	})
+
+	t.Run("introspection disabled", func(t *testing.T) {
+		_, resp := execute(t, schema, &Request{Query: `{ books { id } __schema { queryType { name } } }`}, Limits{DisableIntrospection: true})
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "Introspection is disabled.", resp.Errors[0].Message)
+	})
+
+	t.Run("introspection is exempt from depth", func(t *testing.T) {
+		_, resp := execute(t, schema, &Request{Query: `{ __type(name: "Book") { fields { type { ofType { name } } } } }`}, Limits{MaxDepth: 2})
+		assert.Empty(t, resp.Errors)
+	})
+}
+
+// chainedFragments returns a query whose fragments each spread the next one
+// twice, n levels deep.
+func chainedFragments(n int) string {
+	var b strings.Builder
+	b.WriteString(`{ book(id: "1") { ...F0 } }`)
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, " fragment F%d on Book { ...F%d ...F%d }", i, i+1, i+1)
+	}
+	fmt.Fprintf(&b, " fragment F%d on Book { id }", n)
+	return b.String()
+}
+
+func TestExecute_Introspection(t *testing.T) {
+	schema, _ := newTestSchema(t)
+
+	data, resp := execute(t, schema, &Request{Query: `{
+		__schema { queryType { name } mutationType { name } }
+		book: __type(name: "Book") {
+			kind
+			description
+			fields(includeDeprecated: true) { name isDeprecated deprecationReason type { kind name ofType { name } } }
+		}
+		genre: __type(name: "Genre") { enumValues { name } }
+		input: __type(name: "BookInput") { inputFields { name defaultValue } }
+		missing: __type(name: "Nope") { name }
+	}`}, Limits{})
+
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{
+		"__schema": {"queryType": {"name": "Query"}, "mutationType": {"name": "Mutation"}},
+		"book": {
+			"kind": "OBJECT",
+			"description": "A book in the library",
+			"fields": [
+				{"name": "id", "isDeprecated": false, "deprecationReason": null, "type": {"kind": "NON_NULL", "name": null, "ofType": {"name": "ID"}}},
+				{"name": "title", "isDeprecated": false, "deprecationReason": null, "type": {"kind": "NON_NULL", "name": null, "ofType": {"name": "String"}}},
+				{"name": "rating", "isDeprecated": false, "deprecationReason": null, "type": {"kind": "SCALAR", "name": "Float", "ofType": null}},
+				{"name": "genre", "isDeprecated": false, "deprecationReason": null, "type": {"kind": "ENUM", "name": "Genre", "ofType": null}},
+				{"name": "publishedAt", "isDeprecated": false, "deprecationReason": null, "type": {"kind": "SCALAR", "name": "Time", "ofType": null}},
+				{"name": "author", "isDeprecated": false, "deprecationReason": null, "type": {"kind": "OBJECT", "name": "Author", "ofType": null}},
+				{"name": "isbn", "isDeprecated": true, "deprecationReason": "Use id", "type": {"kind": "SCALAR", "name": "String", "ofType": null}}
+			]
+		},
+		"genre": {"enumValues": [{"name": "FICTION"}, {"name": "HISTORY"}]},
+		"input": {"inputFields": [{"name": "title", "defaultValue": null}, {"name": "genre", "defaultValue": "FICTION"}]},
+		"missing": null
+	}`, data)
+}
+
+func TestNewSchema_Invalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		sdl       string
+		resolvers Resolvers
+	}{
+		{"syntax", `type Query {`, nil},
+		{"no query type", `type Book { id: ID }`, nil},
+		{"unknown type", `type Query { book: Book }`, nil},
+		{"missing scalar", `scalar Money type Query { price: Money }`, nil},
+		{"input as output", `input In { a: Int } type Query { in: In }`, nil},
+		{"output as input", `type Query { a(arg: Query): Int }`, nil},
+		{"invalid default", `type Query { a(n: Int = "x"): Int }`, nil},
+		{"unsupported interface", `interface Node { id: ID } type Query { a: Int }`, nil},
+		{"unknown resolver type", `type Query { a: Int }`, Resolvers{"Nope": {}}},
+		{"unknown resolver field", `type Query { a: Int }`, Resolvers{"Query": {"b": nil}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSchema(tt.sdl, tt.resolvers)
+			assert.ErrorIs(t, err, ErrInvalidSchema)
+		})
+	}
+}
@@ -0,0 +1,353 @@
+package graphql
+
+// introspectionSDL defines the types queried through __schema and __type.
+const introspectionSDL = `
+type __Schema {
+	description: String
+	types: [__Type!]!
+	queryType: __Type!
+	mutationType: __Type
+	subscriptionType: __Type
+	directives: [__Directive!]!
+}
+
+type __Type {
+	kind: __TypeKind!
+	name: String
+	description: String
+	fields(includeDeprecated: Boolean = false): [__Field!]
+	interfaces: [__Type!]
+	possibleTypes: [__Type!]
+	enumValues(includeDeprecated: Boolean = false): [__EnumValue!]
+	inputFields(includeDeprecated: Boolean = false): [__InputValue!]
+	ofType: __Type
+	specifiedByURL: String
+}
+
+type __Field {
+	name: String!
+	description: String
+	args(includeDeprecated: Boolean = false): [__InputValue!]!
+	type: __Type!
+	isDeprecated: Boolean!
+	deprecationReason: String
+}
+
+type __InputValue {
+	name: String!
+	description: String
+	type: __Type!
+	defaultValue: String
+	isDeprecated: Boolean!
+	deprecationReason: String
+}
+
+type __EnumValue {
+	name: String!
+	description: String
+	isDeprecated: Boolean!
+	deprecationReason: String
+}
+
+type __Directive {
+	name: String!
+	description: String
+	isRepeatable: Boolean!
+	locations: [__DirectiveLocation!]!
+	args(includeDeprecated: Boolean = false): [__InputValue!]!
+}
+
+enum __TypeKind {
+	SCALAR
+	OBJECT
+	INTERFACE
+	UNION
+	ENUM
+	INPUT_OBJECT
+	LIST
+	NON_NULL
+}
+
+enum __DirectiveLocation {
+	QUERY
+	MUTATION
+	SUBSCRIPTION
+	FIELD
+	FRAGMENT_DEFINITION
+	FRAGMENT_SPREAD
+	INLINE_FRAGMENT
+	VARIABLE_DEFINITION
+	SCHEMA
+	SCALAR
+	OBJECT
+	FIELD_DEFINITION
+	ARGUMENT_DEFINITION
+	INTERFACE
+	UNION
+	ENUM
+	ENUM_VALUE
+	INPUT_OBJECT
+	INPUT_FIELD_DEFINITION
+}
+`
+
+// introspectionResolvers resolve the introspection types. Sources are
+// *Schema, *typeRef, *fieldDef, *inputValue, *enumValueDef and *directiveDef.
+var introspectionResolvers = Resolvers{
+	"__Schema": {
+		"description": func(p ResolveParams) (interface{}, error) {
+			return nullString(p.Source.(*Schema).description), nil
+		},
+		"types": func(p ResolveParams) (interface{}, error) {
+			s := p.Source.(*Schema)
+			types := make([]*typeRef, 0, len(s.types))
+			for _, name := range s.typeNames() {
+				types = append(types, namedRef(s.types[name]))
+			}
+			return types, nil
+		},
+		"queryType": func(p ResolveParams) (interface{}, error) {
+			return namedRef(p.Source.(*Schema).query), nil
+		},
+		"mutationType": func(p ResolveParams) (interface{}, error) {
+			if m := p.Source.(*Schema).mutation; m != nil {
+				return namedRef(m), nil
+			}
+			return nil, nil
+		},
+		"subscriptionType": func(p ResolveParams) (interface{}, error) {
+			return nil, nil
+		},
+		"directives": func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*Schema).directives, nil
+		},
+	},
+	"__Type": {
+		"kind": func(p ResolveParams) (interface{}, error) {
+			return string(p.Source.(*typeRef).kind), nil
+		},
+		"name": func(p ResolveParams) (interface{}, error) {
+			if t := p.Source.(*typeRef); t.named != nil {
+				return t.named.name, nil
+			}
+			return nil, nil
+		},
+		"description": func(p ResolveParams) (interface{}, error) {
+			if t := p.Source.(*typeRef); t.named != nil {
+				return nullString(t.named.description), nil
+			}
+			return nil, nil
+		},
+		"fields": func(p ResolveParams) (interface{}, error) {
+			t := p.Source.(*typeRef)
+			if t.kind != KindObject {
+				return nil, nil
+			}
+			fields := make([]*fieldDef, 0, len(t.named.fields))
+			for _, f := range t.named.fields {
+				if !f.deprecated || includeDeprecated(p) {
+					fields = append(fields, f)
+				}
+			}
+			return fields, nil
+		},
+		"interfaces": func(p ResolveParams) (interface{}, error) {
+			if p.Source.(*typeRef).kind == KindObject {
+				return []*typeRef{}, nil
+			}
+			return nil, nil
+		},
+		"possibleTypes": func(p ResolveParams) (interface{}, error) {
+			return nil, nil
+		},
+		"enumValues": func(p ResolveParams) (interface{}, error) {
+			t := p.Source.(*typeRef)
+			if t.kind != KindEnum {
+				return nil, nil
+			}
+			values := make([]*enumValueDef, 0, len(t.named.values))
+			for _, v := range t.named.values {
+				if !v.deprecated || includeDeprecated(p) {
+					values = append(values, v)
+				}
+			}
+			return values, nil
+		},
+		"inputFields": func(p ResolveParams) (interface{}, error) {
+			t := p.Source.(*typeRef)
+			if t.kind != KindInputObject {
+				return nil, nil
+			}
+			return filterInputValues(t.named.inputFields, includeDeprecated(p)), nil
+		},
+		"ofType": func(p ResolveParams) (interface{}, error) {
+			if t := p.Source.(*typeRef); t.ofType != nil {
+				return t.ofType, nil
+			}
+			return nil, nil
+		},
+		"specifiedByURL": func(p ResolveParams) (interface{}, error) {
+			return nil, nil
+		},
+	},
+	"__Field": {
+		"name": func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*fieldDef).name, nil
+		},
+		"description": func(p ResolveParams) (interface{}, error) {
+			return nullString(p.Source.(*fieldDef).description), nil
+		},
+		"args": func(p ResolveParams) (interface{}, error) {
+			return filterInputValues(p.Source.(*fieldDef).args, includeDeprecated(p)), nil
+		},
+		"type": func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*fieldDef).typ, nil
+		},
+		"isDeprecated": func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*fieldDef).deprecated, nil
+		},
+		"deprecationReason": func(p ResolveParams) (interface{}, error) {
+			return nullString(p.Source.(*fieldDef).deprecationReason), nil
+		},
+	},
+	"__InputValue": {
+		"name": func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*inputValue).name, nil
+		},
+		"description": func(p ResolveParams) (interface{}, error) {
+			return nullString(p.Source.(*inputValue).description), nil
+		},
+		"type": func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*inputValue).typ, nil
+		},
+		"defaultValue": func(p ResolveParams) (interface{}, error) {
+			if v := p.Source.(*inputValue).defaultValue; v != nil {
+				return printValue(v), nil
+			}
+			return nil, nil
+		},
+		"isDeprecated": func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*inputValue).deprecated, nil
+		},
+		"deprecationReason": func(p ResolveParams) (interface{}, error) {
+			return nullString(p.Source.(*inputValue).deprecationReason), nil
+		},
+	},
+	"__EnumValue": {
+		"name": func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*enumValueDef).name, nil
+		},
+		"description": func(p ResolveParams) (interface{}, error) {
+			return nullString(p.Source.(*enumValueDef).description), nil
+		},
+		"isDeprecated": func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*enumValueDef).deprecated, nil
+		},
+		"deprecationReason": func(p ResolveParams) (interface{}, error) {
+			return nullString(p.Source.(*enumValueDef).deprecationReason), nil
+		},
+	},
+	"__Directive": {
+		"name": func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*directiveDef).name, nil
+		},
+		"description": func(p ResolveParams) (interface{}, error) {
+			return nullString(p.Source.(*directiveDef).description), nil
+		},
+		"isRepeatable": func(p ResolveParams) (interface{}, error) {
+			return false, nil
+		},
+		"locations": func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*directiveDef).locations, nil
+		},
+		"args": func(p ResolveParams) (interface{}, error) {
+			return filterInputValues(p.Source.(*directiveDef).args, includeDeprecated(p)), nil
+		},
+	},
+}
+
+// defineMetaFields creates the __schema, __type and __typename fields, which
+// are available without being declared.
+func (s *Schema) defineMetaFields() {
+	typeType := &typeRef{kind: KindObject, named: s.types["__Type"]}
+	nonNullString := &typeRef{kind: KindNonNull, ofType: &typeRef{kind: KindScalar, named: s.types["String"]}}
+
+	s.schemaField = &fieldDef{
+		name:        "__schema",
+		description: "Access the current type schema of this server.",
+		typ:         &typeRef{kind: KindNonNull, ofType: &typeRef{kind: KindObject, named: s.types["__Schema"]}},
+		argMap:      map[string]*inputValue{},
+		resolve: func(p ResolveParams) (interface{}, error) {
+			return s, nil
+		},
+	}
+
+	nameArg := &inputValue{name: "name", typ: nonNullString}
+	s.typeField = &fieldDef{
+		name:        "__type",
+		description: "Request the type information of a single type.",
+		args:        []*inputValue{nameArg},
+		argMap:      map[string]*inputValue{"name": nameArg},
+		typ:         typeType,
+		resolve: func(p ResolveParams) (interface{}, error) {
+			if t, ok := s.types[p.Args["name"].(string)]; ok {
+				return namedRef(t), nil
+			}
+			return nil, nil
+		},
+	}
+
+	s.typenameField = &fieldDef{
+		name:        "__typename",
+		description: "The name of the current Object type at runtime.",
+		typ:         nonNullString,
+		argMap:      map[string]*inputValue{},
+		resolve: func(p ResolveParams) (interface{}, error) {
+			return p.ParentType, nil
+		},
+	}
+}
+
+// lookupField returns the definition of a field of an object type, including
+// the meta fields.
+func (s *Schema) lookupField(t *namedType, name string) *fieldDef {
+	switch name {
+	case "__typename":
+		return s.typenameField
+	case "__schema":
+		if t == s.query {
+			return s.schemaField
+		}
+	case "__type":
+		if t == s.query {
+			return s.typeField
+		}
+	}
+	return t.fieldMap[name]
+}
+
+func namedRef(t *namedType) *typeRef {
+	return &typeRef{kind: t.kind, named: t}
+}
+
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func includeDeprecated(p ResolveParams) bool {
+	include, _ := p.Args["includeDeprecated"].(bool)
+	return include
+}
+
+func filterInputValues(values []*inputValue, includeDeprecated bool) []*inputValue {
+	filtered := make([]*inputValue, 0, len(values))
+	for _, v := range values {
+		if !v.deprecated || includeDeprecated {
+			filtered = append(filtered, v)
+		}
+	}
+	return filtered
+}
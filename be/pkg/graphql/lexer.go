@@ -0,0 +1,292 @@
+package graphql
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// tokenKind identifies the lexical class of a token.
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+	tokenBlockString
+)
+
+// token is a lexical token with its position in the source.
+type token struct {
+	kind  tokenKind
+	value string
+	loc   Location
+}
+
+// lexer splits GraphQL source text into tokens. Whitespace, commas and
+// comments are insignificant and skipped.
+type lexer struct {
+	src  string
+	pos  int
+	line int
+	col  int
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{src: src, line: 1, col: 1}
+}
+
+// advance moves past n bytes of the current line.
+func (l *lexer) advance(n int) {
+	l.pos += n
+	l.col += n
+}
+
+// newline moves past a line terminator of n bytes.
+func (l *lexer) newline(n int) {
+	l.pos += n
+	l.line++
+	l.col = 1
+}
+
+// skipIgnored skips whitespace, commas, line terminators and comments.
+func (l *lexer) skipIgnored() {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; c {
+		case ' ', '\t', ',':
+			l.advance(1)
+		case '\n':
+			l.newline(1)
+		case '\r':
+			if l.pos+1 < len(l.src) && l.src[l.pos+1] == '\n' {
+				l.newline(2)
+			} else {
+				l.newline(1)
+			}
+		case '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' && l.src[l.pos] != '\r' {
+				l.advance(1)
+			}
+		default:
+			// Byte order mark
+			if strings.HasPrefix(l.src[l.pos:], "\uFEFF") {
+				l.advance(3)
+				continue
+			}
+			return
+		}
+	}
+}
+
+// next returns the next token.
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+	loc := Location{Line: l.line, Column: l.col}
+	if l.pos >= len(l.src) {
+		return token{kind: tokenEOF, loc: loc}, nil
+	}
+
+	c := l.src[l.pos]
+	switch {
+	case strings.IndexByte("!$&():=@[]{}|", c) >= 0:
+		l.advance(1)
+		return token{kind: tokenPunct, value: string(c), loc: loc}, nil
+	case c == '.':
+		if strings.HasPrefix(l.src[l.pos:], "...") {
+			l.advance(3)
+			return token{kind: tokenPunct, value: "...", loc: loc}, nil
+		}
+		return token{}, syntaxError(loc, "unexpected %q", ".")
+	case isNameStart(c):
+		start := l.pos
+		for l.pos < len(l.src) && isNameContinue(l.src[l.pos]) {
+			l.advance(1)
+		}
+		return token{kind: tokenName, value: l.src[start:l.pos], loc: loc}, nil
+	case c == '-' || isDigit(c):
+		return l.readNumber(loc)
+	case c == '"':
+		if strings.HasPrefix(l.src[l.pos:], `"""`) {
+			return l.readBlockString(loc)
+		}
+		return l.readString(loc)
+	}
+
+	r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+	return token{}, syntaxError(loc, "unexpected character %q", r)
+}
+
+// readNumber reads an integer or float literal.
+func (l *lexer) readNumber(loc Location) (token, error) {
+	start := l.pos
+	kind := tokenInt
+	if l.src[l.pos] == '-' {
+		l.advance(1)
+	}
+	if l.pos >= len(l.src) || !isDigit(l.src[l.pos]) {
+		return token{}, syntaxError(loc, "invalid number")
+	}
+	if l.src[l.pos] == '0' && l.pos+1 < len(l.src) && isDigit(l.src[l.pos+1]) {
+		return token{}, syntaxError(loc, "invalid number, unexpected digit after 0")
+	}
+	l.skipDigits()
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		kind = tokenFloat
+		l.advance(1)
+		if l.pos >= len(l.src) || !isDigit(l.src[l.pos]) {
+			return token{}, syntaxError(loc, "invalid number, expected digit after '.'")
+		}
+		l.skipDigits()
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		kind = tokenFloat
+		l.advance(1)
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.advance(1)
+		}
+		if l.pos >= len(l.src) || !isDigit(l.src[l.pos]) {
+			return token{}, syntaxError(loc, "invalid number, expected digit in exponent")
+		}
+		l.skipDigits()
+	}
+	if l.pos < len(l.src) && (isNameStart(l.src[l.pos]) || l.src[l.pos] == '.') {
+		return token{}, syntaxError(loc, "invalid number, unexpected %q", l.src[l.pos])
+	}
+	return token{kind: kind, value: l.src[start:l.pos], loc: loc}, nil
+}
+
+func (l *lexer) skipDigits() {
+	for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+		l.advance(1)
+	}
+}
+
+// readString reads a quoted string literal, resolving escape sequences.
+func (l *lexer) readString(loc Location) (token, error) {
+	l.advance(1)
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '"':
+			l.advance(1)
+			return token{kind: tokenString, value: b.String(), loc: loc}, nil
+		case c == '\n' || c == '\r':
+			return token{}, syntaxError(loc, "unterminated string")
+		case c == '\\':
+			if l.pos+1 >= len(l.src) {
+				return token{}, syntaxError(loc, "unterminated string")
+			}
+			escape := l.src[l.pos+1]
+			l.advance(2)
+			switch escape {
+			case '"', '\\', '/':
+				b.WriteByte(escape)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if l.pos+4 > len(l.src) {
+					return token{}, syntaxError(loc, "invalid unicode escape")
+				}
+				var r rune
+				if _, err := fmt.Sscanf(l.src[l.pos:l.pos+4], "%04x", &r); err != nil {
+					return token{}, syntaxError(loc, "invalid unicode escape")
+				}
+				b.WriteRune(r)
+				l.advance(4)
+			default:
+				return token{}, syntaxError(loc, "invalid escape sequence \\%c", escape)
+			}
+		default:
+			r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+			b.WriteRune(r)
+			l.advance(size)
+		}
+	}
+	return token{}, syntaxError(loc, "unterminated string")
+}
+
+// readBlockString reads a triple-quoted string and removes its common
+// indentation as the specification requires.
+func (l *lexer) readBlockString(loc Location) (token, error) {
+	l.advance(3)
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		switch {
+		case strings.HasPrefix(l.src[l.pos:], `"""`):
+			l.advance(3)
+			return token{kind: tokenBlockString, value: blockStringValue(b.String()), loc: loc}, nil
+		case strings.HasPrefix(l.src[l.pos:], `\"""`):
+			b.WriteString(`"""`)
+			l.advance(4)
+		case l.src[l.pos] == '\n':
+			b.WriteByte('\n')
+			l.newline(1)
+		case l.src[l.pos] == '\r':
+			b.WriteByte('\n')
+			if l.pos+1 < len(l.src) && l.src[l.pos+1] == '\n' {
+				l.newline(2)
+			} else {
+				l.newline(1)
+			}
+		default:
+			b.WriteByte(l.src[l.pos])
+			l.advance(1)
+		}
+	}
+	return token{}, syntaxError(loc, "unterminated block string")
+}
+
+// blockStringValue removes the common indentation and blank leading and
+// trailing lines of a block string.
+func blockStringValue(raw string) string {
+	lines := strings.Split(raw, "\n")
+
+	common := -1
+	for _, line := range lines[1:] {
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < len(line) && (common < 0 || indent < common) {
+			common = indent
+		}
+	}
+	if common > 0 {
+		for i := 1; i < len(lines); i++ {
+			if len(lines[i]) >= common {
+				lines[i] = lines[i][common:]
+			} else {
+				lines[i] = ""
+			}
+		}
+	}
+
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameContinue(c byte) bool {
+	return isNameStart(c) || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
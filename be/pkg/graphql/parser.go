@@ -0,0 +1,659 @@
+package graphql
+
+import (
+	"fmt"
+)
+
+// parser is a recursive descent parser over the lexer's tokens with one
+// token of lookahead.
+type parser struct {
+	lexer *lexer
+	tok   token
+}
+
+func newParser(src string) (*parser, error) {
+	p := &parser{lexer: newLexer(src)}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// advance reads the next token.
+func (p *parser) advance() error {
+	tok, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+// peek reports whether the current token is the given punctuator.
+func (p *parser) peek(punct string) bool {
+	return p.tok.kind == tokenPunct && p.tok.value == punct
+}
+
+// peekName reports whether the current token is the given name.
+func (p *parser) peekName(name string) bool {
+	return p.tok.kind == tokenName && p.tok.value == name
+}
+
+// skip consumes the given punctuator if it is the current token.
+func (p *parser) skip(punct string) (bool, error) {
+	if !p.peek(punct) {
+		return false, nil
+	}
+	return true, p.advance()
+}
+
+// expect consumes the given punctuator.
+func (p *parser) expect(punct string) error {
+	if !p.peek(punct) {
+		return p.unexpected("expected %q", punct)
+	}
+	return p.advance()
+}
+
+// expectKeyword consumes the given name.
+func (p *parser) expectKeyword(name string) error {
+	if !p.peekName(name) {
+		return p.unexpected("expected %q", name)
+	}
+	return p.advance()
+}
+
+// name consumes a name token.
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokenName {
+		return "", p.unexpected("expected name")
+	}
+	name := p.tok.value
+	return name, p.advance()
+}
+
+// unexpected returns a syntax error at the current token.
+func (p *parser) unexpected(format string, args ...interface{}) error {
+	found := p.tok.value
+	if p.tok.kind == tokenEOF {
+		found = "<EOF>"
+	}
+	return syntaxError(p.tok.loc, "%s, found %q", fmt.Sprintf(format, args...), found)
+}
+
+// parseQuery parses an executable document.
+func parseQuery(src string) (*queryDocument, error) {
+	p, err := newParser(src)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &queryDocument{}
+	for p.tok.kind != tokenEOF {
+		switch {
+		case p.peek("{"):
+			loc := p.tok.loc
+			selections, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operationDefinition{operation: "query", selectionSet: selections, loc: loc})
+		case p.peekName("query"), p.peekName("mutation"), p.peekName("subscription"):
+			op, err := p.operationDefinition()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case p.peekName("fragment"):
+			fragment, err := p.fragmentDefinition()
+			if err != nil {
+				return nil, err
+			}
+			doc.fragments = append(doc.fragments, fragment)
+		default:
+			return nil, p.unexpected("expected an operation or fragment definition")
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, syntaxError(p.tok.loc, "document contains no operation")
+	}
+	return doc, nil
+}
+
+func (p *parser) operationDefinition() (*operationDefinition, error) {
+	op := &operationDefinition{operation: p.tok.value, loc: p.tok.loc}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if p.tok.kind == tokenName {
+		if op.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek("(") {
+		if op.variables, err = p.variableDefinitions(); err != nil {
+			return nil, err
+		}
+	}
+	if op.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if op.selectionSet, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+func (p *parser) variableDefinitions() ([]*variableDefinition, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var defs []*variableDefinition
+	for !p.peek(")") {
+		def := &variableDefinition{loc: p.tok.loc}
+		if err := p.expect("$"); err != nil {
+			return nil, err
+		}
+		var err error
+		if def.name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if def.typ, err = p.typeReference(); err != nil {
+			return nil, err
+		}
+		if ok, err := p.skip("="); err != nil {
+			return nil, err
+		} else if ok {
+			if def.defaultValue, err = p.value(true); err != nil {
+				return nil, err
+			}
+		}
+		// Directives on variable definitions are allowed but have no effect
+		if _, err := p.directives(); err != nil {
+			return nil, err
+		}
+		defs = append(defs, def)
+	}
+	return defs, p.expect(")")
+}
+
+func (p *parser) fragmentDefinition() (*fragmentDefinition, error) {
+	fragment := &fragmentDefinition{loc: p.tok.loc}
+	if err := p.expectKeyword("fragment"); err != nil {
+		return nil, err
+	}
+	if p.peekName("on") {
+		return nil, p.unexpected("expected fragment name")
+	}
+	var err error
+	if fragment.name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if err := p.expectKeyword("on"); err != nil {
+		return nil, err
+	}
+	if fragment.typeCondition, err = p.name(); err != nil {
+		return nil, err
+	}
+	if fragment.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if fragment.selectionSet, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return fragment, nil
+}
+
+func (p *parser) selectionSet() ([]selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var selections []selection
+	for !p.peek("}") {
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, sel)
+	}
+	if len(selections) == 0 {
+		return nil, p.unexpected("expected a selection")
+	}
+	return selections, p.expect("}")
+}
+
+func (p *parser) selection() (selection, error) {
+	if p.peek("...") {
+		loc := p.tok.loc
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind == tokenName && !p.peekName("on") {
+			spread := &fragmentSpread{loc: loc}
+			var err error
+			if spread.name, err = p.name(); err != nil {
+				return nil, err
+			}
+			if spread.directives, err = p.directives(); err != nil {
+				return nil, err
+			}
+			return spread, nil
+		}
+
+		fragment := &inlineFragment{loc: loc}
+		var err error
+		if p.peekName("on") {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if fragment.typeCondition, err = p.name(); err != nil {
+				return nil, err
+			}
+		}
+		if fragment.directives, err = p.directives(); err != nil {
+			return nil, err
+		}
+		if fragment.selectionSet, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+		return fragment, nil
+	}
+
+	f := &field{loc: p.tok.loc}
+	var err error
+	if f.name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if ok, err := p.skip(":"); err != nil {
+		return nil, err
+	} else if ok {
+		f.alias = f.name
+		if f.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if f.args, err = p.arguments(false); err != nil {
+		return nil, err
+	}
+	if f.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if p.peek("{") {
+		if f.selectionSet, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (p *parser) arguments(constant bool) ([]*argument, error) {
+	if !p.peek("(") {
+		return nil, nil
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	var args []*argument
+	for !p.peek(")") {
+		arg := &argument{loc: p.tok.loc}
+		var err error
+		if arg.name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if arg.value, err = p.value(constant); err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	if len(args) == 0 {
+		return nil, p.unexpected("expected an argument")
+	}
+	return args, p.expect(")")
+}
+
+func (p *parser) directives() ([]*directive, error) {
+	var directives []*directive
+	for p.peek("@") {
+		d := &directive{loc: p.tok.loc}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		var err error
+		if d.name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if d.args, err = p.arguments(false); err != nil {
+			return nil, err
+		}
+		directives = append(directives, d)
+	}
+	return directives, nil
+}
+
+// value parses a value literal; constant values may not reference variables.
+func (p *parser) value(constant bool) (*value, error) {
+	v := &value{loc: p.tok.loc}
+	switch p.tok.kind {
+	case tokenPunct:
+		switch p.tok.value {
+		case "$":
+			if constant {
+				return nil, p.unexpected("unexpected variable in constant value")
+			}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			v.kind, v.raw = variableValue, name
+			return v, nil
+		case "[":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			v.kind = listValue
+			v.list = []*value{}
+			for !p.peek("]") {
+				item, err := p.value(constant)
+				if err != nil {
+					return nil, err
+				}
+				v.list = append(v.list, item)
+			}
+			return v, p.advance()
+		case "{":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			v.kind = objectValue
+			for !p.peek("}") {
+				f := &objectField{loc: p.tok.loc}
+				var err error
+				if f.name, err = p.name(); err != nil {
+					return nil, err
+				}
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+				if f.value, err = p.value(constant); err != nil {
+					return nil, err
+				}
+				v.fields = append(v.fields, f)
+			}
+			return v, p.advance()
+		}
+	case tokenInt:
+		v.kind, v.raw = intValue, p.tok.value
+		return v, p.advance()
+	case tokenFloat:
+		v.kind, v.raw = floatValue, p.tok.value
+		return v, p.advance()
+	case tokenString, tokenBlockString:
+		v.kind, v.raw = stringValue, p.tok.value
+		return v, p.advance()
+	case tokenName:
+		switch p.tok.value {
+		case "true", "false":
+			v.kind, v.raw = booleanValue, p.tok.value
+		case "null":
+			v.kind = nullValue
+		default:
+			v.kind, v.raw = enumValue, p.tok.value
+		}
+		return v, p.advance()
+	}
+	return nil, p.unexpected("expected a value")
+}
+
+// typeReference parses a type such as [String!]!.
+func (p *parser) typeReference() (*typeNode, error) {
+	t := &typeNode{loc: p.tok.loc}
+	if ok, err := p.skip("["); err != nil {
+		return nil, err
+	} else if ok {
+		if t.elem, err = p.typeReference(); err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if t.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	nonNull, err := p.skip("!")
+	if err != nil {
+		return nil, err
+	}
+	t.nonNull = nonNull
+	return t, nil
+}
+
+// parseSchemaDocument parses a schema in the schema definition language.
+// Object, input object, enum and scalar types are supported, as is a schema
+// definition naming the root operation types.
+func parseSchemaDocument(src string) (*schemaDocument, error) {
+	p, err := newParser(src)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &schemaDocument{}
+	for p.tok.kind != tokenEOF {
+		description, err := p.description()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.tok.kind != tokenName {
+			return nil, p.unexpected("expected a type definition")
+		}
+		switch p.tok.value {
+		case "schema":
+			if doc.roots, err = p.schemaDefinition(); err != nil {
+				return nil, err
+			}
+			doc.description = description
+			continue
+		case "type", "input", "enum", "scalar":
+		default:
+			return nil, p.unexpected("unsupported definition")
+		}
+
+		def, err := p.typeDefinition()
+		if err != nil {
+			return nil, err
+		}
+		def.description = description
+		doc.types = append(doc.types, def)
+	}
+	return doc, nil
+}
+
+// description parses an optional description string.
+func (p *parser) description() (string, error) {
+	if p.tok.kind != tokenString && p.tok.kind != tokenBlockString {
+		return "", nil
+	}
+	description := p.tok.value
+	return description, p.advance()
+}
+
+func (p *parser) schemaDefinition() (map[string]string, error) {
+	if err := p.expectKeyword("schema"); err != nil {
+		return nil, err
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	roots := make(map[string]string)
+	for !p.peek("}") {
+		operation, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if roots[operation], err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	return roots, p.advance()
+}
+
+func (p *parser) typeDefinition() (*typeDefinition, error) {
+	def := &typeDefinition{loc: p.tok.loc}
+	keyword := p.tok.value
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	var err error
+	if def.name, err = p.name(); err != nil {
+		return nil, err
+	}
+
+	switch keyword {
+	case "type":
+		def.kind = objectDefinition
+		if p.peekName("implements") {
+			return nil, p.unexpected("interfaces are not supported")
+		}
+		if def.directives, err = p.directives(); err != nil {
+			return nil, err
+		}
+		err = p.block(func() error {
+			f, err := p.fieldDefinition()
+			def.fields = append(def.fields, f)
+			return err
+		})
+	case "input":
+		def.kind = inputDefinition
+		if def.directives, err = p.directives(); err != nil {
+			return nil, err
+		}
+		err = p.block(func() error {
+			f, err := p.inputValueDefinition()
+			def.inputFields = append(def.inputFields, f)
+			return err
+		})
+	case "enum":
+		def.kind = enumDefinition
+		if def.directives, err = p.directives(); err != nil {
+			return nil, err
+		}
+		err = p.block(func() error {
+			v := &enumValueDefinition{loc: p.tok.loc}
+			var err error
+			if v.description, err = p.description(); err != nil {
+				return err
+			}
+			if v.name, err = p.name(); err != nil {
+				return err
+			}
+			v.directives, err = p.directives()
+			def.values = append(def.values, v)
+			return err
+		})
+	case "scalar":
+		def.kind = scalarDefinition
+		def.directives, err = p.directives()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return def, nil
+}
+
+// block parses a braced, non-empty list of items.
+func (p *parser) block(item func() error) error {
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for !p.peek("}") {
+		if err := item(); err != nil {
+			return err
+		}
+	}
+	return p.advance()
+}
+
+func (p *parser) fieldDefinition() (*fieldDefinition, error) {
+	f := &fieldDefinition{}
+	var err error
+	if f.description, err = p.description(); err != nil {
+		return nil, err
+	}
+	f.loc = p.tok.loc
+	if f.name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if ok, err := p.skip("("); err != nil {
+		return nil, err
+	} else if ok {
+		for !p.peek(")") {
+			arg, err := p.inputValueDefinition()
+			if err != nil {
+				return nil, err
+			}
+			f.args = append(f.args, arg)
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	if f.typ, err = p.typeReference(); err != nil {
+		return nil, err
+	}
+	if f.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (p *parser) inputValueDefinition() (*inputValueDefinition, error) {
+	v := &inputValueDefinition{}
+	var err error
+	if v.description, err = p.description(); err != nil {
+		return nil, err
+	}
+	v.loc = p.tok.loc
+	if v.name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	if v.typ, err = p.typeReference(); err != nil {
+		return nil, err
+	}
+	if ok, err := p.skip("="); err != nil {
+		return nil, err
+	} else if ok {
+		if v.defaultValue, err = p.value(true); err != nil {
+			return nil, err
+		}
+	}
+	if v.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
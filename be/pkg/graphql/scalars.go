@@ -0,0 +1,228 @@
+package graphql
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Scalar implements a scalar type.
+type Scalar struct {
+	Name        string
+	Description string
+
+	// Serialize converts a resolved Go value to its JSON representation.
+	// Returning nil produces null.
+	Serialize func(v interface{}) (interface{}, error)
+
+	// ParseValue converts an input value to the Go value passed to
+	// resolvers. Inputs are decoded JSON values (string, bool, float64 or
+	// json.Number) or literals (string, bool, int or float64).
+	ParseValue func(v interface{}) (interface{}, error)
+}
+
+// builtinScalars returns the scalars every schema has.
+func builtinScalars() []*Scalar {
+	return []*Scalar{
+		{Name: "Int", Description: "A signed 32-bit integer.", Serialize: serializeInt, ParseValue: parseInt},
+		{Name: "Float", Description: "A double-precision floating-point number.", Serialize: serializeFloat, ParseValue: parseFloat},
+		{Name: "String", Description: "A UTF-8 character sequence.", Serialize: serializeString, ParseValue: parseString},
+		{Name: "Boolean", Description: "true or false.", Serialize: serializeBoolean, ParseValue: parseBoolean},
+		{Name: "ID", Description: "A unique identifier, serialized as a string.", Serialize: serializeID, ParseValue: parseID},
+	}
+}
+
+// Time is a scalar for time.Time values, serialized as RFC 3339 timestamps
+// in UTC. Zero times serialize as null.
+var Time = &Scalar{
+	Name:        "Time",
+	Description: "An RFC 3339 timestamp.",
+	Serialize: func(v interface{}) (interface{}, error) {
+		var t time.Time
+		switch tv := v.(type) {
+		case time.Time:
+			t = tv
+		case *time.Time:
+			if tv == nil {
+				return nil, nil
+			}
+			t = *tv
+		default:
+			return nil, fmt.Errorf("Time cannot represent %T", v)
+		}
+		if t.IsZero() {
+			return nil, nil
+		}
+		return t.UTC().Format(time.RFC3339Nano), nil
+	},
+	ParseValue: func(v interface{}) (interface{}, error) {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("Time cannot represent %v", v)
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, fmt.Errorf("Time cannot represent %q: expected an RFC 3339 timestamp", s)
+		}
+		return t, nil
+	},
+}
+
+func serializeInt(v interface{}) (interface{}, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n := rv.Int(); n >= math.MinInt32 && n <= math.MaxInt32 {
+			return int(n), nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n := rv.Uint(); n <= math.MaxInt32 {
+			return int(n), nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if f := rv.Float(); f == math.Trunc(f) && f >= math.MinInt32 && f <= math.MaxInt32 {
+			return int(f), nil
+		}
+	}
+	return nil, fmt.Errorf("Int cannot represent %v", v)
+}
+
+func parseInt(v interface{}) (interface{}, error) {
+	switch n := v.(type) {
+	case int:
+		if n >= math.MinInt32 && n <= math.MaxInt32 {
+			return n, nil
+		}
+	case float64:
+		if n == math.Trunc(n) && n >= math.MinInt32 && n <= math.MaxInt32 {
+			return int(n), nil
+		}
+	case json.Number:
+		if i, err := n.Int64(); err == nil && i >= math.MinInt32 && i <= math.MaxInt32 {
+			return int(i), nil
+		}
+	}
+	return nil, fmt.Errorf("Int cannot represent %v", describe(v))
+}
+
+func serializeFloat(v interface{}) (interface{}, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		if f := rv.Float(); !math.IsNaN(f) && !math.IsInf(f, 0) {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("Float cannot represent %v", v)
+}
+
+func parseFloat(v interface{}) (interface{}, error) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), nil
+	case float64:
+		return n, nil
+	case json.Number:
+		if f, err := n.Float64(); err == nil {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("Float cannot represent %v", describe(v))
+}
+
+func serializeString(v interface{}) (interface{}, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.String {
+		return rv.String(), nil
+	}
+	if s, ok := v.(fmt.Stringer); ok {
+		return s.String(), nil
+	}
+	return nil, fmt.Errorf("String cannot represent %v", v)
+}
+
+func parseString(v interface{}) (interface{}, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("String cannot represent %v", describe(v))
+}
+
+func serializeBoolean(v interface{}) (interface{}, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Bool {
+		return rv.Bool(), nil
+	}
+	return nil, fmt.Errorf("Boolean cannot represent %v", v)
+}
+
+func parseBoolean(v interface{}) (interface{}, error) {
+	if b, ok := v.(bool); ok {
+		return b, nil
+	}
+	return nil, fmt.Errorf("Boolean cannot represent %v", describe(v))
+}
+
+// serializeID accepts strings, integers and text marshalers such as MongoDB
+// object IDs. Zero values that report IsZero, such as unset object IDs,
+// serialize as null.
+func serializeID(v interface{}) (interface{}, error) {
+	if z, ok := v.(interface{ IsZero() bool }); ok && z.IsZero() {
+		return nil, nil
+	}
+	if m, ok := v.(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		return string(text), nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	}
+	return nil, fmt.Errorf("ID cannot represent %v", v)
+}
+
+func parseID(v interface{}) (interface{}, error) {
+	switch id := v.(type) {
+	case string:
+		return id, nil
+	case int:
+		return strconv.Itoa(id), nil
+	case float64:
+		if id == math.Trunc(id) {
+			return strconv.FormatInt(int64(id), 10), nil
+		}
+	case json.Number:
+		if _, err := id.Int64(); err == nil {
+			return id.String(), nil
+		}
+	}
+	return nil, fmt.Errorf("ID cannot represent %v", describe(v))
+}
+
+// describe formats an input value for error messages.
+func describe(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
@@ -0,0 +1,444 @@
+package graphql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TypeKind is the kind of a type as reported by introspection.
+type TypeKind string
+
+// Type kinds
+const (
+	KindScalar      TypeKind = "SCALAR"
+	KindObject      TypeKind = "OBJECT"
+	KindEnum        TypeKind = "ENUM"
+	KindInputObject TypeKind = "INPUT_OBJECT"
+	KindList        TypeKind = "LIST"
+	KindNonNull     TypeKind = "NON_NULL"
+)
+
+// namedType is a scalar, object, enum or input object type.
+type namedType struct {
+	kind        TypeKind
+	name        string
+	description string
+
+	fields   []*fieldDef // objects
+	fieldMap map[string]*fieldDef
+
+	inputFields   []*inputValue // input objects
+	inputFieldMap map[string]*inputValue
+
+	values   []*enumValueDef // enums
+	valueMap map[string]*enumValueDef
+
+	scalar *Scalar
+}
+
+// isInput reports whether values of the type can be used as input.
+func (t *namedType) isInput() bool {
+	return t.kind == KindScalar || t.kind == KindEnum || t.kind == KindInputObject
+}
+
+// isLeaf reports whether the type is a scalar or enum.
+func (t *namedType) isLeaf() bool {
+	return t.kind == KindScalar || t.kind == KindEnum
+}
+
+// typeRef is a named type, or a list or non-null wrapper around a type.
+type typeRef struct {
+	kind   TypeKind // KindList, KindNonNull, or the kind of named
+	ofType *typeRef
+	named  *namedType
+}
+
+func (t *typeRef) isNonNull() bool {
+	return t.kind == KindNonNull
+}
+
+// nullable strips a non-null wrapper.
+func (t *typeRef) nullable() *typeRef {
+	if t.kind == KindNonNull {
+		return t.ofType
+	}
+	return t
+}
+
+// base returns the named type inside all wrappers.
+func (t *typeRef) base() *namedType {
+	for t.named == nil {
+		t = t.ofType
+	}
+	return t.named
+}
+
+// String prints the type as written in GraphQL, e.g. [String!]!.
+func (t *typeRef) String() string {
+	switch t.kind {
+	case KindNonNull:
+		return t.ofType.String() + "!"
+	case KindList:
+		return "[" + t.ofType.String() + "]"
+	}
+	return t.named.name
+}
+
+// fieldDef is a field of an object type.
+type fieldDef struct {
+	name              string
+	description       string
+	args              []*inputValue
+	argMap            map[string]*inputValue
+	typ               *typeRef
+	resolve           FieldResolver
+	deprecated        bool
+	deprecationReason string
+}
+
+// inputValue is an argument or input object field.
+type inputValue struct {
+	name              string
+	description       string
+	typ               *typeRef
+	defaultValue      *value
+	deprecated        bool
+	deprecationReason string
+}
+
+// enumValueDef is a value of an enum type.
+type enumValueDef struct {
+	name              string
+	description       string
+	deprecated        bool
+	deprecationReason string
+}
+
+// directiveDef describes a directive for introspection.
+type directiveDef struct {
+	name        string
+	description string
+	locations   []string
+	args        []*inputValue
+}
+
+// Schema is an executable GraphQL schema.
+type Schema struct {
+	description string
+	types       map[string]*namedType
+	query       *namedType
+	mutation    *namedType
+	directives  []*directiveDef
+
+	// Meta fields available on every type or on the query type
+	schemaField   *fieldDef
+	typeField     *fieldDef
+	typenameField *fieldDef
+}
+
+// builtinDirectives is the SDL of the directives every schema supports.
+const builtinDirectives = `
+directive @skip(if: Boolean!) on FIELD | FRAGMENT_SPREAD | INLINE_FRAGMENT
+directive @include(if: Boolean!) on FIELD | FRAGMENT_SPREAD | INLINE_FRAGMENT
+directive @deprecated(reason: String = "No longer supported") on FIELD_DEFINITION | ARGUMENT_DEFINITION | INPUT_FIELD_DEFINITION | ENUM_VALUE
+`
+
+// NewSchema builds a schema from its definition and resolvers. Custom
+// scalars declared in the definition must be passed in scalars.
+//
+// Parameters:
+//   - sdl: Schema in the GraphQL schema definition language
+//   - resolvers: Resolvers by type and field name; fields without a resolver
+//     read their parent value
+//   - scalars: Implementations of the custom scalars
+//
+// Returns:
+//   - *Schema: Executable schema
+//   - error: ErrInvalidSchema describing the problem
+//
+// Example:
+//
+//	schema, err := graphql.NewSchema(`
+//		scalar Time
+//		type Query { now: Time! }
+//	`, graphql.Resolvers{
+//		"Query": {"now": func(p graphql.ResolveParams) (interface{}, error) { return time.Now(), nil }},
+//	}, graphql.Time)
+func NewSchema(sdl string, resolvers Resolvers, scalars ...*Scalar) (*Schema, error) {
+	doc, err := parseSchemaDocument(sdl)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	introspection, err := parseSchemaDocument(introspectionSDL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: introspection: %v", ErrInvalidSchema, err)
+	}
+
+	s := &Schema{description: doc.description, types: make(map[string]*namedType)}
+	for _, scalar := range append(builtinScalars(), scalars...) {
+		s.types[scalar.Name] = &namedType{kind: KindScalar, name: scalar.Name, description: scalar.Description, scalar: scalar}
+	}
+
+	// Declare all types before resolving references between them
+	defs := append(doc.types, introspection.types...)
+	for _, def := range defs {
+		if def.kind == scalarDefinition {
+			existing, ok := s.types[def.name]
+			if !ok {
+				return nil, fmt.Errorf("%w: no implementation for scalar %s", ErrInvalidSchema, def.name)
+			}
+			if def.description != "" {
+				existing.description = def.description
+			}
+			continue
+		}
+		if _, exists := s.types[def.name]; exists {
+			return nil, fmt.Errorf("%w: type %s is defined twice", ErrInvalidSchema, def.name)
+		}
+		if strings.HasPrefix(def.name, "__") && !isIntrospectionType(def, introspection) {
+			return nil, fmt.Errorf("%w: type name %s is reserved", ErrInvalidSchema, def.name)
+		}
+		s.types[def.name] = &namedType{kind: kindOf(def.kind), name: def.name, description: def.description}
+	}
+	for _, def := range defs {
+		if err := s.defineType(def); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+		}
+	}
+
+	roots := doc.roots
+	if roots == nil {
+		roots = map[string]string{"query": "Query"}
+		if _, ok := s.types["Mutation"]; ok {
+			roots["mutation"] = "Mutation"
+		}
+	}
+	for operation, name := range roots {
+		root, ok := s.types[name]
+		if !ok || root.kind != KindObject {
+			return nil, fmt.Errorf("%w: %s root type %s is not an object type", ErrInvalidSchema, operation, name)
+		}
+		switch operation {
+		case "query":
+			s.query = root
+		case "mutation":
+			s.mutation = root
+		default:
+			return nil, fmt.Errorf("%w: %s operations are not supported", ErrInvalidSchema, operation)
+		}
+	}
+	if s.query == nil {
+		return nil, fmt.Errorf("%w: schema has no query type", ErrInvalidSchema)
+	}
+
+	s.defineMetaFields()
+	if s.directives, err = s.parseDirectives(builtinDirectives); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	if err := s.bind(introspectionResolvers); err != nil {
+		return nil, err
+	}
+	if err := s.bind(resolvers); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// isIntrospectionType reports whether def is one of the introspection types.
+func isIntrospectionType(def *typeDefinition, introspection *schemaDocument) bool {
+	for _, t := range introspection.types {
+		if t == def {
+			return true
+		}
+	}
+	return false
+}
+
+func kindOf(kind definitionKind) TypeKind {
+	switch kind {
+	case objectDefinition:
+		return KindObject
+	case inputDefinition:
+		return KindInputObject
+	case enumDefinition:
+		return KindEnum
+	}
+	return KindScalar
+}
+
+// defineType fills in the fields or values of a declared type.
+func (s *Schema) defineType(def *typeDefinition) error {
+	t := s.types[def.name]
+	switch def.kind {
+	case objectDefinition:
+		t.fieldMap = make(map[string]*fieldDef)
+		for _, fd := range def.fields {
+			if _, exists := t.fieldMap[fd.name]; exists {
+				return fmt.Errorf("field %s.%s is defined twice", def.name, fd.name)
+			}
+			typ, err := s.typeRef(fd.typ)
+			if err != nil {
+				return fmt.Errorf("field %s.%s: %v", def.name, fd.name, err)
+			}
+			if typ.base().kind == KindInputObject {
+				return fmt.Errorf("field %s.%s: input type %s cannot be used as a field type", def.name, fd.name, typ)
+			}
+			f := &fieldDef{name: fd.name, description: fd.description, typ: typ, argMap: make(map[string]*inputValue)}
+			f.deprecated, f.deprecationReason = deprecation(fd.directives)
+			for _, ad := range fd.args {
+				arg, err := s.inputValue(ad)
+				if err != nil {
+					return fmt.Errorf("argument %s.%s(%s): %v", def.name, fd.name, ad.name, err)
+				}
+				f.args = append(f.args, arg)
+				f.argMap[arg.name] = arg
+			}
+			t.fields = append(t.fields, f)
+			t.fieldMap[f.name] = f
+		}
+	case inputDefinition:
+		t.inputFieldMap = make(map[string]*inputValue)
+		for _, fd := range def.inputFields {
+			f, err := s.inputValue(fd)
+			if err != nil {
+				return fmt.Errorf("input field %s.%s: %v", def.name, fd.name, err)
+			}
+			t.inputFields = append(t.inputFields, f)
+			t.inputFieldMap[f.name] = f
+		}
+	case enumDefinition:
+		t.valueMap = make(map[string]*enumValueDef)
+		for _, vd := range def.values {
+			if vd.name == "true" || vd.name == "false" || vd.name == "null" {
+				return fmt.Errorf("enum %s cannot have the value %s", def.name, vd.name)
+			}
+			v := &enumValueDef{name: vd.name, description: vd.description}
+			v.deprecated, v.deprecationReason = deprecation(vd.directives)
+			t.values = append(t.values, v)
+			t.valueMap[v.name] = v
+		}
+	}
+	return nil
+}
+
+// inputValue builds an argument or input field definition.
+func (s *Schema) inputValue(def *inputValueDefinition) (*inputValue, error) {
+	typ, err := s.typeRef(def.typ)
+	if err != nil {
+		return nil, err
+	}
+	if !typ.base().isInput() {
+		return nil, fmt.Errorf("output type %s cannot be used as an input type", typ)
+	}
+	v := &inputValue{name: def.name, description: def.description, typ: typ, defaultValue: def.defaultValue}
+	v.deprecated, v.deprecationReason = deprecation(def.directives)
+	if def.defaultValue != nil {
+		if _, err := coerceLiteral(def.defaultValue, typ, nil); err != nil {
+			return nil, fmt.Errorf("invalid default value: %v", err)
+		}
+	}
+	return v, nil
+}
+
+// typeRef resolves a type reference of the definition.
+func (s *Schema) typeRef(node *typeNode) (*typeRef, error) {
+	var t *typeRef
+	if node.elem != nil {
+		elem, err := s.typeRef(node.elem)
+		if err != nil {
+			return nil, err
+		}
+		t = &typeRef{kind: KindList, ofType: elem}
+	} else {
+		named, ok := s.types[node.name]
+		if !ok {
+			return nil, fmt.Errorf("unknown type %s", node.name)
+		}
+		t = &typeRef{kind: named.kind, named: named}
+	}
+	if node.nonNull {
+		t = &typeRef{kind: KindNonNull, ofType: t}
+	}
+	return t, nil
+}
+
+// parseDirectives reads directive definitions for introspection.
+func (s *Schema) parseDirectives(sdl string) ([]*directiveDef, error) {
+	var directives []*directiveDef
+	for _, line := range strings.Split(strings.TrimSpace(sdl), "\n") {
+		// directive @name(args) on LOCATION | LOCATION
+		rest := strings.TrimPrefix(line, "directive @")
+		on := strings.Index(rest, " on ")
+		head, locations := rest[:on], strings.Split(rest[on+4:], " | ")
+		d := &directiveDef{locations: locations}
+		open := strings.Index(head, "(")
+		d.name = head[:open]
+
+		// Parse the arguments as a field definition
+		doc, err := parseSchemaDocument("type D { f" + head[open:] + ": Boolean }")
+		if err != nil {
+			return nil, err
+		}
+		for _, ad := range doc.types[0].fields[0].args {
+			arg, err := s.inputValue(ad)
+			if err != nil {
+				return nil, err
+			}
+			d.args = append(d.args, arg)
+		}
+		directives = append(directives, d)
+	}
+	return directives, nil
+}
+
+// deprecation reads the @deprecated directive.
+func deprecation(directives []*directive) (bool, string) {
+	for _, d := range directives {
+		if d.name != "deprecated" {
+			continue
+		}
+		for _, arg := range d.args {
+			if arg.name == "reason" && arg.value.kind == stringValue {
+				return true, arg.value.raw
+			}
+		}
+		return true, "No longer supported"
+	}
+	return false, ""
+}
+
+// bind attaches resolvers to fields. Resolvers for unknown types or fields
+// are rejected, so that typos do not silently fall back to default resolution.
+func (s *Schema) bind(resolvers Resolvers) error {
+	typeNames := make([]string, 0, len(resolvers))
+	for name := range resolvers {
+		typeNames = append(typeNames, name)
+	}
+	sort.Strings(typeNames)
+
+	for _, typeName := range typeNames {
+		t, ok := s.types[typeName]
+		if !ok || t.kind != KindObject {
+			return fmt.Errorf("%w: resolvers for unknown object type %s", ErrInvalidSchema, typeName)
+		}
+		for fieldName, resolve := range resolvers[typeName] {
+			f, ok := t.fieldMap[fieldName]
+			if !ok {
+				return fmt.Errorf("%w: resolver for unknown field %s.%s", ErrInvalidSchema, typeName, fieldName)
+			}
+			f.resolve = resolve
+		}
+	}
+	return nil
+}
+
+// typeNames returns the names of all types in order.
+func (s *Schema) typeNames() []string {
+	names := make([]string, 0, len(s.types))
+	for name := range s.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,564 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// introspectionMaxDepth bounds the nesting of __schema and __type queries,
+// which are exempt from Limits.MaxDepth so that clients can always introspect
+// the schema. The standard introspection query is 13 levels deep.
+const introspectionMaxDepth = 15
+
+// validator checks a document against the schema.
+type validator struct {
+	s         *Schema
+	fragments map[string]*fragmentDefinition
+	errs      []*Error
+}
+
+func (v *validator) report(err *Error) {
+	v.errs = append(v.errs, err)
+}
+
+// validate checks a document and returns the operation to execute.
+func (s *Schema) validate(doc *queryDocument, operationName string) (*operationDefinition, []*Error) {
+	v := &validator{s: s, fragments: make(map[string]*fragmentDefinition, len(doc.fragments))}
+
+	for _, fragment := range doc.fragments {
+		if _, dup := v.fragments[fragment.name]; dup {
+			v.report(newError(fragment.loc, "There can be only one fragment named %q.", fragment.name))
+			continue
+		}
+		v.fragments[fragment.name] = fragment
+	}
+
+	var selected *operationDefinition
+	names := make(map[string]bool, len(doc.operations))
+	for _, op := range doc.operations {
+		if op.name == "" && len(doc.operations) > 1 {
+			v.report(newError(op.loc, "This anonymous operation must be the only defined operation."))
+		}
+		if op.name != "" {
+			if names[op.name] {
+				v.report(newError(op.loc, "There can be only one operation named %q.", op.name))
+			}
+			names[op.name] = true
+		}
+		if op.name == operationName || (operationName == "" && len(doc.operations) == 1) {
+			selected = op
+		}
+	}
+	if len(v.errs) > 0 {
+		return nil, v.errs
+	}
+	if selected == nil {
+		if operationName == "" {
+			return nil, []*Error{{Message: "Must provide operation name if query contains multiple operations."}}
+		}
+		return nil, []*Error{{Message: "Unknown operation named " + strconv.Quote(operationName) + "."}}
+	}
+
+	for _, fragment := range doc.fragments {
+		t, ok := s.types[fragment.typeCondition]
+		if !ok {
+			v.report(newError(fragment.loc, "Unknown type %q.", fragment.typeCondition))
+			continue
+		}
+		if t.kind != KindObject {
+			v.report(newError(fragment.loc, "Fragment %q cannot condition on non composite type %q.", fragment.name, t.name))
+			continue
+		}
+		v.checkDirectives(fragment.directives, "FRAGMENT_DEFINITION")
+		v.selectionSet(fragment.selectionSet, t)
+	}
+	v.checkFragmentCycles(doc.fragments)
+
+	for _, op := range doc.operations {
+		v.operation(op)
+	}
+	return selected, v.errs
+}
+
+// rootType returns the root type of an operation.
+func (s *Schema) rootType(op *operationDefinition) *namedType {
+	switch op.operation {
+	case "query":
+		return s.query
+	case "mutation":
+		return s.mutation
+	}
+	return nil
+}
+
+func (v *validator) operation(op *operationDefinition) {
+	root := v.s.rootType(op)
+	if root == nil {
+		v.report(newError(op.loc, "Schema is not configured for %s operations.", op.operation))
+		return
+	}
+
+	defined := make(map[string]bool, len(op.variables))
+	for _, def := range op.variables {
+		if defined[def.name] {
+			v.report(newError(def.loc, "There can be only one variable named \"$%s\".", def.name))
+		}
+		defined[def.name] = true
+		typ, err := v.s.typeRef(def.typ)
+		if err != nil {
+			v.report(newError(def.typ.loc, "Unknown type %q.", def.typ.name))
+			continue
+		}
+		if !typ.base().isInput() {
+			v.report(newError(def.loc, "Variable \"$%s\" cannot be non-input type %q.", def.name, typ))
+			continue
+		}
+		if def.defaultValue != nil {
+			if _, err := coerceLiteral(def.defaultValue, typ, nil); err != nil {
+				v.report(newError(def.defaultValue.loc, "Variable \"$%s\" has an invalid default value: %v.", def.name, err))
+			}
+		}
+	}
+
+	v.checkDirectives(op.directives, op.operation)
+	v.selectionSet(op.selectionSet, root)
+
+	// Every variable used, directly or through fragments, must be defined
+	used := make(map[string]Location)
+	v.collectVariables(op.selectionSet, used, make(map[string]bool))
+	for name, loc := range used {
+		if !defined[name] {
+			label := "operation"
+			if op.name != "" {
+				label = "operation " + strconv.Quote(op.name)
+			}
+			v.report(newError(loc, "Variable \"$%s\" is not defined by %s.", name, label))
+		}
+	}
+}
+
+// selectionSet validates selections against the type they select from.
+func (v *validator) selectionSet(selections []selection, t *namedType) {
+	for _, sel := range selections {
+		switch sel := sel.(type) {
+		case *field:
+			v.field(sel, t)
+		case *fragmentSpread:
+			v.checkDirectives(sel.directives, "FRAGMENT_SPREAD")
+			fragment, ok := v.fragments[sel.name]
+			if !ok {
+				v.report(newError(sel.loc, "Unknown fragment %q.", sel.name))
+				continue
+			}
+			if fragment.typeCondition != t.name {
+				v.report(newError(sel.loc, "Fragment %q cannot be spread here as objects of type %q can never be of type %q.", sel.name, t.name, fragment.typeCondition))
+			}
+		case *inlineFragment:
+			v.checkDirectives(sel.directives, "INLINE_FRAGMENT")
+			if sel.typeCondition != "" && sel.typeCondition != t.name {
+				if _, ok := v.s.types[sel.typeCondition]; !ok {
+					v.report(newError(sel.loc, "Unknown type %q.", sel.typeCondition))
+				} else {
+					v.report(newError(sel.loc, "Fragment cannot be spread here as objects of type %q can never be of type %q.", t.name, sel.typeCondition))
+				}
+				continue
+			}
+			v.selectionSet(sel.selectionSet, t)
+		}
+	}
+	v.checkMerge(selections, t)
+}
+
+func (v *validator) field(f *field, t *namedType) {
+	def := v.s.lookupField(t, f.name)
+	if def == nil {
+		v.report(newError(f.loc, "Cannot query field %q on type %q.", f.name, t.name))
+		return
+	}
+	v.checkDirectives(f.directives, "FIELD")
+	v.checkArgs(f.args, def.args, f.loc, "field \""+t.name+"."+f.name+"\"")
+
+	base := def.typ.base()
+	switch {
+	case base.isLeaf() && len(f.selectionSet) > 0:
+		v.report(newError(f.loc, "Field %q must not have a selection since type %q has no subfields.", f.name, def.typ))
+	case !base.isLeaf() && len(f.selectionSet) == 0:
+		v.report(newError(f.loc, "Field %q of type %q must have a selection of subfields.", f.name, def.typ))
+	case !base.isLeaf():
+		v.selectionSet(f.selectionSet, base)
+	}
+}
+
+// checkArgs validates arguments against their definitions. Literal values are
+// coerced to check them; values containing variables are checked when the
+// operation runs.
+func (v *validator) checkArgs(args []*argument, defs []*inputValue, loc Location, owner string) {
+	seen := make(map[string]bool, len(args))
+	for _, arg := range args {
+		if seen[arg.name] {
+			v.report(newError(arg.loc, "There can be only one argument named %q.", arg.name))
+			continue
+		}
+		seen[arg.name] = true
+		var def *inputValue
+		for _, d := range defs {
+			if d.name == arg.name {
+				def = d
+			}
+		}
+		if def == nil {
+			v.report(newError(arg.loc, "Unknown argument %q on %s.", arg.name, owner))
+			continue
+		}
+		if !hasVariables(arg.value) {
+			if _, err := coerceLiteral(arg.value, def.typ, nil); err != nil {
+				v.report(newError(arg.value.loc, "Argument %q has an invalid value: %v.", arg.name, err))
+			}
+		}
+	}
+	for _, def := range defs {
+		if def.typ.isNonNull() && def.defaultValue == nil && !seen[def.name] {
+			v.report(newError(loc, "Argument %q of type %q is required on %s, but it was not provided.", def.name, def.typ, owner))
+		}
+	}
+}
+
+// checkDirectives validates directives used at a location.
+func (v *validator) checkDirectives(directives []*directive, location string) {
+	for _, d := range directives {
+		var def *directiveDef
+		for _, dd := range v.s.directives {
+			if dd.name == d.name {
+				def = dd
+			}
+		}
+		if def == nil {
+			v.report(newError(d.loc, "Unknown directive \"@%s\".", d.name))
+			continue
+		}
+		allowed := false
+		for _, l := range def.locations {
+			if l == location || (l == "QUERY" && location == "query") || (l == "MUTATION" && location == "mutation") {
+				allowed = true
+			}
+		}
+		if !allowed {
+			v.report(newError(d.loc, "Directive \"@%s\" may not be used on %s.", d.name, location))
+			continue
+		}
+		v.checkArgs(d.args, def.args, d.loc, "directive \"@"+d.name+"\"")
+	}
+}
+
+// checkMerge rejects selections that use the same response key for different
+// fields, such as { name: id name }.
+func (v *validator) checkMerge(selections []selection, t *namedType) {
+	seen := make(map[string]*field)
+	var walk func(selections []selection, visited map[string]bool)
+	walk = func(selections []selection, visited map[string]bool) {
+		for _, sel := range selections {
+			switch sel := sel.(type) {
+			case *field:
+				key := sel.responseKey()
+				if other, ok := seen[key]; ok && other.name != sel.name {
+					v.report(&Error{
+						Message:   "Fields \"" + key + "\" conflict because " + other.name + " and " + sel.name + " are different fields. Use different aliases on the fields to fetch both if this was intentional.",
+						Locations: []Location{other.loc, sel.loc},
+					})
+					continue
+				}
+				seen[key] = sel
+			case *fragmentSpread:
+				fragment, ok := v.fragments[sel.name]
+				if !ok || visited[sel.name] || fragment.typeCondition != t.name {
+					continue
+				}
+				visited[sel.name] = true
+				walk(fragment.selectionSet, visited)
+			case *inlineFragment:
+				if sel.typeCondition == "" || sel.typeCondition == t.name {
+					walk(sel.selectionSet, visited)
+				}
+			}
+		}
+	}
+	walk(selections, make(map[string]bool))
+}
+
+// checkFragmentCycles rejects fragments that spread themselves.
+func (v *validator) checkFragmentCycles(fragments []*fragmentDefinition) {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(fragments))
+	var visit func(name string, loc Location) bool
+	visit = func(name string, loc Location) bool {
+		fragment, ok := v.fragments[name]
+		if !ok {
+			return false
+		}
+		switch state[name] {
+		case visiting:
+			v.report(newError(loc, "Cannot spread fragment %q within itself.", name))
+			return true
+		case done:
+			return false
+		}
+		state[name] = visiting
+		cyclic := false
+		for _, spread := range fragmentSpreads(fragment.selectionSet) {
+			if visit(spread.name, spread.loc) {
+				cyclic = true
+				break
+			}
+		}
+		state[name] = done
+		return cyclic
+	}
+	for _, fragment := range fragments {
+		visit(fragment.name, fragment.loc)
+	}
+}
+
+// fragmentSpreads returns the spreads directly within a selection set.
+func fragmentSpreads(selections []selection) []*fragmentSpread {
+	var spreads []*fragmentSpread
+	for _, sel := range selections {
+		switch sel := sel.(type) {
+		case *field:
+			spreads = append(spreads, fragmentSpreads(sel.selectionSet)...)
+		case *fragmentSpread:
+			spreads = append(spreads, sel)
+		case *inlineFragment:
+			spreads = append(spreads, fragmentSpreads(sel.selectionSet)...)
+		}
+	}
+	return spreads
+}
+
+// collectVariables records the variables used within selections.
+func (v *validator) collectVariables(selections []selection, used map[string]Location, visited map[string]bool) {
+	addDirectives := func(directives []*directive) {
+		for _, d := range directives {
+			for _, arg := range d.args {
+				valueVariables(arg.value, used)
+			}
+		}
+	}
+	for _, sel := range selections {
+		switch sel := sel.(type) {
+		case *field:
+			for _, arg := range sel.args {
+				valueVariables(arg.value, used)
+			}
+			addDirectives(sel.directives)
+			v.collectVariables(sel.selectionSet, used, visited)
+		case *fragmentSpread:
+			addDirectives(sel.directives)
+			if fragment, ok := v.fragments[sel.name]; ok && !visited[sel.name] {
+				visited[sel.name] = true
+				v.collectVariables(fragment.selectionSet, used, visited)
+			}
+		case *inlineFragment:
+			addDirectives(sel.directives)
+			v.collectVariables(sel.selectionSet, used, visited)
+		}
+	}
+}
+
+func valueVariables(val *value, used map[string]Location) {
+	switch val.kind {
+	case variableValue:
+		if _, ok := used[val.raw]; !ok {
+			used[val.raw] = val.loc
+		}
+	case listValue:
+		for _, item := range val.list {
+			valueVariables(item, used)
+		}
+	case objectValue:
+		for _, f := range val.fields {
+			valueVariables(f.value, used)
+		}
+	}
+}
+
+func hasVariables(val *value) bool {
+	used := make(map[string]Location)
+	valueVariables(val, used)
+	return len(used) > 0
+}
+
+// checkDocumentLimits rejects documents with more fragment spreads than
+// allowed, counting every spread written in the document once. It runs
+// before validation so that oversized documents are not walked.
+func checkDocumentLimits(doc *queryDocument, limits Limits) *Error {
+	if limits.MaxFragmentSpreads <= 0 {
+		return nil
+	}
+	spreads := 0
+	for _, op := range doc.operations {
+		spreads += len(fragmentSpreads(op.selectionSet))
+	}
+	for _, fragment := range doc.fragments {
+		spreads += len(fragmentSpreads(fragment.selectionSet))
+	}
+	if spreads > limits.MaxFragmentSpreads {
+		return &Error{Message: fmt.Sprintf("Query has %d fragment spreads, more than the maximum of %d.", spreads, limits.MaxFragmentSpreads)}
+	}
+	return nil
+}
+
+// checkLimits measures the depth and estimated cost of an operation.
+func (s *Schema) checkLimits(op *operationDefinition, fragments map[string]*fragmentDefinition, vars map[string]interface{}, limits Limits) *Error {
+	m := &measurer{
+		s:               s,
+		fragments:       fragments,
+		vars:            vars,
+		defaultPageSize: limits.DefaultPageSize,
+		spreads:         make(map[spreadKey]measure),
+	}
+	result := m.selections(op.selectionSet, s.rootType(op), false)
+	if limits.DisableIntrospection && result.introspects {
+		return newError(op.loc, "Introspection is disabled.")
+	}
+	if limits.MaxDepth > 0 && result.depth > limits.MaxDepth {
+		return newError(op.loc, "Query depth %d exceeds the maximum depth of %d.", result.depth, limits.MaxDepth)
+	}
+	if result.introspectionDepth > introspectionMaxDepth {
+		return newError(op.loc, "Introspection depth %d exceeds the maximum depth of %d.", result.introspectionDepth, introspectionMaxDepth)
+	}
+	if limits.MaxComplexity > 0 && result.cost > limits.MaxComplexity {
+		return newError(op.loc, "Query complexity %d exceeds the maximum complexity of %d.", result.cost, limits.MaxComplexity)
+	}
+	return nil
+}
+
+// measure is the cost of selections and how deep they nest below the
+// selecting field, counting introspection fields separately.
+type measure struct {
+	cost               int
+	depth              int
+	introspectionDepth int
+	introspects        bool
+}
+
+// add combines the measures of sibling selections.
+func (m *measure) add(other measure) {
+	m.cost += other.cost
+	if m.cost > maxCost {
+		m.cost = maxCost
+	}
+	if other.depth > m.depth {
+		m.depth = other.depth
+	}
+	if other.introspectionDepth > m.introspectionDepth {
+		m.introspectionDepth = other.introspectionDepth
+	}
+	m.introspects = m.introspects || other.introspects
+}
+
+// spreadKey identifies the measure of a fragment, which differs within
+// introspection queries.
+type spreadKey struct {
+	fragment      string
+	introspection bool
+}
+
+// measurer computes the depth and cost of a validated operation. Every
+// fragment is measured once, however often it is spread, so that fragments
+// spreading each other repeatedly cannot make measuring exponential.
+type measurer struct {
+	s               *Schema
+	fragments       map[string]*fragmentDefinition
+	vars            map[string]interface{}
+	defaultPageSize int
+	spreads         map[spreadKey]measure
+}
+
+// maxCost caps intermediate costs so that multiplying page sizes of deeply
+// nested connections cannot overflow.
+const maxCost = 1 << 40
+
+// selections measures selections on a type. Every field and fragment spread
+// costs 1.
+func (m *measurer) selections(selections []selection, t *namedType, introspection bool) measure {
+	var result measure
+	for _, sel := range selections {
+		switch sel := sel.(type) {
+		case *field:
+			result.add(m.field(sel, t, introspection))
+		case *fragmentSpread:
+			result.add(measure{cost: 1})
+			result.add(m.spread(sel.name, t, introspection))
+		case *inlineFragment:
+			result.add(m.selections(sel.selectionSet, t, introspection))
+		}
+	}
+	return result
+}
+
+// spread measures the selections of a fragment, once per operation.
+func (m *measurer) spread(name string, t *namedType, introspection bool) measure {
+	key := spreadKey{fragment: name, introspection: introspection}
+	if result, ok := m.spreads[key]; ok {
+		return result
+	}
+	// Validation guarantees the fragment exists and is acyclic
+	result := m.selections(m.fragments[name].selectionSet, t, introspection)
+	m.spreads[key] = result
+	return result
+}
+
+func (m *measurer) field(f *field, t *namedType, introspection bool) measure {
+	def := m.s.lookupField(t, f.name)
+	if def == nil {
+		return measure{}
+	}
+	if f.name == "__schema" || f.name == "__type" {
+		introspection = true
+	}
+	level := func(children measure) measure {
+		children.introspects = children.introspects || introspection
+		if introspection {
+			children.introspectionDepth++
+		} else {
+			children.depth++
+			if children.introspectionDepth > 0 {
+				children.introspectionDepth++
+			}
+		}
+		return children
+	}
+	if len(f.selectionSet) == 0 {
+		return level(measure{cost: 1})
+	}
+
+	multiplier := 1
+	if args, err := coerceArgs(def.args, f.args, m.vars, f.loc); err == nil {
+		paginated, given := false, false
+		for _, name := range []string{"first", "last"} {
+			if _, ok := def.argMap[name]; ok {
+				paginated = true
+			}
+			if n, ok := args[name].(int); ok {
+				given = true
+				if n > multiplier {
+					multiplier = n
+				}
+			}
+		}
+		if paginated && !given && m.defaultPageSize > multiplier {
+			multiplier = m.defaultPageSize
+		}
+	}
+	children := m.selections(f.selectionSet, def.typ.base(), introspection)
+	if children.cost > maxCost/multiplier {
+		children.cost = maxCost
+	} else {
+		children.cost = 1 + multiplier*children.cost
+	}
+	return level(children)
+}
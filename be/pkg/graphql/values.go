@@ -0,0 +1,321 @@
+package graphql
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// coerceLiteral converts a literal in a document to the Go value of an input
+// type. Variables are looked up in vars, which holds already coerced variable
+// values; vars is nil where variables are not allowed.
+func coerceLiteral(v *value, t *typeRef, vars map[string]interface{}) (interface{}, error) {
+	if v.kind == variableValue {
+		if vars == nil {
+			return nil, fmt.Errorf("variable $%s is not allowed here", v.raw)
+		}
+		val, ok := vars[v.raw]
+		if (!ok || val == nil) && t.isNonNull() {
+			return nil, fmt.Errorf("variable $%s of non-null type %s must not be null", v.raw, t)
+		}
+		return val, nil
+	}
+	if v.kind == nullValue {
+		if t.isNonNull() {
+			return nil, fmt.Errorf("expected a non-null value of type %s, found null", t)
+		}
+		return nil, nil
+	}
+
+	t = t.nullable()
+	if t.kind == KindList {
+		if v.kind != listValue {
+			// A single value is accepted as a list of one item
+			item, err := coerceLiteral(v, t.ofType, vars)
+			if err != nil {
+				return nil, err
+			}
+			return []interface{}{item}, nil
+		}
+		list := make([]interface{}, len(v.list))
+		for i, item := range v.list {
+			coerced, err := coerceLiteral(item, t.ofType, vars)
+			if err != nil {
+				return nil, fmt.Errorf("in item %d: %v", i, err)
+			}
+			list[i] = coerced
+		}
+		return list, nil
+	}
+
+	named := t.named
+	switch named.kind {
+	case KindScalar:
+		var raw interface{}
+		switch v.kind {
+		case intValue:
+			n, err := strconv.ParseInt(v.raw, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s cannot represent %s", named.name, v.raw)
+			}
+			raw = int(n)
+		case floatValue:
+			f, err := strconv.ParseFloat(v.raw, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s cannot represent %s", named.name, v.raw)
+			}
+			raw = f
+		case stringValue:
+			raw = v.raw
+		case booleanValue:
+			raw = v.raw == "true"
+		default:
+			return nil, fmt.Errorf("%s cannot represent %s", named.name, printValue(v))
+		}
+		return named.scalar.ParseValue(raw)
+	case KindEnum:
+		if v.kind != enumValue {
+			return nil, fmt.Errorf("enum %s cannot represent non-enum value %s", named.name, printValue(v))
+		}
+		if _, ok := named.valueMap[v.raw]; !ok {
+			return nil, fmt.Errorf("value %s does not exist in enum %s", v.raw, named.name)
+		}
+		return v.raw, nil
+	case KindInputObject:
+		if v.kind != objectValue {
+			return nil, fmt.Errorf("expected an object of type %s, found %s", named.name, printValue(v))
+		}
+		fields := make(map[string]*value, len(v.fields))
+		for _, f := range v.fields {
+			if _, ok := named.inputFieldMap[f.name]; !ok {
+				return nil, fmt.Errorf("field %s is not defined by type %s", f.name, named.name)
+			}
+			if _, dup := fields[f.name]; dup {
+				return nil, fmt.Errorf("field %s is given more than once", f.name)
+			}
+			fields[f.name] = f.value
+		}
+		obj := make(map[string]interface{}, len(named.inputFields))
+		for _, def := range named.inputFields {
+			fv, ok := fields[def.name]
+			if ok && fv.kind == variableValue {
+				if _, provided := vars[fv.raw]; !provided {
+					ok = false
+				}
+			}
+			if !ok {
+				if err := applyDefault(obj, def); err != nil {
+					return nil, fmt.Errorf("field %s.%s: %v", named.name, def.name, err)
+				}
+				continue
+			}
+			coerced, err := coerceLiteral(fv, def.typ, vars)
+			if err != nil {
+				return nil, fmt.Errorf("field %s.%s: %v", named.name, def.name, err)
+			}
+			obj[def.name] = coerced
+		}
+		return obj, nil
+	}
+	return nil, fmt.Errorf("%s is not an input type", named.name)
+}
+
+// applyDefault sets the default value of an absent argument or input field,
+// failing if the value is required.
+func applyDefault(obj map[string]interface{}, def *inputValue) error {
+	if def.defaultValue != nil {
+		v, err := coerceLiteral(def.defaultValue, def.typ, nil)
+		if err != nil {
+			return err
+		}
+		obj[def.name] = v
+		return nil
+	}
+	if def.typ.isNonNull() {
+		return fmt.Errorf("value of required type %s was not provided", def.typ)
+	}
+	return nil
+}
+
+// coerceVariable converts a JSON-decoded variable value to the Go value of an
+// input type.
+func coerceVariable(v interface{}, t *typeRef) (interface{}, error) {
+	if v == nil {
+		if t.isNonNull() {
+			return nil, fmt.Errorf("expected a non-null value of type %s, found null", t)
+		}
+		return nil, nil
+	}
+
+	t = t.nullable()
+	if t.kind == KindList {
+		items, ok := v.([]interface{})
+		if !ok {
+			item, err := coerceVariable(v, t.ofType)
+			if err != nil {
+				return nil, err
+			}
+			return []interface{}{item}, nil
+		}
+		list := make([]interface{}, len(items))
+		for i, item := range items {
+			coerced, err := coerceVariable(item, t.ofType)
+			if err != nil {
+				return nil, fmt.Errorf("in item %d: %v", i, err)
+			}
+			list[i] = coerced
+		}
+		return list, nil
+	}
+
+	named := t.named
+	switch named.kind {
+	case KindScalar:
+		return named.scalar.ParseValue(v)
+	case KindEnum:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("enum %s cannot represent %s", named.name, describe(v))
+		}
+		if _, ok := named.valueMap[s]; !ok {
+			return nil, fmt.Errorf("value %q does not exist in enum %s", s, named.name)
+		}
+		return s, nil
+	case KindInputObject:
+		fields, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected an object of type %s, found %s", named.name, describe(v))
+		}
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if _, ok := named.inputFieldMap[name]; !ok {
+				return nil, fmt.Errorf("field %s is not defined by type %s", name, named.name)
+			}
+		}
+		obj := make(map[string]interface{}, len(named.inputFields))
+		for _, def := range named.inputFields {
+			fv, ok := fields[def.name]
+			if !ok {
+				if err := applyDefault(obj, def); err != nil {
+					return nil, fmt.Errorf("field %s.%s: %v", named.name, def.name, err)
+				}
+				continue
+			}
+			coerced, err := coerceVariable(fv, def.typ)
+			if err != nil {
+				return nil, fmt.Errorf("field %s.%s: %v", named.name, def.name, err)
+			}
+			obj[def.name] = coerced
+		}
+		return obj, nil
+	}
+	return nil, fmt.Errorf("%s is not an input type", named.name)
+}
+
+// coerceArgs converts the arguments of a field or directive, applying
+// defaults. Arguments given as variables that were not provided are treated
+// as absent. loc is reported for missing required arguments.
+func coerceArgs(defs []*inputValue, args []*argument, vars map[string]interface{}, loc Location) (map[string]interface{}, *Error) {
+	coerced := make(map[string]interface{}, len(defs))
+	for _, def := range defs {
+		var arg *argument
+		for _, a := range args {
+			if a.name == def.name {
+				arg = a
+				break
+			}
+		}
+		if arg != nil && arg.value.kind == variableValue {
+			if _, provided := vars[arg.value.raw]; !provided {
+				arg = nil
+			}
+		}
+		if arg == nil {
+			if err := applyDefault(coerced, def); err != nil {
+				return nil, newError(loc, "Argument %q of type %s is required, but it was not provided.", def.name, def.typ)
+			}
+			continue
+		}
+		v, err := coerceLiteral(arg.value, def.typ, vars)
+		if err != nil {
+			return nil, newError(arg.loc, "Argument %q has an invalid value: %v.", def.name, err)
+		}
+		coerced[def.name] = v
+	}
+	return coerced, nil
+}
+
+// coerceVariables converts the provided variable values of an operation,
+// applying defaults. Variables that are neither provided nor defaulted are
+// left out of the result.
+func (s *Schema) coerceVariables(op *operationDefinition, provided map[string]interface{}) (map[string]interface{}, []*Error) {
+	vars := make(map[string]interface{}, len(op.variables))
+	var errs []*Error
+	for _, def := range op.variables {
+		typ, err := s.typeRef(def.typ)
+		if err != nil {
+			// Already reported by validation
+			continue
+		}
+		v, ok := provided[def.name]
+		if !ok {
+			if def.defaultValue != nil {
+				if vars[def.name], err = coerceLiteral(def.defaultValue, typ, nil); err != nil {
+					errs = append(errs, newError(def.loc, "Variable \"$%s\" has an invalid default value: %v.", def.name, err))
+				}
+			} else if typ.isNonNull() {
+				errs = append(errs, newError(def.loc, "Variable \"$%s\" of required type %s was not provided.", def.name, typ))
+			}
+			continue
+		}
+		if vars[def.name], err = coerceVariable(v, typ); err != nil {
+			errs = append(errs, newError(def.loc, "Variable \"$%s\" got invalid value %s; %v.", def.name, describe(v), err))
+		}
+	}
+	return vars, errs
+}
+
+// printValue prints a literal as written in GraphQL.
+func printValue(v *value) string {
+	switch v.kind {
+	case variableValue:
+		return "$" + v.raw
+	case stringValue:
+		return strconv.Quote(v.raw)
+	case nullValue:
+		return "null"
+	case listValue:
+		items := make([]string, len(v.list))
+		for i, item := range v.list {
+			items[i] = printValue(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case objectValue:
+		fields := make([]string, len(v.fields))
+		for i, f := range v.fields {
+			fields[i] = f.name + ": " + printValue(f.value)
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	}
+	return v.raw
+}
+
+// isNil reports whether v is nil or a nil pointer, map, slice, interface or
+// function.
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func, reflect.Chan:
+		return rv.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,275 @@
+// Package ber encodes and decodes the subset of the ASN.1 Basic Encoding Rules
+// used by LDAP (RFC 4511 section 5.1): single-byte tags and definite lengths.
+package ber
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Class is the class of a tag.
+type Class byte
+
+// Tag classes
+const (
+	ClassUniversal   Class = 0x00
+	ClassApplication Class = 0x40
+	ClassContext     Class = 0x80
+	ClassPrivate     Class = 0xc0
+)
+
+// Universal tags used by LDAP
+const (
+	TagBoolean     = 1
+	TagInteger     = 2
+	TagOctetString = 4
+	TagNull        = 5
+	TagEnumerated  = 10
+	TagSequence    = 16
+	TagSet         = 17
+)
+
+const (
+	constructedBit = 0x20
+	classMask      = 0xc0
+	tagMask        = 0x1f
+
+	// maxPacketSize bounds the memory a single element may claim.
+	maxPacketSize = 16 << 20
+)
+
+// ErrInvalidPacket is returned for data that is not a valid element.
+var ErrInvalidPacket = errors.New("ber: invalid packet")
+
+// Packet is a decoded element. Primitive elements hold their contents in
+// Value, constructed ones their elements in Children.
+type Packet struct {
+	Class       Class
+	Constructed bool
+	Tag         int
+	Value       []byte
+	Children    []*Packet
+}
+
+// NewPrimitive returns a primitive element with the given contents.
+func NewPrimitive(class Class, tag int, value []byte) *Packet {
+	return &Packet{Class: class, Tag: tag, Value: value}
+}
+
+// NewConstructed returns a constructed element holding the given elements.
+func NewConstructed(class Class, tag int, children ...*Packet) *Packet {
+	return &Packet{Class: class, Constructed: true, Tag: tag, Children: children}
+}
+
+// NewSequence returns a universal SEQUENCE.
+func NewSequence(children ...*Packet) *Packet {
+	return NewConstructed(ClassUniversal, TagSequence, children...)
+}
+
+// NewSet returns a universal SET.
+func NewSet(children ...*Packet) *Packet {
+	return NewConstructed(ClassUniversal, TagSet, children...)
+}
+
+// NewString returns a universal OCTET STRING.
+func NewString(s string) *Packet {
+	return NewPrimitive(ClassUniversal, TagOctetString, []byte(s))
+}
+
+// NewInteger returns a universal INTEGER.
+func NewInteger(v int64) *Packet {
+	return NewPrimitive(ClassUniversal, TagInteger, encodeInt(v))
+}
+
+// NewEnumerated returns a universal ENUMERATED.
+func NewEnumerated(v int64) *Packet {
+	return NewPrimitive(ClassUniversal, TagEnumerated, encodeInt(v))
+}
+
+// NewBoolean returns a universal BOOLEAN.
+func NewBoolean(v bool) *Packet {
+	if v {
+		return NewPrimitive(ClassUniversal, TagBoolean, []byte{0xff})
+	}
+	return NewPrimitive(ClassUniversal, TagBoolean, []byte{0x00})
+}
+
+// Is reports whether the element has the given class and tag.
+func (p *Packet) Is(class Class, tag int) bool {
+	return p != nil && p.Class == class && p.Tag == tag
+}
+
+// Child returns the i-th element of a constructed element, or nil.
+func (p *Packet) Child(i int) *Packet {
+	if p == nil || i < 0 || i >= len(p.Children) {
+		return nil
+	}
+	return p.Children[i]
+}
+
+// Text returns the contents of a primitive element as a string.
+func (p *Packet) Text() string {
+	if p == nil {
+		return ""
+	}
+	return string(p.Value)
+}
+
+// Int decodes the contents as a two's complement integer.
+func (p *Packet) Int() (int64, error) {
+	if p == nil || p.Constructed || len(p.Value) == 0 || len(p.Value) > 8 {
+		return 0, fmt.Errorf("%w: not an integer", ErrInvalidPacket)
+	}
+	v := int64(int8(p.Value[0]))
+	for _, b := range p.Value[1:] {
+		v = v<<8 | int64(b)
+	}
+	return v, nil
+}
+
+// Bool decodes the contents as a boolean.
+func (p *Packet) Bool() (bool, error) {
+	if p == nil || p.Constructed || len(p.Value) != 1 {
+		return false, fmt.Errorf("%w: not a boolean", ErrInvalidPacket)
+	}
+	return p.Value[0] != 0, nil
+}
+
+// Bytes returns the encoding of the element.
+func (p *Packet) Bytes() []byte {
+	content := p.Value
+	if p.Constructed {
+		content = nil
+		for _, child := range p.Children {
+			content = append(content, child.Bytes()...)
+		}
+	}
+
+	identifier := byte(p.Class) | byte(p.Tag&tagMask)
+	if p.Constructed {
+		identifier |= constructedBit
+	}
+	out := append([]byte{identifier}, encodeLength(len(content))...)
+	return append(out, content...)
+}
+
+// Read reads one element from r. It reads no further than the element's end,
+// so the rest of a stream can be handed to a TLS client afterwards.
+//
+// Parameters:
+//   - r: Stream to read from
+//
+// Returns:
+//   - *Packet: The decoded element
+//   - error: io.EOF at the end of the stream, ErrInvalidPacket or a read error
+func Read(r io.Reader) (*Packet, error) {
+	var header [1]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	identifier := header[0]
+	if identifier&tagMask == tagMask {
+		return nil, fmt.Errorf("%w: multi-byte tags are not supported", ErrInvalidPacket)
+	}
+
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	length := int(header[0])
+	if header[0]&0x80 != 0 {
+		n := int(header[0] & 0x7f)
+		if n == 0 || n > 4 {
+			return nil, fmt.Errorf("%w: unsupported length encoding", ErrInvalidPacket)
+		}
+		lengthBytes := make([]byte, n)
+		if _, err := io.ReadFull(r, lengthBytes); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		length = 0
+		for _, b := range lengthBytes {
+			length = length<<8 | int(b)
+		}
+	}
+	if length > maxPacketSize {
+		return nil, fmt.Errorf("%w: element of %d bytes is too large", ErrInvalidPacket, length)
+	}
+
+	content := make([]byte, length)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return decode(identifier, content)
+}
+
+// decode builds an element from its identifier and contents.
+func decode(identifier byte, content []byte) (*Packet, error) {
+	p := &Packet{
+		Class:       Class(identifier & classMask),
+		Constructed: identifier&constructedBit != 0,
+		Tag:         int(identifier & tagMask),
+	}
+	if !p.Constructed {
+		p.Value = content
+		return p, nil
+	}
+
+	for len(content) > 0 {
+		if len(content) < 2 || content[0]&tagMask == tagMask {
+			return nil, ErrInvalidPacket
+		}
+		identifier := content[0]
+		length, n := int(content[1]), 2
+		if content[1]&0x80 != 0 {
+			size := int(content[1] & 0x7f)
+			if size == 0 || size > 4 || len(content) < 2+size {
+				return nil, ErrInvalidPacket
+			}
+			length = 0
+			for _, b := range content[2 : 2+size] {
+				length = length<<8 | int(b)
+			}
+			n += size
+		}
+		if length < 0 || length > len(content)-n {
+			return nil, ErrInvalidPacket
+		}
+		child, err := decode(identifier, content[n:n+length])
+		if err != nil {
+			return nil, err
+		}
+		p.Children = append(p.Children, child)
+		content = content[n+length:]
+	}
+	return p, nil
+}
+
+// encodeLength returns the definite length octets for n.
+func encodeLength(n int) []byte {
+	if n < 0x80 {
+		return []byte{byte(n)}
+	}
+	var octets []byte
+	for v := n; v > 0; v >>= 8 {
+		octets = append([]byte{byte(v)}, octets...)
+	}
+	return append([]byte{0x80 | byte(len(octets))}, octets...)
+}
+
+// encodeInt returns the shortest two's complement encoding of v.
+func encodeInt(v int64) []byte {
+	out := []byte{byte(v)}
+	for (v > 0x7f || v < -0x80) && len(out) < 8 {
+		v >>= 8
+		out = append([]byte{byte(v)}, out...)
+	}
+	return out
+}
+
+// unexpectedEOF reports a stream ending inside an element.
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,65 @@
+package ber
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPacket_RoundTrip(t *testing.T) {
+	long := strings.Repeat("x", 300)
+	p := NewSequence(
+		NewInteger(0),
+		NewInteger(127),
+		NewInteger(128),
+		NewInteger(-129),
+		NewInteger(1<<40),
+		NewEnumerated(2),
+		NewBoolean(true),
+		NewString(long),
+		NewConstructed(ClassApplication, 3, NewPrimitive(ClassContext, 7, []byte("mail"))),
+	)
+	data := p.Bytes()
+
+	decoded, err := Read(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, data, decoded.Bytes())
+	for i, want := range []int64{0, 127, 128, -129, 1 << 40, 2} {
+		got, err := decoded.Child(i).Int()
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	flag, err := decoded.Child(6).Bool()
+	require.NoError(t, err)
+	assert.True(t, flag)
+	assert.Equal(t, long, decoded.Child(7).Text())
+	assert.True(t, decoded.Child(8).Is(ClassApplication, 3))
+	assert.Equal(t, "mail", decoded.Child(8).Child(0).Text())
+	assert.Nil(t, decoded.Child(9))
+}
+
+func TestRead_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"empty stream", nil, io.EOF},
+		{"truncated length", []byte{0x30}, io.ErrUnexpectedEOF},
+		{"truncated contents", []byte{0x04, 0x05, 'a'}, io.ErrUnexpectedEOF},
+		{"indefinite length", []byte{0x30, 0x80}, ErrInvalidPacket},
+		{"multi-byte tag", []byte{0x1f, 0x81, 0x00}, ErrInvalidPacket},
+		{"oversized element", []byte{0x04, 0x84, 0x7f, 0xff, 0xff, 0xff}, ErrInvalidPacket},
+		{"child overruns parent", []byte{0x30, 0x02, 0x04, 0x05}, ErrInvalidPacket},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(bytes.NewReader(tt.data))
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
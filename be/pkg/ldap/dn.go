@@ -0,0 +1,81 @@
+package ldap
+
+import (
+	"encoding/hex"
+	"strings"
+)
+
+// NormalizeDN returns a distinguished name in a form that can be compared
+// with ==: attribute types and values are lowercased and the spaces around
+// separators are removed. Escaped characters are kept escaped.
+func NormalizeDN(dn string) string {
+	rdns := splitDN(dn)
+	for i, rdn := range rdns {
+		attribute, value, _ := strings.Cut(rdn, "=")
+		rdns[i] = strings.ToLower(strings.TrimSpace(attribute)) + "=" + strings.ToLower(strings.TrimSpace(value))
+	}
+	return strings.Join(rdns, ",")
+}
+
+// RDNValue returns the unescaped value of the first RDN of a distinguished
+// name, e.g. "GRC Admins" for "CN=GRC Admins,OU=Groups,DC=example,DC=com".
+func RDNValue(dn string) string {
+	rdns := splitDN(dn)
+	if len(rdns) == 0 {
+		return ""
+	}
+	_, value, ok := strings.Cut(rdns[0], "=")
+	if !ok {
+		return ""
+	}
+	return unescapeDNValue(strings.TrimSpace(value))
+}
+
+// IsDescendant reports whether dn equals base or lies below it. Both must be
+// normalized.
+func IsDescendant(dn, base string) bool {
+	return base == "" || dn == base || strings.HasSuffix(dn, ","+base)
+}
+
+// splitDN splits a distinguished name at its unescaped commas.
+func splitDN(dn string) []string {
+	var rdns []string
+	start := 0
+	for i := 0; i < len(dn); i++ {
+		switch dn[i] {
+		case '\\':
+			i++
+		case ',', ';':
+			rdns = append(rdns, dn[start:i])
+			start = i + 1
+		}
+	}
+	if rest := strings.TrimSpace(dn[start:]); rest != "" || len(rdns) > 0 {
+		rdns = append(rdns, dn[start:])
+	}
+	return rdns
+}
+
+// unescapeDNValue decodes the backslash escapes of an attribute value (RFC 4514).
+func unescapeDNValue(value string) string {
+	if !strings.Contains(value, "\\") {
+		return value
+	}
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i+1 >= len(value) {
+			b.WriteByte(value[i])
+			continue
+		}
+		if i+2 < len(value) {
+			if decoded, err := hex.DecodeString(value[i+1 : i+3]); err == nil {
+				b.Write(decoded)
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(value[i+1])
+		i++
+	}
+	return b.String()
+}
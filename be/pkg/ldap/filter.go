@@ -0,0 +1,392 @@
+package ldap
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/ldap/ber"
+)
+
+// FilterOp is the kind of a filter, numbered as the filter's context tag.
+type FilterOp int
+
+// Filter kinds (RFC 4511 section 4.5.1.7)
+const (
+	FilterAnd            FilterOp = 0
+	FilterOr             FilterOp = 1
+	FilterNot            FilterOp = 2
+	FilterEqual          FilterOp = 3
+	FilterSubstrings     FilterOp = 4
+	FilterGreaterOrEqual FilterOp = 5
+	FilterLessOrEqual    FilterOp = 6
+	FilterPresent        FilterOp = 7
+	FilterApprox         FilterOp = 8
+)
+
+// Substring choice tags
+const (
+	substringInitial = 0
+	substringAny     = 1
+	substringFinal   = 2
+)
+
+// Filter is a parsed search filter.
+type Filter struct {
+	Op FilterOp
+	// Children holds the operands of and, or and not
+	Children []*Filter
+	// Attribute and Value are the operands of the comparisons
+	Attribute string
+	Value     string
+	// Initial, Any and Final are the parts of a substrings filter
+	Initial string
+	Any     []string
+	Final   string
+}
+
+// ParseFilter parses a string filter as defined by RFC 4515, e.g.
+// "(&(objectClass=person)(mail=*@example.com))". The outer parentheses of a
+// single comparison may be omitted. Extensible matches are not supported.
+//
+// Parameters:
+//   - s: Filter string
+//
+// Returns:
+//   - *Filter: The parsed filter
+//   - error: Error describing the syntax problem
+func ParseFilter(s string) (*Filter, error) {
+	s = strings.TrimSpace(s)
+	if s != "" && s[0] != '(' {
+		s = "(" + s + ")"
+	}
+	p := &filterParser{input: s}
+	f, err := p.filter()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.input) {
+		return nil, p.errorf("unexpected %q", p.input[p.pos:])
+	}
+	return f, nil
+}
+
+// EscapeFilter escapes a value for use in a filter string so that it only
+// ever matches itself.
+func EscapeFilter(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '*', '(', ')', '\\', 0:
+			fmt.Fprintf(&b, "\\%02x", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// Packet returns the protocol encoding of the filter.
+func (f *Filter) Packet() *ber.Packet {
+	tag := int(f.Op)
+	switch f.Op {
+	case FilterAnd, FilterOr:
+		p := ber.NewConstructed(ber.ClassContext, tag)
+		for _, child := range f.Children {
+			p.Children = append(p.Children, child.Packet())
+		}
+		return p
+	case FilterNot:
+		return ber.NewConstructed(ber.ClassContext, tag, f.Children[0].Packet())
+	case FilterPresent:
+		return ber.NewPrimitive(ber.ClassContext, tag, []byte(f.Attribute))
+	case FilterSubstrings:
+		parts := ber.NewSequence()
+		if f.Initial != "" {
+			parts.Children = append(parts.Children, ber.NewPrimitive(ber.ClassContext, substringInitial, []byte(f.Initial)))
+		}
+		for _, part := range f.Any {
+			parts.Children = append(parts.Children, ber.NewPrimitive(ber.ClassContext, substringAny, []byte(part)))
+		}
+		if f.Final != "" {
+			parts.Children = append(parts.Children, ber.NewPrimitive(ber.ClassContext, substringFinal, []byte(f.Final)))
+		}
+		return ber.NewConstructed(ber.ClassContext, tag, ber.NewString(f.Attribute), parts)
+	default:
+		return ber.NewConstructed(ber.ClassContext, tag, ber.NewString(f.Attribute), ber.NewString(f.Value))
+	}
+}
+
+// FilterFromPacket decodes the protocol encoding of a filter; directory
+// servers use it to read search requests.
+//
+// Parameters:
+//   - p: Encoded filter
+//
+// Returns:
+//   - *Filter: The decoded filter
+//   - error: Error if p is not a supported filter
+func FilterFromPacket(p *ber.Packet) (*Filter, error) {
+	if p == nil || p.Class != ber.ClassContext || p.Tag > int(FilterApprox) {
+		return nil, fmt.Errorf("%w: unsupported filter", ber.ErrInvalidPacket)
+	}
+	f := &Filter{Op: FilterOp(p.Tag)}
+	switch f.Op {
+	case FilterAnd, FilterOr, FilterNot:
+		if !p.Constructed || (f.Op == FilterNot && len(p.Children) != 1) {
+			return nil, fmt.Errorf("%w: malformed filter", ber.ErrInvalidPacket)
+		}
+		for _, child := range p.Children {
+			c, err := FilterFromPacket(child)
+			if err != nil {
+				return nil, err
+			}
+			f.Children = append(f.Children, c)
+		}
+	case FilterPresent:
+		f.Attribute = p.Text()
+	case FilterSubstrings:
+		if len(p.Children) != 2 {
+			return nil, fmt.Errorf("%w: malformed substrings filter", ber.ErrInvalidPacket)
+		}
+		f.Attribute = p.Child(0).Text()
+		for _, part := range p.Child(1).Children {
+			switch part.Tag {
+			case substringInitial:
+				f.Initial = part.Text()
+			case substringAny:
+				f.Any = append(f.Any, part.Text())
+			case substringFinal:
+				f.Final = part.Text()
+			}
+		}
+	default:
+		if len(p.Children) != 2 {
+			return nil, fmt.Errorf("%w: malformed comparison", ber.ErrInvalidPacket)
+		}
+		f.Attribute, f.Value = p.Child(0).Text(), p.Child(1).Text()
+	}
+	return f, nil
+}
+
+// Matches evaluates the filter against an entry. Attribute names and values
+// are compared case-insensitively, and ordering comparisons are numeric when
+// both sides are integers; this approximates the matching rules of common
+// directory attributes.
+func (f *Filter) Matches(entry *Entry) bool {
+	switch f.Op {
+	case FilterAnd:
+		for _, child := range f.Children {
+			if !child.Matches(entry) {
+				return false
+			}
+		}
+		return true
+	case FilterOr:
+		for _, child := range f.Children {
+			if child.Matches(entry) {
+				return true
+			}
+		}
+		return false
+	case FilterNot:
+		return !f.Children[0].Matches(entry)
+	case FilterPresent:
+		return len(entry.Values(f.Attribute)) > 0
+	}
+
+	for _, value := range entry.Values(f.Attribute) {
+		if f.matchesValue(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesValue evaluates a comparison against one attribute value.
+func (f *Filter) matchesValue(value string) bool {
+	switch f.Op {
+	case FilterEqual, FilterApprox:
+		return strings.EqualFold(value, f.Value)
+	case FilterGreaterOrEqual:
+		return compareValues(value, f.Value) >= 0
+	case FilterLessOrEqual:
+		return compareValues(value, f.Value) <= 0
+	case FilterSubstrings:
+		value = strings.ToLower(value)
+		initial, final := strings.ToLower(f.Initial), strings.ToLower(f.Final)
+		if !strings.HasPrefix(value, initial) {
+			return false
+		}
+		value = value[len(initial):]
+		for _, part := range f.Any {
+			i := strings.Index(value, strings.ToLower(part))
+			if i < 0 {
+				return false
+			}
+			value = value[i+len(part):]
+		}
+		return strings.HasSuffix(value, final)
+	}
+	return false
+}
+
+// compareValues orders two values, numerically when both are integers.
+func compareValues(a, b string) int {
+	x, errA := strconv.ParseInt(a, 10, 64)
+	y, errB := strconv.ParseInt(b, 10, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+// filterParser is a recursive descent parser for string filters.
+type filterParser struct {
+	input string
+	pos   int
+}
+
+func (p *filterParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("ldap: invalid filter at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+// filter parses "(" filtercomp ")".
+func (p *filterParser) filter() (*Filter, error) {
+	if p.pos >= len(p.input) || p.input[p.pos] != '(' {
+		return nil, p.errorf("expected (")
+	}
+	p.pos++
+	if p.pos >= len(p.input) {
+		return nil, p.errorf("unexpected end")
+	}
+
+	var f *Filter
+	var err error
+	switch p.input[p.pos] {
+	case '&', '|':
+		op := FilterAnd
+		if p.input[p.pos] == '|' {
+			op = FilterOr
+		}
+		p.pos++
+		f = &Filter{Op: op}
+		for p.pos < len(p.input) && p.input[p.pos] == '(' {
+			child, err := p.filter()
+			if err != nil {
+				return nil, err
+			}
+			f.Children = append(f.Children, child)
+		}
+		if len(f.Children) == 0 {
+			return nil, p.errorf("empty filter list")
+		}
+	case '!':
+		p.pos++
+		child, err := p.filter()
+		if err != nil {
+			return nil, err
+		}
+		f = &Filter{Op: FilterNot, Children: []*Filter{child}}
+	default:
+		if f, err = p.item(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.pos >= len(p.input) || p.input[p.pos] != ')' {
+		return nil, p.errorf("expected )")
+	}
+	p.pos++
+	return f, nil
+}
+
+// item parses a comparison: attr ("=" | "~=" | ">=" | "<=") value.
+func (p *filterParser) item() (*Filter, error) {
+	end := strings.IndexAny(p.input[p.pos:], "=()")
+	if end <= 0 || p.input[p.pos+end] != '=' {
+		return nil, p.errorf("expected attribute and operator")
+	}
+	attribute := p.input[p.pos : p.pos+end]
+	op := FilterEqual
+	switch attribute[len(attribute)-1] {
+	case '~':
+		op = FilterApprox
+	case '>':
+		op = FilterGreaterOrEqual
+	case '<':
+		op = FilterLessOrEqual
+	}
+	if op != FilterEqual {
+		attribute = attribute[:len(attribute)-1]
+	}
+	attribute = strings.TrimSpace(attribute)
+	if attribute == "" || strings.ContainsAny(attribute, " *\\") {
+		return nil, p.errorf("invalid attribute %q", attribute)
+	}
+	p.pos += end + 1
+
+	valueEnd := strings.IndexAny(p.input[p.pos:], "()")
+	if valueEnd < 0 {
+		return nil, p.errorf("unterminated value")
+	}
+	raw := p.input[p.pos : p.pos+valueEnd]
+	p.pos += valueEnd
+
+	parts := strings.Split(raw, "*")
+	if op == FilterEqual && raw == "*" {
+		return &Filter{Op: FilterPresent, Attribute: attribute}, nil
+	}
+	for i, part := range parts {
+		value, err := unescapeFilterValue(part)
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		parts[i] = value
+	}
+	if len(parts) == 1 {
+		return &Filter{Op: op, Attribute: attribute, Value: parts[0]}, nil
+	}
+	if op != FilterEqual {
+		return nil, p.errorf("wildcards are only allowed in equality filters")
+	}
+
+	f := &Filter{Op: FilterSubstrings, Attribute: attribute, Initial: parts[0], Final: parts[len(parts)-1]}
+	for _, part := range parts[1 : len(parts)-1] {
+		if part != "" {
+			f.Any = append(f.Any, part)
+		}
+	}
+	return f, nil
+}
+
+// unescapeFilterValue decodes the \XX escapes of a filter value.
+func unescapeFilterValue(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+3 > len(s) {
+			return "", fmt.Errorf("incomplete escape in %q", s)
+		}
+		decoded, err := hex.DecodeString(s[i+1 : i+3])
+		if err != nil {
+			return "", fmt.Errorf("invalid escape in %q", s)
+		}
+		b.Write(decoded)
+		i += 2
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,513 @@
+// Package ldap implements the client side of LDAP v3 (RFC 4511) needed to
+// authenticate users with a simple bind and to read a directory: binding,
+// paged subtree searches, StartTLS and LDAPS. The package ldaptest provides an
+// in-process directory server for tests.
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/ldap/ber"
+)
+
+// Protocol operation tags (RFC 4511 section 4.2 and following)
+const (
+	ApplicationBindRequest           = 0
+	ApplicationBindResponse          = 1
+	ApplicationUnbindRequest         = 2
+	ApplicationSearchRequest         = 3
+	ApplicationSearchResultEntry     = 4
+	ApplicationSearchResultDone      = 5
+	ApplicationSearchResultReference = 19
+	ApplicationExtendedRequest       = 23
+	ApplicationExtendedResponse      = 24
+)
+
+// Result codes
+const (
+	ResultSuccess                      = 0
+	ResultOperationsError              = 1
+	ResultProtocolError                = 2
+	ResultSizeLimitExceeded            = 4
+	ResultUnavailableCriticalExtension = 12
+	ResultConfidentialityRequired      = 13
+	ResultNoSuchObject                 = 32
+	ResultInvalidCredentials           = 49
+	ResultInsufficientAccessRights     = 50
+	ResultUnwillingToPerform           = 53
+)
+
+// Object identifiers of the supported extensions
+const (
+	OIDStartTLS     = "1.3.6.1.4.1.1466.20037"
+	OIDPagedResults = "1.2.840.113556.1.4.319"
+)
+
+// Default ports of the ldap and ldaps schemes
+const (
+	defaultPort    = "389"
+	defaultTLSPort = "636"
+)
+
+// ErrInvalidCredentials is returned when a bind is refused because the DN or
+// password is wrong. Binds with an empty password are refused without asking
+// the server, since servers treat them as anonymous binds that succeed.
+var ErrInvalidCredentials = errors.New("ldap: invalid credentials")
+
+// Error is a result code other than success returned by the server.
+type Error struct {
+	ResultCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("ldap: result code %d", e.ResultCode)
+	}
+	return fmt.Sprintf("ldap: result code %d: %s", e.ResultCode, e.Message)
+}
+
+// Is makes errors.Is(err, ErrInvalidCredentials) hold for refused binds.
+func (e *Error) Is(target error) bool {
+	return target == ErrInvalidCredentials && e.ResultCode == ResultInvalidCredentials
+}
+
+// Scope is the part of the tree below the base DN a search covers.
+type Scope int
+
+// Search scopes
+const (
+	ScopeBaseObject   Scope = 0
+	ScopeSingleLevel  Scope = 1
+	ScopeWholeSubtree Scope = 2
+)
+
+// Config describes how to reach a directory server.
+type Config struct {
+	// URL is the server address, "ldap://host[:port]" or "ldaps://host[:port]"
+	URL string
+	// StartTLS upgrades an ldap:// connection to TLS before anything is sent
+	StartTLS bool
+	// RootCAs verifies the server certificate; nil uses the system roots
+	RootCAs *x509.CertPool
+	// Timeout bounds dialing and each operation
+	Timeout time.Duration
+}
+
+// SearchRequest describes a search.
+type SearchRequest struct {
+	BaseDN string
+	Scope  Scope
+	Filter string
+	// Attributes lists the attributes to return; empty returns all user attributes
+	Attributes []string
+	// SizeLimit caps the number of entries the server returns; zero means no limit
+	SizeLimit int
+	// PageSize enables the paged results control with pages of that size
+	PageSize int
+}
+
+// Entry is a directory entry returned by a search.
+type Entry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+// Values returns the values of an attribute, matching its name case-insensitively.
+func (e *Entry) Values(name string) []string {
+	if values, ok := e.Attributes[name]; ok {
+		return values
+	}
+	for attribute, values := range e.Attributes {
+		if strings.EqualFold(attribute, name) {
+			return values
+		}
+	}
+	return nil
+}
+
+// Value returns the first value of an attribute, or "".
+func (e *Entry) Value(name string) string {
+	if values := e.Values(name); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Conn is a connection to a directory server. Operations are run one at a
+// time; a Conn may be shared by goroutines.
+type Conn struct {
+	mu      sync.Mutex
+	conn    net.Conn
+	timeout time.Duration
+	nextID  int64
+}
+
+// Dial connects to a directory server, negotiating TLS for ldaps:// URLs or
+// when StartTLS is set.
+//
+// Parameters:
+//   - ctx: Context bounding the connection setup
+//   - cfg: Server address and TLS settings
+//
+// Returns:
+//   - *Conn: Connection ready for a bind; call Close when done
+//   - error: Error if the URL is invalid or the server cannot be reached
+//
+// Example:
+//
+//	conn, err := ldap.Dial(ctx, ldap.Config{URL: "ldaps://dc1.example-bank.com", Timeout: 10 * time.Second})
+//	if err != nil {
+//		return err
+//	}
+//	defer conn.Close()
+//	err = conn.Bind(ctx, "CN=svc-goedu,OU=Service,DC=example-bank,DC=com", password)
+func Dial(ctx context.Context, cfg Config) (*Conn, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("ldap: invalid server URL %q", cfg.URL)
+	}
+	useTLS := false
+	port := defaultPort
+	switch strings.ToLower(u.Scheme) {
+	case "ldap":
+	case "ldaps":
+		useTLS, port = true, defaultTLSPort
+	default:
+		return nil, fmt.Errorf("ldap: unsupported scheme %q", u.Scheme)
+	}
+	if useTLS && cfg.StartTLS {
+		return nil, errors.New("ldap: StartTLS cannot be used with ldaps")
+	}
+	address := u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), port)
+	}
+	tlsConfig := &tls.Config{ServerName: u.Hostname(), RootCAs: cfg.RootCAs, MinVersion: tls.VersionTLS12}
+
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("ldap: failed to connect to %s: %w", address, err)
+	}
+	c := &Conn{conn: netConn, timeout: cfg.Timeout}
+	if useTLS {
+		err = c.handshake(ctx, tlsConfig)
+	} else if cfg.StartTLS {
+		err = c.startTLS(ctx, tlsConfig)
+	}
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Close sends an unbind request and closes the connection.
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nextID++
+	_ = c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+	_, _ = c.conn.Write(message(c.nextID, ber.NewPrimitive(ber.ClassApplication, ApplicationUnbindRequest, nil)).Bytes())
+	return c.conn.Close()
+}
+
+// Bind authenticates the connection with a simple bind.
+//
+// Parameters:
+//   - ctx: Context bounding the operation
+//   - dn: Distinguished name to bind as
+//   - password: The entry's password; an empty password is always refused
+//
+// Returns:
+//   - error: ErrInvalidCredentials if the server refuses the credentials,
+//     another *Error or a connection error
+func (c *Conn) Bind(ctx context.Context, dn, password string) error {
+	if password == "" {
+		return ErrInvalidCredentials
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	request := ber.NewConstructed(ber.ClassApplication, ApplicationBindRequest,
+		ber.NewInteger(3),
+		ber.NewString(dn),
+		ber.NewPrimitive(ber.ClassContext, 0, []byte(password)),
+	)
+	response, _, err := c.roundTrip(ctx, request, nil, ApplicationBindResponse)
+	if err != nil {
+		return err
+	}
+	return resultError(response)
+}
+
+// Search runs a search and returns all matching entries, following the
+// paged results cookie when PageSize is set. Search result references are
+// not followed.
+//
+// Parameters:
+//   - ctx: Context bounding the whole search
+//   - req: Base DN, scope, filter and attributes
+//
+// Returns:
+//   - []*Entry: Matching entries
+//   - error: Error for an invalid filter, a result code other than success,
+//     or a connection error
+func (c *Conn) Search(ctx context.Context, req *SearchRequest) ([]*Entry, error) {
+	filter, err := ParseFilter(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+	attributes := ber.NewSequence()
+	for _, attribute := range req.Attributes {
+		attributes.Children = append(attributes.Children, ber.NewString(attribute))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var entries []*Entry
+	var cookie []byte
+	for {
+		request := ber.NewConstructed(ber.ClassApplication, ApplicationSearchRequest,
+			ber.NewString(req.BaseDN),
+			ber.NewEnumerated(int64(req.Scope)),
+			ber.NewEnumerated(0), // never dereference aliases
+			ber.NewInteger(int64(req.SizeLimit)),
+			ber.NewInteger(0),
+			ber.NewBoolean(false),
+			filter.Packet(),
+			attributes,
+		)
+		var controls *ber.Packet
+		if req.PageSize > 0 {
+			controls = ber.NewConstructed(ber.ClassContext, 0, PagedResultsControl(req.PageSize, cookie))
+		}
+
+		id, err := c.send(ctx, request, controls)
+		if err != nil {
+			return nil, err
+		}
+		for {
+			msg, err := c.receive(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			op := msg.Child(1)
+			switch {
+			case op.Is(ber.ClassApplication, ApplicationSearchResultEntry):
+				entry, err := decodeEntry(op)
+				if err != nil {
+					return nil, err
+				}
+				entries = append(entries, entry)
+				continue
+			case op.Is(ber.ClassApplication, ApplicationSearchResultReference):
+				continue
+			case !op.Is(ber.ClassApplication, ApplicationSearchResultDone):
+				return nil, fmt.Errorf("%w: unexpected search response", ber.ErrInvalidPacket)
+			}
+
+			if err := resultError(op); err != nil {
+				return nil, err
+			}
+			cookie = nil
+			if req.PageSize > 0 {
+				if _, cookie, err = PagedResultsCookie(msg.Child(2)); err != nil {
+					return nil, err
+				}
+			}
+			break
+		}
+		if len(cookie) == 0 {
+			return entries, nil
+		}
+	}
+}
+
+// PagedResultsControl returns the paged results control (RFC 2696) asking
+// for pages of the given size, continuing after cookie.
+func PagedResultsControl(size int, cookie []byte) *ber.Packet {
+	value := ber.NewSequence(ber.NewInteger(int64(size)), ber.NewPrimitive(ber.ClassUniversal, ber.TagOctetString, cookie))
+	return ber.NewSequence(ber.NewString(OIDPagedResults), ber.NewPrimitive(ber.ClassUniversal, ber.TagOctetString, value.Bytes()))
+}
+
+// PagedResultsCookie finds the paged results control in a message's controls
+// and returns its size and cookie. Both are empty when the control is absent.
+func PagedResultsCookie(controls *ber.Packet) (int, []byte, error) {
+	if controls == nil {
+		return 0, nil, nil
+	}
+	for _, control := range controls.Children {
+		if control.Child(0).Text() != OIDPagedResults {
+			continue
+		}
+		raw := control.Child(len(control.Children) - 1)
+		value, err := ber.Read(strings.NewReader(raw.Text()))
+		if err != nil || len(value.Children) != 2 {
+			return 0, nil, fmt.Errorf("%w: malformed paged results control", ber.ErrInvalidPacket)
+		}
+		size, err := value.Child(0).Int()
+		if err != nil {
+			return 0, nil, err
+		}
+		return int(size), value.Child(1).Value, nil
+	}
+	return 0, nil, nil
+}
+
+// startTLS runs the StartTLS extended operation and the TLS handshake.
+func (c *Conn) startTLS(ctx context.Context, tlsConfig *tls.Config) error {
+	request := ber.NewConstructed(ber.ClassApplication, ApplicationExtendedRequest,
+		ber.NewPrimitive(ber.ClassContext, 0, []byte(OIDStartTLS)),
+	)
+	response, _, err := c.roundTrip(ctx, request, nil, ApplicationExtendedResponse)
+	if err != nil {
+		return err
+	}
+	if err := resultError(response); err != nil {
+		return fmt.Errorf("ldap: StartTLS refused: %w", err)
+	}
+	return c.handshake(ctx, tlsConfig)
+}
+
+// handshake replaces the connection with a TLS client connection.
+func (c *Conn) handshake(ctx context.Context, tlsConfig *tls.Config) error {
+	tlsConn := tls.Client(c.conn, tlsConfig)
+	ctx, cancel := c.operationContext(ctx)
+	defer cancel()
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return fmt.Errorf("ldap: TLS handshake failed: %w", err)
+	}
+	c.conn = tlsConn
+	return nil
+}
+
+// roundTrip sends a request and reads its single response, which must have
+// the given tag.
+func (c *Conn) roundTrip(ctx context.Context, op, controls *ber.Packet, responseTag int) (*ber.Packet, *ber.Packet, error) {
+	id, err := c.send(ctx, op, controls)
+	if err != nil {
+		return nil, nil, err
+	}
+	msg, err := c.receive(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	response := msg.Child(1)
+	if !response.Is(ber.ClassApplication, responseTag) {
+		return nil, nil, fmt.Errorf("%w: unexpected response", ber.ErrInvalidPacket)
+	}
+	return response, msg.Child(2), nil
+}
+
+// send writes a request and returns its message ID.
+func (c *Conn) send(ctx context.Context, op, controls *ber.Packet) (int64, error) {
+	c.nextID++
+	msg := message(c.nextID, op)
+	if controls != nil {
+		msg.Children = append(msg.Children, controls)
+	}
+	if err := c.conn.SetDeadline(c.deadline(ctx)); err != nil {
+		return 0, err
+	}
+	if _, err := c.conn.Write(msg.Bytes()); err != nil {
+		return 0, fmt.Errorf("ldap: failed to send request: %w", err)
+	}
+	return c.nextID, nil
+}
+
+// receive reads the next message for the given ID. Unsolicited notifications,
+// such as a notice of disconnection, end the operation with an error.
+func (c *Conn) receive(ctx context.Context, id int64) (*ber.Packet, error) {
+	for {
+		if err := c.conn.SetDeadline(c.deadline(ctx)); err != nil {
+			return nil, err
+		}
+		msg, err := ber.Read(c.conn)
+		if err != nil {
+			return nil, fmt.Errorf("ldap: failed to read response: %w", err)
+		}
+		if !msg.Is(ber.ClassUniversal, ber.TagSequence) || len(msg.Children) < 2 {
+			return nil, fmt.Errorf("%w: malformed message", ber.ErrInvalidPacket)
+		}
+		msgID, err := msg.Child(0).Int()
+		if err != nil {
+			return nil, err
+		}
+		switch msgID {
+		case id:
+			return msg, nil
+		case 0:
+			if err := resultError(msg.Child(1)); err != nil {
+				return nil, err
+			}
+			return nil, errors.New("ldap: server sent an unsolicited notification")
+		}
+	}
+}
+
+// deadline returns the earlier of the context deadline and the operation timeout.
+func (c *Conn) deadline(ctx context.Context) time.Time {
+	var deadline time.Time
+	if c.timeout > 0 {
+		deadline = time.Now().Add(c.timeout)
+	}
+	if d, ok := ctx.Deadline(); ok && (deadline.IsZero() || d.Before(deadline)) {
+		deadline = d
+	}
+	return deadline
+}
+
+// operationContext bounds ctx by the operation timeout.
+func (c *Conn) operationContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout > 0 {
+		return context.WithTimeout(ctx, c.timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// message wraps a protocol operation in an LDAPMessage.
+func message(id int64, op *ber.Packet) *ber.Packet {
+	return ber.NewSequence(ber.NewInteger(id), op)
+}
+
+// resultError returns the *Error of an LDAPResult other than success.
+func resultError(result *ber.Packet) error {
+	if result == nil || len(result.Children) < 3 {
+		return fmt.Errorf("%w: malformed result", ber.ErrInvalidPacket)
+	}
+	code, err := result.Child(0).Int()
+	if err != nil {
+		return err
+	}
+	if code == ResultSuccess {
+		return nil
+	}
+	return &Error{ResultCode: int(code), Message: result.Child(2).Text()}
+}
+
+// decodeEntry reads a search result entry.
+func decodeEntry(op *ber.Packet) (*Entry, error) {
+	if len(op.Children) != 2 {
+		return nil, fmt.Errorf("%w: malformed search result entry", ber.ErrInvalidPacket)
+	}
+	entry := &Entry{DN: op.Child(0).Text(), Attributes: make(map[string][]string)}
+	for _, attribute := range op.Child(1).Children {
+		name := attribute.Child(0).Text()
+		for _, value := range attribute.Child(1).Children {
+			entry.Attributes[name] = append(entry.Attributes[name], value.Text())
+		}
+	}
+	return entry, nil
+}
@@ -0,0 +1,166 @@
+package ldap_test
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/ldap"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/ldap/ldaptest"
+)
+
+var jane = &ldap.Entry{
+	DN: "CN=Jane Doe,OU=Staff,DC=example,DC=com",
+	Attributes: map[string][]string{
+		"objectClass":        {"top", "person", "user"},
+		"mail":               {"Jane.Doe@example.com"},
+		"sn":                 {"Doe"},
+		"memberOf":           {"CN=GRC Auditors,OU=Groups,DC=example,DC=com"},
+		"userAccountControl": {"512"},
+	},
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		filter  string
+		matches bool
+	}{
+		{"(mail=jane.doe@example.com)", true},
+		{"mail=jane.doe@example.com", true},
+		{"(MAIL=*)", true},
+		{"(title=*)", false},
+		{"(mail=jane*)", true},
+		{"(mail=*@example.com)", true},
+		{"(mail=j*doe*example*)", true},
+		{"(mail=*doe*jane*)", false},
+		{"(&(objectClass=user)(memberOf=cn=grc auditors,ou=groups,dc=example,dc=com))", true},
+		{"(|(sn=Smith)(sn=Doe))", true},
+		{"(!(sn=Doe))", false},
+		{"(userAccountControl>=500)", true},
+		{"(userAccountControl<=99)", false},
+		{"(sn~=doe)", true},
+		{`(cn=\2a)`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			filter, err := ldap.ParseFilter(tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.matches, filter.Matches(jane))
+
+			decoded, err := ldap.FilterFromPacket(filter.Packet())
+			require.NoError(t, err)
+			assert.Equal(t, filter, decoded)
+		})
+	}
+
+	for _, invalid := range []string{"", "(", "(mail)", "(&)", "(mail=a", "(mail=a))", `(mail=\4)`, "(uid>=a*)", "(=x)"} {
+		_, err := ldap.ParseFilter(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestEscapeFilter(t *testing.T) {
+	escaped := ldap.EscapeFilter(`*)(uid=*))(|(uid=*\`)
+	assert.Equal(t, `\2a\29\28uid=\2a\29\29\28|\28uid=\2a\5c`, escaped)
+
+	filter, err := ldap.ParseFilter("(mail=" + escaped + ")")
+	require.NoError(t, err)
+	assert.Equal(t, ldap.FilterEqual, filter.Op)
+	assert.Equal(t, `*)(uid=*))(|(uid=*\`, filter.Value)
+}
+
+func TestDN(t *testing.T) {
+	assert.Equal(t, "cn=jane doe,ou=staff,dc=example,dc=com", ldap.NormalizeDN("CN=Jane Doe, OU=Staff,DC=Example , DC=com"))
+	assert.Equal(t, "GRC Auditors", ldap.RDNValue("CN=GRC Auditors,OU=Groups,DC=example,DC=com"))
+	assert.Equal(t, "Doe, Jane", ldap.RDNValue(`CN=Doe\, Jane,OU=Staff,DC=example,DC=com`))
+	assert.Equal(t, "Doe, Jane", ldap.RDNValue(`CN=Doe\2c Jane,DC=com`))
+	assert.True(t, ldap.IsDescendant("cn=jane,dc=example,dc=com", "dc=example,dc=com"))
+	assert.False(t, ldap.IsDescendant("cn=jane,dc=notexample,dc=com", "dc=example,dc=com"))
+}
+
+func newDirectory(t *testing.T) (*ldaptest.Server, ldap.Config) {
+	t.Helper()
+	directory, err := ldaptest.NewServer()
+	require.NoError(t, err)
+	t.Cleanup(func() { directory.Close() })
+
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM([]byte(directory.CertificatePEM())))
+	directory.AddEntry("DC=example,DC=com", "", map[string][]string{"objectClass": {"domain"}})
+	directory.AddEntry("CN=svc-goedu,OU=Service,DC=example,DC=com", "service-secret", map[string][]string{"objectClass": {"user"}})
+	directory.AddEntry(jane.DN, "jane-secret", jane.Attributes)
+	for i := range 5 {
+		directory.AddEntry(fmt.Sprintf("CN=User %d,OU=Staff,DC=example,DC=com", i), "", map[string][]string{
+			"objectClass": {"person", "user"},
+			"mail":        {fmt.Sprintf("user%d@example.com", i)},
+		})
+	}
+	return directory, ldap.Config{URL: directory.URL(), StartTLS: true, RootCAs: roots, Timeout: 5 * time.Second}
+}
+
+func TestConn(t *testing.T) {
+	ctx := context.Background()
+	directory, cfg := newDirectory(t)
+
+	conn, err := ldap.Dial(ctx, cfg)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Search(ctx, &ldap.SearchRequest{BaseDN: "DC=example,DC=com", Scope: ldap.ScopeWholeSubtree, Filter: "(objectClass=*)"})
+	var ldapErr *ldap.Error
+	require.ErrorAs(t, err, &ldapErr)
+	assert.Equal(t, ldap.ResultOperationsError, ldapErr.ResultCode)
+
+	assert.ErrorIs(t, conn.Bind(ctx, jane.DN, ""), ldap.ErrInvalidCredentials)
+	assert.ErrorIs(t, conn.Bind(ctx, jane.DN, "wrong"), ldap.ErrInvalidCredentials)
+	require.NoError(t, conn.Bind(ctx, "cn=SVC-GOEDU, ou=service,dc=example,dc=com", "service-secret"))
+	assert.Equal(t, []string{jane.DN, "cn=SVC-GOEDU, ou=service,dc=example,dc=com"}, directory.Binds())
+
+	entries, err := conn.Search(ctx, &ldap.SearchRequest{
+		BaseDN:     "OU=Staff,DC=example,DC=com",
+		Scope:      ldap.ScopeWholeSubtree,
+		Filter:     "(&(objectClass=user)(mail=*))",
+		Attributes: []string{"mail", "memberOf"},
+		PageSize:   2,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 6)
+	assert.Equal(t, 3, directory.Searches())
+	assert.Equal(t, jane.DN, entries[0].DN)
+	assert.Equal(t, "Jane.Doe@example.com", entries[0].Value("MAIL"))
+	assert.Equal(t, []string{"CN=GRC Auditors,OU=Groups,DC=example,DC=com"}, entries[0].Values("memberof"))
+	assert.Empty(t, entries[0].Values("sn"))
+
+	entries, err = conn.Search(ctx, &ldap.SearchRequest{
+		BaseDN: "DC=example,DC=com",
+		Scope:  ldap.ScopeWholeSubtree,
+		Filter: "(mail=" + ldap.EscapeFilter("user3@example.com") + ")",
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "CN=User 3,OU=Staff,DC=example,DC=com", entries[0].DN)
+
+	_, err = conn.Search(ctx, &ldap.SearchRequest{BaseDN: "DC=example,DC=com", Scope: ldap.ScopeWholeSubtree, Filter: "(mail=*)", SizeLimit: 2})
+	require.ErrorAs(t, err, &ldapErr)
+	assert.Equal(t, ldap.ResultSizeLimitExceeded, ldapErr.ResultCode)
+
+	_, err = conn.Search(ctx, &ldap.SearchRequest{BaseDN: "DC=other,DC=com", Scope: ldap.ScopeWholeSubtree, Filter: "(mail=*)"})
+	require.ErrorAs(t, err, &ldapErr)
+	assert.Equal(t, ldap.ResultNoSuchObject, ldapErr.ResultCode)
+}
+
+func TestDial_TLSVerification(t *testing.T) {
+	_, cfg := newDirectory(t)
+	cfg.RootCAs = x509.NewCertPool()
+
+	_, err := ldap.Dial(context.Background(), cfg)
+	assert.ErrorContains(t, err, "TLS handshake failed")
+
+	_, err = ldap.Dial(context.Background(), ldap.Config{URL: "http://example.com"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,436 @@
+// Package ldaptest provides an in-process LDAP directory for tests. It serves
+// simple binds, StartTLS with a self-signed certificate and searches with
+// the paged results control over entries added by the test, and requires a
+// successful bind before it answers searches, as Active Directory does.
+package ldaptest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/ldap"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/ldap/ber"
+)
+
+// entry is a stored directory entry.
+type entry struct {
+	*ldap.Entry
+	normalizedDN string
+	password     string
+}
+
+// Server is a fake directory server listening on a loopback port.
+type Server struct {
+	listener       net.Listener
+	tlsConfig      *tls.Config
+	certificatePEM string
+
+	mu       sync.Mutex
+	entries  []*entry
+	binds    []string
+	searches int
+	conns    map[net.Conn]bool
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// NewServer starts an empty directory.
+//
+// Returns:
+//   - *Server: Running server; call Close when done
+//   - error: Error if no certificate can be generated or no port is free
+//
+// Example:
+//
+//	directory, err := ldaptest.NewServer()
+//	require.NoError(t, err)
+//	defer directory.Close()
+//	directory.AddEntry("cn=svc,dc=example,dc=com", "secret", nil)
+func NewServer() (*Server, error) {
+	certificate, certificatePEM, err := selfSignedCertificate()
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		listener:       listener,
+		tlsConfig:      &tls.Config{Certificates: []tls.Certificate{certificate}, MinVersion: tls.VersionTLS12},
+		certificatePEM: certificatePEM,
+		conns:          make(map[net.Conn]bool),
+	}
+	s.wg.Add(1)
+	go s.accept()
+	return s, nil
+}
+
+// URL returns the server's ldap:// URL.
+func (s *Server) URL() string {
+	return "ldap://" + s.listener.Addr().String()
+}
+
+// CertificatePEM returns the certificate presented after StartTLS.
+func (s *Server) CertificatePEM() string {
+	return s.certificatePEM
+}
+
+// AddEntry adds or replaces an entry. Entries with a password accept simple
+// binds with it.
+//
+// Parameters:
+//   - dn: Distinguished name of the entry
+//   - password: Bind password; empty for entries that cannot bind
+//   - attributes: Attribute values by name
+func (s *Server) AddEntry(dn, password string, attributes map[string][]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := &entry{
+		Entry:        &ldap.Entry{DN: dn, Attributes: attributes},
+		normalizedDN: ldap.NormalizeDN(dn),
+		password:     password,
+	}
+	for i, existing := range s.entries {
+		if existing.normalizedDN == e.normalizedDN {
+			s.entries[i] = e
+			return
+		}
+	}
+	s.entries = append(s.entries, e)
+}
+
+// RemoveEntry removes an entry.
+func (s *Server) RemoveEntry(dn string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	normalized := ldap.NormalizeDN(dn)
+	for i, existing := range s.entries {
+		if existing.normalizedDN == normalized {
+			s.entries = append(s.entries[:i], s.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// Binds returns the DNs of all bind attempts, in order.
+func (s *Server) Binds() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.binds...)
+}
+
+// Searches returns the number of search requests served, counting each page.
+func (s *Server) Searches() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.searches
+}
+
+// Close stops the server and closes open connections.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+// accept serves connections until the listener is closed.
+func (s *Server) accept() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = true
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go s.serve(conn)
+	}
+}
+
+// session is the state of one client connection.
+type session struct {
+	conn  net.Conn
+	bound bool
+	tls   bool
+}
+
+// serve answers requests on a connection until the client unbinds or hangs up.
+func (s *Server) serve(conn net.Conn) {
+	defer s.wg.Done()
+	sess := &session{conn: conn}
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		sess.conn.Close()
+	}()
+
+	for {
+		msg, err := ber.Read(sess.conn)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				_ = s.write(sess, 0, result(ldap.ApplicationExtendedResponse, ldap.ResultProtocolError, err.Error()))
+			}
+			return
+		}
+		id, err := msg.Child(0).Int()
+		op := msg.Child(1)
+		if err != nil || op == nil || op.Class != ber.ClassApplication {
+			_ = s.write(sess, 0, result(ldap.ApplicationExtendedResponse, ldap.ResultProtocolError, "malformed message"))
+			return
+		}
+
+		switch op.Tag {
+		case ldap.ApplicationUnbindRequest:
+			return
+		case ldap.ApplicationBindRequest:
+			err = s.write(sess, id, s.bind(sess, op))
+		case ldap.ApplicationSearchRequest:
+			err = s.search(sess, id, op, msg.Child(2))
+		case ldap.ApplicationExtendedRequest:
+			err = s.extended(sess, id, op)
+		default:
+			err = s.write(sess, id, result(ldap.ApplicationExtendedResponse, ldap.ResultProtocolError, "unsupported operation"))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// bind checks a simple bind against the stored passwords.
+func (s *Server) bind(sess *session, op *ber.Packet) *ber.Packet {
+	dn := op.Child(1).Text()
+	credentials := op.Child(2)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.binds = append(s.binds, dn)
+
+	sess.bound = false
+	if !credentials.Is(ber.ClassContext, 0) {
+		return result(ldap.ApplicationBindResponse, ldap.ResultUnwillingToPerform, "only simple binds are supported")
+	}
+	normalized := ldap.NormalizeDN(dn)
+	for _, e := range s.entries {
+		if e.normalizedDN == normalized && e.password != "" && e.password == credentials.Text() {
+			sess.bound = true
+			return result(ldap.ApplicationBindResponse, ldap.ResultSuccess, "")
+		}
+	}
+	return result(ldap.ApplicationBindResponse, ldap.ResultInvalidCredentials, "invalid credentials")
+}
+
+// extended handles the StartTLS operation.
+func (s *Server) extended(sess *session, id int64, op *ber.Packet) error {
+	if op.Child(0).Text() != ldap.OIDStartTLS {
+		return s.write(sess, id, result(ldap.ApplicationExtendedResponse, ldap.ResultProtocolError, "unsupported extended operation"))
+	}
+	if sess.tls {
+		return s.write(sess, id, result(ldap.ApplicationExtendedResponse, ldap.ResultOperationsError, "TLS is already active"))
+	}
+	if err := s.write(sess, id, result(ldap.ApplicationExtendedResponse, ldap.ResultSuccess, "")); err != nil {
+		return err
+	}
+	tlsConn := tls.Server(sess.conn, s.tlsConfig)
+	if err := tlsConn.Handshake(); err != nil {
+		return err
+	}
+	sess.conn, sess.tls = tlsConn, true
+	return nil
+}
+
+// search returns the entries matching a search request, a page at a time
+// when the paged results control is present.
+func (s *Server) search(sess *session, id int64, op, controls *ber.Packet) error {
+	done := func(code int, message string, responseControls *ber.Packet) error {
+		response := ber.NewSequence(ber.NewInteger(id), result(ldap.ApplicationSearchResultDone, code, message))
+		if responseControls != nil {
+			response.Children = append(response.Children, responseControls)
+		}
+		return writePacket(sess.conn, response)
+	}
+	if !sess.bound {
+		return done(ldap.ResultOperationsError, "a successful bind is required", nil)
+	}
+	if len(op.Children) != 8 {
+		return done(ldap.ResultProtocolError, "malformed search request", nil)
+	}
+	base := ldap.NormalizeDN(op.Child(0).Text())
+	scope, _ := op.Child(1).Int()
+	sizeLimit, _ := op.Child(3).Int()
+	filter, err := ldap.FilterFromPacket(op.Child(6))
+	if err != nil {
+		return done(ldap.ResultProtocolError, err.Error(), nil)
+	}
+	var attributes []string
+	for _, attribute := range op.Child(7).Children {
+		attributes = append(attributes, attribute.Text())
+	}
+	pageSize, cookie, err := ldap.PagedResultsCookie(controls)
+	if err != nil {
+		return done(ldap.ResultProtocolError, err.Error(), nil)
+	}
+
+	s.mu.Lock()
+	s.searches++
+	baseExists := base == ""
+	var matches []*ldap.Entry
+	for _, e := range s.entries {
+		if ldap.IsDescendant(e.normalizedDN, base) {
+			baseExists = true
+		}
+		if inScope(e.normalizedDN, base, ldap.Scope(scope)) && filter.Matches(e.Entry) {
+			matches = append(matches, selectAttributes(e.Entry, attributes))
+		}
+	}
+	s.mu.Unlock()
+	if !baseExists {
+		return done(ldap.ResultNoSuchObject, "no entries below the base DN", nil)
+	}
+
+	offset, _ := strconv.Atoi(string(cookie))
+	offset = min(max(offset, 0), len(matches))
+	matches = matches[offset:]
+	var responseControls *ber.Packet
+	if pageSize > 0 {
+		next := ""
+		if len(matches) > pageSize {
+			matches = matches[:pageSize]
+			next = strconv.Itoa(offset + pageSize)
+		}
+		responseControls = ber.NewConstructed(ber.ClassContext, 0, ldap.PagedResultsControl(0, []byte(next)))
+	}
+	code, message := ldap.ResultSuccess, ""
+	if sizeLimit > 0 && len(matches) > int(sizeLimit) {
+		matches = matches[:sizeLimit]
+		code, message = ldap.ResultSizeLimitExceeded, "size limit exceeded"
+	}
+
+	for _, match := range matches {
+		attributeList := ber.NewSequence()
+		for name, values := range match.Attributes {
+			set := ber.NewSet()
+			for _, value := range values {
+				set.Children = append(set.Children, ber.NewString(value))
+			}
+			attributeList.Children = append(attributeList.Children, ber.NewSequence(ber.NewString(name), set))
+		}
+		response := ber.NewConstructed(ber.ClassApplication, ldap.ApplicationSearchResultEntry, ber.NewString(match.DN), attributeList)
+		if err := s.write(sess, id, response); err != nil {
+			return err
+		}
+	}
+	return done(code, message, responseControls)
+}
+
+// write sends a response message.
+func (s *Server) write(sess *session, id int64, op *ber.Packet) error {
+	return writePacket(sess.conn, ber.NewSequence(ber.NewInteger(id), op))
+}
+
+func writePacket(conn net.Conn, p *ber.Packet) error {
+	_ = conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	_, err := conn.Write(p.Bytes())
+	return err
+}
+
+// result returns an LDAPResult with the given operation tag.
+func result(tag, code int, message string) *ber.Packet {
+	return ber.NewConstructed(ber.ClassApplication, tag,
+		ber.NewEnumerated(int64(code)),
+		ber.NewString(""),
+		ber.NewString(message),
+	)
+}
+
+// inScope reports whether an entry lies within a search's scope.
+func inScope(dn, base string, scope ldap.Scope) bool {
+	switch scope {
+	case ldap.ScopeBaseObject:
+		return dn == base
+	case ldap.ScopeSingleLevel:
+		parent := ""
+		if i := strings.Index(dn, ","); i >= 0 {
+			parent = dn[i+1:]
+		}
+		return dn != base && parent == base
+	default:
+		return ldap.IsDescendant(dn, base)
+	}
+}
+
+// selectAttributes returns a copy of an entry with only the requested
+// attributes; no list or "*" returns all of them.
+func selectAttributes(e *ldap.Entry, attributes []string) *ldap.Entry {
+	selected := &ldap.Entry{DN: e.DN, Attributes: make(map[string][]string)}
+	for name, values := range e.Attributes {
+		if len(attributes) == 0 || containsFold(attributes, name) || containsFold(attributes, "*") {
+			selected.Attributes[name] = values
+		}
+	}
+	return selected
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// selfSignedCertificate returns a certificate for the loopback address.
+func selfSignedCertificate() (tls.Certificate, string, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, "", err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ldaptest"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		DNSNames:              []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, "", err
+	}
+	certificatePEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, certificatePEM, nil
+}
@@ -0,0 +1,187 @@
+// Package mail composes and sends multipart email messages. Messages carry a
+// plain text and an HTML body and are encoded as multipart/alternative, so mail
+// clients pick the richest part they can display.
+//
+// Senders report whether a failure is permanent (the server rejected the message
+// or a recipient) or transient (network errors, 4xx replies); callers use
+// IsPermanent to decide whether a send is worth retrying.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrInvalidMessage is returned for messages without a sender, recipients or body.
+var ErrInvalidMessage = errors.New("mail: invalid message")
+
+// Message is an email with a plain text and an optional HTML body.
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+
+	// Additional headers, e.g. Message-ID or List-Unsubscribe
+	Headers map[string]string
+}
+
+// Sender delivers email messages.
+type Sender interface {
+	// Send delivers a message to all of its recipients
+	Send(ctx context.Context, msg *Message) error
+}
+
+// IsPermanent reports whether a send error will not go away by retrying, i.e.
+// the message is invalid or the server answered with a 5xx reply.
+//
+// Parameters:
+//   - err: Error returned by a Sender
+//
+// Returns:
+//   - bool: True if the send should not be retried
+func IsPermanent(err error) bool {
+	if errors.Is(err, ErrInvalidMessage) {
+		return true
+	}
+	var reply *textproto.Error
+	return errors.As(err, &reply) && reply.Code >= 500 && reply.Code < 600
+}
+
+// Validate checks that the message has a valid sender, recipients and a body.
+//
+// Returns:
+//   - error: ErrInvalidMessage describing the first problem found
+func (m *Message) Validate() error {
+	if _, err := mail.ParseAddress(m.From); err != nil {
+		return fmt.Errorf("%w: sender %q: %v", ErrInvalidMessage, m.From, err)
+	}
+	if len(m.To) == 0 {
+		return fmt.Errorf("%w: no recipients", ErrInvalidMessage)
+	}
+	for _, to := range m.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return fmt.Errorf("%w: recipient %q: %v", ErrInvalidMessage, to, err)
+		}
+	}
+	if m.Text == "" && m.HTML == "" {
+		return fmt.Errorf("%w: empty body", ErrInvalidMessage)
+	}
+	for name, value := range m.Headers {
+		if strings.ContainsAny(name+value, "\r\n") {
+			return fmt.Errorf("%w: header %q contains a line break", ErrInvalidMessage, name)
+		}
+	}
+	return nil
+}
+
+// Bytes encodes the message in RFC 5322 format. Date and Message-ID headers
+// are added unless the message already sets them.
+//
+// Returns:
+//   - []byte: Encoded message
+//   - error: Error if the message is invalid
+func (m *Message) Bytes() ([]byte, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	headers := map[string]string{
+		"From":         m.From,
+		"To":           strings.Join(m.To, ", "),
+		"Subject":      mime.QEncoding.Encode("utf-8", m.Subject),
+		"Date":         time.Now().UTC().Format(time.RFC1123Z),
+		"MIME-Version": "1.0",
+	}
+	for name, value := range m.Headers {
+		headers[textproto.CanonicalMIMEHeaderKey(name)] = value
+	}
+	if _, ok := headers["Message-Id"]; !ok {
+		headers["Message-Id"] = newMessageID(m.From)
+	}
+
+	var body bytes.Buffer
+	if m.HTML == "" {
+		headers["Content-Type"] = "text/plain; charset=utf-8"
+		headers["Content-Transfer-Encoding"] = "quoted-printable"
+		if err := writeQuotedPrintable(&body, m.Text); err != nil {
+			return nil, err
+		}
+	} else {
+		parts := multipart.NewWriter(&body)
+		headers["Content-Type"] = "multipart/alternative; boundary=" + parts.Boundary()
+		// Parts are ordered from least to most preferred
+		for _, part := range []struct{ contentType, content string }{
+			{"text/plain; charset=utf-8", m.Text},
+			{"text/html; charset=utf-8", m.HTML},
+		} {
+			if part.content == "" {
+				continue
+			}
+			w, err := parts.CreatePart(textproto.MIMEHeader{
+				"Content-Type":              {part.contentType},
+				"Content-Transfer-Encoding": {"quoted-printable"},
+			})
+			if err != nil {
+				return nil, err
+			}
+			if err := writeQuotedPrintable(w, part.content); err != nil {
+				return nil, err
+			}
+		}
+		if err := parts.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&out, "%s: %s\r\n", name, headers[name])
+	}
+	out.WriteString("\r\n")
+	out.Write(body.Bytes())
+	return out.Bytes(), nil
+}
+
+// writeQuotedPrintable writes content with CRLF line endings in quoted-printable encoding.
+func writeQuotedPrintable(w io.Writer, content string) error {
+	qp := quotedprintable.NewWriter(w)
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	if _, err := qp.Write([]byte(strings.ReplaceAll(content, "\n", "\r\n"))); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// newMessageID generates a random Message-ID in the sender's domain.
+func newMessageID(from string) string {
+	domain := "localhost"
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if at := strings.LastIndex(addr.Address, "@"); at >= 0 {
+			domain = addr.Address[at+1:]
+		}
+	}
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(buf), domain)
+}
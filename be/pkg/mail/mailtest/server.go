@@ -0,0 +1,230 @@
+// Package mailtest provides an in-process SMTP server for tests. It speaks
+// enough of RFC 5321 for net/smtp clients, records every accepted message and
+// can be told to reject the next transactions to exercise retry handling.
+package mailtest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+)
+
+// Received is a message accepted by the server.
+type Received struct {
+	From     string
+	To       []string
+	Data     []byte
+	Username string // set when the client authenticated
+}
+
+// Parse parses the raw message data.
+func (r *Received) Parse() (*mail.Message, error) {
+	return mail.ReadMessage(bytes.NewReader(r.Data))
+}
+
+// Server is a fake SMTP server listening on a loopback port.
+type Server struct {
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu         sync.Mutex
+	messages   []Received
+	rejections []string
+}
+
+// NewServer starts a server on a random loopback port.
+//
+// Returns:
+//   - *Server: Running server; call Close when done
+//   - error: Error if no port can be bound
+//
+// Example:
+//
+//	server, err := mailtest.NewServer()
+//	require.NoError(t, err)
+//	defer server.Close()
+//	sender := mail.NewSMTPSender(mail.SMTPConfig{Host: server.Host(), Port: server.Port()})
+func NewServer() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{listener: listener}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Host returns the host the server listens on.
+func (s *Server) Host() string {
+	return s.listener.Addr().(*net.TCPAddr).IP.String()
+}
+
+// Port returns the port the server listens on.
+func (s *Server) Port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Messages returns the messages accepted so far.
+func (s *Server) Messages() []Received {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Received(nil), s.messages...)
+}
+
+// Reject makes the server answer the next count MAIL commands with the given
+// reply, e.g. "451 4.3.0 try again later" or "550 5.1.1 no such user".
+func (s *Server) Reject(count int, reply string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < count; i++ {
+		s.rejections = append(s.rejections, reply)
+	}
+}
+
+// Close stops the server and waits for open sessions to finish.
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			s.session(conn)
+		}()
+	}
+}
+
+// nextRejection pops the next queued rejection, if any.
+func (s *Server) nextRejection() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.rejections) == 0 {
+		return ""
+	}
+	reply := s.rejections[0]
+	s.rejections = s.rejections[1:]
+	return reply
+}
+
+func (s *Server) session(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	reply := func(format string, args ...interface{}) {
+		fmt.Fprintf(conn, format+"\r\n", args...)
+	}
+
+	var current *Received
+	var username string
+	reply("220 mailtest ESMTP ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			reply("250-mailtest greets %s", arg)
+			reply("250-8BITMIME")
+			reply("250 AUTH PLAIN")
+		case "HELO":
+			reply("250 mailtest")
+		case "AUTH":
+			username = plainUsername(arg)
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			if rejection := s.nextRejection(); rejection != "" {
+				reply("%s", rejection)
+				continue
+			}
+			current = &Received{From: addressArg(arg), Username: username}
+			reply("250 2.1.0 OK")
+		case "RCPT":
+			if current == nil {
+				reply("503 5.5.1 MAIL first")
+				continue
+			}
+			current.To = append(current.To, addressArg(arg))
+			reply("250 2.1.5 OK")
+		case "DATA":
+			if current == nil || len(current.To) == 0 {
+				reply("503 5.5.1 RCPT first")
+				continue
+			}
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			data, err := readData(r)
+			if err != nil {
+				return
+			}
+			current.Data = data
+			s.mu.Lock()
+			s.messages = append(s.messages, *current)
+			s.mu.Unlock()
+			current = nil
+			reply("250 2.0.0 OK queued")
+		case "RSET":
+			current = nil
+			reply("250 2.0.0 OK")
+		case "NOOP":
+			reply("250 2.0.0 OK")
+		case "QUIT":
+			reply("221 2.0.0 Bye")
+			return
+		default:
+			reply("502 5.5.2 Command not implemented")
+		}
+	}
+}
+
+// readData reads a dot-terminated DATA block and removes dot-stuffing.
+func readData(r *bufio.Reader) ([]byte, error) {
+	var data bytes.Buffer
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		if line == ".\r\n" {
+			return data.Bytes(), nil
+		}
+		data.WriteString(strings.TrimPrefix(line, "."))
+	}
+}
+
+// addressArg extracts the address from "FROM:<a@b>" or "TO:<a@b>".
+func addressArg(arg string) string {
+	_, address, _ := strings.Cut(arg, ":")
+	address, _, _ = strings.Cut(address, " ")
+	return strings.Trim(address, "<>")
+}
+
+// plainUsername decodes the user name from an "AUTH PLAIN <credentials>" argument.
+func plainUsername(arg string) string {
+	_, encoded, _ := strings.Cut(arg, " ")
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return ""
+	}
+	parts := strings.Split(string(decoded), "\x00")
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[1]
+}
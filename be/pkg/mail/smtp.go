@@ -0,0 +1,134 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// defaultSMTPTimeout bounds a whole SMTP session when no timeout is configured.
+const defaultSMTPTimeout = 30 * time.Second
+
+// SMTPConfig contains the connection settings of an SMTP relay.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+
+	// Timeout bounds the whole session, from dialing to QUIT
+	Timeout time.Duration
+}
+
+// SMTPSender sends messages through an SMTP relay. Each message uses its own
+// connection; STARTTLS is used whenever the server offers it, and PLAIN
+// authentication is used when a username is configured.
+type SMTPSender struct {
+	cfg SMTPConfig
+}
+
+// NewSMTPSender creates a sender for the given relay.
+//
+// Parameters:
+//   - cfg: Relay connection settings
+//
+// Returns:
+//   - *SMTPSender: Sender instance
+//
+// Example:
+//
+//	sender := mail.NewSMTPSender(mail.SMTPConfig{Host: "smtp.example.com", Port: 587})
+func NewSMTPSender(cfg SMTPConfig) *SMTPSender {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultSMTPTimeout
+	}
+	return &SMTPSender{cfg: cfg}
+}
+
+// Send delivers a message in a single SMTP session.
+//
+// Parameters:
+//   - ctx: Context bounding the session in addition to the configured timeout
+//   - msg: Message to send
+//
+// Returns:
+//   - error: Error if the message is invalid or any recipient is rejected;
+//     use IsPermanent to decide whether to retry
+func (s *SMTPSender) Send(ctx context.Context, msg *Message) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(s.cfg.Timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	dialer := &net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("mail: failed to connect to %s: %w", addr, err)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return fmt.Errorf("mail: failed to set deadline: %w", err)
+	}
+
+	client, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("mail: failed to start session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.cfg.Host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("mail: STARTTLS failed: %w", err)
+		}
+	}
+	if s.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return fmt.Errorf("mail: authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(envelopeAddress(msg.From)); err != nil {
+		return fmt.Errorf("mail: sender rejected: %w", err)
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(envelopeAddress(to)); err != nil {
+			return fmt.Errorf("mail: recipient %s rejected: %w", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("mail: DATA rejected: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("mail: failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("mail: message rejected: %w", err)
+	}
+
+	// The message has been accepted; a failing QUIT does not make it undelivered
+	_ = client.Quit()
+	return nil
+}
+
+// envelopeAddress strips the display name from an address already checked by Validate.
+func envelopeAddress(address string) string {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return address
+	}
+	return parsed.Address
+}
@@ -0,0 +1,133 @@
+package mail_test
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/mail"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/mail/mailtest"
+)
+
+func newServer(t *testing.T) *mailtest.Server {
+	server, err := mailtest.NewServer()
+	require.NoError(t, err)
+	t.Cleanup(func() { server.Close() })
+	return server
+}
+
+func TestSMTPSender_SendsMultipartMessage(t *testing.T) {
+	server := newServer(t)
+	sender := mail.NewSMTPSender(mail.SMTPConfig{
+		Host:     server.Host(),
+		Port:     server.Port(),
+		Username: "relay-user",
+		Password: "secret",
+	})
+
+	err := sender.Send(context.Background(), &mail.Message{
+		From:    "GoEdu <noreply@goedu.com>",
+		To:      []string{"alice@bank.com"},
+		Subject: "Évidence due",
+		Text:    "Please upload the evidence.\n.leading dot",
+		HTML:    "<p>Please upload the evidence.</p>",
+		Headers: map[string]string{"Message-ID": "<outbox-1@goedu.com>"},
+	})
+	require.NoError(t, err)
+
+	messages := server.Messages()
+	require.Len(t, messages, 1)
+	received := messages[0]
+	assert.Equal(t, "noreply@goedu.com", received.From)
+	assert.Equal(t, []string{"alice@bank.com"}, received.To)
+	assert.Equal(t, "relay-user", received.Username)
+
+	parsed, err := received.Parse()
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Évidence due", subject)
+	assert.Equal(t, "<outbox-1@goedu.com>", parsed.Header.Get("Message-ID"))
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+	var bodies []string
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(part)
+		require.NoError(t, err)
+		bodies = append(bodies, part.Header.Get("Content-Type")+"|"+strings.ReplaceAll(string(content), "\r\n", "\n"))
+	}
+	assert.Equal(t, []string{
+		"text/plain; charset=utf-8|Please upload the evidence.\n.leading dot",
+		"text/html; charset=utf-8|<p>Please upload the evidence.</p>",
+	}, bodies)
+}
+
+func TestSMTPSender_ClassifiesRejections(t *testing.T) {
+	server := newServer(t)
+	sender := mail.NewSMTPSender(mail.SMTPConfig{Host: server.Host(), Port: server.Port()})
+	msg := &mail.Message{From: "noreply@goedu.com", To: []string{"bob@bank.com"}, Subject: "Hi", Text: "Hello"}
+
+	server.Reject(1, "451 4.3.0 try again later")
+	err := sender.Send(context.Background(), msg)
+	require.Error(t, err)
+	assert.False(t, mail.IsPermanent(err))
+
+	server.Reject(1, "550 5.7.1 relaying denied")
+	err = sender.Send(context.Background(), msg)
+	require.Error(t, err)
+	assert.True(t, mail.IsPermanent(err))
+
+	require.NoError(t, sender.Send(context.Background(), msg))
+	assert.Len(t, server.Messages(), 1)
+}
+
+func TestSMTPSender_ConnectionFailureIsTransient(t *testing.T) {
+	server := newServer(t)
+	host, port := server.Host(), server.Port()
+	require.NoError(t, server.Close())
+
+	sender := mail.NewSMTPSender(mail.SMTPConfig{Host: host, Port: port})
+	err := sender.Send(context.Background(), &mail.Message{From: "noreply@goedu.com", To: []string{"bob@bank.com"}, Text: "Hello"})
+	require.Error(t, err)
+	assert.False(t, mail.IsPermanent(err))
+}
+
+func TestMessage_Validate(t *testing.T) {
+	valid := mail.Message{From: "noreply@goedu.com", To: []string{"bob@bank.com"}, Text: "Hello"}
+	require.NoError(t, valid.Validate())
+
+	noRecipients := valid
+	noRecipients.To = nil
+	badRecipient := valid
+	badRecipient.To = []string{"not an address"}
+	noBody := valid
+	noBody.Text = ""
+	headerInjection := valid
+	headerInjection.Headers = map[string]string{"X-Tag": "a\r\nBcc: eve@evil.com"}
+
+	for name, msg := range map[string]mail.Message{
+		"no recipients":    noRecipients,
+		"bad recipient":    badRecipient,
+		"no body":          noBody,
+		"header injection": headerInjection,
+	} {
+		err := msg.Validate()
+		assert.ErrorIs(t, err, mail.ErrInvalidMessage, name)
+		assert.True(t, mail.IsPermanent(err), name)
+	}
+}
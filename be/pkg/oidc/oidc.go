@@ -0,0 +1,484 @@
+// Package oidc implements the relying party side of OpenID Connect for the
+// GoEdu Control Testing Platform: provider discovery, the authorization code
+// flow with PKCE (RFC 7636) and validation of ID tokens against the keys the
+// provider publishes. Provider metadata and keys are cached per issuer.
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Errors returned by the client
+var (
+	ErrDiscovery      = errors.New("oidc: provider discovery failed")
+	ErrExchange       = errors.New("oidc: authorization code exchange failed")
+	ErrInvalidIDToken = errors.New("oidc: invalid ID token")
+)
+
+// DefaultScopes are requested when a Config names none.
+var DefaultScopes = []string{"openid", "email", "profile"}
+
+// signingMethods lists the accepted ID token algorithms. Symmetric and
+// unsigned tokens are never accepted.
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// maxResponseBytes bounds the size of provider responses.
+const maxResponseBytes = 1 << 20
+
+// Config identifies the relying party at a provider.
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// ProviderMetadata is the part of a provider's discovery document the client uses.
+type ProviderMetadata struct {
+	Issuer                        string   `json:"issuer"`
+	AuthorizationEndpoint         string   `json:"authorization_endpoint"`
+	TokenEndpoint                 string   `json:"token_endpoint"`
+	JWKSURI                       string   `json:"jwks_uri"`
+	UserinfoEndpoint              string   `json:"userinfo_endpoint,omitempty"`
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported,omitempty"`
+}
+
+// Token is a token endpoint response.
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int    `json:"expires_in,omitempty"`
+}
+
+// IDToken holds the validated claims of an ID token. Claims keeps every claim
+// so callers can read provider-specific ones such as groups.
+type IDToken struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	GivenName     string
+	FamilyName    string
+	ExpiresAt     time.Time
+	Claims        map[string]interface{}
+}
+
+// StringsClaim returns a claim holding a string or a list of strings, such as
+// a groups claim. Other values yield nil.
+func (t *IDToken) StringsClaim(name string) []string {
+	switch value := t.Claims[name].(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		result := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// provider is the cached state of one issuer.
+type provider struct {
+	metadata  *ProviderMetadata
+	fetchedAt time.Time
+	keys      map[string]crypto.PublicKey
+}
+
+// Client talks to OpenID providers on behalf of the platform.
+type Client struct {
+	httpClient *http.Client
+	cacheTTL   time.Duration
+	clockSkew  time.Duration
+	now        func() time.Time
+
+	mu        sync.Mutex
+	providers map[string]*provider
+}
+
+// NewClient creates a client.
+//
+// Parameters:
+//   - httpClient: Client for provider requests; http.DefaultClient when nil
+//   - cacheTTL: How long discovery documents and keys are reused
+//   - clockSkew: Leeway allowed when checking token times
+//
+// Returns:
+//   - *Client: Configured client
+//
+// Example:
+//
+//	client := oidc.NewClient(&http.Client{Timeout: 10 * time.Second}, time.Hour, time.Minute)
+//	metadata, err := client.Discover(ctx, "https://login.example-bank.com")
+func NewClient(httpClient *http.Client, cacheTTL, clockSkew time.Duration) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		httpClient: httpClient,
+		cacheTTL:   cacheTTL,
+		clockSkew:  clockSkew,
+		now:        time.Now,
+		providers:  make(map[string]*provider),
+	}
+}
+
+// Discover returns an issuer's metadata, fetching its discovery document from
+// <issuer>/.well-known/openid-configuration unless a cached copy is fresh.
+// The document must name the issuer it was fetched for.
+func (c *Client) Discover(ctx context.Context, issuer string) (*ProviderMetadata, error) {
+	issuer = strings.TrimSuffix(issuer, "/")
+	c.mu.Lock()
+	cached, ok := c.providers[issuer]
+	c.mu.Unlock()
+	if ok && c.now().Sub(cached.fetchedAt) < c.cacheTTL {
+		return cached.metadata, nil
+	}
+
+	var metadata ProviderMetadata
+	if err := c.getJSON(ctx, issuer+"/.well-known/openid-configuration", &metadata); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDiscovery, err)
+	}
+	if strings.TrimSuffix(metadata.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("%w: document issuer %q does not match %q", ErrDiscovery, metadata.Issuer, issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: document lacks authorization, token or JWKS endpoint", ErrDiscovery)
+	}
+	if len(metadata.CodeChallengeMethodsSupported) > 0 && !slices.Contains(metadata.CodeChallengeMethodsSupported, "S256") {
+		return nil, fmt.Errorf("%w: provider does not support S256 PKCE", ErrDiscovery)
+	}
+
+	c.mu.Lock()
+	c.providers[issuer] = &provider{metadata: &metadata, fetchedAt: c.now()}
+	c.mu.Unlock()
+	return &metadata, nil
+}
+
+// AuthCodeURL returns the URL the user is sent to for signing in. The S256
+// challenge of verifier is sent along; the verifier itself must be kept until
+// the code is exchanged.
+func (c *Client) AuthCodeURL(cfg Config, metadata *ProviderMetadata, state, nonce, verifier string) string {
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = DefaultScopes
+	}
+	if !slices.Contains(scopes, "openid") {
+		scopes = append([]string{"openid"}, scopes...)
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {cfg.ClientID},
+		"redirect_uri":          {cfg.RedirectURL},
+		"scope":                 {strings.Join(scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {S256Challenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return metadata.AuthorizationEndpoint + separator + query.Encode()
+}
+
+// Exchange redeems an authorization code at the token endpoint, proving
+// possession of the PKCE verifier. The client authenticates with HTTP basic
+// authentication when it has a secret.
+func (c *Client) Exchange(ctx context.Context, cfg Config, metadata *ProviderMetadata, code, verifier string) (*Token, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {cfg.RedirectURL},
+		"client_id":     {cfg.ClientID},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExchange, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(cfg.ClientID), url.QueryEscape(cfg.ClientSecret))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExchange, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExchange, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		_ = json.Unmarshal(body, &failure)
+		return nil, fmt.Errorf("%w: status %d %s %s", ErrExchange, resp.StatusCode, failure.Error, failure.Description)
+	}
+
+	var token Token
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExchange, err)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("%w: response has no id_token", ErrExchange)
+	}
+	return &token, nil
+}
+
+// VerifyIDToken validates an ID token issued to cfg.ClientID: its signature
+// against the issuer's published keys, the issuer, audience and authorized
+// party, expiry and issue time, and that it carries the nonce sent with the
+// authorization request.
+func (c *Client) VerifyIDToken(ctx context.Context, cfg Config, metadata *ProviderMetadata, rawToken, nonce string) (*IDToken, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(rawToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return c.key(ctx, metadata, kid)
+	},
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithIssuer(metadata.Issuer),
+		jwt.WithAudience(cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(c.clockSkew),
+		jwt.WithTimeFunc(c.now),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	audience, _ := claims.GetAudience()
+	if azp, ok := claims["azp"].(string); (ok || len(audience) > 1) && azp != cfg.ClientID {
+		return nil, fmt.Errorf("%w: authorized party %q is not this client", ErrInvalidIDToken, azp)
+	}
+	tokenNonce, _ := claims["nonce"].(string)
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(tokenNonce), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	subject, _ := claims.GetSubject()
+	if subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+
+	expiresAt, _ := claims.GetExpirationTime()
+	idToken := &IDToken{
+		Issuer:    metadata.Issuer,
+		Subject:   subject,
+		ExpiresAt: expiresAt.Time,
+		Claims:    claims,
+	}
+	idToken.Email, _ = claims["email"].(string)
+	idToken.Name, _ = claims["name"].(string)
+	idToken.GivenName, _ = claims["given_name"].(string)
+	idToken.FamilyName, _ = claims["family_name"].(string)
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		idToken.EmailVerified = verified
+	case string:
+		// Some providers send the flag as a string
+		idToken.EmailVerified = verified == "true"
+	}
+	return idToken, nil
+}
+
+// key returns the issuer's public key with the given ID. The key set is
+// fetched again when the ID is unknown, which is how providers roll keys.
+func (c *Client) key(ctx context.Context, metadata *ProviderMetadata, kid string) (crypto.PublicKey, error) {
+	issuer := strings.TrimSuffix(metadata.Issuer, "/")
+	c.mu.Lock()
+	cached := c.providers[issuer]
+	var keys map[string]crypto.PublicKey
+	if cached != nil {
+		keys = cached.keys
+	}
+	c.mu.Unlock()
+
+	if key, ok := lookupKey(keys, kid); ok {
+		return key, nil
+	}
+
+	keys, err := c.fetchKeys(ctx, metadata.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	if cached = c.providers[issuer]; cached != nil {
+		cached.keys = keys
+	}
+	c.mu.Unlock()
+
+	if key, ok := lookupKey(keys, kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("no signing key %q", kid)
+}
+
+// lookupKey finds a key by ID. A token without a key ID matches the only key
+// of a single-key set.
+func lookupKey(keys map[string]crypto.PublicKey, kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, true
+		}
+	}
+	key, ok := keys[kid]
+	return key, ok && kid != ""
+}
+
+// jsonWebKey is a key of a JWKS document.
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+// fetchKeys downloads a JWKS document and returns its signing keys by ID.
+// Keys of unsupported types are skipped.
+func (c *Client) fetchKeys(ctx context.Context, jwksURI string) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := c.getJSON(ctx, jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("failed to fetch signing keys: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.KeyID] = key
+	}
+	return keys, nil
+}
+
+// publicKey decodes an RSA or EC public key.
+func (k *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("RSA exponent too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("EC point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.KeyType)
+}
+
+// decodeBigInt decodes a base64url encoded unsigned big-endian integer.
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// getJSON fetches a JSON document.
+func (c *Client) getJSON(ctx context.Context, url string, dest interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(dest)
+}
+
+// GenerateVerifier returns a random PKCE code verifier, also suitable as a
+// state or nonce value.
+func GenerateVerifier() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// S256Challenge returns the S256 code challenge of a verifier.
+func S256Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
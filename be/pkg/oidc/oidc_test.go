@@ -0,0 +1,161 @@
+package oidc_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/oidc"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/oidc/oidctest"
+)
+
+func TestS256Challenge(t *testing.T) {
+	// Example from RFC 7636 appendix B
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", oidc.S256Challenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+
+	verifier, err := oidc.GenerateVerifier()
+	require.NoError(t, err)
+	assert.Len(t, verifier, 43)
+}
+
+type flow struct {
+	provider *oidctest.Provider
+	client   *oidc.Client
+	cfg      oidc.Config
+	metadata *oidc.ProviderMetadata
+}
+
+func newFlow(t *testing.T) *flow {
+	t.Helper()
+	provider, err := oidctest.NewProvider("goedu", "s3cret&key")
+	require.NoError(t, err)
+	t.Cleanup(provider.Close)
+	provider.SetUser(map[string]interface{}{
+		"sub":            "user-1",
+		"email":          "auditor@example-bank.com",
+		"email_verified": true,
+		"name":           "Ada Auditor",
+		"groups":         []string{"GRC-Auditors", "All-Staff"},
+	})
+
+	client := oidc.NewClient(&http.Client{Timeout: 5 * time.Second}, time.Hour, time.Minute)
+	metadata, err := client.Discover(context.Background(), provider.Issuer()+"/")
+	require.NoError(t, err)
+	return &flow{
+		provider: provider,
+		client:   client,
+		cfg: oidc.Config{
+			Issuer:       provider.Issuer(),
+			ClientID:     "goedu",
+			ClientSecret: "s3cret&key",
+			RedirectURL:  "https://app.example.com/api/v1/auth/sso/oidc/callback",
+		},
+		metadata: metadata,
+	}
+}
+
+// login signs in at the provider and returns the code from the redirect.
+func (f *flow) login(t *testing.T, state, nonce, verifier string) string {
+	t.Helper()
+	redirect, err := f.provider.Login(f.client.AuthCodeURL(f.cfg, f.metadata, state, nonce, verifier))
+	require.NoError(t, err)
+	assert.Equal(t, state, redirect.Query().Get("state"))
+	return redirect.Query().Get("code")
+}
+
+func TestClient_CodeFlow(t *testing.T) {
+	f := newFlow(t)
+	ctx := context.Background()
+
+	authURL, err := url.Parse(f.client.AuthCodeURL(f.cfg, f.metadata, "state-1", "nonce-1", "verifier-1"))
+	require.NoError(t, err)
+	assert.Equal(t, "openid email profile", authURL.Query().Get("scope"))
+	assert.Equal(t, oidc.S256Challenge("verifier-1"), authURL.Query().Get("code_challenge"))
+	assert.Empty(t, authURL.Query().Get("code_verifier"))
+
+	code := f.login(t, "state-1", "nonce-1", "verifier-1")
+	token, err := f.client.Exchange(ctx, f.cfg, f.metadata, code, "verifier-1")
+	require.NoError(t, err)
+
+	idToken, err := f.client.VerifyIDToken(ctx, f.cfg, f.metadata, token.IDToken, "nonce-1")
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", idToken.Subject)
+	assert.Equal(t, "auditor@example-bank.com", idToken.Email)
+	assert.True(t, idToken.EmailVerified)
+	assert.Equal(t, []string{"GRC-Auditors", "All-Staff"}, idToken.StringsClaim("groups"))
+	assert.Nil(t, idToken.StringsClaim("roles"))
+
+	// Codes are single use
+	_, err = f.client.Exchange(ctx, f.cfg, f.metadata, code, "verifier-1")
+	assert.ErrorIs(t, err, oidc.ErrExchange)
+}
+
+func TestClient_ExchangeRequiresVerifier(t *testing.T) {
+	f := newFlow(t)
+
+	code := f.login(t, "state-1", "nonce-1", "verifier-1")
+	_, err := f.client.Exchange(context.Background(), f.cfg, f.metadata, code, "another-verifier")
+	assert.ErrorIs(t, err, oidc.ErrExchange)
+	assert.Zero(t, f.provider.TokensIssued())
+}
+
+func TestClient_VerifyIDTokenRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(claims jwt.MapClaims)
+		nonce  string
+	}{
+		{"wrong nonce", nil, "other-nonce"},
+		{"missing nonce", func(claims jwt.MapClaims) { delete(claims, "nonce") }, "nonce-1"},
+		{"other audience", func(claims jwt.MapClaims) { claims["aud"] = "another-client" }, "nonce-1"},
+		{"other issuer", func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example.com" }, "nonce-1"},
+		{"expired", func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Hour).Unix() }, "nonce-1"},
+		{"issued in the future", func(claims jwt.MapClaims) { claims["iat"] = time.Now().Add(time.Hour).Unix() }, "nonce-1"},
+		{"foreign authorized party", func(claims jwt.MapClaims) {
+			claims["aud"] = []string{"goedu", "another-client"}
+			claims["azp"] = "another-client"
+		}, "nonce-1"},
+		{"missing subject", func(claims jwt.MapClaims) { delete(claims, "sub") }, "nonce-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFlow(t)
+			ctx := context.Background()
+			f.provider.Tamper(tt.tamper)
+
+			code := f.login(t, "state-1", "nonce-1", "verifier-1")
+			token, err := f.client.Exchange(ctx, f.cfg, f.metadata, code, "verifier-1")
+			require.NoError(t, err)
+			_, err = f.client.VerifyIDToken(ctx, f.cfg, f.metadata, token.IDToken, tt.nonce)
+			assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+		})
+	}
+}
+
+func TestClient_KeyRotation(t *testing.T) {
+	f := newFlow(t)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		code := f.login(t, "state-1", "nonce-1", "verifier-1")
+		token, err := f.client.Exchange(ctx, f.cfg, f.metadata, code, "verifier-1")
+		require.NoError(t, err)
+		_, err = f.client.VerifyIDToken(ctx, f.cfg, f.metadata, token.IDToken, "nonce-1")
+		require.NoError(t, err, "tokens signed with a new key are accepted after refetching the key set")
+		require.NoError(t, f.provider.RotateKey())
+	}
+}
+
+func TestClient_DiscoverRejectsIssuerMismatch(t *testing.T) {
+	f := newFlow(t)
+
+	_, err := f.client.Discover(context.Background(), f.provider.Issuer()+"/tenant")
+	assert.ErrorIs(t, err, oidc.ErrDiscovery)
+}
@@ -0,0 +1,279 @@
+// Package oidctest provides an in-process OpenID provider for tests. It serves
+// a discovery document, a key set, an authorization endpoint that signs in a
+// configurable user without prompting and a token endpoint that enforces
+// client authentication and PKCE, and issues RS256 signed ID tokens.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// grant is an issued authorization code.
+type grant struct {
+	claims      jwt.MapClaims
+	nonce       string
+	challenge   string
+	redirectURI string
+}
+
+// Provider is a fake OpenID provider listening on a loopback port.
+type Provider struct {
+	server       *httptest.Server
+	clientID     string
+	clientSecret string
+
+	mu     sync.Mutex
+	key    *rsa.PrivateKey
+	keyID  string
+	user   jwt.MapClaims
+	codes  map[string]*grant
+	tokens int
+	tamper func(claims jwt.MapClaims)
+}
+
+// NewProvider starts a provider that accepts a single client.
+//
+// Parameters:
+//   - clientID: The only client ID the provider accepts
+//   - clientSecret: The client's secret; empty for a public client
+//
+// Returns:
+//   - *Provider: Running provider; call Close when done
+//   - error: Error if no signing key can be generated
+//
+// Example:
+//
+//	provider, err := oidctest.NewProvider("goedu", "secret")
+//	require.NoError(t, err)
+//	defer provider.Close()
+//	provider.SetUser(map[string]interface{}{"sub": "u1", "email": "auditor@example-bank.com"})
+func NewProvider(clientID, clientSecret string) (*Provider, error) {
+	p := &Provider{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		codes:        make(map[string]*grant),
+	}
+	if err := p.RotateKey(); err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("GET /jwks", p.jwks)
+	mux.HandleFunc("GET /authorize", p.authorize)
+	mux.HandleFunc("POST /token", p.token)
+	p.server = httptest.NewServer(mux)
+	return p, nil
+}
+
+// Issuer returns the provider's issuer URL.
+func (p *Provider) Issuer() string {
+	return p.server.URL
+}
+
+// Close shuts the provider down.
+func (p *Provider) Close() {
+	p.server.Close()
+}
+
+// SetUser sets the claims of the user signed in by the next authorization
+// requests, such as sub, email and groups.
+func (p *Provider) SetUser(claims map[string]interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.user = jwt.MapClaims(claims)
+}
+
+// Tamper changes the claims of the ID tokens issued from now on after the
+// provider has filled them in, to produce invalid tokens. Pass nil to stop.
+func (p *Provider) Tamper(fn func(claims jwt.MapClaims)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tamper = fn
+}
+
+// RotateKey replaces the signing key with a new one under a new key ID.
+func (p *Provider) RotateKey() error {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.key = key
+	p.keyID = fmt.Sprintf("key-%d", time.Now().UnixNano())
+	return nil
+}
+
+// TokensIssued returns how many codes were exchanged successfully.
+func (p *Provider) TokensIssued() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.tokens
+}
+
+// Login follows an authorization URL the way a browser would and returns the
+// redirect back to the client, carrying code and state or an error.
+func (p *Provider) Login(authURL string) (*url.URL, error) {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		return nil, fmt.Errorf("authorization failed with status %d", resp.StatusCode)
+	}
+	return url.Parse(resp.Header.Get("Location"))
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                p.Issuer(),
+		"authorization_endpoint":                p.Issuer() + "/authorize",
+		"token_endpoint":                        p.Issuer() + "/token",
+		"jwks_uri":                              p.Issuer() + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (p *Provider) jwks(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	key, keyID := &p.key.PublicKey, p.keyID
+	p.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"keys": []map[string]string{{
+		"kty": "RSA",
+		"use": "sig",
+		"alg": "RS256",
+		"kid": keyID,
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+}
+
+func (p *Provider) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != p.clientID || query.Get("response_type") != "code" {
+		http.Error(w, "unknown client or response type", http.StatusBadRequest)
+		return
+	}
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		http.Error(w, "PKCE is required", http.StatusBadRequest)
+		return
+	}
+	redirect, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || !redirect.IsAbs() {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	p.mu.Lock()
+	user := p.user
+	code := rand.Text()
+	if user != nil {
+		p.codes[code] = &grant{
+			claims:      user,
+			nonce:       query.Get("nonce"),
+			challenge:   query.Get("code_challenge"),
+			redirectURI: redirect.String(),
+		}
+	}
+	p.mu.Unlock()
+
+	params := redirect.Query()
+	if user == nil {
+		params.Set("error", "access_denied")
+	} else {
+		params.Set("code", code)
+	}
+	params.Set("state", query.Get("state"))
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+	clientID, clientSecret, _ := r.BasicAuth()
+	clientID, _ = url.QueryUnescape(clientID)
+	clientSecret, _ = url.QueryUnescape(clientSecret)
+	if clientID == "" {
+		clientID = r.PostForm.Get("client_id")
+	}
+	if clientID != p.clientID || clientSecret != p.clientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	code := r.PostForm.Get("code")
+	issued, ok := p.codes[code]
+	delete(p.codes, code)
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	switch {
+	case !ok, issued.redirectURI != r.PostForm.Get("redirect_uri"):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	case base64.RawURLEncoding.EncodeToString(sum[:]) != issued.challenge:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{}
+	for name, value := range issued.claims {
+		claims[name] = value
+	}
+	claims["iss"] = p.Issuer()
+	claims["aud"] = p.clientID
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(5 * time.Minute).Unix()
+	if issued.nonce != "" {
+		claims["nonce"] = issued.nonce
+	}
+	if p.tamper != nil {
+		p.tamper(claims)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = p.keyID
+	idToken, err := token.SignedString(p.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	p.tokens++
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": rand.Text(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,400 @@
+// Package openapi loads OpenAPI 3.1 documents and validates HTTP requests and
+// responses against them.
+//
+// Only the parts of the specification needed to check JSON APIs are
+// interpreted: paths, operations, path and query parameters, JSON request
+// bodies and JSON responses, with schemas and references to
+// components/schemas, components/parameters, components/requestBodies and
+// components/responses. Everything else in the document is kept for clients
+// but not checked.
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidDocument is returned when a document cannot be loaded.
+var ErrInvalidDocument = errors.New("invalid OpenAPI document")
+
+// Document is a loaded OpenAPI document with all references resolved.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+
+	// basePath is the path of the first server URL, e.g. "/api/v1"
+	basePath string
+	// routes holds the path items by their path including the base path
+	routes map[string]*PathItem
+}
+
+// Server is an entry of the servers list.
+type Server struct {
+	URL string `json:"url"`
+}
+
+// PathItem holds the operations of a path template. Servers replace the
+// document's servers for the path's operations.
+type PathItem struct {
+	Servers    []Server     `json:"servers,omitempty"`
+	Parameters []*Parameter `json:"parameters,omitempty"`
+	Get        *Operation   `json:"get,omitempty"`
+	Put        *Operation   `json:"put,omitempty"`
+	Post       *Operation   `json:"post,omitempty"`
+	Delete     *Operation   `json:"delete,omitempty"`
+	Patch      *Operation   `json:"patch,omitempty"`
+	Head       *Operation   `json:"head,omitempty"`
+	Options    *Operation   `json:"options,omitempty"`
+
+	// basePath is the path of the item's first server URL, or the document's
+	basePath string
+}
+
+// Operations returns the operations of the path item by HTTP method.
+func (p *PathItem) Operations() map[string]*Operation {
+	operations := make(map[string]*Operation)
+	for method, op := range map[string]*Operation{
+		http.MethodGet: p.Get, http.MethodPut: p.Put, http.MethodPost: p.Post, http.MethodDelete: p.Delete,
+		http.MethodPatch: p.Patch, http.MethodHead: p.Head, http.MethodOptions: p.Options,
+	} {
+		if op != nil {
+			operations[method] = op
+		}
+	}
+	return operations
+}
+
+// Operation is a single API operation.
+type Operation struct {
+	OperationID string               `json:"operationId,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+
+	// parameters merges the path item and operation parameters
+	parameters []*Parameter
+}
+
+// Parameter is a path, query, header or cookie parameter.
+type Parameter struct {
+	Ref      string  `json:"$ref,omitempty"`
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema,omitempty"`
+}
+
+// RequestBody describes the body of a request.
+type RequestBody struct {
+	Ref      string                `json:"$ref,omitempty"`
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response describes a response.
+type Response struct {
+	Ref     string                `json:"$ref,omitempty"`
+	Content map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a content type.
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Components holds the reusable objects of a document.
+type Components struct {
+	Schemas       map[string]*Schema      `json:"schemas,omitempty"`
+	Parameters    map[string]*Parameter   `json:"parameters,omitempty"`
+	RequestBodies map[string]*RequestBody `json:"requestBodies,omitempty"`
+	Responses     map[string]*Response    `json:"responses,omitempty"`
+}
+
+// Load parses an OpenAPI 3.1 document and resolves its references.
+//
+// Parameters:
+//   - data: The document as JSON
+//
+// Returns:
+//   - *Document: The loaded document
+//   - error: ErrInvalidDocument with the reason
+//
+// Example:
+//
+//	doc, err := openapi.Load(specJSON)
+//	op := doc.Operation(http.MethodGet, "/webhooks/{id}")
+func Load(data []byte) (*Document, error) {
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.1") {
+		return nil, fmt.Errorf("%w: unsupported version %q, expected 3.1", ErrInvalidDocument, doc.OpenAPI)
+	}
+	basePath, err := serverPath(doc.Servers, "")
+	if err != nil {
+		return nil, err
+	}
+	doc.basePath = basePath
+
+	r := &resolver{doc: &doc, seen: make(map[*Schema]bool)}
+	for name := range doc.Components.Schemas {
+		r.schema(doc.Components.Schemas[name], "#/components/schemas/"+name)
+	}
+	doc.routes = make(map[string]*PathItem, len(doc.Paths))
+	for path, item := range doc.Paths {
+		if !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("%w: path %q must start with /", ErrInvalidDocument, path)
+		}
+		if item.basePath, err = serverPath(item.Servers, doc.basePath); err != nil {
+			return nil, err
+		}
+		doc.routes[item.basePath+path] = item
+		shared := r.parameters(item.Parameters, path)
+		for method, op := range item.Operations() {
+			where := method + " " + path
+			op.parameters = mergeParameters(shared, r.parameters(op.Parameters, where))
+			if op.RequestBody != nil {
+				op.RequestBody = r.requestBody(op.RequestBody, where)
+			}
+			if len(op.Responses) == 0 {
+				r.fail("%s: at least one response is required", where)
+			}
+			for status, response := range op.Responses {
+				op.Responses[status] = r.response(response, where+" response "+status)
+			}
+			r.checkPathParameters(path, op, where)
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &doc, nil
+}
+
+// serverPath returns the path of the first server URL, or fallback when
+// there are no servers.
+func serverPath(servers []Server, fallback string) (string, error) {
+	if len(servers) == 0 {
+		return fallback, nil
+	}
+	u, err := url.Parse(servers[0].URL)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid server URL: %v", ErrInvalidDocument, err)
+	}
+	return strings.TrimSuffix(u.Path, "/"), nil
+}
+
+// BasePath returns the path of the first server URL, which prefixes all paths
+// without servers of their own.
+func (d *Document) BasePath() string {
+	return d.basePath
+}
+
+// BasePathOf returns the base path of a path template: the path of the first
+// server URL of its path item, or the document's base path.
+func (d *Document) BasePathOf(path string) string {
+	if item, ok := d.Paths[path]; ok {
+		return item.basePath
+	}
+	return d.basePath
+}
+
+// Operation returns the operation of a method and path template, or nil.
+func (d *Document) Operation(method, path string) *Operation {
+	item, ok := d.Paths[path]
+	if !ok {
+		return nil
+	}
+	return item.Operations()[method]
+}
+
+// Route returns the operation of a method and a path template that includes
+// its base path, such as "/api/v1/webhooks/{id}", or nil.
+func (d *Document) Route(method, route string) *Operation {
+	item, ok := d.routes[route]
+	if !ok {
+		return nil
+	}
+	return item.Operations()[method]
+}
+
+// RoutePath converts a gin route such as "/webhooks/:id" or "/files/*path"
+// to an OpenAPI path template such as "/webhooks/{id}".
+func RoutePath(route string) string {
+	segments := strings.Split(route, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// resolver replaces references by the objects they point to and records the
+// first problem found.
+type resolver struct {
+	doc  *Document
+	seen map[*Schema]bool
+	err  error
+}
+
+func (r *resolver) fail(format string, args ...interface{}) {
+	if r.err == nil {
+		r.err = fmt.Errorf("%w: %s", ErrInvalidDocument, fmt.Sprintf(format, args...))
+	}
+}
+
+// target returns the component name of a local reference.
+func (r *resolver) target(ref, prefix, where string) string {
+	if !strings.HasPrefix(ref, prefix) {
+		r.fail("%s: unsupported reference %q", where, ref)
+		return ""
+	}
+	return strings.TrimPrefix(ref, prefix)
+}
+
+// schema resolves the references below a schema and returns the schema to
+// use in place of s.
+func (r *resolver) schema(s *Schema, where string) *Schema {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		name := r.target(s.Ref, "#/components/schemas/", where)
+		target, ok := r.doc.Components.Schemas[name]
+		if !ok {
+			r.fail("%s: unknown schema %q", where, s.Ref)
+			return s
+		}
+		return r.schema(target, s.Ref)
+	}
+	if r.seen[s] {
+		return s
+	}
+	r.seen[s] = true
+
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			r.fail("%s: invalid pattern: %v", where, err)
+		}
+		s.pattern = pattern
+	}
+	s.Items = r.schema(s.Items, where+"/items")
+	s.AdditionalProperties = r.schema(s.AdditionalProperties, where+"/additionalProperties")
+	for name, property := range s.Properties {
+		s.Properties[name] = r.schema(property, where+"/properties/"+name)
+	}
+	for _, list := range [][]*Schema{s.AllOf, s.AnyOf, s.OneOf} {
+		for i := range list {
+			list[i] = r.schema(list[i], where)
+		}
+	}
+	return s
+}
+
+func (r *resolver) parameters(params []*Parameter, where string) []*Parameter {
+	resolved := make([]*Parameter, len(params))
+	for i, param := range params {
+		if param.Ref != "" {
+			target, ok := r.doc.Components.Parameters[r.target(param.Ref, "#/components/parameters/", where)]
+			if !ok {
+				r.fail("%s: unknown parameter %q", where, param.Ref)
+				resolved[i] = param
+				continue
+			}
+			param = target
+		}
+		switch param.In {
+		case "path", "query", "header", "cookie":
+		default:
+			r.fail("%s: parameter %q has invalid location %q", where, param.Name, param.In)
+		}
+		param.Schema = r.schema(param.Schema, where+" parameter "+param.Name)
+		resolved[i] = param
+	}
+	return resolved
+}
+
+func (r *resolver) requestBody(body *RequestBody, where string) *RequestBody {
+	if body.Ref != "" {
+		target, ok := r.doc.Components.RequestBodies[r.target(body.Ref, "#/components/requestBodies/", where)]
+		if !ok {
+			r.fail("%s: unknown request body %q", where, body.Ref)
+			return body
+		}
+		body = target
+	}
+	for mediaType, content := range body.Content {
+		content.Schema = r.schema(content.Schema, where+" request "+mediaType)
+	}
+	return body
+}
+
+func (r *resolver) response(response *Response, where string) *Response {
+	if response.Ref != "" {
+		target, ok := r.doc.Components.Responses[r.target(response.Ref, "#/components/responses/", where)]
+		if !ok {
+			r.fail("%s: unknown response %q", where, response.Ref)
+			return response
+		}
+		response = target
+	}
+	for mediaType, content := range response.Content {
+		content.Schema = r.schema(content.Schema, where+" "+mediaType)
+	}
+	return response
+}
+
+// checkPathParameters requires every template variable of a path to be
+// declared as a required path parameter, and the other way round.
+func (r *resolver) checkPathParameters(path string, op *Operation, where string) {
+	declared := make(map[string]bool)
+	for _, param := range op.parameters {
+		if param.In == "path" {
+			if !param.Required {
+				r.fail("%s: path parameter %q must be required", where, param.Name)
+			}
+			declared[param.Name] = true
+		}
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			name := segment[1 : len(segment)-1]
+			if !declared[name] {
+				r.fail("%s: path parameter %q is not declared", where, name)
+			}
+			delete(declared, name)
+		}
+	}
+	for name := range declared {
+		r.fail("%s: path parameter %q is not in the path", where, name)
+	}
+}
+
+// mergeParameters combines path item and operation parameters; operation
+// parameters override path item parameters with the same name and location.
+func mergeParameters(shared, own []*Parameter) []*Parameter {
+	merged := append([]*Parameter(nil), own...)
+	for _, param := range shared {
+		overridden := false
+		for _, o := range own {
+			if o.Name == param.Name && o.In == param.In {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			merged = append(merged, param)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,248 @@
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const petstore = `{
+  "openapi": "3.1.0",
+  "info": {"title": "Pets", "version": "1"},
+  "servers": [{"url": "https://api.example.com/v1/"}],
+  "paths": {
+    "/pets": {
+      "get": {
+        "parameters": [
+          {"name": "limit", "in": "query", "schema": {"type": "integer", "minimum": 1, "maximum": 100}},
+          {"name": "tag", "in": "query", "schema": {"type": "array", "items": {"type": "string", "enum": ["cat", "dog"]}}},
+          {"name": "vaccinated", "in": "query", "schema": {"type": "boolean"}}
+        ],
+        "responses": {
+          "200": {"description": "Pets", "content": {"application/json": {"schema": {
+            "type": "object", "required": ["pets"],
+            "properties": {"pets": {"type": "array", "items": {"$ref": "#/components/schemas/Pet"}}}
+          }}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      },
+      "post": {
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/NewPet"}}}},
+        "responses": {
+          "201": {"description": "Created", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}},
+          "4XX": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/health": {
+      "servers": [{"url": "https://api.example.com"}],
+      "get": {"responses": {"204": {"description": "Healthy"}}}
+    },
+    "/pets/{id}": {
+      "parameters": [{"$ref": "#/components/parameters/ID"}],
+      "delete": {"responses": {"204": {"description": "Deleted"}}},
+      "get": {"responses": {"200": {"description": "Photo", "content": {"image/png": {}}}}}
+    }
+  },
+  "components": {
+    "parameters": {
+      "ID": {"name": "id", "in": "path", "required": true, "schema": {"type": "string", "pattern": "^[0-9]+$"}}
+    },
+    "responses": {
+      "Error": {"description": "Error", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+    },
+    "schemas": {
+      "Error": {"type": "object", "required": ["error", "code"], "properties": {"error": {"type": "string"}, "code": {"type": "string"}}},
+      "NewPet": {
+        "type": "object", "required": ["name"], "additionalProperties": false,
+        "properties": {
+          "name": {"type": "string", "minLength": 1, "maxLength": 20},
+          "owner": {"type": "string", "format": "email"},
+          "born": {"type": ["string", "null"], "format": "date-time"},
+          "weight": {"type": "number", "minimum": 0},
+          "tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2, "uniqueItems": true},
+          "parent": {"oneOf": [{"type": "null"}, {"$ref": "#/components/schemas/NewPet"}]}
+        }
+      },
+      "Named": {"type": "object", "required": ["name"], "properties": {"name": {"type": "string"}}},
+      "Pet": {"allOf": [{"$ref": "#/components/schemas/Named"}], "required": ["id"], "properties": {"id": {"type": "integer"}}}
+    }
+  }
+}`
+
+func loadPetstore(t *testing.T) *Document {
+	t.Helper()
+	doc, err := Load([]byte(petstore))
+	require.NoError(t, err)
+	return doc
+}
+
+func TestLoad(t *testing.T) {
+	doc := loadPetstore(t)
+
+	assert.Equal(t, "/v1", doc.BasePath())
+	assert.NotNil(t, doc.Operation(http.MethodGet, "/pets"))
+	assert.NotNil(t, doc.Operation(http.MethodDelete, "/pets/{id}"))
+	assert.Nil(t, doc.Operation(http.MethodPut, "/pets"))
+	assert.Nil(t, doc.Operation(http.MethodGet, "/owners"))
+	assert.Equal(t, "/v1", doc.BasePathOf("/pets"))
+	assert.Equal(t, "", doc.BasePathOf("/health"))
+	assert.NotNil(t, doc.Route(http.MethodDelete, "/v1/pets/{id}"))
+	assert.NotNil(t, doc.Route(http.MethodGet, "/health"))
+	assert.Nil(t, doc.Route(http.MethodGet, "/v1/health"))
+	assert.Nil(t, doc.Route(http.MethodGet, "/pets"))
+	assert.Equal(t, "/pets/{id}/photos/{path}", RoutePath("/pets/:id/photos/*path"))
+}
+
+func TestLoad_InvalidDocuments(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		message string
+	}{
+		{"not JSON", `{`, "unexpected end of JSON input"},
+		{"version", `{"openapi": "3.0.3", "paths": {}}`, `unsupported version "3.0.3"`},
+		{"unknown schema", `{"openapi": "3.1.0", "paths": {"/a": {"get": {"responses": {"200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Missing"}}}}}}}}}`, `unknown schema "#/components/schemas/Missing"`},
+		{"external reference", `{"openapi": "3.1.0", "paths": {"/a": {"get": {"responses": {"200": {"$ref": "other.json#/a"}}}}}}`, `unsupported reference "other.json#/a"`},
+		{"undeclared path parameter", `{"openapi": "3.1.0", "paths": {"/a/{id}": {"get": {"responses": {"200": {}}}}}}`, `path parameter "id" is not declared`},
+		{"optional path parameter", `{"openapi": "3.1.0", "paths": {"/a/{id}": {"get": {"parameters": [{"name": "id", "in": "path"}], "responses": {"200": {}}}}}}`, `path parameter "id" must be required`},
+		{"no responses", `{"openapi": "3.1.0", "paths": {"/a": {"get": {}}}}`, "at least one response is required"},
+		{"invalid pattern", `{"openapi": "3.1.0", "paths": {}, "components": {"schemas": {"A": {"type": "string", "pattern": "("}}}}`, "invalid pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load([]byte(tt.doc))
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, ErrInvalidDocument))
+			assert.Contains(t, err.Error(), tt.message)
+		})
+	}
+}
+
+func TestOperation_ValidateRequest(t *testing.T) {
+	doc := loadPetstore(t)
+	list := doc.Operation(http.MethodGet, "/pets")
+	create := doc.Operation(http.MethodPost, "/pets")
+	remove := doc.Operation(http.MethodDelete, "/pets/{id}")
+
+	tests := []struct {
+		name    string
+		op      *Operation
+		target  string
+		params  map[string]string
+		body    string
+		message string
+	}{
+		{"valid query", list, "/pets?limit=10&tag=cat&tag=dog&vaccinated=true", nil, "", ""},
+		{"comma separated array", list, "/pets?tag=cat,dog", nil, "", ""},
+		{"undeclared query parameters are ignored", list, "/pets?sort=name", nil, "", ""},
+		{"non-integer query", list, "/pets?limit=ten", nil, "", `query parameter "limit" must be an integer`},
+		{"query below minimum", list, "/pets?limit=0", nil, "", `query parameter "limit" must be at least 1`},
+		{"query not in enum", list, "/pets?tag=bird", nil, "", `query parameter "tag" must be one of "cat", "dog"`},
+		{"invalid boolean", list, "/pets?vaccinated=yes", nil, "", `query parameter "vaccinated" must be true or false`},
+		{"valid path", remove, "/pets/12", map[string]string{"id": "12"}, "", ""},
+		{"invalid path", remove, "/pets/x", map[string]string{"id": "x"}, "", `path parameter "id" must match the pattern ^[0-9]+$`},
+		{"missing path", remove, "/pets/", nil, "", `path parameter "id" is required`},
+		{"valid body", create, "/pets", nil, `{"name": "Rex", "owner": "ann@example.com", "born": null, "weight": 4.5, "tags": ["a"], "parent": {"name": "Max"}}`, ""},
+		{"missing body", create, "/pets", nil, " ", "body is required"},
+		{"malformed body", create, "/pets", nil, `{"name":`, "body is not valid JSON"},
+		{"trailing data", create, "/pets", nil, `{"name": "Rex"} {}`, "body must contain a single JSON value"},
+		{"missing field", create, "/pets", nil, `{}`, "body field /name is required"},
+		{"unknown field", create, "/pets", nil, `{"name": "Rex", "colour": "brown"}`, "body field /colour is not a known field"},
+		{"wrong type", create, "/pets", nil, `{"name": 5}`, "body field /name must be string, got integer"},
+		{"too long", create, "/pets", nil, `{"name": "abcdefghijklmnopqrstuvwxyz"}`, "body field /name must be at most 20 characters long"},
+		{"invalid email", create, "/pets", nil, `{"name": "Rex", "owner": "ann"}`, "body field /owner must be an email address"},
+		{"invalid date-time", create, "/pets", nil, `{"name": "Rex", "born": "yesterday"}`, "body field /born must be an RFC 3339 date-time"},
+		{"negative number", create, "/pets", nil, `{"name": "Rex", "weight": -1}`, "body field /weight must be at least 0"},
+		{"too many items", create, "/pets", nil, `{"name": "Rex", "tags": ["a", "b", "c"]}`, "body field /tags must have at most 2 items"},
+		{"duplicate items", create, "/pets", nil, `{"name": "Rex", "tags": ["a", "a"]}`, "body field /tags must not contain duplicate items"},
+		{"nested error", create, "/pets", nil, `{"name": "Rex", "parent": {"name": ""}}`, "body field /parent/name must be at least 1 characters long"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.op.ValidateRequest(httptest.NewRequest(http.MethodGet, tt.target, nil), tt.params, []byte(tt.body))
+			if tt.message == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, tt.message, err.Error())
+		})
+	}
+}
+
+func TestOperation_ValidateResponse(t *testing.T) {
+	doc := loadPetstore(t)
+	list := doc.Operation(http.MethodGet, "/pets")
+	create := doc.Operation(http.MethodPost, "/pets")
+	remove := doc.Operation(http.MethodDelete, "/pets/{id}")
+	photo := doc.Operation(http.MethodGet, "/pets/{id}")
+	jsonHeader := http.Header{"Content-Type": {"application/json; charset=utf-8"}}
+
+	tests := []struct {
+		name    string
+		op      *Operation
+		status  int
+		header  http.Header
+		body    string
+		message string
+	}{
+		{"valid list", list, 200, jsonHeader, `{"pets": [{"id": 1, "name": "Rex"}]}`, ""},
+		{"invalid item", list, 200, jsonHeader, `{"pets": [{"name": "Rex"}]}`, "response field /pets/0/id is required"},
+		{"default response", list, 500, jsonHeader, `{"error": "Internal server error", "code": "INTERNAL_ERROR"}`, ""},
+		{"invalid error envelope", list, 500, jsonHeader, `{"message": "boom"}`, "response field /error is required"},
+		{"invalid allOf part", list, 200, jsonHeader, `{"pets": [{"id": 1}]}`, "response field /pets/0/name is required"},
+		{"status range", create, 409, jsonHeader, `{"error": "Conflict", "code": "CONFLICT"}`, ""},
+		{"undocumented status", create, 500, jsonHeader, `{}`, "response status 500 is not documented"},
+		{"undocumented content type", create, 201, http.Header{"Content-Type": {"text/plain"}}, `Rex`, `response content type "text/plain" is not documented for status 201`},
+		{"empty response", remove, 204, http.Header{}, ``, ""},
+		{"unexpected body", remove, 204, jsonHeader, `{}`, "response for status 204 must be empty"},
+		{"binary response", photo, 200, http.Header{"Content-Type": {"image/png"}}, "\x89PNG", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.op.ValidateResponse(tt.status, tt.header, []byte(tt.body))
+			if tt.message == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, tt.message, err.Error())
+		})
+	}
+
+	assert.True(t, list.JSONResponses())
+	assert.True(t, remove.JSONResponses())
+	assert.False(t, photo.JSONResponses())
+}
+
+func TestSchema_Validate(t *testing.T) {
+	var schema Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {"kind": {"enum": ["a", "b"]}, "extra": false},
+		"additionalProperties": {"type": "integer"},
+		"anyOf": [{"required": ["kind"]}, {"required": ["count"]}]
+	}`), &schema))
+
+	assert.NoError(t, schema.Validate(map[string]interface{}{"kind": "a", "count": 3}))
+	assert.NoError(t, schema.Validate(map[string]interface{}{"count": json.Number("3")}))
+
+	err := schema.Validate(map[string]interface{}{"kind": "a", "count": 1.5})
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, "/count", validationErr.Pointer)
+	assert.Equal(t, "must be integer, got number", validationErr.Message)
+
+	assert.EqualError(t, schema.Validate(map[string]interface{}{"kind": "a", "extra": 1}), " field /extra is not allowed")
+	assert.EqualError(t, schema.Validate(map[string]interface{}{}), " field /count is required")
+	assert.EqualError(t, schema.Validate([]interface{}{}), " must be object, got array")
+}
@@ -0,0 +1,407 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Schema is a JSON Schema as used by OpenAPI 3.1. The keywords needed to
+// describe JSON request and response payloads are supported; others are
+// accepted and ignored.
+type Schema struct {
+	Ref         string `json:"$ref,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	// Type is a single type name or a list, e.g. ["string", "null"]
+	Type   Types         `json:"type,omitempty"`
+	Enum   []interface{} `json:"enum,omitempty"`
+	Format string        `json:"format,omitempty"`
+
+	// Strings
+	MinLength *int   `json:"minLength,omitempty"`
+	MaxLength *int   `json:"maxLength,omitempty"`
+	Pattern   string `json:"pattern,omitempty"`
+
+	// Numbers
+	Minimum *float64 `json:"minimum,omitempty"`
+	Maximum *float64 `json:"maximum,omitempty"`
+
+	// Arrays
+	Items       *Schema `json:"items,omitempty"`
+	MinItems    *int    `json:"minItems,omitempty"`
+	MaxItems    *int    `json:"maxItems,omitempty"`
+	UniqueItems bool    `json:"uniqueItems,omitempty"`
+
+	// Objects. AdditionalProperties is nil when any property is allowed,
+	// and a schema with NotAllowed set when none is.
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+
+	// Composition
+	AllOf []*Schema `json:"allOf,omitempty"`
+	AnyOf []*Schema `json:"anyOf,omitempty"`
+	OneOf []*Schema `json:"oneOf,omitempty"`
+
+	// NotAllowed is set for the false schema, which matches nothing
+	NotAllowed bool `json:"-"`
+
+	pattern *regexp.Regexp
+}
+
+// UnmarshalJSON accepts the boolean schemas true and false as well as objects.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	switch strings.TrimSpace(string(data)) {
+	case "true":
+		*s = Schema{}
+		return nil
+	case "false":
+		*s = Schema{NotAllowed: true}
+		return nil
+	}
+	type plain Schema
+	return json.Unmarshal(data, (*plain)(s))
+}
+
+// Types is the type keyword of a schema.
+type Types []string
+
+// UnmarshalJSON accepts a single type name or a list of names.
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = Types{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("type must be a string or an array of strings")
+	}
+	*t = list
+	return nil
+}
+
+// has reports whether the type list allows a type name.
+func (t Types) has(name string) bool {
+	for _, typ := range t {
+		if typ == name || (name == "integer" && typ == "number") {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidationError describes the first part of a value that does not conform
+// to its schema.
+type ValidationError struct {
+	// In is where the value came from: "body", "response", "query" or "path"
+	In string
+
+	// Pointer is the JSON pointer of the value within the body, or the
+	// parameter name
+	Pointer string
+
+	Message string
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	switch {
+	case e.In == "query" || e.In == "path":
+		return fmt.Sprintf("%s parameter %q %s", e.In, e.Pointer, e.Message)
+	case e.Pointer == "":
+		return fmt.Sprintf("%s %s", e.In, e.Message)
+	default:
+		return fmt.Sprintf("%s field %s %s", e.In, e.Pointer, e.Message)
+	}
+}
+
+// Validate checks a decoded JSON value against the schema. Numbers may be
+// float64, json.Number or Go integers.
+//
+// Parameters:
+//   - v: Value decoded from JSON
+//
+// Returns:
+//   - error: *ValidationError for the first non-conforming value, or nil
+//
+// Example:
+//
+//	var body interface{}
+//	_ = json.Unmarshal(data, &body)
+//	if err := schema.Validate(body); err != nil {
+//	    // err.(*openapi.ValidationError).Pointer is e.g. "/events/0"
+//	}
+func (s *Schema) Validate(v interface{}) error {
+	if msg, pointer := s.validate(v, ""); msg != "" {
+		return &ValidationError{Pointer: pointer, Message: msg}
+	}
+	return nil
+}
+
+// validate returns a message and the pointer of the first violation, or an
+// empty message.
+func (s *Schema) validate(v interface{}, pointer string) (string, string) {
+	if s == nil {
+		return "", ""
+	}
+	if s.NotAllowed {
+		return "is not allowed", pointer
+	}
+
+	if len(s.Type) > 0 {
+		if typ := jsonType(v); !s.Type.has(typ) {
+			return fmt.Sprintf("must be %s, got %s", strings.Join(s.Type, " or "), typ), pointer
+		}
+	}
+	if len(s.Enum) > 0 && !inEnum(v, s.Enum) {
+		return "must be one of " + formatEnum(s.Enum), pointer
+	}
+
+	switch value := v.(type) {
+	case string:
+		if msg := s.validateString(value); msg != "" {
+			return msg, pointer
+		}
+	case []interface{}:
+		if msg, at := s.validateArray(value, pointer); msg != "" {
+			return msg, at
+		}
+	case map[string]interface{}:
+		if msg, at := s.validateObject(value, pointer); msg != "" {
+			return msg, at
+		}
+	default:
+		if n, ok := number(v); ok {
+			if msg := s.validateNumber(n); msg != "" {
+				return msg, pointer
+			}
+		}
+	}
+
+	for _, sub := range s.AllOf {
+		if msg, at := sub.validate(v, pointer); msg != "" {
+			return msg, at
+		}
+	}
+	if len(s.AnyOf) > 0 {
+		if matches, msg, at := matchAlternatives(s.AnyOf, v, pointer); matches == 0 {
+			return msg, at
+		}
+	}
+	if len(s.OneOf) > 0 {
+		matches, msg, at := matchAlternatives(s.OneOf, v, pointer)
+		switch {
+		case matches == 0:
+			return msg, at
+		case matches > 1:
+			return "matches more than one of the allowed schemas", pointer
+		}
+	}
+	return "", ""
+}
+
+// matchAlternatives counts the schemas a value matches. When it matches none,
+// the violation found deepest in the value is returned, as it most likely
+// comes from the alternative the value was meant to match.
+func matchAlternatives(alternatives []*Schema, v interface{}, pointer string) (int, string, string) {
+	matches := 0
+	var best, bestAt string
+	for _, sub := range alternatives {
+		msg, at := sub.validate(v, pointer)
+		if msg == "" {
+			matches++
+		} else if best == "" || len(at) > len(bestAt) {
+			best, bestAt = msg, at
+		}
+	}
+	return matches, best, bestAt
+}
+
+func (s *Schema) validateString(value string) string {
+	length := utf8.RuneCountInString(value)
+	if s.MinLength != nil && length < *s.MinLength {
+		return fmt.Sprintf("must be at least %d characters long", *s.MinLength)
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		return fmt.Sprintf("must be at most %d characters long", *s.MaxLength)
+	}
+	if s.pattern != nil && !s.pattern.MatchString(value) {
+		return fmt.Sprintf("must match the pattern %s", s.Pattern)
+	}
+	if msg := checkFormat(s.Format, value); msg != "" {
+		return msg
+	}
+	return ""
+}
+
+func (s *Schema) validateNumber(n float64) string {
+	if s.Type.has("integer") && !s.Type.has("number") && n != math.Trunc(n) {
+		return "must be an integer"
+	}
+	if s.Minimum != nil && n < *s.Minimum {
+		return "must be at least " + strconv.FormatFloat(*s.Minimum, 'f', -1, 64)
+	}
+	if s.Maximum != nil && n > *s.Maximum {
+		return "must be at most " + strconv.FormatFloat(*s.Maximum, 'f', -1, 64)
+	}
+	return ""
+}
+
+func (s *Schema) validateArray(items []interface{}, pointer string) (string, string) {
+	if s.MinItems != nil && len(items) < *s.MinItems {
+		return fmt.Sprintf("must have at least %d items", *s.MinItems), pointer
+	}
+	if s.MaxItems != nil && len(items) > *s.MaxItems {
+		return fmt.Sprintf("must have at most %d items", *s.MaxItems), pointer
+	}
+	if s.UniqueItems {
+		for i := range items {
+			for j := 0; j < i; j++ {
+				if equal(items[i], items[j]) {
+					return "must not contain duplicate items", pointer
+				}
+			}
+		}
+	}
+	for i, item := range items {
+		if msg, at := s.Items.validate(item, pointer+"/"+strconv.Itoa(i)); msg != "" {
+			return msg, at
+		}
+	}
+	return "", ""
+}
+
+func (s *Schema) validateObject(object map[string]interface{}, pointer string) (string, string) {
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			return "is required", pointer + "/" + escapePointer(name)
+		}
+	}
+
+	// Check properties in a stable order so the reported error is deterministic
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		at := pointer + "/" + escapePointer(name)
+		property, declared := s.Properties[name]
+		if !declared {
+			if s.AdditionalProperties == nil {
+				continue
+			}
+			if s.AdditionalProperties.NotAllowed {
+				return "is not a known field", at
+			}
+			property = s.AdditionalProperties
+		}
+		if msg, at := property.validate(object[name], at); msg != "" {
+			return msg, at
+		}
+	}
+	return "", ""
+}
+
+// checkFormat validates the formats used by the API; other formats are
+// annotations only.
+func checkFormat(format, value string) string {
+	switch format {
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return "must be an RFC 3339 date-time"
+		}
+	case "email":
+		if addr, err := mail.ParseAddress(value); err != nil || addr.Address != value {
+			return "must be an email address"
+		}
+	case "uri":
+		if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+			return "must be an absolute URI"
+		}
+	}
+	return ""
+}
+
+// jsonType returns the JSON Schema type name of a decoded value.
+func jsonType(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		if n, ok := number(value); ok {
+			if n == math.Trunc(n) {
+				return "integer"
+			}
+			return "number"
+		}
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// number converts the numeric types produced by JSON decoding to float64.
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func inEnum(v interface{}, enum []interface{}) bool {
+	for _, allowed := range enum {
+		if equal(v, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// equal compares decoded JSON values, treating all number types alike.
+func equal(a, b interface{}) bool {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func formatEnum(enum []interface{}) string {
+	parts := make([]string, len(enum))
+	for i, value := range enum {
+		raw, _ := json.Marshal(value)
+		parts[i] = string(raw)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// escapePointer escapes a property name for use in a JSON pointer.
+func escapePointer(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,197 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ValidateRequest checks the parameters and JSON body of a request against
+// the operation. Undeclared query parameters and headers are ignored; a body
+// is only checked when the operation declares a JSON request body.
+//
+// Parameters:
+//   - r: The request; its body is not read
+//   - pathParams: Values of the path template variables
+//   - body: The request body
+//
+// Returns:
+//   - error: *ValidationError for the first problem found, or nil
+func (op *Operation) ValidateRequest(r *http.Request, pathParams map[string]string, body []byte) error {
+	query := r.URL.Query()
+	for _, param := range op.parameters {
+		var values []string
+		switch param.In {
+		case "path":
+			if value, ok := pathParams[param.Name]; ok {
+				values = []string{value}
+			}
+		case "query":
+			values = query[param.Name]
+		case "header":
+			values = r.Header.Values(param.Name)
+		default:
+			continue
+		}
+		if len(values) == 0 {
+			if param.Required {
+				return &ValidationError{In: param.In, Pointer: param.Name, Message: "is required"}
+			}
+			continue
+		}
+		value, msg := parseParameter(param.Schema, values)
+		if msg == "" {
+			msg, _ = param.Schema.validate(value, "")
+		}
+		if msg != "" {
+			return &ValidationError{In: param.In, Pointer: param.Name, Message: msg}
+		}
+	}
+
+	if op.RequestBody == nil {
+		return nil
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		if op.RequestBody.Required {
+			return &ValidationError{In: "body", Message: "is required"}
+		}
+		return nil
+	}
+	content, ok := op.RequestBody.Content["application/json"]
+	if !ok {
+		return nil
+	}
+	return validateJSON(content.Schema, body, "body")
+}
+
+// ValidateResponse checks the status, content type and JSON body of a
+// response against the operation. A status without its own entry is matched
+// by its range, e.g. "4XX", and then by "default".
+//
+// Parameters:
+//   - status: The response status code
+//   - header: The response headers
+//   - body: The response body
+//
+// Returns:
+//   - error: *ValidationError for the first problem found, or nil
+func (op *Operation) ValidateResponse(status int, header http.Header, body []byte) error {
+	response := op.response(status)
+	if response == nil {
+		return &ValidationError{In: "response", Message: fmt.Sprintf("status %d is not documented", status)}
+	}
+	if len(response.Content) == 0 || len(body) == 0 {
+		if len(response.Content) == 0 && len(body) > 0 {
+			return &ValidationError{In: "response", Message: fmt.Sprintf("for status %d must be empty", status)}
+		}
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return &ValidationError{In: "response", Message: "has no valid Content-Type"}
+	}
+	content, ok := response.Content[mediaType]
+	if !ok {
+		return &ValidationError{In: "response", Message: fmt.Sprintf("content type %q is not documented for status %d", mediaType, status)}
+	}
+	if !isJSON(mediaType) {
+		return nil
+	}
+	return validateJSON(content.Schema, body, "response")
+}
+
+// JSONResponses reports whether every documented response of the operation
+// is JSON or empty. Such responses can be buffered and checked before they
+// are sent; streams and file downloads cannot.
+func (op *Operation) JSONResponses() bool {
+	for _, response := range op.Responses {
+		for mediaType := range response.Content {
+			if !isJSON(mediaType) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (op *Operation) response(status int) *Response {
+	for _, key := range []string{strconv.Itoa(status), strconv.Itoa(status/100) + "XX", "default"} {
+		if response, ok := op.Responses[key]; ok {
+			return response
+		}
+	}
+	return nil
+}
+
+// validateJSON decodes a JSON document and validates it against a schema.
+func validateJSON(schema *Schema, data []byte, in string) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return &ValidationError{In: in, Message: "is not valid JSON"}
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return &ValidationError{In: in, Message: "must contain a single JSON value"}
+	}
+	if msg, pointer := schema.validate(value, ""); msg != "" {
+		return &ValidationError{In: in, Pointer: pointer, Message: msg}
+	}
+	return nil
+}
+
+// parseParameter converts the raw values of a parameter to the JSON type its
+// schema expects. Array parameters take every value; others the first.
+func parseParameter(schema *Schema, values []string) (interface{}, string) {
+	if schema == nil {
+		return values[0], ""
+	}
+	if schema.Type.has("array") {
+		items := make([]interface{}, 0, len(values))
+		for _, raw := range values {
+			for _, value := range strings.Split(raw, ",") {
+				item, msg := parseParameter(schema.Items, []string{value})
+				if msg != "" {
+					return nil, msg
+				}
+				items = append(items, item)
+			}
+		}
+		return items, ""
+	}
+
+	raw := values[0]
+	switch {
+	case schema.Type.has("string") || len(schema.Type) == 0:
+		return raw, ""
+	case schema.Type.has("boolean"):
+		value, err := strconv.ParseBool(raw)
+		if err != nil || (raw != "true" && raw != "false") {
+			return nil, "must be true or false"
+		}
+		return value, ""
+	case schema.Type.has("integer") && !schema.Type.has("number"):
+		if _, err := strconv.ParseInt(raw, 10, 64); err != nil {
+			return nil, "must be an integer"
+		}
+		return json.Number(raw), ""
+	case schema.Type.has("number"):
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return nil, "must be a number"
+		}
+		return json.Number(raw), ""
+	}
+	return raw, ""
+}
+
+// isJSON reports whether a media type is JSON, e.g. application/json or
+// application/problem+json.
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
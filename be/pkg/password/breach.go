@@ -0,0 +1,192 @@
+package password
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// hashPrefixLength is the length of the hash prefix sent to a RangeSource
+	hashPrefixLength = 5
+
+	// hashLength is the length of a hex encoded SHA-1 hash
+	hashLength = 2 * sha1.Size
+)
+
+// ErrInvalidHashList is returned when a hash list contains a malformed line.
+var ErrInvalidHashList = errors.New("invalid breached password hash list")
+
+// RangeSource returns the hashes of breached passwords sharing a prefix.
+type RangeSource interface {
+	// Range returns the upper case hex suffixes of the breached password
+	// hashes that start with the five character upper case hex prefix
+	Range(ctx context.Context, prefix string) ([]string, error)
+}
+
+// BreachList tells whether a password is known from a data breach.
+type BreachList struct {
+	source RangeSource
+}
+
+// NewBreachList creates a breach list that looks hashes up in a range source.
+//
+// Parameters:
+//   - source: Source of breached password hashes
+//
+// Returns:
+//   - *BreachList: Configured breach list
+func NewBreachList(source RangeSource) *BreachList {
+	return &BreachList{source: source}
+}
+
+// OpenBreachList opens a locally downloaded list of breached password hashes.
+// A directory holds one range file per prefix, named like "21BD1.txt", with
+// the "SUFFIX:COUNT" lines the Pwned Passwords range API returns; it is read
+// one range at a time, so it can hold the full list. A file holds full SHA-1
+// hashes, optionally followed by ":COUNT", and is loaded into memory.
+//
+// Parameters:
+//   - path: Range directory or hash list file
+//
+// Returns:
+//   - *BreachList: Breach list reading the path
+//   - error: Open or parse error
+func OpenBreachList(path string) (*BreachList, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password list: %w", err)
+	}
+	if info.IsDir() {
+		return NewBreachList(RangeDirectory(path)), nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password list: %w", err)
+	}
+	defer file.Close()
+	set, err := ReadHashSet(file)
+	if err != nil {
+		return nil, err
+	}
+	return NewBreachList(set), nil
+}
+
+// Contains reports whether a password's hash is in the list. Only the first
+// five characters of the hash are passed to the range source.
+//
+// Parameters:
+//   - ctx: Context for the range lookup
+//   - password: Password to look up
+//
+// Returns:
+//   - bool: Whether the password is breached
+//   - error: Range source error
+func (b *BreachList) Contains(ctx context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	suffixes, err := b.source.Range(ctx, hash[:hashPrefixLength])
+	if err != nil {
+		return false, err
+	}
+	for _, suffix := range suffixes {
+		if suffix == hash[hashPrefixLength:] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// HashSet is an in-memory RangeSource.
+type HashSet struct {
+	ranges map[string][]string
+}
+
+// ReadHashSet reads full SHA-1 hashes, one per line and optionally followed
+// by ":COUNT". Blank lines are skipped.
+//
+// Parameters:
+//   - r: Source of the hash list
+//
+// Returns:
+//   - *HashSet: The hashes grouped by prefix
+//   - error: ErrInvalidHashList for a malformed line, or read error
+func ReadHashSet(r io.Reader) (*HashSet, error) {
+	set := &HashSet{ranges: make(map[string][]string)}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		hash, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if hash == "" {
+			continue
+		}
+		hash = strings.ToUpper(hash)
+		if !isHex(hash, hashLength) {
+			return nil, fmt.Errorf("%w: line %d", ErrInvalidHashList, line)
+		}
+		prefix := hash[:hashPrefixLength]
+		set.ranges[prefix] = append(set.ranges[prefix], hash[hashPrefixLength:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read breached password list: %w", err)
+	}
+	return set, nil
+}
+
+// Range returns the suffixes of the hashes starting with prefix.
+func (s *HashSet) Range(ctx context.Context, prefix string) ([]string, error) {
+	return s.ranges[prefix], nil
+}
+
+// RangeDirectory is a RangeSource reading range files from a directory.
+// Missing range files are treated as empty ranges.
+type RangeDirectory string
+
+// Range reads the range file of prefix.
+func (d RangeDirectory) Range(ctx context.Context, prefix string) ([]string, error) {
+	if !isHex(prefix, hashPrefixLength) {
+		return nil, fmt.Errorf("invalid hash prefix %q", prefix)
+	}
+	file, err := os.Open(filepath.Join(string(d), prefix+".txt"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open range file: %w", err)
+	}
+	defer file.Close()
+
+	var suffixes []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		suffix, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if suffix != "" {
+			suffixes = append(suffixes, strings.ToUpper(suffix))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read range file: %w", err)
+	}
+	return suffixes, nil
+}
+
+// isHex reports whether s is an upper case hex string of the given length.
+func isHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'A' || c > 'F') {
+			return false
+		}
+	}
+	return true
+}
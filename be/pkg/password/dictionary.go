@@ -0,0 +1,124 @@
+package password
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// commonPasswords is the built-in list of common passwords and the base
+// words they are built from, one per line
+//
+//go:embed common_passwords.txt
+var commonPasswords string
+
+// leetReplacer undoes the character substitutions common in passwords
+var leetReplacer = strings.NewReplacer(
+	"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "8", "b",
+	"@", "a", "$", "s", "!", "i", "|", "l", "+", "t",
+)
+
+// Dictionary is a set of passwords that must not be used. Passwords match
+// case-insensitively, after undoing common character substitutions and
+// after dropping digits and symbols added before or after a dictionary word,
+// so "P@ssw0rd2024!" matches "password".
+type Dictionary struct {
+	words map[string]struct{}
+}
+
+// DefaultDictionary returns the built-in dictionary of common passwords.
+//
+// Returns:
+//   - *Dictionary: The built-in dictionary
+func DefaultDictionary() *Dictionary {
+	dictionary, _ := ReadDictionary(strings.NewReader(commonPasswords))
+	return dictionary
+}
+
+// ReadDictionary reads a dictionary with one password per line. Blank lines
+// and lines starting with # are skipped.
+//
+// Parameters:
+//   - r: Source of the word list
+//
+// Returns:
+//   - *Dictionary: The dictionary
+//   - error: Read error
+func ReadDictionary(r io.Reader) (*Dictionary, error) {
+	dictionary := &Dictionary{words: make(map[string]struct{})}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		dictionary.Add(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dictionary: %w", err)
+	}
+	return dictionary, nil
+}
+
+// LoadDictionary returns the built-in dictionary extended with the words of
+// a word list file.
+//
+// Parameters:
+//   - path: Word list file with one password per line
+//
+// Returns:
+//   - *Dictionary: The combined dictionary
+//   - error: Open or read error
+func LoadDictionary(path string) (*Dictionary, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dictionary: %w", err)
+	}
+	defer file.Close()
+
+	extra, err := ReadDictionary(file)
+	if err != nil {
+		return nil, err
+	}
+	dictionary := DefaultDictionary()
+	for word := range extra.words {
+		dictionary.words[word] = struct{}{}
+	}
+	return dictionary, nil
+}
+
+// Add adds a password to the dictionary.
+func (d *Dictionary) Add(word string) {
+	word = strings.TrimSpace(word)
+	if word == "" || strings.HasPrefix(word, "#") {
+		return
+	}
+	d.words[strings.ToLower(word)] = struct{}{}
+	d.words[normalize(word)] = struct{}{}
+}
+
+// Contains reports whether a password is in the dictionary. A nil
+// dictionary contains nothing.
+func (d *Dictionary) Contains(password string) bool {
+	if d == nil {
+		return false
+	}
+	lower := strings.ToLower(password)
+	for _, candidate := range []string{lower, normalize(lower), normalize(stripDecoration(lower))} {
+		if _, ok := d.words[candidate]; ok && candidate != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize lowercases a password and undoes common character substitutions.
+func normalize(password string) string {
+	return leetReplacer.Replace(strings.ToLower(password))
+}
+
+// stripDecoration drops everything but letters from both ends of a
+// password, e.g. the year and punctuation of "summer2024!".
+func stripDecoration(password string) string {
+	return strings.TrimFunc(password, func(r rune) bool { return !unicode.IsLetter(r) })
+}
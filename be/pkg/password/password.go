@@ -0,0 +1,165 @@
+// Package password checks candidate passwords against a password policy:
+// length and character-class rules, a dictionary of common passwords and
+// words tied to the account, and a list of passwords known from data
+// breaches.
+//
+// Breach lookups follow the k-anonymity range model of Pwned Passwords: a
+// password's SHA-1 hash is split into a five character prefix, which is all a
+// RangeSource is asked for, and a suffix that is compared locally against the
+// suffixes the source returns. The sources in this package read locally
+// downloaded hash lists, so no password or hash leaves the process.
+package password
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Violation names a rule a password breaks.
+type Violation string
+
+// Violations reported by Checker.Check
+const (
+	ViolationTooShort         Violation = "too_short"
+	ViolationTooLong          Violation = "too_long"
+	ViolationMissingUppercase Violation = "missing_uppercase"
+	ViolationMissingLowercase Violation = "missing_lowercase"
+	ViolationMissingDigit     Violation = "missing_digit"
+	ViolationMissingSymbol    Violation = "missing_symbol"
+	ViolationCommon           Violation = "common"
+	ViolationPersonal         Violation = "personal"
+	ViolationBreached         Violation = "breached"
+)
+
+// minPersonalWordLength is the shortest account word a password may not contain
+const minPersonalWordLength = 4
+
+// Rules are the requirements a password is checked against. Lengths count
+// characters, not bytes; a zero MaxLength means no upper limit.
+type Rules struct {
+	MinLength int
+	MaxLength int
+
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+
+	// RejectCommon rejects dictionary passwords and passwords containing
+	// one of the personal words passed to Check
+	RejectCommon bool
+
+	// RejectBreached rejects passwords found in the breach list
+	RejectBreached bool
+}
+
+// Checker checks passwords against Rules using a dictionary and a breach list.
+type Checker struct {
+	dictionary *Dictionary
+	breaches   *BreachList
+}
+
+// NewChecker creates a checker. Without a dictionary RejectCommon only checks
+// personal words; without a breach list RejectBreached is ignored.
+//
+// Parameters:
+//   - dictionary: Common passwords, or nil
+//   - breaches: Hashes of breached passwords, or nil
+//
+// Returns:
+//   - *Checker: Configured checker
+func NewChecker(dictionary *Dictionary, breaches *BreachList) *Checker {
+	return &Checker{dictionary: dictionary, breaches: breaches}
+}
+
+// Check returns the rules a password breaks, in the order the rules are
+// listed above. The breach list is only consulted for passwords that pass
+// every other rule.
+//
+// Parameters:
+//   - ctx: Context for the breach lookup
+//   - password: Candidate password
+//   - rules: Requirements to check
+//   - personal: Words tied to the account, such as its name and email
+//
+// Returns:
+//   - []Violation: Broken rules; empty when the password is acceptable
+//   - error: Breach list lookup error
+func (c *Checker) Check(ctx context.Context, password string, rules Rules, personal ...string) ([]Violation, error) {
+	var violations []Violation
+	length := utf8.RuneCountInString(password)
+	if length < rules.MinLength {
+		violations = append(violations, ViolationTooShort)
+	}
+	if rules.MaxLength > 0 && length > rules.MaxLength {
+		violations = append(violations, ViolationTooLong)
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	for _, class := range []struct {
+		required, present bool
+		violation         Violation
+	}{
+		{rules.RequireUppercase, upper, ViolationMissingUppercase},
+		{rules.RequireLowercase, lower, ViolationMissingLowercase},
+		{rules.RequireDigit, digit, ViolationMissingDigit},
+		{rules.RequireSymbol, symbol, ViolationMissingSymbol},
+	} {
+		if class.required && !class.present {
+			violations = append(violations, class.violation)
+		}
+	}
+
+	if rules.RejectCommon {
+		if c.dictionary.Contains(password) {
+			violations = append(violations, ViolationCommon)
+		}
+		if containsPersonalWord(password, personal) {
+			violations = append(violations, ViolationPersonal)
+		}
+	}
+
+	if rules.RejectBreached && len(violations) == 0 && c.breaches != nil {
+		breached, err := c.breaches.Contains(ctx, password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check breach list: %w", err)
+		}
+		if breached {
+			violations = append(violations, ViolationBreached)
+		}
+	}
+	return violations, nil
+}
+
+// containsPersonalWord reports whether a password contains one of the
+// personal words, or a part of one split at punctuation such as the local
+// part of an email address.
+func containsPersonalWord(password string, personal []string) bool {
+	lower := strings.ToLower(password)
+	normalized := normalize(lower)
+	for _, value := range personal {
+		for _, word := range strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			if utf8.RuneCountInString(word) >= minPersonalWordLength && (strings.Contains(lower, word) || strings.Contains(normalized, word)) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package password
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sha1("correct horse battery staple")
+const breachedHash = "ABF7AAD6438836DBE526AA231ABDE2D0EEF74D42"
+
+// recordingSource records the prefixes it is asked for.
+type recordingSource struct {
+	RangeSource
+	prefixes []string
+}
+
+func (s *recordingSource) Range(ctx context.Context, prefix string) ([]string, error) {
+	s.prefixes = append(s.prefixes, prefix)
+	return s.RangeSource.Range(ctx, prefix)
+}
+
+func TestChecker_Rules(t *testing.T) {
+	checker := NewChecker(DefaultDictionary(), nil)
+	rules := Rules{MinLength: 12, MaxLength: 20, RequireUppercase: true, RequireLowercase: true, RequireDigit: true, RequireSymbol: true, RejectCommon: true}
+
+	tests := []struct {
+		password string
+		expected []Violation
+	}{
+		{"Vivid-Lantern-42", nil},
+		{"Short-1a", []Violation{ViolationTooShort}},
+		{"a much too long passphrase", []Violation{ViolationTooLong, ViolationMissingUppercase, ViolationMissingDigit, ViolationMissingSymbol}},
+		{"vivid lantern forty", []Violation{ViolationMissingUppercase, ViolationMissingDigit, ViolationMissingSymbol}},
+		{"P@ssw0rd2024!", []Violation{ViolationCommon}},
+		{"Summer2024!!", []Violation{ViolationCommon}},
+		{"Ada.Lovelace#1", []Violation{ViolationPersonal}},
+		{"L0velace-Rules", []Violation{ViolationPersonal}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			violations, err := checker.Check(context.Background(), tt.password, rules, "ada.lovelace@first-bank.com", "Ada", "Lovelace")
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, violations)
+		})
+	}
+
+	violations, err := checker.Check(context.Background(), "Summer2024!!", Rules{MinLength: 12})
+	require.NoError(t, err)
+	assert.Empty(t, violations, "dictionary is only consulted with RejectCommon")
+}
+
+func TestBreachList_KAnonymity(t *testing.T) {
+	set, err := ReadHashSet(strings.NewReader(strings.ToLower(breachedHash) + ":3645804\n\n28A3A91021E8FA93FAA7F4ED3F7CCC354E66307B\n"))
+	require.NoError(t, err)
+	source := &recordingSource{RangeSource: set}
+	checker := NewChecker(nil, NewBreachList(source))
+	rules := Rules{MinLength: 12, RejectBreached: true}
+
+	violations, err := checker.Check(context.Background(), "correct horse battery staple", rules)
+	require.NoError(t, err)
+	assert.Equal(t, []Violation{ViolationBreached}, violations)
+
+	violations, err = checker.Check(context.Background(), "Tr0ub4dor&3xyz", rules)
+	require.NoError(t, err)
+	assert.Empty(t, violations, "same prefix, different suffix")
+
+	assert.Equal(t, []string{"ABF7A", "28A3A"}, source.prefixes, "only hash prefixes leave the checker")
+
+	violations, err = checker.Check(context.Background(), "short", rules)
+	require.NoError(t, err)
+	assert.Equal(t, []Violation{ViolationTooShort}, violations)
+	assert.Len(t, source.prefixes, 2, "passwords failing other rules are not looked up")
+
+	_, err = ReadHashSet(strings.NewReader("not-a-hash\n"))
+	assert.ErrorIs(t, err, ErrInvalidHashList)
+}
+
+func TestOpenBreachList(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, breachedHash[:5]+".txt"), []byte(breachedHash[5:]+":3645804\r\n0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n"), 0o600))
+	file := filepath.Join(t.TempDir(), "hashes.txt")
+	require.NoError(t, os.WriteFile(file, []byte(breachedHash+"\n"), 0o600))
+
+	for name, path := range map[string]string{"range directory": dir, "hash file": file} {
+		t.Run(name, func(t *testing.T) {
+			list, err := OpenBreachList(path)
+			require.NoError(t, err)
+
+			breached, err := list.Contains(context.Background(), "correct horse battery staple")
+			require.NoError(t, err)
+			assert.True(t, breached)
+
+			breached, err = list.Contains(context.Background(), "an unbreached passphrase")
+			require.NoError(t, err)
+			assert.False(t, breached)
+		})
+	}
+
+	_, err := OpenBreachList(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+	_, err = RangeDirectory(dir).Range(context.Background(), "../..")
+	assert.Error(t, err)
+}
+
+func TestLoadDictionary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# bank specific\nfirstbank\n"), 0o600))
+
+	dictionary, err := LoadDictionary(path)
+	require.NoError(t, err)
+	assert.True(t, dictionary.Contains("F1rstB@nk2025!"))
+	assert.True(t, dictionary.Contains("Password123"), "built-in words are kept")
+	assert.False(t, dictionary.Contains("# bank specific"))
+	assert.False(t, (*Dictionary)(nil).Contains("password"))
+}
@@ -0,0 +1,293 @@
+// Package pdf provides a minimal streaming writer for paginated plain text PDF
+// reports. Pages are written to the underlying writer as soon as they are full,
+// so reports of any length can be produced with constant memory.
+//
+// The writer uses the built-in Courier font with WinAnsi encoding; characters
+// outside printable ASCII are replaced with '?'. It is intended for compliance
+// reports such as audit log exports, not for general document layout.
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Page dimensions in points
+const (
+	A4Width  = 595.0
+	A4Height = 842.0
+)
+
+// courierCharWidth is the advance width of every Courier glyph per point of font size.
+const courierCharWidth = 0.6
+
+// ErrClosed is returned when writing to a closed writer.
+var ErrClosed = errors.New("pdf: writer is closed")
+
+// Reserved object numbers; page objects are numbered from firstPageObject.
+const (
+	catalogObject   = 1
+	pagesObject     = 2
+	fontObject      = 3
+	infoObject      = 4
+	firstPageObject = 5
+)
+
+// Options controls the page layout.
+type Options struct {
+	// Title is stored in the document info and printed in every page header
+	Title string
+	// Landscape swaps the A4 page dimensions
+	Landscape bool
+	// FontSize in points, defaults to 8
+	FontSize float64
+	// Margin in points, defaults to 36 (half an inch)
+	Margin float64
+}
+
+// Writer streams a paginated text document.
+type Writer struct {
+	out     *countingWriter
+	opts    Options
+	width   float64
+	height  float64
+	leading float64
+
+	offsets   map[int]int64
+	nextObj   int
+	pageObjs  []int
+	lines     []string
+	maxLines  int
+	maxChars  int
+	closed    bool
+	headerErr error
+}
+
+// NewWriter starts a new document and writes the PDF header.
+//
+// Parameters:
+//   - w: Destination of the document
+//   - opts: Layout options
+//
+// Returns:
+//   - *Writer: Writer ready to receive lines
+//
+// Example:
+//
+//	doc := pdf.NewWriter(file, pdf.Options{Title: "Audit Log", Landscape: true})
+//	doc.WriteLine("2024-05-01T10:00:00Z  control.updated  C-1")
+//	err := doc.Close()
+func NewWriter(w io.Writer, opts Options) *Writer {
+	if opts.FontSize <= 0 {
+		opts.FontSize = 8
+	}
+	if opts.Margin <= 0 {
+		opts.Margin = 36
+	}
+
+	width, height := A4Width, A4Height
+	if opts.Landscape {
+		width, height = height, width
+	}
+
+	leading := opts.FontSize * 1.25
+	// Two lines are reserved for the page header
+	usable := height - 2*opts.Margin
+	maxLines := int(usable/leading) - 2
+	if maxLines < 1 {
+		maxLines = 1
+	}
+
+	pw := &Writer{
+		out:      &countingWriter{w: w},
+		opts:     opts,
+		width:    width,
+		height:   height,
+		leading:  leading,
+		offsets:  make(map[int]int64),
+		nextObj:  firstPageObject,
+		maxLines: maxLines,
+		maxChars: int((width - 2*opts.Margin) / (opts.FontSize * courierCharWidth)),
+	}
+	// The binary comment marks the file as binary for transfer tools
+	_, pw.headerErr = io.WriteString(pw.out, "%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	return pw
+}
+
+// WriteLine appends a line of text, starting a new page when the current one is
+// full. Lines wider than the page are truncated.
+//
+// Parameters:
+//   - text: Line content
+//
+// Returns:
+//   - error: Write error of the underlying writer
+func (w *Writer) WriteLine(text string) error {
+	if w.closed {
+		return ErrClosed
+	}
+	if w.headerErr != nil {
+		return w.headerErr
+	}
+
+	w.lines = append(w.lines, text)
+	if len(w.lines) >= w.maxLines {
+		return w.flushPage()
+	}
+	return nil
+}
+
+// NewPage ends the current page. Empty pages are not emitted.
+func (w *Writer) NewPage() error {
+	if w.closed {
+		return ErrClosed
+	}
+	if len(w.lines) == 0 {
+		return nil
+	}
+	return w.flushPage()
+}
+
+// Close writes the remaining page, the page tree, the cross-reference table
+// and the trailer. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return ErrClosed
+	}
+	if w.headerErr != nil {
+		return w.headerErr
+	}
+	if len(w.lines) > 0 || len(w.pageObjs) == 0 {
+		if err := w.flushPage(); err != nil {
+			return err
+		}
+	}
+	w.closed = true
+
+	kids := make([]string, len(w.pageObjs))
+	for i, obj := range w.pageObjs {
+		kids[i] = fmt.Sprintf("%d 0 R", obj)
+	}
+
+	objects := []struct {
+		num  int
+		body string
+	}{
+		{catalogObject, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObject)},
+		{pagesObject, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))},
+		{fontObject, "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>"},
+		{infoObject, fmt.Sprintf("<< /Title (%s) /Producer (GoEdu) >>", escape(w.opts.Title))},
+	}
+	for _, obj := range objects {
+		if err := w.writeObject(obj.num, []byte(obj.body)); err != nil {
+			return err
+		}
+	}
+
+	size := w.nextObj
+	xref := w.out.n
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", size)
+	for num := 1; num < size; num++ {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", w.offsets[num])
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		size, catalogObject, infoObject, xref)
+	_, err := w.out.Write(buf.Bytes())
+	return err
+}
+
+// Pages returns the number of pages written so far.
+func (w *Writer) Pages() int {
+	return len(w.pageObjs)
+}
+
+// flushPage writes the buffered lines as a content stream and page object.
+func (w *Writer) flushPage() error {
+	pageNumber := len(w.pageObjs) + 1
+
+	var content bytes.Buffer
+	fmt.Fprintf(&content, "BT\n/F1 %.2f Tf\n%.2f TL\n%.2f %.2f Td\n",
+		w.opts.FontSize, w.leading, w.opts.Margin, w.height-w.opts.Margin-w.opts.FontSize)
+	fmt.Fprintf(&content, "(%s) Tj\nT*\nT*\n", escape(w.fit(fmt.Sprintf("%s - page %d", w.opts.Title, pageNumber))))
+	for _, line := range w.lines {
+		fmt.Fprintf(&content, "(%s) Tj\nT*\n", escape(w.fit(line)))
+	}
+	content.WriteString("ET\n")
+	w.lines = w.lines[:0]
+
+	contentObj := w.nextObj
+	pageObj := w.nextObj + 1
+	w.nextObj += 2
+
+	stream := fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String())
+	if err := w.writeObject(contentObj, []byte(stream)); err != nil {
+		return err
+	}
+	page := fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+		pagesObject, w.width, w.height, fontObject, contentObj)
+	if err := w.writeObject(pageObj, []byte(page)); err != nil {
+		return err
+	}
+
+	w.pageObjs = append(w.pageObjs, pageObj)
+	return nil
+}
+
+// writeObject writes an indirect object and records its offset.
+func (w *Writer) writeObject(num int, body []byte) error {
+	w.offsets[num] = w.out.n
+	if _, err := fmt.Fprintf(w.out, "%d 0 obj\n", num); err != nil {
+		return err
+	}
+	if _, err := w.out.Write(body); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w.out, "\nendobj\n")
+	return err
+}
+
+// fit truncates a line to the printable width.
+func (w *Writer) fit(line string) string {
+	if len(line) <= w.maxChars {
+		return line
+	}
+	if w.maxChars <= 3 {
+		return line[:w.maxChars]
+	}
+	return line[:w.maxChars-3] + "..."
+}
+
+// escape makes text safe inside a PDF literal string.
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\t':
+			b.WriteString("    ")
+		case r < 0x20 || r > 0x7e:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// countingWriter tracks the byte offset needed for the cross-reference table.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
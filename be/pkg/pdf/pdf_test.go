@@ -0,0 +1,54 @@
+package pdf_test
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/pdf"
+)
+
+func TestWriter_ProducesConsistentPaginatedDocument(t *testing.T) {
+	var buf bytes.Buffer
+	doc := pdf.NewWriter(&buf, pdf.Options{Title: "Audit (export)", Landscape: true})
+
+	for i := 0; i < 120; i++ {
+		require.NoError(t, doc.WriteLine(fmt.Sprintf("line %d with (parens) and \\ backslash", i)))
+	}
+	require.NoError(t, doc.Close())
+
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "%PDF-1.4\n"))
+	assert.True(t, strings.HasSuffix(out, "%%EOF\n"))
+	assert.Greater(t, doc.Pages(), 1)
+	assert.Contains(t, out, fmt.Sprintf("/Count %d", doc.Pages()))
+	assert.Contains(t, out, `(line 7 with \(parens\) and \\ backslash) Tj`)
+
+	// Every xref entry must point at the start of its object
+	xrefStart := strings.LastIndex(out, "startxref\n")
+	xrefOffset, err := strconv.Atoi(strings.Fields(out[xrefStart+len("startxref\n"):])[0])
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(out[xrefOffset:], "xref\n"))
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(out[xrefOffset:], -1)
+	require.NotEmpty(t, entries)
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(entry[1])
+		assert.True(t, strings.HasPrefix(out[offset:], fmt.Sprintf("%d 0 obj", i+1)), "object %d", i+1)
+	}
+}
+
+func TestWriter_EmptyDocumentHasOnePage(t *testing.T) {
+	var buf bytes.Buffer
+	doc := pdf.NewWriter(&buf, pdf.Options{Title: "Empty"})
+	require.NoError(t, doc.Close())
+
+	assert.Equal(t, 1, doc.Pages())
+	assert.ErrorIs(t, doc.WriteLine("late"), pdf.ErrClosed)
+}
@@ -0,0 +1,234 @@
+package saml_test
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/saml"
+)
+
+// The responses in testdata are laid out the way AD FS, Okta and Azure AD
+// write them. generate.sh signed them with libxml2's canonicalizer, so they
+// also check this package's canonicalization against another implementation.
+const (
+	conformanceRequestID = "_6c3a4f8b0e2d4c7a9b1f5e3d2c8a7b6f"
+	adfsIssuer           = "http://adfs.example-bank.com/adfs/services/trust"
+	oktaIssuer           = "http://www.okta.com/exk1a2b3c4d5e6f7g8h9"
+	azureADIssuer        = "https://sts.windows.net/2b4c6d8e-0f1a-4b3c-9d5e-7f8a9b0c1d2e/"
+)
+
+var conformanceNow = time.Date(2026, 10, 1, 9, 1, 0, 0, time.UTC)
+
+func readResponse(t *testing.T, name string) string {
+	t.Helper()
+	document, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	return string(document)
+}
+
+func conformanceIdentityProvider(t *testing.T, entityID string) *saml.IdentityProvider {
+	t.Helper()
+	certificate, err := os.ReadFile(filepath.Join("testdata", "idp.crt"))
+	require.NoError(t, err)
+	certificates, err := saml.ParseCertificates([]string{string(certificate)})
+	require.NoError(t, err)
+	return &saml.IdentityProvider{EntityID: entityID, Certificates: certificates}
+}
+
+func conformanceServiceProvider() *saml.ServiceProvider {
+	return &saml.ServiceProvider{EntityID: spEntityID, ACSURL: spACSURL, ClockSkew: time.Minute}
+}
+
+func parseConformanceResponse(t *testing.T, document, issuer, requestID string) (*saml.Assertion, error) {
+	t.Helper()
+	encoded := base64.StdEncoding.EncodeToString([]byte(document))
+	return conformanceServiceProvider().ParseResponse(encoded, conformanceIdentityProvider(t, issuer), requestID, conformanceNow)
+}
+
+func TestCanonicalize_MatchesLibXML2(t *testing.T) {
+	// Expected output from xmllint --exc-c14n
+	tests := []struct {
+		name, input, expected string
+	}{
+		{"processing instruction", `<root><?pi   data  x ?><child/></root>`, `<root><?pi data  x ?><child></child></root>`},
+		{"attribute whitespace", "<root attr=\"a&#9;b&#10;c&#13;d\te\r\nf\"/>", `<root attr="a&#x9;b&#xA;c&#xD;d e f"></root>`},
+		{"single quoted attribute", "<root a='x\ty\"z' b=\"1\"/>", `<root a="x y&quot;z" b="1"></root>`},
+		{"default namespace undeclared", `<root xmlns="urn:d"><child xmlns=""><x/></child></root>`, `<root xmlns="urn:d"><child xmlns=""><x></x></child></root>`},
+		{"redundant declaration", `<root xmlns:p="urn:p"><p:c xmlns:p="urn:p"><p:d/></p:c></root>`, `<root><p:c xmlns:p="urn:p"><p:d></p:d></p:c></root>`},
+		{"CDATA", `<root><![CDATA[<x> & y]]></root>`, `<root>&lt;x&gt; &amp; y</root>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			canonical, err := saml.Canonicalize([]byte(tt.input))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(canonical))
+		})
+	}
+}
+
+func TestServiceProvider_ParseResponseConformance(t *testing.T) {
+	tests := []struct {
+		name         string
+		file         string
+		issuer       string
+		nameIDFormat string
+		groups       string
+		expected     []string
+	}{
+		{"AD FS", "adfs.xml", adfsIssuer, saml.NameIDFormatEmail, "http://schemas.xmlsoap.org/claims/Group", []string{"GRC-Auditors", "All-Staff"}},
+		{"Okta", "okta.xml", oktaIssuer, saml.NameIDFormatUnspecified, "groups", []string{"GRC-Auditors", "All-Staff"}},
+		{"Azure AD", "azuread.xml", azureADIssuer, saml.NameIDFormatEmail, "http://schemas.microsoft.com/ws/2008/06/identity/claims/groups",
+			[]string{"5e6f7a8b-9c0d-4e1f-a2b3-c4d5e6f7a8b9", "0a1b2c3d-4e5f-4a7b-8c9d-0e1f2a3b4c5d"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertion, err := parseConformanceResponse(t, readResponse(t, tt.file), tt.issuer, conformanceRequestID)
+			require.NoError(t, err)
+			assert.Equal(t, "auditor@example-bank.com", assertion.NameID)
+			assert.Equal(t, tt.nameIDFormat, assertion.NameIDFormat)
+			assert.Equal(t, tt.issuer, assertion.Issuer)
+			assert.Equal(t, conformanceRequestID, assertion.InResponseTo)
+			assert.Equal(t, tt.expected, assertion.Attributes[tt.groups])
+			assert.NotEmpty(t, assertion.SessionIndex)
+		})
+	}
+}
+
+var (
+	dsSignature      = regexp.MustCompile(`<ds:Signature .*?</ds:Signature>`)
+	defaultSignature = regexp.MustCompile(`<Signature .*?</Signature>`)
+)
+
+// between returns the part of a document from the first occurrence of start
+// to the end of the first following occurrence of end.
+func between(document, start, end string) string {
+	from := strings.Index(document, start)
+	to := strings.Index(document[from:], end)
+	return document[from : from+to+len(end)]
+}
+
+// forge returns an unsigned copy of an assertion issued to the administrator.
+func forge(assertion string) string {
+	assertion = dsSignature.ReplaceAllString(assertion, "")
+	assertion = defaultSignature.ReplaceAllString(assertion, "")
+	return strings.ReplaceAll(assertion, "auditor@", "admin@")
+}
+
+func TestServiceProvider_ParseResponseSignatureWrapping(t *testing.T) {
+	adfs := readResponse(t, "adfs.xml")
+	adfsAssertion := between(adfs, "<Assertion ", "</Assertion>")
+	okta := readResponse(t, "okta.xml")
+	oktaAssertion := between(okta, "<saml2:Assertion ", "</saml2:Assertion>")
+	oktaResponseSignature := dsSignature.FindString(strings.Replace(okta, oktaAssertion, "", 1))
+	azure := readResponse(t, "azuread.xml")
+	azureAssertion := between(azure, "<Assertion ", "</Assertion>")
+
+	tests := []struct {
+		name     string
+		document string
+		issuer   string
+		expected error
+	}{
+		{
+			name: "signed assertion moved into a forged assertion",
+			document: strings.Replace(adfs, adfsAssertion,
+				strings.Replace(forge(adfsAssertion), "</Assertion>", "<Advice>"+adfsAssertion+"</Advice></Assertion>", 1), 1),
+			issuer:   adfsIssuer,
+			expected: saml.ErrSignature,
+		},
+		{
+			name: "signed assertion moved into the response extensions",
+			document: strings.Replace(adfs, adfsAssertion,
+				"<samlp:Extensions>"+adfsAssertion+"</samlp:Extensions>"+forge(adfsAssertion), 1),
+			issuer:   adfsIssuer,
+			expected: saml.ErrSignature,
+		},
+		{
+			name: "forged assertion carrying the original signature",
+			document: strings.Replace(adfs, adfsAssertion,
+				strings.ReplaceAll(adfsAssertion, "auditor@", "admin@")+"<samlp:Extensions>"+adfsAssertion+"</samlp:Extensions>", 1),
+			issuer:   adfsIssuer,
+			expected: saml.ErrSignature,
+		},
+		{
+			name:     "forged assertion with the signed assertion's ID before it",
+			document: strings.Replace(azure, azureAssertion, forge(azureAssertion)+azureAssertion, 1),
+			issuer:   azureADIssuer,
+			expected: saml.ErrInvalidResponse,
+		},
+		{
+			name:     "forged assertion with the signed assertion's ID after it",
+			document: strings.Replace(azure, azureAssertion, azureAssertion+forge(azureAssertion), 1),
+			issuer:   azureADIssuer,
+			expected: saml.ErrInvalidResponse,
+		},
+		{
+			name:     "forged assertion with a duplicate ID in a signed response",
+			document: strings.Replace(okta, oktaAssertion, oktaAssertion+forge(oktaAssertion), 1),
+			issuer:   oktaIssuer,
+			expected: saml.ErrSignature,
+		},
+		{
+			name:     "signed response with its assertion replaced",
+			document: strings.Replace(okta, oktaAssertion, forge(oktaAssertion), 1),
+			issuer:   oktaIssuer,
+			expected: saml.ErrSignature,
+		},
+		{
+			name: "response signature stripped and its destination changed",
+			document: strings.Replace(strings.Replace(okta, oktaResponseSignature, "", 1),
+				`Destination="`+spACSURL+`"`, `Destination="https://other.example.com/acs"`, 1),
+			issuer:   oktaIssuer,
+			expected: saml.ErrInvalidResponse,
+		},
+		{
+			name:     "unsigned response wrapping a forged assertion",
+			document: strings.Replace(strings.Replace(okta, oktaResponseSignature, "", 1), oktaAssertion, forge(oktaAssertion), 1),
+			issuer:   oktaIssuer,
+			expected: saml.ErrSignature,
+		},
+		{
+			name:     "processing instruction added to the signed subject",
+			document: strings.Replace(adfs, "auditor@example-bank.com</NameID>", "auditor@example-bank.com<?x y?></NameID>", 1),
+			issuer:   adfsIssuer,
+			expected: saml.ErrSignature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseConformanceResponse(t, tt.document, tt.issuer, conformanceRequestID)
+			assert.ErrorIs(t, err, tt.expected)
+		})
+	}
+}
+
+func TestServiceProvider_ParseResponseUnsignedResponseWrapper(t *testing.T) {
+	okta := readResponse(t, "okta.xml")
+	oktaAssertion := between(okta, "<saml2:Assertion ", "</saml2:Assertion>")
+	unsigned := dsSignature.ReplaceAllString(strings.Replace(okta, oktaAssertion, "", 1), "")
+	unsigned = strings.Replace(unsigned, "</saml2p:Status>", "</saml2p:Status>"+oktaAssertion, 1)
+
+	// Only the assertion is signed, as AD FS and Azure AD send it
+	assertion, err := parseConformanceResponse(t, unsigned, oktaIssuer, conformanceRequestID)
+	require.NoError(t, err)
+	assert.Equal(t, "auditor@example-bank.com", assertion.NameID)
+
+	// The unsigned response cannot redirect the signed assertion to another request
+	const otherRequestID = "_0d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a"
+	_, err = parseConformanceResponse(t, strings.Replace(unsigned, conformanceRequestID, otherRequestID, 1), oktaIssuer, otherRequestID)
+	assert.ErrorIs(t, err, saml.ErrInvalidResponse)
+
+	// A comment splitting the signed NameID neither breaks the signature nor truncates the NameID
+	commented := strings.Replace(unsigned, "auditor@example-bank.com</saml2:NameID>", "auditor@<!---->example-bank.com</saml2:NameID>", 1)
+	assertion, err = parseConformanceResponse(t, commented, oktaIssuer, conformanceRequestID)
+	require.NoError(t, err)
+	assert.Equal(t, "auditor@example-bank.com", assertion.NameID)
+}
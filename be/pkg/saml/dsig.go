@@ -0,0 +1,244 @@
+package saml
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// XML signature algorithm identifiers
+const (
+	dsigNamespace         = "http://www.w3.org/2000/09/xmldsig#"
+	AlgorithmExcC14N      = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	AlgorithmEnveloped    = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+	AlgorithmRSASHA256    = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	AlgorithmRSASHA512    = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha512"
+	AlgorithmDigestSHA256 = "http://www.w3.org/2001/04/xmlenc#sha256"
+	AlgorithmDigestSHA512 = "http://www.w3.org/2001/04/xmlenc#sha512"
+)
+
+// ErrSignature is returned when an element is unsigned or its signature does
+// not verify against the identity provider's certificates.
+var ErrSignature = errors.New("saml: invalid signature")
+
+// signatureHashes maps the supported signature algorithms to their hash.
+var signatureHashes = map[string]crypto.Hash{
+	AlgorithmRSASHA256: crypto.SHA256,
+	AlgorithmRSASHA512: crypto.SHA512,
+}
+
+// digestHashes maps the supported digest algorithms to their hash.
+var digestHashes = map[string]crypto.Hash{
+	AlgorithmDigestSHA256: crypto.SHA256,
+	AlgorithmDigestSHA512: crypto.SHA512,
+}
+
+// verifySignature verifies the enveloped signature of an element. The
+// signature's single reference must point at the element itself, so a valid
+// signature elsewhere in the document cannot vouch for it. Only the given
+// certificates are trusted; keys embedded in the signature are ignored.
+func verifySignature(el *element, certificates []*x509.Certificate) error {
+	signatures := el.childElements(dsigNamespace, "Signature")
+	if len(signatures) != 1 {
+		return fmt.Errorf("%w: expected one signature, found %d", ErrSignature, len(signatures))
+	}
+	signature := signatures[0]
+	signedInfo := signature.child(dsigNamespace, "SignedInfo")
+	if signedInfo == nil {
+		return fmt.Errorf("%w: missing SignedInfo", ErrSignature)
+	}
+
+	c14nMethod := signedInfo.child(dsigNamespace, "CanonicalizationMethod")
+	if c14nMethod == nil || c14nMethod.attr("Algorithm") != AlgorithmExcC14N {
+		return fmt.Errorf("%w: unsupported canonicalization", ErrSignature)
+	}
+	signatureHash, ok := signatureHashes[attrOf(signedInfo.child(dsigNamespace, "SignatureMethod"), "Algorithm")]
+	if !ok {
+		return fmt.Errorf("%w: unsupported signature algorithm", ErrSignature)
+	}
+
+	references := signedInfo.childElements(dsigNamespace, "Reference")
+	if len(references) != 1 {
+		return fmt.Errorf("%w: expected one reference", ErrSignature)
+	}
+	reference := references[0]
+	id := el.attr("ID")
+	if id == "" || reference.attr("URI") != "#"+id {
+		return fmt.Errorf("%w: reference does not point at the signed element", ErrSignature)
+	}
+
+	var enveloped, excC14N bool
+	var inclusive []string
+	if transforms := reference.child(dsigNamespace, "Transforms"); transforms != nil {
+		for _, transform := range transforms.childElements(dsigNamespace, "Transform") {
+			switch transform.attr("Algorithm") {
+			case AlgorithmEnveloped:
+				enveloped = true
+			case AlgorithmExcC14N:
+				excC14N = true
+				inclusive = inclusivePrefixes(transform)
+			default:
+				return fmt.Errorf("%w: unsupported transform", ErrSignature)
+			}
+		}
+	}
+	if !enveloped || !excC14N {
+		return fmt.Errorf("%w: signature must be enveloped and exclusively canonicalized", ErrSignature)
+	}
+
+	digestHash, ok := digestHashes[attrOf(reference.child(dsigNamespace, "DigestMethod"), "Algorithm")]
+	if !ok {
+		return fmt.Errorf("%w: unsupported digest algorithm", ErrSignature)
+	}
+	expectedDigest, err := decodeBase64(textOf(reference.child(dsigNamespace, "DigestValue")))
+	if err != nil {
+		return fmt.Errorf("%w: malformed digest", ErrSignature)
+	}
+	canonical, err := canonicalize(el, signature, inclusive)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSignature, err)
+	}
+	if subtle.ConstantTimeCompare(hashSum(digestHash, canonical), expectedDigest) != 1 {
+		return fmt.Errorf("%w: digest mismatch", ErrSignature)
+	}
+
+	signatureValue, err := decodeBase64(textOf(signature.child(dsigNamespace, "SignatureValue")))
+	if err != nil {
+		return fmt.Errorf("%w: malformed signature value", ErrSignature)
+	}
+	canonicalSignedInfo, err := canonicalize(signedInfo, nil, inclusivePrefixes(c14nMethod))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSignature, err)
+	}
+	hashed := hashSum(signatureHash, canonicalSignedInfo)
+	for _, certificate := range certificates {
+		key, ok := certificate.PublicKey.(*rsa.PublicKey)
+		if ok && rsa.VerifyPKCS1v15(key, signatureHash, hashed, signatureValue) == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: not signed by a trusted certificate", ErrSignature)
+}
+
+// inclusivePrefixes returns the InclusiveNamespaces PrefixList of a
+// canonicalization method or transform.
+func inclusivePrefixes(el *element) []string {
+	if namespaces := el.child(AlgorithmExcC14N, "InclusiveNamespaces"); namespaces != nil {
+		return strings.Fields(namespaces.attr("PrefixList"))
+	}
+	return nil
+}
+
+// hashSum hashes data with a SHA-2 hash.
+func hashSum(hash crypto.Hash, data []byte) []byte {
+	if hash == crypto.SHA512 {
+		sum := sha512.Sum512(data)
+		return sum[:]
+	}
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
+
+// decodeBase64 decodes base64 that may be wrapped over several lines.
+func decodeBase64(s string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+}
+
+// attrOf returns an attribute of a possibly missing element.
+func attrOf(el *element, name string) string {
+	if el == nil {
+		return ""
+	}
+	return el.attr(name)
+}
+
+// textOf returns the text of a possibly missing element.
+func textOf(el *element) string {
+	if el == nil {
+		return ""
+	}
+	return el.text()
+}
+
+// SignEnveloped signs the root element of a document with an enveloped
+// RSA-SHA256 signature and inserts it after the element's first child, where
+// SAML schemas expect it after the Issuer. The root element must carry an ID
+// attribute. It is used by identity provider test doubles.
+func SignEnveloped(document []byte, key *rsa.PrivateKey, certificate *x509.Certificate) ([]byte, error) {
+	root, err := parseXML(document)
+	if err != nil {
+		return nil, err
+	}
+	id := root.attr("ID")
+	if id == "" {
+		return nil, errors.New("saml: element to sign has no ID")
+	}
+	canonical, err := canonicalize(root, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(canonical)
+
+	signedInfo := `<ds:SignedInfo xmlns:ds="` + dsigNamespace + `">` +
+		`<ds:CanonicalizationMethod Algorithm="` + AlgorithmExcC14N + `"></ds:CanonicalizationMethod>` +
+		`<ds:SignatureMethod Algorithm="` + AlgorithmRSASHA256 + `"></ds:SignatureMethod>` +
+		`<ds:Reference URI="#` + escapeAttr(id) + `"><ds:Transforms>` +
+		`<ds:Transform Algorithm="` + AlgorithmEnveloped + `"></ds:Transform>` +
+		`<ds:Transform Algorithm="` + AlgorithmExcC14N + `"></ds:Transform>` +
+		`</ds:Transforms><ds:DigestMethod Algorithm="` + AlgorithmDigestSHA256 + `"></ds:DigestMethod>` +
+		`<ds:DigestValue>` + base64.StdEncoding.EncodeToString(digest[:]) + `</ds:DigestValue>` +
+		`</ds:Reference></ds:SignedInfo>`
+	canonicalSignedInfo, err := Canonicalize([]byte(signedInfo))
+	if err != nil {
+		return nil, err
+	}
+	hashed := sha256.Sum256(canonicalSignedInfo)
+	signatureValue, err := rsa.SignPKCS1v15(nil, key, crypto.SHA256, hashed[:])
+	if err != nil {
+		return nil, err
+	}
+
+	signature := `<ds:Signature xmlns:ds="` + dsigNamespace + `">` +
+		strings.Replace(signedInfo, ` xmlns:ds="`+dsigNamespace+`"`, "", 1) +
+		`<ds:SignatureValue>` + base64.StdEncoding.EncodeToString(signatureValue) + `</ds:SignatureValue>` +
+		`<ds:KeyInfo><ds:X509Data><ds:X509Certificate>` + base64.StdEncoding.EncodeToString(certificate.Raw) +
+		`</ds:X509Certificate></ds:X509Data></ds:KeyInfo></ds:Signature>`
+
+	// Insert after the end of the root's first child element
+	var first *element
+	for _, child := range root.children {
+		if el, ok := child.(*element); ok {
+			first = el
+			break
+		}
+	}
+	if first == nil {
+		return nil, errors.New("saml: element to sign has no children")
+	}
+	closing := "</" + qualifiedName(first) + ">"
+	at := bytes.Index(document, []byte(closing))
+	if at < 0 {
+		return nil, errors.New("saml: cannot place signature")
+	}
+	at += len(closing)
+	signed := make([]byte, 0, len(document)+len(signature))
+	signed = append(signed, document[:at]...)
+	signed = append(signed, signature...)
+	return append(signed, document[at:]...), nil
+}
+
+// qualifiedName returns the element's name as written.
+func qualifiedName(el *element) string {
+	if el.prefix == "" {
+		return el.name
+	}
+	return el.prefix + ":" + el.name
+}
@@ -0,0 +1,376 @@
+// Package saml implements the service provider side of SAML 2.0 Web Browser
+// SSO: SP metadata, signed AuthnRequests over the HTTP-Redirect binding and
+// validation of signed responses received over the HTTP-POST binding.
+//
+// Only what enterprise identity providers need is supported: RSA-SHA256 and
+// RSA-SHA512 enveloped signatures with exclusive canonicalization, and
+// unencrypted assertions.
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SAML namespaces, bindings and formats
+const (
+	NamespaceAssertion = "urn:oasis:names:tc:SAML:2.0:assertion"
+	NamespaceProtocol  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	NamespaceMetadata  = "urn:oasis:names:tc:SAML:2.0:metadata"
+
+	BindingHTTPPost     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	BindingHTTPRedirect = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+
+	NameIDFormatEmail       = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+	NameIDFormatUnspecified = "urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified"
+
+	StatusSuccess = "urn:oasis:names:tc:SAML:2.0:status:Success"
+
+	confirmationBearer = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+)
+
+// SAML errors
+var (
+	ErrInvalidResponse = errors.New("saml: invalid response")
+	ErrStatus          = errors.New("saml: identity provider did not authenticate the user")
+)
+
+// ServiceProvider is the platform acting as a SAML service provider for one
+// organization.
+type ServiceProvider struct {
+	EntityID    string
+	ACSURL      string
+	Key         *rsa.PrivateKey
+	Certificate *x509.Certificate
+
+	// ClockSkew is the tolerance applied to assertion validity windows
+	ClockSkew time.Duration
+}
+
+// IdentityProvider describes the identity provider an organization trusts.
+type IdentityProvider struct {
+	EntityID     string
+	SSOURL       string
+	Certificates []*x509.Certificate
+}
+
+// Assertion is the validated content of an identity provider's assertion.
+type Assertion struct {
+	ID           string
+	Issuer       string
+	NameID       string
+	NameIDFormat string
+	SessionIndex string
+	InResponseTo string
+	NotOnOrAfter time.Time
+
+	// Attributes holds attribute values by Name and, when given, FriendlyName
+	Attributes map[string][]string
+}
+
+// Attribute returns the first value of an attribute.
+func (a *Assertion) Attribute(name string) string {
+	if values := a.Attributes[name]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Metadata returns the service provider's metadata document, which
+// organizations register with their identity provider.
+func (sp *ServiceProvider) Metadata() []byte {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<md:EntityDescriptor xmlns:md="` + NamespaceMetadata + `" entityID="` + escapeAttr(sp.EntityID) + `">`)
+	b.WriteString(`<md:SPSSODescriptor AuthnRequestsSigned="true" WantAssertionsSigned="true" protocolSupportEnumeration="` + NamespaceProtocol + `">`)
+	if sp.Certificate != nil {
+		b.WriteString(`<md:KeyDescriptor use="signing"><ds:KeyInfo xmlns:ds="` + dsigNamespace + `"><ds:X509Data><ds:X509Certificate>`)
+		b.WriteString(base64.StdEncoding.EncodeToString(sp.Certificate.Raw))
+		b.WriteString(`</ds:X509Certificate></ds:X509Data></ds:KeyInfo></md:KeyDescriptor>`)
+	}
+	b.WriteString(`<md:NameIDFormat>` + NameIDFormatEmail + `</md:NameIDFormat>`)
+	b.WriteString(`<md:AssertionConsumerService Binding="` + BindingHTTPPost + `" Location="` + escapeAttr(sp.ACSURL) + `" index="0" isDefault="true"/>`)
+	b.WriteString(`</md:SPSSODescriptor></md:EntityDescriptor>`)
+	return []byte(b.String())
+}
+
+// AuthnRequestURL builds a signed AuthnRequest for the HTTP-Redirect binding.
+//
+// Parameters:
+//   - idp: Identity provider the user is sent to
+//   - relayState: Opaque value the identity provider posts back with the response
+//   - now: Issue instant of the request
+//
+// Returns:
+//   - string: URL to redirect the browser to
+//   - string: Request ID the response must answer
+//   - error: Signing error
+func (sp *ServiceProvider) AuthnRequestURL(idp *IdentityProvider, relayState string, now time.Time) (string, string, error) {
+	id, err := newID()
+	if err != nil {
+		return "", "", err
+	}
+	request := `<samlp:AuthnRequest xmlns:samlp="` + NamespaceProtocol + `" xmlns:saml="` + NamespaceAssertion + `"` +
+		` ID="` + id + `" Version="2.0" IssueInstant="` + now.UTC().Format(time.RFC3339) + `"` +
+		` Destination="` + escapeAttr(idp.SSOURL) + `" AssertionConsumerServiceURL="` + escapeAttr(sp.ACSURL) + `"` +
+		` ProtocolBinding="` + BindingHTTPPost + `">` +
+		`<saml:Issuer>` + escapeText(sp.EntityID) + `</saml:Issuer>` +
+		`<samlp:NameIDPolicy Format="` + NameIDFormatUnspecified + `" AllowCreate="true"/>` +
+		`</samlp:AuthnRequest>`
+
+	var deflated bytes.Buffer
+	writer, err := flate.NewWriter(&deflated, flate.BestCompression)
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := writer.Write([]byte(request)); err != nil {
+		return "", "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", "", err
+	}
+
+	// The signature covers the parameters in this order, URL-encoded
+	query := "SAMLRequest=" + url.QueryEscape(base64.StdEncoding.EncodeToString(deflated.Bytes()))
+	if relayState != "" {
+		query += "&RelayState=" + url.QueryEscape(relayState)
+	}
+	query += "&SigAlg=" + url.QueryEscape(AlgorithmRSASHA256)
+	hashed := sha256.Sum256([]byte(query))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, sp.Key, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", "", fmt.Errorf("saml: failed to sign request: %w", err)
+	}
+	query += "&Signature=" + url.QueryEscape(base64.StdEncoding.EncodeToString(signature))
+
+	separator := "?"
+	if strings.Contains(idp.SSOURL, "?") {
+		separator = "&"
+	}
+	return idp.SSOURL + separator + query, id, nil
+}
+
+// ParseResponse decodes and validates a response posted to the assertion
+// consumer service. The assertion must be signed by the identity provider,
+// either directly or through a signed response, be addressed to this service
+// provider and be within its validity window.
+//
+// Parameters:
+//   - encoded: Base64 SAMLResponse form value
+//   - idp: Identity provider the response must come from
+//   - requestID: ID of the AuthnRequest being answered, or empty for an
+//     IdP-initiated login, in which case the response must not answer a request
+//   - now: Current time
+//
+// Returns:
+//   - *Assertion: Validated assertion content
+//   - error: ErrStatus when the user was not authenticated, ErrSignature or
+//     ErrInvalidResponse when the response is not acceptable
+func (sp *ServiceProvider) ParseResponse(encoded string, idp *IdentityProvider, requestID string, now time.Time) (*Assertion, error) {
+	data, err := decodeBase64(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed encoding", ErrInvalidResponse)
+	}
+	response, err := parseXML(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	if !response.is(NamespaceProtocol, "Response") || response.attr("Version") != "2.0" {
+		return nil, fmt.Errorf("%w: not a SAML 2.0 response", ErrInvalidResponse)
+	}
+	if destination := response.attr("Destination"); destination != "" && destination != sp.ACSURL {
+		return nil, fmt.Errorf("%w: wrong destination", ErrInvalidResponse)
+	}
+	if response.attr("InResponseTo") != requestID {
+		return nil, fmt.Errorf("%w: response does not answer the expected request", ErrInvalidResponse)
+	}
+	if issuer := response.child(NamespaceAssertion, "Issuer"); issuer != nil && issuer.text() != idp.EntityID {
+		return nil, fmt.Errorf("%w: unexpected issuer", ErrInvalidResponse)
+	}
+
+	responseSigned := len(response.childElements(dsigNamespace, "Signature")) > 0
+	if responseSigned {
+		if err := verifySignature(response, idp.Certificates); err != nil {
+			return nil, err
+		}
+	}
+
+	status := attrOf(response.child(NamespaceProtocol, "Status").childOrNil(NamespaceProtocol, "StatusCode"), "Value")
+	if status != StatusSuccess {
+		return nil, fmt.Errorf("%w: status %s", ErrStatus, status)
+	}
+
+	if len(response.childElements(NamespaceAssertion, "EncryptedAssertion")) > 0 {
+		return nil, fmt.Errorf("%w: encrypted assertions are not supported", ErrInvalidResponse)
+	}
+	assertions := response.childElements(NamespaceAssertion, "Assertion")
+	if len(assertions) != 1 {
+		return nil, fmt.Errorf("%w: expected one assertion, found %d", ErrInvalidResponse, len(assertions))
+	}
+	assertion := assertions[0]
+	if len(assertion.childElements(dsigNamespace, "Signature")) > 0 {
+		if err := verifySignature(assertion, idp.Certificates); err != nil {
+			return nil, err
+		}
+	} else if !responseSigned {
+		return nil, fmt.Errorf("%w: assertion is not signed", ErrSignature)
+	}
+
+	return sp.validateAssertion(assertion, idp, requestID, now)
+}
+
+// validateAssertion checks the issuer, subject confirmation and conditions of
+// a signed assertion and extracts its content.
+func (sp *ServiceProvider) validateAssertion(el *element, idp *IdentityProvider, requestID string, now time.Time) (*Assertion, error) {
+	invalid := func(reason string) error { return fmt.Errorf("%w: %s", ErrInvalidResponse, reason) }
+
+	assertion := &Assertion{
+		ID:         el.attr("ID"),
+		Issuer:     textOf(el.child(NamespaceAssertion, "Issuer")),
+		Attributes: map[string][]string{},
+	}
+	if assertion.ID == "" || el.attr("Version") != "2.0" {
+		return nil, invalid("malformed assertion")
+	}
+	if assertion.Issuer != idp.EntityID {
+		return nil, invalid("unexpected assertion issuer")
+	}
+
+	subject := el.child(NamespaceAssertion, "Subject")
+	nameID := subject.childOrNil(NamespaceAssertion, "NameID")
+	assertion.NameID = textOf(nameID)
+	assertion.NameIDFormat = attrOf(nameID, "Format")
+	if assertion.NameID == "" {
+		return nil, invalid("assertion has no NameID")
+	}
+
+	// A bearer confirmation addressed to this service provider is required
+	confirmed := false
+	for _, confirmation := range subject.childElementsOrNil(NamespaceAssertion, "SubjectConfirmation") {
+		data := confirmation.child(NamespaceAssertion, "SubjectConfirmationData")
+		if confirmation.attr("Method") != confirmationBearer || data == nil {
+			continue
+		}
+		notOnOrAfter, err := parseTime(data.attr("NotOnOrAfter"))
+		if err != nil || !now.Before(notOnOrAfter.Add(sp.ClockSkew)) {
+			continue
+		}
+		if data.attr("Recipient") != sp.ACSURL || data.attr("InResponseTo") != requestID {
+			continue
+		}
+		confirmed = true
+		assertion.InResponseTo = requestID
+		assertion.NotOnOrAfter = notOnOrAfter
+		break
+	}
+	if !confirmed {
+		return nil, invalid("no valid bearer subject confirmation")
+	}
+
+	conditions := el.child(NamespaceAssertion, "Conditions")
+	if conditions == nil {
+		return nil, invalid("assertion has no conditions")
+	}
+	if notBefore := conditions.attr("NotBefore"); notBefore != "" {
+		t, err := parseTime(notBefore)
+		if err != nil || now.Add(sp.ClockSkew).Before(t) {
+			return nil, invalid("assertion is not yet valid")
+		}
+	}
+	if notOnOrAfter := conditions.attr("NotOnOrAfter"); notOnOrAfter != "" {
+		t, err := parseTime(notOnOrAfter)
+		if err != nil || !now.Before(t.Add(sp.ClockSkew)) {
+			return nil, invalid("assertion has expired")
+		}
+		if t.Before(assertion.NotOnOrAfter) {
+			assertion.NotOnOrAfter = t
+		}
+	}
+	restrictions := conditions.childElements(NamespaceAssertion, "AudienceRestriction")
+	if len(restrictions) == 0 {
+		return nil, invalid("assertion has no audience restriction")
+	}
+	for _, restriction := range restrictions {
+		audiences := make([]string, 0, 1)
+		for _, audience := range restriction.childElements(NamespaceAssertion, "Audience") {
+			audiences = append(audiences, audience.text())
+		}
+		if !slices.Contains(audiences, sp.EntityID) {
+			return nil, invalid("assertion is intended for another audience")
+		}
+	}
+
+	if statement := el.child(NamespaceAssertion, "AuthnStatement"); statement != nil {
+		assertion.SessionIndex = statement.attr("SessionIndex")
+	}
+	for _, statement := range el.childElements(NamespaceAssertion, "AttributeStatement") {
+		for _, attribute := range statement.childElements(NamespaceAssertion, "Attribute") {
+			var values []string
+			for _, value := range attribute.childElements(NamespaceAssertion, "AttributeValue") {
+				values = append(values, value.text())
+			}
+			for _, name := range []string{attribute.attr("Name"), attribute.attr("FriendlyName")} {
+				if name != "" {
+					assertion.Attributes[name] = append(assertion.Attributes[name], values...)
+				}
+			}
+		}
+	}
+	return assertion, nil
+}
+
+// parseTime parses an xs:dateTime value.
+func parseTime(value string) (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+// newID returns a random identifier that is a valid xs:ID.
+func newID() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "_" + hex.EncodeToString(b), nil
+}
+
+// ParseCertificates parses the PEM encoded certificates of an identity
+// provider.
+func ParseCertificates(pemData []string) ([]*x509.Certificate, error) {
+	var certificates []*x509.Certificate
+	for _, data := range pemData {
+		rest := []byte(data)
+		for {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			certificate, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("saml: invalid certificate: %w", err)
+			}
+			certificates = append(certificates, certificate)
+		}
+	}
+	if len(certificates) == 0 {
+		return nil, errors.New("saml: no certificates")
+	}
+	return certificates, nil
+}
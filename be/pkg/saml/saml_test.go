@@ -0,0 +1,207 @@
+package saml_test
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/saml"
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/saml/samltest"
+)
+
+const (
+	spEntityID = "https://app.example.com/api/v1/auth/sso/saml/first-bank/metadata"
+	spACSURL   = "https://app.example.com/api/v1/auth/sso/saml/first-bank/acs"
+)
+
+func TestCanonicalize(t *testing.T) {
+	input := `<a:root xmlns:a="urn:a" xmlns:b="urn:b" a:y="2" z="1"><!-- note --><b:child b:k="v">text &amp; &#x3C;more&#x3E;</b:child><empty/></a:root>`
+	canonical, err := saml.Canonicalize([]byte(input))
+	require.NoError(t, err)
+	assert.Equal(t, `<a:root xmlns:a="urn:a" z="1" a:y="2"><b:child xmlns:b="urn:b" b:k="v">text &amp; &lt;more&gt;</b:child><empty></empty></a:root>`, string(canonical))
+
+	_, err = saml.Canonicalize([]byte(`<!DOCTYPE root [<!ENTITY x "y">]><root>&x;</root>`))
+	assert.Error(t, err)
+}
+
+type fixture struct {
+	idp *samltest.IdentityProvider
+	sp  *saml.ServiceProvider
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	idp, err := samltest.NewIdentityProvider("https://idp.example-bank.com")
+	require.NoError(t, err)
+	key, certificate, err := samltest.NewCertificate("goedu")
+	require.NoError(t, err)
+	return &fixture{
+		idp: idp,
+		sp: &saml.ServiceProvider{
+			EntityID:    spEntityID,
+			ACSURL:      spACSURL,
+			Key:         key,
+			Certificate: certificate,
+			ClockSkew:   time.Minute,
+		},
+	}
+}
+
+func (f *fixture) identityProvider() *saml.IdentityProvider {
+	return &saml.IdentityProvider{
+		EntityID:     f.idp.EntityID,
+		SSOURL:       f.idp.SSOURL,
+		Certificates: []*x509.Certificate{f.idp.Certificate()},
+	}
+}
+
+func TestServiceProvider_SPInitiatedLogin(t *testing.T) {
+	f := newFixture(t)
+	idp := f.identityProvider()
+
+	redirectURL, requestID, err := f.sp.AuthnRequestURL(idp, "relay-1", time.Now())
+	require.NoError(t, err)
+	request, err := f.idp.ReadAuthnRequest(redirectURL, f.sp.Certificate)
+	require.NoError(t, err)
+	assert.Equal(t, requestID, request.ID)
+	assert.Equal(t, spEntityID, request.Issuer)
+	assert.Equal(t, spACSURL, request.ACSURL)
+	assert.Equal(t, "relay-1", request.RelayState)
+
+	response, err := f.idp.Response(samltest.Login{
+		ACSURL:       spACSURL,
+		Audience:     spEntityID,
+		InResponseTo: request.ID,
+		NameID:       "auditor@example-bank.com",
+		Attributes: map[string][]string{
+			"givenName": {"Ada"},
+			"groups":    {"GRC-Auditors", "All-Staff"},
+		},
+	})
+	require.NoError(t, err)
+
+	assertion, err := f.sp.ParseResponse(response, idp, requestID, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "auditor@example-bank.com", assertion.NameID)
+	assert.Equal(t, saml.NameIDFormatEmail, assertion.NameIDFormat)
+	assert.Equal(t, "https://idp.example-bank.com", assertion.Issuer)
+	assert.Equal(t, "Ada", assertion.Attribute("givenName"))
+	assert.Equal(t, []string{"GRC-Auditors", "All-Staff"}, assertion.Attributes["groups"])
+	assert.NotEmpty(t, assertion.ID)
+	assert.NotEmpty(t, assertion.SessionIndex)
+
+	// The response answers exactly one request
+	_, err = f.sp.ParseResponse(response, idp, "", time.Now())
+	assert.ErrorIs(t, err, saml.ErrInvalidResponse)
+	_, err = f.sp.ParseResponse(response, idp, "_other", time.Now())
+	assert.ErrorIs(t, err, saml.ErrInvalidResponse)
+}
+
+func TestServiceProvider_IdPInitiatedLogin(t *testing.T) {
+	f := newFixture(t)
+	response, err := f.idp.Response(samltest.Login{ACSURL: spACSURL, Audience: spEntityID, NameID: "auditor@example-bank.com", SignResponse: true})
+	require.NoError(t, err)
+
+	assertion, err := f.sp.ParseResponse(response, f.identityProvider(), "", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "auditor@example-bank.com", assertion.NameID)
+}
+
+func TestServiceProvider_ParseResponseRejects(t *testing.T) {
+	f := newFixture(t)
+	other, err := samltest.NewIdentityProvider("https://idp.example-bank.com")
+	require.NoError(t, err)
+	valid := samltest.Login{ACSURL: spACSURL, Audience: spEntityID, NameID: "auditor@example-bank.com", Attributes: map[string][]string{"role": {"user"}}}
+
+	tests := []struct {
+		name     string
+		idp      *samltest.IdentityProvider
+		modify   func(login *samltest.Login)
+		tamper   func(document string) string
+		now      time.Time
+		expected error
+	}{
+		{"wrong audience", f.idp, func(l *samltest.Login) { l.Audience = "https://other.example.com" }, nil, time.Time{}, saml.ErrInvalidResponse},
+		{"wrong recipient", f.idp, func(l *samltest.Login) { l.ACSURL = "https://other.example.com/acs" }, nil, time.Time{}, saml.ErrInvalidResponse},
+		{"expired", f.idp, nil, nil, time.Now().Add(time.Hour), saml.ErrInvalidResponse},
+		{"not yet valid", f.idp, nil, nil, time.Now().Add(-time.Hour), saml.ErrInvalidResponse},
+		{"unsigned", f.idp, func(l *samltest.Login) { l.UnsignedAssertion = true }, nil, time.Time{}, saml.ErrSignature},
+		{"untrusted key", other, nil, nil, time.Time{}, saml.ErrSignature},
+		{"failed status", f.idp, func(l *samltest.Login) { l.Status = "urn:oasis:names:tc:SAML:2.0:status:Requester" }, nil, time.Time{}, saml.ErrStatus},
+		{"tampered attribute", f.idp, nil, func(d string) string {
+			return strings.Replace(d, "<saml:AttributeValue>user</saml:AttributeValue>", "<saml:AttributeValue>admin</saml:AttributeValue>", 1)
+		}, time.Time{}, saml.ErrSignature},
+		{"tampered subject", f.idp, nil, func(d string) string {
+			return strings.Replace(d, "auditor@example-bank.com", "admin@example-bank.com", 1)
+		}, time.Time{}, saml.ErrSignature},
+		{"wrapped assertion", f.idp, nil, func(d string) string {
+			// A forged unsigned assertion next to the signed one
+			start := strings.Index(d, "<saml:Assertion")
+			forged := strings.Replace(d[start:strings.Index(d, "</samlp:Response>")], "auditor@", "admin@", 1)
+			return strings.Replace(d, "</samlp:Response>", forged+"</samlp:Response>", 1)
+		}, time.Time{}, saml.ErrInvalidResponse},
+		{"encrypted assertion", f.idp, nil, func(d string) string {
+			return strings.Replace(d, "</samlp:Status>", "</samlp:Status><saml:EncryptedAssertion></saml:EncryptedAssertion>", 1)
+		}, time.Time{}, saml.ErrInvalidResponse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			login := valid
+			if tt.modify != nil {
+				tt.modify(&login)
+			}
+			response, err := tt.idp.Response(login)
+			require.NoError(t, err)
+			if tt.tamper != nil {
+				document, err := base64.StdEncoding.DecodeString(response)
+				require.NoError(t, err)
+				response = base64.StdEncoding.EncodeToString([]byte(tt.tamper(string(document))))
+			}
+			now := tt.now
+			if now.IsZero() {
+				now = time.Now()
+			}
+
+			_, err = f.sp.ParseResponse(response, f.identityProvider(), "", now)
+			assert.ErrorIs(t, err, tt.expected)
+		})
+	}
+}
+
+func TestServiceProvider_Metadata(t *testing.T) {
+	f := newFixture(t)
+	var metadata struct {
+		EntityID string `xml:"entityID,attr"`
+		SP       struct {
+			AuthnRequestsSigned bool   `xml:"AuthnRequestsSigned,attr"`
+			Certificate         string `xml:"KeyDescriptor>KeyInfo>X509Data>X509Certificate"`
+			ACS                 struct {
+				Binding  string `xml:"Binding,attr"`
+				Location string `xml:"Location,attr"`
+			} `xml:"AssertionConsumerService"`
+		} `xml:"SPSSODescriptor"`
+	}
+	require.NoError(t, xml.Unmarshal(f.sp.Metadata(), &metadata))
+	assert.Equal(t, spEntityID, metadata.EntityID)
+	assert.True(t, metadata.SP.AuthnRequestsSigned)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(f.sp.Certificate.Raw), metadata.SP.Certificate)
+	assert.Equal(t, saml.BindingHTTPPost, metadata.SP.ACS.Binding)
+	assert.Equal(t, spACSURL, metadata.SP.ACS.Location)
+}
+
+func TestParseCertificates(t *testing.T) {
+	f := newFixture(t)
+	certificates, err := saml.ParseCertificates([]string{f.idp.CertificatePEM()})
+	require.NoError(t, err)
+	assert.Equal(t, f.idp.Certificate().Raw, certificates[0].Raw)
+
+	_, err = saml.ParseCertificates([]string{"not a certificate"})
+	assert.Error(t, err)
+}
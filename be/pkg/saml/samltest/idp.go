@@ -0,0 +1,274 @@
+// Package samltest provides a SAML identity provider for tests. It verifies
+// and decodes the service provider's redirect AuthnRequests and issues signed
+// responses for a configurable user, without any network traffic.
+package samltest
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/saml"
+)
+
+// IdentityProvider is a fake SAML identity provider with its own signing key.
+type IdentityProvider struct {
+	EntityID string
+	SSOURL   string
+
+	key         *rsa.PrivateKey
+	certificate *x509.Certificate
+}
+
+// NewIdentityProvider creates an identity provider with a fresh self-signed
+// signing certificate.
+//
+// Parameters:
+//   - entityID: Entity ID the provider issues assertions as
+//
+// Returns:
+//   - *IdentityProvider: Provider whose SSO URL is under https://idp.example.com
+//   - error: Error if no key or certificate can be generated
+//
+// Example:
+//
+//	idp, err := samltest.NewIdentityProvider("https://idp.example-bank.com")
+//	require.NoError(t, err)
+//	response, err := idp.Response(samltest.Login{ACSURL: acs, Audience: entityID, NameID: "auditor@example-bank.com"})
+func NewIdentityProvider(entityID string) (*IdentityProvider, error) {
+	key, certificate, err := NewCertificate(entityID)
+	if err != nil {
+		return nil, err
+	}
+	return &IdentityProvider{
+		EntityID:    entityID,
+		SSOURL:      "https://idp.example.com/sso",
+		key:         key,
+		certificate: certificate,
+	}, nil
+}
+
+// NewCertificate generates an RSA key and a self-signed certificate for it.
+func NewCertificate(commonName string) (*rsa.PrivateKey, *x509.Certificate, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, certificate, nil
+}
+
+// Certificate returns the provider's signing certificate.
+func (p *IdentityProvider) Certificate() *x509.Certificate {
+	return p.certificate
+}
+
+// CertificatePEM returns the provider's signing certificate PEM encoded.
+func (p *IdentityProvider) CertificatePEM() string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: p.certificate.Raw}))
+}
+
+// AuthnRequest is the content of a decoded AuthnRequest.
+type AuthnRequest struct {
+	ID         string
+	Issuer     string
+	ACSURL     string
+	RelayState string
+}
+
+// ReadAuthnRequest verifies the signature of an HTTP-Redirect binding URL
+// with the service provider's certificate and decodes its AuthnRequest.
+func (p *IdentityProvider) ReadAuthnRequest(redirectURL string, spCertificate *x509.Certificate) (*AuthnRequest, error) {
+	u, err := url.Parse(redirectURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme+"://"+u.Host+u.Path != p.SSOURL {
+		return nil, fmt.Errorf("request sent to %s", u.Path)
+	}
+
+	// The signed string keeps the parameters as they were encoded
+	params := map[string]string{}
+	for _, pair := range strings.Split(u.RawQuery, "&") {
+		name, value, _ := strings.Cut(pair, "=")
+		params[name] = value
+	}
+	signed := "SAMLRequest=" + params["SAMLRequest"]
+	if _, ok := params["RelayState"]; ok {
+		signed += "&RelayState=" + params["RelayState"]
+	}
+	signed += "&SigAlg=" + params["SigAlg"]
+	query := u.Query()
+	if query.Get("SigAlg") != saml.AlgorithmRSASHA256 {
+		return nil, errors.New("unsupported signature algorithm")
+	}
+	signature, err := base64.StdEncoding.DecodeString(query.Get("Signature"))
+	if err != nil {
+		return nil, err
+	}
+	hashed := sha256.Sum256([]byte(signed))
+	if err := rsa.VerifyPKCS1v15(spCertificate.PublicKey.(*rsa.PublicKey), crypto.SHA256, hashed[:], signature); err != nil {
+		return nil, fmt.Errorf("request signature: %w", err)
+	}
+
+	deflated, err := base64.StdEncoding.DecodeString(query.Get("SAMLRequest"))
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(flate.NewReader(bytes.NewReader(deflated)))
+	if err != nil {
+		return nil, err
+	}
+	var request struct {
+		ID     string `xml:"ID,attr"`
+		ACSURL string `xml:"AssertionConsumerServiceURL,attr"`
+		Issuer string `xml:"urn:oasis:names:tc:SAML:2.0:assertion Issuer"`
+	}
+	if err := xml.Unmarshal(data, &request); err != nil {
+		return nil, err
+	}
+	return &AuthnRequest{ID: request.ID, Issuer: request.Issuer, ACSURL: request.ACSURL, RelayState: query.Get("RelayState")}, nil
+}
+
+// Login describes the response the provider issues.
+type Login struct {
+	// ACSURL is the service provider's assertion consumer service
+	ACSURL string
+	// Audience is the service provider's entity ID
+	Audience string
+	// InResponseTo is the answered request ID; empty for IdP-initiated logins
+	InResponseTo string
+	NameID       string
+	Attributes   map[string][]string
+
+	// Now is the issue instant; the current time when zero
+	Now time.Time
+	// SignResponse signs the response in addition to the assertion
+	SignResponse bool
+	// UnsignedAssertion leaves the assertion unsigned
+	UnsignedAssertion bool
+	// Status overrides the success status code
+	Status string
+}
+
+// Response returns the base64 encoded SAMLResponse of a login.
+func (p *IdentityProvider) Response(login Login) (string, error) {
+	now := login.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	now = now.UTC()
+	instant := now.Format(time.RFC3339)
+	expires := now.Add(5 * time.Minute).Format(time.RFC3339)
+	status := login.Status
+	if status == "" {
+		status = saml.StatusSuccess
+	}
+	inResponseTo := ""
+	if login.InResponseTo != "" {
+		inResponseTo = ` InResponseTo="` + escape(login.InResponseTo) + `"`
+	}
+
+	assertionID, err := newID()
+	if err != nil {
+		return "", err
+	}
+	var assertion strings.Builder
+	assertion.WriteString(`<saml:Assertion xmlns:saml="` + saml.NamespaceAssertion + `" ID="` + assertionID + `" Version="2.0" IssueInstant="` + instant + `">`)
+	assertion.WriteString(`<saml:Issuer>` + escape(p.EntityID) + `</saml:Issuer>`)
+	assertion.WriteString(`<saml:Subject><saml:NameID Format="` + saml.NameIDFormatEmail + `">` + escape(login.NameID) + `</saml:NameID>`)
+	assertion.WriteString(`<saml:SubjectConfirmation Method="urn:oasis:names:tc:SAML:2.0:cm:bearer">`)
+	assertion.WriteString(`<saml:SubjectConfirmationData` + inResponseTo + ` NotOnOrAfter="` + expires + `" Recipient="` + escape(login.ACSURL) + `"/>`)
+	assertion.WriteString(`</saml:SubjectConfirmation></saml:Subject>`)
+	assertion.WriteString(`<saml:Conditions NotBefore="` + instant + `" NotOnOrAfter="` + expires + `">`)
+	assertion.WriteString(`<saml:AudienceRestriction><saml:Audience>` + escape(login.Audience) + `</saml:Audience></saml:AudienceRestriction></saml:Conditions>`)
+	assertion.WriteString(`<saml:AuthnStatement AuthnInstant="` + instant + `" SessionIndex="` + assertionID + `">`)
+	assertion.WriteString(`<saml:AuthnContext><saml:AuthnContextClassRef>urn:oasis:names:tc:SAML:2.0:ac:classes:PasswordProtectedTransport</saml:AuthnContextClassRef></saml:AuthnContext></saml:AuthnStatement>`)
+	if len(login.Attributes) > 0 {
+		names := make([]string, 0, len(login.Attributes))
+		for name := range login.Attributes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		assertion.WriteString(`<saml:AttributeStatement>`)
+		for _, name := range names {
+			assertion.WriteString(`<saml:Attribute Name="` + escape(name) + `">`)
+			for _, value := range login.Attributes[name] {
+				assertion.WriteString(`<saml:AttributeValue>` + escape(value) + `</saml:AttributeValue>`)
+			}
+			assertion.WriteString(`</saml:Attribute>`)
+		}
+		assertion.WriteString(`</saml:AttributeStatement>`)
+	}
+	assertion.WriteString(`</saml:Assertion>`)
+
+	signedAssertion := []byte(assertion.String())
+	if !login.UnsignedAssertion {
+		if signedAssertion, err = saml.SignEnveloped(signedAssertion, p.key, p.certificate); err != nil {
+			return "", err
+		}
+	}
+
+	responseID, err := newID()
+	if err != nil {
+		return "", err
+	}
+	response := []byte(`<samlp:Response xmlns:samlp="` + saml.NamespaceProtocol + `" xmlns:saml="` + saml.NamespaceAssertion + `"` +
+		` ID="` + responseID + `" Version="2.0" IssueInstant="` + instant + `" Destination="` + escape(login.ACSURL) + `"` + inResponseTo + `>` +
+		`<saml:Issuer>` + escape(p.EntityID) + `</saml:Issuer>` +
+		`<samlp:Status><samlp:StatusCode Value="` + escape(status) + `"/></samlp:Status>` +
+		string(signedAssertion) + `</samlp:Response>`)
+	if login.SignResponse {
+		if response, err = saml.SignEnveloped(response, p.key, p.certificate); err != nil {
+			return "", err
+		}
+	}
+	return base64.StdEncoding.EncodeToString(response), nil
+}
+
+// newID returns a random xs:ID.
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "_" + hex.EncodeToString(b), nil
+}
+
+// escape escapes text for use in XML content and attribute values.
+func escape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,123 @@
+#!/usr/bin/env bash
+#
+# Regenerates the identity provider conformance fixtures: SAML responses laid
+# out the way AD FS, Okta and Azure AD (Entra ID) write them, signed with
+# idp.key. Digests and signatures are computed with libxml2's exclusive
+# canonicalization (xmllint --exc-c14n) and openssl, independently of the
+# package's own canonicalizer, so the fixtures check it against another
+# implementation.
+#
+# Usage (from anywhere; needs xmllint and openssl):
+#   ./pkg/saml/testdata/generate.sh
+
+set -euo pipefail
+cd "$(dirname "$0")"
+
+DSIG="http://www.w3.org/2000/09/xmldsig#"
+EXC_C14N="http://www.w3.org/2001/10/xml-exc-c14n#"
+ENVELOPED="http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+RSA_SHA256="http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+SHA256="http://www.w3.org/2001/04/xmlenc#sha256"
+XS="http://www.w3.org/2001/XMLSchema"
+XSI="http://www.w3.org/2001/XMLSchema-instance"
+PROTOCOL="urn:oasis:names:tc:SAML:2.0:protocol"
+ASSERTION="urn:oasis:names:tc:SAML:2.0:assertion"
+SUCCESS="urn:oasis:names:tc:SAML:2.0:status:Success"
+BEARER="urn:oasis:names:tc:SAML:2.0:cm:bearer"
+EMAIL="urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+UNSPECIFIED="urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified"
+PASSWORD="urn:oasis:names:tc:SAML:2.0:ac:classes:PasswordProtectedTransport"
+
+# Must match the service provider of saml_test.go and conformance_test.go
+SP_ENTITY_ID="https://app.example.com/api/v1/auth/sso/saml/first-bank/metadata"
+SP_ACS_URL="https://app.example.com/api/v1/auth/sso/saml/first-bank/acs"
+REQUEST_ID="_6c3a4f8b0e2d4c7a9b1f5e3d2c8a7b6f"
+
+CERTIFICATE=$(grep -v CERTIFICATE idp.crt | tr -d '\n')
+
+# canonical [prefix-list]: exclusive canonicalization of stdin. xmllint has no
+# InclusiveNamespaces support, so the inclusive prefixes, which the fixtures
+# only declare on the apex, are added to the apex after its first namespace
+# declaration, the position sorting gives them here.
+canonical() {
+	local declarations=""
+	for prefix in ${1:-}; do
+		[ "$prefix" = xs ] && declarations+=" xmlns:xs=\"$XS\""
+	done
+	xmllint --exc-c14n - | sed "1s|^\(<[^ >]* xmlns:[a-z0-9]*=\"[^\"]*\"\)|\1$declarations|"
+}
+
+# signed_info prefix id digest [prefix-list]
+signed_info() {
+	local p=$1 id=$2 digest=$3 prefixes=${4:-}
+	local transform="<${p}Transform Algorithm=\"$EXC_C14N\"/>"
+	if [ -n "$prefixes" ]; then
+		transform="<${p}Transform Algorithm=\"$EXC_C14N\"><ec:InclusiveNamespaces xmlns:ec=\"$EXC_C14N\" PrefixList=\"$prefixes\"/></${p}Transform>"
+	fi
+	printf '%s' "<${p}SignedInfo><${p}CanonicalizationMethod Algorithm=\"$EXC_C14N\"/><${p}SignatureMethod Algorithm=\"$RSA_SHA256\"/><${p}Reference URI=\"#$id\"><${p}Transforms><${p}Transform Algorithm=\"$ENVELOPED\"/>$transform</${p}Transforms><${p}DigestMethod Algorithm=\"$SHA256\"/><${p}DigestValue>$digest</${p}DigestValue></${p}Reference></${p}SignedInfo>"
+}
+
+# signature prefix id unsigned-element [prefix-list]: the enveloped signature
+# of an element with the given ID, written before the signature is inserted
+signature() {
+	local p=$1 id=$2 element=$3 prefixes=${4:-}
+	local declaration="xmlns:${p%:}=\"$DSIG\""
+	[ -z "$p" ] && declaration="xmlns=\"$DSIG\""
+
+	local digest info value
+	digest=$(printf '%s' "$element" | canonical "$prefixes" | openssl dgst -sha256 -binary | openssl base64 -A)
+	info=$(signed_info "$p" "$id" "$digest" "$prefixes")
+	value=$(printf '%s' "$info" | sed "s|^<${p}SignedInfo|<${p}SignedInfo $declaration|" | canonical |
+		openssl dgst -sha256 -sign idp.key | openssl base64 -A)
+	printf '%s' "<${p}Signature $declaration>$info<${p}SignatureValue>$value</${p}SignatureValue><${p}KeyInfo><${p}X509Data><${p}X509Certificate>$CERTIFICATE</${p}X509Certificate></${p}X509Data></${p}KeyInfo></${p}Signature>"
+}
+
+# AD FS: default namespace assertion signed with a ds: prefixed signature,
+# unsigned response, claim URIs as attribute names
+adfs() {
+	local id="_8f2d6a14-7c3b-4e95-b0d2-5a9e1c7f3b62"
+	local issuer="<Issuer>http://adfs.example-bank.com/adfs/services/trust</Issuer>"
+	local rest="<Subject><NameID Format=\"$EMAIL\">auditor@example-bank.com</NameID><SubjectConfirmation Method=\"$BEARER\"><SubjectConfirmationData InResponseTo=\"$REQUEST_ID\" NotOnOrAfter=\"2026-10-01T09:05:00.412Z\" Recipient=\"$SP_ACS_URL\" /></SubjectConfirmation></Subject><Conditions NotBefore=\"2026-10-01T09:00:00.412Z\" NotOnOrAfter=\"2026-10-01T10:00:00.412Z\"><AudienceRestriction><Audience>$SP_ENTITY_ID</Audience></AudienceRestriction></Conditions><AttributeStatement><Attribute Name=\"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/emailaddress\"><AttributeValue>auditor@example-bank.com</AttributeValue></Attribute><Attribute Name=\"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/givenname\"><AttributeValue>Ada</AttributeValue></Attribute><Attribute Name=\"http://schemas.xmlsoap.org/claims/Group\"><AttributeValue>GRC-Auditors</AttributeValue><AttributeValue>All-Staff</AttributeValue></Attribute></AttributeStatement><AuthnStatement AuthnInstant=\"2026-10-01T08:59:58.120Z\" SessionIndex=\"$id\"><AuthnContext><AuthnContextClassRef>$PASSWORD</AuthnContextClassRef></AuthnContext></AuthnStatement></Assertion>"
+	local open="<Assertion ID=\"$id\" IssueInstant=\"2026-10-01T09:00:00.412Z\" Version=\"2.0\" xmlns=\"$ASSERTION\">"
+
+	local sig
+	sig=$(signature "ds:" "$id" "$open$issuer$rest")
+	printf '%s' "<samlp:Response ID=\"_1b7e3c52-4f0a-4d7e-9a51-2c6d8e0f4a11\" Version=\"2.0\" IssueInstant=\"2026-10-01T09:00:00.412Z\" Destination=\"$SP_ACS_URL\" Consent=\"urn:oasis:names:tc:SAML:2.0:consent:unspecified\" InResponseTo=\"$REQUEST_ID\" xmlns:samlp=\"$PROTOCOL\"><Issuer xmlns=\"$ASSERTION\">http://adfs.example-bank.com/adfs/services/trust</Issuer><samlp:Status><samlp:StatusCode Value=\"$SUCCESS\" /></samlp:Status>$open$issuer$sig$rest</samlp:Response>"
+}
+
+# Okta: saml2 prefixes, signed response and assertion, xs:string typed
+# attribute values kept by an InclusiveNamespaces prefix list
+okta() {
+	local id="id41823917456301288171502340" response_id="id41823917455871529630728451"
+	local entity="http://www.okta.com/exk1a2b3c4d5e6f7g8h9"
+	local issuer="<saml2:Issuer Format=\"urn:oasis:names:tc:SAML:2.0:nameid-format:entity\" xmlns:saml2=\"$ASSERTION\">$entity</saml2:Issuer>"
+	local value="xmlns:xs=\"$XS\" xmlns:xsi=\"$XSI\" xsi:type=\"xs:string\""
+	local rest="<saml2:Subject xmlns:saml2=\"$ASSERTION\"><saml2:NameID Format=\"$UNSPECIFIED\">auditor@example-bank.com</saml2:NameID><saml2:SubjectConfirmation Method=\"$BEARER\"><saml2:SubjectConfirmationData InResponseTo=\"$REQUEST_ID\" NotOnOrAfter=\"2026-10-01T09:05:00.144Z\" Recipient=\"$SP_ACS_URL\"/></saml2:SubjectConfirmation></saml2:Subject><saml2:Conditions NotBefore=\"2026-10-01T08:55:00.144Z\" NotOnOrAfter=\"2026-10-01T09:05:00.144Z\" xmlns:saml2=\"$ASSERTION\"><saml2:AudienceRestriction><saml2:Audience>$SP_ENTITY_ID</saml2:Audience></saml2:AudienceRestriction></saml2:Conditions><saml2:AuthnStatement AuthnInstant=\"2026-10-01T08:59:59.532Z\" SessionIndex=\"id1727773199532.1384290\" xmlns:saml2=\"$ASSERTION\"><saml2:AuthnContext><saml2:AuthnContextClassRef>$PASSWORD</saml2:AuthnContextClassRef></saml2:AuthnContext></saml2:AuthnStatement><saml2:AttributeStatement xmlns:saml2=\"$ASSERTION\"><saml2:Attribute Name=\"givenName\" NameFormat=\"urn:oasis:names:tc:SAML:2.0:attrname-format:unspecified\"><saml2:AttributeValue $value>Ada</saml2:AttributeValue></saml2:Attribute><saml2:Attribute Name=\"groups\" NameFormat=\"urn:oasis:names:tc:SAML:2.0:attrname-format:unspecified\"><saml2:AttributeValue $value>GRC-Auditors</saml2:AttributeValue><saml2:AttributeValue $value>All-Staff</saml2:AttributeValue></saml2:Attribute></saml2:AttributeStatement></saml2:Assertion>"
+	local open="<saml2:Assertion ID=\"$id\" IssueInstant=\"2026-10-01T09:00:00.144Z\" Version=\"2.0\" xmlns:saml2=\"$ASSERTION\" xmlns:xs=\"$XS\">"
+
+	local sig assertion response_open response_rest
+	sig=$(signature "ds:" "$id" "$open$issuer$rest" "xs")
+	assertion="$open$issuer$sig$rest"
+	response_open="<saml2p:Response Destination=\"$SP_ACS_URL\" ID=\"$response_id\" InResponseTo=\"$REQUEST_ID\" IssueInstant=\"2026-10-01T09:00:00.144Z\" Version=\"2.0\" xmlns:saml2p=\"$PROTOCOL\" xmlns:xs=\"$XS\">"
+	response_rest="<saml2p:Status xmlns:saml2p=\"$PROTOCOL\"><saml2p:StatusCode Value=\"$SUCCESS\"/></saml2p:Status>$assertion</saml2p:Response>"
+	sig=$(signature "ds:" "$response_id" "$response_open$issuer$response_rest" "xs")
+	printf '%s\n%s' '<?xml version="1.0" encoding="UTF-8"?>' "$response_open$issuer$sig$response_rest"
+}
+
+# Azure AD: default namespace assertion and signature, unsigned response,
+# seven digit fractional seconds
+azuread() {
+	local id="_a3b5c7d9-1e2f-4a6b-8c0d-e1f2a3b4c5d6"
+	local entity="https://sts.windows.net/2b4c6d8e-0f1a-4b3c-9d5e-7f8a9b0c1d2e/"
+	local issuer="<Issuer>$entity</Issuer>"
+	local rest="<Subject><NameID Format=\"$EMAIL\">auditor@example-bank.com</NameID><SubjectConfirmation Method=\"$BEARER\"><SubjectConfirmationData InResponseTo=\"$REQUEST_ID\" NotOnOrAfter=\"2026-10-01T10:00:00.1234567Z\" Recipient=\"$SP_ACS_URL\"/></SubjectConfirmation></Subject><Conditions NotBefore=\"2026-10-01T08:55:00.1234567Z\" NotOnOrAfter=\"2026-10-01T10:00:00.1234567Z\"><AudienceRestriction><Audience>$SP_ENTITY_ID</Audience></AudienceRestriction></Conditions><AttributeStatement><Attribute Name=\"http://schemas.microsoft.com/identity/claims/tenantid\"><AttributeValue>2b4c6d8e-0f1a-4b3c-9d5e-7f8a9b0c1d2e</AttributeValue></Attribute><Attribute Name=\"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/givenname\"><AttributeValue>Ada</AttributeValue></Attribute><Attribute Name=\"http://schemas.microsoft.com/ws/2008/06/identity/claims/groups\"><AttributeValue>5e6f7a8b-9c0d-4e1f-a2b3-c4d5e6f7a8b9</AttributeValue><AttributeValue>0a1b2c3d-4e5f-4a7b-8c9d-0e1f2a3b4c5d</AttributeValue></Attribute></AttributeStatement><AuthnStatement AuthnInstant=\"2026-10-01T08:59:57.000Z\" SessionIndex=\"$id\"><AuthnContext><AuthnContextClassRef>$PASSWORD</AuthnContextClassRef></AuthnContext></AuthnStatement></Assertion>"
+	local open="<Assertion ID=\"$id\" IssueInstant=\"2026-10-01T09:00:00.1234567Z\" Version=\"2.0\" xmlns=\"$ASSERTION\">"
+
+	local sig
+	sig=$(signature "" "$id" "$open$issuer$rest")
+	printf '%s' "<samlp:Response ID=\"_f0c8e2a4-6b1d-4f3e-a5c7-9d0e1f2a3b4c\" Version=\"2.0\" IssueInstant=\"2026-10-01T09:00:00.1234567Z\" Destination=\"$SP_ACS_URL\" InResponseTo=\"$REQUEST_ID\" xmlns:samlp=\"$PROTOCOL\"><Issuer xmlns=\"$ASSERTION\">$entity</Issuer><samlp:Status><samlp:StatusCode Value=\"$SUCCESS\"/></samlp:Status>$open$issuer$sig$rest</samlp:Response>"
+}
+
+adfs >adfs.xml
+okta >okta.xml
+azuread >azuread.xml
@@ -0,0 +1,329 @@
+package saml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// element is a parsed XML element that keeps the prefixes and namespace
+// declarations as written, which canonicalization needs and encoding/xml's
+// namespace-resolving decoder discards.
+type element struct {
+	prefix   string
+	name     string
+	attrs    []xml.Attr // Name.Space holds the prefix; xmlns declarations included
+	children []interface{}
+	parent   *element
+}
+
+// parseXML parses a document into its root element. Documents with a DOCTYPE
+// or other directives are rejected; comments and processing instructions
+// outside the root element are dropped.
+func parseXML(data []byte) (*element, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var root, current *element
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t, err = normalizeAttributes(t, data[offset:decoder.InputOffset()]); err != nil {
+				return nil, err
+			}
+			el := &element{prefix: t.Name.Space, name: t.Name.Local, attrs: t.Attr, parent: current}
+			if current == nil {
+				if root != nil {
+					return nil, errors.New("multiple root elements")
+				}
+				root = el
+			} else {
+				current.children = append(current.children, el)
+			}
+			current = el
+		case xml.EndElement:
+			if current == nil || t.Name.Space != current.prefix || t.Name.Local != current.name {
+				return nil, fmt.Errorf("unexpected end element %s", t.Name.Local)
+			}
+			current = current.parent
+		case xml.CharData:
+			if current != nil {
+				current.children = append(current.children, string(t))
+			} else if len(bytes.TrimSpace(t)) > 0 {
+				return nil, errors.New("text outside the root element")
+			}
+		case xml.ProcInst:
+			if current != nil {
+				current.children = append(current.children, t.Copy())
+			}
+		case xml.Directive:
+			return nil, errors.New("XML directives are not allowed")
+		}
+	}
+	if root == nil || current != nil {
+		return nil, errors.New("incomplete XML document")
+	}
+	return root, nil
+}
+
+// normalizeAttributes applies the attribute value normalization that
+// encoding/xml leaves out: whitespace written literally in a value reads as
+// a space, while whitespace written as a character reference is kept. raw is
+// the start tag as written.
+func normalizeAttributes(start xml.StartElement, raw []byte) (xml.StartElement, error) {
+	if !bytes.ContainsAny(raw, "\t\r\n") {
+		return start, nil
+	}
+	normalized := make([]byte, 0, len(raw))
+	var quote byte
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case quote == 0:
+			if c == '"' || c == '\'' {
+				quote = c
+			}
+		case c == quote:
+			quote = 0
+		case c == '\r' && i+1 < len(raw) && raw[i+1] == '\n':
+			continue
+		case c == '\t' || c == '\r' || c == '\n':
+			c = ' '
+		}
+		normalized = append(normalized, c)
+	}
+	token, err := xml.NewDecoder(bytes.NewReader(normalized)).RawToken()
+	if err != nil {
+		return start, err
+	}
+	normalizedStart, ok := token.(xml.StartElement)
+	if !ok {
+		return start, errors.New("malformed start element")
+	}
+	return normalizedStart, nil
+}
+
+// lookupNamespace returns the namespace bound to a prefix at this element; ""
+// is the default namespace.
+func (e *element) lookupNamespace(prefix string) (string, bool) {
+	for el := e; el != nil; el = el.parent {
+		for _, attr := range el.attrs {
+			if (prefix == "" && attr.Name.Space == "" && attr.Name.Local == "xmlns") ||
+				(prefix != "" && attr.Name.Space == "xmlns" && attr.Name.Local == prefix) {
+				return attr.Value, true
+			}
+		}
+	}
+	if prefix == "xml" {
+		return "http://www.w3.org/XML/1998/namespace", true
+	}
+	return "", prefix == ""
+}
+
+// namespace returns the element's namespace.
+func (e *element) namespace() string {
+	ns, _ := e.lookupNamespace(e.prefix)
+	return ns
+}
+
+// is reports whether the element has the given namespace and local name.
+func (e *element) is(namespace, name string) bool {
+	return e.name == name && e.namespace() == namespace
+}
+
+// attr returns the value of an unprefixed attribute.
+func (e *element) attr(name string) string {
+	for _, attr := range e.attrs {
+		if attr.Name.Space == "" && attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// childElements returns the child elements with the given namespace and name.
+func (e *element) childElements(namespace, name string) []*element {
+	var result []*element
+	for _, child := range e.children {
+		if el, ok := child.(*element); ok && el.is(namespace, name) {
+			result = append(result, el)
+		}
+	}
+	return result
+}
+
+// child returns the first child element with the given namespace and name.
+func (e *element) child(namespace, name string) *element {
+	if children := e.childElements(namespace, name); len(children) > 0 {
+		return children[0]
+	}
+	return nil
+}
+
+// childOrNil is child for a possibly missing element.
+func (e *element) childOrNil(namespace, name string) *element {
+	if e == nil {
+		return nil
+	}
+	return e.child(namespace, name)
+}
+
+// childElementsOrNil is childElements for a possibly missing element.
+func (e *element) childElementsOrNil(namespace, name string) []*element {
+	if e == nil {
+		return nil
+	}
+	return e.childElements(namespace, name)
+}
+
+// text returns the element's character data, excluding child elements and
+// processing instructions.
+func (e *element) text() string {
+	var b strings.Builder
+	for _, child := range e.children {
+		if s, ok := child.(string); ok {
+			b.WriteString(s)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// canonicalize writes the exclusive XML canonicalization (without comments)
+// of the element's subtree, leaving out the excluded element as the enveloped
+// signature transform requires. Prefixes in inclusive are treated as in
+// inclusive canonicalization, as listed by an InclusiveNamespaces PrefixList.
+func canonicalize(e *element, exclude *element, inclusive []string) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeCanonical(&buf, e, exclude, inclusive, map[string]string{}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeCanonical(buf *bytes.Buffer, e, exclude *element, inclusive []string, rendered map[string]string) error {
+	// Namespaces visibly utilized by the element and its attributes
+	utilized := map[string]bool{e.prefix: true}
+	for _, attr := range e.attrs {
+		if attr.Name.Space != "" && attr.Name.Space != "xmlns" && attr.Name.Space != "xml" {
+			utilized[attr.Name.Space] = true
+		}
+	}
+	for _, prefix := range inclusive {
+		if prefix == "#default" {
+			prefix = ""
+		}
+		if _, ok := e.lookupNamespace(prefix); ok {
+			utilized[prefix] = true
+		}
+	}
+
+	scope := make(map[string]string, len(rendered)+len(utilized))
+	for prefix, ns := range rendered {
+		scope[prefix] = ns
+	}
+	var declarations []string
+	for prefix := range utilized {
+		ns, ok := e.lookupNamespace(prefix)
+		if !ok {
+			return fmt.Errorf("undeclared namespace prefix %q", prefix)
+		}
+		if prefix == "xml" || scope[prefix] == ns {
+			continue
+		}
+		scope[prefix] = ns
+		declarations = append(declarations, prefix)
+	}
+	sort.Strings(declarations)
+
+	type attribute struct {
+		namespace, qname, local, value string
+	}
+	var attributes []attribute
+	for _, attr := range e.attrs {
+		if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+			continue
+		}
+		a := attribute{qname: attr.Name.Local, local: attr.Name.Local, value: attr.Value}
+		if attr.Name.Space != "" {
+			a.qname = attr.Name.Space + ":" + attr.Name.Local
+			a.namespace, _ = e.lookupNamespace(attr.Name.Space)
+		}
+		attributes = append(attributes, a)
+	}
+	sort.Slice(attributes, func(i, j int) bool {
+		if attributes[i].namespace != attributes[j].namespace {
+			return attributes[i].namespace < attributes[j].namespace
+		}
+		return attributes[i].local < attributes[j].local
+	})
+
+	qname := e.name
+	if e.prefix != "" {
+		qname = e.prefix + ":" + e.name
+	}
+	buf.WriteString("<" + qname)
+	for _, prefix := range declarations {
+		if prefix == "" {
+			buf.WriteString(` xmlns="`)
+		} else {
+			buf.WriteString(` xmlns:` + prefix + `="`)
+		}
+		buf.WriteString(escapeAttr(scope[prefix]) + `"`)
+	}
+	for _, a := range attributes {
+		buf.WriteString(" " + a.qname + `="` + escapeAttr(a.value) + `"`)
+	}
+	buf.WriteString(">")
+
+	for _, child := range e.children {
+		switch c := child.(type) {
+		case string:
+			buf.WriteString(escapeText(c))
+		case xml.ProcInst:
+			buf.WriteString("<?" + c.Target)
+			if len(c.Inst) > 0 {
+				buf.WriteString(" " + string(c.Inst))
+			}
+			buf.WriteString("?>")
+		case *element:
+			if c == exclude {
+				continue
+			}
+			if err := writeCanonical(buf, c, exclude, inclusive, scope); err != nil {
+				return err
+			}
+		}
+	}
+	buf.WriteString("</" + qname + ">")
+	return nil
+}
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+)
+
+func escapeText(s string) string { return textEscaper.Replace(s) }
+
+func escapeAttr(s string) string { return attrEscaper.Replace(s) }
+
+// Canonicalize returns the exclusive canonical form of a document's root
+// element. Identity providers and tests use it to produce signatures the
+// service provider accepts.
+func Canonicalize(document []byte) ([]byte, error) {
+	root, err := parseXML(document)
+	if err != nil {
+		return nil, err
+	}
+	return canonicalize(root, nil, nil)
+}
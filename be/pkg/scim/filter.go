@@ -0,0 +1,534 @@
+package scim
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// attrNamePattern matches attribute names (RFC 7643 section 2.1).
+var attrNamePattern = regexp.MustCompile(`^(\$ref|[A-Za-z][A-Za-z0-9_-]*)$`)
+
+// attrPath is an attribute reference such as "userName", "name.givenName" or
+// "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department".
+type attrPath struct {
+	// schema is the extension schema holding the attribute, empty for core attributes
+	schema string
+	name   string
+	sub    string
+}
+
+// parseAttrPath parses an attribute reference. A bare extension schema URN
+// refers to the extension's attributes as a whole.
+func parseAttrPath(s string) (attrPath, bool) {
+	var path attrPath
+	if strings.HasPrefix(strings.ToLower(s), "urn:") {
+		for _, schema := range extensionSchemas {
+			if strings.EqualFold(s, schema) {
+				return attrPath{name: schema}, true
+			}
+		}
+		cut := strings.LastIndex(s, ":")
+		path.schema, s = s[:cut], s[cut+1:]
+		for _, core := range []string{SchemaUser, SchemaGroup} {
+			if strings.EqualFold(path.schema, core) {
+				path.schema = ""
+			}
+		}
+	}
+	path.name, path.sub, _ = strings.Cut(s, ".")
+	if !attrNamePattern.MatchString(path.name) || (path.sub != "" && !attrNamePattern.MatchString(path.sub)) {
+		return attrPath{}, false
+	}
+	return path, true
+}
+
+// container returns the object holding the attribute: the resource itself or
+// the extension object, which is created when create is set.
+func (p attrPath) container(object map[string]interface{}, create bool) map[string]interface{} {
+	if p.schema == "" {
+		return object
+	}
+	key, value, _ := lookup(object, p.schema)
+	extension, ok := value.(map[string]interface{})
+	if !ok && create {
+		extension = map[string]interface{}{}
+		object[key] = extension
+	}
+	return extension
+}
+
+// values returns the values the attribute path refers to; multi-valued
+// attributes contribute every element.
+func (p attrPath) values(object map[string]interface{}) []interface{} {
+	container := p.container(object, false)
+	if container == nil {
+		return nil
+	}
+	_, value, _ := lookup(container, p.name)
+
+	var values []interface{}
+	collect := func(v interface{}) {
+		if p.sub != "" {
+			element, ok := v.(map[string]interface{})
+			if !ok {
+				return
+			}
+			_, v, _ = lookup(element, p.sub)
+		}
+		if v != nil {
+			values = append(values, v)
+		}
+	}
+	if list, ok := value.([]interface{}); ok {
+		for _, element := range list {
+			collect(element)
+		}
+	} else if value != nil {
+		collect(value)
+	}
+	return values
+}
+
+// Filter is a parsed filter expression (RFC 7644 section 3.4.2.2).
+type Filter struct {
+	expression string
+	root       filterNode
+}
+
+// ParseFilter parses a filter expression such as
+// `userName eq "jane@example.com"` or `emails[type eq "work" and value co "@example.com"]`.
+// Attribute names and operators are case-insensitive and string comparisons
+// ignore case.
+//
+// Parameters:
+//   - expression: The filter expression
+//
+// Returns:
+//   - *Filter: The parsed filter
+//   - error: An *Error of type invalidFilter when the expression is malformed
+func ParseFilter(expression string) (*Filter, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if next := p.peek(); next.kind != tokenEOF {
+		return nil, badRequest(ErrorTypeInvalidFilter, "unexpected %q at position %d", next.text, next.pos)
+	}
+	return &Filter{expression: expression, root: root}, nil
+}
+
+// Matches reports whether a resource matches the filter.
+func (f *Filter) Matches(resource Resource) bool {
+	return f.root.matches(resource)
+}
+
+// String returns the filter expression.
+func (f *Filter) String() string {
+	return f.expression
+}
+
+// filterNode is a node of a parsed filter.
+type filterNode interface {
+	matches(object map[string]interface{}) bool
+}
+
+// logicalNode joins two filters with "and" or "or".
+type logicalNode struct {
+	and         bool
+	left, right filterNode
+}
+
+func (n *logicalNode) matches(object map[string]interface{}) bool {
+	if n.and {
+		return n.left.matches(object) && n.right.matches(object)
+	}
+	return n.left.matches(object) || n.right.matches(object)
+}
+
+// notNode negates a filter.
+type notNode struct {
+	node filterNode
+}
+
+func (n *notNode) matches(object map[string]interface{}) bool {
+	return !n.node.matches(object)
+}
+
+// valuePathNode matches when an element of a multi-valued attribute matches
+// the inner filter, as in `emails[type eq "work"]`.
+type valuePathNode struct {
+	path   attrPath
+	filter filterNode
+}
+
+func (n *valuePathNode) matches(object map[string]interface{}) bool {
+	for _, value := range n.path.values(object) {
+		if element, ok := value.(map[string]interface{}); ok && n.filter.matches(element) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareNode compares an attribute with a value. It matches when any of the
+// attribute's values does; "ne" matches when none is equal.
+type compareNode struct {
+	path  attrPath
+	op    string
+	value interface{}
+}
+
+func (n *compareNode) matches(object map[string]interface{}) bool {
+	values := n.path.values(object)
+	switch {
+	case n.op == "pr" || (n.op == "ne" && n.value == nil):
+		return present(values)
+	case n.op == "eq" && n.value == nil:
+		return !present(values)
+	case n.op == "ne":
+		return !anyMatches(values, "eq", n.value)
+	}
+	return anyMatches(values, n.op, n.value)
+}
+
+// present reports whether any value is set and not empty.
+func present(values []interface{}) bool {
+	for _, value := range values {
+		switch v := value.(type) {
+		case string:
+			if v != "" {
+				return true
+			}
+		case map[string]interface{}:
+			if len(v) > 0 {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// anyMatches reports whether any value compares true with the expected value.
+// Complex values are compared through their "value" sub-attribute.
+func anyMatches(values []interface{}, op string, expected interface{}) bool {
+	for _, actual := range values {
+		if element, ok := actual.(map[string]interface{}); ok {
+			_, actual, _ = lookup(element, "value")
+		}
+		if compare(op, actual, expected) {
+			return true
+		}
+	}
+	return false
+}
+
+// compare applies a comparison operator. Values of different types never match.
+func compare(op string, actual, expected interface{}) bool {
+	switch want := expected.(type) {
+	case string:
+		got, ok := actual.(string)
+		if !ok {
+			return false
+		}
+		got, want = strings.ToLower(got), strings.ToLower(want)
+		switch op {
+		case "eq":
+			return got == want
+		case "co":
+			return strings.Contains(got, want)
+		case "sw":
+			return strings.HasPrefix(got, want)
+		case "ew":
+			return strings.HasSuffix(got, want)
+		case "gt":
+			return got > want
+		case "ge":
+			return got >= want
+		case "lt":
+			return got < want
+		case "le":
+			return got <= want
+		}
+	case float64:
+		got, ok := actual.(float64)
+		if !ok {
+			return false
+		}
+		switch op {
+		case "eq":
+			return got == want
+		case "gt":
+			return got > want
+		case "ge":
+			return got >= want
+		case "lt":
+			return got < want
+		case "le":
+			return got <= want
+		}
+	case bool:
+		got, ok := actual.(bool)
+		return ok && op == "eq" && got == want
+	}
+	return false
+}
+
+// template returns the attributes an element must have to match a filter of
+// "eq" comparisons joined by "and", such as {"type": "work"} for
+// `type eq "work"`. Patches use it to create the element a filter targets.
+func template(node filterNode) (map[string]interface{}, bool) {
+	switch n := node.(type) {
+	case *compareNode:
+		if n.op != "eq" || n.value == nil || n.path.schema != "" || n.path.sub != "" {
+			return nil, false
+		}
+		return map[string]interface{}{n.path.name: n.value}, true
+	case *logicalNode:
+		if !n.and {
+			return nil, false
+		}
+		left, ok := template(n.left)
+		if !ok {
+			return nil, false
+		}
+		right, ok := template(n.right)
+		if !ok {
+			return nil, false
+		}
+		for key, value := range right {
+			left[key] = value
+		}
+		return left, true
+	}
+	return nil, false
+}
+
+// compareOperators lists the comparison operators taking a value.
+var compareOperators = map[string]bool{
+	"eq": true, "ne": true, "co": true, "sw": true, "ew": true,
+	"gt": true, "ge": true, "lt": true, "le": true,
+}
+
+// tokenKind is the kind of a filter token.
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenLParen
+	tokenRParen
+	tokenLBracket
+	tokenRBracket
+)
+
+// token is a lexical token of a filter expression.
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// tokenize splits a filter expression into tokens. Words run until
+// whitespace, a parenthesis, a bracket or a quote.
+func tokenize(expression string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expression); {
+		c := expression[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')' || c == '[' || c == ']':
+			kind := map[byte]tokenKind{'(': tokenLParen, ')': tokenRParen, '[': tokenLBracket, ']': tokenRBracket}[c]
+			tokens = append(tokens, token{kind: kind, text: string(c), pos: i})
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(expression) && expression[end] != '"' {
+				if expression[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(expression) {
+				return nil, badRequest(ErrorTypeInvalidFilter, "unterminated string at position %d", i)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: expression[i : end+1], pos: i})
+			i = end + 1
+		default:
+			end := i
+			for end < len(expression) && !strings.ContainsRune(" \t\n\r()[]\"", rune(expression[end])) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: expression[i:end], pos: i})
+			i = end
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(expression)}), nil
+}
+
+// filterParser is a recursive descent parser of filter expressions; "and"
+// binds tighter than "or".
+type filterParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *filterParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// keyword reports whether the next token is the given keyword and consumes it.
+func (p *filterParser) keyword(word string) bool {
+	if t := p.peek(); t.kind == tokenWord && strings.EqualFold(t.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) expect(kind tokenKind, text string) error {
+	if t := p.next(); t.kind != kind {
+		return badRequest(ErrorTypeInvalidFilter, "expected %q at position %d", text, t.pos)
+	}
+	return nil
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseTerm parses a parenthesized filter, a negation, a value path or an
+// attribute expression.
+func (p *filterParser) parseTerm() (filterNode, error) {
+	t := p.peek()
+	if t.kind == tokenWord && strings.EqualFold(t.text, "not") && p.tokens[p.pos+1].kind == tokenLParen {
+		p.pos++
+		node, err := p.parseGroup()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{node: node}, nil
+	}
+	if t.kind == tokenLParen {
+		return p.parseGroup()
+	}
+	if t.kind != tokenWord {
+		return nil, badRequest(ErrorTypeInvalidFilter, "expected an attribute at position %d", t.pos)
+	}
+	p.pos++
+	path, ok := parseAttrPath(t.text)
+	if !ok {
+		return nil, badRequest(ErrorTypeInvalidFilter, "invalid attribute %q", t.text)
+	}
+
+	if p.peek().kind == tokenLBracket {
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenRBracket, "]"); err != nil {
+			return nil, err
+		}
+		return &valuePathNode{path: path, filter: inner}, nil
+	}
+
+	opToken := p.next()
+	op := strings.ToLower(opToken.text)
+	if opToken.kind != tokenWord || (op != "pr" && !compareOperators[op]) {
+		return nil, badRequest(ErrorTypeInvalidFilter, "expected an operator at position %d", opToken.pos)
+	}
+	if op == "pr" {
+		return &compareNode{path: path, op: op}, nil
+	}
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	return &compareNode{path: path, op: op, value: value}, nil
+}
+
+// parseGroup parses a parenthesized filter.
+func (p *filterParser) parseGroup() (filterNode, error) {
+	if err := p.expect(tokenLParen, "("); err != nil {
+		return nil, err
+	}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(tokenRParen, ")"); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// parseValue parses a comparison value: a string, number, boolean or null.
+func (p *filterParser) parseValue() (interface{}, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		var value string
+		if err := json.Unmarshal([]byte(t.text), &value); err != nil {
+			return nil, badRequest(ErrorTypeInvalidFilter, "invalid string at position %d", t.pos)
+		}
+		return value, nil
+	case tokenWord:
+		switch strings.ToLower(t.text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		if number, err := strconv.ParseFloat(t.text, 64); err == nil {
+			return number, nil
+		}
+	}
+	return nil, badRequest(ErrorTypeInvalidFilter, "expected a value at position %d", t.pos)
+}
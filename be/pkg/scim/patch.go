@@ -0,0 +1,315 @@
+package scim
+
+import (
+	"reflect"
+	"strings"
+)
+
+// PatchRequest is the body of a PATCH request (RFC 7644 section 3.5.2).
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+// PatchOperation is one operation of a PATCH request. Op is "add", "replace"
+// or "remove" in any case.
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// patchPath is the target of a patch operation, such as "title",
+// "name.givenName", `emails[type eq "work"].value` or `members[value eq "42"]`.
+// With a filter, attr.sub is the sub-attribute after the closing bracket.
+type patchPath struct {
+	attr   attrPath
+	filter filterNode
+}
+
+// parsePatchPath parses the path of a patch operation.
+func parsePatchPath(s string) (patchPath, error) {
+	invalid := badRequest(ErrorTypeInvalidPath, "invalid path %q", s)
+
+	open := strings.IndexByte(s, '[')
+	if open < 0 {
+		attr, ok := parseAttrPath(s)
+		if !ok {
+			return patchPath{}, invalid
+		}
+		return patchPath{attr: attr}, nil
+	}
+
+	closing := strings.LastIndexByte(s, ']')
+	if closing < open {
+		return patchPath{}, invalid
+	}
+	attr, ok := parseAttrPath(s[:open])
+	if !ok || attr.sub != "" {
+		return patchPath{}, invalid
+	}
+	if rest := s[closing+1:]; rest != "" {
+		if !strings.HasPrefix(rest, ".") || !attrNamePattern.MatchString(rest[1:]) {
+			return patchPath{}, invalid
+		}
+		attr.sub = rest[1:]
+	}
+	filter, err := ParseFilter(s[open+1 : closing])
+	if err != nil {
+		return patchPath{}, badRequest(ErrorTypeInvalidPath, "invalid filter in path %q", s)
+	}
+	return patchPath{attr: attr, filter: filter.root}, nil
+}
+
+// Apply applies the operations to a resource in order. Operations without a
+// path take an object whose keys are attribute paths, as some identity
+// providers send "name.givenName" keys. An add or replace whose filter matches
+// no element of a multi-valued attribute appends the element the filter
+// describes when it is made of "eq" comparisons.
+//
+// Parameters:
+//   - resource: Resource to modify in place
+//
+// Returns:
+//   - error: An *Error when an operation is invalid; earlier operations stay applied
+func (r *PatchRequest) Apply(resource Resource) error {
+	if len(r.Operations) == 0 {
+		return badRequest(ErrorTypeInvalidSyntax, "no operations")
+	}
+	for _, op := range r.Operations {
+		if err := op.apply(resource); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply applies one operation.
+func (op PatchOperation) apply(resource Resource) error {
+	switch strings.ToLower(op.Op) {
+	case "add", "replace":
+		replace := strings.EqualFold(op.Op, "replace")
+		if op.Path != "" {
+			path, err := parsePatchPath(op.Path)
+			if err != nil {
+				return err
+			}
+			return path.set(resource, op.Value, replace)
+		}
+		values, ok := op.Value.(map[string]interface{})
+		if !ok {
+			return badRequest(ErrorTypeInvalidValue, "operations without a path need an object value")
+		}
+		for key, value := range values {
+			path, err := parsePatchPath(key)
+			if err != nil {
+				return err
+			}
+			if err := path.set(resource, value, replace); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "remove":
+		if op.Path == "" {
+			return badRequest(ErrorTypeNoTarget, "remove operations need a path")
+		}
+		path, err := parsePatchPath(op.Path)
+		if err != nil {
+			return err
+		}
+		path.remove(resource, op.Value)
+		return nil
+	}
+	return badRequest(ErrorTypeInvalidSyntax, "unsupported operation %q", op.Op)
+}
+
+// set adds or replaces the value at the path.
+func (p patchPath) set(resource Resource, value interface{}, replace bool) error {
+	container := p.attr.container(resource, true)
+	key, current, _ := lookup(container, p.attr.name)
+
+	if p.filter == nil {
+		if p.attr.sub == "" {
+			container[key] = merge(current, value, replace)
+			return nil
+		}
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			if current != nil {
+				return badRequest(ErrorTypeInvalidPath, "%q is not a complex attribute", p.attr.name)
+			}
+			object = map[string]interface{}{}
+			container[key] = object
+		}
+		setAttribute(object, p.attr.sub, value)
+		return nil
+	}
+
+	list, _ := current.([]interface{})
+	matched := false
+	for _, element := range list {
+		object, ok := element.(map[string]interface{})
+		if !ok || !p.filter.matches(object) {
+			continue
+		}
+		matched = true
+		if err := p.setElement(object, value); err != nil {
+			return err
+		}
+	}
+	if !matched {
+		object, ok := template(p.filter)
+		if !ok {
+			return badRequest(ErrorTypeNoTarget, "no value of %q matches the path filter", p.attr.name)
+		}
+		if err := p.setElement(object, value); err != nil {
+			return err
+		}
+		list = append(list, object)
+	}
+	container[key] = list
+	return nil
+}
+
+// setElement sets the value on an element selected by the path filter.
+func (p patchPath) setElement(element map[string]interface{}, value interface{}) error {
+	if p.attr.sub != "" {
+		setAttribute(element, p.attr.sub, value)
+		return nil
+	}
+	values, ok := value.(map[string]interface{})
+	if !ok {
+		return badRequest(ErrorTypeInvalidValue, "elements of %q need an object value", p.attr.name)
+	}
+	for name, v := range values {
+		setAttribute(element, name, v)
+	}
+	return nil
+}
+
+// remove removes the value at the path. Without a filter, a list value
+// removes the listed elements of a multi-valued attribute, as in
+// {"op": "remove", "path": "members", "value": [{"value": "42"}]}.
+func (p patchPath) remove(resource Resource, value interface{}) {
+	container := p.attr.container(resource, false)
+	if container == nil {
+		return
+	}
+	key, current, ok := lookup(container, p.attr.name)
+	if !ok {
+		return
+	}
+	list, isList := current.([]interface{})
+
+	if p.filter == nil {
+		removals, hasRemovals := value.([]interface{})
+		switch {
+		case isList && hasRemovals && p.attr.sub == "":
+			kept := make([]interface{}, 0, len(list))
+			for _, element := range list {
+				if !containsElement(removals, element) {
+					kept = append(kept, element)
+				}
+			}
+			container[key] = kept
+		case p.attr.sub == "":
+			delete(container, key)
+		case isList:
+			for _, element := range list {
+				if object, ok := element.(map[string]interface{}); ok {
+					deleteAttribute(object, p.attr.sub)
+				}
+			}
+		default:
+			if object, ok := current.(map[string]interface{}); ok {
+				deleteAttribute(object, p.attr.sub)
+			}
+		}
+		return
+	}
+
+	kept := make([]interface{}, 0, len(list))
+	for _, element := range list {
+		object, ok := element.(map[string]interface{})
+		if !ok || !p.filter.matches(object) {
+			kept = append(kept, element)
+			continue
+		}
+		if p.attr.sub != "" {
+			deleteAttribute(object, p.attr.sub)
+			kept = append(kept, object)
+		}
+	}
+	container[key] = kept
+}
+
+// merge returns the attribute value after adding or replacing value. Adding
+// to a multi-valued attribute appends the new elements; adding or replacing
+// a complex attribute sets the given sub-attributes and keeps the others.
+func merge(current, value interface{}, replace bool) interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		list, ok := current.([]interface{})
+		if replace || !ok {
+			return v
+		}
+		for _, element := range v {
+			if !containsElement(list, element) {
+				list = append(list, element)
+			}
+		}
+		return list
+	case map[string]interface{}:
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return v
+		}
+		for name, sub := range v {
+			setAttribute(object, name, sub)
+		}
+		return object
+	}
+	return value
+}
+
+// containsElement reports whether a list holds the element. Complex elements
+// with a "value" sub-attribute are compared by it.
+func containsElement(list []interface{}, element interface{}) bool {
+	want, hasValue := elementValue(element)
+	for _, candidate := range list {
+		if hasValue {
+			if got, ok := elementValue(candidate); ok && got == want {
+				return true
+			}
+			continue
+		}
+		if reflect.DeepEqual(candidate, element) {
+			return true
+		}
+	}
+	return false
+}
+
+// elementValue returns the "value" sub-attribute of a complex element.
+func elementValue(element interface{}) (interface{}, bool) {
+	object, ok := element.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	_, value, ok := lookup(object, "value")
+	return value, ok
+}
+
+// setAttribute sets an attribute, keeping the case of an existing name.
+func setAttribute(object map[string]interface{}, name string, value interface{}) {
+	key, _, _ := lookup(object, name)
+	object[key] = value
+}
+
+// deleteAttribute deletes an attribute by case-insensitive name.
+func deleteAttribute(object map[string]interface{}, name string) {
+	if key, _, ok := lookup(object, name); ok {
+		delete(object, key)
+	}
+}
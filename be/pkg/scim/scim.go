@@ -0,0 +1,265 @@
+// Package scim implements the protocol side of a SCIM 2.0 service provider
+// (RFC 7643 and RFC 7644): the core User and Group resources with the
+// enterprise user extension, list responses, filter expressions, PATCH
+// operations and error responses.
+//
+// Filters and patches work on the JSON representation of a resource, so they
+// need no schema: attribute names are compared case-insensitively and values
+// as JSON strings, numbers and booleans.
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SCIM schemas and the SCIM media type
+const (
+	SchemaUser           = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup          = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaEnterpriseUser = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+
+	SchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+	SchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+
+	ContentType = "application/scim+json"
+)
+
+// Error types of SCIM error responses (RFC 7644 section 3.12)
+const (
+	ErrorTypeInvalidFilter = "invalidFilter"
+	ErrorTypeInvalidSyntax = "invalidSyntax"
+	ErrorTypeInvalidPath   = "invalidPath"
+	ErrorTypeNoTarget      = "noTarget"
+	ErrorTypeInvalidValue  = "invalidValue"
+	ErrorTypeUniqueness    = "uniqueness"
+)
+
+// extensionSchemas lists the schema extensions whose attributes are nested
+// under the schema URN in a resource.
+var extensionSchemas = []string{SchemaEnterpriseUser}
+
+// Error is a SCIM protocol error. It marshals to a SCIM error response.
+type Error struct {
+	Status int
+	Type   string
+	Detail string
+}
+
+// NewError creates an error with the given HTTP status and SCIM error type.
+//
+// Parameters:
+//   - status: HTTP status of the error response
+//   - errorType: SCIM error type, empty when none applies
+//   - detail: Human readable description
+//
+// Returns:
+//   - *Error: The error
+func NewError(status int, errorType, detail string) *Error {
+	return &Error{Status: status, Type: errorType, Detail: detail}
+}
+
+// badRequest returns a 400 error of the given type.
+func badRequest(errorType, format string, args ...interface{}) *Error {
+	return NewError(http.StatusBadRequest, errorType, fmt.Sprintf(format, args...))
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return "scim: " + e.Detail
+}
+
+// MarshalJSON renders the error with the SCIM error schema. The status is a
+// string, as RFC 7644 requires.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Schemas []string `json:"schemas"`
+		Status  string   `json:"status"`
+		Type    string   `json:"scimType,omitempty"`
+		Detail  string   `json:"detail,omitempty"`
+	}{[]string{SchemaError}, strconv.Itoa(e.Status), e.Type, e.Detail})
+}
+
+// Boolean is a SCIM boolean. Some identity providers send booleans as the
+// strings "True" and "False", which it accepts as well.
+type Boolean bool
+
+// UnmarshalJSON accepts JSON booleans and strings holding a boolean.
+func (b *Boolean) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case bool:
+		*b = Boolean(v)
+		return nil
+	case string:
+		parsed, err := strconv.ParseBool(strings.ToLower(v))
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", v)
+		}
+		*b = Boolean(parsed)
+		return nil
+	}
+	return fmt.Errorf("invalid boolean %s", data)
+}
+
+// Meta holds the metadata of a resource.
+type Meta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location,omitempty"`
+}
+
+// Name is the name of a user.
+type Name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+}
+
+// MultiValued is an element of a multi-valued attribute such as emails,
+// phoneNumbers, groups or members.
+type MultiValued struct {
+	Value   string  `json:"value"`
+	Display string  `json:"display,omitempty"`
+	Type    string  `json:"type,omitempty"`
+	Primary Boolean `json:"primary,omitempty"`
+	Ref     string  `json:"$ref,omitempty"`
+}
+
+// EnterpriseUser holds the attributes of the enterprise user extension.
+type EnterpriseUser struct {
+	EmployeeNumber string   `json:"employeeNumber,omitempty"`
+	CostCenter     string   `json:"costCenter,omitempty"`
+	Organization   string   `json:"organization,omitempty"`
+	Division       string   `json:"division,omitempty"`
+	Department     string   `json:"department,omitempty"`
+	Manager        *Manager `json:"manager,omitempty"`
+}
+
+// Manager references a user's manager.
+type Manager struct {
+	Value       string `json:"value,omitempty"`
+	DisplayName string `json:"displayName,omitempty"`
+}
+
+// User is a SCIM user resource. Active is nil when a request left it out.
+type User struct {
+	Schemas      []string        `json:"schemas"`
+	ID           string          `json:"id,omitempty"`
+	ExternalID   string          `json:"externalId,omitempty"`
+	UserName     string          `json:"userName"`
+	Name         *Name           `json:"name,omitempty"`
+	DisplayName  string          `json:"displayName,omitempty"`
+	Title        string          `json:"title,omitempty"`
+	Active       *Boolean        `json:"active,omitempty"`
+	Emails       []MultiValued   `json:"emails,omitempty"`
+	PhoneNumbers []MultiValued   `json:"phoneNumbers,omitempty"`
+	Groups       []MultiValued   `json:"groups,omitempty"`
+	Enterprise   *EnterpriseUser `json:"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User,omitempty"`
+	Meta         *Meta           `json:"meta,omitempty"`
+}
+
+// Group is a SCIM group resource.
+type Group struct {
+	Schemas     []string      `json:"schemas"`
+	ID          string        `json:"id,omitempty"`
+	ExternalID  string        `json:"externalId,omitempty"`
+	DisplayName string        `json:"displayName"`
+	Members     []MultiValued `json:"members,omitempty"`
+	Meta        *Meta         `json:"meta,omitempty"`
+}
+
+// ListResponse is the response of a query.
+type ListResponse struct {
+	Schemas      []string      `json:"schemas"`
+	TotalResults int           `json:"totalResults"`
+	StartIndex   int           `json:"startIndex"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	Resources    []interface{} `json:"Resources"`
+}
+
+// NewListResponse creates the response for one page of query results.
+//
+// Parameters:
+//   - resources: Resources of the page
+//   - totalResults: Number of resources matching the query
+//   - startIndex: 1-based index of the first resource of the page
+//
+// Returns:
+//   - *ListResponse: The list response
+func NewListResponse(resources []interface{}, totalResults, startIndex int) *ListResponse {
+	if resources == nil {
+		resources = []interface{}{}
+	}
+	return &ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: totalResults,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}
+}
+
+// Resource is the JSON representation of a resource, which filters and
+// patches operate on.
+type Resource map[string]interface{}
+
+// ToResource returns the JSON representation of a resource.
+//
+// Parameters:
+//   - v: Resource such as a *User or *Group
+//
+// Returns:
+//   - Resource: The JSON representation
+//   - error: Marshalling error
+func ToResource(v interface{}) (Resource, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var resource Resource
+	if err := json.Unmarshal(data, &resource); err != nil {
+		return nil, err
+	}
+	return resource, nil
+}
+
+// Decode decodes the resource into v, such as a *User or *Group. Attribute
+// names match case-insensitively.
+//
+// Returns:
+//   - error: An *Error of type invalidValue when an attribute has the wrong type
+func (r Resource) Decode(v interface{}) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return badRequest(ErrorTypeInvalidValue, "%v", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return badRequest(ErrorTypeInvalidValue, "%v", err)
+	}
+	return nil
+}
+
+// lookup returns the key and value of an attribute of an object, comparing
+// names case-insensitively. The key is name when the attribute is missing.
+func lookup(object map[string]interface{}, name string) (string, interface{}, bool) {
+	if value, ok := object[name]; ok {
+		return name, value, true
+	}
+	for key, value := range object {
+		if strings.EqualFold(key, name) {
+			return key, value, true
+		}
+	}
+	return name, nil, false
+}
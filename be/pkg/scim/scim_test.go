@@ -0,0 +1,249 @@
+package scim_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/scim"
+)
+
+func resource(t *testing.T, document string) scim.Resource {
+	t.Helper()
+	var r scim.Resource
+	require.NoError(t, json.Unmarshal([]byte(document), &r))
+	return r
+}
+
+const jane = `{
+	"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+	"id": "42",
+	"userName": "Jane.Doe@example.com",
+	"name": {"givenName": "Jane", "familyName": "Doe"},
+	"active": true,
+	"emails": [
+		{"value": "jane.doe@example.com", "type": "work", "primary": true},
+		{"value": "jane@home.example", "type": "home"}
+	],
+	"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {"department": "Internal Audit", "employeeNumber": "1017"},
+	"meta": {"resourceType": "User", "created": "2026-03-01T08:00:00Z"},
+	"logins": 12
+}`
+
+func TestFilter_Matches(t *testing.T) {
+	user := resource(t, jane)
+
+	tests := []struct {
+		filter  string
+		matches bool
+	}{
+		{`userName eq "jane.doe@example.com"`, true},
+		{`USERNAME Eq "JANE.DOE@EXAMPLE.COM"`, true},
+		{`userName eq "john@example.com"`, false},
+		{`userName ne "john@example.com"`, true},
+		{`name.givenName sw "Ja"`, true},
+		{`name.familyName ew "oe"`, true},
+		{`emails co "@home"`, true},
+		{`emails.value co "@example.org"`, false},
+		{`emails[type eq "work" and value co "@example.com"]`, true},
+		{`emails[type eq "work" and value co "@home"]`, false},
+		{`active eq true`, true},
+		{`active eq false`, false},
+		{`logins gt 10 and logins le 12`, true},
+		{`meta.created lt "2026-04-01T00:00:00Z"`, true},
+		{`title pr`, false},
+		{`title eq null`, true},
+		{`name pr and not (title pr)`, true},
+		{`userName eq "john@example.com" or name.givenName eq "Jane"`, true},
+		{`userName eq "john@example.com" or name.givenName eq "Jane" and active eq false`, false},
+		{`(userName eq "john@example.com" or name.givenName eq "Jane") and active eq true`, true},
+		{`urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department eq "internal audit"`, true},
+		{`urn:ietf:params:scim:schemas:core:2.0:User:userName sw "jane"`, true},
+		{`externalId eq "abc"`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			filter, err := scim.ParseFilter(tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.matches, filter.Matches(user))
+		})
+	}
+}
+
+func TestParseFilter_Invalid(t *testing.T) {
+	for _, expression := range []string{
+		``,
+		`userName`,
+		`userName eq`,
+		`userName like "jane"`,
+		`userName eq "jane`,
+		`userName eq jane`,
+		`(userName pr`,
+		`emails[type eq "work"`,
+		`userName pr extra`,
+		`1name pr`,
+	} {
+		t.Run(expression, func(t *testing.T) {
+			_, err := scim.ParseFilter(expression)
+			var scimErr *scim.Error
+			require.True(t, errors.As(err, &scimErr), "expected a SCIM error, got %v", err)
+			assert.Equal(t, http.StatusBadRequest, scimErr.Status)
+			assert.Equal(t, scim.ErrorTypeInvalidFilter, scimErr.Type)
+		})
+	}
+}
+
+func TestPatchRequest_Apply(t *testing.T) {
+	tests := []struct {
+		name       string
+		operations string
+		check      func(t *testing.T, user *scim.User)
+	}{
+		{
+			name:       "replace a simple attribute",
+			operations: `[{"op": "replace", "path": "title", "value": "Senior Auditor"}]`,
+			check: func(t *testing.T, user *scim.User) {
+				assert.Equal(t, "Senior Auditor", user.Title)
+			},
+		},
+		{
+			name:       "string booleans and capitalized operations",
+			operations: `[{"op": "Replace", "path": "active", "value": "False"}]`,
+			check: func(t *testing.T, user *scim.User) {
+				require.NotNil(t, user.Active)
+				assert.False(t, bool(*user.Active))
+			},
+		},
+		{
+			name:       "operation without path",
+			operations: `[{"op": "replace", "value": {"name.givenName": "Janet", "displayName": "Janet Doe", "active": false}}]`,
+			check: func(t *testing.T, user *scim.User) {
+				assert.Equal(t, "Janet", user.Name.GivenName)
+				assert.Equal(t, "Doe", user.Name.FamilyName)
+				assert.Equal(t, "Janet Doe", user.DisplayName)
+				assert.False(t, bool(*user.Active))
+			},
+		},
+		{
+			name:       "sub-attribute of a complex attribute",
+			operations: `[{"op": "add", "path": "name.familyName", "value": "Smith"}]`,
+			check: func(t *testing.T, user *scim.User) {
+				assert.Equal(t, "Jane", user.Name.GivenName)
+				assert.Equal(t, "Smith", user.Name.FamilyName)
+			},
+		},
+		{
+			name:       "filtered element",
+			operations: `[{"op": "replace", "path": "emails[type eq \"work\"].value", "value": "jane.smith@example.com"}]`,
+			check: func(t *testing.T, user *scim.User) {
+				require.Len(t, user.Emails, 2)
+				assert.Equal(t, "jane.smith@example.com", user.Emails[0].Value)
+				assert.Equal(t, "jane@home.example", user.Emails[1].Value)
+			},
+		},
+		{
+			name:       "filter without a match creates the element",
+			operations: `[{"op": "add", "path": "phoneNumbers[type eq \"work\"].value", "value": "+420 555 0100"}]`,
+			check: func(t *testing.T, user *scim.User) {
+				assert.Equal(t, []scim.MultiValued{{Value: "+420 555 0100", Type: "work"}}, user.PhoneNumbers)
+			},
+		},
+		{
+			name:       "add appends to multi-valued attributes without duplicates",
+			operations: `[{"op": "add", "path": "emails", "value": [{"value": "jane@home.example"}, {"value": "jd@example.net", "type": "other"}]}]`,
+			check: func(t *testing.T, user *scim.User) {
+				require.Len(t, user.Emails, 3)
+				assert.Equal(t, "jd@example.net", user.Emails[2].Value)
+			},
+		},
+		{
+			name:       "remove filtered element",
+			operations: `[{"op": "remove", "path": "emails[type eq \"home\"]"}]`,
+			check: func(t *testing.T, user *scim.User) {
+				require.Len(t, user.Emails, 1)
+				assert.Equal(t, "work", user.Emails[0].Type)
+			},
+		},
+		{
+			name:       "remove listed elements",
+			operations: `[{"op": "remove", "path": "emails", "value": [{"value": "jane@home.example"}]}]`,
+			check: func(t *testing.T, user *scim.User) {
+				require.Len(t, user.Emails, 1)
+			},
+		},
+		{
+			name: "extension attributes",
+			operations: `[
+				{"op": "replace", "path": "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department", "value": "Risk"},
+				{"op": "add", "value": {"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {"costCenter": "CC-7"}}},
+				{"op": "remove", "path": "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber"}
+			]`,
+			check: func(t *testing.T, user *scim.User) {
+				assert.Equal(t, &scim.EnterpriseUser{Department: "Risk", CostCenter: "CC-7"}, user.Enterprise)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch scim.PatchRequest
+			require.NoError(t, json.Unmarshal([]byte(`{"schemas": ["`+scim.SchemaPatchOp+`"], "Operations": `+tt.operations+`}`), &patch))
+			r := resource(t, jane)
+			require.NoError(t, patch.Apply(r))
+
+			var user scim.User
+			require.NoError(t, r.Decode(&user))
+			tt.check(t, &user)
+		})
+	}
+}
+
+func TestPatchRequest_ApplyErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		operation scim.PatchOperation
+		errorType string
+	}{
+		{"unknown operation", scim.PatchOperation{Op: "move", Path: "title"}, scim.ErrorTypeInvalidSyntax},
+		{"remove without path", scim.PatchOperation{Op: "remove"}, scim.ErrorTypeNoTarget},
+		{"invalid path", scim.PatchOperation{Op: "add", Path: "emails[type eq", Value: "x"}, scim.ErrorTypeInvalidPath},
+		{"no match for a complex filter", scim.PatchOperation{Op: "replace", Path: `emails[value co "@other"].type`, Value: "work"}, scim.ErrorTypeNoTarget},
+		{"no path and no object", scim.PatchOperation{Op: "replace", Value: "x"}, scim.ErrorTypeInvalidValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch := &scim.PatchRequest{Operations: []scim.PatchOperation{tt.operation}}
+			err := patch.Apply(resource(t, jane))
+			var scimErr *scim.Error
+			require.True(t, errors.As(err, &scimErr), "expected a SCIM error, got %v", err)
+			assert.Equal(t, tt.errorType, scimErr.Type)
+		})
+	}
+
+	var user scim.User
+	err := resource(t, `{"userName": "jane", "active": "maybe"}`).Decode(&user)
+	var scimErr *scim.Error
+	require.True(t, errors.As(err, &scimErr))
+	assert.Equal(t, scim.ErrorTypeInvalidValue, scimErr.Type)
+}
+
+func TestError_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(scim.NewError(http.StatusConflict, scim.ErrorTypeUniqueness, "userName is taken"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:Error"],
+		"status": "409",
+		"scimType": "uniqueness",
+		"detail": "userName is taken"
+	}`, string(data))
+
+	data, err = json.Marshal(scim.NewListResponse(nil, 0, 1))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"schemas": ["urn:ietf:params:scim:api:messages:2.0:ListResponse"], "totalResults": 0, "startIndex": 1, "itemsPerPage": 0, "Resources": []}`, string(data))
+}
@@ -0,0 +1,129 @@
+// Package storage provides file storage for generated artifacts such as audit
+// exports. Files are addressed by slash separated keys, e.g.
+// "exports/<organization id>/<export id>.csv".
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound is returned when no file exists for a key.
+var ErrNotFound = errors.New("storage: file not found")
+
+// ErrInvalidKey is returned for keys that are empty or escape the storage root.
+var ErrInvalidKey = errors.New("storage: invalid key")
+
+// Store persists files by key.
+type Store interface {
+	// Create opens a new file for writing, replacing any existing file with the same key.
+	// The file only becomes visible under the key once the writer is closed.
+	Create(ctx context.Context, key string) (io.WriteCloser, error)
+
+	// Open opens a file for reading
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes a file; deleting a missing file is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// LocalStore stores files below a directory on the local file system.
+type LocalStore struct {
+	root string
+}
+
+// NewLocalStore creates a store rooted at dir, creating the directory if needed.
+//
+// Parameters:
+//   - dir: Root directory of the store
+//
+// Returns:
+//   - *LocalStore: Store instance
+//   - error: Error if the directory cannot be created
+//
+// Example:
+//
+//	store, err := storage.NewLocalStore(cfg.Audit.ExportDirectory)
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("storage: failed to create root directory: %w", err)
+	}
+	return &LocalStore{root: dir}, nil
+}
+
+// Create opens a temporary file that is renamed to its final path on Close,
+// so readers never observe partially written files.
+func (s *LocalStore) Create(ctx context.Context, key string) (io.WriteCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("storage: failed to create directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("storage: failed to create file: %w", err)
+	}
+	return &atomicFile{File: tmp, path: path}, nil
+}
+
+// Open opens a stored file for reading.
+func (s *LocalStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+// Delete removes a stored file.
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("storage: failed to delete file: %w", err)
+	}
+	return nil
+}
+
+// path maps a key to a file path below the root.
+func (s *LocalStore) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.root, clean), nil
+}
+
+// atomicFile renames the temporary file into place when closed.
+type atomicFile struct {
+	*os.File
+	path string
+}
+
+func (f *atomicFile) Close() error {
+	if err := f.File.Close(); err != nil {
+		os.Remove(f.File.Name())
+		return err
+	}
+	if err := os.Rename(f.File.Name(), f.path); err != nil {
+		os.Remove(f.File.Name())
+		return fmt.Errorf("storage: failed to finalize file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package storage_test
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/radek-zitek-cloud/goedu-omicron/be/pkg/storage"
+)
+
+func TestLocalStore_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	w, err := store.Create(ctx, "exports/org/file.csv")
+	require.NoError(t, err)
+	_, err = io.WriteString(w, "a,b\n")
+	require.NoError(t, err)
+
+	_, err = store.Open(ctx, "exports/org/file.csv")
+	assert.ErrorIs(t, err, storage.ErrNotFound, "file is only visible once closed")
+
+	require.NoError(t, w.Close())
+
+	r, err := store.Open(ctx, "exports/org/file.csv")
+	require.NoError(t, err)
+	content, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "a,b\n", string(content))
+
+	require.NoError(t, store.Delete(ctx, "exports/org/file.csv"))
+	require.NoError(t, store.Delete(ctx, "exports/org/file.csv"))
+	_, err = store.Open(ctx, "exports/org/file.csv")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestLocalStore_RejectsEscapingKeys(t *testing.T) {
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	for _, key := range []string{"", "../secret", "/etc/passwd", "a/../../b"} {
+		_, err := store.Open(context.Background(), key)
+		assert.ErrorIs(t, err, storage.ErrInvalidKey, key)
+	}
+}
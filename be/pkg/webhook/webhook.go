@@ -0,0 +1,305 @@
+// Package webhook signs and sends outbound webhook requests.
+//
+// Every request carries a signature header of the form
+//
+//	X-GoEdu-Signature: t=1712345678,v1=5257a869e7ecebeda32affa62cdca3fa51cad7e77a0e56ff536d0ce8e108d8bd
+//
+// where v1 is the hex encoded HMAC-SHA256 of "<t>.<body>" keyed with the
+// subscription secret. Receivers recompute the HMAC with their copy of the
+// secret and reject requests whose timestamp is too old, which prevents replays.
+//
+// Webhook URLs are chosen by tenants, so the HTTP sender only connects to
+// public addresses. The check runs when connecting, after DNS resolution, so
+// a host name that resolves to a private address, also after being
+// re-pointed, is refused.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Request headers set on every webhook request.
+const (
+	SignatureHeader = "X-GoEdu-Signature"
+	EventHeader     = "X-GoEdu-Event"
+	DeliveryHeader  = "X-GoEdu-Delivery"
+)
+
+// maxResponseBody is the number of response body bytes kept for the delivery log.
+const maxResponseBody = 1024
+
+// defaultTimeout bounds a request when no timeout is configured.
+const defaultTimeout = 30 * time.Second
+
+// Signature verification errors.
+var (
+	ErrInvalidSignature = errors.New("webhook: invalid signature")
+	ErrExpiredSignature = errors.New("webhook: signature timestamp outside tolerance")
+)
+
+// ErrForbiddenDestination is returned when a webhook URL resolves to an
+// address that is not public.
+var ErrForbiddenDestination = errors.New("webhook: destination address is not public")
+
+// nonPublicPrefixes are the private and special-purpose address ranges
+// webhooks are never sent to: loopback, private networks, link-local
+// addresses including cloud metadata endpoints, shared and reserved ranges,
+// documentation ranges, multicast, and IPv6 translation prefixes that can
+// embed any of these IPv4 addresses.
+var nonPublicPrefixes = func() []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, cidr := range []string{
+		"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16",
+		"172.16.0.0/12", "192.0.0.0/24", "192.0.2.0/24", "192.88.99.0/24", "192.168.0.0/16",
+		"198.18.0.0/15", "198.51.100.0/24", "203.0.113.0/24", "224.0.0.0/4", "240.0.0.0/4",
+		"::/128", "::1/128", "64:ff9b::/96", "64:ff9b:1::/48", "100::/64", "2001::/23",
+		"2001:db8::/32", "2002::/16", "fc00::/7", "fe80::/10", "ff00::/8",
+	} {
+		prefixes = append(prefixes, netip.MustParsePrefix(cidr))
+	}
+	return prefixes
+}()
+
+// IsPublicIP reports whether ip is a public unicast address webhooks may be
+// sent to. IPv4-mapped IPv6 addresses are checked as IPv4.
+//
+// Parameters:
+//   - ip: Address to check
+//
+// Returns:
+//   - bool: True if the address is public
+func IsPublicIP(ip net.IP) bool {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// Sign returns the signature header value of a request body sent at timestamp.
+//
+// Parameters:
+//   - secret: Subscription secret
+//   - timestamp: Time the request is sent
+//   - body: Request body
+//
+// Returns:
+//   - string: Header value, e.g. "t=1712345678,v1=5257a8..."
+//
+// Example:
+//
+//	req.Header.Set(webhook.SignatureHeader, webhook.Sign(secret, time.Now(), body))
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + unix + ",v1=" + computeMAC(secret, unix, body)
+}
+
+// Verify checks a signature header against a request body. Receivers in Go
+// can use it directly; it is also used to test the signing scheme.
+//
+// Parameters:
+//   - secret: Subscription secret
+//   - header: Value of the signature header
+//   - body: Request body as received
+//   - tolerance: Maximum age of the signature timestamp
+//   - now: Current time
+//
+// Returns:
+//   - error: ErrInvalidSignature or ErrExpiredSignature, nil if the signature is valid
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var unix, mac string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			unix = value
+		case "v1":
+			mac = value
+		}
+	}
+	seconds, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil || mac == "" {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(mac), []byte(computeMAC(secret, unix, body))) {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > tolerance || age < -tolerance {
+		return ErrExpiredSignature
+	}
+	return nil
+}
+
+// DeriveSecret derives the signing secret of a subscription from the server's
+// master secret, so subscription secrets never need to be stored. Bumping the
+// version rotates the secret.
+//
+// Parameters:
+//   - master: Server's webhook master secret
+//   - subscriptionID: Subscription the secret belongs to
+//   - version: Secret version of the subscription
+//
+// Returns:
+//   - string: Subscription secret prefixed with "whsec_"
+func DeriveSecret(master, subscriptionID string, version int) string {
+	mac := hmac.New(sha256.New, []byte(master))
+	mac.Write([]byte(subscriptionID + ":" + strconv.Itoa(version)))
+	return "whsec_" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// computeMAC returns the hex encoded HMAC-SHA256 of "<unix>.<body>".
+func computeMAC(secret, unix string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unix))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Request is a webhook to send.
+type Request struct {
+	URL        string
+	Event      string
+	DeliveryID string
+	Secret     string
+	Body       []byte
+}
+
+// Response is the receiver's answer to a webhook request.
+type Response struct {
+	StatusCode int
+	Body       string
+	Duration   time.Duration
+}
+
+// Succeeded reports whether the receiver accepted the webhook with a 2xx status.
+func (r *Response) Succeeded() bool {
+	return r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// Sender sends webhook requests.
+type Sender interface {
+	// Send posts a signed webhook. Any HTTP response is returned without error;
+	// errors are reserved for requests that got no response at all.
+	Send(ctx context.Context, req *Request) (*Response, error)
+}
+
+// HTTPSender sends webhooks as signed JSON POST requests. It only connects
+// to public addresses and ignores proxy settings, so that the check applies
+// to the receiver itself. Redirects are not followed, so a receiver cannot
+// bounce signed payloads to another host, public or not.
+type HTTPSender struct {
+	client *http.Client
+}
+
+// NewHTTPSender creates a sender with the given per-request timeout.
+//
+// Parameters:
+//   - timeout: Maximum duration of one request, including reading the response
+//
+// Returns:
+//   - *HTTPSender: Configured sender
+//
+// Example:
+//
+//	sender := webhook.NewHTTPSender(cfg.Webhook.Timeout)
+func NewHTTPSender(timeout time.Duration) *HTTPSender {
+	return newHTTPSender(timeout, IsPublicIP)
+}
+
+// newHTTPSender creates a sender that connects only to addresses allowed
+// reports true for.
+func newHTTPSender(timeout time.Duration, allowed func(net.IP) bool) *HTTPSender {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	dialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+		// Control runs for every address a host name resolves to, right
+		// before connecting, so DNS rebinding cannot bypass it
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !allowed(ip) {
+				return fmt.Errorf("%w: %s", ErrForbiddenDestination, host)
+			}
+			return nil
+		},
+	}
+	return &HTTPSender{client: &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+// Send posts a signed webhook and returns the receiver's status code and the
+// beginning of its response body.
+//
+// Parameters:
+//   - ctx: Request context
+//   - req: Webhook to send
+//
+// Returns:
+//   - *Response: Receiver's response
+//   - error: Error if the request could not be sent or no response arrived,
+//     wrapping ErrForbiddenDestination if the receiver's address is not public
+func (s *HTTPSender) Send(ctx context.Context, req *Request) (*Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return nil, fmt.Errorf("webhook: invalid request: %w", err)
+	}
+	started := time.Now()
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "GoEdu-Webhooks/1.0")
+	httpReq.Header.Set(EventHeader, req.Event)
+	httpReq.Header.Set(DeliveryHeader, req.DeliveryID)
+	httpReq.Header.Set(SignatureHeader, Sign(req.Secret, started, req.Body))
+
+	httpResp, err := s.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("webhook: request failed: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(httpResp.Body, maxResponseBody))
+	// Drain the rest so the connection can be reused
+	io.Copy(io.Discard, httpResp.Body)
+
+	return &Response{
+		StatusCode: httpResp.StatusCode,
+		Body:       string(body),
+		Duration:   time.Since(started),
+	}, nil
+}
@@ -0,0 +1,130 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"type":"control.changed"}`)
+	sentAt := time.Unix(1712345678, 0)
+	header := Sign("whsec_test", sentAt, body)
+	assert.True(t, strings.HasPrefix(header, "t=1712345678,v1="))
+
+	assert.NoError(t, Verify("whsec_test", header, body, 5*time.Minute, sentAt.Add(time.Minute)))
+	assert.ErrorIs(t, Verify("whsec_other", header, body, 5*time.Minute, sentAt), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify("whsec_test", header, []byte(`{"type":"cycle.completed"}`), 5*time.Minute, sentAt), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify("whsec_test", header, body, 5*time.Minute, sentAt.Add(time.Hour)), ErrExpiredSignature)
+	assert.ErrorIs(t, Verify("whsec_test", "v1=abc", body, 5*time.Minute, sentAt), ErrInvalidSignature)
+
+	// The timestamp is covered by the signature
+	tampered := strings.Replace(header, "t=1712345678", "t=1712345999", 1)
+	assert.ErrorIs(t, Verify("whsec_test", tampered, body, time.Hour, sentAt), ErrInvalidSignature)
+}
+
+func TestDeriveSecret(t *testing.T) {
+	secret := DeriveSecret("master", "sub1", 1)
+	assert.True(t, strings.HasPrefix(secret, "whsec_"))
+	assert.Equal(t, secret, DeriveSecret("master", "sub1", 1))
+	assert.NotEqual(t, secret, DeriveSecret("master", "sub1", 2))
+	assert.NotEqual(t, secret, DeriveSecret("master", "sub2", 1))
+	assert.NotEqual(t, secret, DeriveSecret("other", "sub1", 1))
+}
+
+func TestHTTPSender_Send(t *testing.T) {
+	var received *http.Request
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/elsewhere", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(strings.Repeat("x", 2*maxResponseBody)))
+	}))
+	defer server.Close()
+
+	// The test server listens on loopback, which NewHTTPSender refuses
+	sender := newHTTPSender(5*time.Second, func(net.IP) bool { return true })
+	body := []byte(`{"id":"evt_1"}`)
+	resp, err := sender.Send(context.Background(), &Request{
+		URL:        server.URL + "/hooks",
+		Event:      "cycle.completed",
+		DeliveryID: "d1",
+		Secret:     "whsec_test",
+		Body:       body,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.True(t, resp.Succeeded())
+	assert.Len(t, resp.Body, maxResponseBody)
+
+	assert.Equal(t, http.MethodPost, received.Method)
+	assert.Equal(t, "application/json", received.Header.Get("Content-Type"))
+	assert.Equal(t, "cycle.completed", received.Header.Get(EventHeader))
+	assert.Equal(t, "d1", received.Header.Get(DeliveryHeader))
+	assert.Equal(t, body, receivedBody)
+	assert.NoError(t, Verify("whsec_test", received.Header.Get(SignatureHeader), receivedBody, time.Minute, time.Now()))
+
+	// Redirects are reported, not followed
+	resp, err = sender.Send(context.Background(), &Request{URL: server.URL + "/redirect", Secret: "whsec_test", Body: body})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.False(t, resp.Succeeded())
+
+	// Unreachable receivers are errors
+	server.Close()
+	_, err = sender.Send(context.Background(), &Request{URL: server.URL, Secret: "whsec_test", Body: body})
+	assert.Error(t, err)
+}
+
+func TestHTTPSender_RefusesNonPublicAddresses(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.Write([]byte("secret from an internal service"))
+	}))
+	defer server.Close()
+	port := server.URL[strings.LastIndex(server.URL, ":"):]
+
+	sender := NewHTTPSender(5 * time.Second)
+	for _, url := range []string{server.URL, "http://localhost" + port, "http://[::ffff:127.0.0.1]" + port} {
+		resp, err := sender.Send(context.Background(), &Request{URL: url, Secret: "whsec_test", Body: []byte(`{}`)})
+		assert.ErrorIs(t, err, ErrForbiddenDestination, url)
+		assert.Nil(t, resp, url)
+	}
+	assert.False(t, called, "no request reaches the receiver")
+}
+
+func TestIsPublicIP(t *testing.T) {
+	for address, public := range map[string]bool{
+		"93.184.216.34":        true,
+		"2606:2800:220:1::248": true,
+		"127.0.0.1":            false,
+		"10.1.2.3":             false,
+		"172.20.0.1":           false,
+		"192.168.1.1":          false,
+		"169.254.169.254":      false,
+		"100.100.100.200":      false,
+		"0.0.0.0":              false,
+		"255.255.255.255":      false,
+		"::1":                  false,
+		"::ffff:10.0.0.1":      false,
+		"64:ff9b::a00:1":       false,
+		"fd00:ec2::254":        false,
+		"fe80::1":              false,
+	} {
+		assert.Equal(t, public, IsPublicIP(net.ParseIP(address)), address)
+	}
+}